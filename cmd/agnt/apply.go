@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/standardbeagle/agnt/internal/apply"
+
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <file.kdl|file.yaml>",
+	Short: "Reconcile processes, proxies and tunnels with a declarative file",
+	Long: `Reconcile the daemon's processes, proxies and tunnels for a project with a
+declarative file, creating, updating and removing them as needed, and report
+the changes.
+
+Entries are keyed by the ID they run under:
+
+  processes {
+      web script="dev"
+      api command="go" { args "run" "./cmd/api"; }
+  }
+  proxies {
+      app port=3000 chaos="mobile-3g"
+  }
+  tunnels {
+      public provider="cloudflare" proxy="app"
+  }
+
+The same layout works as YAML (.yaml or .yml).
+
+Everything apply creates is labeled agnt/apply=<file name>. Entries removed
+from the file are stopped on the next apply; processes, proxies and tunnels
+started another way are left alone unless the file names their ID.
+
+Examples:
+  agnt apply agnt-apply.kdl
+  agnt apply --dry-run agnt-apply.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run:  runApply,
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan <file.kdl|file.yaml>",
+	Short: "Show the changes apply would make, without making them",
+	Long: `Compare a declarative file with the daemon's processes, proxies and tunnels
+and print the changes agnt apply would make. Nothing is started or stopped;
+this is the same as agnt apply --dry-run.
+
+Examples:
+  agnt plan agnt-apply.kdl
+  agnt plan --json agnt-apply.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runReconcile(cmd, args, true)
+	},
+}
+
+func init() {
+	applyCmd.Flags().Bool("dry-run", false, "Show the changes without making them")
+	for _, c := range []*cobra.Command{applyCmd, planCmd} {
+		c.Flags().String("path", "", "Project directory (default: the current directory)")
+		c.Flags().Bool("json", false, "Print the changes as JSON")
+		rootCmd.AddCommand(c)
+	}
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	runReconcile(cmd, args, dryRun)
+}
+
+// runReconcile plans the changes for the file in args[0] and, unless dryRun,
+// executes them.
+func runReconcile(cmd *cobra.Command, args []string, dryRun bool) {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	dir, _ := cmd.Flags().GetString("path")
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get working directory: %v\n", err)
+			os.Exit(1)
+		}
+		dir = cwd
+	}
+
+	spec, err := apply.Load(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	state, err := apply.FetchState(client, dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	name := filepath.Base(args[0])
+	changes := apply.Plan(spec, name, state)
+
+	var results []apply.Result
+	if dryRun {
+		for _, c := range changes {
+			results = append(results, apply.Result{Change: c})
+		}
+	} else {
+		executor := &apply.Executor{Client: client, Spec: spec, Name: name, Dir: dir, State: state}
+		results = executor.Execute(changes)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if asJSON {
+		data, _ := json.MarshalIndent(map[string]interface{}{
+			"dry_run": dryRun,
+			"changes": results,
+			"failed":  failed,
+		}, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printApplyResults(results, dryRun)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func printApplyResults(results []apply.Result, dryRun bool) {
+	counts := make(map[apply.Action]int)
+	for _, r := range results {
+		counts[r.Action]++
+		if r.Action == apply.ActionUnchanged {
+			continue
+		}
+		if r.Error != "" {
+			fmt.Printf("  ! %s (%s)\n", r.Change, r.Error)
+		} else {
+			fmt.Printf("  %s %s\n", actionMarker(r.Action), r.Change)
+		}
+	}
+
+	verb := "Applied"
+	if dryRun {
+		verb = "Planned (dry run)"
+	}
+	fmt.Printf("%s: %d create, %d update, %d replace, %d delete, %d unchanged\n", verb,
+		counts[apply.ActionCreate], counts[apply.ActionUpdate], counts[apply.ActionReplace],
+		counts[apply.ActionDelete], counts[apply.ActionUnchanged])
+}
+
+func actionMarker(action apply.Action) string {
+	switch action {
+	case apply.ActionCreate:
+		return "+"
+	case apply.ActionDelete:
+		return "-"
+	case apply.ActionReplace:
+		return "±"
+	default:
+		return "~"
+	}
+}
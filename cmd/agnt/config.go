@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Check and reload the project's .agnt.kdl",
+}
+
+var configLintCmd = &cobra.Command{
+	Use:   "lint [dir]",
+	Short: "Report errors in .agnt.kdl and what autostart would fail to start",
+	Long: `Check the .agnt.kdl of dir (default: the current directory) or its nearest
+parent without starting anything:
+
+  - KDL syntax errors
+  - unknown settings and values of the wrong type
+  - scripts, proxies and other entries defined twice, and ports declared twice
+  - autostart as the daemon would run it: double profiles, script commands
+    and working directories, depends-on, stacks, proxy targets, and the
+    scripts of git-hooks
+
+Each issue is printed with its line. Exits 1 when there are errors; warnings
+alone pass. The daemon runs the same checks with CONFIG VALIDATE.
+
+Examples:
+  agnt config lint
+  agnt config lint ../api
+  agnt config lint --json`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigLint,
+}
+
+var configReloadCmd = &cobra.Command{
+	Use:   "reload [dir]",
+	Short: "Apply edits to .agnt.kdl to what autostart started",
+	Long: `Apply the current .agnt.kdl of dir (default: the current directory) to the
+processes and proxies autostart started for it, without restarting sessions:
+
+  - new autostart scripts, stacks, doubles and proxies start
+  - removed scripts, stacks, doubles and proxies stop
+  - running scripts and doubles whose settings changed restart
+  - running proxies whose target changed are retargeted
+
+A file with errors is not applied. The daemon also reloads by itself within
+about two seconds of a save, and tells the project's sessions and pages.
+
+Examples:
+  agnt config reload
+  agnt config reload --json`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runConfigReload,
+}
+
+func init() {
+	configLintCmd.Flags().Bool("json", false, "Print the report as JSON")
+	configReloadCmd.Flags().Bool("json", false, "Print the result as JSON")
+	configCmd.AddCommand(configLintCmd)
+	configCmd.AddCommand(configReloadCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigLint(cmd *cobra.Command, args []string) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	report, err := daemon.ValidateConfig(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if asJSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printConfigReport(report)
+	}
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
+
+// printConfigReport prints the issues as file:line: severity: path: message,
+// then what autostart would start.
+func printConfigReport(report *daemon.ConfigReport) {
+	file := report.File
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+
+	errors, warnings := 0, 0
+	for _, issue := range report.Issues {
+		if issue.Severity == config.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+		fmt.Println(formatConfigIssue(file, issue))
+	}
+
+	if a := report.Autostart; a != nil {
+		var parts []string
+		add := func(kind string, names []string) {
+			if len(names) > 0 {
+				parts = append(parts, kind+" "+strings.Join(names, ", "))
+			}
+		}
+		add("doubles", a.Doubles)
+		add("scripts", a.Scripts)
+		add("stacks", a.Stacks)
+		add("proxies", a.Proxies)
+		add("then scripts", a.WaitingScripts)
+		add("then proxies", a.WaitingProxies)
+		if len(parts) == 0 {
+			parts = []string{"nothing"}
+		}
+		fmt.Printf("Autostart: %s\n", strings.Join(parts, "; "))
+	}
+
+	switch {
+	case errors > 0:
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", file, errors, warnings)
+	case warnings > 0:
+		fmt.Printf("%s: OK with %d warning(s)\n", file, warnings)
+	default:
+		fmt.Printf("%s: OK\n", file)
+	}
+}
+
+func runConfigReload(cmd *cobra.Command, args []string) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	result, err := client.ConfigReload(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if asJSON {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+	}
+	if applied, _ := result["applied"].(bool); !applied {
+		if !asJSON {
+			file, _ := result["file"].(string)
+			issues, _ := result["issues"].([]interface{})
+			for _, i := range issues {
+				data, _ := json.Marshal(i)
+				var issue config.ConfigIssue
+				if json.Unmarshal(data, &issue) == nil {
+					fmt.Println(formatConfigIssue(file, issue))
+				}
+			}
+			fmt.Printf("%s has errors, not applied\n", file)
+		}
+		os.Exit(1)
+	}
+	if asJSON {
+		return
+	}
+
+	changed := false
+	for _, kind := range [][2]string{{"started", "Started"}, {"stopped", "Stopped"}, {"restarted", "Restarted"}, {"retargeted", "Retargeted"}} {
+		items, _ := result[kind[0]].([]interface{})
+		for _, item := range items {
+			fmt.Printf("%s %v\n", kind[1], item)
+			changed = true
+		}
+	}
+	errs, _ := result["errors"].([]interface{})
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "Failed: %v\n", e)
+	}
+	if !changed && len(errs) == 0 {
+		fmt.Println("Nothing to change")
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// formatConfigIssue formats an issue the way compilers do, so editors can
+// jump to it.
+func formatConfigIssue(file string, issue config.ConfigIssue) string {
+	if issue.Line > 0 {
+		return fmt.Sprintf("%s:%s", file, issue)
+	}
+	return fmt.Sprintf("%s: %s", file, issue)
+}
@@ -2,14 +2,17 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/fixture"
 	"github.com/standardbeagle/go-cli-server/process"
 
 	"github.com/spf13/cobra"
@@ -43,9 +46,19 @@ var daemonRestartCmd = &cobra.Command{
 }
 
 var daemonStatusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [path]",
 	Short: "Check daemon status",
-	Run:   runDaemonStatus,
+	Long: `Check whether the daemon is running.
+
+With --lite, print the STATUS-LITE counts of the project at path (default: the
+current directory) as one line of JSON, for shell prompts and status bars:
+
+  {"v":1,"path":"/home/dev/app","processes_running":2,"processes_failed":0,
+   "proxies_active":1,"errors_15m":3,"tunnel_exposed":false}
+
+Nothing is printed when the daemon isn't running, and the exit code is 1.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runDaemonStatus,
 }
 
 var daemonInfoCmd = &cobra.Command{
@@ -54,12 +67,31 @@ var daemonInfoCmd = &cobra.Command{
 	Run:   runDaemonInfo,
 }
 
+var daemonReplayCmd = &cobra.Command{
+	Use:   "replay <fixtures.jsonl>",
+	Short: "Serve recorded protocol fixtures in place of the daemon",
+	Long: `Serve a fixture file recorded with "agnt daemon start --record" on the
+daemon socket, so clients can be developed and tested without a live daemon.
+
+Requests are answered from the recording: exact matches first, then by verb,
+sub-verb and args, then by verb and sub-verb. Requests with no recording get
+a not_found error and are listed on exit.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runDaemonReplay,
+}
+
 func init() {
 	daemonCmd.AddCommand(daemonStartCmd)
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonRestartCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
 	daemonCmd.AddCommand(daemonInfoCmd)
+	daemonCmd.AddCommand(daemonReplayCmd)
+
+	daemonStartCmd.Flags().String("record", "", "Record sanitized request/response fixtures to this file")
+	daemonStartCmd.Flags().Bool("no-forward", false, "Don't forward loopback proxies and dev servers to the host from WSL or containers (also AGNT_NO_FORWARD=1)")
+	daemonStatusCmd.Flags().Bool("lite", false, "Print the project's process, proxy, error and tunnel counts as JSON")
+	daemonStartCmd.Flags().String("admin-addr", "", "Serve pprof for the daemon itself on this loopback address, e.g. 127.0.0.1:6061 (also AGNT_ADMIN_ADDR)")
 }
 
 func getSocketPath(cmd *cobra.Command) string {
@@ -92,6 +124,16 @@ func runDaemonStart(cmd *cobra.Command, args []string) {
 		MaxClients:   100,
 		WriteTimeout: 30 * time.Second,
 	}
+	if cmd.Flags().Lookup("record") != nil {
+		config.RecordPath, _ = cmd.Flags().GetString("record")
+	}
+	if noForward, _ := cmd.Flags().GetBool("no-forward"); noForward || os.Getenv("AGNT_NO_FORWARD") != "" {
+		config.DisableForwarding = true
+	}
+	config.AdminAddr, _ = cmd.Flags().GetString("admin-addr")
+	if config.AdminAddr == "" {
+		config.AdminAddr = os.Getenv("AGNT_ADMIN_ADDR")
+	}
 
 	d := daemon.New(config)
 
@@ -157,6 +199,11 @@ func runDaemonRestart(cmd *cobra.Command, args []string) {
 func runDaemonStatus(cmd *cobra.Command, args []string) {
 	socketPath := getSocketPath(cmd)
 
+	if lite, _ := cmd.Flags().GetBool("lite"); lite {
+		runDaemonStatusLite(socketPath, args)
+		return
+	}
+
 	if daemon.IsRunning(socketPath) {
 		fmt.Println("Daemon is running")
 		fmt.Printf("Socket: %s\n", socketPath)
@@ -167,6 +214,37 @@ func runDaemonStatus(cmd *cobra.Command, args []string) {
 	}
 }
 
+// runDaemonStatusLite prints STATUS-LITE as JSON. It never starts the
+// daemon, so a prompt polling it doesn't either.
+func runDaemonStatusLite(socketPath string, args []string) {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	} else if wd, err := os.Getwd(); err == nil {
+		path = wd
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	if !daemon.IsRunning(socketPath) {
+		os.Exit(1)
+	}
+	client := daemon.NewClient(daemon.WithSocketPath(socketPath))
+	if err := client.Connect(); err != nil {
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	status, err := client.StatusLite(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get status: %v\n", err)
+		os.Exit(1)
+	}
+	data, _ := json.Marshal(status)
+	fmt.Println(string(data))
+}
+
 func runDaemonInfo(cmd *cobra.Command, args []string) {
 	socketPath := getSocketPath(cmd)
 
@@ -197,6 +275,9 @@ func runDaemonInfo(cmd *cobra.Command, args []string) {
 		info.ProcessInfo.Active, info.ProcessInfo.TotalStarted, info.ProcessInfo.TotalFailed)
 	fmt.Printf("Proxies: %d active, %d total\n",
 		info.ProxyInfo.Active, info.ProxyInfo.TotalStarted)
+	if info.AdminURL != "" {
+		fmt.Printf("Admin: %s/debug/pprof/\n", info.AdminURL)
+	}
 
 	// Show update notification if available
 	if info.UpdateInfo != nil {
@@ -215,3 +296,46 @@ func runDaemonInfo(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+func runDaemonReplay(cmd *cobra.Command, args []string) {
+	socketPath := getSocketPath(cmd)
+
+	header, exchanges, err := fixture.Load(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load fixtures: %v\n", err)
+		os.Exit(1)
+	}
+
+	sm := daemon.NewSocketManager(daemon.SocketConfig{Path: socketPath, Mode: 0600})
+	l, err := sm.Listen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to listen on %s: %v\n", socketPath, err)
+		os.Exit(1)
+	}
+	defer sm.Close()
+
+	srv := fixture.NewServer(exchanges, nil)
+	go func() {
+		if err := srv.Serve(l); err != nil {
+			log.Printf("Fixture server error: %v", err)
+		}
+	}()
+
+	recorded := "unknown daemon"
+	if header != nil && header.DaemonVersion != "" {
+		recorded = "daemon v" + header.DaemonVersion
+	}
+	fmt.Printf("Replaying %d exchanges (recorded from %s) on %s\n", len(exchanges), recorded, socketPath)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	<-ctx.Done()
+	srv.Close()
+
+	if unmatched := srv.Unmatched(); len(unmatched) > 0 {
+		fmt.Printf("%d requests had no fixture:\n", len(unmatched))
+		for _, req := range unmatched {
+			fmt.Printf("  %s\n", req)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/standardbeagle/agnt/internal/double"
+
+	"github.com/spf13/cobra"
+)
+
+var doubleCmd = &cobra.Command{
+	Use:   "double <profile>",
+	Short: "Serve a stand-in for a third-party API",
+	Long: `Serve a stand-in (double) for a third-party API with canned, predictable
+responses, realistic latency and webhook emissions, so payment and auth flows
+can be exercised offline. The daemon runs doubles as managed processes with
+DOUBLE START; this command is what it runs.
+
+Built-in profiles:
+  stripe   Customers, payment intents, refunds; signed webhooks
+  auth0    OIDC discovery, authorize redirect, token, userinfo
+  rest     In-memory CRUD collections at /{collection}[/{id}]
+
+A JSON profile file can define other APIs (see internal/double.Profile).
+
+Examples:
+  agnt double stripe --port 12111 --webhook-url http://localhost:3000/api/webhooks
+  agnt double --profile-file doubles/github.json --port 12112`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runDouble,
+}
+
+func init() {
+	doubleCmd.Flags().Int("port", 0, "Port to listen on (default: any free port)")
+	doubleCmd.Flags().String("profile-file", "", "JSON profile instead of a built-in one")
+	doubleCmd.Flags().String("webhook-url", "", "URL the profile's webhooks are posted to")
+	doubleCmd.Flags().Int64("seed", 1, "Seed of IDs and latencies")
+	doubleCmd.Flags().String("latency-ms", "", "Latency range such as 50-200 (default: the profile's)")
+	rootCmd.AddCommand(doubleCmd)
+}
+
+func runDouble(cmd *cobra.Command, args []string) {
+	profileFile, _ := cmd.Flags().GetString("profile-file")
+	var profile *double.Profile
+	switch {
+	case profileFile != "":
+		p, err := double.LoadProfile(profileFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		profile = p
+	case len(args) == 1:
+		p, ok := double.Builtin(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown profile %q (built-in: %s)\n", args[0], strings.Join(double.BuiltinNames(), ", "))
+			os.Exit(1)
+		}
+		profile = p
+	default:
+		fmt.Fprintf(os.Stderr, "A profile or --profile-file is required\n")
+		os.Exit(1)
+	}
+
+	opts := double.Options{Log: os.Stdout}
+	opts.WebhookURL, _ = cmd.Flags().GetString("webhook-url")
+	opts.Seed, _ = cmd.Flags().GetInt64("seed")
+	if latency, _ := cmd.Flags().GetString("latency-ms"); latency != "" {
+		lo, hi, _ := strings.Cut(latency, "-")
+		minMs, err1 := strconv.Atoi(lo)
+		maxMs, err2 := strconv.Atoi(hi)
+		if hi == "" {
+			maxMs, err2 = minMs, nil
+		}
+		if err1 != nil || err2 != nil || maxMs < minMs {
+			fmt.Fprintf(os.Stderr, "Invalid --latency-ms %q (use min-max)\n", latency)
+			os.Exit(1)
+		}
+		opts.LatencyMs = &[2]int{minMs, maxMs}
+	}
+	server, err := double.NewServer(profile, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	port, _ := cmd.Flags().GetInt("port")
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to listen: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s double listening on http://localhost:%d\n", profile.Name, ln.Addr().(*net.TCPAddr).Port)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		ln.Close()
+	}()
+	if err := http.Serve(ln, server); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
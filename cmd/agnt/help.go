@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+var helpJSON bool
+
+// helpCmd replaces cobra's help command to add the daemon-protocol topic.
+var helpCmd = &cobra.Command{
+	Use:   "help [command | daemon-protocol [VERB]]",
+	Short: "Help about any command or the daemon protocol",
+	Long: `Help provides help for any command in the application.
+
+"agnt help daemon-protocol" documents the commands the daemon accepts on its
+socket: sub-verbs, positional arguments, JSON payload schemas and examples.
+It is generated from the same registry the daemon dispatches from, and is
+also available from a running daemon with the HELP command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 && args[0] == "daemon-protocol" {
+			if err := printDaemonProtocol(args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		target, _, err := cmd.Root().Find(args)
+		if target == nil || err != nil {
+			fmt.Fprintf(os.Stderr, "Unknown help topic %q\n", strings.Join(args, " "))
+			cmd.Root().Usage()
+			return
+		}
+		target.InitDefaultHelpFlag()
+		target.Help()
+	},
+}
+
+func init() {
+	helpCmd.Flags().BoolVar(&helpJSON, "json", false, "Print daemon-protocol help as JSON")
+	rootCmd.SetHelpCommand(helpCmd)
+}
+
+// printDaemonProtocol prints the usage of every daemon command, or of the
+// verbs named in args.
+func printDaemonProtocol(args []string) error {
+	commands := daemon.CommandHelp()
+	if len(args) > 0 {
+		commands = nil
+		for _, verb := range args {
+			h, ok := daemon.FindCommandHelp(verb)
+			if !ok {
+				return fmt.Errorf("unknown daemon command %q", verb)
+			}
+			commands = append(commands, h)
+		}
+	}
+
+	if helpJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(commands)
+	}
+
+	for i, c := range commands {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s - %s", c.Verb, c.Description)
+		if c.Builtin {
+			fmt.Print(" (hub built-in)")
+		}
+		fmt.Println()
+		if len(c.SubVerbs) == 0 {
+			printUsage("  ", c.Usage, c.Args, c.Data, c.DataText, c.Examples)
+			continue
+		}
+		for _, s := range c.SubVerbs {
+			fmt.Printf("  %s - %s\n", s.Name, s.Description)
+			printUsage("    ", s.Usage, s.Args, s.Data, s.DataText, s.Examples)
+		}
+	}
+	return nil
+}
+
+func printUsage(indent, usage string, args []protocol.ArgHelp, data *protocol.Schema, dataText string, examples []string) {
+	fmt.Printf("%sUsage: %s\n", indent, usage)
+	for _, a := range args {
+		if a.Description != "" {
+			fmt.Printf("%s  %-16s %s\n", indent, a.Name, a.Description)
+		}
+	}
+	if data != nil && len(data.Properties) > 0 {
+		fmt.Printf("%sData: %s\n", indent, schemaSummary(data))
+	} else if dataText != "" {
+		fmt.Printf("%sData: %s\n", indent, dataText)
+	}
+	for _, ex := range examples {
+		fmt.Printf("%sExample: %s\n", indent, strings.ReplaceAll(ex, "\n", " ⏎ "))
+	}
+}
+
+// schemaSummary renders an object schema as "{name: type, ...}".
+func schemaSummary(s *protocol.Schema) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + schemaType(s.Properties[name])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func schemaType(s *protocol.Schema) string {
+	switch s.Type {
+	case "":
+		return "any"
+	case "array":
+		if s.Items != nil {
+			return schemaType(s.Items) + "[]"
+		}
+	}
+	return s.Type
+}
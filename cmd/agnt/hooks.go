@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+// gitHookMarker marks the hooks agnt writes, so it never replaces or
+// removes anyone else's.
+const gitHookMarker = "# agnt git hook"
+
+// defaultGitHooks are installed when the git-hooks block names none.
+var defaultGitHooks = []string{"pre-commit", "pre-push"}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Run the project's scripts from git hooks through the daemon",
+	Long: `Install git hooks that run scripts through the agnt daemon, so their output
+is kept as managed processes (PROC OUTPUT hook-pre-commit-lint) and failures
+show up as a toast in proxied pages and in the project's agnt run sessions.
+
+The scripts of each hook come from the git-hooks block of .agnt.kdl:
+
+  git-hooks {
+      pre-commit { scripts "lint" "typecheck"; }
+      pre-push { scripts "test"; timeout-ms 600000; }
+  }
+
+Scripts run in order and the hook fails on the first that fails. Hooks
+without scripts pass. Skip the hooks once with git's --no-verify.
+
+Examples:
+  agnt hooks install
+  agnt hooks install --hook pre-commit
+  agnt hooks uninstall
+  agnt hooks run pre-commit`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the hooks of the git-hooks block (default: pre-commit, pre-push)",
+	Args:  cobra.NoArgs,
+	Run:   runHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the hooks agnt installed",
+	Args:  cobra.NoArgs,
+	Run:   runHooksUninstall,
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:   "run <hook> [git args...]",
+	Short: "Run a hook's scripts through the daemon (called by the installed hooks)",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runHooksRun,
+}
+
+func init() {
+	hooksInstallCmd.Flags().StringSlice("hook", nil, "Hooks to install (default: those in git-hooks, else pre-commit and pre-push)")
+	hooksInstallCmd.Flags().Bool("force", false, "Replace hooks agnt didn't write")
+	hooksUninstallCmd.Flags().StringSlice("hook", nil, "Hooks to remove (default: every hook agnt installed)")
+
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.AddCommand(hooksRunCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
+
+// gitHooksDir returns the hooks directory of the repository at dir,
+// honoring core.hooksPath.
+func gitHooksDir(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not in a git repository", dir)
+	}
+	hooksDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(dir, hooksDir)
+	}
+	return hooksDir, nil
+}
+
+// gitHookScript is the hook agnt writes: it hands the hook to the daemon.
+func gitHookScript(agntPath, hook string) string {
+	return fmt.Sprintf("#!/bin/sh\n%s: runs the %s scripts of .agnt.kdl through the agnt daemon\nexec '%s' hooks run %s \"$@\"\n",
+		gitHookMarker, hook, strings.ReplaceAll(agntPath, "'", `'\''`), hook)
+}
+
+// isAgntHook reports whether the hook file at path was written by agnt.
+func isAgntHook(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && bytes.Contains(data, []byte(gitHookMarker))
+}
+
+// installGitHook writes hook to hooksDir. A hook agnt didn't write is kept
+// unless force is set. Only git's own hook names are accepted, since the
+// name becomes a file name and a word of the script.
+func installGitHook(hooksDir, hook, agntPath string, force bool) error {
+	if !config.IsGitHook(hook) {
+		return fmt.Errorf("%q is not a git hook", hook)
+	}
+	path := filepath.Join(hooksDir, hook)
+	if _, err := os.Stat(path); err == nil && !force && !isAgntHook(path) {
+		return fmt.Errorf("%s exists and wasn't installed by agnt (use --force to replace it)", path)
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(gitHookScript(agntPath, hook)), 0755)
+}
+
+// uninstallGitHooks removes the given hooks, or every hook agnt wrote when
+// none are given, from hooksDir and returns the ones removed.
+func uninstallGitHooks(hooksDir string, hooks []string) ([]string, error) {
+	for _, hook := range hooks {
+		if !config.IsGitHook(hook) {
+			return nil, fmt.Errorf("%q is not a git hook", hook)
+		}
+	}
+	if len(hooks) == 0 {
+		entries, err := os.ReadDir(hooksDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				hooks = append(hooks, e.Name())
+			}
+		}
+	}
+	var removed []string
+	for _, hook := range hooks {
+		if !config.IsGitHook(hook) {
+			continue
+		}
+		path := filepath.Join(hooksDir, hook)
+		if !isAgntHook(path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, hook)
+	}
+	return removed, nil
+}
+
+func runHooksInstall(cmd *cobra.Command, args []string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get working directory: %v\n", err)
+		os.Exit(1)
+	}
+	hooksDir, err := gitHooksDir(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	agntPath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get executable path: %v\n", err)
+		os.Exit(1)
+	}
+
+	hooks, _ := cmd.Flags().GetStringSlice("hook")
+	if len(hooks) == 0 {
+		if cfg, err := config.LoadAgntConfig(cwd); err == nil {
+			for hook := range cfg.GitHooks {
+				hooks = append(hooks, hook)
+			}
+			sort.Strings(hooks)
+		}
+	}
+	if len(hooks) == 0 {
+		hooks = defaultGitHooks
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	failed := false
+	for _, hook := range hooks {
+		if err := installGitHook(hooksDir, hook, agntPath, force); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %v\n", hook, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Installed %s\n", filepath.Join(hooksDir, hook))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runHooksUninstall(cmd *cobra.Command, args []string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get working directory: %v\n", err)
+		os.Exit(1)
+	}
+	hooksDir, err := gitHooksDir(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	hooks, _ := cmd.Flags().GetStringSlice("hook")
+	removed, err := uninstallGitHooks(hooksDir, hooks)
+	for _, hook := range removed {
+		fmt.Printf("Removed %s\n", filepath.Join(hooksDir, hook))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if len(removed) == 0 {
+		fmt.Println("No agnt hooks installed")
+	}
+}
+
+// runHooksRun runs in the hooks agnt installs. It exits non-zero when a
+// script fails, which aborts the git command, and lets git go on when the
+// daemon can't be reached.
+func runHooksRun(cmd *cobra.Command, args []string) {
+	hook := args[0]
+	// Git runs hooks at the top of the work tree
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get working directory: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.LoadAgntConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agnt %s: %v\n", hook, err)
+		os.Exit(1)
+	}
+	hookCfg := cfg.GitHooks[hook]
+	if hookCfg == nil || len(hookCfg.Scripts) == 0 {
+		return
+	}
+
+	autoStart := daemon.DefaultAutoStartConfig()
+	autoStart.SocketPath = getSocketPath(cmd)
+	client, err := daemon.EnsureDaemonRunning(autoStart)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agnt %s: daemon unavailable, scripts not run: %v\n", hook, err)
+		return
+	}
+	defer client.Close()
+
+	timeout := daemon.GitHookTimeout(hookCfg) * time.Duration(len(hookCfg.Scripts))
+	result, err := client.HookRun(hook, cwd, timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agnt %s: %v\n", hook, err)
+		os.Exit(1)
+	}
+
+	passed, _ := result["passed"].(bool)
+	results, _ := result["results"].([]interface{})
+	for _, r := range results {
+		rm, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		script, _ := rm["script"].(string)
+		duration, _ := rm["duration"].(string)
+		if ok, _ := rm["passed"].(bool); ok {
+			fmt.Fprintf(os.Stderr, "agnt %s: %s passed (%s)\n", hook, script, duration)
+			continue
+		}
+		if msg, _ := rm["error"].(string); msg != "" {
+			fmt.Fprintf(os.Stderr, "agnt %s: %s failed: %s\n", hook, script, msg)
+			continue
+		}
+		status := fmt.Sprintf("exited with %v", rm["exit_code"])
+		if timedOut, _ := rm["timed_out"].(bool); timedOut {
+			status = "timed out"
+		}
+		processID, _ := rm["process_id"].(string)
+		fmt.Fprintf(os.Stderr, "agnt %s: %s %s (full output: PROC OUTPUT %s)\n", hook, script, status, processID)
+		if output, _ := rm["output"].(string); output != "" {
+			fmt.Fprintln(os.Stderr, output)
+		}
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallGitHook(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+
+	if err := installGitHook(dir, "pre-commit", "/usr/local/bin/agnt", false); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	path := filepath.Join(dir, "pre-commit")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `exec '/usr/local/bin/agnt' hooks run pre-commit "$@"`) {
+		t.Errorf("hook = %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode()&0100 == 0 {
+		t.Errorf("hook isn't executable: %v", info.Mode())
+	}
+
+	// Reinstalling replaces agnt's own hook
+	if err := installGitHook(dir, "pre-commit", "/opt/agnt", false); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	// Other hooks are kept unless forced
+	other := filepath.Join(dir, "pre-push")
+	os.WriteFile(other, []byte("#!/bin/sh\nmake lint\n"), 0755)
+	if err := installGitHook(dir, "pre-push", "/opt/agnt", false); err == nil {
+		t.Error("expected an error replacing a foreign hook")
+	}
+	if err := installGitHook(dir, "pre-push", "/opt/agnt", true); err != nil {
+		t.Errorf("forced install: %v", err)
+	}
+	if !isAgntHook(other) {
+		t.Error("forced install didn't replace the hook")
+	}
+
+	// Only git's hook names are written, so none leaves the hooks directory
+	// or breaks the script
+	for _, hook := range []string{"../post-checkout", "pre-commit; rm -rf ~", "not-a-hook"} {
+		if err := installGitHook(dir, hook, "/opt/agnt", true); err == nil {
+			t.Errorf("expected %q to be rejected", hook)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "post-checkout")); err == nil {
+		t.Error("a hook was written outside the hooks directory")
+	}
+}
+
+func TestUninstallGitHooks(t *testing.T) {
+	dir := t.TempDir()
+	installGitHook(dir, "pre-commit", "agnt", false)
+	installGitHook(dir, "pre-push", "agnt", false)
+	os.WriteFile(filepath.Join(dir, "commit-msg"), []byte("#!/bin/sh\n"), 0755)
+
+	removed, err := uninstallGitHooks(dir, []string{"pre-push"})
+	if err != nil || len(removed) != 1 || removed[0] != "pre-push" {
+		t.Fatalf("removed %v, %v", removed, err)
+	}
+
+	if _, err := uninstallGitHooks(dir, []string{"../pre-commit"}); err == nil {
+		t.Error("expected a hook name outside git's to be rejected")
+	}
+
+	removed, err = uninstallGitHooks(dir, nil)
+	if err != nil || len(removed) != 1 || removed[0] != "pre-commit" {
+		t.Fatalf("removed %v, %v", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "commit-msg")); err != nil {
+		t.Error("removed a hook agnt didn't write")
+	}
+}
+
+func TestGitHookScriptRuns(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	// A stand-in agnt that prints its arguments
+	fake := filepath.Join(dir, "it's agnt")
+	os.WriteFile(fake, []byte("#!/bin/sh\necho \"$@\"\n"), 0755)
+	if err := installGitHook(dir, "pre-push", fake, false); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(filepath.Join(dir, "pre-push"), "origin", "git@example.com:app.git").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "hooks run pre-push origin git@example.com:app.git" {
+		t.Errorf("hook ran %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"net"
 	"net/http"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/standardbeagle/agnt/internal/endpoint"
 	"github.com/standardbeagle/agnt/internal/overlay"
 )
 
@@ -45,11 +47,21 @@ type OverlayMessage struct {
 
 // TypeMessage is a message to type into the PTY.
 type TypeMessage struct {
-	Text    string `json:"text"`
-	Enter   bool   `json:"enter"`   // Whether to send Enter after text
-	Instant bool   `json:"instant"` // Type instantly vs simulate typing
+	Text           string `json:"text"`
+	Enter          bool   `json:"enter"`           // Whether to send Enter after text
+	Instant        bool   `json:"instant"`         // Type instantly vs simulate typing
+	ChunkSize      int    `json:"chunk_size"`      // Characters per write when pacing (overrides Instant)
+	ChunkDelayMs   int    `json:"chunk_delay_ms"`  // Pause between chunks, jittered by ±25%
+	BracketedPaste bool   `json:"bracketed_paste"` // Wrap the text in bracketed-paste markers
 }
 
+// Bracketed-paste markers: tools that enable bracketed paste treat the text
+// between them as one paste rather than typed keys.
+const (
+	bracketedPasteStart = "\x1b[200~"
+	bracketedPasteEnd   = "\x1b[201~"
+)
+
 // KeyMessage is a key event to inject.
 type KeyMessage struct {
 	Key      string `json:"key"`      // Key name (e.g., "Enter", "Tab", "Escape")
@@ -69,8 +81,8 @@ type ToastMessage struct {
 
 // DefaultOverlaySocketPath returns the default socket path for the overlay.
 func DefaultOverlaySocketPath() string {
-	// Windows: use Unix domain socket in temp directory (supported since Windows 10 1803)
-	// Note: Named pipes (\\.\pipe\...) require different APIs, so we use Unix sockets
+	// Windows: use Unix domain socket in temp directory (supported since Windows 10 1803);
+	// Start falls back to a loopback TCP port where it isn't
 	if os.PathSeparator == '\\' {
 		username := os.Getenv("USERNAME")
 		if username == "" {
@@ -132,11 +144,16 @@ func (o *Overlay) Start(ctx context.Context) error {
 		os.Remove(o.socketPath)
 	}
 
-	// Create Unix socket listener
-	listener, err := net.Listen("unix", o.socketPath)
+	// Create Unix socket listener, or on Windows without Unix sockets a
+	// token-guarded loopback TCP one; the daemon dials either
+	listener, registered, err := endpoint.Listen(o.socketPath)
 	if err != nil {
 		return fmt.Errorf("failed to create overlay socket: %w", err)
 	}
+	if registered != o.socketPath {
+		log.Printf("Overlay socket %s unavailable, listening on %s", o.socketPath, listener.Addr())
+		o.socketPath = registered
+	}
 	o.listener = listener
 
 	mux := http.NewServeMux()
@@ -148,7 +165,7 @@ func (o *Overlay) Start(ctx context.Context) error {
 	mux.HandleFunc("/toast", o.handleToast)
 
 	o.server = &http.Server{
-		Handler: mux,
+		Handler: endpoint.RequireToken(endpoint.Token(registered), mux),
 	}
 
 	go func() {
@@ -171,7 +188,9 @@ func (o *Overlay) Stop() {
 	if o.listener != nil {
 		o.listener.Close()
 	}
-	os.Remove(o.socketPath)
+	if kind, _, _ := endpoint.Parse(o.socketPath); kind == endpoint.KindUnix {
+		os.Remove(o.socketPath)
+	}
 
 	// Close all WebSocket connections
 	o.clients.Range(func(key, value interface{}) bool {
@@ -337,6 +356,8 @@ type attachmentInfo struct {
 	Area     *screenshotArea
 	FilePath string // Path to the saved file (for screenshots, sketches)
 	FileName string // Just the filename for display
+	// MCP resource the agent can read the screenshot or file from
+	ResourceURI string
 }
 
 // screenshotArea represents coordinates for a screenshot region.
@@ -420,6 +441,9 @@ func (o *Overlay) processAttachments(attachments []struct {
 					if fn, ok := dataFields["file_name"].(string); ok {
 						info.FileName = fn
 					}
+					if uri, ok := dataFields["resource_uri"].(string); ok {
+						info.ResourceURI = uri
+					}
 				}
 			}
 
@@ -501,6 +525,9 @@ func (o *Overlay) formatPanelMessage(message string, auditReports []string, atta
 				if att.FilePath != "" {
 					text += fmt.Sprintf("   → %s\n", att.FilePath)
 				}
+				if att.ResourceURI != "" {
+					text += fmt.Sprintf("   MCP resource: %s\n", att.ResourceURI)
+				}
 			case "element":
 				if att.Selector != "" {
 					text += fmt.Sprintf(": %s", att.Selector)
@@ -521,6 +548,18 @@ func (o *Overlay) formatPanelMessage(message string, auditReports []string, atta
 				if att.FilePath != "" {
 					text += fmt.Sprintf("   → %s\n", att.FilePath)
 				}
+			case "file":
+				// Uploaded files are only useful with their path
+				if att.Text != "" {
+					text += fmt.Sprintf(": %s", att.Text)
+				}
+				text += "\n"
+				if att.FilePath != "" {
+					text += fmt.Sprintf("   → %s\n", att.FilePath)
+				}
+				if att.ResourceURI != "" {
+					text += fmt.Sprintf("   MCP resource: %s\n", att.ResourceURI)
+				}
 			default:
 				if att.Selector != "" {
 					text += fmt.Sprintf(": %s", att.Selector)
@@ -830,33 +869,52 @@ func (o *Overlay) handleMessage(msg OverlayMessage) {
 }
 
 func (o *Overlay) typeText(msg TypeMessage) {
-	if msg.Instant {
+	pasteStart, pasteEnd := "", ""
+	if msg.BracketedPaste {
+		pasteStart, pasteEnd = bracketedPasteStart, bracketedPasteEnd
+	}
+
+	paced := true
+	switch {
+	case msg.ChunkSize > 0:
+		o.writeTopty(pasteStart)
+		o.writeChunks(msg.Text, msg.ChunkSize, time.Duration(msg.ChunkDelayMs)*time.Millisecond)
+		o.writeTopty(pasteEnd)
+	case msg.Instant:
 		// Send full text as single write - large buffer triggers paste detection
 		// in terminal input handlers without needing bracketed paste escape sequences.
-		o.writeTopty(msg.Text)
-
-		if msg.Enter {
-			// Progressive enter key timing to ensure agent accepts the message.
-			// Send enters at 100ms, 200ms, then 500ms intervals until activity is detected.
-			// This handles different AI agent input processing speeds.
-			o.sendEntersUntilActivity()
-		}
-	} else {
+		o.writeTopty(pasteStart + msg.Text + pasteEnd)
+		paced = false
+	default:
 		// Simulate typing character by character
-		delay := 10 * time.Millisecond
-
-		for _, ch := range msg.Text {
-			o.writeTopty(string(ch))
-			time.Sleep(delay)
-		}
+		o.writeTopty(pasteStart)
+		o.writeChunks(msg.Text, 1, 10*time.Millisecond)
+		o.writeTopty(pasteEnd)
+	}
 
-		if msg.Enter {
+	if msg.Enter {
+		if paced {
 			// Wait for Ink to process all characters before sending submit sequence
 			time.Sleep(100 * time.Millisecond)
+		}
 
-			// Progressive enter key timing to ensure agent accepts the message.
-			o.sendEntersUntilActivity()
+		// Progressive enter key timing to ensure agent accepts the message.
+		// Send enters at 100ms, 200ms, then 500ms intervals until activity is detected.
+		// This handles different AI agent input processing speeds.
+		o.sendEntersUntilActivity()
+	}
+}
+
+// writeChunks writes text size characters at a time, pausing about delay
+// between writes so tools that drop large writes keep up.
+func (o *Overlay) writeChunks(text string, size int, delay time.Duration) {
+	runes := []rune(text)
+	for i := 0; i < len(runes); i += size {
+		if i > 0 && delay > 0 {
+			jitter := time.Duration(rand.Int63n(int64(delay)/2+1)) - delay/4
+			time.Sleep(delay + jitter)
 		}
+		o.writeTopty(string(runes[i:min(i+size, len(runes))]))
 	}
 }
 
@@ -975,6 +1033,9 @@ func (o *Overlay) buildKeySequence(msg KeyMessage) string {
 }
 
 func (o *Overlay) writeTopty(s string) {
+	if s == "" {
+		return
+	}
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	if o.ptmx == nil {
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/standardbeagle/agnt/internal/daemon"
 	"github.com/standardbeagle/agnt/internal/overlay"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/transcript"
 )
 
 // daemonSessionHandle manages the daemon connection and session registration.
@@ -57,6 +62,29 @@ func (h *daemonSessionHandle) BroadcastOutputPreview(lines []string) {
 	}
 }
 
+// openTranscript starts recording the session's terminal output when
+// --transcript is given. A transcript that can't be created is reported and
+// the session runs without one.
+func openTranscript(enabled bool, projectPath, sessionCode, command string) *transcript.Writer {
+	if !enabled {
+		return nil
+	}
+	w, err := transcript.Create(projectPath, sessionCode, command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[agnt] Not recording a transcript: %v\n", err)
+		return nil
+	}
+	return w
+}
+
+// teeTranscript copies output written to w into the transcript, if any.
+func teeTranscript(w io.Writer, t *transcript.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return io.MultiWriter(w, t)
+}
+
 // terminalOverlayComponents contains all overlay-related components.
 // These are initialized together and need coordinated cleanup.
 type terminalOverlayComponents struct {
@@ -150,6 +178,33 @@ type daemonSessionConfig struct {
 	SocketPath        string
 	SkipAutostart     bool
 	HeartbeatInterval time.Duration
+	Tags              protocol.Labels
+	Description       string
+}
+
+// registerConfig returns the SESSION REGISTER metadata of the session.
+func (cfg daemonSessionConfig) registerConfig() protocol.SessionRegisterConfig {
+	return protocol.SessionRegisterConfig{
+		OverlayPath: cfg.OverlayEndpoint,
+		ProjectPath: cfg.ProjectPath,
+		Command:     cfg.Command,
+		Args:        cfg.CmdArgs,
+		Tags:        cfg.Tags,
+		Description: cfg.Description,
+	}
+}
+
+// addSessionTags adds the tags of a --tag flag, such as role=frontend or
+// role=frontend,ticket=ABC-123, to tags.
+func addSessionTags(tags protocol.Labels, s string) error {
+	parsed, err := protocol.ParseLabels(s)
+	if err != nil {
+		return err
+	}
+	for k, v := range parsed {
+		tags[k] = v
+	}
+	return tags.Validate()
 }
 
 // startDaemonSession starts daemon connection and session registration in a goroutine.
@@ -173,7 +228,7 @@ func startDaemonSession(ctx context.Context, cfg daemonSessionConfig, onAutostar
 				return err
 			}
 			// Re-register session
-			_, _ = client.SessionRegister(cfg.SessionCode, cfg.OverlayEndpoint, cfg.ProjectPath, cfg.Command, cfg.CmdArgs)
+			_, _ = client.SessionRegisterWithConfig(cfg.SessionCode, cfg.registerConfig())
 			return nil
 		}
 
@@ -186,7 +241,7 @@ func startDaemonSession(ctx context.Context, cfg daemonSessionConfig, onAutostar
 		_, _ = handle.client.OverlaySet(cfg.OverlayEndpoint)
 
 		// Register session with daemon (autostart happens server-side)
-		result, err := handle.client.SessionRegister(cfg.SessionCode, cfg.OverlayEndpoint, cfg.ProjectPath, cfg.Command, cfg.CmdArgs)
+		result, err := handle.client.SessionRegisterWithConfig(cfg.SessionCode, cfg.registerConfig())
 		if err != nil {
 			return
 		}
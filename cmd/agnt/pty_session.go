@@ -0,0 +1,167 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/ptyhost"
+
+	"github.com/creack/pty"
+)
+
+// sessionPTY is the terminal of the command agnt run wraps: a PTY of our own,
+// or one the daemon hosts for a detachable session.
+type sessionPTY interface {
+	io.ReadWriter
+	// Resize sets the size of the command's terminal.
+	Resize(cols, rows int) error
+	// Interrupt is called when agnt run itself is interrupted.
+	Interrupt()
+	// Wait waits for the command once its output has ended.
+	Wait() error
+	Close() error
+}
+
+// localPTY runs the command in a PTY owned by this process; the command
+// ends with it.
+type localPTY struct {
+	cmd  *execCmd
+	ptmx *os.File
+}
+
+func startLocalPTY(c *execCmd) (*localPTY, error) {
+	ptmx, err := pty.Start(c)
+	if err != nil {
+		return nil, err
+	}
+	return &localPTY{cmd: c, ptmx: ptmx}, nil
+}
+
+func (p *localPTY) Read(b []byte) (int, error)  { return p.ptmx.Read(b) }
+func (p *localPTY) Write(b []byte) (int, error) { return p.ptmx.Write(b) }
+func (p *localPTY) Close() error                { return p.ptmx.Close() }
+func (p *localPTY) Wait() error                 { return p.cmd.Wait() }
+
+func (p *localPTY) Resize(cols, rows int) error {
+	return pty.Setsize(p.ptmx, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)})
+}
+
+func (p *localPTY) Interrupt() {
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Signal(syscall.SIGINT)
+	}
+}
+
+// hostedPTY is a terminal attached to a command the daemon hosts. Losing
+// the terminal detaches it; the command keeps running until it exits or
+// the daemon stops.
+type hostedPTY struct {
+	conn   *ptyhost.Conn
+	exited atomic.Bool
+}
+
+// startHostedPTY has the daemon start c in a PTY it keeps, and attaches to it.
+func startHostedPTY(socketPath, code string, c *execCmd, cols, rows int) (*hostedPTY, error) {
+	client, err := hostClient(socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	// The daemon runs in a directory of its own
+	dir := c.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	result, err := client.SessionHost(code, ptyhost.Config{
+		Command: c.Path,
+		Args:    c.Args[1:],
+		Dir:     dir,
+		Env:     c.Env,
+		Cols:    cols,
+		Rows:    rows,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachHostedPTY(code, getString(result, "socket_path"), cols, rows)
+}
+
+// resumeHostedPTY attaches to the command a detachable session left running.
+// It returns what the daemon knows about the command.
+func resumeHostedPTY(socketPath, code string, cols, rows int) (*hostedPTY, map[string]interface{}, error) {
+	client, err := hostClient(socketPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.Close()
+
+	info, err := client.SessionHosted(code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no detachable session %q to resume (list them with agnt session hosted): %w", code, err)
+	}
+	p, err := attachHostedPTY(code, getString(info, "socket_path"), cols, rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, info, nil
+}
+
+func attachHostedPTY(code, hostSocket string, cols, rows int) (*hostedPTY, error) {
+	if hostSocket == "" {
+		return nil, fmt.Errorf("daemon returned no terminal socket for %s", code)
+	}
+	conn, err := ptyhost.Attach(hostSocket, cols, rows)
+	if err != nil {
+		return nil, err
+	}
+	return &hostedPTY{conn: conn}, nil
+}
+
+// hostClient connects to the daemon, starting it if needed: the hosted
+// command lives in the daemon.
+func hostClient(socketPath string) (*daemon.Client, error) {
+	config := daemon.DefaultAutoStartConfig()
+	if socketPath != "" {
+		config.SocketPath = socketPath
+	}
+	client, err := daemon.EnsureDaemonRunning(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	return client, nil
+}
+
+// Read ends with io.EOF whether the command exited or the terminal was
+// detached; Detached tells them apart.
+func (p *hostedPTY) Read(b []byte) (int, error) {
+	n, err := p.conn.Read(b)
+	if err == io.EOF {
+		p.exited.Store(true)
+	} else if errors.Is(err, ptyhost.ErrDetached) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (p *hostedPTY) Write(b []byte) (int, error) { return p.conn.Write(b) }
+func (p *hostedPTY) Resize(cols, rows int) error { return p.conn.Resize(cols, rows) }
+func (p *hostedPTY) Close() error                { return p.conn.Close() }
+
+// Interrupt leaves the command running; the session can be resumed.
+func (p *hostedPTY) Interrupt() {}
+
+// Wait reports nothing: the daemon reaps the command.
+func (p *hostedPTY) Wait() error { return nil }
+
+// Detached reports whether the command was left running without a terminal.
+func (p *hostedPTY) Detached() bool {
+	return !p.exited.Load()
+}
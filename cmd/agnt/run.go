@@ -21,7 +21,6 @@ import (
 	"github.com/standardbeagle/agnt/internal/overlay"
 	"github.com/standardbeagle/agnt/internal/protocol"
 
-	"github.com/creack/pty"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -44,12 +43,24 @@ Flags:
   --no-indicator        Disable the indicator bar
   --no-overlay          Disable terminal overlay entirely
   --no-autostart        Skip auto-starting scripts and proxies from .agnt.kdl
+  --transcript          Record terminal output to .agnt/sessions/<code>.jsonl
+                        (search it with: agnt session transcript <code>)
+  --detachable          Run the command in a PTY kept by the daemon, so it
+                        survives this terminal closing
+  --resume <code>       Reattach to a detachable session that is still running
+  --tag <key=value>     Tag the session (repeatable, or comma-separated), to tell
+                        parallel agents apart in agnt session list --tag
+  --description <text>  Describe what the session is working on
 
 Examples:
   agnt run claude --dangerously-skip-permissions
   agnt run claude --session dev
   agnt run claude
   agnt run claude --no-autostart    # Skip .agnt.kdl autostart
+  agnt run claude --transcript      # Review later with agnt session transcript
+  agnt run claude --tag role=frontend --tag ticket=ABC-123
+  agnt run claude --detachable --session dev
+  agnt run --resume dev             # After the terminal crashed or was closed
   agnt run gemini
   agnt run copilot
   agnt run opencode
@@ -74,6 +85,11 @@ var (
 	useTermOverlay    bool = true
 	sessionCode       string
 	skipAutostart     bool = false
+	recordTranscript  bool
+	sessionTags       = protocol.Labels{}
+	sessionDesc       string
+	detachableSession bool
+	resumeCode        string
 )
 
 func init() {
@@ -136,11 +152,41 @@ func runCommand(cmd *cobra.Command, args []string) {
 			skipAutostart = true
 			commandArgs = append(args[:i], args[i+1:]...)
 			continue
+		case "--transcript":
+			recordTranscript = true
+			commandArgs = append(args[:i], args[i+1:]...)
+			continue
+		case "--tag":
+			if i+1 < len(args) {
+				if err := addSessionTags(sessionTags, args[i+1]); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: --tag: %v\n", err)
+					os.Exit(1)
+				}
+				commandArgs = append(args[:i], args[i+2:]...)
+				continue
+			}
+		case "--description":
+			if i+1 < len(args) {
+				sessionDesc = args[i+1]
+				commandArgs = append(args[:i], args[i+2:]...)
+				continue
+			}
+		case "--detachable":
+			detachableSession = true
+			commandArgs = append(args[:i], args[i+1:]...)
+			continue
+		case "--resume":
+			if i+1 < len(args) {
+				resumeCode = args[i+1]
+				commandArgs = append(args[:i], args[i+2:]...)
+				continue
+			}
 		}
 		i++
 	}
 
-	if len(commandArgs) == 0 {
+	// A resumed session already runs its command
+	if len(commandArgs) == 0 && resumeCode == "" {
 		fmt.Fprintln(os.Stderr, "Error: command is required")
 		os.Exit(1)
 	}
@@ -194,38 +240,90 @@ func spinner(message string) func() {
 
 // runWithPTY runs a command in a PTY with overlay support.
 func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCode string) error {
-	// Find the command
-	command := args[0]
-	cmdArgs := args[1:]
+	daemonSocketPath, _ := rootCmd.Flags().GetString("socket")
 
-	// Auto-generate session code if not provided
-	if sessionCode == "" {
-		sessionCode = generateSessionCode(command)
+	// Get initial terminal size
+	width, height := 80, 24
+	if w, h, err := term.GetSize(int(os.Stdin.Fd())); err == nil {
+		width, height = w, h
+	}
+
+	// Reserve bottom row for indicator bar by telling child the terminal is 1 row shorter.
+	// This prevents the child from drawing in our indicator area.
+	childHeight := height
+	if useTermOverlay && showIndicator && height > 1 {
+		childHeight = height - 1
 	}
 
 	// Get project path for session registration
 	projectPath, _ := os.Getwd()
 
-	// For Claude, inject system prompt with agnt context
-	// Check if command is Claude (handles aliases, paths like /usr/bin/claude, etc.)
-	if isClaudeCommand(command) {
-		if prompt := buildAgntSystemPrompt(socketPath); prompt != "" {
-			cmdArgs = append(cmdArgs, "--append-system-prompt", prompt)
+	var ptmx sessionPTY
+	var command string
+	var cmdArgs []string
+	if len(args) > 0 {
+		command = args[0]
+		cmdArgs = args[1:]
+	}
+
+	// A resumed session reattaches to the command it left running in the
+	// daemon, in the directory it was started from
+	if resumeCode != "" {
+		sessionCode = resumeCode
+		hosted, info, err := resumeHostedPTY(daemonSocketPath, sessionCode, width, childHeight)
+		if err != nil {
+			return err
+		}
+		ptmx = hosted
+		if command == "" {
+			command = filepath.Base(getString(info, "command"))
+		}
+		if dir := getString(info, "dir"); dir != "" {
+			projectPath = dir
 		}
 	}
 
-	// Show startup animation
-	stopSpinner := spinner(fmt.Sprintf("Starting %s...", command))
+	// Auto-generate session code if not provided
+	if sessionCode == "" {
+		sessionCode = generateSessionCode(command)
+	}
 
-	// Create the command
-	c := commandWithArgs(command, cmdArgs...)
-	c.Env = append(os.Environ(), "AGNT_PROJECT_PATH="+projectPath)
+	// Record terminal output for agnt session transcript
+	sessionTranscript := openTranscript(recordTranscript, projectPath, sessionCode, command)
+	defer sessionTranscript.Close()
 
-	// Start the command with a pty
-	ptmx, err := pty.Start(c)
-	stopSpinner() // Stop spinner once PTY starts
-	if err != nil {
-		return fmt.Errorf("failed to start pty: %w", err)
+	if ptmx == nil {
+		// For Claude, inject system prompt with agnt context
+		// Check if command is Claude (handles aliases, paths like /usr/bin/claude, etc.)
+		if isClaudeCommand(command) {
+			if prompt := buildAgntSystemPrompt(socketPath); prompt != "" {
+				cmdArgs = append(cmdArgs, "--append-system-prompt", prompt)
+			}
+		}
+
+		// Show startup animation
+		stopSpinner := spinner(fmt.Sprintf("Starting %s...", command))
+
+		// Create the command
+		c := commandWithArgs(command, cmdArgs...)
+		c.Env = append(os.Environ(), "AGNT_PROJECT_PATH="+projectPath)
+
+		// Start the command with a pty, in the daemon when it should outlive
+		// this terminal
+		var err error
+		if detachableSession {
+			ptmx, err = startHostedPTY(daemonSocketPath, sessionCode, c, width, childHeight)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\r[agnt] Not detachable, running in this terminal: %v\n", err)
+			}
+		}
+		if ptmx == nil {
+			ptmx, err = startLocalPTY(c)
+		}
+		stopSpinner() // Stop spinner once PTY starts
+		if err != nil {
+			return fmt.Errorf("failed to start pty: %w", err)
+		}
 	}
 
 	// Clear screen before child starts outputting to prevent visual artifacts
@@ -236,24 +334,12 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 		_ = ptmx.Close()
 	}()
 
-	// Get initial terminal size
-	width, height := 80, 24
-	if w, h, err := term.GetSize(int(os.Stdin.Fd())); err == nil {
-		width, height = w, h
-	}
-
 	// Handle pty size changes
 	sizeCh := make(chan os.Signal, 1)
 	signal.Notify(sizeCh, syscall.SIGWINCH)
 	defer signal.Stop(sizeCh)
 
-	// Reserve bottom row for indicator bar by telling child the terminal is 1 row shorter.
-	// This prevents the child from drawing in our indicator area.
-	childHeight := height
-	if useTermOverlay && showIndicator && height > 1 {
-		childHeight = height - 1
-	}
-	if err := pty.Setsize(ptmx, &pty.Winsize{Rows: uint16(childHeight), Cols: uint16(width)}); err != nil {
+	if err := ptmx.Resize(width, childHeight); err != nil {
 		log.Printf("error setting pty size: %s", err)
 	}
 
@@ -280,7 +366,6 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 
 	// Register overlay endpoint with daemon so proxies forward events to us
 	// Use ResilientClient for automatic reconnection with overlay re-registration
-	daemonSocketPath, _ := rootCmd.Flags().GetString("socket")
 	daemonHandle := startDaemonSession(ctx, daemonSessionConfig{
 		SessionCode:     sessionCode,
 		OverlayEndpoint: netOverlay.SocketPath(),
@@ -289,6 +374,8 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 		CmdArgs:         cmdArgs,
 		SocketPath:      daemonSocketPath,
 		SkipAutostart:   skipAutostart,
+		Tags:            sessionTags,
+		Description:     sessionDesc,
 	}, func(errs []string) {
 		// Log autostart errors prominently
 		fmt.Fprintf(os.Stderr, "\r\n[agnt] \x1b[31mAutostart errors:\x1b[0m\r\n")
@@ -420,7 +507,7 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 				if termOverlay != nil && termOverlay.ShowIndicator() && h > 1 {
 					ch = h - 1
 				}
-				if err := pty.Setsize(ptmx, &pty.Winsize{Rows: uint16(ch), Cols: uint16(w)}); err != nil {
+				if err := ptmx.Resize(w, ch); err != nil {
 					log.Printf("error resizing pty: %s", err)
 				}
 				// Update overlay with full terminal dimensions (it draws in the reserved row)
@@ -436,8 +523,9 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 	}()
 
 	// For non-Claude AI agents, inject initial context about agnt setup
-	// This helps them understand the MCP tools available
-	if !isClaudeCommand(command) && isKnownAIAgent(command) {
+	// This helps them understand the MCP tools available (a resumed agent
+	// already has it)
+	if resumeCode == "" && !isClaudeCommand(command) && isKnownAIAgent(command) {
 		if prompt := buildAgntSystemPrompt(socketPath); prompt != "" {
 			// Send as initial stdin to the agent (appears as if user typed it)
 			// Use a brief, succinct message
@@ -490,7 +578,7 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 		}
 		activityMonitor = overlay.NewActivityMonitor(outputDest, activityCfg)
 
-		_, _ = io.Copy(activityMonitor, ptmx)
+		_, _ = io.Copy(teeTranscript(activityMonitor, sessionTranscript), ptmx)
 		close(done)
 	}()
 
@@ -498,9 +586,7 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 	select {
 	case <-ctx.Done():
 		// Send interrupt to the process
-		if c.Process != nil {
-			_ = c.Process.Signal(syscall.SIGINT)
-		}
+		ptmx.Interrupt()
 	case <-done:
 		// Process exited normally
 	}
@@ -521,12 +607,16 @@ func runWithPTY(ctx context.Context, args []string, socketPath string, sessionCo
 	}
 
 	// Wait for the process
-	_ = c.Wait()
+	_ = ptmx.Wait()
 
 	// Clean up terminal state before returning
 	// This resets scroll region, shows cursor, and resets text attributes
 	cleanupTerminal(height)
 
+	if hosted, ok := ptmx.(*hostedPTY); ok && hosted.Detached() {
+		fmt.Fprintf(os.Stdout, "\r\n[agnt] Detached; %s keeps running. Resume with: agnt run --resume %s\r\n", command, sessionCode)
+	}
+
 	return nil
 }
 
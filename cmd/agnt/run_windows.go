@@ -45,12 +45,19 @@ Flags:
   --no-indicator        Disable the indicator bar
   --no-overlay          Disable terminal overlay entirely
   --no-autostart        Skip auto-starting scripts and proxies from .agnt.kdl
+  --transcript          Record terminal output to .agnt/sessions/<code>.jsonl
+                        (search it with: agnt session transcript <code>)
+  --tag <key=value>     Tag the session (repeatable, or comma-separated), to tell
+                        parallel agents apart in agnt session list --tag
+  --description <text>  Describe what the session is working on
 
 Examples:
   agnt run claude --dangerously-skip-permissions
   agnt run claude --session dev
   agnt run claude
   agnt run claude --no-autostart    # Skip .agnt.kdl autostart
+  agnt run claude --transcript      # Review later with agnt session transcript
+  agnt run claude --tag role=frontend --tag ticket=ABC-123
   agnt run gemini
   agnt run copilot
   agnt run opencode
@@ -75,6 +82,9 @@ var (
 	useTermOverlay    bool = true
 	sessionCode       string
 	skipAutostart     bool = false
+	recordTranscript  bool
+	sessionTags       = protocol.Labels{}
+	sessionDesc       string
 )
 
 func init() {
@@ -138,6 +148,29 @@ func runCommand(cmd *cobra.Command, args []string) {
 			skipAutostart = true
 			commandArgs = append(args[:i], args[i+1:]...)
 			continue
+		case "--transcript":
+			recordTranscript = true
+			commandArgs = append(args[:i], args[i+1:]...)
+			continue
+		case "--tag":
+			if i+1 < len(args) {
+				if err := addSessionTags(sessionTags, args[i+1]); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: --tag: %v\n", err)
+					os.Exit(1)
+				}
+				commandArgs = append(args[:i], args[i+2:]...)
+				continue
+			}
+		case "--description":
+			if i+1 < len(args) {
+				sessionDesc = args[i+1]
+				commandArgs = append(args[:i], args[i+2:]...)
+				continue
+			}
+		case "--detachable", "--resume":
+			// The daemon hosts detachable sessions in a Unix PTY
+			fmt.Fprintf(os.Stderr, "Error: %s is not supported on Windows\n", args[i])
+			os.Exit(1)
 		}
 		i++
 	}
@@ -202,6 +235,10 @@ func runWithConPTY(ctx context.Context, args []string, socketPath string, sessio
 	// Get project path for session registration and MCP directory filtering
 	projectPath, _ := os.Getwd()
 
+	// Record terminal output for agnt session transcript
+	sessionTranscript := openTranscript(recordTranscript, projectPath, sessionCode, command)
+	defer sessionTranscript.Close()
+
 	// For Claude, inject system prompt with agnt context
 	if isClaudeCommand(command) {
 		if prompt := buildAgntSystemPrompt(socketPath); prompt != "" {
@@ -304,6 +341,8 @@ func runWithConPTY(ctx context.Context, args []string, socketPath string, sessio
 		CmdArgs:         cmdArgs,
 		SocketPath:      daemonSocketPath,
 		SkipAutostart:   skipAutostart,
+		Tags:            sessionTags,
+		Description:     sessionDesc,
 	}, func(errs []string) {
 		// Log autostart errors
 		for _, e := range errs {
@@ -505,7 +544,7 @@ func runWithConPTY(ctx context.Context, args []string, socketPath string, sessio
 		}
 		activityMonitor = overlay.NewActivityMonitor(browserHelper, activityCfg)
 
-		_, _ = io.Copy(activityMonitor, ptmx)
+		_, _ = io.Copy(teeTranscript(activityMonitor, sessionTranscript), ptmx)
 		close(done)
 	}()
 
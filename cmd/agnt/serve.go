@@ -24,7 +24,12 @@ var serveCmd = &cobra.Command{
 	Long: `Run as a shared server that syncronizes processes and proxies across clients.
 
 By default, uses a background daemon for persistent state.
-Use --legacy for direct process management (state lost on exit).`,
+Use --legacy for direct process management (state lost on exit).
+
+With --http, serves MCP over streamable HTTP (POST and SSE on /mcp) instead of
+stdio, so editor extensions and remote assistants can share one long-running
+instance. Clients send "Authorization: Bearer <token>"; the token comes from
+--http-token or AGNT_MCP_TOKEN, or is generated and logged at startup.`,
 	Run: runServe,
 }
 
@@ -39,12 +44,16 @@ Uses a background daemon for persistent state across connections.`,
 }
 
 var (
-	serveLegacy bool
-	mcpNoAttach bool
+	serveLegacy    bool
+	serveHTTPAddr  string
+	serveHTTPToken string
+	mcpNoAttach    bool
 )
 
 func init() {
 	serveCmd.Flags().BoolVar(&serveLegacy, "legacy", false, "Run in legacy mode (no daemon)")
+	serveCmd.Flags().StringVar(&serveHTTPAddr, "http", "", "Serve MCP over streamable HTTP on this address (e.g. :8765) instead of stdio")
+	serveCmd.Flags().StringVar(&serveHTTPToken, "http-token", "", "Bearer token HTTP clients must send (default: $"+mcpTokenEnv+", or generated)")
 	mcpCmd.Flags().BoolVar(&mcpNoAttach, "no-attach", false, "Don't auto-attach to existing session (operate globally)")
 }
 
@@ -95,6 +104,10 @@ func runDaemonClient(socketPath string, noAttach ...bool) {
 		dt.SetNoAutoAttach(true)
 	}
 
+	// Resource subscriptions, so clients hear when process output, proxy
+	// logs or agnt://notifications change
+	watcher := tools.NewResourceWatcher()
+
 	// Create MCP server
 	server := mcp.NewServer(
 		&mcp.Implementation{
@@ -102,7 +115,9 @@ func runDaemonClient(socketPath string, noAttach ...bool) {
 			Version: appVersion,
 		},
 		&mcp.ServerOptions{
-			HasTools: true,
+			HasTools:           true,
+			SubscribeHandler:   watcher.Subscribe,
+			UnsubscribeHandler: watcher.Unsubscribe,
 			Instructions: `Development tool server for project detection, process management, and reverse proxy with traffic logging.
 
 Uses a background daemon for persistent state across connections:
@@ -112,6 +127,7 @@ Uses a background daemon for persistent state across connections:
 
 Available tools:
 - detect: Detect project type and available scripts
+- project: Switch the project later calls work on (use, list)
 - run: Run scripts or raw commands (background/foreground modes)
 - proc: Manage processes (status, output, stop, list, cleanup_port)
 - proxy: Reverse proxy with traffic logging and JS instrumentation
@@ -124,8 +140,34 @@ Available tools:
 
 	// Register daemon-aware tools
 	tools.RegisterDaemonTools(server, dt)
+	tools.RegisterProjectTool(server, dt)
 	tools.RegisterDaemonManagementTool(server, dt)
 	tools.RegisterTunnelTool(server, dt)
+	tools.RegisterExposeTool(server, dt)
+	tools.RegisterInvestigateTool(server, dt)
+	tools.RegisterTestTool(server, dt)
+	tools.RegisterBenchTool(server, dt)
+	tools.RegisterProfileTool(server, dt)
+	tools.RegisterWorkspaceTool(server, dt)
+	tools.RegisterCompareTool(server, dt)
+	tools.RegisterRemoteTool(server, dt)
+	tools.RegisterK8sTool(server, dt)
+	tools.RegisterGitTool(server, dt)
+	tools.RegisterStackTool(server, dt)
+	tools.RegisterDoubleTool(server, dt)
+	tools.RegisterWatchTool(server, dt)
+	tools.RegisterWaitTool(server, dt)
+	tools.RegisterEventsTool(server, dt)
+	tools.RegisterNotifyTool(server, dt)
+	tools.RegisterA11yTool(server, dt)
+	tools.RegisterRecordTool(server, dt)
+
+	// Attachments of floating panel messages (screenshots, dropped files)
+	tools.RegisterPanelResources(server, dt)
+	// Process output and proxy log summaries
+	tools.RegisterLiveResources(server, dt, watcher)
+	// Prompts that gather daemon data for common diagnoses
+	tools.RegisterPrompts(server, dt)
 
 	// Register snapshot tools (visual regression testing)
 	snapshotManager, err := snapshot.NewManager("", 0.01) // Default path and 1% threshold
@@ -141,11 +183,11 @@ Available tools:
 		log.Println("MCP client shutdown signal received...")
 	}()
 
-	// Run server over stdio
+	// Run server over stdio, or HTTP with --http
 	log.SetOutput(os.Stderr)
 	log.Printf("Starting %s v%s (daemon mode)", appName, appVersion)
 
-	if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil {
+	if err := runServer(ctx, server); err != nil {
 		if ctx.Err() == nil {
 			log.Fatalf("Server error: %v", err)
 		}
@@ -219,11 +261,11 @@ func runLegacyServer() {
 		}
 	}()
 
-	// Run server over stdio
+	// Run server over stdio, or HTTP with --http
 	log.SetOutput(os.Stderr)
 	log.Printf("Starting %s v%s (legacy mode)", appName, appVersion)
 
-	if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil {
+	if err := runServer(ctx, server); err != nil {
 		if ctx.Err() == nil {
 			log.Fatalf("Server error: %v", err)
 		}
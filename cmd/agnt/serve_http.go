@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+const (
+	// mcpHTTPPath is where the streamable HTTP transport is served.
+	mcpHTTPPath = "/mcp"
+	// mcpTokenEnv holds the bearer token when --http-token isn't given.
+	mcpTokenEnv = "AGNT_MCP_TOKEN"
+	// mcpHTTPShutdownTimeout is how long open streams get to finish on exit.
+	mcpHTTPShutdownTimeout = 2 * time.Second
+)
+
+// runServer serves the MCP server over stdio, or over streamable HTTP when
+// --http is set.
+func runServer(ctx context.Context, server *mcp.Server) error {
+	if serveHTTPAddr == "" {
+		return server.Run(ctx, &mcp.StdioTransport{})
+	}
+	token := serveHTTPToken
+	if token == "" {
+		token = os.Getenv(mcpTokenEnv)
+	}
+	return serveMCPHTTP(ctx, server, serveHTTPAddr, token)
+}
+
+// serveMCPHTTP serves the MCP streamable HTTP transport (POST for requests,
+// GET for the SSE stream of server messages) on addr until ctx ends. Every
+// request needs the token as "Authorization: Bearer"; without one a random
+// token is generated and logged.
+func serveMCPHTTP(ctx context.Context, server *mcp.Server, addr, token string) error {
+	if token == "" {
+		token = proxy.NewAccessToken()
+		log.Printf("Generated MCP token (set --http-token or %s to choose one): %s", mcpTokenEnv, token)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("MCP HTTP transport: %w", err)
+	}
+
+	httpServer := &http.Server{
+		Handler:           mcpHTTPHandler(server, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), mcpHTTPShutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			// SSE streams stay open until their clients leave
+			httpServer.Close()
+		}
+	}()
+
+	log.Printf("MCP streamable HTTP transport on http://%s%s", ln.Addr(), mcpHTTPPath)
+	if err := httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// mcpHTTPHandler routes mcpHTTPPath to the streamable transport of server,
+// which every client session shares, behind bearer token auth.
+func mcpHTTPHandler(server *mcp.Server, token string) http.Handler {
+	streamable := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+
+	mux := http.NewServeMux()
+	mux.Handle(mcpHTTPPath, requireBearer(token, streamable))
+	return mux
+}
+
+// requireBearer rejects requests without "Authorization: Bearer <token>".
+func requireBearer(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="agnt"`)
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -23,10 +25,16 @@ directly to a session or schedule messages for future delivery.
 Examples:
   agnt session list
   agnt session list --global
+  agnt session list --tag role=frontend
+  agnt session tag claude-1 role=frontend ticket=ABC-123
   agnt session send claude-1 "Check the test results"
   agnt session schedule claude-1 5m "Verify this completed"
   agnt session tasks
-  agnt session cancel task-abc123`,
+  agnt session cancel task-abc123
+  agnt session clip claude-1 < error.log
+  agnt session transcript claude-1 --grep "(?i)fail" --since 12h
+  agnt session hosted
+  agnt session detach claude-1`,
 }
 
 var sessionListCmd = &cobra.Command{
@@ -35,11 +43,41 @@ var sessionListCmd = &cobra.Command{
 	Run:   runSessionList,
 }
 
+var sessionTagCmd = &cobra.Command{
+	Use:   "tag <code> [key=value ...] [key- ...]",
+	Short: "Set or remove tags and the description of a session",
+	Long: `Tag a session to tell parallel agents of a project apart. key=value sets a
+tag, key- removes it; without changes the session's tags are shown.
+Filter by tag with 'agnt session list --tag'.
+
+Example:
+  agnt session tag claude-1 role=frontend ticket=ABC-123
+  agnt session tag claude-1 ticket- --description "Checkout redesign"
+  agnt session tag claude-1`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runSessionTag,
+}
+
 var sessionSendCmd = &cobra.Command{
 	Use:   "send <code> <message>",
 	Short: "Send a message to a session immediately",
-	Args:  cobra.ExactArgs(2),
-	Run:   runSessionSend,
+	Long: `Send a message to a session immediately.
+
+Messages are typed with a preset chosen for the session's tool: most get the
+whole message in one write, while tools that drop large pastes get it in
+short chunks. Override the pacing with:
+
+  --preset   instant, paste (bracketed paste), chunked or typed
+  --chunk    characters per write
+  --delay    milliseconds between chunks
+  --paste    wrap the message in bracketed-paste markers
+  --no-enter type the message without submitting it
+
+Example:
+  agnt session send claude-1 "Check the test results"
+  agnt session send --chunk 128 --delay 25 gemini-1 "$(cat prompt.md)"`,
+	Args: cobra.ExactArgs(2),
+	Run:  runSessionSend,
 }
 
 var sessionScheduleCmd = &cobra.Command{
@@ -53,12 +91,75 @@ Duration format:
   - "1h30m" = 1 hour 30 minutes
   - "30s" = 30 seconds
 
+With --cron, the message is delivered every time the cron expression
+(minute hour day-of-month month day-of-week, @hourly, @daily, @weekly or
+@every 45m) matches, in --tz or the daemon's local time, until cancelled.
+The duration argument is left out.
+
+With --wait-for-idle, a due message is held while the agent is busy
+(up to 10 minutes) so it doesn't interrupt an answer.
+
 Example:
-  agnt session schedule claude-1 5m "Verify this completed"`,
-	Args: cobra.ExactArgs(3),
+  agnt session schedule claude-1 5m "Verify this completed"
+  agnt session schedule --wait-for-idle claude-1 1m "Run the tests"
+  agnt session schedule --cron "*/30 * * * *" --wait-for-idle claude-1 "Commit your work"
+  agnt session schedule --cron "0 9 * * MON-FRI" --tz Europe/Berlin claude-1 "Summarize open PRs"`,
+	Args: cobra.RangeArgs(2, 3),
 	Run:  runSessionSchedule,
 }
 
+var sessionClipCmd = &cobra.Command{
+	Use:   "clip <code> [text]",
+	Short: "Share terminal text with the session's browser pages",
+	Long: `Share text with the floating panel of the session's proxied pages, where
+it can be copied or pasted into the message box. Text comes from the
+argument or from stdin, up to 64KB.
+
+Without text, prints the last text moved between the pages and the
+terminal, including text sent from the panel with "To terminal".
+
+Example:
+  agnt session clip claude-1 "TypeError: x is undefined"
+  pbpaste | agnt session clip claude-1
+  agnt session clip claude-1 > last-clip.txt`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  runSessionClip,
+}
+
+var sessionTranscriptCmd = &cobra.Command{
+	Use:   "transcript <code>",
+	Short: "Search the recorded terminal output of a session",
+	Long: `Print the terminal output a session recorded with 'agnt run --transcript',
+kept in .agnt/sessions/<code>.jsonl. Filters apply in order: time range,
+pattern, then the last --tail lines. Works after the session has ended,
+from its project directory.
+
+Example:
+  agnt session transcript claude-1
+  agnt session transcript claude-1 --tail 500 --since 12h
+  agnt session transcript claude-1 --grep "(?i)error|panic"
+  agnt session transcript claude-1 --since 2026-03-01T22:00:00Z --until 2026-03-02T06:00:00Z`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSessionTranscript,
+}
+
+var sessionHostedCmd = &cobra.Command{
+	Use:   "hosted",
+	Short: "List detachable sessions the daemon keeps running",
+	Long: `List the commands started with 'agnt run --detachable', which the daemon
+keeps running when their terminal goes away. Reattach to one with
+'agnt run --resume <code>'.`,
+	Args: cobra.NoArgs,
+	Run:  runSessionHosted,
+}
+
+var sessionDetachCmd = &cobra.Command{
+	Use:   "detach <code>",
+	Short: "Detach the terminal of a detachable session, leaving it running",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSessionDetach,
+}
+
 var sessionTasksCmd = &cobra.Command{
 	Use:   "tasks",
 	Short: "List scheduled tasks",
@@ -72,16 +173,52 @@ var sessionCancelCmd = &cobra.Command{
 	Run:   runSessionCancel,
 }
 
+var sessionPauseCmd = &cobra.Command{
+	Use:   "pause <task_id>",
+	Short: "Hold a scheduled task without cancelling it",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSessionPauseResume,
+}
+
+var sessionResumeCmd = &cobra.Command{
+	Use:   "resume <task_id>",
+	Short: "Resume a paused task; a recurring one continues at its next time",
+	Args:  cobra.ExactArgs(1),
+	Run:   runSessionPauseResume,
+}
+
 func init() {
 	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionTagCmd)
 	sessionCmd.AddCommand(sessionSendCmd)
 	sessionCmd.AddCommand(sessionScheduleCmd)
 	sessionCmd.AddCommand(sessionTasksCmd)
 	sessionCmd.AddCommand(sessionCancelCmd)
+	sessionCmd.AddCommand(sessionPauseCmd)
+	sessionCmd.AddCommand(sessionResumeCmd)
+	sessionCmd.AddCommand(sessionClipCmd)
+	sessionCmd.AddCommand(sessionTranscriptCmd)
+	sessionCmd.AddCommand(sessionHostedCmd)
+	sessionCmd.AddCommand(sessionDetachCmd)
 
 	// Add --global flag to list and tasks commands
 	sessionListCmd.Flags().Bool("global", false, "Include sessions from all directories")
+	sessionListCmd.Flags().StringArray("tag", nil, "Only sessions with this tag (key=value, or key for any value)")
+	sessionTagCmd.Flags().String("description", "", "Replace the session's description (\"\" clears it)")
 	sessionTasksCmd.Flags().Bool("global", false, "Include tasks from all directories")
+	sessionScheduleCmd.Flags().Bool("wait-for-idle", false, "Hold delivery while the agent is busy")
+	sessionScheduleCmd.Flags().String("cron", "", "Deliver every time this cron expression matches")
+	sessionScheduleCmd.Flags().String("tz", "", "IANA time zone of --cron (default: the daemon's local time)")
+	sessionSendCmd.Flags().String("preset", "", "Typing preset: instant, paste, chunked or typed (default: by tool)")
+	sessionSendCmd.Flags().Int("chunk", 0, "Characters per write")
+	sessionSendCmd.Flags().Int("delay", 0, "Milliseconds between chunks")
+	sessionSendCmd.Flags().Bool("paste", false, "Wrap the message in bracketed-paste markers")
+	sessionSendCmd.Flags().Bool("no-enter", false, "Type the message without submitting it")
+	sessionTranscriptCmd.Flags().Int("tail", 0, "Last N lines (default 100, max 5000)")
+	sessionTranscriptCmd.Flags().String("grep", "", "Only lines matching this Go regexp")
+	sessionTranscriptCmd.Flags().String("since", "", "From this RFC 3339 time, or a duration ago like 12h")
+	sessionTranscriptCmd.Flags().String("until", "", "Before this RFC 3339 time, or a duration ago")
+	sessionTranscriptCmd.Flags().Bool("raw", false, "Keep terminal escape sequences")
 }
 
 func getSessionClient(cmd *cobra.Command) (*daemon.Client, error) {
@@ -115,7 +252,16 @@ func runSessionList(cmd *cobra.Command, args []string) {
 		Global:    global,
 	}
 
-	result, err := client.SessionList(dirFilter)
+	tags := protocol.Labels{}
+	tagFlags, _ := cmd.Flags().GetStringArray("tag")
+	for _, t := range tagFlags {
+		if err := addSessionTags(tags, t); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --tag: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	result, err := client.SessionListTagged(dirFilter, tags)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to list sessions: %v\n", err)
 		os.Exit(1)
@@ -123,7 +269,9 @@ func runSessionList(cmd *cobra.Command, args []string) {
 
 	sessions, ok := result["sessions"].([]interface{})
 	if !ok || len(sessions) == 0 {
-		if global {
+		if len(tags) > 0 {
+			fmt.Printf("No active sessions tagged %s\n", tags)
+		} else if global {
 			fmt.Println("No active sessions")
 		} else {
 			fmt.Printf("No active sessions in %s\n", cwd)
@@ -133,7 +281,7 @@ func runSessionList(cmd *cobra.Command, args []string) {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "CODE\tCOMMAND\tSTATUS\tPROJECT\tSTARTED")
+	fmt.Fprintln(w, "CODE\tCOMMAND\tSTATUS\tPROJECT\tSTARTED\tTAGS")
 
 	for _, s := range sessions {
 		if sm, ok := s.(map[string]interface{}); ok {
@@ -149,12 +297,64 @@ func runSessionList(cmd *cobra.Command, args []string) {
 				}
 			}
 
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", code, command, status, projectPath, started)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", code, command, status, projectPath, started, sessionTagsString(sm))
 		}
 	}
 	w.Flush()
 }
 
+func runSessionTag(cmd *cobra.Command, args []string) {
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	set := protocol.Labels{}
+	var remove []string
+	for _, arg := range args[1:] {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			set[key] = value
+		} else if key, ok := strings.CutSuffix(arg, "-"); ok && key != "" {
+			remove = append(remove, key)
+		} else {
+			fmt.Fprintf(os.Stderr, "Invalid tag %q: use key=value to set or key- to remove\n", arg)
+			os.Exit(1)
+		}
+	}
+	var description *string
+	if cmd.Flags().Changed("description") {
+		d, _ := cmd.Flags().GetString("description")
+		description = &d
+	}
+
+	result, err := client.SessionTag(args[0], set, remove, description)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to tag session: %v\n", err)
+		os.Exit(1)
+	}
+
+	tags := sessionTagsString(result)
+	if tags == "" {
+		tags = "(none)"
+	}
+	fmt.Printf("Session %s tags: %s\n", args[0], tags)
+	if d := getString(result, "description"); d != "" {
+		fmt.Printf("  %s\n", d)
+	}
+}
+
+// sessionTagsString renders the tags of a session as sorted key=value pairs.
+func sessionTagsString(session map[string]interface{}) string {
+	raw, _ := session["tags"].(map[string]interface{})
+	tags := make(protocol.Labels, len(raw))
+	for k, v := range raw {
+		tags[k], _ = v.(string)
+	}
+	return tags.String()
+}
+
 func runSessionSend(cmd *cobra.Command, args []string) {
 	client, err := getSessionClient(cmd)
 	if err != nil {
@@ -166,7 +366,20 @@ func runSessionSend(cmd *cobra.Command, args []string) {
 	code := args[0]
 	message := args[1]
 
-	result, err := client.SessionSend(code, message)
+	var opts daemon.SendOptions
+	opts.Preset, _ = cmd.Flags().GetString("preset")
+	opts.ChunkSize, _ = cmd.Flags().GetInt("chunk")
+	opts.ChunkDelayMs, _ = cmd.Flags().GetInt("delay")
+	if cmd.Flags().Changed("paste") {
+		paste, _ := cmd.Flags().GetBool("paste")
+		opts.BracketedPaste = &paste
+	}
+	if noEnter, _ := cmd.Flags().GetBool("no-enter"); noEnter {
+		enter := false
+		opts.Enter = &enter
+	}
+
+	result, err := client.SessionSendWithOptions(code, message, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to send message: %v\n", err)
 		os.Exit(1)
@@ -188,11 +401,27 @@ func runSessionSchedule(cmd *cobra.Command, args []string) {
 	}
 	defer client.Close()
 
+	cron, _ := cmd.Flags().GetString("cron")
+	waitForIdle, _ := cmd.Flags().GetBool("wait-for-idle")
+	if (cron != "") != (len(args) == 2) {
+		fmt.Fprintln(os.Stderr, "Give either <duration> or --cron")
+		os.Exit(1)
+	}
+
 	code := args[0]
-	duration := args[1]
-	message := args[2]
+	message := args[len(args)-1]
 
-	result, err := client.SessionSchedule(code, duration, message)
+	var result map[string]interface{}
+	if cron != "" {
+		tz, _ := cmd.Flags().GetString("tz")
+		result, err = client.SessionScheduleCron(code, cron, tz, message, waitForIdle)
+	} else {
+		schedule := client.SessionSchedule
+		if waitForIdle {
+			schedule = client.SessionScheduleWhenIdle
+		}
+		result, err = schedule(code, args[1], message)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to schedule message: %v\n", err)
 		os.Exit(1)
@@ -215,6 +444,148 @@ func runSessionSchedule(cmd *cobra.Command, args []string) {
 	}
 }
 
+func runSessionClip(cmd *cobra.Command, args []string) {
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	code := args[0]
+	var text string
+	if len(args) > 1 {
+		text = args[1]
+	} else if !isTerminal(os.Stdin) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read stdin: %v\n", err)
+			os.Exit(1)
+		}
+		text = strings.TrimRight(string(data), "\n")
+	}
+
+	if text == "" {
+		result, err := client.SessionClipboard(code)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read clipboard: %v\n", err)
+			os.Exit(1)
+		}
+		clip, ok := result["clipboard"].(map[string]interface{})
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Nothing moved between pages and session %s yet\n", code)
+			os.Exit(1)
+		}
+		fmt.Println(getString(clip, "text"))
+		return
+	}
+
+	result, err := client.SessionShareClipboard(code, text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to share text: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Shared %d bytes with %d page(s)\n", getInt(result, "bytes"), getInt(result, "sent_count"))
+}
+
+func runSessionTranscript(cmd *cobra.Command, args []string) {
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	query := daemon.TranscriptQuery{}
+	query.Tail, _ = cmd.Flags().GetInt("tail")
+	query.Grep, _ = cmd.Flags().GetString("grep")
+	query.Since, _ = cmd.Flags().GetString("since")
+	query.Until, _ = cmd.Flags().GetString("until")
+	query.Raw, _ = cmd.Flags().GetBool("raw")
+	query.ProjectPath, _ = os.Getwd()
+
+	result, err := client.SessionTranscript(args[0], query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read transcript: %v\n", err)
+		os.Exit(1)
+	}
+
+	lines, _ := result["lines"].([]interface{})
+	for _, l := range lines {
+		lm, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stamp := ""
+		if t, err := time.Parse(time.RFC3339, getString(lm, "time")); err == nil {
+			stamp = t.Local().Format("01-02 15:04:05")
+		}
+		fmt.Printf("%s  %s\n", stamp, getString(lm, "text"))
+	}
+	if getBool(result, "truncated") {
+		fmt.Fprintf(os.Stderr, "(%d of %d matching lines; use --tail for more)\n", len(lines), getInt(result, "matched"))
+	}
+}
+
+func runSessionHosted(cmd *cobra.Command, args []string) {
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	result, err := client.SessionHosted("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list hosted sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	hosted, ok := result["hosted"].([]interface{})
+	if !ok || len(hosted) == 0 {
+		fmt.Println("No detachable sessions (start one with agnt run --detachable)")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tPID\tTERMINAL\tDIR\tCOMMAND")
+
+	for _, h := range hosted {
+		if hm, ok := h.(map[string]interface{}); ok {
+			terminal := "attached"
+			if !getBool(hm, "attached") {
+				terminal = "detached"
+				if t, err := time.Parse(time.RFC3339, getString(hm, "detached_at")); err == nil {
+					terminal += " " + time.Since(t).Round(time.Second).String() + " ago"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", getString(hm, "code"), getInt(hm, "pid"), terminal, getString(hm, "dir"), getString(hm, "command"))
+		}
+	}
+	w.Flush()
+}
+
+func runSessionDetach(cmd *cobra.Command, args []string) {
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	result, err := client.SessionDetach(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to detach session: %v\n", err)
+		os.Exit(1)
+	}
+
+	if getBool(result, "detached") {
+		fmt.Printf("Session %s detached; resume it with: agnt run --resume %s\n", args[0], args[0])
+	} else {
+		fmt.Printf("Session %s has no terminal attached; resume it with: agnt run --resume %s\n", args[0], args[0])
+	}
+}
+
 func runSessionTasks(cmd *cobra.Command, args []string) {
 	client, err := getSessionClient(cmd)
 	if err != nil {
@@ -255,13 +626,16 @@ func runSessionTasks(cmd *cobra.Command, args []string) {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tSESSION\tSTATUS\tDELIVER AT\tMESSAGE")
+	fmt.Fprintln(w, "ID\tSESSION\tSTATUS\tDELIVER AT\tREPEAT\tRUNS\tMESSAGE")
 
 	for _, t := range tasks {
 		if tm, ok := t.(map[string]interface{}); ok {
 			id := getString(tm, "id")
 			sessionCode := getString(tm, "session_code")
 			status := getString(tm, "status")
+			if getBool(tm, "overdue") {
+				status += " (overdue)"
+			}
 			message := getString(tm, "message")
 
 			// Truncate message if too long
@@ -276,7 +650,14 @@ func runSessionTasks(cmd *cobra.Command, args []string) {
 				}
 			}
 
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, sessionCode, status, deliverAt, message)
+			repeat := getString(tm, "cron")
+			if repeat == "" {
+				repeat = "-"
+			} else if tz := getString(tm, "timezone"); tz != "" {
+				repeat += " (" + tz + ")"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", id, sessionCode, status, deliverAt, repeat, getInt(tm, "run_count"), message)
 		}
 	}
 	w.Flush()
@@ -301,6 +682,30 @@ func runSessionCancel(cmd *cobra.Command, args []string) {
 	fmt.Printf("Task %s cancelled\n", taskID)
 }
 
+func runSessionPauseResume(cmd *cobra.Command, args []string) {
+	client, err := getSessionClient(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	update := client.SessionPause
+	if cmd.Name() == "resume" {
+		update = client.SessionResume
+	}
+	result, err := update(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to %s task: %v\n", cmd.Name(), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Task %s %s\n", args[0], getString(result, "status"))
+	if ts, err := time.Parse(time.RFC3339, getString(result, "deliver_at")); err == nil && cmd.Name() == "resume" {
+		fmt.Printf("  Next delivery: %s\n", ts.Format(time.RFC1123))
+	}
+}
+
 // getString extracts a string value from a map.
 func getString(m map[string]interface{}, key string) string {
 	if v, ok := m[key].(string); ok {
@@ -316,3 +721,11 @@ func getBool(m map[string]interface{}, key string) bool {
 	}
 	return false
 }
+
+// getInt extracts a numeric value from a map.
+func getInt(m map[string]interface{}, key string) int {
+	if v, ok := m[key].(float64); ok {
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,273 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// Client is the part of the daemon client apply uses.
+type Client interface {
+	ProcListFiltered(filter protocol.ListFilter) (map[string]interface{}, error)
+	ProxyListFiltered(filter protocol.ListFilter) (map[string]interface{}, error)
+	TunnelListFiltered(filter protocol.ListFilter) (map[string]interface{}, error)
+
+	Run(config protocol.RunConfig) (map[string]interface{}, error)
+	ProcRestart(processID string) (map[string]interface{}, error)
+	ProcStop(processID string, force bool) (map[string]interface{}, error)
+	ProcLabel(processID string, set protocol.Labels, remove []string) (map[string]interface{}, error)
+
+	ProxyStartWithConfig(id, targetURL string, port, maxLogSize int, config daemon.ProxyStartConfig) (map[string]interface{}, error)
+	ProxyStop(id string) error
+	ProxyLabel(id string, set protocol.Labels, remove []string) (map[string]interface{}, error)
+	ChaosPreset(proxyID, preset string) (map[string]interface{}, error)
+	ChaosDisable(proxyID string) (map[string]interface{}, error)
+
+	TunnelStart(config protocol.TunnelStartConfig) (map[string]interface{}, error)
+	TunnelStop(id string) error
+	TunnelLabel(id string, set protocol.Labels, remove []string) (map[string]interface{}, error)
+}
+
+// listEntry is an entry of a PROC, PROXY or TUNNEL LIST response.
+type listEntry struct {
+	ID         string          `json:"id"`
+	State      string          `json:"state"`
+	Command    string          `json:"command"`
+	TargetURL  string          `json:"target_url"`
+	ListenAddr string          `json:"listen_addr"`
+	Provider   string          `json:"provider"`
+	Labels     protocol.Labels `json:"labels"`
+}
+
+// FetchState lists what runs for the project at dir. Tunnels aren't scoped
+// to a project, so every tunnel is listed.
+func FetchState(c Client, dir string) (State, error) {
+	filter := protocol.ListFilter{DirectoryFilter: protocol.DirectoryFilter{Directory: dir}}
+	var state State
+	var err error
+	if state.Processes, err = fetchEntities(c.ProcListFiltered, filter, "processes"); err != nil {
+		return state, fmt.Errorf("failed to list processes: %w", err)
+	}
+	if state.Proxies, err = fetchEntities(c.ProxyListFiltered, filter, "proxies"); err != nil {
+		return state, fmt.Errorf("failed to list proxies: %w", err)
+	}
+	if state.Tunnels, err = fetchEntities(c.TunnelListFiltered, filter, "tunnels"); err != nil {
+		return state, fmt.Errorf("failed to list tunnels: %w", err)
+	}
+	return state, nil
+}
+
+func fetchEntities(list func(protocol.ListFilter) (map[string]interface{}, error), filter protocol.ListFilter, key string) (map[string]Entity, error) {
+	result, err := list(filter)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result[key])
+	if err != nil {
+		return nil, err
+	}
+	var entries []listEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	entities := make(map[string]Entity, len(entries))
+	for _, e := range entries {
+		entities[e.ID] = Entity{
+			ID:         e.ID,
+			State:      e.State,
+			Command:    e.Command,
+			Target:     e.TargetURL,
+			ListenAddr: e.ListenAddr,
+			Provider:   e.Provider,
+			Labels:     e.Labels,
+		}
+	}
+	return entities, nil
+}
+
+// Result is the outcome of one change.
+type Result struct {
+	Change
+	Error string `json:"error,omitempty"`
+}
+
+// Executor carries out a plan for the project at Dir.
+type Executor struct {
+	Client Client
+	Spec   *Spec
+	Name   string // Spec name, stored in ManagedLabel
+	Dir    string
+	State  State
+
+	// listenAddrs holds the listen address of proxies created by this run,
+	// for tunnels to them.
+	listenAddrs map[string]string
+}
+
+// Execute applies the changes in order and reports each. A failed change
+// doesn't stop the rest.
+func (e *Executor) Execute(changes []Change) []Result {
+	e.listenAddrs = make(map[string]string)
+	results := make([]Result, 0, len(changes))
+	for _, c := range changes {
+		r := Result{Change: c}
+		if err := e.execute(c); err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (e *Executor) execute(c Change) error {
+	if c.Action == ActionUnchanged {
+		return nil
+	}
+	if c.Action == ActionDelete || c.Action == ActionReplace {
+		if err := e.delete(c.Kind, c.ID); err != nil || c.Action == ActionDelete {
+			return err
+		}
+	}
+	switch c.Kind {
+	case KindProcess:
+		return e.applyProcess(c)
+	case KindProxy:
+		return e.applyProxy(c)
+	case KindTunnel:
+		return e.applyTunnel(c)
+	}
+	return fmt.Errorf("unknown kind %q", c.Kind)
+}
+
+func (e *Executor) delete(kind, id string) error {
+	switch kind {
+	case KindProcess:
+		_, err := e.Client.ProcStop(id, false)
+		return err
+	case KindProxy:
+		return e.Client.ProxyStop(id)
+	case KindTunnel:
+		return e.Client.TunnelStop(id)
+	}
+	return fmt.Errorf("unknown kind %q", kind)
+}
+
+func (e *Executor) applyProcess(c Change) error {
+	p := e.Spec.Processes[c.ID]
+	labels := desiredLabels(p.Labels, e.Name, p.hash())
+	if c.Action == ActionUpdate {
+		cur := e.State.Processes[c.ID]
+		if cur.State != "running" && cur.State != "starting" {
+			if _, err := e.Client.ProcRestart(c.ID); err != nil {
+				return err
+			}
+		}
+		return e.syncLabels(e.Client.ProcLabel, c.ID, cur.Labels, labels)
+	}
+
+	config := protocol.RunConfig{ID: c.ID, Path: e.Dir, Mode: "background"}
+	if p.Script != "" {
+		config.ScriptName = p.Script
+	} else {
+		config.Raw = true
+		config.Command = p.Command
+		config.Args = p.Args
+	}
+	if _, err := e.Client.Run(config); err != nil {
+		return err
+	}
+	_, err := e.Client.ProcLabel(c.ID, labels, nil)
+	return err
+}
+
+func (e *Executor) applyProxy(c Change) error {
+	p := e.Spec.Proxies[c.ID]
+	labels := desiredLabels(p.Labels, e.Name, p.hash())
+	if c.Action == ActionUpdate {
+		cur := e.State.Proxies[c.ID]
+		if err := e.syncLabels(e.Client.ProxyLabel, c.ID, cur.Labels, labels); err != nil {
+			return err
+		}
+		if cur.Labels[ChaosLabel] != p.Chaos {
+			return e.applyChaos(c.ID, p.Chaos)
+		}
+		return nil
+	}
+
+	port := -1
+	if p.ListenPort > 0 {
+		port = p.ListenPort
+	}
+	result, err := e.Client.ProxyStartWithConfig(c.ID, p.TargetURL(), port, p.MaxLogSize, daemon.ProxyStartConfig{
+		Path:        e.Dir,
+		BindAddress: p.BindAddress,
+		Labels:      labels,
+	})
+	if err != nil {
+		return err
+	}
+	if addr, ok := result["listen_addr"].(string); ok {
+		e.listenAddrs[c.ID] = addr
+	}
+	if p.Chaos != "" {
+		return e.applyChaos(c.ID, p.Chaos)
+	}
+	return nil
+}
+
+// applyChaos enables a chaos preset on a proxy, or disables chaos when
+// preset is empty, and records it in ChaosLabel.
+func (e *Executor) applyChaos(proxyID, preset string) error {
+	if preset == "" {
+		if _, err := e.Client.ChaosDisable(proxyID); err != nil {
+			return err
+		}
+		_, err := e.Client.ProxyLabel(proxyID, nil, []string{ChaosLabel})
+		return err
+	}
+	if _, err := e.Client.ChaosPreset(proxyID, preset); err != nil {
+		return err
+	}
+	_, err := e.Client.ProxyLabel(proxyID, protocol.Labels{ChaosLabel: preset}, nil)
+	return err
+}
+
+func (e *Executor) applyTunnel(c Change) error {
+	t := e.Spec.Tunnels[c.ID]
+	labels := desiredLabels(t.Labels, e.Name, t.hash())
+	if c.Action == ActionUpdate {
+		return e.syncLabels(e.Client.TunnelLabel, c.ID, e.State.Tunnels[c.ID].Labels, labels)
+	}
+
+	config := protocol.TunnelStartConfig{
+		ID:        c.ID,
+		Provider:  t.Provider,
+		LocalPort: t.LocalPort,
+		ProxyID:   t.Proxy,
+		Expires:   t.Expires,
+		Labels:    labels,
+	}
+	if t.Proxy != "" {
+		addr, ok := e.listenAddrs[t.Proxy]
+		if !ok {
+			addr = e.State.Proxies[t.Proxy].ListenAddr
+		}
+		if config.LocalPort = listenPort(addr); config.LocalPort == 0 {
+			return fmt.Errorf("proxy %q is not running", t.Proxy)
+		}
+	}
+	_, err := e.Client.TunnelStart(config)
+	return err
+}
+
+// syncLabels sets and removes labels so an entity has the desired ones.
+func (e *Executor) syncLabels(label func(string, protocol.Labels, []string) (map[string]interface{}, error), id string, current, desired protocol.Labels) error {
+	set, remove := labelDiff(current, desired)
+	if len(set) == 0 && len(remove) == 0 {
+		return nil
+	}
+	_, err := label(id, set, remove)
+	return err
+}
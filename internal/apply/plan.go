@@ -0,0 +1,272 @@
+package apply
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// Labels apply keeps on what it manages. ManagedLabel holds the spec name,
+// so entities left out of a spec are removed only by the spec that created
+// them; ChaosLabel records the chaos preset applied to a proxy; SpecLabel
+// holds a hash of the settings an entity was created with, so changing any
+// of them replaces it.
+const (
+	ManagedLabel = "agnt/apply"
+	ChaosLabel   = "agnt/chaos"
+	SpecLabel    = "agnt/spec"
+)
+
+// Entity kinds.
+const (
+	KindProcess = "process"
+	KindProxy   = "proxy"
+	KindTunnel  = "tunnel"
+)
+
+// Action is what a change does to an entity.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"  // Changed in place
+	ActionReplace   Action = "replace" // Stopped and created again
+	ActionDelete    Action = "delete"
+	ActionUnchanged Action = "unchanged"
+)
+
+// Change is one step of a plan.
+type Change struct {
+	Kind   string   `json:"kind"`
+	ID     string   `json:"id"`
+	Action Action   `json:"action"`
+	Detail []string `json:"detail,omitempty"`
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.ID)
+	if len(c.Detail) > 0 {
+		s += ": " + strings.Join(c.Detail, ", ")
+	}
+	return s
+}
+
+// Entity is the part of a running process, proxy or tunnel a plan compares.
+type Entity struct {
+	ID         string
+	State      string // Process or tunnel state
+	Command    string // Process command
+	Target     string // Proxy target URL
+	ListenAddr string // Proxy listen address
+	Provider   string // Tunnel provider
+	Labels     protocol.Labels
+}
+
+// State is what currently runs for a project, by ID.
+type State struct {
+	Processes map[string]Entity
+	Proxies   map[string]Entity
+	Tunnels   map[string]Entity
+}
+
+// Plan compares a spec named name with the current state and returns the
+// changes that reconcile them: deletions first (tunnels, proxies, then
+// processes), then the rest in dependency order (processes, proxies, then
+// tunnels).
+func Plan(spec *Spec, name string, state State) []Change {
+	var deletes, changes []Change
+
+	for _, id := range sortedKeys(spec.Processes) {
+		p := spec.Processes[id]
+		changes = append(changes, planEntity(KindProcess, id, state.Processes, desiredLabels(p.Labels, name, p.hash()), func(cur Entity) (replace, update []string) {
+			if p.Command != "" && cur.Command != "" && cur.Command != p.Command {
+				replace = append(replace, fmt.Sprintf("command %s -> %s", cur.Command, p.Command))
+			}
+			if cur.State != "running" && cur.State != "starting" {
+				update = append(update, fmt.Sprintf("start (%s)", cur.State))
+			}
+			return replace, update
+		}))
+	}
+	for _, id := range sortedKeys(spec.Proxies) {
+		p := spec.Proxies[id]
+		changes = append(changes, planEntity(KindProxy, id, state.Proxies, desiredLabels(p.Labels, name, p.hash()), func(cur Entity) (replace, update []string) {
+			if target := p.TargetURL(); strings.TrimSuffix(cur.Target, "/") != strings.TrimSuffix(target, "/") {
+				replace = append(replace, fmt.Sprintf("target %s -> %s", cur.Target, target))
+			}
+			if port := listenPort(cur.ListenAddr); p.ListenPort > 0 && port != p.ListenPort {
+				replace = append(replace, fmt.Sprintf("listen port %d -> %d", port, p.ListenPort))
+			}
+			if current := cur.Labels[ChaosLabel]; current != p.Chaos {
+				update = append(update, fmt.Sprintf("chaos %s -> %s", orNone(current), orNone(p.Chaos)))
+			}
+			return replace, update
+		}))
+	}
+	for _, id := range sortedKeys(spec.Tunnels) {
+		t := spec.Tunnels[id]
+		changes = append(changes, planEntity(KindTunnel, id, state.Tunnels, desiredLabels(t.Labels, name, t.hash()), func(cur Entity) (replace, update []string) {
+			if cur.Provider != t.Provider {
+				replace = append(replace, fmt.Sprintf("provider %s -> %s", cur.Provider, t.Provider))
+			}
+			if cur.State == "stopped" || cur.State == "failed" {
+				replace = append(replace, fmt.Sprintf("restart (%s)", cur.State))
+			}
+			return replace, update
+		}))
+	}
+
+	deletes = append(deletes, planDeletes(KindTunnel, spec.Tunnels, state.Tunnels, name)...)
+	deletes = append(deletes, planDeletes(KindProxy, spec.Proxies, state.Proxies, name)...)
+	deletes = append(deletes, planDeletes(KindProcess, spec.Processes, state.Processes, name)...)
+	return append(deletes, changes...)
+}
+
+// planEntity compares one declared entity with what runs under its ID.
+// compare reports the differences that need a replacement and those that
+// can be changed in place; label differences are always in place. An
+// entity whose SpecLabel differs from the declared one is replaced too.
+func planEntity(kind, id string, current map[string]Entity, labels protocol.Labels, compare func(Entity) (replace, update []string)) Change {
+	cur, ok := current[id]
+	if !ok {
+		return Change{Kind: kind, ID: id, Action: ActionCreate}
+	}
+	replace, update := compare(cur)
+	if applied := cur.Labels[SpecLabel]; len(replace) == 0 && applied != "" && applied != labels[SpecLabel] {
+		replace = append(replace, "spec changed since it was applied")
+	}
+	if len(replace) > 0 {
+		return Change{Kind: kind, ID: id, Action: ActionReplace, Detail: replace}
+	}
+	if set, remove := labelDiff(cur.Labels, labels); len(set) > 0 || len(remove) > 0 {
+		update = append(update, "labels "+labelSummary(set, remove))
+	}
+	if len(update) > 0 {
+		return Change{Kind: kind, ID: id, Action: ActionUpdate, Detail: update}
+	}
+	return Change{Kind: kind, ID: id, Action: ActionUnchanged}
+}
+
+// planDeletes removes entities the spec created that it no longer declares.
+func planDeletes[T any](kind string, declared map[string]T, current map[string]Entity, name string) []Change {
+	var changes []Change
+	for _, id := range sortedKeys(current) {
+		if _, ok := declared[id]; ok || current[id].Labels[ManagedLabel] != name {
+			continue
+		}
+		changes = append(changes, Change{Kind: kind, ID: id, Action: ActionDelete})
+	}
+	return changes
+}
+
+// desiredLabels returns the declared labels plus the managed and spec
+// labels.
+func desiredLabels(labels map[string]string, name, hash string) protocol.Labels {
+	out := protocol.Labels{ManagedLabel: name, SpecLabel: hash}
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+// hash identifies the settings a process is created with.
+func (p *ProcessSpec) hash() string {
+	return specHash(struct {
+		Script, Command string
+		Args            []string
+	}{p.Script, p.Command, p.Args})
+}
+
+// hash identifies the settings a proxy is created with; chaos and labels
+// change in place.
+func (p *ProxySpec) hash() string {
+	return specHash(struct {
+		Target, BindAddress    string
+		ListenPort, MaxLogSize int
+	}{strings.TrimSuffix(p.TargetURL(), "/"), p.BindAddress, p.ListenPort, p.MaxLogSize})
+}
+
+// hash identifies the settings a tunnel is created with.
+func (t *TunnelSpec) hash() string {
+	return specHash(struct {
+		Provider, Proxy, Expires string
+		LocalPort                int
+	}{t.Provider, t.Proxy, t.Expires, t.LocalPort})
+}
+
+// specHash returns a short hash of the JSON form of v.
+func specHash(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// labelDiff returns the labels to set and remove to turn current into
+// desired. ChaosLabel is left alone: it follows the chaos preset; so is
+// SpecLabel, which only changes with a replacement.
+func labelDiff(current, desired protocol.Labels) (protocol.Labels, []string) {
+	set := protocol.Labels{}
+	for k, v := range desired {
+		if cur, ok := current[k]; (!ok || cur != v) && !appliedLabel(k) {
+			set[k] = v
+		}
+	}
+	var remove []string
+	for k := range current {
+		if _, ok := desired[k]; !ok && !appliedLabel(k) {
+			remove = append(remove, k)
+		}
+	}
+	sort.Strings(remove)
+	return set, remove
+}
+
+// appliedLabel reports whether a label records what apply did rather than
+// what the spec declares.
+func appliedLabel(key string) bool {
+	return key == ChaosLabel || key == SpecLabel
+}
+
+func labelSummary(set protocol.Labels, remove []string) string {
+	parts := make([]string, 0, 2)
+	if len(set) > 0 {
+		parts = append(parts, "+"+set.String())
+	}
+	if len(remove) > 0 {
+		parts = append(parts, "-"+strings.Join(remove, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// listenPort returns the port of a listen address such as ":12345", or 0.
+func listenPort(addr string) int {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	kdlPath := filepath.Join(dir, "agnt-apply.kdl")
+	os.WriteFile(kdlPath, []byte(`
+processes {
+    web script="dev"
+}
+proxies {
+    app port=3000 chaos="mobile-3g"
+}
+tunnels {
+    public provider="cloudflare" proxy="app"
+}
+`), 0644)
+	yamlPath := filepath.Join(dir, "agnt-apply.yaml")
+	os.WriteFile(yamlPath, []byte(`
+processes:
+  web:
+    script: dev
+proxies:
+  app:
+    port: 3000
+    chaos: mobile-3g
+tunnels:
+  public:
+    provider: cloudflare
+    proxy: app
+`), 0644)
+
+	for _, path := range []string{kdlPath, yamlPath} {
+		spec, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", filepath.Base(path), err)
+		}
+		if spec.Processes["web"] == nil || spec.Processes["web"].Script != "dev" {
+			t.Errorf("%s: unexpected processes %+v", filepath.Base(path), spec.Processes)
+		}
+		if p := spec.Proxies["app"]; p == nil || p.TargetURL() != "http://localhost:3000" || p.Chaos != "mobile-3g" {
+			t.Errorf("%s: unexpected proxies %+v", filepath.Base(path), spec.Proxies)
+		}
+		if tun := spec.Tunnels["public"]; tun == nil || tun.Provider != "cloudflare" || tun.Proxy != "app" {
+			t.Errorf("%s: unexpected tunnels %+v", filepath.Base(path), spec.Tunnels)
+		}
+	}
+
+	bad := &Spec{Proxies: map[string]*ProxySpec{"app": {URL: "http://localhost:3000", Port: 3000}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("Expected error for a proxy with both url and port")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	spec := &Spec{
+		Processes: map[string]*ProcessSpec{
+			"web": {Script: "dev"},
+			"api": {Command: "go", Args: []string{"run", "."}},
+		},
+		Proxies: map[string]*ProxySpec{
+			"app":  {Port: 3000, Chaos: "mobile-3g"},
+			"docs": {URL: "http://localhost:4000/", Labels: map[string]string{"area": "docs"}},
+		},
+		Tunnels: map[string]*TunnelSpec{
+			"public": {Provider: "cloudflare", Proxy: "app"},
+		},
+	}
+	managed := protocol.Labels{ManagedLabel: "agnt.kdl"}
+	state := State{
+		Processes: map[string]Entity{
+			"web": {ID: "web", State: "running", Command: "npm", Labels: managed},
+			"api": {ID: "api", State: "stopped", Command: "go", Labels: managed},
+			"old": {ID: "old", State: "running", Labels: managed},
+			// Not created by this spec, so left alone
+			"other": {ID: "other", State: "running"},
+		},
+		Proxies: map[string]Entity{
+			"app":  {ID: "app", Target: "http://localhost:3000", ListenAddr: ":45000", Labels: managed},
+			"docs": {ID: "docs", Target: "http://localhost:4001", Labels: managed},
+		},
+		Tunnels: map[string]Entity{
+			"public": {ID: "public", Provider: "ngrok", State: "connected", Labels: managed},
+			"stale":  {ID: "stale", Provider: "ngrok", Labels: protocol.Labels{ManagedLabel: "other.kdl"}},
+		},
+	}
+
+	changes := Plan(spec, "agnt.kdl", state)
+	want := []struct {
+		kind   string
+		id     string
+		action Action
+	}{
+		{KindProcess, "old", ActionDelete},
+		{KindProcess, "api", ActionUpdate},
+		{KindProcess, "web", ActionUnchanged},
+		{KindProxy, "app", ActionUpdate},
+		{KindProxy, "docs", ActionReplace},
+		{KindTunnel, "public", ActionReplace},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %v", len(want), changes)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.Kind != w.kind || c.ID != w.id || c.Action != w.action {
+			t.Errorf("change %d = %s, want %s %s %s", i, c, w.action, w.kind, w.id)
+		}
+	}
+	if got := changes[3].String(); got != "update proxy app: chaos none -> mobile-3g" {
+		t.Errorf("Unexpected chaos change: %s", got)
+	}
+
+	// Labels are reconciled in place, without touching the chaos label
+	state = State{Proxies: map[string]Entity{
+		"app": {ID: "app", Target: "http://localhost:3000", Labels: protocol.Labels{ManagedLabel: "agnt.kdl", ChaosLabel: "mobile-3g", "area": "old"}},
+	}}
+	changes = Plan(&Spec{Proxies: map[string]*ProxySpec{"app": spec.Proxies["app"]}}, "agnt.kdl", state)
+	if len(changes) != 1 || changes[0].String() != "update proxy app: labels -area" {
+		t.Errorf("Expected a label update, got %v", changes)
+	}
+
+	// An empty state creates everything
+	changes = Plan(spec, "agnt.kdl", State{})
+	for _, c := range changes {
+		if c.Action != ActionCreate {
+			t.Errorf("Expected create, got %s", c)
+		}
+	}
+	if len(changes) != 5 || changes[0].Kind != KindProcess || changes[4].Kind != KindTunnel {
+		t.Errorf("Expected processes created before tunnels, got %v", changes)
+	}
+}
+
+func TestPlanSpecChanges(t *testing.T) {
+	applied := func(hash string) protocol.Labels {
+		return desiredLabels(nil, "agnt.kdl", hash)
+	}
+	api := &ProcessSpec{Command: "go", Args: []string{"run", "."}}
+	web := &ProcessSpec{Script: "dev"}
+	public := &TunnelSpec{Provider: "cloudflare", Proxy: "app"}
+	state := State{
+		Processes: map[string]Entity{
+			"api": {ID: "api", State: "running", Command: "go", Labels: applied(api.hash())},
+			"web": {ID: "web", State: "running", Command: "npm", Labels: applied(web.hash())},
+		},
+		Tunnels: map[string]Entity{
+			"public": {ID: "public", Provider: "cloudflare", State: "connected", Labels: applied(public.hash())},
+		},
+	}
+	spec := &Spec{
+		Processes: map[string]*ProcessSpec{"api": api, "web": web},
+		Tunnels:   map[string]*TunnelSpec{"public": public},
+	}
+	for _, c := range Plan(spec, "agnt.kdl", state) {
+		if c.Action != ActionUnchanged {
+			t.Errorf("Expected the applied spec unchanged, got %s", c)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		spec   *Spec
+		kind   string
+		id     string
+		action Action
+	}{
+		{"process args", &Spec{Processes: map[string]*ProcessSpec{"api": {Command: "go", Args: []string{"run", "./cmd/api"}}}}, KindProcess, "api", ActionReplace},
+		{"process script", &Spec{Processes: map[string]*ProcessSpec{"web": {Script: "start"}}}, KindProcess, "web", ActionReplace},
+		{"process labels", &Spec{Processes: map[string]*ProcessSpec{"web": {Script: "dev", Labels: map[string]string{"area": "web"}}}}, KindProcess, "web", ActionUpdate},
+		{"tunnel proxy", &Spec{Tunnels: map[string]*TunnelSpec{"public": {Provider: "cloudflare", Proxy: "docs"}}}, KindTunnel, "public", ActionReplace},
+		{"tunnel local port", &Spec{Tunnels: map[string]*TunnelSpec{"public": {Provider: "cloudflare", LocalPort: 8080}}}, KindTunnel, "public", ActionReplace},
+		{"tunnel expires", &Spec{Tunnels: map[string]*TunnelSpec{"public": {Provider: "cloudflare", Proxy: "app", Expires: "2h"}}}, KindTunnel, "public", ActionReplace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found bool
+			for _, c := range Plan(tt.spec, "agnt.kdl", state) {
+				if c.Kind != tt.kind || c.ID != tt.id {
+					continue
+				}
+				found = true
+				if c.Action != tt.action {
+					t.Errorf("Expected %s, got %s", tt.action, c)
+				}
+			}
+			if !found {
+				t.Errorf("Expected a change for %s %s", tt.kind, tt.id)
+			}
+		})
+	}
+
+	// The spec label only changes with a replacement, never as a label update
+	labels, remove := labelDiff(applied(api.hash()), applied(web.hash()))
+	if len(labels) != 0 || len(remove) != 0 {
+		t.Errorf("Expected the spec label left out of label diffs, got %v %v", labels, remove)
+	}
+}
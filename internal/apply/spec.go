@@ -0,0 +1,122 @@
+// Package apply reconciles the daemon's processes, proxies and tunnels for a
+// project with a declarative description, as used by agnt apply.
+package apply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kdl "github.com/sblinch/kdl-go"
+	"gopkg.in/yaml.v3"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// Spec is the desired state of a project. Entries are keyed by the ID the
+// process, proxy or tunnel runs under.
+type Spec struct {
+	Processes map[string]*ProcessSpec `kdl:"processes" yaml:"processes"`
+	Proxies   map[string]*ProxySpec   `kdl:"proxies" yaml:"proxies"`
+	Tunnels   map[string]*TunnelSpec  `kdl:"tunnels" yaml:"tunnels"`
+}
+
+// ProcessSpec is a process that should be running: a package.json script or
+// a raw command.
+type ProcessSpec struct {
+	Script  string            `kdl:"script" yaml:"script"`
+	Command string            `kdl:"command" yaml:"command"`
+	Args    []string          `kdl:"args" yaml:"args"`
+	Labels  map[string]string `kdl:"labels" yaml:"labels"`
+}
+
+// ProxySpec is a reverse proxy with its settings.
+type ProxySpec struct {
+	// URL is the target URL (e.g., "http://localhost:3000")
+	URL string `kdl:"url" yaml:"url"`
+	// Port is the target port - shorthand for http://localhost:PORT
+	Port int `kdl:"port" yaml:"port"`
+	// Host is the target host (default: localhost) - only used with Port
+	Host string `kdl:"host" yaml:"host"`
+	// ListenPort is the proxy's own port (default: derived from the target)
+	ListenPort  int    `kdl:"listen-port" yaml:"listen-port"`
+	BindAddress string `kdl:"bind-address" yaml:"bind-address"`
+	MaxLogSize  int    `kdl:"max-log-size" yaml:"max-log-size"`
+	// Chaos is a chaos preset to enable (e.g., "mobile-3g")
+	Chaos  string            `kdl:"chaos" yaml:"chaos"`
+	Labels map[string]string `kdl:"labels" yaml:"labels"`
+}
+
+// TunnelSpec is a tunnel to a proxy or a local port.
+type TunnelSpec struct {
+	Provider string `kdl:"provider" yaml:"provider"`
+	// Proxy is the proxy to expose; its public URL is set to the tunnel's
+	Proxy     string            `kdl:"proxy" yaml:"proxy"`
+	LocalPort int               `kdl:"local-port" yaml:"local-port"`
+	Expires   string            `kdl:"expires" yaml:"expires"`
+	Labels    map[string]string `kdl:"labels" yaml:"labels"`
+}
+
+// TargetURL returns the proxy's target URL.
+func (p *ProxySpec) TargetURL() string {
+	if p.URL != "" {
+		return p.URL
+	}
+	host := p.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("http://%s:%d", host, p.Port)
+}
+
+// Load reads a spec from a .kdl or .yaml file.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	spec := &Spec{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, spec)
+	default:
+		err = kdl.Unmarshal(data, spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return spec, spec.Validate()
+}
+
+// Validate checks that every entry can be created.
+func (s *Spec) Validate() error {
+	for id, p := range s.Processes {
+		if p == nil || (p.Script == "") == (p.Command == "") {
+			return fmt.Errorf("process %q: set exactly one of script or command", id)
+		}
+		if err := protocol.Labels(p.Labels).Validate(); err != nil {
+			return fmt.Errorf("process %q: %w", id, err)
+		}
+	}
+	for id, p := range s.Proxies {
+		if p == nil || (p.URL == "") == (p.Port == 0) {
+			return fmt.Errorf("proxy %q: set exactly one of url or port", id)
+		}
+		if err := protocol.Labels(p.Labels).Validate(); err != nil {
+			return fmt.Errorf("proxy %q: %w", id, err)
+		}
+	}
+	for id, t := range s.Tunnels {
+		if t == nil || t.Provider == "" {
+			return fmt.Errorf("tunnel %q: provider is required", id)
+		}
+		if (t.Proxy == "") == (t.LocalPort == 0) {
+			return fmt.Errorf("tunnel %q: set exactly one of proxy or local-port", id)
+		}
+		if err := protocol.Labels(t.Labels).Validate(); err != nil {
+			return fmt.Errorf("tunnel %q: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package bench
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseOutput_GoBench(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: github.com/acme/app/internal/parser
+cpu: AMD Ryzen
+BenchmarkParse-16        	   10000	    105234 ns/op	   4096 B/op	      12 allocs/op
+BenchmarkParse-16        	   10000	    104000 ns/op	   4096 B/op	      12 allocs/op
+BenchmarkEncode/small-16 	  500000	      2400 ns/op	 250.50 MB/s
+PASS
+ok  	github.com/acme/app/internal/parser	3.2s
+`
+	got := ParseOutput(out)
+	want := []Result{
+		{Name: "parser.BenchmarkParse", Metrics: map[string]float64{"ns/op": 105234, "B/op": 4096, "allocs/op": 12}},
+		{Name: "parser.BenchmarkParse", Metrics: map[string]float64{"ns/op": 104000, "B/op": 4096, "allocs/op": 12}},
+		{Name: "parser.BenchmarkEncode/small", Metrics: map[string]float64{"ns/op": 2400, "MB/s": 250.5}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOutput() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestParseOutput_JSONLines(t *testing.T) {
+	out := `starting bench
+{"name": "render", "value": 12.5, "unit": "ms"}
+{"name": "score", "value": 0}
+{"unrelated": true}
+`
+	got := ParseOutput(out)
+	want := []Result{
+		{Name: "render", Metrics: map[string]float64{"ms": 12.5}},
+		{Name: "score", Metrics: map[string]float64{"value": 0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOutput() = %v, want %v", got, want)
+	}
+}
+
+func TestMannWhitneyP(t *testing.T) {
+	// Completely separated samples of 5: exact two-sided p = 2/252
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{6, 7, 8, 9, 10}
+	if p := MannWhitneyP(x, y); math.Abs(p-2.0/252) > 1e-9 {
+		t.Errorf("separated p = %v, want %v", p, 2.0/252)
+	}
+
+	// Interleaved samples are not significant
+	if p := MannWhitneyP([]float64{1, 3, 5, 7, 9}, []float64{2, 4, 6, 8, 10}); p < 0.5 {
+		t.Errorf("interleaved p = %v, want large", p)
+	}
+
+	// Ties fall back to the normal approximation
+	if p := MannWhitneyP([]float64{1, 1, 2, 2, 3, 3}, []float64{5, 5, 6, 6, 7, 7}); p > 0.01 {
+		t.Errorf("tied separated p = %v, want small", p)
+	}
+
+	if p := MannWhitneyP(nil, y); p != 1 {
+		t.Errorf("empty p = %v, want 1", p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := []float64{100, 101, 99, 100, 102}
+	slower := []float64{120, 119, 121, 122, 118}
+
+	c := Compare("BenchmarkX", "ns/op", base, slower, DefaultAlpha, DefaultThreshold)
+	if !c.Regression || c.Improvement || c.DeltaPct < 19 || c.DeltaPct > 21 {
+		t.Errorf("expected ~20%% regression, got %+v", c)
+	}
+
+	// Higher throughput is an improvement
+	c = Compare("BenchmarkX", "MB/s", base, slower, DefaultAlpha, DefaultThreshold)
+	if c.Regression || !c.Improvement {
+		t.Errorf("expected improvement for MB/s, got %+v", c)
+	}
+
+	// Significant but below threshold
+	c = Compare("BenchmarkX", "ns/op", base, []float64{103, 103.5, 104, 104.5, 105}, DefaultAlpha, DefaultThreshold)
+	if !c.Significant || c.Regression {
+		t.Errorf("expected significant non-regression, got %+v", c)
+	}
+
+	// Too few samples are never significant
+	c = Compare("BenchmarkX", "ns/op", []float64{100}, []float64{200}, DefaultAlpha, DefaultThreshold)
+	if c.Significant {
+		t.Errorf("single samples should not be significant, got %+v", c)
+	}
+}
+
+func runWith(suite, commit string, dirty bool, values ...float64) *Run {
+	r := &Run{Suite: suite, Commit: commit, Dirty: dirty}
+	for _, v := range values {
+		r.Results = append(r.Results, Result{Name: "BenchmarkX", Metrics: map[string]float64{"ns/op": v}})
+	}
+	return r
+}
+
+func TestBaselineAndCompareRuns(t *testing.T) {
+	old := runWith("core", "aaa", false, 100, 101, 99, 100, 102)
+	other := runWith("ui", "bbb", false, 1, 2, 3)
+	dirtyBase := runWith("core", "bbb", true, 50, 50, 50, 50, 50)
+	current := runWith("core", "bbb", false, 120, 119, 121, 122, 118)
+	runs := []*Run{old, other, dirtyBase, current}
+
+	base := Baseline(runs, current, "")
+	if len(base) != 1 || base[0] != old {
+		t.Fatalf("Baseline() = %v, want the clean run at aaa", base)
+	}
+	if got := Baseline(runs, current, "zzz"); got != nil {
+		t.Errorf("unknown base commit should give no baseline, got %v", got)
+	}
+
+	comparisons := CompareRuns(base, current, DefaultAlpha, DefaultThreshold)
+	if len(comparisons) != 1 || !comparisons[0].Regression {
+		t.Errorf("CompareRuns() = %+v, want one regression", comparisons)
+	}
+
+	if got := Baseline([]*Run{current}, current, ""); got != nil {
+		t.Errorf("first run should have no baseline, got %v", got)
+	}
+}
+
+func TestHistoryAppend(t *testing.T) {
+	dir := t.TempDir()
+	h := Open(dir)
+	h.maxRuns = 2
+	for i := 0; i < 3; i++ {
+		if err := h.Append(runWith("core", "aaa", false, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := Open(dir).Runs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Results[0].Metrics["ns/op"] != 1 {
+		t.Errorf("expected the two newest runs, got %d", len(runs))
+	}
+	if runs[0].ID == "" || runs[0].Timestamp.IsZero() {
+		t.Error("expected ID and timestamp to be assigned")
+	}
+}
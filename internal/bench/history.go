@@ -0,0 +1,256 @@
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HistoryDirName is the project-relative directory holding benchmark history.
+	HistoryDirName = ".agnt"
+
+	// HistoryFileName is the benchmark history file name (JSON lines, one run per line).
+	HistoryFileName = "bench-history.jsonl"
+
+	// DefaultMaxRuns is how many runs are kept per project.
+	DefaultMaxRuns = 200
+)
+
+// Run is one recorded benchmark run.
+type Run struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Suite     string    `json:"suite"`            // Configured benchmark name
+	Commit    string    `json:"commit,omitempty"` // git HEAD at record time
+	Dirty     bool      `json:"dirty,omitempty"`  // Uncommitted changes were present
+	Results   []Result  `json:"results"`
+}
+
+// Samples returns every value of a metric for each benchmark in the run.
+func (r *Run) Samples() map[string]map[string][]float64 {
+	samples := make(map[string]map[string][]float64)
+	for _, res := range r.Results {
+		byUnit, ok := samples[res.Name]
+		if !ok {
+			byUnit = make(map[string][]float64)
+			samples[res.Name] = byUnit
+		}
+		for unit, v := range res.Metrics {
+			byUnit[unit] = append(byUnit[unit], v)
+		}
+	}
+	return samples
+}
+
+// History is the file-backed benchmark history for one project.
+type History struct {
+	path    string
+	maxRuns int
+
+	mu     sync.Mutex
+	runs   []*Run
+	loaded bool
+}
+
+// Open returns the benchmark history for a project. The file is read lazily.
+func Open(projectPath string) *History {
+	return &History{
+		path:    filepath.Join(projectPath, HistoryDirName, HistoryFileName),
+		maxRuns: DefaultMaxRuns,
+	}
+}
+
+// Append records a run, assigning its ID and timestamp if unset.
+func (h *History) Append(run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.loadLocked(); err != nil {
+		return err
+	}
+	if run.Timestamp.IsZero() {
+		run.Timestamp = time.Now()
+	}
+	if run.ID == "" {
+		run.ID = fmt.Sprintf("bench-%d", run.Timestamp.UnixNano())
+	}
+
+	h.runs = append(h.runs, run)
+	if len(h.runs) > h.maxRuns {
+		h.runs = h.runs[len(h.runs)-h.maxRuns:]
+		return h.rewriteLocked()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Runs returns the recorded runs, oldest first.
+func (h *History) Runs() ([]*Run, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.loadLocked(); err != nil {
+		return nil, err
+	}
+	return append([]*Run(nil), h.runs...), nil
+}
+
+func (h *History) loadLocked() error {
+	if h.loaded {
+		return nil
+	}
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		h.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue // Skip corrupt lines rather than losing the whole history
+		}
+		h.runs = append(h.runs, &run)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	h.loaded = true
+	return nil
+}
+
+func (h *History) rewriteLocked() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, run := range h.runs {
+		data, err := json.Marshal(run)
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// Baseline picks the runs to compare current against: all runs of the same
+// suite at baseCommit when given, otherwise at the most recent clean commit
+// other than current's. Returns nil when there is nothing to compare with.
+func Baseline(runs []*Run, current *Run, baseCommit string) []*Run {
+	if baseCommit == "" {
+		for i := len(runs) - 1; i >= 0; i-- {
+			r := runs[i]
+			if r == current || r.Suite != current.Suite || r.Commit == "" || r.Dirty {
+				continue
+			}
+			if r.Commit != current.Commit || current.Dirty {
+				baseCommit = r.Commit
+				break
+			}
+		}
+		if baseCommit == "" {
+			return nil
+		}
+	}
+
+	var base []*Run
+	for _, r := range runs {
+		if r != current && r.Suite == current.Suite && r.Commit == baseCommit && !r.Dirty {
+			base = append(base, r)
+		}
+	}
+	return base
+}
+
+// CompareRuns compares every benchmark metric in current with the baseline runs.
+// Results are ordered with regressions first, then by name and unit.
+func CompareRuns(base []*Run, current *Run, alpha, thresholdPct float64) []Comparison {
+	baseSamples := make(map[string]map[string][]float64)
+	for _, r := range base {
+		for name, byUnit := range r.Samples() {
+			if baseSamples[name] == nil {
+				baseSamples[name] = make(map[string][]float64)
+			}
+			for unit, vs := range byUnit {
+				baseSamples[name][unit] = append(baseSamples[name][unit], vs...)
+			}
+		}
+	}
+
+	var comparisons []Comparison
+	for name, byUnit := range current.Samples() {
+		for unit, cur := range byUnit {
+			baseVals := baseSamples[name][unit]
+			if len(baseVals) == 0 {
+				continue
+			}
+			comparisons = append(comparisons, Compare(name, unit, baseVals, cur, alpha, thresholdPct))
+		}
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		a, b := comparisons[i], comparisons[j]
+		if a.Regression != b.Regression {
+			return a.Regression
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Unit < b.Unit
+	})
+	return comparisons
+}
+
+// ResolveCommit resolves a git ref such as "main" or "HEAD~1" to a commit hash.
+func ResolveCommit(projectPath, ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", ref+"^{commit}")
+	cmd.Dir = projectPath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown baseline ref %q", ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
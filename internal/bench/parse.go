@@ -0,0 +1,91 @@
+// Package bench records benchmark results per commit and detects
+// statistically significant regressions against a baseline.
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Result is one benchmark measurement: a single iteration of `go test -bench`
+// (one line per -count) or one JSON line from a custom script.
+type Result struct {
+	Name    string             `json:"name"`
+	Metrics map[string]float64 `json:"metrics"` // Unit -> value, e.g. "ns/op": 1234
+}
+
+var (
+	goBenchLine = regexp.MustCompile(`^(Benchmark\S+)\s+(\d+)\s+(.+)$`)
+	goProcs     = regexp.MustCompile(`-\d+$`)
+)
+
+// ParseOutput extracts benchmark results from output. It understands the
+// standard `go test -bench` format and JSON lines of the form
+// {"name": "render", "value": 12.5, "unit": "ms"} for custom scripts.
+// Go benchmark names are prefixed with their package's last path element
+// and lose the GOMAXPROCS suffix so series survive machine changes.
+func ParseOutput(output string) []Result {
+	var (
+		results []Result
+		pkg     string
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if rest, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(rest)
+			continue
+		}
+
+		if strings.HasPrefix(line, "{") {
+			var custom struct {
+				Name  string   `json:"name"`
+				Value *float64 `json:"value"`
+				Unit  string   `json:"unit"`
+			}
+			if err := json.Unmarshal([]byte(line), &custom); err == nil && custom.Name != "" && custom.Value != nil {
+				unit := custom.Unit
+				if unit == "" {
+					unit = "value"
+				}
+				results = append(results, Result{Name: custom.Name, Metrics: map[string]float64{unit: *custom.Value}})
+			}
+			continue
+		}
+
+		m := goBenchLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		fields := strings.Fields(m[3])
+		metrics := make(map[string]float64)
+		for i := 0; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			metrics[fields[i+1]] = v
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		name := goProcs.ReplaceAllString(m[1], "")
+		if pkg != "" {
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+		}
+		results = append(results, Result{Name: name, Metrics: metrics})
+	}
+	return results
+}
+
+// HigherIsBetter reports whether larger values of unit are improvements,
+// as for throughput units such as MB/s.
+func HigherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
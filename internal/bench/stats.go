@@ -0,0 +1,186 @@
+package bench
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultAlpha is the significance level for regression detection.
+	DefaultAlpha = 0.05
+
+	// DefaultThreshold is the minimum relative slowdown, in percent, reported
+	// as a regression even when statistically significant.
+	DefaultThreshold = 5.0
+
+	// exactLimit is the largest combined sample size using the exact
+	// Mann-Whitney distribution; larger samples use the normal approximation.
+	exactLimit = 50
+)
+
+// Comparison is the change of one benchmark metric between a baseline and
+// the current samples.
+type Comparison struct {
+	Name        string  `json:"name"`
+	Unit        string  `json:"unit"`
+	BaseMean    float64 `json:"base_mean"`
+	CurMean     float64 `json:"cur_mean"`
+	BaseN       int     `json:"base_n"`
+	CurN        int     `json:"cur_n"`
+	DeltaPct    float64 `json:"delta_pct"` // Positive means the value grew
+	P           float64 `json:"p"`
+	Significant bool    `json:"significant"`
+	Regression  bool    `json:"regression"`
+	Improvement bool    `json:"improvement"`
+}
+
+// Compare compares current samples with baseline samples using a two-sided
+// Mann-Whitney U test. A change is a regression when it is significant at
+// alpha, moves in the worse direction for the unit and exceeds thresholdPct.
+func Compare(name, unit string, base, cur []float64, alpha, thresholdPct float64) Comparison {
+	c := Comparison{
+		Name:     name,
+		Unit:     unit,
+		BaseMean: mean(base),
+		CurMean:  mean(cur),
+		BaseN:    len(base),
+		CurN:     len(cur),
+		P:        1,
+	}
+	if len(base) == 0 || len(cur) == 0 {
+		return c
+	}
+	if c.BaseMean != 0 {
+		c.DeltaPct = (c.CurMean - c.BaseMean) / math.Abs(c.BaseMean) * 100
+	}
+	c.P = MannWhitneyP(base, cur)
+	c.Significant = c.P < alpha
+
+	if c.Significant && math.Abs(c.DeltaPct) >= thresholdPct {
+		worse := c.DeltaPct > 0
+		if HigherIsBetter(unit) {
+			worse = !worse
+		}
+		c.Regression = worse
+		c.Improvement = !worse
+	}
+	return c
+}
+
+// MannWhitneyP returns the two-sided p-value of the Mann-Whitney U test for
+// samples x and y. Small samples without ties use the exact distribution.
+func MannWhitneyP(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type obs struct {
+		v     float64
+		fromX bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range y {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Midranks for ties, plus the tie correction term
+	ranks := make([]float64, len(all))
+	tieTerm := 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			ranks[k] = rank
+		}
+		if t := float64(j - i); t > 1 {
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+
+	rankSumX := 0.0
+	for i, o := range all {
+		if o.fromX {
+			rankSumX += ranks[i]
+		}
+	}
+	u := rankSumX - float64(n1*(n1+1))/2
+	if alt := float64(n1*n2) - u; alt < u {
+		u = alt
+	}
+
+	if tieTerm == 0 && n1+n2 <= exactLimit {
+		p := 2 * exactUCDF(n1, n2, int(u))
+		return math.Min(p, 1)
+	}
+
+	// Normal approximation with tie and continuity corrections
+	n := float64(n1 + n2)
+	meanU := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (u - meanU + 0.5) / math.Sqrt(variance)
+	return math.Min(2*normalCDF(z), 1)
+}
+
+// exactUCDF returns P(U <= u) under the null hypothesis for sample sizes n1, n2.
+func exactUCDF(n1, n2, u int) float64 {
+	// counts[i][j][k]: arrangements of i x's and j y's with U = k, built up
+	// one sample size at a time using f(i,j,k) = f(i-1,j,k-j) + f(i,j-1,k)
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1 // i = 0
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		for j := 0; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				if j > 0 {
+					cur[j][k] += cur[j-1][k]
+				}
+			}
+		}
+		prev = cur
+	}
+
+	dist := prev[n2]
+	total, below := 0.0, 0.0
+	for k, c := range dist {
+		total += c
+		if k <= u {
+			below += c
+		}
+	}
+	return below / total
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
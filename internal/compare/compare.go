@@ -0,0 +1,255 @@
+// Package compare extracts structured results — tests, diagnostics, bundle
+// sizes and benchmarks — from the output of a script, and diffs the results
+// of two runs of the same script against different working states.
+package compare
+
+import (
+	"math"
+	"sort"
+
+	"github.com/standardbeagle/agnt/internal/bench"
+	"github.com/standardbeagle/agnt/internal/testhistory"
+)
+
+// Verdicts of a Diff.
+const (
+	VerdictBetter = "better"
+	VerdictWorse  = "worse"
+	VerdictMixed  = "mixed"
+	VerdictSame   = "same"
+)
+
+const (
+	// maxFailing is the failing tests listed per result.
+	maxFailing = 50
+	// bundleChangePct is the relative bundle size change counted as better
+	// or worse.
+	bundleChangePct = 1.0
+)
+
+// Result is what one run of a script produced.
+type Result struct {
+	ExitCode    int                `json:"exit_code"`
+	DurationMs  int64              `json:"duration_ms"`
+	Tests       *TestSummary       `json:"tests,omitempty"`
+	Diagnostics *DiagnosticSummary `json:"diagnostics,omitempty"`
+	Bundle      []Asset            `json:"bundle,omitempty"`
+	BundleBytes int64              `json:"bundle_bytes,omitempty"`
+	Benchmarks  []bench.Result     `json:"benchmarks,omitempty"`
+}
+
+// TestSummary counts test outcomes.
+type TestSummary struct {
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+	Failing []string `json:"failing,omitempty"` // Test IDs, up to maxFailing
+}
+
+// Collect extracts the structured results from a script's output.
+func Collect(output string, exitCode int, durationMs int64) *Result {
+	r := &Result{ExitCode: exitCode, DurationMs: durationMs}
+
+	if tests := testhistory.ParseOutput(output); len(tests) > 0 {
+		r.Tests = &TestSummary{}
+		for _, t := range tests {
+			switch t.Outcome {
+			case testhistory.OutcomePass:
+				r.Tests.Passed++
+			case testhistory.OutcomeFail:
+				r.Tests.Failed++
+				if len(r.Tests.Failing) < maxFailing {
+					r.Tests.Failing = append(r.Tests.Failing, t.ID())
+				}
+			case testhistory.OutcomeSkip:
+				r.Tests.Skipped++
+			}
+		}
+	}
+	if diags := ParseDiagnostics(output); len(diags) > 0 {
+		r.Diagnostics = summarizeDiagnostics(diags)
+	}
+	r.SetBundle(ParseBundle(output))
+	r.Benchmarks = bench.ParseOutput(output)
+	return r
+}
+
+// SetBundle replaces the bundle assets and their total size.
+func (r *Result) SetBundle(assets []Asset) {
+	r.Bundle, r.BundleBytes = assets, 0
+	for _, a := range assets {
+		r.BundleBytes += a.Bytes
+	}
+}
+
+// Diff is the change from a base result to the current one.
+type Diff struct {
+	Verdict          string             `json:"verdict"`
+	ExitCodeChanged  bool               `json:"exit_code_changed,omitempty"`
+	DurationDeltaMs  int64              `json:"duration_delta_ms"`
+	NewFailures      []string           `json:"new_failures,omitempty"` // Failing now, not in the base
+	Fixed            []string           `json:"fixed,omitempty"`        // Failing in the base, not now
+	PassedDelta      int                `json:"passed_delta,omitempty"`
+	ErrorsDelta      int                `json:"errors_delta,omitempty"`
+	WarningsDelta    int                `json:"warnings_delta,omitempty"`
+	NewDiagnostics   []Diagnostic       `json:"new_diagnostics,omitempty"`
+	BundleBytesDelta int64              `json:"bundle_bytes_delta,omitempty"`
+	BundleDeltaPct   float64            `json:"bundle_delta_pct,omitempty"`
+	Assets           []AssetDelta       `json:"assets,omitempty"` // Assets whose size changed
+	Benchmarks       []bench.Comparison `json:"benchmarks,omitempty"`
+}
+
+// AssetDelta is the size change of one bundle asset; a zero size means the
+// asset is missing on that side.
+type AssetDelta struct {
+	Name    string `json:"name"`
+	Base    int64  `json:"base"`
+	Current int64  `json:"current"`
+	Delta   int64  `json:"delta"`
+}
+
+// Compare diffs the current result against the base.
+func Compare(base, cur *Result) *Diff {
+	d := &Diff{
+		ExitCodeChanged: base.ExitCode != cur.ExitCode,
+		DurationDeltaMs: cur.DurationMs - base.DurationMs,
+	}
+	better, worse := false, false
+	if d.ExitCodeChanged {
+		if cur.ExitCode == 0 {
+			better = true
+		} else if base.ExitCode == 0 {
+			worse = true
+		}
+	}
+
+	baseTests, curTests := testsOrEmpty(base.Tests), testsOrEmpty(cur.Tests)
+	d.NewFailures = missingFrom(curTests.Failing, baseTests.Failing)
+	d.Fixed = missingFrom(baseTests.Failing, curTests.Failing)
+	d.PassedDelta = curTests.Passed - baseTests.Passed
+	worse = worse || len(d.NewFailures) > 0
+	better = better || len(d.Fixed) > 0
+
+	baseDiags, curDiags := diagnosticsOrEmpty(base.Diagnostics), diagnosticsOrEmpty(cur.Diagnostics)
+	d.ErrorsDelta = curDiags.Errors - baseDiags.Errors
+	d.WarningsDelta = curDiags.Warnings - baseDiags.Warnings
+	d.NewDiagnostics = newDiagnostics(baseDiags.Items, curDiags.Items)
+	worse = worse || d.ErrorsDelta > 0
+	better = better || d.ErrorsDelta < 0
+
+	d.BundleBytesDelta = cur.BundleBytes - base.BundleBytes
+	if base.BundleBytes > 0 {
+		d.BundleDeltaPct = math.Round(float64(d.BundleBytesDelta)/float64(base.BundleBytes)*1000) / 10
+		worse = worse || d.BundleDeltaPct >= bundleChangePct
+		better = better || d.BundleDeltaPct <= -bundleChangePct
+	}
+	d.Assets = assetDeltas(base.Bundle, cur.Bundle)
+
+	if len(base.Benchmarks) > 0 && len(cur.Benchmarks) > 0 {
+		d.Benchmarks = bench.CompareRuns(
+			[]*bench.Run{{Results: base.Benchmarks}},
+			&bench.Run{Results: cur.Benchmarks},
+			bench.DefaultAlpha, bench.DefaultThreshold)
+		for _, c := range d.Benchmarks {
+			worse = worse || c.Regression
+			better = better || c.Improvement
+		}
+	}
+
+	switch {
+	case better && worse:
+		d.Verdict = VerdictMixed
+	case worse:
+		d.Verdict = VerdictWorse
+	case better:
+		d.Verdict = VerdictBetter
+	default:
+		d.Verdict = VerdictSame
+	}
+	return d
+}
+
+func testsOrEmpty(t *TestSummary) *TestSummary {
+	if t == nil {
+		return &TestSummary{}
+	}
+	return t
+}
+
+func diagnosticsOrEmpty(s *DiagnosticSummary) *DiagnosticSummary {
+	if s == nil {
+		return &DiagnosticSummary{}
+	}
+	return s
+}
+
+// missingFrom returns the entries of a that are not in b.
+func missingFrom(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !in[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// newDiagnostics returns the current diagnostics without a counterpart in
+// the base. Lines are ignored so diagnostics survive edits above them.
+func newDiagnostics(base, cur []Diagnostic) []Diagnostic {
+	seen := make(map[string]int, len(base))
+	for _, d := range base {
+		seen[d.key()]++
+	}
+	var out []Diagnostic
+	for _, d := range cur {
+		if seen[d.key()] > 0 {
+			seen[d.key()]--
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// assetDeltas pairs assets by name and returns those whose size changed,
+// largest change first.
+func assetDeltas(base, cur []Asset) []AssetDelta {
+	sizes := make(map[string]*AssetDelta)
+	get := func(name string) *AssetDelta {
+		if sizes[name] == nil {
+			sizes[name] = &AssetDelta{Name: name}
+		}
+		return sizes[name]
+	}
+	for _, a := range base {
+		get(a.Name).Base += a.Bytes
+	}
+	for _, a := range cur {
+		get(a.Name).Current += a.Bytes
+	}
+	var out []AssetDelta
+	for _, a := range sizes {
+		if a.Delta = a.Current - a.Base; a.Delta != 0 {
+			out = append(out, *a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if di, dj := abs(out[i].Delta), abs(out[j].Delta); di != dj {
+			return di > dj
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,122 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	output := "src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.\n" +
+		"src/util.ts:3:1 - warning TS6133: 'x' is declared but never used.\n" +
+		"\n" +
+		"/home/dev/app/src/index.js\n" +
+		"  4:7   error    'unused' is assigned a value but never used  no-unused-vars\n" +
+		"  9:1   warning  Unexpected console statement                  no-console\n" +
+		"\n" +
+		"✖ 2 problems (1 error, 1 warning)\n" +
+		"main.go:7:2: declared and not used: y\n" +
+		"--- FAIL: TestX (0.00s)\n" +
+		"    x_test.go:12: expected 1\n"
+
+	diags := ParseDiagnostics(output)
+	if len(diags) != 5 {
+		t.Fatalf("Expected 5 diagnostics, got %d: %+v", len(diags), diags)
+	}
+	want := []Diagnostic{
+		{File: "src/app.ts", Line: 12, Column: 5, Severity: "error", Code: "TS2322", Message: "Type 'string' is not assignable to type 'number'."},
+		{File: "src/util.ts", Line: 3, Column: 1, Severity: "warning", Code: "TS6133", Message: "'x' is declared but never used."},
+		{File: "/home/dev/app/src/index.js", Line: 4, Column: 7, Severity: "error", Code: "no-unused-vars", Message: "'unused' is assigned a value but never used"},
+		{File: "/home/dev/app/src/index.js", Line: 9, Column: 1, Severity: "warning", Code: "no-console", Message: "Unexpected console statement"},
+		{File: "main.go", Line: 7, Column: 2, Severity: "error", Message: "declared and not used: y"},
+	}
+	for i, w := range want {
+		if diags[i] != w {
+			t.Errorf("Diagnostic %d: expected %+v, got %+v", i, w, diags[i])
+		}
+	}
+}
+
+func TestParseBundle(t *testing.T) {
+	output := "vite v5.0.0 building for production...\n" +
+		"dist/index.html                   0.46 kB │ gzip:  0.30 kB\n" +
+		"dist/assets/index-BxT3kq9a.css    1.50 kB │ gzip:  0.70 kB\n" +
+		"dist/assets/index-Dk29fa0Q.js   143.21 kB │ gzip: 46.11 kB\n" +
+		"asset vendor.3f2a1b4c.js 1 KiB [emitted] [minimized]\n"
+
+	assets := ParseBundle(output)
+	want := []Asset{
+		{Name: "dist/index.html", Bytes: 460},
+		{Name: "dist/assets/index.css", Bytes: 1500},
+		{Name: "dist/assets/index.js", Bytes: 143210},
+		{Name: "vendor.js", Bytes: 1024},
+	}
+	if len(assets) != len(want) {
+		t.Fatalf("Expected %d assets, got %+v", len(want), assets)
+	}
+	for i, w := range want {
+		if assets[i] != w {
+			t.Errorf("Asset %d: expected %+v, got %+v", i, w, assets[i])
+		}
+	}
+
+	if got := assetName("component-library.js"); got != "component-library.js" {
+		t.Errorf("Expected names without a hash kept, got %q", got)
+	}
+}
+
+func TestMeasureDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "assets"), 0755)
+	os.WriteFile(filepath.Join(dir, "assets", "app-a1b2c3d4.js"), make([]byte, 300), 0644)
+	os.WriteFile(filepath.Join(dir, "assets", "app-a1b2c3d4.js.map"), make([]byte, 900), 0644)
+
+	assets, err := MeasureDir(dir)
+	if err != nil {
+		t.Fatalf("MeasureDir failed: %v", err)
+	}
+	if len(assets) != 1 || assets[0] != (Asset{Name: "assets/app.js", Bytes: 300}) {
+		t.Errorf("Expected only the hashed script, got %+v", assets)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := Collect("--- PASS: TestA (0.00s)\n--- FAIL: TestB (0.00s)\n--- PASS: TestC (0.00s)\nFAIL\tapp\t0.01s\n"+
+		"a.go:1:1: old problem\n", 1, 1000)
+	base.SetBundle([]Asset{{Name: "app.js", Bytes: 1000}})
+	cur := Collect("--- PASS: TestA (0.00s)\n--- PASS: TestB (0.00s)\n--- FAIL: TestC (0.00s)\nFAIL\tapp\t0.01s\n"+
+		"a.go:5:1: old problem\nb.go:2:3: new problem\n", 1, 800)
+	cur.SetBundle([]Asset{{Name: "app.js", Bytes: 1000}, {Name: "chunk.js", Bytes: 200}})
+
+	if cur.Tests == nil || cur.Tests.Passed != 2 || cur.Tests.Failed != 1 {
+		t.Fatalf("Unexpected test summary %+v", cur.Tests)
+	}
+
+	d := Compare(base, cur)
+	if d.Verdict != VerdictMixed {
+		t.Errorf("Expected a mixed verdict, got %q", d.Verdict)
+	}
+	if len(d.NewFailures) != 1 || d.NewFailures[0] != "app::TestC" {
+		t.Errorf("Expected TestC newly failing, got %v", d.NewFailures)
+	}
+	if len(d.Fixed) != 1 || d.Fixed[0] != "app::TestB" {
+		t.Errorf("Expected TestB fixed, got %v", d.Fixed)
+	}
+	if d.ErrorsDelta != 1 || len(d.NewDiagnostics) != 1 || d.NewDiagnostics[0].File != "b.go" {
+		t.Errorf("Expected only the b.go diagnostic new despite the moved line, got %d %+v", d.ErrorsDelta, d.NewDiagnostics)
+	}
+	if d.BundleBytesDelta != 200 || d.BundleDeltaPct != 20 || len(d.Assets) != 1 || d.Assets[0].Name != "chunk.js" {
+		t.Errorf("Unexpected bundle diff %d %v %+v", d.BundleBytesDelta, d.BundleDeltaPct, d.Assets)
+	}
+	if d.DurationDeltaMs != -200 {
+		t.Errorf("Expected -200ms, got %d", d.DurationDeltaMs)
+	}
+
+	if same := Compare(base, base); same.Verdict != VerdictSame {
+		t.Errorf("Expected same verdict comparing a result with itself, got %q", same.Verdict)
+	}
+	fixed := Compare(&Result{ExitCode: 1}, &Result{ExitCode: 0})
+	if fixed.Verdict != VerdictBetter || !fixed.ExitCodeChanged {
+		t.Errorf("Expected better when the script starts succeeding, got %+v", fixed)
+	}
+}
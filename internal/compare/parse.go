@@ -0,0 +1,202 @@
+package compare
+
+import (
+	"bufio"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDiagnostics is the diagnostics listed per result.
+const maxDiagnostics = 50
+
+// Diagnostic is a compiler or linter message about a source location.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // error or warning
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+}
+
+// key identifies a diagnostic regardless of its position in the file.
+func (d Diagnostic) key() string {
+	return d.File + "\x00" + d.Severity + "\x00" + d.Code + "\x00" + d.Message
+}
+
+// DiagnosticSummary counts diagnostics by severity.
+type DiagnosticSummary struct {
+	Errors   int          `json:"errors"`
+	Warnings int          `json:"warnings"`
+	Items    []Diagnostic `json:"items,omitempty"` // Up to maxDiagnostics
+}
+
+func summarizeDiagnostics(diags []Diagnostic) *DiagnosticSummary {
+	s := &DiagnosticSummary{}
+	for _, d := range diags {
+		if d.Severity == "warning" {
+			s.Warnings++
+		} else {
+			s.Errors++
+		}
+		if len(s.Items) < maxDiagnostics {
+			s.Items = append(s.Items, d)
+		}
+	}
+	return s
+}
+
+var (
+	tscDiagnostic       = regexp.MustCompile(`^(\S.*?)\((\d+),(\d+)\): (error|warning) (TS\d+): (.*)$`)
+	tscPrettyDiagnostic = regexp.MustCompile(`^(\S.*?):(\d+):(\d+) - (error|warning) (TS\d+): (.*)$`)
+	eslintFile          = regexp.MustCompile(`^(/|[A-Za-z]:\\|\./)?\S.*\.\w+$`)
+	eslintDiagnostic    = regexp.MustCompile(`^\s+(\d+):(\d+)\s+(error|warning)\s+(.+?)(?:\s{2,}(\S+))?$`)
+	compilerDiagnostic  = regexp.MustCompile(`^(\S+\.\w+):(\d+):(\d+): (?:(error|warning|fatal error): )?(.*)$`)
+)
+
+// ParseDiagnostics extracts compiler and linter messages from output. It
+// understands tsc (plain and pretty), ESLint's stylish reporter, and the
+// file:line:col: message form of go build/vet, gcc, clang and most linters.
+func ParseDiagnostics(output string) []Diagnostic {
+	var (
+		diags      []Diagnostic
+		eslintPath string
+	)
+	scanner := bufio.NewScanner(strings.NewReader(stripANSI(output)))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := tscDiagnostic.FindStringSubmatch(line); m != nil {
+			diags = append(diags, newDiagnostic(m[1], m[2], m[3], m[4], m[5], m[6]))
+			continue
+		}
+		if m := tscPrettyDiagnostic.FindStringSubmatch(line); m != nil {
+			diags = append(diags, newDiagnostic(m[1], m[2], m[3], m[4], m[5], m[6]))
+			continue
+		}
+		if eslintPath != "" {
+			if m := eslintDiagnostic.FindStringSubmatch(line); m != nil {
+				diags = append(diags, newDiagnostic(eslintPath, m[1], m[2], m[3], m[5], m[4]))
+				continue
+			}
+		}
+		if m := compilerDiagnostic.FindStringSubmatch(line); m != nil {
+			severity := m[4]
+			if severity != "warning" {
+				severity = "error"
+			}
+			diags = append(diags, newDiagnostic(m[1], m[2], m[3], severity, "", m[5]))
+			eslintPath = ""
+			continue
+		}
+		switch {
+		case eslintFile.MatchString(line):
+			eslintPath = line
+		case strings.TrimSpace(line) == "":
+			eslintPath = ""
+		}
+	}
+	return diags
+}
+
+func newDiagnostic(file, line, col, severity, code, message string) Diagnostic {
+	l, _ := strconv.Atoi(line)
+	c, _ := strconv.Atoi(col)
+	return Diagnostic{File: file, Line: l, Column: c, Severity: severity, Code: code, Message: strings.TrimSpace(message)}
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// Asset is one output file of a build.
+type Asset struct {
+	Name  string `json:"name"` // Path with content hashes removed
+	Bytes int64  `json:"bytes"`
+}
+
+var (
+	// asset main.js 1.2 MiB [emitted] (webpack 5)
+	webpackAsset = regexp.MustCompile(`^\s*asset (\S+) ([\d.]+) (bytes|KiB|MiB|GiB)\b`)
+	// dist/assets/index-BxT3kq9a.js   143.21 kB │ gzip: 46.11 kB (Vite, esbuild, Rollup)
+	sizedAsset = regexp.MustCompile(`^\s*(\S+\.(?:js|mjs|cjs|css|html|wasm))\s+([\d.,]+)\s?(B|b|bytes|kB|kb|KB|KiB|MB|mb|MiB)\b`)
+	// A content hash segment: index-BxT3kq9a.js, main.3f2a1b4c.js
+	assetHash = regexp.MustCompile(`[-.]([A-Za-z0-9_]{8,})(\.\w+)$`)
+	hasDigit  = regexp.MustCompile(`\d`)
+)
+
+var sizeUnits = map[string]float64{
+	"B": 1, "b": 1, "bytes": 1,
+	"kB": 1000, "kb": 1000, "KB": 1000, "KiB": 1024,
+	"MB": 1000 * 1000, "mb": 1000 * 1000, "MiB": 1024 * 1024,
+	"GiB": 1024 * 1024 * 1024,
+}
+
+// ParseBundle extracts the output files a bundler reports with their sizes.
+// It understands webpack 5 and the file-size tables of Vite, Rollup and
+// esbuild. Sizes are before compression.
+func ParseBundle(output string) []Asset {
+	var assets []Asset
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(stripANSI(output)))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := webpackAsset.FindStringSubmatch(line)
+		if m == nil {
+			m = sizedAsset.FindStringSubmatch(line)
+		}
+		if m == nil {
+			continue
+		}
+		size, err := strconv.ParseFloat(strings.ReplaceAll(m[2], ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		name := assetName(m[1])
+		if seen[name] {
+			continue // Rebuilds in watch mode repeat the table
+		}
+		seen[name] = true
+		assets = append(assets, Asset{Name: name, Bytes: int64(size * sizeUnits[m[3]])})
+	}
+	return assets
+}
+
+// MeasureDir lists the files under dir as assets, for builds that don't
+// report sizes. Source maps are left out.
+func MeasureDir(dir string) ([]Asset, error) {
+	var assets []Asset
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasSuffix(path, ".map") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		assets = append(assets, Asset{Name: assetName(filepath.ToSlash(rel)), Bytes: info.Size()})
+		return nil
+	})
+	return assets, err
+}
+
+// assetName removes the content hash from a file name so an asset can be
+// paired across builds.
+func assetName(name string) string {
+	m := assetHash.FindStringSubmatchIndex(name)
+	if m == nil || !hasDigit.MatchString(name[m[2]:m[3]]) {
+		return name
+	}
+	return name[:m[0]] + name[m[4]:m[5]]
+}
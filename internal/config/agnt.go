@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -30,6 +31,22 @@ type AgntConfig struct {
 
 	// Toast notification settings
 	Toast *ToastConfig `kdl:"toast"`
+
+	// Benchmarks to track with BENCH RUN
+	Benchmarks map[string]*BenchConfig `kdl:"benchmarks"`
+
+	// Remote runs the project's scripts on a remote checkout over ssh
+	Remote *RemoteConfig `kdl:"remote"`
+
+	// Stacks are named groups of scripts started in dependency order
+	Stacks map[string]*StackConfig `kdl:"stacks"`
+
+	// Doubles are stand-ins for third-party APIs, run with DOUBLE START
+	Doubles map[string]*DoubleConfig `kdl:"doubles"`
+
+	// GitHooks are the scripts each git hook runs through the daemon,
+	// installed with "agnt hooks install"
+	GitHooks map[string]*GitHookConfig `kdl:"git-hooks"`
 }
 
 // ScriptConfig defines a script to run.
@@ -41,12 +58,30 @@ type ScriptConfig struct {
 	URLMatchers []string          `kdl:"url-matchers"` // Patterns for URL detection: "local:{url}", "network:{url}"
 	Env         map[string]string `kdl:"env"`
 	Cwd         string            `kdl:"cwd"`
+
+	// Ports the script listens on; the first is freed before it starts and,
+	// without a ready-* check, is its ready check
+	Ports []int `kdl:"ports"`
+	// DependsOn names scripts that must be ready before this one starts on
+	// autostart; they are started too if they aren't autostarted
+	DependsOn []string `kdl:"depends-on"`
+	// ReadyURL is ready once it answers with a status below 400
+	ReadyURL string `kdl:"ready-url"`
+	// ReadyPort is ready once it accepts TCP connections on localhost
+	ReadyPort int `kdl:"ready-port"`
+	// ReadyLog is a regex, ready once it matches the script's output
+	ReadyLog string `kdl:"ready-log"`
+	// ReadyTimeoutMs bounds the wait for the ready check (default 60000)
+	ReadyTimeoutMs int `kdl:"ready-timeout-ms"`
 }
 
 // ProxyConfig defines a reverse proxy to start.
 type ProxyConfig struct {
 	// Autostart indicates whether to start on session open (only for fully-specified proxies)
 	Autostart bool `kdl:"autostart"`
+	// DependsOn names scripts that must be ready before the proxy is
+	// autostarted, so it doesn't come up before its dev server
+	DependsOn []string `kdl:"depends-on"`
 	// MaxLogSize is the max number of log entries to keep
 	MaxLogSize int `kdl:"max-log-size"`
 
@@ -62,11 +97,242 @@ type ProxyConfig struct {
 	// Host is the target host (default: localhost) - only used with Port
 	Host string `kdl:"host"`
 
+	// NoRetarget keeps the proxy on its target port when the dev server
+	// comes back on a different one (default: follow it)
+	NoRetarget bool `kdl:"no-retarget"`
+
+	// Routes maps request host patterns to other upstreams, for apps using
+	// subdomains: "*.localhost" "http://localhost:3000", "auth.localhost" "4000"
+	Routes map[string]string `kdl:"routes"`
+
+	// PathRoutes maps request path prefixes to other upstreams, for one
+	// proxy in front of several servers: "/api" "8000"
+	PathRoutes map[string]string `kdl:"path-routes"`
+
+	// Cookies adjusts upstream Set-Cookie headers for the proxy origin
+	Cookies *ProxyCookieConfig `kdl:"cookies"`
+
+	// URLRewrite controls rewriting of upstream URLs to the proxy origin
+	URLRewrite *ProxyURLRewriteConfig `kdl:"url-rewrite"`
+
+	// Storms tunes detection of repeated-request bursts (N+1, refetch loops)
+	Storms *ProxyStormConfig `kdl:"storms"`
+
+	// Banner draws an environment banner on proxied pages
+	Banner *ProxyBannerConfig `kdl:"banner"`
+
+	// BodyCapture sets what the traffic log keeps of bodies and headers
+	BodyCapture *ProxyBodyCaptureConfig `kdl:"body-capture"`
+
+	// PersistLogs keeps the traffic log on disk across daemon restarts
+	PersistLogs *ProxyPersistLogsConfig `kdl:"persist-logs"`
+
+	// TrustedProxies lists IPs/CIDRs allowed to set X-Forwarded-* headers
+	// (default: loopback, where tunnel agents connect from; "none" for none)
+	TrustedProxies []string `kdl:"trusted-proxies"`
+
 	// Legacy fields (deprecated)
 	// Target is the explicit target URL (use URL instead)
 	Target string `kdl:"target"`
 }
 
+// ProxyCookieConfig configures Set-Cookie rewriting for a proxy.
+type ProxyCookieConfig struct {
+	// Disabled passes cookies through unchanged
+	Disabled bool `kdl:"disabled"`
+	// Domains maps upstream cookie domains to replacements ("" removes Domain)
+	Domains map[string]string `kdl:"domains"`
+	// Secure is "auto" (default) or "keep"
+	Secure string `kdl:"secure"`
+	// SameSite is "auto" (default), "keep", "lax", "strict" or "none"
+	SameSite string `kdl:"same-site"`
+}
+
+// ProxyURLRewriteConfig configures rewriting of upstream URLs for a proxy.
+type ProxyURLRewriteConfig struct {
+	// NoBody leaves absolute URLs in HTML alone (Location is still rewritten)
+	NoBody bool `kdl:"no-body"`
+	// Exclude lists path prefixes ("/oauth/") or URL prefixes never rewritten
+	Exclude []string `kdl:"exclude"`
+}
+
+// ProxyStormConfig configures request storm detection for a proxy.
+type ProxyStormConfig struct {
+	// Disabled turns detection off
+	Disabled bool `kdl:"disabled"`
+	// Threshold is the requests to one endpoint within the window (default 20)
+	Threshold int `kdl:"threshold"`
+	// WindowMs is the detection window in milliseconds (default 5000)
+	WindowMs int `kdl:"window-ms"`
+	// Toast shows a warning in the page when a storm starts
+	Toast bool `kdl:"toast"`
+}
+
+// ProxyBannerConfig configures the environment banner of a proxy.
+type ProxyBannerConfig struct {
+	// Disabled keeps the banner off while leaving the block in place
+	Disabled bool `kdl:"disabled"`
+	// Label is the environment name (default: the proxy ID)
+	Label string `kdl:"label"`
+	// Position is top or bottom (default: bottom)
+	Position string `kdl:"position"`
+	// Color is the CSS background color
+	Color string `kdl:"color"`
+	// NoBranch hides the git branch
+	NoBranch bool `kdl:"no-branch"`
+}
+
+// ProxyBodyCaptureConfig configures body capture in a proxy's traffic log.
+type ProxyBodyCaptureConfig struct {
+	// Disabled keeps bodies out of the log
+	Disabled bool `kdl:"disabled"`
+	// MaxRequestBytes caps each captured request body (default 10240)
+	MaxRequestBytes int `kdl:"max-request-bytes"`
+	// MaxResponseBytes caps each captured response body (default 10240)
+	MaxResponseBytes int `kdl:"max-response-bytes"`
+	// ContentTypes lists media type prefixes whose bodies are captured
+	// (default: text, JSON, XML, form, JavaScript and GraphQL)
+	ContentTypes []string `kdl:"content-types"`
+	// RedactHeaders are masked on top of Authorization and Cookie headers
+	RedactHeaders []string `kdl:"redact-headers"`
+	// NoRedact logs credential headers as sent
+	NoRedact bool `kdl:"no-redact"`
+}
+
+// ProxyPersistLogsConfig configures the on-disk copy of a proxy's traffic
+// log. The block's presence turns it on.
+type ProxyPersistLogsConfig struct {
+	// Disabled keeps the log in memory only while leaving the block in place
+	Disabled bool `kdl:"disabled"`
+	// Dir holds the segment files (default: .agnt/logs/<proxy>)
+	Dir string `kdl:"dir"`
+	// SegmentBytes is the size at which a segment is rotated (default 4MB)
+	SegmentBytes int `kdl:"segment-bytes"`
+	// MaxSegments is how many segments are kept (default 8)
+	MaxSegments int `kdl:"max-segments"`
+	// MaxAgeHours deletes older segments (default: no limit)
+	MaxAgeHours int `kdl:"max-age-hours"`
+}
+
+// BenchConfig defines a benchmark suite tracked across commits.
+// Without run or command, Go benchmarks are run with go test -bench.
+type BenchConfig struct {
+	// Run is a shell command printing go test -bench lines or JSON lines
+	// like {"name": "render", "value": 12.5, "unit": "ms"}
+	Run     string   `kdl:"run"`
+	Command string   `kdl:"command"`
+	Args    []string `kdl:"args"`
+	// Package is the Go package pattern to benchmark (default "./...")
+	Package string `kdl:"package"`
+	// Bench is the -bench pattern (default ".")
+	Bench string `kdl:"bench"`
+	// Count is how many samples to take per benchmark (default 5)
+	Count int `kdl:"count"`
+	// Threshold is the minimum slowdown in percent reported as a regression (default 5)
+	Threshold float64 `kdl:"threshold"`
+	// Baseline is a git ref to compare against (default: the previous commit with results)
+	Baseline string `kdl:"baseline"`
+	Cwd      string `kdl:"cwd"`
+}
+
+// RemoteConfig points the project at a checkout on another machine. Scripts
+// run there over ssh, and the ports they listen on are forwarded back.
+type RemoteConfig struct {
+	// Host is an ssh destination: a ~/.ssh/config alias or user@host
+	Host string `kdl:"host"`
+	// Path is the checkout on the remote host
+	Path     string `kdl:"path"`
+	Port     int    `kdl:"port"`
+	Identity string `kdl:"identity"`
+	// Options are extra ssh -o options, e.g. "ProxyJump=bastion"
+	Options []string `kdl:"options"`
+}
+
+// StackConfig defines a group of scripts started and stopped as a unit.
+// Services start once the services they depend on are ready.
+type StackConfig struct {
+	Services map[string]*StackServiceConfig `kdl:"services"`
+	// Autostart starts the stack on session open, in place of the autostart
+	// of its scripts
+	Autostart bool `kdl:"autostart"`
+	// TimeoutMs bounds the wait for each service to become ready (default 60000)
+	TimeoutMs int `kdl:"timeout-ms"`
+}
+
+// StackServiceConfig defines one service of a stack. Without a ready-*
+// check, the script's is used; without either, a service is ready once it
+// has kept running for a second.
+type StackServiceConfig struct {
+	// Script is the script to run (default: the service name)
+	Script    string   `kdl:"script"`
+	DependsOn []string `kdl:"depends-on"`
+	// ReadyURL is ready once it answers with a status below 400
+	ReadyURL string `kdl:"ready-url"`
+	// ReadyPort is ready once it accepts TCP connections on localhost
+	ReadyPort int `kdl:"ready-port"`
+	// ReadyLog is a regex, ready once it matches the service's output
+	ReadyLog string `kdl:"ready-log"`
+}
+
+// DoubleConfig defines a stand-in for a third-party API. While it runs, its
+// env is added to the scripts the daemon starts for the project.
+type DoubleConfig struct {
+	// Profile is a built-in profile: stripe, auth0 or rest (default: the name)
+	Profile string `kdl:"profile"`
+	// ProfileFile is a JSON profile, relative to the project
+	ProfileFile string `kdl:"profile-file"`
+	// Port to listen on (default: any free port)
+	Port int `kdl:"port"`
+	// WebhookURL receives the profile's webhooks
+	WebhookURL string `kdl:"webhook-url"`
+	// LatencyMs overrides the profile's latency, e.g. "50-200"
+	LatencyMs string `kdl:"latency-ms"`
+	// Seed of IDs and latencies (default 1)
+	Seed int64 `kdl:"seed"`
+	// Env overrides or adds to the profile's env; {url} is the double's URL
+	Env map[string]string `kdl:"env"`
+	// Autostart starts the double on session open, before scripts
+	Autostart bool `kdl:"autostart"`
+}
+
+// GitHookConfig defines the scripts a git hook (pre-commit, pre-push) runs.
+// Scripts run in order as daemon-managed processes; the hook fails on the
+// first script that fails.
+type GitHookConfig struct {
+	Scripts []string `kdl:"scripts"`
+	// TimeoutMs bounds each script (default 300000)
+	TimeoutMs int `kdl:"timeout-ms"`
+}
+
+// GitHookNames are the hooks git runs (see githooks(5)). git-hooks keys
+// become file names in the hooks directory and words of the generated hook
+// script, so no other names are accepted.
+var GitHookNames = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch",
+	"pre-commit", "pre-merge-commit", "prepare-commit-msg", "commit-msg", "post-commit",
+	"pre-rebase", "post-checkout", "post-merge", "pre-push",
+	"pre-receive", "update", "proc-receive", "post-receive", "post-update",
+	"reference-transaction", "push-to-checkout", "pre-auto-gc", "post-rewrite",
+	"sendemail-validate", "fsmonitor-watchman",
+	"p4-changelist", "p4-prepare-changelist", "p4-post-changelist", "p4-pre-submit",
+	"post-index-change",
+}
+
+// IsGitHook reports whether name is one of GitHookNames.
+func IsGitHook(name string) bool {
+	return slices.Contains(GitHookNames, name)
+}
+
+// checkGitHooks rejects git-hooks keys that aren't git hooks.
+func checkGitHooks(cfg *AgntConfig) error {
+	for name := range cfg.GitHooks {
+		if !IsGitHook(name) {
+			return fmt.Errorf("git-hooks: %q is not a git hook", name)
+		}
+	}
+	return nil
+}
+
 // HooksConfig defines hook behavior.
 type HooksConfig struct {
 	// OnResponse controls what happens when Claude responds
@@ -96,8 +362,12 @@ type ToastConfig struct {
 // DefaultAgntConfig returns a config with sensible defaults.
 func DefaultAgntConfig() *AgntConfig {
 	return &AgntConfig{
-		Scripts: make(map[string]*ScriptConfig),
-		Proxies: make(map[string]*ProxyConfig),
+		Scripts:    make(map[string]*ScriptConfig),
+		Proxies:    make(map[string]*ProxyConfig),
+		Benchmarks: make(map[string]*BenchConfig),
+		Stacks:     make(map[string]*StackConfig),
+		Doubles:    make(map[string]*DoubleConfig),
+		GitHooks:   make(map[string]*GitHookConfig),
 		Hooks: &HooksConfig{
 			OnResponse: &ResponseHookConfig{
 				Toast:     true,
@@ -169,8 +439,11 @@ func ParseAgntConfig(data string) (*AgntConfig, error) {
 	// Try kdl-go first
 	if err := kdl.Unmarshal([]byte(data), cfg); err == nil {
 		// Check if we got anything useful
-		if len(cfg.Scripts) > 0 || len(cfg.Proxies) > 0 {
+		if len(cfg.Scripts) > 0 || len(cfg.Proxies) > 0 || len(cfg.Benchmarks) > 0 || cfg.Remote != nil || len(cfg.Stacks) > 0 || len(cfg.Doubles) > 0 || len(cfg.GitHooks) > 0 {
 			log.Printf("[DEBUG] ParseAgntConfig: kdl-go parsed %d scripts, %d proxies", len(cfg.Scripts), len(cfg.Proxies))
+			if err := checkGitHooks(cfg); err != nil {
+				return nil, err
+			}
 			return cfg, nil
 		}
 		log.Printf("[DEBUG] ParseAgntConfig: kdl-go succeeded but got empty config, falling back to simple parser")
@@ -347,6 +620,28 @@ func (c *AgntConfig) GetAutostartScripts() map[string]*ScriptConfig {
 	return result
 }
 
+// GetAutostartStacks returns stacks configured for autostart.
+func (c *AgntConfig) GetAutostartStacks() map[string]*StackConfig {
+	result := make(map[string]*StackConfig)
+	for name, stack := range c.Stacks {
+		if stack != nil && stack.Autostart {
+			result[name] = stack
+		}
+	}
+	return result
+}
+
+// GetAutostartDoubles returns doubles configured for autostart.
+func (c *AgntConfig) GetAutostartDoubles() map[string]*DoubleConfig {
+	result := make(map[string]*DoubleConfig)
+	for name, dbl := range c.Doubles {
+		if dbl != nil && dbl.Autostart {
+			result[name] = dbl
+		}
+	}
+	return result
+}
+
 // GetAutostartProxies returns proxies configured for autostart.
 func (c *AgntConfig) GetAutostartProxies() map[string]*ProxyConfig {
 	result := make(map[string]*ProxyConfig)
@@ -381,9 +676,18 @@ scripts {
     //     }
     // }
 
-    // Example: API server
+    // Example: API server the web server waits for
     // api {
-    //     run "go run ./cmd/server"
+    //     run "go run ./cmd/api"
+    //     ports 8080
+    //     ready-url "http://localhost:8080/health"
+    //     env { PORT "8080"; }
+    //     autostart true
+    // }
+    // web {
+    //     run "npm run dev"
+    //     depends-on "api"
+    //     ready-log "ready in"
     //     autostart true
     // }
 
@@ -405,9 +709,10 @@ scripts {
 
 // Reverse proxies to start
 proxies {
-    // Example: frontend proxy
+    // Example: frontend proxy, started once the web script is ready
     // frontend {
     //     target "http://localhost:3000"
+    //     depends-on "web"
     //     autostart true
     // }
 
@@ -420,6 +725,61 @@ proxies {
     // }
 }
 
+// Benchmarks tracked per commit with BENCH RUN
+benchmarks {
+    // Example: Go benchmarks in one package
+    // parser {
+    //     package "./internal/parser"
+    //     bench "BenchmarkParse"
+    //     count 10
+    //     threshold 3    // Report slowdowns of 3% or more
+    // }
+
+    // Example: custom script printing JSON lines {"name": "...", "value": 1.5, "unit": "ms"}
+    // render {
+    //     run "node scripts/bench-render.js"
+    //     baseline "main"
+    // }
+}
+
+// Groups of scripts started in dependency order, each once its
+// dependencies are ready
+stacks {
+    // dev {
+    //     autostart true    // Instead of autostarting the scripts one by one
+    //     services {
+    //         db { ready-port 5432; }
+    //         api { depends-on "db"; ready-url "http://localhost:8080/health"; }
+    //         web { depends-on "api"; ready-log "ready in"; }
+    //     }
+    // }
+}
+
+// Stand-ins for third-party APIs; their env (e.g. STRIPE_API_BASE) is
+// added to scripts while they run
+doubles {
+    // stripe {
+    //     webhook-url "http://localhost:3000/api/webhooks/stripe"
+    //     autostart true
+    // }
+    // github {
+    //     profile-file "doubles/github.json"
+    //     env { GITHUB_API_URL "{url}"; }
+    // }
+}
+
+// Scripts git hooks run through the daemon, so their output is kept as
+// processes and failures show up in the session (agnt hooks install)
+git-hooks {
+    // pre-commit {
+    //     scripts "lint" "typecheck"
+    // }
+    // pre-push {
+    //     scripts "test"
+    //     timeout-ms 600000
+    // }
+}
+
 // Hook configuration for notifications
 hooks {
     // What to do when Claude responds
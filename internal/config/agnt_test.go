@@ -206,6 +206,215 @@ func TestParseAgntConfigWithRun(t *testing.T) {
 	}
 }
 
+func TestParseAgntConfigWithBenchmarks(t *testing.T) {
+	input := `benchmarks {
+    parser {
+        package "./internal/parser"
+        bench "BenchmarkParse"
+        count 10
+        threshold 3
+    }
+    render {
+        run "node scripts/bench.js"
+        baseline "main"
+    }
+}`
+
+	cfg, err := ParseAgntConfig(input)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+
+	assert.Len(t, cfg.Benchmarks, 2, "should have 2 benchmarks")
+
+	parser, ok := cfg.Benchmarks["parser"]
+	assert.True(t, ok, "should have 'parser' benchmark")
+	if ok {
+		assert.Equal(t, "./internal/parser", parser.Package)
+		assert.Equal(t, "BenchmarkParse", parser.Bench)
+		assert.Equal(t, 10, parser.Count)
+		assert.Equal(t, 3.0, parser.Threshold)
+	}
+
+	render, ok := cfg.Benchmarks["render"]
+	assert.True(t, ok, "should have 'render' benchmark")
+	if ok {
+		assert.Equal(t, "node scripts/bench.js", render.Run)
+		assert.Equal(t, "main", render.Baseline)
+	}
+}
+
+func TestParseAgntConfigWithRemote(t *testing.T) {
+	input := `remote {
+    host "me@devbox"
+    path "/srv/app"
+    port 2222
+    options "ProxyJump=bastion" "Compression=yes"
+}
+scripts {
+    dev {
+        run "npm run dev"
+    }
+}`
+
+	cfg, err := ParseAgntConfig(input)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Remote)
+
+	assert.Equal(t, "me@devbox", cfg.Remote.Host)
+	assert.Equal(t, "/srv/app", cfg.Remote.Path)
+	assert.Equal(t, 2222, cfg.Remote.Port)
+	assert.Equal(t, []string{"ProxyJump=bastion", "Compression=yes"}, cfg.Remote.Options)
+	assert.Contains(t, cfg.Scripts, "dev")
+}
+
+func TestParseAgntConfigWithStacks(t *testing.T) {
+	input := `scripts {
+    db {
+        run "docker compose up db"
+    }
+    api {
+        run "go run ./cmd/api"
+    }
+}
+stacks {
+    dev {
+        autostart true
+        timeout-ms 30000
+        services {
+            db {
+                ready-port 5432
+            }
+            api {
+                depends-on "db"
+                ready-url "http://localhost:8080/health"
+            }
+        }
+    }
+}`
+
+	cfg, err := ParseAgntConfig(input)
+	require.NoError(t, err)
+	require.Contains(t, cfg.Stacks, "dev")
+
+	dev := cfg.Stacks["dev"]
+	assert.True(t, dev.Autostart)
+	assert.Equal(t, 30000, dev.TimeoutMs)
+	require.Contains(t, dev.Services, "api")
+	assert.Equal(t, []string{"db"}, dev.Services["api"].DependsOn)
+	assert.Equal(t, "http://localhost:8080/health", dev.Services["api"].ReadyURL)
+	assert.Equal(t, 5432, dev.Services["db"].ReadyPort)
+	assert.Contains(t, cfg.GetAutostartStacks(), "dev")
+}
+
+func TestParseAgntConfigWithReadiness(t *testing.T) {
+	input := `scripts {
+    api {
+        run "go run ./cmd/api"
+        ports 8080 8081
+        ready-url "http://localhost:8080/health"
+        ready-timeout-ms 30000
+        env {
+            PORT "8080"
+        }
+        autostart true
+    }
+    web {
+        run "npm run dev"
+        depends-on "api"
+        ready-log "ready in"
+        autostart true
+    }
+}
+proxies {
+    frontend {
+        port 3000
+        depends-on "web"
+        autostart true
+    }
+}`
+
+	cfg, err := ParseAgntConfig(input)
+	require.NoError(t, err)
+	require.Contains(t, cfg.Scripts, "api")
+	require.Contains(t, cfg.Scripts, "web")
+
+	api := cfg.Scripts["api"]
+	assert.Equal(t, []int{8080, 8081}, api.Ports)
+	assert.Equal(t, "http://localhost:8080/health", api.ReadyURL)
+	assert.Equal(t, 30000, api.ReadyTimeoutMs)
+	assert.Equal(t, "8080", api.Env["PORT"])
+	assert.Equal(t, []string{"api"}, cfg.Scripts["web"].DependsOn)
+	assert.Equal(t, "ready in", cfg.Scripts["web"].ReadyLog)
+	require.Contains(t, cfg.Proxies, "frontend")
+	assert.Equal(t, []string{"web"}, cfg.Proxies["frontend"].DependsOn)
+}
+
+func TestParseAgntConfigWithGitHooks(t *testing.T) {
+	input := `git-hooks {
+    pre-commit {
+        scripts "lint" "typecheck"
+    }
+    pre-push {
+        scripts "test"
+        timeout-ms 600000
+    }
+}`
+
+	cfg, err := ParseAgntConfig(input)
+	require.NoError(t, err)
+	require.Contains(t, cfg.GitHooks, "pre-commit")
+	require.Contains(t, cfg.GitHooks, "pre-push")
+	assert.Equal(t, []string{"lint", "typecheck"}, cfg.GitHooks["pre-commit"].Scripts)
+	assert.Equal(t, 0, cfg.GitHooks["pre-commit"].TimeoutMs)
+	assert.Equal(t, []string{"test"}, cfg.GitHooks["pre-push"].Scripts)
+	assert.Equal(t, 600000, cfg.GitHooks["pre-push"].TimeoutMs)
+}
+
+func TestParseAgntConfigRejectsUnknownGitHooks(t *testing.T) {
+	for _, name := range []string{`"../../bin/x"`, `"pre-commit;rm"`, "not-a-hook"} {
+		input := "git-hooks {\n    " + name + " {\n        scripts \"lint\"\n    }\n}"
+		_, err := ParseAgntConfig(input)
+		assert.Error(t, err, name)
+	}
+	assert.True(t, IsGitHook("commit-msg"))
+	assert.False(t, IsGitHook("../pre-commit"))
+}
+
+func TestValidateAgntConfig_UnknownGitHook(t *testing.T) {
+	v := ValidateAgntConfig("git-hooks {\n    pre-commit { scripts \"lint\"; }\n    \"../evil\" { scripts \"lint\"; }\n}\n")
+	require.True(t, v.HasErrors())
+	assert.Equal(t, 3, v.Issues[0].Line)
+	assert.Contains(t, v.Issues[0].Message, "is not a git hook")
+}
+
+func TestParseAgntConfigWithDoubles(t *testing.T) {
+	input := `doubles {
+    stripe {
+        webhook-url "http://localhost:3000/api/webhooks"
+        latency-ms "0-10"
+        autostart true
+    }
+    github {
+        profile-file "doubles/github.json"
+        port 12112
+        env {
+            GITHUB_API_URL "{url}"
+        }
+    }
+}`
+
+	cfg, err := ParseAgntConfig(input)
+	require.NoError(t, err)
+	require.Contains(t, cfg.Doubles, "stripe")
+	require.Contains(t, cfg.Doubles, "github")
+
+	assert.True(t, cfg.Doubles["stripe"].Autostart)
+	assert.Equal(t, "0-10", cfg.Doubles["stripe"].LatencyMs)
+	assert.Equal(t, "doubles/github.json", cfg.Doubles["github"].ProfileFile)
+	assert.Equal(t, 12112, cfg.Doubles["github"].Port)
+	assert.Equal(t, "{url}", cfg.Doubles["github"].Env["GITHUB_API_URL"])
+}
+
 func TestFindAgntConfigFile(t *testing.T) {
 	// Create temp directory with nested subdirectory
 	tmpDir := t.TempDir()
@@ -78,10 +78,25 @@ func DefaultConfig() *Config {
 				Commands:             nodeCommandsToConfig(project.DefaultNodeCommands("npm")),
 			},
 			"python": {
-				Markers:  []string{"pyproject.toml", "setup.py", "requirements.txt"},
-				Priority: 80,
+				Markers:  []string{"pyproject.toml", "setup.py", "requirements.txt", "Pipfile"},
+				Priority: 60,
 				Commands: pythonCommandsToConfig(project.DefaultPythonCommands()),
 			},
+			"rust": {
+				Markers:  []string{"Cargo.toml"},
+				Priority: 85,
+				Commands: goCommandsToConfig(project.DefaultRustCommands()),
+			},
+			"java": {
+				Markers:  []string{"build.gradle.kts", "build.gradle", "settings.gradle.kts", "settings.gradle", "pom.xml"},
+				Priority: 80,
+				Commands: goCommandsToConfig(project.DefaultGradleCommands("gradle", false)),
+			},
+			"dotnet": {
+				Markers:  []string{"*.sln", "*.csproj", "*.fsproj"},
+				Priority: 70,
+				Commands: goCommandsToConfig(project.DefaultDotnetCommands("", "")),
+			},
 		},
 	}
 }
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The parsed KDL document carries no positions, so validation reads the
+// node structure and lines from the source with this scanner. It assumes
+// the source already parsed and only needs names, values and nesting.
+
+type kdlTokenKind int
+
+const (
+	tokWord      kdlTokenKind = iota // Bare identifier, number or keyword
+	tokString                        // Quoted or raw string
+	tokProp                          // "name=" of a property; its value follows
+	tokOpen                          // {
+	tokClose                         // }
+	tokEnd                           // Newline or ;
+	tokSlashdash                     // /-
+)
+
+// kdlToken is a token of a KDL source and the line it is on.
+type kdlToken struct {
+	Kind kdlTokenKind
+	Text string
+	Line int
+}
+
+// display returns the token as written, for messages.
+func (t kdlToken) display() string {
+	if t.Kind == tokString {
+		return strconv.Quote(t.Text)
+	}
+	return t.Text
+}
+
+// kdlProp is a name=value property of a node.
+type kdlProp struct {
+	Name  string
+	Value kdlToken
+}
+
+// kdlNode is a node of a KDL source and the line it starts on.
+type kdlNode struct {
+	Name     string
+	Line     int
+	Args     []kdlToken
+	Props    []kdlProp
+	Children []*kdlNode
+}
+
+// scanKDLNodes returns the top-level nodes of a KDL source, leaving out
+// those commented out with /-.
+func scanKDLNodes(data string) []*kdlNode {
+	toks := tokenizeKDL(data)
+	i := 0
+	return parseKDLNodes(toks, &i, false)
+}
+
+// tokenizeKDL splits a KDL source into tokens, dropping comments, type
+// annotations and escaped newlines.
+func tokenizeKDL(data string) []kdlToken {
+	var toks []kdlToken
+	line := 1
+	emit := func(kind kdlTokenKind, text string) {
+		toks = append(toks, kdlToken{Kind: kind, Text: text, Line: line})
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			emit(tokEnd, "")
+			line++
+			i++
+		case c == ';':
+			emit(tokEnd, "")
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\\':
+			// Line continuation: the node goes on after the newline
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				line++
+				i++
+			}
+		case strings.HasPrefix(data[i:], "//"):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(data[i:], "/*"):
+			depth := 0
+			for i < len(data) {
+				switch {
+				case strings.HasPrefix(data[i:], "/*"):
+					depth++
+					i += 2
+				case strings.HasPrefix(data[i:], "*/"):
+					depth--
+					i += 2
+				default:
+					if data[i] == '\n' {
+						line++
+					}
+					i++
+				}
+				if depth == 0 {
+					break
+				}
+			}
+		case strings.HasPrefix(data[i:], "/-"):
+			emit(tokSlashdash, "")
+			i += 2
+		case c == '{':
+			emit(tokOpen, "")
+			i++
+		case c == '}':
+			emit(tokClose, "")
+			i++
+		case c == '(':
+			// Type annotation
+			for i < len(data) && data[i] != ')' {
+				i++
+			}
+			i++
+		case c == '"':
+			start, startLine := i, line
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+				if i < len(data) && data[i] == '\n' {
+					line++
+				}
+			}
+			i++
+			raw := data[start:min(i, len(data))]
+			text, err := strconv.Unquote(strings.ReplaceAll(raw, "\n", `\n`))
+			if err != nil {
+				text = strings.Trim(raw, `"`)
+			}
+			toks = append(toks, kdlToken{Kind: tokString, Text: text, Line: startLine})
+		case (c == 'r' || c == '#') && rawStringStart(data[i:]) > 0:
+			startLine := line
+			open := rawStringStart(data[i:])
+			hashes := strings.Count(data[i:i+open], "#")
+			end := `"` + strings.Repeat("#", hashes)
+			i += open
+			j := strings.Index(data[i:], end)
+			if j < 0 {
+				j = len(data) - i
+			}
+			text := data[i : i+j]
+			line += strings.Count(text, "\n")
+			i = min(i+j+len(end), len(data))
+			toks = append(toks, kdlToken{Kind: tokString, Text: text, Line: startLine})
+		default:
+			start := i
+			for i < len(data) && !strings.ContainsRune(" \t\r\n;{}()\"=\\", rune(data[i])) {
+				i++
+			}
+			if i < len(data) && data[i] == '=' {
+				i++
+				emit(tokProp, data[start:i-1])
+				continue
+			}
+			if i == start {
+				i++ // Stray character
+				continue
+			}
+			emit(tokWord, data[start:i])
+		}
+	}
+	return toks
+}
+
+// rawStringStart returns the length of the opening of a raw string
+// (r"…", r#"…"#, #"…"#) at the start of s, or 0.
+func rawStringStart(s string) int {
+	i := 0
+	if strings.HasPrefix(s, "r") {
+		i++
+	}
+	n := i
+	for n < len(s) && s[n] == '#' {
+		n++
+	}
+	if n < len(s) && s[n] == '"' && (i == 1 || n > 0) {
+		return n + 1
+	}
+	return 0
+}
+
+// parseKDLNodes reads nodes up to the end of the tokens or, when nested, the
+// closing brace of the block.
+func parseKDLNodes(toks []kdlToken, i *int, nested bool) []*kdlNode {
+	var nodes []*kdlNode
+	for *i < len(toks) {
+		t := toks[*i]
+		switch t.Kind {
+		case tokEnd:
+			*i++
+			continue
+		case tokClose:
+			*i++
+			if nested {
+				return nodes
+			}
+			continue
+		}
+
+		dropped := false
+		if t.Kind == tokSlashdash {
+			dropped = true
+			*i++
+			for *i < len(toks) && toks[*i].Kind == tokEnd {
+				*i++
+			}
+			if *i >= len(toks) {
+				break
+			}
+			t = toks[*i]
+		}
+		if t.Kind != tokWord && t.Kind != tokString {
+			*i++
+			continue
+		}
+		*i++
+		n := parseKDLNode(toks, i, t)
+		if !dropped {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// parseKDLNode reads the values, properties and children of the node named
+// by name.
+func parseKDLNode(toks []kdlToken, i *int, name kdlToken) *kdlNode {
+	n := &kdlNode{Name: name.Text, Line: name.Line}
+	for *i < len(toks) {
+		t := toks[*i]
+		switch t.Kind {
+		case tokEnd:
+			*i++
+			return n
+		case tokClose:
+			return n
+		case tokOpen:
+			*i++
+			n.Children = parseKDLNodes(toks, i, true)
+			return n
+		case tokSlashdash:
+			*i++
+			if *i < len(toks) && toks[*i].Kind == tokOpen {
+				*i++
+				parseKDLNodes(toks, i, true)
+				return n
+			}
+			if *i < len(toks) && toks[*i].Kind == tokProp {
+				*i++
+			}
+			*i++ // The dropped value
+		case tokProp:
+			*i++
+			if *i < len(toks) {
+				n.Props = append(n.Props, kdlProp{Name: t.Text, Value: toks[*i]})
+				*i++
+			}
+		default:
+			n.Args = append(n.Args, t)
+			*i++
+		}
+	}
+	return n
+}
@@ -0,0 +1,326 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sblinch/kdl-go"
+)
+
+// Severities of a ConfigIssue.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ConfigIssue is a problem found in an .agnt.kdl file.
+type ConfigIssue struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+	Path     string `json:"path,omitempty"` // Node path, e.g. "scripts.dev.ready-log"
+	Message  string `json:"message"`
+}
+
+func (i ConfigIssue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "%d: ", i.Line)
+	}
+	b.WriteString(i.Severity + ": ")
+	if i.Path != "" {
+		b.WriteString(i.Path + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ConfigValidation is the result of ValidateAgntConfig.
+type ConfigValidation struct {
+	// Config is what the daemon loads, nil when the file doesn't parse
+	Config *AgntConfig
+	Issues []ConfigIssue
+
+	lines map[string]int
+}
+
+// Line returns the line of the node at path, or 0 when there is none.
+func (v *ConfigValidation) Line(path string) int {
+	return v.lines[path]
+}
+
+// Add records an issue at the node at path, or at its nearest ancestor in
+// the file.
+func (v *ConfigValidation) Add(severity, path, format string, args ...interface{}) {
+	line := 0
+	for p := path; p != "" && line == 0; {
+		line = v.lines[p]
+		i := strings.LastIndexByte(p, '.')
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	v.Issues = append(v.Issues, ConfigIssue{Severity: severity, Line: line, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// HasErrors reports whether any issue is an error.
+func (v *ConfigValidation) HasErrors() bool {
+	for _, issue := range v.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort orders the issues by line.
+func (v *ConfigValidation) Sort() {
+	sort.SliceStable(v.Issues, func(i, j int) bool { return v.Issues[i].Line < v.Issues[j].Line })
+}
+
+// kdlLineRe finds the 0-based position kdl-go puts in syntax errors.
+var kdlLineRe = regexp.MustCompile(`at line (\d+), column \d+`)
+
+// ValidateAgntConfig checks KDL configuration data against AgntConfig:
+// syntax, unknown nodes and properties, values of the wrong type, names
+// given twice in a block and ports declared twice. Issues carry the line of
+// their node.
+func ValidateAgntConfig(data string) *ConfigValidation {
+	v := &ConfigValidation{lines: make(map[string]int)}
+
+	if _, err := kdl.Parse(strings.NewReader(data)); err != nil {
+		line := 0
+		if m := kdlLineRe.FindStringSubmatch(err.Error()); m != nil {
+			if n, _ := strconv.Atoi(m[1]); n > 0 {
+				line = n + 1
+			}
+		}
+		message, _, _ := strings.Cut(err.Error(), "\n")
+		message = strings.TrimSuffix(kdlLineRe.ReplaceAllString(message, ""), " ")
+		v.Issues = append(v.Issues, ConfigIssue{Severity: SeverityError, Line: line, Message: message})
+		return v
+	}
+
+	nodes := scanKDLNodes(data)
+	v.checkNodes(nodes, reflect.TypeOf(AgntConfig{}), "")
+
+	cfg := DefaultAgntConfig()
+	if err := kdl.Unmarshal([]byte(data), cfg); err != nil {
+		v.Add(SeverityError, "", "%v", err)
+		v.Add(SeverityWarning, "", "the daemon falls back to the legacy line parser, which only reads scripts and proxies")
+		cfg, _ = parseAgntConfigSimple(data)
+	}
+	v.Config = cfg
+	v.checkPorts(cfg)
+	for _, name := range sortedKeys(cfg.GitHooks) {
+		if !IsGitHook(name) {
+			v.Add(SeverityError, joinPath("git-hooks", name), "%q is not a git hook (use pre-commit, pre-push, commit-msg, ...)", name)
+		}
+	}
+	v.Sort()
+	return v
+}
+
+// checkNodes checks the child nodes of a struct or map of type t.
+func (v *ConfigValidation) checkNodes(nodes []*kdlNode, t reflect.Type, path string) {
+	t = derefType(t)
+	seen := make(map[string]int)
+	for _, n := range nodes {
+		childPath := joinPath(path, n.Name)
+		if _, ok := v.lines[childPath]; !ok {
+			v.lines[childPath] = n.Line
+		}
+
+		var childType reflect.Type
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := kdlField(t, n.Name)
+			if !ok {
+				v.Issues = append(v.Issues, ConfigIssue{Severity: SeverityError, Line: n.Line, Path: childPath, Message: fmt.Sprintf("unknown setting %q in %s", n.Name, describePath(path))})
+				continue
+			}
+			childType = f.Type
+			if first, dup := seen[n.Name]; dup && derefType(childType).Kind() != reflect.Slice {
+				v.Issues = append(v.Issues, ConfigIssue{Severity: SeverityWarning, Line: n.Line, Path: childPath, Message: fmt.Sprintf("set twice (also on line %d)", first)})
+			}
+		case reflect.Map:
+			childType = t.Elem()
+			if first, dup := seen[n.Name]; dup {
+				v.Issues = append(v.Issues, ConfigIssue{Severity: SeverityError, Line: n.Line, Path: childPath, Message: fmt.Sprintf("%q is defined twice in %s (also on line %d)", n.Name, describePath(path), first)})
+			}
+		default:
+			return
+		}
+		if _, ok := seen[n.Name]; !ok {
+			seen[n.Name] = n.Line
+		}
+		v.checkValue(n, childType, childPath)
+	}
+}
+
+// checkValue checks a node whose value is of type t.
+func (v *ConfigValidation) checkValue(n *kdlNode, t reflect.Type, path string) {
+	t = derefType(t)
+	issue := func(format string, args ...interface{}) {
+		v.Issues = append(v.Issues, ConfigIssue{Severity: SeverityError, Line: n.Line, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if len(n.Args) > 0 {
+			issue("takes settings, not the value %s", n.Args[0].Text)
+		}
+		for _, p := range n.Props {
+			f, ok := kdlField(t, p.Name)
+			if !ok {
+				issue("unknown setting %q", p.Name)
+				continue
+			}
+			if msg := checkScalar(p.Value, derefType(f.Type)); msg != "" {
+				issue("%s: %s", p.Name, msg)
+			}
+		}
+		v.checkNodes(n.Children, t, path)
+	case reflect.Map:
+		if isStructType(t.Elem()) {
+			v.checkNodes(n.Children, t, path)
+			return
+		}
+		for _, c := range n.Children {
+			v.lines[joinPath(path, c.Name)] = c.Line
+			if len(c.Args) != 1 {
+				v.Issues = append(v.Issues, ConfigIssue{Severity: SeverityError, Line: c.Line, Path: joinPath(path, c.Name), Message: "expected one value"})
+			}
+		}
+	case reflect.Slice:
+		if len(n.Children) > 0 {
+			issue("takes values, not a block")
+		}
+		for _, arg := range n.Args {
+			if msg := checkScalar(arg, derefType(t.Elem())); msg != "" {
+				issue("%s", msg)
+			}
+		}
+	default:
+		if len(n.Children) > 0 {
+			issue("takes a value, not a block")
+		}
+		switch len(n.Args) {
+		case 0:
+			issue("missing value")
+		case 1:
+			if msg := checkScalar(n.Args[0], t); msg != "" {
+				issue("%s", msg)
+			}
+		default:
+			issue("expected one value, got %d", len(n.Args))
+		}
+	}
+}
+
+// checkScalar describes why tok isn't a value of kind t, or returns "".
+func checkScalar(tok kdlToken, t reflect.Type) string {
+	text := strings.ReplaceAll(strings.TrimPrefix(tok.Text, "#"), "_", "")
+	switch t.Kind() {
+	case reflect.Bool:
+		if tok.Kind != tokWord || (text != "true" && text != "false") {
+			return fmt.Sprintf("expected true or false, got %s", tok.display())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(text, 0, 64); tok.Kind != tokWord || err != nil {
+			return fmt.Sprintf("expected a whole number, got %s", tok.display())
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(text, 64); tok.Kind != tokWord || err != nil {
+			return fmt.Sprintf("expected a number, got %s", tok.display())
+		}
+	}
+	return ""
+}
+
+// checkPorts reports ports declared by more than one script or double.
+func (v *ConfigValidation) checkPorts(cfg *AgntConfig) {
+	owners := make(map[int][]string)
+	for _, name := range sortedKeys(cfg.Scripts) {
+		if s := cfg.Scripts[name]; s != nil {
+			for _, port := range s.Ports {
+				owners[port] = append(owners[port], joinPath("scripts", name))
+			}
+		}
+	}
+	for _, name := range sortedKeys(cfg.Doubles) {
+		if d := cfg.Doubles[name]; d != nil && d.Port > 0 {
+			owners[d.Port] = append(owners[d.Port], joinPath("doubles", name))
+		}
+	}
+	ports := make([]int, 0, len(owners))
+	for port := range owners {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	for _, port := range ports {
+		o := owners[port]
+		for _, owner := range o[1:] {
+			v.Add(SeverityWarning, owner+".ports", "port %d is also declared by %s; they can't run at the same time", port, o[0])
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "the top level"
+	}
+	return path
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isStructType(t reflect.Type) bool {
+	return derefType(t).Kind() == reflect.Struct
+}
+
+// kdlField finds the field of struct t that the node name unmarshals into.
+func kdlField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("kdl"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(f.Name)
+		}
+		if tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findIssue returns the first issue at path, failing when there is none.
+func findIssue(t *testing.T, v *ConfigValidation, path string) ConfigIssue {
+	t.Helper()
+	for _, issue := range v.Issues {
+		if issue.Path == path {
+			return issue
+		}
+	}
+	t.Fatalf("no issue at %s in %v", path, v.Issues)
+	return ConfigIssue{}
+}
+
+func TestValidateAgntConfigValid(t *testing.T) {
+	input := `// Project scripts
+scripts {
+    dev {
+        run "npm run dev"
+        autostart true
+        ports 3000
+        /- ready-log "ignored"
+    }
+    test command="go" { args "test" "./..."; }
+}
+
+proxies {
+    app {
+        target "http://localhost:3000"
+        autostart true
+    }
+}
+
+/- doubles {
+    stripe { bogus 1; }
+}
+`
+	v := ValidateAgntConfig(input)
+	assert.Empty(t, v.Issues)
+	assert.False(t, v.HasErrors())
+	require.NotNil(t, v.Config)
+	assert.Contains(t, v.Config.Scripts, "dev")
+	assert.Equal(t, 3, v.Line("scripts.dev"))
+	assert.Equal(t, 9, v.Line("scripts.test"))
+	assert.Equal(t, 13, v.Line("proxies.app"))
+}
+
+func TestValidateAgntConfigSchemaErrors(t *testing.T) {
+	input := `scripts {
+    dev {
+        run "npm run dev"
+        autostart "yes"
+        bogus 1
+    }
+}
+proxies {
+    app { port "eighty"; }
+}
+unknown-block {
+}
+`
+	v := ValidateAgntConfig(input)
+	assert.True(t, v.HasErrors())
+
+	issue := findIssue(t, v, "scripts.dev.autostart")
+	assert.Equal(t, SeverityError, issue.Severity)
+	assert.Equal(t, 4, issue.Line)
+	assert.Contains(t, issue.Message, `expected true or false, got "yes"`)
+
+	issue = findIssue(t, v, "scripts.dev.bogus")
+	assert.Equal(t, 5, issue.Line)
+	assert.Contains(t, issue.Message, `unknown setting "bogus"`)
+
+	issue = findIssue(t, v, "proxies.app.port")
+	assert.Equal(t, 9, issue.Line)
+	assert.Contains(t, issue.Message, "expected a whole number")
+
+	issue = findIssue(t, v, "unknown-block")
+	assert.Equal(t, 11, issue.Line)
+	assert.Contains(t, issue.Message, "the top level")
+}
+
+func TestValidateAgntConfigDuplicates(t *testing.T) {
+	input := `scripts {
+    api { run "go run ./cmd/api"; ports 8080; }
+    web { run "npm run dev"; ports 3000; }
+    api { run "make api"; }
+}
+doubles {
+    stripe { port 8080; }
+}
+`
+	v := ValidateAgntConfig(input)
+
+	issue := findIssue(t, v, "scripts.api")
+	assert.Equal(t, SeverityError, issue.Severity)
+	assert.Equal(t, 4, issue.Line)
+	assert.Contains(t, issue.Message, "also on line 2")
+
+	issue = findIssue(t, v, "doubles.stripe.ports")
+	assert.Equal(t, SeverityWarning, issue.Severity)
+	assert.Equal(t, 7, issue.Line)
+	assert.Contains(t, issue.Message, "port 8080 is also declared by scripts.api")
+}
+
+func TestValidateAgntConfigSyntaxError(t *testing.T) {
+	input := `scripts {
+    dev { run "npm run dev"; }
+}
+proxies {
+    app { target "http://localhost:3000
+`
+	v := ValidateAgntConfig(input)
+	require.Len(t, v.Issues, 1)
+	assert.Equal(t, SeverityError, v.Issues[0].Severity)
+	assert.Nil(t, v.Config)
+	assert.NotContains(t, v.Issues[0].Message, "\n")
+}
+
+func TestScanKDLNodes(t *testing.T) {
+	input := `a 1 "two" key=r#"raw "quoted""# /- dropped
+/* block
+   /* nested */ comment */
+b \
+  3 {
+    (t)c #"x"#; d
+}
+`
+	nodes := scanKDLNodes(input)
+	require.Len(t, nodes, 2)
+
+	a := nodes[0]
+	assert.Equal(t, "a", a.Name)
+	assert.Equal(t, 1, a.Line)
+	require.Len(t, a.Args, 2)
+	assert.Equal(t, "1", a.Args[0].Text)
+	assert.Equal(t, "two", a.Args[1].Text)
+	require.Len(t, a.Props, 1)
+	assert.Equal(t, `raw "quoted"`, a.Props[0].Value.Text)
+
+	b := nodes[1]
+	assert.Equal(t, 4, b.Line)
+	require.Len(t, b.Args, 1)
+	require.Len(t, b.Children, 2)
+	assert.Equal(t, "c", b.Children[0].Name)
+	assert.Equal(t, 6, b.Children[0].Line)
+	assert.Equal(t, "x", b.Children[0].Args[0].Text)
+	assert.Equal(t, "d", b.Children[1].Name)
+}
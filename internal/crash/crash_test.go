@@ -0,0 +1,186 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const goPanicOutput = `starting server on :8080
+panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b3c]
+
+goroutine 7 [running]:
+example.com/app/handlers.(*Server).getUser(0x0, {0x7f1c, 0x3})
+	/home/dev/app/handlers/user.go:42 +0x1c
+example.com/app/handlers.(*Server).ServeHTTP(0xc0000a2000, {0x8a1b20, 0xc0001c0000}, 0xc0001b6000)
+	/home/dev/app/handlers/server.go:88 +0x245
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3285 +0x4b4
+
+goroutine 1 [IO wait]:
+internal/poll.runtime_pollWait(0x7f, 0x72)
+	/usr/local/go/src/runtime/netpoll.go:345 +0x85
+exit status 2
+`
+
+const nodeOutput = `Server listening on http://localhost:3000
+/home/dev/app/src/db.js:12
+    throw new TypeError("Cannot read config");
+    ^
+
+TypeError: Cannot read config
+    at loadConfig (/home/dev/app/src/db.js:12:11)
+    at Object.<anonymous> (/home/dev/app/src/index.js:4:1)
+    at async Promise.all (index 0)
+    at node:internal/main/run_main_module:28:49
+
+Node.js v20.11.0
+`
+
+func TestAnalyze_GoPanic(t *testing.T) {
+	r := Analyze([]byte(goPanicOutput), 2)
+	if r == nil {
+		t.Fatal("expected crash report")
+	}
+	if r.Kind != KindGoPanic || r.Signal != "SIGSEGV" || r.Goroutine != "7 [running]" {
+		t.Errorf("report = %s %s %q", r.Kind, r.Signal, r.Goroutine)
+	}
+	if !strings.Contains(r.Message, "nil pointer dereference") {
+		t.Errorf("message = %q", r.Message)
+	}
+	want := []Frame{
+		{Function: "example.com/app/handlers.(*Server).getUser", File: "/home/dev/app/handlers/user.go", Line: 42},
+		{Function: "example.com/app/handlers.(*Server).ServeHTTP", File: "/home/dev/app/handlers/server.go", Line: 88},
+		{Function: "net/http.(*Server).Serve", File: "/usr/local/go/src/net/http/server.go", Line: 3285},
+	}
+	if len(r.Frames) != len(want) {
+		t.Fatalf("frames = %+v, want %d", r.Frames, len(want))
+	}
+	for i := range want {
+		if r.Frames[i] != want[i] {
+			t.Errorf("frame %d = %+v, want %+v", i, r.Frames[i], want[i])
+		}
+	}
+}
+
+func TestAnalyze_GoFatal(t *testing.T) {
+	out := "fatal error: concurrent map writes\n\ngoroutine 12 [running]:\nmain.worker()\n\t/app/main.go:20 +0x3c\n"
+	r := Analyze([]byte(out), 2)
+	if r == nil || r.Kind != KindGoFatal || r.Message != "concurrent map writes" || len(r.Frames) != 1 {
+		t.Fatalf("report = %+v", r)
+	}
+}
+
+func TestAnalyze_NodeException(t *testing.T) {
+	r := Analyze([]byte(nodeOutput), 1)
+	if r == nil {
+		t.Fatal("expected crash report")
+	}
+	if r.Kind != KindNodeException || r.Message != "TypeError: Cannot read config" {
+		t.Errorf("report = %s %q", r.Kind, r.Message)
+	}
+	want := []Frame{
+		{Function: "loadConfig", File: "/home/dev/app/src/db.js", Line: 12, Column: 11},
+		{Function: "Object.<anonymous>", File: "/home/dev/app/src/index.js", Line: 4, Column: 1},
+		{Function: "Promise.all", File: "index 0"},
+		{Function: "<anonymous>", File: "node:internal/main/run_main_module", Line: 28, Column: 49},
+	}
+	if len(r.Frames) != len(want) {
+		t.Fatalf("frames = %+v", r.Frames)
+	}
+	for i := range want {
+		if r.Frames[i] != want[i] {
+			t.Errorf("frame %d = %+v, want %+v", i, r.Frames[i], want[i])
+		}
+	}
+}
+
+func TestAnalyze_NotACrash(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		code   int
+	}{
+		{"clean exit", goPanicOutput, 0},
+		{"error exit", "Error: missing argument --port\n", 1},
+		{"terminated", "shutting down\n", 143},
+	}
+	for _, tt := range tests {
+		if r := Analyze([]byte(tt.output), tt.code); r != nil {
+			t.Errorf("%s: got %s report", tt.name, r.Kind)
+		}
+	}
+}
+
+func TestAnalyze_Signal(t *testing.T) {
+	r := Analyze([]byte("loading model\n"), 139)
+	if r == nil || r.Kind != KindSignal || r.Signal != "SIGSEGV" {
+		t.Fatalf("report = %+v", r)
+	}
+	r = Analyze([]byte("sh: line 1: 4242 Segmentation fault (core dumped) ./native\n"), 1)
+	if r == nil || r.Kind != KindSignal || r.Message != "Segmentation fault (core dumped)" {
+		t.Fatalf("report = %+v", r)
+	}
+}
+
+func TestTail(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 300; i++ {
+		b.WriteString("line\n")
+	}
+	if got := strings.Count(Tail([]byte(b.String())), "\n"); got != tailLines-1 {
+		t.Errorf("tail has %d newlines, want %d", got, tailLines-1)
+	}
+}
+
+func TestFindCore_WorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	since := time.Now().Add(-time.Second)
+	if err := os.WriteFile(filepath.Join(dir, "core.4242"), []byte("ELF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ref := FindCore(dir, 4242, since)
+	if ref == nil || ref.Path != filepath.Join(dir, "core.4242") {
+		t.Errorf("FindCore = %+v", ref)
+	}
+}
+
+func TestExpandCorePattern(t *testing.T) {
+	if got := expandCorePattern("/var/cores/core.%e.%p.%t%%", 77); got != "/var/cores/core.*.77.*%" {
+		t.Errorf("expandCorePattern = %q", got)
+	}
+}
+
+func TestSaveListGet(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"api", "web", "api"} {
+		r := &Report{ProcessID: id, Kind: KindGoPanic, ExitCode: 2, CrashedAt: base.Add(time.Duration(i) * time.Minute)}
+		if _, err := Save(dir, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := List(dir, "")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("List = %d reports, %v", len(all), err)
+	}
+	if all[0].ProcessID != "api" || !all[0].CrashedAt.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("newest = %+v", all[0])
+	}
+
+	latest, err := Get(dir, "api")
+	if err != nil || latest.ID != all[0].ID {
+		t.Errorf("Get(api) = %+v, %v", latest, err)
+	}
+	byID, err := Get(dir, all[1].ID)
+	if err != nil || byID.ProcessID != "web" {
+		t.Errorf("Get(id) = %+v, %v", byID, err)
+	}
+	if _, err := Get(dir, "missing"); err != ErrNotFound {
+		t.Errorf("Get(missing) err = %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Package crash turns the output of crashed processes into structured
+// post-mortem reports: Go panics and fatal errors, uncaught Node exceptions,
+// and signal deaths with an optional core dump reference.
+package crash
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame is one decoded stack frame.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// maxFrames bounds the frames kept per trace.
+const maxFrames = 50
+
+var (
+	goPanicLine     = regexp.MustCompile(`(?m)^(panic|fatal error): (.*)$`)
+	goSignalLine    = regexp.MustCompile(`(?m)^\[signal (SIG[A-Z]+)[^\]]*\]`)
+	goGoroutineLine = regexp.MustCompile(`^goroutine (\d+) \[([^\]]+)\]:$`)
+	goFileLine      = regexp.MustCompile(`^\s+(\S+?):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+	nodeAtLine    = regexp.MustCompile(`^\s+at (?:async )?(?:(.+?) \((.+?)\)|(.+))$`)
+	nodeLocation  = regexp.MustCompile(`^(.*?):(\d+):(\d+)$`)
+	nodeErrorLine = regexp.MustCompile(`^(?:Uncaught )?([A-Z]\w*(?:Error|Exception)|Error)(?: \[\w+\])?(?::|$)`)
+)
+
+// goTrace is a decoded Go panic or fatal error.
+type goTrace struct {
+	Kind      string // "panic" or "fatal error"
+	Message   string
+	Signal    string
+	Goroutine string
+	Frames    []Frame
+}
+
+// parseGo decodes the first Go panic or fatal error in output. The frames are
+// those of the first goroutine listed, which is the one that crashed.
+func parseGo(output string) *goTrace {
+	loc := goPanicLine.FindStringSubmatchIndex(output)
+	if loc == nil {
+		return nil
+	}
+	t := &goTrace{
+		Kind:    output[loc[2]:loc[3]],
+		Message: strings.TrimSpace(output[loc[4]:loc[5]]),
+	}
+	rest := output[loc[1]:]
+	if m := goSignalLine.FindStringSubmatch(rest); m != nil {
+		t.Signal = m[1]
+	}
+
+	lines := strings.Split(rest, "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		if m := goGoroutineLine.FindStringSubmatch(strings.TrimSpace(lines[i])); m != nil {
+			t.Goroutine = m[1] + " [" + m[2] + "]"
+			i++
+			break
+		}
+	}
+	for ; i+1 < len(lines) && len(t.Frames) < maxFrames; i++ {
+		fn := strings.TrimRight(lines[i], "\r")
+		if strings.TrimSpace(fn) == "" || strings.HasPrefix(fn, "goroutine ") {
+			break
+		}
+		m := goFileLine.FindStringSubmatch(strings.TrimRight(lines[i+1], "\r"))
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		name := strings.TrimPrefix(fn, "created by ")
+		if idx := strings.Index(name, " in goroutine "); idx >= 0 {
+			name = name[:idx]
+		}
+		// Drop the argument list; receivers like (*T) come before the last "("
+		if strings.HasSuffix(name, ")") {
+			if idx := strings.LastIndex(name, "("); idx > 0 {
+				name = name[:idx]
+			}
+		}
+		t.Frames = append(t.Frames, Frame{Function: name, File: m[1], Line: line})
+		i++
+	}
+	return t
+}
+
+// nodeTrace is a decoded JavaScript error with its stack.
+type nodeTrace struct {
+	Message string
+	Frames  []Frame
+}
+
+// parseNode decodes the last JavaScript stack trace in output, which for a
+// crashed Node process is the uncaught exception.
+func parseNode(output string) *nodeTrace {
+	lines := strings.Split(output, "\n")
+	end := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if nodeAtLine.MatchString(strings.TrimRight(lines[i], "\r")) {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+	start := end
+	for start > 0 && nodeAtLine.MatchString(strings.TrimRight(lines[start-1], "\r")) {
+		start--
+	}
+
+	t := &nodeTrace{}
+	// The message precedes the frames and may span several lines
+	for i := start - 1; i >= 0 && i >= start-20; i-- {
+		line := strings.TrimSpace(lines[i])
+		if nodeErrorLine.MatchString(line) {
+			t.Message = strings.TrimSpace(strings.Join(trimLines(lines[i:start]), "\n"))
+			break
+		}
+	}
+	if t.Message == "" && start > 0 {
+		t.Message = strings.TrimSpace(lines[start-1])
+	}
+
+	for _, raw := range lines[start : end+1] {
+		if len(t.Frames) >= maxFrames {
+			break
+		}
+		m := nodeAtLine.FindStringSubmatch(strings.TrimRight(raw, "\r"))
+		fn, location := m[1], m[2]
+		if location == "" {
+			location = m[3]
+		}
+		f := Frame{Function: fn}
+		if lm := nodeLocation.FindStringSubmatch(location); lm != nil {
+			f.File = lm[1]
+			f.Line, _ = strconv.Atoi(lm[2])
+			f.Column, _ = strconv.Atoi(lm[3])
+		} else if fn == "" {
+			f.Function = location // e.g. "at <anonymous>" or "at new Promise (<anonymous>)"
+		} else {
+			f.File = location
+		}
+		if f.Function == "" {
+			f.Function = "<anonymous>"
+		}
+		t.Frames = append(t.Frames, f)
+	}
+	return t
+}
+
+func trimLines(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, strings.TrimRight(l, "\r"))
+	}
+	return out
+}
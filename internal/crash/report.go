@@ -0,0 +1,219 @@
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of crash.
+const (
+	KindGoPanic       = "go_panic"
+	KindGoFatal       = "go_fatal"
+	KindNodeException = "node_exception"
+	KindNodeFatal     = "node_fatal"
+	KindSignal        = "signal"
+)
+
+const (
+	// tailLines and tailBytes bound the stderr tail kept in a report.
+	tailLines = 100
+	tailBytes = 16 * 1024
+)
+
+// crashSignals maps the signals that indicate a crash (rather than a
+// requested stop) to their numbers.
+var crashSignals = map[string]int{
+	"SIGILL":  4,
+	"SIGTRAP": 5,
+	"SIGABRT": 6,
+	"SIGBUS":  7,
+	"SIGFPE":  8,
+	"SIGSEGV": 11,
+}
+
+var (
+	nodeFatalLine  = regexp.MustCompile(`(?m)^FATAL ERROR: (.*)$`)
+	segfaultNotice = regexp.MustCompile(`(?i)\b(?:segmentation fault|bus error|illegal instruction|floating point exception)\b(?: \(core dumped\))?|\bAborted \(core dumped\)`)
+)
+
+// Report is the post-mortem of one crashed process.
+type Report struct {
+	ID          string     `json:"id"`
+	ProcessID   string     `json:"process_id"`
+	ProjectPath string     `json:"project_path,omitempty"`
+	Command     string     `json:"command,omitempty"`
+	Args        []string   `json:"args,omitempty"`
+	PID         int        `json:"pid,omitempty"`
+	ExitCode    int        `json:"exit_code"`
+	Kind        string     `json:"kind"`
+	Signal      string     `json:"signal,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	Goroutine   string     `json:"goroutine,omitempty"`
+	Frames      []Frame    `json:"frames,omitempty"`
+	StderrTail  string     `json:"stderr_tail"`
+	Core        *CoreRef   `json:"core,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CrashedAt   time.Time  `json:"crashed_at"`
+}
+
+// CoreRef points at a core dump left by a crash. Either Path names the file,
+// or Hint says how to retrieve it from the system's crash handler.
+type CoreRef struct {
+	Path string `json:"path,omitempty"`
+	Hint string `json:"hint,omitempty"`
+}
+
+// Analyze inspects the stderr of an exited process. It returns nil when the
+// exit does not look like a crash (a clean exit, an ordinary error exit, or
+// a requested stop).
+func Analyze(stderr []byte, exitCode int) *Report {
+	if exitCode == 0 {
+		return nil
+	}
+	output := string(stderr)
+	r := &Report{ExitCode: exitCode, StderrTail: Tail(stderr)}
+
+	if t := parseGo(output); t != nil {
+		r.Kind = KindGoPanic
+		if t.Kind == "fatal error" {
+			r.Kind = KindGoFatal
+		}
+		r.Message, r.Signal, r.Goroutine, r.Frames = t.Message, t.Signal, t.Goroutine, t.Frames
+		return r
+	}
+	if m := nodeFatalLine.FindStringSubmatch(output); m != nil {
+		r.Kind = KindNodeFatal
+		r.Message = strings.TrimSpace(m[1])
+		if t := parseNode(output); t != nil {
+			r.Frames = t.Frames
+		}
+		return r
+	}
+	if t := parseNode(output); t != nil {
+		r.Kind = KindNodeException
+		r.Message, r.Frames = t.Message, t.Frames
+		return r
+	}
+	if sig := exitSignal(exitCode); sig != "" {
+		r.Kind = KindSignal
+		r.Signal = sig
+		r.Message = fmt.Sprintf("killed by %s", sig)
+		return r
+	}
+	if m := segfaultNotice.FindString(output); m != "" {
+		r.Kind = KindSignal
+		r.Message = m
+		return r
+	}
+	return nil
+}
+
+// exitSignal names the crash signal encoded in a shell-style exit code
+// (128 + signal number), if any.
+func exitSignal(exitCode int) string {
+	if exitCode <= 128 {
+		return ""
+	}
+	for name, num := range crashSignals {
+		if exitCode-128 == num {
+			return name
+		}
+	}
+	return ""
+}
+
+// Tail returns the last lines of output, bounded in lines and bytes.
+func Tail(output []byte) string {
+	if len(output) > tailBytes {
+		output = output[len(output)-tailBytes:]
+		// Start at a line boundary
+		for i, b := range output {
+			if b == '\n' {
+				output = output[i+1:]
+				break
+			}
+		}
+	}
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(lines) > tailLines {
+		lines = lines[len(lines)-tailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FindCore looks for a core dump written by process pid after since: a core
+// file in dir, or the location given by the kernel's core_pattern. Returns
+// nil when no core is found.
+func FindCore(dir string, pid int, since time.Time) *CoreRef {
+	for _, name := range []string{"core." + strconv.Itoa(pid), "core"} {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && !info.ModTime().Before(since) {
+			return &CoreRef{Path: path}
+		}
+	}
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	data, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+	if err != nil {
+		return nil
+	}
+	pattern := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(pattern, "|") && strings.Contains(pattern, "systemd-coredump"):
+		return &CoreRef{Hint: fmt.Sprintf("coredumpctl info %d", pid)}
+	case strings.HasPrefix(pattern, "|") && strings.Contains(pattern, "apport"):
+		return &CoreRef{Hint: "see /var/crash (apport)"}
+	case strings.HasPrefix(pattern, "/"):
+		return newestMatch(expandCorePattern(pattern, pid), since)
+	}
+	return nil
+}
+
+// expandCorePattern turns a core_pattern into a glob: %p becomes the PID and
+// other specifiers match anything.
+func expandCorePattern(pattern string, pid int) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 == len(pattern) {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'p', 'P':
+			b.WriteString(strconv.Itoa(pid))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('*')
+		}
+	}
+	return b.String()
+}
+
+func newestMatch(glob string, since time.Time) *CoreRef {
+	matches, _ := filepath.Glob(glob)
+	var best string
+	var bestTime time.Time
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() || info.ModTime().Before(since) {
+			continue
+		}
+		if best == "" || info.ModTime().After(bestTime) {
+			best, bestTime = m, info.ModTime()
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return &CoreRef{Path: best}
+}
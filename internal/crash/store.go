@@ -0,0 +1,137 @@
+package crash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// Dir holds crash reports, relative to the project root.
+	Dir = ".agnt/crashes"
+
+	// maxReports is how many reports are kept per project.
+	maxReports = 50
+)
+
+// ErrNotFound is returned when no crash report matches.
+var ErrNotFound = errors.New("crash report not found")
+
+// Save writes r under the project's crash directory, assigning its ID, and
+// prunes the oldest reports beyond the retention limit.
+func Save(projectPath string, r *Report) (string, error) {
+	dir := filepath.Join(projectPath, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	r.ID = fmt.Sprintf("%s-%s", fileSafe(r.ProcessID), r.CrashedAt.UTC().Format("20060102T150405.000"))
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	prune(dir)
+	return path, nil
+}
+
+// List returns the project's crash reports, newest first. When processID is
+// set, only that process's reports are returned.
+func List(projectPath, processID string) ([]*Report, error) {
+	dir := filepath.Join(projectPath, Dir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*Report
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		r, err := load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		if processID != "" && r.ProcessID != processID {
+			continue
+		}
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].CrashedAt.After(reports[j].CrashedAt) })
+	return reports, nil
+}
+
+// Get returns a report by ID, or the latest report of a process when ref
+// names a process instead.
+func Get(projectPath, ref string) (*Report, error) {
+	if r, err := load(filepath.Join(projectPath, Dir, fileSafe(ref)+".json")); err == nil {
+		return r, nil
+	}
+	reports, err := List(projectPath, ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, ErrNotFound
+	}
+	return reports[0], nil
+}
+
+func load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// prune removes the oldest reports beyond maxReports. Names sort by time
+// within a process, so modification time orders across processes.
+func prune(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) <= maxReports {
+		return
+	}
+	type file struct {
+		path string
+		mod  int64
+	}
+	files := make([]file, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		files = append(files, file{filepath.Join(dir, e.Name()), info.ModTime().UnixNano()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod < files[j].mod })
+	for len(files) > maxReports {
+		os.Remove(files[0].path)
+		files = files[1:]
+	}
+}
+
+// fileSafe makes an ID safe to use in a file name.
+func fileSafe(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, id)
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// hubHandleA11y handles the A11Y command.
+func (d *Daemon) hubHandleA11y(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "A11Y %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbAudit:
+		return d.hubHandleA11yAudit(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "a11y", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown A11Y sub-command",
+			Command:      protocol.VerbA11y,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbAudit},
+		})
+	}
+}
+
+// hubHandleA11yAudit handles A11Y AUDIT <proxy_id> [session_id].
+func (d *Daemon) hubHandleA11yAudit(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "A11Y AUDIT requires: <proxy_id> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	sessionID := ""
+	if len(cmd.Args) > 1 {
+		sessionID = cmd.Args[1]
+	}
+
+	var opts proxy.A11yAuditOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	switch opts.Level {
+	case "", "a", "aa", "aaa":
+	default:
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "level must be a, aa or aaa")
+	}
+	switch opts.Mode {
+	case "", "standard", "basic", "fast", "comprehensive":
+	default:
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "mode must be standard, basic, fast or comprehensive")
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+
+	report, err := p.AuditAccessibility(ctx, sessionID, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(report)
+	return conn.WriteJSON(data)
+}
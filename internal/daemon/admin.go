@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/profile"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// defaultDaemonProfileSeconds is how long DAEMON PROFILE samples CPU.
+	defaultDaemonProfileSeconds = 30
+
+	// maxDaemonProfileSeconds caps a DAEMON PROFILE capture.
+	maxDaemonProfileSeconds = 120
+)
+
+// startAdmin serves net/http/pprof on the configured loopback address, so
+// the daemon itself can be profiled with `go tool pprof`.
+func (d *Daemon) startAdmin() error {
+	addr := d.config.AdminAddr
+	if addr == "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid admin address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("admin address %q must be on loopback", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("admin endpoint: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	d.admin = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	d.adminURL = "http://" + ln.Addr().String()
+
+	go func() {
+		if err := d.admin.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[WARN] admin endpoint stopped: %v", err)
+		}
+	}()
+	log.Printf("Admin endpoint with pprof on %s/debug/pprof/", d.adminURL)
+	return nil
+}
+
+// stopAdmin shuts the admin endpoint down.
+func (d *Daemon) stopAdmin(ctx context.Context) {
+	if d.admin != nil {
+		d.admin.Shutdown(ctx)
+	}
+}
+
+// daemonProfileRequest is the JSON payload of DAEMON PROFILE.
+type daemonProfileRequest struct {
+	Seconds int    `json:"seconds,omitempty"` // CPU sampling duration (default 30, max 120)
+	Top     int    `json:"top,omitempty"`     // Functions in the summary (default 20)
+	Path    string `json:"path,omitempty"`    // Project to store the profile in when no session is attached
+}
+
+// hubHandleDaemon handles the DAEMON command.
+func (d *Daemon) hubHandleDaemon(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "DAEMON %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbProfile:
+		return d.hubHandleDaemonProfile(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown DAEMON sub-command",
+			Command:      protocol.VerbDaemon,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbProfile},
+		})
+	}
+}
+
+// hubHandleDaemonProfile handles DAEMON PROFILE cpu|heap: profiles the
+// daemon itself, stores the profile next to process profiles and returns
+// its top functions.
+func (d *Daemon) hubHandleDaemonProfile(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	kind := profile.KindCPU
+	if len(cmd.Args) > 0 && cmd.Args[0] != "" {
+		kind = strings.ToLower(cmd.Args[0])
+	}
+	if kind != profile.KindCPU && kind != profile.KindHeap {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("unknown profile kind %q: use cpu or heap", kind))
+	}
+	var req daemonProfileRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if req.Seconds <= 0 {
+		req.Seconds = defaultDaemonProfileSeconds
+	}
+	if req.Seconds > maxDaemonProfileSeconds {
+		req.Seconds = maxDaemonProfileSeconds
+	}
+	if req.Top <= 0 {
+		req.Top = defaultProfileTop
+	}
+
+	data, err := captureSelfProfile(ctx, kind, time.Duration(req.Seconds)*time.Second)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+	summary, err := profile.SummarizePprof(data, req.Top)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("cannot summarize profile: %v", err))
+	}
+
+	dir := daemonProfileDir()
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath != "" {
+		dir = filepath.Join(projectPath, profileDir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	artifact := filepath.Join(dir, fmt.Sprintf("daemon-%s-%s.pb.gz", kind, time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(artifact, data, 0644); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	resp := map[string]interface{}{
+		"kind":       kind,
+		"artifact":   artifact,
+		"bytes":      len(data),
+		"summary":    summary,
+		"table":      summary.Table(),
+		"goroutines": runtime.NumGoroutine(),
+		"heap_bytes": mem.HeapAlloc,
+		"version":    Version,
+	}
+	if kind == profile.KindCPU {
+		resp["seconds"] = req.Seconds
+	}
+	if d.adminURL != "" {
+		resp["admin_url"] = d.adminURL + "/debug/pprof/"
+	}
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// captureSelfProfile profiles the daemon's own process. Only one CPU
+// profile can run at a time, so this fails while the admin endpoint serves
+// one.
+func captureSelfProfile(ctx context.Context, kind string, duration time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	if kind == profile.KindHeap {
+		runtime.GC()
+		if err := rpprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	if err := rpprof.StartCPUProfile(&buf); err != nil {
+		return nil, fmt.Errorf("cannot start CPU profile: %v", err)
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	rpprof.StopCPUProfile()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return buf.Bytes(), nil
+}
+
+// daemonProfileDir holds daemon profiles captured without a project, next
+// to the daemon's state file.
+func daemonProfileDir() string {
+	return filepath.Join(filepath.Dir(DefaultStatePath()), "profiles")
+}
@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/profile"
+)
+
+func TestStartAdminRequiresLoopback(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:0", "192.0.2.1:6061", "6061"} {
+		d := &Daemon{config: DaemonConfig{AdminAddr: addr}}
+		if err := d.startAdmin(); err == nil {
+			d.stopAdmin(context.Background())
+			t.Errorf("startAdmin(%q) succeeded, want error", addr)
+		}
+	}
+}
+
+func TestStartAdminServesPprof(t *testing.T) {
+	d := &Daemon{config: DaemonConfig{AdminAddr: "127.0.0.1:0"}}
+	if err := d.startAdmin(); err != nil {
+		t.Fatalf("startAdmin: %v", err)
+	}
+	defer d.stopAdmin(context.Background())
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(d.adminURL + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("GET pprof index: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine") {
+		t.Errorf("pprof index = %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestCaptureSelfProfileHeap(t *testing.T) {
+	data, err := captureSelfProfile(context.Background(), profile.KindHeap, 0)
+	if err != nil {
+		t.Fatalf("captureSelfProfile: %v", err)
+	}
+	summary, err := profile.SummarizePprof(data, 5)
+	if err != nil {
+		t.Fatalf("SummarizePprof: %v", err)
+	}
+	if !strings.Contains(summary.Table(), "flat%") {
+		t.Errorf("table missing header:\n%s", summary.Table())
+	}
+}
@@ -0,0 +1,337 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// alertKinds are the debug event kinds an alert rule can count.
+var alertKinds = []string{eventFrontendError, eventHTTPError}
+
+// AlertRule pushes a summary to a session when its project's proxies log
+// more than Threshold frontend errors or 5xx responses within a window.
+type AlertRule struct {
+	ID              string   `json:"id,omitempty"`               // Rule ID (default alert-N)
+	Kinds           []string `json:"kinds,omitempty"`            // frontend_error and/or http_5xx (default both)
+	Threshold       int      `json:"threshold,omitempty"`        // Alert when more than this many errors arrive (default 5)
+	WindowSeconds   int      `json:"window_seconds,omitempty"`   // Window the errors are counted in (default 60)
+	CooldownSeconds int      `json:"cooldown_seconds,omitempty"` // Quiet time after an alert (default 300)
+	ProxyID         string   `json:"proxy_id,omitempty"`         // Only count this proxy's errors
+	Match           string   `json:"match,omitempty"`            // Only count errors whose message or URL matches this regex
+	Deliver         string   `json:"deliver,omitempty"`          // session (default), toast or both
+}
+
+// withDefaults fills unset fields.
+func (r AlertRule) withDefaults() AlertRule {
+	if len(r.Kinds) == 0 {
+		r.Kinds = alertKinds
+	}
+	if r.Threshold == 0 {
+		r.Threshold = 5
+	}
+	if r.WindowSeconds == 0 {
+		r.WindowSeconds = 60
+	}
+	if r.CooldownSeconds == 0 {
+		r.CooldownSeconds = 300
+	}
+	if r.Deliver == "" {
+		r.Deliver = DigestDeliverSession
+	}
+	return r
+}
+
+// Validate checks the kinds, numbers, pattern and delivery target.
+func (r AlertRule) Validate() error {
+	for _, kind := range r.Kinds {
+		if !slices.Contains(alertKinds, kind) {
+			return fmt.Errorf("unknown kind %q (use %s)", kind, strings.Join(alertKinds, ", "))
+		}
+	}
+	if r.Threshold < 0 || r.WindowSeconds < 0 || r.CooldownSeconds < 0 {
+		return fmt.Errorf("alert threshold, window and cooldown must not be negative")
+	}
+	if r.Match != "" {
+		if _, err := regexp.Compile(r.Match); err != nil {
+			return fmt.Errorf("invalid match pattern: %w", err)
+		}
+	}
+	switch r.Deliver {
+	case "", DigestDeliverSession, DigestDeliverToast, DigestDeliverBoth:
+		return nil
+	}
+	return fmt.Errorf("invalid alert delivery %q: use session, toast or both", r.Deliver)
+}
+
+func (r AlertRule) window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+func (r AlertRule) cooldown() time.Duration {
+	return time.Duration(r.CooldownSeconds) * time.Second
+}
+
+// alertState is one rule of a session and the errors it has counted.
+type alertState struct {
+	rule          AlertRule
+	pattern       *regexp.Regexp
+	hits          []debugEvent // Counted errors inside the window, oldest first
+	cooldownUntil time.Time
+	fired         int
+	lastFired     time.Time
+	lastSummary   string
+}
+
+// matches reports whether the rule counts an event of the session's project.
+func (s *alertState) matches(e debugEvent) bool {
+	if !slices.Contains(s.rule.Kinds, e.Kind) {
+		return false
+	}
+	if s.rule.ProxyID != "" {
+		if rank, _ := matchEntityID(s.rule.ProxyID, e.Source); rank > matchComponent {
+			return false
+		}
+	}
+	if s.pattern != nil {
+		text := e.Message
+		switch {
+		case e.Error != nil:
+			text += "\n" + e.Error.URL
+		case e.HTTP != nil:
+			text += "\n" + e.HTTP.URL
+		}
+		if !s.pattern.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// observe counts an event and reports whether the rule fires.
+func (s *alertState) observe(e debugEvent, now time.Time) bool {
+	start := now.Add(-s.rule.window())
+	i := 0
+	for i < len(s.hits) && s.hits[i].Time.Before(start) {
+		i++
+	}
+	s.hits = append(s.hits[i:], e)
+	if len(s.hits) <= s.rule.Threshold || now.Before(s.cooldownUntil) {
+		return false
+	}
+	return true
+}
+
+// status describes the rule for SESSION ALERT.
+func (s *alertState) status() map[string]interface{} {
+	status := map[string]interface{}{
+		"rule":  s.rule,
+		"count": len(s.hits),
+		"fired": s.fired,
+	}
+	if !s.lastFired.IsZero() {
+		status["last_fired"] = s.lastFired.Format(time.RFC3339)
+		status["last_summary"] = s.lastSummary
+	}
+	if time.Now().Before(s.cooldownUntil) {
+		status["cooldown_until"] = s.cooldownUntil.Format(time.RFC3339)
+	}
+	return status
+}
+
+// alertEngine holds the alert rules of sessions. The proxies' log hook
+// checks active first, so rules cost nothing when there are none.
+type alertEngine struct {
+	mu     sync.Mutex
+	rules  map[string][]*alertState // By session code
+	nextID int
+	n      atomic.Int32
+}
+
+func (a *alertEngine) active() bool {
+	return a.n.Load() > 0
+}
+
+// add adds a rule to a session, replacing one with the same ID.
+func (a *alertEngine) add(code string, rule AlertRule) *alertState {
+	rule = rule.withDefaults()
+	state := &alertState{rule: rule}
+	if rule.Match != "" {
+		state.pattern = regexp.MustCompile(rule.Match)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rules == nil {
+		a.rules = make(map[string][]*alertState)
+	}
+	if state.rule.ID == "" {
+		a.nextID++
+		state.rule.ID = fmt.Sprintf("alert-%d", a.nextID)
+	}
+	states := a.rules[code]
+	if i := slices.IndexFunc(states, func(s *alertState) bool { return s.rule.ID == state.rule.ID }); i >= 0 {
+		states[i] = state
+	} else {
+		a.rules[code] = append(states, state)
+		a.n.Add(1)
+	}
+	return state
+}
+
+// remove removes a rule of a session and reports whether it existed.
+func (a *alertEngine) remove(code, id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	states := a.rules[code]
+	i := slices.IndexFunc(states, func(s *alertState) bool { return s.rule.ID == id })
+	if i < 0 {
+		return false
+	}
+	if states = slices.Delete(states, i, i+1); len(states) == 0 {
+		delete(a.rules, code)
+	} else {
+		a.rules[code] = states
+	}
+	a.n.Add(-1)
+	return true
+}
+
+// drop removes all rules of a session.
+func (a *alertEngine) drop(code string) {
+	a.n.Add(-int32(len(a.rules[code])))
+	delete(a.rules, code)
+}
+
+// list describes the rules of a session.
+func (a *alertEngine) list(code string) []map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]map[string]interface{}, 0, len(a.rules[code]))
+	for _, s := range a.rules[code] {
+		list = append(list, s.status())
+	}
+	return list
+}
+
+// observeAlert counts a proxy error against the alert rules of the sessions
+// in its project and pushes a summary for each rule that fires. Rules of
+// sessions that are gone are dropped.
+func (d *Daemon) observeAlert(e debugEvent) {
+	type firing struct {
+		session *Session
+		rule    AlertRule
+		summary string
+	}
+	var list []firing
+	path := normalizePath(e.Path)
+	now := time.Now()
+
+	d.alerts.mu.Lock()
+	for code, states := range d.alerts.rules {
+		session, ok := d.sessionRegistry.Get(code)
+		if !ok {
+			d.alerts.drop(code)
+			continue
+		}
+		if normalizePath(session.ProjectPath) != path {
+			continue
+		}
+		for _, s := range states {
+			if !s.matches(e) || !s.observe(e, now) {
+				continue
+			}
+			summary := composeAlert(s.rule, s.hits, now)
+			s.fired++
+			s.lastFired, s.lastSummary = now, summary
+			s.cooldownUntil = now.Add(s.rule.cooldown())
+			s.hits = nil
+			list = append(list, firing{session, s.rule, summary})
+		}
+	}
+	d.alerts.mu.Unlock()
+
+	// Deliver off the proxy's request path
+	for _, f := range list {
+		go func() {
+			if err := d.deliverToSession(f.session, f.rule.Deliver, "agnt alert", f.summary); err != nil {
+				log.Printf("[WARN] failed to deliver alert %s to session %s: %v", f.rule.ID, f.session.Code, err)
+			}
+		}()
+	}
+}
+
+// composeAlert summarizes the errors that fired a rule in one line.
+func composeAlert(rule AlertRule, hits []debugEvent, now time.Time) string {
+	counts := make(map[string]int)
+	var sources []string
+	for _, e := range hits {
+		switch {
+		case e.Error != nil:
+			counts[truncateDigest(e.Message, 80)]++
+		case e.HTTP != nil:
+			counts[fmt.Sprintf("%s %s → %d", e.HTTP.Method, digestPath(e.HTTP.URL), e.HTTP.StatusCode)]++
+		default:
+			counts[truncateDigest(e.Message, 80)]++
+		}
+		if !slices.Contains(sources, e.Source) {
+			sources = append(sources, e.Source)
+		}
+	}
+	span := rule.window()
+	if len(hits) > 0 {
+		span = max(now.Sub(hits[0].Time), time.Second)
+	}
+	n := len(hits)
+	return fmt.Sprintf("[agnt alert] Error spike on %s: %d %s in %s (%s). Check proxylog for details.",
+		strings.Join(sources, ", "), n, plural(n, "error", "errors"), span.Round(time.Second), topCounts(counts))
+}
+
+// hubHandleSessionAlert handles SESSION ALERT <code> [REMOVE <rule_id>].
+// With a JSON AlertRule, adds (or replaces) a rule; with REMOVE, removes
+// one; otherwise lists the session's rules and their state.
+func (d *Daemon) hubHandleSessionAlert(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION ALERT requires: <code>")
+	}
+	code := cmd.Args[0]
+	if _, ok := d.sessionRegistry.Get(code); !ok {
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
+	}
+
+	if len(cmd.Args) > 1 {
+		if !strings.EqualFold(cmd.Args[1], "remove") || len(cmd.Args) < 3 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, "usage: SESSION ALERT <code> REMOVE <rule_id>")
+		}
+		id := cmd.Args[2]
+		if !d.alerts.remove(code, id) {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %s has no alert rule %q", code, id))
+		}
+		data, _ := json.Marshal(map[string]interface{}{"session_code": code, "removed": id, "alerts": d.alerts.list(code)})
+		return conn.WriteJSON(data)
+	}
+
+	resp := map[string]interface{}{"session_code": code}
+	if len(cmd.Data) > 0 {
+		var rule AlertRule
+		if err := decodeData(cmd, &rule); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		if err := rule.Validate(); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["added"] = d.alerts.add(code, rule).rule.ID
+	}
+	resp["alerts"] = d.alerts.list(code)
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
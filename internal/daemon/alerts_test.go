@@ -0,0 +1,122 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestComposeAlert(t *testing.T) {
+	now := time.Now()
+	hits := []debugEvent{
+		{Kind: eventHTTPError, Time: now.Add(-20 * time.Second), Source: "app", HTTP: &proxy.HTTPLogEntry{Method: "GET", URL: "http://localhost:3000/api/orders?id=1", StatusCode: 502}},
+		{Kind: eventHTTPError, Time: now.Add(-10 * time.Second), Source: "app", HTTP: &proxy.HTTPLogEntry{Method: "GET", URL: "http://localhost:3000/api/orders?id=2", StatusCode: 502}},
+		{Kind: eventFrontendError, Time: now, Source: "app", Message: "TypeError: x is undefined"},
+	}
+	summary := composeAlert(AlertRule{}.withDefaults(), hits, now)
+	for _, want := range []string{"Error spike on app", "3 errors in 20s", "GET /api/orders → 502 ×2", "TypeError: x is undefined"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary %q lacks %q", summary, want)
+		}
+	}
+}
+
+func TestAlertRuleValidate(t *testing.T) {
+	for _, rule := range []AlertRule{
+		{Kinds: []string{"process_failure"}},
+		{Threshold: -1},
+		{Match: "("},
+		{Deliver: "email"},
+	} {
+		if rule.Validate() == nil {
+			t.Errorf("Expected %+v to be invalid", rule)
+		}
+	}
+	if err := (AlertRule{Kinds: []string{eventHTTPError}, Match: "/api/", Deliver: DigestDeliverBoth}).Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+func TestObserveAlert(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Overlay that records injected messages
+	overlayPath := filepath.Join(tmpDir, "overlay.sock")
+	listener, err := net.Listen("unix", overlayPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	injected := make(chan string, 4)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		injected <- string(body)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	if err := d.sessionRegistry.Register(&Session{Code: "claude-1", OverlayPath: overlayPath, ProjectPath: tmpDir, Status: SessionStatusActive}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer px.Stop(context.Background())
+
+	d.alerts.add("claude-1", AlertRule{Threshold: 2, Match: "boom"})
+	d.alerts.add("gone", AlertRule{})
+	if d.alerts.n.Load() != 2 {
+		t.Fatalf("Expected 2 rules, got %d", d.alerts.n.Load())
+	}
+
+	px.Logger().LogError(proxy.FrontendError{ID: "e1", Timestamp: time.Now(), Message: "boom"})
+	px.Logger().LogError(proxy.FrontendError{ID: "e2", Timestamp: time.Now(), Message: "unrelated"})
+	px.Logger().LogError(proxy.FrontendError{ID: "e3", Timestamp: time.Now(), Message: "boom"})
+	select {
+	case message := <-injected:
+		t.Fatalf("Expected no alert at the threshold, got %q", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if d.alerts.active() && len(d.alerts.rules) != 1 {
+		t.Error("Expected the rules of an unknown session dropped")
+	}
+
+	px.Logger().LogError(proxy.FrontendError{ID: "e4", Timestamp: time.Now(), Message: "boom"})
+	select {
+	case message := <-injected:
+		if !strings.Contains(message, "3 errors") || !strings.Contains(message, "boom ×3") {
+			t.Errorf("Unexpected alert %q", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the alert delivered to the session")
+	}
+
+	// Cooling down: more errors stay quiet
+	for range 4 {
+		px.Logger().LogError(proxy.FrontendError{Timestamp: time.Now(), Message: "boom"})
+	}
+	select {
+	case message := <-injected:
+		t.Fatalf("Expected no alert during the cooldown, got %q", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	rules := d.alerts.list("claude-1")
+	if len(rules) != 1 || rules[0]["fired"] != 1 || rules[0]["cooldown_until"] == nil {
+		t.Errorf("rules = %v", rules)
+	}
+	if !d.alerts.remove("claude-1", "alert-1") || d.alerts.active() {
+		t.Error("Expected the last rule removed")
+	}
+}
@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/stack"
+)
+
+// autostartPlan holds the autostart scripts and proxies that wait for the
+// scripts they depend on (depends-on in .agnt.kdl).
+type autostartPlan struct {
+	// Levels orders the waiting scripts and their dependencies: each level
+	// starts once the scripts of the previous ones are ready
+	Levels  [][]string
+	Scripts []string
+	Proxies []string
+}
+
+// newAutostartPlan takes the scripts and proxies with depends-on out of
+// scripts and proxies and plans their start after their dependencies. It
+// returns nil when nothing waits, and leaves both maps alone on error.
+func newAutostartPlan(cfg *config.AgntConfig, scripts map[string]*config.ScriptConfig, proxies map[string]*config.ProxyConfig) (*autostartPlan, error) {
+	plan := &autostartPlan{}
+	deps := make(map[string][]string)
+
+	// add plans a script and, transitively, what it depends on
+	var add func(name string) error
+	add = func(name string) error {
+		if _, ok := deps[name]; ok {
+			return nil
+		}
+		script := cfg.Scripts[name]
+		if _, err := scriptReadyCheck(script); err != nil {
+			return fmt.Errorf("script %s: %w", name, err)
+		}
+		deps[name] = script.DependsOn
+		for _, dep := range script.DependsOn {
+			if cfg.Scripts[dep] == nil {
+				return fmt.Errorf("script %s depends on unknown script %s", name, dep)
+			}
+			if err := add(dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for name, script := range scripts {
+		if script == nil || len(script.DependsOn) == 0 {
+			continue
+		}
+		if err := add(name); err != nil {
+			return nil, err
+		}
+		plan.Scripts = append(plan.Scripts, name)
+	}
+	for name, p := range proxies {
+		if p == nil || len(p.DependsOn) == 0 {
+			continue
+		}
+		for _, dep := range p.DependsOn {
+			if cfg.Scripts[dep] == nil {
+				return nil, fmt.Errorf("proxy %s depends on unknown script %s", name, dep)
+			}
+			if err := add(dep); err != nil {
+				return nil, err
+			}
+		}
+		plan.Proxies = append(plan.Proxies, name)
+	}
+	if len(deps) == 0 {
+		return nil, nil
+	}
+
+	levels, err := stack.Plan(deps)
+	if err != nil {
+		return nil, fmt.Errorf("depends-on: %w", err)
+	}
+	plan.Levels = levels
+	sort.Strings(plan.Scripts)
+	sort.Strings(plan.Proxies)
+	for _, name := range plan.Scripts {
+		delete(scripts, name)
+	}
+	for _, name := range plan.Proxies {
+		delete(proxies, name)
+	}
+	return plan, nil
+}
+
+// runAutostartPlan starts the waiting scripts level by level, each once the
+// scripts it depends on are ready, then the waiting proxies. Anything whose
+// dependencies didn't become ready is left stopped.
+func (d *Daemon) runAutostartPlan(ctx context.Context, cfg *config.AgntConfig, projectPath string, plan *autostartPlan) {
+	var mu sync.Mutex
+	ready := make(map[string]bool)
+	notReady := func(deps []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, dep := range deps {
+			if !ready[dep] {
+				return dep
+			}
+		}
+		return ""
+	}
+
+	for _, level := range plan.Levels {
+		var wg sync.WaitGroup
+		for _, name := range level {
+			script := cfg.Scripts[name]
+			if dep := notReady(script.DependsOn); dep != "" {
+				log.Printf("[WARN] autostart: not starting script %s: %s isn't ready", name, dep)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := d.startScriptReady(ctx, cfg, name, script, projectPath)
+				if err != nil {
+					log.Printf("[WARN] autostart: script %s: %v", name, err)
+				}
+				mu.Lock()
+				ready[name] = err == nil
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+
+	for _, name := range plan.Proxies {
+		p := cfg.Proxies[name]
+		if dep := notReady(p.DependsOn); dep != "" {
+			log.Printf("[WARN] autostart: not starting proxy %s: %s isn't ready", name, dep)
+			continue
+		}
+		if err := d.autostartProxy(ctx, name, p, projectPath); err != nil {
+			log.Printf("[WARN] autostart: proxy %s: %v", name, err)
+		}
+	}
+}
+
+// startScriptReady starts a script, unless it already runs, and waits
+// until it passes its ready check.
+func (d *Daemon) startScriptReady(ctx context.Context, cfg *config.AgntConfig, name string, script *config.ScriptConfig, projectPath string) error {
+	processID := makeProcessID(projectPath, name)
+	if proc, err := d.hub.ProcessManager().Get(processID); err == nil && proc.IsDone() {
+		d.hub.ProcessManager().RemoveByPath(processID, proc.ProjectPath)
+	}
+	if err := d.autostartScript(ctx, name, script, projectPath, cfg.Proxies); err != nil {
+		return err
+	}
+	check, err := scriptReadyCheck(script)
+	if err != nil {
+		return err
+	}
+	return d.waitReady(ctx, processID, projectPath, check, scriptReadyTimeout(script))
+}
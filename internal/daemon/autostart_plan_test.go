@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/config"
+)
+
+func TestNewAutostartPlan(t *testing.T) {
+	cfg := config.DefaultAgntConfig()
+	cfg.Scripts = map[string]*config.ScriptConfig{
+		"db":  {Run: "db", Ports: []int{5432}},
+		"api": {Run: "api", Autostart: true, DependsOn: []string{"db"}, ReadyURL: "http://localhost:8080/health"},
+		"web": {Run: "web", Autostart: true, DependsOn: []string{"api"}, ReadyLog: "ready in"},
+		"doc": {Run: "doc", Autostart: true},
+	}
+	cfg.Proxies = map[string]*config.ProxyConfig{
+		"frontend": {Port: 3000, Autostart: true, DependsOn: []string{"web"}},
+		"backend":  {Port: 8080, Autostart: true},
+	}
+
+	scripts := cfg.GetAutostartScripts()
+	proxies := cfg.GetAutostartProxies()
+	plan, err := newAutostartPlan(cfg, scripts, proxies)
+	if err != nil {
+		t.Fatalf("newAutostartPlan failed: %v", err)
+	}
+	if got := fmt.Sprint(plan.Levels); got != "[[db] [api] [web]]" {
+		t.Errorf("Unexpected levels %s", got)
+	}
+	if fmt.Sprint(plan.Scripts) != "[api web]" || fmt.Sprint(plan.Proxies) != "[frontend]" {
+		t.Errorf("Unexpected waiting scripts %v and proxies %v", plan.Scripts, plan.Proxies)
+	}
+	if len(scripts) != 1 || scripts["doc"] == nil {
+		t.Errorf("Expected only doc to start right away, got %v", scripts)
+	}
+	if len(proxies) != 1 || proxies["backend"] == nil {
+		t.Errorf("Expected only backend to start right away, got %v", proxies)
+	}
+}
+
+func TestNewAutostartPlanNothingWaits(t *testing.T) {
+	cfg := config.DefaultAgntConfig()
+	cfg.Scripts = map[string]*config.ScriptConfig{"dev": {Run: "dev", Autostart: true}}
+	plan, err := newAutostartPlan(cfg, cfg.GetAutostartScripts(), cfg.GetAutostartProxies())
+	if err != nil || plan != nil {
+		t.Errorf("Expected no plan, got %+v, %v", plan, err)
+	}
+}
+
+func TestNewAutostartPlanErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts map[string]*config.ScriptConfig
+		proxies map[string]*config.ProxyConfig
+		want    string
+	}{
+		{
+			name:    "unknown script",
+			scripts: map[string]*config.ScriptConfig{"web": {Autostart: true, DependsOn: []string{"api"}}},
+			want:    "depends on unknown script api",
+		},
+		{
+			name:    "unknown proxy dependency",
+			proxies: map[string]*config.ProxyConfig{"frontend": {Port: 3000, Autostart: true, DependsOn: []string{"web"}}},
+			want:    "proxy frontend depends on unknown script web",
+		},
+		{
+			name: "cycle",
+			scripts: map[string]*config.ScriptConfig{
+				"a": {Autostart: true, DependsOn: []string{"b"}},
+				"b": {DependsOn: []string{"a"}},
+			},
+			want: "cycle",
+		},
+		{
+			name: "ready-log",
+			scripts: map[string]*config.ScriptConfig{
+				"api": {ReadyLog: "("},
+				"web": {Autostart: true, DependsOn: []string{"api"}},
+			},
+			want: "ready-log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultAgntConfig()
+			if tt.scripts != nil {
+				cfg.Scripts = tt.scripts
+			}
+			if tt.proxies != nil {
+				cfg.Proxies = tt.proxies
+			}
+			scripts := cfg.GetAutostartScripts()
+			before := len(scripts)
+			_, err := newAutostartPlan(cfg, scripts, cfg.GetAutostartProxies())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+			if len(scripts) != before {
+				t.Error("Scripts were taken out despite the error")
+			}
+		})
+	}
+}
+
+func TestScriptReadyCheck(t *testing.T) {
+	check, err := scriptReadyCheck(&config.ScriptConfig{Ports: []int{3000, 3001}})
+	if err != nil || check.Port != 3000 {
+		t.Errorf("Expected the first declared port, got %+v, %v", check, err)
+	}
+	check, err = scriptReadyCheck(&config.ScriptConfig{Ports: []int{3000}, ReadyLog: "listening"})
+	if err != nil || check.Port != 0 || check.Log == nil {
+		t.Errorf("Expected the ready-log check only, got %+v, %v", check, err)
+	}
+	if check, _ := scriptReadyCheck(&config.ScriptConfig{}); !check.none() {
+		t.Errorf("Expected no check, got %+v", check)
+	}
+}
@@ -0,0 +1,464 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/bench"
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/testhistory"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// defaultBenchTimeout bounds a BENCH RUN when no timeout is given.
+	defaultBenchTimeout = 20 * time.Minute
+
+	// defaultBenchCount is how many samples each benchmark takes by default.
+	defaultBenchCount = 5
+
+	// defaultGoBenchSuite names the implicit suite for Go projects without configured benchmarks.
+	defaultGoBenchSuite = "go"
+
+	// benchHistoryLimit is how many runs BENCH HISTORY considers.
+	benchHistoryLimit = 50
+)
+
+// benchHistory returns the shared benchmark history for a project.
+func (d *Daemon) benchHistory(projectPath string) *bench.History {
+	d.benchHistoryMu.Lock()
+	defer d.benchHistoryMu.Unlock()
+
+	h, ok := d.benchHistories[projectPath]
+	if !ok {
+		h = bench.Open(projectPath)
+		d.benchHistories[projectPath] = h
+	}
+	return h
+}
+
+// hubHandleBench handles the BENCH command and its sub-verbs.
+func (d *Daemon) hubHandleBench(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "BENCH %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "RUN":
+		return d.hubHandleBenchRun(ctx, conn, cmd)
+	case "REPORT":
+		return d.hubHandleBenchReport(conn, cmd)
+	case "HISTORY":
+		return d.hubHandleBenchHistory(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown BENCH sub-command",
+			Command:      "BENCH",
+			ValidActions: []string{"RUN", "REPORT", "HISTORY"},
+		})
+	}
+}
+
+// benchRequest is the JSON payload shared by BENCH sub-verbs.
+type benchRequest struct {
+	Bench     string  `json:"bench"`
+	Package   string  `json:"package"`
+	Count     int     `json:"count"`
+	Baseline  string  `json:"baseline"`
+	Threshold float64 `json:"threshold"`
+	Timeout   string  `json:"timeout"`
+	Path      string  `json:"path"`
+}
+
+// resolveBenchSuite picks the configured benchmark suite for name, applying
+// request overrides. An empty name selects the only configured suite, or the
+// implicit Go suite when none are configured.
+func resolveBenchSuite(projectPath, name string, req benchRequest) (string, *config.BenchConfig, error) {
+	cfg, err := config.LoadAgntConfig(projectPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var suite *config.BenchConfig
+	switch {
+	case name != "":
+		suite = cfg.Benchmarks[name]
+		if suite == nil && name != defaultGoBenchSuite {
+			return "", nil, fmt.Errorf("benchmark %q is not configured in .agnt.kdl", name)
+		}
+	case len(cfg.Benchmarks) == 1:
+		for n, s := range cfg.Benchmarks {
+			name, suite = n, s
+		}
+	case len(cfg.Benchmarks) > 1:
+		names := make([]string, 0, len(cfg.Benchmarks))
+		for n := range cfg.Benchmarks {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", nil, fmt.Errorf("several benchmarks configured, name one of: %s", strings.Join(names, ", "))
+	default:
+		name = defaultGoBenchSuite
+	}
+
+	// Copy so request overrides don't leak into the loaded config
+	resolved := &config.BenchConfig{}
+	if suite != nil {
+		*resolved = *suite
+	}
+	if req.Bench != "" {
+		resolved.Bench = req.Bench
+	}
+	if req.Package != "" {
+		resolved.Package = req.Package
+	}
+	if req.Count > 0 {
+		resolved.Count = req.Count
+	}
+	if req.Baseline != "" {
+		resolved.Baseline = req.Baseline
+	}
+	if req.Threshold > 0 {
+		resolved.Threshold = req.Threshold
+	}
+	if resolved.Count <= 0 {
+		resolved.Count = defaultBenchCount
+	}
+	if resolved.Threshold <= 0 {
+		resolved.Threshold = bench.DefaultThreshold
+	}
+
+	if resolved.Run == "" && resolved.Command == "" {
+		if _, err := os.Stat(filepath.Join(resolveWorkingDir(projectPath, resolved.Cwd), "go.mod")); err != nil {
+			return "", nil, fmt.Errorf("benchmark %q has no run or command, and is not a Go module", name)
+		}
+	}
+	return name, resolved, nil
+}
+
+// benchCommand returns the command line for a benchmark suite.
+func benchCommand(suite *config.BenchConfig) (string, []string) {
+	switch {
+	case suite.Run != "":
+		return "sh", []string{"-c", suite.Run}
+	case suite.Command != "":
+		return suite.Command, suite.Args
+	}
+	pattern := suite.Bench
+	if pattern == "" {
+		pattern = "."
+	}
+	pkg := suite.Package
+	if pkg == "" {
+		pkg = "./..."
+	}
+	return "go", []string{"test", "-run=^$", "-bench=" + pattern, "-benchmem", fmt.Sprintf("-count=%d", suite.Count), pkg}
+}
+
+// hubHandleBenchRun handles BENCH RUN [name].
+// Runs a benchmark suite as a managed process, records its results against
+// the current commit and compares them with the baseline. Blocks until the
+// benchmarks finish or the timeout elapses.
+func (d *Daemon) hubHandleBenchRun(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req benchRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "BENCH RUN requires a session or path")
+	}
+
+	timeout := defaultBenchTimeout
+	if req.Timeout != "" {
+		parsed, err := time.ParseDuration(req.Timeout)
+		if err != nil || parsed <= 0 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid timeout %q (use e.g. '10m')", req.Timeout))
+		}
+		timeout = parsed
+	}
+
+	name := ""
+	if len(cmd.Args) > 0 {
+		name = cmd.Args[0]
+	}
+	name, suite, err := resolveBenchSuite(projectPath, name, req)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	processID := makeProcessID(projectPath, "bench-"+name)
+	command, args := benchCommand(suite)
+	proc, err := d.startFreshProcess(ctx, process.ProcessConfig{
+		ID:          processID,
+		ProjectPath: resolveWorkingDir(projectPath, suite.Cwd),
+		Command:     command,
+		Args:        args,
+	})
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start benchmark: %v", err))
+	}
+
+	select {
+	case <-proc.Done():
+	case <-time.After(timeout):
+		stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		d.hub.ProcessManager().Stop(stopCtx, processID)
+		cancel()
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("benchmark %s timed out after %s", name, timeout))
+	}
+
+	out, _ := proc.CombinedOutput()
+	results := bench.ParseOutput(string(out))
+	if len(results) == 0 {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("no benchmark results in output of %s (exit code %d)", processID, proc.ExitCode()))
+	}
+
+	run := &bench.Run{Suite: name, Results: results}
+	var dirty map[string]string
+	run.Commit, dirty = testhistory.Snapshot(projectPath)
+	run.Dirty = len(dirty) > 0
+
+	history := d.benchHistory(projectPath)
+	if err := history.Append(run); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	resp, err := d.benchReport(projectPath, run, suite)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	resp["process_id"] = processID
+	resp["exit_code"] = proc.ExitCode()
+
+	if regressions, _ := resp["regressions"].(int); regressions > 0 {
+		d.notifyBenchRegressions(projectPath, name, resp["comparisons"].([]bench.Comparison))
+	}
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleBenchReport handles BENCH REPORT [name].
+// Compares the latest recorded run of a suite with its baseline without running it.
+func (d *Daemon) hubHandleBenchReport(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req benchRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "BENCH REPORT requires a session or path")
+	}
+
+	name := ""
+	if len(cmd.Args) > 0 {
+		name = cmd.Args[0]
+	}
+	name, suite, err := resolveBenchSuite(projectPath, name, req)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	runs, err := d.benchHistory(projectPath).Runs()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	var latest *bench.Run
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Suite == name {
+			latest = runs[i]
+			break
+		}
+	}
+	if latest == nil {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no recorded runs of benchmark %q (use BENCH RUN)", name))
+	}
+
+	resp, err := d.benchReport(projectPath, latest, suite)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// benchReport compares a run with its baseline and builds the response.
+func (d *Daemon) benchReport(projectPath string, run *bench.Run, suite *config.BenchConfig) (map[string]interface{}, error) {
+	baseCommit := ""
+	if suite.Baseline != "" {
+		commit, err := bench.ResolveCommit(projectPath, suite.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		baseCommit = commit
+	}
+
+	runs, err := d.benchHistory(projectPath).Runs()
+	if err != nil {
+		return nil, err
+	}
+	base := bench.Baseline(runs, run, baseCommit)
+	comparisons := bench.CompareRuns(base, run, bench.DefaultAlpha, suite.Threshold)
+
+	regressions, improvements := 0, 0
+	for _, c := range comparisons {
+		if c.Regression {
+			regressions++
+		}
+		if c.Improvement {
+			improvements++
+		}
+	}
+
+	resp := map[string]interface{}{
+		"run_id":       run.ID,
+		"suite":        run.Suite,
+		"commit":       shortCommit(run.Commit),
+		"dirty":        run.Dirty,
+		"benchmarks":   len(run.Samples()),
+		"comparisons":  comparisons,
+		"regressions":  regressions,
+		"improvements": improvements,
+		"threshold":    suite.Threshold,
+	}
+	if len(base) > 0 {
+		resp["baseline_commit"] = shortCommit(base[0].Commit)
+		resp["baseline_runs"] = len(base)
+	} else {
+		resp["message"] = "no baseline yet: commit and run again to compare"
+	}
+	return resp, nil
+}
+
+// notifyBenchRegressions shows a toast in the project's proxied pages.
+func (d *Daemon) notifyBenchRegressions(projectPath, suite string, comparisons []bench.Comparison) {
+	var parts []string
+	for _, c := range comparisons {
+		if c.Regression {
+			parts = append(parts, fmt.Sprintf("%s %+.1f%% %s", c.Name, c.DeltaPct, c.Unit))
+		}
+	}
+	if len(parts) > 3 {
+		parts = append(parts[:3], fmt.Sprintf("and %d more", len(parts)-3))
+	}
+	message := strings.Join(parts, ", ")
+
+	for _, p := range d.proxym.List() {
+		if normalizePath(p.Path) == projectPath {
+			p.BroadcastToast("warning", fmt.Sprintf("Benchmark regression (%s)", suite), message, 0)
+		}
+	}
+}
+
+// hubHandleBenchHistory handles BENCH HISTORY [benchmark_filter].
+// Returns the per-commit series of each matching benchmark's primary metric.
+func (d *Daemon) hubHandleBenchHistory(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "BENCH HISTORY requires a session")
+	}
+
+	runs, err := d.benchHistory(projectPath).Runs()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	if len(runs) > benchHistoryLimit {
+		runs = runs[len(runs)-benchHistoryLimit:]
+	}
+
+	filter := ""
+	if len(cmd.Args) > 0 {
+		filter = cmd.Args[0]
+	}
+
+	type point struct {
+		RunID     string    `json:"run_id"`
+		Timestamp time.Time `json:"timestamp"`
+		Commit    string    `json:"commit"`
+		Dirty     bool      `json:"dirty,omitempty"`
+		Mean      float64   `json:"mean"`
+		N         int       `json:"n"`
+	}
+	type series struct {
+		Name   string  `json:"name"`
+		Suite  string  `json:"suite"`
+		Unit   string  `json:"unit"`
+		Points []point `json:"points"`
+	}
+
+	byName := make(map[string]*series)
+	for _, run := range runs {
+		for name, byUnit := range run.Samples() {
+			if filter != "" && !strings.Contains(name, filter) {
+				continue
+			}
+			s, ok := byName[name]
+			if !ok {
+				s = &series{Name: name, Suite: run.Suite, Unit: primaryUnit(byUnit)}
+				byName[name] = s
+			}
+			values := byUnit[s.Unit]
+			if len(values) == 0 {
+				continue
+			}
+			sum := 0.0
+			for _, v := range values {
+				sum += v
+			}
+			s.Points = append(s.Points, point{
+				RunID:     run.ID,
+				Timestamp: run.Timestamp,
+				Commit:    shortCommit(run.Commit),
+				Dirty:     run.Dirty,
+				Mean:      sum / float64(len(values)),
+				N:         len(values),
+			})
+		}
+	}
+
+	list := make([]*series, 0, len(byName))
+	for _, s := range byName {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	resp := map[string]interface{}{
+		"series": list,
+		"count":  len(list),
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// primaryUnit picks the metric a benchmark series is charted by: ns/op when
+// present, otherwise the alphabetically first unit.
+func primaryUnit(byUnit map[string][]float64) string {
+	if _, ok := byUnit["ns/op"]; ok {
+		return "ns/op"
+	}
+	units := make([]string, 0, len(byUnit))
+	for u := range byUnit {
+		units = append(units, u)
+	}
+	sort.Strings(units)
+	if len(units) == 0 {
+		return ""
+	}
+	return units[0]
+}
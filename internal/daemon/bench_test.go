@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/config"
+)
+
+func TestResolveBenchSuite(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n"), 0644)
+
+	// No configured suites: implicit Go suite with defaults
+	name, suite, err := resolveBenchSuite(dir, "", benchRequest{Bench: "BenchmarkParse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != defaultGoBenchSuite || suite.Count != defaultBenchCount || suite.Bench != "BenchmarkParse" {
+		t.Errorf("got %s %+v", name, suite)
+	}
+
+	os.WriteFile(filepath.Join(dir, config.AgntConfigFileName), []byte(`benchmarks {
+    render {
+        run "node bench.js"
+        threshold 2
+    }
+    parser {
+        package "./parser"
+    }
+}`), 0644)
+
+	if _, _, err := resolveBenchSuite(dir, "", benchRequest{}); err == nil || !strings.Contains(err.Error(), "parser, render") {
+		t.Errorf("expected ambiguity error naming suites, got %v", err)
+	}
+	if _, _, err := resolveBenchSuite(dir, "missing", benchRequest{}); err == nil {
+		t.Error("expected error for unknown suite")
+	}
+
+	name, suite, err = resolveBenchSuite(dir, "render", benchRequest{Count: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "render" || suite.Run != "node bench.js" || suite.Threshold != 2 || suite.Count != 8 {
+		t.Errorf("got %s %+v", name, suite)
+	}
+}
+
+func TestBenchCommand(t *testing.T) {
+	cmd, args := benchCommand(&config.BenchConfig{Count: 5})
+	if cmd != "go" || strings.Join(args, " ") != "test -run=^$ -bench=. -benchmem -count=5 ./..." {
+		t.Errorf("go command = %s %v", cmd, args)
+	}
+
+	cmd, args = benchCommand(&config.BenchConfig{Run: "node bench.js"})
+	if cmd != "sh" || strings.Join(args, " ") != "-c node bench.js" {
+		t.Errorf("run command = %s %v", cmd, args)
+	}
+}
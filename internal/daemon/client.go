@@ -3,10 +3,16 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/standardbeagle/agnt/internal/debug"
 	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/ptyhost"
+	"github.com/standardbeagle/agnt/internal/workspace"
 	"github.com/standardbeagle/go-cli-server/client"
 )
 
@@ -164,6 +170,26 @@ func (c *Client) ProcStatus(processID string) (map[string]interface{}, error) {
 
 // ProcOutput gets the output of a process.
 func (c *Client) ProcOutput(processID string, filter protocol.OutputFilter) (string, error) {
+	args := procOutputArgs(processID, filter)
+	return c.conn.Request(protocol.VerbProc, args...).String()
+}
+
+// ProcOutputFollow gets the output of a process followed by the lines it
+// writes until it exits or the timeout passes. The timeout defaults to
+// DefaultFollowTimeout and is capped at MaxFollowTimeout, since the whole
+// stream is collected into one response.
+func (c *Client) ProcOutputFollow(processID string, filter protocol.OutputFilter, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		timeout = DefaultFollowTimeout
+	}
+	timeout = min(timeout, MaxFollowTimeout)
+	args := procOutputArgs(processID, filter)
+	args = append(args, "follow", fmt.Sprintf("timeout_ms=%d", timeout.Milliseconds()))
+	return c.conn.Request(protocol.VerbProc, args...).String()
+}
+
+// procOutputArgs returns the PROC OUTPUT args for a filter.
+func procOutputArgs(processID string, filter protocol.OutputFilter) []string {
 	args := []string{protocol.SubVerbOutput, processID}
 
 	// Add filter args
@@ -182,40 +208,94 @@ func (c *Client) ProcOutput(processID string, filter protocol.OutputFilter) (str
 	if filter.GrepV {
 		args = append(args, "grep_v")
 	}
-
-	return c.conn.Request(protocol.VerbProc, args...).String()
+	return args
 }
 
 // ProcStop stops a process.
 func (c *Client) ProcStop(processID string, force bool) (map[string]interface{}, error) {
+	return c.ProcStopCascade(processID, force, false)
+}
+
+// ProcStopCascade stops a process, and with cascade also the proxies and
+// tunnels that depend on it.
+func (c *Client) ProcStopCascade(processID string, force, cascade bool) (map[string]interface{}, error) {
 	args := []string{protocol.SubVerbStop, processID}
 	if force {
 		args = append(args, "force")
 	}
+	if cascade {
+		args = append(args, "cascade")
+	}
 	return c.conn.Request(protocol.VerbProc, args...).JSON()
 }
 
 // ProcList lists all processes.
 func (c *Client) ProcList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
-	req := c.conn.Request(protocol.VerbProc, protocol.SubVerbList)
-	if dirFilter.Directory != "" || dirFilter.Global {
-		req = req.WithJSON(dirFilter)
+	return c.ProcListFiltered(protocol.ListFilter{DirectoryFilter: dirFilter})
+}
+
+// ProcListFiltered lists processes, keeping only those with every label of
+// the filter.
+func (c *Client) ProcListFiltered(filter protocol.ListFilter) (map[string]interface{}, error) {
+	return c.listRequest(protocol.VerbProc, filter)
+}
+
+// ProcLabel sets and removes labels of a process and returns its labels.
+func (c *Client) ProcLabel(processID string, set protocol.Labels, remove []string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProc, labelArgs(processID, set, remove)...).JSON()
+}
+
+// listRequest sends <verb> LIST with the filter when it narrows the list.
+func (c *Client) listRequest(verb string, filter protocol.ListFilter) (map[string]interface{}, error) {
+	req := c.conn.Request(verb, protocol.SubVerbList)
+	if filter.Directory != "" || filter.Global || len(filter.Labels) > 0 {
+		req = req.WithJSON(filter)
 	}
 	return req.JSON()
 }
 
+// labelArgs returns the LABEL args for an entity.
+func labelArgs(id string, set protocol.Labels, remove []string) []string {
+	args := []string{protocol.SubVerbLabel, id}
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		args = append(args, key+"="+set[key])
+	}
+	for _, key := range remove {
+		args = append(args, key+"-")
+	}
+	return args
+}
+
 // ProcCleanupPort kills processes on a specific port.
 func (c *Client) ProcCleanupPort(port int) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbProc, protocol.SubVerbCleanupPort, fmt.Sprintf("%d", port)).JSON()
 }
 
+// ProcCleanupPortDryRun lists the processes ProcCleanupPort would kill.
+func (c *Client) ProcCleanupPortDryRun(port int) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProc, protocol.SubVerbCleanupPort, fmt.Sprintf("%d", port), protocol.DryRunArg).JSON()
+}
+
 // ProxyStartConfig holds configuration for starting a proxy.
 type ProxyStartConfig struct {
-	Path        string                 `json:"path,omitempty"`
-	BindAddress string                 `json:"bind_address,omitempty"`
-	PublicURL   string                 `json:"public_url,omitempty"`
-	VerifyTLS   bool                   `json:"verify_tls,omitempty"`
-	Tunnel      *protocol.TunnelConfig `json:"tunnel,omitempty"`
+	Path           string                   `json:"path,omitempty"`
+	BindAddress    string                   `json:"bind_address,omitempty"`
+	PublicURL      string                   `json:"public_url,omitempty"`
+	VerifyTLS      bool                     `json:"verify_tls,omitempty"`
+	Encrypt        bool                     `json:"encrypt,omitempty"`
+	NoRetarget     bool                     `json:"no_retarget,omitempty"`
+	Routes         []proxy.HostRoute        `json:"routes,omitempty"`
+	PathRoutes     []proxy.PathRoute        `json:"path_routes,omitempty"`
+	Cookies        *proxy.CookieRewrite     `json:"cookies,omitempty"`
+	URLRewrite     *proxy.URLRewrite        `json:"url_rewrite,omitempty"`
+	Storms         *proxy.StormDetection    `json:"storms,omitempty"`
+	Banner         *proxy.EnvironmentBanner `json:"banner,omitempty"`
+	UI             *proxy.OverlayUI         `json:"ui,omitempty"`
+	BodyCapture    *proxy.BodyCapture       `json:"body_capture,omitempty"`
+	PersistLogs    *proxy.LogPersistence    `json:"persist_logs,omitempty"`
+	TrustedProxies []string                 `json:"trusted_proxies,omitempty"`
+	Labels         protocol.Labels          `json:"labels,omitempty"`
+	Tunnel         *protocol.TunnelConfig   `json:"tunnel,omitempty"`
 }
 
 // ProxyStart starts a reverse proxy.
@@ -234,6 +314,15 @@ func (c *Client) ProxyStartWithConfig(id, targetURL string, port, maxLogSize int
 
 // ProxyStop stops a reverse proxy.
 func (c *Client) ProxyStop(id string) error {
+	return c.ProxyStopCascade(id, false)
+}
+
+// ProxyStopCascade stops a reverse proxy, and with cascade also the tunnels
+// that front it.
+func (c *Client) ProxyStopCascade(id string, cascade bool) error {
+	if cascade {
+		return c.conn.Request(protocol.VerbProxy, protocol.SubVerbStop, id, "cascade").OK()
+	}
 	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbStop, id).OK()
 }
 
@@ -244,21 +333,120 @@ func (c *Client) ProxyStatus(id string) (map[string]interface{}, error) {
 
 // ProxyList lists all proxies.
 func (c *Client) ProxyList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
-	req := c.conn.Request(protocol.VerbProxy, protocol.SubVerbList)
-	if dirFilter.Directory != "" || dirFilter.Global {
-		req = req.WithJSON(dirFilter)
+	return c.ProxyListFiltered(protocol.ListFilter{DirectoryFilter: dirFilter})
+}
+
+// ProxyListFiltered lists proxies, keeping only those with every label of
+// the filter.
+func (c *Client) ProxyListFiltered(filter protocol.ListFilter) (map[string]interface{}, error) {
+	return c.listRequest(protocol.VerbProxy, filter)
+}
+
+// ProxyLabel sets and removes labels of a proxy and returns its labels.
+func (c *Client) ProxyLabel(id string, set protocol.Labels, remove []string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, labelArgs(id, set, remove)...).JSON()
+}
+
+// ProxyRoutesAdd adds a path route to a proxy, replacing the route for the
+// same path, and returns the proxy's routes.
+func (c *Client) ProxyRoutesAdd(id string, route proxy.PathRoute) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbRoutes, protocol.SubVerbAdd, id).WithJSON(route).JSON()
+}
+
+// ProxyRoutesRemove removes the path route for path from a proxy.
+func (c *Client) ProxyRoutesRemove(id, path string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbRoutes, protocol.SubVerbRemove, id, path).JSON()
+}
+
+// ProxyRoutesList lists the path routes of a proxy.
+func (c *Client) ProxyRoutesList(id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbRoutes, protocol.SubVerbList, id).JSON()
+}
+
+// ProxyInterceptAdd adds an intercept rule to a proxy, replacing the rule
+// with the same ID, and returns the proxy's rules and paused requests.
+func (c *Client) ProxyInterceptAdd(id string, rule proxy.InterceptRule) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbAdd, id).WithJSON(rule).JSON()
+}
+
+// ProxyInterceptRemove removes an intercept rule, letting the requests it
+// paused continue.
+func (c *Client) ProxyInterceptRemove(id, ruleID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbRemove, id, ruleID).JSON()
+}
+
+// ProxyInterceptList lists the intercept rules and paused requests of a
+// proxy. A positive wait blocks until a request is paused or wait passes.
+func (c *Client) ProxyInterceptList(id string, wait time.Duration) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbList, id)
+	if wait > 0 {
+		c.conn.SetTimeout(wait + 30*time.Second)
+		defer c.conn.SetTimeout(30 * time.Second)
+		req = req.WithJSON(interceptListRequest{WaitMs: int(wait.Milliseconds())})
+	}
+	return req.JSON()
+}
+
+// ProxyInterceptModify edits a paused request, which stays paused.
+func (c *Client) ProxyInterceptModify(id, requestID string, edit proxy.InterceptEdit) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbModify, id, requestID).WithJSON(edit).JSON()
+}
+
+// ProxyInterceptResume lets a paused request continue, applying edit first
+// when non-nil.
+func (c *Client) ProxyInterceptResume(id, requestID string, edit *proxy.InterceptEdit) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbResume, id, requestID)
+	if edit != nil {
+		req = req.WithJSON(edit)
 	}
 	return req.JSON()
 }
 
+// ProxyInterceptReject answers a paused request with status and body
+// without calling the target.
+func (c *Client) ProxyInterceptReject(id, requestID string, status int, body string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbReject, id, requestID).WithJSON(interceptRejectRequest{Status: status, Body: body}).JSON()
+}
+
+// ProxyInterceptClear removes all intercept rules of a proxy and lets every
+// paused request continue.
+func (c *Client) ProxyInterceptClear(id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbIntercept, protocol.SubVerbClear, id).JSON()
+}
+
+// ProxyUI returns the overlay options of a proxy, with the strings they
+// resolve to.
+func (c *Client) ProxyUI(id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbUI, id).JSON()
+}
+
+// ProxySetUI replaces the overlay options of a proxy and applies them to the
+// pages connected now.
+func (c *Client) ProxySetUI(id string, ui proxy.OverlayUI) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbUI, id).WithJSON(ui).JSON()
+}
+
 // ProxyExec executes JavaScript in connected browsers.
 func (c *Client) ProxyExec(id, code string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbExec, id).WithData([]byte(code)).JSON()
 }
 
-// ProxyToast sends a toast notification to connected browsers.
+// ProxyToast sends a toast notification to connected browsers. With
+// actions and WaitMs, it returns the action clicked.
 func (c *Client) ProxyToast(id string, toast protocol.ToastConfig) (map[string]interface{}, error) {
-	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbToast, id).WithJSON(toast).JSON()
+	if toast.WaitMs > 0 {
+		c.conn.SetTimeout(min(time.Duration(toast.WaitMs)*time.Millisecond, MaxToastWait) + 10*time.Second)
+		defer c.conn.SetTimeout(30 * time.Second)
+	}
+	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbToast, id).WithJSON(map[string]interface{}{
+		"toast_type":     toast.Type,
+		"toast_title":    toast.Title,
+		"toast_message":  toast.Message,
+		"toast_duration": toast.Duration,
+		"actions":        toast.Actions,
+		"session":        toast.Session,
+		"wait_ms":        toast.WaitMs,
+	}).JSON()
 }
 
 // ProxyLogQuery queries proxy logs.
@@ -271,11 +459,31 @@ func (c *Client) ProxyLogClear(proxyID string) error {
 	return c.conn.Request(protocol.VerbProxyLog, protocol.SubVerbClear, proxyID).OK()
 }
 
+// ProxyLogReplay re-issues a logged request, with optional overrides.
+func (c *Client) ProxyLogReplay(proxyID, entryID string, overrides *proxy.ReplayOverrides) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbProxyLog, protocol.SubVerbReplay, proxyID, entryID)
+	if overrides != nil {
+		req = req.WithJSON(overrides)
+	}
+	return req.JSON()
+}
+
+// ProxyLogAggregate gets a proxy's response bytes by content type.
+func (c *Client) ProxyLogAggregate(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxyLog, protocol.SubVerbAggregate, proxyID).JSON()
+}
+
 // ProxyLogStats gets proxy log statistics.
 func (c *Client) ProxyLogStats(proxyID string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbProxyLog, protocol.SubVerbStats, proxyID).JSON()
 }
 
+// ProxyLogRouteStats gets proxy log statistics with the per-route report
+// selected and ordered by filter.
+func (c *Client) ProxyLogRouteStats(proxyID string, filter proxy.RouteStatsFilter) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProxyLog, protocol.SubVerbStats, proxyID).WithJSON(filter).JSON()
+}
+
 // CurrentPageList lists active page sessions.
 func (c *Client) CurrentPageList(proxyID string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbCurrentPage, protocol.SubVerbList, proxyID).JSON()
@@ -291,9 +499,111 @@ func (c *Client) CurrentPageClear(proxyID string) error {
 	return c.conn.Request(protocol.VerbCurrentPage, protocol.SubVerbClear, proxyID).OK()
 }
 
+// CurrentPageWaitForIdle waits until a page session's network and DOM activity
+// settle. An empty sessionID waits for every page on the proxy.
+func (c *Client) CurrentPageWaitForIdle(proxyID, sessionID string, opts proxy.IdleOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbWaitForIdle, proxyID}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	return c.conn.Request(protocol.VerbCurrentPage, args...).WithJSON(opts).JSON()
+}
+
+// CurrentPageSnapshot captures a serialized DOM of a page session. An empty
+// sessionID captures whichever page answers first.
+func (c *Client) CurrentPageSnapshot(proxyID, sessionID string, opts proxy.DOMSnapshotOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbSnapshot, proxyID}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	return c.conn.Request(protocol.VerbCurrentPage, args...).WithJSON(opts).JSON()
+}
+
+// CurrentPageSnapshots lists the DOM snapshots kept for a proxy.
+func (c *Client) CurrentPageSnapshots(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbCurrentPage, protocol.SubVerbSnapshots, proxyID).JSON()
+}
+
+// CurrentPageDiff compares two DOM snapshots of a proxy.
+func (c *Client) CurrentPageDiff(proxyID, from, to string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbCurrentPage, protocol.SubVerbDiff, proxyID, from, to).JSON()
+}
+
+// CurrentPageCoverage reports the unused CSS of a page session. An empty
+// sessionID reports on whichever page answers first.
+func (c *Client) CurrentPageCoverage(proxyID, sessionID string, opts proxy.CSSCoverageOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbCoverage, proxyID}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	return c.conn.Request(protocol.VerbCurrentPage, args...).WithJSON(opts).JSON()
+}
+
+// ScreenshotCapture captures and stores a screenshot of a page session. An
+// empty sessionID captures whichever page answers first.
+func (c *Client) ScreenshotCapture(proxyID, sessionID string, opts proxy.ScreenshotOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbCapture, proxyID}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	return c.conn.Request(protocol.VerbScreenshot, args...).WithJSON(opts).JSON()
+}
+
+// ScreenshotList lists the stored screenshots of a proxy.
+func (c *Client) ScreenshotList(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbScreenshot, protocol.SubVerbList, proxyID).JSON()
+}
+
+// A11yAudit runs an accessibility audit in a page session. An empty
+// sessionID audits whichever page answers first.
+func (c *Client) A11yAudit(proxyID, sessionID string, opts proxy.A11yAuditOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbAudit, proxyID}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	return c.conn.Request(protocol.VerbA11y, args...).WithJSON(opts).JSON()
+}
+
+// RecordStart starts recording a page session of a proxy. An empty sessionID
+// records the first page to act.
+func (c *Client) RecordStart(proxyID, sessionID string, opts proxy.RecordOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbStart, proxyID}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	return c.conn.Request(protocol.VerbRecord, args...).WithJSON(opts).JSON()
+}
+
+// RecordStop stops a proxy's recording and stores it.
+func (c *Client) RecordStop(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbRecord, protocol.SubVerbStop, proxyID).JSON()
+}
+
+// RecordStatus returns a proxy's recording in progress.
+func (c *Client) RecordStatus(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbRecord, protocol.SubVerbStatus, proxyID).JSON()
+}
+
+// RecordReplay replays a stored recording in a page session. An empty
+// sessionID replays in whichever page answers first.
+func (c *Client) RecordReplay(proxyID, name, sessionID string, opts proxy.RecordingReplayOptions) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbReplay, proxyID, name}
+	if sessionID != "" {
+		args = append(args, sessionID)
+	}
+	c.conn.SetTimeout(proxy.MaxReplayDuration + 30*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbRecord, args...).WithJSON(opts).JSON()
+}
+
+// RecordList lists the stored recordings of a proxy's project.
+func (c *Client) RecordList(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbRecord, protocol.SubVerbList, proxyID).JSON()
+}
+
 // OverlaySet sets the overlay endpoint URL.
 func (c *Client) OverlaySet(endpoint string) (map[string]interface{}, error) {
-	return c.conn.Request(protocol.VerbOverlay, protocol.SubVerbSet, endpoint).JSON()
+	return c.conn.Request(protocol.VerbOverlay, protocol.SubVerbSet).WithJSON(map[string]string{"endpoint": endpoint}).JSON()
 }
 
 // OverlayGet gets the current overlay endpoint configuration.
@@ -329,7 +639,7 @@ func (c *Client) BroadcastOutputPreview(lines []string, proxyIDs ...string) erro
 
 // TunnelStart starts a tunnel for a local port.
 func (c *Client) TunnelStart(config protocol.TunnelStartConfig) (map[string]interface{}, error) {
-	return c.conn.Request(protocol.VerbTunnel, protocol.SubVerbStart).WithJSON(config).JSON()
+	return c.conn.Request(protocol.VerbTunnel, protocol.SubVerbStart, config.ID).WithJSON(config).JSON()
 }
 
 // TunnelStop stops a running tunnel.
@@ -342,15 +652,306 @@ func (c *Client) TunnelStatus(id string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbTunnel, protocol.SubVerbStatus, id).JSON()
 }
 
+// TunnelResume resumes a tunnel paused by its bandwidth cap.
+func (c *Client) TunnelResume(id string) error {
+	return c.conn.Request(protocol.VerbTunnel, protocol.SubVerbResume, id).OK()
+}
+
+// TunnelEvents returns the disconnect and reconnect log of the session's
+// tunnels, or of one tunnel when id is set; limit > 0 keeps the newest events.
+func (c *Client) TunnelEvents(id string, limit int) (map[string]interface{}, error) {
+	var args []string
+	if id != "" {
+		args = append(args, id)
+	}
+	req := c.conn.Request(protocol.VerbTunnel, append([]string{protocol.SubVerbEvents}, args...)...)
+	if limit > 0 {
+		req = req.WithJSON(map[string]int{"limit": limit})
+	}
+	return req.JSON()
+}
+
 // TunnelList lists all active tunnels.
 func (c *Client) TunnelList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
-	req := c.conn.Request(protocol.VerbTunnel, protocol.SubVerbList)
+	return c.TunnelListFiltered(protocol.ListFilter{DirectoryFilter: dirFilter})
+}
+
+// TunnelListFiltered lists tunnels, keeping only those with every label of
+// the filter.
+func (c *Client) TunnelListFiltered(filter protocol.ListFilter) (map[string]interface{}, error) {
+	return c.listRequest(protocol.VerbTunnel, filter)
+}
+
+// TunnelLabel sets and removes labels of a tunnel and returns its labels.
+func (c *Client) TunnelLabel(id string, set protocol.Labels, remove []string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbTunnel, labelArgs(id, set, remove)...).JSON()
+}
+
+// ExposeStart exposes a dev server publicly (process, proxy, tunnel and access token).
+func (c *Client) ExposeStart(config protocol.ExposeStartConfig) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbExpose, protocol.SubVerbStart, config.ID).WithJSON(config).JSON()
+}
+
+// ExposeStop tears down an exposure.
+func (c *Client) ExposeStop(id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbExpose, protocol.SubVerbStop, id).JSON()
+}
+
+// ExposeList lists active exposures.
+func (c *Client) ExposeList() (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbExpose, protocol.SubVerbList).JSON()
+}
+
+// TestRecord records test results parsed from a process's output (or raw output).
+func (c *Client) TestRecord(config protocol.TestRecordConfig) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbRecord}
+	if config.ProcessID != "" {
+		args = append(args, config.ProcessID)
+	}
+	return c.conn.Request(protocol.VerbTest, args...).WithJSON(config).JSON()
+}
+
+// TestHistory returns recent test runs, optionally filtered to matching tests.
+func (c *Client) TestHistory(filter string) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbHistory}
+	if filter != "" {
+		args = append(args, filter)
+	}
+	return c.conn.Request(protocol.VerbTest, args...).JSON()
+}
+
+// TestRun runs the project's test suite, split into parallel shards.
+// The run blocks on the daemon, so the request timeout is extended to cover it.
+func (c *Client) TestRun(config protocol.TestRunConfig) (map[string]interface{}, error) {
+	timeout := 10 * time.Minute
+	if config.Timeout != "" {
+		if d, err := time.ParseDuration(config.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	c.conn.SetTimeout(timeout + 30*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbTest, protocol.SubVerbRun).WithJSON(config).JSON()
+}
+
+// FlakyList reports tests that flip outcome without related changes.
+func (c *Client) FlakyList() (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbFlaky, protocol.SubVerbList).JSON()
+}
+
+// BenchRun runs a benchmark suite and compares it with the baseline.
+// The run blocks on the daemon, so the request timeout is extended to cover it.
+func (c *Client) BenchRun(config protocol.BenchConfig) (map[string]interface{}, error) {
+	timeout := 20 * time.Minute
+	if config.Timeout != "" {
+		if d, err := time.ParseDuration(config.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	c.conn.SetTimeout(timeout + 30*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbBench, benchArgs(protocol.SubVerbRun, config.Name)...).WithJSON(config).JSON()
+}
+
+// BenchReport compares the latest recorded run of a suite with its baseline.
+func (c *Client) BenchReport(config protocol.BenchConfig) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbBench, benchArgs(protocol.SubVerbReport, config.Name)...).WithJSON(config).JSON()
+}
+
+// BenchHistory returns per-commit series of benchmarks, optionally filtered by name.
+func (c *Client) BenchHistory(filter string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbBench, benchArgs(protocol.SubVerbHistory, filter)...).JSON()
+}
+
+func benchArgs(subVerb, arg string) []string {
+	if arg == "" {
+		return []string{subVerb}
+	}
+	return []string{subVerb, arg}
+}
+
+// ProfileCPU captures a CPU profile from a managed process.
+func (c *Client) ProfileCPU(processID string, config protocol.ProfileConfig) (map[string]interface{}, error) {
+	return c.profileCapture(protocol.SubVerbCPU, processID, config)
+}
+
+// ProfileHeap captures a heap profile from a managed process.
+func (c *Client) ProfileHeap(processID string, config protocol.ProfileConfig) (map[string]interface{}, error) {
+	return c.profileCapture(protocol.SubVerbHeap, processID, config)
+}
+
+func (c *Client) profileCapture(subVerb, processID string, config protocol.ProfileConfig) (map[string]interface{}, error) {
+	seconds := config.Seconds
+	if seconds <= 0 {
+		seconds = 10
+	}
+	c.conn.SetTimeout(time.Duration(seconds)*time.Second + time.Minute)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbProfile, subVerb, processID).WithJSON(config).JSON()
+}
+
+// ProfileList lists the profiles stored for a project.
+func (c *Client) ProfileList(path string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProfile, protocol.SubVerbList).WithJSON(protocol.ProfileConfig{Path: path}).JSON()
+}
+
+// DaemonProfile profiles the daemon itself: kind is cpu or heap.
+func (c *Client) DaemonProfile(kind string, config protocol.ProfileConfig) (map[string]interface{}, error) {
+	seconds := config.Seconds
+	if seconds <= 0 {
+		seconds = 30
+	}
+	c.conn.SetTimeout(time.Duration(seconds)*time.Second + time.Minute)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbDaemon, protocol.SubVerbProfile, kind).WithJSON(config).JSON()
+}
+
+// GraphShow returns the dependency graph of the session's project, or of
+// all projects when global is set.
+func (c *Client) GraphShow(global bool) (map[string]interface{}, error) {
+	if global {
+		return c.conn.Request(protocol.VerbGraph, protocol.SubVerbShow, "global").JSON()
+	}
+	return c.conn.Request(protocol.VerbGraph, protocol.SubVerbShow).JSON()
+}
+
+// GraphImpact lists the entities that depend on kind:id.
+func (c *Client) GraphImpact(kind, id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbGraph, protocol.SubVerbImpact, kind, id).JSON()
+}
+
+// Resolve maps a partial ID to the entity type and full ID it names;
+// entityType (process, proxy, tunnel or session) narrows the search.
+func (c *Client) Resolve(partial, entityType string) (map[string]interface{}, error) {
+	if entityType != "" {
+		return c.conn.Request(protocol.VerbResolve, partial, entityType).JSON()
+	}
+	return c.conn.Request(protocol.VerbResolve, partial).JSON()
+}
+
+// StatusLite returns the counts of a project, or of all projects when
+// path is empty and no session is attached.
+func (c *Client) StatusLite(path string) (*StatusLite, error) {
+	req := c.conn.Request(protocol.VerbStatusLite)
+	if path != "" {
+		req = c.conn.Request(protocol.VerbStatusLite, path)
+	}
+	result, err := req.JSON()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	var s StatusLite
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse status: %w", err)
+	}
+	return &s, nil
+}
+
+// WaitError blocks until the next frontend error, 5xx response or process
+// failure matching config, or until its timeout.
+func (c *Client) WaitError(config protocol.WaitErrorConfig) (map[string]interface{}, error) {
+	timeout := DefaultWaitErrorTimeout
+	if config.TimeoutMs > 0 {
+		timeout = min(time.Duration(config.TimeoutMs)*time.Millisecond, MaxWaitErrorTimeout)
+	}
+	c.conn.SetTimeout(timeout + 10*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbWait, protocol.SubVerbError).WithJSON(config).JSON()
+}
+
+// EventsQuery returns the newest daemon events matching filter.
+func (c *Client) EventsQuery(filter protocol.EventsFilter) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbEvents, protocol.SubVerbQuery).WithJSON(filter).JSON()
+}
+
+// EventsSubscribe collects the daemon events matching filter as they
+// happen, until filter.Limit events arrived or filter.TimeoutMs passed.
+func (c *Client) EventsSubscribe(filter protocol.EventsFilter) ([]DaemonEvent, error) {
+	timeout := DefaultEventsSubscribeTimeout
+	if filter.TimeoutMs > 0 {
+		timeout = min(time.Duration(filter.TimeoutMs)*time.Millisecond, MaxEventsSubscribeTimeout)
+	}
+	c.conn.SetTimeout(timeout + 10*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	stream, err := c.conn.Request(protocol.VerbEvents, protocol.SubVerbSubscribe).WithJSON(filter).String()
+	if err != nil {
+		return nil, err
+	}
+	events := []DaemonEvent{}
+	for _, line := range strings.Split(stream, "\n") {
+		if line == "" {
+			continue
+		}
+		var e DaemonEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return events, fmt.Errorf("invalid event in stream: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// WorkspaceCreate creates a disposable copy of a project.
+func (c *Client) WorkspaceCreate(opts workspace.CreateOptions) (map[string]interface{}, error) {
+	c.conn.SetTimeout(2*time.Minute + 10*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbWorkspace, protocol.SubVerbCreate).WithJSON(opts).JSON()
+}
+
+// WorkspaceList lists the workspaces of a directory, or all of them.
+func (c *Client) WorkspaceList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbWorkspace, protocol.SubVerbList)
 	if dirFilter.Directory != "" || dirFilter.Global {
 		req = req.WithJSON(dirFilter)
 	}
 	return req.JSON()
 }
 
+// WorkspaceGet returns a workspace.
+func (c *Client) WorkspaceGet(id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbWorkspace, protocol.SubVerbGet, id).JSON()
+}
+
+// WorkspaceRemove stops the processes running in a workspace and deletes it.
+func (c *Client) WorkspaceRemove(id string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbWorkspace, protocol.SubVerbRemove, id).JSON()
+}
+
+// CompareRun runs a script against the working tree and a base ref and
+// diffs the results.
+func (c *Client) CompareRun(config protocol.CompareConfig, sequential bool) (map[string]interface{}, error) {
+	timeout := 10 * time.Minute
+	if config.Timeout != "" {
+		if d, err := time.ParseDuration(config.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	c.conn.SetTimeout(timeout + 3*time.Minute)
+	defer c.conn.SetTimeout(30 * time.Second)
+	args := []string{protocol.SubVerbRun}
+	if sequential {
+		args = append(args, "sequential")
+	}
+	return c.conn.Request(protocol.VerbCompare, args...).WithJSON(config).JSON()
+}
+
+// Help returns the usage of every daemon command, of one verb, or of one
+// sub-verb when verb and subVerb are set.
+func (c *Client) Help(verb, subVerb string) (map[string]interface{}, error) {
+	args := []string{}
+	if verb != "" {
+		args = append(args, verb)
+		if subVerb != "" {
+			args = append(args, subVerb)
+		}
+	}
+	return c.conn.Request(protocol.VerbHelp, args...).JSON()
+}
+
 // ChaosEnable enables chaos injection on a proxy.
 func (c *Client) ChaosEnable(proxyID string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbEnable, proxyID).JSON()
@@ -368,7 +969,24 @@ func (c *Client) ChaosStatus(proxyID string) (map[string]interface{}, error) {
 
 // ChaosPreset applies a preset chaos configuration to a proxy.
 func (c *Client) ChaosPreset(proxyID, preset string) (map[string]interface{}, error) {
-	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbPreset, proxyID, preset).JSON()
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbPreset, proxyID).WithJSON(map[string]string{"chaos_preset": preset}).JSON()
+}
+
+// ChaosPresetDryRun lists the rules ChaosPreset would replace and previews
+// which logged requests the preset would hit.
+func (c *Client) ChaosPresetDryRun(proxyID, preset string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbPreset, proxyID, protocol.DryRunArg).WithJSON(map[string]string{"chaos_preset": preset}).JSON()
+}
+
+// ChaosPresetForSessions applies a preset whose rules only affect requests
+// from the given page or browser sessions.
+func (c *Client) ChaosPresetForSessions(proxyID, preset string, sessions []string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbPreset, proxyID).WithJSON(chaosPresetRequest{Preset: preset, Sessions: sessions}).JSON()
+}
+
+// ChaosPresetForSessionsDryRun previews ChaosPresetForSessions.
+func (c *Client) ChaosPresetForSessionsDryRun(proxyID, preset string, sessions []string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbPreset, proxyID, protocol.DryRunArg).WithJSON(chaosPresetRequest{Preset: preset, Sessions: sessions}).JSON()
 }
 
 // ChaosSet sets the full chaos configuration on a proxy.
@@ -376,6 +994,12 @@ func (c *Client) ChaosSet(proxyID string, config protocol.ChaosConfigPayload) (m
 	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbSet, proxyID).WithJSON(config).JSON()
 }
 
+// ChaosSetDryRun lists the rules ChaosSet would replace and previews which
+// logged requests config would hit.
+func (c *Client) ChaosSetDryRun(proxyID string, config protocol.ChaosConfigPayload) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbSet, proxyID, protocol.DryRunArg).WithJSON(config).JSON()
+}
+
 // ChaosAddRule adds a single rule to a proxy's chaos engine.
 func (c *Client) ChaosAddRule(proxyID string, rule protocol.ChaosRuleConfig) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbAddRule, proxyID).WithJSON(rule).JSON()
@@ -383,7 +1007,7 @@ func (c *Client) ChaosAddRule(proxyID string, rule protocol.ChaosRuleConfig) (ma
 
 // ChaosRemoveRule removes a rule from a proxy's chaos engine.
 func (c *Client) ChaosRemoveRule(proxyID, ruleID string) (map[string]interface{}, error) {
-	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbRemoveRule, proxyID, ruleID).JSON()
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbRemoveRule, proxyID).WithJSON(map[string]string{"chaos_rule_id": ruleID}).JSON()
 }
 
 // ChaosListRules lists all chaos rules for a proxy.
@@ -396,25 +1020,85 @@ func (c *Client) ChaosStats(proxyID string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbStats, proxyID).JSON()
 }
 
+// ChaosPreview evaluates chaos rules against a proxy's logged traffic
+// without applying them: config if set, else preset if set, else the
+// proxy's current rules.
+func (c *Client) ChaosPreview(proxyID, preset string, config *protocol.ChaosConfigPayload) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbChaos, protocol.SubVerbPreview, proxyID)
+	switch {
+	case config != nil:
+		req = req.WithJSON(map[string]interface{}{"chaos_config": config})
+	case preset != "":
+		req = req.WithJSON(map[string]string{"chaos_preset": preset})
+	}
+	return req.JSON()
+}
+
 // ChaosClear clears all chaos rules and resets stats for a proxy.
 func (c *Client) ChaosClear(proxyID string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbClear, proxyID).JSON()
 }
 
+// ChaosClearDryRun lists the rules ChaosClear would remove.
+func (c *Client) ChaosClearDryRun(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbClear, proxyID, protocol.DryRunArg).JSON()
+}
+
 // ChaosListPresets returns the list of available chaos presets.
 func (c *Client) ChaosListPresets() (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbChaos, "LIST-PRESETS").JSON()
 }
 
+// ChaosScenarioStart steps a proxy's chaos rules through a scenario's phases.
+func (c *Client) ChaosScenarioStart(proxyID string, scenario protocol.ChaosScenarioPayload) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbScenario, protocol.SubVerbStart, proxyID).WithJSON(scenario).JSON()
+}
+
+// ChaosScenarioStop stops a proxy's running scenario, restoring the chaos
+// configuration it replaced.
+func (c *Client) ChaosScenarioStop(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbScenario, protocol.SubVerbStop, proxyID).JSON()
+}
+
+// ChaosScenarioStatus reports a proxy's current or most recent scenario.
+func (c *Client) ChaosScenarioStatus(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbChaos, protocol.SubVerbScenario, protocol.SubVerbStatus, proxyID).JSON()
+}
+
+// MockAdd adds a mock rule to a proxy, replacing the rule with the same ID.
+func (c *Client) MockAdd(proxyID string, rule proxy.MockRule) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbMock, protocol.SubVerbAdd, proxyID).WithJSON(rule).JSON()
+}
+
+// MockRemove removes a mock rule from a proxy.
+func (c *Client) MockRemove(proxyID, ruleID string) error {
+	return c.conn.Request(protocol.VerbMock, protocol.SubVerbRemove, proxyID, ruleID).OK()
+}
+
+// MockList lists the mock rules of a proxy with their hit counts.
+func (c *Client) MockList(proxyID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbMock, protocol.SubVerbList, proxyID).JSON()
+}
+
+// MockClear removes all mock rules from a proxy.
+func (c *Client) MockClear(proxyID string) error {
+	return c.conn.Request(protocol.VerbMock, protocol.SubVerbClear, proxyID).OK()
+}
+
 // SessionRegister registers a new session with the daemon.
 func (c *Client) SessionRegister(code string, overlayPath string, projectPath string, command string, args []string) (map[string]interface{}, error) {
-	metadata := protocol.SessionRegisterConfig{
+	return c.SessionRegisterWithConfig(code, protocol.SessionRegisterConfig{
 		OverlayPath: overlayPath,
 		ProjectPath: projectPath,
 		Command:     command,
 		Args:        args,
-	}
-	return c.conn.Request(protocol.VerbSession, protocol.SubVerbRegister, code, overlayPath).WithJSON(metadata).JSON()
+	})
+}
+
+// SessionRegisterWithConfig registers a new session with its tags and
+// description.
+func (c *Client) SessionRegisterWithConfig(code string, metadata protocol.SessionRegisterConfig) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbRegister, code, metadata.OverlayPath).WithJSON(metadata).JSON()
 }
 
 // SessionUnregister unregisters a session from the daemon.
@@ -429,9 +1113,33 @@ func (c *Client) SessionHeartbeat(code string) error {
 
 // SessionList lists active sessions.
 func (c *Client) SessionList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+	return c.SessionListTagged(dirFilter, nil)
+}
+
+// sessionTagFilter is the payload of SESSION LIST and FIND with tags.
+type sessionTagFilter struct {
+	protocol.DirectoryFilter
+	Tags protocol.Labels `json:"tags,omitempty"`
+}
+
+// SessionListTagged lists active sessions, keeping only those with every
+// tag given.
+func (c *Client) SessionListTagged(dirFilter protocol.DirectoryFilter, tags protocol.Labels) (map[string]interface{}, error) {
 	req := c.conn.Request(protocol.VerbSession, protocol.SubVerbList)
-	if dirFilter.Directory != "" || dirFilter.Global {
-		req = req.WithJSON(dirFilter)
+	if dirFilter.Directory != "" || dirFilter.Global || len(tags) > 0 {
+		req = req.WithJSON(sessionTagFilter{DirectoryFilter: dirFilter, Tags: tags})
+	}
+	return req.JSON()
+}
+
+// SessionTag sets and removes tags of a session, and replaces its
+// description when one is given. It returns the session.
+func (c *Client) SessionTag(code string, set protocol.Labels, remove []string, description *string) (map[string]interface{}, error) {
+	args := labelArgs(code, set, remove)
+	args[0] = protocol.SubVerbTag
+	req := c.conn.Request(protocol.VerbSession, args...)
+	if description != nil {
+		req = req.WithJSON(sessionTagRequest{Description: description})
 	}
 	return req.JSON()
 }
@@ -446,11 +1154,119 @@ func (c *Client) SessionSend(code string, message string) (map[string]interface{
 	return c.conn.Request(protocol.VerbSession, protocol.SubVerbSend, code).WithData([]byte(message)).JSON()
 }
 
+// SessionSendWithOptions sends an immediate message to a session, typed
+// with the given chunking, pacing, submit and bracketed-paste options.
+func (c *Client) SessionSendWithOptions(code, message string, opts SendOptions) (map[string]interface{}, error) {
+	args := append([]string{protocol.SubVerbSend, code}, opts.args()...)
+	return c.conn.Request(protocol.VerbSession, args...).WithData([]byte(message)).JSON()
+}
+
 // SessionSchedule schedules a message for future delivery.
 func (c *Client) SessionSchedule(code string, duration string, message string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbSession, protocol.SubVerbSchedule, code, duration).WithData([]byte(message)).JSON()
 }
 
+// SessionDigest turns on or reconfigures the periodic activity digest of a
+// session, or with a nil config reports its state and a preview.
+func (c *Client) SessionDigest(code string, config *DigestConfig) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbSession, protocol.SubVerbDigest, code)
+	if config != nil {
+		req = req.WithJSON(config)
+	}
+	return req.JSON()
+}
+
+// SessionDigestOff turns off the activity digest of a session.
+func (c *Client) SessionDigestOff(code string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbDigest, code, "off").JSON()
+}
+
+// SessionAlerts lists the error spike alert rules of a session, or with a
+// rule adds it first.
+func (c *Client) SessionAlerts(code string, rule *AlertRule) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbSession, protocol.SubVerbAlert, code)
+	if rule != nil {
+		req = req.WithJSON(rule)
+	}
+	return req.JSON()
+}
+
+// SessionAlertRemove removes an alert rule of a session.
+func (c *Client) SessionAlertRemove(code, ruleID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbAlert, code, "REMOVE", ruleID).JSON()
+}
+
+// SessionScheduleWhenIdle schedules a message that, once due, waits for the
+// session's tool to be idle.
+func (c *Client) SessionScheduleWhenIdle(code string, duration string, message string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbSchedule, code, duration, protocol.SubVerbWaitForIdle).WithData([]byte(message)).JSON()
+}
+
+// SessionScheduleCron schedules a message delivered every time a cron
+// expression matches in timezone (empty for the daemon's local time).
+func (c *Client) SessionScheduleCron(code, cron, timezone, message string, waitForIdle bool) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbSchedule, code, scheduleCronArg}
+	if waitForIdle {
+		args = append(args, protocol.SubVerbWaitForIdle)
+	}
+	req := scheduleCronRequest{Cron: cron, Timezone: timezone, Message: message}
+	return c.conn.Request(protocol.VerbSession, args...).WithJSON(req).JSON()
+}
+
+// SessionPause holds a scheduled task without cancelling it.
+func (c *Client) SessionPause(taskID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbPause, taskID).JSON()
+}
+
+// SessionResume resumes a paused scheduled task.
+func (c *Client) SessionResume(taskID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbResume, taskID).JSON()
+}
+
+// SessionStatus reports whether a session's tool is busy or idle.
+func (c *Client) SessionStatus(code string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbStatus, code).JSON()
+}
+
+// SessionClipboard returns the last text moved between a session and its
+// proxied pages.
+func (c *Client) SessionClipboard(code string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbClipboard, code).JSON()
+}
+
+// SessionShareClipboard offers terminal text to the floating panel of a
+// session's proxied pages.
+func (c *Client) SessionShareClipboard(code, text string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbClipboard, code).WithData([]byte(text)).JSON()
+}
+
+// SessionTranscript searches the terminal output a session recorded with
+// agnt run --transcript.
+func (c *Client) SessionTranscript(code string, query TranscriptQuery) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbTranscript, code).WithJSON(query).JSON()
+}
+
+// SessionHost starts a command in a PTY the daemon keeps for a detachable
+// session, returning where to attach.
+func (c *Client) SessionHost(code string, config ptyhost.Config) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbHost, code).WithJSON(config).JSON()
+}
+
+// SessionHosted lists the processes hosted for detachable sessions, or with
+// a code describes one.
+func (c *Client) SessionHosted(code string) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbHosted}
+	if code != "" {
+		args = append(args, code)
+	}
+	return c.conn.Request(protocol.VerbSession, args...).JSON()
+}
+
+// SessionDetach disconnects the terminal attached to a hosted session.
+func (c *Client) SessionDetach(code string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbSession, protocol.SubVerbDetach, code).JSON()
+}
+
 // SessionCancel cancels a scheduled task.
 func (c *Client) SessionCancel(taskID string) error {
 	return c.conn.Request(protocol.VerbSession, protocol.SubVerbCancel, taskID).OK()
@@ -472,7 +1288,17 @@ func (c *Client) SessionGenerateCode(command string) (string, error) {
 
 // SessionFind finds a session by directory ancestry.
 func (c *Client) SessionFind(directory string) (map[string]interface{}, error) {
-	return c.conn.Request(protocol.VerbSession, protocol.SubVerbFind, directory).JSON()
+	return c.SessionFindTagged(directory, nil)
+}
+
+// SessionFindTagged finds a session by directory ancestry among those with
+// every tag given.
+func (c *Client) SessionFindTagged(directory string, tags protocol.Labels) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbSession, protocol.SubVerbFind, directory)
+	if len(tags) > 0 {
+		req = req.WithJSON(sessionTagFilter{Tags: tags})
+	}
+	return req.JSON()
 }
 
 // SessionAttach attaches to a session found by directory ancestry.
@@ -525,6 +1351,11 @@ func (c *Client) StopAll() (map[string]interface{}, error) {
 	return c.conn.Request("STOP-ALL").JSON()
 }
 
+// StopAllDryRun lists the processes, proxies and tunnels StopAll would stop.
+func (c *Client) StopAllDryRun() (map[string]interface{}, error) {
+	return c.conn.Request("STOP-ALL", protocol.DryRunArg).JSON()
+}
+
 // RestartAll restarts all running processes and proxies with their original configurations.
 func (c *Client) RestartAll() (map[string]interface{}, error) {
 	return c.conn.Request("RESTART-ALL").JSON()
@@ -535,6 +1366,231 @@ func (c *Client) ProcRestart(processID string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbProc, protocol.SubVerbRestart, processID).JSON()
 }
 
+// ProcSupervise sets the health check and restart policy of a process, or
+// stops supervising it when cfg is nil.
+func (c *Client) ProcSupervise(processID string, cfg *protocol.SuperviseConfig) (map[string]interface{}, error) {
+	if cfg == nil {
+		return c.conn.Request(protocol.VerbProc, protocol.SubVerbSupervise, processID, "off").JSON()
+	}
+	return c.conn.Request(protocol.VerbProc, protocol.SubVerbSupervise, processID).WithJSON(cfg).JSON()
+}
+
+// RemoteRun runs a script or command on the project's remote checkout.
+// Foreground runs wait for the command to exit.
+func (c *Client) RemoteRun(config protocol.RemoteRunConfig) (map[string]interface{}, error) {
+	if config.Mode == "foreground" {
+		c.conn.SetTimeout(30 * time.Minute)
+		defer c.conn.SetTimeout(30 * time.Second)
+	}
+	return c.conn.Request(protocol.VerbRemote, protocol.SubVerbRun).WithJSON(config).JSON()
+}
+
+// RemoteStatus returns the project's remote, its remote processes and the
+// port forwards.
+func (c *Client) RemoteStatus(path string) (map[string]interface{}, error) {
+	if path != "" {
+		return c.conn.Request(protocol.VerbRemote, protocol.SubVerbStatus, path).JSON()
+	}
+	return c.conn.Request(protocol.VerbRemote, protocol.SubVerbStatus).JSON()
+}
+
+// RemoteForward forwards a port on the project's remote host to this machine.
+func (c *Client) RemoteForward(port int, path string) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbForward, fmt.Sprintf("%d", port)}
+	if path != "" {
+		args = append(args, path)
+	}
+	return c.conn.Request(protocol.VerbRemote, args...).JSON()
+}
+
+// GitStatus returns the branch and changed files of the project.
+func (c *Client) GitStatus(req protocol.GitRequest) (map[string]interface{}, error) {
+	return c.gitRequest(protocol.SubVerbStatus, req)
+}
+
+// GitDiff returns the changed files of the project with their hunks.
+func (c *Client) GitDiff(req protocol.GitRequest) (map[string]interface{}, error) {
+	return c.gitRequest(protocol.SubVerbDiff, req)
+}
+
+// GitLog returns the commits of the project and their authors.
+func (c *Client) GitLog(req protocol.GitRequest) (map[string]interface{}, error) {
+	return c.gitRequest(protocol.SubVerbLog, req)
+}
+
+// GitChanged returns the files changed since a commit, committed or not.
+func (c *Client) GitChanged(req protocol.GitRequest) (map[string]interface{}, error) {
+	return c.gitRequest(protocol.SubVerbChanged, req)
+}
+
+// gitRequest sends a GIT command, waiting as long as the daemon lets git run.
+func (c *Client) gitRequest(subVerb string, req protocol.GitRequest) (map[string]interface{}, error) {
+	c.conn.SetTimeout(gitTimeout + 10*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbGit, subVerb).WithJSON(req).JSON()
+}
+
+// HookRun runs the scripts of a git hook, waiting up to timeout for them.
+func (c *Client) HookRun(hook, path string, timeout time.Duration) (map[string]interface{}, error) {
+	c.conn.SetTimeout(timeout + 30*time.Second)
+	defer c.conn.SetTimeout(30 * time.Second)
+	args := []string{protocol.SubVerbRun, hook}
+	if path != "" {
+		args = append(args, path)
+	}
+	return c.conn.Request(protocol.VerbHook, args...).JSON()
+}
+
+// ConfigValidate checks the .agnt.kdl of the session's project, or of path.
+func (c *Client) ConfigValidate(path string) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbValidate}
+	if path != "" {
+		args = append(args, path)
+	}
+	return c.conn.Request(protocol.VerbConfig, args...).JSON()
+}
+
+// ConfigReload applies edits to the .agnt.kdl of the session's project, or
+// of path, to what autostart started.
+func (c *Client) ConfigReload(path string) (map[string]interface{}, error) {
+	args := []string{protocol.SubVerbReload}
+	if path != "" {
+		args = append(args, path)
+	}
+	return c.conn.Request(protocol.VerbConfig, args...).JSON()
+}
+
+// K8sForward starts a kubectl port-forward as a managed process.
+func (c *Client) K8sForward(config protocol.K8sForwardConfig) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbK8s, protocol.SubVerbForward).WithJSON(config).JSON()
+}
+
+// K8sLogs follows the logs of the pods matching a selector as a managed
+// process's output.
+func (c *Client) K8sLogs(config protocol.K8sLogsConfig) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbK8s, protocol.SubVerbLogs).WithJSON(config).JSON()
+}
+
+// K8sStatus returns the project's port-forwards and log streams with their
+// pods and container restarts.
+func (c *Client) K8sStatus(path string) (map[string]interface{}, error) {
+	if path != "" {
+		return c.conn.Request(protocol.VerbK8s, protocol.SubVerbStatus, path).JSON()
+	}
+	return c.conn.Request(protocol.VerbK8s, protocol.SubVerbStatus).JSON()
+}
+
+// K8sStop stops a port-forward or log stream.
+func (c *Client) K8sStop(id, path string) error {
+	if path != "" {
+		return c.conn.Request(protocol.VerbK8s, protocol.SubVerbStop, id, path).OK()
+	}
+	return c.conn.Request(protocol.VerbK8s, protocol.SubVerbStop, id).OK()
+}
+
+// StackStart starts a stack and waits until it is up or a service fails.
+func (c *Client) StackStart(name, path string) (map[string]interface{}, error) {
+	c.conn.SetTimeout(10 * time.Minute)
+	defer c.conn.SetTimeout(30 * time.Second)
+	return c.conn.Request(protocol.VerbStack, stackArgs(protocol.SubVerbStart, name, path)...).JSON()
+}
+
+// StackStop stops a stack's services, dependents first.
+func (c *Client) StackStop(name, path string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbStack, stackArgs(protocol.SubVerbStop, name, path)...).JSON()
+}
+
+// StackStatus returns the state of a stack's services.
+func (c *Client) StackStatus(name, path string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbStack, stackArgs(protocol.SubVerbStatus, name, path)...).JSON()
+}
+
+// StackList returns the project's stacks.
+func (c *Client) StackList(path string) (map[string]interface{}, error) {
+	if path != "" {
+		return c.conn.Request(protocol.VerbStack, protocol.SubVerbList, path).JSON()
+	}
+	return c.conn.Request(protocol.VerbStack, protocol.SubVerbList).JSON()
+}
+
+func stackArgs(subVerb, name, path string) []string {
+	args := []string{subVerb, name}
+	if path != "" {
+		args = append(args, path)
+	}
+	return args
+}
+
+// WatchAdd watches files matching a glob, re-running script (empty to only
+// notify) when they change.
+func (c *Client) WatchAdd(pattern, script, path string, config protocol.WatchConfig) (map[string]interface{}, error) {
+	if script == "" {
+		script = "-"
+	}
+	args := []string{protocol.SubVerbAdd, pattern, script}
+	if path != "" {
+		args = append(args, path)
+	}
+	return c.conn.Request(protocol.VerbWatch, args...).WithJSON(config).JSON()
+}
+
+// WatchList returns the project's watches.
+func (c *Client) WatchList(path string) (map[string]interface{}, error) {
+	if path != "" {
+		return c.conn.Request(protocol.VerbWatch, protocol.SubVerbList, path).JSON()
+	}
+	return c.conn.Request(protocol.VerbWatch, protocol.SubVerbList).JSON()
+}
+
+// WatchRemove stops a watch.
+func (c *Client) WatchRemove(id, path string) error {
+	return c.conn.Request(protocol.VerbWatch, stackArgs(protocol.SubVerbRemove, id, path)...).OK()
+}
+
+// DoubleStart starts an API double and waits until it listens.
+func (c *Client) DoubleStart(name, path string, config protocol.DoubleStartConfig) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbDouble, stackArgs(protocol.SubVerbStart, name, path)...).WithJSON(config).JSON()
+}
+
+// DoubleStop stops an API double.
+func (c *Client) DoubleStop(name, path string) error {
+	return c.conn.Request(protocol.VerbDouble, stackArgs(protocol.SubVerbStop, name, path)...).OK()
+}
+
+// DoubleList returns the built-in profiles, the project's doubles and the
+// env of those running.
+func (c *Client) DoubleList(path string) (map[string]interface{}, error) {
+	if path != "" {
+		return c.conn.Request(protocol.VerbDouble, protocol.SubVerbList, path).JSON()
+	}
+	return c.conn.Request(protocol.VerbDouble, protocol.SubVerbList).JSON()
+}
+
+// ProcMetrics returns a process's CPU and memory samples, the last limit
+// of them when limit is positive.
+func (c *Client) ProcMetrics(processID string, limit int) (map[string]interface{}, error) {
+	if limit > 0 {
+		return c.conn.Request(protocol.VerbProc, protocol.SubVerbMetrics, processID, fmt.Sprintf("limit=%d", limit)).JSON()
+	}
+	return c.conn.Request(protocol.VerbProc, protocol.SubVerbMetrics, processID).JSON()
+}
+
+// ProcResults returns the test results and build errors parsed from a
+// process's output.
+func (c *Client) ProcResults(processID string) (map[string]interface{}, error) {
+	return c.conn.Request(protocol.VerbProc, protocol.SubVerbResults, processID).JSON()
+}
+
+// ProcCrash returns a crash report by process or report ID, or lists the
+// project's crash reports when ref is empty.
+func (c *Client) ProcCrash(ref, path string) (map[string]interface{}, error) {
+	req := c.conn.Request(protocol.VerbProc, protocol.SubVerbCrash)
+	if ref != "" {
+		req = c.conn.Request(protocol.VerbProc, protocol.SubVerbCrash, ref)
+	}
+	return req.WithJSON(map[string]string{"path": path}).JSON()
+}
+
 // ProxyRestart restarts a single proxy by ID.
 func (c *Client) ProxyRestart(id string) (map[string]interface{}, error) {
 	return c.conn.Request(protocol.VerbProxy, protocol.SubVerbRestart, id).JSON()
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// setClipboard records the last text moved to or from the session's pages.
+func (s *Session) setClipboard(clip proxy.ClipboardEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clipboard = clip
+}
+
+// Clipboard returns the last text moved to or from the session's pages.
+func (s *Session) Clipboard() (proxy.ClipboardEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clipboard, s.clipboard.Text != ""
+}
+
+// sessionBridge connects the floating panel of proxied pages with the
+// terminal session of their project (see proxy.SessionBridge).
+type sessionBridge struct {
+	d *Daemon
+}
+
+// RelayClipboard types text copied in a page's floating panel into the
+// session of the page's project, like SESSION SEND without submitting it
+// unless enter is set.
+func (b sessionBridge) RelayClipboard(px *proxy.ProxyServer, clip proxy.ClipboardEntry, enter bool) error {
+	d := b.d
+	if err := clip.Validate(); err != nil {
+		return err
+	}
+	session, ok := d.sessionRegistry.FindByDirectory(px.Path)
+	if !ok {
+		return fmt.Errorf("no active session for %s", px.Path)
+	}
+
+	msg, err := session.typeMessage(clip.Text, SendOptions{Enter: &enter})
+	if err != nil {
+		return err
+	}
+	if err := d.sendMessageToOverlay(session.OverlayPath, msg); err != nil {
+		return err
+	}
+	session.setClipboard(clip)
+	return nil
+}
+
+// shareTerminalClipboard offers text from the terminal to the floating panel
+// of the session's proxied pages. Returns the number of pages reached.
+func (d *Daemon) shareTerminalClipboard(session *Session, clip proxy.ClipboardEntry) (int, error) {
+	if err := clip.Validate(); err != nil {
+		return 0, err
+	}
+	session.setClipboard(clip)
+
+	sent := 0
+	projectPath := normalizePath(session.ProjectPath)
+	for _, px := range d.proxym.List() {
+		if normalizePath(px.Path) != projectPath {
+			continue
+		}
+		n, err := px.BroadcastClipboard(clip)
+		if err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// hubHandleSessionClipboard handles SESSION CLIPBOARD command.
+// SESSION CLIPBOARD <code> [-- <text>]
+// With text, shares it from the terminal with the session's proxied pages;
+// without, returns the last text moved either way.
+func (d *Daemon) hubHandleSessionClipboard(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION CLIPBOARD requires: <code>")
+	}
+
+	code := cmd.Args[0]
+	session, ok := d.sessionRegistry.Get(code)
+	if !ok {
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
+	}
+
+	if len(cmd.Data) == 0 {
+		resp := map[string]interface{}{"session_code": code}
+		if clip, ok := session.Clipboard(); ok {
+			resp["clipboard"] = clip
+		}
+		data, _ := json.Marshal(resp)
+		return conn.WriteJSON(data)
+	}
+
+	clip := proxy.ClipboardEntry{
+		Text:      string(cmd.Data),
+		Source:    proxy.ClipboardFromTerminal,
+		Timestamp: time.Now(),
+	}
+	sent, err := d.shareTerminalClipboard(session, clip)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	resp := map[string]interface{}{
+		"success":      true,
+		"session_code": code,
+		"bytes":        len(clip.Text),
+		"sent_count":   sent,
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
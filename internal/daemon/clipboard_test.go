@@ -0,0 +1,94 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestSessionClipboard(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Overlay that records typed messages
+	overlayPath := filepath.Join(tmpDir, "overlay.sock")
+	listener, err := net.Listen("unix", overlayPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	typed := make(chan overlayTypeMessage, 4)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg overlayTypeMessage
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &msg)
+		typed <- msg
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	session := &Session{Code: "claude-1", Command: "claude", OverlayPath: overlayPath, ProjectPath: tmpDir, Status: SessionStatusActive}
+	if err := d.sessionRegistry.Register(session); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer px.Stop(context.Background())
+
+	bridge := sessionBridge{d: d}
+
+	// Page to terminal: typed without submitting
+	clip := proxy.ClipboardEntry{Text: "TypeError: x is undefined", Source: proxy.ClipboardFromPage, URL: "http://localhost:3000/", Timestamp: time.Now()}
+	if err := bridge.RelayClipboard(px, clip, false); err != nil {
+		t.Fatalf("RelayClipboard failed: %v", err)
+	}
+	select {
+	case msg := <-typed:
+		if msg.Text != clip.Text || msg.Enter {
+			t.Errorf("Expected the clip typed without enter, got %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the clip typed into the session")
+	}
+	if got, ok := session.Clipboard(); !ok || got.Source != proxy.ClipboardFromPage || got.URL != clip.URL {
+		t.Errorf("Expected the page clip recorded, got %+v", got)
+	}
+
+	// Terminal to page: recorded and broadcast to the project's proxies
+	sent, err := d.shareTerminalClipboard(session, proxy.ClipboardEntry{Text: "npm ERR! missing script", Source: proxy.ClipboardFromTerminal})
+	if err != nil || sent != 0 {
+		t.Errorf("Expected no pages reached, got %d (%v)", sent, err)
+	}
+	if got, _ := session.Clipboard(); got.Source != proxy.ClipboardFromTerminal {
+		t.Errorf("Expected the terminal clip recorded, got %+v", got)
+	}
+
+	large := proxy.ClipboardEntry{Text: strings.Repeat("x", proxy.MaxClipboardBytes+1), Source: proxy.ClipboardFromPage}
+	if err := bridge.RelayClipboard(px, large, false); err == nil {
+		t.Error("Expected error for text over the size limit")
+	}
+	if _, err := d.shareTerminalClipboard(session, proxy.ClipboardEntry{Source: proxy.ClipboardFromTerminal}); err == nil {
+		t.Error("Expected error for empty text")
+	}
+
+	other, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "other", TargetURL: "http://localhost:3001", ListenPort: -1, Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer other.Stop(context.Background())
+	if err := bridge.RelayClipboard(other, clip, false); err == nil {
+		t.Error("Expected error for a page without a session")
+	}
+}
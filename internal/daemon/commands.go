@@ -0,0 +1,656 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/ptyhost"
+	"github.com/standardbeagle/agnt/internal/workspace"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// commandSpec describes a daemon command. It is the single source for both
+// Hub registration and HELP, so the documented sub-verbs are exactly the ones
+// registered and the payload schemas come from the types handlers decode.
+type commandSpec struct {
+	verb        string
+	description string
+	handler     func(*Daemon, context.Context, *hubpkg.Connection, *hubproto.Command) error // nil for Hub built-ins
+
+	// For verbs without sub-verbs
+	args     []protocol.ArgHelp
+	data     interface{}
+	dataText string
+	examples []string
+
+	subVerbs []subVerbSpec
+}
+
+// subVerbSpec describes one sub-verb of a command.
+type subVerbSpec struct {
+	name        string
+	description string
+	args        []protocol.ArgHelp
+	data        interface{} // Zero value of the JSON payload type
+	dataText    string      // Description of a non-JSON payload
+	examples    []string    // Command line, then the payload on the next line
+}
+
+func arg(name, description string) protocol.ArgHelp {
+	return protocol.ArgHelp{Name: name, Description: description}
+}
+
+func optArg(name, description string) protocol.ArgHelp {
+	return protocol.ArgHelp{Name: name, Description: description, Optional: true}
+}
+
+var (
+	processIDArg = arg("process_id", "Managed process ID")
+	proxyIDArg   = arg("proxy_id", "Proxy ID (session-scoped IDs are resolved)")
+	tunnelIDArg  = arg("tunnel_id", "Tunnel ID")
+	sessionArg   = arg("code", "Session code")
+	labelsArg    = protocol.ArgHelp{Name: "labels", Description: "key=value sets a label, key- removes it; none lists the labels", Optional: true, Variadic: true}
+	dryRunOptArg = optArg(protocol.DryRunArg, "Report what would change instead of changing it")
+)
+
+// commandSpecs returns every command the daemon answers, in HELP order.
+func commandSpecs() []commandSpec {
+	return []commandSpec{
+		{
+			verb:        protocol.VerbHelp,
+			description: "Machine-readable usage of daemon commands",
+			handler:     (*Daemon).hubHandleHelp,
+			args:        []protocol.ArgHelp{optArg("verb", "Command to describe"), optArg("sub_verb", "Sub-verb to describe")},
+			examples:    []string{"HELP", "HELP PROC", "HELP PROXY START"},
+		},
+		{
+			verb:        protocol.VerbPing,
+			description: "Check that the daemon is alive",
+			examples:    []string{"PING"},
+		},
+		{
+			verb:        protocol.VerbInfo,
+			description: "Daemon version and uptime",
+			examples:    []string{"INFO"},
+		},
+		{
+			verb:        protocol.VerbShutdown,
+			description: "Stop the daemon",
+			examples:    []string{"SHUTDOWN"},
+		},
+		{
+			verb:        protocol.VerbRun,
+			description: "Start a process",
+			args: []protocol.ArgHelp{
+				arg("id", "Process ID"),
+				arg("project_path", "Working directory"),
+				arg("mode", "background, foreground or foreground-raw"),
+				arg("command", "Executable"),
+				{Name: "args", Description: "Command arguments", Optional: true, Variadic: true},
+			},
+			examples: []string{"RUN dev /home/dev/app background npm run dev"},
+		},
+		{
+			verb:        protocol.VerbRunJSON,
+			description: "Start a process described by a JSON payload",
+			data:        hubproto.RunConfig{},
+			examples:    []string{"RUN-JSON\n{\"id\":\"dev\",\"path\":\"/home/dev/app\",\"mode\":\"background\",\"command\":\"npm\",\"args\":[\"run\",\"dev\"]}"},
+		},
+		{
+			verb:        protocol.VerbProc,
+			description: "Manage running processes",
+			handler:     (*Daemon).hubHandleProc,
+			subVerbs: []subVerbSpec{
+				{name: "STATUS", description: "State, exit code, detected URLs and crash summary of a process", args: []protocol.ArgHelp{processIDArg}, examples: []string{"PROC STATUS dev"}},
+				{
+					name:        "OUTPUT",
+					description: "Captured output, optionally filtered; filters may also be given as key=value args",
+					args: []protocol.ArgHelp{
+						processIDArg,
+						optArg("stream=stdout|stderr|combined", "Stream to read (default: combined)"),
+						optArg("tail=N", "Last N lines"),
+						optArg("head=N", "First N lines"),
+						optArg("grep=pattern", "Only lines matching the regular expression"),
+						optArg("grep_v", "Invert grep"),
+						optArg("follow", "Stream new lines as CHUNK frames until the process exits or the client disconnects"),
+						optArg("timeout_ms=N", "With follow: stop streaming after N ms"),
+					},
+					data:     protocol.ProcOutputRequest{},
+					examples: []string{"PROC OUTPUT dev tail=50", "PROC OUTPUT dev stream=stderr grep=error", "PROC OUTPUT dev tail=20 follow"},
+				},
+				{name: "STOP", description: "Stop a process; cascade also stops the proxies and tunnels that depend on it", args: []protocol.ArgHelp{processIDArg, optArg("force", "Kill immediately"), optArg("cascade", "Stop dependents too")}, examples: []string{"PROC STOP dev", "PROC STOP dev force cascade"}},
+				{name: "RESTART", description: "Restart a process with the same command, args, environment and project path, clearing rogue listeners on its port", args: []protocol.ArgHelp{processIDArg}, examples: []string{"PROC RESTART dev"}},
+				{name: "LIST", description: "Processes of the session's project, or of all projects, optionally only those with the given labels", data: protocol.ListFilter{}, examples: []string{"PROC LIST", "PROC LIST\n{\"global\":true}", "PROC LIST\n{\"labels\":{\"area\":\"checkout\"}}"}},
+				{name: "CLEANUP-PORT", description: "Kill the processes listening on a port", args: []protocol.ArgHelp{arg("port", "TCP port"), dryRunOptArg}, examples: []string{"PROC CLEANUP-PORT 3000", "PROC CLEANUP-PORT 3000 dry-run"}},
+				{name: "CRASH", description: "Crash reports: the project's list, a report by ID, or a process's latest", args: []protocol.ArgHelp{optArg("ref", "Report ID or process ID")}, data: procCrashRequest{}, examples: []string{"PROC CRASH", "PROC CRASH dev"}},
+				{name: protocol.SubVerbLabel, description: "Set or remove labels of a process; they survive restarts", args: []protocol.ArgHelp{processIDArg, labelsArg}, examples: []string{"PROC LABEL dev area=checkout owner=payments", "PROC LABEL dev owner-"}},
+				{name: protocol.SubVerbSupervise, description: "Health check a process over HTTP or TCP and restart it per policy (never, on-failure, always); state is shown in PROC STATUS", args: []protocol.ArgHelp{processIDArg, optArg("off", "Stop supervising")}, data: protocol.SuperviseConfig{}, examples: []string{"PROC SUPERVISE dev\n{\"health_check\":{\"url\":\"http://localhost:3000/health\"},\"restart\":\"on-failure\"}", "PROC SUPERVISE worker\n{\"restart\":\"always\",\"max_restarts\":10}", "PROC SUPERVISE dev off"}},
+				{name: protocol.SubVerbMetrics, description: "CPU and memory of a process and its children, sampled every 5s with 10 minutes of history; the latest sample is also in PROC STATUS", args: []protocol.ArgHelp{processIDArg, optArg("limit=N", "Only the last N samples")}, examples: []string{"PROC METRICS dev", "PROC METRICS dev limit=12"}},
+				{name: protocol.SubVerbResults, description: "Test results (go test, jest, vitest, pytest) and compiler errors (Go, TypeScript, Rust) parsed from a process's output: counts, failing tests with their messages, the slowest tests", args: []protocol.ArgHelp{processIDArg}, examples: []string{"PROC RESULTS test"}},
+			},
+		},
+		{
+			verb:        protocol.VerbDetect,
+			description: "Detect project type and available scripts",
+			handler:     (*Daemon).hubHandleDetect,
+			args:        []protocol.ArgHelp{optArg("path", "Project directory (default: current)")},
+			examples:    []string{"DETECT /home/dev/app"},
+		},
+		{
+			verb:        protocol.VerbProxy,
+			description: "Manage reverse proxies",
+			handler:     (*Daemon).hubHandleProxy,
+			subVerbs: []subVerbSpec{
+				{
+					name:        "START",
+					description: "Start a proxy; port -1 derives a stable port from the target URL, 0 picks a free one",
+					args:        []protocol.ArgHelp{arg("id", "Proxy ID"), arg("target_url", "URL to forward to"), arg("port", "Listen port"), optArg("max_log_size", "Traffic log entries to keep (default: 1000)")},
+					data:        proxyStartRequest{},
+					examples:    []string{"PROXY START app http://localhost:3000 -1", "PROXY START app http://localhost:3000 0 2000\n{\"bind_address\":\"0.0.0.0\"}", "PROXY START app http://localhost:3000 -1\n{\"routes\":[{\"host\":\"*.localhost\",\"target\":\"3000\"},{\"host\":\"auth.localhost\",\"target\":\"4000\"}]}", "PROXY START app http://localhost:5173 -1\n{\"path_routes\":[{\"path\":\"/api\",\"target\":\"8000\"}]}"},
+				},
+				{name: "STOP", description: "Stop a proxy; cascade also stops the tunnels in front of it", args: []protocol.ArgHelp{proxyIDArg, optArg("cascade", "Stop dependents too")}, examples: []string{"PROXY STOP app", "PROXY STOP app cascade"}},
+				{name: "RESTART", description: "Restart a proxy on the same port", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"PROXY RESTART app"}},
+				{name: "STATUS", description: "Listen address, target and statistics of a proxy", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"PROXY STATUS app"}},
+				{name: "LIST", description: "Proxies of the session's project, or of all projects, optionally only those with the given labels", data: protocol.ListFilter{}, examples: []string{"PROXY LIST", "PROXY LIST\n{\"labels\":{\"area\":\"checkout\"}}"}},
+				{name: "EXEC", description: "Run JavaScript in the browser pages connected to the proxy", args: []protocol.ArgHelp{proxyIDArg}, dataText: "JavaScript source", examples: []string{"PROXY EXEC app\ndocument.title"}},
+				{name: "TOAST", description: "Show a toast notification in connected pages; with actions, a click is typed into the session, or returned when wait_ms is set", args: []protocol.ArgHelp{proxyIDArg}, data: proxyToastRequest{}, examples: []string{"PROXY TOAST app\n{\"toast_type\":\"success\",\"toast_message\":\"Build finished\"}", "PROXY TOAST app\n{\"toast_title\":\"Apply fix?\",\"toast_message\":\"Retry failed requests\",\"actions\":[{\"label\":\"Yes\",\"callback\":\"apply\"},{\"label\":\"No\",\"callback\":\"skip\"}],\"wait_ms\":60000}"}},
+				{name: protocol.SubVerbLabel, description: "Set or remove labels of a proxy", args: []protocol.ArgHelp{proxyIDArg, labelsArg}, examples: []string{"PROXY LABEL app area=checkout", "PROXY LABEL app area-"}},
+				{name: protocol.SubVerbUI, description: "Overlay language, strings and theme of a proxy; with a payload, replace them and apply them to connected pages", args: []protocol.ArgHelp{proxyIDArg}, data: proxy.OverlayUI{}, examples: []string{"PROXY UI app", "PROXY UI app\n{\"language\":\"de\",\"theme\":\"dark\",\"position\":\"bottom-right\"}", "PROXY UI app\n{\"accent\":\"#0ea5e9\",\"minimal\":true,\"messages\":{\"banner.label\":\"shop dev\"}}", "PROXY UI app\n{\"hidden\":true}"}},
+				{name: protocol.SubVerbRoutes, description: "Add, remove or list path routes sending path prefixes to other upstreams; the longest prefix wins", args: []protocol.ArgHelp{arg("action", "ADD, REMOVE or LIST"), proxyIDArg, optArg("path", "For REMOVE: the route's path")}, data: proxy.PathRoute{}, examples: []string{"PROXY ROUTES ADD app\n{\"path\":\"/api\",\"target\":\"8000\"}", "PROXY ROUTES REMOVE app /api", "PROXY ROUTES LIST app"}},
+				{name: protocol.SubVerbIntercept, description: "Pause requests whose path and query match a regex until they are resumed, possibly modified, or rejected; a paused request continues unchanged after the rule's timeout_ms (default 60000). LIST takes {wait_ms} to wait for a request to pause, MODIFY and RESUME an edit {method, url, set_headers, remove_headers, body}, REJECT {status, body}", args: []protocol.ArgHelp{arg("action", "ADD, REMOVE, LIST, MODIFY, RESUME, REJECT or CLEAR"), proxyIDArg, optArg("target", "For ADD: URL pattern; for REMOVE: rule ID; for MODIFY, RESUME and REJECT: paused request ID")}, data: proxy.InterceptRule{}, examples: []string{"PROXY INTERCEPT ADD app ^/api/orders", "PROXY INTERCEPT ADD app\n{\"id\":\"checkout\",\"methods\":[\"POST\"],\"url_pattern\":\"^/api/checkout\",\"timeout_ms\":120000}", "PROXY INTERCEPT LIST app\n{\"wait_ms\":30000}", "PROXY INTERCEPT MODIFY app int-1\n{\"set_headers\":{\"Authorization\":\"Bearer expired\"},\"body\":\"{\\\"qty\\\":-1}\"}", "PROXY INTERCEPT RESUME app int-1", "PROXY INTERCEPT REJECT app int-1\n{\"status\":503,\"body\":\"maintenance\"}", "PROXY INTERCEPT REMOVE app checkout", "PROXY INTERCEPT CLEAR app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbProxyLog,
+			description: "Query proxy traffic logs",
+			handler:     (*Daemon).hubHandleProxyLog,
+			subVerbs: []subVerbSpec{
+				{name: "QUERY", description: "Log entries matching a filter", args: []protocol.ArgHelp{proxyIDArg}, data: proxy.LogFilter{}, examples: []string{"PROXYLOG QUERY app\n{\"types\":[\"http\"],\"status_codes\":[500],\"limit\":20}", "PROXYLOG QUERY app\n{\"types\":[\"ws_message\"],\"url_pattern\":\"/socket\"}"}},
+				{name: "SUMMARY", description: "Aggregate view of recent traffic and errors", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"PROXYLOG SUMMARY app"}},
+				{name: "CLEAR", description: "Discard logged entries", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"PROXYLOG CLEAR app"}},
+				{name: "STATS", description: "Log buffer statistics and per-route request counts, error rates, p50/p95/p99 latency and average upstream vs proxy time, slowest first", args: []protocol.ArgHelp{proxyIDArg}, data: proxy.RouteStatsFilter{}, examples: []string{"PROXYLOG STATS app", "PROXYLOG STATS app\n{\"sort_by\":\"error_rate\",\"min_count\":10}", "PROXYLOG STATS app\n{\"sort_by\":\"proxy\"}"}},
+				{name: protocol.SubVerbAggregate, description: "Response bytes by content type and the largest responses", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"PROXYLOG AGGREGATE app"}},
+				{name: protocol.SubVerbReplay, description: "Re-issue a logged request against the target and log the new response; credentials masked in the log aren't resent", args: []protocol.ArgHelp{proxyIDArg, arg("entry_id", "ID of the logged HTTP request")}, data: proxy.ReplayOverrides{}, examples: []string{"PROXYLOG REPLAY app req-42", "PROXYLOG REPLAY app req-42\n{\"headers\":{\"Authorization\":\"Bearer dev-token\"},\"body\":\"{\\\"id\\\":7}\"}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbCurrentPage,
+			description: "View active page sessions",
+			handler:     (*Daemon).hubHandleCurrentPage,
+			subVerbs: []subVerbSpec{
+				{name: "LIST", description: "Pages currently open through the proxy", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CURRENTPAGE LIST app"}},
+				{name: "GET", description: "Full details of a page session", args: []protocol.ArgHelp{proxyIDArg, arg("session_id", "Page session ID")}, examples: []string{"CURRENTPAGE GET app page-1"}},
+				{name: "SUMMARY", description: "Condensed view of a page session", args: []protocol.ArgHelp{proxyIDArg, arg("session_id", "Page session ID")}, examples: []string{"CURRENTPAGE SUMMARY app page-1"}},
+				{name: "CLEAR", description: "Forget tracked page sessions", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CURRENTPAGE CLEAR app"}},
+				{name: protocol.SubVerbWaitForIdle, description: "Wait until a page (or every page) has no requests in flight and no DOM mutations for the quiet window", args: []protocol.ArgHelp{proxyIDArg, optArg("session_id", "Page session ID (default: all pages)")}, data: proxy.IdleOptions{}, examples: []string{"CURRENTPAGE WAIT-FOR-IDLE app page-1", "CURRENTPAGE WAIT-FOR-IDLE app\n{\"quiet_ms\":1000,\"timeout_ms\":20000}"}},
+				{name: protocol.SubVerbSnapshot, description: "Capture and keep a serialized DOM of a page: tags, attributes, own text and optionally key computed styles, without scripts, styles or the overlay. The last 20 snapshots are kept", args: []protocol.ArgHelp{proxyIDArg, optArg("session_id", "Page session ID (default: whichever page answers first)")}, data: proxy.DOMSnapshotOptions{}, examples: []string{"CURRENTPAGE SNAPSHOT app page-1", "CURRENTPAGE SNAPSHOT app page-1\n{\"name\":\"before\",\"selector\":\"#app\",\"styles\":true}"}},
+				{name: protocol.SubVerbSnapshots, description: "Kept DOM snapshots, oldest first", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CURRENTPAGE SNAPSHOTS app"}},
+				{name: protocol.SubVerbDiff, description: "Elements added, removed and changed (text, attributes, styles) between two DOM snapshots", args: []protocol.ArgHelp{proxyIDArg, arg("from", "Earlier snapshot ID"), arg("to", "Later snapshot ID")}, examples: []string{"CURRENTPAGE DIFF app dom-1 dom-2"}},
+				{name: protocol.SubVerbCoverage, description: "Unused CSS by stylesheet: style rules of the page that matched no element on any page of its browser tab, largest first. Rules count as used whatever their @media condition or :hover-like state; cross-origin sheets without CORS are listed as inaccessible", args: []protocol.ArgHelp{proxyIDArg, optArg("session_id", "Page session ID (default: whichever page answers first)")}, data: proxy.CSSCoverageOptions{}, examples: []string{"CURRENTPAGE COVERAGE app page-1", "CURRENTPAGE COVERAGE app page-1\n{\"limit\":50}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbScreenshot,
+			description: "Screenshots of proxied pages rendered by the connected browser, stored in the project's .agnt/audit/screenshots with thumbnails; a proxy keeps its newest 100 files for up to 7 days",
+			handler:     (*Daemon).hubHandleScreenshot,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbCapture, description: "Capture the full page (up to 16000px), the viewport or one element, optionally with the targets of recent interactions outlined and numbered newest first; returns the file path, a PNG thumbnail of at most 320x960 and where each outline landed", args: []protocol.ArgHelp{proxyIDArg, optArg("session_id", "Page session ID (default: whichever page answers first)")}, data: proxy.ScreenshotOptions{}, examples: []string{"SCREENSHOT CAPTURE app page-1", "SCREENSHOT CAPTURE app page-1\n{\"name\":\"checkout\",\"selector\":\"#cart\",\"annotate\":true}", "SCREENSHOT CAPTURE app\n{\"viewport\":true,\"format\":\"jpeg\"}"}},
+				{name: protocol.SubVerbList, description: "Stored screenshots of a proxy, newest first", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"SCREENSHOT LIST app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbRecord,
+			description: "Flows of clicks, field values, key presses and page loads recorded from a proxied page and stored in the project's .agnt/recordings, replayed through the injected script as regression checks; a proxy records one flow at a time",
+			handler:     (*Daemon).hubHandleRecord,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbStart, description: "Start recording a page session, or the first page to act", args: []protocol.ArgHelp{proxyIDArg, optArg("session_id", "Page session ID (default: the first page to act)")}, data: proxy.RecordOptions{}, examples: []string{"RECORD START app page-1", "RECORD START app\n{\"name\":\"checkout\"}"}},
+				{name: protocol.SubVerbStop, description: "Stop recording, after waiting 1.5s for the page's last interactions, and store the steps", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"RECORD STOP app"}},
+				{name: protocol.SubVerbStatus, description: "The recording in progress, if any", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"RECORD STATUS app"}},
+				{name: protocol.SubVerbReplay, description: "Replay a stored recording step by step, waiting for each element or page, and report each step as passed, failed or skipped; values of fields masked when recorded are passed by selector", args: []protocol.ArgHelp{proxyIDArg, arg("name", "Recording name"), optArg("session_id", "Page session ID (default: whichever page answers first)")}, data: proxy.RecordingReplayOptions{}, examples: []string{"RECORD REPLAY app checkout page-1", "RECORD REPLAY app login\n{\"values\":{\"#password\":\"dev-secret\"},\"step_timeout_ms\":10000}"}},
+				{name: protocol.SubVerbList, description: "Stored recordings of the proxy's project, newest first", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"RECORD LIST app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbA11y,
+			description: "Accessibility audits run in the connected browser",
+			handler:     (*Daemon).hubHandleA11y,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbAudit, description: "Audit a page, or the element matching a selector, against WCAG with axe-core (falling back to the built-in checks when it cannot load); violations are grouped by rule with counts and sample selectors, errors first", args: []protocol.ArgHelp{proxyIDArg, optArg("session_id", "Page session ID (default: whichever page answers first)")}, data: proxy.A11yAuditOptions{}, examples: []string{"A11Y AUDIT app page-1", "A11Y AUDIT app\n{\"selector\":\"#checkout\",\"level\":\"aaa\"}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbOverlay,
+			description: "Configure overlay endpoint",
+			handler:     (*Daemon).hubHandleOverlay,
+			subVerbs: []subVerbSpec{
+				{name: "SET", description: "Set the overlay endpoint proxies forward events to", data: overlaySetRequest{}, examples: []string{"OVERLAY SET\n{\"endpoint\":\"/tmp/agnt-overlay.sock\"}"}},
+				{name: "GET", description: "Current overlay endpoint", examples: []string{"OVERLAY GET"}},
+				{name: "CLEAR", description: "Remove the overlay endpoint", examples: []string{"OVERLAY CLEAR"}},
+				{name: "ACTIVITY", description: "Broadcast agent activity to connected pages", args: []protocol.ArgHelp{arg("active", "true or false"), {Name: "proxy_ids", Description: "Limit to these proxies", Optional: true, Variadic: true}}, examples: []string{"OVERLAY ACTIVITY true"}},
+				{name: "OUTPUT-PREVIEW", description: "Broadcast recent agent output lines to connected pages", data: outputPreviewRequest{}, examples: []string{"OVERLAY OUTPUT-PREVIEW\n{\"lines\":[\"Running tests...\"]}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbTunnel,
+			description: "Manage tunnel connections",
+			handler:     (*Daemon).hubHandleTunnel,
+			subVerbs: []subVerbSpec{
+				{name: "START", description: "Start a tunnel to a local port with cloudflare, ngrok, localtunnel, tailscale (Funnel) or a custom command whose output carries the URL", args: []protocol.ArgHelp{tunnelIDArg}, data: tunnelStartRequest{}, examples: []string{"TUNNEL START app\n{\"provider\":\"cloudflare\",\"local_port\":45123,\"proxy_id\":\"app\"}", "TUNNEL START app\n{\"provider\":\"tailscale\",\"local_port\":45123,\"proxy_id\":\"app\"}", "TUNNEL START app\n{\"provider\":\"cloudflare\",\"local_port\":45123,\"proxy_id\":\"app\",\"access_token\":\"auto\"}", "TUNNEL START app\n{\"provider\":\"custom\",\"local_port\":45123,\"command\":\"ssh -R 80:localhost:{{PORT}} nokey@localhost.run\",\"url_pattern\":\"https://[a-z0-9]+\\\\.lhr\\\\.life\"}"}},
+				{name: "STOP", description: "Stop a tunnel", args: []protocol.ArgHelp{tunnelIDArg}, examples: []string{"TUNNEL STOP app"}},
+				{name: "STATUS", description: "Public URL, traffic and limits of a tunnel", args: []protocol.ArgHelp{tunnelIDArg}, examples: []string{"TUNNEL STATUS app"}},
+				{name: "LIST", description: "Tunnels of the session's project, or of all projects, optionally only those with the given labels", data: protocol.ListFilter{}, examples: []string{"TUNNEL LIST", "TUNNEL LIST\n{\"labels\":{\"owner\":\"\"}}"}},
+				{name: "RESUME", description: "Resume a tunnel paused by its bandwidth cap", args: []protocol.ArgHelp{tunnelIDArg}, examples: []string{"TUNNEL RESUME app"}},
+				{name: protocol.SubVerbEvents, description: "Disconnects, failed health checks and reconnects of the session's tunnels, oldest first, including tunnels that have ended", args: []protocol.ArgHelp{optArg("id", "Only this tunnel's events")}, data: tunnelEventsRequest{}, examples: []string{"TUNNEL EVENTS", "TUNNEL EVENTS app\n{\"limit\":20}"}},
+				{name: protocol.SubVerbLabel, description: "Set or remove labels of a tunnel", args: []protocol.ArgHelp{tunnelIDArg, labelsArg}, examples: []string{"TUNNEL LABEL app owner=payments"}},
+			},
+		},
+		{
+			verb:        protocol.VerbExpose,
+			description: "Expose a dev server publicly via proxy and tunnel",
+			handler:     (*Daemon).hubHandleExpose,
+			subVerbs: []subVerbSpec{
+				{name: "START", description: "Run a script (or use a URL), proxy it and open a tunnel", args: []protocol.ArgHelp{arg("id", "Exposure ID")}, data: exposeStartRequest{}, examples: []string{"EXPOSE START dev\n{\"provider\":\"cloudflare\"}"}},
+				{name: "STOP", description: "Tear down what the exposure started", args: []protocol.ArgHelp{arg("id", "Exposure ID")}, examples: []string{"EXPOSE STOP dev"}},
+				{name: "LIST", description: "Active exposures", examples: []string{"EXPOSE LIST"}},
+			},
+		},
+		{
+			verb:        protocol.VerbTest,
+			description: "Record test results and view per-test history",
+			handler:     (*Daemon).hubHandleTest,
+			subVerbs: []subVerbSpec{
+				{name: "RECORD", description: "Parse test results from a process's output or raw output", args: []protocol.ArgHelp{optArg("process_id", "Process whose output holds the results")}, data: testRecordRequest{}, examples: []string{"TEST RECORD test"}},
+				{name: "HISTORY", description: "Recent runs and per-test outcomes", args: []protocol.ArgHelp{optArg("filter", "Test name substring")}, examples: []string{"TEST HISTORY TestLogin"}},
+				{name: "RUN", description: "Run the test suite, optionally sharded or limited to affected tests", data: testRunRequest{}, examples: []string{"TEST RUN\n{\"shards\":4}", "TEST RUN\n{\"affected\":true,\"dry_run\":true}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbFlaky,
+			description: "Report tests that flip outcome without related changes",
+			handler:     (*Daemon).hubHandleFlaky,
+			subVerbs: []subVerbSpec{
+				{name: "LIST", description: "Flaky tests of the session's project", examples: []string{"FLAKY LIST"}},
+			},
+		},
+		{
+			verb:        protocol.VerbBench,
+			description: "Benchmark tracking per commit with regression detection",
+			handler:     (*Daemon).hubHandleBench,
+			subVerbs: []subVerbSpec{
+				{name: "RUN", description: "Run a benchmark suite and compare with its baseline", args: []protocol.ArgHelp{optArg("name", "Configured suite")}, data: benchRequest{}, examples: []string{"BENCH RUN", "BENCH RUN api\n{\"baseline\":\"main\"}"}},
+				{name: "REPORT", description: "Compare the latest run with its baseline without running", args: []protocol.ArgHelp{optArg("name", "Configured suite")}, data: benchRequest{}, examples: []string{"BENCH REPORT"}},
+				{name: "HISTORY", description: "Per-benchmark results across commits", args: []protocol.ArgHelp{optArg("filter", "Benchmark name substring")}, examples: []string{"BENCH HISTORY Render"}},
+			},
+		},
+		{
+			verb:        protocol.VerbProfile,
+			description: "Capture CPU/heap profiles from managed processes",
+			handler:     (*Daemon).hubHandleProfile,
+			subVerbs: []subVerbSpec{
+				{name: "CPU", description: "Sample a CPU profile and summarize the top functions", args: []protocol.ArgHelp{processIDArg}, data: profileRequest{}, examples: []string{"PROFILE CPU api\n{\"seconds\":15}"}},
+				{name: "HEAP", description: "Capture a heap profile and summarize the top allocators", args: []protocol.ArgHelp{processIDArg}, data: profileRequest{}, examples: []string{"PROFILE HEAP api"}},
+				{name: "LIST", description: "Saved profiles of the project", data: profileRequest{}, examples: []string{"PROFILE LIST"}},
+			},
+		},
+		{
+			verb:        protocol.VerbGraph,
+			description: "Dependency graph between processes, proxies and tunnels",
+			handler:     (*Daemon).hubHandleGraph,
+			subVerbs: []subVerbSpec{
+				{name: "SHOW", description: "Nodes and edges of the session's project, or of all projects", args: []protocol.ArgHelp{optArg("global", "Include all projects")}, examples: []string{"GRAPH SHOW", "GRAPH SHOW global"}},
+				{name: "IMPACT", description: "Everything that depends on an entity", args: []protocol.ArgHelp{arg("kind", "process, proxy or tunnel"), arg("id", "Entity ID")}, examples: []string{"GRAPH IMPACT process dev"}},
+			},
+		},
+		{
+			verb:        protocol.VerbWorkspace,
+			description: "Disposable copies of a project, removed when their session ends; RUN one by its path",
+			handler:     (*Daemon).hubHandleWorkspace,
+			subVerbs: []subVerbSpec{
+				{name: "CREATE", description: "Git worktree of the working state or a ref, or a plain copy", args: []protocol.ArgHelp{optArg("id", "Workspace ID (default ws-N)")}, data: workspace.CreateOptions{}, examples: []string{"WORKSPACE CREATE", "WORKSPACE CREATE base\n{\"ref\":\"main\"}"}},
+				{name: "LIST", description: "Workspaces of a directory, or all", data: sessionFilter{}, examples: []string{"WORKSPACE LIST", "WORKSPACE LIST\n{\"global\":true}"}},
+				{name: "GET", description: "Details of a workspace, including its path", args: []protocol.ArgHelp{arg("id", "Workspace ID")}, examples: []string{"WORKSPACE GET ws-1"}},
+				{name: "REMOVE", description: "Stop the processes running in a workspace and delete it", args: []protocol.ArgHelp{arg("id", "Workspace ID")}, examples: []string{"WORKSPACE REMOVE ws-1"}},
+			},
+		},
+		{
+			verb:        protocol.VerbGit,
+			description: "Status, diffs and history of the session's project repository as structured JSON, limited to paths inside the project when given",
+			handler:     (*Daemon).hubHandleGit,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbStatus, description: "Branch, upstream, ahead/behind and the staged, unstaged, untracked and conflicted files", data: protocol.GitRequest{}, examples: []string{"GIT STATUS", "GIT STATUS\n{\"paths\":[\"src\"]}"}},
+				{name: protocol.SubVerbDiff, description: "Changed files of the working tree, the index with staged, or against ref, with line counts and hunks; the patch text with patch", data: protocol.GitRequest{}, examples: []string{"GIT DIFF", "GIT DIFF\n{\"staged\":true}", "GIT DIFF\n{\"ref\":\"main\",\"paths\":[\"src/api\"],\"patch\":true}"}},
+				{name: protocol.SubVerbLog, description: "Commits, newest first, with their files and line counts, and the authors by commit count", data: protocol.GitRequest{}, examples: []string{"GIT LOG", "GIT LOG\n{\"since\":\"1 week ago\",\"author\":\"ann\",\"limit\":50}"}},
+				{name: protocol.SubVerbChanged, description: "Files that differ between the working tree and ref, or the last commit before since, committed or not and including untracked files, with the commits since", data: protocol.GitRequest{}, examples: []string{"GIT CHANGED\n{\"ref\":\"main\"}", "GIT CHANGED\n{\"since\":\"2 hours ago\"}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbHook,
+			description: "Scripts git hooks run through the daemon, from the git-hooks block of .agnt.kdl; sent by the hooks 'agnt hooks install' writes",
+			handler:     (*Daemon).hubHandleHook,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbRun, description: "Run the hook's scripts in order as managed processes until one fails, and show the failure in the project's pages and sessions; output stays in PROC OUTPUT", args: []protocol.ArgHelp{arg("hook", "Git hook, e.g. pre-commit"), optArg("path", "Project path when no session is attached")}, examples: []string{"HOOK RUN pre-commit", "HOOK RUN pre-push /home/dev/app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbConfig,
+			description: "Checks and reloads of the project's .agnt.kdl, the file the daemon reads for autostart, stacks, doubles and hooks",
+			handler:     (*Daemon).hubHandleConfig,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbValidate, description: "Report syntax and schema errors, names and ports given twice, and what autostart would fail to start, each with its line; nothing is started", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"CONFIG VALIDATE", "CONFIG VALIDATE /home/dev/app"}},
+				{name: protocol.SubVerbReload, description: "Apply edits to .agnt.kdl without restarting sessions: new autostart entries start, removed ones stop, running scripts and doubles whose settings changed restart, proxies whose target changed are retargeted; a file with errors is not applied. The daemon also does this by itself within about two seconds of a save", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"CONFIG RELOAD", "CONFIG RELOAD /home/dev/app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbCompare,
+			description: "Run the same script against the working tree and a base ref side by side",
+			handler:     (*Daemon).hubHandleCompare,
+			subVerbs: []subVerbSpec{
+				{name: "RUN", description: "Run in the project and in a workspace at the base ref (default HEAD), and diff tests, diagnostics, bundle sizes and benchmarks", args: []protocol.ArgHelp{optArg("sequential", "Run one after the other, for load-sensitive benchmarks")}, data: protocol.CompareConfig{}, examples: []string{"COMPARE RUN\n{\"script\":\"test\"}", "COMPARE RUN sequential\n{\"run\":\"go test -bench=. -count=5 ./...\",\"ref\":\"main\"}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbRemote,
+			description: "Run scripts on the remote checkout named by the remote block of .agnt.kdl, over ssh, with their ports forwarded back",
+			handler:     (*Daemon).hubHandleRemote,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbRun, description: "Run a script or command in the remote checkout as a managed process; URLs it prints point at local forwards", data: protocol.RemoteRunConfig{}, examples: []string{"REMOTE RUN\n{\"script\":\"dev\"}", "REMOTE RUN\n{\"run\":\"go test ./...\",\"mode\":\"foreground\"}"}},
+				{name: protocol.SubVerbStatus, description: "The project's remote, its remote processes and the port forwards", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"REMOTE STATUS"}},
+				{name: protocol.SubVerbForward, description: "Forward a port on the remote host's loopback to this machine", args: []protocol.ArgHelp{arg("port", "Remote TCP port"), optArg("path", "Project path when no session is attached")}, examples: []string{"REMOTE FORWARD 5432"}},
+			},
+		},
+		{
+			verb:        protocol.VerbK8s,
+			description: "kubectl port-forwards and pod log streams as managed processes, reconnected when pods restart, with container restart events",
+			handler:     (*Daemon).hubHandleK8s,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbForward, description: "Port-forward a service, deployment or pod to loopback", data: protocol.K8sForwardConfig{}, examples: []string{"K8S FORWARD\n{\"resource\":\"svc/api\",\"port\":8080}"}},
+				{name: protocol.SubVerbLogs, description: "Follow the logs of the pods matching a selector as process output, so PROC OUTPUT and grep work on them", data: protocol.K8sLogsConfig{}, examples: []string{"K8S LOGS\n{\"selector\":\"app=api\",\"namespace\":\"dev\"}"}},
+				{name: protocol.SubVerbStatus, description: "Forwards and log streams with their pods and container restarts", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"K8S STATUS"}},
+				{name: protocol.SubVerbStop, description: "Stop a forward or log stream", args: []protocol.ArgHelp{arg("id", "Forward or log stream ID"), optArg("path", "Project path when no session is attached")}, examples: []string{"K8S STOP svc-api-8080"}},
+			},
+		},
+		{
+			verb:        protocol.VerbStack,
+			description: "Named groups of scripts from the stacks block of .agnt.kdl, started in dependency order with readiness gating and stopped in reverse",
+			handler:     (*Daemon).hubHandleStack,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbStart, description: "Start the stack level by level and wait until every service is ready or one fails", args: []protocol.ArgHelp{arg("name", "Stack name"), optArg("path", "Project path when no session is attached")}, examples: []string{"STACK START dev"}},
+				{name: protocol.SubVerbStop, description: "Stop the stack's services, dependents first", args: []protocol.ArgHelp{arg("name", "Stack name"), optArg("path", "Project path when no session is attached")}, examples: []string{"STACK STOP dev"}},
+				{name: protocol.SubVerbStatus, description: "State of each service and its process", args: []protocol.ArgHelp{arg("name", "Stack name"), optArg("path", "Project path when no session is attached")}, examples: []string{"STACK STATUS dev"}},
+				{name: protocol.SubVerbList, description: "The project's stacks with their startup order", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"STACK LIST"}},
+			},
+		},
+		{
+			verb:        protocol.VerbDouble,
+			description: "Stand-ins for third-party APIs (Stripe, Auth0, generic REST or a JSON profile) with canned responses, latency and webhooks, run as managed processes whose env is added to the project's processes",
+			handler:     (*Daemon).hubHandleDouble,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbStart, description: "Start the double of that name from .agnt.kdl, or a built-in profile, and wait until it listens", args: []protocol.ArgHelp{arg("name", "Double name or built-in profile"), optArg("path", "Project path when no session is attached")}, data: protocol.DoubleStartConfig{}, examples: []string{"DOUBLE START stripe", "DOUBLE START payments\n{\"profile\":\"stripe\",\"webhook_url\":\"http://localhost:3000/api/webhooks\"}"}},
+				{name: protocol.SubVerbStop, description: "Stop a double", args: []protocol.ArgHelp{arg("name", "Double name"), optArg("path", "Project path when no session is attached")}, examples: []string{"DOUBLE STOP stripe"}},
+				{name: protocol.SubVerbList, description: "Built-in profiles, configured and running doubles, and the env they export", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"DOUBLE LIST"}},
+			},
+		},
+		{
+			verb:        protocol.VerbWatch,
+			description: "Watch project files matching a glob and re-run a script or notify the project's sessions when they change, nodemon style; files are polled every second",
+			handler:     (*Daemon).hubHandleWatch,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbAdd, description: "Watch files matching a glob (** for any directories; without / it matches file names anywhere) and restart the script's process on change, or start it from .agnt.kdl; - as script only notifies", args: []protocol.ArgHelp{arg("glob", "Glob relative to the project, such as src/**/*.ts or *.go"), optArg("script", "Script or process to re-run, or - to only notify"), optArg("path", "Project path when no session is attached")}, data: protocol.WatchConfig{}, examples: []string{"WATCH ADD *.go server", "WATCH ADD src/**/*.ts -", "WATCH ADD config/*.yaml api\n{\"debounce_ms\":1000,\"notify\":true}"}},
+				{name: protocol.SubVerbList, description: "The project's watches with their triggers and last changes", args: []protocol.ArgHelp{optArg("path", "Project path when no session is attached")}, examples: []string{"WATCH LIST"}},
+				{name: protocol.SubVerbRemove, description: "Stop a watch", args: []protocol.ArgHelp{arg("id", "Watch ID"), optArg("path", "Project path when no session is attached")}, examples: []string{"WATCH REMOVE watch-1"}},
+			},
+		},
+		{
+			verb:        protocol.VerbDaemon,
+			description: "Diagnostics of the daemon itself, to report its performance issues with data",
+			handler:     (*Daemon).hubHandleDaemon,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbProfile, description: "Profile the daemon's CPU for some seconds (default 30, max 120) or snapshot its heap; stores the pprof file in the project's .agnt/profiles, or next to the daemon state without a project, and returns the top functions", args: []protocol.ArgHelp{optArg("kind", "cpu (default) or heap")}, data: daemonProfileRequest{}, examples: []string{"DAEMON PROFILE cpu", "DAEMON PROFILE heap", "DAEMON PROFILE cpu\n{\"seconds\":10,\"top\":30}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbResolve,
+			description: "Entity type and full ID of a partial process, proxy, tunnel or session ID; matches in the session's project come first, and several equally good matches are listed as candidates",
+			handler:     (*Daemon).hubHandleResolve,
+			args:        []protocol.ArgHelp{arg("partial-id", "Full ID, one of its :-separated parts, a prefix or a near miss"), optArg("type", "process, proxy, tunnel or session")},
+			examples:    []string{"RESOLVE dev", "RESOLVE app proxy"},
+		},
+		{
+			verb:        protocol.VerbWait,
+			description: "Block until the next matching event, to check whether a fix held",
+			handler:     (*Daemon).hubHandleWait,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbError, description: "The next frontend error, 5xx response through a proxy or process failure of the session's project, with the failed requests and interactions before it or the process's stderr tail; times out after timeout_ms (default 60000, max 300000) with timed_out", data: protocol.WaitErrorConfig{}, examples: []string{"WAIT ERROR", "WAIT ERROR\n{\"kinds\":[\"frontend_error\"],\"match\":\"checkout\",\"timeout_ms\":120000}", "WAIT ERROR\n{\"kinds\":[\"process_failure\"],\"source\":\"api\"}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbEvents,
+			description: "Log of the last 1000 things the daemon started, stopped and changed: process.started/exited, proxy.created/stopped/unreachable, tunnel.url, session.registered/unregistered, chaos.enabled/disabled, config.reloaded; each event has a sequence number",
+			handler:     (*Daemon).hubHandleEvents,
+			subVerbs: []subVerbSpec{
+				{name: protocol.SubVerbQuery, description: "The newest matching events of the session's project (default 100), oldest first, with last_seq to pass as since next time; a type without a dot matches its family", data: protocol.EventsFilter{}, examples: []string{"EVENTS QUERY", "EVENTS QUERY\n{\"types\":[\"process\"],\"source\":\"api\",\"limit\":20}", "EVENTS QUERY\n{\"since\":42,\"global\":true}", "EVENTS QUERY\n{\"labels\":{\"area\":\"checkout\"}}"}},
+				{name: protocol.SubVerbSubscribe, description: "Stream matching events as a CHUNK of newline-delimited JSON per batch, starting with the next event or after since, until limit events were sent or timeout_ms passes (default 60000, max 600000)", data: protocol.EventsFilter{}, examples: []string{"EVENTS SUBSCRIBE", "EVENTS SUBSCRIBE\n{\"types\":[\"proxy\",\"tunnel.url\"],\"timeout_ms\":300000}", "EVENTS SUBSCRIBE\n{\"types\":[\"process.exited\"],\"source\":\"dev\",\"limit\":1}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbChaos,
+			description: "Configure chaos engineering rules",
+			handler:     (*Daemon).hubHandleChaos,
+			subVerbs: []subVerbSpec{
+				{name: "ENABLE", description: "Turn on failure injection", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CHAOS ENABLE app"}},
+				{name: "DISABLE", description: "Turn off failure injection", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CHAOS DISABLE app"}},
+				{name: "STATUS", description: "Whether chaos is enabled and its rules", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CHAOS STATUS app"}},
+				{name: "PRESET", description: "Apply a named set of rules", args: []protocol.ArgHelp{proxyIDArg, dryRunOptArg}, data: chaosPresetRequest{}, examples: []string{"CHAOS PRESET app\n{\"chaos_preset\":\"mobile-3g\"}", "CHAOS PRESET app dry-run\n{\"chaos_preset\":\"flaky-api\"}", "CHAOS PRESET app\n{\"chaos_preset\":\"flaky-api\",\"sessions\":[\"page-3\"]}"}},
+				{name: "SET", description: "Replace the whole chaos configuration", args: []protocol.ArgHelp{proxyIDArg, dryRunOptArg}, data: proxy.ChaosConfig{}, examples: []string{"CHAOS SET app\n{\"enabled\":true,\"global_odds\":0.5}"}},
+				{name: "ADD-RULE", description: "Add a failure injection rule", args: []protocol.ArgHelp{proxyIDArg}, data: chaosAddRuleRequest{}, examples: []string{"CHAOS ADD-RULE app\n{\"chaos_rule\":{\"id\":\"slow\",\"type\":\"latency\",\"enabled\":true,\"min_latency_ms\":500,\"max_latency_ms\":2000}}", "CHAOS ADD-RULE app\n{\"chaos_rule\":{\"id\":\"3g\",\"type\":\"bandwidth_limit\",\"enabled\":true,\"bandwidth_kbps\":400}}", "CHAOS ADD-RULE app\n{\"chaos_rule\":{\"id\":\"no-dns\",\"type\":\"dns_failure\",\"enabled\":true,\"hosts\":[\"api.stripe.com\"]}}", "CHAOS ADD-RULE app\n{\"chaos_rule\":{\"id\":\"checkout\",\"type\":\"http_error\",\"enabled\":true,\"error_codes\":[503],\"headers\":[{\"name\":\"X-Tenant\",\"value\":\"^acme$\"}],\"body_fields\":[{\"name\":\"operationName\",\"value\":\"^Checkout$\"}]}}"}},
+				{name: "REMOVE-RULE", description: "Remove a rule", args: []protocol.ArgHelp{proxyIDArg}, data: chaosRemoveRuleRequest{}, examples: []string{"CHAOS REMOVE-RULE app\n{\"chaos_rule_id\":\"slow\"}"}},
+				{name: "LIST-RULES", description: "Configured rules", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CHAOS LIST-RULES app"}},
+				{name: "STATS", description: "Injection counters per rule", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"CHAOS STATS app"}},
+				{name: "PREVIEW", description: "Which logged requests the current rules, a preset or a config would hit, with expected impact", args: []protocol.ArgHelp{proxyIDArg}, data: chaosPreviewRequest{}, examples: []string{"CHAOS PREVIEW app", "CHAOS PREVIEW app\n{\"chaos_preset\":\"flaky-api\"}"}},
+				{name: "CLEAR", description: "Remove all rules", args: []protocol.ArgHelp{proxyIDArg, dryRunOptArg}, examples: []string{"CHAOS CLEAR app", "CHAOS CLEAR app dry-run"}},
+				{name: "LIST-PRESETS", description: "Available presets", examples: []string{"CHAOS LIST-PRESETS"}},
+				{name: protocol.SubVerbScenario, description: "Step the rules through timed phases, each with rules or a preset; a phase with neither recovers, and duration_ms 0 on the last phase holds it until STOP. The configuration before START is restored when the scenario finishes or stops, and phase changes are logged and toasted", args: []protocol.ArgHelp{arg("action", "START, STOP or STATUS"), proxyIDArg}, data: proxy.ChaosScenario{}, examples: []string{"CHAOS SCENARIO START app\n{\"name\":\"outage\",\"phases\":[{\"name\":\"slow\",\"duration_ms\":120000,\"rules\":[{\"id\":\"lat\",\"type\":\"latency\",\"min_latency_ms\":500}]},{\"name\":\"errors\",\"duration_ms\":30000,\"rules\":[{\"id\":\"503\",\"type\":\"http_error\",\"error_codes\":[503],\"probability\":0.2}]},{\"name\":\"recover\"}]}", "CHAOS SCENARIO STATUS app", "CHAOS SCENARIO STOP app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbMock,
+			description: "Canned responses for endpoints, served without calling the target",
+			handler:     (*Daemon).hubHandleMock,
+			subVerbs: []subVerbSpec{
+				{name: "ADD", description: "Add a mock rule matching method and URL regex, or replace the rule with its ID", args: []protocol.ArgHelp{proxyIDArg}, data: proxy.MockRule{}, examples: []string{"MOCK ADD app\n{\"id\":\"users\",\"methods\":[\"GET\"],\"url_pattern\":\"^/api/users\",\"body\":\"[{\\\"id\\\":1}]\"}"}},
+				{name: "REMOVE", description: "Remove a mock rule", args: []protocol.ArgHelp{proxyIDArg, arg("rule_id", "Mock rule ID")}, examples: []string{"MOCK REMOVE app users"}},
+				{name: "LIST", description: "Mock rules with their hit counts", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"MOCK LIST app"}},
+				{name: "CLEAR", description: "Remove all mock rules", args: []protocol.ArgHelp{proxyIDArg}, examples: []string{"MOCK CLEAR app"}},
+			},
+		},
+		{
+			verb:        protocol.VerbSession,
+			description: "Manage client sessions",
+			handler:     (*Daemon).hubHandleSession,
+			subVerbs: []subVerbSpec{
+				{name: "REGISTER", description: "Register an agnt run session", args: []protocol.ArgHelp{sessionArg, arg("overlay_path", "Overlay endpoint: socket path, tcp://127.0.0.1:port[?token=...] or named pipe")}, data: sessionRegisterRequest{}, examples: []string{"SESSION REGISTER claude-1 /tmp/agnt-overlay-1.sock\n{\"project_path\":\"/home/dev/app\",\"command\":\"claude\"}", "SESSION REGISTER claude-2 /tmp/agnt-overlay-2.sock\n{\"project_path\":\"/home/dev/app\",\"command\":\"claude\",\"tags\":{\"role\":\"frontend\"},\"description\":\"Checkout redesign\"}"}},
+				{name: "UNREGISTER", description: "Remove a session", args: []protocol.ArgHelp{sessionArg}, examples: []string{"SESSION UNREGISTER claude-1"}},
+				{name: "HEARTBEAT", description: "Keep a session alive", args: []protocol.ArgHelp{sessionArg}, examples: []string{"SESSION HEARTBEAT claude-1"}},
+				{name: "LIST", description: "Sessions of a directory, or all, optionally only those with the given tags", data: sessionListFilter{}, examples: []string{"SESSION LIST\n{\"global\":true}", "SESSION LIST\n{\"tags\":{\"role\":\"frontend\"}}"}},
+				{name: protocol.SubVerbTag, description: "Set or remove tags of a session, such as role=frontend or ticket=ABC-123, and replace its description; without changes, shows them", args: []protocol.ArgHelp{sessionArg, {Name: "tags", Description: "key=value sets a tag, key- removes it", Optional: true, Variadic: true}}, data: sessionTagRequest{}, examples: []string{"SESSION TAG claude-1 role=frontend ticket=ABC-123", "SESSION TAG claude-1 ticket-\n{\"description\":\"Checkout redesign\"}"}},
+				{name: "GET", description: "Details of a session", args: []protocol.ArgHelp{sessionArg}, examples: []string{"SESSION GET claude-1"}},
+				{name: "SEND", description: "Type a message into the session's terminal; options set chunking and pacing, which otherwise follow a preset for the session's tool", args: []protocol.ArgHelp{sessionArg, optArg("preset", "preset=instant, paste, chunked or typed"), optArg("chunk", "chunk=N characters per write"), optArg("delay", "delay=MS between chunks"), optArg("enter", "enter=off to leave the message unsent"), optArg("paste", "paste=on for bracketed paste")}, dataText: "Message text", examples: []string{"SESSION SEND claude-1\nrun the tests again", "SESSION SEND gemini-1 chunk=128 delay=25\n<long prompt>"}},
+				{name: "SCHEDULE", description: "Send a message after a delay, or with CRON every time a cron expression (5 fields, @daily-style shorthands or @every 30m) matches in a time zone, until cancelled; with WAIT-FOR-IDLE, held while the session's tool is busy (up to 10m)", args: []protocol.ArgHelp{sessionArg, arg("duration", "Go duration such as 5m, or CRON"), optArg("wait-for-idle", "WAIT-FOR-IDLE to hold delivery until the tool is idle")}, dataText: "Message text; with CRON, {\"cron\", \"timezone\", \"message\"} as JSON", examples: []string{"SESSION SCHEDULE claude-1 10m\ncheck the deploy", "SESSION SCHEDULE claude-1 1m WAIT-FOR-IDLE\nrun the tests", "SESSION SCHEDULE claude-1 CRON WAIT-FOR-IDLE\n{\"cron\":\"*/30 * * * *\",\"timezone\":\"Europe/Berlin\",\"message\":\"commit your work\"}"}},
+				{name: "CLIPBOARD", description: "Share terminal text with the floating panel of the session's pages (64KB max); no data returns the last text moved between pages and terminal", args: []protocol.ArgHelp{sessionArg}, dataText: "Text to share", examples: []string{"SESSION CLIPBOARD claude-1\nTypeError: cannot read properties of undefined", "SESSION CLIPBOARD claude-1"}},
+				{name: protocol.SubVerbTranscript, description: "Search the terminal output recorded by agnt run --transcript: the last lines, lines matching a regexp, or a time range; project_path finds the transcript of a session that has ended", args: []protocol.ArgHelp{sessionArg}, data: TranscriptQuery{}, examples: []string{"SESSION TRANSCRIPT claude-1\n{\"tail\":50}", "SESSION TRANSCRIPT claude-1\n{\"grep\":\"(?i)fail|panic\",\"since\":\"12h\",\"project_path\":\"/home/dev/app\"}"}},
+				{name: protocol.SubVerbHost, description: "Start the command of an agnt run --detachable session in a PTY the daemon keeps; the terminal attaches over the returned socket and can go away without ending the process", args: []protocol.ArgHelp{sessionArg}, data: ptyhost.Config{}, examples: []string{"SESSION HOST claude-1\n{\"command\":\"/usr/local/bin/claude\",\"dir\":\"/home/dev/app\",\"cols\":120,\"rows\":40}"}},
+				{name: protocol.SubVerbHosted, description: "Processes hosted for detachable sessions, whether a terminal is attached and since when it is not; with a code, one of them", args: []protocol.ArgHelp{optArg("code", "Session code")}, examples: []string{"SESSION HOSTED", "SESSION HOSTED claude-1"}},
+				{name: protocol.SubVerbDetach, description: "Disconnect the terminal attached to a hosted session; the process keeps running for agnt run --resume", args: []protocol.ArgHelp{sessionArg}, examples: []string{"SESSION DETACH claude-1"}},
+				{name: "STATUS", description: "Whether the session's tool is busy or idle, since when, and its recent transitions", args: []protocol.ArgHelp{sessionArg}, examples: []string{"SESSION STATUS claude-1"}},
+				{name: "CANCEL", description: "Cancel a scheduled message", args: []protocol.ArgHelp{arg("task_id", "Scheduled task ID")}, examples: []string{"SESSION CANCEL task-1"}},
+				{name: protocol.SubVerbPause, description: "Hold a scheduled message without cancelling it", args: []protocol.ArgHelp{arg("task_id", "Scheduled task ID")}, examples: []string{"SESSION PAUSE task-1"}},
+				{name: protocol.SubVerbResume, description: "Resume a paused message; a recurring one continues at its next time from now", args: []protocol.ArgHelp{arg("task_id", "Scheduled task ID")}, examples: []string{"SESSION RESUME task-1"}},
+				{name: "TASKS", description: "Scheduled messages of a directory, or all, with their cron schedule and last 20 deliveries", data: sessionFilter{}, examples: []string{"SESSION TASKS"}},
+				{name: "FIND", description: "Session running in a directory or its parents, optionally among those with the given tags", args: []protocol.ArgHelp{arg("directory", "Directory")}, data: sessionListFilter{}, examples: []string{"SESSION FIND /home/dev/app", "SESSION FIND /home/dev/app\n{\"tags\":{\"role\":\"backend\"}}"}},
+				{name: "ATTACH", description: "Attach this connection to the session of a directory or its parents; without one the connection is detached from any session", args: []protocol.ArgHelp{arg("directory", "Directory")}, examples: []string{"SESSION ATTACH /home/dev/app"}},
+				{name: "URL", description: "Report a URL detected in session output", args: []protocol.ArgHelp{sessionArg, arg("url", "Detected URL")}, data: sessionURLRequest{}, examples: []string{"SESSION URL claude-1 http://localhost:5173"}},
+				{name: "DIGEST", description: "Periodic summary of new errors, failed processes and slow endpoints, sent only when a threshold is reached; \"off\" turns it off, no data shows its state", args: []protocol.ArgHelp{sessionArg, optArg("off", "Turn the digest off")}, data: DigestConfig{}, examples: []string{"SESSION DIGEST claude-1\n{\"interval_minutes\":10,\"min_slow_requests\":5}", "SESSION DIGEST claude-1", "SESSION DIGEST claude-1 off"}},
+				{name: protocol.SubVerbAlert, description: "Push a summary to the session when its project's proxies log more than a threshold of frontend errors or 5xx responses within a window; with data adds a rule, REMOVE removes one, otherwise lists the rules", args: []protocol.ArgHelp{sessionArg, optArg("REMOVE", "Remove a rule"), optArg("rule_id", "Rule to remove")}, data: AlertRule{}, examples: []string{"SESSION ALERT claude-1\n{\"threshold\":5,\"window_seconds\":60}", "SESSION ALERT claude-1", "SESSION ALERT claude-1 REMOVE alert-1"}},
+			},
+		},
+		{
+			verb:        protocol.VerbStatus,
+			description: "Get full daemon status and statistics",
+			handler:     (*Daemon).hubHandleStatus,
+			examples:    []string{"STATUS"},
+		},
+		{
+			verb:        protocol.VerbStatusLite,
+			description: "Counts of running and failed processes, active proxies, errors in the last 15 minutes and whether a tunnel exposes the project, cheap enough to poll every few seconds; the JSON shape is stable (v: 1)",
+			handler:     (*Daemon).hubHandleStatusLite,
+			args:        []protocol.ArgHelp{optArg("path", "Project path when no session is attached (default: all projects)")},
+			examples:    []string{"STATUS-LITE", "STATUS-LITE /home/dev/app"},
+		},
+		{
+			verb:        protocol.VerbStore,
+			description: "Manage persistent key-value storage",
+			handler:     (*Daemon).hubHandleStore,
+			subVerbs: []subVerbSpec{
+				{name: "GET", description: "Read a value", data: protocol.StoreGetRequest{}, examples: []string{"STORE GET\n{\"scope\":\"global\",\"key\":\"theme\"}"}},
+				{name: "SET", description: "Write a value", data: protocol.StoreSetRequest{}, examples: []string{"STORE SET\n{\"scope\":\"global\",\"key\":\"theme\",\"value\":\"dark\"}"}},
+				{name: "DELETE", description: "Delete a value", data: protocol.StoreDeleteRequest{}, examples: []string{"STORE DELETE\n{\"scope\":\"global\",\"key\":\"theme\"}"}},
+				{name: "LIST", description: "Keys in a scope", data: protocol.StoreListRequest{}, examples: []string{"STORE LIST\n{\"scope\":\"global\"}"}},
+				{name: "CLEAR", description: "Delete every value in a scope", data: protocol.StoreClearRequest{}, examples: []string{"STORE CLEAR\n{\"scope\":\"global\"}"}},
+				{name: "GET-ALL", description: "Every entry in a scope", data: protocol.StoreGetAllRequest{}, examples: []string{"STORE GET-ALL\n{\"scope\":\"global\"}"}},
+			},
+		},
+		{
+			verb:        protocol.VerbAutomate,
+			description: "Process automation tasks using AI",
+			handler:     (*Daemon).hubHandleAutomate,
+			subVerbs: []subVerbSpec{
+				{name: "PROCESS", description: "Run one automation task", data: protocol.AutomateProcessRequest{}, examples: []string{"AUTOMATE PROCESS\n{\"type\":\"summarize\",\"data\":{\"text\":\"...\"}}"}},
+				{name: "BATCH", description: "Run several automation tasks", data: protocol.AutomateBatchRequest{}, examples: []string{"AUTOMATE BATCH\n{\"tasks\":[{\"type\":\"summarize\",\"data\":{\"text\":\"...\"}}]}"}},
+			},
+		},
+		{
+			verb:        "STOP-ALL",
+			description: "Stop all running processes, proxies, and tunnels",
+			handler:     (*Daemon).hubHandleStopAll,
+			args:        []protocol.ArgHelp{dryRunOptArg},
+			examples:    []string{"STOP-ALL", "STOP-ALL dry-run"},
+		},
+		{
+			verb:        "RESTART-ALL",
+			description: "Restart all processes and proxies using .agnt.kdl config",
+			handler:     (*Daemon).hubHandleRestartAll,
+			examples:    []string{"RESTART-ALL"},
+		},
+	}
+}
+
+// subVerbNames returns the sub-verbs of a command in registration order.
+func (c commandSpec) subVerbNames() []string {
+	names := make([]string, len(c.subVerbs))
+	for i, s := range c.subVerbs {
+		names[i] = s.name
+	}
+	return names
+}
+
+// help converts the spec to its HELP representation.
+func (c commandSpec) help() protocol.CommandHelp {
+	h := protocol.CommandHelp{
+		Verb:        c.verb,
+		Description: c.description,
+		Builtin:     c.handler == nil,
+	}
+	if len(c.subVerbs) == 0 {
+		h.Usage = protocol.FormatUsage(c.verb, "", c.args)
+		h.Args = c.args
+		h.Data = protocol.SchemaOf(c.data)
+		h.DataText = c.dataText
+		h.Examples = c.examples
+	}
+	for _, s := range c.subVerbs {
+		h.SubVerbs = append(h.SubVerbs, protocol.SubVerbHelp{
+			Name:        s.name,
+			Description: s.description,
+			Usage:       protocol.FormatUsage(c.verb, s.name, s.args),
+			Args:        s.args,
+			Data:        protocol.SchemaOf(s.data),
+			DataText:    s.dataText,
+			Examples:    s.examples,
+		})
+	}
+	return h
+}
+
+// CommandHelp returns the usage of every daemon command, as served by HELP.
+func CommandHelp() []protocol.CommandHelp {
+	specs := commandSpecs()
+	out := make([]protocol.CommandHelp, len(specs))
+	for i, c := range specs {
+		out[i] = c.help()
+	}
+	return out
+}
+
+// FindCommandHelp returns the usage of one command, matched case-insensitively.
+func FindCommandHelp(verb string) (protocol.CommandHelp, bool) {
+	for _, c := range commandSpecs() {
+		if strings.EqualFold(c.verb, verb) {
+			return c.help(), true
+		}
+	}
+	return protocol.CommandHelp{}, false
+}
+
+// hubHandleHelp handles HELP [verb [sub_verb]].
+func (d *Daemon) hubHandleHelp(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	// The parser may take the first word after HELP as a sub-verb
+	var words []string
+	if cmd.SubVerb != "" {
+		words = append(words, cmd.SubVerb)
+	}
+	words = append(words, cmd.Args...)
+
+	var resp interface{}
+	switch len(words) {
+	case 0:
+		commands := CommandHelp()
+		resp = map[string]interface{}{
+			"commands": commands,
+			"count":    len(commands),
+		}
+	default:
+		h, ok := FindCommandHelp(words[0])
+		if !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("unknown command %q", words[0]))
+		}
+		resp = h
+		if len(words) > 1 {
+			sub, ok := findSubVerbHelp(h, words[1])
+			if !ok {
+				return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("unknown %s sub-command %q", h.Verb, words[1]))
+			}
+			resp = map[string]interface{}{
+				"verb":     h.Verb,
+				"sub_verb": sub,
+			}
+		}
+	}
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// findSubVerbHelp returns the usage of a sub-verb, matched case-insensitively.
+func findSubVerbHelp(h protocol.CommandHelp, name string) (protocol.SubVerbHelp, bool) {
+	for _, s := range h.SubVerbs {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return protocol.SubVerbHelp{}, false
+}
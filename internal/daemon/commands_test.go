@@ -0,0 +1,79 @@
+package daemon
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCommandSpecs(t *testing.T) {
+	verbs := make(map[string]bool)
+	for _, c := range commandSpecs() {
+		if verbs[c.verb] {
+			t.Errorf("Duplicate verb %s", c.verb)
+		}
+		verbs[c.verb] = true
+		if c.description == "" {
+			t.Errorf("%s has no description", c.verb)
+		}
+
+		subVerbs := make(map[string]bool)
+		for _, s := range c.subVerbs {
+			if subVerbs[s.name] {
+				t.Errorf("Duplicate sub-verb %s %s", c.verb, s.name)
+			}
+			subVerbs[s.name] = true
+			if s.description == "" {
+				t.Errorf("%s %s has no description", c.verb, s.name)
+			}
+			if len(s.examples) == 0 {
+				t.Errorf("%s %s has no example", c.verb, s.name)
+			}
+			for _, ex := range s.examples {
+				if !strings.HasPrefix(ex, c.verb+" "+s.name) {
+					t.Errorf("%s %s example %q does not invoke it", c.verb, s.name, ex)
+				}
+			}
+		}
+		if len(c.subVerbs) == 0 && len(c.examples) == 0 {
+			t.Errorf("%s has no example", c.verb)
+		}
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	for _, h := range CommandHelp() {
+		if len(h.SubVerbs) == 0 && !strings.HasPrefix(h.Usage, h.Verb) {
+			t.Errorf("%s usage %q does not start with the verb", h.Verb, h.Usage)
+		}
+		if h.Data != nil && h.Data.Type != "object" {
+			t.Errorf("%s payload is not an object", h.Verb)
+		}
+		for _, s := range h.SubVerbs {
+			if !strings.HasPrefix(s.Usage, h.Verb+" "+s.Name) {
+				t.Errorf("%s %s usage %q does not start with the command", h.Verb, s.Name, s.Usage)
+			}
+			if s.Data != nil && s.Data.Type != "object" {
+				t.Errorf("%s %s payload is not an object", h.Verb, s.Name)
+			}
+		}
+	}
+	if _, err := json.Marshal(CommandHelp()); err != nil {
+		t.Fatalf("Help does not marshal: %v", err)
+	}
+
+	h, ok := FindCommandHelp("proxy")
+	if !ok || h.Verb != "PROXY" {
+		t.Fatalf("Expected case-insensitive lookup of PROXY, got %v", h.Verb)
+	}
+	start, ok := findSubVerbHelp(h, "start")
+	if !ok {
+		t.Fatal("Expected PROXY START")
+	}
+	if start.Data == nil || start.Data.Properties["bind_address"] == nil {
+		t.Error("Expected PROXY START schema from the handler's payload type")
+	}
+	if _, ok := FindCommandHelp("NOPE"); ok {
+		t.Error("Expected unknown verb to be missing")
+	}
+}
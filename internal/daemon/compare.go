@@ -0,0 +1,237 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/compare"
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/project"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/workspace"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// defaultCompareTimeout bounds a COMPARE RUN when no timeout is given.
+const defaultCompareTimeout = 10 * time.Minute
+
+// hubHandleCompare handles the COMPARE command and its sub-verbs.
+func (d *Daemon) hubHandleCompare(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "COMPARE %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbRun:
+		return d.hubHandleCompareRun(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown COMPARE sub-command",
+			Command:      protocol.VerbCompare,
+			ValidActions: []string{protocol.SubVerbRun},
+		})
+	}
+}
+
+// scriptCommand is a resolved script: the command line COMPARE RUN executes
+// in both trees, or REMOTE RUN on the remote checkout.
+type scriptCommand struct {
+	Command string
+	Args    []string
+	Cwd     string // Relative to each tree's root
+	Env     []string
+}
+
+// resolveScriptCommand picks the command to execute: a shell (run) or raw
+// command, an .agnt.kdl script, or a detected project command; args are
+// appended to scripts.
+func resolveScriptCommand(projectPath, name, run, command string, args []string) (*scriptCommand, error) {
+	switch {
+	case run != "":
+		return &scriptCommand{Command: "sh", Args: []string{"-c", run}}, nil
+	case command != "":
+		return &scriptCommand{Command: command, Args: args}, nil
+	case name == "":
+		return nil, fmt.Errorf("script, run or command required")
+	}
+
+	if cfg, err := config.LoadAgntConfig(projectPath); err == nil {
+		if script := cfg.Scripts[name]; script != nil {
+			c := &scriptCommand{Cwd: script.Cwd, Env: envMapToSlice(script.Env)}
+			if script.Run != "" {
+				run := script.Run
+				if len(args) > 0 {
+					run += " " + strings.Join(args, " ")
+				}
+				c.Command, c.Args = "sh", []string{"-c", run}
+			} else {
+				c.Command, c.Args = script.Command, append(append([]string{}, script.Args...), args...)
+			}
+			return c, nil
+		}
+	}
+
+	proj, err := project.Detect(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if def := project.GetCommandByName(proj, name); def != nil {
+		return &scriptCommand{Command: def.Command, Args: append(append([]string{}, def.Args...), args...)}, nil
+	}
+	if project.GetScriptCommand(projectPath, name) != "" {
+		args := append([]string{"run", name}, args...)
+		return &scriptCommand{Command: proj.PackageManager, Args: args}, nil
+	}
+	return nil, fmt.Errorf("script %q is not in .agnt.kdl or detected for the project", name)
+}
+
+// compareSide is one of the two runs of a COMPARE RUN.
+type compareSide struct {
+	Name      string
+	Root      string
+	ProcessID string
+	Proc      *process.ManagedProcess
+}
+
+// hubHandleCompareRun handles COMPARE RUN with a protocol.CompareConfig
+// payload. The command runs in the project and in a workspace at the base
+// ref, concurrently unless "sequential" is given, and the structured results
+// of both are returned with their diff. Blocks until both finish.
+func (d *Daemon) hubHandleCompareRun(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req protocol.CompareConfig
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	sequential := hasArg(cmd.Args, "sequential")
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "COMPARE RUN requires a session or path")
+	}
+
+	timeout := defaultCompareTimeout
+	if req.Timeout != "" {
+		parsed, err := time.ParseDuration(req.Timeout)
+		if err != nil || parsed <= 0 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid timeout %q (use e.g. '10m')", req.Timeout))
+		}
+		timeout = parsed
+	}
+
+	command, err := resolveScriptCommand(projectPath, req.Script, req.Run, req.Command, req.Args)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	var ws *workspace.Workspace
+	if req.Workspace != "" {
+		var ok bool
+		if ws, ok = d.workspaces.Get(req.Workspace); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("workspace %q not found", req.Workspace))
+		}
+	} else {
+		ref := req.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		createCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		ws, err = d.workspaces.Create(createCtx, workspace.CreateOptions{
+			ProjectPath: projectPath,
+			Ref:         ref,
+			Method:      workspace.MethodWorktree,
+			SessionCode: conn.SessionCode(),
+		})
+		cancel()
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("failed to create base workspace: %v", err))
+		}
+		// The workspace exists only for this comparison
+		defer d.removeWorkspace(context.Background(), ws.ID)
+	}
+
+	sides := []*compareSide{
+		{Name: "current", Root: projectPath, ProcessID: makeProcessID(projectPath, "compare-current")},
+		{Name: "base", Root: ws.Path, ProcessID: makeProcessID(projectPath, "compare-base")},
+	}
+	deadline := time.After(timeout)
+	for _, side := range sides {
+		side.Proc, err = d.startFreshProcess(ctx, process.ProcessConfig{
+			ID:          side.ProcessID,
+			ProjectPath: resolveWorkingDir(side.Root, command.Cwd),
+			Command:     command.Command,
+			Args:        command.Args,
+			Env:         command.Env,
+		})
+		if err != nil {
+			d.stopCompareSides(sides)
+			return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start %s run: %v", side.Name, err))
+		}
+		if sequential && !waitCompareSide(side, deadline) {
+			d.stopCompareSides(sides)
+			return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("COMPARE RUN timed out after %s", timeout))
+		}
+	}
+	for _, side := range sides {
+		if !waitCompareSide(side, deadline) {
+			d.stopCompareSides(sides)
+			return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("COMPARE RUN timed out after %s", timeout))
+		}
+	}
+
+	results := make(map[string]*compare.Result, len(sides))
+	for _, side := range sides {
+		out, _ := side.Proc.CombinedOutput()
+		result := compare.Collect(string(out), side.Proc.ExitCode(), side.Proc.Runtime().Milliseconds())
+		if req.BundleDir != "" {
+			if assets, err := compare.MeasureDir(filepath.Join(side.Root, req.BundleDir)); err == nil {
+				result.SetBundle(assets)
+			}
+		}
+		results[side.Name] = result
+	}
+
+	resp := map[string]interface{}{
+		"command":            strings.TrimSpace(command.Command + " " + strings.Join(command.Args, " ")),
+		"ref":                ws.Ref,
+		"commit":             ws.Commit,
+		"current":            results["current"],
+		"base":               results["base"],
+		"diff":               compare.Compare(results["base"], results["current"]),
+		"current_process_id": sides[0].ProcessID,
+		"base_process_id":    sides[1].ProcessID,
+	}
+	if req.Workspace != "" {
+		resp["workspace"] = ws.ID
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// waitCompareSide waits for a run to finish, returning false on deadline.
+func waitCompareSide(side *compareSide, deadline <-chan time.Time) bool {
+	select {
+	case <-side.Proc.Done():
+		return true
+	case <-deadline:
+		return false
+	}
+}
+
+// stopCompareSides stops the runs that are still going.
+func (d *Daemon) stopCompareSides(sides []*compareSide) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, side := range sides {
+		if side.Proc != nil && !side.Proc.IsDone() {
+			d.hub.ProcessManager().Stop(ctx, side.ProcessID)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+func TestResolveScriptCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".agnt.kdl"), []byte(`scripts {
+    check {
+        run "npm run lint"
+        cwd "web"
+    }
+}
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0644)
+
+	resolve := func(req protocol.CompareConfig) (*scriptCommand, error) {
+		return resolveScriptCommand(dir, req.Script, req.Run, req.Command, req.Args)
+	}
+
+	tests := []struct {
+		name     string
+		req      protocol.CompareConfig
+		wantCmd  string
+		wantArgs []string
+		wantCwd  string
+	}{
+		{"shell", protocol.CompareConfig{Run: "make test"}, "sh", []string{"-c", "make test"}, ""},
+		{"raw", protocol.CompareConfig{Command: "go", Args: []string{"vet", "./..."}}, "go", []string{"vet", "./..."}, ""},
+		{"configured script", protocol.CompareConfig{Script: "check", Args: []string{"--quiet"}}, "sh", []string{"-c", "npm run lint --quiet"}, "web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := resolve(tt.req)
+			if err != nil {
+				t.Fatalf("resolveScriptCommand failed: %v", err)
+			}
+			if c.Command != tt.wantCmd || !reflect.DeepEqual(c.Args, tt.wantArgs) || c.Cwd != tt.wantCwd {
+				t.Errorf("Expected %s %v in %q, got %s %v in %q", tt.wantCmd, tt.wantArgs, tt.wantCwd, c.Command, c.Args, c.Cwd)
+			}
+		})
+	}
+
+	detected, err := resolve(protocol.CompareConfig{Script: "test"})
+	if err != nil {
+		t.Fatalf("Expected the detected Go test command, got %v", err)
+	}
+	if detected.Command != "go" {
+		t.Errorf("Expected go test, got %s %v", detected.Command, detected.Args)
+	}
+
+	if _, err := resolve(protocol.CompareConfig{Script: "no-such-script"}); err == nil {
+		t.Error("Expected error for an unknown script")
+	}
+	if _, err := resolve(protocol.CompareConfig{}); err == nil {
+		t.Error("Expected error without a command")
+	}
+}
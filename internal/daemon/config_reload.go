@@ -0,0 +1,556 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// configStamp identifies a version of a project's .agnt.kdl.
+type configStamp struct {
+	File    string
+	ModTime time.Time
+	Size    int64
+}
+
+// statConfig returns the stamp of the .agnt.kdl projectPath reads, and
+// false when there is none.
+func statConfig(projectPath string) (configStamp, bool) {
+	file := config.FindAgntConfigFile(projectPath)
+	if file == "" {
+		return configStamp{}, false
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return configStamp{}, false
+	}
+	return configStamp{File: file, ModTime: info.ModTime(), Size: info.Size()}, true
+}
+
+// appliedConfig is the .agnt.kdl a project's autostart last applied.
+type appliedConfig struct {
+	Config  *config.AgntConfig
+	Stamp   configStamp
+	pending configStamp // Seen changed, applied once it holds for a poll
+}
+
+// configState tracks the applied config of each autostarted project, so
+// edits to .agnt.kdl can be diffed and applied.
+type configState struct {
+	mu       sync.Mutex
+	projects map[string]*appliedConfig
+
+	// reloadMu keeps reloads from the watcher and CONFIG RELOAD apart
+	reloadMu sync.Mutex
+}
+
+// set records cfg as applied to projectPath.
+func (s *configState) set(projectPath string, cfg *config.AgntConfig, stamp configStamp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.projects == nil {
+		s.projects = make(map[string]*appliedConfig)
+	}
+	s.projects[projectPath] = &appliedConfig{Config: cfg, Stamp: stamp, pending: stamp}
+}
+
+// get returns the config applied to projectPath.
+func (s *configState) get(projectPath string) (*config.AgntConfig, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.projects[projectPath]
+	if !ok {
+		return nil, false
+	}
+	return a.Config, true
+}
+
+// observe records the current stamp of a project's file and reports
+// whether it changed and then held steady since the last poll.
+func (s *configState) observe(projectPath string, stamp configStamp) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.projects[projectPath]
+	if !ok || stamp == a.Stamp {
+		return false
+	}
+	if stamp != a.pending {
+		a.pending = stamp
+		return false
+	}
+	// Applied or not, this version is done with
+	a.Stamp = stamp
+	return true
+}
+
+// projectPaths returns the tracked projects.
+func (s *configState) projectPaths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make([]string, 0, len(s.projects))
+	for p := range s.projects {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// forgetProject stops tracking a project.
+func (s *configState) forgetProject(projectPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.projects, projectPath)
+}
+
+// trackConfig records the config RunAutostart applied to projectPath.
+func (d *Daemon) trackConfig(projectPath string, cfg *config.AgntConfig) {
+	stamp, _ := statConfig(projectPath)
+	d.configs.set(normalizePath(projectPath), cfg, stamp)
+}
+
+// ConfigReloadResult holds what a reload of .agnt.kdl changed. Entries are
+// "<kind> <name>", e.g. "script dev".
+type ConfigReloadResult struct {
+	File       string   `json:"file"`
+	Applied    bool     `json:"applied"`
+	Started    []string `json:"started,omitempty"`
+	Stopped    []string `json:"stopped,omitempty"`
+	Restarted  []string `json:"restarted,omitempty"`
+	Retargeted []string `json:"retargeted,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+
+	// Issues are the errors in the file that kept it from being applied
+	Issues []config.ConfigIssue `json:"issues,omitempty"`
+}
+
+// changed reports whether the reload did anything.
+func (r *ConfigReloadResult) changed() bool {
+	return len(r.Started)+len(r.Stopped)+len(r.Restarted)+len(r.Retargeted)+len(r.Errors) > 0
+}
+
+// summary describes the reload in one line.
+func (r *ConfigReloadResult) summary() string {
+	if !r.Applied {
+		var errs []string
+		for _, issue := range r.Issues {
+			errs = append(errs, issue.String())
+		}
+		return fmt.Sprintf("%s has errors, not applied: %s", config.AgntConfigFileName, strings.Join(errs, "; "))
+	}
+	var parts []string
+	add := func(verb string, items []string) {
+		if len(items) > 0 {
+			parts = append(parts, verb+" "+strings.Join(items, ", "))
+		}
+	}
+	add("started", r.Started)
+	add("stopped", r.Stopped)
+	add("restarted", r.Restarted)
+	add("retargeted", r.Retargeted)
+	add("failed", r.Errors)
+	if len(parts) == 0 {
+		return fmt.Sprintf("Reloaded %s: nothing to change", config.AgntConfigFileName)
+	}
+	return fmt.Sprintf("Reloaded %s: %s", config.AgntConfigFileName, strings.Join(parts, "; "))
+}
+
+// reloadConfig applies the project's current .agnt.kdl against the one
+// last applied: autostart entries that are new start, entries that are gone
+// stop, running scripts and doubles whose settings changed restart, and
+// running proxies whose target changed are retargeted. A file with errors
+// is not applied.
+func (d *Daemon) reloadConfig(ctx context.Context, projectPath string) (*ConfigReloadResult, error) {
+	d.configs.reloadMu.Lock()
+	defer d.configs.reloadMu.Unlock()
+
+	projectPath = normalizePath(projectPath)
+	old, ok := d.configs.get(projectPath)
+	if !ok {
+		return nil, fmt.Errorf("autostart hasn't run for %s; nothing to reload", projectPath)
+	}
+	stamp, ok := statConfig(projectPath)
+	if !ok {
+		return nil, fmt.Errorf("no %s in %s or its parents", config.AgntConfigFileName, projectPath)
+	}
+	data, err := os.ReadFile(stamp.File)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConfigReloadResult{File: stamp.File}
+	v := config.ValidateAgntConfig(string(data))
+	if v.HasErrors() {
+		for _, issue := range v.Issues {
+			if issue.Severity == config.SeverityError {
+				result.Issues = append(result.Issues, issue)
+			}
+		}
+		return result, nil
+	}
+	cfg := v.Config
+	result.Applied = true
+	d.configs.set(projectPath, cfg, stamp)
+
+	d.reloadDoubles(ctx, projectPath, old, cfg, result)
+	d.reloadScripts(ctx, projectPath, old, cfg, result)
+	d.reloadStacks(ctx, projectPath, old, cfg, result)
+	d.reloadProxies(ctx, projectPath, old, cfg, result)
+
+	d.eventLog.record(DaemonEvent{
+		Type:    EventConfigReloaded,
+		Path:    projectPath,
+		Message: result.summary(),
+	})
+	return result, nil
+}
+
+// stopConfigProcess stops a process started from .agnt.kdl, keeping its
+// restart policy from bringing it back, and reports whether it ran.
+func (d *Daemon) stopConfigProcess(ctx context.Context, processID string) (bool, error) {
+	d.supervisor.remove(processID)
+	proc, err := d.hub.ProcessManager().Get(processID)
+	if err != nil || !proc.IsRunning() {
+		return false, nil
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return true, d.hub.ProcessManager().Stop(stopCtx, processID)
+}
+
+// reloadDoubles stops removed doubles, starts new autostart ones and
+// restarts running ones whose settings changed.
+func (d *Daemon) reloadDoubles(ctx context.Context, projectPath string, old, cfg *config.AgntConfig, result *ConfigReloadResult) {
+	for _, name := range sortedNames(old.Doubles) {
+		if cfg.Doubles[name] != nil {
+			continue
+		}
+		processID := makeProcessID(projectPath, "double-"+name)
+		if !d.doubles.remove(processID) {
+			continue
+		}
+		if _, err := d.stopConfigProcess(ctx, processID); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("double %s: %v", name, err))
+			continue
+		}
+		result.Stopped = append(result.Stopped, "double "+name)
+	}
+
+	for _, name := range sortedNames(cfg.Doubles) {
+		dbl, prev := cfg.Doubles[name], old.Doubles[name]
+		if dbl == nil {
+			continue
+		}
+		processID := makeProcessID(projectPath, "double-"+name)
+		running := d.doubles.has(processID)
+		switch {
+		case running && prev != nil && !reflect.DeepEqual(dbl, prev):
+			d.doubles.remove(processID)
+			if _, err := d.stopConfigProcess(ctx, processID); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("double %s: %v", name, err))
+				continue
+			}
+			if _, err := d.startDouble(ctx, projectPath, name, protocol.DoubleStartConfig{}); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("double %s: %v", name, err))
+				continue
+			}
+			result.Restarted = append(result.Restarted, "double "+name)
+		case running:
+		case dbl.Autostart && (prev == nil || !prev.Autostart):
+			if _, err := d.startDouble(ctx, projectPath, name, protocol.DoubleStartConfig{}); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("double %s: %v", name, err))
+				continue
+			}
+			result.Started = append(result.Started, "double "+name)
+		}
+	}
+}
+
+// reloadScripts stops removed scripts, starts new autostart ones (after
+// their depends-on, in the background) and restarts running ones whose
+// settings changed.
+func (d *Daemon) reloadScripts(ctx context.Context, projectPath string, old, cfg *config.AgntConfig, result *ConfigReloadResult) {
+	for _, name := range sortedNames(old.Scripts) {
+		if cfg.Scripts[name] != nil {
+			continue
+		}
+		stopped, err := d.stopConfigProcess(ctx, makeProcessID(projectPath, name))
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, fmt.Sprintf("script %s: %v", name, err))
+		case stopped:
+			result.Stopped = append(result.Stopped, "script "+name)
+		}
+	}
+
+	// Scripts of autostarted stacks start with their stack
+	inStack := make(map[string]bool)
+	for name, st := range cfg.GetAutostartStacks() {
+		if old.Stacks[name] != nil && old.Stacks[name].Autostart {
+			continue
+		}
+		for svcName, svc := range st.Services {
+			if svc != nil && svc.Script != "" {
+				inStack[svc.Script] = true
+			} else {
+				inStack[svcName] = true
+			}
+		}
+	}
+
+	for _, name := range sortedNames(cfg.Scripts) {
+		script, prev := cfg.Scripts[name], old.Scripts[name]
+		if script == nil {
+			continue
+		}
+		processID := makeProcessID(projectPath, name)
+		proc, err := d.hub.ProcessManager().Get(processID)
+		running := err == nil && proc.IsRunning()
+		switch {
+		case running && prev != nil && !reflect.DeepEqual(script, prev):
+			if _, err := d.stopConfigProcess(ctx, processID); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("script %s: %v", name, err))
+				continue
+			}
+			d.hub.ProcessManager().RemoveByPath(processID, projectPath)
+			if err := d.autostartScript(ctx, name, script, projectPath, cfg.Proxies); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("script %s: %v", name, err))
+				continue
+			}
+			result.Restarted = append(result.Restarted, "script "+name)
+		case running || inStack[name]:
+		case script.Autostart && (prev == nil || !prev.Autostart):
+			if len(script.DependsOn) > 0 {
+				if err := d.startAfterDeps(cfg, projectPath, map[string]*config.ScriptConfig{name: script}, nil); err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("script %s: %v", name, err))
+					continue
+				}
+				result.Started = append(result.Started, "script "+name)
+				continue
+			}
+			if proc != nil && proc.IsDone() {
+				d.hub.ProcessManager().RemoveByPath(processID, proc.ProjectPath)
+			}
+			if err := d.autostartScript(ctx, name, script, projectPath, cfg.Proxies); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("script %s: %v", name, err))
+				continue
+			}
+			result.Started = append(result.Started, "script "+name)
+		}
+	}
+}
+
+// reloadStacks stops removed stacks and starts new autostart ones.
+func (d *Daemon) reloadStacks(ctx context.Context, projectPath string, old, cfg *config.AgntConfig, result *ConfigReloadResult) {
+	for _, name := range sortedNames(old.Stacks) {
+		if cfg.Stacks[name] != nil {
+			continue
+		}
+		d.stacks.mu.Lock()
+		run, ok := d.stacks.runs[stackKey(projectPath, name)]
+		d.stacks.mu.Unlock()
+		if !ok {
+			continue
+		}
+		d.stopStack(ctx, run)
+		d.stacks.mu.Lock()
+		delete(d.stacks.runs, stackKey(projectPath, name))
+		d.stacks.mu.Unlock()
+		result.Stopped = append(result.Stopped, "stack "+name)
+	}
+
+	for _, name := range sortedNames(cfg.Stacks) {
+		st, prev := cfg.Stacks[name], old.Stacks[name]
+		if st == nil || !st.Autostart || (prev != nil && prev.Autostart) {
+			continue
+		}
+		run, err := newStackRun(projectPath, name, st)
+		if err == nil {
+			err = d.stacks.begin(run)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("stack %s: %v", name, err))
+			continue
+		}
+		go d.startStack(d.ctx, cfg, st, run)
+		result.Started = append(result.Started, "stack "+name)
+	}
+}
+
+// reloadProxies stops removed proxies, starts new autostart ones and
+// retargets running ones whose target changed.
+func (d *Daemon) reloadProxies(ctx context.Context, projectPath string, old, cfg *config.AgntConfig, result *ConfigReloadResult) {
+	for _, name := range sortedNames(old.Proxies) {
+		if cfg.Proxies[name] != nil {
+			continue
+		}
+		proxyID := makeProcessID(projectPath, name)
+		if _, err := d.proxym.Get(proxyID); err != nil {
+			continue
+		}
+		if err := d.proxym.Stop(ctx, proxyID); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("proxy %s: %v", name, err))
+			continue
+		}
+		if d.stateMgr != nil {
+			d.stateMgr.RemoveProxy(proxyID)
+		}
+		result.Stopped = append(result.Stopped, "proxy "+name)
+	}
+
+	for _, name := range sortedNames(cfg.Proxies) {
+		p, prev := cfg.Proxies[name], old.Proxies[name]
+		if p == nil {
+			continue
+		}
+		px, err := d.proxym.Get(makeProcessID(projectPath, name))
+		switch {
+		case err == nil:
+			target := configProxyTarget(p)
+			if prev != nil && target != "" && target != configProxyTarget(prev) {
+				d.retargetProxy(px, target, fmt.Sprintf("target changed in %s", config.AgntConfigFileName))
+				result.Retargeted = append(result.Retargeted, "proxy "+name)
+			}
+		case p.Autostart && (prev == nil || !prev.Autostart):
+			if p.Script != "" {
+				continue // Created when the script prints its URL
+			}
+			if len(p.DependsOn) > 0 {
+				if err := d.startAfterDeps(cfg, projectPath, nil, map[string]*config.ProxyConfig{name: p}); err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("proxy %s: %v", name, err))
+					continue
+				}
+			} else if err := d.autostartProxy(ctx, name, p, projectPath); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("proxy %s: %v", name, err))
+				continue
+			}
+			result.Started = append(result.Started, "proxy "+name)
+		}
+	}
+}
+
+// startAfterDeps starts scripts and proxies with depends-on in the
+// background, once what they depend on is ready, as autostart does.
+func (d *Daemon) startAfterDeps(cfg *config.AgntConfig, projectPath string, scripts map[string]*config.ScriptConfig, proxies map[string]*config.ProxyConfig) error {
+	plan, err := newAutostartPlan(cfg, scripts, proxies)
+	if err != nil || plan == nil {
+		return err
+	}
+	go d.runAutostartPlan(d.ctx, cfg, projectPath, plan)
+	return nil
+}
+
+// configProxyTarget returns the target URL of an .agnt.kdl proxy: its url,
+// port on host, or legacy target.
+func configProxyTarget(p *config.ProxyConfig) string {
+	switch {
+	case p.URL != "":
+		return p.URL
+	case p.Port > 0:
+		host := p.Host
+		if host == "" {
+			host = "localhost"
+		}
+		return fmt.Sprintf("http://%s:%d", host, p.Port)
+	default:
+		return p.Target
+	}
+}
+
+// configWatchLoop reloads the .agnt.kdl of autostarted projects when it
+// changes, until the daemon stops.
+func (d *Daemon) configWatchLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.pollConfigs()
+		}
+	}
+}
+
+// pollConfigs reloads the projects whose .agnt.kdl changed. A file that
+// went away is left applied, since editors briefly remove files on save.
+func (d *Daemon) pollConfigs() {
+	for _, projectPath := range d.configs.projectPaths() {
+		stamp, ok := statConfig(projectPath)
+		if !ok || !d.configs.observe(projectPath, stamp) {
+			continue
+		}
+		go func() {
+			result, err := d.reloadConfig(d.ctx, projectPath)
+			if err != nil {
+				log.Printf("[WARN] config reload of %s: %v", projectPath, err)
+				return
+			}
+			log.Printf("[INFO] %s: %s", projectPath, result.summary())
+			if !result.Applied || result.changed() {
+				d.notifyConfigReload(projectPath, result)
+			}
+		}()
+	}
+}
+
+// notifyConfigReload shows a toast in the project's proxied pages and tells
+// its active sessions what the reload changed.
+func (d *Daemon) notifyConfigReload(projectPath string, result *ConfigReloadResult) {
+	message := result.summary()
+	level, title := "info", "Config reloaded"
+	if !result.Applied || len(result.Errors) > 0 {
+		level, title = "error", "Config reload failed"
+	}
+
+	projectPath = normalizePath(projectPath)
+	for _, px := range d.proxym.List() {
+		if normalizePath(px.Path) == projectPath {
+			px.BroadcastToast(level, title, message, 0)
+		}
+	}
+	for _, session := range d.sessionRegistry.ListActive(projectPath, false) {
+		if session.ProjectPath != projectPath {
+			continue
+		}
+		msg, err := session.typeMessage("[agnt config] "+message, SendOptions{})
+		if err == nil {
+			err = d.sendMessageToOverlay(session.OverlayPath, msg)
+		}
+		if err != nil {
+			log.Printf("[WARN] config reload: failed to notify session %s: %v", session.Code, err)
+		}
+	}
+}
+
+// hubHandleConfigReload handles CONFIG RELOAD [path]: the session's or
+// path's .agnt.kdl is applied against the one autostart last applied.
+func (d *Daemon) hubHandleConfigReload(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CONFIG RELOAD requires a session or path")
+	}
+
+	result, err := d.reloadConfig(ctx, projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+	data, _ := json.Marshal(result)
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestConfigStateObserve(t *testing.T) {
+	var s configState
+	v1 := configStamp{File: "/p/.agnt.kdl", ModTime: time.Unix(1, 0), Size: 10}
+	v2 := configStamp{File: "/p/.agnt.kdl", ModTime: time.Unix(2, 0), Size: 12}
+
+	if s.observe("/p", v2) {
+		t.Error("Expected an untracked project ignored")
+	}
+	s.set("/p", config.DefaultAgntConfig(), v1)
+	if s.observe("/p", v1) {
+		t.Error("Expected no reload without a change")
+	}
+	if s.observe("/p", v2) {
+		t.Error("Expected a change to wait a poll")
+	}
+	if !s.observe("/p", v2) {
+		t.Error("Expected a steady change to reload")
+	}
+	if s.observe("/p", v2) {
+		t.Error("Expected a change to reload once")
+	}
+
+	s.forgetProject("/p")
+	if _, ok := s.get("/p"); ok {
+		t.Error("Expected the project forgotten")
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	dir := t.TempDir()
+	d := New(DaemonConfig{SocketPath: filepath.Join(dir, "daemon.sock")})
+	file := filepath.Join(dir, config.AgntConfigFileName)
+	write := func(kdl string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(kdl), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := d.reloadConfig(context.Background(), dir); err == nil {
+		t.Error("Expected an error before autostart ran")
+	}
+
+	write(`proxies {
+    app { port 3000; }
+    old { port 3001; }
+}
+`)
+	cfg, err := config.LoadAgntConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.trackConfig(dir, cfg)
+	ctx := context.Background()
+	for name, port := range map[string]string{"app": "3000", "old": "3001"} {
+		if _, err := d.proxym.Create(ctx, proxy.ProxyConfig{ID: makeProcessID(dir, name), TargetURL: "http://localhost:" + port, ListenPort: -1, Path: dir}); err != nil {
+			t.Fatalf("Failed to create proxy %s: %v", name, err)
+		}
+	}
+	defer d.proxym.StopAll(ctx) //nolint:errcheck
+
+	write(`proxies {
+    app { port "4000"; }
+}
+`)
+	result, err := d.reloadConfig(ctx, dir)
+	if err != nil {
+		t.Fatalf("reloadConfig failed: %v", err)
+	}
+	if result.Applied || len(result.Issues) != 1 || result.Issues[0].Line != 2 {
+		t.Errorf("Expected the invalid file refused with its line, got %+v", result)
+	}
+
+	write(`proxies {
+    app { port 4000; }
+}
+`)
+	result, err = d.reloadConfig(ctx, dir)
+	if err != nil {
+		t.Fatalf("reloadConfig failed: %v", err)
+	}
+	if !result.Applied || len(result.Retargeted) != 1 || len(result.Stopped) != 1 || result.Stopped[0] != "proxy old" {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	px, err := d.proxym.Get(makeProcessID(dir, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := px.Target().String(); got != "http://localhost:4000" {
+		t.Errorf("Expected app retargeted to port 4000, got %s", got)
+	}
+	if _, err := d.proxym.Get(makeProcessID(dir, "old")); err == nil {
+		t.Error("Expected old stopped")
+	}
+
+	result, err = d.reloadConfig(ctx, dir)
+	if err != nil || result.changed() {
+		t.Errorf("Expected nothing to change on a second reload, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// ConfigReport is the result of ValidateConfig.
+type ConfigReport struct {
+	File   string               `json:"file"`
+	Valid  bool                 `json:"valid"`
+	Issues []config.ConfigIssue `json:"issues"`
+
+	// Autostart is what autostart would start, when the file parses; its
+	// errors are among the issues
+	Autostart *AutostartResult `json:"autostart,omitempty"`
+
+	// Commands are the command lines the autostarted scripts resolve to
+	Commands map[string]string `json:"commands,omitempty"`
+}
+
+// ValidateConfig checks the .agnt.kdl of projectPath (see
+// config.ValidateAgntConfig) and dry-runs its autostart: doubles, script
+// commands, depends-on, stacks and proxies are resolved as RunAutostart
+// would, and git-hooks scripts as HOOK RUN would, without starting
+// anything.
+func ValidateConfig(projectPath string) (*ConfigReport, error) {
+	file := config.FindAgntConfigFile(projectPath)
+	if file == "" {
+		return nil, fmt.Errorf("no %s in %s or its parents", config.AgntConfigFileName, projectPath)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	v := config.ValidateAgntConfig(string(data))
+	report := &ConfigReport{File: file}
+	if v.Config != nil {
+		report.Autostart, report.Commands = dryRunAutostart(v, projectPath)
+	}
+	v.Sort()
+	report.Issues = v.Issues
+	if report.Issues == nil {
+		report.Issues = []config.ConfigIssue{}
+	}
+	report.Valid = !v.HasErrors()
+	return report, nil
+}
+
+// dryRunAutostart resolves what RunAutostart would start from the validated
+// config, adding what would fail to v.
+func dryRunAutostart(v *config.ConfigValidation, projectPath string) (*AutostartResult, map[string]string) {
+	cfg := v.Config
+	result := &AutostartResult{}
+	commands := make(map[string]string)
+
+	for _, name := range sortedNames(cfg.GetAutostartDoubles()) {
+		if _, _, err := doubleSpec(projectPath, name, protocol.DoubleStartConfig{}); err != nil {
+			v.Add(config.SeverityError, "doubles."+name, "%v", err)
+			continue
+		}
+		result.Doubles = append(result.Doubles, name)
+	}
+
+	// resolve checks a script's command the way autostartScript does
+	resolve := func(name string, script *config.ScriptConfig) {
+		if _, done := commands[name]; done {
+			return
+		}
+		if script == nil {
+			script = &config.ScriptConfig{}
+		}
+		workingDir := resolveWorkingDir(projectPath, script.Cwd)
+		if info, err := os.Stat(workingDir); err != nil || !info.IsDir() {
+			v.Add(config.SeverityError, "scripts."+name+".cwd", "directory %s does not exist", workingDir)
+			return
+		}
+		command, args, err := resolveAutostartCommand(name, script, workingDir)
+		if err != nil {
+			v.Add(config.SeverityError, "scripts."+name, "%v", err)
+			return
+		}
+		commands[name] = strings.TrimSpace(command + " " + strings.Join(args, " "))
+	}
+
+	scripts := cfg.GetAutostartScripts()
+	stacks := cfg.GetAutostartStacks()
+	for _, name := range sortedNames(stacks) {
+		st := stacks[name]
+		for svcName, svc := range st.Services {
+			if svc != nil && svc.Script != "" {
+				delete(scripts, svc.Script)
+			} else {
+				delete(scripts, svcName)
+			}
+		}
+		run, err := newStackRun(projectPath, name, st)
+		if err != nil {
+			v.Add(config.SeverityError, "stacks."+name, "%v", err)
+			continue
+		}
+		for _, svc := range run.Services {
+			resolve(svc.Script, cfg.Scripts[svc.Script])
+		}
+		result.Stacks = append(result.Stacks, name)
+	}
+
+	proxies := cfg.GetAutostartProxies()
+	plan, err := newAutostartPlan(cfg, scripts, proxies)
+	if err != nil {
+		v.Add(config.SeverityError, "", "autostart: %v", err)
+	}
+	if plan != nil {
+		for _, level := range plan.Levels {
+			for _, name := range level {
+				resolve(name, cfg.Scripts[name])
+			}
+		}
+		result.WaitingScripts = plan.Scripts
+		result.WaitingProxies = plan.Proxies
+	}
+	for _, name := range sortedNames(scripts) {
+		resolve(name, scripts[name])
+		if _, ok := commands[name]; ok {
+			result.Scripts = append(result.Scripts, name)
+		}
+	}
+
+	for _, name := range sortedNames(cfg.Proxies) {
+		p := cfg.Proxies[name]
+		if p == nil || !p.Autostart {
+			continue
+		}
+		switch {
+		case p.Script != "":
+			if cfg.Scripts[p.Script] == nil {
+				v.Add(config.SeverityError, "proxies."+name+".script", "unknown script %s", p.Script)
+				continue
+			}
+		case p.URL == "" && p.Port == 0 && p.Target == "":
+			v.Add(config.SeverityWarning, "proxies."+name, "has no url, port, target or script, so autostart skips it")
+			continue
+		}
+		if _, ok := proxies[name]; ok {
+			result.Proxies = append(result.Proxies, name)
+		}
+	}
+
+	for _, hook := range sortedNames(cfg.GitHooks) {
+		h := cfg.GitHooks[hook]
+		if h == nil {
+			continue
+		}
+		for _, script := range h.Scripts {
+			if _, err := resolveScriptCommand(projectPath, script, "", "", nil); err != nil {
+				v.Add(config.SeverityError, "git-hooks."+hook+".scripts", "%v", err)
+			}
+		}
+	}
+	return result, commands
+}
+
+// sortedNames returns the keys of a config map in order.
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hubHandleConfig handles the CONFIG command.
+func (d *Daemon) hubHandleConfig(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbValidate:
+		return d.hubHandleConfigValidate(conn, cmd)
+	case protocol.SubVerbReload:
+		return d.hubHandleConfigReload(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown CONFIG sub-command",
+			Command:      protocol.VerbConfig,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbValidate, protocol.SubVerbReload},
+		})
+	}
+}
+
+// hubHandleConfigValidate handles CONFIG VALIDATE [path]: the session's or
+// path's .agnt.kdl is checked and its autostart dry-run.
+func (d *Daemon) hubHandleConfigValidate(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CONFIG VALIDATE requires a session or path")
+	}
+
+	report, err := ValidateConfig(projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+	data, _ := json.Marshal(report)
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	kdl := `scripts {
+    api { run "go run ./cmd/api"; autostart true; ports 8080; }
+    web { run "npm run dev"; autostart true; depends-on "api"; }
+    docs { run "mkdocs serve"; autostart true; cwd "missing"; }
+    worker { run "worker"; autostart true; depends-on "queue"; }
+}
+proxies {
+    app { autostart true; port 3000; }
+    stray { autostart true; }
+    linked { autostart true; script "nope"; }
+}
+git-hooks {
+    pre-commit { scripts "lint"; }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, config.AgntConfigFileName), []byte(kdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ValidateConfig(dir)
+	if err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	if report.Valid {
+		t.Error("Expected an invalid report")
+	}
+
+	issues := make(map[string]config.ConfigIssue)
+	for _, issue := range report.Issues {
+		issues[issue.Path] = issue
+	}
+	expect := func(path string, line int, contains string) {
+		t.Helper()
+		issue, ok := issues[path]
+		if !ok {
+			t.Errorf("No issue at %s in %v", path, report.Issues)
+			return
+		}
+		if issue.Line != line || !strings.Contains(issue.Message, contains) {
+			t.Errorf("Issue at %s: got line %d %q, want line %d containing %q", path, issue.Line, issue.Message, line, contains)
+		}
+	}
+	expect("scripts.docs.cwd", 4, "does not exist")
+	expect("", 0, "unknown script queue")
+	expect("proxies.stray", 9, "autostart skips it")
+	expect("proxies.linked.script", 10, "unknown script nope")
+	expect("git-hooks.pre-commit.scripts", 13, `"lint"`)
+
+	// The broken plan starts everything right away, as RunAutostart does
+	if got := fmt.Sprint(report.Autostart.Scripts); got != "[api web worker]" {
+		t.Errorf("Unexpected scripts %s", got)
+	}
+	if got := fmt.Sprint(report.Autostart.Proxies); got != "[app]" {
+		t.Errorf("Unexpected proxies %s", got)
+	}
+	if got := report.Commands["api"]; got != "sh -c go run ./cmd/api" {
+		t.Errorf("Unexpected api command %q", got)
+	}
+}
+
+func TestValidateConfigWaiting(t *testing.T) {
+	dir := t.TempDir()
+	kdl := `scripts {
+    api { run "api"; autostart true; ports 8080; }
+    web { run "web"; autostart true; depends-on "api"; }
+}
+proxies {
+    app { autostart true; port 3000; depends-on "web"; }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, config.AgntConfigFileName), []byte(kdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ValidateConfig(dir)
+	if err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	if !report.Valid || len(report.Issues) != 0 {
+		t.Errorf("Expected a valid report, got %v", report.Issues)
+	}
+	a := report.Autostart
+	if fmt.Sprint(a.Scripts, a.WaitingScripts, a.WaitingProxies) != "[api] [web] [app]" {
+		t.Errorf("Unexpected autostart %+v", a)
+	}
+	if report.Commands["web"] != "sh -c web" {
+		t.Errorf("Expected the waiting script's command, got %v", report.Commands)
+	}
+}
+
+func TestValidateConfigNoFile(t *testing.T) {
+	if _, err := ValidateConfig(t.TempDir()); err == nil {
+		t.Error("Expected an error without .agnt.kdl")
+	}
+}
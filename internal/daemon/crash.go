@@ -0,0 +1,221 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/crash"
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// crashScanInterval is how often exited processes are checked for crashes.
+const crashScanInterval = time.Second
+
+// crashLoop captures a crash report for each managed process that crashes,
+// so the post-mortem outlives the process entry.
+func (d *Daemon) crashLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(crashScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.scanCrashes()
+		}
+	}
+}
+
+// scanCrashes checks each exited process once, and records process runs
+// starting and ending in the EVENTS log.
+func (d *Daemon) scanCrashes() {
+	procs := d.hub.ProcessManager().List()
+
+	var started, exited []*process.ManagedProcess
+	present := make(map[string]bool, len(procs))
+	d.crashMu.Lock()
+	for _, p := range procs {
+		key := crashKey(p)
+		present[key] = true
+		if !d.startedSeen[key] && p.StartTime() != nil {
+			d.startedSeen[key] = true
+			started = append(started, p)
+		}
+		if !p.IsDone() || d.crashSeen[key] {
+			continue
+		}
+		d.crashSeen[key] = true
+		exited = append(exited, p)
+	}
+	// Forget processes that have been removed
+	for key := range d.crashSeen {
+		if !present[key] {
+			delete(d.crashSeen, key)
+		}
+	}
+	for key := range d.startedSeen {
+		if !present[key] {
+			delete(d.startedSeen, key)
+		}
+	}
+	d.crashMu.Unlock()
+	// Removed processes take their labels with them
+	d.labels.retain(depgraph.KindProcess, func(id string) bool {
+		_, err := d.hub.ProcessManager().Get(id)
+		return err == nil
+	})
+
+	for _, p := range started {
+		d.eventLog.record(processStartedEvent(p))
+	}
+	for _, p := range exited {
+		d.eventLog.record(processExitedEvent(p))
+		r := d.captureCrash(p)
+		if (r != nil || p.State() == process.StateFailed) && d.events.active() {
+			d.events.publish(processFailureEvent(p, r))
+		}
+	}
+}
+
+// crashKey identifies one run of a process; restarts reuse the ID.
+func crashKey(p *process.ManagedProcess) string {
+	key := p.ID
+	if start := p.StartTime(); start != nil {
+		key += "@" + strconv.FormatInt(start.UnixNano(), 10)
+	}
+	return key
+}
+
+// captureCrash stores a crash report for p if its exit looks like a crash,
+// and returns it.
+func (d *Daemon) captureCrash(p *process.ManagedProcess) *crash.Report {
+	stderr, _ := p.Stderr()
+	r := crash.Analyze(stderr, p.ExitCode())
+	if r == nil {
+		return nil
+	}
+
+	r.ProcessID = p.ID
+	r.ProjectPath = p.ProjectPath
+	r.Command = p.Command
+	r.Args = p.Args
+	r.PID = p.PID()
+	r.StartedAt = p.StartTime()
+	r.CrashedAt = time.Now()
+	if end := p.EndTime(); end != nil {
+		r.CrashedAt = *end
+	}
+	if r.Signal != "" && r.PID > 0 && r.StartedAt != nil {
+		r.Core = crash.FindCore(p.ProjectPath, r.PID, *r.StartedAt)
+	}
+
+	path, err := crash.Save(p.ProjectPath, r)
+	if err != nil {
+		log.Printf("[WARN] failed to save crash report for %s: %v", p.ID, err)
+		return r
+	}
+	log.Printf("[INFO] Process %s crashed (%s), report saved to %s", p.ID, r.Kind, path)
+
+	message := r.Message
+	if len(r.Frames) > 0 {
+		message += " at " + r.Frames[0].Function
+	}
+	projectPath := normalizePath(p.ProjectPath)
+	for _, px := range d.proxym.List() {
+		if normalizePath(px.Path) == projectPath {
+			px.BroadcastToast("error", fmt.Sprintf("Process %s crashed", p.ID), message, 0)
+		}
+	}
+	return r
+}
+
+// procCrashRequest is the JSON payload of PROC CRASH.
+type procCrashRequest struct {
+	Path string `json:"path"`
+}
+
+// hubHandleProcCrash handles PROC CRASH [process_id|report_id].
+// Without an argument, lists the project's crash reports; with one, returns
+// the full report, or the latest report of the named process.
+func (d *Daemon) hubHandleProcCrash(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req procCrashRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" && len(cmd.Args) > 0 {
+		if proc, err := d.hub.ProcessManager().Get(cmd.Args[0]); err == nil {
+			projectPath = proc.ProjectPath
+		}
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROC CRASH requires a session or path")
+	}
+
+	if len(cmd.Args) > 0 {
+		r, err := crash.Get(projectPath, cmd.Args[0])
+		if errors.Is(err, crash.ErrNotFound) {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no crash report for %q", cmd.Args[0]))
+		}
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInternal, err.Error())
+		}
+		data, _ := json.Marshal(map[string]interface{}{"crash": r})
+		return conn.WriteJSON(data)
+	}
+
+	reports, err := crash.List(projectPath, "")
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	type entry struct {
+		ID        string    `json:"id"`
+		ProcessID string    `json:"process_id"`
+		Kind      string    `json:"kind"`
+		Message   string    `json:"message,omitempty"`
+		ExitCode  int       `json:"exit_code"`
+		CrashedAt time.Time `json:"crashed_at"`
+	}
+	list := make([]entry, 0, len(reports))
+	for _, r := range reports {
+		list = append(list, entry{r.ID, r.ProcessID, r.Kind, r.Message, r.ExitCode, r.CrashedAt})
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"crashes": list,
+		"count":   len(list),
+	})
+	return conn.WriteJSON(data)
+}
+
+// latestCrash returns a summary of the newest crash report of an exited
+// process, for PROC STATUS.
+func latestCrash(proc *process.ManagedProcess) map[string]interface{} {
+	reports, err := crash.List(proc.ProjectPath, proc.ID)
+	if err != nil || len(reports) == 0 {
+		return nil
+	}
+	r := reports[0]
+	if start := proc.StartTime(); start != nil && r.CrashedAt.Before(*start) {
+		return nil // From an earlier run
+	}
+	return map[string]interface{}{
+		"id":      r.ID,
+		"kind":    r.Kind,
+		"message": r.Message,
+	}
+}
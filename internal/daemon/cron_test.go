@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		expr  string
+		tz    string
+		after time.Time
+		want  time.Time
+	}{
+		{"*/30 * * * *", "UTC", time.Date(2026, 3, 2, 10, 7, 30, 0, time.UTC), time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)},
+		{"*/30 * * * *", "UTC", time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC), time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", "UTC", time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"@daily", "UTC", time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"15 2 1 feb,jun *", "UTC", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 1, 2, 15, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted: the 13th or a Friday
+		{"0 12 13 * 5", "UTC", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", "UTC", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", "Europe/Berlin", time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 9, 0, 0, 0, berlin)},
+		{"@every 45m", "", time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 2, 10, 45, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.expr, tt.tz)
+		if err != nil {
+			t.Fatalf("parseCron(%q) error = %v", tt.expr, err)
+		}
+		if got := c.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("parseCron(%q).Next(%v) = %v, want %v", tt.expr, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@every 10s"} {
+		if _, err := parseCron(expr, ""); err == nil {
+			t.Errorf("parseCron(%q) should fail", expr)
+		}
+	}
+	if _, err := parseCron("* * * * *", "Mars/Olympus"); err == nil {
+		t.Error("parseCron should reject an unknown time zone")
+	}
+}
+
+func TestParseCron_NeverMatches(t *testing.T) {
+	c, err := parseCron("0 0 30 2 *", "UTC")
+	if err != nil {
+		t.Fatalf("parseCron error = %v", err)
+	}
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Expected no match for February 30th, got %v", got)
+	}
+}
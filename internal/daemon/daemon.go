@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,13 +15,20 @@ import (
 	"time"
 
 	"github.com/standardbeagle/agnt/internal/automation"
+	"github.com/standardbeagle/agnt/internal/bench"
 	"github.com/standardbeagle/agnt/internal/config"
 	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/fixture"
 	"github.com/standardbeagle/agnt/internal/project"
+	"github.com/standardbeagle/agnt/internal/protocol"
 	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/ptyhost"
+	"github.com/standardbeagle/agnt/internal/remote"
 	"github.com/standardbeagle/agnt/internal/store"
+	"github.com/standardbeagle/agnt/internal/testhistory"
 	"github.com/standardbeagle/agnt/internal/tunnel"
 	"github.com/standardbeagle/agnt/internal/updater"
+	"github.com/standardbeagle/agnt/internal/workspace"
 	"github.com/standardbeagle/go-cli-server/hub"
 	"github.com/standardbeagle/go-cli-server/process"
 )
@@ -95,6 +103,20 @@ type DaemonConfig struct {
 	// UpdateCheckInterval is the interval between update checks.
 	// Default: 24 hours
 	UpdateCheckInterval time.Duration
+
+	// RecordPath, when set, records every request/response pair on the
+	// socket, sanitized, into a replayable fixture file. The hub then
+	// listens on a private socket behind a recording relay.
+	RecordPath string
+
+	// DisableForwarding turns off the automatic forwards that make loopback
+	// proxies and dev servers reachable from the host when the daemon runs
+	// in WSL or a container.
+	DisableForwarding bool
+
+	// AdminAddr, when set, serves net/http/pprof for the daemon itself on
+	// this loopback address (e.g. "127.0.0.1:6061").
+	AdminAddr string
 }
 
 // DefaultDaemonConfig returns sensible defaults.
@@ -132,6 +154,9 @@ type Daemon struct {
 	scheduler         *Scheduler
 	schedulerStateMgr *SchedulerStateManager
 
+	// Terminals of agnt run --detachable sessions, kept across terminal crashes
+	ptyHosts *ptyhost.Manager
+
 	// State persistence
 	stateMgr   *StateManager
 	pidTracker *process.FilePIDTracker
@@ -139,14 +164,92 @@ type Daemon struct {
 	// URL tracking for processes
 	urlTracker *URLTracker
 
+	// Labels of processes, proxies and tunnels
+	labels labelStore
+
+	// Disposable project copies created by WORKSPACE CREATE
+	workspaces *workspace.Manager
+
+	// Health checks and restart policies set by PROC SUPERVISE
+	supervisor supervisor
+
+	// CPU and memory samples of running processes
+	metrics processMetrics
+
+	// Scripts running on remote checkouts, and forwards of their ports
+	remotes remoteState
+
+	// kubectl port-forwards and pod log streams started by K8S
+	k8s k8sState
+
+	// Stacks started by STACK START or autostart
+	stacks stackState
+
+	// API doubles started by DOUBLE START or autostart
+	doubles doubleState
+
+	// File watches that re-run scripts on change
+	watches watchState
+
+	// The .agnt.kdl autostart applied to each project, for hot-reload
+	configs configState
+
+	// Where the daemon runs, and forwards of loopback listeners to the host
+	topology atomic.Pointer[topologyState]
+
 	// Proxy event system
 	proxyEvents   chan ProxyEvent
 	scriptProxies map[string][]string // scriptID -> []proxyID
 	scriptProxyMu sync.RWMutex
 
+	// Exposures created by EXPOSE START, keyed by session-scoped ID
+	exposures  map[string]*exposure
+	exposureMu sync.Mutex
+
+	// Test run history per project path
+	testHistories map[string]*testhistory.History
+	testHistoryMu sync.Mutex
+
+	// Benchmark history per project path
+	benchHistories map[string]*bench.History
+	benchHistoryMu sync.Mutex
+
+	// Process runs already checked for crashes, and those recorded as
+	// started, keyed by ID and start time
+	crashSeen   map[string]bool
+	startedSeen map[string]bool
+	crashMu     sync.Mutex
+
+	// Activity digests turned on by SESSION DIGEST, keyed by session code
+	digests  map[string]*sessionDigest
+	digestMu sync.Mutex
+
+	// Frontend errors, 5xx responses and process failures, for WAIT ERROR
+	events eventBus
+
+	// Error spike alert rules added by SESSION ALERT
+	alerts alertEngine
+
+	// Toasts with actions waiting for a click
+	toasts toastRegistry
+
+	// Processes, proxies, tunnels and sessions coming and going, for EVENTS
+	eventLog eventLog
+	// Start of the target outage last recorded per proxy ID
+	unreachableSeen sync.Map
+
 	// Update checker
 	updateChecker *updater.UpdateChecker
 
+	// Protocol fixture recording (RecordPath)
+	recorder     *fixture.Recorder
+	recordFile   *os.File
+	recordSocket *SocketManager
+
+	// Admin endpoint (AdminAddr)
+	admin    *http.Server
+	adminURL string
+
 	// Overlay endpoint (can be set dynamically)
 	overlayEndpoint atomic.Pointer[string]
 
@@ -182,7 +285,7 @@ func New(config DaemonConfig) *Daemon {
 	procConfig.PIDTracker = pidTracker
 
 	hubConfig := hub.Config{
-		SocketPath:        config.SocketPath,
+		SocketPath:        hubSocketPath(config),
 		SocketName:        "devtool-mcp", // Keep existing socket name
 		MaxClients:        config.MaxClients,
 		ReadTimeout:       config.ReadTimeout,
@@ -194,6 +297,12 @@ func New(config DaemonConfig) *Daemon {
 
 	h := hub.New(hubConfig)
 
+	// Attach sockets of hosted terminals live next to the daemon's
+	ptySocketDir := filepath.Dir(DefaultSocketPath())
+	if config.SocketPath != "" {
+		ptySocketDir = filepath.Dir(config.SocketPath)
+	}
+
 	d := &Daemon{
 		config:            config,
 		hub:               h,
@@ -203,13 +312,31 @@ func New(config DaemonConfig) *Daemon {
 		sessionRegistry:   sessionRegistry,
 		scheduler:         scheduler,
 		schedulerStateMgr: schedulerStateMgr,
+		ptyHosts:          ptyhost.NewManager(ptySocketDir),
 		pidTracker:        pidTracker,
 		proxyEvents:       make(chan ProxyEvent, 10), // Buffer 10 events
 		scriptProxies:     make(map[string][]string),
+		exposures:         make(map[string]*exposure),
+		testHistories:     make(map[string]*testhistory.History),
+		benchHistories:    make(map[string]*bench.History),
+		crashSeen:         make(map[string]bool),
+		startedSeen:       make(map[string]bool),
+		digests:           make(map[string]*sessionDigest),
+		workspaces:        workspace.NewManager(workspaceRoot()),
+		remotes:           remoteState{forwards: remote.NewForwards()},
 		ctx:               ctx,
 		cancel:            cancel,
 	}
 
+	// Clipboard text and files from the floating panel go to the project's session
+	d.proxym.SetSessionBridge(sessionBridge{d: d})
+	// Errors the proxies log go to WAIT ERROR waiters
+	d.proxym.SetLogHook(d.publishProxyEntry)
+	// Events carry the labels of their process, proxy or tunnel
+	d.eventLog.labels = d.labels.get
+	// Proxies starting and stopping go to the EVENTS log
+	d.proxym.SetLifecycleHook(d.recordProxyLifecycle)
+
 	// Create URLTracker with callbacks to emit proxy events
 	// Access ProcessManager through Hub
 	urlTracker := NewURLTracker(h.ProcessManager(), DefaultURLTrackerConfig())
@@ -249,6 +376,7 @@ func New(config DaemonConfig) *Daemon {
 		// Load URL matchers from config when a process is first detected
 		d.LoadURLMatchersForProcess(processID)
 	}
+	urlTracker.mapURL = d.mapProcessURL
 	d.urlTracker = urlTracker
 
 	// Initialize state manager if persistence is enabled
@@ -257,6 +385,8 @@ func New(config DaemonConfig) *Daemon {
 			StatePath: config.StatePath,
 			AutoLoad:  true,
 		})
+		// The daemon state lists the projects whose scheduled tasks to restore
+		d.schedulerStateMgr.SetProjectIndex(d.stateMgr)
 	}
 
 	// Set initial overlay endpoint from config or persisted state
@@ -317,6 +447,17 @@ func (d *Daemon) Start() error {
 	}
 	d.started = time.Now()
 
+	if d.config.RecordPath != "" {
+		if err := d.startRecorder(); err != nil {
+			d.hub.Stop(context.Background())
+			return err
+		}
+	}
+
+	if err := d.startAdmin(); err != nil {
+		log.Printf("[WARN] %v", err)
+	}
+
 	// Clean up orphaned processes from previous crash
 	d.cleanupOrphans()
 
@@ -335,6 +476,33 @@ func (d *Daemon) Start() error {
 	d.wg.Add(1)
 	go d.handleProxyEvents()
 
+	// Capture crash reports as managed processes exit
+	d.wg.Add(1)
+	go d.crashLoop()
+
+	// Sample CPU and memory of running processes
+	d.wg.Add(1)
+	go d.metricsLoop()
+
+	// Watch the pods of K8S log streams for container restarts
+	d.wg.Add(1)
+	go d.k8sLoop()
+
+	// Re-run scripts when watched files change
+	d.wg.Add(1)
+	go d.watchLoop()
+
+	// Apply edits to the .agnt.kdl of autostarted projects
+	d.wg.Add(1)
+	go d.configWatchLoop()
+
+	// Forward loopback listeners when the host can't reach them
+	d.startForwarding()
+
+	// Send periodic activity digests to sessions that asked for them
+	d.wg.Add(1)
+	go d.digestLoop()
+
 	// Start update checker if enabled
 	if d.updateChecker != nil {
 		d.updateChecker.Start()
@@ -366,6 +534,18 @@ func (d *Daemon) restoreProxies() {
 			MaxLogSize:  pc.MaxLogSize,
 			AutoRestart: true,
 			Path:        pc.Path,
+			NoRetarget:  pc.NoRetarget,
+			Routes:      pc.Routes,
+			PathRoutes:  pc.PathRoutes,
+			Cookies:     pc.Cookies,
+			URLRewrite:  pc.URLRewrite,
+			Storms:      pc.Storms,
+			Banner:      pc.Banner,
+			UI:          pc.UI,
+
+			BodyCapture:    pc.BodyCapture,
+			PersistLogs:    pc.PersistLogs,
+			TrustedProxies: pc.TrustedProxies,
 		}
 
 		proxyServer, err := d.proxym.Create(d.ctx, config)
@@ -422,6 +602,10 @@ func (d *Daemon) Stop(ctx context.Context) error {
 	// Signal all goroutines to stop
 	d.cancel()
 
+	// Stop recording relay before the hub it forwards to
+	d.stopRecorder()
+	d.stopAdmin(ctx)
+
 	// Stop Hub (handles listener, clients, connections)
 	if err := d.hub.Stop(ctx); err != nil {
 		log.Printf("[Daemon] error stopping hub: %v", err)
@@ -433,6 +617,16 @@ func (d *Daemon) Stop(ctx context.Context) error {
 	// Stop scheduler
 	d.scheduler.Stop()
 
+	// Hang up hosted terminals; their processes don't outlive the daemon
+	d.ptyHosts.StopAll()
+
+	// Write out state changes still waiting on the save debounce
+	if d.stateMgr != nil {
+		if err := d.stateMgr.Flush(); err != nil {
+			debug.Error("daemon", "state flush error: %v", err)
+		}
+	}
+
 	// Stop update checker
 	if d.updateChecker != nil {
 		d.updateChecker.Stop()
@@ -448,6 +642,11 @@ func (d *Daemon) Stop(ctx context.Context) error {
 		errs = append(errs, fmt.Errorf("proxy manager: %w", err))
 	}
 
+	// Workspaces are disposable; their processes stopped with the hub
+	d.workspaces.RemoveAll(ctx)
+
+	d.remotes.forwards.Close("")
+
 	// Clear PID tracking (clean shutdown)
 	if d.pidTracker != nil {
 		if err := d.pidTracker.Clear(); err != nil {
@@ -508,6 +707,8 @@ func (d *Daemon) Info() DaemonInfo {
 		},
 		SessionInfo:   d.sessionRegistry.Info(),
 		SchedulerInfo: d.scheduler.Info(),
+		Topology:      d.topologyInfo(),
+		AdminURL:      d.adminURL,
 	}
 
 	// Include update info if update checker is enabled
@@ -690,10 +891,19 @@ func (d *Daemon) CleanupSessionResources(sessionCode string) {
 		log.Printf("[Daemon] session %s not found for cleanup", sessionCode)
 		return
 	}
+	defer d.eventLog.record(DaemonEvent{
+		Type:    EventSessionUnregistered,
+		Source:  sessionCode,
+		Path:    session.ProjectPath,
+		Message: fmt.Sprintf("session %s unregistered", sessionCode),
+	})
 
 	projectPath := session.ProjectPath
 	if projectPath == "" {
 		log.Printf("[Daemon] session %s has no project path, skipping resource cleanup", sessionCode)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		d.cleanupSessionWorkspaces(ctx, sessionCode)
+		cancel()
 		// Still unregister the session
 		d.sessionRegistry.Unregister(sessionCode)
 		return
@@ -741,6 +951,18 @@ func (d *Daemon) CleanupSessionResources(sessionCode string) {
 
 	wg.Wait()
 
+	// Stopped processes must not be brought back by their restart policy
+	d.supervisor.removeProject(projectPath)
+	d.remotes.forgetProject(projectPath)
+	d.k8s.forgetProject(projectPath)
+	d.stacks.forgetProject(projectPath)
+	d.doubles.forgetProject(projectPath)
+	d.watches.forgetProject(projectPath)
+	d.configs.forgetProject(projectPath)
+
+	// Remove the session's workspaces and what runs in them
+	d.cleanupSessionWorkspaces(ctx, sessionCode)
+
 	// Unregister the session
 	if err := d.sessionRegistry.Unregister(sessionCode); err != nil {
 		log.Printf("[Daemon] error unregistering session %s: %v", sessionCode, err)
@@ -766,6 +988,8 @@ type DaemonInfo struct {
 	SessionInfo   SessionInfo         `json:"session_info"`
 	SchedulerInfo SchedulerInfo       `json:"scheduler_info"`
 	UpdateInfo    *updater.UpdateInfo `json:"update_info,omitempty"` // Update availability info
+	Topology      *TopologyInfo       `json:"topology,omitempty"`    // Set once Start detects the environment
+	AdminURL      string              `json:"admin_url,omitempty"`   // pprof endpoint when AdminAddr is set
 }
 
 // ProcessInfo holds process manager statistics.
@@ -792,8 +1016,15 @@ type TunnelInfo struct {
 // AutostartResult holds the results of an autostart operation.
 type AutostartResult struct {
 	Scripts []string `json:"scripts,omitempty"`
+	Stacks  []string `json:"stacks,omitempty"`
+	Doubles []string `json:"doubles,omitempty"`
 	Proxies []string `json:"proxies,omitempty"`
 	Errors  []string `json:"errors,omitempty"`
+
+	// Scripts and proxies starting in the background once the scripts they
+	// depend on are ready
+	WaitingScripts []string `json:"waiting_scripts,omitempty"`
+	WaitingProxies []string `json:"waiting_proxies,omitempty"`
 }
 
 // RunAutostart loads .agnt.kdl config from projectPath and starts configured processes/proxies.
@@ -825,9 +1056,42 @@ func (d *Daemon) RunAutostart(ctx context.Context, projectPath string) *Autostar
 	log.Printf("[DEBUG] RunAutostart: config loaded, scripts=%d proxies=%d",
 		len(agntConfig.Scripts), len(agntConfig.Proxies))
 
+	// Later edits to the file are diffed against this
+	d.trackConfig(projectPath, agntConfig)
+
+	// Start doubles first, so scripts get their env
+	for name := range agntConfig.GetAutostartDoubles() {
+		if _, err := d.startDouble(ctx, projectPath, name, protocol.DoubleStartConfig{}); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("double %s: %v", name, err))
+			continue
+		}
+		result.Doubles = append(result.Doubles, name)
+	}
+
 	// Start scripts (pass proxy configs for port detection)
 	autostartScripts := agntConfig.GetAutostartScripts()
 	proxyConfigs := agntConfig.Proxies // All proxies, not just autostart ones
+
+	// Scripts of autostarted stacks start with their stack, in order
+	autostartStacks := agntConfig.GetAutostartStacks()
+	for _, st := range autostartStacks {
+		for svcName, svc := range st.Services {
+			if svc != nil && svc.Script != "" {
+				delete(autostartScripts, svc.Script)
+			} else {
+				delete(autostartScripts, svcName)
+			}
+		}
+	}
+
+	// Scripts and proxies with depends-on wait for those scripts to be
+	// ready; with a broken plan they start right away
+	autostartProxies := agntConfig.GetAutostartProxies()
+	plan, err := newAutostartPlan(agntConfig, autostartScripts, autostartProxies)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
 	log.Printf("[DEBUG] RunAutostart: found %d autostart scripts: %v", len(autostartScripts), mapKeys(autostartScripts))
 	for name, script := range autostartScripts {
 		log.Printf("[DEBUG] RunAutostart: starting script %s", name)
@@ -840,8 +1104,21 @@ func (d *Daemon) RunAutostart(ctx context.Context, projectPath string) *Autostar
 		}
 	}
 
+	// Start stacks in the background, since readiness can take a while
+	for name, st := range autostartStacks {
+		run, err := newStackRun(projectPath, name, st)
+		if err == nil {
+			err = d.stacks.begin(run)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("stack %s: %v", name, err))
+			continue
+		}
+		go d.startStack(d.ctx, agntConfig, st, run)
+		result.Stacks = append(result.Stacks, name)
+	}
+
 	// Start proxies
-	autostartProxies := agntConfig.GetAutostartProxies()
 	log.Printf("[DEBUG] RunAutostart: found %d autostart proxies: %v", len(autostartProxies), mapKeysProxy(autostartProxies))
 	for name, proxyConfig := range autostartProxies {
 		log.Printf("[DEBUG] RunAutostart: starting proxy %s (script=%s port=%d)", name, proxyConfig.Script, proxyConfig.Port)
@@ -854,6 +1131,12 @@ func (d *Daemon) RunAutostart(ctx context.Context, projectPath string) *Autostar
 		}
 	}
 
+	if plan != nil {
+		go d.runAutostartPlan(d.ctx, agntConfig, projectPath, plan)
+		result.WaitingScripts = plan.Scripts
+		result.WaitingProxies = plan.Proxies
+	}
+
 	return result
 }
 
@@ -930,18 +1213,52 @@ func (d *Daemon) autostartScript(ctx context.Context, name string, script *confi
 	// Make process ID unique per project to avoid collisions between sessions
 	processID := makeProcessID(projectPath, name)
 
-	// Resolve working directory and environment
+	// Resolve working directory and environment; the script's own env
+	// overrides that of the project's doubles
 	workingDir := resolveWorkingDir(projectPath, script.Cwd)
-	envSlice := envMapToSlice(script.Env)
+	envSlice := append(d.doubles.env(projectPath), envMapToSlice(script.Env)...)
 
 	// Check if already running
 	if _, err := d.hub.ProcessManager().Get(processID); err == nil {
 		return nil // Already running
 	}
 
-	var command string
-	var args []string
+	command, args, err := resolveAutostartCommand(name, script, workingDir)
+	if err != nil {
+		return err
+	}
+
+	// Determine expected port for pre-flight cleanup and EADDRINUSE recovery
+	expectedPort := d.getExpectedPortForScript(name, script, proxyConfigs, workingDir, command, args)
+
+	// Projects with a remote block run their scripts on the remote checkout;
+	// its ports are not ours to clean up
+	target, err := remoteTarget(projectPath)
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		command, args = target.Command(script.Cwd, envSlice, command, args)
+		workingDir, envSlice, expectedPort = projectPath, nil, 0
+		d.remotes.track(processID, projectPath, *target)
+	}
+
+	// Start with automatic EADDRINUSE recovery
+	_, startupErr := d.startScriptWithRetry(ctx, processID, workingDir, command, args, envSlice, expectedPort)
+	if startupErr != nil {
+		return startupErr
+	}
 
+	// Load and set URL matchers for this process
+	d.LoadURLMatchersForProcess(processID)
+
+	return nil
+}
+
+// resolveAutostartCommand returns the command line of a script: its run
+// string through sh, its command, or the project command of that name
+// detected in workingDir.
+func resolveAutostartCommand(name string, script *config.ScriptConfig, workingDir string) (command string, args []string, err error) {
 	if script.Run != "" {
 		// Shell command string - execute via sh -c
 		command = "sh"
@@ -956,7 +1273,7 @@ func (d *Daemon) autostartScript(ctx context.Context, name string, script *confi
 		proj, err := project.Detect(workingDir)
 		if err != nil {
 			debug.Error("daemon", "project detection failed for %s: %v", workingDir, err)
-			return fmt.Errorf("project detection failed: %v", err)
+			return "", nil, fmt.Errorf("project detection failed: %v", err)
 		}
 
 		switch proj.Type {
@@ -978,25 +1295,21 @@ func (d *Daemon) autostartScript(ctx context.Context, name string, script *confi
 		case project.ProjectPython:
 			command = "python"
 			args = []string{"-m", name}
+			// Makefile targets and tox environments the project defines win
+			if def := project.GetCommandByName(proj, name); def != nil && (def.Command == "make" || def.Command == "tox") {
+				command, args = def.Command, def.Args
+			}
 		default:
+			// Rust, JVM and .NET projects run their detected commands
+			if def := project.GetCommandByName(proj, name); def != nil {
+				command, args = def.Command, def.Args
+				break
+			}
 			debug.Error("daemon", "cannot run script %q: unknown project type %s", name, proj.Type)
-			return fmt.Errorf("cannot run script %q: unknown project type and no command specified", name)
+			return "", nil, fmt.Errorf("cannot run script %q: unknown project type and no command specified", name)
 		}
 	}
-
-	// Determine expected port for pre-flight cleanup and EADDRINUSE recovery
-	expectedPort := d.getExpectedPortForScript(name, script, proxyConfigs, workingDir, command, args)
-
-	// Start with automatic EADDRINUSE recovery
-	_, startupErr := d.startScriptWithRetry(ctx, processID, workingDir, command, args, envSlice, expectedPort)
-	if startupErr != nil {
-		return startupErr
-	}
-
-	// Load and set URL matchers for this process
-	d.LoadURLMatchersForProcess(processID)
-
-	return nil
+	return command, args, nil
 }
 
 // autostartProxy starts a single proxy from config.
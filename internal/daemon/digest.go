@@ -0,0 +1,417 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// digestScanInterval is how often sessions with a digest are checked for a
+// due summary.
+const digestScanInterval = 10 * time.Second
+
+// digestTopN is how many error messages and slow endpoints a digest names.
+const digestTopN = 3
+
+// Digest delivery targets.
+const (
+	DigestDeliverSession = "session" // Typed into the session's agent
+	DigestDeliverToast   = "toast"   // Toast on the project's proxied pages
+	DigestDeliverBoth    = "both"
+)
+
+// DigestConfig configures the periodic activity digest of a session. A
+// digest is only sent when at least one kind of activity reaches its
+// threshold, so quiet periods stay quiet.
+type DigestConfig struct {
+	IntervalMinutes    int    `json:"interval_minutes,omitempty"`     // Minutes between digests (default 15)
+	Deliver            string `json:"deliver,omitempty"`              // session (default), toast or both
+	MinErrors          int    `json:"min_errors,omitempty"`           // New JS errors and 5xx responses to report (default 1)
+	MinFailedProcesses int    `json:"min_failed_processes,omitempty"` // Processes exiting non-zero to report (default 1)
+	MinSlowRequests    int    `json:"min_slow_requests,omitempty"`    // Slow responses to report (default 3)
+	SlowMs             int    `json:"slow_ms,omitempty"`              // Responses at least this slow count as slow (default 1000)
+}
+
+// withDefaults fills unset fields.
+func (c DigestConfig) withDefaults() DigestConfig {
+	if c.IntervalMinutes == 0 {
+		c.IntervalMinutes = 15
+	}
+	if c.Deliver == "" {
+		c.Deliver = DigestDeliverSession
+	}
+	if c.MinErrors == 0 {
+		c.MinErrors = 1
+	}
+	if c.MinFailedProcesses == 0 {
+		c.MinFailedProcesses = 1
+	}
+	if c.MinSlowRequests == 0 {
+		c.MinSlowRequests = 3
+	}
+	if c.SlowMs == 0 {
+		c.SlowMs = 1000
+	}
+	return c
+}
+
+// Validate checks the interval, thresholds and delivery target.
+func (c DigestConfig) Validate() error {
+	if c.IntervalMinutes < 0 || c.MinErrors < 0 || c.MinFailedProcesses < 0 || c.MinSlowRequests < 0 || c.SlowMs < 0 {
+		return fmt.Errorf("digest interval and thresholds must not be negative")
+	}
+	switch c.Deliver {
+	case "", DigestDeliverSession, DigestDeliverToast, DigestDeliverBoth:
+		return nil
+	}
+	return fmt.Errorf("invalid digest delivery %q: use session, toast or both", c.Deliver)
+}
+
+func (c DigestConfig) interval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+// DigestActivity is what happened in a project during one digest window.
+type DigestActivity struct {
+	Since           time.Time
+	Errors          []proxy.FrontendError
+	ServerErrors    []proxy.HTTPLogEntry // 5xx responses
+	FailedProcesses []DigestProcess
+	Slow            []proxy.HTTPLogEntry // Responses over the slow threshold
+}
+
+// DigestProcess is a managed process that exited with an error.
+type DigestProcess struct {
+	ID       string
+	ExitCode int
+}
+
+// sessionDigest is the digest state of one session.
+type sessionDigest struct {
+	config   DigestConfig
+	since    time.Time // Start of the current window
+	lastSent time.Time
+	sent     int
+}
+
+// status describes the digest for SESSION DIGEST.
+func (sd *sessionDigest) status(code string) map[string]interface{} {
+	status := map[string]interface{}{
+		"session_code": code,
+		"enabled":      true,
+		"config":       sd.config,
+		"next_at":      sd.since.Add(sd.config.interval()).Format(time.RFC3339),
+		"sent":         sd.sent,
+	}
+	if !sd.lastSent.IsZero() {
+		status["last_sent"] = sd.lastSent.Format(time.RFC3339)
+	}
+	return status
+}
+
+// composeDigest summarizes activity in one line, or returns "" when nothing
+// reaches its threshold.
+func composeDigest(config DigestConfig, a *DigestActivity, until time.Time) string {
+	var parts []string
+
+	if n := len(a.Errors) + len(a.ServerErrors); n > 0 && n >= config.MinErrors {
+		counts := make(map[string]int)
+		for _, e := range a.Errors {
+			counts[truncateDigest(e.Message, 80)]++
+		}
+		for _, e := range a.ServerErrors {
+			counts[fmt.Sprintf("%s %s → %d", e.Method, digestPath(e.URL), e.StatusCode)]++
+		}
+		parts = append(parts, fmt.Sprintf("%d new %s (%s)", n, plural(n, "error", "errors"), topCounts(counts)))
+	}
+
+	if n := len(a.FailedProcesses); n > 0 && n >= config.MinFailedProcesses {
+		procs := make([]string, n)
+		for i, p := range a.FailedProcesses {
+			procs[i] = fmt.Sprintf("%s exited %d", p.ID, p.ExitCode)
+		}
+		parts = append(parts, fmt.Sprintf("%d failed %s (%s)", n, plural(n, "process", "processes"), strings.Join(procs, ", ")))
+	}
+
+	if n := len(a.Slow); n > 0 && n >= config.MinSlowRequests {
+		counts := make(map[string]int)
+		slowest := make(map[string]time.Duration)
+		for _, e := range a.Slow {
+			key := e.Method + " " + digestPath(e.URL)
+			counts[key]++
+			if e.Duration > slowest[key] {
+				slowest[key] = e.Duration
+			}
+		}
+		endpoints := topKeys(counts)
+		for i, key := range endpoints {
+			endpoints[i] = fmt.Sprintf("%s up to %s", key, slowest[key].Round(time.Millisecond))
+		}
+		parts = append(parts, fmt.Sprintf("%d slow %s over %dms (%s)", n, plural(n, "request", "requests"), config.SlowMs, strings.Join(endpoints, ", ")))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	window := until.Sub(a.Since).Round(time.Second).String()
+	if until.Sub(a.Since) >= time.Minute {
+		window = strings.TrimSuffix(until.Sub(a.Since).Round(time.Minute).String(), "0s")
+	}
+	return fmt.Sprintf("[agnt digest, last %s] %s. Check proxylog and proc for details.", window, strings.Join(parts, "; "))
+}
+
+// collectDigest gathers the activity of a project since a time from its
+// proxies' traffic logs and its managed processes.
+func (d *Daemon) collectDigest(projectPath string, config DigestConfig, since time.Time) *DigestActivity {
+	a := &DigestActivity{Since: since}
+	projectPath = normalizePath(projectPath)
+	slow := time.Duration(config.SlowMs) * time.Millisecond
+
+	for _, px := range d.proxym.List() {
+		if normalizePath(px.Path) != projectPath {
+			continue
+		}
+		entries := px.Logger().Query(proxy.LogFilter{
+			Types: []proxy.LogEntryType{proxy.LogTypeError, proxy.LogTypeHTTP},
+			Since: &since,
+		})
+		for _, entry := range entries {
+			switch {
+			case entry.Error != nil:
+				a.Errors = append(a.Errors, *entry.Error)
+			case entry.HTTP != nil:
+				if entry.HTTP.StatusCode >= 500 {
+					a.ServerErrors = append(a.ServerErrors, *entry.HTTP)
+				}
+				if entry.HTTP.Duration >= slow {
+					a.Slow = append(a.Slow, *entry.HTTP)
+				}
+			}
+		}
+	}
+
+	for _, p := range d.hub.ProcessManager().List() {
+		if normalizePath(p.ProjectPath) != projectPath || !p.IsDone() || p.ExitCode() == 0 {
+			continue
+		}
+		if end := p.EndTime(); end == nil || end.Before(since) {
+			continue
+		}
+		a.FailedProcesses = append(a.FailedProcesses, DigestProcess{ID: p.ID, ExitCode: p.ExitCode()})
+	}
+	return a
+}
+
+// digestLoop sends each due session digest.
+func (d *Daemon) digestLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(digestScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.sendDueDigests(now)
+		}
+	}
+}
+
+// sendDueDigests composes the digests whose window has ended and delivers
+// the ones with something to report. Digests of sessions that are gone are
+// dropped.
+func (d *Daemon) sendDueDigests(now time.Time) {
+	type due struct {
+		session *Session
+		config  DigestConfig
+		since   time.Time
+	}
+	var list []due
+
+	d.digestMu.Lock()
+	for code, sd := range d.digests {
+		session, ok := d.sessionRegistry.Get(code)
+		if !ok {
+			delete(d.digests, code)
+			continue
+		}
+		if now.Before(sd.since.Add(sd.config.interval())) {
+			continue
+		}
+		list = append(list, due{session, sd.config, sd.since})
+		sd.since = now
+	}
+	d.digestMu.Unlock()
+
+	for _, item := range list {
+		activity := d.collectDigest(item.session.ProjectPath, item.config, item.since)
+		message := composeDigest(item.config, activity, now)
+		if message == "" {
+			continue
+		}
+		if err := d.deliverToSession(item.session, item.config.Deliver, "agnt digest", message); err != nil {
+			log.Printf("[WARN] failed to deliver digest to session %s: %v", item.session.Code, err)
+			continue
+		}
+		d.digestMu.Lock()
+		if sd, ok := d.digests[item.session.Code]; ok {
+			sd.lastSent = now
+			sd.sent++
+		}
+		d.digestMu.Unlock()
+	}
+}
+
+// deliverToSession types a digest or alert into the session's agent and/or
+// shows it as a toast on the project's proxied pages.
+func (d *Daemon) deliverToSession(session *Session, deliver, title, message string) error {
+	if deliver == DigestDeliverToast || deliver == DigestDeliverBoth {
+		projectPath := normalizePath(session.ProjectPath)
+		for _, px := range d.proxym.List() {
+			if normalizePath(px.Path) == projectPath {
+				px.BroadcastToast("warning", title, message, 0)
+			}
+		}
+	}
+	if deliver == DigestDeliverToast {
+		return nil
+	}
+	if session.GetStatus() != SessionStatusActive {
+		return fmt.Errorf("session %q is not active", session.Code)
+	}
+	msg, err := session.typeMessage(message, SendOptions{})
+	if err != nil {
+		return err
+	}
+	return d.sendMessageToOverlay(session.OverlayPath, msg)
+}
+
+// hubHandleSessionDigest handles SESSION DIGEST <code> [off].
+// With a JSON DigestConfig, turns on (or reconfigures) the session's digest;
+// with "off", turns it off; otherwise reports its state and what a digest
+// would say right now.
+func (d *Daemon) hubHandleSessionDigest(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION DIGEST requires: <code>")
+	}
+	code := cmd.Args[0]
+	session, ok := d.sessionRegistry.Get(code)
+	if !ok {
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
+	}
+
+	if len(cmd.Args) > 1 {
+		if !strings.EqualFold(cmd.Args[1], "off") {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("unknown SESSION DIGEST argument %q: use off", cmd.Args[1]))
+		}
+		d.digestMu.Lock()
+		delete(d.digests, code)
+		d.digestMu.Unlock()
+		data, _ := json.Marshal(map[string]interface{}{"session_code": code, "enabled": false})
+		return conn.WriteJSON(data)
+	}
+
+	if len(cmd.Data) > 0 {
+		var config DigestConfig
+		if err := decodeData(cmd, &config); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		if err := config.Validate(); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		d.digestMu.Lock()
+		sd := &sessionDigest{config: config.withDefaults(), since: time.Now()}
+		if old, ok := d.digests[code]; ok {
+			sd.since, sd.lastSent, sd.sent = old.since, old.lastSent, old.sent
+		}
+		d.digests[code] = sd
+		status := sd.status(code)
+		d.digestMu.Unlock()
+		data, _ := json.Marshal(status)
+		return conn.WriteJSON(data)
+	}
+
+	d.digestMu.Lock()
+	sd, ok := d.digests[code]
+	var status map[string]interface{}
+	var config DigestConfig
+	var since time.Time
+	if ok {
+		status, config, since = sd.status(code), sd.config, sd.since
+	}
+	d.digestMu.Unlock()
+	if !ok {
+		data, _ := json.Marshal(map[string]interface{}{"session_code": code, "enabled": false})
+		return conn.WriteJSON(data)
+	}
+	now := time.Now()
+	status["preview"] = composeDigest(config, d.collectDigest(session.ProjectPath, config, since), now)
+	data, _ := json.Marshal(status)
+	return conn.WriteJSON(data)
+}
+
+// digestPath strips the query from a logged URL.
+func digestPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Path == "" {
+		return raw
+	}
+	return u.Path
+}
+
+// topKeys returns the most frequent keys, busiest first.
+func topKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > digestTopN {
+		keys = keys[:digestTopN]
+	}
+	return keys
+}
+
+// topCounts lists the most frequent keys with their counts.
+func topCounts(counts map[string]int) string {
+	keys := topKeys(counts)
+	for i, k := range keys {
+		if n := counts[k]; n > 1 {
+			keys[i] = fmt.Sprintf("%s ×%d", k, n)
+		}
+	}
+	if len(counts) > len(keys) {
+		keys = append(keys, fmt.Sprintf("%d more", len(counts)-len(keys)))
+	}
+	return strings.Join(keys, ", ")
+}
+
+func truncateDigest(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,129 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestComposeDigest(t *testing.T) {
+	now := time.Now()
+	config := DigestConfig{}.withDefaults()
+	activity := &DigestActivity{
+		Since: now.Add(-15 * time.Minute),
+		Errors: []proxy.FrontendError{
+			{Message: "TypeError: x is undefined"},
+			{Message: "TypeError: x is undefined"},
+		},
+		ServerErrors:    []proxy.HTTPLogEntry{{Method: "POST", URL: "/api/orders?id=1", StatusCode: 500}},
+		FailedProcesses: []DigestProcess{{ID: "build", ExitCode: 2}},
+		Slow: []proxy.HTTPLogEntry{
+			{Method: "GET", URL: "/api/users", Duration: 1500 * time.Millisecond},
+			{Method: "GET", URL: "/api/users?page=2", Duration: 2300 * time.Millisecond},
+		},
+	}
+
+	message := composeDigest(config, activity, now)
+	for _, want := range []string{
+		"last 15m]",
+		"3 new errors (TypeError: x is undefined ×2, POST /api/orders → 500)",
+		"1 failed process (build exited 2)",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected %q in %q", want, message)
+		}
+	}
+	if strings.Contains(message, "slow") {
+		t.Errorf("Expected 2 slow requests below the threshold of 3, got %q", message)
+	}
+
+	config.MinSlowRequests = 2
+	config.MinErrors = 5
+	config.MinFailedProcesses = 2
+	message = composeDigest(config, activity, now)
+	if !strings.Contains(message, "2 slow requests over 1000ms (GET /api/users up to 2.3s)") {
+		t.Errorf("Expected the slow endpoint, got %q", message)
+	}
+	if strings.Contains(message, "error") || strings.Contains(message, "process") {
+		t.Errorf("Expected errors and processes below their thresholds left out, got %q", message)
+	}
+
+	if message := composeDigest(config, &DigestActivity{Since: now}, now); message != "" {
+		t.Errorf("Expected a quiet window to compose nothing, got %q", message)
+	}
+
+	if err := (DigestConfig{Deliver: "email"}).Validate(); err == nil {
+		t.Error("Expected error for an unknown delivery target")
+	}
+	if err := (DigestConfig{IntervalMinutes: -1}).Validate(); err == nil {
+		t.Error("Expected error for a negative interval")
+	}
+}
+
+func TestSendDueDigests(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Overlay that records injected messages
+	overlayPath := filepath.Join(tmpDir, "overlay.sock")
+	listener, err := net.Listen("unix", overlayPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	injected := make(chan string, 4)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		injected <- string(body)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	if err := d.sessionRegistry.Register(&Session{Code: "claude-1", OverlayPath: overlayPath, ProjectPath: tmpDir, Status: SessionStatusActive}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer px.Stop(context.Background())
+
+	start := time.Now().Add(-time.Minute)
+	d.digests["claude-1"] = &sessionDigest{config: DigestConfig{IntervalMinutes: 1}.withDefaults(), since: start}
+	d.digests["gone"] = &sessionDigest{config: DigestConfig{}.withDefaults(), since: start}
+
+	// Nothing happened: the window closes without a message
+	d.sendDueDigests(time.Now())
+	if _, ok := d.digests["gone"]; ok {
+		t.Error("Expected the digest of an unknown session dropped")
+	}
+	select {
+	case message := <-injected:
+		t.Fatalf("Expected a quiet digest to stay quiet, got %q", message)
+	default:
+	}
+
+	px.Logger().LogError(proxy.FrontendError{ID: "e1", Timestamp: time.Now(), Message: "boom"})
+	d.sendDueDigests(time.Now().Add(time.Minute))
+
+	select {
+	case message := <-injected:
+		if !strings.Contains(message, "1 new error (boom)") {
+			t.Errorf("Unexpected digest %q", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the digest delivered to the session")
+	}
+	if d.digests["claude-1"].sent != 1 {
+		t.Errorf("Expected 1 digest sent, got %d", d.digests["claude-1"].sent)
+	}
+}
@@ -0,0 +1,339 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/double"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// doubleStartTimeout is how long a double has to start listening.
+const doubleStartTimeout = 5 * time.Second
+
+// runningDouble is a double started by DOUBLE START or autostart.
+type runningDouble struct {
+	Name        string            `json:"name"`
+	Profile     string            `json:"profile"`
+	ProcessID   string            `json:"process_id"`
+	ProjectPath string            `json:"-"`
+	Port        int               `json:"port"`
+	URL         string            `json:"url"`
+	WebhookURL  string            `json:"webhook_url,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+}
+
+// doubleState tracks running doubles by process ID.
+type doubleState struct {
+	mu      sync.Mutex
+	running map[string]*runningDouble
+}
+
+func (s *doubleState) add(rd *runningDouble) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == nil {
+		s.running = make(map[string]*runningDouble)
+	}
+	s.running[rd.ProcessID] = rd
+}
+
+// has reports whether the double of processID is running.
+func (s *doubleState) has(processID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.running[processID]
+	return ok
+}
+
+func (s *doubleState) remove(processID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.running[processID]
+	delete(s.running, processID)
+	return ok
+}
+
+// forgetProject drops a project's doubles, whose processes are stopped with
+// the project's other processes.
+func (s *doubleState) forgetProject(projectPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, rd := range s.running {
+		if rd.ProjectPath == projectPath {
+			delete(s.running, id)
+		}
+	}
+}
+
+// list returns a project's doubles by name.
+func (s *doubleState) list(projectPath string) []runningDouble {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []runningDouble
+	for _, rd := range s.running {
+		if rd.ProjectPath == projectPath {
+			list = append(list, *rd)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// env returns the env of a project's doubles as KEY=VALUE entries, to add
+// to the processes started for it.
+func (s *doubleState) env(projectPath string) []string {
+	var env []string
+	for _, rd := range s.list(projectPath) {
+		for k, v := range rd.Env {
+			env = append(env, k+"="+v)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+// doubleSpec resolves what DOUBLE START runs: the project's doubles block
+// entry of that name, or a built-in profile, with req overriding either.
+func doubleSpec(projectPath, name string, req protocol.DoubleStartConfig) (*config.DoubleConfig, *double.Profile, error) {
+	spec := &config.DoubleConfig{}
+	if cfg, err := config.LoadAgntConfig(projectPath); err == nil && cfg.Doubles[name] != nil {
+		*spec = *cfg.Doubles[name]
+	}
+	if req.Profile != "" {
+		spec.Profile, spec.ProfileFile = req.Profile, ""
+	}
+	if req.ProfileFile != "" {
+		spec.ProfileFile = req.ProfileFile
+	}
+	if req.Port > 0 {
+		spec.Port = req.Port
+	}
+	if req.WebhookURL != "" {
+		spec.WebhookURL = req.WebhookURL
+	}
+	if req.LatencyMs != "" {
+		spec.LatencyMs = req.LatencyMs
+	}
+	if req.Seed != 0 {
+		spec.Seed = req.Seed
+	}
+
+	if spec.ProfileFile != "" {
+		if !filepath.IsAbs(spec.ProfileFile) {
+			spec.ProfileFile = filepath.Join(projectPath, spec.ProfileFile)
+		}
+		p, err := double.LoadProfile(spec.ProfileFile)
+		return spec, p, err
+	}
+	if spec.Profile == "" {
+		spec.Profile = name
+	}
+	p, ok := double.Builtin(spec.Profile)
+	if !ok {
+		return nil, nil, fmt.Errorf("no double %q in %s and no built-in profile %q (built-in: %s)",
+			name, config.AgntConfigFileName, spec.Profile, strings.Join(double.BuiltinNames(), ", "))
+	}
+	return spec, p, nil
+}
+
+// startDouble runs a double as a managed process and waits until it
+// listens.
+func (d *Daemon) startDouble(ctx context.Context, projectPath, name string, req protocol.DoubleStartConfig) (*runningDouble, error) {
+	spec, profile, err := doubleSpec(projectPath, name, req)
+	if err != nil {
+		return nil, err
+	}
+	port := spec.Port
+	if port <= 0 {
+		if port, err = freeLoopbackPort(0); err != nil {
+			return nil, err
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate the agnt binary: %v", err)
+	}
+
+	args := []string{"double"}
+	if spec.ProfileFile != "" {
+		args = append(args, "--profile-file", spec.ProfileFile)
+	} else {
+		args = append(args, spec.Profile)
+	}
+	args = append(args, "--port", strconv.Itoa(port))
+	if spec.WebhookURL != "" {
+		args = append(args, "--webhook-url", spec.WebhookURL)
+	}
+	if spec.LatencyMs != "" {
+		args = append(args, "--latency-ms", spec.LatencyMs)
+	}
+	if spec.Seed != 0 {
+		args = append(args, "--seed", strconv.FormatInt(spec.Seed, 10))
+	}
+
+	processID := makeProcessID(projectPath, "double-"+name)
+	proc, err := d.startFreshProcess(ctx, process.ProcessConfig{
+		ID:          processID,
+		ProjectPath: projectPath,
+		Command:     exe,
+		Args:        args,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	deadline := time.Now().Add(doubleStartTimeout)
+	for {
+		if conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+			conn.Close()
+			break
+		}
+		if proc.IsDone() {
+			out, _ := proc.CombinedOutput()
+			return nil, fmt.Errorf("double exited: %s", strings.TrimSpace(string(out)))
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("double not listening on %d after %s", port, doubleStartTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	url := fmt.Sprintf("http://localhost:%d", port)
+	env := profile.ExpandEnv(url)
+	for k, v := range spec.Env {
+		env[k] = strings.ReplaceAll(v, "{url}", url)
+	}
+	rd := &runningDouble{
+		Name:        name,
+		Profile:     profile.Name,
+		ProcessID:   processID,
+		ProjectPath: projectPath,
+		Port:        port,
+		URL:         url,
+		WebhookURL:  spec.WebhookURL,
+		Env:         env,
+	}
+	d.doubles.add(rd)
+	return rd, nil
+}
+
+// hubHandleDouble handles the DOUBLE command.
+func (d *Daemon) hubHandleDouble(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbStart:
+		return d.hubHandleDoubleStart(ctx, conn, cmd)
+	case protocol.SubVerbStop:
+		return d.hubHandleDoubleStop(ctx, conn, cmd)
+	case protocol.SubVerbList:
+		return d.hubHandleDoubleList(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown DOUBLE sub-command",
+			Command:      protocol.VerbDouble,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbStart, protocol.SubVerbStop, protocol.SubVerbList},
+		})
+	}
+}
+
+// hubHandleDoubleStart handles DOUBLE START <name> [path] with an optional
+// protocol.DoubleStartConfig payload.
+func (d *Daemon) hubHandleDoubleStart(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "double name required")
+	}
+	var req protocol.DoubleStartConfig
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "DOUBLE START requires a session or path")
+	}
+
+	rd, err := d.startDouble(ctx, projectPath, cmd.Args[0], req)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start double: %v", err))
+	}
+	data, _ := json.Marshal(rd)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleDoubleStop handles DOUBLE STOP <name> [path].
+func (d *Daemon) hubHandleDoubleStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "double name required")
+	}
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	processID := makeProcessID(d.remoteProjectPath(conn, path), "double-"+cmd.Args[0])
+	if !d.doubles.remove(processID) {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("double %q is not running", cmd.Args[0]))
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := d.hub.ProcessManager().Stop(stopCtx, processID); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to stop double: %v", err))
+	}
+	return conn.WriteOK(fmt.Sprintf("stopped %s", cmd.Args[0]))
+}
+
+// hubHandleDoubleList handles DOUBLE LIST [path]: the available profiles,
+// the project's configured and running doubles, and the env they export.
+func (d *Daemon) hubHandleDoubleList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+
+	var profiles []map[string]string
+	for _, name := range double.BuiltinNames() {
+		p, _ := double.Builtin(name)
+		profiles = append(profiles, map[string]string{"name": name, "description": p.Description})
+	}
+	var configured []string
+	if projectPath != "" {
+		if cfg, err := config.LoadAgntConfig(projectPath); err == nil {
+			for name := range cfg.Doubles {
+				configured = append(configured, name)
+			}
+			sort.Strings(configured)
+		}
+	}
+
+	env := make(map[string]string)
+	for _, kv := range d.doubles.env(projectPath) {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"profiles":   profiles,
+		"configured": configured,
+		"running":    d.doubles.list(projectPath),
+		"env":        env,
+	})
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+func TestDoubleSpec(t *testing.T) {
+	dir := t.TempDir()
+	kdl := `doubles {
+    payments {
+        profile "stripe"
+        webhook-url "http://localhost:3000/hooks"
+    }
+    github {
+        profile-file "github.json"
+    }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, ".agnt.kdl"), []byte(kdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "github.json"), []byte(`{"routes": [{"method": "GET", "path": "/user", "body": "{}"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, p, err := doubleSpec(dir, "payments", protocol.DoubleStartConfig{Seed: 7})
+	if err != nil {
+		t.Fatalf("doubleSpec failed: %v", err)
+	}
+	if p.Name != "stripe" || spec.WebhookURL != "http://localhost:3000/hooks" || spec.Seed != 7 {
+		t.Errorf("Unexpected spec %+v for profile %s", spec, p.Name)
+	}
+
+	spec, p, err = doubleSpec(dir, "github", protocol.DoubleStartConfig{})
+	if err != nil {
+		t.Fatalf("doubleSpec failed: %v", err)
+	}
+	if p.Name != "github" || spec.ProfileFile != filepath.Join(dir, "github.json") {
+		t.Errorf("Unexpected spec %+v for profile %s", spec, p.Name)
+	}
+
+	if _, p, err = doubleSpec(dir, "auth0", protocol.DoubleStartConfig{}); err != nil || p.Name != "auth0" {
+		t.Errorf("Expected the built-in auth0 profile, got %v", err)
+	}
+	if _, _, err = doubleSpec(dir, "paypal", protocol.DoubleStartConfig{}); err == nil || !strings.Contains(err.Error(), "stripe") {
+		t.Errorf("Expected an unknown profile error listing built-ins, got %v", err)
+	}
+}
+
+func TestDoubleStateEnv(t *testing.T) {
+	var s doubleState
+	s.add(&runningDouble{Name: "stripe", ProcessID: "p:double-stripe", ProjectPath: "/p", Env: map[string]string{"STRIPE_API_BASE": "http://localhost:1"}})
+	s.add(&runningDouble{Name: "rest", ProcessID: "p:double-rest", ProjectPath: "/p", Env: map[string]string{"API_BASE_URL": "http://localhost:2"}})
+	s.add(&runningDouble{Name: "rest", ProcessID: "q:double-rest", ProjectPath: "/q", Env: map[string]string{"API_BASE_URL": "http://localhost:3"}})
+
+	env := s.env("/p")
+	if strings.Join(env, " ") != "API_BASE_URL=http://localhost:2 STRIPE_API_BASE=http://localhost:1" {
+		t.Errorf("Unexpected env %v", env)
+	}
+	if !s.remove("p:double-rest") || s.remove("p:double-rest") {
+		t.Error("Expected remove to report whether the double was running")
+	}
+	s.forgetProject("/q")
+	if len(s.list("/q")) != 0 || len(s.list("/p")) != 1 {
+		t.Errorf("Unexpected doubles after forgetProject: %v %v", s.list("/p"), s.list("/q"))
+	}
+}
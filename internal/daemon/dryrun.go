@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+)
+
+// writeDryRun writes the response of a dry run: the affected entities plus
+// command-specific fields.
+func writeDryRun(conn *hubpkg.Connection, affected []protocol.AffectedEntity, fields map[string]interface{}) error {
+	resp := map[string]interface{}{
+		"dry_run":  true,
+		"count":    len(affected),
+		"affected": affected,
+	}
+	for k, v := range fields {
+		resp[k] = v
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// portAffected returns the processes listening on port, as PROC
+// CLEANUP-PORT would kill them.
+func (d *Daemon) portAffected(ctx context.Context, port int) []protocol.AffectedEntity {
+	affected := []protocol.AffectedEntity{}
+	for _, pid := range findProcessesByPort(ctx, port) {
+		detail := "unmanaged"
+		if d.hub.ProcessManager().IsManagedPID(pid) {
+			detail = "managed by agnt"
+		}
+		affected = append(affected, protocol.AffectedEntity{Kind: "pid", ID: strconv.Itoa(pid), Detail: detail})
+	}
+	return affected
+}
+
+// stopAllAffected returns every process, proxy and tunnel, as STOP-ALL would
+// stop them.
+func (d *Daemon) stopAllAffected() []protocol.AffectedEntity {
+	affected := []protocol.AffectedEntity{}
+	for _, p := range d.hub.ProcessManager().List() {
+		affected = append(affected, protocol.AffectedEntity{Kind: depgraph.KindProcess, ID: p.ID, Detail: p.State().String()})
+	}
+	for _, p := range d.proxym.List() {
+		affected = append(affected, protocol.AffectedEntity{Kind: depgraph.KindProxy, ID: p.ID, Detail: p.Target().String()})
+	}
+	for _, t := range d.tunnelm.List() {
+		affected = append(affected, protocol.AffectedEntity{Kind: depgraph.KindTunnel, ID: t.ID, Detail: t.PublicURL})
+	}
+	return affected
+}
+
+// chaosRulesAffected returns the chaos rules of a proxy, as replacing or
+// clearing its chaos configuration would remove them.
+func chaosRulesAffected(p *proxy.ProxyServer) []protocol.AffectedEntity {
+	affected := []protocol.AffectedEntity{}
+	if config := p.ChaosEngine().GetConfig(); config != nil {
+		for _, rule := range config.Rules {
+			affected = append(affected, protocol.AffectedEntity{Kind: "chaos_rule", ID: rule.ID, Detail: string(rule.Type)})
+		}
+	}
+	return affected
+}
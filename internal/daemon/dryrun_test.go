@@ -0,0 +1,31 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestChaosRulesAffected(t *testing.T) {
+	ps, err := proxy.NewProxyServer(proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: 8080})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	if affected := chaosRulesAffected(ps); len(affected) != 0 {
+		t.Errorf("Expected no rules before chaos is set, got %v", affected)
+	}
+
+	preset := proxy.GetPreset("flaky-api")
+	if err := ps.ChaosEngine().SetConfig(preset); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	affected := chaosRulesAffected(ps)
+	if len(affected) != len(preset.Rules) {
+		t.Fatalf("Expected %d rules, got %v", len(preset.Rules), affected)
+	}
+	for i, a := range affected {
+		if a.Kind != "chaos_rule" || a.ID != preset.Rules[i].ID || a.Detail != string(preset.Rules[i].Type) {
+			t.Errorf("affected[%d] = %+v, want rule %s", i, a, preset.Rules[i].ID)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// Types of DaemonEvent.
+const (
+	EventProcessStarted      = "process.started"
+	EventProcessExited       = "process.exited"
+	EventProxyCreated        = "proxy.created"
+	EventProxyStopped        = "proxy.stopped"
+	EventProxyUnreachable    = "proxy.unreachable"
+	EventTunnelURL           = "tunnel.url"
+	EventSessionRegistered   = "session.registered"
+	EventSessionUnregistered = "session.unregistered"
+	EventChaosEnabled        = "chaos.enabled"
+	EventChaosDisabled       = "chaos.disabled"
+	EventConfigReloaded      = "config.reloaded"
+)
+
+var daemonEventTypes = []string{
+	EventProcessStarted, EventProcessExited,
+	EventProxyCreated, EventProxyStopped, EventProxyUnreachable,
+	EventTunnelURL,
+	EventSessionRegistered, EventSessionUnregistered,
+	EventChaosEnabled, EventChaosDisabled,
+	EventConfigReloaded,
+}
+
+const (
+	// eventLogSize is how many events the daemon keeps.
+	eventLogSize = 1000
+	// defaultEventsLimit caps an EVENTS QUERY without limit.
+	defaultEventsLimit = 100
+
+	// DefaultEventsSubscribeTimeout bounds an EVENTS SUBSCRIBE without timeout_ms.
+	DefaultEventsSubscribeTimeout = time.Minute
+	// MaxEventsSubscribeTimeout bounds any EVENTS SUBSCRIBE.
+	MaxEventsSubscribeTimeout = 10 * time.Minute
+)
+
+// DaemonEvent is something significant the daemon did or saw: a process or
+// proxy coming and going, a tunnel getting its URL, a session registering.
+type DaemonEvent struct {
+	Seq     int64                  `json:"seq"`
+	Time    time.Time              `json:"time"`
+	Type    string                 `json:"type"`
+	Source  string                 `json:"source"`         // Process, proxy, tunnel or session ID
+	Path    string                 `json:"path,omitempty"` // Project path
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+	Labels  protocol.Labels        `json:"labels,omitempty"` // Labels of the source when recorded
+}
+
+// eventSourceKind returns the depgraph kind of an event's source, or "" for
+// sources that can't have labels.
+func eventSourceKind(eventType string) string {
+	family, _, _ := strings.Cut(eventType, ".")
+	switch family {
+	case depgraph.KindProcess, depgraph.KindProxy, depgraph.KindTunnel:
+		return family
+	case "chaos":
+		return depgraph.KindProxy
+	}
+	return ""
+}
+
+// eventLog keeps the last eventLogSize daemon events in order. Readers
+// wait on changed, which is closed and replaced by each record.
+type eventLog struct {
+	mu      sync.Mutex
+	events  []DaemonEvent
+	seq     int64
+	changed chan struct{}
+
+	// labels returns the labels of an entity, stamped on its events so
+	// they match label filters after the entity is gone.
+	labels func(kind, id string) protocol.Labels
+}
+
+// record stamps e with the next sequence number and its source's labels
+// and appends it.
+func (l *eventLog) record(e DaemonEvent) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if kind := eventSourceKind(e.Type); kind != "" && e.Labels == nil && l.labels != nil {
+		e.Labels = l.labels(kind, e.Source)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	e.Seq = l.seq
+	l.events = append(l.events, e)
+	if len(l.events) > eventLogSize {
+		l.events = l.events[len(l.events)-eventLogSize:]
+	}
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+// since returns the events after seq that match accepts, oldest first, the
+// sequence number of the newest event, and a channel closed once another
+// event is recorded.
+func (l *eventLog) since(seq int64, match func(DaemonEvent) bool) ([]DaemonEvent, int64, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var events []DaemonEvent
+	for _, e := range l.events {
+		if e.Seq > seq && match(e) {
+			events = append(events, e)
+		}
+	}
+	if l.changed == nil {
+		l.changed = make(chan struct{})
+	}
+	return events, l.seq, l.changed
+}
+
+// last returns the sequence number of the newest event.
+func (l *eventLog) last() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq
+}
+
+// daemonEventMatcher returns a function accepting the events that pass
+// filter. Events outside projectPath are left out unless filter.Global.
+func daemonEventMatcher(filter protocol.EventsFilter, projectPath string) (func(DaemonEvent) bool, error) {
+	for _, t := range filter.Types {
+		if !slices.Contains(daemonEventTypes, t) && !slices.ContainsFunc(daemonEventTypes, func(known string) bool {
+			return strings.HasPrefix(known, t+".")
+		}) {
+			return nil, fmt.Errorf("unknown event type %q (use %s, or a family like process)", t, strings.Join(daemonEventTypes, ", "))
+		}
+	}
+	if err := filter.Labels.Validate(); err != nil {
+		return nil, err
+	}
+	if !filter.Global && projectPath != "" {
+		projectPath = normalizePath(projectPath)
+	} else {
+		projectPath = ""
+	}
+
+	return func(e DaemonEvent) bool {
+		if len(filter.Types) > 0 && !slices.ContainsFunc(filter.Types, func(t string) bool {
+			return e.Type == t || strings.HasPrefix(e.Type, t+".")
+		}) {
+			return false
+		}
+		if projectPath != "" && normalizePath(e.Path) != projectPath {
+			return false
+		}
+		if filter.Source != "" {
+			if rank, _ := matchEntityID(filter.Source, e.Source); rank > matchComponent {
+				return false
+			}
+		}
+		if len(filter.Labels) > 0 && !e.Labels.Matches(filter.Labels) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// recordProxyLifecycle is the proxies' lifecycle hook.
+func (d *Daemon) recordProxyLifecycle(ps *proxy.ProxyServer, running bool) {
+	e := DaemonEvent{
+		Type:   EventProxyStopped,
+		Source: ps.ID,
+		Path:   ps.Path,
+		Data:   map[string]interface{}{"target_url": ps.Target().String(), "listen_addr": ps.ListenAddr},
+	}
+	e.Message = fmt.Sprintf("proxy %s stopped", ps.ID)
+	if running {
+		e.Type = EventProxyCreated
+		e.Message = fmt.Sprintf("proxy %s started on %s for %s", ps.ID, ps.ListenAddr, ps.Target())
+	} else {
+		d.unreachableSeen.Delete(ps.ID)
+	}
+	d.eventLog.record(e)
+	if !running {
+		// After the record, so proxy.stopped still carries them
+		d.labels.remove(depgraph.KindProxy, ps.ID)
+	}
+}
+
+// recordUnreachable records the start of each outage of a proxy's target,
+// once: the proxy marks the target unreachable on its first refused
+// connection and clears the mark on the next response.
+func (d *Daemon) recordUnreachable(ps *proxy.ProxyServer, h *proxy.HTTPLogEntry) {
+	since := ps.TargetUnreachableSince()
+	if since.IsZero() {
+		return
+	}
+	if prev, loaded := d.unreachableSeen.Swap(ps.ID, since); loaded && prev.(time.Time).Equal(since) {
+		return
+	}
+	d.eventLog.record(DaemonEvent{
+		Type:    EventProxyUnreachable,
+		Time:    since,
+		Source:  ps.ID,
+		Path:    ps.Path,
+		Message: fmt.Sprintf("proxy %s can't reach %s: %s", ps.ID, ps.Target(), h.Error),
+		Data:    map[string]interface{}{"target_url": ps.Target().String(), "error": h.Error},
+	})
+}
+
+// processStartedEvent describes a process run the crash scan saw first.
+func processStartedEvent(p *process.ManagedProcess) DaemonEvent {
+	e := DaemonEvent{
+		Type:    EventProcessStarted,
+		Source:  p.ID,
+		Path:    p.ProjectPath,
+		Message: fmt.Sprintf("process %s started: %s", p.ID, strings.Join(append([]string{p.Command}, p.Args...), " ")),
+		Data:    map[string]interface{}{"pid": p.PID()},
+	}
+	if start := p.StartTime(); start != nil {
+		e.Time = *start
+	}
+	return e
+}
+
+// processExitedEvent describes a process run that ended.
+func processExitedEvent(p *process.ManagedProcess) DaemonEvent {
+	e := DaemonEvent{
+		Type:    EventProcessExited,
+		Source:  p.ID,
+		Path:    p.ProjectPath,
+		Message: fmt.Sprintf("process %s exited with code %d", p.ID, p.ExitCode()),
+		Data:    map[string]interface{}{"exit_code": p.ExitCode(), "state": p.State().String()},
+	}
+	if end := p.EndTime(); end != nil {
+		e.Time = *end
+	}
+	return e
+}
+
+// hubHandleEvents handles the EVENTS command.
+func (d *Daemon) hubHandleEvents(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbQuery:
+		return d.hubHandleEventsQuery(conn, cmd)
+	case protocol.SubVerbSubscribe:
+		return d.hubHandleEventsSubscribe(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown EVENTS action",
+			Command:      protocol.VerbEvents,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbQuery, protocol.SubVerbSubscribe},
+		})
+	}
+}
+
+// eventsMatcher returns the matcher of an EVENTS filter, scoped to the
+// session's project.
+func (d *Daemon) eventsMatcher(conn *hubpkg.Connection, filter protocol.EventsFilter) (func(DaemonEvent) bool, error) {
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && filter.Path != "" {
+		projectPath = filter.Path
+	}
+	return daemonEventMatcher(filter, projectPath)
+}
+
+// hubHandleEventsQuery handles EVENTS QUERY: the newest matching events,
+// oldest first, with the sequence number to pass as since next time.
+func (d *Daemon) hubHandleEventsQuery(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var filter protocol.EventsFilter
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	match, err := d.eventsMatcher(conn, filter)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultEventsLimit
+	}
+	events, last, _ := d.eventLog.since(filter.Since, match)
+	truncated := len(events) > limit
+	if truncated {
+		events = events[len(events)-limit:]
+	}
+	if events == nil {
+		events = []DaemonEvent{}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"events":    events,
+		"count":     len(events),
+		"truncated": truncated,
+		"last_seq":  last,
+	})
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	return conn.WriteJSON(data)
+}
+
+// hubHandleEventsSubscribe handles EVENTS SUBSCRIBE: a CHUNK of
+// newline-delimited JSON per batch of matching events, starting after since
+// (or with the next event), until limit events were sent, timeout_ms
+// passes or the client goes away.
+func (d *Daemon) hubHandleEventsSubscribe(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var filter protocol.EventsFilter
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	match, err := d.eventsMatcher(conn, filter)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	cursor := filter.Since
+	if cursor <= 0 {
+		cursor = d.eventLog.last()
+	}
+	timeout := DefaultEventsSubscribeTimeout
+	if filter.TimeoutMs > 0 {
+		timeout = min(time.Duration(filter.TimeoutMs)*time.Millisecond, MaxEventsSubscribeTimeout)
+	}
+	debug.Log("daemon", "EVENTS SUBSCRIBE: types=%v source=%q labels=%s since=%d timeout=%s", filter.Types, filter.Source, filter.Labels, cursor, timeout)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	sent := 0
+	for {
+		events, last, changed := d.eventLog.since(cursor, match)
+		// Events that don't match move the cursor on too
+		cursor = last
+		if filter.Limit > 0 && sent+len(events) > filter.Limit {
+			events = events[:filter.Limit-sent]
+		}
+		if len(events) > 0 {
+			var buf strings.Builder
+			for _, e := range events {
+				line, err := json.Marshal(e)
+				if err != nil {
+					return conn.WriteErr(hubproto.ErrInternal, err.Error())
+				}
+				buf.Write(line)
+				buf.WriteByte('\n')
+			}
+			if err := conn.WriteChunk([]byte(buf.String())); err != nil {
+				return err // Client gone
+			}
+			sent += len(events)
+		}
+		if filter.Limit > 0 && sent >= filter.Limit {
+			return conn.WriteEnd()
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return conn.WriteEnd()
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-d.ctx.Done():
+			return conn.WriteEnd()
+		}
+	}
+}
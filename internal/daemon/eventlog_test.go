@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestEventLog(t *testing.T) {
+	var l eventLog
+	all := func(DaemonEvent) bool { return true }
+
+	events, last, changed := l.since(0, all)
+	if len(events) != 0 || last != 0 {
+		t.Fatalf("Expected an empty log, got %d events, last %d", len(events), last)
+	}
+	l.record(DaemonEvent{Type: EventProxyCreated, Source: "app"})
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Expected a record to wake readers")
+	}
+
+	for i := 0; i < eventLogSize+10; i++ {
+		l.record(DaemonEvent{Type: EventProcessStarted, Source: "dev"})
+	}
+	events, last, _ = l.since(0, all)
+	if len(events) != eventLogSize {
+		t.Errorf("Expected the log capped at %d, got %d", eventLogSize, len(events))
+	}
+	if last != eventLogSize+11 || events[len(events)-1].Seq != last {
+		t.Errorf("last = %d, newest seq = %d", last, events[len(events)-1].Seq)
+	}
+	if events[0].Seq != 12 {
+		t.Errorf("Expected the oldest events dropped, first seq %d", events[0].Seq)
+	}
+
+	events, _, _ = l.since(last-2, all)
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events after %d, got %d", last-2, len(events))
+	}
+}
+
+func TestDaemonEventMatcher(t *testing.T) {
+	events := []DaemonEvent{
+		{Type: EventProcessStarted, Source: "abc:dev", Path: "/app", Labels: protocol.Labels{"area": "checkout"}},
+		{Type: EventProcessExited, Source: "abc:dev", Path: "/app"},
+		{Type: EventProxyCreated, Source: "abc:web:3000", Path: "/app"},
+		{Type: EventTunnelURL, Source: "share", Path: "/other"},
+	}
+	count := func(filter protocol.EventsFilter, projectPath string) int {
+		t.Helper()
+		match, err := daemonEventMatcher(filter, projectPath)
+		if err != nil {
+			t.Fatalf("daemonEventMatcher(%+v) failed: %v", filter, err)
+		}
+		n := 0
+		for _, e := range events {
+			if match(e) {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := count(protocol.EventsFilter{}, "/app"); n != 3 {
+		t.Errorf("Expected the project's 3 events, got %d", n)
+	}
+	if n := count(protocol.EventsFilter{Global: true}, "/app"); n != 4 {
+		t.Errorf("Expected all 4 events with global, got %d", n)
+	}
+	if n := count(protocol.EventsFilter{Types: []string{"process"}}, "/app"); n != 2 {
+		t.Errorf("Expected a family to match its 2 events, got %d", n)
+	}
+	if n := count(protocol.EventsFilter{Types: []string{EventProcessExited}}, "/app"); n != 1 {
+		t.Errorf("Expected 1 process.exited, got %d", n)
+	}
+	if n := count(protocol.EventsFilter{Source: "web"}, "/app"); n != 1 {
+		t.Errorf("Expected an ID part to match the proxy, got %d", n)
+	}
+	if n := count(protocol.EventsFilter{Labels: protocol.Labels{"area": "checkout"}}, "/app"); n != 1 {
+		t.Errorf("Expected 1 event with the label, got %d", n)
+	}
+	if n := count(protocol.EventsFilter{Labels: protocol.Labels{"area": "search"}}, "/app"); n != 0 {
+		t.Errorf("Expected no event with another value, got %d", n)
+	}
+	if _, err := daemonEventMatcher(protocol.EventsFilter{Types: []string{"proc"}}, ""); err == nil {
+		t.Error("Expected an unknown type rejected")
+	}
+	if _, err := daemonEventMatcher(protocol.EventsFilter{Labels: protocol.Labels{"bad key": "x"}}, ""); err == nil {
+		t.Error("Expected an invalid label selector rejected")
+	}
+}
+
+func TestProxyLifecycleEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	d.labels.update(depgraph.KindProxy, px.ID, protocol.Labels{"area": "checkout"}, nil)
+	if err := d.proxym.Stop(context.Background(), px.ID); err != nil {
+		t.Fatalf("Failed to stop proxy: %v", err)
+	}
+
+	match, _ := daemonEventMatcher(protocol.EventsFilter{Types: []string{"proxy"}}, tmpDir)
+	events, _, _ := d.eventLog.since(0, match)
+	if len(events) != 2 || events[0].Type != EventProxyCreated || events[1].Type != EventProxyStopped {
+		t.Fatalf("Expected proxy.created then proxy.stopped, got %+v", events)
+	}
+	if events[0].Data["target_url"] != "http://localhost:3000" || events[0].Time.After(time.Now()) {
+		t.Errorf("Unexpected created event: %+v", events[0])
+	}
+
+	// Events carry the labels the proxy had when they were recorded
+	match, _ = daemonEventMatcher(protocol.EventsFilter{Labels: protocol.Labels{"area": "checkout"}}, tmpDir)
+	events, _, _ = d.eventLog.since(0, match)
+	if len(events) != 1 || events[0].Type != EventProxyStopped {
+		t.Errorf("Expected only the labeled proxy.stopped, got %+v", events)
+	}
+}
@@ -0,0 +1,342 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/crash"
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// Kinds of debugEvent.
+const (
+	eventFrontendError  = "frontend_error"
+	eventHTTPError      = "http_5xx"
+	eventProcessFailure = "process_failure"
+)
+
+var eventKinds = []string{eventFrontendError, eventHTTPError, eventProcessFailure}
+
+const (
+	// DefaultWaitErrorTimeout bounds a WAIT ERROR without timeout_ms.
+	DefaultWaitErrorTimeout = time.Minute
+	// MaxWaitErrorTimeout bounds any WAIT ERROR.
+	MaxWaitErrorTimeout = 5 * time.Minute
+
+	// waitContextWindow is how far before a proxy event its context reaches.
+	waitContextWindow = 15 * time.Second
+	// waitContextEntries caps the failed requests and interactions returned.
+	waitContextEntries = 10
+	// waitStderrLines is how much of a failed process's stderr is returned.
+	waitStderrLines = 20
+)
+
+// debugEvent is an error worth an agent's attention: a frontend error or a
+// 5xx response a proxy logged, or a process that failed.
+type debugEvent struct {
+	Kind     string               `json:"kind"`
+	Time     time.Time            `json:"time"`
+	Source   string               `json:"source"`         // Proxy or process ID
+	Path     string               `json:"path,omitempty"` // Project path
+	Message  string               `json:"message"`
+	Error    *proxy.FrontendError `json:"error,omitempty"`
+	HTTP     *proxy.HTTPLogEntry  `json:"http,omitempty"`
+	ExitCode int                  `json:"exit_code,omitempty"`
+	Crash    *crash.Report        `json:"crash,omitempty"`
+	Stderr   []string             `json:"stderr,omitempty"` // Last lines of a failed process
+	// Failed requests and interactions before a proxy event, oldest first
+	Recent []proxy.LogEntry `json:"recent,omitempty"`
+
+	proxy *proxy.ProxyServer
+}
+
+// eventBus hands debug events to the WAIT ERROR commands waiting for them.
+// Publishers check active first, so the bus costs nothing without waiters.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan debugEvent]func(debugEvent) bool
+	n    atomic.Int32
+}
+
+// subscribe returns a channel receiving the events match accepts, and a
+// function ending the subscription.
+func (b *eventBus) subscribe(match func(debugEvent) bool) (<-chan debugEvent, func()) {
+	ch := make(chan debugEvent, 1)
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[chan debugEvent]func(debugEvent) bool)
+	}
+	b.subs[ch] = match
+	b.n.Add(1)
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			b.n.Add(-1)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// active reports whether anyone is subscribed.
+func (b *eventBus) active() bool {
+	return b.n.Load() > 0
+}
+
+// publish sends e to the matching subscribers. A subscriber still holding
+// an earlier event misses it; each waits for one event only.
+func (b *eventBus) publish(e debugEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, match := range b.subs {
+		if match(e) {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+// publishProxyEntry is the proxies' log hook: it publishes frontend errors
+// and 5xx responses, counts them against alert rules, and records targets
+// going unreachable in the EVENTS log.
+func (d *Daemon) publishProxyEntry(ps *proxy.ProxyServer, entry proxy.LogEntry) {
+	if entry.Type == proxy.LogTypeHTTP && entry.HTTP != nil && entry.HTTP.Error != "" {
+		d.recordUnreachable(ps, entry.HTTP)
+	}
+	if !d.events.active() && !d.alerts.active() {
+		return
+	}
+	e := debugEvent{Source: ps.ID, Path: ps.Path, proxy: ps}
+	switch {
+	case entry.Type == proxy.LogTypeError && entry.Error != nil:
+		e.Kind, e.Time, e.Error = eventFrontendError, entry.Error.Timestamp, entry.Error
+		e.Message = entry.Error.Message
+	case entry.Type == proxy.LogTypeHTTP && entry.HTTP != nil && isServerError(entry.HTTP):
+		e.Kind, e.Time, e.HTTP = eventHTTPError, entry.HTTP.Timestamp, entry.HTTP
+		e.Message = describeRequest(entry.HTTP)
+	default:
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if d.events.active() {
+		d.events.publish(e)
+	}
+	if d.alerts.active() {
+		d.observeAlert(e)
+	}
+}
+
+// isServerError reports whether a proxied request got a 5xx response or
+// none at all.
+func isServerError(h *proxy.HTTPLogEntry) bool {
+	return h.StatusCode >= 500 || (h.StatusCode == 0 && h.Error != "")
+}
+
+// describeRequest renders a request as "GET /path → 502".
+func describeRequest(h *proxy.HTTPLogEntry) string {
+	target := h.URL
+	if u, err := url.Parse(h.URL); err == nil && u.Path != "" {
+		target = u.RequestURI()
+	}
+	if h.StatusCode == 0 && h.Error != "" {
+		return fmt.Sprintf("%s %s → %s", h.Method, target, h.Error)
+	}
+	return fmt.Sprintf("%s %s → %d", h.Method, target, h.StatusCode)
+}
+
+// processFailureEvent describes a process that exited with a failure, and
+// its crash report if it crashed.
+func processFailureEvent(p *process.ManagedProcess, r *crash.Report) debugEvent {
+	e := debugEvent{
+		Kind:     eventProcessFailure,
+		Time:     time.Now(),
+		Source:   p.ID,
+		Path:     p.ProjectPath,
+		ExitCode: p.ExitCode(),
+		Crash:    r,
+		Message:  fmt.Sprintf("process %s exited with code %d", p.ID, p.ExitCode()),
+	}
+	if end := p.EndTime(); end != nil {
+		e.Time = *end
+	}
+	if r != nil {
+		e.Message = fmt.Sprintf("process %s crashed (%s)", p.ID, r.Kind)
+		if r.Message != "" {
+			e.Message += ": " + r.Message
+		}
+	}
+	if stderr, _ := p.Stderr(); len(stderr) > 0 {
+		lines := strings.Split(strings.TrimRight(string(stderr), "\n"), "\n")
+		if len(lines) > waitStderrLines {
+			lines = lines[len(lines)-waitStderrLines:]
+		}
+		e.Stderr = lines
+	}
+	return e
+}
+
+// addRecent fills in the failed requests and interactions the proxy logged
+// shortly before the event.
+func (e *debugEvent) addRecent() {
+	if e.proxy == nil {
+		return
+	}
+	since := e.Time.Add(-waitContextWindow)
+	entries := e.proxy.Logger().Query(proxy.LogFilter{
+		Types: []proxy.LogEntryType{proxy.LogTypeHTTP, proxy.LogTypeInteraction},
+		Since: &since,
+		Until: &e.Time,
+	})
+	var recent []proxy.LogEntry
+	for _, entry := range entries {
+		switch {
+		case entry.HTTP != nil:
+			if (e.HTTP != nil && entry.HTTP.ID == e.HTTP.ID) || (entry.HTTP.StatusCode < 400 && entry.HTTP.Error == "") {
+				continue
+			}
+		case entry.Interaction == nil:
+			continue
+		}
+		recent = append(recent, entry)
+	}
+	if len(recent) > waitContextEntries {
+		recent = recent[len(recent)-waitContextEntries:]
+	}
+	e.Recent = recent
+}
+
+// eventMatcher builds the filter of a WAIT ERROR: kinds, source, a pattern
+// over the message and URL, and unless global the project.
+func eventMatcher(config protocol.WaitErrorConfig, projectPath string) (func(debugEvent) bool, error) {
+	for _, kind := range config.Kinds {
+		if !slices.Contains(eventKinds, kind) {
+			return nil, fmt.Errorf("unknown kind %q (use %s)", kind, strings.Join(eventKinds, ", "))
+		}
+	}
+	var pattern *regexp.Regexp
+	if config.Match != "" {
+		re, err := regexp.Compile(config.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern: %w", err)
+		}
+		pattern = re
+	}
+	if !config.Global && projectPath != "" {
+		projectPath = normalizePath(projectPath)
+	} else {
+		projectPath = ""
+	}
+
+	return func(e debugEvent) bool {
+		if len(config.Kinds) > 0 && !slices.Contains(config.Kinds, e.Kind) {
+			return false
+		}
+		if projectPath != "" && normalizePath(e.Path) != projectPath {
+			return false
+		}
+		if config.Source != "" {
+			if rank, _ := matchEntityID(config.Source, e.Source); rank > matchComponent {
+				return false
+			}
+		}
+		if pattern != nil {
+			text := e.Message
+			switch {
+			case e.Error != nil:
+				text += "\n" + e.Error.URL + "\n" + e.Error.Stack
+			case e.HTTP != nil:
+				text += "\n" + e.HTTP.URL
+			}
+			if !pattern.MatchString(text) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// hubHandleWait handles the WAIT command and its sub-verbs.
+func (d *Daemon) hubHandleWait(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbError:
+		return d.hubHandleWaitError(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown WAIT action",
+			Command:      protocol.VerbWait,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbError},
+		})
+	}
+}
+
+// hubHandleWaitError handles WAIT ERROR: it blocks until the next frontend
+// error, 5xx response or process failure matching the filter, and returns
+// it with what led up to it, or reports a timeout.
+func (d *Daemon) hubHandleWaitError(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var config protocol.WaitErrorConfig
+	if err := decodeData(cmd, &config); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && config.Path != "" {
+		projectPath = config.Path
+	}
+	match, err := eventMatcher(config, projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	timeout := DefaultWaitErrorTimeout
+	if config.TimeoutMs > 0 {
+		timeout = min(time.Duration(config.TimeoutMs)*time.Millisecond, MaxWaitErrorTimeout)
+	}
+	debug.Log("daemon", "WAIT ERROR: kinds=%v source=%q timeout=%s", config.Kinds, config.Source, timeout)
+
+	events, unsubscribe := d.events.subscribe(match)
+	defer unsubscribe()
+
+	start := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	resp := map[string]interface{}{}
+	select {
+	case e := <-events:
+		e.addRecent()
+		resp["event"] = e
+	case <-timer.C:
+		resp["timed_out"] = true
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.ctx.Done():
+		return conn.WriteErr(hubproto.ErrInvalidState, "daemon shutting down")
+	}
+	resp["waited_ms"] = time.Since(start).Milliseconds()
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestEventMatcher(t *testing.T) {
+	frontend := debugEvent{
+		Kind:    eventFrontendError,
+		Source:  "web-0001:dev",
+		Path:    "/src/web",
+		Message: "TypeError: cart is undefined",
+		Error:   &proxy.FrontendError{URL: "http://localhost:3000/checkout"},
+	}
+	failure := debugEvent{Kind: eventProcessFailure, Source: "api-0002:server", Path: "/src/api", Message: "process api-0002:server exited with code 1"}
+
+	tests := []struct {
+		name   string
+		config protocol.WaitErrorConfig
+		path   string
+		want   [2]bool
+	}{
+		{"all of the project", protocol.WaitErrorConfig{}, "/src/web", [2]bool{true, false}},
+		{"global", protocol.WaitErrorConfig{Global: true}, "/src/web", [2]bool{true, true}},
+		{"kind", protocol.WaitErrorConfig{Kinds: []string{eventProcessFailure}}, "", [2]bool{false, true}},
+		{"source part", protocol.WaitErrorConfig{Source: "server"}, "", [2]bool{false, true}},
+		{"match page URL", protocol.WaitErrorConfig{Match: "checkout"}, "", [2]bool{true, false}},
+	}
+	for _, tt := range tests {
+		match, err := eventMatcher(tt.config, tt.path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := [2]bool{match(frontend), match(failure)}; got != tt.want {
+			t.Errorf("%s: matched %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := eventMatcher(protocol.WaitErrorConfig{Kinds: []string{"warning"}}, ""); err == nil {
+		t.Error("unknown kind accepted")
+	}
+	if _, err := eventMatcher(protocol.WaitErrorConfig{Match: "("}, ""); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+func TestPublishProxyEntry(t *testing.T) {
+	d := &Daemon{}
+	ps := &proxy.ProxyServer{ID: "web-0001:dev", Path: "/src/web"}
+
+	// Nothing is published without a subscriber.
+	d.publishProxyEntry(ps, proxy.LogEntry{Type: proxy.LogTypeError, Error: &proxy.FrontendError{Message: "early"}})
+
+	events, unsubscribe := d.events.subscribe(func(e debugEvent) bool { return e.Kind == eventHTTPError })
+	defer unsubscribe()
+
+	d.publishProxyEntry(ps, proxy.LogEntry{Type: proxy.LogTypeError, Error: &proxy.FrontendError{Message: "filtered"}})
+	d.publishProxyEntry(ps, proxy.LogEntry{Type: proxy.LogTypeHTTP, HTTP: &proxy.HTTPLogEntry{Method: "GET", URL: "http://localhost:3000/", StatusCode: 404}})
+	d.publishProxyEntry(ps, proxy.LogEntry{Type: proxy.LogTypeHTTP, HTTP: &proxy.HTTPLogEntry{Method: "POST", URL: "http://localhost:3000/api/orders?id=7", StatusCode: 502}})
+
+	select {
+	case e := <-events:
+		if e.Source != "web-0001:dev" || e.Message != "POST /api/orders?id=7 → 502" || e.Time.IsZero() {
+			t.Errorf("event = %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("5xx response not published")
+	}
+
+	unsubscribe()
+	if d.events.active() {
+		t.Error("bus active after unsubscribe")
+	}
+}
@@ -0,0 +1,469 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/tunnel"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// exposeURLTimeout bounds how long EXPOSE START waits for a script to print its URL.
+const exposeURLTimeout = 60 * time.Second
+
+// exposeURLPollInterval is how often the URL tracker is checked while waiting.
+const exposeURLPollInterval = 250 * time.Millisecond
+
+// exposure records what EXPOSE START set up, so EXPOSE STOP only tears down
+// the pieces it created itself.
+type exposure struct {
+	ID             string
+	ProjectPath    string
+	ProcessID      string
+	ProxyID        string
+	TunnelID       string
+	TargetURL      string
+	PublicURL      string
+	AccessToken    string
+	StartedProcess bool
+	CreatedProxy   bool
+	CreatedAt      time.Time
+
+	// Starting marks the placeholder that reserves the ID while EXPOSE START
+	// sets up; it has no resources of its own.
+	Starting bool
+
+	// The access token and public URL a reused proxy had before, restored
+	// when the exposure is torn down.
+	prevAccessToken string
+	prevPublicURL   string
+}
+
+// ShareURL returns the public URL including the access token, if any.
+func (e *exposure) ShareURL() string {
+	if e.AccessToken == "" {
+		return e.PublicURL
+	}
+	return e.PublicURL + "/?" + proxy.AccessTokenParam + "=" + url.QueryEscape(e.AccessToken)
+}
+
+// hubHandleExpose handles the EXPOSE command and its sub-verbs.
+func (d *Daemon) hubHandleExpose(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "EXPOSE %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "START":
+		return d.hubHandleExposeStart(ctx, conn, cmd)
+	case "STOP":
+		return d.hubHandleExposeStop(ctx, conn, cmd)
+	case "LIST":
+		return d.hubHandleExposeList(conn)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown EXPOSE sub-command",
+			Command:      "EXPOSE",
+			ValidActions: []string{"START", "STOP", "LIST"},
+		})
+	}
+}
+
+// exposeStartRequest is the JSON payload of EXPOSE START.
+type exposeStartRequest struct {
+	Script     string `json:"script"`
+	TargetURL  string `json:"target_url"`
+	Provider   string `json:"provider"`
+	BinaryPath string `json:"binary_path"`
+	Command    string `json:"command"`
+	URLPattern string `json:"url_pattern"`
+	MaxBytes   int64  `json:"max_bytes"`
+	Expires    string `json:"expires"`
+	NoAuth     bool   `json:"no_auth"`
+	Encrypt    bool   `json:"encrypt"`
+	Path       string `json:"path"`
+}
+
+// hubHandleExposeStart handles EXPOSE START <id>.
+// Ensures the dev script is running, proxies its detected URL, starts a tunnel
+// and protects the proxy with an access token, returning one shareable URL.
+func (d *Daemon) hubHandleExposeStart(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "EXPOSE START requires: <id>")
+	}
+
+	name := cmd.Args[0]
+
+	var data exposeStartRequest
+	if err := decodeData(cmd, &data); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	if data.Provider == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "provider is required")
+	}
+	if data.Script == "" && data.TargetURL == "" {
+		data.Script = name
+	}
+	if data.MaxBytes < 0 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "max_bytes must not be negative")
+	}
+	if err := (tunnel.Config{Provider: tunnel.Provider(data.Provider), Command: data.Command, URLPattern: data.URLPattern}).Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	var expires time.Duration
+	if data.Expires != "" {
+		var err error
+		expires, err = time.ParseDuration(data.Expires)
+		if err != nil || expires <= 0 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid expires %q (use a duration like 30m or 2h)", data.Expires))
+		}
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && data.Path != "" {
+		projectPath = normalizePath(data.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "EXPOSE requires a session or path")
+	}
+
+	// Reserve the ID for the whole setup, so a concurrent START for the same
+	// name can't share (and tear down) this one's proxy and tunnel
+	exposeID := makeProcessID(projectPath, name)
+	d.exposureMu.Lock()
+	_, exists := d.exposures[exposeID]
+	if !exists {
+		d.exposures[exposeID] = &exposure{ID: name, ProjectPath: projectPath, Starting: true, CreatedAt: time.Now()}
+	}
+	d.exposureMu.Unlock()
+	if exists {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("%q is already exposed; stop it first", name))
+	}
+	registered := false
+	defer func() {
+		if !registered {
+			d.exposureMu.Lock()
+			delete(d.exposures, exposeID)
+			d.exposureMu.Unlock()
+		}
+	}()
+
+	e := &exposure{
+		ID:          name,
+		ProjectPath: projectPath,
+		TargetURL:   data.TargetURL,
+		CreatedAt:   time.Now(),
+	}
+
+	// Step 1: ensure the dev process is running and discover its URL
+	if e.TargetURL == "" {
+		e.ProcessID = makeProcessID(projectPath, data.Script)
+		started, err := d.ensureExposedScript(ctx, data.Script, projectPath)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to start script %q: %v", data.Script, err))
+		}
+		e.StartedProcess = started
+
+		targetURL, err := d.waitForProcessURL(ctx, e.ProcessID)
+		if err != nil {
+			d.teardownExposure(ctx, e)
+			return conn.WriteErr(hubproto.ErrInternal, err.Error())
+		}
+		e.TargetURL = targetURL
+	}
+
+	// Step 2: create (or reuse) a proxy for the URL. A reused proxy keeps
+	// its own token under no_auth, and gets it back on teardown otherwise.
+	if !data.NoAuth {
+		e.AccessToken = proxy.NewAccessToken()
+	}
+	e.ProxyID = exposeID
+	p, err := d.proxym.Get(e.ProxyID)
+	if err == nil {
+		e.prevAccessToken = p.AccessToken()
+		e.prevPublicURL = p.PublicURL
+		if !data.NoAuth {
+			p.SetAccessToken(e.AccessToken)
+		}
+	} else {
+		p, err = d.proxym.Create(ctx, proxy.ProxyConfig{
+			ID:          e.ProxyID,
+			TargetURL:   e.TargetURL,
+			ListenPort:  -1, // Auto-assign
+			MaxLogSize:  1000,
+			AutoRestart: true,
+			Path:        projectPath,
+			Encrypt:     data.Encrypt,
+			AccessToken: e.AccessToken,
+		})
+		if err != nil {
+			d.teardownExposure(ctx, e)
+			return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to create proxy: %v", err))
+		}
+		e.CreatedProxy = true
+
+		if session, ok := d.sessionRegistry.FindByDirectory(projectPath); ok && session.OverlayPath != "" {
+			p.SetOverlayEndpoint(session.OverlayPath)
+		} else if endpoint := d.OverlayEndpoint(); endpoint != "" {
+			p.SetOverlayEndpoint(endpoint)
+		}
+	}
+
+	localPort, err := listenPort(p.ListenAddr)
+	if err != nil {
+		d.teardownExposure(ctx, e)
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	// Step 3: start the tunnel in front of the proxy
+	e.TunnelID = exposeID
+	t, publicURL, err := d.startLinkedTunnel(ctx, e.TunnelID, tunnel.Config{
+		Provider:   tunnel.Provider(data.Provider),
+		LocalPort:  localPort,
+		LocalHost:  "127.0.0.1",
+		BinaryPath: data.BinaryPath,
+		Path:       projectPath,
+		MaxBytes:   data.MaxBytes,
+		Expires:    expires,
+		Command:    data.Command,
+		URLPattern: data.URLPattern,
+	}, e.ProxyID, p)
+	if err != nil {
+		if t == nil {
+			e.TunnelID = ""
+		}
+		d.teardownExposure(ctx, e)
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	e.PublicURL = publicURL
+
+	d.exposureMu.Lock()
+	d.exposures[exposeID] = e
+	registered = true
+	d.exposureMu.Unlock()
+
+	log.Printf("[INFO] Exposed %s (%s) at %s", name, e.TargetURL, publicURL)
+
+	resp := exposureResponse(e)
+	resp["status"] = "running"
+	if expiresAt := t.ExpiresAt(); !expiresAt.IsZero() {
+		resp["expires_at"] = expiresAt.Format(time.RFC3339)
+	}
+
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// hubHandleExposeStop handles EXPOSE STOP <id>.
+func (d *Daemon) hubHandleExposeStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "EXPOSE STOP requires: <id>")
+	}
+
+	name := cmd.Args[0]
+	e := d.takeExposure(d.getSessionProjectPath(conn), name)
+	if e == nil {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("%q is not exposed", name))
+	}
+	if e.Starting {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("%q is still starting; stop it once EXPOSE START returns", name))
+	}
+
+	stopped := d.teardownExposure(ctx, e)
+
+	resp := map[string]interface{}{
+		"id":      e.ID,
+		"stopped": stopped,
+		"success": true,
+	}
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// hubHandleExposeList handles EXPOSE LIST.
+func (d *Daemon) hubHandleExposeList(conn *hubpkg.Connection) error {
+	projectPath := d.getSessionProjectPath(conn)
+
+	d.exposureMu.Lock()
+	entries := make([]map[string]interface{}, 0, len(d.exposures))
+	for _, e := range d.exposures {
+		if projectPath != "" && e.ProjectPath != projectPath {
+			continue
+		}
+		entries = append(entries, exposureResponse(e))
+	}
+	d.exposureMu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i]["id"].(string) < entries[j]["id"].(string)
+	})
+
+	resp := map[string]interface{}{
+		"exposures": entries,
+		"count":     len(entries),
+	}
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// takeExposure removes and returns an exposure by name, preferring the
+// caller's project. Without a session the first match by name is used.
+// Placeholders of exposures still starting are returned but left in place.
+func (d *Daemon) takeExposure(projectPath, name string) *exposure {
+	d.exposureMu.Lock()
+	defer d.exposureMu.Unlock()
+
+	if projectPath != "" {
+		key := makeProcessID(projectPath, name)
+		if e, ok := d.exposures[key]; ok {
+			if !e.Starting {
+				delete(d.exposures, key)
+			}
+			return e
+		}
+		return nil
+	}
+	for key, e := range d.exposures {
+		if e.ID == name {
+			if !e.Starting {
+				delete(d.exposures, key)
+			}
+			return e
+		}
+	}
+	return nil
+}
+
+// ensureExposedScript starts a script unless it is already running.
+// Scripts defined in the project's agnt config use that definition; other
+// names run as package scripts. Reports whether the process was started here.
+func (d *Daemon) ensureExposedScript(ctx context.Context, script, projectPath string) (bool, error) {
+	processID := makeProcessID(projectPath, script)
+	if proc, err := d.hub.ProcessManager().Get(processID); err == nil {
+		if proc.State().String() == "running" {
+			return false, nil
+		}
+		// Stale registration from an earlier run; replace it
+		d.hub.ProcessManager().RemoveByPath(processID, projectPath)
+	}
+
+	scriptConfig := &config.ScriptConfig{}
+	var proxyConfigs map[string]*config.ProxyConfig
+	if agntConfig, err := config.LoadAgntConfig(projectPath); err == nil {
+		if sc, ok := agntConfig.Scripts[script]; ok && sc != nil {
+			scriptConfig = sc
+		}
+		proxyConfigs = agntConfig.Proxies
+	}
+
+	if err := d.autostartScript(ctx, script, scriptConfig, projectPath, proxyConfigs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// waitForProcessURL polls the URL tracker until the process reports a URL.
+func (d *Daemon) waitForProcessURL(ctx context.Context, processID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, exposeURLTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(exposeURLPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if urls := d.urlTracker.GetURLs(processID); len(urls) > 0 {
+			return urls[0], nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("no URL detected in output of %s within %s", processID, exposeURLTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// teardownExposure stops the tunnel, then the proxy and process if EXPOSE
+// created them. Returns the kinds of resources stopped.
+func (d *Daemon) teardownExposure(ctx context.Context, e *exposure) []string {
+	var stopped []string
+
+	if e.TunnelID != "" {
+		if err := d.tunnelm.Stop(ctx, e.TunnelID); err != nil {
+			log.Printf("[WARN] expose %s: failed to stop tunnel: %v", e.ID, err)
+		} else {
+			stopped = append(stopped, "tunnel")
+		}
+	}
+
+	if e.ProxyID != "" {
+		if e.CreatedProxy {
+			if err := d.proxym.Stop(ctx, e.ProxyID); err != nil {
+				log.Printf("[WARN] expose %s: failed to stop proxy: %v", e.ID, err)
+			} else {
+				stopped = append(stopped, "proxy")
+			}
+		} else if p, err := d.proxym.Get(e.ProxyID); err == nil {
+			// Reused proxy: put back the token and public URL it had
+			if e.AccessToken != "" {
+				p.SetAccessToken(e.prevAccessToken)
+			}
+			p.SetPublicURL(e.prevPublicURL)
+		}
+	}
+
+	if e.StartedProcess && e.ProcessID != "" {
+		if err := d.hub.ProcessManager().Stop(ctx, e.ProcessID); err != nil {
+			log.Printf("[WARN] expose %s: failed to stop process: %v", e.ID, err)
+		} else {
+			stopped = append(stopped, "process")
+		}
+	}
+
+	return stopped
+}
+
+// exposureResponse builds the JSON fields describing an exposure.
+func exposureResponse(e *exposure) map[string]interface{} {
+	resp := map[string]interface{}{
+		"id":         e.ID,
+		"proxy_id":   e.ProxyID,
+		"tunnel_id":  e.TunnelID,
+		"target_url": e.TargetURL,
+		"public_url": e.PublicURL,
+		"share_url":  e.ShareURL(),
+		"created_at": e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.Starting {
+		resp["status"] = "starting"
+	}
+	if e.ProcessID != "" {
+		resp["process_id"] = e.ProcessID
+	}
+	if e.AccessToken != "" {
+		resp["access_token"] = e.AccessToken
+	}
+	return resp
+}
+
+// listenPort extracts the port from a listen address such as "127.0.0.1:12345".
+func listenPort(addr string) (int, error) {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid listen address %q: %v", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 {
+		return 0, fmt.Errorf("invalid listen port in %q", addr)
+	}
+	return port, nil
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestExposureShareURL(t *testing.T) {
+	e := &exposure{PublicURL: "https://abc.trycloudflare.com"}
+	if got := e.ShareURL(); got != "https://abc.trycloudflare.com" {
+		t.Errorf("Expected bare public URL without token, got %q", got)
+	}
+
+	e.AccessToken = "tok"
+	if got := e.ShareURL(); got != "https://abc.trycloudflare.com/?agnt_access=tok" {
+		t.Errorf("Unexpected share URL %q", got)
+	}
+}
+
+func TestListenPort(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    int
+		wantErr bool
+	}{
+		{"127.0.0.1:12345", 12345, false},
+		{"[::]:8080", 8080, false},
+		{":0", 0, true},
+		{"localhost", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := listenPort(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("listenPort(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("listenPort(%q) = %d, want %d", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestTakeExposure(t *testing.T) {
+	d := &Daemon{exposures: make(map[string]*exposure)}
+	key := makeProcessID("/tmp/project", "dev")
+	d.exposures[key] = &exposure{ID: "dev", ProjectPath: "/tmp/project"}
+
+	if e := d.takeExposure("/tmp/other", "dev"); e != nil {
+		t.Error("Expected no exposure for a different project")
+	}
+	if e := d.takeExposure("/tmp/project", "dev"); e == nil || e.ID != "dev" {
+		t.Fatalf("Expected exposure dev, got %v", e)
+	}
+	if len(d.exposures) != 0 {
+		t.Error("Expected exposure removed after take")
+	}
+}
+
+func TestTakeExposure_Starting(t *testing.T) {
+	d := &Daemon{exposures: make(map[string]*exposure)}
+	key := makeProcessID("/tmp/project", "dev")
+	d.exposures[key] = &exposure{ID: "dev", ProjectPath: "/tmp/project", Starting: true}
+
+	if e := d.takeExposure("/tmp/project", "dev"); e == nil || !e.Starting {
+		t.Fatalf("Expected the starting placeholder, got %v", e)
+	}
+	if e := d.takeExposure("", "dev"); e == nil || !e.Starting {
+		t.Fatalf("Expected the starting placeholder by name, got %v", e)
+	}
+	if len(d.exposures) != 1 {
+		t.Error("Expected the placeholder to stay reserved")
+	}
+}
+
+func TestTeardownExposure_RestoresReusedProxy(t *testing.T) {
+	tmpDir := t.TempDir()
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir, AccessToken: "own"})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer px.Stop(context.Background())
+	px.SetPublicURL("https://own.example.com")
+
+	e := &exposure{ID: "app", ProxyID: "app", AccessToken: "exposed", prevAccessToken: "own", prevPublicURL: "https://own.example.com"}
+	px.SetAccessToken(e.AccessToken)
+	px.SetPublicURL("https://abc.trycloudflare.com")
+
+	if stopped := d.teardownExposure(context.Background(), e); len(stopped) != 0 {
+		t.Errorf("Expected a reused proxy to keep running, stopped %v", stopped)
+	}
+	if got := px.AccessToken(); got != "own" {
+		t.Errorf("Expected the proxy's own token back, got %q", got)
+	}
+	if px.PublicURL != "https://own.example.com" {
+		t.Errorf("Expected the proxy's own public URL back, got %q", px.PublicURL)
+	}
+
+	// no_auth never touched the token
+	e = &exposure{ID: "app", ProxyID: "app", prevAccessToken: "own"}
+	px.SetAccessToken("changed-elsewhere")
+	d.teardownExposure(context.Background(), e)
+	if got := px.AccessToken(); got != "changed-elsewhere" {
+		t.Errorf("Expected no_auth teardown to leave the token alone, got %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	goprocess "github.com/standardbeagle/go-cli-server/process"
+)
+
+const (
+	// DefaultFollowTimeout bounds a follow from the MCP tools, which return
+	// the collected lines in one response.
+	DefaultFollowTimeout = 10 * time.Second
+	// MaxFollowTimeout stays below the client's request timeout.
+	MaxFollowTimeout = 25 * time.Second
+
+	// followPollInterval is how often the output buffer is re-read.
+	followPollInterval = 200 * time.Millisecond
+	// followAnchorSize is the tail of the previous read used to find where
+	// new output starts once the ring buffer has wrapped.
+	followAnchorSize = 256
+)
+
+// OutputFollower yields the lines a process writes after it was created.
+// The process output is a ring buffer without offsets, so the follower diffs
+// successive reads.
+type OutputFollower struct {
+	read    func() ([]byte, bool)
+	done    <-chan struct{}
+	prev    []byte
+	pending []byte // Partial last line, held until its newline arrives
+}
+
+// NewOutputFollower starts following a stream (stdout, stderr or combined)
+// of proc. It returns the complete lines already in the buffer; a trailing
+// partial line is held back and sent once complete.
+func NewOutputFollower(proc *goprocess.ManagedProcess, stream string) (*OutputFollower, []string) {
+	read := proc.CombinedOutput
+	switch stream {
+	case "stdout":
+		read = proc.Stdout
+	case "stderr":
+		read = proc.Stderr
+	}
+	return newOutputFollower(read, proc.Done())
+}
+
+func newOutputFollower(read func() ([]byte, bool), done <-chan struct{}) (*OutputFollower, []string) {
+	f := &OutputFollower{read: read, done: done}
+	data, _ := read()
+	f.prev = data
+	return f, f.lines(data, false)
+}
+
+// poll returns the complete lines added since the last read, and with flush
+// also a trailing partial line.
+func (f *OutputFollower) poll(flush bool) []string {
+	data, wrapped := f.read()
+	added := newOutput(f.prev, data, wrapped)
+	f.prev = data
+	return f.lines(added, flush)
+}
+
+// lines splits data after the pending partial line into complete lines.
+func (f *OutputFollower) lines(data []byte, flush bool) []string {
+	buf := append(f.pending, data...)
+	f.pending = nil
+	end := bytes.LastIndexByte(buf, '\n')
+	if !flush {
+		f.pending = append([]byte(nil), buf[end+1:]...)
+		buf = buf[:end+1]
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+}
+
+// newOutput returns the part of cur written after prev was read.
+func newOutput(prev, cur []byte, wrapped bool) []byte {
+	if !wrapped || len(prev) == 0 {
+		if len(cur) < len(prev) {
+			return cur // Buffer was reset
+		}
+		return cur[len(prev):]
+	}
+	anchor := prev[max(0, len(prev)-followAnchorSize):]
+	if i := bytes.LastIndex(cur, anchor); i >= 0 {
+		return cur[i+len(anchor):]
+	}
+	return cur // Wrapped past everything seen; all of it is new
+}
+
+// Follow calls emit with new lines until the process exits, ctx is done or
+// timeout passes (0 for no limit). Output written before the exit is flushed
+// first, including a final line without a newline.
+func (f *OutputFollower) Follow(ctx context.Context, timeout time.Duration, emit func([]string) error) error {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
+
+	send := func(flush bool) error {
+		if lines := f.poll(flush); len(lines) > 0 {
+			return emit(lines)
+		}
+		return nil
+	}
+	for {
+		select {
+		case <-f.done:
+			return send(true)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-expired:
+			return send(false)
+		case <-ticker.C:
+			if err := send(false); err != nil {
+				return err
+			}
+		}
+	}
+}
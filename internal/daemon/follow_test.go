@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// fakeOutput is a process output buffer that keeps the last size bytes.
+type fakeOutput struct {
+	mu      sync.Mutex
+	size    int
+	data    []byte
+	wrapped bool
+}
+
+func (o *fakeOutput) write(s string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.data = append(o.data, s...)
+	if o.size > 0 && len(o.data) > o.size {
+		o.data = o.data[len(o.data)-o.size:]
+		o.wrapped = true
+	}
+}
+
+func (o *fakeOutput) read() ([]byte, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]byte(nil), o.data...), o.wrapped
+}
+
+func TestOutputFollower(t *testing.T) {
+	out := &fakeOutput{}
+	out.write("one\ntwo\npart")
+	f, lines := newOutputFollower(out.read, nil)
+	if !reflect.DeepEqual(lines, []string{"one", "two"}) {
+		t.Fatalf("Expected existing complete lines, got %q", lines)
+	}
+
+	if lines := f.poll(false); lines != nil {
+		t.Errorf("Expected nothing new, got %q", lines)
+	}
+	out.write("ial\nthree\nfo")
+	if lines := f.poll(false); !reflect.DeepEqual(lines, []string{"partial", "three"}) {
+		t.Errorf("Expected the partial line completed, got %q", lines)
+	}
+	out.write("ur")
+	if lines := f.poll(true); !reflect.DeepEqual(lines, []string{"four"}) {
+		t.Errorf("Expected the last line flushed, got %q", lines)
+	}
+}
+
+func TestOutputFollowerWrapped(t *testing.T) {
+	out := &fakeOutput{size: 1024}
+	out.write(strings.Repeat("old line\n", 200))
+	f, _ := newOutputFollower(out.read, nil)
+
+	out.write("new 1\nnew 2\n")
+	if lines := f.poll(false); !reflect.DeepEqual(lines, []string{"new 1", "new 2"}) {
+		t.Errorf("Expected only new lines after the buffer wrapped, got %q", lines)
+	}
+}
+
+func TestOutputFollowerFollow(t *testing.T) {
+	out := &fakeOutput{}
+	done := make(chan struct{})
+	f, _ := newOutputFollower(out.read, done)
+
+	var mu sync.Mutex
+	var got []string
+	result := make(chan error, 1)
+	go func() {
+		result <- f.Follow(context.Background(), 0, func(lines []string) error {
+			mu.Lock()
+			got = append(got, lines...)
+			mu.Unlock()
+			return nil
+		})
+	}()
+
+	out.write("starting\n")
+	time.Sleep(2 * followPollInterval)
+	out.write("exited without newline")
+	close(done)
+	if err := <-result; err != nil {
+		t.Fatalf("Follow failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"starting", "exited without newline"}) {
+		t.Errorf("Unexpected lines %q", got)
+	}
+
+	// A canceled client and a timeout both end the follow
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f, _ = newOutputFollower(out.read, nil)
+	if err := f.Follow(ctx, 0, func([]string) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := f.Follow(context.Background(), 10*time.Millisecond, func([]string) error { return nil }); err != nil {
+		t.Errorf("Expected timeout to end cleanly, got %v", err)
+	}
+}
+
+func TestParseProcOutputArgs(t *testing.T) {
+	var req protocol.ProcOutputRequest
+	if err := parseProcOutputArgs([]string{"stream=stderr", "tail=20", "grep=error", "grep_v", "follow", "timeout_ms=5000"}, &req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if req.Stream != "stderr" || req.Tail != 20 || req.Grep != "error" || !req.GrepV || !req.Follow || req.TimeoutMs != 5000 {
+		t.Errorf("Unexpected request %+v", req)
+	}
+	if err := parseProcOutputArgs([]string{"tail=many"}, &req); err == nil {
+		t.Error("Expected error for a non-numeric tail")
+	}
+	if err := parseProcOutputArgs([]string{"bogus"}, &req); err == nil {
+		t.Error("Expected error for an unknown option")
+	}
+}
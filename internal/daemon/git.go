@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/git"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// gitTimeout bounds the git commands of one GIT request.
+const gitTimeout = 30 * time.Second
+
+// hubHandleGit handles the GIT command and its sub-verbs, which run git in
+// the session's project, or the payload's path without a session.
+func (d *Daemon) hubHandleGit(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "GIT %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbStatus, protocol.SubVerbDiff, protocol.SubVerbLog, protocol.SubVerbChanged:
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown GIT sub-command",
+			Command:      protocol.VerbGit,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbStatus, protocol.SubVerbDiff, protocol.SubVerbLog, protocol.SubVerbChanged},
+		})
+	}
+
+	var req protocol.GitRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "GIT requires a session or path")
+	}
+	if err := git.ValidatePaths(req.Paths); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	var result interface{}
+	var err error
+	switch cmd.SubVerb {
+	case protocol.SubVerbStatus:
+		result, err = git.GetStatus(ctx, projectPath, req.Paths)
+	case protocol.SubVerbDiff:
+		result, err = git.GetDiff(ctx, projectPath, git.DiffOptions{Ref: req.Ref, Staged: req.Staged, Paths: req.Paths, Patch: req.Patch})
+	case protocol.SubVerbLog:
+		result, err = git.GetLog(ctx, projectPath, git.LogOptions{Ref: req.Ref, Since: req.Since, Author: req.Author, Paths: req.Paths, Limit: req.Limit})
+	case protocol.SubVerbChanged:
+		result, err = git.ChangedSince(ctx, projectPath, req.Ref, req.Since, req.Paths)
+	}
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+
+	data, _ := json.Marshal(result)
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/crash"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// defaultGitHookTimeout bounds each script of a git hook when the hook sets
+// no timeout-ms.
+const defaultGitHookTimeout = 5 * time.Minute
+
+// gitHookResult is the outcome of one script of a HOOK RUN.
+type gitHookResult struct {
+	Script    string `json:"script"`
+	ProcessID string `json:"process_id,omitempty"`
+	Passed    bool   `json:"passed"`
+	ExitCode  int    `json:"exit_code"`
+	TimedOut  bool   `json:"timed_out,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	Output    string `json:"output,omitempty"` // Tail of the output of a failed script
+	Error     string `json:"error,omitempty"`  // Why the script couldn't start
+}
+
+// hubHandleHook handles the HOOK command.
+func (d *Daemon) hubHandleHook(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbRun:
+		return d.hubHandleHookRun(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown HOOK sub-command",
+			Command:      protocol.VerbHook,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbRun},
+		})
+	}
+}
+
+// GitHookTimeout returns how long each script of a git hook may run.
+func GitHookTimeout(hook *config.GitHookConfig) time.Duration {
+	if hook == nil || hook.TimeoutMs <= 0 {
+		return defaultGitHookTimeout
+	}
+	return time.Duration(hook.TimeoutMs) * time.Millisecond
+}
+
+// hubHandleHookRun handles HOOK RUN <hook> [path]: the scripts the
+// git-hooks block of .agnt.kdl gives the hook run in order as managed
+// processes, stopping at the first failure, which is shown in the project's
+// proxied pages and sessions. Their output stays available with PROC OUTPUT.
+// Blocks until the scripts finish.
+func (d *Daemon) hubHandleHookRun(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "HOOK RUN requires: <hook> [path]")
+	}
+	hookName := cmd.Args[0]
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "HOOK RUN requires a session or path")
+	}
+
+	cfg, err := config.LoadAgntConfig(projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	hook := cfg.GitHooks[hookName]
+	resp := map[string]interface{}{
+		"hook":    hookName,
+		"passed":  true,
+		"results": []gitHookResult{},
+	}
+	if hook == nil || len(hook.Scripts) == 0 {
+		// An unconfigured hook must not block git
+		resp["message"] = fmt.Sprintf("no scripts for %s in git-hooks of %s", hookName, config.AgntConfigFileName)
+		data, _ := json.Marshal(resp)
+		return conn.WriteJSON(data)
+	}
+
+	started := time.Now()
+	timeout := GitHookTimeout(hook)
+	results := make([]gitHookResult, 0, len(hook.Scripts))
+	for _, script := range hook.Scripts {
+		result := d.runGitHookScript(ctx, projectPath, hookName, script, timeout)
+		results = append(results, result)
+		if !result.Passed {
+			resp["passed"] = false
+			d.notifyGitHookFailure(projectPath, hookName, result)
+			break
+		}
+	}
+	resp["results"] = results
+	resp["duration"] = time.Since(started).Round(time.Millisecond).String()
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// runGitHookScript runs one script of a git hook and waits for it.
+func (d *Daemon) runGitHookScript(ctx context.Context, projectPath, hookName, script string, timeout time.Duration) gitHookResult {
+	result := gitHookResult{Script: script, ExitCode: -1}
+	command, err := resolveScriptCommand(projectPath, script, "", "", nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.ProcessID = makeProcessID(projectPath, fmt.Sprintf("hook-%s-%s", hookName, script))
+	proc, err := d.startFreshProcess(ctx, process.ProcessConfig{
+		ID:          result.ProcessID,
+		ProjectPath: resolveWorkingDir(projectPath, command.Cwd),
+		Command:     command.Command,
+		Args:        command.Args,
+		Env:         append(command.Env, "AGNT_GIT_HOOK="+hookName),
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	select {
+	case <-proc.Done():
+	case <-time.After(timeout):
+		result.TimedOut = true
+		stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := d.hub.ProcessManager().Stop(stopCtx, result.ProcessID); err != nil {
+			log.Printf("[WARN] HOOK RUN: failed to stop %s: %v", result.ProcessID, err)
+		}
+		cancel()
+	}
+
+	result.ExitCode = proc.ExitCode()
+	result.Passed = !result.TimedOut && proc.IsDone() && result.ExitCode == 0
+	result.Duration = proc.Runtime().Round(time.Millisecond).String()
+	if !result.Passed {
+		out, _ := proc.CombinedOutput()
+		result.Output = crash.Tail(out)
+	}
+	return result
+}
+
+// notifyGitHookFailure shows a toast in the project's proxied pages and
+// tells the project's active sessions which script failed the hook.
+func (d *Daemon) notifyGitHookFailure(projectPath, hookName string, result gitHookResult) {
+	var message string
+	switch {
+	case result.Error != "":
+		message = fmt.Sprintf("%s failed: %s: %s", hookName, result.Script, result.Error)
+	case result.TimedOut:
+		message = fmt.Sprintf("%s failed: %s timed out. Output: PROC OUTPUT %s", hookName, result.Script, result.ProcessID)
+	default:
+		message = fmt.Sprintf("%s failed: %s exited with %d. Output: PROC OUTPUT %s", hookName, result.Script, result.ExitCode, result.ProcessID)
+	}
+
+	for _, px := range d.proxym.List() {
+		if normalizePath(px.Path) == projectPath {
+			px.BroadcastToast("error", fmt.Sprintf("Git %s hook failed", hookName), message, 0)
+		}
+	}
+	for _, session := range d.sessionRegistry.ListActive(projectPath, false) {
+		msg, err := session.typeMessage("[agnt hook] "+message, SendOptions{})
+		if err == nil {
+			err = d.sendMessageToOverlay(session.OverlayPath, msg)
+		}
+		if err != nil {
+			log.Printf("[WARN] HOOK %s: failed to notify session %s: %v", hookName, session.Code, err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// buildGraph derives the dependency graph from the daemon's current state:
+// proxies depend on the process serving their target, tunnels on the proxy
+// or process listening on their local port.
+func (d *Daemon) buildGraph() *depgraph.Graph {
+	g := depgraph.New()
+
+	// Listening ports of each process, from detected URLs and the command line
+	processPorts := make(map[int][]string)
+	for _, p := range d.hub.ProcessManager().List() {
+		g.AddNode(depgraph.Node{Kind: depgraph.KindProcess, ID: p.ID, State: p.State().String(), Path: p.ProjectPath})
+		ports := make(map[int]bool)
+		for _, u := range d.urlTracker.GetURLs(p.ID) {
+			if port := urlPort(u); port > 0 {
+				ports[port] = true
+			}
+		}
+		if port := extractPortFromCommand(p.Command, p.Args); port > 0 {
+			ports[port] = true
+		}
+		for port := range ports {
+			processPorts[port] = append(processPorts[port], p.ID)
+		}
+	}
+
+	proxyPorts := make(map[int]string)
+	proxies := d.proxym.List()
+	for _, px := range proxies {
+		state := "stopped"
+		if px.IsRunning() {
+			state = "running"
+		}
+		g.AddNode(depgraph.Node{Kind: depgraph.KindProxy, ID: px.ID, State: state, Path: px.Path})
+		if _, port, err := net.SplitHostPort(px.ListenAddr); err == nil {
+			if n, err := strconv.Atoi(port); err == nil && n > 0 {
+				proxyPorts[n] = px.ID
+			}
+		}
+	}
+
+	// Explicit links first so their reasons win over port matches
+	d.scriptProxyMu.RLock()
+	for scriptID, proxyIDs := range d.scriptProxies {
+		for _, proxyID := range proxyIDs {
+			g.AddEdge(depgraph.KindProxy+":"+proxyID, depgraph.KindProcess+":"+scriptID, "created from script output")
+		}
+	}
+	d.scriptProxyMu.RUnlock()
+
+	d.exposureMu.Lock()
+	for _, e := range d.exposures {
+		reason := "exposure " + e.ID
+		if e.ProxyID != "" && e.ProcessID != "" {
+			g.AddEdge(depgraph.KindProxy+":"+e.ProxyID, depgraph.KindProcess+":"+e.ProcessID, reason)
+		}
+		if e.TunnelID != "" && e.ProxyID != "" {
+			g.AddEdge(depgraph.KindTunnel+":"+e.TunnelID, depgraph.KindProxy+":"+e.ProxyID, reason)
+		}
+	}
+	d.exposureMu.Unlock()
+
+	for _, px := range proxies {
+		target := px.Target()
+		if target == nil || !isLocalHost(target.Hostname()) {
+			continue
+		}
+		port := urlPort(target.String())
+		for _, procID := range processPorts[port] {
+			g.AddEdge(depgraph.KindProxy+":"+px.ID, depgraph.KindProcess+":"+procID, fmt.Sprintf("targets port %d", port))
+		}
+	}
+
+	for _, t := range d.tunnelm.List() {
+		g.AddNode(depgraph.Node{Kind: depgraph.KindTunnel, ID: t.ID, State: t.State, Path: t.Path})
+		host, portStr, err := net.SplitHostPort(t.LocalAddr)
+		if err != nil || !isLocalHost(host) {
+			continue
+		}
+		port, _ := strconv.Atoi(portStr)
+		key := depgraph.KindTunnel + ":" + t.ID
+		if proxyID, ok := proxyPorts[port]; ok {
+			g.AddEdge(key, depgraph.KindProxy+":"+proxyID, fmt.Sprintf("fronts port %d", port))
+			continue
+		}
+		for _, procID := range processPorts[port] {
+			g.AddEdge(key, depgraph.KindProcess+":"+procID, fmt.Sprintf("fronts port %d", port))
+		}
+	}
+
+	return g
+}
+
+// urlPort returns the port of a URL, defaulting by scheme.
+func urlPort(raw string) int {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return 0
+	}
+	if p := u.Port(); p != "" {
+		n, _ := strconv.Atoi(p)
+		return n
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		return 80
+	case "https", "wss":
+		return 443
+	}
+	return 0
+}
+
+// isLocalHost reports whether host refers to this machine.
+func isLocalHost(host string) bool {
+	switch strings.ToLower(host) {
+	case "", "localhost", "0.0.0.0", "::", "::1":
+		return true
+	}
+	return strings.HasPrefix(host, "127.")
+}
+
+// impactOf returns the entities that an operation on kind:id would break,
+// with a human-readable warning.
+func (d *Daemon) impactOf(verb, kind, id string) ([]depgraph.Impact, string) {
+	g := d.buildGraph()
+	target, ok := g.Node(kind + ":" + id)
+	if !ok {
+		return nil, ""
+	}
+	impacts := g.Dependents(target.Key())
+	return impacts, depgraph.Describe(verb, target, impacts)
+}
+
+// cascadeStop stops the dependents of an entity, outermost first: tunnels,
+// then proxies, then processes. Returns the keys that were stopped.
+func (d *Daemon) cascadeStop(ctx context.Context, impacts []depgraph.Impact) []string {
+	var stopped []string
+	for _, kind := range []string{depgraph.KindTunnel, depgraph.KindProxy, depgraph.KindProcess} {
+		for _, im := range impacts {
+			if im.Kind != kind {
+				continue
+			}
+			var err error
+			switch kind {
+			case depgraph.KindTunnel:
+				err = d.tunnelm.Stop(ctx, im.ID)
+			case depgraph.KindProxy:
+				err = d.proxym.Stop(ctx, im.ID)
+				if err == nil && d.stateMgr != nil {
+					d.stateMgr.RemoveProxy(im.ID)
+				}
+			case depgraph.KindProcess:
+				err = d.hub.ProcessManager().Stop(ctx, im.ID)
+				if err == nil {
+					d.dropProcessLabels(im.ID)
+				}
+			}
+			if err != nil {
+				log.Printf("[WARN] cascade stop of %s failed: %v", im.Key(), err)
+				continue
+			}
+			stopped = append(stopped, im.Key())
+		}
+	}
+	return stopped
+}
+
+// hubHandleGraph handles the GRAPH command and its sub-verbs.
+func (d *Daemon) hubHandleGraph(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "GRAPH %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "SHOW":
+		return d.hubHandleGraphShow(conn, cmd)
+	case "IMPACT":
+		return d.hubHandleGraphImpact(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown GRAPH sub-command",
+			Command:      "GRAPH",
+			ValidActions: []string{"SHOW", "IMPACT"},
+		})
+	}
+}
+
+// hubHandleGraphShow handles GRAPH SHOW [global].
+// Returns the nodes and edges of the session's project, or of all projects.
+func (d *Daemon) hubHandleGraphShow(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	g := d.buildGraph()
+
+	global := len(cmd.Args) > 0 && cmd.Args[0] == "global"
+	projectPath := d.getSessionProjectPath(conn)
+	if !global && projectPath != "" {
+		g = g.Subgraph(func(n depgraph.Node) bool {
+			return n.Path == "" || normalizePath(n.Path) == projectPath
+		})
+	}
+
+	resp := map[string]interface{}{
+		"nodes": g.Nodes(),
+		"edges": g.Edges(),
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleGraphImpact handles GRAPH IMPACT <kind> <id>.
+// Lists what directly or transitively depends on the entity.
+func (d *Daemon) hubHandleGraphImpact(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "GRAPH IMPACT requires: <process|proxy|tunnel> <id>")
+	}
+	kind, id := strings.ToLower(cmd.Args[0]), cmd.Args[1]
+
+	// Proxies and tunnels may be named by their session-scoped ID
+	switch kind {
+	case depgraph.KindProxy:
+		if p, err := d.getSessionScopedProxy(conn, id); err == nil {
+			id = p.ID
+		}
+	case depgraph.KindTunnel:
+		if t, err := d.getSessionScopedTunnel(conn, id); err == nil {
+			id = t.ID()
+		}
+	}
+
+	g := d.buildGraph()
+	target, ok := g.Node(kind + ":" + id)
+	if !ok {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("%s %q not found", kind, id))
+	}
+	impacts := g.Dependents(target.Key())
+
+	resp := map[string]interface{}{
+		"target":     target,
+		"dependents": impacts,
+		"count":      len(impacts),
+	}
+	if msg := depgraph.Describe("stopping", target, impacts); msg != "" {
+		resp["message"] = msg
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
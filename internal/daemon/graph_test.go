@@ -0,0 +1,31 @@
+package daemon
+
+import "testing"
+
+func TestURLPort(t *testing.T) {
+	tests := map[string]int{
+		"http://localhost:3000/":  3000,
+		"http://127.0.0.1":        80,
+		"https://example.test/x":  443,
+		"ws://[::1]:24678/socket": 24678,
+		"not a url\x7f":           0,
+	}
+	for in, want := range tests {
+		if got := urlPort(in); got != want {
+			t.Errorf("urlPort(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestIsLocalHost(t *testing.T) {
+	for _, h := range []string{"localhost", "127.0.0.1", "127.1.2.3", "::1", "0.0.0.0", ""} {
+		if !isLocalHost(h) {
+			t.Errorf("isLocalHost(%q) = false", h)
+		}
+	}
+	for _, h := range []string{"example.com", "192.168.1.5"} {
+		if isLocalHost(h) {
+			t.Errorf("isLocalHost(%q) = true", h)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/ptyhost"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// hubHandleSessionHost handles SESSION HOST command.
+// SESSION HOST <code> -- <config_json>
+// Starts the command of an agnt run --detachable session in a PTY the daemon
+// owns; the terminal attaches over the returned socket and can go away
+// without ending the process.
+func (d *Daemon) hubHandleSessionHost(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION HOST requires: <code>")
+	}
+	var cfg ptyhost.Config
+	if err := json.Unmarshal(cmd.Data, &cfg); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid host config: %v", err))
+	}
+
+	info, err := d.ptyHosts.Start(cmd.Args[0], cfg)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	data, _ := json.Marshal(info)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleSessionHosted handles SESSION HOSTED command.
+// SESSION HOSTED [code]
+// Lists the hosted terminals, or describes one, for agnt run --resume.
+func (d *Daemon) hubHandleSessionHosted(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) > 0 {
+		info, ok := d.ptyHosts.Get(cmd.Args[0])
+		if !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q hosts no process", cmd.Args[0]))
+		}
+		data, _ := json.Marshal(info)
+		return conn.WriteJSON(data)
+	}
+
+	hosted := d.ptyHosts.List()
+	data, _ := json.Marshal(map[string]interface{}{
+		"hosted": hosted,
+		"count":  len(hosted),
+	})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleSessionDetach handles SESSION DETACH command.
+// SESSION DETACH <code>
+// Disconnects the terminal attached to a hosted session; the process keeps
+// running until agnt run --resume attaches again.
+func (d *Daemon) hubHandleSessionDetach(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION DETACH requires: <code>")
+	}
+	detached, err := d.ptyHosts.Detach(cmd.Args[0])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"success":      true,
+		"session_code": cmd.Args[0],
+		"detached":     detached,
+	})
+	return conn.WriteJSON(data)
+}
@@ -6,19 +6,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/standardbeagle/agnt/internal/automation"
 	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	"github.com/standardbeagle/agnt/internal/endpoint"
 	"github.com/standardbeagle/agnt/internal/project"
+	"github.com/standardbeagle/agnt/internal/protocol"
 	"github.com/standardbeagle/agnt/internal/proxy"
 	"github.com/standardbeagle/agnt/internal/tunnel"
 	hubpkg "github.com/standardbeagle/go-cli-server/hub"
@@ -249,117 +253,28 @@ func extractPortFromURL(urlStr string) int {
 
 // registerAgntCommands registers agnt-specific commands with the Hub.
 // This enables Hub's command dispatch to route these commands to the daemon's handlers.
-// Note: Registering a command that Hub already registered will override Hub's handler.
+// Note: Registering a command that Hub already registered will override Hub's handler
+// (PROC is overridden to add URL tracking and project filtering).
+// Commands are declared in commandSpecs, which also backs HELP.
 func (d *Daemon) registerAgntCommands() {
-	// PROC command - override Hub's to add URL tracking and project filtering
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "PROC",
-		SubVerbs:    []string{"STATUS", "OUTPUT", "STOP", "RESTART", "LIST", "CLEANUP-PORT"},
-		Description: "Manage running processes",
-		Handler:     d.hubHandleProc,
-	})
-
-	// DETECT command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "DETECT",
-		Description: "Detect project type and available scripts",
-		Handler:     d.hubHandleDetect,
-	})
-
-	// PROXY command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "PROXY",
-		SubVerbs:    []string{"START", "STOP", "RESTART", "STATUS", "LIST", "EXEC", "TOAST"},
-		Description: "Manage reverse proxies",
-		Handler:     d.hubHandleProxy,
-	})
-
-	// PROXYLOG command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "PROXYLOG",
-		SubVerbs:    []string{"QUERY", "SUMMARY", "CLEAR", "STATS"},
-		Description: "Query proxy traffic logs",
-		Handler:     d.hubHandleProxyLog,
-	})
-
-	// CURRENTPAGE command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "CURRENTPAGE",
-		SubVerbs:    []string{"LIST", "GET", "SUMMARY", "CLEAR"},
-		Description: "View active page sessions",
-		Handler:     d.hubHandleCurrentPage,
-	})
-
-	// OVERLAY command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "OVERLAY",
-		SubVerbs:    []string{"SET", "GET", "CLEAR", "ACTIVITY"},
-		Description: "Configure overlay endpoint",
-		Handler:     d.hubHandleOverlay,
-	})
-
-	// TUNNEL command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "TUNNEL",
-		SubVerbs:    []string{"START", "STOP", "STATUS", "LIST"},
-		Description: "Manage tunnel connections",
-		Handler:     d.hubHandleTunnel,
-	})
-
-	// CHAOS command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "CHAOS",
-		SubVerbs:    []string{"ENABLE", "DISABLE", "STATUS", "PRESET", "SET", "ADD-RULE", "REMOVE-RULE", "LIST-RULES", "STATS", "CLEAR", "LIST-PRESETS"},
-		Description: "Configure chaos engineering rules",
-		Handler:     d.hubHandleChaos,
-	})
-
-	// SESSION command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "SESSION",
-		SubVerbs:    []string{"REGISTER", "UNREGISTER", "HEARTBEAT", "LIST", "GET", "SEND", "SCHEDULE", "CANCEL", "TASKS", "FIND", "ATTACH", "URL"},
-		Description: "Manage client sessions",
-		Handler:     d.hubHandleSession,
-	})
-
-	// STATUS command - returns full daemon info (Hub's INFO is minimal)
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "STATUS",
-		Description: "Get full daemon status and statistics",
-		Handler:     d.hubHandleStatus,
-	})
-
-	// STORE command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "STORE",
-		SubVerbs:    []string{"GET", "SET", "DELETE", "LIST", "CLEAR", "GET-ALL"},
-		Description: "Manage persistent key-value storage",
-		Handler:     d.hubHandleStore,
-	})
-
-	// AUTOMATE command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "AUTOMATE",
-		SubVerbs:    []string{"PROCESS", "BATCH"},
-		Description: "Process automation tasks using AI",
-		Handler:     d.hubHandleAutomate,
-	})
-
-	// STOP-ALL command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "STOP-ALL",
-		Description: "Stop all running processes, proxies, and tunnels",
-		Handler:     d.hubHandleStopAll,
-	})
-
-	// RESTART-ALL command
-	d.hub.RegisterCommand(hubpkg.CommandDefinition{
-		Verb:        "RESTART-ALL",
-		Description: "Restart all processes and proxies using .agnt.kdl config",
-		Handler:     d.hubHandleRestartAll,
-	})
+	count := 0
+	for _, spec := range commandSpecs() {
+		if spec.handler == nil {
+			continue // Served by the Hub itself
+		}
+		handler := spec.handler
+		d.hub.RegisterCommand(hubpkg.CommandDefinition{
+			Verb:        spec.verb,
+			SubVerbs:    spec.subVerbNames(),
+			Description: spec.description,
+			Handler: func(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+				return handler(d, ctx, conn, cmd)
+			},
+		})
+		count++
+	}
 
-	log.Printf("[DEBUG] Registered %d agnt-specific commands with Hub", 14)
+	log.Printf("[DEBUG] Registered %d agnt-specific commands with Hub", count)
 }
 
 // hubHandleProc handles the PROC command (overrides Hub's built-in).
@@ -379,13 +294,23 @@ func (d *Daemon) hubHandleProc(ctx context.Context, conn *hubpkg.Connection, cmd
 		return d.hubHandleProcList(ctx, conn, cmd)
 	case "CLEANUP-PORT":
 		return d.hubHandleProcCleanupPort(ctx, conn, cmd)
+	case "CRASH":
+		return d.hubHandleProcCrash(ctx, conn, cmd)
+	case protocol.SubVerbLabel:
+		return d.hubHandleProcLabel(conn, cmd)
+	case protocol.SubVerbSupervise:
+		return d.hubHandleProcSupervise(conn, cmd)
+	case protocol.SubVerbMetrics:
+		return d.hubHandleProcMetrics(conn, cmd)
+	case protocol.SubVerbResults:
+		return d.hubHandleProcResults(conn, cmd)
 	case "":
 		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
 			Code:         hubproto.ErrMissingParam,
 			Message:      "action required",
 			Command:      "PROC",
 			Param:        "action",
-			ValidActions: []string{"STATUS", "OUTPUT", "STOP", "RESTART", "LIST", "CLEANUP-PORT"},
+			ValidActions: []string{"STATUS", "OUTPUT", "STOP", "RESTART", "LIST", "CLEANUP-PORT", "CRASH", protocol.SubVerbLabel, protocol.SubVerbSupervise, protocol.SubVerbMetrics, protocol.SubVerbResults},
 		})
 	default:
 		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
@@ -393,7 +318,7 @@ func (d *Daemon) hubHandleProc(ctx context.Context, conn *hubpkg.Connection, cmd
 			Message:      "unknown action",
 			Command:      "PROC",
 			Action:       cmd.SubVerb,
-			ValidActions: []string{"STATUS", "OUTPUT", "STOP", "RESTART", "LIST", "CLEANUP-PORT"},
+			ValidActions: []string{"STATUS", "OUTPUT", "STOP", "RESTART", "LIST", "CLEANUP-PORT", "CRASH", protocol.SubVerbLabel, protocol.SubVerbSupervise, protocol.SubVerbMetrics, protocol.SubVerbResults},
 		})
 	}
 }
@@ -407,7 +332,7 @@ func (d *Daemon) hubHandleProcStatus(ctx context.Context, conn *hubpkg.Connectio
 	processID := cmd.Args[0]
 	proc, err := d.hub.ProcessManager().Get(processID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("process %q not found", processID))
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
 	}
 
 	resp := map[string]interface{}{
@@ -426,6 +351,9 @@ func (d *Daemon) hubHandleProcStatus(ctx context.Context, conn *hubpkg.Connectio
 	}
 	if proc.State().String() == "stopped" || proc.State().String() == "failed" {
 		resp["exit_code"] = proc.ExitCode()
+		if c := latestCrash(proc); c != nil {
+			resp["crash"] = c
+		}
 	}
 
 	// Add URLs from URL tracker
@@ -433,6 +361,13 @@ func (d *Daemon) hubHandleProcStatus(ctx context.Context, conn *hubpkg.Connectio
 		resp["urls"] = urls
 	}
 
+	if health, ok := d.supervisor.status(processID); ok {
+		resp["health"] = health
+	}
+	if metrics := d.latestMetrics(processID); metrics != nil {
+		resp["metrics"] = metrics
+	}
+
 	// Check for rogue process using the same port
 	if rogueInfo := d.detectRogueProcess(ctx, proc); rogueInfo != nil && rogueInfo.HasWarning {
 		resp["warning"] = fmt.Sprintf(
@@ -449,7 +384,7 @@ func (d *Daemon) hubHandleProcStatus(ctx context.Context, conn *hubpkg.Connectio
 	return conn.WriteJSON(data)
 }
 
-// hubHandleProcOutput handles PROC OUTPUT <id> [filter].
+// hubHandleProcOutput handles PROC OUTPUT <id> [key=value...] [filter].
 func (d *Daemon) hubHandleProcOutput(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
 		return conn.WriteErr(hubproto.ErrMissingParam, "process_id required")
@@ -458,15 +393,21 @@ func (d *Daemon) hubHandleProcOutput(ctx context.Context, conn *hubpkg.Connectio
 	processID := cmd.Args[0]
 	proc, err := d.hub.ProcessManager().Get(processID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("process %q not found", processID))
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
 	}
 
-	// Parse optional filter from JSON data
-	var filter hubproto.OutputFilter
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &filter); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid filter JSON: %v", err))
-		}
+	// Parse optional filter from JSON data, then key=value args
+	var req protocol.ProcOutputRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if err := parseProcOutputArgs(cmd.Args[1:], &req); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	filter := req.OutputFilter
+
+	if req.Follow {
+		return d.followProcOutput(ctx, conn, proc, req)
 	}
 
 	var output []byte
@@ -481,20 +422,7 @@ func (d *Daemon) hubHandleProcOutput(ctx context.Context, conn *hubpkg.Connectio
 
 	// Apply filters
 	lines := strings.Split(string(output), "\n")
-	var filtered []string
-
-	for _, line := range lines {
-		if filter.Grep != "" {
-			match := strings.Contains(line, filter.Grep)
-			if filter.GrepV {
-				match = !match
-			}
-			if !match {
-				continue
-			}
-		}
-		filtered = append(filtered, line)
-	}
+	filtered := grepLines(lines, filter)
 
 	// Apply head/tail limits
 	if filter.Head > 0 && len(filtered) > filter.Head {
@@ -512,6 +440,76 @@ func (d *Daemon) hubHandleProcOutput(ctx context.Context, conn *hubpkg.Connectio
 	return conn.WriteEnd()
 }
 
+// followProcOutput sends the tail of a process's output, then a CHUNK per
+// batch of new lines until the process exits, the client goes away or the
+// request's timeout passes.
+func (d *Daemon) followProcOutput(ctx context.Context, conn *hubpkg.Connection, proc *goprocess.ManagedProcess, req protocol.ProcOutputRequest) error {
+	follower, lines := NewOutputFollower(proc, req.Stream)
+	lines = grepLines(lines, req.OutputFilter)
+	if req.Tail > 0 && len(lines) > req.Tail {
+		lines = lines[len(lines)-req.Tail:]
+	}
+	writeLines := func(lines []string) error {
+		if lines = grepLines(lines, req.OutputFilter); len(lines) == 0 {
+			return nil
+		}
+		return conn.WriteChunk([]byte(strings.Join(lines, "\n") + "\n"))
+	}
+	if err := writeLines(lines); err != nil {
+		return err
+	}
+
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	if err := follower.Follow(ctx, timeout, writeLines); err != nil {
+		return err // Client gone; nothing more to write
+	}
+	return conn.WriteEnd()
+}
+
+// grepLines applies the grep filters of a PROC OUTPUT request.
+func grepLines(lines []string, filter hubproto.OutputFilter) []string {
+	if filter.Grep == "" {
+		return lines
+	}
+	var filtered []string
+	for _, line := range lines {
+		if strings.Contains(line, filter.Grep) != filter.GrepV {
+			filtered = append(filtered, line)
+		}
+	}
+	return filtered
+}
+
+// parseProcOutputArgs applies PROC OUTPUT key=value args to req.
+func parseProcOutputArgs(args []string, req *protocol.ProcOutputRequest) error {
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch key {
+		case "stream":
+			req.Stream = value
+		case "tail":
+			req.Tail, err = strconv.Atoi(value)
+		case "head":
+			req.Head, err = strconv.Atoi(value)
+		case "grep":
+			req.Grep = value
+		case "grep_v":
+			req.GrepV = true
+		case "follow":
+			req.Follow = true
+		case "timeout_ms":
+			req.TimeoutMs, err = strconv.Atoi(value)
+		default:
+			return fmt.Errorf("unknown output option %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %q", key, value)
+		}
+	}
+	return nil
+}
+
 // hubHandleProcStop handles PROC STOP <id>.
 func (d *Daemon) hubHandleProcStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
@@ -521,7 +519,7 @@ func (d *Daemon) hubHandleProcStop(ctx context.Context, conn *hubpkg.Connection,
 	processID := cmd.Args[0]
 	proc, err := d.hub.ProcessManager().Get(processID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("process %q not found", processID))
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
 	}
 
 	if !proc.IsRunning() {
@@ -535,9 +533,20 @@ func (d *Daemon) hubHandleProcStop(ctx context.Context, conn *hubpkg.Connection,
 		return conn.WriteJSON(data)
 	}
 
+	// Find what depends on the process before it goes away
+	impacts, warning := d.impactOf("stopping", depgraph.KindProcess, processID)
+	cascade := hasArg(cmd.Args[1:], "cascade")
+
+	var cascaded []string
+	if cascade {
+		cascaded = d.cascadeStop(ctx, impacts)
+	}
+	// A stop is deliberate; the restart policy must not undo it
+	d.supervisor.remove(processID)
 	if err := d.hub.ProcessManager().Stop(ctx, processID); err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to stop: %v", err))
 	}
+	d.dropProcessLabels(processID)
 
 	resp := map[string]interface{}{
 		"process_id": processID,
@@ -545,21 +554,38 @@ func (d *Daemon) hubHandleProcStop(ctx context.Context, conn *hubpkg.Connection,
 		"success":    true,
 		"message":    fmt.Sprintf("process %q stopped", processID),
 	}
+	if len(impacts) > 0 {
+		resp["impact"] = impacts
+		if cascade {
+			resp["cascaded"] = cascaded
+		} else {
+			resp["warning"] = warning + " (stop with cascade to stop them too)"
+		}
+	}
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
 }
 
+// hasArg reports whether a flag word appears among positional args.
+func hasArg(args []string, flag string) bool {
+	for _, a := range args {
+		if strings.EqualFold(a, flag) {
+			return true
+		}
+	}
+	return false
+}
+
 // hubHandleProcList handles PROC LIST [filter].
 func (d *Daemon) hubHandleProcList(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	procs := d.hub.ProcessManager().List()
 
-	// Parse directory filter from JSON data (optional)
-	var dirFilter hubproto.DirectoryFilter
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &dirFilter); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid filter JSON: %v", err))
-		}
+	// Parse directory and label filter from JSON data (optional)
+	filter, err := parseListFilter(cmd.Data)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid filter: %v", err))
 	}
+	dirFilter := filter.DirectoryFilter
 
 	// Resolve the project path for filtering
 	var projectPath string
@@ -572,7 +598,7 @@ func (d *Daemon) hubHandleProcList(ctx context.Context, conn *hubpkg.Connection,
 		sessionCode = dirFilter.SessionCode
 		session, ok := d.sessionRegistry.Get(sessionCode)
 		if !ok {
-			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found", sessionCode))
+			return d.writeNotFound(conn, cmd, entitySession, sessionCode, nil)
 		}
 		projectPath = session.ProjectPath
 	} else if dirFilter.Directory != "" {
@@ -597,6 +623,17 @@ func (d *Daemon) hubHandleProcList(ctx context.Context, conn *hubpkg.Connection,
 		filteredProcs = filtered
 	}
 
+	// Filter processes by labels
+	if len(filter.Labels) > 0 {
+		var filtered []*goprocess.ManagedProcess
+		for _, p := range filteredProcs {
+			if d.labels.matches(depgraph.KindProcess, p.ID, filter.Labels) {
+				filtered = append(filtered, p)
+			}
+		}
+		filteredProcs = filtered
+	}
+
 	entries := make([]map[string]interface{}, len(filteredProcs))
 	var warnings []string
 	for i, p := range filteredProcs {
@@ -613,6 +650,9 @@ func (d *Daemon) hubHandleProcList(ctx context.Context, conn *hubpkg.Connection,
 		if urls := d.urlTracker.GetURLs(p.ID); len(urls) > 0 {
 			entry["urls"] = urls
 		}
+		if labels := d.labels.get(depgraph.KindProcess, p.ID); labels != nil {
+			entry["labels"] = labels
+		}
 		// Check for rogue process using the same port
 		if rogueInfo := d.detectRogueProcess(ctx, p); rogueInfo != nil && rogueInfo.HasWarning {
 			warning := fmt.Sprintf(
@@ -649,7 +689,7 @@ func (d *Daemon) hubHandleProcList(ctx context.Context, conn *hubpkg.Connection,
 	return conn.WriteJSON(data)
 }
 
-// hubHandleProcCleanupPort handles PROC CLEANUP-PORT <port>.
+// hubHandleProcCleanupPort handles PROC CLEANUP-PORT <port> [dry-run].
 func (d *Daemon) hubHandleProcCleanupPort(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
 		return conn.WriteErr(hubproto.ErrMissingParam, "port required")
@@ -659,6 +699,9 @@ func (d *Daemon) hubHandleProcCleanupPort(ctx context.Context, conn *hubpkg.Conn
 	if err != nil || port <= 0 || port > 65535 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid port number")
 	}
+	if hasArg(cmd.Args[1:], protocol.DryRunArg) {
+		return writeDryRun(conn, d.portAffected(ctx, port), map[string]interface{}{"port": port})
+	}
 
 	pids, err := d.hub.ProcessManager().KillProcessByPort(ctx, port)
 	if err != nil {
@@ -711,6 +754,7 @@ func (d *Daemon) hubHandleDetect(ctx context.Context, conn *hubpkg.Connection, c
 		"path":            proj.Path,
 		"package_manager": proj.PackageManager,
 		"scripts":         project.GetCommandNames(proj),
+		"workspaces":      proj.Workspaces,
 	}
 
 	data, err := json.Marshal(resp)
@@ -738,15 +782,310 @@ func (d *Daemon) hubHandleProxy(ctx context.Context, conn *hubpkg.Connection, cm
 	case "EXEC":
 		return d.hubHandleProxyExec(conn, cmd)
 	case "TOAST":
-		return d.hubHandleProxyToast(conn, cmd)
+		return d.hubHandleProxyToast(ctx, conn, cmd)
+	case protocol.SubVerbLabel:
+		return d.hubHandleProxyLabel(conn, cmd)
+	case protocol.SubVerbRoutes:
+		return d.hubHandleProxyRoutes(conn, cmd)
+	case protocol.SubVerbUI:
+		return d.hubHandleProxyUI(conn, cmd)
+	case protocol.SubVerbIntercept:
+		return d.hubHandleProxyIntercept(ctx, conn, cmd)
 	default:
 		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
 			Code:         hubproto.ErrInvalidArgs,
 			Message:      "unknown PROXY sub-command",
 			Command:      "PROXY",
-			ValidActions: []string{"START", "STOP", "RESTART", "STATUS", "LIST", "EXEC", "TOAST"},
+			ValidActions: []string{"START", "STOP", "RESTART", "STATUS", "LIST", "EXEC", "TOAST", protocol.SubVerbLabel, protocol.SubVerbRoutes, protocol.SubVerbUI, protocol.SubVerbIntercept},
+		})
+	}
+}
+
+// hubHandleProxyRoutes handles PROXY ROUTES ADD|REMOVE|LIST <id>. ADD takes
+// a PathRoute as JSON, REMOVE the route's path as a further arg.
+func (d *Daemon) hubHandleProxyRoutes(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXY ROUTES requires: ADD|REMOVE|LIST <id>")
+	}
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[1])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[1], err)
+	}
+
+	resp := map[string]interface{}{"id": p.ID}
+	switch strings.ToUpper(cmd.Args[0]) {
+	case protocol.SubVerbAdd:
+		var route proxy.PathRoute
+		if err := decodeData(cmd, &route); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		added, err := p.AddPathRoute(route)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["added"] = added
+		d.persistPathRoutes(p)
+	case protocol.SubVerbRemove:
+		if len(cmd.Args) < 3 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXY ROUTES REMOVE requires: <id> <path>")
+		}
+		if !p.RemovePathRoute(cmd.Args[2]) {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no route for path %q", cmd.Args[2]))
+		}
+		resp["removed"] = cmd.Args[2]
+		d.persistPathRoutes(p)
+	case protocol.SubVerbList:
+	default:
+		return conn.WriteStructuredErr(&hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown PROXY ROUTES action",
+			Command:      "PROXY ROUTES",
+			ValidActions: []string{protocol.SubVerbAdd, protocol.SubVerbRemove, protocol.SubVerbList},
+		})
+	}
+	resp["path_routes"] = p.PathRoutes()
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// maxInterceptWait caps how long PROXY INTERCEPT LIST waits for a request.
+const maxInterceptWait = 5 * time.Minute
+
+// interceptListRequest is the optional JSON payload of PROXY INTERCEPT LIST.
+type interceptListRequest struct {
+	WaitMs int `json:"wait_ms,omitempty"` // Wait up to this long for a request to pause (max 300000)
+}
+
+// interceptRejectRequest is the optional JSON payload of PROXY INTERCEPT REJECT.
+type interceptRejectRequest struct {
+	Status int    `json:"status,omitempty"` // Default 403
+	Body   string `json:"body,omitempty"`
+}
+
+// hubHandleProxyIntercept handles PROXY INTERCEPT
+// ADD|REMOVE|LIST|MODIFY|RESUME|REJECT|CLEAR <id> [rule_or_request_id].
+// ADD takes the URL pattern as a further arg or an InterceptRule as JSON;
+// MODIFY and RESUME take an InterceptEdit.
+func (d *Daemon) hubHandleProxyIntercept(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXY INTERCEPT requires: ADD|REMOVE|LIST|MODIFY|RESUME|REJECT|CLEAR <id>")
+	}
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[1])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[1], err)
+	}
+	engine := p.InterceptEngine()
+	action := strings.ToUpper(cmd.Args[0])
+
+	target := ""
+	switch action {
+	case protocol.SubVerbRemove, protocol.SubVerbModify, protocol.SubVerbResume, protocol.SubVerbReject:
+		if len(cmd.Args) < 3 {
+			what := "request_id"
+			if action == protocol.SubVerbRemove {
+				what = "rule_id"
+			}
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("PROXY INTERCEPT %s requires: <id> <%s>", action, what))
+		}
+		target = cmd.Args[2]
+	}
+
+	resp := map[string]interface{}{"id": p.ID}
+	switch action {
+	case protocol.SubVerbAdd:
+		var rule proxy.InterceptRule
+		if len(cmd.Data) > 0 {
+			if err := decodeData(cmd, &rule); err != nil {
+				return writePayloadErr(conn, cmd, err)
+			}
+		}
+		if len(cmd.Args) > 2 {
+			rule.URLPattern = cmd.Args[2]
+		}
+		added, err := engine.AddRule(rule)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		engine.SetOnPause(func(req proxy.InterceptedRequest) {
+			d.notifyIntercept(p, req)
+		})
+		resp["added"] = added
+	case protocol.SubVerbRemove:
+		if !engine.RemoveRule(target) {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no intercept rule %q", target))
+		}
+		resp["removed"] = target
+	case protocol.SubVerbList:
+		var req interceptListRequest
+		if len(cmd.Data) > 0 {
+			if err := decodeData(cmd, &req); err != nil {
+				return writePayloadErr(conn, cmd, err)
+			}
+		}
+		if req.WaitMs > 0 {
+			wait := time.Duration(req.WaitMs) * time.Millisecond
+			if wait > maxInterceptWait {
+				wait = maxInterceptWait
+			}
+			waitCtx, cancel := context.WithTimeout(ctx, wait)
+			resp["pending"] = engine.Wait(waitCtx)
+			cancel()
+		}
+	case protocol.SubVerbModify:
+		var edit proxy.InterceptEdit
+		if err := decodeData(cmd, &edit); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		modified, err := engine.Modify(target, edit)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["request"] = modified
+	case protocol.SubVerbResume:
+		var edit *proxy.InterceptEdit
+		if len(cmd.Data) > 0 {
+			edit = &proxy.InterceptEdit{}
+			if err := decodeData(cmd, edit); err != nil {
+				return writePayloadErr(conn, cmd, err)
+			}
+		}
+		if err := engine.Resume(target, edit); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["resumed"] = target
+	case protocol.SubVerbReject:
+		var req interceptRejectRequest
+		if len(cmd.Data) > 0 {
+			if err := decodeData(cmd, &req); err != nil {
+				return writePayloadErr(conn, cmd, err)
+			}
+		}
+		if err := engine.Reject(target, req.Status, req.Body); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["rejected"] = target
+	case protocol.SubVerbClear:
+		resp["released"] = len(engine.Pending())
+		engine.Clear()
+	default:
+		return conn.WriteStructuredErr(&hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown PROXY INTERCEPT action",
+			Command:      "PROXY INTERCEPT",
+			ValidActions: []string{protocol.SubVerbAdd, protocol.SubVerbRemove, protocol.SubVerbList, protocol.SubVerbModify, protocol.SubVerbResume, protocol.SubVerbReject, protocol.SubVerbClear},
 		})
 	}
+	resp["rules"] = engine.Rules()
+	if _, ok := resp["pending"]; !ok {
+		resp["pending"] = engine.Pending()
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// notifyIntercept tells the proxy's project sessions that a request is
+// paused and waiting for them.
+func (d *Daemon) notifyIntercept(p *proxy.ProxyServer, req proxy.InterceptedRequest) {
+	message := fmt.Sprintf("%s %s paused by rule %s on proxy %s as %s. Inspect it with PROXY INTERCEPT LIST %s, then MODIFY, RESUME or REJECT it before %s; it continues on its own after that.",
+		req.Method, req.URL, req.RuleID, p.ID, req.ID, p.ID, req.ExpiresAt.Format("15:04:05"))
+	if p.Path == "" {
+		return
+	}
+	for _, session := range d.sessionRegistry.ListActive(p.Path, false) {
+		if session.ProjectPath != p.Path {
+			continue
+		}
+		msg, err := session.typeMessage("[agnt intercept] "+message, SendOptions{})
+		if err == nil {
+			err = d.sendMessageToOverlay(session.OverlayPath, msg)
+		}
+		if err != nil {
+			log.Printf("[WARN] PROXY INTERCEPT %s: failed to notify session %s: %v", p.ID, session.Code, err)
+		}
+	}
+}
+
+// persistPathRoutes saves a proxy's current path routes with its persisted
+// config, so they come back after a daemon restart.
+func (d *Daemon) persistPathRoutes(p *proxy.ProxyServer) {
+	if d.stateMgr == nil {
+		return
+	}
+	if pc, ok := d.stateMgr.GetProxy(p.ID); ok {
+		pc.PathRoutes = p.PathRoutes()
+		d.stateMgr.AddProxy(pc)
+	}
+}
+
+// hubHandleProxyUI handles PROXY UI <id>. With an OverlayUI as JSON it
+// replaces the proxy's overlay options and pushes them to connected pages;
+// without, it reports them.
+func (d *Daemon) hubHandleProxyUI(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXY UI requires: <id>")
+	}
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
+	}
+
+	resp := map[string]interface{}{"id": p.ID}
+	if len(cmd.Data) > 0 {
+		var ui proxy.OverlayUI
+		if err := decodeData(cmd, &ui); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		sent, err := p.SetOverlayUI(ui)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["pages_updated"] = sent
+		if d.stateMgr != nil {
+			if pc, ok := d.stateMgr.GetProxy(p.ID); ok {
+				pc.UI = ui
+				d.stateMgr.AddProxy(pc)
+			}
+		}
+	}
+	ui := p.OverlayUI()
+	resp["ui"] = ui
+	resp["ui_state"] = ui.State()
+	resp["ui_languages"] = proxy.OverlayUILanguages()
+	resp["ui_message_keys"] = proxy.OverlayUIMessageKeys()
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// proxyStartRequest is the optional JSON payload of PROXY START.
+type proxyStartRequest struct {
+	Path        string `json:"path"`
+	BindAddress string `json:"bind_address"`
+	PublicURL   string `json:"public_url"`
+	VerifyTLS   bool   `json:"verify_tls"`
+	Encrypt     bool   `json:"encrypt"`
+	NoRetarget  bool   `json:"no_retarget"`
+	// Routes send matching request Hosts to other upstreams
+	Routes []proxy.HostRoute `json:"routes"`
+	// PathRoutes send matching request paths to other upstreams
+	PathRoutes []proxy.PathRoute `json:"path_routes"`
+	// Cookies adjusts upstream Set-Cookie headers for the proxy origin
+	Cookies proxy.CookieRewrite `json:"cookies"`
+	// URLRewrite controls rewriting of upstream URLs to the proxy origin
+	URLRewrite proxy.URLRewrite `json:"url_rewrite"`
+	// Storms configures detection of repeated-request bursts
+	Storms proxy.StormDetection `json:"storms"`
+	// Banner configures the environment banner drawn on proxied pages
+	Banner proxy.EnvironmentBanner `json:"banner"`
+	// UI sets the language and theme of the overlay on proxied pages
+	UI proxy.OverlayUI `json:"ui"`
+	// BodyCapture sets what the traffic log keeps of bodies and headers
+	BodyCapture proxy.BodyCapture `json:"body_capture"`
+	// PersistLogs keeps a disk copy of the traffic log under the project
+	PersistLogs proxy.LogPersistence `json:"persist_logs"`
+	// TrustedProxies lists peers whose forwarding headers are honored
+	TrustedProxies []string `json:"trusted_proxies"`
+	// Labels tag the proxy for filtering PROXY LIST
+	Labels protocol.Labels `json:"labels"`
 }
 
 // hubHandleProxyStart handles PROXY START command.
@@ -773,21 +1112,73 @@ func (d *Daemon) hubHandleProxyStart(ctx context.Context, conn *hubpkg.Connectio
 	bindAddress := ""
 	publicURL := ""
 	verifyTLS := false
+	encrypt := false
+	noRetarget := false
+	var routes []proxy.HostRoute
+	var pathRoutes []proxy.PathRoute
+	var cookies proxy.CookieRewrite
+	var urlRewrite proxy.URLRewrite
+	var storms proxy.StormDetection
+	var banner proxy.EnvironmentBanner
+	var overlayUI proxy.OverlayUI
+	var bodyCapture proxy.BodyCapture
+	var persistLogs proxy.LogPersistence
+	var trustedProxies []string
+	var labels protocol.Labels
 	if len(cmd.Data) > 0 {
-		var data struct {
-			Path        string `json:"path"`
-			BindAddress string `json:"bind_address"`
-			PublicURL   string `json:"public_url"`
-			VerifyTLS   bool   `json:"verify_tls"`
-		}
-		if err := json.Unmarshal(cmd.Data, &data); err == nil {
-			if data.Path != "" {
-				path = data.Path
-			}
-			bindAddress = data.BindAddress
-			publicURL = data.PublicURL
-			verifyTLS = data.VerifyTLS
+		var data proxyStartRequest
+		if err := decodeData(cmd, &data); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		if data.Path != "" {
+			path = data.Path
 		}
+		bindAddress = data.BindAddress
+		publicURL = data.PublicURL
+		verifyTLS = data.VerifyTLS
+		encrypt = data.Encrypt
+		noRetarget = data.NoRetarget
+		routes = data.Routes
+		pathRoutes = data.PathRoutes
+		cookies = data.Cookies
+		urlRewrite = data.URLRewrite
+		storms = data.Storms
+		banner = data.Banner
+		overlayUI = data.UI
+		bodyCapture = data.BodyCapture
+		persistLogs = data.PersistLogs
+		trustedProxies = data.TrustedProxies
+		labels = data.Labels
+	}
+	// A loopback target of a remote project is on the remote host
+	remoteProjectPath := d.getSessionProjectPath(conn)
+	if remoteProjectPath == "" {
+		remoteProjectPath = path
+	}
+	targetURL, err = d.remoteProxyTarget(remoteProjectPath, targetURL)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("failed to forward remote target: %v", err))
+	}
+	if err := proxy.ValidateRoutes(routes, targetURL); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := proxy.ValidatePathRoutes(pathRoutes, targetURL); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := cookies.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := proxy.ValidateTrustedProxies(trustedProxies); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := persistLogs.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := labels.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := overlayUI.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
 	}
 
 	// Create proxy config
@@ -801,12 +1192,28 @@ func (d *Daemon) hubHandleProxyStart(ctx context.Context, conn *hubpkg.Connectio
 		BindAddress: bindAddress,
 		PublicURL:   publicURL,
 		VerifyTLS:   verifyTLS,
+		Encrypt:     encrypt,
+		NoRetarget:  noRetarget,
+		Routes:      routes,
+		PathRoutes:  pathRoutes,
+		Cookies:     cookies,
+		URLRewrite:  urlRewrite,
+		Storms:      storms,
+		Banner:      banner,
+		UI:          overlayUI,
+
+		BodyCapture:    bodyCapture,
+		PersistLogs:    persistLogs,
+		TrustedProxies: trustedProxies,
 	}
 
 	proxyServer, err := d.proxym.Create(ctx, proxyConfig)
 	if err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, err.Error())
 	}
+	if len(labels) > 0 {
+		labels, _ = d.labels.update(depgraph.KindProxy, proxyServer.ID, labels, nil)
+	}
 
 	// Find session for this project to get session-specific overlay endpoint
 	if path != "" {
@@ -832,18 +1239,36 @@ func (d *Daemon) hubHandleProxyStart(ctx context.Context, conn *hubpkg.Connectio
 			Port:       port,
 			MaxLogSize: maxLogSize,
 			Path:       path,
+			NoRetarget: noRetarget,
+			Routes:     routes,
+			PathRoutes: pathRoutes,
+			Cookies:    cookies,
+			URLRewrite: urlRewrite,
+			Storms:     storms,
+			Banner:     banner,
+			UI:         overlayUI,
+
+			BodyCapture:    bodyCapture,
+			PersistLogs:    persistLogs,
+			TrustedProxies: trustedProxies,
 		})
 	}
 
 	resp := map[string]interface{}{
 		"id":          proxyServer.ID,
 		"listen_addr": proxyServer.ListenAddr,
-		"target_url":  proxyServer.TargetURL.String(),
+		"target_url":  proxyServer.Target().String(),
 		"status":      "running",
 	}
 	if proxyServer.BindAddress != "" {
 		resp["bind_address"] = proxyServer.BindAddress
 	}
+	if encrypt {
+		resp["encrypted"] = true
+	}
+	if len(labels) > 0 {
+		resp["labels"] = labels
+	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
@@ -860,7 +1285,17 @@ func (d *Daemon) hubHandleProxyStop(ctx context.Context, conn *hubpkg.Connection
 	// Use session-scoped lookup to resolve the proxy
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	impacts, warning := d.impactOf("stopping", depgraph.KindProxy, p.ID)
+	message := "proxy stopped"
+	if len(impacts) > 0 {
+		if hasArg(cmd.Args[1:], "cascade") {
+			message += "; also stopped " + strings.Join(d.cascadeStop(ctx, impacts), ", ")
+		} else {
+			message += "; warning: " + warning
+		}
 	}
 
 	// Stop using the resolved full ID
@@ -873,7 +1308,7 @@ func (d *Daemon) hubHandleProxyStop(ctx context.Context, conn *hubpkg.Connection
 		d.stateMgr.RemoveProxy(p.ID)
 	}
 
-	return conn.WriteOK("proxy stopped")
+	return conn.WriteOK(message)
 }
 
 // hubHandleProxyStatus handles PROXY STATUS command.
@@ -886,13 +1321,13 @@ func (d *Daemon) hubHandleProxyStatus(conn *hubpkg.Connection, cmd *hubproto.Com
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	resp := map[string]interface{}{
 		"id":          p.ID,
 		"listen_addr": p.ListenAddr,
-		"target_url":  p.TargetURL.String(),
+		"target_url":  p.Target().String(),
 		"status":      "running",
 		"stats":       p.Stats(),
 	}
@@ -904,10 +1339,11 @@ func (d *Daemon) hubHandleProxyStatus(conn *hubpkg.Connection, cmd *hubproto.Com
 // hubHandleProxyList handles PROXY LIST command.
 func (d *Daemon) hubHandleProxyList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	// Parse filter from command data
-	var dirFilter hubproto.DirectoryFilter
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &dirFilter)
+	filter, err := parseListFilter(cmd.Data)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid filter: %v", err))
 	}
+	dirFilter := filter.DirectoryFilter
 
 	// Resolve filter path from session code or directory
 	filterPath := ""
@@ -932,15 +1368,22 @@ func (d *Daemon) hubHandleProxyList(conn *hubpkg.Connection, cmd *hubproto.Comma
 		if !dirFilter.Global && filterPath != "" && filterPath != "." && proxyPath != filterPath {
 			continue
 		}
+		if !d.labels.matches(depgraph.KindProxy, p.ID, filter.Labels) {
+			continue
+		}
 
-		result = append(result, map[string]interface{}{
+		entry := map[string]interface{}{
 			"id":          p.ID,
 			"listen_addr": p.ListenAddr,
-			"target_url":  p.TargetURL.String(),
+			"target_url":  p.Target().String(),
 			"status":      "running",
 			"running":     true,
 			"path":        p.Path,
-		})
+		}
+		if labels := d.labels.get(depgraph.KindProxy, p.ID); labels != nil {
+			entry["labels"] = labels
+		}
+		result = append(result, entry)
 	}
 
 	data, _ := json.Marshal(map[string]interface{}{
@@ -960,7 +1403,7 @@ func (d *Daemon) hubHandleProxyExec(conn *hubpkg.Connection, cmd *hubproto.Comma
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	// Code is in the data payload
@@ -1003,8 +1446,20 @@ func (d *Daemon) hubHandleProxyExec(conn *hubpkg.Connection, cmd *hubproto.Comma
 	}
 }
 
-// hubHandleProxyToast handles PROXY TOAST command.
-func (d *Daemon) hubHandleProxyToast(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+// proxyToastRequest is the JSON payload of PROXY TOAST.
+type proxyToastRequest struct {
+	Message  string                 `json:"toast_message"`
+	Type     string                 `json:"toast_type"`
+	Title    string                 `json:"toast_title"`
+	Duration int                    `json:"toast_duration"`
+	Actions  []protocol.ToastAction `json:"actions"` // Buttons; a click goes to the session or, with wait_ms, to the caller
+	Session  string                 `json:"session"` // Session a click is typed into (default: the proxy's project session)
+	WaitMs   int                    `json:"wait_ms"` // Wait this long for a click and return it
+}
+
+// hubHandleProxyToast handles PROXY TOAST command. A toast with actions is
+// registered for its click; with wait_ms the command returns the click.
+func (d *Daemon) hubHandleProxyToast(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	debug.Log("daemon", "PROXY TOAST: args=%v dataLen=%d", cmd.Args, len(cmd.Data))
 
 	if len(cmd.Args) < 1 {
@@ -1018,7 +1473,7 @@ func (d *Daemon) hubHandleProxyToast(conn *hubpkg.Connection, cmd *hubproto.Comm
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
 		debug.Log("daemon", "PROXY TOAST: proxy not found: %v", err)
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 	debug.Log("daemon", "PROXY TOAST: found proxy %s at %s", p.ID, p.ListenAddr)
 
@@ -1028,15 +1483,9 @@ func (d *Daemon) hubHandleProxyToast(conn *hubpkg.Connection, cmd *hubproto.Comm
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXY TOAST requires toast config")
 	}
 
-	var toast struct {
-		Message  string `json:"toast_message"`
-		Type     string `json:"toast_type"`
-		Title    string `json:"toast_title"`
-		Duration int    `json:"toast_duration"`
-	}
-	if err := json.Unmarshal(cmd.Data, &toast); err != nil {
-		debug.Log("daemon", "PROXY TOAST: failed to unmarshal: %v", err)
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid toast config: "+err.Error())
+	var toast proxyToastRequest
+	if err := decodeData(cmd, &toast); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if toast.Type == "" {
@@ -1047,12 +1496,30 @@ func (d *Daemon) hubHandleProxyToast(conn *hubpkg.Connection, cmd *hubproto.Comm
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "toast_message is required")
 	}
 
-	debug.Log("daemon", "PROXY TOAST: sending type=%s title=%q message=%q", toast.Type, toast.Title, toast.Message)
+	debug.Log("daemon", "PROXY TOAST: sending type=%s title=%q message=%q actions=%d", toast.Type, toast.Title, toast.Message, len(toast.Actions))
+
+	t := proxy.Toast{Type: toast.Type, Title: toast.Title, Message: toast.Message, Duration: toast.Duration, Actions: toast.Actions}
+	var pending *pendingToast
+	if len(toast.Actions) > 0 {
+		if toast.Session != "" {
+			if _, ok := d.sessionRegistry.Get(toast.Session); !ok {
+				return d.writeNotFound(conn, cmd, entitySession, toast.Session, nil)
+			}
+		}
+		pending = &pendingToast{title: toast.Title, message: toast.Message, actions: toast.Actions, session: toast.Session, created: time.Now()}
+		if toast.WaitMs > 0 {
+			pending.waiter = make(chan proxy.ToastClick, 1)
+		}
+		t.ID = d.toasts.add(pending)
+	}
 
-	sentCount, err := p.BroadcastToast(toast.Type, toast.Title, toast.Message, toast.Duration)
+	sentCount, err := p.SendToast(t)
 	if err != nil {
 		debug.Log("daemon", "PROXY TOAST: broadcast error: %v", err)
-		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+		if t.ID != "" {
+			d.toasts.take(t.ID)
+		}
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
 	}
 
 	debug.Log("daemon", "PROXY TOAST: sent to %d clients", sentCount)
@@ -1061,6 +1528,28 @@ func (d *Daemon) hubHandleProxyToast(conn *hubpkg.Connection, cmd *hubproto.Comm
 		"success":    true,
 		"sent_count": sentCount,
 	}
+	if t.ID != "" {
+		resp["toast_id"] = t.ID
+	}
+
+	if pending != nil && pending.waiter != nil {
+		timer := time.NewTimer(min(time.Duration(toast.WaitMs)*time.Millisecond, MaxToastWait))
+		defer timer.Stop()
+		select {
+		case click := <-pending.waiter:
+			resp["action"] = click
+		case <-timer.C:
+			d.toasts.take(t.ID)
+			p.DismissToast(t.ID)
+			resp["timed_out"] = true
+		case <-ctx.Done():
+			d.toasts.take(t.ID)
+			p.DismissToast(t.ID)
+			return ctx.Err()
+		case <-d.ctx.Done():
+			return conn.WriteErr(hubproto.ErrInvalidState, "daemon shutting down")
+		}
+	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
@@ -1078,12 +1567,16 @@ func (d *Daemon) hubHandleProxyLog(ctx context.Context, conn *hubpkg.Connection,
 		return d.hubHandleProxyLogClear(conn, cmd)
 	case "STATS":
 		return d.hubHandleProxyLogStats(conn, cmd)
+	case protocol.SubVerbAggregate:
+		return d.hubHandleProxyLogAggregate(conn, cmd)
+	case protocol.SubVerbReplay:
+		return d.hubHandleProxyLogReplay(ctx, conn, cmd)
 	default:
 		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
 			Code:         hubproto.ErrInvalidArgs,
 			Message:      "unknown PROXYLOG sub-command",
 			Command:      "PROXYLOG",
-			ValidActions: []string{"QUERY", "SUMMARY", "CLEAR", "STATS"},
+			ValidActions: []string{"QUERY", "SUMMARY", "CLEAR", "STATS", protocol.SubVerbAggregate, protocol.SubVerbReplay},
 		})
 	}
 }
@@ -1098,12 +1591,12 @@ func (d *Daemon) hubHandleProxyLogQuery(conn *hubpkg.Connection, cmd *hubproto.C
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	var filter proxy.LogFilter
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &filter)
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	entries := p.Logger().Query(filter)
@@ -1122,7 +1615,7 @@ func (d *Daemon) hubHandleProxyLogSummary(conn *hubpkg.Connection, cmd *hubproto
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	// For summary, we return stats plus recent entries
@@ -1146,10 +1639,12 @@ func (d *Daemon) hubHandleProxyLogClear(conn *hubpkg.Connection, cmd *hubproto.C
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	p.Logger().Clear()
+	p.ResetTraffic()
+	p.ResetRequestStorms()
 	return conn.WriteOK("logs cleared")
 }
 
@@ -1163,38 +1658,98 @@ func (d *Daemon) hubHandleProxyLogStats(conn *hubpkg.Connection, cmd *hubproto.C
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
-	stats := p.Logger().Stats()
+	var filter proxy.RouteStatsFilter
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if err := filter.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
 
-	data, _ := json.Marshal(stats)
+	logger := p.Logger()
+	data, _ := json.Marshal(struct {
+		proxy.LoggerStats
+		Routes []proxy.RouteStats `json:"routes"`
+	}{logger.Stats(), logger.RouteStats(filter)})
 	return conn.WriteJSON(data)
 }
 
-// hubHandleCurrentPage handles the CURRENTPAGE command.
-func (d *Daemon) hubHandleCurrentPage(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	debug.Log("daemon", "CURRENTPAGE %s: args=%v", cmd.SubVerb, cmd.Args)
-	switch cmd.SubVerb {
-	case "LIST", "":
-		return d.hubHandleCurrentPageList(conn, cmd)
-	case "GET":
-		return d.hubHandleCurrentPageGet(conn, cmd)
-	case "SUMMARY":
-		return d.hubHandleCurrentPageSummary(conn, cmd)
-	case "CLEAR":
-		return d.hubHandleCurrentPageClear(conn, cmd)
-	default:
-		return conn.WriteStructuredErr(&hubproto.StructuredError{
-			Code:         hubproto.ErrInvalidArgs,
-			Message:      "unknown CURRENTPAGE sub-command",
-			Command:      "CURRENTPAGE",
-			ValidActions: []string{"LIST", "GET", "SUMMARY", "CLEAR"},
-		})
+// hubHandleProxyLogAggregate handles PROXYLOG AGGREGATE command.
+func (d *Daemon) hubHandleProxyLogAggregate(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXYLOG AGGREGATE requires: <proxy_id>")
 	}
-}
 
-// hubHandleCurrentPageList handles CURRENTPAGE LIST command.
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
+	}
+
+	data, _ := json.Marshal(p.Traffic())
+	return conn.WriteJSON(data)
+}
+
+// hubHandleProxyLogReplay handles PROXYLOG REPLAY <proxy_id> <entry_id>.
+// The optional JSON payload overrides the method, URL, headers or body.
+func (d *Daemon) hubHandleProxyLogReplay(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXYLOG REPLAY requires: <proxy_id> <entry_id>")
+	}
+
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
+	}
+
+	var overrides proxy.ReplayOverrides
+	if err := decodeData(cmd, &overrides); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	result, err := p.Replay(ctx, cmd.Args[1], overrides)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	data, _ := json.Marshal(result)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleCurrentPage handles the CURRENTPAGE command.
+func (d *Daemon) hubHandleCurrentPage(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "CURRENTPAGE %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "LIST", "":
+		return d.hubHandleCurrentPageList(conn, cmd)
+	case "GET":
+		return d.hubHandleCurrentPageGet(conn, cmd)
+	case "SUMMARY":
+		return d.hubHandleCurrentPageSummary(conn, cmd)
+	case "CLEAR":
+		return d.hubHandleCurrentPageClear(conn, cmd)
+	case protocol.SubVerbWaitForIdle:
+		return d.hubHandleCurrentPageWaitForIdle(ctx, conn, cmd)
+	case protocol.SubVerbSnapshot:
+		return d.hubHandleCurrentPageSnapshot(ctx, conn, cmd)
+	case protocol.SubVerbSnapshots:
+		return d.hubHandleCurrentPageSnapshots(conn, cmd)
+	case protocol.SubVerbDiff:
+		return d.hubHandleCurrentPageDiff(conn, cmd)
+	case protocol.SubVerbCoverage:
+		return d.hubHandleCurrentPageCoverage(ctx, conn, cmd)
+	default:
+		return conn.WriteStructuredErr(&hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown CURRENTPAGE sub-command",
+			Command:      "CURRENTPAGE",
+			ValidActions: []string{"LIST", "GET", "SUMMARY", "CLEAR", protocol.SubVerbWaitForIdle, protocol.SubVerbSnapshot, protocol.SubVerbSnapshots, protocol.SubVerbDiff, protocol.SubVerbCoverage},
+		})
+	}
+}
+
+// hubHandleCurrentPageList handles CURRENTPAGE LIST command.
 func (d *Daemon) hubHandleCurrentPageList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "CURRENTPAGE LIST requires: <proxy_id>")
@@ -1204,7 +1759,7 @@ func (d *Daemon) hubHandleCurrentPageList(conn *hubpkg.Connection, cmd *hubproto
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	// Return lightweight summaries instead of full sessions with massive arrays
@@ -1230,7 +1785,7 @@ func (d *Daemon) hubHandleCurrentPageGet(conn *hubpkg.Connection, cmd *hubproto.
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	session, ok := p.PageTracker().GetSession(sessionID)
@@ -1253,7 +1808,7 @@ func (d *Daemon) hubHandleCurrentPageSummary(conn *hubpkg.Connection, cmd *hubpr
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	session, ok := p.PageTracker().GetSession(sessionID)
@@ -1276,13 +1831,170 @@ func (d *Daemon) hubHandleCurrentPageClear(conn *hubpkg.Connection, cmd *hubprot
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	p.PageTracker().Clear()
 	return conn.WriteOK("page sessions cleared")
 }
 
+// hubHandleCurrentPageWaitForIdle handles CURRENTPAGE WAIT-FOR-IDLE command.
+// CURRENTPAGE WAIT-FOR-IDLE <proxy_id> [session_id]
+func (d *Daemon) hubHandleCurrentPageWaitForIdle(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CURRENTPAGE WAIT-FOR-IDLE requires: <proxy_id> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	sessionID := ""
+	if len(cmd.Args) > 1 {
+		sessionID = cmd.Args[1]
+	}
+
+	var opts proxy.IdleOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+
+	result, err := p.WaitForIdle(ctx, sessionID, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(result)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleCurrentPageSnapshot handles CURRENTPAGE SNAPSHOT command.
+// CURRENTPAGE SNAPSHOT <proxy_id> [session_id]
+func (d *Daemon) hubHandleCurrentPageSnapshot(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CURRENTPAGE SNAPSHOT requires: <proxy_id> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	sessionID := ""
+	if len(cmd.Args) > 1 {
+		sessionID = cmd.Args[1]
+	}
+
+	var opts proxy.DOMSnapshotOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+
+	snapshot, err := p.CaptureDOMSnapshot(ctx, sessionID, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(snapshot)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleCurrentPageCoverage handles CURRENTPAGE COVERAGE command.
+// CURRENTPAGE COVERAGE <proxy_id> [session_id]
+func (d *Daemon) hubHandleCurrentPageCoverage(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CURRENTPAGE COVERAGE requires: <proxy_id> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	sessionID := ""
+	if len(cmd.Args) > 1 {
+		sessionID = cmd.Args[1]
+	}
+
+	var opts proxy.CSSCoverageOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+
+	report, err := p.CSSCoverage(ctx, sessionID, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(report)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleCurrentPageSnapshots handles CURRENTPAGE SNAPSHOTS command.
+func (d *Daemon) hubHandleCurrentPageSnapshots(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CURRENTPAGE SNAPSHOTS requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	snapshots := p.DOMSnapshots()
+	resp := map[string]interface{}{
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+	}
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleCurrentPageDiff handles CURRENTPAGE DIFF command.
+// CURRENTPAGE DIFF <proxy_id> <from_snapshot> <to_snapshot>
+func (d *Daemon) hubHandleCurrentPageDiff(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 3 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CURRENTPAGE DIFF requires: <proxy_id> <from_snapshot> <to_snapshot>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	diff, err := p.DiffDOMSnapshots(cmd.Args[1], cmd.Args[2])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+
+	data, _ := json.Marshal(diff)
+	return conn.WriteJSON(data)
+}
+
 // hubHandleOverlay handles the OVERLAY command.
 func (d *Daemon) hubHandleOverlay(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	debug.Log("daemon", "OVERLAY %s: args=%v", cmd.SubVerb, cmd.Args)
@@ -1307,14 +2019,17 @@ func (d *Daemon) hubHandleOverlay(ctx context.Context, conn *hubpkg.Connection,
 	}
 }
 
+// overlaySetRequest is the JSON payload of OVERLAY SET.
+type overlaySetRequest struct {
+	Endpoint string `json:"endpoint"`
+}
+
 // hubHandleOverlaySet handles OVERLAY SET command.
 func (d *Daemon) hubHandleOverlaySet(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var config struct {
-		Endpoint string `json:"endpoint"`
-	}
+	var config overlaySetRequest
 
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &config)
+	if err := decodeData(cmd, &config); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if config.Endpoint == "" {
@@ -1354,6 +2069,11 @@ func (d *Daemon) hubHandleOverlayActivity(conn *hubpkg.Connection, cmd *hubproto
 	// Parse active state
 	active := cmd.Args[0] == "true"
 
+	// Track the wrapped tool's state on the reporting session
+	if code := conn.SessionCode(); code != "" {
+		d.sessionRegistry.SetActivity(code, active)
+	}
+
 	// Get proxy IDs (if specified)
 	proxyIDs := cmd.Args[1:]
 
@@ -1390,18 +2110,19 @@ func (d *Daemon) hubHandleOverlayActivity(conn *hubpkg.Connection, cmd *hubproto
 	return conn.WriteJSON(data)
 }
 
+// outputPreviewRequest is the JSON payload of OVERLAY OUTPUT-PREVIEW.
+type outputPreviewRequest struct {
+	Lines    []string `json:"lines"`
+	ProxyIDs []string `json:"proxy_ids"`
+}
+
 // hubHandleOverlayOutputPreview handles OVERLAY OUTPUT-PREVIEW command.
 // Broadcasts output preview lines to connected browsers via proxies.
 func (d *Daemon) hubHandleOverlayOutputPreview(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var payload struct {
-		Lines    []string `json:"lines"`
-		ProxyIDs []string `json:"proxy_ids"`
-	}
+	var payload outputPreviewRequest
 
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &payload); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid payload")
-		}
+	if err := decodeData(cmd, &payload); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if len(payload.Lines) == 0 {
@@ -1450,16 +2171,57 @@ func (d *Daemon) hubHandleTunnel(ctx context.Context, conn *hubpkg.Connection, c
 		return d.hubHandleTunnelStatus(conn, cmd)
 	case "LIST":
 		return d.hubHandleTunnelList(conn, cmd)
+	case "RESUME":
+		return d.hubHandleTunnelResume(conn, cmd)
+	case protocol.SubVerbLabel:
+		return d.hubHandleTunnelLabel(conn, cmd)
+	case protocol.SubVerbEvents:
+		return d.hubHandleTunnelEvents(conn, cmd)
 	default:
 		return conn.WriteStructuredErr(&hubproto.StructuredError{
 			Code:         hubproto.ErrInvalidArgs,
 			Message:      "unknown TUNNEL sub-command",
 			Command:      "TUNNEL",
-			ValidActions: []string{"START", "STOP", "STATUS", "LIST"},
+			ValidActions: []string{"START", "STOP", "STATUS", "LIST", "RESUME", protocol.SubVerbLabel, protocol.SubVerbEvents},
 		})
 	}
 }
 
+// tunnelStartRequest is the JSON payload of TUNNEL START.
+type tunnelStartRequest struct {
+	Provider   string `json:"provider"`
+	LocalPort  int    `json:"local_port"`
+	LocalHost  string `json:"local_host"`
+	ProxyID    string `json:"proxy_id"`
+	BinaryPath string `json:"binary_path"`
+	MaxBytes   int64  `json:"max_bytes"`
+	Expires    string `json:"expires"`
+	Command    string `json:"command"`
+	URLPattern string `json:"url_pattern"`
+
+	NoReconnect bool            `json:"no_reconnect"`
+	Labels      protocol.Labels `json:"labels"`
+
+	// Credentials the linked proxy requires of requests through the tunnel
+	AccessToken string `json:"access_token"` // "auto" generates one
+	BasicAuth   string `json:"basic_auth"`   // user:password
+}
+
+// tunnelAuth returns the credentials requested for the tunnel.
+func (r tunnelStartRequest) tunnelAuth() (proxy.TunnelAuth, error) {
+	auth := proxy.TunnelAuth{Token: r.AccessToken}
+	if auth.Token == "auto" {
+		auth.Token = proxy.NewAccessToken()
+	}
+	if r.BasicAuth != "" {
+		var ok bool
+		if auth.Username, auth.Password, ok = strings.Cut(r.BasicAuth, ":"); !ok {
+			return auth, fmt.Errorf("basic_auth must be user:password")
+		}
+	}
+	return auth, auth.Validate()
+}
+
 // hubHandleTunnelStart handles TUNNEL START command.
 func (d *Daemon) hubHandleTunnelStart(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
@@ -1468,16 +2230,10 @@ func (d *Daemon) hubHandleTunnelStart(ctx context.Context, conn *hubpkg.Connecti
 
 	tunnelID := cmd.Args[0]
 
-	var config struct {
-		Provider   string `json:"provider"`
-		LocalPort  int    `json:"local_port"`
-		LocalHost  string `json:"local_host"`
-		ProxyID    string `json:"proxy_id"`
-		BinaryPath string `json:"binary_path"`
-	}
+	var config tunnelStartRequest
 
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &config)
+	if err := decodeData(cmd, &config); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if config.Provider == "" {
@@ -1486,35 +2242,83 @@ func (d *Daemon) hubHandleTunnelStart(ctx context.Context, conn *hubpkg.Connecti
 	if config.LocalPort == 0 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "local_port is required")
 	}
-
-	// Get project path from session for session scoping
-	projectPath := d.getSessionProjectPath(conn)
+	if config.MaxBytes < 0 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "max_bytes must not be negative")
+	}
+	if err := config.Labels.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	var expires time.Duration
+	if config.Expires != "" {
+		var err error
+		expires, err = time.ParseDuration(config.Expires)
+		if err != nil || expires <= 0 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid expires %q (use a duration like 30m or 2h)", config.Expires))
+		}
+	}
 
 	tunnelConfig := tunnel.Config{
-		Provider:   tunnel.Provider(config.Provider),
-		LocalPort:  config.LocalPort,
-		LocalHost:  config.LocalHost,
-		BinaryPath: config.BinaryPath,
-		Path:       projectPath,
+		Provider:    tunnel.Provider(config.Provider),
+		LocalPort:   config.LocalPort,
+		LocalHost:   config.LocalHost,
+		BinaryPath:  config.BinaryPath,
+		Path:        d.getSessionProjectPath(conn),
+		MaxBytes:    config.MaxBytes,
+		Expires:     expires,
+		Command:     config.Command,
+		URLPattern:  config.URLPattern,
+		NoReconnect: config.NoReconnect,
+	}
+	if err := tunnelConfig.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	auth, err := config.tunnelAuth()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
 	}
 
-	t, err := d.tunnelm.Start(ctx, tunnelID, tunnelConfig)
-	if err != nil {
-		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	// Resolve the linked proxy up front so cap notifications can reach its browsers
+	var linkedProxy *proxy.ProxyServer
+	if config.ProxyID != "" {
+		linkedProxy, _ = d.getSessionScopedProxy(conn, config.ProxyID)
 	}
 
-	// Wait for public URL
-	publicURL, err := t.WaitForURL(ctx)
-	if err != nil {
-		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("tunnel started but failed to get URL: %v", err))
+	// Protect the proxy before the public URL exists
+	if !auth.IsZero() {
+		if linkedProxy == nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, "access_token and basic_auth require proxy_id of a running proxy, which checks them")
+		}
+		linkedProxy.SetTunnelAuth(auth)
 	}
 
-	// Update proxy public URL if proxy_id specified
-	if config.ProxyID != "" {
-		if p, err := d.getSessionScopedProxy(conn, config.ProxyID); err == nil {
-			p.SetPublicURL(publicURL)
+	t, publicURL, err := d.startLinkedTunnel(ctx, tunnelID, tunnelConfig, config.ProxyID, linkedProxy)
+	if err != nil {
+		if !auth.IsZero() {
+			linkedProxy.SetTunnelAuth(proxy.TunnelAuth{})
 		}
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
 	}
+	if !auth.IsZero() {
+		// The requirement ends with the tunnel, unless another one replaced it
+		go func() {
+			<-t.Done()
+			if linkedProxy.TunnelAuth() == auth {
+				linkedProxy.SetTunnelAuth(proxy.TunnelAuth{})
+			}
+		}()
+	}
+	expiresAt := t.ExpiresAt()
+	// A new tunnel starts with its own labels, which end with it
+	d.labels.set(depgraph.KindTunnel, tunnelID, config.Labels)
+	labels := d.labels.get(depgraph.KindTunnel, tunnelID)
+	go func() {
+		<-t.Done()
+		if cur, err := d.tunnelm.Get(tunnelID); err == nil && cur != t && cur.ID() == tunnelID {
+			return // Another tunnel took the ID
+		}
+		d.labels.remove(depgraph.KindTunnel, tunnelID)
+	}()
 
 	resp := map[string]interface{}{
 		"id":         tunnelID,
@@ -1523,11 +2327,123 @@ func (d *Daemon) hubHandleTunnelStart(ctx context.Context, conn *hubpkg.Connecti
 		"public_url": publicURL,
 		"status":     "running",
 	}
+	if config.MaxBytes > 0 {
+		resp["max_bytes"] = config.MaxBytes
+	}
+	if !expiresAt.IsZero() {
+		resp["expires_at"] = expiresAt.Format(time.RFC3339)
+	}
+	if len(labels) > 0 {
+		resp["labels"] = labels
+	}
+	if mode := auth.Mode(); mode != "" {
+		resp["auth"] = mode
+	}
+	if auth.Token != "" {
+		resp["access_token"] = auth.Token
+		resp["share_url"] = strings.TrimRight(publicURL, "/") + "/?" + proxy.AccessTokenParam + "=" + url.QueryEscape(auth.Token)
+	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
 }
 
+// startLinkedTunnel starts a tunnel, waits for its public URL and points the
+// linked proxy (if any) at it. Cap, expiry and health callbacks are wired to
+// notify the proxy's browsers, and TTL tunnels are recorded in the project
+// audit log.
+func (d *Daemon) startLinkedTunnel(ctx context.Context, tunnelID string, tunnelConfig tunnel.Config, proxyID string, linkedProxy *proxy.ProxyServer) (*tunnel.Tunnel, string, error) {
+	projectPath := tunnelConfig.Path
+
+	// Public URL captured for the expiry callbacks once the tunnel reports
+	// it; the callbacks run on the tunnel's goroutines
+	var sharedURL atomic.Pointer[string]
+	currentURL := func() string {
+		if u := sharedURL.Load(); u != nil {
+			return *u
+		}
+		return ""
+	}
+
+	tunnelConfig.OnCapExceeded = func(usage tunnel.Usage) {
+		d.notifyTunnelPaused(tunnelID, linkedProxy, usage)
+	}
+	tunnelConfig.OnExpiryWarning = func(remaining time.Duration) {
+		message := fmt.Sprintf("Tunnel %s expires in %s and will stop sharing %s.", tunnelID, remaining.Round(time.Second), currentURL())
+		log.Printf("[WARN] %s", message)
+		if linkedProxy != nil {
+			linkedProxy.BroadcastToast("warning", "Tunnel expiring", message, 0)
+		}
+	}
+	tunnelConfig.OnExpired = func() {
+		d.handleTunnelExpired(tunnelID, string(tunnelConfig.Provider), proxyID, projectPath, currentURL(), linkedProxy)
+	}
+	tunnelConfig.OnEvent = func(event tunnel.Event) {
+		switch event.Type {
+		case tunnel.EventReconnected:
+			// Quick tunnels come back on a new URL
+			if event.PublicURL != currentURL() {
+				d.recordTunnelURL(tunnelID, proxyID, projectPath, event.PublicURL)
+			}
+			sharedURL.Store(&event.PublicURL)
+			if linkedProxy != nil {
+				linkedProxy.SetPublicURL(event.PublicURL)
+			}
+		case tunnel.EventGaveUp:
+			if linkedProxy != nil {
+				linkedProxy.SetPublicURL("")
+			}
+		}
+		d.notifyTunnelEvent(event, linkedProxy)
+	}
+
+	t, err := d.tunnelm.Start(ctx, tunnelID, tunnelConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Wait for public URL
+	publicURL, err := t.WaitForURL(ctx)
+	if err != nil {
+		return t, "", fmt.Errorf("tunnel started but failed to get URL: %v", err)
+	}
+
+	sharedURL.Store(&publicURL)
+	d.recordTunnelURL(tunnelID, proxyID, projectPath, publicURL)
+
+	// Update proxy public URL if a proxy is linked
+	if linkedProxy != nil {
+		linkedProxy.SetPublicURL(publicURL)
+	}
+
+	expiresAt := t.ExpiresAt()
+	if !expiresAt.IsZero() && projectPath != "" {
+		if err := tunnel.AppendAudit(projectPath, tunnel.AuditRecord{
+			Event:     "started",
+			TunnelID:  tunnelID,
+			Provider:  tunnelConfig.Provider,
+			PublicURL: publicURL,
+			ProxyID:   proxyID,
+			ExpiresAt: &expiresAt,
+		}); err != nil {
+			log.Printf("[WARN] failed to record tunnel audit entry: %v", err)
+		}
+	}
+
+	return t, publicURL, nil
+}
+
+// recordTunnelURL records a tunnel getting a public URL in the EVENTS log.
+func (d *Daemon) recordTunnelURL(tunnelID, proxyID, projectPath, publicURL string) {
+	d.eventLog.record(DaemonEvent{
+		Type:    EventTunnelURL,
+		Source:  tunnelID,
+		Path:    projectPath,
+		Message: fmt.Sprintf("tunnel %s is at %s", tunnelID, publicURL),
+		Data:    map[string]interface{}{"public_url": publicURL, "proxy_id": proxyID},
+	})
+}
+
 // hubHandleTunnelStop handles TUNNEL STOP command.
 func (d *Daemon) hubHandleTunnelStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
@@ -1539,7 +2455,7 @@ func (d *Daemon) hubHandleTunnelStop(ctx context.Context, conn *hubpkg.Connectio
 	// Use session-scoped lookup to find the tunnel
 	t, err := d.getSessionScopedTunnel(conn, tunnelID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityTunnel, tunnelID, err)
 	}
 
 	// Stop using the resolved full ID
@@ -1561,7 +2477,7 @@ func (d *Daemon) hubHandleTunnelStatus(conn *hubpkg.Connection, cmd *hubproto.Co
 	// Use session-scoped lookup to find the tunnel
 	t, err := d.getSessionScopedTunnel(conn, tunnelID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityTunnel, tunnelID, err)
 	}
 
 	info := t.Info()
@@ -1576,18 +2492,189 @@ func (d *Daemon) hubHandleTunnelStatus(conn *hubpkg.Connection, cmd *hubproto.Co
 	if info.Error != "" {
 		resp["error"] = info.Error
 	}
+	if info.Usage != nil {
+		resp["bytes_in"] = info.Usage.BytesIn
+		resp["bytes_out"] = info.Usage.BytesOut
+		resp["rate_in"] = info.Usage.RateIn
+		resp["rate_out"] = info.Usage.RateOut
+		if info.Usage.MaxBytes > 0 {
+			resp["max_bytes"] = info.Usage.MaxBytes
+		}
+	}
+	if info.ExpiresAt != "" {
+		resp["expires_at"] = info.ExpiresAt
+	}
+	if info.Reconnects > 0 {
+		resp["reconnects"] = info.Reconnects
+	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
 }
 
+// hubHandleTunnelResume handles TUNNEL RESUME command.
+func (d *Daemon) hubHandleTunnelResume(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "TUNNEL RESUME requires: <id>")
+	}
+
+	t, err := d.getSessionScopedTunnel(conn, cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityTunnel, cmd.Args[0], err)
+	}
+
+	if err := t.Resume(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+
+	return conn.WriteOK("tunnel resumed")
+}
+
+// handleTunnelExpired clears the share after a tunnel's TTL stopped it:
+// the linked proxy loses its public URL, browsers get a toast, and the
+// expiry is recorded in the project's tunnel audit log.
+func (d *Daemon) handleTunnelExpired(tunnelID, provider, proxyID, projectPath, publicURL string, p *proxy.ProxyServer) {
+	log.Printf("[INFO] Tunnel %s expired; stopped sharing %s", tunnelID, publicURL)
+
+	if p != nil {
+		p.SetPublicURL("")
+		p.BroadcastToast("info", "Tunnel expired", fmt.Sprintf("Tunnel %s expired and %s is no longer shared.", tunnelID, publicURL), 0)
+	}
+
+	if projectPath == "" {
+		return
+	}
+	if err := tunnel.AppendAudit(projectPath, tunnel.AuditRecord{
+		Event:     "expired",
+		TunnelID:  tunnelID,
+		Provider:  tunnel.Provider(provider),
+		PublicURL: publicURL,
+		ProxyID:   proxyID,
+	}); err != nil {
+		log.Printf("[WARN] failed to record tunnel audit entry: %v", err)
+	}
+}
+
+// notifyTunnelPaused reports a tunnel paused by its bandwidth cap: a toast and
+// a log entry on the linked proxy (if any) plus an overlay event.
+func (d *Daemon) notifyTunnelPaused(tunnelID string, p *proxy.ProxyServer, usage tunnel.Usage) {
+	message := fmt.Sprintf("Tunnel %s paused: bandwidth cap of %d bytes exceeded (in %d, out %d). Use TUNNEL RESUME to continue.",
+		tunnelID, usage.MaxBytes, usage.BytesIn, usage.BytesOut)
+	log.Printf("[WARN] %s", message)
+
+	if p == nil {
+		return
+	}
+
+	p.BroadcastToast("warning", "Tunnel paused", message, 0)
+	p.Logger().LogCustom(proxy.CustomLog{
+		ID:        fmt.Sprintf("tunnel-paused-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Level:     "warn",
+		Message:   message,
+		Data: map[string]interface{}{
+			"event":     "tunnel_paused",
+			"tunnel_id": tunnelID,
+			"bytes_in":  usage.BytesIn,
+			"bytes_out": usage.BytesOut,
+			"max_bytes": usage.MaxBytes,
+		},
+	})
+	p.OverlayNotifier().NotifyCustom(p.ID, "tunnel_paused", map[string]interface{}{
+		"tunnel_id": tunnelID,
+		"usage":     usage,
+	})
+}
+
+// notifyTunnelEvent reports a tunnel health event: a log line, a toast on the
+// linked proxy (if any), and, for state changes, a message to the project's
+// active sessions. Backoff retries only reach the log.
+func (d *Daemon) notifyTunnelEvent(event tunnel.Event, p *proxy.ProxyServer) {
+	var level, title, message string
+	switch event.Type {
+	case tunnel.EventDisconnected:
+		level, title = "warning", "Tunnel disconnected"
+		message = fmt.Sprintf("Tunnel %s disconnected (%s); reconnecting.", event.TunnelID, orUnknown(event.Error))
+	case tunnel.EventUnhealthy:
+		level, title = "warning", "Tunnel unreachable"
+		message = fmt.Sprintf("Tunnel %s stopped answering at %s (%s); restarting it.", event.TunnelID, event.PublicURL, event.Error)
+	case tunnel.EventReconnected:
+		level, title = "success", "Tunnel reconnected"
+		message = fmt.Sprintf("Tunnel %s reconnected after %d attempt(s) at %s.", event.TunnelID, event.Attempt, event.PublicURL)
+	case tunnel.EventGaveUp:
+		level, title = "error", "Tunnel failed"
+		message = fmt.Sprintf("Tunnel %s gave up after %d reconnect attempts (%s). Start it again with TUNNEL START.", event.TunnelID, event.Attempt, orUnknown(event.Error))
+	default:
+		log.Printf("[INFO] Tunnel %s %s (attempt %d, retry in %s): %s", event.TunnelID, event.Type, event.Attempt, event.RetryIn, event.Error)
+		return
+	}
+	log.Printf("[WARN] %s", message)
+
+	if p != nil {
+		p.BroadcastToast(level, title, message, 0)
+	}
+	if event.Path == "" {
+		return
+	}
+	for _, session := range d.sessionRegistry.ListActive(event.Path, false) {
+		if session.ProjectPath != event.Path {
+			continue
+		}
+		msg, err := session.typeMessage("[agnt tunnel] "+message, SendOptions{})
+		if err == nil {
+			err = d.sendMessageToOverlay(session.OverlayPath, msg)
+		}
+		if err != nil {
+			log.Printf("[WARN] tunnel %s: failed to notify session %s: %v", event.TunnelID, session.Code, err)
+		}
+	}
+}
+
+// orUnknown returns s, or "unknown reason" when it is empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown reason"
+	}
+	return s
+}
+
+// tunnelEventsRequest is the optional JSON payload of TUNNEL EVENTS.
+type tunnelEventsRequest struct {
+	Limit int `json:"limit"` // keep only the newest events
+}
+
+// hubHandleTunnelEvents handles TUNNEL EVENTS [id]: the disconnect, health
+// and reconnect log of the session's tunnels, including ones that have ended.
+func (d *Daemon) hubHandleTunnelEvents(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req tunnelEventsRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	id := ""
+	if len(cmd.Args) > 0 {
+		id = cmd.Args[0]
+	}
+	events := d.tunnelm.Events(id, d.getSessionProjectPath(conn), req.Limit)
+	if events == nil {
+		events = []tunnel.Event{}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	})
+	return conn.WriteJSON(data)
+}
+
 // hubHandleTunnelList handles TUNNEL LIST command.
 func (d *Daemon) hubHandleTunnelList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	// Parse filter from command data
-	var dirFilter hubproto.DirectoryFilter
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &dirFilter)
+	filter, err := parseListFilter(cmd.Data)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid filter: %v", err))
 	}
+	dirFilter := filter.DirectoryFilter
 
 	var infos []tunnel.TunnelInfo
 	if dirFilter.Global {
@@ -1604,8 +2691,11 @@ func (d *Daemon) hubHandleTunnelList(conn *hubpkg.Connection, cmd *hubproto.Comm
 		}
 	}
 
-	entries := make([]map[string]interface{}, len(infos))
-	for i, info := range infos {
+	entries := make([]map[string]interface{}, 0, len(infos))
+	for _, info := range infos {
+		if !d.labels.matches(depgraph.KindTunnel, info.ID, filter.Labels) {
+			continue
+		}
 		entry := map[string]interface{}{
 			"id":         info.ID,
 			"provider":   string(info.Provider),
@@ -1617,7 +2707,17 @@ func (d *Daemon) hubHandleTunnelList(conn *hubpkg.Connection, cmd *hubproto.Comm
 		if info.Error != "" {
 			entry["error"] = info.Error
 		}
-		entries[i] = entry
+		if info.Usage != nil {
+			entry["bytes_in"] = info.Usage.BytesIn
+			entry["bytes_out"] = info.Usage.BytesOut
+		}
+		if info.Reconnects > 0 {
+			entry["reconnects"] = info.Reconnects
+		}
+		if labels := d.labels.get(depgraph.KindTunnel, info.ID); labels != nil {
+			entry["labels"] = labels
+		}
+		entries = append(entries, entry)
 	}
 
 	data, _ := json.Marshal(map[string]interface{}{"tunnels": entries})
@@ -1646,16 +2746,20 @@ func (d *Daemon) hubHandleChaos(ctx context.Context, conn *hubpkg.Connection, cm
 		return d.hubHandleChaosListRules(conn, cmd)
 	case "STATS":
 		return d.hubHandleChaosStats(conn, cmd)
+	case "PREVIEW":
+		return d.hubHandleChaosPreview(conn, cmd)
 	case "CLEAR":
 		return d.hubHandleChaosClear(conn, cmd)
 	case "LIST-PRESETS":
 		return d.hubHandleChaosListPresets(conn)
+	case protocol.SubVerbScenario:
+		return d.hubHandleChaosScenario(conn, cmd)
 	default:
 		return conn.WriteStructuredErr(&hubproto.StructuredError{
 			Code:         hubproto.ErrInvalidArgs,
 			Message:      "unknown CHAOS sub-command",
 			Command:      "CHAOS",
-			ValidActions: []string{"ENABLE", "DISABLE", "STATUS", "PRESET", "SET", "ADD-RULE", "REMOVE-RULE", "LIST-RULES", "STATS", "CLEAR", "LIST-PRESETS"},
+			ValidActions: []string{"ENABLE", "DISABLE", "STATUS", "PRESET", "SET", "ADD-RULE", "REMOVE-RULE", "LIST-RULES", "STATS", "PREVIEW", "CLEAR", "LIST-PRESETS", protocol.SubVerbScenario},
 		})
 	}
 }
@@ -1670,10 +2774,11 @@ func (d *Daemon) hubHandleChaosEnable(conn *hubpkg.Connection, cmd *hubproto.Com
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	p.ChaosEngine().Enable()
+	d.eventLog.record(DaemonEvent{Type: EventChaosEnabled, Source: p.ID, Path: p.Path, Message: fmt.Sprintf("chaos enabled on proxy %s", p.ID)})
 	return conn.WriteOK("chaos enabled")
 }
 
@@ -1687,10 +2792,11 @@ func (d *Daemon) hubHandleChaosDisable(conn *hubpkg.Connection, cmd *hubproto.Co
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	p.ChaosEngine().Disable()
+	d.eventLog.record(DaemonEvent{Type: EventChaosDisabled, Source: p.ID, Path: p.Path, Message: fmt.Sprintf("chaos disabled on proxy %s", p.ID)})
 	return conn.WriteOK("chaos disabled")
 }
 
@@ -1704,7 +2810,7 @@ func (d *Daemon) hubHandleChaosStatus(conn *hubpkg.Connection, cmd *hubproto.Com
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	engine := p.ChaosEngine()
@@ -1720,6 +2826,12 @@ func (d *Daemon) hubHandleChaosStatus(conn *hubpkg.Connection, cmd *hubproto.Com
 	return conn.WriteJSON(data)
 }
 
+// chaosPresetRequest is the JSON payload of CHAOS PRESET.
+type chaosPresetRequest struct {
+	Preset   string   `json:"chaos_preset"`
+	Sessions []string `json:"sessions,omitempty"` // Limit every rule of the preset to these page or browser sessions
+}
+
 // hubHandleChaosPreset handles CHAOS PRESET command.
 func (d *Daemon) hubHandleChaosPreset(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
@@ -1730,14 +2842,12 @@ func (d *Daemon) hubHandleChaosPreset(conn *hubpkg.Connection, cmd *hubproto.Com
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
-	var config struct {
-		Preset string `json:"chaos_preset"`
-	}
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &config)
+	var config chaosPresetRequest
+	if err := decodeData(cmd, &config); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if config.Preset == "" {
@@ -1749,14 +2859,31 @@ func (d *Daemon) hubHandleChaosPreset(conn *hubpkg.Connection, cmd *hubproto.Com
 		availablePresets := proxy.ListPresets()
 		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("unknown preset %q. Available: %s", config.Preset, strings.Join(availablePresets, ", ")))
 	}
+	scopeChaosRules(presetConfig, config.Sessions)
+	if hasArg(cmd.Args[1:], protocol.DryRunArg) {
+		return d.writeChaosDryRun(conn, p, presetConfig)
+	}
 
 	if err := p.ChaosEngine().SetConfig(presetConfig); err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, err.Error())
 	}
 
+	if len(config.Sessions) > 0 {
+		return conn.WriteOK(fmt.Sprintf("preset %s applied to sessions %s", config.Preset, strings.Join(config.Sessions, ", ")))
+	}
 	return conn.WriteOK(fmt.Sprintf("preset %s applied", config.Preset))
 }
 
+// scopeChaosRules limits every rule of config to sessions, when given.
+func scopeChaosRules(config *proxy.ChaosConfig, sessions []string) {
+	if len(sessions) == 0 {
+		return
+	}
+	for _, rule := range config.Rules {
+		rule.Sessions = append([]string(nil), sessions...)
+	}
+}
+
 // hubHandleChaosSet handles CHAOS SET command.
 func (d *Daemon) hubHandleChaosSet(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
@@ -1767,12 +2894,16 @@ func (d *Daemon) hubHandleChaosSet(conn *hubpkg.Connection, cmd *hubproto.Comman
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	var config proxy.ChaosConfig
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &config)
+	if err := decodeData(cmd, &config); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	if hasArg(cmd.Args[1:], protocol.DryRunArg) {
+		return d.writeChaosDryRun(conn, p, &config)
 	}
 
 	if err := p.ChaosEngine().SetConfig(&config); err != nil {
@@ -1782,6 +2913,21 @@ func (d *Daemon) hubHandleChaosSet(conn *hubpkg.Connection, cmd *hubproto.Comman
 	return conn.WriteOK("chaos config set")
 }
 
+// writeChaosDryRun reports the rules replacing a proxy's chaos configuration
+// with config would remove, and which logged requests config would hit.
+func (d *Daemon) writeChaosDryRun(conn *hubpkg.Connection, p *proxy.ProxyServer, config *proxy.ChaosConfig) error {
+	preview, err := p.PreviewChaos(config)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	return writeDryRun(conn, chaosRulesAffected(p), map[string]interface{}{"proxy_id": p.ID, "preview": preview})
+}
+
+// chaosAddRuleRequest is the JSON payload of CHAOS ADD-RULE.
+type chaosAddRuleRequest struct {
+	Rule proxy.ChaosRule `json:"chaos_rule"`
+}
+
 // hubHandleChaosAddRule handles CHAOS ADD-RULE command.
 func (d *Daemon) hubHandleChaosAddRule(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
@@ -1792,14 +2938,12 @@ func (d *Daemon) hubHandleChaosAddRule(conn *hubpkg.Connection, cmd *hubproto.Co
 
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
-	var wrapper struct {
-		Rule proxy.ChaosRule `json:"chaos_rule"`
-	}
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &wrapper)
+	var wrapper chaosAddRuleRequest
+	if err := decodeData(cmd, &wrapper); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if wrapper.Rule.ID == "" {
@@ -1809,106 +2953,322 @@ func (d *Daemon) hubHandleChaosAddRule(conn *hubpkg.Connection, cmd *hubproto.Co
 	if err := p.ChaosEngine().AddRule(&wrapper.Rule); err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, err.Error())
 	}
-
-	return conn.WriteOK("rule added")
+
+	return conn.WriteOK("rule added")
+}
+
+// chaosRemoveRuleRequest is the JSON payload of CHAOS REMOVE-RULE.
+type chaosRemoveRuleRequest struct {
+	RuleID string `json:"chaos_rule_id"`
+}
+
+// hubHandleChaosRemoveRule handles CHAOS REMOVE-RULE command.
+func (d *Daemon) hubHandleChaosRemoveRule(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS REMOVE-RULE requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	var config chaosRemoveRuleRequest
+	if err := decodeData(cmd, &config); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	if config.RuleID == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "chaos_rule_id is required")
+	}
+
+	p.ChaosEngine().RemoveRule(config.RuleID)
+	return conn.WriteOK("rule removed")
+}
+
+// hubHandleChaosListRules handles CHAOS LIST-RULES command.
+func (d *Daemon) hubHandleChaosListRules(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS LIST-RULES requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	config := p.ChaosEngine().GetConfig()
+	var rules []*proxy.ChaosRule
+	if config != nil {
+		rules = config.Rules
+	}
+	if rules == nil {
+		rules = []*proxy.ChaosRule{}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"rules": rules})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleChaosStats handles CHAOS STATS command.
+func (d *Daemon) hubHandleChaosStats(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS STATS requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	stats := p.ChaosEngine().GetStats()
+
+	data, _ := json.Marshal(stats)
+	return conn.WriteJSON(data)
+}
+
+// chaosPreviewRequest is the JSON payload of CHAOS PREVIEW. Without a preset
+// or config the proxy's current rules are previewed.
+type chaosPreviewRequest struct {
+	Preset   string             `json:"chaos_preset,omitempty"`
+	Sessions []string           `json:"sessions,omitempty"` // Limit the preset's rules to these sessions
+	Config   *proxy.ChaosConfig `json:"chaos_config,omitempty"`
+}
+
+// hubHandleChaosPreview handles CHAOS PREVIEW command.
+func (d *Daemon) hubHandleChaosPreview(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS PREVIEW requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	var req chaosPreviewRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	config := req.Config
+	if config == nil && req.Preset != "" {
+		config = proxy.GetPreset(req.Preset)
+		if config == nil {
+			availablePresets := proxy.ListPresets()
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("unknown preset %q. Available: %s", req.Preset, strings.Join(availablePresets, ", ")))
+		}
+		scopeChaosRules(config, req.Sessions)
+	}
+
+	preview, err := p.PreviewChaos(config)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	data, _ := json.Marshal(preview)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleChaosClear handles CHAOS CLEAR command.
+func (d *Daemon) hubHandleChaosClear(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS CLEAR requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	if hasArg(cmd.Args[1:], protocol.DryRunArg) {
+		return writeDryRun(conn, chaosRulesAffected(p), map[string]interface{}{"proxy_id": p.ID})
+	}
+
+	p.ChaosEngine().Clear()
+	return conn.WriteOK("chaos cleared")
+}
+
+// hubHandleChaosListPresets handles CHAOS LIST-PRESETS command.
+func (d *Daemon) hubHandleChaosListPresets(conn *hubpkg.Connection) error {
+	presets := proxy.ListPresets()
+
+	data, _ := json.Marshal(map[string]interface{}{"presets": presets})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleChaosScenario handles CHAOS SCENARIO START|STOP|STATUS <proxy_id>.
+// START takes a proxy.ChaosScenario as JSON.
+func (d *Daemon) hubHandleChaosScenario(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS SCENARIO requires: START|STOP|STATUS <proxy_id>")
+	}
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[1])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[1], err)
+	}
+	engine := p.ChaosEngine()
+
+	resp := map[string]interface{}{"proxy_id": p.ID}
+	switch action := strings.ToUpper(cmd.Args[0]); action {
+	case protocol.SubVerbStart:
+		var scenario proxy.ChaosScenario
+		if err := decodeData(cmd, &scenario); err != nil {
+			return writePayloadErr(conn, cmd, err)
+		}
+		engine.SetOnScenarioEvent(func(event proxy.ChaosScenarioEvent) {
+			d.notifyChaosScenario(p, event)
+		})
+		if err := engine.StartScenario(scenario); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["scenario"], _ = engine.ScenarioStatus()
+	case protocol.SubVerbStop:
+		status, err := engine.StopScenario()
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		resp["scenario"] = status
+	case protocol.SubVerbStatus:
+		if status, ok := engine.ScenarioStatus(); ok {
+			resp["scenario"] = status
+		}
+	default:
+		return conn.WriteStructuredErr(&hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      fmt.Sprintf("unknown CHAOS SCENARIO action %q", action),
+			Command:      "CHAOS SCENARIO",
+			ValidActions: []string{protocol.SubVerbStart, protocol.SubVerbStop, protocol.SubVerbStatus},
+		})
+	}
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// notifyChaosScenario toasts a scenario's phase changes on the proxy's pages
+// and tells the project's agent sessions, so failures seen during a phase
+// can be tied to it.
+func (d *Daemon) notifyChaosScenario(p *proxy.ProxyServer, event proxy.ChaosScenarioEvent) {
+	message := event.Message() + " on proxy " + p.ID
+	p.BroadcastToast("info", "Chaos scenario", message, 0)
+	if p.Path == "" {
+		return
+	}
+	for _, session := range d.sessionRegistry.ListActive(p.Path, false) {
+		if session.ProjectPath != p.Path {
+			continue
+		}
+		msg, err := session.typeMessage("[agnt chaos] "+message, SendOptions{})
+		if err == nil {
+			err = d.sendMessageToOverlay(session.OverlayPath, msg)
+		}
+		if err != nil {
+			log.Printf("[WARN] CHAOS SCENARIO %s: failed to notify session %s: %v", p.ID, session.Code, err)
+		}
+	}
+}
+
+// hubHandleMock handles the MOCK command.
+func (d *Daemon) hubHandleMock(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "MOCK %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "ADD":
+		return d.hubHandleMockAdd(conn, cmd)
+	case "REMOVE":
+		return d.hubHandleMockRemove(conn, cmd)
+	case "LIST":
+		return d.hubHandleMockList(conn, cmd)
+	case "CLEAR":
+		return d.hubHandleMockClear(conn, cmd)
+	default:
+		return conn.WriteStructuredErr(&hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown MOCK sub-command",
+			Command:      "MOCK",
+			ValidActions: []string{"ADD", "REMOVE", "LIST", "CLEAR"},
+		})
+	}
 }
 
-// hubHandleChaosRemoveRule handles CHAOS REMOVE-RULE command.
-func (d *Daemon) hubHandleChaosRemoveRule(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+// hubHandleMockAdd handles MOCK ADD command.
+func (d *Daemon) hubHandleMockAdd(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS REMOVE-RULE requires: <proxy_id>")
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "MOCK ADD requires: <proxy_id>")
 	}
 
-	proxyID := cmd.Args[0]
-
-	p, err := d.getSessionScopedProxy(conn, proxyID)
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
 	}
 
-	var config struct {
-		RuleID string `json:"chaos_rule_id"`
-	}
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &config)
+	var rule proxy.MockRule
+	if err := decodeData(cmd, &rule); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
-
-	if config.RuleID == "" {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "chaos_rule_id is required")
+	if err := p.MockEngine().AddRule(rule); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
 	}
 
-	p.ChaosEngine().RemoveRule(config.RuleID)
-	return conn.WriteOK("rule removed")
+	data, _ := json.Marshal(map[string]interface{}{"rule_id": rule.ID, "rules": p.MockEngine().Rules()})
+	return conn.WriteJSON(data)
 }
 
-// hubHandleChaosListRules handles CHAOS LIST-RULES command.
-func (d *Daemon) hubHandleChaosListRules(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	if len(cmd.Args) < 1 {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS LIST-RULES requires: <proxy_id>")
+// hubHandleMockRemove handles MOCK REMOVE command.
+func (d *Daemon) hubHandleMockRemove(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "MOCK REMOVE requires: <proxy_id> <rule_id>")
 	}
 
-	proxyID := cmd.Args[0]
-
-	p, err := d.getSessionScopedProxy(conn, proxyID)
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
 	}
 
-	config := p.ChaosEngine().GetConfig()
-	var rules []*proxy.ChaosRule
-	if config != nil {
-		rules = config.Rules
+	if !p.MockEngine().RemoveRule(cmd.Args[1]) {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("mock rule %q not found", cmd.Args[1]))
 	}
-	if rules == nil {
-		rules = []*proxy.ChaosRule{}
-	}
-
-	data, _ := json.Marshal(map[string]interface{}{"rules": rules})
-	return conn.WriteJSON(data)
+	return conn.WriteOK("mock rule removed")
 }
 
-// hubHandleChaosStats handles CHAOS STATS command.
-func (d *Daemon) hubHandleChaosStats(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+// hubHandleMockList handles MOCK LIST command.
+func (d *Daemon) hubHandleMockList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS STATS requires: <proxy_id>")
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "MOCK LIST requires: <proxy_id>")
 	}
 
-	proxyID := cmd.Args[0]
-
-	p, err := d.getSessionScopedProxy(conn, proxyID)
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
 	}
 
-	stats := p.ChaosEngine().GetStats()
-
-	data, _ := json.Marshal(stats)
+	data, _ := json.Marshal(map[string]interface{}{"rules": p.MockEngine().Rules()})
 	return conn.WriteJSON(data)
 }
 
-// hubHandleChaosClear handles CHAOS CLEAR command.
-func (d *Daemon) hubHandleChaosClear(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+// hubHandleMockClear handles MOCK CLEAR command.
+func (d *Daemon) hubHandleMockClear(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "CHAOS CLEAR requires: <proxy_id>")
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "MOCK CLEAR requires: <proxy_id>")
 	}
 
-	proxyID := cmd.Args[0]
-
-	p, err := d.getSessionScopedProxy(conn, proxyID)
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
 	}
 
-	p.ChaosEngine().Clear()
-	return conn.WriteOK("chaos cleared")
-}
-
-// hubHandleChaosListPresets handles CHAOS LIST-PRESETS command.
-func (d *Daemon) hubHandleChaosListPresets(conn *hubpkg.Connection) error {
-	presets := proxy.ListPresets()
-
-	data, _ := json.Marshal(map[string]interface{}{"presets": presets})
-	return conn.WriteJSON(data)
+	p.MockEngine().Clear()
+	return conn.WriteOK("mock rules cleared")
 }
 
 // hubHandleSession handles the SESSION command.
@@ -1933,22 +3293,53 @@ func (d *Daemon) hubHandleSession(ctx context.Context, conn *hubpkg.Connection,
 		return d.hubHandleSessionCancel(conn, cmd)
 	case "TASKS":
 		return d.hubHandleSessionTasks(conn, cmd)
+	case protocol.SubVerbPause:
+		return d.hubHandleSessionPause(conn, cmd)
+	case protocol.SubVerbResume:
+		return d.hubHandleSessionResume(conn, cmd)
 	case "FIND":
 		return d.hubHandleSessionFind(conn, cmd)
 	case "ATTACH":
 		return d.hubHandleSessionAttach(conn, cmd)
 	case "URL":
 		return d.hubHandleSessionURL(conn, cmd)
+	case "DIGEST":
+		return d.hubHandleSessionDigest(conn, cmd)
+	case "STATUS":
+		return d.hubHandleSessionStatus(conn, cmd)
+	case "CLIPBOARD":
+		return d.hubHandleSessionClipboard(conn, cmd)
+	case protocol.SubVerbTranscript:
+		return d.hubHandleSessionTranscript(conn, cmd)
+	case protocol.SubVerbHost:
+		return d.hubHandleSessionHost(conn, cmd)
+	case protocol.SubVerbHosted:
+		return d.hubHandleSessionHosted(conn, cmd)
+	case protocol.SubVerbDetach:
+		return d.hubHandleSessionDetach(conn, cmd)
+	case protocol.SubVerbTag:
+		return d.hubHandleSessionTag(conn, cmd)
+	case protocol.SubVerbAlert:
+		return d.hubHandleSessionAlert(conn, cmd)
 	default:
 		return conn.WriteStructuredErr(&hubproto.StructuredError{
 			Code:         hubproto.ErrInvalidArgs,
 			Message:      "unknown SESSION sub-command",
 			Command:      "SESSION",
-			ValidActions: []string{"REGISTER", "UNREGISTER", "HEARTBEAT", "LIST", "GET", "SEND", "SCHEDULE", "CANCEL", "TASKS", "FIND", "ATTACH", "URL"},
+			ValidActions: []string{"REGISTER", "UNREGISTER", "HEARTBEAT", "LIST", "GET", "SEND", "SCHEDULE", "CANCEL", protocol.SubVerbPause, protocol.SubVerbResume, "TASKS", "FIND", "ATTACH", "URL", "DIGEST", "STATUS", "CLIPBOARD", protocol.SubVerbTranscript, protocol.SubVerbHost, protocol.SubVerbHosted, protocol.SubVerbDetach, protocol.SubVerbTag, protocol.SubVerbAlert},
 		})
 	}
 }
 
+// sessionRegisterRequest is the optional JSON payload of SESSION REGISTER.
+type sessionRegisterRequest struct {
+	ProjectPath string          `json:"project_path"`
+	Command     string          `json:"command"`
+	Args        []string        `json:"args"`
+	Tags        protocol.Labels `json:"tags"`
+	Description string          `json:"description"`
+}
+
 // hubHandleSessionRegister handles SESSION REGISTER command.
 // SESSION REGISTER <code> <overlay_path> -- <json_metadata>
 func (d *Daemon) hubHandleSessionRegister(conn *hubpkg.Connection, cmd *hubproto.Command) error {
@@ -1960,13 +3351,9 @@ func (d *Daemon) hubHandleSessionRegister(conn *hubpkg.Connection, cmd *hubproto
 	overlayPath := cmd.Args[1]
 
 	// Parse optional metadata from data payload
-	var metadata struct {
-		ProjectPath string   `json:"project_path"`
-		Command     string   `json:"command"`
-		Args        []string `json:"args"`
-	}
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &metadata)
+	var metadata sessionRegisterRequest
+	if err := decodeData(cmd, &metadata); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	// Create session
@@ -1980,6 +3367,9 @@ func (d *Daemon) hubHandleSessionRegister(conn *hubpkg.Connection, cmd *hubproto
 		Status:      SessionStatusActive,
 		LastSeen:    time.Now(),
 	}
+	if _, err := session.SetTags(metadata.Tags, nil, &metadata.Description); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
 
 	if err := d.sessionRegistry.Register(session); err != nil {
 		return conn.WriteErr(hubproto.ErrAlreadyExists, err.Error())
@@ -1988,6 +3378,13 @@ func (d *Daemon) hubHandleSessionRegister(conn *hubpkg.Connection, cmd *hubproto
 	// Associate session with this connection for cleanup
 	conn.SetSessionCode(code)
 
+	d.eventLog.record(DaemonEvent{
+		Type:    EventSessionRegistered,
+		Source:  code,
+		Path:    session.ProjectPath,
+		Message: fmt.Sprintf("session %s registered: %s", code, strings.Join(append([]string{session.Command}, session.Args...), " ")),
+	})
+
 	// Run autostart for this project
 	autostartResult := d.RunAutostart(context.Background(), metadata.ProjectPath)
 
@@ -2025,25 +3422,37 @@ func (d *Daemon) hubHandleSessionHeartbeat(conn *hubpkg.Connection, cmd *hubprot
 	code := cmd.Args[0]
 
 	if err := d.sessionRegistry.Heartbeat(code); err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entitySession, code, err)
 	}
 
 	return conn.WriteOK("heartbeat received")
 }
 
+// sessionFilter is the optional JSON payload of SESSION LIST and SESSION TASKS.
+type sessionFilter struct {
+	Directory string `json:"directory"`
+	Global    bool   `json:"global"`
+}
+
+// sessionListFilter is the optional JSON payload of SESSION LIST and FIND.
+type sessionListFilter struct {
+	sessionFilter
+	Tags protocol.Labels `json:"tags,omitempty"` // Every tag must match; an empty value matches any
+}
+
 // hubHandleSessionList handles SESSION LIST command.
 // SESSION LIST [-- <directory_filter_json>]
 func (d *Daemon) hubHandleSessionList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var filter struct {
-		Directory string `json:"directory"`
-		Global    bool   `json:"global"`
-	}
+	var filter sessionListFilter
 
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &filter)
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if err := filter.Tags.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
 	}
 
-	sessions := d.sessionRegistry.List(normalizePath(filter.Directory), filter.Global)
+	sessions := d.sessionRegistry.ListTagged(normalizePath(filter.Directory), filter.Global, filter.Tags)
 
 	// Convert to response format
 	sessionList := make([]map[string]interface{}, 0, len(sessions))
@@ -2057,6 +3466,9 @@ func (d *Daemon) hubHandleSessionList(conn *hubpkg.Connection, cmd *hubproto.Com
 		"directory": filter.Directory,
 		"global":    filter.Global,
 	}
+	if len(filter.Tags) > 0 {
+		resp["tags"] = filter.Tags
+	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
@@ -2073,7 +3485,40 @@ func (d *Daemon) hubHandleSessionGet(conn *hubpkg.Connection, cmd *hubproto.Comm
 
 	session, ok := d.sessionRegistry.Get(code)
 	if !ok {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found", code))
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
+	}
+
+	data, _ := json.Marshal(session.ToJSON())
+	return conn.WriteJSON(data)
+}
+
+// sessionTagRequest is the optional JSON payload of SESSION TAG.
+type sessionTagRequest struct {
+	Description *string `json:"description"` // Replaces the description; "" clears it
+}
+
+// hubHandleSessionTag handles SESSION TAG command.
+// SESSION TAG <code> [key=value ...] [key- ...] [-- {"description": ...}]
+// Without changes it reports the session's tags.
+func (d *Daemon) hubHandleSessionTag(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION TAG requires: <code>")
+	}
+	session, ok := d.sessionRegistry.Get(cmd.Args[0])
+	if !ok {
+		return d.writeNotFound(conn, cmd, entitySession, cmd.Args[0], nil)
+	}
+
+	set, remove, err := parseLabelArgs(cmd.Args[1:])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	var req sessionTagRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if _, err := session.SetTags(set, remove, req.Description); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
 	}
 
 	data, _ := json.Marshal(session.ToJSON())
@@ -2081,7 +3526,8 @@ func (d *Daemon) hubHandleSessionGet(conn *hubpkg.Connection, cmd *hubproto.Comm
 }
 
 // hubHandleSessionSend handles SESSION SEND command.
-// SESSION SEND <code> -- <message>
+// SESSION SEND <code> [preset=] [chunk=] [delay=] [enter=] [paste=] -- <message>
+// Options left out come from the preset for the session's command.
 func (d *Daemon) hubHandleSessionSend(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION SEND requires: <code>")
@@ -2093,37 +3539,83 @@ func (d *Daemon) hubHandleSessionSend(conn *hubpkg.Connection, cmd *hubproto.Com
 	code := cmd.Args[0]
 	message := string(cmd.Data)
 
+	opts, err := parseSendArgs(cmd.Args[1:])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
 	// Get session
 	session, ok := d.sessionRegistry.Get(code)
 	if !ok {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found", code))
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
 	}
 
 	if session.GetStatus() != SessionStatusActive {
 		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("session %q is not active", code))
 	}
 
+	msg, err := session.typeMessage(message, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
 	// Send message to overlay
-	if err := d.sendMessageToOverlay(session.OverlayPath, message); err != nil {
+	if err := d.sendMessageToOverlay(session.OverlayPath, msg); err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to send message: %v", err))
 	}
 
 	resp := map[string]interface{}{
-		"success":      true,
-		"session_code": code,
-		"message_len":  len(message),
+		"success":         true,
+		"session_code":    code,
+		"message_len":     len(message),
+		"chunk_size":      msg.ChunkSize,
+		"chunk_delay_ms":  msg.ChunkDelayMs,
+		"enter":           msg.Enter,
+		"bracketed_paste": msg.BracketedPaste,
 	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
 }
 
+// hubHandleSessionStatus handles SESSION STATUS command.
+// SESSION STATUS <code>
+// Reports whether the session's tool is busy or idle, and for how long.
+func (d *Daemon) hubHandleSessionStatus(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION STATUS requires: <code>")
+	}
+
+	session, ok := d.sessionRegistry.Get(cmd.Args[0])
+	if !ok {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found", cmd.Args[0]))
+	}
+
+	data, _ := json.Marshal(session.ActivityStatus(time.Now()))
+	return conn.WriteJSON(data)
+}
+
+// scheduleCronRequest is the JSON payload of SESSION SCHEDULE <code> CRON.
+type scheduleCronRequest struct {
+	Cron     string `json:"cron"`               // e.g. "*/30 * * * *", "0 9 * * MON-FRI", "@every 45m"
+	Timezone string `json:"timezone,omitempty"` // IANA name such as Europe/Berlin (default: the daemon's)
+	Message  string `json:"message"`
+}
+
 // hubHandleSessionSchedule handles SESSION SCHEDULE command.
-// SESSION SCHEDULE <code> <duration> -- <message>
+// SESSION SCHEDULE <code> <duration> [wait-for-idle] -- <message>
+// SESSION SCHEDULE <code> CRON [wait-for-idle] -- {"cron": ..., "timezone": ..., "message": ...}
 func (d *Daemon) hubHandleSessionSchedule(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 2 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION SCHEDULE requires: <code> <duration>")
 	}
+	waitForIdle := false
+	if len(cmd.Args) > 2 {
+		if !strings.EqualFold(cmd.Args[2], protocol.SubVerbWaitForIdle) {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("unknown SESSION SCHEDULE option %q: use %s", cmd.Args[2], protocol.SubVerbWaitForIdle))
+		}
+		waitForIdle = true
+	}
 	if len(cmd.Data) == 0 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION SCHEDULE requires message data")
 	}
@@ -2132,6 +3624,10 @@ func (d *Daemon) hubHandleSessionSchedule(conn *hubpkg.Connection, cmd *hubproto
 	durationStr := cmd.Args[1]
 	message := string(cmd.Data)
 
+	if strings.EqualFold(durationStr, scheduleCronArg) {
+		return d.hubHandleSessionScheduleCron(conn, cmd, code, waitForIdle)
+	}
+
 	// Parse duration
 	duration, err := time.ParseDuration(durationStr)
 	if err != nil {
@@ -2141,26 +3637,100 @@ func (d *Daemon) hubHandleSessionSchedule(conn *hubpkg.Connection, cmd *hubproto
 	// Get session to determine project path
 	session, ok := d.sessionRegistry.Get(code)
 	if !ok {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found", code))
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
 	}
 
 	// Schedule the task
-	task, err := d.scheduler.Schedule(code, duration, message, session.ProjectPath)
+	schedule := d.scheduler.Schedule
+	if waitForIdle {
+		schedule = d.scheduler.ScheduleWhenIdle
+	}
+	task, err := schedule(code, duration, message, session.ProjectPath)
 	if err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to schedule: %v", err))
 	}
 
 	resp := map[string]interface{}{
-		"task_id":      task.ID,
-		"session_code": code,
-		"deliver_at":   task.DeliverAt.Format(time.RFC3339),
-		"message_len":  len(message),
+		"task_id":       task.ID,
+		"session_code":  code,
+		"deliver_at":    task.DeliverAt.Format(time.RFC3339),
+		"message_len":   len(message),
+		"wait_for_idle": task.WaitForIdle,
+	}
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleSessionScheduleCron handles the recurring form of SESSION SCHEDULE.
+func (d *Daemon) hubHandleSessionScheduleCron(conn *hubpkg.Connection, cmd *hubproto.Command, code string, waitForIdle bool) error {
+	var req scheduleCronRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if req.Cron == "" || req.Message == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION SCHEDULE CRON requires cron and message")
+	}
+	if _, err := parseCron(req.Cron, req.Timezone); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	session, ok := d.sessionRegistry.Get(code)
+	if !ok {
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
+	}
+
+	task, err := d.scheduler.ScheduleCron(code, req.Cron, req.Timezone, req.Message, session.ProjectPath, waitForIdle)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to schedule: %v", err))
+	}
+
+	resp := map[string]interface{}{
+		"task_id":       task.ID,
+		"session_code":  code,
+		"deliver_at":    task.DeliverAt.Format(time.RFC3339),
+		"message_len":   len(req.Message),
+		"wait_for_idle": task.WaitForIdle,
+		"cron":          task.Cron,
+		"timezone":      task.Timezone,
 	}
 
 	data, _ := json.Marshal(resp)
 	return conn.WriteJSON(data)
 }
 
+// hubHandleSessionPause handles SESSION PAUSE command.
+// SESSION PAUSE <task_id>
+func (d *Daemon) hubHandleSessionPause(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION PAUSE requires: <task_id>")
+	}
+
+	task, err := d.scheduler.Pause(cmd.Args[0])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+
+	data, _ := json.Marshal(task.ToJSON())
+	return conn.WriteJSON(data)
+}
+
+// hubHandleSessionResume handles SESSION RESUME command.
+// SESSION RESUME <task_id>
+func (d *Daemon) hubHandleSessionResume(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION RESUME requires: <task_id>")
+	}
+
+	task, err := d.scheduler.Resume(cmd.Args[0])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+
+	data, _ := json.Marshal(task.ToJSON())
+	return conn.WriteJSON(data)
+}
+
 // hubHandleSessionCancel handles SESSION CANCEL command.
 // SESSION CANCEL <task_id>
 func (d *Daemon) hubHandleSessionCancel(conn *hubpkg.Connection, cmd *hubproto.Command) error {
@@ -2180,13 +3750,10 @@ func (d *Daemon) hubHandleSessionCancel(conn *hubpkg.Connection, cmd *hubproto.C
 // hubHandleSessionTasks handles SESSION TASKS command.
 // SESSION TASKS [-- <directory_filter_json>]
 func (d *Daemon) hubHandleSessionTasks(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var filter struct {
-		Directory string `json:"directory"`
-		Global    bool   `json:"global"`
-	}
+	var filter sessionFilter
 
-	if len(cmd.Data) > 0 {
-		json.Unmarshal(cmd.Data, &filter)
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	tasks := d.scheduler.ListTasks(normalizePath(filter.Directory), filter.Global)
@@ -2209,7 +3776,7 @@ func (d *Daemon) hubHandleSessionTasks(conn *hubpkg.Connection, cmd *hubproto.Co
 }
 
 // hubHandleSessionFind handles SESSION FIND command.
-// SESSION FIND <directory>
+// SESSION FIND <directory> [-- {"tags": {...}}]
 func (d *Daemon) hubHandleSessionFind(conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	if len(cmd.Args) < 1 {
 		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION FIND requires: <directory>")
@@ -2217,8 +3784,19 @@ func (d *Daemon) hubHandleSessionFind(conn *hubpkg.Connection, cmd *hubproto.Com
 
 	directory := cmd.Args[0]
 
-	session, found := d.sessionRegistry.FindByDirectory(directory)
+	var filter sessionListFilter
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if err := filter.Tags.Validate(); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	session, found := d.sessionRegistry.FindByDirectoryTagged(directory, filter.Tags)
 	if !found {
+		if len(filter.Tags) > 0 {
+			return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no active session tagged %s found for directory %q or its parents", filter.Tags, directory))
+		}
 		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no active session found for directory %q or its parents", directory))
 	}
 
@@ -2237,6 +3815,8 @@ func (d *Daemon) hubHandleSessionAttach(conn *hubpkg.Connection, cmd *hubproto.C
 
 	session, found := d.sessionRegistry.FindByDirectory(directory)
 	if !found {
+		// Don't leave the connection scoped to the project it switched from
+		conn.SetSessionCode("")
 		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no active session found for directory %q or its parents", directory))
 	}
 
@@ -2255,6 +3835,11 @@ func (d *Daemon) hubHandleSessionAttach(conn *hubpkg.Connection, cmd *hubproto.C
 	return conn.WriteJSON(data)
 }
 
+// sessionURLRequest is the optional JSON payload of SESSION URL.
+type sessionURLRequest struct {
+	Script string `json:"script"`
+}
+
 // hubHandleSessionURL handles SESSION URL command.
 // Reports a detected URL from an agnt run session, triggering proxy creation.
 // SESSION URL <code> <url> -- {"script": "dev"}
@@ -2269,16 +3854,17 @@ func (d *Daemon) hubHandleSessionURL(conn *hubpkg.Connection, cmd *hubproto.Comm
 	// Get session
 	session, ok := d.sessionRegistry.Get(code)
 	if !ok {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found", code))
+		return d.writeNotFound(conn, cmd, entitySession, code, nil)
 	}
 
 	// Parse script name from data payload (default to "dev")
 	scriptName := "dev"
 	if len(cmd.Data) > 0 {
-		var data struct {
-			Script string `json:"script"`
+		var data sessionURLRequest
+		if err := decodeData(cmd, &data); err != nil {
+			return writePayloadErr(conn, cmd, err)
 		}
-		if err := json.Unmarshal(cmd.Data, &data); err == nil && data.Script != "" {
+		if data.Script != "" {
 			scriptName = data.Script
 		}
 	}
@@ -2311,28 +3897,27 @@ func (d *Daemon) hubHandleSessionURL(conn *hubpkg.Connection, cmd *hubproto.Comm
 	return conn.WriteJSON(data)
 }
 
-// sendMessageToOverlay sends a message to an overlay socket.
-func (d *Daemon) sendMessageToOverlay(socketPath string, message string) error {
-	// Create HTTP client that connects via Unix socket
+// sendMessageToOverlay types a message into the tool behind an overlay
+// socket, paced as msg describes.
+func (d *Daemon) sendMessageToOverlay(socketPath string, msg overlayTypeMessage) error {
+	// Create HTTP client that connects to the overlay's socket, named pipe
+	// or loopback port; the overlay answers once the message is typed
 	client := &http.Client{
-		Timeout: 5 * time.Second,
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-				var dialer net.Dialer
-				return dialer.DialContext(ctx, "unix", socketPath)
-			},
-		},
+		Timeout:   5*time.Second + msg.duration()*5/4,
+		Transport: endpoint.Transport(socketPath),
 	}
 
-	// Build request body
-	body := bytes.NewBufferString(message)
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
 
-	// POST to /inject endpoint
-	req, err := http.NewRequest("POST", "http://unix/inject", body)
+	// POST to /type endpoint
+	req, err := http.NewRequest("POST", "http://unix/type", bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -2375,15 +3960,9 @@ func (d *Daemon) hubHandleStore(ctx context.Context, conn *hubpkg.Connection, cm
 
 // hubHandleStoreGet handles STORE GET command.
 func (d *Daemon) hubHandleStoreGet(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var req struct {
-		Scope    string `json:"scope"`
-		ScopeKey string `json:"scope_key"`
-		Key      string `json:"key"`
-	}
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &req); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid request JSON: "+err.Error())
-		}
+	var req protocol.StoreGetRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Scope == "" {
@@ -2410,17 +3989,9 @@ func (d *Daemon) hubHandleStoreGet(conn *hubpkg.Connection, cmd *hubproto.Comman
 
 // hubHandleStoreSet handles STORE SET command.
 func (d *Daemon) hubHandleStoreSet(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var req struct {
-		Scope    string         `json:"scope"`
-		ScopeKey string         `json:"scope_key"`
-		Key      string         `json:"key"`
-		Value    interface{}    `json:"value"`
-		Metadata map[string]any `json:"metadata,omitempty"`
-	}
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &req); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid request JSON: "+err.Error())
-		}
+	var req protocol.StoreSetRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Scope == "" {
@@ -2445,15 +4016,9 @@ func (d *Daemon) hubHandleStoreSet(conn *hubpkg.Connection, cmd *hubproto.Comman
 
 // hubHandleStoreDelete handles STORE DELETE command.
 func (d *Daemon) hubHandleStoreDelete(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var req struct {
-		Scope    string `json:"scope"`
-		ScopeKey string `json:"scope_key"`
-		Key      string `json:"key"`
-	}
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &req); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid request JSON: "+err.Error())
-		}
+	var req protocol.StoreDeleteRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Scope == "" {
@@ -2478,14 +4043,9 @@ func (d *Daemon) hubHandleStoreDelete(conn *hubpkg.Connection, cmd *hubproto.Com
 
 // hubHandleStoreList handles STORE LIST command.
 func (d *Daemon) hubHandleStoreList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var req struct {
-		Scope    string `json:"scope"`
-		ScopeKey string `json:"scope_key"`
-	}
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &req); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid request JSON: "+err.Error())
-		}
+	var req protocol.StoreListRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Scope == "" {
@@ -2513,14 +4073,9 @@ func (d *Daemon) hubHandleStoreList(conn *hubpkg.Connection, cmd *hubproto.Comma
 
 // hubHandleStoreClear handles STORE CLEAR command.
 func (d *Daemon) hubHandleStoreClear(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var req struct {
-		Scope    string `json:"scope"`
-		ScopeKey string `json:"scope_key"`
-	}
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &req); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid request JSON: "+err.Error())
-		}
+	var req protocol.StoreClearRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Scope == "" {
@@ -2542,14 +4097,9 @@ func (d *Daemon) hubHandleStoreClear(conn *hubpkg.Connection, cmd *hubproto.Comm
 
 // hubHandleStoreGetAll handles STORE GET-ALL command.
 func (d *Daemon) hubHandleStoreGetAll(conn *hubpkg.Connection, cmd *hubproto.Command) error {
-	var req struct {
-		Scope    string `json:"scope"`
-		ScopeKey string `json:"scope_key"`
-	}
-	if len(cmd.Data) > 0 {
-		if err := json.Unmarshal(cmd.Data, &req); err != nil {
-			return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid request JSON: "+err.Error())
-		}
+	var req protocol.StoreGetAllRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Scope == "" {
@@ -2629,18 +4179,9 @@ func (d *Daemon) hubHandleAutomateProcess(ctx context.Context, conn *hubpkg.Conn
 	}
 
 	// Parse the task request
-	var req struct {
-		Type    string                 `json:"type"`
-		Data    map[string]interface{} `json:"data"`
-		Context map[string]interface{} `json:"context"`
-		Options struct {
-			Model       string  `json:"model,omitempty"`
-			MaxTokens   int     `json:"max_tokens,omitempty"`
-			Temperature float64 `json:"temperature,omitempty"`
-		} `json:"options,omitempty"`
-	}
-	if err := json.Unmarshal(cmd.Data, &req); err != nil {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid task JSON: "+err.Error())
+	var req protocol.AutomateProcessRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if req.Type == "" {
@@ -2699,20 +4240,9 @@ func (d *Daemon) hubHandleAutomateBatch(ctx context.Context, conn *hubpkg.Connec
 	}
 
 	// Parse the batch request
-	var req struct {
-		Tasks []struct {
-			Type    string                 `json:"type"`
-			Data    map[string]interface{} `json:"data"`
-			Context map[string]interface{} `json:"context"`
-			Options struct {
-				Model       string  `json:"model,omitempty"`
-				MaxTokens   int     `json:"max_tokens,omitempty"`
-				Temperature float64 `json:"temperature,omitempty"`
-			} `json:"options,omitempty"`
-		} `json:"tasks"`
-	}
-	if err := json.Unmarshal(cmd.Data, &req); err != nil {
-		return conn.WriteErr(hubproto.ErrInvalidArgs, "invalid batch JSON: "+err.Error())
+	var req protocol.AutomateBatchRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
 	}
 
 	if len(req.Tasks) == 0 {
@@ -2794,10 +4324,13 @@ func (d *Daemon) hubHandleAutomateBatch(ctx context.Context, conn *hubpkg.Connec
 	return conn.WriteJSON(data)
 }
 
-// hubHandleStopAll handles the STOP-ALL command.
+// hubHandleStopAll handles the STOP-ALL [dry-run] command.
 // Stops all running processes, proxies, and tunnels without shutting down the daemon.
 func (d *Daemon) hubHandleStopAll(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
 	debug.Log("daemon", "STOP-ALL: args=%v", cmd.Args)
+	if hasArg(cmd.Args, protocol.DryRunArg) {
+		return writeDryRun(conn, d.stopAllAffected(), nil)
+	}
 	// Count resources before stopping
 	procsBefore := len(d.hub.ProcessManager().List())
 	proxiesBefore := len(d.proxym.List())
@@ -2827,12 +4360,6 @@ func (d *Daemon) hubHandleRestartAll(ctx context.Context, conn *hubpkg.Connectio
 	runningProxies := d.proxym.List()
 
 	// Build restart manifests from running resources
-	type procManifest struct {
-		ID          string
-		Command     string
-		Args        []string
-		ProjectPath string
-	}
 	type proxyManifest struct {
 		ID          string
 		TargetURL   string
@@ -2840,19 +4367,17 @@ func (d *Daemon) hubHandleRestartAll(ctx context.Context, conn *hubpkg.Connectio
 		MaxLogSize  int
 		ProjectPath string
 		BindAddress string
+		Labels      protocol.Labels
 	}
 
-	var procsToRestart []procManifest
+	var procsToRestart []process.ProcessConfig
 	var proxiesToRestart []proxyManifest
+	procLabels := make(map[string]protocol.Labels)
 
 	for _, p := range runningProcs {
 		if p.State().String() == "running" {
-			procsToRestart = append(procsToRestart, procManifest{
-				ID:          p.ID,
-				Command:     p.Command,
-				Args:        p.Args,
-				ProjectPath: p.ProjectPath,
-			})
+			procsToRestart = append(procsToRestart, restartConfig(p))
+			procLabels[p.ID] = d.labels.get(depgraph.KindProcess, p.ID)
 		}
 	}
 
@@ -2860,11 +4385,12 @@ func (d *Daemon) hubHandleRestartAll(ctx context.Context, conn *hubpkg.Connectio
 		if p.IsRunning() {
 			proxiesToRestart = append(proxiesToRestart, proxyManifest{
 				ID:          p.ID,
-				TargetURL:   p.TargetURL.String(),
+				TargetURL:   p.Target().String(),
 				Port:        0, // Will use auto-port
 				MaxLogSize:  int(p.Logger().Stats().MaxSize),
 				ProjectPath: p.Path,
 				BindAddress: p.BindAddress,
+				Labels:      d.labels.get(depgraph.KindProxy, p.ID),
 			})
 		}
 	}
@@ -2880,16 +4406,12 @@ func (d *Daemon) hubHandleRestartAll(ctx context.Context, conn *hubpkg.Connectio
 	var proxyRestarted, proxyFailed int
 
 	for _, pm := range procsToRestart {
-		_, err := d.hub.ProcessManager().StartOrReuse(ctx, process.ProcessConfig{
-			ID:          pm.ID,
-			ProjectPath: pm.ProjectPath,
-			Command:     pm.Command,
-			Args:        pm.Args,
-		})
+		_, err := d.hub.ProcessManager().StartOrReuse(ctx, pm)
 		if err != nil {
 			log.Printf("[RESTART-ALL] Failed to restart process %s: %v", pm.ID, err)
 			procsFailed++
 		} else {
+			d.labels.set(depgraph.KindProcess, pm.ID, procLabels[pm.ID])
 			procsRestarted++
 		}
 	}
@@ -2908,6 +4430,7 @@ func (d *Daemon) hubHandleRestartAll(ctx context.Context, conn *hubpkg.Connectio
 			log.Printf("[RESTART-ALL] Failed to restart proxy %s: %v", pm.ID, err)
 			proxyFailed++
 		} else {
+			d.labels.set(depgraph.KindProxy, pm.ID, pm.Labels)
 			proxyRestarted++
 		}
 	}
@@ -2926,6 +4449,19 @@ func (d *Daemon) hubHandleRestartAll(ctx context.Context, conn *hubpkg.Connectio
 	return conn.WriteJSON(data)
 }
 
+// restartConfig returns the configuration a process was started with, so it
+// can be started again identically: command, args, environment and project
+// path.
+func restartConfig(p *process.ManagedProcess) process.ProcessConfig {
+	return process.ProcessConfig{
+		ID:          p.ID,
+		ProjectPath: p.ProjectPath,
+		Command:     p.Command,
+		Args:        append([]string(nil), p.Args...),
+		Env:         append([]string(nil), p.Env...),
+	}
+}
+
 // hubHandleProcRestart handles PROC RESTART <id>.
 // Stops a process and restarts it with the same configuration.
 // If a rogue process is using the expected port, it will be killed first.
@@ -2939,22 +4475,56 @@ func (d *Daemon) hubHandleProcRestart(ctx context.Context, conn *hubpkg.Connecti
 	// Get the process to capture its config
 	proc, err := d.hub.ProcessManager().Get(processID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("process %q not found", processID))
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
 	}
 
-	// Capture config before stopping
-	command := proc.Command
-	args := proc.Args
-	projectPath := proc.ProjectPath
-
 	// Check if process is in a restartable state
 	state := proc.State().String()
 	if state != "running" && state != "stopped" && state != "failed" {
 		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("process %q is in state %s, cannot restart", processID, state))
 	}
 
+	config := restartConfig(proc)
+	newProc, expectedPort, killedPIDs, err := d.restartProcess(ctx, proc)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to restart: %v", err))
+	}
+
+	resp := map[string]interface{}{
+		"id":           processID,
+		"process_id":   processID,
+		"command":      config.Command,
+		"args":         config.Args,
+		"project_path": config.ProjectPath,
+		"state":        newProc.State().String(),
+		"pid":          newProc.PID(),
+		"restarted":    true,
+		"success":      true,
+		"message":      fmt.Sprintf("Process %q restarted successfully", processID),
+	}
+
+	// Include info about killed rogue processes
+	if len(killedPIDs) > 0 {
+		resp["rogue_processes_killed"] = killedPIDs
+		resp["port_cleaned"] = expectedPort
+		resp["message"] = fmt.Sprintf("Process %q restarted successfully (killed rogue process(es) on port %d)", processID, expectedPort)
+	}
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// restartProcess stops a process if it is running, clears rogue listeners
+// on its expected port and starts it again with the same configuration.
+// Returns the new process, the port cleaned and the PIDs killed there.
+func (d *Daemon) restartProcess(ctx context.Context, proc *process.ManagedProcess) (*process.ManagedProcess, int, []int, error) {
+	processID := proc.ID
+	// Capture config and labels before stopping
+	config := restartConfig(proc)
+	labels := d.labels.get(depgraph.KindProcess, processID)
+
 	// Stop the process if running
-	if state == "running" {
+	if proc.IsRunning() {
 		stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 		if err := d.hub.ProcessManager().Stop(stopCtx, processID); err != nil {
@@ -2968,6 +4538,7 @@ func (d *Daemon) hubHandleProcRestart(ctx context.Context, conn *hubpkg.Connecti
 	expectedPort := d.getExpectedPortForProcess(proc)
 	var killedPIDs []int
 	if expectedPort > 0 {
+		var err error
 		killedPIDs, err = d.preflightPortCleanup(ctx, expectedPort)
 		if err != nil {
 			log.Printf("[PROC RESTART] Warning: port cleanup failed for port %d: %v", expectedPort, err)
@@ -2977,43 +4548,16 @@ func (d *Daemon) hubHandleProcRestart(ctx context.Context, conn *hubpkg.Connecti
 	}
 
 	// Remove the old process registration
-	d.hub.ProcessManager().RemoveByPath(processID, projectPath)
+	d.hub.ProcessManager().RemoveByPath(processID, config.ProjectPath)
 
 	// Start the process with the same config
-	result, err := d.hub.ProcessManager().StartOrReuse(ctx, process.ProcessConfig{
-		ID:          processID,
-		ProjectPath: projectPath,
-		Command:     command,
-		Args:        args,
-	})
+	result, err := d.hub.ProcessManager().StartOrReuse(ctx, config)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to restart: %v", err))
-	}
-
-	newProc := result.Process
-
-	resp := map[string]interface{}{
-		"id":           processID,
-		"process_id":   processID,
-		"command":      command,
-		"args":         args,
-		"project_path": projectPath,
-		"state":        newProc.State().String(),
-		"pid":          newProc.PID(),
-		"restarted":    true,
-		"success":      true,
-		"message":      fmt.Sprintf("Process %q restarted successfully", processID),
-	}
-
-	// Include info about killed rogue processes
-	if len(killedPIDs) > 0 {
-		resp["rogue_processes_killed"] = killedPIDs
-		resp["port_cleaned"] = expectedPort
-		resp["message"] = fmt.Sprintf("Process %q restarted successfully (killed rogue process(es) on port %d)", processID, expectedPort)
+		return nil, expectedPort, killedPIDs, err
 	}
+	d.labels.set(depgraph.KindProcess, processID, labels)
 
-	data, _ := json.Marshal(resp)
-	return conn.WriteJSON(data)
+	return result.Process, expectedPort, killedPIDs, nil
 }
 
 // hubHandleProxyRestart handles PROXY RESTART <id>.
@@ -3028,14 +4572,15 @@ func (d *Daemon) hubHandleProxyRestart(ctx context.Context, conn *hubpkg.Connect
 	// Get the proxy to capture its config
 	p, err := d.getSessionScopedProxy(conn, proxyID)
 	if err != nil {
-		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
 	}
 
 	// Capture config before stopping
-	targetURL := p.TargetURL.String()
+	targetURL := p.Target().String()
 	maxLogSize := int(p.Logger().Stats().MaxSize)
 	projectPath := p.Path
 	bindAddress := p.BindAddress
+	labels := d.labels.get(depgraph.KindProxy, p.ID)
 
 	// Stop the proxy
 	if err := d.proxym.Stop(ctx, proxyID); err != nil {
@@ -3062,6 +4607,7 @@ func (d *Daemon) hubHandleProxyRestart(ctx context.Context, conn *hubpkg.Connect
 	if err != nil {
 		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to restart proxy: %v", err))
 	}
+	d.labels.set(depgraph.KindProxy, newProxy.ID, labels)
 
 	// Persist the new proxy state
 	if d.stateMgr != nil {
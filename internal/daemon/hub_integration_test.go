@@ -108,6 +108,30 @@ func TestHubIntegration_CommandDispatch(t *testing.T) {
 		}
 	})
 
+	// Test HELP command through Hub
+	t.Run("HELP", func(t *testing.T) {
+		result, err := client.Help("", "")
+		if err != nil {
+			t.Fatalf("Help failed: %v", err)
+		}
+		if result["count"] == nil {
+			t.Error("Expected count field in help")
+		}
+
+		result, err = client.Help("proxy", "start")
+		if err != nil {
+			t.Fatalf("Help PROXY START failed: %v", err)
+		}
+		sub, _ := result["sub_verb"].(map[string]interface{})
+		if sub["usage"] != "PROXY START <id> <target_url> <port> [max_log_size]" {
+			t.Errorf("Unexpected PROXY START usage: %v", sub["usage"])
+		}
+
+		if _, err := client.Help("NOPE", ""); err == nil {
+			t.Error("Expected error for unknown verb")
+		}
+	})
+
 	// Test SESSION commands through Hub
 	t.Run("SESSION", func(t *testing.T) {
 		// LIST (no sessions yet)
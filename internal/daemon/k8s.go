@@ -0,0 +1,398 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/k8s"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// k8sPollInterval is how often the pods of log streams are listed for
+	// restarts.
+	k8sPollInterval = 10 * time.Second
+	// k8sMaxEvents is how many restart events are kept per log stream.
+	k8sMaxEvents   = 50
+	k8sDefaultTail = 100
+)
+
+// k8sEntity is a port-forward or log stream started by K8S FORWARD or LOGS.
+// Each runs kubectl as a managed process restarted when it exits, since
+// kubectl gives up when the pod it is attached to goes away.
+type k8sEntity struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"` // forward or logs
+	ProcessID   string     `json:"process_id"`
+	ProjectPath string     `json:"-"`
+	Target      k8s.Target `json:"target"`
+
+	// Port-forwards
+	Resource  string `json:"resource,omitempty"`
+	Port      int    `json:"port,omitempty"`
+	LocalPort int    `json:"local_port,omitempty"`
+	URL       string `json:"url,omitempty"`
+
+	// Log streams, whose pods are watched for restarts
+	Selector  string             `json:"selector,omitempty"`
+	Container string             `json:"container,omitempty"`
+	Pods      []k8s.Container    `json:"pods,omitempty"`
+	Restarts  []k8s.RestartEvent `json:"restarts,omitempty"`
+	LastError string             `json:"last_error,omitempty"`
+
+	counts map[string]int // Restart counts of the last listing
+}
+
+// k8sState tracks the K8S entities by process ID.
+type k8sState struct {
+	mu       sync.Mutex
+	entities map[string]*k8sEntity
+}
+
+func (s *k8sState) add(e *k8sEntity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entities == nil {
+		s.entities = make(map[string]*k8sEntity)
+	}
+	s.entities[e.ProcessID] = e
+}
+
+func (s *k8sState) remove(processID string) (*k8sEntity, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entities[processID]
+	delete(s.entities, processID)
+	return e, ok
+}
+
+// forgetProject drops a project's entities, whose processes are stopped
+// with the project's other processes.
+func (s *k8sState) forgetProject(projectPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.entities {
+		if e.ProjectPath == projectPath {
+			delete(s.entities, id)
+		}
+	}
+}
+
+// list returns copies of a project's entities, or all of them, by ID.
+func (s *k8sState) list(projectPath string) []k8sEntity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []k8sEntity
+	for _, e := range s.entities {
+		if projectPath == "" || e.ProjectPath == projectPath {
+			list = append(list, *e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// k8sEntityID derives an entity ID from what it forwards or follows, such
+// as "svc-api-8080" or "logs-app-api".
+func k8sEntityID(parts ...string) string {
+	id := strings.Join(parts, "-")
+	id = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, id)
+	return strings.Trim(id, "-")
+}
+
+// k8sLoop lists the pods of log streams for restarts until the daemon stops.
+func (d *Daemon) k8sLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(k8sPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.pollK8sPods()
+		}
+	}
+}
+
+// pollK8sPods records the container restarts of each log stream's pods.
+func (d *Daemon) pollK8sPods() {
+	d.k8s.mu.Lock()
+	var streams []*k8sEntity
+	for _, e := range d.k8s.entities {
+		if e.Kind == "logs" {
+			streams = append(streams, e)
+		}
+	}
+	d.k8s.mu.Unlock()
+
+	for _, e := range streams {
+		containers, err := listK8sPods(d.ctx, e.Target, e.Selector)
+		now := time.Now()
+		d.k8s.mu.Lock()
+		if err != nil {
+			e.LastError = err.Error()
+			d.k8s.mu.Unlock()
+			continue
+		}
+		e.LastError = ""
+		e.Pods = containers
+		if e.counts != nil {
+			for _, ev := range k8s.Restarts(e.counts, containers, now) {
+				log.Printf("[K8S] %s: container %s/%s restarted (%s, exit %d)", e.ID, ev.Pod, ev.Container, ev.Reason, ev.ExitCode)
+				e.Restarts = append(e.Restarts, ev)
+			}
+			if len(e.Restarts) > k8sMaxEvents {
+				e.Restarts = e.Restarts[len(e.Restarts)-k8sMaxEvents:]
+			}
+		}
+		e.counts = k8s.RestartCounts(containers)
+		d.k8s.mu.Unlock()
+	}
+}
+
+// listK8sPods lists the containers of the pods matching selector.
+func listK8sPods(ctx context.Context, target k8s.Target, selector string) ([]k8s.Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, k8sPollInterval)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "kubectl", target.PodsArgs(selector)...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("kubectl get pods: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("kubectl get pods: %w", err)
+	}
+	return k8s.ParsePods(out)
+}
+
+// hubHandleK8s handles the K8S command.
+func (d *Daemon) hubHandleK8s(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbForward:
+		return d.hubHandleK8sForward(ctx, conn, cmd)
+	case protocol.SubVerbLogs:
+		return d.hubHandleK8sLogs(ctx, conn, cmd)
+	case protocol.SubVerbStatus:
+		return d.hubHandleK8sStatus(conn, cmd)
+	case protocol.SubVerbStop:
+		return d.hubHandleK8sStop(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown K8S sub-command",
+			Command:      protocol.VerbK8s,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbForward, protocol.SubVerbLogs, protocol.SubVerbStatus, protocol.SubVerbStop},
+		})
+	}
+}
+
+// startK8sEntity runs kubectl for an entity as a managed process that is
+// restarted whenever it exits.
+func (d *Daemon) startK8sEntity(ctx context.Context, e *k8sEntity, args []string) (*process.ManagedProcess, error) {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return nil, fmt.Errorf("kubectl not found in PATH")
+	}
+	proc, err := d.startFreshProcess(ctx, process.ProcessConfig{
+		ID:          e.ProcessID,
+		ProjectPath: e.ProjectPath,
+		Command:     "kubectl",
+		Args:        args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg, _ := normalizeSuperviseConfig(protocol.SuperviseConfig{Restart: protocol.RestartAlways, MaxRestarts: 100})
+	d.supervise(e.ProcessID, e.ProjectPath, cfg)
+	d.k8s.add(e)
+	return proc, nil
+}
+
+// hubHandleK8sForward handles K8S FORWARD with a protocol.K8sForwardConfig
+// payload.
+func (d *Daemon) hubHandleK8sForward(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req protocol.K8sForwardConfig
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if err := k8s.ValidateResource(req.Resource); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if req.Port <= 0 || req.Port > 65535 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "port required")
+	}
+	projectPath := d.remoteProjectPath(conn, req.Path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "K8S FORWARD requires a session or path")
+	}
+
+	localPort := req.LocalPort
+	if localPort <= 0 {
+		port, err := freeLoopbackPort(req.Port)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInternal, err.Error())
+		}
+		localPort = port
+	}
+	id := req.ID
+	if id == "" {
+		id = k8sEntityID(strings.Replace(req.Resource, "/", "-", 1), strconv.Itoa(req.Port))
+	}
+	target := k8s.Target{Context: req.Context, Namespace: req.Namespace}
+	e := &k8sEntity{
+		ID:          id,
+		Kind:        "forward",
+		ProcessID:   makeProcessID(projectPath, "k8s-"+id),
+		ProjectPath: projectPath,
+		Target:      target,
+		Resource:    req.Resource,
+		Port:        req.Port,
+		LocalPort:   localPort,
+		URL:         fmt.Sprintf("http://localhost:%d", localPort),
+	}
+	proc, err := d.startK8sEntity(ctx, e, target.PortForwardArgs(req.Resource, localPort, req.Port))
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start port-forward: %v", err))
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":         e.ID,
+		"process_id": e.ProcessID,
+		"pid":        proc.PID(),
+		"resource":   e.Resource,
+		"port":       e.Port,
+		"local_port": e.LocalPort,
+		"url":        e.URL,
+	})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleK8sLogs handles K8S LOGS with a protocol.K8sLogsConfig payload.
+func (d *Daemon) hubHandleK8sLogs(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req protocol.K8sLogsConfig
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if req.Selector == "" || strings.HasPrefix(req.Selector, "-") {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "selector required, e.g. app=api")
+	}
+	projectPath := d.remoteProjectPath(conn, req.Path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "K8S LOGS requires a session or path")
+	}
+	tail := req.Tail
+	if tail <= 0 {
+		tail = k8sDefaultTail
+	}
+	id := req.ID
+	if id == "" {
+		id = k8sEntityID("logs", req.Selector)
+	}
+	target := k8s.Target{Context: req.Context, Namespace: req.Namespace}
+	e := &k8sEntity{
+		ID:          id,
+		Kind:        "logs",
+		ProcessID:   makeProcessID(projectPath, "k8s-"+id),
+		ProjectPath: projectPath,
+		Target:      target,
+		Selector:    req.Selector,
+		Container:   req.Container,
+	}
+	// The first listing is the baseline restarts are counted from
+	if containers, err := listK8sPods(ctx, target, req.Selector); err == nil {
+		e.Pods = containers
+		e.counts = k8s.RestartCounts(containers)
+	}
+	proc, err := d.startK8sEntity(ctx, e, target.LogsArgs(req.Selector, req.Container, tail))
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start log stream: %v", err))
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":         e.ID,
+		"process_id": e.ProcessID,
+		"pid":        proc.PID(),
+		"selector":   e.Selector,
+		"pods":       len(e.Pods),
+	})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleK8sStatus handles K8S STATUS [path]: the project's forwards and
+// log streams with their pods and restart events.
+func (d *Daemon) hubHandleK8sStatus(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	entities := d.k8s.list(d.remoteProjectPath(conn, path))
+	resp := make([]map[string]interface{}, 0, len(entities))
+	for _, e := range entities {
+		state := "removed"
+		if proc, err := d.hub.ProcessManager().Get(e.ProcessID); err == nil {
+			state = proc.State().String()
+		}
+		entry := map[string]interface{}{"entity": e, "state": state}
+		if sv, ok := d.supervisor.status(e.ProcessID); ok {
+			entry["reconnects"] = sv.Restarts
+		}
+		resp = append(resp, entry)
+	}
+	data, _ := json.Marshal(map[string]interface{}{"entities": resp, "count": len(resp)})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleK8sStop handles K8S STOP <id>: stop and forget a forward or log
+// stream.
+func (d *Daemon) hubHandleK8sStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "id required")
+	}
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	processID := makeProcessID(d.remoteProjectPath(conn, path), "k8s-"+cmd.Args[0])
+	if _, ok := d.k8s.remove(processID); !ok {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("no K8S forward or log stream %q", cmd.Args[0]))
+	}
+	d.supervisor.remove(processID)
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := d.hub.ProcessManager().Stop(stopCtx, processID); err != nil {
+		log.Printf("[K8S] error stopping %s: %v", processID, err)
+	}
+	return conn.WriteOK(fmt.Sprintf("stopped %s", cmd.Args[0]))
+}
+
+// freeLoopbackPort returns preferred if it is free on loopback, else any
+// free port.
+func freeLoopbackPort(preferred int) (int, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(preferred)))
+	if err != nil {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,34 @@
+package daemon
+
+import "testing"
+
+func TestK8sEntityID(t *testing.T) {
+	tests := map[string][]string{
+		"svc-api-8080":         {"svc-api", "8080"},
+		"logs-app-api":         {"logs", "app=api"},
+		"logs-app-in--api-web": {"logs", "app in (api,web)"},
+	}
+	for want, parts := range tests {
+		if got := k8sEntityID(parts...); got != want {
+			t.Errorf("k8sEntityID(%q) = %s, want %s", parts, got, want)
+		}
+	}
+}
+
+func TestK8sStateForgetProject(t *testing.T) {
+	var s k8sState
+	s.add(&k8sEntity{ID: "b", ProcessID: "p1:b", ProjectPath: "/p1"})
+	s.add(&k8sEntity{ID: "a", ProcessID: "p1:a", ProjectPath: "/p1"})
+	s.add(&k8sEntity{ID: "c", ProcessID: "p2:c", ProjectPath: "/p2"})
+
+	if list := s.list("/p1"); len(list) != 2 || list[0].ID != "a" {
+		t.Fatalf("Expected p1's entities by ID, got %+v", list)
+	}
+	s.forgetProject("/p1")
+	if list := s.list(""); len(list) != 1 || list[0].ID != "c" {
+		t.Errorf("Expected only p2's entity left, got %+v", list)
+	}
+	if _, ok := s.remove("p2:c"); !ok {
+		t.Error("Expected p2:c removed")
+	}
+}
@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// labelStore holds the labels of processes, proxies and tunnels. Labels go
+// away with the entity, so a new one reusing its ID starts without them;
+// restarts carry them over explicitly.
+type labelStore struct {
+	mu     sync.RWMutex
+	labels map[labelKey]protocol.Labels
+}
+
+// labelKey identifies a labeled entity; kinds are the depgraph kinds.
+type labelKey struct {
+	kind, id string
+}
+
+// get returns a copy of the entity's labels, or nil.
+func (s *labelStore) get(kind, id string) protocol.Labels {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	labels := s.labels[labelKey{kind, id}]
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(protocol.Labels, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+// update sets and removes labels of an entity and returns the result.
+func (s *labelStore) update(kind, id string, set protocol.Labels, remove []string) (protocol.Labels, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := labelKey{kind, id}
+	labels := make(protocol.Labels, len(s.labels[key])+len(set))
+	for k, v := range s.labels[key] {
+		labels[k] = v
+	}
+	for k, v := range set {
+		labels[k] = v
+	}
+	for _, k := range remove {
+		delete(labels, k)
+	}
+	if err := labels.Validate(); err != nil {
+		return nil, err
+	}
+
+	if s.labels == nil {
+		s.labels = make(map[labelKey]protocol.Labels)
+	}
+	if len(labels) == 0 {
+		delete(s.labels, key)
+		return nil, nil
+	}
+	s.labels[key] = labels
+	return labels, nil
+}
+
+// set replaces the labels of an entity.
+func (s *labelStore) set(kind, id string, labels protocol.Labels) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := labelKey{kind, id}
+	if len(labels) == 0 {
+		delete(s.labels, key)
+		return
+	}
+	if s.labels == nil {
+		s.labels = make(map[labelKey]protocol.Labels)
+	}
+	s.labels[key] = labels
+}
+
+// remove drops the labels of an entity that went away.
+func (s *labelStore) remove(kind, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.labels, labelKey{kind, id})
+}
+
+// retain drops the labels of the entities of kind that keep rejects.
+func (s *labelStore) retain(kind string, keep func(id string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.labels {
+		if key.kind == kind && !keep(key.id) {
+			delete(s.labels, key)
+		}
+	}
+}
+
+// matches reports whether the entity has every label of selector.
+func (s *labelStore) matches(kind, id string, selector protocol.Labels) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.labels[labelKey{kind, id}].Matches(selector)
+}
+
+// dropProcessLabels drops the labels of a stopped process, after recording
+// its exit so process.exited still carries them.
+func (d *Daemon) dropProcessLabels(id string) {
+	d.scanCrashes()
+	d.labels.remove(depgraph.KindProcess, id)
+}
+
+// parseLabelArgs reads LABEL args: key=value sets a label, key- removes it.
+func parseLabelArgs(args []string) (protocol.Labels, []string, error) {
+	set := protocol.Labels{}
+	var remove []string
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			set[key] = value
+			continue
+		}
+		if key, ok := strings.CutSuffix(arg, "-"); ok && key != "" {
+			remove = append(remove, key)
+			continue
+		}
+		return nil, nil, fmt.Errorf("invalid label %q: use key=value to set or key- to remove", arg)
+	}
+	return set, remove, set.Validate()
+}
+
+// parseListFilter reads the optional filter of a LIST command.
+func parseListFilter(data []byte) (protocol.ListFilter, error) {
+	var filter protocol.ListFilter
+	if len(data) == 0 {
+		return filter, nil
+	}
+	if err := json.Unmarshal(data, &filter); err != nil {
+		return filter, err
+	}
+	return filter, filter.Labels.Validate()
+}
+
+// hubHandleLabel handles <VERB> LABEL <id> [key=value ...] [key- ...] once
+// the entity is resolved. Without changes it reports the current labels.
+func (d *Daemon) hubHandleLabel(conn *hubpkg.Connection, kind, id string, args []string) error {
+	set, remove, err := parseLabelArgs(args)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	labels, err := d.labels.update(kind, id, set, remove)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	resp := map[string]interface{}{
+		"id":     id,
+		"kind":   kind,
+		"labels": labels,
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleProcLabel handles PROC LABEL <id> [key=value ...] [key- ...].
+func (d *Daemon) hubHandleProcLabel(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "process_id required")
+	}
+	proc, err := d.hub.ProcessManager().Get(cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProcess, cmd.Args[0], err)
+	}
+	return d.hubHandleLabel(conn, depgraph.KindProcess, proc.ID, cmd.Args[1:])
+}
+
+// hubHandleProxyLabel handles PROXY LABEL <id> [key=value ...] [key- ...].
+func (d *Daemon) hubHandleProxyLabel(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROXY LABEL requires: <id>")
+	}
+	p, err := d.getSessionScopedProxy(conn, cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, cmd.Args[0], err)
+	}
+	return d.hubHandleLabel(conn, depgraph.KindProxy, p.ID, cmd.Args[1:])
+}
+
+// hubHandleTunnelLabel handles TUNNEL LABEL <id> [key=value ...] [key- ...].
+func (d *Daemon) hubHandleTunnelLabel(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "TUNNEL LABEL requires: <id>")
+	}
+	t, err := d.tunnelm.Get(cmd.Args[0])
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityTunnel, cmd.Args[0], err)
+	}
+	return d.hubHandleLabel(conn, depgraph.KindTunnel, t.ID(), cmd.Args[1:])
+}
@@ -0,0 +1,146 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/depgraph"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestLabelStore(t *testing.T) {
+	var store labelStore
+
+	labels, err := store.update(depgraph.KindProxy, "app", protocol.Labels{"area": "checkout", "owner": "payments"}, nil)
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if labels.String() != "area=checkout,owner=payments" {
+		t.Errorf("Expected both labels, got %s", labels)
+	}
+
+	// Same ID of another kind is a different entity
+	if got := store.get(depgraph.KindProcess, "app"); got != nil {
+		t.Errorf("Expected no process labels, got %v", got)
+	}
+
+	tests := []struct {
+		selector protocol.Labels
+		want     bool
+	}{
+		{nil, true},
+		{protocol.Labels{"area": "checkout"}, true},
+		{protocol.Labels{"area": ""}, true},
+		{protocol.Labels{"area": "search"}, false},
+		{protocol.Labels{"area": "checkout", "team": ""}, false},
+	}
+	for _, tt := range tests {
+		if got := store.matches(depgraph.KindProxy, "app", tt.selector); got != tt.want {
+			t.Errorf("matches(%v) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+
+	// get returns a copy
+	store.get(depgraph.KindProxy, "app")["area"] = "search"
+	if !store.matches(depgraph.KindProxy, "app", protocol.Labels{"area": "checkout"}) {
+		t.Error("Expected get to return a copy")
+	}
+
+	labels, err = store.update(depgraph.KindProxy, "app", protocol.Labels{"area": "search"}, []string{"owner"})
+	if err != nil || labels.String() != "area=search" {
+		t.Errorf("Expected area replaced and owner removed, got %s (%v)", labels, err)
+	}
+	labels, _ = store.update(depgraph.KindProxy, "app", nil, []string{"area"})
+	if labels != nil || store.get(depgraph.KindProxy, "app") != nil {
+		t.Errorf("Expected no labels left, got %v", labels)
+	}
+
+	if _, err := store.update(depgraph.KindProxy, "app", protocol.Labels{"bad key": "x"}, nil); err == nil {
+		t.Error("Expected error for an invalid key")
+	}
+}
+
+func TestLabelStoreRemove(t *testing.T) {
+	var store labelStore
+	store.set(depgraph.KindProcess, "dev", protocol.Labels{"agnt/apply": "stack"})
+	store.set(depgraph.KindProcess, "api", protocol.Labels{"area": "checkout"})
+	store.set(depgraph.KindProxy, "dev", protocol.Labels{"area": "checkout"})
+
+	store.retain(depgraph.KindProcess, func(id string) bool { return id == "api" })
+	if got := store.get(depgraph.KindProcess, "dev"); got != nil {
+		t.Errorf("Expected the gone process's labels dropped, got %v", got)
+	}
+	if store.get(depgraph.KindProcess, "api") == nil || store.get(depgraph.KindProxy, "dev") == nil {
+		t.Error("Expected the labels of other entities kept")
+	}
+
+	store.remove(depgraph.KindProxy, "dev")
+	store.set(depgraph.KindProcess, "api", nil)
+	if len(store.labels) != 0 {
+		t.Errorf("Expected no labels left, got %v", store.labels)
+	}
+}
+
+func TestLabelsEndWithProxy(t *testing.T) {
+	tmpDir := t.TempDir()
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	ctx := context.Background()
+	config := proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir}
+
+	px, err := d.proxym.Create(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	d.labels.update(depgraph.KindProxy, px.ID, protocol.Labels{"agnt/apply": "stack"}, nil)
+	if err := d.proxym.Stop(ctx, px.ID); err != nil {
+		t.Fatalf("Failed to stop proxy: %v", err)
+	}
+
+	// A new proxy under the same ID doesn't inherit them
+	px, err = d.proxym.Create(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to create proxy again: %v", err)
+	}
+	defer d.proxym.Stop(ctx, px.ID)
+	if got := d.labels.get(depgraph.KindProxy, px.ID); got != nil {
+		t.Errorf("Expected a new proxy without labels, got %v", got)
+	}
+}
+
+func TestParseLabelArgs(t *testing.T) {
+	set, remove, err := parseLabelArgs([]string{"area=checkout", "note=", "owner-"})
+	if err != nil {
+		t.Fatalf("parseLabelArgs failed: %v", err)
+	}
+	if set.String() != "area=checkout,note=" {
+		t.Errorf("Unexpected set: %s", set)
+	}
+	if len(remove) != 1 || remove[0] != "owner" {
+		t.Errorf("Unexpected remove: %v", remove)
+	}
+
+	for _, args := range [][]string{{"area"}, {"-"}, {"a b=c"}} {
+		if _, _, err := parseLabelArgs(args); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestParseListFilter(t *testing.T) {
+	filter, err := parseListFilter([]byte(`{"global":true,"labels":{"area":"checkout"}}`))
+	if err != nil {
+		t.Fatalf("parseListFilter failed: %v", err)
+	}
+	if !filter.Global || filter.Labels["area"] != "checkout" {
+		t.Errorf("Unexpected filter: %+v", filter)
+	}
+
+	if _, err := parseListFilter(nil); err != nil {
+		t.Errorf("Expected no filter to be valid, got %v", err)
+	}
+	if _, err := parseListFilter([]byte(`{"labels":{"":"x"}}`)); err == nil {
+		t.Error("Expected error for an empty label key")
+	}
+}
@@ -0,0 +1,175 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/procstat"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// metricsInterval is how often running processes are sampled.
+	metricsInterval = 5 * time.Second
+	// metricsHistory is how many samples are kept per process: 10 minutes.
+	metricsHistory = 120
+)
+
+// processMetrics holds the CPU and memory history of managed processes.
+type processMetrics struct {
+	mu   sync.Mutex
+	byID map[string]*procstat.History
+}
+
+// get returns a process's history, if it has been sampled.
+func (m *processMetrics) get(processID string) (*procstat.History, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.byID[processID]
+	return h, ok
+}
+
+// metricsLoop samples running processes until the daemon stops.
+func (d *Daemon) metricsLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(metricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.sampleMetrics()
+		}
+	}
+}
+
+// sampleMetrics records one sample per running process and drops the
+// histories of removed processes. Stopped processes keep theirs, so the
+// usage leading up to a crash can be read.
+func (d *Daemon) sampleMetrics() {
+	procs := d.hub.ProcessManager().List()
+	present := make(map[string]bool, len(procs))
+	now := time.Now()
+	for _, p := range procs {
+		present[p.ID] = true
+		pid := p.PID()
+		if !p.IsRunning() || pid <= 0 {
+			continue
+		}
+		usage, err := procstat.Tree(pid)
+		if err != nil {
+			continue // Exited meanwhile, or unsupported
+		}
+		d.metrics.mu.Lock()
+		if d.metrics.byID == nil {
+			d.metrics.byID = make(map[string]*procstat.History)
+		}
+		h, ok := d.metrics.byID[p.ID]
+		if !ok {
+			h = procstat.NewHistory(metricsHistory)
+			d.metrics.byID[p.ID] = h
+		}
+		d.metrics.mu.Unlock()
+		h.Add(pid, usage, now)
+	}
+
+	d.metrics.mu.Lock()
+	for id := range d.metrics.byID {
+		if !present[id] {
+			delete(d.metrics.byID, id)
+		}
+	}
+	d.metrics.mu.Unlock()
+}
+
+// metricsSummary is the latest sample of a process, as shown in PROC STATUS.
+type metricsSummary struct {
+	procstat.Sample
+	RSS          string `json:"rss"`
+	PeakRSSBytes uint64 `json:"peak_rss_bytes"`
+}
+
+// latestMetrics returns a process's latest sample, or nil before the first.
+func (d *Daemon) latestMetrics(processID string) *metricsSummary {
+	h, ok := d.metrics.get(processID)
+	if !ok {
+		return nil
+	}
+	s, ok := h.Latest()
+	if !ok {
+		return nil
+	}
+	return &metricsSummary{Sample: s, RSS: formatBytes(s.RSSBytes), PeakRSSBytes: h.PeakRSS()}
+}
+
+// hubHandleProcMetrics handles PROC METRICS <id> [limit=N]: the latest CPU
+// and memory sample of a process and its recent history.
+func (d *Daemon) hubHandleProcMetrics(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "process_id required")
+	}
+	processID := cmd.Args[0]
+	if _, err := d.hub.ProcessManager().Get(processID); err != nil {
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
+	}
+
+	limit := 0
+	for _, arg := range cmd.Args[1:] {
+		value, ok := strings.CutPrefix(arg, "limit=")
+		n, err := strconv.Atoi(value)
+		if !ok || err != nil || n < 0 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid option %q (use limit=N)", arg))
+		}
+		limit = n
+	}
+
+	resp := map[string]interface{}{
+		"process_id":  processID,
+		"interval_ms": metricsInterval.Milliseconds(),
+	}
+	h, ok := d.metrics.get(processID)
+	if !ok {
+		resp["samples"] = []procstat.Sample{}
+		resp["message"] = fmt.Sprintf("no samples yet; running processes are sampled every %s", metricsInterval)
+		data, _ := json.Marshal(resp)
+		return conn.WriteJSON(data)
+	}
+
+	samples := h.Samples()
+	if len(samples) > 0 {
+		first, last := samples[0], samples[len(samples)-1]
+		resp["current"] = d.latestMetrics(processID)
+		// Growth over the window, the sign of a leak
+		resp["rss_growth_bytes"] = int64(last.RSSBytes) - int64(first.RSSBytes)
+		resp["window_ms"] = last.Time.Sub(first.Time).Milliseconds()
+	}
+	if limit > 0 && len(samples) > limit {
+		samples = samples[len(samples)-limit:]
+	}
+	resp["samples"] = samples
+	resp["peak_rss_bytes"] = h.PeakRSS()
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// formatBytes renders a byte count with a binary unit.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
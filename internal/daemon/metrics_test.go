@@ -0,0 +1,18 @@
+package daemon
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:                     "512 B",
+		1536:                    "1.5 KiB",
+		300 << 20:               "300.0 MiB",
+		4 << 30:                 "4.0 GiB",
+		(5 << 40) + (512 << 30): "5.5 TiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// decodeData decodes a command's JSON payload into v (see
+// protocol.DecodePayload). An empty payload leaves v as is. Unknown fields
+// don't fail the command; they are logged with the closest known field.
+func decodeData(cmd *hubproto.Command, v interface{}) error {
+	warnings, err := protocol.DecodePayload(cmd.Data, v)
+	for _, w := range warnings {
+		log.Printf("[WARN] %s: %s", commandLabel(cmd), w)
+	}
+	return err
+}
+
+// writePayloadErr reports a payload decodeData rejected, naming the field at
+// fault and listing the fields and types the command takes.
+func writePayloadErr(conn *hubpkg.Connection, cmd *hubproto.Command, err error) error {
+	var perr *protocol.PayloadError
+	if !errors.As(err, &perr) {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid %s payload: %v", commandLabel(cmd), err))
+	}
+	details := map[string]any{}
+	if perr.Expected != "" {
+		details["expected"] = perr.Expected
+		details["got"] = perr.Got
+	}
+	if perr.Offset > 0 {
+		details["offset"] = perr.Offset
+	}
+	if perr.Schema != nil {
+		details["schema"] = perr.Schema
+	}
+	return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+		Code:        hubproto.ErrInvalidArgs,
+		Message:     fmt.Sprintf("invalid %s payload: %s", commandLabel(cmd), perr.Reason),
+		Command:     cmd.Verb,
+		Action:      cmd.SubVerb,
+		Param:       perr.Field,
+		ValidParams: perr.Fields(),
+		Details:     details,
+	})
+}
+
+// commandLabel names a command for messages, e.g. "PROXY START".
+func commandLabel(cmd *hubproto.Command) string {
+	return strings.TrimSpace(cmd.Verb + " " + cmd.SubVerb)
+}
@@ -0,0 +1,254 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/profile"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// defaultProfileSeconds is how long a CPU profile samples by default.
+	defaultProfileSeconds = 10
+
+	// maxProfileSeconds caps a single profile capture.
+	maxProfileSeconds = 60
+
+	// defaultProfileTop is how many functions a profile summary lists.
+	defaultProfileTop = 20
+
+	// profileDir holds captured profiles, relative to the project root.
+	profileDir = ".agnt/profiles"
+
+	// defaultPprofAddress is where net/http/pprof is conventionally served
+	// when the app runs a dedicated debug listener.
+	defaultPprofAddress = "http://localhost:6060"
+)
+
+// hubHandleProfile handles the PROFILE command and its sub-verbs.
+func (d *Daemon) hubHandleProfile(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "PROFILE %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "CPU":
+		return d.hubHandleProfileCapture(ctx, conn, cmd, profile.KindCPU)
+	case "HEAP":
+		return d.hubHandleProfileCapture(ctx, conn, cmd, profile.KindHeap)
+	case "LIST":
+		return d.hubHandleProfileList(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown PROFILE sub-command",
+			Command:      "PROFILE",
+			ValidActions: []string{"CPU", "HEAP", "LIST"},
+		})
+	}
+}
+
+// profileRequest is the JSON payload for PROFILE CPU and PROFILE HEAP.
+type profileRequest struct {
+	Seconds int    `json:"seconds"`
+	Address string `json:"address"`
+	Top     int    `json:"top"`
+	Path    string `json:"path"`
+}
+
+// hubHandleProfileCapture handles PROFILE CPU|HEAP <process_id>.
+// Node processes are profiled through the inspector they announce in their
+// output; Go processes through net/http/pprof on one of their detected URLs.
+// The raw profile is stored under .agnt/profiles and summarized.
+func (d *Daemon) hubHandleProfileCapture(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command, kind string) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "process_id required")
+	}
+	processID := cmd.Args[0]
+
+	var req profileRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if req.Seconds <= 0 {
+		req.Seconds = defaultProfileSeconds
+	}
+	if req.Seconds > maxProfileSeconds {
+		req.Seconds = maxProfileSeconds
+	}
+	if req.Top <= 0 {
+		req.Top = defaultProfileTop
+	}
+
+	proc, err := d.hub.ProcessManager().Get(processID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
+	}
+	if proc.IsDone() {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("process %q is not running", processID))
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" {
+		projectPath = proc.ProjectPath
+	}
+
+	duration := time.Duration(req.Seconds) * time.Second
+	captureCtx, cancel := context.WithTimeout(ctx, duration+30*time.Second)
+	defer cancel()
+
+	runtime, data, err := d.captureProfile(captureCtx, proc, kind, req.Address, duration)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+
+	var (
+		summary *profile.Summary
+		ext     string
+	)
+	switch {
+	case runtime == "go":
+		summary, err = profile.SummarizePprof(data, req.Top)
+		ext = ".pb.gz"
+	case kind == profile.KindCPU:
+		summary, err = profile.SummarizeNodeCPU(data, req.Top)
+		ext = ".cpuprofile"
+	default:
+		summary, err = profile.SummarizeNodeHeap(data, req.Top)
+		ext = ".heapprofile"
+	}
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("cannot summarize profile: %v", err))
+	}
+
+	dir := filepath.Join(projectPath, profileDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	name := fmt.Sprintf("%s-%s-%s%s", sanitizeProfileName(processID), kind, time.Now().Format("20060102-150405"), ext)
+	artifact := filepath.Join(dir, name)
+	if err := os.WriteFile(artifact, data, 0644); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	resp := map[string]interface{}{
+		"process_id": processID,
+		"kind":       kind,
+		"runtime":    runtime,
+		"artifact":   artifact,
+		"bytes":      len(data),
+		"summary":    summary,
+	}
+	if kind == profile.KindCPU {
+		resp["seconds"] = req.Seconds
+	}
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// captureProfile grabs a profile from proc and reports which runtime served it
+// ("go" or "node"). An explicit address takes precedence over discovery.
+func (d *Daemon) captureProfile(ctx context.Context, proc *process.ManagedProcess, kind, address string, duration time.Duration) (string, []byte, error) {
+	if strings.HasPrefix(address, "ws://") || strings.HasPrefix(address, "wss://") {
+		data, err := profile.CaptureNode(ctx, address, kind, duration)
+		return "node", data, err
+	}
+
+	if address == "" {
+		if output, _ := proc.CombinedOutput(); len(output) > 0 {
+			if wsURL := profile.InspectorURL(string(output)); wsURL != "" {
+				data, err := profile.CaptureNode(ctx, wsURL, kind, duration)
+				return "node", data, err
+			}
+		}
+	}
+
+	candidates := []string{address}
+	if address == "" {
+		candidates = append(d.urlTracker.GetURLs(proc.ID), defaultPprofAddress)
+	}
+	client := &http.Client{Timeout: duration + 15*time.Second}
+	probe := &http.Client{Timeout: 2 * time.Second}
+	for _, base := range candidates {
+		if !strings.Contains(base, "://") {
+			base = "http://" + base
+		}
+		if !profile.HasPprof(ctx, probe, base) {
+			continue
+		}
+		data, err := profile.FetchPprof(ctx, client, base, kind, duration)
+		return "go", data, err
+	}
+
+	if address != "" {
+		return "", nil, fmt.Errorf("no pprof endpoint at %s/debug/pprof/", strings.TrimRight(address, "/"))
+	}
+	return "", nil, errors.New("no profiling endpoint found: start Node processes with profile enabled, " +
+		"or import net/http/pprof in the Go app and pass its address")
+}
+
+// sanitizeProfileName makes a process ID safe to use in a file name.
+func sanitizeProfileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// hubHandleProfileList handles PROFILE LIST.
+// Lists the profiles stored for the session's project, newest first.
+func (d *Daemon) hubHandleProfileList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req profileRequest
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && req.Path != "" {
+		projectPath = normalizePath(req.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "PROFILE LIST requires a session or path")
+	}
+
+	type artifact struct {
+		Path     string    `json:"path"`
+		Bytes    int64     `json:"bytes"`
+		Captured time.Time `json:"captured"`
+	}
+	dir := filepath.Join(projectPath, profileDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	list := make([]artifact, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		list = append(list, artifact{Path: filepath.Join(dir, e.Name()), Bytes: info.Size(), Captured: info.ModTime()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Captured.After(list[j].Captured) })
+
+	resp := map[string]interface{}{
+		"profiles": list,
+		"count":    len(list),
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
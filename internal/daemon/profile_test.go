@@ -0,0 +1,16 @@
+package daemon
+
+import "testing"
+
+func TestSanitizeProfileName(t *testing.T) {
+	tests := map[string]string{
+		"dev":                 "dev",
+		"/home/me/app:dev":    "_home_me_app_dev",
+		"api server (v2).bin": "api_server__v2_.bin",
+	}
+	for in, want := range tests {
+		if got := sanitizeProfileName(in); got != want {
+			t.Errorf("sanitizeProfileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/standardbeagle/agnt/internal/config"
@@ -39,6 +40,9 @@ func (d *Daemon) handleProxyEvents() {
 func (d *Daemon) handleURLDetected(event ProxyEvent) {
 	log.Printf("[DEBUG] URL detected from %s: %s (path: %s)", event.ScriptID, event.URL, event.Path)
 
+	// Proxies whose server moved here follow it instead of being recreated
+	moved := d.retargetProxies(event.ScriptID, event.URL)
+
 	// Get project path from event
 	projectPath := event.Path
 	if projectPath == "" {
@@ -67,8 +71,13 @@ func (d *Daemon) handleURLDetected(event ProxyEvent) {
 			continue // Not linked to this script
 		}
 
-		// Create proxy for this URL
+		// Create proxy for this URL, unless this proxy's previous instance is
+		// being retargeted to it
 		proxyID := makeProxyIDFromURL(projectPath, proxyName, event.URL)
+		if px := findProxyWithPrefix(moved, makeProcessID(projectPath, proxyName)+":"); px != nil {
+			log.Printf("[DEBUG] Proxy %s follows %s to %s, skipping", px.ID, event.ScriptID, event.URL)
+			continue
+		}
 
 		// Check if proxy already exists
 		if _, err := d.proxym.Get(proxyID); err == nil {
@@ -94,6 +103,17 @@ func (d *Daemon) handleURLDetected(event ProxyEvent) {
 			MaxLogSize:  proxyConfig.MaxLogSize,
 			AutoRestart: true,
 			Path:        projectPath,
+			NoRetarget:  proxyConfig.NoRetarget,
+			Routes:      configRoutes(proxyConfig.Routes),
+			PathRoutes:  configPathRoutes(proxyConfig.PathRoutes),
+			Cookies:     configCookies(proxyConfig.Cookies),
+			URLRewrite:  configURLRewrite(proxyConfig.URLRewrite),
+			Storms:      configStorms(proxyConfig.Storms),
+			Banner:      configBanner(proxyConfig.Banner),
+
+			BodyCapture:    configBodyCapture(proxyConfig.BodyCapture),
+			PersistLogs:    configPersistLogs(proxyConfig.PersistLogs),
+			TrustedProxies: proxyConfig.TrustedProxies,
 		}
 
 		server, err := d.proxym.Create(d.ctx, proxyServerConfig)
@@ -157,6 +177,17 @@ func (d *Daemon) handleExplicitStart(event ProxyEvent) {
 		MaxLogSize:  event.Config.MaxLogSize,
 		AutoRestart: true,
 		Path:        event.Path,
+		NoRetarget:  event.Config.NoRetarget,
+		Routes:      configRoutes(event.Config.Routes),
+		PathRoutes:  configPathRoutes(event.Config.PathRoutes),
+		Cookies:     configCookies(event.Config.Cookies),
+		URLRewrite:  configURLRewrite(event.Config.URLRewrite),
+		Storms:      configStorms(event.Config.Storms),
+		Banner:      configBanner(event.Config.Banner),
+
+		BodyCapture:    configBodyCapture(event.Config.BodyCapture),
+		PersistLogs:    configPersistLogs(event.Config.PersistLogs),
+		TrustedProxies: event.Config.TrustedProxies,
 	}
 
 	server, err := d.proxym.Create(d.ctx, proxyServerConfig)
@@ -241,6 +272,16 @@ func (d *Daemon) clearScriptProxies(scriptID string) {
 	log.Printf("[DEBUG] Cleared proxy tracking for script %s", scriptID)
 }
 
+// findProxyWithPrefix returns the first proxy whose ID starts with prefix.
+func findProxyWithPrefix(proxies []*proxy.ProxyServer, prefix string) *proxy.ProxyServer {
+	for _, px := range proxies {
+		if strings.HasPrefix(px.ID, prefix) {
+			return px
+		}
+	}
+	return nil
+}
+
 // makeProxyIDFromURL creates a unique proxy ID from project path, proxy name, and URL.
 // Format: {projectPath}:{proxyName}:{host}:{port}
 func makeProxyIDFromURL(projectPath, proxyName, urlStr string) string {
@@ -268,3 +309,109 @@ func makeProxyIDFromURL(projectPath, proxyName, urlStr string) string {
 
 	return fmt.Sprintf("%s:%s-%s", makeProcessID(projectPath, proxyName), cleanHost, port)
 }
+
+// configRoutes converts the routes map of a .agnt.kdl proxy, ordered by host
+// pattern for a stable match order.
+func configRoutes(routes map[string]string) []proxy.HostRoute {
+	if len(routes) == 0 {
+		return nil
+	}
+	hosts := make([]string, 0, len(routes))
+	for host := range routes {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	out := make([]proxy.HostRoute, len(hosts))
+	for i, host := range hosts {
+		out[i] = proxy.HostRoute{Host: host, Target: routes[host]}
+	}
+	return out
+}
+
+// configPathRoutes converts the path-routes map of a .agnt.kdl proxy. Match
+// order follows prefix length, so map order doesn't matter.
+func configPathRoutes(routes map[string]string) []proxy.PathRoute {
+	out := make([]proxy.PathRoute, 0, len(routes))
+	for path, target := range routes {
+		out = append(out, proxy.PathRoute{Path: path, Target: target})
+	}
+	return out
+}
+
+// configCookies converts the cookies block of a .agnt.kdl proxy.
+func configCookies(c *config.ProxyCookieConfig) proxy.CookieRewrite {
+	if c == nil {
+		return proxy.CookieRewrite{}
+	}
+	return proxy.CookieRewrite{
+		Disabled: c.Disabled,
+		Domains:  c.Domains,
+		Secure:   c.Secure,
+		SameSite: c.SameSite,
+	}
+}
+
+// configURLRewrite converts the url-rewrite block of a .agnt.kdl proxy.
+func configURLRewrite(c *config.ProxyURLRewriteConfig) proxy.URLRewrite {
+	if c == nil {
+		return proxy.URLRewrite{}
+	}
+	return proxy.URLRewrite{NoBody: c.NoBody, Exclude: c.Exclude}
+}
+
+// configStorms converts the storms block of a .agnt.kdl proxy.
+func configStorms(c *config.ProxyStormConfig) proxy.StormDetection {
+	if c == nil {
+		return proxy.StormDetection{}
+	}
+	return proxy.StormDetection{
+		Disabled:  c.Disabled,
+		Threshold: c.Threshold,
+		WindowMs:  c.WindowMs,
+		Toast:     c.Toast,
+	}
+}
+
+// configBanner converts the banner block of a .agnt.kdl proxy. The block
+// turns the banner on unless it says disabled.
+func configBanner(c *config.ProxyBannerConfig) proxy.EnvironmentBanner {
+	if c == nil {
+		return proxy.EnvironmentBanner{}
+	}
+	return proxy.EnvironmentBanner{
+		Enabled:  !c.Disabled,
+		Label:    c.Label,
+		Position: c.Position,
+		Color:    c.Color,
+		NoBranch: c.NoBranch,
+	}
+}
+
+// configBodyCapture converts the body-capture block of a .agnt.kdl proxy.
+func configBodyCapture(c *config.ProxyBodyCaptureConfig) proxy.BodyCapture {
+	if c == nil {
+		return proxy.BodyCapture{}
+	}
+	return proxy.BodyCapture{
+		Disabled:         c.Disabled,
+		MaxRequestBytes:  c.MaxRequestBytes,
+		MaxResponseBytes: c.MaxResponseBytes,
+		ContentTypes:     c.ContentTypes,
+		RedactHeaders:    c.RedactHeaders,
+		NoRedact:         c.NoRedact,
+	}
+}
+
+// configPersistLogs converts the persist-logs block of a .agnt.kdl proxy.
+func configPersistLogs(c *config.ProxyPersistLogsConfig) proxy.LogPersistence {
+	if c == nil || c.Disabled {
+		return proxy.LogPersistence{}
+	}
+	return proxy.LogPersistence{
+		Enabled:      true,
+		Dir:          c.Dir,
+		SegmentBytes: int64(c.SegmentBytes),
+		MaxSegments:  c.MaxSegments,
+		MaxAgeHours:  c.MaxAgeHours,
+	}
+}
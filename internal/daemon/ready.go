@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// readyCheck is how a started script shows it is ready: its URL answers,
+// its port accepts connections, or its output matches. Without any, it is
+// ready once it has kept running for stackStartGrace.
+type readyCheck struct {
+	URL  string
+	Port int
+	Log  *regexp.Regexp
+}
+
+// none reports whether c checks nothing but the start grace.
+func (c readyCheck) none() bool {
+	return c.URL == "" && c.Port == 0 && c.Log == nil
+}
+
+// newReadyCheck builds a ready check from ready-url, ready-port and
+// ready-log settings.
+func newReadyCheck(url string, port int, logPattern string) (readyCheck, error) {
+	c := readyCheck{URL: url, Port: port}
+	if logPattern != "" {
+		re, err := regexp.Compile(logPattern)
+		if err != nil {
+			return readyCheck{}, fmt.Errorf("invalid ready-log: %v", err)
+		}
+		c.Log = re
+	}
+	return c, nil
+}
+
+// scriptReadyCheck returns the ready check of a script: its ready-*
+// settings, else its first declared port.
+func scriptReadyCheck(script *config.ScriptConfig) (readyCheck, error) {
+	c, err := newReadyCheck(script.ReadyURL, script.ReadyPort, script.ReadyLog)
+	if err != nil {
+		return c, err
+	}
+	if c.none() && len(script.Ports) > 0 {
+		c.Port = script.Ports[0]
+	}
+	return c, nil
+}
+
+// scriptReadyTimeout returns how long a script may take to become ready.
+func scriptReadyTimeout(script *config.ScriptConfig) time.Duration {
+	if script.ReadyTimeoutMs > 0 {
+		return time.Duration(script.ReadyTimeoutMs) * time.Millisecond
+	}
+	return defaultStackTimeout
+}
+
+// waitReady waits until the process passes check. It fails when the process
+// exits first or isn't ready within timeout.
+func (d *Daemon) waitReady(ctx context.Context, processID, projectPath string, check readyCheck, timeout time.Duration) error {
+	readyURL := check.URL
+	if readyURL != "" {
+		if mapped, err := d.remoteProxyTarget(projectPath, readyURL); err == nil {
+			readyURL = mapped
+		}
+	}
+
+	began := time.Now()
+	deadline := began.Add(timeout)
+	ticker := time.NewTicker(stackReadyPoll)
+	defer ticker.Stop()
+	for {
+		proc, err := d.hub.ProcessManager().Get(processID)
+		if err != nil {
+			return fmt.Errorf("process %s is gone", processID)
+		}
+		if proc.IsDone() {
+			return fmt.Errorf("exited with code %d before it was ready", proc.ExitCode())
+		}
+
+		switch {
+		case readyURL != "" || check.Port > 0:
+			hc := &protocol.HealthCheck{URL: readyURL, Port: check.Port, TimeoutMs: 1000}
+			if probeHealth(ctx, hc) == nil {
+				return nil
+			}
+		case check.Log != nil:
+			if out, _ := proc.CombinedOutput(); check.Log.Match(out) {
+				return nil
+			}
+		default:
+			if time.Since(began) >= stackStartGrace {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not ready after %s", formatDuration(timeout))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
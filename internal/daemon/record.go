@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/standardbeagle/agnt/internal/fixture"
+)
+
+// recordUpstreamSuffix is appended to the socket path for the hub's private
+// socket while recording.
+const recordUpstreamSuffix = ".live"
+
+// hubSocketPath returns the socket the hub listens on. When recording, the
+// public socket belongs to the recording relay.
+func hubSocketPath(config DaemonConfig) string {
+	if config.RecordPath == "" {
+		return config.SocketPath
+	}
+	path := config.SocketPath
+	if path == "" {
+		path = DefaultSocketPath()
+	}
+	return path + recordUpstreamSuffix
+}
+
+// startRecorder listens on the public socket and relays to the hub, writing
+// each exchange to config.RecordPath. Each start begins a new fixture file.
+func (d *Daemon) startRecorder() error {
+	f, err := os.OpenFile(d.config.RecordPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open fixture file: %w", err)
+	}
+	w, err := fixture.NewWriter(f, Version, fixture.NewSanitizer())
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write fixture header: %w", err)
+	}
+
+	path := d.config.SocketPath
+	if path == "" {
+		path = DefaultSocketPath()
+	}
+	sm := NewSocketManager(SocketConfig{Path: path, Mode: 0600})
+	l, err := sm.Listen()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to listen for recording: %w", err)
+	}
+
+	d.recorder = fixture.NewRecorder(hubSocketPath(d.config), w)
+	d.recordFile = f
+	d.recordSocket = sm
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.recorder.Serve(l); err != nil {
+			log.Printf("[WARN] Fixture recorder stopped: %v", err)
+		}
+	}()
+	log.Printf("[INFO] Recording protocol fixtures to %s", d.config.RecordPath)
+	return nil
+}
+
+// stopRecorder closes the relay and the fixture file.
+func (d *Daemon) stopRecorder() {
+	if d.recorder == nil {
+		return
+	}
+	if err := d.recorder.Close(); err != nil {
+		log.Printf("[Daemon] error closing fixture recorder: %v", err)
+	}
+	d.recordSocket.Close()
+	d.recordFile.Close()
+}
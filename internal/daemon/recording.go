@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// hubHandleRecord handles the RECORD command.
+func (d *Daemon) hubHandleRecord(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "RECORD %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbStart:
+		return d.hubHandleRecordStart(conn, cmd)
+	case protocol.SubVerbStop:
+		return d.hubHandleRecordStop(ctx, conn, cmd)
+	case protocol.SubVerbStatus:
+		return d.hubHandleRecordStatus(conn, cmd)
+	case protocol.SubVerbReplay:
+		return d.hubHandleRecordReplay(ctx, conn, cmd)
+	case protocol.SubVerbList:
+		return d.hubHandleRecordList(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "record", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown RECORD sub-command",
+			Command:      protocol.VerbRecord,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbStart, protocol.SubVerbStop, protocol.SubVerbStatus, protocol.SubVerbReplay, protocol.SubVerbList},
+		})
+	}
+}
+
+// hubHandleRecordStart handles RECORD START <proxy_id> [session_id].
+func (d *Daemon) hubHandleRecordStart(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "RECORD START requires: <proxy_id> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	sessionID := ""
+	if len(cmd.Args) > 1 {
+		sessionID = cmd.Args[1]
+	}
+
+	var opts proxy.RecordOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+
+	status, err := p.StartRecording(sessionID, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"proxy_id":  proxyID,
+		"recording": status,
+	})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleRecordStop handles RECORD STOP <proxy_id>.
+func (d *Daemon) hubHandleRecordStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "RECORD STOP requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	rec, err := p.StopRecording(ctx)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	data, _ := json.Marshal(rec)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleRecordStatus handles RECORD STATUS <proxy_id>.
+func (d *Daemon) hubHandleRecordStatus(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "RECORD STATUS requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	resp := map[string]interface{}{
+		"proxy_id":  proxyID,
+		"recording": false,
+	}
+	if status, ok := p.RecordingStatus(); ok {
+		resp["recording"] = true
+		resp["status"] = status
+	}
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleRecordReplay handles RECORD REPLAY <proxy_id> <name> [session_id].
+func (d *Daemon) hubHandleRecordReplay(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 2 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "RECORD REPLAY requires: <proxy_id> <name> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	name := cmd.Args[1]
+	sessionID := ""
+	if len(cmd.Args) > 2 {
+		sessionID = cmd.Args[2]
+	}
+
+	var opts proxy.RecordingReplayOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+	if status, ok := p.RecordingStatus(); ok {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "proxy is recording "+status.Name+"; stop it before replaying")
+	}
+
+	rec, err := p.LoadRecording(name)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+
+	result := p.ReplayRecording(ctx, sessionID, rec, opts)
+	data, _ := json.Marshal(result)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleRecordList handles RECORD LIST <proxy_id>.
+func (d *Daemon) hubHandleRecordList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "RECORD LIST requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	files, err := p.Recordings()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"proxy_id":   proxyID,
+		"recordings": files,
+		"count":      len(files),
+	})
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,317 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/remote"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// remoteProcess is a managed ssh process running a script remotely.
+type remoteProcess struct {
+	ProjectPath string
+	Target      remote.Target
+}
+
+// remoteState tracks processes running on remote checkouts and the
+// forwards of their ports.
+type remoteState struct {
+	mu       sync.Mutex
+	procs    map[string]remoteProcess // processID -> remote
+	forwards *remote.Forwards
+}
+
+func (r *remoteState) track(processID, projectPath string, target remote.Target) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.procs == nil {
+		r.procs = make(map[string]remoteProcess)
+	}
+	r.procs[processID] = remoteProcess{ProjectPath: projectPath, Target: target}
+}
+
+func (r *remoteState) lookup(processID string) (remoteProcess, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rp, ok := r.procs[processID]
+	return rp, ok
+}
+
+// forgetProject drops a project's remote processes and closes the forwards
+// to hosts no other project still runs on.
+func (r *remoteState) forgetProject(projectPath string) {
+	r.mu.Lock()
+	hosts := make(map[string]bool)
+	for id, rp := range r.procs {
+		if rp.ProjectPath == projectPath {
+			hosts[rp.Target.Host] = true
+			delete(r.procs, id)
+		}
+	}
+	for _, rp := range r.procs {
+		delete(hosts, rp.Target.Host)
+	}
+	r.mu.Unlock()
+	for host := range hosts {
+		r.forwards.Close(host)
+	}
+}
+
+// remoteTarget returns the remote checkout configured in a project's
+// .agnt.kdl, or nil when the project runs locally.
+func remoteTarget(projectPath string) (*remote.Target, error) {
+	cfg, err := config.LoadAgntConfig(projectPath)
+	if err != nil || cfg.Remote == nil {
+		return nil, err
+	}
+	target := &remote.Target{
+		Host:     cfg.Remote.Host,
+		Path:     cfg.Remote.Path,
+		Port:     cfg.Remote.Port,
+		Identity: cfg.Remote.Identity,
+		Options:  cfg.Remote.Options,
+	}
+	if err := target.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid remote block in %s: %w", config.AgntConfigFileName, err)
+	}
+	return target, nil
+}
+
+// remoteURL rewrites a loopback URL on target's host to a forward on this
+// machine. Other URLs are returned unchanged.
+func (d *Daemon) remoteURL(target remote.Target, raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw, nil
+	}
+	port := loopbackPort(u.Host)
+	if host, portStr, err := net.SplitHostPort(u.Host); err == nil && (host == "0.0.0.0" || host == "::") {
+		port, _ = strconv.Atoi(portStr) // Listening on all remote interfaces
+	}
+	if port <= 0 {
+		return raw, nil
+	}
+	localPort, err := d.remotes.forwards.Ensure(target, port)
+	if err != nil {
+		return raw, err
+	}
+	u.Host = net.JoinHostPort("localhost", strconv.Itoa(localPort))
+	return u.String(), nil
+}
+
+// mapProcessURL is the URL tracker's mapping of URLs printed by remote
+// processes to their forwards.
+func (d *Daemon) mapProcessURL(processID, raw string) string {
+	rp, ok := d.remotes.lookup(processID)
+	if !ok {
+		return raw
+	}
+	mapped, err := d.remoteURL(rp.Target, raw)
+	if err != nil {
+		return raw
+	}
+	return mapped
+}
+
+// remoteProxyTarget maps a proxy target on a remote project's loopback to
+// its forward.
+func (d *Daemon) remoteProxyTarget(projectPath, targetURL string) (string, error) {
+	target, err := remoteTarget(projectPath)
+	if err != nil || target == nil {
+		return targetURL, err
+	}
+	return d.remoteURL(*target, targetURL)
+}
+
+// hubHandleRemote handles the REMOTE command.
+func (d *Daemon) hubHandleRemote(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbRun:
+		return d.hubHandleRemoteRun(ctx, conn, cmd)
+	case protocol.SubVerbStatus:
+		return d.hubHandleRemoteStatus(conn, cmd)
+	case protocol.SubVerbForward:
+		return d.hubHandleRemoteForward(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown REMOTE sub-command",
+			Command:      protocol.VerbRemote,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbRun, protocol.SubVerbStatus, protocol.SubVerbForward},
+		})
+	}
+}
+
+// remoteProjectPath returns the session's project, or the path of a
+// request made without a session.
+func (d *Daemon) remoteProjectPath(conn *hubpkg.Connection, path string) string {
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && path != "" {
+		projectPath = normalizePath(path)
+	}
+	return projectPath
+}
+
+// hubHandleRemoteRun handles REMOTE RUN with a protocol.RemoteRunConfig
+// payload: the command runs over ssh in the remote checkout as a managed
+// process, and URLs it prints are mapped to forwards. Foreground mode
+// blocks until it exits.
+func (d *Daemon) hubHandleRemoteRun(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var req protocol.RemoteRunConfig
+	if err := decodeData(cmd, &req); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = "background"
+	case "background", "foreground":
+	default:
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("unknown mode %q (use background or foreground)", req.Mode))
+	}
+
+	projectPath := d.remoteProjectPath(conn, req.Path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "REMOTE RUN requires a session or path")
+	}
+	target, err := remoteTarget(projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if target == nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("no remote block in %s for %s", config.AgntConfigFileName, projectPath))
+	}
+
+	// Scripts resolve against the local checkout, which mirrors the remote
+	command, err := resolveScriptCommand(projectPath, req.Script, req.Run, req.Command, req.Args)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	name := req.ID
+	if name == "" {
+		name = req.Script
+	}
+	if name == "" {
+		name = filepath.Base(command.Command)
+		if command.Command == "sh" {
+			name = "remote"
+		}
+	}
+	processID := makeProcessID(projectPath, name)
+
+	sshCommand, sshArgs := target.Command(command.Cwd, command.Env, command.Command, command.Args)
+	d.remotes.track(processID, projectPath, *target)
+	proc, err := d.startFreshProcess(ctx, process.ProcessConfig{
+		ID:          processID,
+		ProjectPath: projectPath,
+		Command:     sshCommand,
+		Args:        sshArgs,
+	})
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start remote run: %v", err))
+	}
+
+	resp := map[string]interface{}{
+		"process_id":  processID,
+		"pid":         proc.PID(),
+		"command":     strings.TrimSpace(command.Command + " " + strings.Join(command.Args, " ")),
+		"remote_host": target.Host,
+		"remote_path": target.Path,
+		"mode":        req.Mode,
+	}
+	if req.Mode == "foreground" {
+		select {
+		case <-proc.Done():
+		case <-ctx.Done():
+			return conn.WriteErr(hubproto.ErrTimeout, "REMOTE RUN cancelled before the command finished")
+		}
+		out, _ := proc.CombinedOutput()
+		resp["exit_code"] = proc.ExitCode()
+		resp["runtime"] = formatDuration(proc.Runtime())
+		resp["stdout"] = string(out)
+	}
+	resp["state"] = proc.State().String()
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleRemoteStatus handles REMOTE STATUS: the project's remote, its
+// remote processes and the forwards.
+func (d *Daemon) hubHandleRemoteStatus(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	resp := map[string]interface{}{"project_path": projectPath}
+	if projectPath != "" {
+		target, err := remoteTarget(projectPath)
+		if err != nil {
+			resp["error"] = err.Error()
+		} else if target != nil {
+			resp["remote"] = target
+		}
+	}
+
+	var procs []map[string]interface{}
+	d.remotes.mu.Lock()
+	for id, rp := range d.remotes.procs {
+		if projectPath == "" || rp.ProjectPath == projectPath {
+			procs = append(procs, map[string]interface{}{"process_id": id, "host": rp.Target.Host})
+		}
+	}
+	d.remotes.mu.Unlock()
+	resp["processes"] = procs
+	resp["forwards"] = d.remotes.forwards.List()
+
+	data, _ := json.Marshal(resp)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleRemoteForward handles REMOTE FORWARD <port>: forward a port on
+// the remote host's loopback to this machine.
+func (d *Daemon) hubHandleRemoteForward(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "port required")
+	}
+	port, err := strconv.Atoi(cmd.Args[0])
+	if err != nil || port <= 0 || port > 65535 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid port %q", cmd.Args[0]))
+	}
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	target, err := remoteTarget(projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if target == nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("no remote block in %s for %s", config.AgntConfigFileName, projectPath))
+	}
+
+	localPort, err := d.remotes.forwards.Ensure(*target, port)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"host":        target.Host,
+		"remote_port": port,
+		"local_port":  localPort,
+		"url":         fmt.Sprintf("http://localhost:%d", localPort),
+	})
+	return conn.WriteJSON(data)
+}
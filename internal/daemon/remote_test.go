@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoteTarget(t *testing.T) {
+	dir := t.TempDir()
+	target, err := remoteTarget(dir)
+	if err != nil || target != nil {
+		t.Fatalf("Expected no remote without a config, got %+v, %v", target, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".agnt.kdl"), []byte(`remote {
+    host "devbox"
+    path "/srv/app"
+}
+`), 0644)
+	target, err = remoteTarget(dir)
+	if err != nil || target == nil {
+		t.Fatalf("Expected the configured remote, got %v", err)
+	}
+	if target.Host != "devbox" || target.Path != "/srv/app" {
+		t.Errorf("Unexpected remote %+v", target)
+	}
+
+	// Only loopback URLs need a forward
+	d := &Daemon{}
+	for _, raw := range []string{"https://example.com/app", "http://10.0.0.5:3000/"} {
+		if got, err := d.remoteURL(*target, raw); err != nil || got != raw {
+			t.Errorf("Expected %s unchanged, got %s (%v)", raw, got, err)
+		}
+	}
+	if got := d.mapProcessURL("not-remote", "http://localhost:3000"); got != "http://localhost:3000" {
+		t.Errorf("Expected a local process's URL unchanged, got %s", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".agnt.kdl"), []byte(`remote {
+    path "/srv/app"
+}
+`), 0644)
+	if _, err := remoteTarget(dir); err == nil {
+		t.Error("Expected an error for a remote without a host")
+	}
+}
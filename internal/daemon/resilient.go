@@ -8,6 +8,9 @@ import (
 
 	"github.com/standardbeagle/agnt/internal/debug"
 	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/ptyhost"
+	"github.com/standardbeagle/agnt/internal/workspace"
 )
 
 var (
@@ -239,442 +242,1790 @@ func (rc *ResilientClient) ProxyStop(id string) error {
 	})
 }
 
+// ProxyStopCascade stops a proxy and, with cascade, the tunnels fronting it.
+func (rc *ResilientClient) ProxyStopCascade(id string, cascade bool) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.ProxyStopCascade(id, cascade)
+	})
+}
+
 // ProxyList lists all proxies.
 func (rc *ResilientClient) ProxyList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyList(dirFilter)
+		result, e = c.ProxyList(dirFilter)
+		return e
+	})
+	return result, err
+}
+
+// ProxyListFiltered lists proxies, keeping only those with every label of the filter.
+func (rc *ResilientClient) ProxyListFiltered(filter protocol.ListFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyListFiltered(filter)
+		return e
+	})
+	return result, err
+}
+
+// ProxyLabel sets and removes labels of a proxy and returns its labels.
+func (rc *ResilientClient) ProxyLabel(id string, set protocol.Labels, remove []string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyLabel(id, set, remove)
+		return e
+	})
+	return result, err
+}
+
+// ProxyRoutesAdd adds a path route to a proxy, replacing the route for the
+// same path, and returns the proxy's routes.
+func (rc *ResilientClient) ProxyRoutesAdd(id string, route proxy.PathRoute) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyRoutesAdd(id, route)
+		return e
+	})
+	return result, err
+}
+
+// ProxyRoutesRemove removes the path route for path from a proxy.
+func (rc *ResilientClient) ProxyRoutesRemove(id, path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyRoutesRemove(id, path)
+		return e
+	})
+	return result, err
+}
+
+// ProxyRoutesList lists the path routes of a proxy.
+func (rc *ResilientClient) ProxyRoutesList(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyRoutesList(id)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptAdd adds an intercept rule to a proxy.
+func (rc *ResilientClient) ProxyInterceptAdd(id string, rule proxy.InterceptRule) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptAdd(id, rule)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptRemove removes an intercept rule from a proxy.
+func (rc *ResilientClient) ProxyInterceptRemove(id, ruleID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptRemove(id, ruleID)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptList lists the intercept rules and paused requests of a proxy.
+func (rc *ResilientClient) ProxyInterceptList(id string, wait time.Duration) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptList(id, wait)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptModify edits a paused request.
+func (rc *ResilientClient) ProxyInterceptModify(id, requestID string, edit proxy.InterceptEdit) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptModify(id, requestID, edit)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptResume lets a paused request continue.
+func (rc *ResilientClient) ProxyInterceptResume(id, requestID string, edit *proxy.InterceptEdit) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptResume(id, requestID, edit)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptReject answers a paused request without calling the target.
+func (rc *ResilientClient) ProxyInterceptReject(id, requestID string, status int, body string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptReject(id, requestID, status, body)
+		return e
+	})
+	return result, err
+}
+
+// ProxyInterceptClear removes all intercept rules of a proxy.
+func (rc *ResilientClient) ProxyInterceptClear(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyInterceptClear(id)
+		return e
+	})
+	return result, err
+}
+
+// ProxyUI returns the overlay options of a proxy.
+func (rc *ResilientClient) ProxyUI(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyUI(id)
+		return e
+	})
+	return result, err
+}
+
+// ProxySetUI replaces the overlay options of a proxy.
+func (rc *ResilientClient) ProxySetUI(id string, ui proxy.OverlayUI) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxySetUI(id, ui)
+		return e
+	})
+	return result, err
+}
+
+// Detect detects the project type at the given path.
+func (rc *ResilientClient) Detect(path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.Detect(path)
+		return e
+	})
+	return result, err
+}
+
+// Run starts a process on the daemon.
+func (rc *ResilientClient) Run(config protocol.RunConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.Run(config)
+		return e
+	})
+	return result, err
+}
+
+// ProcStatus gets the status of a process.
+func (rc *ResilientClient) ProcStatus(processID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcStatus(processID)
+		return e
+	})
+	return result, err
+}
+
+// ProcOutput gets the output of a process.
+func (rc *ResilientClient) ProcOutput(processID string, filter protocol.OutputFilter) (string, error) {
+	var output string
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		output, e = c.ProcOutput(processID, filter)
+		return e
+	})
+	return output, err
+}
+
+// ProcOutputFollow gets the output of a process and the lines it writes
+// until it exits or the timeout passes.
+func (rc *ResilientClient) ProcOutputFollow(processID string, filter protocol.OutputFilter, timeout time.Duration) (string, error) {
+	var output string
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		output, e = c.ProcOutputFollow(processID, filter, timeout)
+		return e
+	})
+	return output, err
+}
+
+// ProcStop stops a process.
+func (rc *ResilientClient) ProcStop(processID string, force bool) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcStop(processID, force)
+		return e
+	})
+	return result, err
+}
+
+// ProcStopCascade stops a process and, with cascade, its dependents.
+func (rc *ResilientClient) ProcStopCascade(processID string, force, cascade bool) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcStopCascade(processID, force, cascade)
+		return e
+	})
+	return result, err
+}
+
+// ProcList lists all processes.
+func (rc *ResilientClient) ProcList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcList(dirFilter)
+		return e
+	})
+	return result, err
+}
+
+// ProcListFiltered lists processes, keeping only those with every label of the filter.
+func (rc *ResilientClient) ProcListFiltered(filter protocol.ListFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcListFiltered(filter)
+		return e
+	})
+	return result, err
+}
+
+// ProcLabel sets and removes labels of a process and returns its labels.
+func (rc *ResilientClient) ProcLabel(processID string, set protocol.Labels, remove []string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcLabel(processID, set, remove)
+		return e
+	})
+	return result, err
+}
+
+// ProcCleanupPort kills processes on a specific port.
+func (rc *ResilientClient) ProcCleanupPort(port int) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcCleanupPort(port)
+		return e
+	})
+	return result, err
+}
+
+// ProcCleanupPortDryRun lists the processes ProcCleanupPort would kill.
+func (rc *ResilientClient) ProcCleanupPortDryRun(port int) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcCleanupPortDryRun(port)
+		return e
+	})
+	return result, err
+}
+
+// ProcCrash returns or lists crash reports.
+func (rc *ResilientClient) ProcCrash(ref, path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcCrash(ref, path)
+		return e
+	})
+	return result, err
+}
+
+// ProxyStartWithConfig starts a reverse proxy with extended configuration.
+func (rc *ResilientClient) ProxyStartWithConfig(id, targetURL string, port, maxLogSize int, config ProxyStartConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyStartWithConfig(id, targetURL, port, maxLogSize, config)
+		return e
+	})
+	return result, err
+}
+
+// ProxyStatus gets the status of a proxy.
+func (rc *ResilientClient) ProxyStatus(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyStatus(id)
+		return e
+	})
+	return result, err
+}
+
+// ProxyExec executes JavaScript in connected browsers.
+func (rc *ResilientClient) ProxyExec(id, code string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyExec(id, code)
+		return e
+	})
+	return result, err
+}
+
+// ProxyToast sends a toast notification to connected browsers.
+func (rc *ResilientClient) ProxyToast(id string, toast protocol.ToastConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyToast(id, toast)
+		return e
+	})
+	return result, err
+}
+
+// ProxyLogQuery queries proxy logs.
+func (rc *ResilientClient) ProxyLogQuery(proxyID string, filter protocol.LogQueryFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyLogQuery(proxyID, filter)
+		return e
+	})
+	return result, err
+}
+
+// ProxyLogClear clears proxy logs.
+func (rc *ResilientClient) ProxyLogClear(proxyID string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.ProxyLogClear(proxyID)
+	})
+}
+
+// ProxyLogStats gets proxy log statistics.
+func (rc *ResilientClient) ProxyLogStats(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyLogStats(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ProxyLogRouteStats gets proxy log statistics with the per-route report
+// selected and ordered by filter.
+func (rc *ResilientClient) ProxyLogRouteStats(proxyID string, filter proxy.RouteStatsFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyLogRouteStats(proxyID, filter)
+		return e
+	})
+	return result, err
+}
+
+// ProxyLogReplay re-issues a logged request, with optional overrides.
+func (rc *ResilientClient) ProxyLogReplay(proxyID, entryID string, overrides *proxy.ReplayOverrides) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyLogReplay(proxyID, entryID, overrides)
+		return e
+	})
+	return result, err
+}
+
+// ProxyLogAggregate gets a proxy's response bytes by content type.
+func (rc *ResilientClient) ProxyLogAggregate(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProxyLogAggregate(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageList lists active page sessions.
+func (rc *ResilientClient) CurrentPageList(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageList(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageGet gets details for a specific page session.
+func (rc *ResilientClient) CurrentPageGet(proxyID, sessionID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageGet(proxyID, sessionID)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageClear clears page sessions.
+func (rc *ResilientClient) CurrentPageClear(proxyID string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.CurrentPageClear(proxyID)
+	})
+}
+
+// CurrentPageWaitForIdle waits until a page session's network and DOM activity
+// settle.
+func (rc *ResilientClient) CurrentPageWaitForIdle(proxyID, sessionID string, opts proxy.IdleOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageWaitForIdle(proxyID, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageSnapshot captures a serialized DOM of a page session.
+func (rc *ResilientClient) CurrentPageSnapshot(proxyID, sessionID string, opts proxy.DOMSnapshotOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageSnapshot(proxyID, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageSnapshots lists the DOM snapshots kept for a proxy.
+func (rc *ResilientClient) CurrentPageSnapshots(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageSnapshots(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageDiff compares two DOM snapshots of a proxy.
+func (rc *ResilientClient) CurrentPageDiff(proxyID, from, to string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageDiff(proxyID, from, to)
+		return e
+	})
+	return result, err
+}
+
+// CurrentPageCoverage reports the unused CSS of a page session.
+func (rc *ResilientClient) CurrentPageCoverage(proxyID, sessionID string, opts proxy.CSSCoverageOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CurrentPageCoverage(proxyID, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// ScreenshotCapture captures and stores a screenshot of a page session.
+func (rc *ResilientClient) ScreenshotCapture(proxyID, sessionID string, opts proxy.ScreenshotOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ScreenshotCapture(proxyID, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// ScreenshotList lists the stored screenshots of a proxy.
+func (rc *ResilientClient) ScreenshotList(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ScreenshotList(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// RecordStart starts recording a page session of a proxy.
+func (rc *ResilientClient) RecordStart(proxyID, sessionID string, opts proxy.RecordOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RecordStart(proxyID, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// RecordStop stops a proxy's recording and stores it.
+func (rc *ResilientClient) RecordStop(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RecordStop(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// RecordStatus returns a proxy's recording in progress.
+func (rc *ResilientClient) RecordStatus(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RecordStatus(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// RecordReplay replays a stored recording in a page session.
+func (rc *ResilientClient) RecordReplay(proxyID, name, sessionID string, opts proxy.RecordingReplayOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RecordReplay(proxyID, name, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// RecordList lists the stored recordings of a proxy's project.
+func (rc *ResilientClient) RecordList(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RecordList(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// A11yAudit runs an accessibility audit in a page session.
+func (rc *ResilientClient) A11yAudit(proxyID, sessionID string, opts proxy.A11yAuditOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.A11yAudit(proxyID, sessionID, opts)
+		return e
+	})
+	return result, err
+}
+
+// Chaos methods
+
+// ChaosEnable enables chaos injection on a proxy.
+func (rc *ResilientClient) ChaosEnable(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosEnable(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosDisable disables chaos injection on a proxy.
+func (rc *ResilientClient) ChaosDisable(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosDisable(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosStatus gets the chaos status of a proxy.
+func (rc *ResilientClient) ChaosStatus(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosStatus(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosPreset applies a preset chaos configuration to a proxy.
+func (rc *ResilientClient) ChaosPreset(proxyID, preset string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosPreset(proxyID, preset)
+		return e
+	})
+	return result, err
+}
+
+// ChaosPresetDryRun previews applying a chaos preset.
+func (rc *ResilientClient) ChaosPresetDryRun(proxyID, preset string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosPresetDryRun(proxyID, preset)
+		return e
+	})
+	return result, err
+}
+
+// ChaosPresetForSessions applies a preset limited to the given sessions.
+func (rc *ResilientClient) ChaosPresetForSessions(proxyID, preset string, sessions []string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosPresetForSessions(proxyID, preset, sessions)
+		return e
+	})
+	return result, err
+}
+
+// ChaosPresetForSessionsDryRun previews applying a preset limited to sessions.
+func (rc *ResilientClient) ChaosPresetForSessionsDryRun(proxyID, preset string, sessions []string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosPresetForSessionsDryRun(proxyID, preset, sessions)
+		return e
+	})
+	return result, err
+}
+
+// ChaosSet sets the full chaos configuration on a proxy.
+func (rc *ResilientClient) ChaosSet(proxyID string, config protocol.ChaosConfigPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosSet(proxyID, config)
+		return e
+	})
+	return result, err
+}
+
+// ChaosSetDryRun previews replacing a proxy's chaos configuration.
+func (rc *ResilientClient) ChaosSetDryRun(proxyID string, config protocol.ChaosConfigPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosSetDryRun(proxyID, config)
+		return e
+	})
+	return result, err
+}
+
+// ChaosAddRule adds a single rule to a proxy's chaos engine.
+func (rc *ResilientClient) ChaosAddRule(proxyID string, rule protocol.ChaosRuleConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosAddRule(proxyID, rule)
+		return e
+	})
+	return result, err
+}
+
+// ChaosRemoveRule removes a rule from a proxy's chaos engine.
+func (rc *ResilientClient) ChaosRemoveRule(proxyID, ruleID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosRemoveRule(proxyID, ruleID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosListRules lists all chaos rules for a proxy.
+func (rc *ResilientClient) ChaosListRules(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosListRules(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosStats gets chaos statistics for a proxy.
+func (rc *ResilientClient) ChaosStats(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosStats(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosPreview evaluates chaos rules against a proxy's logged traffic.
+func (rc *ResilientClient) ChaosPreview(proxyID, preset string, config *protocol.ChaosConfigPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosPreview(proxyID, preset, config)
+		return e
+	})
+	return result, err
+}
+
+// ChaosClear clears all chaos rules and resets stats for a proxy.
+func (rc *ResilientClient) ChaosClear(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosClear(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosClearDryRun lists the rules ChaosClear would remove.
+func (rc *ResilientClient) ChaosClearDryRun(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosClearDryRun(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosListPresets returns the list of available chaos presets.
+func (rc *ResilientClient) ChaosListPresets() (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosListPresets()
+		return e
+	})
+	return result, err
+}
+
+// ChaosScenarioStart steps a proxy's chaos rules through a scenario's phases.
+func (rc *ResilientClient) ChaosScenarioStart(proxyID string, scenario protocol.ChaosScenarioPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosScenarioStart(proxyID, scenario)
+		return e
+	})
+	return result, err
+}
+
+// ChaosScenarioStop stops a proxy's running scenario.
+func (rc *ResilientClient) ChaosScenarioStop(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosScenarioStop(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// ChaosScenarioStatus reports a proxy's current or most recent scenario.
+func (rc *ResilientClient) ChaosScenarioStatus(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ChaosScenarioStatus(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// MockAdd adds a mock rule to a proxy, replacing the rule with the same ID.
+func (rc *ResilientClient) MockAdd(proxyID string, rule proxy.MockRule) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.MockAdd(proxyID, rule)
+		return e
+	})
+	return result, err
+}
+
+// MockRemove removes a mock rule from a proxy.
+func (rc *ResilientClient) MockRemove(proxyID, ruleID string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.MockRemove(proxyID, ruleID)
+	})
+}
+
+// MockList lists the mock rules of a proxy with their hit counts.
+func (rc *ResilientClient) MockList(proxyID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.MockList(proxyID)
+		return e
+	})
+	return result, err
+}
+
+// MockClear removes all mock rules from a proxy.
+func (rc *ResilientClient) MockClear(proxyID string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.MockClear(proxyID)
+	})
+}
+
+// Tunnel methods
+
+// TunnelStart starts a tunnel for a local port.
+func (rc *ResilientClient) TunnelStart(config protocol.TunnelStartConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.TunnelStart(config)
+		return e
+	})
+	return result, err
+}
+
+// TunnelStop stops a running tunnel.
+func (rc *ResilientClient) TunnelStop(id string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.TunnelStop(id)
+	})
+}
+
+// TunnelStatus gets the status of a tunnel.
+func (rc *ResilientClient) TunnelStatus(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.TunnelStatus(id)
+		return e
+	})
+	return result, err
+}
+
+// TunnelResume resumes a tunnel paused by its bandwidth cap.
+func (rc *ResilientClient) TunnelResume(id string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.TunnelResume(id)
+	})
+}
+
+// ExposeStart exposes a dev server publicly.
+func (rc *ResilientClient) ExposeStart(config protocol.ExposeStartConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ExposeStart(config)
+		return e
+	})
+	return result, err
+}
+
+// ExposeStop tears down an exposure.
+func (rc *ResilientClient) ExposeStop(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ExposeStop(id)
+		return e
+	})
+	return result, err
+}
+
+// ExposeList lists active exposures.
+func (rc *ResilientClient) ExposeList() (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ExposeList()
+		return e
+	})
+	return result, err
+}
+
+// TestRecord records test results parsed from a process's output.
+func (rc *ResilientClient) TestRecord(config protocol.TestRecordConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.TestRecord(config)
+		return e
+	})
+	return result, err
+}
+
+// TestHistory returns recent test runs.
+func (rc *ResilientClient) TestHistory(filter string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.TestHistory(filter)
+		return e
+	})
+	return result, err
+}
+
+// TestRun runs the test suite in parallel shards.
+func (rc *ResilientClient) TestRun(config protocol.TestRunConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.TestRun(config)
+		return e
+	})
+	return result, err
+}
+
+// BenchRun runs a benchmark suite.
+func (rc *ResilientClient) BenchRun(config protocol.BenchConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.BenchRun(config)
+		return e
+	})
+	return result, err
+}
+
+// BenchReport compares the latest benchmark run with its baseline.
+func (rc *ResilientClient) BenchReport(config protocol.BenchConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.BenchReport(config)
+		return e
+	})
+	return result, err
+}
+
+// BenchHistory returns benchmark series.
+func (rc *ResilientClient) BenchHistory(filter string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.BenchHistory(filter)
+		return e
+	})
+	return result, err
+}
+
+// GraphShow returns the dependency graph.
+func (rc *ResilientClient) GraphShow(global bool) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.GraphShow(global)
+		return e
+	})
+	return result, err
+}
+
+// GraphImpact lists the dependents of an entity.
+func (rc *ResilientClient) GraphImpact(kind, id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.GraphImpact(kind, id)
+		return e
+	})
+	return result, err
+}
+
+// Resolve maps a partial ID to its entity type and full ID.
+func (rc *ResilientClient) Resolve(partial, entityType string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.Resolve(partial, entityType)
+		return e
+	})
+	return result, err
+}
+
+// StatusLite returns the counts of a project.
+func (rc *ResilientClient) StatusLite(path string) (*StatusLite, error) {
+	var result *StatusLite
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.StatusLite(path)
+		return e
+	})
+	return result, err
+}
+
+// WaitError waits for the next matching error.
+func (rc *ResilientClient) WaitError(config protocol.WaitErrorConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.WaitError(config)
+		return e
+	})
+	return result, err
+}
+
+// EventsQuery returns the newest daemon events matching filter.
+func (rc *ResilientClient) EventsQuery(filter protocol.EventsFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.EventsQuery(filter)
+		return e
+	})
+	return result, err
+}
+
+// EventsSubscribe collects the daemon events matching filter as they happen.
+func (rc *ResilientClient) EventsSubscribe(filter protocol.EventsFilter) ([]DaemonEvent, error) {
+	var events []DaemonEvent
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		events, e = c.EventsSubscribe(filter)
+		return e
+	})
+	return events, err
+}
+
+// WorkspaceCreate creates a disposable copy of a project.
+func (rc *ResilientClient) WorkspaceCreate(opts workspace.CreateOptions) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.WorkspaceCreate(opts)
+		return e
+	})
+	return result, err
+}
+
+// WorkspaceList lists workspaces.
+func (rc *ResilientClient) WorkspaceList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.WorkspaceList(dirFilter)
+		return e
+	})
+	return result, err
+}
+
+// WorkspaceGet returns a workspace.
+func (rc *ResilientClient) WorkspaceGet(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.WorkspaceGet(id)
+		return e
+	})
+	return result, err
+}
+
+// WorkspaceRemove deletes a workspace.
+func (rc *ResilientClient) WorkspaceRemove(id string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.WorkspaceRemove(id)
+		return e
+	})
+	return result, err
+}
+
+// CompareRun runs a script against the working tree and a base ref.
+func (rc *ResilientClient) CompareRun(config protocol.CompareConfig, sequential bool) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.CompareRun(config, sequential)
+		return e
+	})
+	return result, err
+}
+
+// ProcSupervise sets or removes the supervision of a process.
+func (rc *ResilientClient) ProcSupervise(processID string, cfg *protocol.SuperviseConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcSupervise(processID, cfg)
+		return e
+	})
+	return result, err
+}
+
+// ProcMetrics returns a process's CPU and memory samples.
+func (rc *ResilientClient) ProcMetrics(processID string, limit int) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcMetrics(processID, limit)
+		return e
+	})
+	return result, err
+}
+
+// ProcResults returns the test results and build errors in a process's output.
+func (rc *ResilientClient) ProcResults(processID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.ProcResults(processID)
+		return e
+	})
+	return result, err
+}
+
+// RemoteRun runs a script or command on the project's remote checkout.
+func (rc *ResilientClient) RemoteRun(config protocol.RemoteRunConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RemoteRun(config)
+		return e
+	})
+	return result, err
+}
+
+// RemoteStatus returns the project's remote, its remote processes and the
+// port forwards.
+func (rc *ResilientClient) RemoteStatus(path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RemoteStatus(path)
+		return e
+	})
+	return result, err
+}
+
+// RemoteForward forwards a port on the project's remote host to this machine.
+func (rc *ResilientClient) RemoteForward(port int, path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.RemoteForward(port, path)
+		return e
+	})
+	return result, err
+}
+
+// GitStatus returns the branch and changed files of the project.
+func (rc *ResilientClient) GitStatus(req protocol.GitRequest) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.GitStatus(req)
+		return e
+	})
+	return result, err
+}
+
+// GitDiff returns the changed files of the project with their hunks.
+func (rc *ResilientClient) GitDiff(req protocol.GitRequest) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.GitDiff(req)
+		return e
+	})
+	return result, err
+}
+
+// GitLog returns the commits of the project and their authors.
+func (rc *ResilientClient) GitLog(req protocol.GitRequest) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.GitLog(req)
+		return e
+	})
+	return result, err
+}
+
+// GitChanged returns the files changed since a commit, committed or not.
+func (rc *ResilientClient) GitChanged(req protocol.GitRequest) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.GitChanged(req)
+		return e
+	})
+	return result, err
+}
+
+// K8sForward starts a kubectl port-forward as a managed process.
+func (rc *ResilientClient) K8sForward(config protocol.K8sForwardConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.K8sForward(config)
+		return e
+	})
+	return result, err
+}
+
+// K8sLogs follows the logs of the pods matching a selector as a managed
+// process's output.
+func (rc *ResilientClient) K8sLogs(config protocol.K8sLogsConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.K8sLogs(config)
+		return e
+	})
+	return result, err
+}
+
+// K8sStatus returns the project's port-forwards and log streams with their
+// pods and container restarts.
+func (rc *ResilientClient) K8sStatus(path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.K8sStatus(path)
+		return e
+	})
+	return result, err
+}
+
+// K8sStop stops a port-forward or log stream.
+func (rc *ResilientClient) K8sStop(id, path string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.K8sStop(id, path)
+	})
+}
+
+// StackStart starts a stack and waits until it is up or a service fails.
+func (rc *ResilientClient) StackStart(name, path string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.StackStart(name, path)
 		return e
 	})
 	return result, err
 }
 
-// Detect detects the project type at the given path.
-func (rc *ResilientClient) Detect(path string) (map[string]interface{}, error) {
+// StackStop stops a stack's services, dependents first.
+func (rc *ResilientClient) StackStop(name, path string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.Detect(path)
+		result, e = c.StackStop(name, path)
 		return e
 	})
 	return result, err
 }
 
-// Run starts a process on the daemon.
-func (rc *ResilientClient) Run(config protocol.RunConfig) (map[string]interface{}, error) {
+// StackStatus returns the state of a stack's services.
+func (rc *ResilientClient) StackStatus(name, path string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.Run(config)
+		result, e = c.StackStatus(name, path)
 		return e
 	})
 	return result, err
 }
 
-// ProcStatus gets the status of a process.
-func (rc *ResilientClient) ProcStatus(processID string) (map[string]interface{}, error) {
+// StackList returns the project's stacks.
+func (rc *ResilientClient) StackList(path string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProcStatus(processID)
+		result, e = c.StackList(path)
 		return e
 	})
 	return result, err
 }
 
-// ProcOutput gets the output of a process.
-func (rc *ResilientClient) ProcOutput(processID string, filter protocol.OutputFilter) (string, error) {
-	var output string
+// DaemonProfile profiles the daemon itself.
+func (rc *ResilientClient) DaemonProfile(kind string, config protocol.ProfileConfig) (map[string]interface{}, error) {
+	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		output, e = c.ProcOutput(processID, filter)
+		result, e = c.DaemonProfile(kind, config)
 		return e
 	})
-	return output, err
+	return result, err
 }
 
-// ProcStop stops a process.
-func (rc *ResilientClient) ProcStop(processID string, force bool) (map[string]interface{}, error) {
+// WatchAdd watches files matching a glob.
+func (rc *ResilientClient) WatchAdd(pattern, script, path string, config protocol.WatchConfig) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProcStop(processID, force)
+		result, e = c.WatchAdd(pattern, script, path, config)
 		return e
 	})
 	return result, err
 }
 
-// ProcList lists all processes.
-func (rc *ResilientClient) ProcList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+// WatchList returns the project's watches.
+func (rc *ResilientClient) WatchList(path string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProcList(dirFilter)
+		result, e = c.WatchList(path)
 		return e
 	})
 	return result, err
 }
 
-// ProcCleanupPort kills processes on a specific port.
-func (rc *ResilientClient) ProcCleanupPort(port int) (map[string]interface{}, error) {
+// WatchRemove stops a watch.
+func (rc *ResilientClient) WatchRemove(id, path string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.WatchRemove(id, path)
+	})
+}
+
+// DoubleStart starts an API double.
+func (rc *ResilientClient) DoubleStart(name, path string, config protocol.DoubleStartConfig) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProcCleanupPort(port)
+		result, e = c.DoubleStart(name, path, config)
 		return e
 	})
 	return result, err
 }
 
-// ProxyStartWithConfig starts a reverse proxy with extended configuration.
-func (rc *ResilientClient) ProxyStartWithConfig(id, targetURL string, port, maxLogSize int, config ProxyStartConfig) (map[string]interface{}, error) {
+// DoubleStop stops an API double.
+func (rc *ResilientClient) DoubleStop(name, path string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.DoubleStop(name, path)
+	})
+}
+
+// DoubleList returns the API doubles and the env of those running.
+func (rc *ResilientClient) DoubleList(path string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyStartWithConfig(id, targetURL, port, maxLogSize, config)
+		result, e = c.DoubleList(path)
 		return e
 	})
 	return result, err
 }
 
-// ProxyStatus gets the status of a proxy.
-func (rc *ResilientClient) ProxyStatus(id string) (map[string]interface{}, error) {
+// Help returns machine-readable usage of daemon commands.
+func (rc *ResilientClient) Help(verb, subVerb string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyStatus(id)
+		result, e = c.Help(verb, subVerb)
 		return e
 	})
 	return result, err
 }
 
-// ProxyExec executes JavaScript in connected browsers.
-func (rc *ResilientClient) ProxyExec(id, code string) (map[string]interface{}, error) {
+// ProfileCPU captures a CPU profile from a managed process.
+func (rc *ResilientClient) ProfileCPU(processID string, config protocol.ProfileConfig) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyExec(id, code)
+		result, e = c.ProfileCPU(processID, config)
 		return e
 	})
 	return result, err
 }
 
-// ProxyToast sends a toast notification to connected browsers.
-func (rc *ResilientClient) ProxyToast(id string, toast protocol.ToastConfig) (map[string]interface{}, error) {
+// ProfileHeap captures a heap profile from a managed process.
+func (rc *ResilientClient) ProfileHeap(processID string, config protocol.ProfileConfig) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyToast(id, toast)
+		result, e = c.ProfileHeap(processID, config)
 		return e
 	})
 	return result, err
 }
 
-// ProxyLogQuery queries proxy logs.
-func (rc *ResilientClient) ProxyLogQuery(proxyID string, filter protocol.LogQueryFilter) (map[string]interface{}, error) {
+// ProfileList lists stored profiles.
+func (rc *ResilientClient) ProfileList(path string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyLogQuery(proxyID, filter)
+		result, e = c.ProfileList(path)
 		return e
 	})
 	return result, err
 }
 
-// ProxyLogClear clears proxy logs.
-func (rc *ResilientClient) ProxyLogClear(proxyID string) error {
-	return rc.WithClient(func(c *Client) error {
-		return c.ProxyLogClear(proxyID)
+// FlakyList reports flaky tests.
+func (rc *ResilientClient) FlakyList() (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.FlakyList()
+		return e
 	})
+	return result, err
 }
 
-// ProxyLogStats gets proxy log statistics.
-func (rc *ResilientClient) ProxyLogStats(proxyID string) (map[string]interface{}, error) {
+// TunnelEvents returns the disconnect and reconnect log of tunnels.
+func (rc *ResilientClient) TunnelEvents(id string, limit int) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ProxyLogStats(proxyID)
+		result, e = c.TunnelEvents(id, limit)
 		return e
 	})
 	return result, err
 }
 
-// CurrentPageList lists active page sessions.
-func (rc *ResilientClient) CurrentPageList(proxyID string) (map[string]interface{}, error) {
+// TunnelList lists all active tunnels.
+func (rc *ResilientClient) TunnelList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.CurrentPageList(proxyID)
+		result, e = c.TunnelList(dirFilter)
 		return e
 	})
 	return result, err
 }
 
-// CurrentPageGet gets details for a specific page session.
-func (rc *ResilientClient) CurrentPageGet(proxyID, sessionID string) (map[string]interface{}, error) {
+// TunnelListFiltered lists tunnels, keeping only those with every label of the filter.
+func (rc *ResilientClient) TunnelListFiltered(filter protocol.ListFilter) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.CurrentPageGet(proxyID, sessionID)
+		result, e = c.TunnelListFiltered(filter)
 		return e
 	})
 	return result, err
 }
 
-// CurrentPageClear clears page sessions.
-func (rc *ResilientClient) CurrentPageClear(proxyID string) error {
+// TunnelLabel sets and removes labels of a tunnel and returns its labels.
+func (rc *ResilientClient) TunnelLabel(id string, set protocol.Labels, remove []string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.TunnelLabel(id, set, remove)
+		return e
+	})
+	return result, err
+}
+
+// BroadcastActivity sends an activity state update to connected browsers via specified proxies.
+// If proxyIDs is empty, broadcasts to all proxies (backward compatibility).
+func (rc *ResilientClient) BroadcastActivity(active bool, proxyIDs ...string) error {
 	return rc.WithClient(func(c *Client) error {
-		return c.CurrentPageClear(proxyID)
+		return c.BroadcastActivity(active, proxyIDs...)
 	})
 }
 
-// Chaos methods
+// BroadcastOutputPreview sends output preview lines to connected browsers via proxies.
+func (rc *ResilientClient) BroadcastOutputPreview(lines []string, proxyIDs ...string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.BroadcastOutputPreview(lines, proxyIDs...)
+	})
+}
 
-// ChaosEnable enables chaos injection on a proxy.
-func (rc *ResilientClient) ChaosEnable(proxyID string) (map[string]interface{}, error) {
+// Session methods
+
+// SessionRegister registers a new session with the daemon.
+func (rc *ResilientClient) SessionRegister(code, overlayPath, projectPath, command string, args []string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosEnable(proxyID)
+		result, e = c.SessionRegister(code, overlayPath, projectPath, command, args)
 		return e
 	})
 	return result, err
 }
 
-// ChaosDisable disables chaos injection on a proxy.
-func (rc *ResilientClient) ChaosDisable(proxyID string) (map[string]interface{}, error) {
+// SessionRegisterWithConfig registers a new session with its tags and
+// description.
+func (rc *ResilientClient) SessionRegisterWithConfig(code string, metadata protocol.SessionRegisterConfig) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosDisable(proxyID)
+		result, e = c.SessionRegisterWithConfig(code, metadata)
 		return e
 	})
 	return result, err
 }
 
-// ChaosStatus gets the chaos status of a proxy.
-func (rc *ResilientClient) ChaosStatus(proxyID string) (map[string]interface{}, error) {
+// SessionUnregister unregisters a session.
+func (rc *ResilientClient) SessionUnregister(code string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.SessionUnregister(code)
+	})
+}
+
+// SessionHeartbeat sends a heartbeat for a session.
+func (rc *ResilientClient) SessionHeartbeat(code string) error {
+	return rc.WithClient(func(c *Client) error {
+		return c.SessionHeartbeat(code)
+	})
+}
+
+// SessionList lists sessions, optionally filtered by directory.
+func (rc *ResilientClient) SessionList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosStatus(proxyID)
+		result, e = c.SessionList(dirFilter)
 		return e
 	})
 	return result, err
 }
 
-// ChaosPreset applies a preset chaos configuration to a proxy.
-func (rc *ResilientClient) ChaosPreset(proxyID, preset string) (map[string]interface{}, error) {
+// SessionListTagged lists sessions with every tag given.
+func (rc *ResilientClient) SessionListTagged(dirFilter protocol.DirectoryFilter, tags protocol.Labels) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosPreset(proxyID, preset)
+		result, e = c.SessionListTagged(dirFilter, tags)
 		return e
 	})
 	return result, err
 }
 
-// ChaosSet sets the full chaos configuration on a proxy.
-func (rc *ResilientClient) ChaosSet(proxyID string, config protocol.ChaosConfigPayload) (map[string]interface{}, error) {
+// SessionTag sets and removes tags of a session and replaces its
+// description when one is given.
+func (rc *ResilientClient) SessionTag(code string, set protocol.Labels, remove []string, description *string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosSet(proxyID, config)
+		result, e = c.SessionTag(code, set, remove, description)
 		return e
 	})
 	return result, err
 }
 
-// ChaosAddRule adds a single rule to a proxy's chaos engine.
-func (rc *ResilientClient) ChaosAddRule(proxyID string, rule protocol.ChaosRuleConfig) (map[string]interface{}, error) {
+// SessionGet retrieves details for a specific session.
+func (rc *ResilientClient) SessionGet(code string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosAddRule(proxyID, rule)
+		result, e = c.SessionGet(code)
 		return e
 	})
 	return result, err
 }
 
-// ChaosRemoveRule removes a rule from a proxy's chaos engine.
-func (rc *ResilientClient) ChaosRemoveRule(proxyID, ruleID string) (map[string]interface{}, error) {
+// SessionSend sends a message to a session immediately.
+func (rc *ResilientClient) SessionSend(code, message string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosRemoveRule(proxyID, ruleID)
+		result, e = c.SessionSend(code, message)
 		return e
 	})
 	return result, err
 }
 
-// ChaosListRules lists all chaos rules for a proxy.
-func (rc *ResilientClient) ChaosListRules(proxyID string) (map[string]interface{}, error) {
+// SessionSendWithOptions sends a message to a session immediately, typed
+// with the given chunking, pacing, submit and bracketed-paste options.
+func (rc *ResilientClient) SessionSendWithOptions(code, message string, opts SendOptions) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosListRules(proxyID)
+		result, e = c.SessionSendWithOptions(code, message, opts)
 		return e
 	})
 	return result, err
 }
 
-// ChaosStats gets chaos statistics for a proxy.
-func (rc *ResilientClient) ChaosStats(proxyID string) (map[string]interface{}, error) {
+// SessionSchedule schedules a message for future delivery.
+func (rc *ResilientClient) SessionSchedule(code, duration, message string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosStats(proxyID)
+		result, e = c.SessionSchedule(code, duration, message)
 		return e
 	})
 	return result, err
 }
 
-// ChaosClear clears all chaos rules and resets stats for a proxy.
-func (rc *ResilientClient) ChaosClear(proxyID string) (map[string]interface{}, error) {
+// SessionDigest turns on, reconfigures or reports the activity digest of a session.
+func (rc *ResilientClient) SessionDigest(code string, config *DigestConfig) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosClear(proxyID)
+		result, e = c.SessionDigest(code, config)
 		return e
 	})
 	return result, err
 }
 
-// ChaosListPresets returns the list of available chaos presets.
-func (rc *ResilientClient) ChaosListPresets() (map[string]interface{}, error) {
+// SessionDigestOff turns off the activity digest of a session.
+func (rc *ResilientClient) SessionDigestOff(code string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.ChaosListPresets()
+		result, e = c.SessionDigestOff(code)
 		return e
 	})
 	return result, err
 }
 
-// Tunnel methods
-
-// TunnelStart starts a tunnel for a local port.
-func (rc *ResilientClient) TunnelStart(config protocol.TunnelStartConfig) (map[string]interface{}, error) {
+// SessionAlerts lists the error spike alert rules of a session, adding one if given.
+func (rc *ResilientClient) SessionAlerts(code string, rule *AlertRule) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.TunnelStart(config)
+		result, e = c.SessionAlerts(code, rule)
 		return e
 	})
 	return result, err
 }
 
-// TunnelStop stops a running tunnel.
-func (rc *ResilientClient) TunnelStop(id string) error {
-	return rc.WithClient(func(c *Client) error {
-		return c.TunnelStop(id)
+// SessionAlertRemove removes an alert rule of a session.
+func (rc *ResilientClient) SessionAlertRemove(code, ruleID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.SessionAlertRemove(code, ruleID)
+		return e
 	})
+	return result, err
 }
 
-// TunnelStatus gets the status of a tunnel.
-func (rc *ResilientClient) TunnelStatus(id string) (map[string]interface{}, error) {
+// SessionScheduleWhenIdle schedules a message that waits for the session's tool to be idle.
+func (rc *ResilientClient) SessionScheduleWhenIdle(code, duration, message string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.TunnelStatus(id)
+		result, e = c.SessionScheduleWhenIdle(code, duration, message)
 		return e
 	})
 	return result, err
 }
 
-// TunnelList lists all active tunnels.
-func (rc *ResilientClient) TunnelList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+// SessionScheduleCron schedules a message delivered every time a cron expression matches.
+func (rc *ResilientClient) SessionScheduleCron(code, cron, timezone, message string, waitForIdle bool) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.TunnelList(dirFilter)
+		result, e = c.SessionScheduleCron(code, cron, timezone, message, waitForIdle)
 		return e
 	})
 	return result, err
 }
 
-// BroadcastActivity sends an activity state update to connected browsers via specified proxies.
-// If proxyIDs is empty, broadcasts to all proxies (backward compatibility).
-func (rc *ResilientClient) BroadcastActivity(active bool, proxyIDs ...string) error {
-	return rc.WithClient(func(c *Client) error {
-		return c.BroadcastActivity(active, proxyIDs...)
+// SessionPause holds a scheduled task without cancelling it.
+func (rc *ResilientClient) SessionPause(taskID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.SessionPause(taskID)
+		return e
 	})
+	return result, err
 }
 
-// BroadcastOutputPreview sends output preview lines to connected browsers via proxies.
-func (rc *ResilientClient) BroadcastOutputPreview(lines []string, proxyIDs ...string) error {
-	return rc.WithClient(func(c *Client) error {
-		return c.BroadcastOutputPreview(lines, proxyIDs...)
+// SessionResume resumes a paused scheduled task.
+func (rc *ResilientClient) SessionResume(taskID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.SessionResume(taskID)
+		return e
 	})
+	return result, err
 }
 
-// Session methods
-
-// SessionRegister registers a new session with the daemon.
-func (rc *ResilientClient) SessionRegister(code, overlayPath, projectPath, command string, args []string) (map[string]interface{}, error) {
+// SessionStatus reports whether a session's tool is busy or idle.
+func (rc *ResilientClient) SessionStatus(code string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.SessionRegister(code, overlayPath, projectPath, command, args)
+		result, e = c.SessionStatus(code)
 		return e
 	})
 	return result, err
 }
 
-// SessionUnregister unregisters a session.
-func (rc *ResilientClient) SessionUnregister(code string) error {
-	return rc.WithClient(func(c *Client) error {
-		return c.SessionUnregister(code)
+// SessionTranscript searches the terminal output a session recorded.
+func (rc *ResilientClient) SessionTranscript(code string, query TranscriptQuery) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.SessionTranscript(code, query)
+		return e
 	})
+	return result, err
 }
 
-// SessionHeartbeat sends a heartbeat for a session.
-func (rc *ResilientClient) SessionHeartbeat(code string) error {
-	return rc.WithClient(func(c *Client) error {
-		return c.SessionHeartbeat(code)
+// SessionHost starts a command in a PTY the daemon keeps for a detachable session.
+func (rc *ResilientClient) SessionHost(code string, config ptyhost.Config) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.SessionHost(code, config)
+		return e
 	})
+	return result, err
 }
 
-// SessionList lists sessions, optionally filtered by directory.
-func (rc *ResilientClient) SessionList(dirFilter protocol.DirectoryFilter) (map[string]interface{}, error) {
+// SessionHosted lists the processes hosted for detachable sessions, or describes one.
+func (rc *ResilientClient) SessionHosted(code string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.SessionList(dirFilter)
+		result, e = c.SessionHosted(code)
 		return e
 	})
 	return result, err
 }
 
-// SessionGet retrieves details for a specific session.
-func (rc *ResilientClient) SessionGet(code string) (map[string]interface{}, error) {
+// SessionDetach disconnects the terminal attached to a hosted session.
+func (rc *ResilientClient) SessionDetach(code string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.SessionGet(code)
+		result, e = c.SessionDetach(code)
 		return e
 	})
 	return result, err
 }
 
-// SessionSend sends a message to a session immediately.
-func (rc *ResilientClient) SessionSend(code, message string) (map[string]interface{}, error) {
+// SessionClipboard returns the last text moved between a session and its
+// proxied pages.
+func (rc *ResilientClient) SessionClipboard(code string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.SessionSend(code, message)
+		result, e = c.SessionClipboard(code)
 		return e
 	})
 	return result, err
 }
 
-// SessionSchedule schedules a message for future delivery.
-func (rc *ResilientClient) SessionSchedule(code, duration, message string) (map[string]interface{}, error) {
+// SessionShareClipboard offers terminal text to the floating panel of a
+// session's proxied pages.
+func (rc *ResilientClient) SessionShareClipboard(code, text string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := rc.WithClient(func(c *Client) error {
 		var e error
-		result, e = c.SessionSchedule(code, duration, message)
+		result, e = c.SessionShareClipboard(code, text)
 		return e
 	})
 	return result, err
@@ -720,6 +2071,18 @@ func (rc *ResilientClient) SessionFind(directory string) (map[string]interface{}
 	return result, err
 }
 
+// SessionFindTagged finds a session by directory ancestry among those with
+// every tag given.
+func (rc *ResilientClient) SessionFindTagged(directory string, tags protocol.Labels) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.SessionFindTagged(directory, tags)
+		return e
+	})
+	return result, err
+}
+
 // SessionAttach attaches to a session found by directory ancestry.
 func (rc *ResilientClient) SessionAttach(directory string) (map[string]interface{}, error) {
 	var result map[string]interface{}
@@ -822,6 +2185,17 @@ func (rc *ResilientClient) StopAll() (map[string]interface{}, error) {
 	return result, err
 }
 
+// StopAllDryRun lists what StopAll would stop.
+func (rc *ResilientClient) StopAllDryRun() (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := rc.WithClient(func(c *Client) error {
+		var e error
+		result, e = c.StopAllDryRun()
+		return e
+	})
+	return result, err
+}
+
 // RestartAll restarts all processes and proxies.
 func (rc *ResilientClient) RestartAll() (map[string]interface{}, error) {
 	var result map[string]interface{}
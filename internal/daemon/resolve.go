@@ -0,0 +1,301 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/tunnel"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// Entity types RESOLVE and not-found errors report.
+const (
+	entityProxy   = "proxy"
+	entityTunnel  = "tunnel"
+	entityProcess = "process"
+	entitySession = "session"
+)
+
+var entityTypes = []string{entityProcess, entityProxy, entityTunnel, entitySession}
+
+// How an ID matches a partial one, best first.
+const (
+	matchExact     = iota // The full ID
+	matchComponent        // A ":"-separated part, as proxy and tunnel lookups accept
+	matchPrefix           // The ID or a part starts with it
+	matchSubstring        // The ID contains it
+	matchSimilar          // A part is a few edits away
+	matchNone
+)
+
+var matchNames = [...]string{"exact", "component", "prefix", "substring", "similar"}
+
+// maxCandidates limits the candidates listed per scope.
+const maxCandidates = 5
+
+// entityRef is a managed entity a partial ID may refer to.
+type entityRef struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Path  string `json:"path,omitempty"`
+	Match string `json:"match,omitempty"`
+
+	rank     int
+	distance int
+}
+
+// entityRefs lists the entities of a type, or of all types when entityType
+// is empty.
+func (d *Daemon) entityRefs(entityType string) []entityRef {
+	var refs []entityRef
+	want := func(t string) bool { return entityType == "" || entityType == t }
+	if want(entityProcess) && d.hub != nil {
+		for _, p := range d.hub.ProcessManager().List() {
+			refs = append(refs, entityRef{Type: entityProcess, ID: p.ID, Path: p.ProjectPath})
+		}
+	}
+	if want(entityProxy) && d.proxym != nil {
+		for _, p := range d.proxym.List() {
+			refs = append(refs, entityRef{Type: entityProxy, ID: p.ID, Path: p.Path})
+		}
+	}
+	if want(entityTunnel) && d.tunnelm != nil {
+		for _, t := range d.tunnelm.List() {
+			refs = append(refs, entityRef{Type: entityTunnel, ID: t.ID, Path: t.Path})
+		}
+	}
+	if want(entitySession) && d.sessionRegistry != nil {
+		for _, s := range d.sessionRegistry.List("", true) {
+			refs = append(refs, entityRef{Type: entitySession, ID: s.Code, Path: s.ProjectPath})
+		}
+	}
+	return refs
+}
+
+// matchEntityID rates how id matches the partial ID query, and for similar
+// IDs how many edits apart the closest part is.
+func matchEntityID(query, id string) (rank, distance int) {
+	if id == query {
+		return matchExact, 0
+	}
+	parts := strings.Split(id, ":")
+	for _, part := range parts {
+		if part == query {
+			return matchComponent, 0
+		}
+	}
+	q, lower := strings.ToLower(query), strings.ToLower(id)
+	if strings.HasPrefix(lower, q) {
+		return matchPrefix, 0
+	}
+	for _, part := range parts {
+		if strings.HasPrefix(strings.ToLower(part), q) {
+			return matchPrefix, 0
+		}
+	}
+	if strings.Contains(lower, q) {
+		return matchSubstring, 0
+	}
+	distance = protocol.EditDistance(q, lower)
+	for _, part := range parts {
+		distance = min(distance, protocol.EditDistance(q, strings.ToLower(part)))
+	}
+	if len(query) > 2 && distance <= max(2, len(query)/3) {
+		return matchSimilar, distance
+	}
+	return matchNone, distance
+}
+
+// rankEntities returns the entities matching query, best first: those in
+// the scope project, and those elsewhere. Without a scope every match is
+// in scoped.
+func rankEntities(query string, refs []entityRef, scope string) (scoped, others []entityRef) {
+	if scope != "" {
+		scope = normalizePath(scope)
+	}
+	for _, ref := range refs {
+		ref.rank, ref.distance = matchEntityID(query, ref.ID)
+		if ref.rank == matchNone {
+			continue
+		}
+		ref.Match = matchNames[ref.rank]
+		if scope == "" || normalizePath(ref.Path) == scope {
+			scoped = append(scoped, ref)
+		} else {
+			others = append(others, ref)
+		}
+	}
+	for _, list := range [][]entityRef{scoped, others} {
+		sort.SliceStable(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if a.rank != b.rank {
+				return a.rank < b.rank
+			}
+			if a.distance != b.distance {
+				return a.distance < b.distance
+			}
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+			return a.ID < b.ID
+		})
+	}
+	return limitRefs(scoped), limitRefs(others)
+}
+
+func limitRefs(refs []entityRef) []entityRef {
+	if len(refs) > maxCandidates {
+		return refs[:maxCandidates]
+	}
+	return refs
+}
+
+// resolvedRef is the entity query unambiguously names: an exact ID, or the
+// only best match short of a similar one. Matches in scope win over others.
+func resolvedRef(scoped, others []entityRef) (entityRef, bool) {
+	pool := scoped
+	if len(pool) == 0 {
+		pool = others
+	}
+	if len(pool) == 0 {
+		return entityRef{}, false
+	}
+	best := pool[0]
+	switch {
+	case best.rank == matchExact:
+		return best, true
+	case best.rank >= matchSimilar:
+		return entityRef{}, false
+	case len(pool) > 1 && pool[1].rank == best.rank:
+		return entityRef{}, false
+	}
+	return best, true
+}
+
+// writeNotFound reports an ID of entityType that doesn't resolve, listing
+// the closest IDs in the session's project and in other projects so the
+// caller can retry with a full one. err is the lookup's error, if any.
+func (d *Daemon) writeNotFound(conn *hubpkg.Connection, cmd *hubproto.Command, entityType, id string, err error) error {
+	scoped, others := rankEntities(id, d.entityRefs(entityType), d.getSessionProjectPath(conn))
+
+	message := fmt.Sprintf("%s %q not found", entityType, id)
+	if errors.Is(err, proxy.ErrProxyAmbiguous) || errors.Is(err, tunnel.ErrTunnelAmbiguous) {
+		message = fmt.Sprintf("%s ID %q is ambiguous", entityType, id)
+	}
+	candidates := make([]string, 0, len(scoped)+len(others))
+	for _, ref := range slices.Concat(scoped, others) {
+		candidates = append(candidates, ref.ID)
+	}
+	details := map[string]any{"type": entityType, "id": id}
+	if len(scoped) > 0 {
+		details["candidates"] = scoped
+	}
+	if len(others) > 0 {
+		details["other_projects"] = others
+	}
+	switch {
+	case len(candidates) == 1:
+		message += fmt.Sprintf("; did you mean %q?", candidates[0])
+	case len(candidates) > 1:
+		message += "; candidates: " + strings.Join(candidates, ", ")
+	default:
+		// Perhaps the ID names an entity of another type.
+		for _, ref := range d.entityRefs("") {
+			if ref.Type != entityType {
+				if rank, _ := matchEntityID(id, ref.ID); rank <= matchComponent {
+					message += fmt.Sprintf("; %q is a %s", ref.ID, ref.Type)
+					details["other_types"] = []entityRef{{Type: ref.Type, ID: ref.ID, Path: ref.Path}}
+					break
+				}
+			}
+		}
+	}
+
+	return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+		Code:        hubproto.ErrNotFound,
+		Message:     message,
+		Command:     cmd.Verb,
+		Action:      cmd.SubVerb,
+		Param:       "id",
+		ValidParams: candidates,
+		Details:     details,
+	})
+}
+
+// hubHandleResolve handles RESOLVE <partial-id> [type]: the entity a
+// partial ID names, and the candidates when it names none or several.
+func (d *Daemon) hubHandleResolve(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	args := cmd.Args
+	if cmd.SubVerb != "" {
+		// Partial IDs are parsed as a sub-verb when they look like one.
+		args = append([]string{cmd.SubVerb}, args...)
+	}
+	if len(args) < 1 {
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:    hubproto.ErrMissingParam,
+			Message: "RESOLVE requires: <partial-id> [type]",
+			Command: protocol.VerbResolve,
+			Param:   "partial-id",
+		})
+	}
+	query := args[0]
+	entityType := ""
+	if len(args) > 1 {
+		entityType = strings.ToLower(args[1])
+		if !slices.Contains(entityTypes, entityType) {
+			return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+				Code:        hubproto.ErrInvalidArgs,
+				Message:     fmt.Sprintf("unknown entity type %q", args[1]),
+				Command:     protocol.VerbResolve,
+				Param:       "type",
+				ValidParams: entityTypes,
+			})
+		}
+	}
+
+	scoped, others := rankEntities(query, d.entityRefs(entityType), d.getSessionProjectPath(conn))
+	if len(scoped) == 0 && len(others) == 0 {
+		kind := entityType
+		if kind == "" {
+			kind = "process, proxy, tunnel or session"
+		}
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:    hubproto.ErrNotFound,
+			Message: fmt.Sprintf("no %s matches %q", kind, query),
+			Command: protocol.VerbResolve,
+			Param:   "partial-id",
+		})
+	}
+
+	if scoped == nil {
+		scoped = []entityRef{}
+	}
+	resp := map[string]interface{}{
+		"query":      query,
+		"candidates": scoped,
+	}
+	if len(others) > 0 {
+		resp["other_projects"] = others
+	}
+	if ref, ok := resolvedRef(scoped, others); ok {
+		resp["type"] = ref.Type
+		resp["id"] = ref.ID
+		resp["path"] = ref.Path
+		resp["match"] = ref.Match
+	} else {
+		resp["ambiguous"] = true
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	return conn.WriteJSON(data)
+}
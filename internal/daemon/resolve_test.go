@@ -0,0 +1,62 @@
+package daemon
+
+import "testing"
+
+func TestMatchEntityID(t *testing.T) {
+	tests := []struct {
+		query, id string
+		want      int
+	}{
+		{"app-1a2b:dev", "app-1a2b:dev", matchExact},
+		{"dev", "app-1a2b:dev", matchComponent},
+		{"de", "app-1a2b:dev", matchPrefix},
+		{"APP", "app-1a2b:dev", matchPrefix},
+		{"1a2b", "app-1a2b:dev", matchSubstring},
+		{"dve", "app-1a2b:dev", matchSimilar},
+		{"frontend", "app-1a2b:dev", matchNone},
+		{"x", "app-1a2b:y", matchNone},
+	}
+	for _, tt := range tests {
+		if got, _ := matchEntityID(tt.query, tt.id); got != tt.want {
+			t.Errorf("matchEntityID(%q, %q) = %d, want %d", tt.query, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestRankEntities(t *testing.T) {
+	refs := []entityRef{
+		{Type: entityProcess, ID: "web-0001:devserver", Path: "/src/web"},
+		{Type: entityProcess, ID: "web-0001:dev", Path: "/src/web"},
+		{Type: entityProcess, ID: "api-0002:dev", Path: "/src/api"},
+		{Type: entityProcess, ID: "api-0002:test", Path: "/src/api"},
+	}
+
+	scoped, others := rankEntities("dev", refs, "/src/web")
+	if len(scoped) != 2 || scoped[0].ID != "web-0001:dev" || scoped[0].Match != "component" || scoped[1].ID != "web-0001:devserver" {
+		t.Errorf("scoped = %+v", scoped)
+	}
+	if len(others) != 1 || others[0].ID != "api-0002:dev" {
+		t.Errorf("others = %+v", others)
+	}
+	if ref, ok := resolvedRef(scoped, others); !ok || ref.ID != "web-0001:dev" {
+		t.Errorf("resolvedRef = %+v, %v", ref, ok)
+	}
+
+	// Without a session every project is in scope, and "dev" names two.
+	scoped, others = rankEntities("dev", refs, "")
+	if len(scoped) != 3 || len(others) != 0 {
+		t.Errorf("unscoped = %+v, %+v", scoped, others)
+	}
+	if _, ok := resolvedRef(scoped, others); ok {
+		t.Error("resolvedRef resolved an ambiguous ID")
+	}
+
+	// A near miss is suggested but never resolved.
+	scoped, others = rankEntities("tset", refs, "/src/web")
+	if len(scoped) != 0 || len(others) != 1 || others[0].Match != "similar" {
+		t.Errorf("near miss = %+v, %+v", scoped, others)
+	}
+	if _, ok := resolvedRef(scoped, others); ok {
+		t.Error("resolvedRef resolved a near miss")
+	}
+}
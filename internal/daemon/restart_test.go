@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/go-cli-server/process"
 )
 
 // TestRestartIntegration_ProcRestart tests single process restart.
@@ -569,3 +570,27 @@ func TestRestartIntegration_RestartAll_Empty(t *testing.T) {
 			processesRestarted, processesFailed, proxiesRestarted, proxiesFailed)
 	}
 }
+
+// TestRestartConfig tests that a restart keeps the process's configuration.
+func TestRestartConfig(t *testing.T) {
+	proc := &process.ManagedProcess{
+		ID:          "dev",
+		ProjectPath: "/project",
+		Command:     "npm",
+		Args:        []string{"run", "dev"},
+		Env:         []string{"PORT=3000", "NODE_ENV=development"},
+	}
+	config := restartConfig(proc)
+	if config.ID != "dev" || config.ProjectPath != "/project" || config.Command != "npm" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if len(config.Args) != 2 || len(config.Env) != 2 || config.Env[0] != "PORT=3000" {
+		t.Errorf("Expected args and environment kept, got %+v", config)
+	}
+
+	// The config doesn't share slices with the stopped process
+	config.Env[0] = "PORT=4000"
+	if proc.Env[0] != "PORT=3000" {
+		t.Error("Expected the environment copied")
+	}
+}
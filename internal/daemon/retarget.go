@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+const (
+	// retargetWait is how long a newly detected URL may take to accept
+	// connections; dev servers often print their URL before listening.
+	retargetWait = 10 * time.Second
+	// retargetPoll is the interval between reachability probes.
+	retargetPoll = 250 * time.Millisecond
+	// retargetDialTimeout bounds a single reachability probe.
+	retargetDialTimeout = 500 * time.Millisecond
+)
+
+// retargetProxies follows a process that came back on a new port: proxies
+// linked to it or targeting one of its earlier ports, whose target no longer
+// accepts connections, are pointed at newURL once it does. Returns the
+// proxies being moved.
+func (d *Daemon) retargetProxies(processID, newURL string) []*proxy.ProxyServer {
+	u, err := url.Parse(newURL)
+	if err != nil || !isLocalHost(u.Hostname()) {
+		return nil
+	}
+	newPort := urlPort(newURL)
+	if newPort == 0 {
+		return nil
+	}
+
+	// Ports the process announced before this URL
+	oldPorts := make(map[int]bool)
+	for _, seen := range d.urlTracker.GetURLs(processID) {
+		if port := urlPort(seen); port > 0 && port != newPort {
+			oldPorts[port] = true
+		}
+	}
+	if proc, err := d.hub.ProcessManager().Get(processID); err == nil {
+		if port := extractPortFromCommand(proc.Command, proc.Args); port > 0 && port != newPort {
+			oldPorts[port] = true
+		}
+	}
+	linked := make(map[string]bool)
+	for _, id := range d.getProxiesForScript(processID) {
+		linked[id] = true
+	}
+	d.exposureMu.Lock()
+	for _, e := range d.exposures {
+		if e.ProcessID == processID && e.ProxyID != "" {
+			linked[e.ProxyID] = true
+		}
+	}
+	d.exposureMu.Unlock()
+
+	var candidates []*proxy.ProxyServer
+	for _, px := range d.proxym.List() {
+		target := px.Target()
+		if target == nil || !isLocalHost(target.Hostname()) {
+			continue
+		}
+		port := urlPort(target.String())
+		if port == newPort || (!oldPorts[port] && !linked[px.ID]) {
+			continue
+		}
+		if px.TargetUnreachableSince().IsZero() && hostReachable(target.Host) {
+			continue // Old server still up: a second listener, not a move
+		}
+		if !px.AutoRetarget() {
+			log.Printf("[INFO] Process %s moved to port %d; auto-retarget disabled for proxy %s", processID, newPort, px.ID)
+			continue
+		}
+		candidates = append(candidates, px)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if !d.waitReachable(u.Host) {
+			log.Printf("[DEBUG] New URL %s of %s never accepted connections, not retargeting", newURL, processID)
+			return
+		}
+		for _, px := range candidates {
+			reason := fmt.Sprintf("process %s moved from port %d to %d", processID, urlPort(px.Target().String()), newPort)
+			d.retargetProxy(px, newURL, reason)
+		}
+	}()
+	return candidates
+}
+
+// retargetProxy points a proxy at newURL, persists the change and tells the
+// browser.
+func (d *Daemon) retargetProxy(px *proxy.ProxyServer, newURL, reason string) {
+	old, err := px.Retarget(newURL, reason)
+	if err != nil {
+		log.Printf("[WARN] Failed to retarget proxy %s to %s: %v", px.ID, newURL, err)
+		return
+	}
+	target := px.Target().String()
+	log.Printf("[INFO] Proxy %s retargeted %s -> %s (%s)", px.ID, old, target, reason)
+
+	if d.stateMgr != nil {
+		if pc, ok := d.stateMgr.GetProxy(px.ID); ok {
+			pc.TargetURL = target
+			d.stateMgr.AddProxy(pc)
+		}
+	}
+
+	px.BroadcastToast("info", "Proxy retargeted", fmt.Sprintf("Now forwarding to %s (%s)", target, reason), 0)
+}
+
+// waitReachable polls host until it accepts connections, giving up after
+// retargetWait or on shutdown.
+func (d *Daemon) waitReachable(host string) bool {
+	deadline := time.Now().Add(retargetWait)
+	for {
+		if hostReachable(host) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-d.ctx.Done():
+			return false
+		case <-time.After(retargetPoll):
+		}
+	}
+}
+
+// hostReachable reports whether host:port accepts TCP connections.
+func hostReachable(host string) bool {
+	conn, err := net.DialTimeout("tcp", host, retargetDialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"net"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestHostReachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if !hostReachable(addr) {
+		t.Errorf("hostReachable(%s) = false while listening", addr)
+	}
+	ln.Close()
+	if hostReachable(addr) {
+		t.Errorf("hostReachable(%s) = true after close", addr)
+	}
+}
+
+func TestFindProxyWithPrefix(t *testing.T) {
+	api := &proxy.ProxyServer{ID: makeProxyIDFromURL("/home/dev/app", "api", "http://localhost:3001")}
+	web := &proxy.ProxyServer{ID: makeProxyIDFromURL("/home/dev/app", "web", "http://localhost:3000")}
+	proxies := []*proxy.ProxyServer{api, web}
+
+	if px := findProxyWithPrefix(proxies, makeProcessID("/home/dev/app", "web")+":"); px != web {
+		t.Errorf("findProxyWithPrefix(web) = %v, want %s", px, web.ID)
+	}
+	if px := findProxyWithPrefix(proxies, makeProcessID("/home/dev/app", "docs")+":"); px != nil {
+		t.Errorf("findProxyWithPrefix(docs) = %s, want nil", px.ID)
+	}
+}
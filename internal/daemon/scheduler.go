@@ -6,11 +6,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/standardbeagle/agnt/internal/endpoint"
 )
 
 // TaskStatus represents the current state of a scheduled task.
@@ -25,33 +28,96 @@ const (
 	TaskStatusFailed TaskStatus = "failed"
 	// TaskStatusCancelled indicates the task was cancelled.
 	TaskStatusCancelled TaskStatus = "cancelled"
+	// TaskStatusPaused indicates the task is kept but not delivered.
+	TaskStatusPaused TaskStatus = "paused"
 )
 
+// scheduleCronArg takes the place of the duration in SESSION SCHEDULE for a
+// recurring schedule.
+const scheduleCronArg = "CRON"
+
+// maxTaskRuns is how many deliveries a task's run history keeps.
+const maxTaskRuns = 20
+
+// TaskRun is one delivery of a scheduled task.
+type TaskRun struct {
+	At     time.Time  `json:"at"`
+	Status TaskStatus `json:"status"` // delivered or failed
+	Error  string     `json:"error,omitempty"`
+}
+
 // ScheduledTask represents a message scheduled for future delivery.
 type ScheduledTask struct {
-	ID          string     `json:"id"`                   // Unique task ID (e.g., "task-abc123")
-	SessionCode string     `json:"session_code"`         // Target session
-	Message     string     `json:"message"`              // Message to deliver
-	DeliverAt   time.Time  `json:"deliver_at"`           // Scheduled delivery time
-	CreatedAt   time.Time  `json:"created_at"`           // When task was created
-	ProjectPath string     `json:"project_path"`         // For project-scoped filtering
-	Status      TaskStatus `json:"status"`               // Current status
-	Attempts    int        `json:"attempts"`             // Delivery attempts
-	LastError   string     `json:"last_error,omitempty"` // Last delivery error
+	ID          string     `json:"id"`                      // Unique task ID (e.g., "task-abc123")
+	SessionCode string     `json:"session_code"`            // Target session
+	Message     string     `json:"message"`                 // Message to deliver
+	DeliverAt   time.Time  `json:"deliver_at"`              // Scheduled delivery time
+	CreatedAt   time.Time  `json:"created_at"`              // When task was created
+	ProjectPath string     `json:"project_path"`            // For project-scoped filtering
+	Status      TaskStatus `json:"status"`                  // Current status
+	Attempts    int        `json:"attempts"`                // Delivery attempts
+	LastError   string     `json:"last_error,omitempty"`    // Last delivery error
+	WaitForIdle bool       `json:"wait_for_idle,omitempty"` // Hold delivery while the session's tool is busy
+	Cron        string     `json:"cron,omitempty"`          // Recurring schedule; empty for a one-shot task
+	Timezone    string     `json:"timezone,omitempty"`      // Time zone of Cron (default: the daemon's)
+	RunCount    int        `json:"run_count,omitempty"`     // Deliveries so far, failed ones included
+	Runs        []TaskRun  `json:"runs,omitempty"`          // The last 20 deliveries, oldest first
+	Overdue     bool       `json:"overdue,omitempty"`       // Came due while the daemon was down
+}
+
+// recurring reports whether the task repeats on a cron schedule.
+func (t *ScheduledTask) recurring() bool {
+	return t.Cron != ""
+}
+
+// recordRun adds a delivery to the task's run history.
+func (t *ScheduledTask) recordRun(status TaskStatus, errMsg string) {
+	t.RunCount++
+	t.Runs = appendBoundedRuns(t.Runs, TaskRun{At: time.Now(), Status: status, Error: errMsg})
+}
+
+// appendBoundedRuns appends a run, dropping the oldest beyond maxTaskRuns.
+func appendBoundedRuns(runs []TaskRun, run TaskRun) []TaskRun {
+	runs = append(runs, run)
+	if len(runs) > maxTaskRuns {
+		runs = runs[len(runs)-maxTaskRuns:]
+	}
+	return runs
+}
+
+// scheduleNext moves a recurring task to its next time after now.
+func (t *ScheduledTask) scheduleNext(now time.Time) error {
+	schedule, err := parseCron(t.Cron, t.Timezone)
+	if err != nil {
+		return err
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return fmt.Errorf("cron expression %q never matches", t.Cron)
+	}
+	t.DeliverAt = next
+	t.Overdue = false
+	return nil
 }
 
 // ToJSON returns the task as a JSON-serializable map.
 func (t *ScheduledTask) ToJSON() map[string]interface{} {
 	return map[string]interface{}{
-		"id":           t.ID,
-		"session_code": t.SessionCode,
-		"message":      t.Message,
-		"deliver_at":   t.DeliverAt.Format(time.RFC3339),
-		"created_at":   t.CreatedAt.Format(time.RFC3339),
-		"project_path": t.ProjectPath,
-		"status":       string(t.Status),
-		"attempts":     t.Attempts,
-		"last_error":   t.LastError,
+		"id":            t.ID,
+		"session_code":  t.SessionCode,
+		"message":       t.Message,
+		"deliver_at":    t.DeliverAt.Format(time.RFC3339),
+		"created_at":    t.CreatedAt.Format(time.RFC3339),
+		"project_path":  t.ProjectPath,
+		"status":        string(t.Status),
+		"attempts":      t.Attempts,
+		"last_error":    t.LastError,
+		"wait_for_idle": t.WaitForIdle,
+		"cron":          t.Cron,
+		"timezone":      t.Timezone,
+		"run_count":     t.RunCount,
+		"runs":          t.Runs,
+		"overdue":       t.Overdue,
 	}
 }
 
@@ -67,6 +133,14 @@ type SchedulerConfig struct {
 	DeliveryTimeout time.Duration
 }
 
+// sessionRestoreGrace is how long after startup a due task waits for its
+// session to register again, as sessions reconnect after a daemon restart.
+const sessionRestoreGrace = 2 * time.Minute
+
+// maxIdleWait is how long past its due time a wait-for-idle task is held
+// for a busy session before it is delivered anyway.
+const maxIdleWait = 10 * time.Minute
+
 // DefaultSchedulerConfig returns sensible defaults.
 func DefaultSchedulerConfig() SchedulerConfig {
 	return SchedulerConfig{
@@ -86,12 +160,16 @@ type Scheduler struct {
 	// Task storage (sync.Map for lock-free access)
 	tasks sync.Map // map[string]*ScheduledTask
 
+	// Tasks being delivered, so a slow delivery isn't started twice
+	delivering sync.Map // map[string]bool
+
 	// Lifecycle management
-	ctx     context.Context
-	cancel  context.CancelFunc
-	wg      sync.WaitGroup
-	mu      sync.Mutex
-	started bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	started   bool
+	startedAt time.Time
 
 	// Statistics (atomics)
 	totalScheduled atomic.Int64
@@ -126,15 +204,11 @@ func (s *Scheduler) Start(ctx context.Context) error {
 
 	s.ctx, s.cancel = context.WithCancel(ctx)
 	s.started = true
+	s.startedAt = time.Now()
 
 	// Load persisted tasks from all project directories
 	if s.stateMgr != nil {
-		tasks := s.stateMgr.LoadAllTasks()
-		for _, task := range tasks {
-			if task.Status == TaskStatusPending {
-				s.tasks.Store(task.ID, task)
-			}
-		}
+		s.restoreTasks(s.stateMgr.LoadAllTasks(), s.startedAt)
 	}
 
 	s.wg.Add(1)
@@ -143,6 +217,35 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	return nil
 }
 
+// restoreTasks adds tasks persisted before a restart. Tasks that came due
+// while the daemon was down keep their deliver-at time and are flagged
+// overdue, so they are delivered on the next tick; a recurring task catches
+// up with one delivery however many runs it missed.
+func (s *Scheduler) restoreTasks(tasks []*ScheduledTask, now time.Time) {
+	for _, task := range tasks {
+		if task.Status != TaskStatusPending && task.Status != TaskStatusPaused {
+			continue
+		}
+		if _, exists := s.tasks.Load(task.ID); exists {
+			continue
+		}
+		if task.Status == TaskStatusPending && task.DeliverAt.Before(now) {
+			task.Overdue = true
+		}
+		s.tasks.Store(task.ID, task)
+
+		// New task IDs continue after the restored ones
+		if n, err := strconv.ParseInt(strings.TrimPrefix(task.ID, "task-"), 10, 64); err == nil {
+			for {
+				cur := s.nextTaskID.Load()
+				if n <= cur || s.nextTaskID.CompareAndSwap(cur, n) {
+					break
+				}
+			}
+		}
+	}
+}
+
 // Stop stops the scheduler.
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
@@ -167,12 +270,20 @@ func (s *Scheduler) run() {
 	ticker := time.NewTicker(s.config.TickInterval)
 	defer ticker.Stop()
 
+	// Tasks waiting for a session to go idle are checked as soon as it does
+	activity, unsubscribe := s.registry.SubscribeActivity()
+	defer unsubscribe()
+
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
 			s.checkDueTasks()
+		case event := <-activity:
+			if event.State == ActivityIdle {
+				s.checkDueTasks()
+			}
 		}
 	}
 }
@@ -182,39 +293,51 @@ func (s *Scheduler) checkDueTasks() {
 	now := time.Now()
 	s.tasks.Range(func(key, value interface{}) bool {
 		task := value.(*ScheduledTask)
-		if task.Status == TaskStatusPending && task.DeliverAt.Before(now) {
+		if task.Status == TaskStatusPending && task.DeliverAt.Before(now) && !s.heldForIdle(task, now) && !s.heldForSession(task, now) {
+			if _, busy := s.delivering.LoadOrStore(task.ID, true); busy {
+				return true
+			}
 			// Attempt delivery in a goroutine
-			go s.deliverTask(task)
+			go func() {
+				defer s.delivering.Delete(task.ID)
+				s.deliverTask(task)
+			}()
 		}
 		return true
 	})
 }
 
+// heldForIdle reports whether a due task waits for its session's tool to
+// finish working.
+func (s *Scheduler) heldForIdle(task *ScheduledTask, now time.Time) bool {
+	if !task.WaitForIdle || now.After(task.DeliverAt.Add(maxIdleWait)) {
+		return false
+	}
+	session, ok := s.registry.Get(task.SessionCode)
+	return ok && session.IsBusy()
+}
+
+// heldForSession reports whether a due task waits for its session to
+// register again after a daemon restart.
+func (s *Scheduler) heldForSession(task *ScheduledTask, now time.Time) bool {
+	if now.After(s.startedAt.Add(sessionRestoreGrace)) {
+		return false
+	}
+	_, ok := s.registry.Get(task.SessionCode)
+	return !ok
+}
+
 // deliverTask attempts to deliver a scheduled task.
 func (s *Scheduler) deliverTask(task *ScheduledTask) {
 	// Get the session
 	session, ok := s.registry.Get(task.SessionCode)
 	if !ok {
-		task.Attempts++
-		task.LastError = fmt.Sprintf("session %q not found", task.SessionCode)
-		if task.Attempts >= s.config.MaxRetries {
-			task.Status = TaskStatusFailed
-			s.totalFailed.Add(1)
-			s.removeTaskFromStorage(task)
-		}
-		s.persistTask(task)
+		s.failAttempt(task, fmt.Sprintf("session %q not found", task.SessionCode))
 		return
 	}
 
 	if session.GetStatus() != SessionStatusActive {
-		task.Attempts++
-		task.LastError = "session not active"
-		if task.Attempts >= s.config.MaxRetries {
-			task.Status = TaskStatusFailed
-			s.totalFailed.Add(1)
-			s.removeTaskFromStorage(task)
-		}
-		s.persistTask(task)
+		s.failAttempt(task, "session not active")
 		return
 	}
 
@@ -230,14 +353,7 @@ func (s *Scheduler) deliverTask(task *ScheduledTask) {
 
 	data, err := json.Marshal(payload)
 	if err != nil {
-		task.Attempts++
-		task.LastError = fmt.Sprintf("failed to marshal payload: %v", err)
-		if task.Attempts >= s.config.MaxRetries {
-			task.Status = TaskStatusFailed
-			s.totalFailed.Add(1)
-			s.removeTaskFromStorage(task)
-		}
-		s.persistTask(task)
+		s.failAttempt(task, fmt.Sprintf("failed to marshal payload: %v", err))
 		return
 	}
 
@@ -247,60 +363,75 @@ func (s *Scheduler) deliverTask(task *ScheduledTask) {
 
 	req, err := http.NewRequestWithContext(ctx, "POST", "http://localhost/type", bytes.NewReader(data))
 	if err != nil {
-		task.Attempts++
-		task.LastError = fmt.Sprintf("failed to create request: %v", err)
-		if task.Attempts >= s.config.MaxRetries {
-			task.Status = TaskStatusFailed
-			s.totalFailed.Add(1)
-			s.removeTaskFromStorage(task)
-		}
-		s.persistTask(task)
+		s.failAttempt(task, fmt.Sprintf("failed to create request: %v", err))
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		task.Attempts++
-		task.LastError = fmt.Sprintf("delivery failed: %v", err)
-		if task.Attempts >= s.config.MaxRetries {
-			task.Status = TaskStatusFailed
-			s.totalFailed.Add(1)
-			s.removeTaskFromStorage(task)
-		}
-		s.persistTask(task)
+		s.failAttempt(task, fmt.Sprintf("delivery failed: %v", err))
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		task.Attempts++
-		task.LastError = fmt.Sprintf("overlay returned status %d", resp.StatusCode)
-		if task.Attempts >= s.config.MaxRetries {
-			task.Status = TaskStatusFailed
-			s.totalFailed.Add(1)
-			s.removeTaskFromStorage(task)
-		}
-		s.persistTask(task)
+		s.failAttempt(task, fmt.Sprintf("overlay returned status %d", resp.StatusCode))
 		return
 	}
 
 	// Success!
-	task.Status = TaskStatusDelivered
 	s.totalDelivered.Add(1)
+	if task.recurring() {
+		task.recordRun(TaskStatusDelivered, "")
+		task.Attempts = 0
+		task.LastError = ""
+		s.rescheduleOrFail(task)
+		return
+	}
+	task.Status = TaskStatusDelivered
+	s.removeTaskFromStorage(task)
+}
+
+// failAttempt counts a failed delivery attempt. After MaxRetries a one-shot
+// task fails, while a recurring one records the failed run and waits for its
+// next time.
+func (s *Scheduler) failAttempt(task *ScheduledTask, reason string) {
+	task.Attempts++
+	task.LastError = reason
+	if task.Attempts < s.config.MaxRetries {
+		s.persistTask(task)
+		return
+	}
+	s.totalFailed.Add(1)
+	if task.recurring() {
+		task.recordRun(TaskStatusFailed, reason)
+		task.Attempts = 0
+		s.rescheduleOrFail(task)
+		return
+	}
+	task.Status = TaskStatusFailed
 	s.removeTaskFromStorage(task)
 }
 
-// createOverlayClient creates an HTTP client that connects via Unix socket.
+// rescheduleOrFail moves a recurring task to its next time, or fails it when
+// its schedule no longer matches.
+func (s *Scheduler) rescheduleOrFail(task *ScheduledTask) {
+	if err := task.scheduleNext(time.Now()); err != nil {
+		task.Status = TaskStatusFailed
+		task.LastError = err.Error()
+		s.removeTaskFromStorage(task)
+		return
+	}
+	s.persistTask(task)
+}
+
+// createOverlayClient creates an HTTP client that connects to the overlay's
+// socket, named pipe or loopback port.
 func (s *Scheduler) createOverlayClient(socketPath string) *http.Client {
 	return &http.Client{
-		Timeout: s.config.DeliveryTimeout,
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-				var d net.Dialer
-				return d.DialContext(ctx, "unix", socketPath)
-			},
-		},
+		Timeout:   s.config.DeliveryTimeout,
+		Transport: endpoint.Transport(socketPath),
 	}
 }
 
@@ -321,40 +452,78 @@ func (s *Scheduler) removeTaskFromStorage(task *ScheduledTask) {
 
 // Schedule adds a new task to the scheduler.
 func (s *Scheduler) Schedule(sessionCode string, duration time.Duration, message string, projectPath string) (*ScheduledTask, error) {
+	return s.schedule(sessionCode, duration, message, projectPath, false)
+}
+
+// ScheduleWhenIdle adds a task that, once due, is held while the session's
+// tool is busy, so the message doesn't interrupt it mid-answer.
+func (s *Scheduler) ScheduleWhenIdle(sessionCode string, duration time.Duration, message string, projectPath string) (*ScheduledTask, error) {
+	return s.schedule(sessionCode, duration, message, projectPath, true)
+}
+
+func (s *Scheduler) schedule(sessionCode string, duration time.Duration, message string, projectPath string, waitForIdle bool) (*ScheduledTask, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	task, err := s.newTask(sessionCode, message, projectPath, waitForIdle)
+	if err != nil {
+		return nil, err
+	}
+	task.DeliverAt = task.CreatedAt.Add(duration)
+	s.add(task)
+	return task, nil
+}
+
+// ScheduleCron adds a task delivered every time a cron expression matches,
+// in timezone (an IANA name; empty for the daemon's local time), until it is
+// cancelled.
+func (s *Scheduler) ScheduleCron(sessionCode, cron, timezone, message, projectPath string, waitForIdle bool) (*ScheduledTask, error) {
+	if cron == "" {
+		return nil, fmt.Errorf("cron expression is required")
+	}
+	task, err := s.newTask(sessionCode, message, projectPath, waitForIdle)
+	if err != nil {
+		return nil, err
+	}
+	task.Cron = cron
+	task.Timezone = timezone
+	if err := task.scheduleNext(task.CreatedAt); err != nil {
+		return nil, err
+	}
+	s.add(task)
+	return task, nil
+}
+
+// newTask validates and builds a pending task for a session.
+func (s *Scheduler) newTask(sessionCode, message, projectPath string, waitForIdle bool) (*ScheduledTask, error) {
 	if sessionCode == "" {
 		return nil, fmt.Errorf("session code is required")
 	}
 	if message == "" {
 		return nil, fmt.Errorf("message is required")
 	}
-	if duration <= 0 {
-		return nil, fmt.Errorf("duration must be positive")
-	}
 
 	// Verify session exists
 	if _, ok := s.registry.Get(sessionCode); !ok {
 		return nil, fmt.Errorf("session %q not found", sessionCode)
 	}
 
-	taskID := fmt.Sprintf("task-%d", s.nextTaskID.Add(1))
-	now := time.Now()
-
-	task := &ScheduledTask{
-		ID:          taskID,
+	return &ScheduledTask{
+		ID:          fmt.Sprintf("task-%d", s.nextTaskID.Add(1)),
 		SessionCode: sessionCode,
 		Message:     message,
-		DeliverAt:   now.Add(duration),
-		CreatedAt:   now,
+		CreatedAt:   time.Now(),
 		ProjectPath: projectPath,
 		Status:      TaskStatusPending,
-		Attempts:    0,
-	}
+		WaitForIdle: waitForIdle,
+	}, nil
+}
 
+// add stores and persists a new task.
+func (s *Scheduler) add(task *ScheduledTask) {
 	s.tasks.Store(task.ID, task)
 	s.totalScheduled.Add(1)
 	s.persistTask(task)
-
-	return task, nil
 }
 
 // Cancel cancels a scheduled task.
@@ -365,7 +534,7 @@ func (s *Scheduler) Cancel(taskID string) error {
 	}
 
 	task := val.(*ScheduledTask)
-	if task.Status != TaskStatusPending {
+	if task.Status != TaskStatusPending && task.Status != TaskStatusPaused {
 		return fmt.Errorf("task %q is not pending (status: %s)", taskID, task.Status)
 	}
 
@@ -376,6 +545,44 @@ func (s *Scheduler) Cancel(taskID string) error {
 	return nil
 }
 
+// Pause stops a pending task from being delivered until it is resumed.
+func (s *Scheduler) Pause(taskID string) (*ScheduledTask, error) {
+	task, ok := s.GetTask(taskID)
+	if !ok {
+		return nil, fmt.Errorf("task %q not found", taskID)
+	}
+	if task.Status != TaskStatusPending {
+		return nil, fmt.Errorf("task %q is not pending (status: %s)", taskID, task.Status)
+	}
+
+	task.Status = TaskStatusPaused
+	s.persistTask(task)
+	return task, nil
+}
+
+// Resume makes a paused task pending again. A recurring task continues at
+// its next time from now, skipping the runs missed while paused; a one-shot
+// task past its time is delivered right away.
+func (s *Scheduler) Resume(taskID string) (*ScheduledTask, error) {
+	task, ok := s.GetTask(taskID)
+	if !ok {
+		return nil, fmt.Errorf("task %q not found", taskID)
+	}
+	if task.Status != TaskStatusPaused {
+		return nil, fmt.Errorf("task %q is not paused (status: %s)", taskID, task.Status)
+	}
+
+	if task.recurring() {
+		if err := task.scheduleNext(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	task.Attempts = 0
+	task.Status = TaskStatusPending
+	s.persistTask(task)
+	return task, nil
+}
+
 // GetTask retrieves a task by ID.
 func (s *Scheduler) GetTask(taskID string) (*ScheduledTask, bool) {
 	val, ok := s.tasks.Load(taskID)
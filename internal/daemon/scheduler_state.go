@@ -29,6 +29,9 @@ type SchedulerStateManager struct {
 
 	// Cache of known project directories with tasks
 	knownProjects sync.Map // map[string]bool
+
+	// Daemon state recording the known projects across restarts (may be nil)
+	index *StateManager
 }
 
 // NewSchedulerStateManager creates a new scheduler state manager.
@@ -36,6 +39,22 @@ func NewSchedulerStateManager() *SchedulerStateManager {
 	return &SchedulerStateManager{}
 }
 
+// SetProjectIndex keeps the list of projects with tasks in the daemon's
+// state, and registers the projects already listed there so their tasks are
+// loaded on startup.
+func (m *SchedulerStateManager) SetProjectIndex(index *StateManager) {
+	m.mu.Lock()
+	m.index = index
+	m.mu.Unlock()
+
+	if index == nil {
+		return
+	}
+	for _, projectPath := range index.GetTaskProjects() {
+		m.RegisterProject(projectPath)
+	}
+}
+
 // getStatePath returns the path to the state file for a project.
 func (m *SchedulerStateManager) getStatePath(projectPath string) string {
 	return filepath.Join(projectPath, SchedulerStateDir, SchedulerStateFile)
@@ -87,6 +106,9 @@ func (m *SchedulerStateManager) SaveTask(task *ScheduledTask) error {
 
 	// Track this project
 	m.knownProjects.Store(task.ProjectPath, true)
+	if m.index != nil {
+		m.index.AddTaskProject(task.ProjectPath)
+	}
 
 	return nil
 }
@@ -122,6 +144,9 @@ func (m *SchedulerStateManager) RemoveTask(taskID string, projectPath string) er
 	// Save state (or remove file if empty)
 	if len(state.Tasks) == 0 {
 		os.Remove(statePath)
+		if m.index != nil {
+			m.index.RemoveTaskProject(projectPath)
+		}
 		return nil
 	}
 
@@ -239,5 +264,8 @@ func (m *SchedulerStateManager) ClearProject(projectPath string) error {
 	}
 
 	m.knownProjects.Delete(projectPath)
+	if m.index != nil {
+		m.index.RemoveTaskProject(projectPath)
+	}
 	return nil
 }
@@ -368,3 +368,28 @@ func TestSchedulerStateManager_RemoveLastTask(t *testing.T) {
 		t.Error("State file should be removed when last task is deleted")
 	}
 }
+
+func TestSchedulerStateManager_ProjectIndex(t *testing.T) {
+	projectDir := t.TempDir()
+	index := NewStateManager(StateManagerConfig{StatePath: filepath.Join(t.TempDir(), "state.json")})
+
+	sm := NewSchedulerStateManager()
+	sm.SetProjectIndex(index)
+	task := &ScheduledTask{ID: "task-1", ProjectPath: projectDir, Status: TaskStatusPending}
+	if err := sm.SaveTask(task); err != nil {
+		t.Fatalf("SaveTask failed: %v", err)
+	}
+
+	projects := index.GetTaskProjects()
+	if len(projects) != 1 || projects[0] != projectDir {
+		t.Errorf("Expected the project in the index, got %v", projects)
+	}
+
+	// Removing the last task drops the project from the index
+	if err := sm.RemoveTask(task.ID, projectDir); err != nil {
+		t.Fatalf("RemoveTask failed: %v", err)
+	}
+	if projects := index.GetTaskProjects(); len(projects) != 0 {
+		t.Errorf("Expected an empty index, got %v", projects)
+	}
+}
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -345,3 +346,185 @@ func TestScheduler_Start_AlreadyStarted(t *testing.T) {
 		t.Error("Second Start() should return error for already started scheduler")
 	}
 }
+
+func TestScheduler_ScheduleWhenIdle(t *testing.T) {
+	scheduler, registry, cleanup := setupSchedulerTest(t)
+	defer cleanup()
+
+	task, err := scheduler.ScheduleWhenIdle("test-session", time.Minute, "Run the tests", "/project")
+	if err != nil {
+		t.Fatalf("ScheduleWhenIdle() error = %v", err)
+	}
+	if !task.WaitForIdle || task.ToJSON()["wait_for_idle"] != true {
+		t.Error("ScheduleWhenIdle() should mark the task wait_for_idle")
+	}
+
+	due := task.DeliverAt.Add(time.Second)
+	if scheduler.heldForIdle(task, due) {
+		t.Error("A session with unknown activity should not hold the task")
+	}
+
+	registry.SetActivity("test-session", true)
+	if !scheduler.heldForIdle(task, due) {
+		t.Error("A busy session should hold the task")
+	}
+	if scheduler.heldForIdle(task, task.DeliverAt.Add(maxIdleWait+time.Second)) {
+		t.Error("The task should be delivered anyway after maxIdleWait")
+	}
+
+	registry.SetActivity("test-session", false)
+	if scheduler.heldForIdle(task, due) {
+		t.Error("An idle session should not hold the task")
+	}
+
+	plain, _ := scheduler.Schedule("test-session", time.Minute, "Now", "/project")
+	registry.SetActivity("test-session", true)
+	if scheduler.heldForIdle(plain, plain.DeliverAt.Add(time.Second)) {
+		t.Error("A plain task should not wait for idle")
+	}
+}
+
+func TestScheduler_ScheduleCron(t *testing.T) {
+	scheduler, _, cleanup := setupSchedulerTest(t)
+	defer cleanup()
+
+	task, err := scheduler.ScheduleCron("test-session", "*/30 * * * *", "UTC", "Commit your work", "/project", true)
+	if err != nil {
+		t.Fatalf("ScheduleCron() error = %v", err)
+	}
+	if task.Cron != "*/30 * * * *" || task.Timezone != "UTC" || !task.WaitForIdle {
+		t.Errorf("Unexpected task: %+v", task)
+	}
+	if m := task.DeliverAt.Minute(); (m != 0 && m != 30) || !task.DeliverAt.After(time.Now()) {
+		t.Errorf("DeliverAt = %v, want the next half hour", task.DeliverAt)
+	}
+
+	if _, err := scheduler.ScheduleCron("test-session", "not a cron", "", "x", "/project", false); err == nil {
+		t.Error("ScheduleCron() should reject an invalid expression")
+	}
+}
+
+func TestScheduler_RecurringFailureReschedules(t *testing.T) {
+	scheduler, _, cleanup := setupSchedulerTest(t)
+	defer cleanup()
+
+	task, err := scheduler.ScheduleCron("test-session", "@hourly", "", "Commit your work", "/project", false)
+	if err != nil {
+		t.Fatalf("ScheduleCron() error = %v", err)
+	}
+	task.DeliverAt = time.Now().Add(-time.Second)
+
+	for i := 0; i < scheduler.config.MaxRetries; i++ {
+		scheduler.failAttempt(task, "overlay unreachable")
+	}
+
+	if task.Status != TaskStatusPending {
+		t.Errorf("Status = %v, want a recurring task to stay pending", task.Status)
+	}
+	if task.RunCount != 1 || len(task.Runs) != 1 || task.Runs[0].Status != TaskStatusFailed || task.Runs[0].Error != "overlay unreachable" {
+		t.Errorf("Unexpected run history: %d %+v", task.RunCount, task.Runs)
+	}
+	if !task.DeliverAt.After(time.Now()) || task.Attempts != 0 {
+		t.Errorf("Expected the next hour with attempts reset, got %v after %d attempts", task.DeliverAt, task.Attempts)
+	}
+	if _, ok := scheduler.GetTask(task.ID); !ok {
+		t.Error("Recurring task should be kept")
+	}
+}
+
+func TestScheduler_PauseResume(t *testing.T) {
+	scheduler, _, cleanup := setupSchedulerTest(t)
+	defer cleanup()
+
+	task, _ := scheduler.ScheduleCron("test-session", "@hourly", "", "Commit your work", "/project", false)
+	if _, err := scheduler.Pause(task.ID); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if task.Status != TaskStatusPaused {
+		t.Errorf("Status = %v, want paused", task.Status)
+	}
+	if _, err := scheduler.Pause(task.ID); err == nil {
+		t.Error("Pause() of a paused task should fail")
+	}
+	if len(scheduler.ListPendingTasks("", true)) != 0 {
+		t.Error("A paused task should not be pending")
+	}
+
+	task.DeliverAt = time.Now().Add(-2 * time.Hour)
+	if _, err := scheduler.Resume(task.ID); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if task.Status != TaskStatusPending || !task.DeliverAt.After(time.Now()) {
+		t.Errorf("Expected pending at the next hour, got %v at %v", task.Status, task.DeliverAt)
+	}
+	if _, err := scheduler.Resume(task.ID); err == nil {
+		t.Error("Resume() of a pending task should fail")
+	}
+
+	// Paused tasks can be cancelled
+	_, _ = scheduler.Pause(task.ID)
+	if err := scheduler.Cancel(task.ID); err != nil {
+		t.Errorf("Cancel() of a paused task error = %v", err)
+	}
+}
+
+func TestScheduler_RestoresTasksAfterRestart(t *testing.T) {
+	projectDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	stateMgr := NewStateManager(StateManagerConfig{StatePath: statePath})
+	schedState := NewSchedulerStateManager()
+	schedState.SetProjectIndex(stateMgr)
+
+	registry := NewSessionRegistry(60 * time.Second)
+	_ = registry.Register(&Session{Code: "test-session", ProjectPath: projectDir, Status: SessionStatusActive, LastSeen: time.Now()})
+	first := NewScheduler(DefaultSchedulerConfig(), registry, schedState)
+
+	due, err := first.Schedule("test-session", time.Hour, "Check the build", projectDir)
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	later, _ := first.Schedule("test-session", 2*time.Hour, "Run the tests", projectDir)
+
+	// The first task comes due while the daemon is down
+	due.DeliverAt = time.Now().Add(-time.Minute).Truncate(time.Second)
+	first.persistTask(due)
+
+	// A restarted daemon starts from the state file alone
+	restarted := NewSchedulerStateManager()
+	restarted.SetProjectIndex(NewStateManager(StateManagerConfig{StatePath: statePath, AutoLoad: true}))
+	second := NewScheduler(DefaultSchedulerConfig(), NewSessionRegistry(60*time.Second), restarted)
+	if err := second.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer second.Stop()
+
+	got, ok := second.GetTask(due.ID)
+	if !ok {
+		t.Fatalf("Task %s was not restored", due.ID)
+	}
+	if !got.Overdue || !got.DeliverAt.Equal(due.DeliverAt) {
+		t.Errorf("Expected overdue at the original time %v, got overdue=%v at %v", due.DeliverAt, got.Overdue, got.DeliverAt)
+	}
+	if got, ok := second.GetTask(later.ID); !ok || got.Overdue || !got.DeliverAt.Equal(later.DeliverAt) {
+		t.Errorf("Expected the future task unchanged, got %+v", got)
+	}
+
+	// The session has not registered again yet, so delivery waits for it
+	if !second.heldForSession(got, time.Now()) {
+		t.Error("Expected a restored task to wait for its session to reconnect")
+	}
+
+	// New IDs don't collide with the restored tasks
+	if _, err := second.Schedule("test-session", time.Hour, "x", projectDir); err == nil {
+		t.Fatal("Schedule() should need a registered session")
+	}
+	_ = second.registry.Register(&Session{Code: "test-session", Status: SessionStatusActive, LastSeen: time.Now()})
+	next, err := second.Schedule("test-session", time.Hour, "x", projectDir)
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if next.ID == due.ID || next.ID == later.ID {
+		t.Errorf("New task reused restored ID %s", next.ID)
+	}
+}
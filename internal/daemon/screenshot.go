@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// hubHandleScreenshot handles the SCREENSHOT command.
+func (d *Daemon) hubHandleScreenshot(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "SCREENSHOT %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbCapture:
+		return d.hubHandleScreenshotCapture(ctx, conn, cmd)
+	case protocol.SubVerbList:
+		return d.hubHandleScreenshotList(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "screenshot", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown SCREENSHOT sub-command",
+			Command:      protocol.VerbScreenshot,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbCapture, protocol.SubVerbList},
+		})
+	}
+}
+
+// hubHandleScreenshotCapture handles SCREENSHOT CAPTURE <proxy_id> [session_id].
+func (d *Daemon) hubHandleScreenshotCapture(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SCREENSHOT CAPTURE requires: <proxy_id> [session_id]")
+	}
+
+	proxyID := cmd.Args[0]
+	sessionID := ""
+	if len(cmd.Args) > 1 {
+		sessionID = cmd.Args[1]
+	}
+
+	var opts proxy.ScreenshotOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+	if sessionID != "" {
+		if _, ok := p.PageTracker().GetSession(sessionID); !ok {
+			return conn.WriteErr(hubproto.ErrNotFound, "session not found")
+		}
+	}
+
+	shot, err := p.CaptureScreenshot(ctx, sessionID, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(shot)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleScreenshotList handles SCREENSHOT LIST <proxy_id>.
+func (d *Daemon) hubHandleScreenshotList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SCREENSHOT LIST requires: <proxy_id>")
+	}
+
+	proxyID := cmd.Args[0]
+
+	p, err := d.getSessionScopedProxy(conn, proxyID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProxy, proxyID, err)
+	}
+
+	files, err := p.Screenshots()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"proxy_id":    proxyID,
+		"screenshots": files,
+		"count":       len(files),
+	})
+	return conn.WriteJSON(data)
+}
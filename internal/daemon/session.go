@@ -10,6 +10,9 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
 )
 
 // SessionStatus represents the current state of a session.
@@ -25,7 +28,7 @@ const (
 // Session represents an active agnt run instance.
 type Session struct {
 	Code        string        `json:"code"`         // Unique session identifier (e.g., "claude-1", "dev")
-	OverlayPath string        `json:"overlay_path"` // Unix socket path for overlay
+	OverlayPath string        `json:"overlay_path"` // Overlay endpoint: socket path, named pipe or tcp://127.0.0.1:port
 	ProjectPath string        `json:"project_path"` // Directory where session was started
 	Command     string        `json:"command"`      // Command being run (e.g., "claude")
 	Args        []string      `json:"args"`         // Command arguments
@@ -33,8 +36,15 @@ type Session struct {
 	Status      SessionStatus `json:"status"`       // Current status
 	LastSeen    time.Time     `json:"last_seen"`    // Last heartbeat timestamp
 
+	// Tags tell parallel agents of a project apart, e.g. role=frontend or
+	// ticket=ABC-123
+	Tags        protocol.Labels `json:"tags,omitempty"`
+	Description string          `json:"description,omitempty"`
+
 	// Internal fields (not serialized)
-	mu sync.RWMutex
+	mu        sync.RWMutex
+	activity  sessionActivity      // Busy/idle state from OVERLAY ACTIVITY
+	clipboard proxy.ClipboardEntry // Last text moved to or from its pages
 }
 
 // UpdateLastSeen updates the last seen timestamp and sets status to active.
@@ -64,11 +74,60 @@ func (s *Session) IsActive() bool {
 	return s.GetStatus() == SessionStatusActive
 }
 
+// SetTags sets and removes tags, and replaces the description when one is
+// given. It returns the resulting tags.
+func (s *Session) SetTags(set protocol.Labels, remove []string, description *string) (protocol.Labels, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags := make(protocol.Labels, len(s.Tags)+len(set))
+	for k, v := range s.Tags {
+		tags[k] = v
+	}
+	for k, v := range set {
+		tags[k] = v
+	}
+	for _, k := range remove {
+		delete(tags, k)
+	}
+	if err := tags.Validate(); err != nil {
+		return nil, err
+	}
+	if description != nil {
+		if len(*description) > maxSessionDescription {
+			return nil, fmt.Errorf("description over %d characters", maxSessionDescription)
+		}
+		s.Description = *description
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	s.Tags = tags
+	return tags, nil
+}
+
+// HasTags reports whether the session has every tag of selector; an empty
+// selector value matches any value of the key.
+func (s *Session) HasTags(selector protocol.Labels) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Tags.Matches(selector)
+}
+
+// maxSessionDescription caps the length of a session description.
+const maxSessionDescription = 1024
+
 // ToJSON returns the session as a JSON-serializable map.
 func (s *Session) ToJSON() map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return map[string]interface{}{
+	activity := s.activity.state
+	if activity == "" {
+		activity = ActivityUnknown
+	}
+	result := map[string]interface{}{
 		"code":         s.Code,
 		"overlay_path": s.OverlayPath,
 		"project_path": s.ProjectPath,
@@ -77,7 +136,15 @@ func (s *Session) ToJSON() map[string]interface{} {
 		"started_at":   s.StartedAt.Format(time.RFC3339),
 		"status":       string(s.Status),
 		"last_seen":    s.LastSeen.Format(time.RFC3339),
+		"activity":     string(activity),
+	}
+	if len(s.Tags) > 0 {
+		result["tags"] = s.Tags
 	}
+	if s.Description != "" {
+		result["description"] = s.Description
+	}
+	return result
 }
 
 // SessionRegistry manages active sessions with lock-free operations.
@@ -91,6 +158,9 @@ type SessionRegistry struct {
 
 	// Heartbeat timeout configuration
 	heartbeatTimeout time.Duration
+
+	// Busy/idle transitions of all sessions
+	activity activityBus
 }
 
 // NewSessionRegistry creates a new session registry.
@@ -151,11 +221,17 @@ func (r *SessionRegistry) Heartbeat(code string) error {
 
 // List returns all sessions, optionally filtered by project path.
 func (r *SessionRegistry) List(projectPath string, global bool) []*Session {
+	return r.ListTagged(projectPath, global, nil)
+}
+
+// ListTagged returns the sessions with every tag of selector, optionally
+// filtered by project path.
+func (r *SessionRegistry) ListTagged(projectPath string, global bool, selector protocol.Labels) []*Session {
 	var result []*Session
 	r.sessions.Range(func(key, value interface{}) bool {
 		session := value.(*Session)
 		// Filter by project path unless global is true
-		if global || projectPath == "" || session.ProjectPath == projectPath {
+		if (global || projectPath == "" || session.ProjectPath == projectPath) && session.HasTags(selector) {
 			result = append(result, session)
 		}
 		return true
@@ -252,6 +328,12 @@ func (r *SessionRegistry) Info() SessionInfo {
 // This enables auto-attach behavior where MCP clients in subdirectories can find
 // sessions started in parent directories.
 func (r *SessionRegistry) FindByDirectory(directory string) (*Session, bool) {
+	return r.FindByDirectoryTagged(directory, nil)
+}
+
+// FindByDirectoryTagged is FindByDirectory among the sessions with every tag
+// of selector, for picking one of several agents working in a project.
+func (r *SessionRegistry) FindByDirectoryTagged(directory string, selector protocol.Labels) (*Session, bool) {
 	if directory == "" {
 		return nil, false
 	}
@@ -266,7 +348,7 @@ func (r *SessionRegistry) FindByDirectory(directory string) (*Session, bool) {
 		session := value.(*Session)
 
 		// Only consider active sessions
-		if !session.IsActive() {
+		if !session.IsActive() || !session.HasTags(selector) {
 			return true
 		}
 
@@ -333,14 +415,16 @@ func (s *Session) MarshalJSON() ([]byte, error) {
 	defer s.mu.RUnlock()
 
 	type sessionJSON struct {
-		Code        string   `json:"code"`
-		OverlayPath string   `json:"overlay_path"`
-		ProjectPath string   `json:"project_path"`
-		Command     string   `json:"command"`
-		Args        []string `json:"args"`
-		StartedAt   string   `json:"started_at"`
-		Status      string   `json:"status"`
-		LastSeen    string   `json:"last_seen"`
+		Code        string          `json:"code"`
+		OverlayPath string          `json:"overlay_path"`
+		ProjectPath string          `json:"project_path"`
+		Command     string          `json:"command"`
+		Args        []string        `json:"args"`
+		StartedAt   string          `json:"started_at"`
+		Status      string          `json:"status"`
+		LastSeen    string          `json:"last_seen"`
+		Tags        protocol.Labels `json:"tags,omitempty"`
+		Description string          `json:"description,omitempty"`
 	}
 
 	return json.Marshal(sessionJSON{
@@ -352,5 +436,7 @@ func (s *Session) MarshalJSON() ([]byte, error) {
 		StartedAt:   s.StartedAt.Format(time.RFC3339),
 		Status:      string(s.Status),
 		LastSeen:    s.LastSeen.Format(time.RFC3339),
+		Tags:        s.Tags,
+		Description: s.Description,
 	})
 }
@@ -0,0 +1,159 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+)
+
+// ActivityState is whether a session's wrapped AI tool is working.
+type ActivityState string
+
+const (
+	// ActivityUnknown means the session hasn't reported activity yet.
+	ActivityUnknown ActivityState = "unknown"
+	// ActivityBusy means the tool is generating output.
+	ActivityBusy ActivityState = "busy"
+	// ActivityIdle means the tool is waiting for input.
+	ActivityIdle ActivityState = "idle"
+)
+
+// maxActivityEvents is how many transitions a session keeps.
+const maxActivityEvents = 50
+
+// ActivityEvent is a busy/idle transition of a session.
+type ActivityEvent struct {
+	SessionCode string        `json:"session_code"`
+	State       ActivityState `json:"state"`
+	At          time.Time     `json:"at"`
+}
+
+// sessionActivity tracks the activity state of one session.
+type sessionActivity struct {
+	state  ActivityState
+	since  time.Time // When the current state began
+	events []ActivityEvent
+}
+
+// activityBus fans activity transitions out to subscribers.
+type activityBus struct {
+	mu   sync.Mutex
+	subs map[int]chan ActivityEvent
+	next int
+}
+
+// subscribe returns a channel of transitions and a function that ends the
+// subscription. Slow subscribers miss events rather than block sessions.
+func (b *activityBus) subscribe() (<-chan ActivityEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[int]chan ActivityEvent)
+	}
+	id := b.next
+	b.next++
+	ch := make(chan ActivityEvent, 16)
+	b.subs[id] = ch
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[id]; ok {
+			delete(b.subs, id)
+			close(ch)
+		}
+	}
+}
+
+func (b *activityBus) publish(event ActivityEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SetActivity records the session's tool going busy or idle. It reports
+// the transition, or false when the state didn't change.
+func (s *Session) SetActivity(busy bool, at time.Time) (ActivityEvent, bool) {
+	state := ActivityIdle
+	if busy {
+		state = ActivityBusy
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activity.state == state {
+		return ActivityEvent{}, false
+	}
+	event := ActivityEvent{SessionCode: s.Code, State: state, At: at}
+	s.activity.state = state
+	s.activity.since = at
+	s.activity.events = append(s.activity.events, event)
+	if len(s.activity.events) > maxActivityEvents {
+		s.activity.events = s.activity.events[len(s.activity.events)-maxActivityEvents:]
+	}
+	return event, true
+}
+
+// Activity returns the session's activity state and when it began.
+func (s *Session) Activity() (ActivityState, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.activity.state == "" {
+		return ActivityUnknown, time.Time{}
+	}
+	return s.activity.state, s.activity.since
+}
+
+// IsBusy reports whether the session's tool is known to be working.
+func (s *Session) IsBusy() bool {
+	state, _ := s.Activity()
+	return state == ActivityBusy
+}
+
+// ActivityStatus describes the session's activity for SESSION STATUS.
+func (s *Session) ActivityStatus(now time.Time) map[string]interface{} {
+	state, since := s.Activity()
+	status := s.ToJSON()
+	status["activity"] = string(state)
+	if state == ActivityUnknown {
+		return status
+	}
+
+	s.mu.RLock()
+	events := append([]ActivityEvent(nil), s.activity.events...)
+	s.mu.RUnlock()
+
+	elapsed := now.Sub(since)
+	status["activity_since"] = since.Format(time.RFC3339)
+	status["events"] = events
+	if state == ActivityIdle {
+		status["idle_for"] = elapsed.Round(time.Second).String()
+		status["idle_for_ms"] = elapsed.Milliseconds()
+	} else {
+		status["busy_for"] = elapsed.Round(time.Second).String()
+		status["busy_for_ms"] = elapsed.Milliseconds()
+	}
+	return status
+}
+
+// SetActivity records a busy/idle report of a session and publishes the
+// transition to activity subscribers.
+func (r *SessionRegistry) SetActivity(code string, busy bool) bool {
+	session, ok := r.Get(code)
+	if !ok {
+		return false
+	}
+	if event, changed := session.SetActivity(busy, time.Now()); changed {
+		r.activity.publish(event)
+	}
+	return true
+}
+
+// SubscribeActivity returns a channel of busy/idle transitions of all
+// sessions and a function that ends the subscription.
+func (r *SessionRegistry) SubscribeActivity() (<-chan ActivityEvent, func()) {
+	return r.activity.subscribe()
+}
@@ -0,0 +1,209 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Send presets: how a message is typed into a wrapped tool.
+const (
+	SendPresetInstant = "instant" // One write; the tool sees a paste
+	SendPresetPaste   = "paste"   // One write wrapped in bracketed-paste markers
+	SendPresetChunked = "chunked" // Short bursts for tools that drop large writes
+	SendPresetTyped   = "typed"   // A few characters at a time, like a person typing
+)
+
+// sendPresets are the pacing of each preset.
+var sendPresets = map[string]SendOptions{
+	SendPresetInstant: {},
+	SendPresetPaste:   {BracketedPaste: boolPtr(true)},
+	SendPresetChunked: {ChunkSize: 256, ChunkDelayMs: 15},
+	SendPresetTyped:   {ChunkSize: 4, ChunkDelayMs: 30},
+}
+
+// toolSendPresets is the default preset of wrapped tools known to mishandle
+// instant writes. Other tools get SendPresetInstant.
+var toolSendPresets = map[string]string{
+	"aider":        SendPresetPaste,
+	"codex":        SendPresetChunked,
+	"copilot":      SendPresetChunked,
+	"cursor-agent": SendPresetChunked,
+	"gemini":       SendPresetChunked,
+}
+
+// maxSendDuration bounds how long pacing may stretch a message.
+const maxSendDuration = 5 * time.Minute
+
+// SendOptions controls how SESSION SEND types a message into the session's
+// tool. Fields left unset come from the preset, which defaults to the one
+// for the session's command.
+type SendOptions struct {
+	Preset         string `json:"preset,omitempty"`          // instant, paste, chunked or typed
+	ChunkSize      int    `json:"chunk_size,omitempty"`      // Characters per write; 0 writes the message at once
+	ChunkDelayMs   int    `json:"chunk_delay_ms,omitempty"`  // Pause between chunks, with some jitter
+	Enter          *bool  `json:"enter,omitempty"`           // Submit the message (default true)
+	BracketedPaste *bool  `json:"bracketed_paste,omitempty"` // Wrap in ESC[200~ ... ESC[201~
+}
+
+// overlayTypeMessage is the body of the overlay's /type endpoint.
+type overlayTypeMessage struct {
+	Text           string `json:"text"`
+	Enter          bool   `json:"enter"`
+	Instant        bool   `json:"instant"`
+	ChunkSize      int    `json:"chunk_size,omitempty"`
+	ChunkDelayMs   int    `json:"chunk_delay_ms,omitempty"`
+	BracketedPaste bool   `json:"bracketed_paste,omitempty"`
+}
+
+// parseSendArgs reads SESSION SEND key=value options: preset=, chunk=,
+// delay= (milliseconds or a Go duration), enter= and paste=.
+func parseSendArgs(args []string) (SendOptions, error) {
+	var opts SendOptions
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch strings.ToLower(key) {
+		case "preset":
+			opts.Preset = value
+		case "chunk":
+			opts.ChunkSize, err = strconv.Atoi(value)
+		case "delay":
+			opts.ChunkDelayMs, err = parseDelayMs(value)
+		case "enter":
+			var enter bool
+			enter, err = parseSwitch(value)
+			opts.Enter = &enter
+		case "paste":
+			var paste bool
+			paste, err = parseSwitch(value)
+			opts.BracketedPaste = &paste
+		default:
+			return opts, fmt.Errorf("unknown send option %q", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %q", key, value)
+		}
+	}
+	return opts, nil
+}
+
+// args encodes opts as SESSION SEND key=value options.
+func (opts SendOptions) args() []string {
+	var args []string
+	if opts.Preset != "" {
+		args = append(args, "preset="+opts.Preset)
+	}
+	if opts.ChunkSize > 0 {
+		args = append(args, "chunk="+strconv.Itoa(opts.ChunkSize))
+	}
+	if opts.ChunkDelayMs > 0 {
+		args = append(args, "delay="+strconv.Itoa(opts.ChunkDelayMs))
+	}
+	if opts.Enter != nil {
+		args = append(args, "enter="+strconv.FormatBool(*opts.Enter))
+	}
+	if opts.BracketedPaste != nil {
+		args = append(args, "paste="+strconv.FormatBool(*opts.BracketedPaste))
+	}
+	return args
+}
+
+// parseDelayMs reads milliseconds ("30") or a duration ("30ms").
+func parseDelayMs(value string) (int, error) {
+	if ms, err := strconv.Atoi(value); err == nil {
+		return ms, nil
+	}
+	d, err := time.ParseDuration(value)
+	return int(d.Milliseconds()), err
+}
+
+// parseSwitch reads on/off, true/false, yes/no or 1/0.
+func parseSwitch(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1", "":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("not a switch: %q", value)
+}
+
+// defaultSendPreset returns the preset for a wrapped command.
+func defaultSendPreset(command string) string {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(command), filepath.Ext(command)))
+	if preset, ok := toolSendPresets[name]; ok {
+		return preset
+	}
+	return SendPresetInstant
+}
+
+// sendPresetNames lists the presets for error messages.
+func sendPresetNames() []string {
+	names := make([]string, 0, len(sendPresets))
+	for name := range sendPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// typeMessage resolves opts against the preset and builds the overlay
+// request for message.
+func (s *Session) typeMessage(message string, opts SendOptions) (overlayTypeMessage, error) {
+	presetName := opts.Preset
+	if presetName == "" {
+		presetName = defaultSendPreset(s.Command)
+	}
+	preset, ok := sendPresets[strings.ToLower(presetName)]
+	if !ok {
+		return overlayTypeMessage{}, fmt.Errorf("unknown send preset %q (use %s)", presetName, strings.Join(sendPresetNames(), ", "))
+	}
+	if opts.ChunkSize < 0 || opts.ChunkDelayMs < 0 {
+		return overlayTypeMessage{}, fmt.Errorf("chunk size and delay can't be negative")
+	}
+
+	msg := overlayTypeMessage{
+		Text:         message,
+		Enter:        true,
+		ChunkSize:    preset.ChunkSize,
+		ChunkDelayMs: preset.ChunkDelayMs,
+	}
+	if preset.BracketedPaste != nil {
+		msg.BracketedPaste = *preset.BracketedPaste
+	}
+	if opts.ChunkSize > 0 {
+		msg.ChunkSize = opts.ChunkSize
+	}
+	if opts.ChunkDelayMs > 0 {
+		msg.ChunkDelayMs = opts.ChunkDelayMs
+	}
+	if opts.Enter != nil {
+		msg.Enter = *opts.Enter
+	}
+	if opts.BracketedPaste != nil {
+		msg.BracketedPaste = *opts.BracketedPaste
+	}
+	msg.Instant = msg.ChunkSize == 0
+
+	if d := msg.duration(); d > maxSendDuration {
+		return overlayTypeMessage{}, fmt.Errorf("pacing would take %s to type %d characters; use larger chunks or a shorter delay", d.Round(time.Second), len([]rune(message)))
+	}
+	return msg, nil
+}
+
+// duration estimates how long the overlay takes to type the message.
+func (m overlayTypeMessage) duration() time.Duration {
+	if m.ChunkSize == 0 {
+		return 0
+	}
+	chunks := (len([]rune(m.Text)) + m.ChunkSize - 1) / m.ChunkSize
+	return time.Duration(chunks) * time.Duration(m.ChunkDelayMs) * time.Millisecond
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSession_TypeMessage(t *testing.T) {
+	claude := &Session{Code: "claude-1", Command: "claude"}
+	msg, err := claude.typeMessage("hello", SendOptions{})
+	if err != nil {
+		t.Fatalf("typeMessage failed: %v", err)
+	}
+	if !msg.Instant || !msg.Enter || msg.ChunkSize != 0 || msg.BracketedPaste {
+		t.Errorf("Expected an instant write for claude, got %+v", msg)
+	}
+
+	gemini := &Session{Code: "gemini-1", Command: "/usr/local/bin/gemini"}
+	msg, _ = gemini.typeMessage("hello", SendOptions{})
+	if msg.Instant || msg.ChunkSize != 256 || msg.ChunkDelayMs != 15 {
+		t.Errorf("Expected the chunked preset for gemini, got %+v", msg)
+	}
+
+	opts, err := parseSendArgs([]string{"preset=paste", "chunk=64", "delay=20ms", "enter=off"})
+	if err != nil {
+		t.Fatalf("parseSendArgs failed: %v", err)
+	}
+	msg, err = claude.typeMessage("hello", opts)
+	if err != nil {
+		t.Fatalf("typeMessage failed: %v", err)
+	}
+	if !msg.BracketedPaste || msg.ChunkSize != 64 || msg.ChunkDelayMs != 20 || msg.Enter || msg.Instant {
+		t.Errorf("Expected explicit options over the preset, got %+v", msg)
+	}
+
+	// Options survive the trip through SESSION SEND args
+	parsed, err := parseSendArgs(opts.args())
+	if err != nil || !reflect.DeepEqual(parsed, opts) {
+		t.Errorf("Expected %+v after encoding, got %+v (%v)", opts, parsed, err)
+	}
+
+	for _, args := range [][]string{{"chunk=x"}, {"paste=maybe"}, {"speed=1"}} {
+		if _, err := parseSendArgs(args); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+	if _, err := claude.typeMessage("hello", SendOptions{Preset: "telepathy"}); err == nil {
+		t.Error("Expected error for an unknown preset")
+	}
+	long := make([]byte, 100000)
+	if _, err := claude.typeMessage(string(long), SendOptions{Preset: SendPresetTyped}); err == nil {
+		t.Error("Expected error for pacing that takes too long")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
 )
 
 func TestSessionRegistry_Register(t *testing.T) {
@@ -228,6 +230,49 @@ func TestSessionRegistry_ListByProject(t *testing.T) {
 	}
 }
 
+func TestSessionRegistry_Tags(t *testing.T) {
+	registry := NewSessionRegistry(60 * time.Second)
+
+	frontend := &Session{Code: "claude-1", ProjectPath: "/home/user/app", Status: SessionStatusActive}
+	backend := &Session{Code: "claude-2", ProjectPath: "/home/user/app", Status: SessionStatusActive}
+	_ = registry.Register(frontend)
+	_ = registry.Register(backend)
+
+	desc := "Checkout redesign"
+	if _, err := frontend.SetTags(protocol.Labels{"role": "frontend", "ticket": "ABC-123"}, nil, &desc); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	if _, err := backend.SetTags(protocol.Labels{"role": "backend"}, nil, nil); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+
+	sessions := registry.ListTagged("/home/user/app", false, protocol.Labels{"role": "frontend"})
+	if len(sessions) != 1 || sessions[0].Code != "claude-1" {
+		t.Errorf("ListTagged(role=frontend) = %v, want claude-1", sessions)
+	}
+	if sessions := registry.ListTagged("", true, protocol.Labels{"role": ""}); len(sessions) != 2 {
+		t.Errorf("ListTagged(role) returned %d sessions, want 2", len(sessions))
+	}
+
+	found, ok := registry.FindByDirectoryTagged("/home/user/app/web", protocol.Labels{"role": "backend"})
+	if !ok || found.Code != "claude-2" {
+		t.Errorf("FindByDirectoryTagged(role=backend) = %v, %v; want claude-2", found, ok)
+	}
+
+	tags, err := frontend.SetTags(nil, []string{"ticket"}, nil)
+	if err != nil || len(tags) != 1 || frontend.Description != desc {
+		t.Errorf("Removing ticket left tags %v, description %q (err %v)", tags, frontend.Description, err)
+	}
+	if _, err := frontend.SetTags(protocol.Labels{"bad key": "x"}, nil, nil); err == nil {
+		t.Error("Expected an error for an invalid tag key")
+	}
+
+	json := frontend.ToJSON()
+	if json["description"] != desc || json["tags"] == nil {
+		t.Errorf("ToJSON() = %v, want tags and description", json)
+	}
+}
+
 func TestSessionRegistry_ActiveCount(t *testing.T) {
 	registry := NewSessionRegistry(60 * time.Second)
 
@@ -671,3 +716,48 @@ func TestSession_MarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestSession_Activity(t *testing.T) {
+	registry := NewSessionRegistry(60 * time.Second)
+	_ = registry.Register(&Session{Code: "claude-1", Status: SessionStatusActive})
+	session, _ := registry.Get("claude-1")
+
+	if state, _ := session.Activity(); state != ActivityUnknown || session.ToJSON()["activity"] != "unknown" {
+		t.Errorf("Activity() = %v, want unknown before any report", state)
+	}
+
+	events, unsubscribe := registry.SubscribeActivity()
+	defer unsubscribe()
+
+	registry.SetActivity("claude-1", true)
+	registry.SetActivity("claude-1", true) // Repeats are not transitions
+	registry.SetActivity("claude-1", false)
+	if registry.SetActivity("missing", true) {
+		t.Error("SetActivity() should report an unknown session")
+	}
+
+	for _, want := range []ActivityState{ActivityBusy, ActivityIdle} {
+		select {
+		case event := <-events:
+			if event.State != want || event.SessionCode != "claude-1" {
+				t.Errorf("event = %+v, want %s", event, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected a %s event", want)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	default:
+	}
+
+	_, since := session.Activity()
+	status := session.ActivityStatus(since.Add(90 * time.Second))
+	if status["activity"] != "idle" || status["idle_for"] != "1m30s" || status["idle_for_ms"] != int64(90000) {
+		t.Errorf("ActivityStatus() = %v", status)
+	}
+	if events := status["events"].([]ActivityEvent); len(events) != 2 {
+		t.Errorf("ActivityStatus() events = %v, want 2 transitions", events)
+	}
+}
@@ -0,0 +1,459 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/stack"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	defaultStackTimeout = 60 * time.Second
+	// stackReadyPoll is how often a starting service is checked.
+	stackReadyPoll = 250 * time.Millisecond
+	// stackStartGrace is how long a service without a ready check must keep
+	// running to count as ready.
+	stackStartGrace = time.Second
+)
+
+// States of a stack and its services.
+const (
+	stackPending  = "pending"  // Waiting for its dependencies
+	stackStarting = "starting" // Started, not ready yet
+	stackReady    = "ready"    // Ready check passed
+	stackUp       = "up"       // All services ready
+	stackFailed   = "failed"   // Exited or not ready in time
+	stackSkipped  = "skipped"  // Not started because a dependency failed
+	stackStopped  = "stopped"
+)
+
+// stackService is the state of one service of a started stack.
+type stackService struct {
+	Name      string   `json:"name"`
+	Script    string   `json:"script"`
+	ProcessID string   `json:"process_id"`
+	Level     int      `json:"level"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	State     string   `json:"state"`
+	Process   string   `json:"process_state,omitempty"`
+	ReadyIn   string   `json:"ready_in,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// stackRun is a stack started by STACK START or autostart.
+type stackRun struct {
+	Name        string         `json:"name"`
+	ProjectPath string         `json:"project_path"`
+	State       string         `json:"state"`
+	StartedAt   time.Time      `json:"started_at"`
+	Duration    string         `json:"duration,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Services    []stackService `json:"services"`
+}
+
+// stackState tracks started stacks by project and name.
+type stackState struct {
+	mu   sync.Mutex
+	runs map[string]*stackRun
+}
+
+func stackKey(projectPath, name string) string {
+	return projectPath + "\x00" + name
+}
+
+// update applies fn to a run under the lock.
+func (s *stackState) update(run *stackRun, fn func(*stackRun)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(run)
+}
+
+// get returns a copy of a run.
+func (s *stackState) get(projectPath, name string) (stackRun, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[stackKey(projectPath, name)]
+	if !ok {
+		return stackRun{}, false
+	}
+	cp := *run
+	cp.Services = append([]stackService(nil), run.Services...)
+	return cp, true
+}
+
+// begin registers a run, unless the stack is already starting.
+func (s *stackState) begin(run *stackRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stackKey(run.ProjectPath, run.Name)
+	if old, ok := s.runs[key]; ok && old.State == stackStarting {
+		return fmt.Errorf("stack %s is already starting", run.Name)
+	}
+	if s.runs == nil {
+		s.runs = make(map[string]*stackRun)
+	}
+	s.runs[key] = run
+	return nil
+}
+
+// forgetProject drops a project's runs.
+func (s *stackState) forgetProject(projectPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, run := range s.runs {
+		if run.ProjectPath == projectPath {
+			delete(s.runs, key)
+		}
+	}
+}
+
+// loadStack returns a project's config and one of its stacks.
+func loadStack(projectPath, name string) (*config.AgntConfig, *config.StackConfig, error) {
+	cfg, err := config.LoadAgntConfig(projectPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, ok := cfg.Stacks[name]
+	if !ok || st == nil {
+		return nil, nil, fmt.Errorf("no stack %q in %s", name, config.AgntConfigFileName)
+	}
+	if len(st.Services) == 0 {
+		return nil, nil, fmt.Errorf("stack %q has no services", name)
+	}
+	return cfg, st, nil
+}
+
+// newStackRun plans a stack's services into dependency levels.
+func newStackRun(projectPath, name string, st *config.StackConfig) (*stackRun, error) {
+	deps := make(map[string][]string, len(st.Services))
+	for svcName, svc := range st.Services {
+		if svc == nil {
+			svc = &config.StackServiceConfig{}
+			st.Services[svcName] = svc
+		}
+		if svc.ReadyLog != "" {
+			if _, err := regexp.Compile(svc.ReadyLog); err != nil {
+				return nil, fmt.Errorf("service %s: invalid ready-log: %v", svcName, err)
+			}
+		}
+		deps[svcName] = svc.DependsOn
+	}
+	levels, err := stack.Plan(deps)
+	if err != nil {
+		return nil, fmt.Errorf("stack %s: %w", name, err)
+	}
+
+	run := &stackRun{Name: name, ProjectPath: projectPath, State: stackStarting, StartedAt: time.Now()}
+	for i, level := range levels {
+		for _, svcName := range level {
+			svc := st.Services[svcName]
+			script := svc.Script
+			if script == "" {
+				script = svcName
+			}
+			run.Services = append(run.Services, stackService{
+				Name:      svcName,
+				Script:    script,
+				ProcessID: makeProcessID(projectPath, script),
+				Level:     i,
+				DependsOn: svc.DependsOn,
+				State:     stackPending,
+			})
+		}
+	}
+	return run, nil
+}
+
+// startStack starts a stack level by level, each level once the previous
+// one is ready. A failed service stops the start; its dependents are
+// skipped and services already up are left running.
+func (d *Daemon) startStack(ctx context.Context, cfg *config.AgntConfig, st *config.StackConfig, run *stackRun) {
+	timeout := defaultStackTimeout
+	if st.TimeoutMs > 0 {
+		timeout = time.Duration(st.TimeoutMs) * time.Millisecond
+	}
+
+	failed := ""
+	for i := 0; i < len(run.Services) && failed == ""; {
+		level := run.Services[i].Level
+		var wg sync.WaitGroup
+		errs := make(map[int]error)
+		var errMu sync.Mutex
+		for ; i < len(run.Services) && run.Services[i].Level == level; i++ {
+			idx := i
+			svc := run.Services[idx]
+			d.stacks.update(run, func(r *stackRun) { r.Services[idx].State = stackStarting })
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				began := time.Now()
+				err := d.startStackService(ctx, cfg, st.Services[svc.Name], svc, run.ProjectPath, timeout)
+				if err != nil {
+					errMu.Lock()
+					errs[idx] = err
+					errMu.Unlock()
+				}
+				d.stacks.update(run, func(r *stackRun) {
+					if err != nil {
+						r.Services[idx].State, r.Services[idx].Error = stackFailed, err.Error()
+						return
+					}
+					r.Services[idx].State = stackReady
+					r.Services[idx].ReadyIn = formatDuration(time.Since(began))
+				})
+			}()
+		}
+		wg.Wait()
+		for idx := range run.Services {
+			if err, ok := errs[idx]; ok && failed == "" {
+				failed = fmt.Sprintf("%s: %v", run.Services[idx].Name, err)
+			}
+		}
+	}
+
+	d.stacks.update(run, func(r *stackRun) {
+		r.Duration = formatDuration(time.Since(r.StartedAt))
+		if failed == "" {
+			r.State = stackUp
+			return
+		}
+		r.State, r.Error = stackFailed, failed
+		for i := range r.Services {
+			if r.Services[i].State == stackPending {
+				r.Services[i].State = stackSkipped
+			}
+		}
+	})
+	if failed != "" {
+		log.Printf("[STACK] %s failed to start: %s", run.Name, failed)
+	}
+}
+
+// startStackService starts a service's script, unless it already runs, and
+// waits until it is ready.
+func (d *Daemon) startStackService(ctx context.Context, cfg *config.AgntConfig, svcCfg *config.StackServiceConfig, svc stackService, projectPath string, timeout time.Duration) error {
+	script, ok := cfg.Scripts[svc.Script]
+	if !ok || script == nil {
+		script = &config.ScriptConfig{} // Detected from the project, as with run
+	}
+	if proc, err := d.hub.ProcessManager().Get(svc.ProcessID); err == nil && proc.IsDone() {
+		d.hub.ProcessManager().RemoveByPath(svc.ProcessID, proc.ProjectPath)
+	}
+	if err := d.autostartScript(ctx, svc.Script, script, projectPath, cfg.Proxies); err != nil {
+		return err
+	}
+
+	// The service's ready check, else the script's
+	check, err := newReadyCheck(svcCfg.ReadyURL, svcCfg.ReadyPort, svcCfg.ReadyLog)
+	if err == nil && check.none() {
+		check, err = scriptReadyCheck(script)
+	}
+	if err != nil {
+		return err
+	}
+	return d.waitReady(ctx, svc.ProcessID, projectPath, check, timeout)
+}
+
+// stopStack stops a stack's services in reverse dependency order.
+func (d *Daemon) stopStack(ctx context.Context, run *stackRun) []string {
+	var stopped []string
+	for i := len(run.Services) - 1; i >= 0; i-- {
+		svc := run.Services[i]
+		d.supervisor.remove(svc.ProcessID)
+		proc, err := d.hub.ProcessManager().Get(svc.ProcessID)
+		if err == nil && proc.IsRunning() {
+			stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := d.hub.ProcessManager().Stop(stopCtx, svc.ProcessID); err != nil {
+				log.Printf("[STACK] error stopping %s: %v", svc.ProcessID, err)
+			} else {
+				stopped = append(stopped, svc.Name)
+			}
+			cancel()
+		}
+		d.stacks.update(run, func(r *stackRun) { r.Services[i].State = stackStopped })
+	}
+	d.stacks.update(run, func(r *stackRun) { r.State = stackStopped })
+	return stopped
+}
+
+// hubHandleStack handles the STACK command.
+func (d *Daemon) hubHandleStack(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbStart:
+		return d.hubHandleStackStart(ctx, conn, cmd)
+	case protocol.SubVerbStop:
+		return d.hubHandleStackStop(ctx, conn, cmd)
+	case protocol.SubVerbStatus:
+		return d.hubHandleStackStatus(conn, cmd)
+	case protocol.SubVerbList:
+		return d.hubHandleStackList(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown STACK sub-command",
+			Command:      protocol.VerbStack,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbStart, protocol.SubVerbStop, protocol.SubVerbStatus, protocol.SubVerbList},
+		})
+	}
+}
+
+// stackArgs returns the stack name and project of STACK <sub> <name> [path].
+func (d *Daemon) stackArgs(conn *hubpkg.Connection, cmd *hubproto.Command) (string, string, error) {
+	if len(cmd.Args) < 1 {
+		return "", "", fmt.Errorf("stack name required")
+	}
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return "", "", fmt.Errorf("STACK %s requires a session or path", cmd.SubVerb)
+	}
+	return cmd.Args[0], projectPath, nil
+}
+
+// hubHandleStackStart handles STACK START <name> [path]: start the stack
+// and wait until it is up or a service fails.
+func (d *Daemon) hubHandleStackStart(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	name, projectPath, err := d.stackArgs(conn, cmd)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrMissingParam, err.Error())
+	}
+	cfg, st, err := loadStack(projectPath, name)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+	run, err := newStackRun(projectPath, name, st)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if err := d.stacks.begin(run); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+
+	d.startStack(ctx, cfg, st, run)
+	return d.writeStackRun(conn, projectPath, name)
+}
+
+// hubHandleStackStop handles STACK STOP <name> [path].
+func (d *Daemon) hubHandleStackStop(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	name, projectPath, err := d.stackArgs(conn, cmd)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrMissingParam, err.Error())
+	}
+	d.stacks.mu.Lock()
+	run, ok := d.stacks.runs[stackKey(projectPath, name)]
+	d.stacks.mu.Unlock()
+	if !ok {
+		// Not started by STACK START, but its scripts may run anyway
+		_, st, err := loadStack(projectPath, name)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		}
+		if run, err = newStackRun(projectPath, name, st); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+	}
+
+	stopped := d.stopStack(ctx, run)
+	data, _ := json.Marshal(map[string]interface{}{"name": name, "stopped": stopped})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleStackStatus handles STACK STATUS <name> [path].
+func (d *Daemon) hubHandleStackStatus(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	name, projectPath, err := d.stackArgs(conn, cmd)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrMissingParam, err.Error())
+	}
+	if _, ok := d.stacks.get(projectPath, name); !ok {
+		_, st, err := loadStack(projectPath, name)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+		}
+		run, err := newStackRun(projectPath, name, st)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		run.State = stackStopped
+		return d.writeStackSnapshot(conn, *run)
+	}
+	return d.writeStackRun(conn, projectPath, name)
+}
+
+// writeStackRun writes a run with the current state of its processes.
+func (d *Daemon) writeStackRun(conn *hubpkg.Connection, projectPath, name string) error {
+	run, _ := d.stacks.get(projectPath, name)
+	return d.writeStackSnapshot(conn, run)
+}
+
+func (d *Daemon) writeStackSnapshot(conn *hubpkg.Connection, run stackRun) error {
+	for i := range run.Services {
+		if proc, err := d.hub.ProcessManager().Get(run.Services[i].ProcessID); err == nil {
+			run.Services[i].Process = proc.State().String()
+		}
+	}
+	data, _ := json.Marshal(run)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleStackList handles STACK LIST [path]: the project's stacks with
+// their startup order and state.
+func (d *Daemon) hubHandleStackList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "STACK LIST requires a session or path")
+	}
+	cfg, err := config.LoadAgntConfig(projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	names := make([]string, 0, len(cfg.Stacks))
+	for name := range cfg.Stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stacks := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		entry := map[string]interface{}{"name": name, "state": stackStopped}
+		st := cfg.Stacks[name]
+		if st != nil {
+			entry["autostart"] = st.Autostart
+			if run, err := newStackRun(projectPath, name, st); err != nil {
+				entry["error"] = err.Error()
+			} else {
+				var levels [][]string
+				for _, svc := range run.Services {
+					if svc.Level == len(levels) {
+						levels = append(levels, nil)
+					}
+					levels[svc.Level] = append(levels[svc.Level], svc.Name)
+				}
+				entry["order"] = levels
+			}
+		}
+		if run, ok := d.stacks.get(projectPath, name); ok {
+			entry["state"] = run.State
+		}
+		stacks = append(stacks, entry)
+	}
+	data, _ := json.Marshal(map[string]interface{}{"stacks": stacks, "count": len(stacks)})
+	return conn.WriteJSON(data)
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/config"
+)
+
+func TestNewStackRun(t *testing.T) {
+	st := &config.StackConfig{Services: map[string]*config.StackServiceConfig{
+		"db":  {ReadyPort: 5432},
+		"api": {Script: "api-dev", DependsOn: []string{"db"}},
+		"web": nil,
+	}}
+	run, err := newStackRun("/p", "dev", st)
+	if err != nil {
+		t.Fatalf("newStackRun failed: %v", err)
+	}
+	var order []string
+	for _, svc := range run.Services {
+		order = append(order, svc.Name)
+		if svc.State != stackPending {
+			t.Errorf("Expected %s pending, got %s", svc.Name, svc.State)
+		}
+	}
+	if strings.Join(order, " ") != "db web api" {
+		t.Errorf("Unexpected order %v", order)
+	}
+	api := run.Services[2]
+	if api.Level != 1 || api.Script != "api-dev" || api.ProcessID != makeProcessID("/p", "api-dev") {
+		t.Errorf("Unexpected service %+v", api)
+	}
+
+	st.Services["web"] = &config.StackServiceConfig{ReadyLog: "("}
+	if _, err := newStackRun("/p", "dev", st); err == nil || !strings.Contains(err.Error(), "ready-log") {
+		t.Errorf("Expected a ready-log error, got %v", err)
+	}
+	st.Services["web"] = &config.StackServiceConfig{DependsOn: []string{"cache"}}
+	if _, err := newStackRun("/p", "dev", st); err == nil {
+		t.Error("Expected an unknown dependency error")
+	}
+}
+
+func TestStackStateBegin(t *testing.T) {
+	var s stackState
+	run := &stackRun{Name: "dev", ProjectPath: "/p", State: stackStarting}
+	if err := s.begin(run); err != nil {
+		t.Fatalf("begin failed: %v", err)
+	}
+	if err := s.begin(&stackRun{Name: "dev", ProjectPath: "/p"}); err == nil {
+		t.Error("Expected a stack that is starting not to be started again")
+	}
+	s.update(run, func(r *stackRun) { r.State = stackUp })
+	if err := s.begin(&stackRun{Name: "dev", ProjectPath: "/p", State: stackStarting}); err != nil {
+		t.Errorf("Expected a restart once up, got %v", err)
+	}
+	s.forgetProject("/p")
+	if _, ok := s.get("/p", "dev"); ok {
+		t.Error("Expected the run forgotten")
+	}
+}
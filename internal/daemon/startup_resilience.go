@@ -342,6 +342,11 @@ func (d *Daemon) getExpectedPortForScript(
 	args []string,
 ) int {
 
+	// Declared ports come first
+	if script != nil && len(script.Ports) > 0 {
+		return script.Ports[0]
+	}
+
 	// Check if there's a linked proxy with a port
 	for _, proxyConfig := range proxyConfigs {
 		if proxyConfig.Script == scriptName {
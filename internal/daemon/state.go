@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
 )
 
 // PersistentProxyConfig stores the configuration needed to recreate a proxy.
@@ -16,7 +18,19 @@ type PersistentProxyConfig struct {
 	Port       int    `json:"port"`
 	MaxLogSize int    `json:"max_log_size"`
 	Path       string `json:"path"`
+	NoRetarget bool   `json:"no_retarget,omitempty"`
 	CreatedAt  string `json:"created_at"`
+
+	Routes         []proxy.HostRoute       `json:"routes,omitempty"`
+	PathRoutes     []proxy.PathRoute       `json:"path_routes,omitempty"`
+	Cookies        proxy.CookieRewrite     `json:"cookies,omitempty"`
+	URLRewrite     proxy.URLRewrite        `json:"url_rewrite,omitempty"`
+	Storms         proxy.StormDetection    `json:"storms,omitempty"`
+	Banner         proxy.EnvironmentBanner `json:"banner,omitempty"`
+	UI             proxy.OverlayUI         `json:"ui,omitempty"`
+	BodyCapture    proxy.BodyCapture       `json:"body_capture,omitempty"`
+	TrustedProxies []string                `json:"trusted_proxies,omitempty"`
+	PersistLogs    proxy.LogPersistence    `json:"persist_logs,omitempty"`
 }
 
 // PersistentState stores daemon state that should survive restarts.
@@ -24,6 +38,7 @@ type PersistentState struct {
 	Version         int                     `json:"version"`
 	OverlayEndpoint string                  `json:"overlay_endpoint,omitempty"`
 	Proxies         []PersistentProxyConfig `json:"proxies,omitempty"`
+	TaskProjects    []string                `json:"task_projects,omitempty"` // Projects with persisted scheduled tasks
 	UpdatedAt       string                  `json:"updated_at"`
 }
 
@@ -266,6 +281,47 @@ func (sm *StateManager) GetProxy(id string) (PersistentProxyConfig, bool) {
 	return PersistentProxyConfig{}, false
 }
 
+// AddTaskProject records a project directory holding scheduled tasks, so
+// the scheduler finds them again after a restart. Unlike proxy changes it is
+// saved right away: losing it would lose the project's tasks.
+func (sm *StateManager) AddTaskProject(projectPath string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	for _, p := range sm.state.TaskProjects {
+		if p == projectPath {
+			return
+		}
+	}
+	sm.state.TaskProjects = append(sm.state.TaskProjects, projectPath)
+	_ = sm.saveLocked() // Best-effort save, errors are non-critical
+}
+
+// RemoveTaskProject forgets a project directory that has no scheduled tasks
+// left.
+func (sm *StateManager) RemoveTaskProject(projectPath string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	for i, p := range sm.state.TaskProjects {
+		if p == projectPath {
+			sm.state.TaskProjects = append(sm.state.TaskProjects[:i], sm.state.TaskProjects[i+1:]...)
+			_ = sm.saveLocked() // Best-effort save, errors are non-critical
+			return
+		}
+	}
+}
+
+// GetTaskProjects returns the project directories holding scheduled tasks.
+func (sm *StateManager) GetTaskProjects() []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	result := make([]string, len(sm.state.TaskProjects))
+	copy(result, sm.state.TaskProjects)
+	return result
+}
+
 // Clear removes all state.
 func (sm *StateManager) Clear() error {
 	sm.mu.Lock()
@@ -308,10 +364,14 @@ func (sm *StateManager) State() PersistentState {
 	proxies := make([]PersistentProxyConfig, len(sm.state.Proxies))
 	copy(proxies, sm.state.Proxies)
 
+	taskProjects := make([]string, len(sm.state.TaskProjects))
+	copy(taskProjects, sm.state.TaskProjects)
+
 	return PersistentState{
 		Version:         sm.state.Version,
 		OverlayEndpoint: sm.state.OverlayEndpoint,
 		Proxies:         proxies,
+		TaskProjects:    taskProjects,
 		UpdatedAt:       sm.state.UpdatedAt,
 	}
 }
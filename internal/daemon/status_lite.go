@@ -0,0 +1,79 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// StatusLiteVersion is the version of the StatusLite shape. Fields are only
+// ever added; a change to existing ones bumps it.
+const StatusLiteVersion = 1
+
+// statusLiteErrorWindow is the window StatusLite counts errors over.
+const statusLiteErrorWindow = 15 * time.Minute
+
+// StatusLite is the STATUS-LITE response: counts for an editor status bar
+// or a shell prompt, cheap enough to poll every few seconds.
+type StatusLite struct {
+	Version          int    `json:"v"`
+	Path             string `json:"path"` // Project counted, or "" for all
+	ProcessesRunning int    `json:"processes_running"`
+	ProcessesFailed  int    `json:"processes_failed"`
+	ProxiesActive    int    `json:"proxies_active"`
+	Errors15m        int    `json:"errors_15m"` // Frontend errors and 5xx responses in the last 15 minutes
+	TunnelExposed    bool   `json:"tunnel_exposed"`
+}
+
+// statusLite counts the entities of a project, or of all projects when
+// projectPath is empty. It reads no logs, only counters.
+func (d *Daemon) statusLite(projectPath string) StatusLite {
+	s := StatusLite{Version: StatusLiteVersion, Path: projectPath}
+	inProject := func(path string) bool {
+		return projectPath == "" || normalizePath(path) == projectPath
+	}
+
+	for _, p := range d.hub.ProcessManager().List() {
+		if !inProject(p.ProjectPath) {
+			continue
+		}
+		switch {
+		case p.IsRunning():
+			s.ProcessesRunning++
+		case p.State() == process.StateFailed:
+			s.ProcessesFailed++
+		}
+	}
+	for _, px := range d.proxym.List() {
+		if !inProject(px.Path) {
+			continue
+		}
+		s.ProxiesActive++
+		s.Errors15m += px.Logger().RecentErrors(statusLiteErrorWindow)
+	}
+	for _, t := range d.tunnelm.List() {
+		if inProject(t.Path) && t.PublicURL != "" {
+			s.TunnelExposed = true
+			break
+		}
+	}
+	return s
+}
+
+// hubHandleStatusLite handles STATUS-LITE [path]: counts for the session's
+// project, the path's without a session, or all projects.
+func (d *Daemon) hubHandleStatusLite(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	data, err := json.Marshal(d.statusLite(d.remoteProjectPath(conn, path)))
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	return conn.WriteJSON(data)
+}
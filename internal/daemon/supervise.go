@@ -0,0 +1,356 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	defaultHealthInterval  = 5 * time.Second
+	defaultHealthTimeout   = 2 * time.Second
+	defaultHealthThreshold = 3
+	defaultMaxRestarts     = 5
+
+	// superviseExitPoll is how often exits are checked without a health check.
+	superviseExitPoll = time.Second
+	// maxRestartBackoff caps the delay before a restart, which doubles with
+	// each restart.
+	maxRestartBackoff = 30 * time.Second
+)
+
+// Health states of a supervised process.
+const (
+	healthStarting  = "starting"  // No probe has succeeded yet
+	healthHealthy   = "healthy"   // The last probe succeeded
+	healthUnhealthy = "unhealthy" // Failure threshold reached
+	healthExited    = "exited"    // Exited and not restarted
+)
+
+// supervision is the health and restart state of one process, as shown in
+// PROC STATUS.
+type supervision struct {
+	ProcessID   string                   `json:"-"`
+	ProjectPath string                   `json:"-"`
+	Config      protocol.SuperviseConfig `json:"config"`
+	State       string                   `json:"state"`
+	Failures    int                      `json:"consecutive_failures"`
+	LastCheck   *time.Time               `json:"last_check,omitempty"`
+	LastError   string                   `json:"last_error,omitempty"`
+	Restarts    int                      `json:"restarts"`
+	LastRestart string                   `json:"last_restart_reason,omitempty"`
+	GaveUp      bool                     `json:"gave_up,omitempty"` // max_restarts reached
+
+	cancel context.CancelFunc
+}
+
+// supervisor runs the health checks and restart policies of processes.
+type supervisor struct {
+	mu    sync.Mutex
+	procs map[string]*supervision
+}
+
+// status returns a copy of a process's supervision state.
+func (s *supervisor) status(processID string) (supervision, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sv, ok := s.procs[processID]
+	if !ok {
+		return supervision{}, false
+	}
+	return *sv, true
+}
+
+// remove stops supervising a process.
+func (s *supervisor) remove(processID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sv, ok := s.procs[processID]
+	if ok {
+		sv.cancel()
+		delete(s.procs, processID)
+	}
+	return ok
+}
+
+// removeProject stops supervising the processes of a project.
+func (s *supervisor) removeProject(projectPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sv := range s.procs {
+		if sv.ProjectPath == projectPath {
+			sv.cancel()
+			delete(s.procs, id)
+		}
+	}
+}
+
+// update applies fn to a process's state under the lock, unless the
+// supervision was replaced or removed.
+func (s *supervisor) update(sv *supervision, fn func(*supervision)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.procs[sv.ProcessID] != sv {
+		return false
+	}
+	fn(sv)
+	return true
+}
+
+// normalizeSuperviseConfig validates a PROC SUPERVISE payload and fills in
+// defaults.
+func normalizeSuperviseConfig(cfg protocol.SuperviseConfig) (protocol.SuperviseConfig, error) {
+	switch cfg.Restart {
+	case "":
+		cfg.Restart = protocol.RestartNever
+	case protocol.RestartNever, protocol.RestartOnFailure, protocol.RestartAlways:
+	default:
+		return cfg, fmt.Errorf("unknown restart policy %q (use never, on-failure or always)", cfg.Restart)
+	}
+	if cfg.MaxRestarts <= 0 {
+		cfg.MaxRestarts = defaultMaxRestarts
+	}
+	if hc := cfg.HealthCheck; hc != nil {
+		if hc.URL == "" && hc.Port <= 0 {
+			return cfg, fmt.Errorf("health_check needs a url or port")
+		}
+		checked := *hc
+		if checked.IntervalMs <= 0 {
+			checked.IntervalMs = int(defaultHealthInterval / time.Millisecond)
+		}
+		if checked.TimeoutMs <= 0 {
+			checked.TimeoutMs = int(defaultHealthTimeout / time.Millisecond)
+		}
+		if checked.FailureThreshold <= 0 {
+			checked.FailureThreshold = defaultHealthThreshold
+		}
+		cfg.HealthCheck = &checked
+	} else if cfg.Restart == protocol.RestartNever {
+		return cfg, fmt.Errorf("health_check or a restart policy required")
+	}
+	return cfg, nil
+}
+
+// probeHealth runs one health check.
+func probeHealth(ctx context.Context, hc *protocol.HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(hc.TimeoutMs)*time.Millisecond)
+	defer cancel()
+	if hc.URL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, hc.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return nil
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(hc.Port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// restartReason returns why a process should be restarted under a policy,
+// or "" to leave it.
+func restartReason(policy string, exited bool, exitCode int, unhealthy bool) string {
+	switch {
+	case policy == protocol.RestartNever:
+		return ""
+	case unhealthy:
+		return "unhealthy"
+	case !exited:
+		return ""
+	case exitCode != 0:
+		return fmt.Sprintf("exited with code %d", exitCode)
+	case policy == protocol.RestartAlways:
+		return "exited"
+	}
+	return ""
+}
+
+// restartBackoff is the delay before the given restart, doubling from 1s.
+func restartBackoff(restarts int) time.Duration {
+	if restarts > 5 {
+		return maxRestartBackoff
+	}
+	return min(time.Second<<restarts, maxRestartBackoff)
+}
+
+// supervise starts watching a process, replacing earlier supervision.
+func (d *Daemon) supervise(processID, projectPath string, cfg protocol.SuperviseConfig) *supervision {
+	ctx, cancel := context.WithCancel(d.ctx)
+	sv := &supervision{
+		ProcessID:   processID,
+		ProjectPath: projectPath,
+		Config:      cfg,
+		State:       healthStarting,
+		cancel:      cancel,
+	}
+	if cfg.HealthCheck == nil {
+		sv.State = ""
+	}
+
+	d.supervisor.mu.Lock()
+	if d.supervisor.procs == nil {
+		d.supervisor.procs = make(map[string]*supervision)
+	}
+	if old, ok := d.supervisor.procs[processID]; ok {
+		old.cancel()
+	}
+	d.supervisor.procs[processID] = sv
+	d.supervisor.mu.Unlock()
+
+	go d.superviseLoop(ctx, sv)
+	return sv
+}
+
+// superviseLoop probes a process and restarts it per its policy until the
+// supervision is removed or the policy gives up.
+func (d *Daemon) superviseLoop(ctx context.Context, sv *supervision) {
+	cfg := sv.Config
+	interval := superviseExitPoll
+	if cfg.HealthCheck != nil {
+		interval = time.Duration(cfg.HealthCheck.IntervalMs) * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		proc, err := d.hub.ProcessManager().Get(sv.ProcessID)
+		if err != nil {
+			d.supervisor.remove(sv.ProcessID) // Removed from the process manager
+			return
+		}
+
+		unhealthy := false
+		if cfg.HealthCheck != nil && proc.IsRunning() {
+			probeErr := probeHealth(ctx, cfg.HealthCheck)
+			if ctx.Err() != nil {
+				return
+			}
+			d.supervisor.update(sv, func(sv *supervision) {
+				now := time.Now()
+				sv.LastCheck = &now
+				if probeErr == nil {
+					sv.State, sv.Failures, sv.LastError = healthHealthy, 0, ""
+					return
+				}
+				sv.Failures++
+				sv.LastError = probeErr.Error()
+				// A process that never came up stays "starting" until it
+				// fails as often as a healthy one may
+				if sv.Failures >= cfg.HealthCheck.FailureThreshold {
+					sv.State = healthUnhealthy
+				}
+			})
+			st, _ := d.supervisor.status(sv.ProcessID)
+			unhealthy = st.State == healthUnhealthy
+		}
+
+		exited := proc.IsDone()
+		reason := restartReason(cfg.Restart, exited, proc.ExitCode(), unhealthy)
+		if reason == "" {
+			if exited {
+				d.supervisor.update(sv, func(sv *supervision) { sv.State = healthExited })
+				if cfg.Restart == protocol.RestartNever || cfg.Restart == protocol.RestartOnFailure {
+					return // Clean exit, nothing left to watch
+				}
+			}
+			continue
+		}
+
+		st, _ := d.supervisor.status(sv.ProcessID)
+		if st.Restarts >= cfg.MaxRestarts {
+			d.supervisor.update(sv, func(sv *supervision) { sv.GaveUp = true })
+			log.Printf("[SUPERVISE] %s %s; gave up after %d restarts", sv.ProcessID, reason, st.Restarts)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartBackoff(st.Restarts)):
+		}
+
+		log.Printf("[SUPERVISE] restarting %s: %s", sv.ProcessID, reason)
+		restartCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		_, _, _, err = d.restartProcess(restartCtx, proc)
+		cancel()
+		if !d.supervisor.update(sv, func(sv *supervision) {
+			sv.Restarts++
+			sv.LastRestart = reason
+			sv.Failures = 0
+			if cfg.HealthCheck != nil {
+				sv.State = healthStarting
+			}
+			if err != nil {
+				sv.LastError = fmt.Sprintf("restart failed: %v", err)
+			}
+		}) {
+			return
+		}
+	}
+}
+
+// hubHandleProcSupervise handles PROC SUPERVISE <id> with a
+// protocol.SuperviseConfig payload; "off" stops supervising the process.
+func (d *Daemon) hubHandleProcSupervise(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "process_id required")
+	}
+	processID := cmd.Args[0]
+	proc, err := d.hub.ProcessManager().Get(processID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
+	}
+
+	if hasArg(cmd.Args[1:], "off") {
+		data, _ := json.Marshal(map[string]interface{}{
+			"process_id": processID,
+			"supervised": false,
+			"removed":    d.supervisor.remove(processID),
+		})
+		return conn.WriteJSON(data)
+	}
+
+	var cfg protocol.SuperviseConfig
+	if err := decodeData(cmd, &cfg); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	cfg, err = normalizeSuperviseConfig(cfg)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+
+	sv := d.supervise(processID, proc.ProjectPath, cfg)
+	st, _ := d.supervisor.status(sv.ProcessID)
+	data, _ := json.Marshal(map[string]interface{}{
+		"process_id": processID,
+		"supervised": true,
+		"health":     st,
+	})
+	return conn.WriteJSON(data)
+}
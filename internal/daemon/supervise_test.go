@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+func TestNormalizeSuperviseConfig(t *testing.T) {
+	cfg, err := normalizeSuperviseConfig(protocol.SuperviseConfig{HealthCheck: &protocol.HealthCheck{Port: 3000}})
+	if err != nil {
+		t.Fatalf("normalizeSuperviseConfig failed: %v", err)
+	}
+	if cfg.Restart != protocol.RestartNever || cfg.MaxRestarts != defaultMaxRestarts {
+		t.Errorf("Expected policy defaults, got %+v", cfg)
+	}
+	if hc := cfg.HealthCheck; hc.IntervalMs != 5000 || hc.TimeoutMs != 2000 || hc.FailureThreshold != 3 {
+		t.Errorf("Expected health check defaults, got %+v", hc)
+	}
+
+	for _, bad := range []protocol.SuperviseConfig{
+		{},
+		{Restart: "sometimes"},
+		{HealthCheck: &protocol.HealthCheck{IntervalMs: 1000}},
+	} {
+		if _, err := normalizeSuperviseConfig(bad); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+	if _, err := normalizeSuperviseConfig(protocol.SuperviseConfig{Restart: protocol.RestartAlways}); err != nil {
+		t.Errorf("Expected a restart policy alone to be valid, got %v", err)
+	}
+}
+
+func TestRestartReason(t *testing.T) {
+	tests := []struct {
+		policy    string
+		exited    bool
+		exitCode  int
+		unhealthy bool
+		want      string
+	}{
+		{protocol.RestartNever, true, 1, false, ""},
+		{protocol.RestartNever, false, 0, true, ""},
+		{protocol.RestartOnFailure, true, 0, false, ""},
+		{protocol.RestartOnFailure, true, 2, false, "exited with code 2"},
+		{protocol.RestartOnFailure, false, 0, true, "unhealthy"},
+		{protocol.RestartOnFailure, false, 0, false, ""},
+		{protocol.RestartAlways, true, 0, false, "exited"},
+	}
+	for _, tt := range tests {
+		if got := restartReason(tt.policy, tt.exited, tt.exitCode, tt.unhealthy); got != tt.want {
+			t.Errorf("restartReason(%s, exited=%v, code=%d, unhealthy=%v) = %q, want %q",
+				tt.policy, tt.exited, tt.exitCode, tt.unhealthy, got, tt.want)
+		}
+	}
+
+	if restartBackoff(0) != time.Second || restartBackoff(3) != 8*time.Second || restartBackoff(10) != maxRestartBackoff {
+		t.Errorf("Unexpected backoff %v %v %v", restartBackoff(0), restartBackoff(3), restartBackoff(10))
+	}
+}
+
+func TestProbeHealth(t *testing.T) {
+	ctx := context.Background()
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	hc := &protocol.HealthCheck{URL: srv.URL + "/health", TimeoutMs: 1000}
+	if err := probeHealth(ctx, hc); err != nil {
+		t.Errorf("Expected healthy, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := probeHealth(ctx, hc); err == nil {
+		t.Error("Expected a 503 to fail the check")
+	}
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	if err := probeHealth(ctx, &protocol.HealthCheck{Port: port, TimeoutMs: 1000}); err != nil {
+		t.Errorf("Expected the open port healthy, got %v", err)
+	}
+	ln.Close()
+	if err := probeHealth(ctx, &protocol.HealthCheck{Port: port, TimeoutMs: 1000}); err == nil {
+		t.Error("Expected the closed port to fail the check")
+	}
+}
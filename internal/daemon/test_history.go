@@ -0,0 +1,296 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/testhistory"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// testHistoryListLimit is how many runs TEST HISTORY returns.
+const testHistoryListLimit = 20
+
+// testHistory returns the shared history for a project.
+func (d *Daemon) testHistory(projectPath string) *testhistory.History {
+	d.testHistoryMu.Lock()
+	defer d.testHistoryMu.Unlock()
+
+	h, ok := d.testHistories[projectPath]
+	if !ok {
+		h = testhistory.Open(projectPath)
+		d.testHistories[projectPath] = h
+	}
+	return h
+}
+
+// hubHandleTest handles the TEST command and its sub-verbs.
+func (d *Daemon) hubHandleTest(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "TEST %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "RECORD":
+		return d.hubHandleTestRecord(conn, cmd)
+	case "HISTORY":
+		return d.hubHandleTestHistory(conn, cmd)
+	case "RUN":
+		return d.hubHandleTestRun(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown TEST sub-command",
+			Command:      "TEST",
+			ValidActions: []string{"RECORD", "HISTORY", "RUN"},
+		})
+	}
+}
+
+// hubHandleFlaky handles the FLAKY command and its sub-verbs.
+func (d *Daemon) hubHandleFlaky(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "FLAKY %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case "LIST":
+		return d.hubHandleFlakyList(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown FLAKY sub-command",
+			Command:      "FLAKY",
+			ValidActions: []string{"LIST"},
+		})
+	}
+}
+
+// procResultsResponse is the response of PROC RESULTS.
+type procResultsResponse struct {
+	ProcessID string `json:"process_id"`
+	State     string `json:"state"`
+	ExitCode  int    `json:"exit_code"`
+	*testhistory.Report
+	Message string `json:"message,omitempty"`
+}
+
+// hubHandleProcResults handles PROC RESULTS <id>: the test results and build
+// errors in a process's output, so callers needn't search the raw output.
+// Nothing is recorded; TEST RECORD adds a run to the project's history.
+func (d *Daemon) hubHandleProcResults(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "process_id required")
+	}
+	processID := cmd.Args[0]
+	proc, err := d.hub.ProcessManager().Get(processID)
+	if err != nil {
+		return d.writeNotFound(conn, cmd, entityProcess, processID, err)
+	}
+
+	out, _ := proc.CombinedOutput()
+	resp := procResultsResponse{
+		ProcessID: proc.ID,
+		State:     proc.State().String(),
+		ExitCode:  proc.ExitCode(),
+		Report:    testhistory.Summarize(string(out)),
+	}
+	if resp.Report.Empty() {
+		resp.Message = "no test results or build errors found in output (use go test -v/-json, jest, vitest or pytest -v)"
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	return conn.WriteJSON(data)
+}
+
+// testRecordRequest is the JSON payload of TEST RECORD.
+type testRecordRequest struct {
+	Output string `json:"output"`
+	Source string `json:"source"`
+	Path   string `json:"path"`
+}
+
+// hubHandleTestRecord handles TEST RECORD [process_id].
+// Parses test results from a managed process's output (or output supplied in
+// the JSON payload) and records them with the project's git state.
+func (d *Daemon) hubHandleTestRecord(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var data testRecordRequest
+	if err := decodeData(cmd, &data); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	output := data.Output
+	source := data.Source
+
+	if len(cmd.Args) > 0 {
+		processID := cmd.Args[0]
+		proc, err := d.hub.ProcessManager().Get(processID)
+		if err != nil {
+			return d.writeNotFound(conn, cmd, entityProcess, processID, err)
+		}
+		if output == "" {
+			out, _ := proc.CombinedOutput()
+			output = string(out)
+		}
+		if source == "" {
+			source = processID
+		}
+		if projectPath == "" {
+			projectPath = proc.ProjectPath
+		}
+	}
+	if projectPath == "" && data.Path != "" {
+		projectPath = normalizePath(data.Path)
+	}
+
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "TEST RECORD requires a session, process or path")
+	}
+	if output == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "TEST RECORD requires: <process_id> or output")
+	}
+
+	results := testhistory.ParseOutput(output)
+	if len(results) == 0 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "no test results found in output (use go test -v/-json, jest, vitest or pytest -v)")
+	}
+	testhistory.AssignDirs(projectPath, results)
+
+	run := &testhistory.Run{Source: source, Results: results}
+	run.Commit, run.Dirty = testhistory.Snapshot(projectPath)
+
+	history := d.testHistory(projectPath)
+	if err := history.Append(run); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	passed, failed, skipped := run.Counts()
+	resp := map[string]interface{}{
+		"run_id":  run.ID,
+		"source":  source,
+		"commit":  run.Commit,
+		"total":   len(results),
+		"passed":  passed,
+		"failed":  failed,
+		"skipped": skipped,
+	}
+
+	// Point out failures that are already known to be flaky
+	if failed > 0 {
+		if runs, err := history.Runs(); err == nil {
+			flakyIDs := make(map[string]bool)
+			for _, f := range testhistory.DetectFlaky(runs, d.testChangeFunc(projectPath)) {
+				flakyIDs[f.ID] = true
+			}
+			var knownFlaky []string
+			for _, r := range results {
+				if r.Outcome == testhistory.OutcomeFail && flakyIDs[r.ID()] {
+					knownFlaky = append(knownFlaky, r.ID())
+				}
+			}
+			if len(knownFlaky) > 0 {
+				resp["known_flaky"] = knownFlaky
+			}
+		}
+	}
+
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// hubHandleTestHistory handles TEST HISTORY [test_filter].
+// Without a filter it summarizes recent runs; with one it returns the
+// per-run outcomes of matching tests.
+func (d *Daemon) hubHandleTestHistory(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "TEST HISTORY requires a session")
+	}
+
+	runs, err := d.testHistory(projectPath).Runs()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+	if len(runs) > testHistoryListLimit {
+		runs = runs[len(runs)-testHistoryListLimit:]
+	}
+
+	filter := ""
+	if len(cmd.Args) > 0 {
+		filter = cmd.Args[0]
+	}
+
+	entries := make([]map[string]interface{}, 0, len(runs))
+	for _, run := range runs {
+		passed, failed, skipped := run.Counts()
+		entry := map[string]interface{}{
+			"run_id":    run.ID,
+			"timestamp": run.Timestamp,
+			"source":    run.Source,
+			"commit":    shortCommit(run.Commit),
+			"dirty":     len(run.Dirty),
+			"passed":    passed,
+			"failed":    failed,
+			"skipped":   skipped,
+		}
+		if filter != "" {
+			outcomes := make(map[string]string)
+			for _, r := range run.Results {
+				if strings.Contains(r.ID(), filter) {
+					outcomes[r.ID()] = string(r.Outcome)
+				}
+			}
+			if len(outcomes) == 0 {
+				continue
+			}
+			entry["outcomes"] = outcomes
+		}
+		entries = append(entries, entry)
+	}
+
+	resp := map[string]interface{}{
+		"runs":  entries,
+		"count": len(entries),
+	}
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// hubHandleFlakyList handles FLAKY LIST.
+func (d *Daemon) hubHandleFlakyList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "FLAKY LIST requires a session")
+	}
+
+	runs, err := d.testHistory(projectPath).Runs()
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, err.Error())
+	}
+
+	flaky := testhistory.DetectFlaky(runs, d.testChangeFunc(projectPath))
+
+	resp := map[string]interface{}{
+		"flaky": flaky,
+		"count": len(flaky),
+		"runs":  len(runs),
+	}
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// testChangeFunc returns a change lookup backed by the project's git repository.
+func (d *Daemon) testChangeFunc(projectPath string) testhistory.ChangeFunc {
+	return func(a, b *testhistory.Run) ([]string, bool) {
+		return testhistory.ChangedBetween(projectPath, a, b)
+	}
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,310 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/testhistory"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	"github.com/standardbeagle/go-cli-server/process"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// defaultTestRunTimeout bounds a TEST RUN when no timeout is given.
+	defaultTestRunTimeout = 10 * time.Minute
+
+	// maxTestShards caps --shards to keep a run from flooding the machine.
+	maxTestShards = 32
+
+	// coverageDirName is the directory under .agnt holding shard and merged coverage.
+	coverageDirName = "coverage"
+)
+
+// testShard is one slice of a sharded TEST RUN.
+type testShard struct {
+	Index     int
+	ProcessID string
+	Units     []string
+	Cover     string // Cover profile (Go) or report directory (Jest/Vitest)
+	Proc      *process.ManagedProcess
+}
+
+// testRunRequest is the JSON payload of TEST RUN.
+type testRunRequest struct {
+	Shards   int    `json:"shards"`
+	Timeout  string `json:"timeout"`
+	Affected bool   `json:"affected"`
+	Base     string `json:"base"`
+	DryRun   bool   `json:"dry_run"`
+	Path     string `json:"path"`
+}
+
+// hubHandleTestRun handles TEST RUN.
+// Splits the project's test suite into shards by package or file, runs each
+// shard as a managed process, then records the combined results and merges
+// coverage. Blocks until every shard finishes or the timeout elapses.
+// With affected set, only units related to changed files are run and the
+// reasons each was selected are returned.
+func (d *Daemon) hubHandleTestRun(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var data testRunRequest
+	if err := decodeData(cmd, &data); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+
+	projectPath := d.getSessionProjectPath(conn)
+	if projectPath == "" && data.Path != "" {
+		projectPath = normalizePath(data.Path)
+	}
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "TEST RUN requires a session or path")
+	}
+
+	shardCount := data.Shards
+	if shardCount < 1 {
+		shardCount = 1
+	}
+	if shardCount > maxTestShards {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("shards must be at most %d", maxTestShards))
+	}
+
+	timeout := defaultTestRunTimeout
+	if data.Timeout != "" {
+		parsed, err := time.ParseDuration(data.Timeout)
+		if err != nil || parsed <= 0 {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid timeout %q (use e.g. '5m')", data.Timeout))
+		}
+		timeout = parsed
+	}
+
+	suite, err := testhistory.DetectSuite(projectPath)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	if len(suite.Units) == 0 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("no %s tests found in %s", suite.Runner, projectPath))
+	}
+
+	units := suite.Units
+	source := "TEST RUN"
+	var selected []testhistory.Selection
+	if data.Affected {
+		changed, err := testhistory.ChangedFiles(projectPath, data.Base)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+		}
+		selected, err = suite.Affected(projectPath, changed)
+		if err != nil {
+			return conn.WriteErr(hubproto.ErrInternal, err.Error())
+		}
+		units = make([]string, 0, len(selected))
+		for _, sel := range selected {
+			units = append(units, sel.Unit)
+		}
+		source = "TEST RUN --affected"
+
+		if len(units) == 0 || data.DryRun {
+			resp := map[string]interface{}{
+				"runner":   string(suite.Runner),
+				"changed":  changed,
+				"selected": selected,
+				"units":    len(units),
+			}
+			if len(units) == 0 {
+				resp["message"] = "no tests affected by the current changes"
+			}
+			out, _ := json.Marshal(resp)
+			return conn.WriteJSON(out)
+		}
+	}
+
+	history := d.testHistory(projectPath)
+	runs, _ := history.Runs()
+	plan := testhistory.PlanShards(units, shardCount, testhistory.UnitWeights(runs))
+
+	coverDir := filepath.Join(projectPath, testhistory.HistoryDirName, coverageDirName)
+	if err := os.MkdirAll(coverDir, 0755); err != nil {
+		return conn.WriteErr(hubproto.ErrInternal, fmt.Sprintf("failed to create coverage directory: %v", err))
+	}
+
+	started := time.Now()
+	shards := make([]*testShard, 0, len(plan))
+	for i, units := range plan {
+		shard := &testShard{
+			Index:     i + 1,
+			ProcessID: makeProcessID(projectPath, fmt.Sprintf("test-shard-%d", i+1)),
+			Units:     units,
+		}
+		if suite.Runner != testhistory.RunnerPytest {
+			shard.Cover = filepath.Join(coverDir, fmt.Sprintf("shard-%d", shard.Index))
+			if suite.Runner == testhistory.RunnerGo {
+				shard.Cover += ".out"
+			}
+			os.RemoveAll(shard.Cover)
+		}
+
+		command, args := suite.Command(units, shard.Cover)
+		proc, err := d.startFreshProcess(ctx, process.ProcessConfig{
+			ID:          shard.ProcessID,
+			ProjectPath: projectPath,
+			Command:     command,
+			Args:        args,
+		})
+		if err != nil {
+			d.stopTestShards(shards)
+			return conn.WriteErr(hubproto.ErrInvalidState, fmt.Sprintf("failed to start shard %d: %v", shard.Index, err))
+		}
+		shard.Proc = proc
+		shards = append(shards, shard)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	timedOut := false
+	for _, shard := range shards {
+		select {
+		case <-shard.Proc.Done():
+		case <-waitCtx.Done():
+			timedOut = true
+		}
+		if timedOut {
+			break
+		}
+	}
+	if timedOut {
+		d.stopTestShards(shards)
+	}
+
+	// Aggregate shard results into a single run
+	var results []testhistory.Result
+	shardEntries := make([]map[string]interface{}, 0, len(shards))
+	for _, shard := range shards {
+		out, _ := shard.Proc.CombinedOutput()
+		shardResults := testhistory.ParseOutput(string(out))
+		results = append(results, shardResults...)
+
+		run := testhistory.Run{Results: shardResults}
+		passed, failed, skipped := run.Counts()
+		entry := map[string]interface{}{
+			"index":      shard.Index,
+			"process_id": shard.ProcessID,
+			"units":      shard.Units,
+			"state":      shard.Proc.State().String(),
+			"passed":     passed,
+			"failed":     failed,
+			"skipped":    skipped,
+		}
+		if shard.Proc.IsDone() {
+			entry["exit_code"] = shard.Proc.ExitCode()
+			if start, end := shard.Proc.StartTime(), shard.Proc.EndTime(); start != nil && end != nil {
+				entry["duration"] = end.Sub(*start).Round(time.Millisecond).String()
+			}
+		}
+		shardEntries = append(shardEntries, entry)
+	}
+
+	resp := map[string]interface{}{
+		"runner":    string(suite.Runner),
+		"shards":    shardEntries,
+		"units":     len(units),
+		"duration":  time.Since(started).Round(time.Millisecond).String(),
+		"timed_out": timedOut,
+	}
+	if data.Affected {
+		resp["selected"] = selected
+	}
+
+	if len(results) > 0 {
+		testhistory.AssignDirs(projectPath, results)
+		run := &testhistory.Run{Source: source, Results: results}
+		run.Commit, run.Dirty = testhistory.Snapshot(projectPath)
+		if err := history.Append(run); err != nil {
+			log.Printf("[WARN] TEST RUN: failed to record history: %v", err)
+		} else {
+			resp["run_id"] = run.ID
+		}
+		passed, failed, skipped := run.Counts()
+		resp["total"] = len(results)
+		resp["passed"] = passed
+		resp["failed"] = failed
+		resp["skipped"] = skipped
+	}
+
+	if coverage := mergeShardCoverage(suite.Runner, shards, coverDir); coverage != nil {
+		resp["coverage"] = coverage
+	}
+
+	out, _ := json.Marshal(resp)
+	return conn.WriteJSON(out)
+}
+
+// startFreshProcess starts a one-shot process, replacing a finished process
+// left over under the same ID. Fails if that process is still running.
+func (d *Daemon) startFreshProcess(ctx context.Context, cfg process.ProcessConfig) (*process.ManagedProcess, error) {
+	pm := d.hub.ProcessManager()
+	if existing, err := pm.Get(cfg.ID); err == nil && existing != nil {
+		if !existing.IsDone() {
+			return nil, fmt.Errorf("%s is still running from a previous run", cfg.ID)
+		}
+		pm.RemoveByPath(cfg.ID, existing.ProjectPath)
+	}
+	return pm.StartCommand(ctx, cfg)
+}
+
+// stopTestShards stops shards that are still running.
+func (d *Daemon) stopTestShards(shards []*testShard) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, shard := range shards {
+		if shard.Proc != nil && !shard.Proc.IsDone() {
+			if err := d.hub.ProcessManager().Stop(ctx, shard.ProcessID); err != nil {
+				log.Printf("[WARN] TEST RUN: failed to stop %s: %v", shard.ProcessID, err)
+			}
+		}
+	}
+}
+
+// mergeShardCoverage merges per-shard coverage into a single report.
+// Returns nil when the runner produced no coverage.
+func mergeShardCoverage(runner testhistory.Runner, shards []*testShard, coverDir string) map[string]interface{} {
+	var (
+		inputs []string
+		out    string
+		merge  func([]string, string) (float64, error)
+	)
+	switch runner {
+	case testhistory.RunnerGo:
+		for _, shard := range shards {
+			inputs = append(inputs, shard.Cover)
+		}
+		out = filepath.Join(coverDir, "coverage.out")
+		merge = testhistory.MergeGoCoverProfiles
+	case testhistory.RunnerJest, testhistory.RunnerVitest:
+		for _, shard := range shards {
+			inputs = append(inputs, filepath.Join(shard.Cover, "coverage-final.json"))
+		}
+		out = filepath.Join(coverDir, "coverage-final.json")
+		merge = testhistory.MergeIstanbulCoverage
+	default:
+		return nil
+	}
+
+	pct, err := merge(inputs, out)
+	if err != nil {
+		if !errors.Is(err, testhistory.ErrNoCoverage) {
+			log.Printf("[WARN] TEST RUN: failed to merge coverage: %v", err)
+		}
+		return nil
+	}
+	return map[string]interface{}{
+		"file":    out,
+		"percent": math.Round(pct*10) / 10,
+	}
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+const (
+	// toastAnswerTTL is how long a toast with actions waits for a click.
+	toastAnswerTTL = time.Hour
+	// MaxToastWait bounds a PROXY TOAST waiting for a click.
+	MaxToastWait = 5 * time.Minute
+)
+
+// pendingToast is a toast with actions that has not been answered yet.
+type pendingToast struct {
+	title   string
+	message string
+	actions []protocol.ToastAction
+	session string                // Session a click is typed into; "" for the proxy's project
+	waiter  chan proxy.ToastClick // Set while PROXY TOAST waits for the click
+	created time.Time
+}
+
+// subject names the toast in a message to the session.
+func (t *pendingToast) subject() string {
+	if t.title != "" {
+		return t.title
+	}
+	return truncateDigest(t.message, 80)
+}
+
+// toastRegistry holds the toasts waiting for a click, by toast ID.
+type toastRegistry struct {
+	mu      sync.Mutex
+	pending map[string]*pendingToast
+	nextID  int
+}
+
+// add registers a toast and returns its ID. Toasts nobody answered within
+// toastAnswerTTL are dropped.
+func (r *toastRegistry) add(t *pendingToast) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[string]*pendingToast)
+	}
+	for id, old := range r.pending {
+		if t.created.Sub(old.created) > toastAnswerTTL {
+			delete(r.pending, id)
+		}
+	}
+	r.nextID++
+	id := fmt.Sprintf("toast-%d", r.nextID)
+	r.pending[id] = t
+	return id
+}
+
+// take removes and returns a pending toast.
+func (r *toastRegistry) take(id string) (*pendingToast, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.pending[id]
+	delete(r.pending, id)
+	return t, ok
+}
+
+// answer removes a pending toast for a click on one of its actions and
+// returns it with the action.
+func (r *toastRegistry) answer(id, callback string) (*pendingToast, protocol.ToastAction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.pending[id]
+	if !ok {
+		return nil, protocol.ToastAction{}, fmt.Errorf("toast %s is not waiting for an answer", id)
+	}
+	i := slices.IndexFunc(t.actions, func(a protocol.ToastAction) bool { return a.Callback == callback })
+	if i < 0 {
+		return nil, protocol.ToastAction{}, fmt.Errorf("toast %s has no action %q", id, callback)
+	}
+	delete(r.pending, id)
+	return t, t.actions[i], nil
+}
+
+// RelayToastAction hands a clicked toast action to the PROXY TOAST waiting
+// for it, or types it into the session the toast was sent for. The first
+// click answers a toast.
+func (b sessionBridge) RelayToastAction(px *proxy.ProxyServer, click proxy.ToastClick) error {
+	d := b.d
+	t, action, err := d.toasts.answer(click.ToastID, click.Callback)
+	if err != nil {
+		return err
+	}
+	click.Label = action.Label
+
+	if t.waiter != nil {
+		t.waiter <- click
+		return nil
+	}
+
+	var session *Session
+	var ok bool
+	if t.session != "" {
+		session, ok = d.sessionRegistry.Get(t.session)
+	} else {
+		session, ok = d.sessionRegistry.FindByDirectory(px.Path)
+	}
+	if !ok {
+		return fmt.Errorf("no active session for toast %s", click.ToastID)
+	}
+	message := fmt.Sprintf("[agnt toast] The user clicked %q on %q (callback: %s)", click.Label, t.subject(), click.Callback)
+	msg, err := session.typeMessage(message, SendOptions{})
+	if err != nil {
+		return err
+	}
+	return d.sendMessageToOverlay(session.OverlayPath, msg)
+}
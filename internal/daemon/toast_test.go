@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestRelayToastAction(t *testing.T) {
+	tmpDir := t.TempDir()
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer px.Stop(context.Background())
+	bridge := sessionBridge{d: d}
+
+	pending := &pendingToast{
+		title:   "Apply fix?",
+		actions: []protocol.ToastAction{{Label: "Yes", Callback: "apply"}, {Label: "No", Callback: "skip"}},
+		waiter:  make(chan proxy.ToastClick, 1),
+		created: time.Now(),
+	}
+	id := d.toasts.add(pending)
+
+	if err := bridge.RelayToastAction(px, proxy.ToastClick{ToastID: id, Callback: "delete-everything"}); err == nil {
+		t.Error("Expected a callback the toast doesn't offer rejected")
+	}
+
+	if err := bridge.RelayToastAction(px, proxy.ToastClick{ToastID: id, Callback: "apply", Label: "spoofed"}); err != nil {
+		t.Fatalf("RelayToastAction failed: %v", err)
+	}
+	select {
+	case click := <-pending.waiter:
+		if click.Callback != "apply" || click.Label != "Yes" {
+			t.Errorf("click = %+v", click)
+		}
+	default:
+		t.Fatal("Expected the click handed to the waiter")
+	}
+
+	// The first click answers the toast
+	if err := bridge.RelayToastAction(px, proxy.ToastClick{ToastID: id, Callback: "skip"}); err == nil {
+		t.Error("Expected a second click rejected")
+	}
+
+	// Without a waiter or a session, the click has nowhere to go
+	id = d.toasts.add(&pendingToast{message: "Retry?", actions: pending.actions, created: time.Now()})
+	if err := bridge.RelayToastAction(px, proxy.ToastClick{ToastID: id, Callback: "skip"}); err == nil {
+		t.Error("Expected an error without a session")
+	}
+
+	// Unanswered toasts expire
+	d.toasts.add(&pendingToast{actions: pending.actions, created: time.Now().Add(2 * toastAnswerTTL)})
+	if len(d.toasts.pending) != 1 {
+		t.Errorf("Expected expired toasts dropped, %d left", len(d.toasts.pending))
+	}
+}
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/topology"
+)
+
+// forwardInterval is how often forwards are reconciled with the running
+// proxies and detected URLs.
+const forwardInterval = 3 * time.Second
+
+// topologyState is the detected environment and its forwarder, if any.
+type topologyState struct {
+	env       topology.Environment
+	forwarder *topology.Forwarder
+}
+
+// TopologyInfo describes where the daemon runs and what it forwards, as
+// shown in STATUS.
+type TopologyInfo struct {
+	Environment topology.Environment `json:"environment"`
+	// Forwarding is false on the host, without an external address, or
+	// when disabled.
+	Forwarding bool               `json:"forwarding"`
+	Forwards   []topology.Forward `json:"forwards,omitempty"`
+	Projects   []ProjectTopology  `json:"projects,omitempty"`
+}
+
+// ProjectTopology notes a session project in a different environment than
+// the daemon.
+type ProjectTopology struct {
+	ProjectPath string `json:"project_path"`
+	Environment string `json:"environment"`
+}
+
+// startForwarding detects the environment and, when loopback listeners here
+// are hidden from the host, keeps forwards to them on the external address.
+func (d *Daemon) startForwarding() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		env := topology.Detect()
+		ts := &topologyState{env: env}
+		if env.Isolated() && env.ExternalAddress != "" && !d.config.DisableForwarding {
+			ts.forwarder = topology.NewForwarder(env.ExternalAddress)
+			defer ts.forwarder.Close()
+		}
+		d.topology.Store(ts)
+		if env.Isolated() {
+			log.Printf("[TOPOLOGY] running in %s (%s), external address %q, forwarding %v",
+				env.Kind, env.Detail, env.ExternalAddress, ts.forwarder != nil)
+		}
+		if ts.forwarder == nil {
+			return
+		}
+
+		ticker := time.NewTicker(forwardInterval)
+		defer ticker.Stop()
+		for {
+			added, removed := ts.forwarder.Reconcile(d.forwardWants())
+			for _, port := range added {
+				log.Printf("[TOPOLOGY] forwarding %s:%d to localhost:%d", env.ExternalAddress, port, port)
+			}
+			for _, port := range removed {
+				log.Printf("[TOPOLOGY] stopped forwarding port %d", port)
+			}
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// forwardWants returns the loopback ports of running proxies and of URLs
+// detected in running processes.
+func (d *Daemon) forwardWants() []topology.Want {
+	var wants []topology.Want
+	for _, ps := range d.proxym.List() {
+		if !ps.IsRunning() || ps.BindAddress != "127.0.0.1" {
+			continue
+		}
+		if port := loopbackPort(ps.ListenAddr); port > 0 {
+			wants = append(wants, topology.Want{Kind: "proxy", ID: ps.ID, Port: port})
+		}
+	}
+	for _, p := range d.hub.ProcessManager().List() {
+		if !p.IsRunning() {
+			continue
+		}
+		for _, raw := range d.urlTracker.GetURLs(p.ID) {
+			u, err := url.Parse(raw)
+			if err != nil {
+				continue
+			}
+			if port := loopbackPort(u.Host); port > 0 {
+				wants = append(wants, topology.Want{Kind: "url", ID: p.ID, Port: port})
+			}
+		}
+	}
+	return wants
+}
+
+// loopbackPort returns the port of a loopback host:port, or 0.
+func loopbackPort(hostport string) int {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return 0
+	}
+	if host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return 0
+		}
+	}
+	port, _ := strconv.Atoi(portStr)
+	return port
+}
+
+// topologyInfo returns the STATUS view of the topology, or nil before
+// detection finishes.
+func (d *Daemon) topologyInfo() *TopologyInfo {
+	ts := d.topology.Load()
+	if ts == nil {
+		return nil
+	}
+	info := &TopologyInfo{Environment: ts.env, Forwarding: ts.forwarder != nil}
+	if ts.forwarder != nil {
+		info.Forwards = ts.forwarder.List()
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range d.sessionRegistry.List("", true) {
+		if seen[s.ProjectPath] {
+			continue
+		}
+		seen[s.ProjectPath] = true
+		if kind := topology.ProjectEnvironment(s.ProjectPath); kind != "" && kind != ts.env.Kind {
+			info.Projects = append(info.Projects, ProjectTopology{ProjectPath: s.ProjectPath, Environment: kind})
+		}
+	}
+	sort.Slice(info.Projects, func(i, j int) bool { return info.Projects[i].ProjectPath < info.Projects[j].ProjectPath })
+	return info
+}
@@ -0,0 +1,19 @@
+package daemon
+
+import "testing"
+
+func TestLoopbackPort(t *testing.T) {
+	tests := map[string]int{
+		"127.0.0.1:3000": 3000,
+		"localhost:5173": 5173,
+		"[::1]:8080":     8080,
+		"0.0.0.0:3000":   0,
+		"10.0.0.5:3000":  0,
+		"localhost":      0,
+	}
+	for hostport, want := range tests {
+		if got := loopbackPort(hostport); got != want {
+			t.Errorf("loopbackPort(%q) = %d, want %d", hostport, got, want)
+		}
+	}
+}
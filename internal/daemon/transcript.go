@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/transcript"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// TranscriptQuery is the JSON payload of SESSION TRANSCRIPT.
+type TranscriptQuery struct {
+	Tail  int    `json:"tail,omitempty"`  // Last N matching lines (default 100, max 5000)
+	Grep  string `json:"grep,omitempty"`  // Go regular expression; (?i) for case-insensitive
+	Since string `json:"since,omitempty"` // RFC 3339 time, or a duration ago such as 12h
+	Until string `json:"until,omitempty"` // RFC 3339 time, or a duration ago
+	Raw   bool   `json:"raw,omitempty"`   // Keep terminal escape sequences
+	// ProjectPath locates the transcript of a session that is no longer
+	// registered; a registered session's own project is used otherwise.
+	ProjectPath string `json:"project_path,omitempty"`
+}
+
+// hubHandleSessionTranscript handles SESSION TRANSCRIPT command.
+// SESSION TRANSCRIPT <code> [-- <query_json>]
+// Searches the terminal output recorded by agnt run --transcript.
+func (d *Daemon) hubHandleSessionTranscript(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "SESSION TRANSCRIPT requires: <code>")
+	}
+	code := cmd.Args[0]
+
+	var req TranscriptQuery
+	if len(cmd.Data) > 0 {
+		if err := json.Unmarshal(cmd.Data, &req); err != nil {
+			return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid transcript query: %v", err))
+		}
+	}
+
+	projectPath := req.ProjectPath
+	if session, ok := d.sessionRegistry.Get(code); ok {
+		projectPath = session.ProjectPath
+	} else if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("session %q not found; pass project_path for a session that has ended", code))
+	}
+
+	q, err := req.query(time.Now())
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	res, err := transcript.Search(projectPath, code, q)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+
+	data, _ := json.Marshal(struct {
+		SessionCode string `json:"session_code"`
+		*transcript.Result
+	}{code, res})
+	return conn.WriteJSON(data)
+}
+
+// query converts the request into a transcript query.
+func (r TranscriptQuery) query(now time.Time) (transcript.Query, error) {
+	q := transcript.Query{Tail: r.Tail, Raw: r.Raw}
+	if r.Grep != "" {
+		re, err := regexp.Compile(r.Grep)
+		if err != nil {
+			return q, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+		q.Grep = re
+	}
+	var err error
+	if q.Since, err = transcript.ParseTime(r.Since, now); err != nil {
+		return q, err
+	}
+	if q.Until, err = transcript.ParseTime(r.Until, now); err != nil {
+		return q, err
+	}
+	return q, nil
+}
@@ -0,0 +1,24 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTranscriptQuery(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	q, err := TranscriptQuery{Tail: 20, Grep: "(?i)fail", Since: "12h"}.query(now)
+	if err != nil {
+		t.Fatalf("query() error = %v", err)
+	}
+	if q.Tail != 20 || q.Grep == nil || !q.Grep.MatchString("FAIL TestLogin") || !q.Since.Equal(now.Add(-12*time.Hour)) || !q.Until.IsZero() {
+		t.Errorf("Unexpected query: %+v", q)
+	}
+
+	if _, err := (TranscriptQuery{Grep: "("}).query(now); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	if _, err := (TranscriptQuery{Until: "yesterday"}).query(now); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+// uploadDir is where files dropped on the floating panel are stored,
+// relative to the project.
+const uploadDir = ".agnt/uploads"
+
+// StoreUpload saves a file dropped on a page's floating panel under the
+// page's project, next to the other agnt artifacts. The proxy then tells
+// the session where it is with a panel message.
+func (b sessionBridge) StoreUpload(px *proxy.ProxyServer, upload proxy.FileUpload) (string, error) {
+	projectPath := px.Path
+	if projectPath == "" {
+		return "", fmt.Errorf("proxy %s has no project directory", px.ID)
+	}
+	if len(upload.Data) > proxy.MaxUploadBytes {
+		return "", fmt.Errorf("file is over the %d MB upload limit", proxy.MaxUploadBytes>>20)
+	}
+
+	dir := filepath.Join(projectPath, uploadDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return writeUpload(dir, uploadFileName(upload.Name, time.Now()), upload.Data)
+}
+
+// uploadFileName prefixes a sanitized upload name with its time, so
+// uploads sort by arrival and rarely collide.
+func uploadFileName(name string, now time.Time) string {
+	name = sanitizeProfileName(filepath.Base(name))
+	if strings.Trim(name, "._") == "" {
+		name = "upload"
+	}
+	return now.Format("20060102-150405") + "-" + name
+}
+
+// writeUpload creates name in dir, adding a counter rather than replacing
+// an earlier upload of the same name.
+func writeUpload(dir, name string, data []byte) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; i <= 100; i++ {
+		path := filepath.Join(dir, name)
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", base, i, ext))
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("too many uploads named %s", name)
+}
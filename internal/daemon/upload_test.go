@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestStoreUpload(t *testing.T) {
+	tmpDir := t.TempDir()
+	d := New(DaemonConfig{SocketPath: filepath.Join(tmpDir, "daemon.sock")})
+	px, err := d.proxym.Create(context.Background(), proxy.ProxyConfig{ID: "app", TargetURL: "http://localhost:3000", ListenPort: -1, Path: tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	defer px.Stop(context.Background())
+	bridge := sessionBridge{d: d}
+
+	upload := proxy.FileUpload{Name: "design mock.png", Data: []byte("png")}
+	first, err := bridge.StoreUpload(px, upload)
+	if err != nil {
+		t.Fatalf("StoreUpload failed: %v", err)
+	}
+	if filepath.Dir(first) != filepath.Join(tmpDir, uploadDir) || !strings.HasSuffix(first, "-design_mock.png") {
+		t.Errorf("Unexpected upload path %s", first)
+	}
+	if data, err := os.ReadFile(first); err != nil || string(data) != "png" {
+		t.Errorf("Expected the file written, got %q (%v)", data, err)
+	}
+
+	// Same name in the same second keeps both
+	second, err := bridge.StoreUpload(px, upload)
+	if err != nil || second == first {
+		t.Errorf("Expected a second file, got %s (%v)", second, err)
+	}
+
+	if name := uploadFileName("..", time.Now()); !strings.HasSuffix(name, "-upload") {
+		t.Errorf("Expected a fallback name, got %s", name)
+	}
+}
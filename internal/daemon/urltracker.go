@@ -42,6 +42,10 @@ type URLTracker struct {
 
 	// onProcessFirstSeen is called when a process is first scanned (for loading config)
 	onProcessFirstSeen func(processID string)
+
+	// mapURL rewrites a detected URL before it is recorded, e.g. to the
+	// local forward of a port on a remote host
+	mapURL func(processID, url string) string
 }
 
 // URLTrackerConfig configures the URL tracker.
@@ -221,6 +225,9 @@ func (t *URLTracker) scanProcess(p *process.ManagedProcess) {
 	processID := p.ID // Save processID for callback after unlock
 
 	for _, url := range urls {
+		if t.mapURL != nil {
+			url = t.mapURL(p.ID, url)
+		}
 		if t.seenURLs[p.ID][url] {
 			continue // Already seen
 		}
@@ -0,0 +1,386 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/config"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/watch"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+const (
+	// watchPollInterval is how often watched files are scanned.
+	watchPollInterval = time.Second
+	// defaultWatchDebounce is the quiet time after the last change before
+	// a watch fires, so a save of many files runs the script once.
+	defaultWatchDebounce = 300 * time.Millisecond
+	// maxWatchesPerProject bounds the scans of one project.
+	maxWatchesPerProject = 20
+)
+
+// watchEntry is a WATCH ADD trigger: files matching Pattern re-run Script
+// and/or notify the project's sessions when they change.
+type watchEntry struct {
+	ID          string    `json:"id"`
+	ProjectPath string    `json:"project_path"`
+	Pattern     string    `json:"pattern"`
+	Script      string    `json:"script,omitempty"`
+	Notify      bool      `json:"notify"`
+	DebounceMs  int       `json:"debounce_ms"`
+	Files       int       `json:"files"`
+	Created     time.Time `json:"created"`
+	Triggers    int       `json:"triggers"`
+	LastTrigger time.Time `json:"last_trigger,omitempty"`
+	LastChanges []string  `json:"last_changes,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	snapshot   watch.Snapshot
+	pending    watch.Changes
+	lastChange time.Time
+	firing     bool
+}
+
+// watchState tracks the watches of all projects.
+type watchState struct {
+	mu      sync.Mutex
+	entries map[string]*watchEntry
+	nextID  int
+}
+
+// add registers a watch, numbering it.
+func (s *watchState) add(w *watchEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*watchEntry)
+	}
+	n := 0
+	for _, e := range s.entries {
+		if e.ProjectPath == w.ProjectPath {
+			n++
+		}
+	}
+	if n >= maxWatchesPerProject {
+		return fmt.Errorf("project already has %d watches", maxWatchesPerProject)
+	}
+	s.nextID++
+	w.ID = fmt.Sprintf("watch-%d", s.nextID)
+	s.entries[w.ID] = w
+	return nil
+}
+
+// remove drops a project's watch.
+func (s *watchState) remove(id, projectPath string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.entries[id]
+	if !ok || w.ProjectPath != projectPath {
+		return false
+	}
+	delete(s.entries, id)
+	return true
+}
+
+// forgetProject drops a project's watches.
+func (s *watchState) forgetProject(projectPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, w := range s.entries {
+		if w.ProjectPath == projectPath {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// list returns copies of a project's watches, oldest first.
+func (s *watchState) list(projectPath string) []watchEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []watchEntry
+	for _, w := range s.entries {
+		if w.ProjectPath == projectPath {
+			list = append(list, *w)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+// observe records a scan of a watch and returns a copy of it with the
+// changes to fire, if its debounce has passed since the last change and it
+// isn't firing.
+func (s *watchState) observe(id string, snap watch.Snapshot, scanErr error, now time.Time) (*watchEntry, watch.Changes, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.entries[id]
+	if !ok {
+		return nil, watch.Changes{}, false
+	}
+	if scanErr != nil && !errors.Is(scanErr, watch.ErrTooManyFiles) {
+		w.LastError = scanErr.Error()
+		return nil, watch.Changes{}, false
+	}
+	if changes := watch.Diff(w.snapshot, snap); !changes.Empty() {
+		w.pending = w.pending.Merge(changes)
+		w.lastChange = now
+	}
+	w.snapshot = snap
+	w.Files = len(snap)
+
+	debounce := time.Duration(w.DebounceMs) * time.Millisecond
+	if w.pending.Empty() || w.firing || now.Sub(w.lastChange) < debounce {
+		return nil, watch.Changes{}, false
+	}
+	changes := w.pending
+	w.pending = watch.Changes{}
+	w.firing = true
+	w.Triggers++
+	w.LastTrigger = now
+	w.LastChanges = changes.Paths()
+	w.LastError = ""
+	entry := *w
+	return &entry, changes, true
+}
+
+// fired records the outcome of a watch's trigger.
+func (s *watchState) fired(id string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.entries[id]; ok {
+		w.firing = false
+		if err != nil {
+			w.LastError = err.Error()
+		}
+	}
+}
+
+// watchTarget is what a poll scans for a watch.
+type watchTarget struct {
+	id, projectPath, pattern string
+}
+
+// targets returns what to scan for each watch.
+func (s *watchState) targets() []watchTarget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targets := make([]watchTarget, 0, len(s.entries))
+	for id, w := range s.entries {
+		targets = append(targets, watchTarget{id, w.ProjectPath, w.Pattern})
+	}
+	return targets
+}
+
+// watchLoop scans watched files until the daemon stops.
+func (d *Daemon) watchLoop() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.pollWatches(time.Now())
+		}
+	}
+}
+
+// pollWatches scans each watch and fires those whose files changed.
+func (d *Daemon) pollWatches(now time.Time) {
+	for _, t := range d.watches.targets() {
+		snap, err := watch.Scan(t.projectPath, t.pattern)
+		if w, changes, fire := d.watches.observe(t.id, snap, err, now); fire {
+			go d.fireWatch(w, changes)
+		}
+	}
+}
+
+// fireWatch re-runs a watch's script and notifies the project's sessions.
+func (d *Daemon) fireWatch(w *watchEntry, changes watch.Changes) {
+	var err error
+	if w.Script != "" {
+		err = d.rerunScript(d.ctx, w.ProjectPath, w.Script)
+		if err != nil {
+			log.Printf("[WARN] WATCH %s: %v", w.ID, err)
+		}
+	}
+	if w.Notify {
+		d.notifyWatch(w, changes, err)
+	}
+	d.watches.fired(w.ID, err)
+}
+
+// rerunScript restarts the script's process, or starts it from .agnt.kdl
+// when it isn't running.
+func (d *Daemon) rerunScript(ctx context.Context, projectPath, script string) error {
+	pm := d.hub.ProcessManager()
+	for _, id := range []string{makeProcessID(projectPath, script), script} {
+		if proc, err := pm.Get(id); err == nil && proc != nil && (id != script || proc.ProjectPath == projectPath) {
+			_, _, _, err := d.restartProcess(ctx, proc)
+			return err
+		}
+	}
+	cfg, err := config.LoadAgntConfig(projectPath)
+	if err != nil || cfg.Scripts[script] == nil {
+		return fmt.Errorf("%s is not running and not a script in %s", script, config.AgntConfigFileName)
+	}
+	return d.autostartScript(ctx, script, cfg.Scripts[script], projectPath, cfg.Proxies)
+}
+
+// notifyWatch shows a toast in the project's proxied pages and tells the
+// project's active sessions which files changed.
+func (d *Daemon) notifyWatch(w *watchEntry, changes watch.Changes, rerunErr error) {
+	paths := changes.Paths()
+	if len(paths) > 5 {
+		paths = append(paths[:5], fmt.Sprintf("and %d more", len(paths)-5))
+	}
+	message := fmt.Sprintf("Files changed (%s): %s", w.Pattern, strings.Join(paths, ", "))
+	switch {
+	case rerunErr != nil:
+		message += fmt.Sprintf(". Re-running %s failed: %v", w.Script, rerunErr)
+	case w.Script != "":
+		message += fmt.Sprintf(". Restarted %s.", w.Script)
+	}
+
+	projectPath := normalizePath(w.ProjectPath)
+	for _, px := range d.proxym.List() {
+		if normalizePath(px.Path) == projectPath {
+			px.BroadcastToast("info", "Files changed", message, 0)
+		}
+	}
+	for _, session := range d.sessionRegistry.ListActive(w.ProjectPath, false) {
+		if session.ProjectPath != w.ProjectPath {
+			continue
+		}
+		msg, err := session.typeMessage("[agnt watch] "+message, SendOptions{})
+		if err == nil {
+			err = d.sendMessageToOverlay(session.OverlayPath, msg)
+		}
+		if err != nil {
+			log.Printf("[WARN] WATCH %s: failed to notify session %s: %v", w.ID, session.Code, err)
+		}
+	}
+}
+
+// hubHandleWatch handles the WATCH command.
+func (d *Daemon) hubHandleWatch(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	switch cmd.SubVerb {
+	case protocol.SubVerbAdd:
+		return d.hubHandleWatchAdd(conn, cmd)
+	case protocol.SubVerbList:
+		return d.hubHandleWatchList(conn, cmd)
+	case protocol.SubVerbRemove:
+		return d.hubHandleWatchRemove(conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidAction,
+			Message:      "unknown WATCH sub-command",
+			Command:      protocol.VerbWatch,
+			Action:       cmd.SubVerb,
+			ValidActions: []string{protocol.SubVerbAdd, protocol.SubVerbList, protocol.SubVerbRemove},
+		})
+	}
+}
+
+// hubHandleWatchAdd handles WATCH ADD <glob> [script|-] [path] with an
+// optional protocol.WatchConfig payload.
+func (d *Daemon) hubHandleWatchAdd(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "WATCH ADD requires: <glob> [script|-] [path]")
+	}
+	pattern := cmd.Args[0]
+	if err := watch.ValidatePattern(pattern); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("invalid glob %q: %v", pattern, err))
+	}
+	script := ""
+	if len(cmd.Args) > 1 && cmd.Args[1] != "-" {
+		script = cmd.Args[1]
+	}
+	path := ""
+	if len(cmd.Args) > 2 {
+		path = cmd.Args[2]
+	}
+	var cfg protocol.WatchConfig
+	if err := decodeData(cmd, &cfg); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	projectPath := d.remoteProjectPath(conn, path)
+	if projectPath == "" {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "WATCH ADD requires a session or path")
+	}
+
+	w := &watchEntry{
+		ProjectPath: projectPath,
+		Pattern:     pattern,
+		Script:      script,
+		Notify:      script == "",
+		DebounceMs:  cfg.DebounceMs,
+		Created:     time.Now(),
+	}
+	if cfg.Notify != nil {
+		w.Notify = *cfg.Notify
+	}
+	if w.Script == "" && !w.Notify {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, "a watch without a script must notify")
+	}
+	if w.DebounceMs <= 0 {
+		w.DebounceMs = int(defaultWatchDebounce / time.Millisecond)
+	}
+	snap, err := watch.Scan(projectPath, pattern)
+	if err != nil && !errors.Is(err, watch.ErrTooManyFiles) {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, fmt.Sprintf("cannot scan %s: %v", projectPath, err))
+	}
+	if err != nil {
+		w.LastError = fmt.Sprintf("%v: only the first %d are watched", err, watch.MaxFiles)
+	}
+	w.snapshot, w.Files = snap, len(snap)
+	if err := d.watches.add(w); err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidState, err.Error())
+	}
+
+	data, _ := json.Marshal(w)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleWatchList handles WATCH LIST [path].
+func (d *Daemon) hubHandleWatchList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	path := ""
+	if len(cmd.Args) > 0 {
+		path = cmd.Args[0]
+	}
+	watches := d.watches.list(d.remoteProjectPath(conn, path))
+	if watches == nil {
+		watches = []watchEntry{}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"watches": watches,
+		"count":   len(watches),
+	})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleWatchRemove handles WATCH REMOVE <id> [path].
+func (d *Daemon) hubHandleWatchRemove(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "watch id required")
+	}
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+	if !d.watches.remove(cmd.Args[0], d.remoteProjectPath(conn, path)) {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("watch %q not found", cmd.Args[0]))
+	}
+	return conn.WriteOK(fmt.Sprintf("removed %s", cmd.Args[0]))
+}
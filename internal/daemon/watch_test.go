@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/watch"
+)
+
+func TestWatchStateObserve(t *testing.T) {
+	var s watchState
+	start := time.Now()
+	w := &watchEntry{ProjectPath: "/p", Pattern: "*.go", Script: "server", DebounceMs: 300, Created: start,
+		snapshot: watch.Snapshot{"main.go": {Size: 1, ModTime: start}}}
+	if err := s.add(w); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if w.ID != "watch-1" {
+		t.Errorf("Expected watch-1, got %s", w.ID)
+	}
+
+	changed := watch.Snapshot{"main.go": {Size: 2, ModTime: start}}
+	if _, _, fire := s.observe(w.ID, changed, nil, start); fire {
+		t.Error("Expected no trigger within the debounce")
+	}
+	more := watch.Snapshot{"main.go": {Size: 2, ModTime: start}, "util.go": {Size: 1, ModTime: start}}
+	if _, _, fire := s.observe(w.ID, more, nil, start.Add(200*time.Millisecond)); fire {
+		t.Error("Expected a new change to restart the debounce")
+	}
+	fired, changes, fire := s.observe(w.ID, more, nil, start.Add(600*time.Millisecond))
+	if !fire {
+		t.Fatal("Expected a trigger once changes settle")
+	}
+	if !reflect.DeepEqual(changes.Paths(), []string{"main.go", "util.go"}) || fired.Triggers != 1 {
+		t.Errorf("Unexpected trigger %+v with %+v", fired, changes)
+	}
+
+	// No second trigger while firing, even with new changes
+	again := watch.Snapshot{"main.go": {Size: 3, ModTime: start}}
+	if _, _, fire := s.observe(w.ID, again, nil, start.Add(2*time.Second)); fire {
+		t.Error("Expected no trigger while the previous one runs")
+	}
+	s.fired(w.ID, nil)
+	if _, changes, fire := s.observe(w.ID, again, nil, start.Add(3*time.Second)); !fire || !reflect.DeepEqual(changes.Removed, []string{"util.go"}) {
+		t.Errorf("Expected the changes made while firing to trigger next, got %v %+v", fire, changes)
+	}
+	s.fired(w.ID, errors.New("boom"))
+	if list := s.list("/p"); len(list) != 1 || list[0].LastError != "boom" || list[0].Triggers != 2 {
+		t.Errorf("Unexpected watches %+v", list)
+	}
+
+	if s.remove(w.ID, "/q") || !s.remove(w.ID, "/p") {
+		t.Error("Expected remove scoped to the project")
+	}
+}
+
+func TestWatchStateLimit(t *testing.T) {
+	var s watchState
+	for i := 0; i < maxWatchesPerProject; i++ {
+		if err := s.add(&watchEntry{ProjectPath: "/p", Pattern: "*"}); err != nil {
+			t.Fatalf("add %d failed: %v", i, err)
+		}
+	}
+	if err := s.add(&watchEntry{ProjectPath: "/p", Pattern: "*"}); err == nil || !strings.Contains(err.Error(), "watches") {
+		t.Errorf("Expected the per-project limit, got %v", err)
+	}
+	if err := s.add(&watchEntry{ProjectPath: "/q", Pattern: "*"}); err != nil {
+		t.Errorf("Expected other projects unaffected, got %v", err)
+	}
+	s.forgetProject("/p")
+	if len(s.list("/p")) != 0 || len(s.list("/q")) != 1 {
+		t.Error("Expected forgetProject to drop only the project's watches")
+	}
+}
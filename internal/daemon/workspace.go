@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/workspace"
+	hubpkg "github.com/standardbeagle/go-cli-server/hub"
+	hubproto "github.com/standardbeagle/go-cli-server/protocol"
+)
+
+// hubHandleWorkspace handles the WORKSPACE command and its sub-verbs.
+// Workspaces are disposable copies of a project that RUN can target by
+// their path; they are removed when their session ends.
+func (d *Daemon) hubHandleWorkspace(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	debug.Log("daemon", "WORKSPACE %s: args=%v", cmd.SubVerb, cmd.Args)
+	switch cmd.SubVerb {
+	case protocol.SubVerbCreate:
+		return d.hubHandleWorkspaceCreate(ctx, conn, cmd)
+	case protocol.SubVerbList:
+		return d.hubHandleWorkspaceList(conn, cmd)
+	case protocol.SubVerbGet:
+		return d.hubHandleWorkspaceGet(conn, cmd)
+	case protocol.SubVerbRemove:
+		return d.hubHandleWorkspaceRemove(ctx, conn, cmd)
+	default:
+		return writeStructuredErr(conn, "daemon", &hubproto.StructuredError{
+			Code:         hubproto.ErrInvalidArgs,
+			Message:      "unknown WORKSPACE sub-command",
+			Command:      protocol.VerbWorkspace,
+			ValidActions: []string{protocol.SubVerbCreate, protocol.SubVerbList, protocol.SubVerbGet, protocol.SubVerbRemove},
+		})
+	}
+}
+
+// hubHandleWorkspaceCreate handles WORKSPACE CREATE [id] with an optional
+// workspace.CreateOptions payload. The project and session default to the
+// connection's session.
+func (d *Daemon) hubHandleWorkspaceCreate(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var opts workspace.CreateOptions
+	if err := decodeData(cmd, &opts); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	if len(cmd.Args) > 0 {
+		opts.ID = cmd.Args[0]
+	}
+	if opts.SessionCode == "" {
+		opts.SessionCode = conn.SessionCode()
+	}
+	if opts.ProjectPath == "" {
+		opts.ProjectPath = d.getSessionProjectPath(conn)
+	}
+	if opts.ProjectPath == "" {
+		return conn.WriteErr(hubproto.ErrMissingParam, "path required when no session is attached")
+	}
+	opts.ProjectPath = normalizePath(opts.ProjectPath)
+
+	createCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	ws, err := d.workspaces.Create(createCtx, opts)
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrInvalidArgs, err.Error())
+	}
+	data, _ := json.Marshal(ws)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleWorkspaceList handles WORKSPACE LIST with an optional
+// {"directory":..., "global":bool} payload.
+func (d *Daemon) hubHandleWorkspaceList(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	var filter sessionFilter
+	if err := decodeData(cmd, &filter); err != nil {
+		return writePayloadErr(conn, cmd, err)
+	}
+	projectPath := ""
+	if !filter.Global {
+		projectPath = d.getSessionProjectPath(conn)
+		if filter.Directory != "" {
+			projectPath = normalizePath(filter.Directory)
+		}
+	}
+
+	list := d.workspaces.List(projectPath)
+	if list == nil {
+		list = []*workspace.Workspace{}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"workspaces": list,
+		"count":      len(list),
+	})
+	return conn.WriteJSON(data)
+}
+
+// hubHandleWorkspaceGet handles WORKSPACE GET <id>.
+func (d *Daemon) hubHandleWorkspaceGet(conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "workspace id required")
+	}
+	ws, ok := d.workspaces.Get(cmd.Args[0])
+	if !ok {
+		return conn.WriteErr(hubproto.ErrNotFound, fmt.Sprintf("workspace %q not found", cmd.Args[0]))
+	}
+	data, _ := json.Marshal(ws)
+	return conn.WriteJSON(data)
+}
+
+// hubHandleWorkspaceRemove handles WORKSPACE REMOVE <id>, stopping the
+// processes running in it first.
+func (d *Daemon) hubHandleWorkspaceRemove(ctx context.Context, conn *hubpkg.Connection, cmd *hubproto.Command) error {
+	if len(cmd.Args) < 1 {
+		return conn.WriteErr(hubproto.ErrMissingParam, "workspace id required")
+	}
+	stopped, err := d.removeWorkspace(ctx, cmd.Args[0])
+	if err != nil {
+		return conn.WriteErr(hubproto.ErrNotFound, err.Error())
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":                cmd.Args[0],
+		"removed":           true,
+		"stopped_processes": stopped,
+	})
+	return conn.WriteJSON(data)
+}
+
+// removeWorkspace stops the processes running in a workspace and deletes it.
+func (d *Daemon) removeWorkspace(ctx context.Context, id string) ([]string, error) {
+	ws, ok := d.workspaces.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("workspace %q not found", id)
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	stopped, err := d.hub.ProcessManager().StopByProjectPath(stopCtx, ws.Path)
+	if err != nil {
+		log.Printf("[WORKSPACE] error stopping processes in %s: %v", ws.Path, err)
+	}
+	for _, procID := range stopped {
+		d.hub.ProcessManager().RemoveByPath(procID, ws.Path)
+	}
+	if err := d.workspaces.Remove(stopCtx, id); err != nil {
+		return stopped, err
+	}
+	return stopped, nil
+}
+
+// cleanupSessionWorkspaces removes the workspaces tied to a session.
+func (d *Daemon) cleanupSessionWorkspaces(ctx context.Context, sessionCode string) {
+	for _, ws := range d.workspaces.ForSession(sessionCode) {
+		if _, err := d.removeWorkspace(ctx, ws.ID); err != nil {
+			log.Printf("[Daemon] error removing workspace %s: %v", ws.ID, err)
+		}
+	}
+}
+
+// workspaceRoot returns the directory workspaces are created in: the
+// user's cache directory, so other users can't see or plant them.
+func workspaceRoot() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "agnt", "workspaces")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("agnt-workspaces-%d", os.Getuid()))
+}
@@ -0,0 +1,177 @@
+// Package depgraph models dependencies between daemon-managed entities
+// (processes, proxies, tunnels) so operations can report, or cascade to,
+// the entities they would break.
+package depgraph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of node.
+const (
+	KindProcess = "process"
+	KindProxy   = "proxy"
+	KindTunnel  = "tunnel"
+)
+
+// Node is a managed entity.
+type Node struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	State string `json:"state,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+// Key returns the node's "kind:id" reference.
+func (n Node) Key() string { return n.Kind + ":" + n.ID }
+
+// Edge records that From depends on To.
+type Edge struct {
+	From   string `json:"from"` // Node key
+	To     string `json:"to"`   // Node key
+	Reason string `json:"reason"`
+}
+
+// Impact is an entity affected by an operation on another.
+type Impact struct {
+	Node
+	Via    string `json:"via"` // Key of the node it depends on
+	Reason string `json:"reason"`
+}
+
+// Graph is a dependency graph between managed entities.
+type Graph struct {
+	nodes map[string]Node
+	edges []Edge
+	seen  map[[2]string]bool
+}
+
+// New returns an empty graph.
+func New() *Graph {
+	return &Graph{nodes: make(map[string]Node), seen: make(map[[2]string]bool)}
+}
+
+// AddNode adds or replaces a node.
+func (g *Graph) AddNode(n Node) {
+	g.nodes[n.Key()] = n
+}
+
+// Node returns the node with the given key.
+func (g *Graph) Node(key string) (Node, bool) {
+	n, ok := g.nodes[key]
+	return n, ok
+}
+
+// AddEdge records that from depends on to. Both nodes must exist; duplicate
+// and self edges are ignored.
+func (g *Graph) AddEdge(from, to, reason string) {
+	if from == to {
+		return
+	}
+	if _, ok := g.nodes[from]; !ok {
+		return
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return
+	}
+	k := [2]string{from, to}
+	if g.seen[k] {
+		return
+	}
+	g.seen[k] = true
+	g.edges = append(g.edges, Edge{From: from, To: to, Reason: reason})
+}
+
+// Nodes returns all nodes sorted by kind and ID.
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Kind != nodes[j].Kind {
+			return kindOrder(nodes[i].Kind) < kindOrder(nodes[j].Kind)
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// Edges returns all edges in insertion order.
+func (g *Graph) Edges() []Edge {
+	return append([]Edge(nil), g.edges...)
+}
+
+// Dependents returns every node that directly or transitively depends on key,
+// nearest first.
+func (g *Graph) Dependents(key string) []Impact {
+	var out []Impact
+	visited := map[string]bool{key: true}
+	queue := []string{key}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range g.edges {
+			if e.To != cur || visited[e.From] {
+				continue
+			}
+			visited[e.From] = true
+			out = append(out, Impact{Node: g.nodes[e.From], Via: cur, Reason: e.Reason})
+			queue = append(queue, e.From)
+		}
+	}
+	return out
+}
+
+// Subgraph returns the nodes and edges whose nodes all satisfy keep.
+func (g *Graph) Subgraph(keep func(Node) bool) *Graph {
+	sub := New()
+	for _, n := range g.nodes {
+		if keep(n) {
+			sub.AddNode(n)
+		}
+	}
+	for _, e := range g.edges {
+		sub.AddEdge(e.From, e.To, e.Reason)
+	}
+	return sub
+}
+
+// Describe summarizes what an operation on the node breaks, e.g.
+// "stopping process dev will break proxy dev-proxy and tunnel t1".
+// Returns "" when nothing depends on it.
+func Describe(verb string, target Node, impacts []Impact) string {
+	if len(impacts) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(impacts))
+	for _, im := range impacts {
+		parts = append(parts, im.Kind+" "+im.ID)
+	}
+	return fmt.Sprintf("%s %s %s will break %s", verb, target.Kind, target.ID, joinAnd(parts))
+}
+
+func joinAnd(parts []string) string {
+	switch len(parts) {
+	case 1:
+		return parts[0]
+	case 2:
+		return parts[0] + " and " + parts[1]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// kindOrder sorts nodes from the bottom of the stack up.
+func kindOrder(kind string) int {
+	switch kind {
+	case KindProcess:
+		return 0
+	case KindProxy:
+		return 1
+	case KindTunnel:
+		return 2
+	}
+	return 3
+}
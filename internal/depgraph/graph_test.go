@@ -0,0 +1,74 @@
+package depgraph
+
+import "testing"
+
+func testGraph() *Graph {
+	g := New()
+	g.AddNode(Node{Kind: KindProcess, ID: "dev", Path: "/app"})
+	g.AddNode(Node{Kind: KindProcess, ID: "api", Path: "/api"})
+	g.AddNode(Node{Kind: KindProxy, ID: "dev-proxy", Path: "/app"})
+	g.AddNode(Node{Kind: KindTunnel, ID: "t1", Path: "/app"})
+	g.AddEdge("proxy:dev-proxy", "process:dev", "targets port 3000")
+	g.AddEdge("proxy:dev-proxy", "process:dev", "duplicate")
+	g.AddEdge("tunnel:t1", "proxy:dev-proxy", "fronts port 45000")
+	g.AddEdge("tunnel:t1", "process:missing", "dangling")
+	return g
+}
+
+func TestDependents(t *testing.T) {
+	g := testGraph()
+	if n := len(g.Edges()); n != 2 {
+		t.Fatalf("edges = %d, want 2 (duplicates and dangling dropped)", n)
+	}
+
+	impacts := g.Dependents("process:dev")
+	if len(impacts) != 2 {
+		t.Fatalf("dependents = %+v", impacts)
+	}
+	if impacts[0].Key() != "proxy:dev-proxy" || impacts[0].Via != "process:dev" || impacts[0].Reason != "targets port 3000" {
+		t.Errorf("first = %+v", impacts[0])
+	}
+	if impacts[1].Key() != "tunnel:t1" || impacts[1].Via != "proxy:dev-proxy" {
+		t.Errorf("second = %+v", impacts[1])
+	}
+	if got := g.Dependents("process:api"); len(got) != 0 {
+		t.Errorf("api dependents = %+v", got)
+	}
+}
+
+func TestDependents_Cycle(t *testing.T) {
+	g := New()
+	g.AddNode(Node{Kind: KindProxy, ID: "a"})
+	g.AddNode(Node{Kind: KindProxy, ID: "b"})
+	g.AddEdge("proxy:a", "proxy:b", "x")
+	g.AddEdge("proxy:b", "proxy:a", "y")
+	if got := g.Dependents("proxy:a"); len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("dependents = %+v", got)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	g := testGraph()
+	target, _ := g.Node("process:dev")
+	want := "stopping process dev will break proxy dev-proxy and tunnel t1"
+	if got := Describe("stopping", target, g.Dependents(target.Key())); got != want {
+		t.Errorf("Describe = %q, want %q", got, want)
+	}
+	if got := Describe("stopping", target, nil); got != "" {
+		t.Errorf("Describe(no impacts) = %q", got)
+	}
+	if got := joinAnd([]string{"a", "b", "c"}); got != "a, b and c" {
+		t.Errorf("joinAnd = %q", got)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	sub := testGraph().Subgraph(func(n Node) bool { return n.Path == "/app" })
+	nodes := sub.Nodes()
+	if len(nodes) != 3 || nodes[0].Kind != KindProcess || nodes[2].Kind != KindTunnel {
+		t.Errorf("nodes = %+v", nodes)
+	}
+	if len(sub.Edges()) != 2 {
+		t.Errorf("edges = %+v", sub.Edges())
+	}
+}
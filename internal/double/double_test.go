@@ -0,0 +1,159 @@
+package double
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func noLatency() *[2]int { return &[2]int{0, 0} }
+
+func TestBuiltinsValid(t *testing.T) {
+	for _, name := range BuiltinNames() {
+		p, _ := Builtin(name)
+		if err := p.Validate(); err != nil {
+			t.Errorf("Builtin %s invalid: %v", name, err)
+		}
+	}
+}
+
+func TestStripeDouble(t *testing.T) {
+	var mu sync.Mutex
+	var events []map[string]interface{}
+	var signatures []string
+	hooks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&ev)
+		mu.Lock()
+		events = append(events, ev)
+		signatures = append(signatures, r.Header.Get("Stripe-Signature"))
+		mu.Unlock()
+	}))
+	defer hooks.Close()
+
+	p, _ := Builtin("stripe")
+	for i := range p.Routes {
+		if p.Routes[i].Webhook != nil {
+			p.Routes[i].Webhook.DelayMs = 0
+		}
+		for j := range p.Routes[i].Cases {
+			if p.Routes[i].Cases[j].Webhook != nil {
+				p.Routes[i].Cases[j].Webhook.DelayMs = 0
+			}
+		}
+	}
+	s, err := NewServer(p, Options{WebhookURL: hooks.URL, LatencyMs: noLatency()})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp, err := http.PostForm(srv.URL+"/v1/payment_intents", url.Values{"amount": {"2500"}, "currency": {"eur"}})
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	var pi map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&pi)
+	resp.Body.Close()
+	if pi["id"] != "pi_dbl000001" || pi["amount"] != 2500.0 || pi["currency"] != "eur" || pi["status"] != "requires_payment_method" {
+		t.Errorf("Unexpected payment intent %v", pi)
+	}
+
+	resp, _ = http.PostForm(srv.URL+"/v1/payment_intents/pi_x/confirm", url.Values{"payment_method": {"pm_card_chargeDeclined"}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPaymentRequired {
+		t.Errorf("Expected the declined card to get 402, got %d", resp.StatusCode)
+	}
+	s.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0]["type"] != "payment_intent.created" || events[1]["type"] != "payment_intent.payment_failed" {
+		t.Fatalf("Unexpected events %v", events)
+	}
+	object := events[0]["data"].(map[string]interface{})["object"].(map[string]interface{})
+	if object["id"] != "pi_dbl000001" {
+		t.Errorf("Expected the response as data.object, got %v", object)
+	}
+	if !strings.HasPrefix(signatures[0], "t=") || !strings.Contains(signatures[0], ",v1=") {
+		t.Errorf("Unexpected signature %q", signatures[0])
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	p, _ := Builtin("rest")
+	s, _ := NewServer(p, Options{LatencyMs: noLatency()})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, string) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	if status, body := do("POST", "/users", `{"name": "ada"}`); status != 201 || !strings.Contains(body, `"id":"1"`) {
+		t.Errorf("POST = %d %s", status, body)
+	}
+	if status, body := do("PATCH", "/users/1", `{"role": "admin"}`); status != 200 || !strings.Contains(body, `"role":"admin"`) || !strings.Contains(body, `"name":"ada"`) {
+		t.Errorf("PATCH = %d %s", status, body)
+	}
+	if status, body := do("GET", "/users", ""); status != 200 || !strings.HasPrefix(body, `[{`) {
+		t.Errorf("GET = %d %s", status, body)
+	}
+	if status, _ := do("DELETE", "/users/1", ""); status != 204 {
+		t.Errorf("DELETE = %d", status)
+	}
+	if status, _ := do("GET", "/users/1", ""); status != 404 {
+		t.Errorf("GET deleted = %d", status)
+	}
+}
+
+func TestAuth0Authorize(t *testing.T) {
+	p, _ := Builtin("auth0")
+	s, _ := NewServer(p, Options{LatencyMs: noLatency()})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(srv.URL + "/authorize?redirect_uri=http://app.test/cb&state=a+b")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); resp.StatusCode != 302 || loc != "http://app.test/cb?code=code_dbl000001&state=a+b" {
+		t.Errorf("Unexpected redirect %d %s", resp.StatusCode, loc)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "github.json")
+	os.WriteFile(path, []byte(`{"routes": [{"method": "GET", "path": "/user", "body": "{\"login\": \"octocat\"}"}]}`), 0644)
+	p, err := LoadProfile(path)
+	if err != nil || p.Name != "github" {
+		t.Fatalf("LoadProfile = %+v, %v", p, err)
+	}
+
+	os.WriteFile(path, []byte(`{"routes": [{"method": "GET", "path": "/user", "body": "{{field"}]}`), 0644)
+	if _, err := LoadProfile(path); err == nil {
+		t.Error("Expected a template error")
+	}
+	if env := (&Profile{Env: map[string]string{"API": "{url}/v1"}}).ExpandEnv("http://localhost:1"); env["API"] != "http://localhost:1/v1" {
+		t.Errorf("Unexpected env %v", env)
+	}
+}
@@ -0,0 +1,212 @@
+// Package double serves stand-ins for third-party APIs (payments, auth,
+// generic REST) with canned, predictable responses, realistic latency and
+// webhook emissions, so flows that call them can run offline.
+package double
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Profile describes the API a double stands in for.
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Env is exported to the project's processes while the double runs;
+	// {url} in a value is replaced by the double's base URL.
+	Env map[string]string `json:"env,omitempty"`
+	// LatencyMs is the [min, max] delay before each response.
+	LatencyMs [2]int  `json:"latency_ms,omitempty"`
+	Routes    []Route `json:"routes,omitempty"`
+	// CRUD serves in-memory collections at /{collection} and
+	// /{collection}/{id} for requests no route matches.
+	CRUD bool `json:"crud,omitempty"`
+	// WebhookSecret signs webhooks in the SignatureHeader, Stripe style:
+	// "t=<unix>,v1=<hex HMAC-SHA256 of t.payload>".
+	WebhookSecret   string `json:"webhook_secret,omitempty"`
+	SignatureHeader string `json:"signature_header,omitempty"`
+}
+
+// Route is a canned response. Path segments starting with ":" capture a
+// parameter. Headers and body are text/template strings with the functions
+// id, param, field, num, now, base and json.
+type Route struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Status  int               `json:"status,omitempty"` // Default 200
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Cases replace the response when a request field has a value, such as
+	// a test card that is declined.
+	Cases []Case `json:"cases,omitempty"`
+	// Webhook is emitted after the response, with the response body as
+	// data.object.
+	Webhook *Webhook `json:"webhook,omitempty"`
+}
+
+// Case is an alternative response of a route.
+type Case struct {
+	Field  string `json:"field"`
+	Equals string `json:"equals"`
+	Status int    `json:"status,omitempty"`
+	Body   string `json:"body,omitempty"`
+	// NoWebhook suppresses the route's webhook, e.g. for a failed payment.
+	NoWebhook bool     `json:"no_webhook,omitempty"`
+	Webhook   *Webhook `json:"webhook,omitempty"`
+}
+
+// Webhook is an event posted to the webhook URL.
+type Webhook struct {
+	Event   string `json:"event"`
+	DelayMs int    `json:"delay_ms,omitempty"`
+}
+
+// LoadProfile reads a profile from a JSON file.
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(baseName(path), ".json")
+	}
+	return &p, p.Validate()
+}
+
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// Validate checks the routes and their templates.
+func (p *Profile) Validate() error {
+	if p.LatencyMs[1] < p.LatencyMs[0] {
+		return fmt.Errorf("profile %s: latency max below min", p.Name)
+	}
+	for _, r := range p.Routes {
+		if r.Method == "" || !strings.HasPrefix(r.Path, "/") {
+			return fmt.Errorf("profile %s: route needs a method and a path starting with /", p.Name)
+		}
+		texts := []string{r.Body}
+		for _, v := range r.Headers {
+			texts = append(texts, v)
+		}
+		for _, c := range r.Cases {
+			texts = append(texts, c.Body)
+		}
+		for _, text := range texts {
+			if _, err := parseTemplate(text); err != nil {
+				return fmt.Errorf("profile %s: %s %s: %w", p.Name, r.Method, r.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandEnv returns the profile's env for a double at baseURL.
+func (p *Profile) ExpandEnv(baseURL string) map[string]string {
+	env := make(map[string]string, len(p.Env))
+	for k, v := range p.Env {
+		env[k] = strings.ReplaceAll(v, "{url}", baseURL)
+	}
+	return env
+}
+
+// Builtin returns a built-in profile by name.
+func Builtin(name string) (*Profile, bool) {
+	fn, ok := builtins[name]
+	if !ok {
+		return nil, false
+	}
+	return fn(), true
+}
+
+// BuiltinNames returns the names of the built-in profiles.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var builtins = map[string]func() *Profile{
+	"stripe": stripeProfile,
+	"auth0":  auth0Profile,
+	"rest":   restProfile,
+}
+
+const paymentIntentBody = `{"id": {{json (param "id" (id "pi_"))}}, "object": "payment_intent", "amount": {{num "amount" 1000}}, "currency": {{json (field "currency" "usd")}}, "customer": {{json (field "customer" "")}}, "status": "%s", "created": {{now}}, "livemode": false}`
+
+func stripeProfile() *Profile {
+	declined := `{"error": {"type": "card_error", "code": "card_declined", "decline_code": "generic_decline", "message": "Your card was declined."}}`
+	return &Profile{
+		Name:        "stripe",
+		Description: "Stripe-style payments: customers, payment intents, refunds; pm_card_chargeDeclined is declined",
+		Env: map[string]string{
+			"STRIPE_API_BASE":       "{url}",
+			"STRIPE_SECRET_KEY":     "sk_test_double",
+			"STRIPE_WEBHOOK_SECRET": "whsec_double",
+		},
+		LatencyMs:       [2]int{80, 250},
+		WebhookSecret:   "whsec_double",
+		SignatureHeader: "Stripe-Signature",
+		Routes: []Route{
+			{Method: "POST", Path: "/v1/customers", Body: `{"id": {{json (id "cus_")}}, "object": "customer", "email": {{json (field "email" "")}}, "created": {{now}}}`,
+				Webhook: &Webhook{Event: "customer.created"}},
+			{Method: "GET", Path: "/v1/customers/:id", Body: `{"id": {{json (param "id" "")}}, "object": "customer"}`},
+			{Method: "POST", Path: "/v1/payment_intents", Body: fmt.Sprintf(paymentIntentBody, "requires_payment_method"),
+				Webhook: &Webhook{Event: "payment_intent.created"}},
+			{Method: "GET", Path: "/v1/payment_intents/:id", Body: fmt.Sprintf(paymentIntentBody, "requires_payment_method")},
+			{Method: "POST", Path: "/v1/payment_intents/:id/confirm", Body: fmt.Sprintf(paymentIntentBody, "succeeded"),
+				Webhook: &Webhook{Event: "payment_intent.succeeded", DelayMs: 500},
+				Cases: []Case{{Field: "payment_method", Equals: "pm_card_chargeDeclined", Status: 402, Body: declined,
+					Webhook: &Webhook{Event: "payment_intent.payment_failed", DelayMs: 500}}}},
+			{Method: "POST", Path: "/v1/refunds", Body: `{"id": {{json (id "re_")}}, "object": "refund", "payment_intent": {{json (field "payment_intent" "")}}, "amount": {{num "amount" 1000}}, "status": "succeeded"}`,
+				Webhook: &Webhook{Event: "charge.refunded", DelayMs: 500}},
+		},
+	}
+}
+
+func auth0Profile() *Profile {
+	return &Profile{
+		Name:        "auth0",
+		Description: "Auth0-style OIDC: discovery, authorize redirect, token, userinfo, logout; unsigned tokens",
+		Env: map[string]string{
+			"AUTH0_DOMAIN":        "{url}",
+			"AUTH0_ISSUER_BASE":   "{url}",
+			"AUTH0_CLIENT_ID":     "double-client",
+			"AUTH0_CLIENT_SECRET": "double-secret",
+		},
+		LatencyMs: [2]int{30, 120},
+		Routes: []Route{
+			{Method: "GET", Path: "/.well-known/openid-configuration", Body: `{"issuer": "{{base}}/", "authorization_endpoint": "{{base}}/authorize", "token_endpoint": "{{base}}/oauth/token", "userinfo_endpoint": "{{base}}/userinfo", "end_session_endpoint": "{{base}}/v2/logout", "jwks_uri": "{{base}}/.well-known/jwks.json", "response_types_supported": ["code"], "id_token_signing_alg_values_supported": ["none"]}`},
+			{Method: "GET", Path: "/.well-known/jwks.json", Body: `{"keys": []}`},
+			{Method: "GET", Path: "/authorize", Status: 302, Headers: map[string]string{"Location": `{{field "redirect_uri" "/"}}?code={{id "code_"}}&state={{field "state" "" | urlquery}}`}},
+			{Method: "POST", Path: "/oauth/token", Body: `{"access_token": {{json (id "at_")}}, "id_token": "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJzdWIiOiJhdXRoMHxkb3VibGUiLCJlbWFpbCI6ImRldkBleGFtcGxlLmNvbSJ9.", "token_type": "Bearer", "expires_in": 86400, "scope": {{json (field "scope" "openid profile email")}}}`,
+				Cases: []Case{{Field: "code", Equals: "invalid", Status: 403, Body: `{"error": "invalid_grant", "error_description": "Invalid authorization code"}`}}},
+			{Method: "GET", Path: "/userinfo", Body: `{"sub": "auth0|double", "email": "dev@example.com", "email_verified": true, "name": "Dev User"}`},
+			{Method: "GET", Path: "/v2/logout", Status: 302, Headers: map[string]string{"Location": `{{field "returnTo" "/"}}`}},
+		},
+	}
+}
+
+func restProfile() *Profile {
+	return &Profile{
+		Name:        "rest",
+		Description: "Generic REST: in-memory collections with POST, GET, PUT, PATCH and DELETE at /{collection}[/{id}]",
+		Env:         map[string]string{"API_BASE_URL": "{url}"},
+		LatencyMs:   [2]int{20, 80},
+		CRUD:        true,
+	}
+}
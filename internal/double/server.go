@@ -0,0 +1,418 @@
+package double
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// maxBody caps the request bodies a double reads.
+const maxBody = 1 << 20
+
+// Options configure a running double.
+type Options struct {
+	// WebhookURL receives the profile's webhooks; without it none are sent.
+	WebhookURL string
+	// Seed makes IDs and latencies repeat across runs (default 1).
+	Seed int64
+	// LatencyMs overrides the profile's latency range.
+	LatencyMs *[2]int
+	// Log receives a line per request and webhook.
+	Log io.Writer
+}
+
+// Server serves a profile over HTTP.
+type Server struct {
+	profile *Profile
+	opts    Options
+	client  *http.Client
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	ids   int
+	store map[string]*collection
+	wg    sync.WaitGroup
+}
+
+// collection is an in-memory CRUD collection, in insertion order.
+type collection struct {
+	order []string
+	items map[string]map[string]interface{}
+}
+
+// NewServer creates a server for a profile.
+func NewServer(p *Profile, opts Options) (*Server, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Seed == 0 {
+		opts.Seed = 1
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	return &Server{
+		profile: p,
+		opts:    opts,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		rng:     rand.New(rand.NewSource(opts.Seed)),
+		store:   make(map[string]*collection),
+	}, nil
+}
+
+// Wait blocks until pending webhooks are sent.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// request is what templates see of a request.
+type request struct {
+	base   string
+	params map[string]string
+	fields map[string]interface{}
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids++
+	return fmt.Sprintf("%sdbl%06d", prefix, s.ids)
+}
+
+func (s *Server) latency() time.Duration {
+	lat := s.profile.LatencyMs
+	if s.opts.LatencyMs != nil {
+		lat = *s.opts.LatencyMs
+	}
+	if lat[1] <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ms := lat[0]
+	if lat[1] > lat[0] {
+		ms += s.rng.Intn(lat[1] - lat[0] + 1)
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// ServeHTTP answers with the first matching route, or a CRUD collection.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	req := &request{base: "http://" + r.Host, fields: readFields(r)}
+
+	status, headers, body, hook := http.StatusNotFound, map[string]string(nil), "", (*Webhook)(nil)
+	route, params := s.match(r.Method, r.URL.Path)
+	switch {
+	case route != nil:
+		req.params = params
+		status, body, hook = route.Status, route.Body, route.Webhook
+		for _, c := range route.Cases {
+			if fmt.Sprint(req.fields[c.Field]) == c.Equals {
+				status, body = c.Status, c.Body
+				if c.NoWebhook || c.Webhook != nil {
+					hook = c.Webhook
+				}
+				break
+			}
+		}
+		headers = route.Headers
+	case s.profile.CRUD:
+		status, body = s.crud(r.Method, r.URL.Path, req.fields)
+	default:
+		body = fmt.Sprintf(`{"error": "no route for %s %s in the %s double"}`, r.Method, r.URL.Path, s.profile.Name)
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	rendered, err := s.render(body, req)
+	if err != nil {
+		status, rendered = http.StatusInternalServerError, fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	for k, v := range headers {
+		value, err := s.render(v, req)
+		if err == nil {
+			w.Header().Set(k, value)
+		}
+	}
+	if rendered != "" && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	select {
+	case <-time.After(s.latency()):
+	case <-r.Context().Done():
+		return
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, rendered)
+	fmt.Fprintf(s.opts.Log, "%s %s %d %dms\n", r.Method, r.URL.Path, status, time.Since(start).Milliseconds())
+
+	if hook != nil && s.opts.WebhookURL != "" {
+		s.wg.Add(1)
+		go s.emit(*hook, rendered)
+	}
+}
+
+// match finds the route of a request and its path parameters.
+func (s *Server) match(method, path string) (*Route, map[string]string) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range s.profile.Routes {
+		route := &s.profile.Routes[i]
+		if !strings.EqualFold(route.Method, method) {
+			continue
+		}
+		want := strings.Split(strings.Trim(route.Path, "/"), "/")
+		if len(want) != len(segs) {
+			continue
+		}
+		params := make(map[string]string)
+		ok := true
+		for j, w := range want {
+			if strings.HasPrefix(w, ":") {
+				params[w[1:]] = segs[j]
+			} else if w != segs[j] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// readFields merges the query with a JSON or form body.
+func readFields(r *http.Request) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for k, v := range r.URL.Query() {
+		fields[k] = v[0]
+	}
+	data, _ := io.ReadAll(io.LimitReader(r.Body, maxBody))
+	if len(data) == 0 {
+		return fields
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var obj map[string]interface{}
+		if json.Unmarshal(data, &obj) == nil {
+			for k, v := range obj {
+				fields[k] = v
+			}
+			return fields
+		}
+	}
+	if form, err := url.ParseQuery(string(data)); err == nil {
+		for k, v := range form {
+			fields[k] = v[0]
+		}
+	}
+	return fields
+}
+
+// parseTemplate parses a response template with placeholder functions, so
+// profiles can be validated without a request.
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("").Funcs(templateFuncs(nil, nil)).Parse(text)
+}
+
+func templateFuncs(s *Server, req *request) template.FuncMap {
+	return template.FuncMap{
+		"id": func(prefix string) string { return s.nextID(prefix) },
+		"param": func(name, def string) string {
+			if v, ok := req.params[name]; ok {
+				return v
+			}
+			return def
+		},
+		"field": func(name, def string) string {
+			if v, ok := req.fields[name]; ok {
+				return fmt.Sprint(v)
+			}
+			return def
+		},
+		"num": func(name string, def int) string {
+			if v, ok := req.fields[name]; ok {
+				if n, err := strconv.ParseFloat(fmt.Sprint(v), 64); err == nil {
+					return strconv.FormatFloat(n, 'f', -1, 64)
+				}
+			}
+			return strconv.Itoa(def)
+		},
+		"now":  func() int64 { return time.Now().Unix() },
+		"base": func() string { return req.base },
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+}
+
+func (s *Server) render(text string, req *request) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := template.New("").Funcs(templateFuncs(s, req)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// emit posts a webhook event with the response as data.object.
+func (s *Server) emit(hook Webhook, response string) {
+	defer s.wg.Done()
+	if hook.DelayMs > 0 {
+		time.Sleep(time.Duration(hook.DelayMs) * time.Millisecond)
+	}
+
+	var object interface{} = response
+	var parsed interface{}
+	if json.Unmarshal([]byte(response), &parsed) == nil {
+		object = parsed
+	}
+	now := time.Now().Unix()
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":       s.nextID("evt_"),
+		"object":   "event",
+		"type":     hook.Event,
+		"created":  now,
+		"livemode": false,
+		"data":     map[string]interface{}{"object": object},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.profile.SignatureHeader != "" && s.profile.WebhookSecret != "" {
+		req.Header.Set(s.profile.SignatureHeader, Sign(s.profile.WebhookSecret, now, payload))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		fmt.Fprintf(s.opts.Log, "webhook %s -> %s failed: %v\n", hook.Event, s.opts.WebhookURL, err)
+		return
+	}
+	resp.Body.Close()
+	fmt.Fprintf(s.opts.Log, "webhook %s -> %s %d\n", hook.Event, s.opts.WebhookURL, resp.StatusCode)
+}
+
+// Sign returns a Stripe-style signature of a webhook payload.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// crud serves in-memory collections at /{collection}[/{id}].
+func (s *Server) crud(method, path string, fields map[string]interface{}) (int, string) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) > 2 || segs[0] == "" {
+		return http.StatusNotFound, `{"error": "use /{collection} or /{collection}/{id}"}`
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.store[segs[0]]
+	if !ok {
+		c = &collection{items: make(map[string]map[string]interface{})}
+		s.store[segs[0]] = c
+	}
+	encode := func(status int, v interface{}) (int, string) {
+		b, _ := json.Marshal(v)
+		return status, string(b)
+	}
+
+	if len(segs) == 1 {
+		switch method {
+		case http.MethodGet:
+			items := make([]map[string]interface{}, 0, len(c.order))
+			for _, id := range c.order {
+				items = append(items, c.items[id])
+			}
+			return encode(http.StatusOK, items)
+		case http.MethodPost:
+			id := fmt.Sprint(fields["id"])
+			if _, given := fields["id"]; !given {
+				s.ids++
+				id = strconv.Itoa(s.ids)
+			}
+			if _, exists := c.items[id]; exists {
+				return http.StatusConflict, fmt.Sprintf(`{"error": "%s/%s exists"}`, segs[0], id)
+			}
+			item := copyFields(fields)
+			item["id"] = id
+			c.items[id] = item
+			c.order = append(c.order, id)
+			return encode(http.StatusCreated, item)
+		}
+		return http.StatusMethodNotAllowed, `{"error": "use GET or POST"}`
+	}
+
+	id := segs[1]
+	item, exists := c.items[id]
+	if !exists && method != http.MethodPut {
+		return http.StatusNotFound, fmt.Sprintf(`{"error": "%s/%s not found"}`, segs[0], id)
+	}
+	switch method {
+	case http.MethodGet:
+		return encode(http.StatusOK, item)
+	case http.MethodPut:
+		item = copyFields(fields)
+		item["id"] = id
+		if !exists {
+			c.order = append(c.order, id)
+		}
+		c.items[id] = item
+		return encode(http.StatusOK, item)
+	case http.MethodPatch:
+		for k, v := range fields {
+			if k != "id" {
+				item[k] = v
+			}
+		}
+		return encode(http.StatusOK, item)
+	case http.MethodDelete:
+		delete(c.items, id)
+		for i, oid := range c.order {
+			if oid == id {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+		return http.StatusNoContent, ""
+	}
+	return http.StatusMethodNotAllowed, `{"error": "use GET, PUT, PATCH or DELETE"}`
+}
+
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	item := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		item[k] = v
+	}
+	return item
+}
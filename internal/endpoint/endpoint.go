@@ -0,0 +1,173 @@
+// Package endpoint dials the local HTTP endpoints overlays listen on: a unix
+// socket path, TCP on a loopback address (tcp://127.0.0.1:port, which agnt
+// run falls back to on Windows without AF_UNIX), or a Windows named pipe
+// (\\.\pipe\name). agnt itself never listens on a named pipe; pipe
+// endpoints are for external overlays registered with SESSION REGISTER.
+package endpoint
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// Kinds of endpoint.
+const (
+	KindUnix = "unix"
+	KindPipe = "pipe"
+	KindTCP  = "tcp"
+)
+
+// TokenHeader carries the token of a TCP endpoint on every request to it.
+const TokenHeader = "X-Agnt-Token"
+
+// Parse returns the kind of an endpoint and the address to dial. TCP
+// endpoints (tcp:// or http:// URLs) must be on a loopback address, since
+// what the daemon sends overlays is typed into terminals; a token=
+// parameter is left to Token.
+func Parse(endpoint string) (kind, addr string, err error) {
+	switch {
+	case endpoint == "":
+		return "", "", fmt.Errorf("empty endpoint")
+	case IsPipe(endpoint):
+		return KindPipe, strings.ReplaceAll(endpoint, "/", `\`), nil
+	case strings.HasPrefix(endpoint, "unix://"):
+		return KindUnix, strings.TrimPrefix(endpoint, "unix://"), nil
+	case strings.HasPrefix(endpoint, "tcp://"), strings.HasPrefix(endpoint, "http://"):
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+		}
+		if u.Port() == "" {
+			return "", "", fmt.Errorf("invalid endpoint %q: port required", endpoint)
+		}
+		if !isLoopback(u.Hostname()) {
+			return "", "", fmt.Errorf("invalid endpoint %q: TCP endpoints must be on localhost", endpoint)
+		}
+		return KindTCP, u.Host, nil
+	}
+	return KindUnix, endpoint, nil
+}
+
+// Token returns the token a TCP endpoint requires (its token= parameter),
+// or "" when it requires none.
+func Token(endpoint string) string {
+	if !strings.HasPrefix(endpoint, "tcp://") && !strings.HasPrefix(endpoint, "http://") {
+		return ""
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("token")
+}
+
+// IsPipe reports whether endpoint names a Windows named pipe.
+func IsPipe(endpoint string) bool {
+	p := strings.ToLower(strings.ReplaceAll(endpoint, "/", `\`))
+	return strings.HasPrefix(p, `\\.\pipe\`)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Dial connects to an endpoint.
+func Dial(ctx context.Context, endpoint string) (net.Conn, error) {
+	kind, addr, err := Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if kind == KindPipe {
+		return dialPipe(ctx, addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, kind, addr)
+}
+
+// Transport returns an HTTP transport whose connections all go to
+// endpoint, whatever the host of the request URL, and which sends the
+// endpoint's token with every request.
+func Transport(endpoint string) http.RoundTripper {
+	t := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return Dial(ctx, endpoint)
+		},
+	}
+	if token := Token(endpoint); token != "" {
+		return &tokenTransport{token: token, next: t}
+	}
+	return t
+}
+
+// tokenTransport adds TokenHeader to requests.
+type tokenTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(TokenHeader, t.token)
+	return t.next.RoundTrip(req)
+}
+
+// RequireToken wraps h to answer 401 to requests without token in
+// TokenHeader. An empty token requires nothing.
+func RequireToken(token string, h http.Handler) http.Handler {
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Listen listens on a unix socket at path. On Windows, where AF_UNIX is
+// missing before 10 1803, it falls back to a free loopback TCP port
+// guarded by a random token, which any local process could reach
+// otherwise. It returns the listener and the endpoint to register for it,
+// token included; serve it through RequireToken(Token(endpoint), ...).
+func Listen(path string) (net.Listener, string, error) {
+	return listen(path, runtime.GOOS == "windows")
+}
+
+func listen(path string, tcpFallback bool) (net.Listener, string, error) {
+	l, err := net.Listen("unix", path)
+	if err == nil || !tcpFallback {
+		return l, path, err
+	}
+	token, tokenErr := newToken()
+	if tokenErr != nil {
+		return nil, "", fmt.Errorf("%v; TCP fallback: %v", err, tokenErr)
+	}
+	l, tcpErr := net.Listen("tcp", "127.0.0.1:0")
+	if tcpErr != nil {
+		return nil, "", fmt.Errorf("%v; TCP fallback: %v", err, tcpErr)
+	}
+	return l, "tcp://" + l.Addr().String() + "?token=" + token, nil
+}
+
+// newToken returns 32 random hex digits.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,118 @@
+package endpoint
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		kind     string
+		addr     string
+		wantErr  bool
+	}{
+		{endpoint: "/tmp/agnt-overlay-1.sock", kind: KindUnix, addr: "/tmp/agnt-overlay-1.sock"},
+		{endpoint: "unix:///tmp/o.sock", kind: KindUnix, addr: "/tmp/o.sock"},
+		{endpoint: `\\.\pipe\agnt-overlay`, kind: KindPipe, addr: `\\.\pipe\agnt-overlay`},
+		{endpoint: "//./pipe/agnt-overlay", kind: KindPipe, addr: `\\.\pipe\agnt-overlay`},
+		{endpoint: "tcp://127.0.0.1:1234", kind: KindTCP, addr: "127.0.0.1:1234"},
+		{endpoint: "tcp://127.0.0.1:1234?token=abc", kind: KindTCP, addr: "127.0.0.1:1234"},
+		{endpoint: "http://localhost:19191", kind: KindTCP, addr: "localhost:19191"},
+		{endpoint: "tcp://[::1]:80", kind: KindTCP, addr: "[::1]:80"},
+		{endpoint: "tcp://10.0.0.5:1234", wantErr: true},
+		{endpoint: "http://example.com:80", wantErr: true},
+		{endpoint: "tcp://127.0.0.1", wantErr: true},
+		{endpoint: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			kind, addr, err := Parse(tt.endpoint)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.addr, addr)
+		})
+	}
+}
+
+func TestListenAndTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "o.sock")
+	l, ep, err := Listen(path)
+	require.NoError(t, err)
+	defer l.Close()
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, path, ep)
+	}
+	roundTrip(t, l, ep)
+}
+
+func TestListenNoFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows falls back to TCP")
+	}
+	// A path in a missing directory can't be listened on
+	_, _, err := Listen(filepath.Join(t.TempDir(), "missing", "o.sock"))
+	assert.Error(t, err)
+}
+
+func TestTransportTCP(t *testing.T) {
+	l, ep, err := listen(filepath.Join(t.TempDir(), "missing", "o.sock"), true)
+	require.NoError(t, err)
+	defer l.Close()
+	kind, _, err := Parse(ep)
+	require.NoError(t, err)
+	assert.Equal(t, KindTCP, kind)
+	token := Token(ep)
+	assert.Len(t, token, 32)
+	roundTrip(t, l, ep)
+
+	// Without the token the endpoint refuses
+	bare := "tcp://" + l.Addr().String()
+	assert.Empty(t, Token(bare))
+	client := &http.Client{Transport: Transport(bare), Timeout: 5 * time.Second}
+	resp, err := client.Get("http://overlay/type")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestDialPipeUnsupported(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are available")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := Dial(ctx, `\\.\pipe\agnt-test`)
+	assert.Error(t, err)
+}
+
+// roundTrip serves one HTTP handler on l and requests it through ep.
+func roundTrip(t *testing.T, l net.Listener, ep string) {
+	t.Helper()
+	srv := &http.Server{Handler: RequireToken(Token(ep), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	client := &http.Client{Transport: Transport(ep), Timeout: 5 * time.Second}
+	resp, err := client.Get("http://overlay/type")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "/type", string(body))
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// dialPipe fails: named pipes only exist on Windows.
+func dialPipe(ctx context.Context, name string) (net.Conn, error) {
+	return nil, fmt.Errorf("dial %s: named pipes are only available on Windows", name)
+}
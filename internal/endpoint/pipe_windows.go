@@ -0,0 +1,65 @@
+//go:build windows
+
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// pipeBusyRetry is how long to wait for a busy pipe to accept a client.
+const pipeBusyRetry = 20 * time.Millisecond
+
+// dialPipe opens a client handle to a named pipe, waiting while all of its
+// instances are busy.
+func dialPipe(ctx context.Context, name string) (net.Conn, error) {
+	path, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		h, err := windows.CreateFile(path,
+			windows.GENERIC_READ|windows.GENERIC_WRITE,
+			0, nil, windows.OPEN_EXISTING,
+			// Don't let the pipe server impersonate us
+			windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION,
+			0)
+		if err == nil {
+			return &pipeConn{File: os.NewFile(uintptr(h), name), addr: pipeAddr(name)}, nil
+		}
+		if !errors.Is(err, windows.ERROR_PIPE_BUSY) {
+			return nil, fmt.Errorf("dial %s: %w", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("dial %s: %w", name, ctx.Err())
+		case <-time.After(pipeBusyRetry):
+		}
+	}
+}
+
+// pipeAddr is the net.Addr of a named pipe.
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return KindPipe }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn is a client connection to a named pipe. The handle is
+// synchronous, so deadlines are not supported; HTTP clients give up on it
+// through their timeout instead.
+type pipeConn struct {
+	*os.File
+	addr pipeAddr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr                { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr               { return c.addr }
+func (c *pipeConn) SetDeadline(t time.Time) error      { return nil }
+func (c *pipeConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return nil }
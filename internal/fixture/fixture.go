@@ -0,0 +1,204 @@
+// Package fixture records daemon protocol exchanges into replayable fixtures
+// and serves them back without a live daemon, so alternative clients can be
+// developed and tested against the protocol.
+package fixture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// Version is the fixture file format version. Readers accept files without a
+// header and ignore fields they do not know, so newer recordings stay
+// loadable by older replayers.
+const Version = 1
+
+// Header is the optional first line of a fixture file.
+type Header struct {
+	FixtureVersion int       `json:"fixture_version"`
+	DaemonVersion  string    `json:"daemon_version,omitempty"`
+	RecordedAt     time.Time `json:"recorded_at"`
+}
+
+// Exchange is one request and the responses it produced. Streamed responses
+// are all CHUNK frames followed by the closing END.
+type Exchange struct {
+	Request    Request    `json:"request"`
+	Responses  []Response `json:"responses"`
+	DurationMs int64      `json:"duration_ms,omitempty"`
+}
+
+// Request is a recorded command.
+type Request struct {
+	Verb    string   `json:"verb"`
+	SubVerb string   `json:"sub_verb,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Payload
+}
+
+// Response is a recorded response frame.
+type Response struct {
+	Type       string                    `json:"type"`
+	Code       string                    `json:"code,omitempty"`
+	Message    string                    `json:"message,omitempty"`
+	Structured *protocol.StructuredError `json:"structured,omitempty"`
+	Payload
+}
+
+// Payload holds a frame's data: inline JSON when it parses, text otherwise.
+type Payload struct {
+	JSON json.RawMessage `json:"json,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+func newPayload(data []byte) Payload {
+	if len(data) == 0 {
+		return Payload{}
+	}
+	if json.Valid(data) {
+		var buf bytes.Buffer
+		if json.Compact(&buf, data) == nil {
+			return Payload{JSON: buf.Bytes()}
+		}
+	}
+	return Payload{Text: string(data)}
+}
+
+// Bytes returns the payload as sent on the wire.
+func (p Payload) Bytes() []byte {
+	if len(p.JSON) > 0 {
+		return p.JSON
+	}
+	if p.Text != "" {
+		return []byte(p.Text)
+	}
+	return nil
+}
+
+// String renders the request as a command line, e.g. "PROC STATUS dev".
+func (r Request) String() string {
+	parts := []string{r.Verb}
+	if r.SubVerb != "" {
+		parts = append(parts, r.SubVerb)
+	}
+	return strings.Join(append(parts, r.Args...), " ")
+}
+
+// newRequest converts a parsed command.
+func newRequest(cmd *protocol.Command) Request {
+	return Request{
+		Verb:    cmd.Verb,
+		SubVerb: cmd.SubVerb,
+		Args:    append([]string(nil), cmd.Args...),
+		Payload: newPayload(cmd.Data),
+	}
+}
+
+// newResponse converts a parsed response frame.
+func newResponse(resp *protocol.Response) Response {
+	return Response{
+		Type:       string(resp.Type),
+		Code:       resp.Code,
+		Message:    resp.Message,
+		Structured: resp.Structured,
+		Payload:    newPayload(resp.Data),
+	}
+}
+
+// terminal reports whether a response ends its exchange.
+func (r Response) terminal() bool {
+	return r.Type != string(protocol.ResponseChunk)
+}
+
+// Load reads a fixture file.
+func Load(path string) (*Header, []Exchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read parses fixtures, one JSON object per line, with an optional header
+// line. Blank lines are skipped.
+func Read(r io.Reader) (*Header, []Exchange, error) {
+	var header *Header
+	var exchanges []Exchange
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if header == nil && len(exchanges) == 0 && bytes.Contains(text, []byte(`"fixture_version"`)) {
+			var h Header
+			if err := json.Unmarshal(text, &h); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if h.FixtureVersion > Version {
+				return nil, nil, fmt.Errorf("fixture version %d is newer than supported version %d", h.FixtureVersion, Version)
+			}
+			header = &h
+			continue
+		}
+		var ex Exchange
+		if err := json.Unmarshal(text, &ex); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if ex.Request.Verb == "" {
+			return nil, nil, fmt.Errorf("line %d: exchange has no verb", line)
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return header, exchanges, scanner.Err()
+}
+
+// Writer appends sanitized exchanges to a fixture stream. It is safe for
+// concurrent use.
+type Writer struct {
+	mu        sync.Mutex
+	w         io.Writer
+	sanitizer *Sanitizer
+}
+
+// NewWriter writes a header to w and returns a Writer appending to it.
+func NewWriter(w io.Writer, daemonVersion string, s *Sanitizer) (*Writer, error) {
+	data, err := json.Marshal(Header{
+		FixtureVersion: Version,
+		DaemonVersion:  daemonVersion,
+		RecordedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, sanitizer: s}, nil
+}
+
+// Write sanitizes and appends one exchange.
+func (fw *Writer) Write(ex Exchange) error {
+	fw.sanitizer.Exchange(&ex)
+	data, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	_, err = fw.w.Write(append(data, '\n'))
+	return err
+}
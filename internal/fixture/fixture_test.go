@@ -0,0 +1,202 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "1.2.3", &Sanitizer{Home: "/home/dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Write(Exchange{
+		Request: Request{
+			Verb:    "PROXY",
+			SubVerb: "START",
+			Args:    []string{"app", "http://user:pw@localhost:3000", "0"},
+			Payload: newPayload([]byte(`{"path": "/home/dev/app", "auth_token": "abc", "tokens": 12}`)),
+		},
+		Responses: []Response{{Type: "JSON", Payload: newPayload([]byte(`{"id":"app"}`))}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(Exchange{
+		Request:   Request{Verb: "PROC", SubVerb: "OUTPUT", Args: []string{"dev"}},
+		Responses: []Response{{Type: "CHUNK", Payload: newPayload([]byte("line 1\n"))}, {Type: "END"}},
+	})
+
+	header, exchanges, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if header == nil || header.FixtureVersion != Version || header.DaemonVersion != "1.2.3" {
+		t.Errorf("Unexpected header %+v", header)
+	}
+	if len(exchanges) != 2 {
+		t.Fatalf("Expected 2 exchanges, got %d", len(exchanges))
+	}
+
+	req := exchanges[0].Request
+	if req.Args[1] != "http://[REDACTED]@localhost:3000" {
+		t.Errorf("Expected URL credentials redacted, got %q", req.Args[1])
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(req.JSON, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data["path"] != "~/app" || data["auth_token"] != Redacted || data["tokens"] != float64(12) {
+		t.Errorf("Unexpected sanitized payload %v", data)
+	}
+
+	out := exchanges[1].Responses
+	if len(out) != 2 || out[0].Text != "line 1\n" || out[0].terminal() || !out[1].terminal() {
+		t.Errorf("Unexpected streamed responses %+v", out)
+	}
+}
+
+func TestWriteRedactsURLQuery(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "1.2.3", &Sanitizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Write(Exchange{
+		Request: Request{Verb: "EXPOSE", SubVerb: "START", Args: []string{"app"}},
+		Responses: []Response{{Type: "JSON", Payload: newPayload([]byte(`{
+			"share_url": "https://x.trycloudflare.com/?agnt_access=SECRET123#top",
+			"public_url": "https://x.trycloudflare.com/app?page=2&token=abc",
+			"message": "Share https://x.trycloudflare.com/?agnt_access=SECRET123 with your team"
+		}`))}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, exchanges, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "SECRET123") {
+		t.Errorf("Access token leaked into fixture: %s", buf.String())
+	}
+	var data map[string]string
+	if err := json.Unmarshal(exchanges[0].Responses[0].JSON, &data); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"share_url":  "https://x.trycloudflare.com/?agnt_access=[REDACTED]#top",
+		"public_url": "https://x.trycloudflare.com/app?page=2&token=[REDACTED]",
+		"message":    "Share https://x.trycloudflare.com/?agnt_access=[REDACTED] with your team",
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("Expected %s %q, got %q", k, v, data[k])
+		}
+	}
+}
+
+func TestRead_Compatibility(t *testing.T) {
+	// No header, unknown fields and blank lines are accepted
+	in := "\n" + `{"request":{"verb":"PING"},"responses":[{"type":"PONG"}],"future":true}` + "\n"
+	header, exchanges, err := Read(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if header != nil || len(exchanges) != 1 {
+		t.Errorf("Unexpected result %v %v", header, exchanges)
+	}
+
+	if _, _, err := Read(strings.NewReader(`{"fixture_version":99}`)); err == nil {
+		t.Error("Expected error for newer fixture version")
+	}
+	if _, _, err := Read(strings.NewReader(`{"request":{}}`)); err == nil {
+		t.Error("Expected error for exchange without verb")
+	}
+}
+
+func TestServerMatch(t *testing.T) {
+	list := func(n int) Exchange {
+		return Exchange{
+			Request:   Request{Verb: "PROXY", SubVerb: "LIST"},
+			Responses: []Response{{Type: "JSON", Payload: newPayload([]byte(fmt.Sprintf(`{"count":%d}`, n)))}},
+		}
+	}
+	srv := NewServer([]Exchange{
+		list(0),
+		{
+			Request:   Request{Verb: "PROXY", SubVerb: "STATUS", Args: []string{"app"}, Payload: newPayload([]byte(`{"b":1,"a":2}`))},
+			Responses: []Response{{Type: "JSON", Payload: newPayload([]byte(`{"id":"app"}`))}},
+		},
+		list(1),
+	}, &Sanitizer{})
+
+	// Sequences replay in order, then repeat the last recording
+	for _, want := range []string{`{"count":0}`, `{"count":1}`, `{"count":1}`} {
+		ex := srv.Match(Request{Verb: "proxy", SubVerb: "list"})
+		if ex == nil || string(ex.Responses[0].JSON) != want {
+			t.Fatalf("Expected %s, got %+v", want, ex)
+		}
+	}
+
+	// Payload key order does not matter; differing payloads fall back to args
+	for _, payload := range []string{`{"a":2,"b":1}`, `{"other":true}`} {
+		if srv.Match(Request{Verb: "PROXY", SubVerb: "STATUS", Args: []string{"app"}, Payload: newPayload([]byte(payload))}) == nil {
+			t.Errorf("Expected match for payload %s", payload)
+		}
+	}
+	// Differing args fall back to verb and sub-verb
+	if srv.Match(Request{Verb: "PROXY", SubVerb: "STATUS", Args: []string{"other"}}) == nil {
+		t.Error("Expected verb-level match")
+	}
+
+	if srv.Match(Request{Verb: "TUNNEL", SubVerb: "LIST"}) != nil {
+		t.Error("Expected no match for unrecorded verb")
+	}
+	if u := srv.Unmatched(); len(u) != 1 || u[0].String() != "TUNNEL LIST" {
+		t.Errorf("Unexpected unmatched requests %v", u)
+	}
+}
+
+func TestServerReplay(t *testing.T) {
+	srv := NewServer([]Exchange{{
+		Request:   Request{Verb: "PROC", SubVerb: "STATUS", Args: []string{"dev"}},
+		Responses: []Response{{Type: "JSON", Payload: newPayload([]byte(`{"id":"dev","state":"running"}`))}},
+	}}, &Sanitizer{})
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		srv.serveConn(server)
+		server.Close()
+	}()
+
+	w := protocol.NewWriter(client)
+	parser := protocol.NewParser(client)
+
+	go w.WriteCommand(&protocol.Command{Verb: "PROC", SubVerb: "STATUS", Args: []string{"dev"}})
+	resp, err := parser.ParseResponse()
+	if err != nil || resp == nil {
+		t.Fatalf("ParseResponse failed: %v", err)
+	}
+	if resp.Type != protocol.ResponseJSON || !strings.Contains(string(resp.Data), `"running"`) {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+
+	go w.WriteCommand(&protocol.Command{Verb: "TUNNEL", SubVerb: "LIST"})
+	resp, err = parser.ParseResponse()
+	if err != nil || resp == nil {
+		t.Fatalf("ParseResponse failed: %v", err)
+	}
+	if resp.Type != protocol.ResponseErr || resp.Code != string(protocol.ErrNotFound) {
+		t.Errorf("Expected not_found for unrecorded request, got %+v", resp)
+	}
+}
@@ -0,0 +1,218 @@
+package fixture
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/go-cli-server/socket"
+)
+
+// Recorder relays client connections to the daemon socket unchanged and
+// records every request/response pair. The relay is byte-for-byte; recording
+// works on a parsed copy, so a frame the parser rejects stops recording for
+// that connection without affecting the client.
+type Recorder struct {
+	upstream string
+	out      *Writer
+
+	// Dial connects to the daemon; defaults to socket.Connect(upstream).
+	Dial func() (net.Conn, error)
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// pendingRequest is a request awaiting its responses.
+type pendingRequest struct {
+	req   Request
+	start time.Time
+}
+
+// NewRecorder returns a Recorder forwarding to the daemon at upstreamPath.
+func NewRecorder(upstreamPath string, out *Writer) *Recorder {
+	r := &Recorder{
+		upstream: upstreamPath,
+		out:      out,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	r.Dial = func() (net.Conn, error) { return socket.Connect(r.upstream) }
+	return r
+}
+
+// Serve accepts connections on l until Close is called.
+func (r *Recorder) Serve(l net.Listener) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return net.ErrClosed
+	}
+	r.listener = l
+	r.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			r.mu.Lock()
+			closed := r.closed
+			r.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.relay(conn)
+		}()
+	}
+}
+
+// Close stops accepting, closes open connections and waits for relays to end.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	var err error
+	if r.listener != nil {
+		err = r.listener.Close()
+	}
+	for c := range r.conns {
+		c.Close()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+	return err
+}
+
+func (r *Recorder) track(c net.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+	r.conns[c] = struct{}{}
+	return true
+}
+
+func (r *Recorder) untrack(c net.Conn) {
+	r.mu.Lock()
+	delete(r.conns, c)
+	r.mu.Unlock()
+	c.Close()
+}
+
+// relay copies bytes in both directions, teeing each into a parser.
+func (r *Recorder) relay(client net.Conn) {
+	if !r.track(client) {
+		client.Close()
+		return
+	}
+	defer r.untrack(client)
+
+	upstream, err := r.Dial()
+	if err != nil {
+		log.Printf("[WARN] Fixture recorder: cannot reach daemon at %s: %v", r.upstream, err)
+		return
+	}
+	if !r.track(upstream) {
+		upstream.Close()
+		return
+	}
+	defer r.untrack(upstream)
+
+	requests := make(chan pendingRequest, 64)
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+
+	var parsers sync.WaitGroup
+	parsers.Add(2)
+	go func() {
+		defer parsers.Done()
+		parseRequests(reqR, requests)
+	}()
+	go func() {
+		defer parsers.Done()
+		r.parseResponses(respR, requests)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(upstream, io.TeeReader(client, reqW))
+		reqW.Close()
+		upstream.Close() // Unblock the response copy
+		close(done)
+	}()
+	io.Copy(client, io.TeeReader(upstream, respW))
+	respW.Close()
+	client.Close()
+	<-done
+	parsers.Wait()
+}
+
+// parseRequests parses the client's byte stream into requests.
+func parseRequests(rd *io.PipeReader, out chan<- pendingRequest) {
+	defer close(out)
+	defer io.Copy(io.Discard, rd) // Never block the relay
+	parser := protocol.NewParser(rd)
+	for {
+		cmd, err := parser.ParseCommand()
+		if err != nil || cmd == nil {
+			if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+				log.Printf("[DEBUG] Fixture recorder: stopped recording connection: %v", err)
+			}
+			return
+		}
+		out <- pendingRequest{req: newRequest(cmd), start: time.Now()}
+	}
+}
+
+// parseResponses pairs the daemon's responses with pending requests and
+// writes completed exchanges.
+func (r *Recorder) parseResponses(rd *io.PipeReader, requests <-chan pendingRequest) {
+	defer io.Copy(io.Discard, rd)
+	defer func() {
+		// Keep the request parser from blocking once pairing stops
+		go func() {
+			for range requests {
+			}
+		}()
+	}()
+	parser := protocol.NewParser(rd)
+	var current *pendingRequest
+	var responses []Response
+	for {
+		resp, err := parser.ParseResponse()
+		if err != nil || resp == nil {
+			return
+		}
+		if current == nil {
+			p, ok := <-requests
+			if !ok {
+				return // Requests stopped parsing; pairing would be unreliable
+			}
+			current = &p
+		}
+		rec := newResponse(resp)
+		responses = append(responses, rec)
+		if !rec.terminal() {
+			continue
+		}
+		ex := Exchange{
+			Request:    current.req,
+			Responses:  responses,
+			DurationMs: time.Since(current.start).Milliseconds(),
+		}
+		if err := r.out.Write(ex); err != nil {
+			log.Printf("[WARN] Fixture recorder: write failed: %v", err)
+		}
+		current, responses = nil, nil
+	}
+}
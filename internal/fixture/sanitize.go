@@ -0,0 +1,148 @@
+package fixture
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+// Redacted replaces sensitive values in recorded fixtures.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are JSON key fragments whose values are redacted.
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey",
+	"authorization", "cookie", "credential", "private_key",
+}
+
+// urlUserInfo matches credentials embedded in URLs.
+var urlUserInfo = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s"]+@`)
+
+// urlQuery matches the query string of a URL, up to its fragment.
+var urlQuery = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^?#\s"'<>]*\?)([^#\s"'<>]*)`)
+
+// Sanitizer removes secrets and machine-specific paths from exchanges before
+// they are written. Replay applies the same sanitizer to incoming requests so
+// they match what was recorded.
+type Sanitizer struct {
+	// Home is replaced by "~" in every string. Empty disables the rewrite.
+	Home string
+}
+
+// NewSanitizer returns a Sanitizer for the current user.
+func NewSanitizer() *Sanitizer {
+	home, _ := os.UserHomeDir()
+	return &Sanitizer{Home: home}
+}
+
+// Exchange sanitizes a request and its responses in place.
+func (s *Sanitizer) Exchange(ex *Exchange) {
+	s.Request(&ex.Request)
+	for i := range ex.Responses {
+		r := &ex.Responses[i]
+		r.Message = s.String(r.Message)
+		if r.Structured != nil {
+			st := *r.Structured
+			st.Message = s.String(st.Message)
+			r.Structured = &st
+		}
+		s.payload(&r.Payload)
+	}
+}
+
+// Request sanitizes a request in place.
+func (s *Sanitizer) Request(r *Request) {
+	for i, a := range r.Args {
+		r.Args[i] = s.String(a)
+	}
+	s.payload(&r.Payload)
+}
+
+func (s *Sanitizer) payload(p *Payload) {
+	p.Text = s.String(p.Text)
+	if len(p.JSON) == 0 {
+		return
+	}
+	var v interface{}
+	if err := json.Unmarshal(p.JSON, &v); err != nil {
+		return
+	}
+	if data, err := json.Marshal(s.value(v)); err == nil {
+		p.JSON = data
+	}
+}
+
+func (s *Sanitizer) value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			// Only string values are secrets; counts such as "tokens" are kept
+			if str, ok := val.(string); ok && isSensitiveKey(k) {
+				if str != "" {
+					t[k] = Redacted
+				}
+				continue
+			}
+			t[k] = s.value(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = s.value(val)
+		}
+		return t
+	case string:
+		return s.String(t)
+	}
+	return v
+}
+
+// String strips URL credentials, sensitive query parameters and the home
+// directory from str.
+func (s *Sanitizer) String(str string) string {
+	if s == nil || str == "" {
+		return str
+	}
+	str = urlUserInfo.ReplaceAllString(str, "${1}"+Redacted+"@")
+	str = urlQuery.ReplaceAllStringFunc(str, func(u string) string {
+		m := urlQuery.FindStringSubmatch(u)
+		return m[1] + sanitizeQuery(m[2])
+	})
+	if s.Home != "" && s.Home != "/" {
+		str = strings.ReplaceAll(str, s.Home, "~")
+	}
+	return str
+}
+
+// sanitizeQuery redacts the values of sensitive parameters in a raw query,
+// keeping the order and encoding of the others.
+func sanitizeQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		name, _, ok := strings.Cut(p, "=")
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if name == proxy.AccessTokenParam || isSensitiveKey(name) {
+			params[i] = p[:strings.IndexByte(p, '=')+1] + Redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, frag := range sensitiveKeys {
+		if strings.Contains(k, frag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package fixture
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// Server answers protocol requests from recorded exchanges, standing in for
+// the daemon.
+//
+// A request is matched against the fixtures from most to least specific:
+// the exact request including its payload, then verb, sub-verb and args, then
+// verb and sub-verb alone. Repeated requests replay their recordings in order
+// and then repeat the last one, so stateful sequences such as LIST, START,
+// LIST replay faithfully. PING is answered even when not recorded.
+type Server struct {
+	exchanges []Exchange
+	sanitizer *Sanitizer
+
+	mu       sync.Mutex
+	indexes  [3]map[string]*replayQueue // By match level, most specific first
+	unmatch  []Request
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// replayQueue holds the exchanges recorded for one match key.
+type replayQueue struct {
+	entries []int
+	next    int
+}
+
+func (q *replayQueue) take() int {
+	i := q.entries[q.next]
+	if q.next < len(q.entries)-1 {
+		q.next++
+	}
+	return i
+}
+
+// NewServer returns a Server replaying exchanges. Incoming requests pass
+// through s before matching, as recordings did; nil uses NewSanitizer.
+func NewServer(exchanges []Exchange, s *Sanitizer) *Server {
+	if s == nil {
+		s = NewSanitizer()
+	}
+	srv := &Server{
+		exchanges: exchanges,
+		sanitizer: s,
+		conns:     make(map[net.Conn]struct{}),
+	}
+	for level := range srv.indexes {
+		srv.indexes[level] = make(map[string]*replayQueue)
+	}
+	for i, ex := range exchanges {
+		for level := range srv.indexes {
+			key := matchKey(ex.Request, level)
+			q := srv.indexes[level][key]
+			if q == nil {
+				q = &replayQueue{}
+				srv.indexes[level][key] = q
+			}
+			q.entries = append(q.entries, i)
+		}
+	}
+	return srv
+}
+
+// matchKey identifies a request at a match level: 0 is exact, 1 ignores the
+// payload, 2 also ignores args.
+func matchKey(r Request, level int) string {
+	parts := []string{strings.ToUpper(r.Verb), strings.ToUpper(r.SubVerb)}
+	if level < 2 {
+		parts = append(parts, strings.Join(r.Args, "\x1f"))
+	}
+	if level < 1 {
+		parts = append(parts, string(r.Bytes()))
+	}
+	return strings.Join(parts, "\x1e")
+}
+
+// Match returns the exchange replayed for req, or nil.
+func (s *Server) Match(req Request) *Exchange {
+	s.sanitizer.Request(&req)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for level, index := range s.indexes {
+		if q := index[matchKey(req, level)]; q != nil {
+			return &s.exchanges[q.take()]
+		}
+	}
+	s.unmatch = append(s.unmatch, req)
+	return nil
+}
+
+// Unmatched returns the requests no fixture answered.
+func (s *Server) Unmatched() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.unmatch...)
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// Close stops accepting, closes open connections and waits for them to end.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	parser := protocol.NewParser(conn)
+	w := protocol.NewWriter(conn)
+	for {
+		cmd, err := parser.ParseCommand()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if w.WriteErr(protocol.ErrInvalidCommand, err.Error()) != nil || parser.Resync() != nil {
+				return
+			}
+			continue
+		}
+		if cmd == nil {
+			return
+		}
+
+		req := newRequest(cmd)
+		ex := s.Match(req)
+		if ex == nil {
+			if strings.EqualFold(req.Verb, protocol.VerbPing) {
+				err = w.WritePong()
+			} else {
+				log.Printf("[DEBUG] Fixture server: no fixture for %s", req)
+				err = w.WriteErr(protocol.ErrNotFound, "no fixture for "+req.String())
+			}
+		} else {
+			err = writeResponses(w, ex.Responses)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeResponses replays recorded frames.
+func writeResponses(w *protocol.Writer, responses []Response) error {
+	for _, r := range responses {
+		var err error
+		switch protocol.ResponseType(r.Type) {
+		case protocol.ResponseOK:
+			err = w.WriteOK(r.Message)
+		case protocol.ResponseErr:
+			code := protocol.ErrorCode(r.Code)
+			if code == "" && r.Structured != nil {
+				code = r.Structured.Code
+			}
+			err = w.WriteErr(code, r.Message)
+		case protocol.ResponseJSON:
+			err = w.WriteJSON(r.Bytes())
+		case protocol.ResponseData:
+			err = w.WriteData(r.Bytes())
+		case protocol.ResponseChunk:
+			err = w.WriteChunk(r.Bytes())
+		case protocol.ResponseEnd:
+			err = w.WriteEnd()
+		case protocol.ResponsePong:
+			err = w.WritePong()
+		default:
+			err = fmt.Errorf("unknown response type %q", r.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,544 @@
+// Package git runs git in a project and parses its output into structured
+// status, diffs, logs and change sets.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLogLimit is how many commits GetLog returns by default.
+	DefaultLogLimit = 20
+	// MaxLogLimit caps the commits of one GetLog.
+	MaxLogLimit = 500
+	// MaxPatchBytes caps the patch text GetDiff returns.
+	MaxPatchBytes = 64 * 1024
+)
+
+// Change kinds of a file, shared by status entries, diffs and change sets.
+const (
+	Added      = "added"
+	Modified   = "modified"
+	Deleted    = "deleted"
+	Renamed    = "renamed"
+	Copied     = "copied"
+	TypeChange = "type_changed"
+	Untracked  = "untracked"
+	Conflicted = "conflicted"
+)
+
+// Run runs git in dir and returns its output. Failures carry git's stderr.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// ValidatePaths rejects paths that aren't relative to the project or that
+// leave it, so requests stay scoped to the project.
+func ValidatePaths(paths []string) error {
+	for _, p := range paths {
+		clean := filepath.ToSlash(filepath.Clean(p))
+		if filepath.IsAbs(p) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("path %q must be inside the project", p)
+		}
+	}
+	return nil
+}
+
+// validateRef rejects refs git would read as an option.
+func validateRef(ref string) error {
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+	return nil
+}
+
+// changeKind names the change of a status letter.
+func changeKind(c byte) string {
+	switch c {
+	case 'A':
+		return Added
+	case 'M':
+		return Modified
+	case 'D':
+		return Deleted
+	case 'R':
+		return Renamed
+	case 'C':
+		return Copied
+	case 'T':
+		return TypeChange
+	case 'U':
+		return Conflicted
+	case '?':
+		return Untracked
+	}
+	return ""
+}
+
+// FileStatus is one changed file of the working tree.
+type FileStatus struct {
+	Path     string `json:"path"`
+	OldPath  string `json:"old_path,omitempty"` // Renames and copies
+	Staged   string `json:"staged,omitempty"`   // Change in the index
+	Unstaged string `json:"unstaged,omitempty"` // Change in the working tree
+}
+
+// Status is the branch and changed files of a working tree.
+type Status struct {
+	Branch     string       `json:"branch"` // "(detached)" at a detached HEAD
+	Commit     string       `json:"commit,omitempty"`
+	Upstream   string       `json:"upstream,omitempty"`
+	Ahead      int          `json:"ahead,omitempty"`
+	Behind     int          `json:"behind,omitempty"`
+	Clean      bool         `json:"clean"`
+	Staged     int          `json:"staged"`
+	Unstaged   int          `json:"unstaged"`
+	Untracked  int          `json:"untracked"`
+	Conflicted int          `json:"conflicted"`
+	Files      []FileStatus `json:"files"`
+}
+
+// GetStatus returns the status of the working tree at dir, limited to paths
+// when given.
+func GetStatus(ctx context.Context, dir string, paths []string) (*Status, error) {
+	args := []string{"status", "--porcelain=v2", "--branch", "-z", "--untracked-files=all"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	out, err := Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatus(out), nil
+}
+
+// ParseStatus parses the output of git status --porcelain=v2 --branch -z.
+func ParseStatus(out string) *Status {
+	s := &Status{Files: []FileStatus{}}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		line := fields[i]
+		switch {
+		case strings.HasPrefix(line, "# branch.oid "):
+			if oid := strings.TrimPrefix(line, "# branch.oid "); oid != "(initial)" {
+				s.Commit = oid
+			}
+		case strings.HasPrefix(line, "# branch.head "):
+			s.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			s.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &s.Ahead, &s.Behind)
+		case strings.HasPrefix(line, "1 "), strings.HasPrefix(line, "2 "):
+			// 1 XY sub mH mI mW hH hI path; 2 adds a score, then the old path
+			n := 9
+			if line[0] == '2' {
+				n = 10
+			}
+			parts := strings.SplitN(line, " ", n)
+			if len(parts) < n {
+				continue
+			}
+			f := FileStatus{Path: parts[n-1], Staged: changeKind(parts[1][0]), Unstaged: changeKind(parts[1][1])}
+			if line[0] == '2' && i+1 < len(fields) {
+				i++
+				f.OldPath = fields[i]
+			}
+			s.Files = append(s.Files, f)
+		case strings.HasPrefix(line, "u "):
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			parts := strings.SplitN(line, " ", 11)
+			if len(parts) < 11 {
+				continue
+			}
+			s.Files = append(s.Files, FileStatus{Path: parts[10], Staged: Conflicted, Unstaged: Conflicted})
+		case strings.HasPrefix(line, "? "):
+			s.Files = append(s.Files, FileStatus{Path: line[2:], Unstaged: Untracked})
+		}
+	}
+
+	for _, f := range s.Files {
+		switch {
+		case f.Staged == Conflicted:
+			s.Conflicted++
+		case f.Unstaged == Untracked:
+			s.Untracked++
+		default:
+			if f.Staged != "" {
+				s.Staged++
+			}
+			if f.Unstaged != "" {
+				s.Unstaged++
+			}
+		}
+	}
+	s.Clean = len(s.Files) == 0
+	return s
+}
+
+// DiffOptions selects what GetDiff compares.
+type DiffOptions struct {
+	Ref    string   // Compare the working tree (or index with Staged) to this commit
+	Staged bool     // The index instead of the working tree
+	Paths  []string // Only these project-relative paths
+	Patch  bool     // Include the patch text, up to MaxPatchBytes
+}
+
+// Hunk is one changed region of a file.
+type Hunk struct {
+	Header    string `json:"header"` // The @@ line, with the enclosing function when git found one
+	OldStart  int    `json:"old_start"`
+	OldLines  int    `json:"old_lines"`
+	NewStart  int    `json:"new_start"`
+	NewLines  int    `json:"new_lines"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// FileDiff is the change to one file.
+type FileDiff struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"` // Renames and copies
+	Change    string `json:"change"`
+	Binary    bool   `json:"binary,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Hunks     []Hunk `json:"hunks,omitempty"`
+}
+
+// Diff is a parsed git diff.
+type Diff struct {
+	Files          []FileDiff `json:"files"`
+	Additions      int        `json:"additions"`
+	Deletions      int        `json:"deletions"`
+	Patch          string     `json:"patch,omitempty"`
+	PatchTruncated bool       `json:"patch_truncated,omitempty"`
+}
+
+// GetDiff diffs the working tree at dir as opts selects.
+func GetDiff(ctx context.Context, dir string, opts DiffOptions) (*Diff, error) {
+	if err := validateRef(opts.Ref); err != nil {
+		return nil, err
+	}
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--find-renames"}
+	if opts.Staged {
+		args = append(args, "--cached")
+	}
+	if opts.Ref != "" {
+		args = append(args, opts.Ref)
+	}
+	args = append(append(args, "--"), opts.Paths...)
+	out, err := Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	d := ParseDiff(out)
+	if opts.Patch {
+		d.Patch = out
+		if len(d.Patch) > MaxPatchBytes {
+			d.Patch, d.PatchTruncated = d.Patch[:MaxPatchBytes], true
+		}
+	}
+	return d, nil
+}
+
+// ParseDiff parses a unified diff as git diff prints it.
+func ParseDiff(out string) *Diff {
+	d := &Diff{Files: []FileDiff{}}
+	var file *FileDiff
+	var hunk *Hunk
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			d.Files = append(d.Files, FileDiff{Change: Modified})
+			file, hunk = &d.Files[len(d.Files)-1], nil
+			// Paths come from the ---/+++ and rename lines; this covers
+			// files without them, such as binary and mode-only changes
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				file.OldPath, file.Path = strings.TrimPrefix(a, "a/"), b
+			}
+		case file == nil:
+		case hunk == nil && strings.HasPrefix(line, "new file mode"):
+			file.Change = Added
+		case hunk == nil && strings.HasPrefix(line, "deleted file mode"):
+			file.Change = Deleted
+		case hunk == nil && strings.HasPrefix(line, "rename from "):
+			file.Change, file.OldPath = Renamed, strings.TrimPrefix(line, "rename from ")
+		case hunk == nil && strings.HasPrefix(line, "rename to "):
+			file.Path = strings.TrimPrefix(line, "rename to ")
+		case hunk == nil && strings.HasPrefix(line, "copy from "):
+			file.Change, file.OldPath = Copied, strings.TrimPrefix(line, "copy from ")
+		case hunk == nil && strings.HasPrefix(line, "copy to "):
+			file.Path = strings.TrimPrefix(line, "copy to ")
+		case hunk == nil && strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+		case hunk == nil && strings.HasPrefix(line, "+++ "):
+			if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+				file.Path = strings.TrimPrefix(p, "b/")
+			}
+		case strings.HasPrefix(line, "@@ "):
+			file.Hunks = append(file.Hunks, parseHunkHeader(line))
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk == nil:
+		case strings.HasPrefix(line, "+"):
+			hunk.Additions++
+			file.Additions++
+			d.Additions++
+		case strings.HasPrefix(line, "-"):
+			hunk.Deletions++
+			file.Deletions++
+			d.Deletions++
+		}
+	}
+	for i := range d.Files {
+		if f := &d.Files[i]; f.Change != Renamed && f.Change != Copied {
+			f.OldPath = ""
+		}
+	}
+	return d
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ context". Omitted counts are 1.
+func parseHunkHeader(line string) Hunk {
+	h := Hunk{Header: line, OldLines: 1, NewLines: 1}
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return h
+	}
+	h.OldStart, h.OldLines = parseRange(strings.TrimPrefix(fields[1], "-"))
+	h.NewStart, h.NewLines = parseRange(strings.TrimPrefix(fields[2], "+"))
+	return h
+}
+
+func parseRange(s string) (start, lines int) {
+	startStr, linesStr, found := strings.Cut(s, ",")
+	start, _ = strconv.Atoi(startStr)
+	lines = 1
+	if found {
+		lines, _ = strconv.Atoi(linesStr)
+	}
+	return start, lines
+}
+
+// LogOptions selects the commits of GetLog.
+type LogOptions struct {
+	Ref    string   // Start from this commit (default: HEAD)
+	Since  string   // Only commits after this date, as git understands it ("2 days ago")
+	Author string   // Only commits whose author matches this pattern
+	Paths  []string // Only commits touching these project-relative paths
+	Limit  int      // Commits returned (default DefaultLogLimit, max MaxLogLimit)
+}
+
+// Commit is one commit of a log, with its change totals.
+type Commit struct {
+	Hash      string    `json:"hash"`
+	ShortHash string    `json:"short_hash"`
+	Author    string    `json:"author"`
+	Email     string    `json:"email"`
+	Date      time.Time `json:"date"`
+	Subject   string    `json:"subject"`
+	Files     []string  `json:"files"`
+	Additions int       `json:"additions"`
+	Deletions int       `json:"deletions"`
+}
+
+// AuthorSummary counts the commits of one author in a log.
+type AuthorSummary struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// Log is a list of commits, newest first, with their authors.
+type Log struct {
+	Commits []Commit        `json:"commits"`
+	Authors []AuthorSummary `json:"authors"` // Most commits first
+}
+
+// logFormat separates commits with RS and fields with US, so subjects can
+// hold any text.
+const logFormat = "--format=%x1e%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%s"
+
+// GetLog returns the commits opts selects.
+func GetLog(ctx context.Context, dir string, opts LogOptions) (*Log, error) {
+	if err := validateRef(opts.Ref); err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLogLimit
+	}
+	limit = min(limit, MaxLogLimit)
+
+	args := []string{"log", logFormat, "--numstat", "-z", fmt.Sprintf("--max-count=%d", limit)}
+	if opts.Since != "" {
+		args = append(args, "--since="+opts.Since)
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.Ref != "" {
+		args = append(args, opts.Ref)
+	}
+	args = append(append(args, "--"), opts.Paths...)
+	out, err := Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLog(out), nil
+}
+
+// ParseLog parses git log output in logFormat with --numstat -z.
+func ParseLog(out string) *Log {
+	l := &Log{Commits: []Commit{}, Authors: []AuthorSummary{}}
+	authors := map[string]int{} // Index in l.Authors by email
+	for _, record := range strings.Split(out, "\x1e") {
+		header, stats, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) < 6 {
+			continue
+		}
+		c := Commit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Email:     fields[3],
+			Subject:   strings.TrimRight(fields[5], "\x00"),
+			Files:     []string{},
+		}
+		c.Date, _ = time.Parse(time.RFC3339, fields[4])
+
+		// With -z each numstat is "added\tdeleted\tpath\0", or for renames
+		// "added\tdeleted\t\0old\0new\0"
+		entries := strings.Split(stats, "\x00")
+		for i := 0; i < len(entries); i++ {
+			parts := strings.SplitN(strings.TrimLeft(entries[i], "\n"), "\t", 3)
+			if len(parts) < 3 {
+				continue
+			}
+			path := parts[2]
+			if path == "" && i+2 < len(entries) {
+				path = entries[i+2]
+				i += 2
+			}
+			added, _ := strconv.Atoi(parts[0]) // "-" for binary files
+			deleted, _ := strconv.Atoi(parts[1])
+			c.Files = append(c.Files, path)
+			c.Additions += added
+			c.Deletions += deleted
+		}
+		l.Commits = append(l.Commits, c)
+
+		if i, ok := authors[c.Email]; ok {
+			l.Authors[i].Commits++
+		} else {
+			authors[c.Email] = len(l.Authors)
+			l.Authors = append(l.Authors, AuthorSummary{Name: c.Author, Email: c.Email, Commits: 1})
+		}
+	}
+	sort.SliceStable(l.Authors, func(i, j int) bool { return l.Authors[i].Commits > l.Authors[j].Commits })
+	return l
+}
+
+// ChangedFile is a file that differs from the base of a change set.
+type ChangedFile struct {
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+	Change  string `json:"change"`
+}
+
+// Changes are the files that changed since a commit, committed or not.
+type Changes struct {
+	Base    string        `json:"base"`    // Commit compared against
+	Commits int           `json:"commits"` // Commits from the base to HEAD
+	Files   []ChangedFile `json:"files"`
+}
+
+// ChangedSince returns the files that differ between the working tree at dir
+// and ref, or the last commit before since when ref is empty, including
+// untracked files.
+func ChangedSince(ctx context.Context, dir, ref, since string, paths []string) (*Changes, error) {
+	if err := validateRef(ref); err != nil {
+		return nil, err
+	}
+	base := ref
+	if base == "" && since != "" {
+		out, err := Run(ctx, dir, "rev-list", "-1", "--before="+since, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		if base = strings.TrimSpace(out); base == "" {
+			return nil, fmt.Errorf("no commit before %s", since)
+		}
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+	out, err := Run(ctx, dir, "rev-parse", "--verify", base+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	c := &Changes{Base: strings.TrimSpace(out)}
+
+	out, err = Run(ctx, dir, "rev-list", "--count", c.Base+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	c.Commits, _ = strconv.Atoi(strings.TrimSpace(out))
+
+	out, err = Run(ctx, dir, append([]string{"diff", "--name-status", "-z", "--find-renames", c.Base, "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	c.Files = ParseNameStatus(out)
+
+	status, err := GetStatus(ctx, dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range status.Files {
+		if f.Unstaged == Untracked {
+			c.Files = append(c.Files, ChangedFile{Path: f.Path, Change: Untracked})
+		}
+	}
+	return c, nil
+}
+
+// ParseNameStatus parses git diff --name-status -z output.
+func ParseNameStatus(out string) []ChangedFile {
+	files := []ChangedFile{}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		code := fields[i]
+		if code == "" {
+			continue
+		}
+		f := ChangedFile{Path: fields[i+1], Change: changeKind(code[0])}
+		// Renames and copies (R100, C75) are followed by both paths
+		if (code[0] == 'R' || code[0] == 'C') && i+2 < len(fields) {
+			f.OldPath, f.Path = fields[i+1], fields[i+2]
+			i++
+		}
+		files = append(files, f)
+	}
+	return files
+}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := "# branch.oid 2b02fe3\x00# branch.head main\x00# branch.upstream origin/main\x00# branch.ab +2 -1\x00" +
+		"1 .M N... 100644 100644 100644 aaa aaa f.txt\x00" +
+		"1 A. N... 000000 100644 100644 000 bbb new file.go\x00" +
+		"2 R. N... 100644 100644 100644 ccc ccc R100 new.txt\x00old.txt\x00" +
+		"u UU N... 100644 100644 100644 100644 d e f conflict.go\x00" +
+		"? untracked.txt\x00"
+	s := ParseStatus(out)
+
+	if s.Branch != "main" || s.Upstream != "origin/main" || s.Ahead != 2 || s.Behind != 1 || s.Commit != "2b02fe3" {
+		t.Errorf("Unexpected branch info %+v", s)
+	}
+	if s.Clean || s.Staged != 2 || s.Unstaged != 1 || s.Untracked != 1 || s.Conflicted != 1 {
+		t.Errorf("Unexpected counts %+v", s)
+	}
+	want := []FileStatus{
+		{Path: "f.txt", Unstaged: Modified},
+		{Path: "new file.go", Staged: Added},
+		{Path: "new.txt", OldPath: "old.txt", Staged: Renamed},
+		{Path: "conflict.go", Staged: Conflicted, Unstaged: Conflicted},
+		{Path: "untracked.txt", Unstaged: Untracked},
+	}
+	if len(s.Files) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), s.Files)
+	}
+	for i := range want {
+		if s.Files[i] != want[i] {
+			t.Errorf("File %d: expected %+v, got %+v", i, want[i], s.Files[i])
+		}
+	}
+
+	if s := ParseStatus("# branch.oid (initial)\x00# branch.head main\x00"); !s.Clean || s.Commit != "" {
+		t.Errorf("Expected a clean new repository, got %+v", s)
+	}
+}
+
+func TestParseDiff(t *testing.T) {
+	out := `diff --git a/f.txt b/f.txt
+index 1111111..2222222 100644
+--- a/f.txt
++++ b/f.txt
+@@ -1,3 +1,4 @@ func main() {
+ a
+-b
++B
+ c
++d
+@@ -10 +11 @@
+--- old dashes
++++ new pluses
+diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package gone
+-
+diff --git a/img.png b/img.png
+new file mode 100644
+Binary files /dev/null and b/img.png differ
+`
+	d := ParseDiff(out)
+	if len(d.Files) != 4 {
+		t.Fatalf("Expected 4 files, got %+v", d.Files)
+	}
+
+	f := d.Files[0]
+	if f.Path != "f.txt" || f.Change != Modified || f.OldPath != "" || f.Additions != 3 || f.Deletions != 2 || len(f.Hunks) != 2 {
+		t.Errorf("Unexpected modified file %+v", f)
+	}
+	if h := f.Hunks[0]; h.OldStart != 1 || h.OldLines != 3 || h.NewStart != 1 || h.NewLines != 4 || h.Additions != 2 || h.Deletions != 1 {
+		t.Errorf("Unexpected first hunk %+v", h)
+	}
+	if h := f.Hunks[1]; h.OldStart != 10 || h.OldLines != 1 || h.NewStart != 11 || h.NewLines != 1 {
+		t.Errorf("Expected omitted counts read as 1, got %+v", h)
+	}
+	if f := d.Files[1]; f.Path != "new.txt" || f.OldPath != "old.txt" || f.Change != Renamed {
+		t.Errorf("Unexpected rename %+v", f)
+	}
+	if f := d.Files[2]; f.Path != "gone.go" || f.Change != Deleted || f.Deletions != 2 {
+		t.Errorf("Unexpected deletion %+v", f)
+	}
+	if f := d.Files[3]; f.Path != "img.png" || f.Change != Added || !f.Binary {
+		t.Errorf("Unexpected binary file %+v", f)
+	}
+	if d.Additions != 3 || d.Deletions != 4 {
+		t.Errorf("Expected totals 3/4, got %d/%d", d.Additions, d.Deletions)
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	out := "\x1eaaa111\x1faaa\x1fAnn\x1fann@example.com\x1f2026-10-17T13:39:24+00:00\x1fsecond\x00\n" +
+		"2\t1\tf.txt\x000\t0\t\x00old.txt\x00new.txt\x00" +
+		"\x1ebbb222\x1fbbb\x1fBob\x1fbob@example.com\x1f2026-10-16T10:00:00+00:00\x1ffix: a\tb\x00\n" +
+		"-\t-\timg.png\x00" +
+		"\x1eccc333\x1fccc\x1fAnn\x1fann@example.com\x1f2026-10-15T10:00:00+00:00\x1ffirst\x00\n"
+	l := ParseLog(out)
+
+	if len(l.Commits) != 3 {
+		t.Fatalf("Expected 3 commits, got %+v", l.Commits)
+	}
+	c := l.Commits[0]
+	if c.Hash != "aaa111" || c.Subject != "second" || c.Additions != 2 || c.Deletions != 1 || c.Date.Day() != 17 {
+		t.Errorf("Unexpected commit %+v", c)
+	}
+	if len(c.Files) != 2 || c.Files[0] != "f.txt" || c.Files[1] != "new.txt" {
+		t.Errorf("Expected the renamed file by its new path, got %v", c.Files)
+	}
+	if c := l.Commits[1]; c.Subject != "fix: a\tb" || len(c.Files) != 1 || c.Additions != 0 {
+		t.Errorf("Unexpected binary commit %+v", c)
+	}
+	if len(l.Authors) != 2 || l.Authors[0].Name != "Ann" || l.Authors[0].Commits != 2 || l.Authors[1].Name != "Bob" {
+		t.Errorf("Unexpected authors %+v", l.Authors)
+	}
+}
+
+func TestParseNameStatus(t *testing.T) {
+	files := ParseNameStatus("M\x00f.txt\x00R087\x00old.go\x00new.go\x00A\x00added.go\x00D\x00gone.go\x00")
+	want := []ChangedFile{
+		{Path: "f.txt", Change: Modified},
+		{Path: "new.go", OldPath: "old.go", Change: Renamed},
+		{Path: "added.go", Change: Added},
+		{Path: "gone.go", Change: Deleted},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("File %d: expected %+v, got %+v", i, want[i], files[i])
+		}
+	}
+}
+
+func TestValidatePaths(t *testing.T) {
+	if err := ValidatePaths([]string{"src", "a/../b.go", "./c"}); err != nil {
+		t.Errorf("Expected project paths accepted: %v", err)
+	}
+	for _, p := range []string{"..", "../other", "a/../../b", "/etc/passwd"} {
+		if err := ValidatePaths([]string{p}); err == nil {
+			t.Errorf("Expected %q rejected", p)
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	ctx := context.Background()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := Run(ctx, dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "ann@example.com")
+	git("config", "user.name", "Ann")
+	write("f.txt", "a\nb\n")
+	git("add", ".")
+	git("commit", "-qm", "first")
+	write("f.txt", "a\nB\nc\n")
+	git("commit", "-qam", "second")
+	write("f.txt", "a\nB\nc\nd\n")
+	write("new.txt", "n\n")
+
+	status, err := GetStatus(ctx, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Unstaged != 1 || status.Untracked != 1 || status.Commit == "" {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	diff, err := GetDiff(ctx, dir, DiffOptions{Patch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Files) != 1 || diff.Additions != 1 || diff.Patch == "" {
+		t.Errorf("Unexpected diff %+v", diff)
+	}
+
+	log, err := GetLog(ctx, dir, LogOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Commits) != 1 || log.Commits[0].Subject != "second" || log.Commits[0].Additions != 2 {
+		t.Errorf("Unexpected log %+v", log)
+	}
+
+	changes, err := ChangedSince(ctx, dir, "HEAD~1", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Commits != 1 || len(changes.Files) != 2 || changes.Files[1].Change != Untracked {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+
+	if _, err := GetDiff(ctx, dir, DiffOptions{Ref: "--output=/tmp/x"}); err == nil {
+		t.Error("Expected a ref that looks like an option rejected")
+	}
+}
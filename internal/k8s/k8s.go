@@ -0,0 +1,191 @@
+// Package k8s builds kubectl invocations for port-forwards and pod logs,
+// and detects container restarts from pod listings, for dev backends that
+// run in a cluster.
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Target selects the cluster and namespace; empty fields use kubectl's
+// current context and namespace.
+type Target struct {
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// args prefixes kubectl args with the context and namespace.
+func (t Target) args(args ...string) []string {
+	var out []string
+	if t.Context != "" {
+		out = append(out, "--context", t.Context)
+	}
+	if t.Namespace != "" {
+		out = append(out, "--namespace", t.Namespace)
+	}
+	return append(out, args...)
+}
+
+// PortForwardArgs returns the kubectl args forwarding localPort on loopback
+// to port of a resource such as svc/api, deploy/api or pod/api-0.
+func (t Target) PortForwardArgs(resource string, localPort, port int) []string {
+	return t.args("port-forward", "--address", "127.0.0.1", resource, fmt.Sprintf("%d:%d", localPort, port))
+}
+
+// LogsArgs returns the kubectl args following the logs of the pods matching
+// selector, each line prefixed with its pod and container.
+func (t Target) LogsArgs(selector, container string, tail int) []string {
+	args := []string{"logs", "--follow", "--prefix", "--selector", selector, "--max-log-requests", "20", "--tail", strconv.Itoa(tail)}
+	if container != "" {
+		args = append(args, "--container", container)
+	} else {
+		args = append(args, "--all-containers")
+	}
+	return t.args(args...)
+}
+
+// PodsArgs returns the kubectl args listing the pods matching selector as JSON.
+func (t Target) PodsArgs(selector string) []string {
+	return t.args("get", "pods", "--selector", selector, "--output", "json")
+}
+
+// ValidateResource checks a port-forward resource.
+func ValidateResource(resource string) error {
+	kind, name, ok := strings.Cut(resource, "/")
+	if !ok || name == "" || strings.HasPrefix(resource, "-") {
+		return fmt.Errorf("resource must be kind/name, e.g. svc/api, got %q", resource)
+	}
+	switch kind {
+	case "svc", "service", "services", "deploy", "deployment", "deployments", "pod", "pods", "sts", "statefulset", "statefulsets", "rs", "replicaset", "replicasets":
+		return nil
+	}
+	return fmt.Errorf("cannot port-forward to %q (use svc, deploy, pod, sts or rs)", kind)
+}
+
+// Container is the state of one container of a pod.
+type Container struct {
+	Pod          string `json:"pod"`
+	Name         string `json:"name"`
+	Ready        bool   `json:"ready"`
+	State        string `json:"state"` // running, waiting or terminated
+	Reason       string `json:"reason,omitempty"`
+	RestartCount int    `json:"restart_count"`
+	// Of the previous run, which explains a restart
+	LastReason   string    `json:"last_reason,omitempty"`
+	LastExitCode int       `json:"last_exit_code,omitempty"`
+	LastFinished time.Time `json:"last_finished,omitempty"`
+}
+
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			ContainerStatuses []struct {
+				Name         string         `json:"name"`
+				Ready        bool           `json:"ready"`
+				RestartCount int            `json:"restartCount"`
+				State        containerState `json:"state"`
+				LastState    containerState `json:"lastState"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type containerState struct {
+	Running *struct{} `json:"running"`
+	Waiting *struct {
+		Reason string `json:"reason"`
+	} `json:"waiting"`
+	Terminated *struct {
+		Reason     string    `json:"reason"`
+		ExitCode   int       `json:"exitCode"`
+		FinishedAt time.Time `json:"finishedAt"`
+	} `json:"terminated"`
+}
+
+// ParsePods parses kubectl get pods -o json into containers, by pod and name.
+func ParsePods(data []byte) ([]Container, error) {
+	var list podList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid pod list: %w", err)
+	}
+	var containers []Container
+	for _, pod := range list.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			c := Container{Pod: pod.Metadata.Name, Name: cs.Name, Ready: cs.Ready, RestartCount: cs.RestartCount}
+			switch s := cs.State; {
+			case s.Running != nil:
+				c.State = "running"
+			case s.Waiting != nil:
+				c.State, c.Reason = "waiting", s.Waiting.Reason
+			case s.Terminated != nil:
+				c.State, c.Reason = "terminated", s.Terminated.Reason
+			}
+			if t := cs.LastState.Terminated; t != nil {
+				c.LastReason, c.LastExitCode, c.LastFinished = t.Reason, t.ExitCode, t.FinishedAt
+			}
+			containers = append(containers, c)
+		}
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		if containers[i].Pod != containers[j].Pod {
+			return containers[i].Pod < containers[j].Pod
+		}
+		return containers[i].Name < containers[j].Name
+	})
+	return containers, nil
+}
+
+// RestartEvent is a container restart seen between two pod listings.
+type RestartEvent struct {
+	Pod          string    `json:"pod"`
+	Container    string    `json:"container"`
+	RestartCount int       `json:"restart_count"`
+	Reason       string    `json:"reason,omitempty"` // e.g. OOMKilled, Error, Completed
+	ExitCode     int       `json:"exit_code,omitempty"`
+	State        string    `json:"state"` // e.g. waiting: CrashLoopBackOff
+	SeenAt       time.Time `json:"seen_at"`
+}
+
+// Restarts returns the containers whose restart count grew since prev, the
+// restart counts by pod/container of the previous listing. Pods missing from
+// prev are new and not reported.
+func Restarts(prev map[string]int, cur []Container, now time.Time) []RestartEvent {
+	var events []RestartEvent
+	for _, c := range cur {
+		before, ok := prev[c.Pod+"/"+c.Name]
+		if !ok || c.RestartCount <= before {
+			continue
+		}
+		state := c.State
+		if c.Reason != "" {
+			state += ": " + c.Reason
+		}
+		events = append(events, RestartEvent{
+			Pod:          c.Pod,
+			Container:    c.Name,
+			RestartCount: c.RestartCount,
+			Reason:       c.LastReason,
+			ExitCode:     c.LastExitCode,
+			State:        state,
+			SeenAt:       now,
+		})
+	}
+	return events
+}
+
+// RestartCounts indexes a listing's restart counts by pod/container.
+func RestartCounts(containers []Container) map[string]int {
+	counts := make(map[string]int, len(containers))
+	for _, c := range containers {
+		counts[c.Pod+"/"+c.Name] = c.RestartCount
+	}
+	return counts
+}
@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const podsJSON = `{
+  "items": [
+    {
+      "metadata": {"name": "api-7d9f-x2"},
+      "status": {
+        "containerStatuses": [
+          {
+            "name": "api",
+            "ready": false,
+            "restartCount": 3,
+            "state": {"waiting": {"reason": "CrashLoopBackOff"}},
+            "lastState": {"terminated": {"reason": "OOMKilled", "exitCode": 137, "finishedAt": "2026-10-17T10:00:00Z"}}
+          },
+          {"name": "sidecar", "ready": true, "restartCount": 0, "state": {"running": {}}, "lastState": {}}
+        ]
+      }
+    },
+    {
+      "metadata": {"name": "api-7d9f-a1"},
+      "status": {"containerStatuses": [{"name": "api", "ready": true, "restartCount": 1, "state": {"running": {}}, "lastState": {}}]}
+    }
+  ]
+}`
+
+func TestParsePods(t *testing.T) {
+	containers, err := ParsePods([]byte(podsJSON))
+	if err != nil {
+		t.Fatalf("ParsePods failed: %v", err)
+	}
+	if len(containers) != 3 || containers[0].Pod != "api-7d9f-a1" {
+		t.Fatalf("Expected 3 containers sorted by pod, got %+v", containers)
+	}
+	api := containers[1]
+	if api.State != "waiting" || api.Reason != "CrashLoopBackOff" || api.LastReason != "OOMKilled" || api.LastExitCode != 137 {
+		t.Errorf("Unexpected container %+v", api)
+	}
+	if _, err := ParsePods([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid output")
+	}
+}
+
+func TestRestarts(t *testing.T) {
+	containers, _ := ParsePods([]byte(podsJSON))
+	prev := map[string]int{"api-7d9f-x2/api": 2, "api-7d9f-x2/sidecar": 0}
+	now := time.Now()
+
+	events := Restarts(prev, containers, now)
+	if len(events) != 1 {
+		t.Fatalf("Expected one restart, got %+v", events)
+	}
+	e := events[0]
+	if e.Pod != "api-7d9f-x2" || e.Reason != "OOMKilled" || e.ExitCode != 137 || e.State != "waiting: CrashLoopBackOff" || e.RestartCount != 3 {
+		t.Errorf("Unexpected event %+v", e)
+	}
+
+	// The first listing is the baseline
+	if events := Restarts(RestartCounts(containers), containers, now); len(events) != 0 {
+		t.Errorf("Expected no restarts without a change, got %+v", events)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	target := Target{Context: "kind-dev", Namespace: "shop"}
+	got := strings.Join(target.PortForwardArgs("svc/api", 18080, 8080), " ")
+	if got != "--context kind-dev --namespace shop port-forward --address 127.0.0.1 svc/api 18080:8080" {
+		t.Errorf("Unexpected port-forward args %s", got)
+	}
+	got = strings.Join(Target{}.LogsArgs("app=api", "", 50), " ")
+	if got != "logs --follow --prefix --selector app=api --max-log-requests 20 --tail 50 --all-containers" {
+		t.Errorf("Unexpected logs args %s", got)
+	}
+
+	for _, ok := range []string{"svc/api", "deploy/web", "pod/api-0"} {
+		if err := ValidateResource(ok); err != nil {
+			t.Errorf("Expected %s valid, got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"api", "svc/", "configmap/x", "--help/x"} {
+		if ValidateResource(bad) == nil {
+			t.Errorf("Expected %s invalid", bad)
+		}
+	}
+}
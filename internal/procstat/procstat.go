@@ -0,0 +1,177 @@
+// Package procstat samples the CPU and memory use of a process and its
+// descendants, since dev servers and test runners work in child processes.
+package procstat
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported is returned where process usage can't be read.
+var ErrUnsupported = errors.New("process metrics not supported on this platform")
+
+// Usage is the cumulative resource use of a process tree at one moment.
+type Usage struct {
+	CPUTime   time.Duration // User plus system time
+	RSSBytes  uint64
+	Processes int
+}
+
+// entry is one process as read from the system.
+type entry struct {
+	ppid    int
+	cpuTime time.Duration
+	rss     uint64
+}
+
+// sumTree adds up root and its descendants.
+func sumTree(root int, procs map[int]entry) (Usage, error) {
+	if _, ok := procs[root]; !ok {
+		return Usage{}, errors.New("process not found")
+	}
+	children := make(map[int][]int)
+	for pid, e := range procs {
+		if pid != root {
+			children[e.ppid] = append(children[e.ppid], pid)
+		}
+	}
+
+	var u Usage
+	seen := make(map[int]bool)
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		e := procs[pid]
+		u.CPUTime += e.cpuTime
+		u.RSSBytes += e.rss
+		u.Processes++
+		queue = append(queue, children[pid]...)
+	}
+	return u, nil
+}
+
+// parsePS parses `ps -A -o pid=,ppid=,rss=,time=` output, the source where
+// /proc is absent. RSS is in KiB.
+func parsePS(out string) map[int]entry {
+	procs := make(map[int]entry)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		rss, err3 := strconv.ParseUint(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		procs[pid] = entry{ppid: ppid, cpuTime: parsePSTime(fields[3]), rss: rss * 1024}
+	}
+	return procs
+}
+
+// parsePSTime parses ps's [[dd-]hh:]mm:ss[.cc] CPU time.
+func parsePSTime(s string) time.Duration {
+	var days int
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		days, _ = strconv.Atoi(s[:i])
+		s = s[i+1:]
+	}
+	var total time.Duration
+	for _, part := range strings.Split(s, ":") {
+		secs, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + time.Duration(secs*float64(time.Second))
+	}
+	return total + time.Duration(days)*24*time.Hour
+}
+
+// Sample is a process tree's resource use over one sampling interval.
+type Sample struct {
+	Time       time.Time `json:"time"`
+	PID        int       `json:"pid"`
+	CPUPercent float64   `json:"cpu_percent"` // 100 per fully used core
+	RSSBytes   uint64    `json:"rss_bytes"`
+	Processes  int       `json:"processes"`
+}
+
+// History keeps the latest samples of one process.
+type History struct {
+	mu      sync.Mutex
+	samples []Sample // Ring buffer
+	next    int
+	full    bool
+	peakRSS uint64
+
+	lastPID   int
+	lastUsage Usage
+	lastTime  time.Time
+}
+
+// NewHistory returns a history of up to size samples.
+func NewHistory(size int) *History {
+	return &History{samples: make([]Sample, size)}
+}
+
+// Add records usage read at a time. CPU percent is measured against the
+// previous reading of the same PID, so the first sample after a (re)start
+// reports 0.
+func (h *History) Add(pid int, u Usage, at time.Time) Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Sample{Time: at, PID: pid, RSSBytes: u.RSSBytes, Processes: u.Processes}
+	if pid == h.lastPID && !h.lastTime.IsZero() && u.CPUTime >= h.lastUsage.CPUTime {
+		if wall := at.Sub(h.lastTime); wall > 0 {
+			pct := float64(u.CPUTime-h.lastUsage.CPUTime) / float64(wall) * 100
+			s.CPUPercent = float64(int(pct*10+0.5)) / 10
+		}
+	}
+	h.lastPID, h.lastUsage, h.lastTime = pid, u, at
+
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+	h.peakRSS = max(h.peakRSS, u.RSSBytes)
+	return s
+}
+
+// Samples returns the samples oldest first.
+func (h *History) Samples() []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]Sample(nil), h.samples[:h.next]...)
+	}
+	return append(append([]Sample(nil), h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// Latest returns the newest sample.
+func (h *History) Latest() (Sample, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full && h.next == 0 {
+		return Sample{}, false
+	}
+	return h.samples[(h.next-1+len(h.samples))%len(h.samples)], true
+}
+
+// PeakRSS returns the largest RSS recorded, including samples that have
+// left the history.
+func (h *History) PeakRSS() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.peakRSS
+}
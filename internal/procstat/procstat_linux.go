@@ -0,0 +1,63 @@
+//go:build linux
+
+package procstat
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc; 100 on every
+// mainstream Linux architecture.
+const clockTicks = 100
+
+// Tree returns the usage of pid and its descendants.
+func Tree(pid int) (Usage, error) {
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return Usage{}, err
+	}
+	pageSize := uint64(os.Getpagesize())
+	procs := make(map[int]entry, len(dirs))
+	for _, dir := range dirs {
+		p, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile("/proc/" + dir.Name() + "/stat")
+		if err != nil {
+			continue // Exited meanwhile
+		}
+		if e, ok := parseStat(string(data), pageSize); ok {
+			procs[p] = e
+		}
+	}
+	return sumTree(pid, procs)
+}
+
+// parseStat parses /proc/<pid>/stat. The command name is parenthesized
+// and may hold spaces, so fields are counted from its closing paren.
+func parseStat(stat string, pageSize uint64) (entry, bool) {
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return entry{}, false
+	}
+	// state ppid pgrp session tty_nr tpgid flags minflt cminflt majflt
+	// cmajflt utime stime cutime cstime priority nice num_threads
+	// itrealvalue starttime vsize rss
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 22 {
+		return entry{}, false
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	rss, _ := strconv.ParseUint(fields[21], 10, 64)
+	return entry{
+		ppid:    ppid,
+		cpuTime: time.Duration(utime+stime) * time.Second / clockTicks,
+		rss:     rss * pageSize,
+	}, true
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package procstat
+
+import "os/exec"
+
+// Tree returns the usage of pid and its descendants.
+func Tree(pid int) (Usage, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=,time=").Output()
+	if err != nil {
+		return Usage{}, err
+	}
+	return sumTree(pid, parsePS(string(out)))
+}
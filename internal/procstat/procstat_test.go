@@ -0,0 +1,85 @@
+package procstat
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSumTree(t *testing.T) {
+	procs := map[int]entry{
+		1:  {ppid: 0, cpuTime: time.Hour, rss: 1 << 30},
+		10: {ppid: 1, cpuTime: time.Second, rss: 100},
+		11: {ppid: 10, cpuTime: 2 * time.Second, rss: 200},
+		12: {ppid: 11, cpuTime: 3 * time.Second, rss: 300},
+		20: {ppid: 1, cpuTime: time.Minute, rss: 999},
+	}
+	u, err := sumTree(10, procs)
+	if err != nil {
+		t.Fatalf("sumTree failed: %v", err)
+	}
+	if u.CPUTime != 6*time.Second || u.RSSBytes != 600 || u.Processes != 3 {
+		t.Errorf("Expected the subtree of 10, got %+v", u)
+	}
+	if _, err := sumTree(99, procs); err == nil {
+		t.Error("Expected an error for a missing process")
+	}
+}
+
+func TestParsePS(t *testing.T) {
+	procs := parsePS("  100     1  2048 00:01:02.50\n  101   100   512 1-02:00:00\ngarbage\n")
+	if e := procs[100]; e.ppid != 1 || e.rss != 2048*1024 || e.cpuTime != 62500*time.Millisecond {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	if e := procs[101]; e.cpuTime != 26*time.Hour {
+		t.Errorf("Expected days in the CPU time, got %v", e.cpuTime)
+	}
+	if len(procs) != 2 {
+		t.Errorf("Expected 2 entries, got %d", len(procs))
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(3)
+	if _, ok := h.Latest(); ok {
+		t.Error("Expected no sample in a new history")
+	}
+	start := time.Now()
+	h.Add(42, Usage{CPUTime: time.Second, RSSBytes: 500}, start)
+	s := h.Add(42, Usage{CPUTime: 1500 * time.Millisecond, RSSBytes: 800}, start.Add(time.Second))
+	if s.CPUPercent != 50 {
+		t.Errorf("Expected 50%% CPU, got %v", s.CPUPercent)
+	}
+
+	// A restart resets the CPU baseline
+	s = h.Add(43, Usage{CPUTime: 100 * time.Millisecond, RSSBytes: 300}, start.Add(2*time.Second))
+	if s.CPUPercent != 0 {
+		t.Errorf("Expected 0%% CPU after a PID change, got %v", s.CPUPercent)
+	}
+	h.Add(43, Usage{CPUTime: 2100 * time.Millisecond, RSSBytes: 400}, start.Add(3*time.Second))
+
+	samples := h.Samples()
+	if len(samples) != 3 || samples[0].RSSBytes != 800 || samples[2].CPUPercent != 200 {
+		t.Errorf("Expected the last 3 samples oldest first, got %+v", samples)
+	}
+	if h.PeakRSS() != 800 {
+		t.Errorf("Expected peak RSS 800, got %d", h.PeakRSS())
+	}
+	if latest, _ := h.Latest(); latest.RSSBytes != 400 {
+		t.Errorf("Unexpected latest sample %+v", latest)
+	}
+}
+
+func TestTree(t *testing.T) {
+	u, err := Tree(os.Getpid())
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	if u.Processes < 1 || u.RSSBytes == 0 {
+		t.Errorf("Expected this process's usage, got %+v", u)
+	}
+}
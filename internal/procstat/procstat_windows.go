@@ -0,0 +1,8 @@
+//go:build windows
+
+package procstat
+
+// Tree returns the usage of pid and its descendants.
+func Tree(pid int) (Usage, error) {
+	return Usage{}, ErrUnsupported
+}
@@ -0,0 +1,206 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Kinds of profile that can be captured.
+const (
+	KindCPU  = "cpu"
+	KindHeap = "heap"
+)
+
+// NodeInspectorFlag makes Node open the inspector on a free local port.
+// The chosen address is printed as "Debugger listening on ws://...".
+const NodeInspectorFlag = "--inspect=127.0.0.1:0"
+
+// heapSamplingInterval is the average bytes between heap samples in Node.
+const heapSamplingInterval = 32 * 1024
+
+var inspectorURL = regexp.MustCompile(`Debugger listening on (ws://\S+)`)
+
+// InspectorURL returns the last Node inspector WebSocket URL printed in output.
+func InspectorURL(output string) string {
+	matches := inspectorURL.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// WithNodeInspector returns env with NodeInspectorFlag added to NODE_OPTIONS,
+// keeping any options already set.
+func WithNodeInspector(env []string) []string {
+	out := make([]string, 0, len(env)+1)
+	found := false
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "NODE_OPTIONS="); ok {
+			found = true
+			if !strings.Contains(value, "--inspect") {
+				kv = strings.TrimSpace("NODE_OPTIONS=" + value + " " + NodeInspectorFlag)
+			}
+		}
+		out = append(out, kv)
+	}
+	if !found {
+		out = append(out, "NODE_OPTIONS="+NodeInspectorFlag)
+	}
+	return out
+}
+
+// HasPprof reports whether baseURL serves the net/http/pprof index.
+func HasPprof(ctx context.Context, client *http.Client, baseURL string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/debug/pprof/", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.StatusCode == http.StatusOK
+}
+
+// FetchPprof downloads a profile from a net/http/pprof endpoint. CPU profiles
+// sample for the given duration.
+func FetchPprof(ctx context.Context, client *http.Client, baseURL, kind string, duration time.Duration) ([]byte, error) {
+	endpoint := strings.TrimRight(baseURL, "/") + "/debug/pprof/"
+	switch kind {
+	case KindCPU:
+		seconds := int(duration.Round(time.Second) / time.Second)
+		if seconds < 1 {
+			seconds = 1
+		}
+		endpoint += fmt.Sprintf("profile?seconds=%d", seconds)
+	case KindHeap:
+		endpoint += "heap"
+	default:
+		return nil, fmt.Errorf("unknown profile kind %q", kind)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// CaptureNode records a profile through the Node inspector at wsURL: a CPU
+// profile, or a sampling heap profile of allocations still live at the end.
+// Returns the profile JSON (.cpuprofile or .heapprofile format).
+func CaptureNode(ctx context.Context, wsURL, kind string, duration time.Duration) ([]byte, error) {
+	var enable, start, stop, disable string
+	var startParams interface{}
+	switch kind {
+	case KindCPU:
+		enable, start, stop, disable = "Profiler.enable", "Profiler.start", "Profiler.stop", "Profiler.disable"
+	case KindHeap:
+		enable, start, stop, disable = "HeapProfiler.enable", "HeapProfiler.startSampling", "HeapProfiler.stopSampling", "HeapProfiler.disable"
+		startParams = map[string]interface{}{"samplingInterval": heapSamplingInterval}
+	default:
+		return nil, fmt.Errorf("unknown profile kind %q", kind)
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to Node inspector at %s: %w", wsURL, err)
+	}
+	defer conn.Close()
+
+	s := &cdpSession{conn: conn}
+	if _, err := s.call(ctx, enable, nil); err != nil {
+		return nil, err
+	}
+	defer s.call(context.Background(), disable, nil)
+	if _, err := s.call(ctx, start, startParams); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	result, err := s.call(ctx, stop, nil)
+	if err != nil {
+		return nil, err
+	}
+	var stopped struct {
+		Profile json.RawMessage `json:"profile"`
+	}
+	if err := json.Unmarshal(result, &stopped); err != nil || len(stopped.Profile) == 0 {
+		return nil, fmt.Errorf("%s returned no profile", stop)
+	}
+	return stopped.Profile, nil
+}
+
+// cdpSession issues sequential Chrome DevTools Protocol calls.
+type cdpSession struct {
+	conn   *websocket.Conn
+	nextID int
+}
+
+// call sends a method and waits for its response, skipping events.
+func (s *cdpSession) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.nextID++
+	id := s.nextID
+	msg := map[string]interface{}{"id": id, "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetWriteDeadline(deadline)
+	s.conn.SetReadDeadline(deadline)
+
+	if err := s.conn.WriteJSON(msg); err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	for {
+		var resp struct {
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := s.conn.ReadJSON(&resp); err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		if resp.ID != id {
+			continue
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s: %s", method, resp.Error.Message)
+		}
+		return resp.Result, nil
+	}
+}
@@ -0,0 +1,102 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// nodeCallFrame is a V8 call frame in CPU and sampling heap profiles.
+type nodeCallFrame struct {
+	FunctionName string `json:"functionName"`
+	URL          string `json:"url"`
+	LineNumber   int64  `json:"lineNumber"` // 0-based
+}
+
+func (cf nodeCallFrame) key() funcKey {
+	name := cf.FunctionName
+	if name == "" {
+		name = "(anonymous)"
+	}
+	return funcKey{name: name, file: cf.URL}
+}
+
+// skippedNodeFrames carry no useful attribution in summaries.
+var skippedNodeFrames = map[string]bool{"(root)": true, "(idle)": true}
+
+// SummarizeNodeCPU summarizes a V8 .cpuprofile (Profiler.stop result).
+// Values are sample counts.
+func SummarizeNodeCPU(data []byte, n int) (*Summary, error) {
+	var prof struct {
+		Nodes []struct {
+			ID        int           `json:"id"`
+			CallFrame nodeCallFrame `json:"callFrame"`
+			HitCount  int64         `json:"hitCount"`
+			Children  []int         `json:"children"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &prof); err != nil {
+		return nil, fmt.Errorf("invalid cpuprofile: %w", err)
+	}
+
+	parent := make(map[int]int, len(prof.Nodes))
+	frames := make(map[int]nodeCallFrame, len(prof.Nodes))
+	for _, node := range prof.Nodes {
+		frames[node.ID] = node.CallFrame
+		for _, child := range node.Children {
+			parent[child] = node.ID
+		}
+	}
+
+	acc := newAccumulator()
+	for _, node := range prof.Nodes {
+		if node.HitCount == 0 || skippedNodeFrames[node.CallFrame.FunctionName] {
+			continue
+		}
+		var stack []funcKey
+		var lines []int64
+		for id, ok := node.ID, true; ok; id, ok = parent[id] {
+			cf := frames[id]
+			if skippedNodeFrames[cf.FunctionName] {
+				continue
+			}
+			stack = append(stack, cf.key())
+			lines = append(lines, cf.LineNumber+1)
+		}
+		acc.add(stack, lines, node.HitCount)
+	}
+	return acc.summary("cpu", "samples", n), nil
+}
+
+// nodeHeapNode is a node of a V8 sampling heap profile.
+type nodeHeapNode struct {
+	CallFrame nodeCallFrame  `json:"callFrame"`
+	SelfSize  int64          `json:"selfSize"`
+	Children  []nodeHeapNode `json:"children"`
+}
+
+// SummarizeNodeHeap summarizes a V8 sampling heap profile
+// (HeapProfiler.stopSampling result). Values are bytes.
+func SummarizeNodeHeap(data []byte, n int) (*Summary, error) {
+	var prof struct {
+		Head nodeHeapNode `json:"head"`
+	}
+	if err := json.Unmarshal(data, &prof); err != nil {
+		return nil, fmt.Errorf("invalid heapprofile: %w", err)
+	}
+
+	acc := newAccumulator()
+	var walk func(node *nodeHeapNode, stack []funcKey, lines []int64)
+	walk = func(node *nodeHeapNode, stack []funcKey, lines []int64) {
+		if !skippedNodeFrames[node.CallFrame.FunctionName] {
+			// Prepend so the leaf comes first
+			stack = append([]funcKey{node.CallFrame.key()}, stack...)
+			lines = append([]int64{node.CallFrame.LineNumber + 1}, lines...)
+			acc.add(stack, lines, node.SelfSize)
+		}
+		for i := range node.Children {
+			walk(&node.Children[i], stack, lines)
+		}
+	}
+	walk(&prof.Head, nil, nil)
+	return acc.summary("inuse_space", "bytes", n), nil
+}
@@ -0,0 +1,407 @@
+// Package profile captures CPU and heap profiles from running Go and Node
+// processes and summarizes them as top-N function tables.
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one function in a profile summary.
+type Entry struct {
+	Function string  `json:"function"`
+	File     string  `json:"file,omitempty"`
+	Line     int64   `json:"line,omitempty"`
+	Flat     int64   `json:"flat"`
+	FlatPct  float64 `json:"flat_pct"`
+	Cum      int64   `json:"cum"`
+	CumPct   float64 `json:"cum_pct"`
+}
+
+// Summary is the top-N view of a profile.
+type Summary struct {
+	SampleType string  `json:"sample_type"` // e.g. "cpu", "inuse_space"
+	Unit       string  `json:"unit"`        // e.g. "nanoseconds", "bytes"
+	Total      int64   `json:"total"`
+	Top        []Entry `json:"top"`
+}
+
+// funcKey identifies a function across samples.
+type funcKey struct {
+	name string
+	file string
+}
+
+// accumulator builds a Summary from stacks of functions, leaf first.
+type accumulator struct {
+	flat  map[funcKey]int64
+	cum   map[funcKey]int64
+	lines map[funcKey]int64
+	total int64
+}
+
+func newAccumulator() *accumulator {
+	return &accumulator{
+		flat:  make(map[funcKey]int64),
+		cum:   make(map[funcKey]int64),
+		lines: make(map[funcKey]int64),
+	}
+}
+
+// add records value for a stack whose first element is the leaf.
+func (a *accumulator) add(stack []funcKey, lines []int64, value int64) {
+	if value == 0 || len(stack) == 0 {
+		return
+	}
+	a.total += value
+	a.flat[stack[0]] += value
+	seen := make(map[funcKey]bool, len(stack))
+	for i, fn := range stack {
+		if _, ok := a.lines[fn]; !ok && i < len(lines) {
+			a.lines[fn] = lines[i]
+		}
+		if seen[fn] {
+			continue // Recursion counts once toward cumulative
+		}
+		seen[fn] = true
+		a.cum[fn] += value
+	}
+}
+
+// summary returns the n functions with the highest flat value.
+func (a *accumulator) summary(sampleType, unit string, n int) *Summary {
+	s := &Summary{SampleType: sampleType, Unit: unit, Total: a.total}
+	for fn, cum := range a.cum {
+		s.Top = append(s.Top, Entry{
+			Function: fn.name,
+			File:     fn.file,
+			Line:     a.lines[fn],
+			Flat:     a.flat[fn],
+			FlatPct:  pct(a.flat[fn], a.total),
+			Cum:      cum,
+			CumPct:   pct(cum, a.total),
+		})
+	}
+	sort.Slice(s.Top, func(i, j int) bool {
+		if s.Top[i].Flat != s.Top[j].Flat {
+			return s.Top[i].Flat > s.Top[j].Flat
+		}
+		if s.Top[i].Cum != s.Top[j].Cum {
+			return s.Top[i].Cum > s.Top[j].Cum
+		}
+		return s.Top[i].Function < s.Top[j].Function
+	})
+	if n > 0 && len(s.Top) > n {
+		s.Top = s.Top[:n]
+	}
+	return s
+}
+
+func pct(v, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(int64(float64(v)*10000/float64(total))) / 100
+}
+
+// SummarizePprof summarizes a pprof profile (gzipped or raw protobuf), using
+// the last sample type, which is the one `go tool pprof` shows by default
+// (cpu for CPU profiles, inuse_space for heap profiles).
+func SummarizePprof(data []byte, n int) (*Summary, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip profile: %w", err)
+		}
+	}
+
+	p, err := decodeProfile(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.sampleTypes) == 0 {
+		return nil, errors.New("profile has no sample types")
+	}
+	valueIndex := len(p.sampleTypes) - 1
+	st := p.sampleTypes[valueIndex]
+
+	functions := make(map[uint64]funcKey, len(p.functions))
+	for _, f := range p.functions {
+		functions[f.id] = funcKey{name: p.str(f.name), file: p.str(f.filename)}
+	}
+	locations := make(map[uint64][]pprofLine, len(p.locations))
+	for _, l := range p.locations {
+		locations[l.id] = l.lines
+	}
+
+	acc := newAccumulator()
+	for _, s := range p.samples {
+		if valueIndex >= len(s.values) {
+			continue
+		}
+		var stack []funcKey
+		var lines []int64
+		for _, locID := range s.locationIDs {
+			// Inlined functions come first within a location
+			for _, ln := range locations[locID] {
+				fn, ok := functions[ln.functionID]
+				if !ok {
+					continue
+				}
+				stack = append(stack, fn)
+				lines = append(lines, ln.line)
+			}
+		}
+		acc.add(stack, lines, s.values[valueIndex])
+	}
+	return acc.summary(p.str(st.typ), p.str(st.unit), n), nil
+}
+
+// The types below mirror the parts of profile.proto used for summaries.
+// See https://github.com/google/pprof/blob/main/proto/profile.proto.
+
+type pprofValueType struct{ typ, unit int64 }
+
+type pprofSample struct {
+	locationIDs []uint64
+	values      []int64
+}
+
+type pprofLine struct {
+	functionID uint64
+	line       int64
+}
+
+type pprofLocation struct {
+	id    uint64
+	lines []pprofLine
+}
+
+type pprofFunction struct {
+	id       uint64
+	name     int64
+	filename int64
+}
+
+type pprofProfile struct {
+	sampleTypes []pprofValueType
+	samples     []pprofSample
+	locations   []pprofLocation
+	functions   []pprofFunction
+	strings     []string
+}
+
+func (p *pprofProfile) str(i int64) string {
+	if i < 0 || int(i) >= len(p.strings) {
+		return ""
+	}
+	return p.strings[i]
+}
+
+func decodeProfile(data []byte) (*pprofProfile, error) {
+	p := &pprofProfile{}
+	err := eachField(data, func(field int, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1: // sample_type
+			var vt pprofValueType
+			err := eachField(b, func(f, _ int, v uint64, _ []byte) error {
+				switch f {
+				case 1:
+					vt.typ = int64(v)
+				case 2:
+					vt.unit = int64(v)
+				}
+				return nil
+			})
+			p.sampleTypes = append(p.sampleTypes, vt)
+			return err
+		case 2: // sample
+			var s pprofSample
+			err := eachField(b, func(f, w int, v uint64, sb []byte) error {
+				switch f {
+				case 1:
+					return appendVarints(w, v, sb, func(x uint64) { s.locationIDs = append(s.locationIDs, x) })
+				case 2:
+					return appendVarints(w, v, sb, func(x uint64) { s.values = append(s.values, int64(x)) })
+				}
+				return nil
+			})
+			p.samples = append(p.samples, s)
+			return err
+		case 4: // location
+			var l pprofLocation
+			err := eachField(b, func(f, _ int, v uint64, lb []byte) error {
+				switch f {
+				case 1:
+					l.id = v
+				case 4:
+					var ln pprofLine
+					err := eachField(lb, func(f, _ int, v uint64, _ []byte) error {
+						switch f {
+						case 1:
+							ln.functionID = v
+						case 2:
+							ln.line = int64(v)
+						}
+						return nil
+					})
+					l.lines = append(l.lines, ln)
+					return err
+				}
+				return nil
+			})
+			p.locations = append(p.locations, l)
+			return err
+		case 5: // function
+			var fn pprofFunction
+			err := eachField(b, func(f, _ int, v uint64, _ []byte) error {
+				switch f {
+				case 1:
+					fn.id = v
+				case 2:
+					fn.name = int64(v)
+				case 4:
+					fn.filename = int64(v)
+				}
+				return nil
+			})
+			p.functions = append(p.functions, fn)
+			return err
+		case 6: // string_table
+			p.strings = append(p.strings, string(b))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid pprof profile: %w", err)
+	}
+	return p, nil
+}
+
+// appendVarints handles a repeated integer field that may be packed.
+func appendVarints(wire int, v uint64, b []byte, add func(uint64)) error {
+	if wire == wireVarint {
+		add(v)
+		return nil
+	}
+	for len(b) > 0 {
+		x, n := readVarint(b)
+		if n == 0 {
+			return errors.New("truncated packed varint")
+		}
+		add(x)
+		b = b[n:]
+	}
+	return nil
+}
+
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+// eachField walks the fields of a protobuf message. Varint fields pass their
+// value in v; length-delimited fields pass their contents in b.
+func eachField(data []byte, fn func(field, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		if n == 0 {
+			return errors.New("truncated field key")
+		}
+		data = data[n:]
+		field, wire := int(key>>3), int(key&7)
+
+		var (
+			v uint64
+			b []byte
+		)
+		switch wire {
+		case wireVarint:
+			v, n = readVarint(data)
+			if n == 0 {
+				return errors.New("truncated varint")
+			}
+			data = data[n:]
+		case wire64:
+			if len(data) < 8 {
+				return errors.New("truncated fixed64")
+			}
+			data = data[8:]
+			continue
+		case wireBytes:
+			l, n := readVarint(data)
+			if n == 0 || uint64(len(data)-n) < l {
+				return errors.New("truncated bytes")
+			}
+			b = data[n : n+int(l)]
+			data = data[n+int(l):]
+		case wire32:
+			if len(data) < 4 {
+				return errors.New("truncated fixed32")
+			}
+			data = data[4:]
+			continue
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readVarint(b []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		x |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// Table renders the summary like `go tool pprof -top`: flat and cumulative
+// values with their share of the total, one function per line.
+func (s *Summary) Table() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s total %s\n", s.SampleType, formatValue(s.Total, s.Unit))
+	fmt.Fprintf(&b, "%10s %7s %10s %7s  %s\n", "flat", "flat%", "cum", "cum%", "function")
+	for _, e := range s.Top {
+		fmt.Fprintf(&b, "%10s %6.2f%% %10s %6.2f%%  %s\n",
+			formatValue(e.Flat, s.Unit), e.FlatPct, formatValue(e.Cum, s.Unit), e.CumPct, e.Function)
+	}
+	return b.String()
+}
+
+// formatValue renders a sample value in its unit.
+func formatValue(v int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(v).Round(time.Microsecond * 10).String()
+	case "bytes":
+		switch {
+		case v >= 1<<30:
+			return fmt.Sprintf("%.2fGB", float64(v)/(1<<30))
+		case v >= 1<<20:
+			return fmt.Sprintf("%.2fMB", float64(v)/(1<<20))
+		case v >= 1<<10:
+			return fmt.Sprintf("%.2fkB", float64(v)/(1<<10))
+		}
+		return fmt.Sprintf("%dB", v)
+	}
+	return fmt.Sprintf("%d", v)
+}
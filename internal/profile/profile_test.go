@@ -0,0 +1,225 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var heapSink [][]byte
+
+//go:noinline
+func allocateForProfile() {
+	for i := 0; i < 64; i++ {
+		heapSink = append(heapSink, make([]byte, 64*1024))
+	}
+}
+
+func TestSummarizePprof_Heap(t *testing.T) {
+	old := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() { runtime.MemProfileRate = old; heapSink = nil }()
+
+	allocateForProfile()
+	runtime.GC()
+
+	var buf bytes.Buffer
+	if err := rpprof.WriteHeapProfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := SummarizePprof(buf.Bytes(), 10)
+	if err != nil {
+		t.Fatalf("SummarizePprof: %v", err)
+	}
+	if s.SampleType != "inuse_space" || s.Unit != "bytes" {
+		t.Errorf("sample type = %s/%s, want inuse_space/bytes", s.SampleType, s.Unit)
+	}
+	if len(s.Top) == 0 || len(s.Top) > 10 {
+		t.Fatalf("got %d entries, want 1..10", len(s.Top))
+	}
+	found := false
+	for _, e := range s.Top {
+		if strings.HasSuffix(e.Function, "allocateForProfile") {
+			found = true
+			if e.Flat < 64*64*1024 {
+				t.Errorf("allocateForProfile flat = %d, want >= %d", e.Flat, 64*64*1024)
+			}
+			if !strings.HasSuffix(e.File, "profile_test.go") || e.Line == 0 {
+				t.Errorf("location = %s:%d", e.File, e.Line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("allocateForProfile not in top entries: %+v", s.Top)
+	}
+}
+
+func TestSummaryTable(t *testing.T) {
+	s := &Summary{SampleType: "cpu", Unit: "nanoseconds", Total: 2e9, Top: []Entry{
+		{Function: "main.work", Flat: 15e8, FlatPct: 75, Cum: 2e9, CumPct: 100},
+	}}
+	table := s.Table()
+	for _, want := range []string{"cpu total 2s", "flat%", "1.5s  75.00%", "main.work"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table missing %q:\n%s", want, table)
+		}
+	}
+	heap := &Summary{SampleType: "inuse_space", Unit: "bytes", Total: 3 << 20}
+	if !strings.Contains(heap.Table(), "inuse_space total 3.00MB") {
+		t.Errorf("unexpected heap table:\n%s", heap.Table())
+	}
+}
+
+func TestSummarizePprof_Invalid(t *testing.T) {
+	if _, err := SummarizePprof([]byte{0x0a, 0xff}, 10); err == nil {
+		t.Error("expected error for truncated profile")
+	}
+}
+
+func TestSummarizeNodeCPU(t *testing.T) {
+	data := []byte(`{"nodes":[
+		{"id":1,"callFrame":{"functionName":"(root)","url":"","lineNumber":-1},"hitCount":0,"children":[2,5]},
+		{"id":2,"callFrame":{"functionName":"main","url":"file:///app/index.js","lineNumber":0},"hitCount":1,"children":[3]},
+		{"id":3,"callFrame":{"functionName":"render","url":"file:///app/render.js","lineNumber":9},"hitCount":6,"children":[4]},
+		{"id":4,"callFrame":{"functionName":"","url":"file:///app/render.js","lineNumber":20},"hitCount":3},
+		{"id":5,"callFrame":{"functionName":"(idle)","url":"","lineNumber":-1},"hitCount":90}
+	]}`)
+	s, err := SummarizeNodeCPU(data, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Total != 10 {
+		t.Fatalf("total = %d, want 10 (idle excluded)", s.Total)
+	}
+	if s.Top[0].Function != "render" || s.Top[0].Flat != 6 || s.Top[0].Cum != 9 || s.Top[0].Line != 10 {
+		t.Errorf("top = %+v, want render flat 6 cum 9 line 10", s.Top[0])
+	}
+	for _, e := range s.Top {
+		if e.Function == "main" && (e.Cum != 10 || e.CumPct != 100) {
+			t.Errorf("main = %+v, want cum 10 (100%%)", e)
+		}
+	}
+}
+
+func TestSummarizeNodeHeap(t *testing.T) {
+	data := []byte(`{"head":{"callFrame":{"functionName":"(root)"},"selfSize":0,"children":[
+		{"callFrame":{"functionName":"load","url":"file:///app/a.js","lineNumber":4},"selfSize":100,"children":[
+			{"callFrame":{"functionName":"parse","url":"file:///app/b.js","lineNumber":1},"selfSize":300}
+		]}
+	]}}`)
+	s, err := SummarizeNodeHeap(data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Total != 400 || s.Unit != "bytes" {
+		t.Errorf("total = %d %s, want 400 bytes", s.Total, s.Unit)
+	}
+	if len(s.Top) != 1 || s.Top[0].Function != "parse" || s.Top[0].FlatPct != 75 {
+		t.Errorf("top = %+v, want parse at 75%%", s.Top)
+	}
+}
+
+func TestInspectorURL(t *testing.T) {
+	out := "Debugger listening on ws://127.0.0.1:9229/old\n" +
+		"restarting\nDebugger listening on ws://127.0.0.1:40123/3f2a-11\nFor help, see: https://nodejs.org/en/docs/inspector\n"
+	if got := InspectorURL(out); got != "ws://127.0.0.1:40123/3f2a-11" {
+		t.Errorf("InspectorURL = %q", got)
+	}
+	if got := InspectorURL("listening on http://localhost:3000"); got != "" {
+		t.Errorf("InspectorURL = %q, want empty", got)
+	}
+}
+
+func TestWithNodeInspector(t *testing.T) {
+	env := WithNodeInspector([]string{"PATH=/bin", "NODE_OPTIONS=--max-old-space-size=4096"})
+	if env[1] != "NODE_OPTIONS=--max-old-space-size=4096 "+NodeInspectorFlag {
+		t.Errorf("NODE_OPTIONS = %q", env[1])
+	}
+	env = WithNodeInspector([]string{"NODE_OPTIONS=--inspect=9230"})
+	if env[0] != "NODE_OPTIONS=--inspect=9230" {
+		t.Errorf("existing --inspect overridden: %q", env[0])
+	}
+	env = WithNodeInspector([]string{"PATH=/bin"})
+	if len(env) != 2 || env[1] != "NODE_OPTIONS="+NodeInspectorFlag {
+		t.Errorf("env = %v", env)
+	}
+}
+
+func TestFetchPprof_Heap(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	if !HasPprof(ctx, srv.Client(), srv.URL) {
+		t.Fatal("HasPprof = false for pprof server")
+	}
+	data, err := FetchPprof(ctx, srv.Client(), srv.URL, KindHeap, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SummarizePprof(data, 5); err != nil {
+		t.Errorf("fetched heap profile does not parse: %v", err)
+	}
+
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	if HasPprof(ctx, plain.Client(), plain.URL) {
+		t.Error("HasPprof = true for server without pprof")
+	}
+}
+
+func TestCaptureNode_CPU(t *testing.T) {
+	var methods []string
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			methods = append(methods, msg.Method)
+			// Events interleave with responses
+			conn.WriteJSON(map[string]interface{}{"method": "Profiler.consoleProfileStarted"})
+			result := map[string]interface{}{}
+			if msg.Method == "Profiler.stop" {
+				result["profile"] = map[string]interface{}{"nodes": []interface{}{}}
+			}
+			conn.WriteJSON(map[string]interface{}{"id": msg.ID, "result": result})
+		}
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	data, err := CaptureNode(context.Background(), wsURL, KindCPU, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prof map[string]json.RawMessage
+	if err := json.Unmarshal(data, &prof); err != nil || prof["nodes"] == nil {
+		t.Errorf("profile = %s", data)
+	}
+	want := "Profiler.enable Profiler.start Profiler.stop"
+	if got := strings.Join(methods[:3], " "); got != want {
+		t.Errorf("methods = %q, want %q", got, want)
+	}
+}
@@ -235,6 +235,234 @@ func DefaultPythonCommands() []CommandDef {
 	}
 }
 
+// PythonCommandsFor returns the default Python commands run through a
+// dependency manager (poetry, uv or pipenv), or plain ones for pip.
+func PythonCommandsFor(manager string) []CommandDef {
+	cmds := DefaultPythonCommands()
+	if manager == "" {
+		return cmds
+	}
+	for i := range cmds {
+		switch cmds[i].Name {
+		case "install", "install-dev":
+			cmds[i].Description = "Install dependencies with " + manager
+			cmds[i].Command = manager
+			switch {
+			case manager == "uv":
+				cmds[i].Args = []string{"sync"}
+			case manager == "pipenv" && cmds[i].Name == "install-dev":
+				cmds[i].Args = []string{"install", "--dev"}
+			default:
+				cmds[i].Args = []string{"install"}
+			}
+		default:
+			cmds[i].Args = append([]string{"run", cmds[i].Command}, cmds[i].Args...)
+			cmds[i].Command = manager
+		}
+	}
+	return cmds
+}
+
+// DefaultRustCommands returns the default commands for a Rust project.
+func DefaultRustCommands() []CommandDef {
+	return []CommandDef{
+		{
+			Name:        "test",
+			Description: "Run cargo tests",
+			Command:     "cargo",
+			Args:        []string{"test"},
+			Timeout:     600,
+		},
+		{
+			Name:        "build",
+			Description: "Build the crate",
+			Command:     "cargo",
+			Args:        []string{"build"},
+			Timeout:     600,
+		},
+		{
+			Name:        "check",
+			Description: "Type-check without building",
+			Command:     "cargo",
+			Args:        []string{"check"},
+			Timeout:     300,
+		},
+		{
+			Name:        "lint",
+			Description: "Run clippy",
+			Command:     "cargo",
+			Args:        []string{"clippy", "--all-targets"},
+			Timeout:     300,
+		},
+		{
+			Name:        "fmt-check",
+			Description: "Check formatting with rustfmt",
+			Command:     "cargo",
+			Args:        []string{"fmt", "--check"},
+			Timeout:     60,
+		},
+		{
+			Name:        "run",
+			Description: "Run the main binary",
+			Command:     "cargo",
+			Args:        []string{"run"},
+			Persistent:  true,
+		},
+	}
+}
+
+// DefaultGradleCommands returns the default commands for a Gradle project;
+// gradle is ./gradlew when the project has the wrapper.
+func DefaultGradleCommands(gradle string, springBoot bool) []CommandDef {
+	run := "run"
+	if springBoot {
+		run = "bootRun"
+	}
+	return []CommandDef{
+		{
+			Name:        "test",
+			Description: "Run tests",
+			Command:     gradle,
+			Args:        []string{"test"},
+			Timeout:     900,
+		},
+		{
+			Name:        "build",
+			Description: "Build the project",
+			Command:     gradle,
+			Args:        []string{"build", "-x", "test"},
+			Timeout:     900,
+		},
+		{
+			Name:        "lint",
+			Description: "Run verification tasks",
+			Command:     gradle,
+			Args:        []string{"check", "-x", "test"},
+			Timeout:     600,
+		},
+		{
+			Name:        "clean",
+			Description: "Delete build outputs",
+			Command:     gradle,
+			Args:        []string{"clean"},
+			Timeout:     120,
+		},
+		{
+			Name:        "run",
+			Description: "Run the application",
+			Command:     gradle,
+			Args:        []string{run},
+			Persistent:  true,
+		},
+	}
+}
+
+// DefaultMavenCommands returns the default commands for a Maven project;
+// mvn is ./mvnw when the project has the wrapper.
+func DefaultMavenCommands(mvn string, springBoot bool) []CommandDef {
+	cmds := []CommandDef{
+		{
+			Name:        "test",
+			Description: "Run tests",
+			Command:     mvn,
+			Args:        []string{"test"},
+			Timeout:     900,
+		},
+		{
+			Name:        "build",
+			Description: "Package the project",
+			Command:     mvn,
+			Args:        []string{"package", "-DskipTests"},
+			Timeout:     900,
+		},
+		{
+			Name:        "verify",
+			Description: "Run integration tests and checks",
+			Command:     mvn,
+			Args:        []string{"verify"},
+			Timeout:     1200,
+		},
+		{
+			Name:        "clean",
+			Description: "Delete build outputs",
+			Command:     mvn,
+			Args:        []string{"clean"},
+			Timeout:     120,
+		},
+	}
+	if springBoot {
+		cmds = append(cmds, CommandDef{
+			Name:        "run",
+			Description: "Run the Spring Boot application",
+			Command:     mvn,
+			Args:        []string{"spring-boot:run"},
+			Persistent:  true,
+		})
+	}
+	return cmds
+}
+
+// DefaultDotnetCommands returns the default commands for a .NET project.
+// target is the solution or project file to build, and runProject the
+// project to run (empty when there is none to pick).
+func DefaultDotnetCommands(target, runProject string) []CommandDef {
+	withTarget := func(args ...string) []string {
+		if target != "" {
+			args = append(args, target)
+		}
+		return args
+	}
+	cmds := []CommandDef{
+		{
+			Name:        "test",
+			Description: "Run dotnet tests",
+			Command:     "dotnet",
+			Args:        withTarget("test"),
+			Timeout:     900,
+		},
+		{
+			Name:        "build",
+			Description: "Build the solution",
+			Command:     "dotnet",
+			Args:        withTarget("build"),
+			Timeout:     600,
+		},
+		{
+			Name:        "restore",
+			Description: "Restore NuGet packages",
+			Command:     "dotnet",
+			Args:        withTarget("restore"),
+			Timeout:     300,
+		},
+		{
+			Name:        "format-check",
+			Description: "Check formatting with dotnet format",
+			Command:     "dotnet",
+			Args:        withTarget("format", "--verify-no-changes"),
+			Timeout:     300,
+		},
+	}
+	if runProject != "" {
+		cmds = append(cmds,
+			CommandDef{
+				Name:        "run",
+				Description: "Run " + runProject,
+				Command:     "dotnet",
+				Args:        []string{"run", "--project", runProject},
+				Persistent:  true,
+			},
+			CommandDef{
+				Name:        "watch",
+				Description: "Run " + runProject + " with hot reload",
+				Command:     "dotnet",
+				Args:        []string{"watch", "--project", runProject, "run"},
+				Persistent:  true,
+			},
+		)
+	}
+	return cmds
+}
+
 // GetCommandByName finds a command by name in a project.
 func GetCommandByName(proj *Project, name string) *CommandDef {
 	for i := range proj.Commands {
@@ -258,3 +486,13 @@ func GetCommandNames(proj *Project) []string {
 	}
 	return names
 }
+
+// setCommand adds cmd to a project, replacing a command of the same name:
+// scripts the project defines win over the defaults.
+func setCommand(proj *Project, cmd CommandDef) {
+	if existing := GetCommandByName(proj, cmd.Name); existing != nil {
+		*existing = cmd
+		return
+	}
+	proj.Commands = append(proj.Commands, cmd)
+}
@@ -16,8 +16,14 @@ const (
 	ProjectGo ProjectType = "go"
 	// ProjectNode is a Node.js project (package.json).
 	ProjectNode ProjectType = "node"
-	// ProjectPython is a Python project (pyproject.toml, setup.py, requirements.txt).
+	// ProjectPython is a Python project (pyproject.toml, setup.py, requirements.txt, Pipfile).
 	ProjectPython ProjectType = "python"
+	// ProjectRust is a Rust project (Cargo.toml).
+	ProjectRust ProjectType = "rust"
+	// ProjectJava is a JVM project built with Gradle or Maven.
+	ProjectJava ProjectType = "java"
+	// ProjectDotnet is a .NET project (*.sln, *.csproj, *.fsproj).
+	ProjectDotnet ProjectType = "dotnet"
 	// ProjectUnknown is an unrecognized project type.
 	ProjectUnknown ProjectType = "unknown"
 )
@@ -36,6 +42,19 @@ type Project struct {
 	PackageManager string `json:"package_manager,omitempty"`
 	// Metadata holds additional project-specific info.
 	Metadata map[string]string `json:"metadata,omitempty"`
+	// Workspaces are the packages of a monorepo or the projects of a
+	// .NET solution.
+	Workspaces []Workspace `json:"workspaces,omitempty"`
+}
+
+// detectors are tried in priority order; the first match wins.
+var detectors = []func(path string) *Project{
+	detectGo,
+	detectNode,
+	detectRust,
+	detectJava,
+	detectDotnet,
+	detectPython,
 }
 
 // Detect examines the given path and returns project information.
@@ -56,14 +75,10 @@ func Detect(path string) (*Project, error) {
 	}
 
 	// Try each detector in priority order
-	if proj := detectGo(absPath); proj != nil {
-		return proj, nil
-	}
-	if proj := detectNode(absPath); proj != nil {
-		return proj, nil
-	}
-	if proj := detectPython(absPath); proj != nil {
-		return proj, nil
+	for _, detect := range detectors {
+		if proj := detect(absPath); proj != nil {
+			return proj, nil
+		}
 	}
 
 	// Unknown project type
@@ -148,6 +163,9 @@ func detectNode(path string) *Project {
 		proj.Metadata["scripts"] = strings.Join(scripts, ",")
 	}
 
+	// Monorepo packages with their own scripts
+	detectNodeWorkspaces(proj)
+
 	return proj
 }
 
@@ -227,7 +245,7 @@ func detectPackageManager(path string) string {
 // detectPython checks for a Python project.
 func detectPython(path string) *Project {
 	// Check markers in priority order
-	markers := []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt"}
+	markers := []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"}
 	found := false
 	var marker string
 	for _, m := range markers {
@@ -245,19 +263,15 @@ func detectPython(path string) *Project {
 		Path:     path,
 		Type:     ProjectPython,
 		Name:     parsePythonProjectName(path, marker),
-		Commands: DefaultPythonCommands(),
 		Metadata: make(map[string]string),
 	}
 
 	// Check for common Python tools
-	if fileExists(filepath.Join(path, "pyproject.toml")) {
+	pyproject := filepath.Join(path, "pyproject.toml")
+	if fileExists(pyproject) {
 		proj.Metadata["config"] = "pyproject.toml"
-		// Check for poetry
-		if containsString(filepath.Join(path, "pyproject.toml"), "tool.poetry") {
-			proj.Metadata["manager"] = "poetry"
-		}
 		// Check for ruff
-		if containsString(filepath.Join(path, "pyproject.toml"), "tool.ruff") {
+		if containsString(pyproject, "tool.ruff") {
 			proj.Metadata["linter"] = "ruff"
 		}
 	}
@@ -270,9 +284,95 @@ func detectPython(path string) *Project {
 		}
 	}
 
+	manager := detectPythonManager(path)
+	if manager != "" {
+		proj.Metadata["manager"] = manager
+	}
+	proj.Commands = PythonCommandsFor(manager)
+
+	// tox environments run as tox-<env>
+	if envs := parseToxEnvs(filepath.Join(path, "tox.ini")); len(envs) > 0 {
+		setCommand(proj, CommandDef{Name: "tox", Description: "Run all tox environments", Command: "tox", Timeout: 900})
+		for _, env := range envs {
+			setCommand(proj, CommandDef{
+				Name:        "tox-" + env,
+				Description: "Run tox environment " + env,
+				Command:     "tox",
+				Args:        []string{"-e", env},
+				Timeout:     600,
+			})
+		}
+		proj.Metadata["tox_envs"] = strings.Join(envs, ",")
+	}
+	addMakeTargets(proj)
+
 	return proj
 }
 
+// detectPythonManager returns the dependency manager of a Python project:
+// poetry, uv, pipenv, or empty for plain pip.
+func detectPythonManager(path string) string {
+	pyproject := filepath.Join(path, "pyproject.toml")
+	switch {
+	case fileExists(filepath.Join(path, "poetry.lock")) || containsString(pyproject, "tool.poetry"):
+		return "poetry"
+	case fileExists(filepath.Join(path, "uv.lock")) || containsString(pyproject, "tool.uv"):
+		return "uv"
+	case fileExists(filepath.Join(path, "Pipfile")):
+		return "pipenv"
+	}
+	return ""
+}
+
+// parseToxEnvs returns the environments of tox.ini: its envlist and
+// [testenv:<name>] sections.
+func parseToxEnvs(toxPath string) []string {
+	data, err := os.ReadFile(toxPath)
+	if err != nil {
+		return nil
+	}
+	var envs []string
+	seen := make(map[string]bool)
+	add := func(env string) {
+		env = strings.TrimSpace(env)
+		if env != "" && !seen[env] && !strings.ContainsAny(env, "{}") {
+			seen[env] = true
+			envs = append(envs, env)
+		}
+	}
+
+	inTox := false
+	inEnvlist := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inTox = trimmed == "[tox]"
+			inEnvlist = false
+			if name, ok := strings.CutPrefix(trimmed, "[testenv:"); ok {
+				add(strings.TrimSuffix(name, "]"))
+			}
+			continue
+		}
+		if !inTox {
+			continue
+		}
+		if key, value, ok := strings.Cut(trimmed, "="); ok && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			key = strings.TrimSpace(key)
+			inEnvlist = key == "envlist" || key == "env_list"
+			if !inEnvlist {
+				continue
+			}
+			trimmed = value
+		}
+		if inEnvlist {
+			for _, env := range strings.Split(trimmed, ",") {
+				add(env)
+			}
+		}
+	}
+	return envs
+}
+
 // parsePythonProjectName tries to extract the project name.
 func parsePythonProjectName(path, marker string) string {
 	if marker == "pyproject.toml" {
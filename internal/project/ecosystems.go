@@ -0,0 +1,442 @@
+package project
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// detectRust checks for a Rust project, with cargo-make tasks from
+// Makefile.toml.
+func detectRust(path string) *Project {
+	cargoPath := filepath.Join(path, "Cargo.toml")
+	data, err := os.ReadFile(cargoPath)
+	if err != nil {
+		return nil
+	}
+
+	proj := &Project{
+		Path:     path,
+		Type:     ProjectRust,
+		Name:     tomlSectionValue(data, "package", "name"),
+		Commands: DefaultRustCommands(),
+		Metadata: make(map[string]string),
+	}
+	if proj.Name == "" {
+		proj.Name = filepath.Base(path)
+	}
+
+	// Workspace members, each a crate with its own Cargo.toml
+	for _, member := range tomlSectionList(data, "workspace", "members") {
+		for _, dir := range expandWorkspacePattern(path, member) {
+			memberData, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+			if err != nil {
+				continue
+			}
+			name := tomlSectionValue(memberData, "package", "name")
+			if name == "" {
+				name = filepath.Base(dir)
+			}
+			proj.Workspaces = append(proj.Workspaces, Workspace{Name: name, Path: relSlash(path, dir)})
+		}
+	}
+	sortWorkspaces(proj.Workspaces)
+
+	if tasks := parseCargoMakeTasks(filepath.Join(path, "Makefile.toml")); len(tasks) > 0 {
+		proj.Metadata["task_runner"] = "cargo-make"
+		names := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			setCommand(proj, CommandDef{
+				Name:        task.name,
+				Description: task.description,
+				Command:     "cargo",
+				Args:        []string{"make", task.name},
+			})
+			names = append(names, task.name)
+		}
+		proj.Metadata["scripts"] = strings.Join(names, ",")
+	}
+
+	return proj
+}
+
+// namedTask is a task declared by a task runner file.
+type namedTask struct {
+	name        string
+	description string
+}
+
+// parseCargoMakeTasks returns the [tasks.<name>] of a cargo-make
+// Makefile.toml, without private tasks.
+func parseCargoMakeTasks(makefilePath string) []namedTask {
+	data, err := os.ReadFile(makefilePath)
+	if err != nil {
+		return nil
+	}
+	var tasks []namedTask
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if name, ok := strings.CutPrefix(line, "[tasks."); ok {
+			name = strings.Trim(strings.TrimSuffix(name, "]"), `"`)
+			if name != "" && !strings.Contains(name, ".") {
+				tasks = append(tasks, namedTask{name: name, description: "cargo make " + name})
+			}
+			continue
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		last := &tasks[len(tasks)-1]
+		key, value, ok := strings.Cut(line, "=")
+		switch {
+		case !ok:
+		case strings.TrimSpace(key) == "description":
+			last.description = strings.Trim(strings.TrimSpace(value), `"'`)
+		case strings.TrimSpace(key) == "private" && strings.TrimSpace(value) == "true":
+			last.name = ""
+		}
+	}
+	public := tasks[:0]
+	for _, task := range tasks {
+		if task.name != "" {
+			public = append(public, task)
+		}
+	}
+	return public
+}
+
+// makeTargetPattern matches a rule line of a Makefile: targets, then a
+// colon that isn't an assignment.
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.\-/ ]*?)\s*:([^=]|$)`)
+
+// addMakeTargets adds the targets of the project's Makefile as commands,
+// replacing defaults of the same name.
+func addMakeTargets(proj *Project) {
+	targets := parseMakeTargets(proj.Path)
+	if len(targets) == 0 {
+		return
+	}
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		setCommand(proj, CommandDef{
+			Name:        target.name,
+			Description: target.description,
+			Command:     "make",
+			Args:        []string{target.name},
+		})
+		names = append(names, target.name)
+	}
+	proj.Metadata["make_targets"] = strings.Join(names, ",")
+}
+
+// parseMakeTargets returns the explicit targets of a project's Makefile, with
+// a "## comment" on the rule line or the line above as description.
+func parseMakeTargets(path string) []namedTask {
+	var f *os.File
+	for _, name := range []string{"GNUmakefile", "makefile", "Makefile"} {
+		var err error
+		if f, err = os.Open(filepath.Join(path, name)); err == nil {
+			break
+		}
+	}
+	if f == nil {
+		return nil
+	}
+	defer f.Close()
+
+	var targets []namedTask
+	seen := make(map[string]bool)
+	comment := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if c, ok := strings.CutPrefix(line, "##"); ok {
+			comment = strings.TrimSpace(c)
+			continue
+		}
+		m := makeTargetPattern.FindStringSubmatch(line)
+		if m == nil {
+			if !strings.HasPrefix(line, "\t") {
+				comment = ""
+			}
+			continue
+		}
+		description := comment
+		if _, c, ok := strings.Cut(line, "##"); ok {
+			description = strings.TrimSpace(c)
+		}
+		comment = ""
+		for _, name := range strings.Fields(m[1]) {
+			if seen[name] || strings.ContainsAny(name, "%$/") || strings.HasSuffix(name, ".o") {
+				continue
+			}
+			seen[name] = true
+			desc := description
+			if desc == "" {
+				desc = "make " + name
+			}
+			targets = append(targets, namedTask{name: name, description: desc})
+		}
+	}
+	return targets
+}
+
+// detectJava checks for a Gradle or Maven project.
+func detectJava(path string) *Project {
+	var buildFile string
+	for _, name := range []string{"build.gradle.kts", "build.gradle", "settings.gradle.kts", "settings.gradle"} {
+		if fileExists(filepath.Join(path, name)) {
+			buildFile = name
+			break
+		}
+	}
+	if buildFile != "" {
+		return detectGradle(path, buildFile)
+	}
+	if fileExists(filepath.Join(path, "pom.xml")) {
+		return detectMaven(path)
+	}
+	return nil
+}
+
+// gradleIncludePattern matches the projects of an include in
+// settings.gradle(.kts).
+var gradleIncludePattern = regexp.MustCompile(`["']:?([\w\-.:]+)["']`)
+
+// detectGradle describes a Gradle build; subprojects included by the
+// settings file become workspaces.
+func detectGradle(path, buildFile string) *Project {
+	gradle := "gradle"
+	if fileExists(filepath.Join(path, "gradlew")) {
+		gradle = "./gradlew"
+	}
+	springBoot := containsString(filepath.Join(path, "build.gradle"), "org.springframework.boot") ||
+		containsString(filepath.Join(path, "build.gradle.kts"), "org.springframework.boot")
+
+	proj := &Project{
+		Path:     path,
+		Type:     ProjectJava,
+		Name:     filepath.Base(path),
+		Commands: DefaultGradleCommands(gradle, springBoot),
+		Metadata: map[string]string{"build_tool": "gradle"},
+	}
+	if strings.HasSuffix(buildFile, ".kts") {
+		proj.Metadata["dsl"] = "kotlin"
+	}
+	if springBoot {
+		proj.Metadata["framework"] = "spring-boot"
+	}
+
+	for _, settings := range []string{"settings.gradle.kts", "settings.gradle"} {
+		data, err := os.ReadFile(filepath.Join(path, settings))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if name, ok := strings.CutPrefix(line, "rootProject.name"); ok {
+				if m := gradleIncludePattern.FindStringSubmatch(name); m != nil {
+					proj.Name = m[1]
+				}
+				continue
+			}
+			if !strings.HasPrefix(line, "include") {
+				continue
+			}
+			for _, m := range gradleIncludePattern.FindAllStringSubmatch(line, -1) {
+				sub := m[1]
+				proj.Workspaces = append(proj.Workspaces, Workspace{
+					Name: sub[strings.LastIndex(sub, ":")+1:],
+					Path: strings.ReplaceAll(sub, ":", "/"),
+				})
+			}
+		}
+		break
+	}
+	sortWorkspaces(proj.Workspaces)
+	return proj
+}
+
+var (
+	mavenParentPattern   = regexp.MustCompile(`(?s)<parent>.*?</parent>`)
+	mavenArtifactPattern = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+	mavenModulePattern   = regexp.MustCompile(`<module>\s*([^<\s]+)\s*</module>`)
+)
+
+// detectMaven describes a Maven build; its modules become workspaces.
+func detectMaven(path string) *Project {
+	data, _ := os.ReadFile(filepath.Join(path, "pom.xml"))
+	mvn := "mvn"
+	if fileExists(filepath.Join(path, "mvnw")) {
+		mvn = "./mvnw"
+	}
+	springBoot := strings.Contains(string(data), "spring-boot-maven-plugin")
+
+	proj := &Project{
+		Path:     path,
+		Type:     ProjectJava,
+		Name:     filepath.Base(path),
+		Commands: DefaultMavenCommands(mvn, springBoot),
+		Metadata: map[string]string{"build_tool": "maven"},
+	}
+	if springBoot {
+		proj.Metadata["framework"] = "spring-boot"
+	}
+
+	pom := mavenParentPattern.ReplaceAll(data, nil)
+	if m := mavenArtifactPattern.FindSubmatch(pom); m != nil {
+		proj.Name = string(m[1])
+	}
+	for _, m := range mavenModulePattern.FindAllSubmatch(pom, -1) {
+		module := string(m[1])
+		ws := Workspace{Name: filepath.Base(module), Path: module}
+		if moduleData, err := os.ReadFile(filepath.Join(path, module, "pom.xml")); err == nil {
+			moduleData = mavenParentPattern.ReplaceAll(moduleData, nil)
+			if am := mavenArtifactPattern.FindSubmatch(moduleData); am != nil {
+				ws.Name = string(am[1])
+			}
+		}
+		proj.Workspaces = append(proj.Workspaces, ws)
+	}
+	sortWorkspaces(proj.Workspaces)
+	return proj
+}
+
+// slnProjectPattern matches a project line of a .sln file:
+// Project("{type}") = "Name", "path\Name.csproj", "{guid}".
+var slnProjectPattern = regexp.MustCompile(`(?m)^Project\("[^"]*"\)\s*=\s*"([^"]+)",\s*"([^"]+\.(?:cs|fs|vb)proj)"`)
+
+// detectDotnet checks for a .NET solution or project. The projects of a
+// solution become workspaces, and the single executable one is what run
+// starts.
+func detectDotnet(path string) *Project {
+	solutions, _ := filepath.Glob(filepath.Join(path, "*.sln"))
+	var projects []string
+	for _, ext := range []string{"*.csproj", "*.fsproj", "*.vbproj"} {
+		matches, _ := filepath.Glob(filepath.Join(path, ext))
+		projects = append(projects, matches...)
+	}
+	if len(solutions) == 0 && len(projects) == 0 {
+		return nil
+	}
+
+	proj := &Project{
+		Path:     path,
+		Type:     ProjectDotnet,
+		Metadata: make(map[string]string),
+	}
+
+	var target string
+	var candidates []string // project files relative to path
+	if len(solutions) > 0 {
+		sort.Strings(solutions)
+		target = filepath.Base(solutions[0])
+		proj.Name = strings.TrimSuffix(target, ".sln")
+		proj.Metadata["solution"] = target
+		data, _ := os.ReadFile(solutions[0])
+		for _, m := range slnProjectPattern.FindAllStringSubmatch(string(data), -1) {
+			rel := strings.ReplaceAll(m[2], `\`, "/")
+			proj.Workspaces = append(proj.Workspaces, Workspace{Name: m[1], Path: filepath.ToSlash(filepath.Dir(rel))})
+			candidates = append(candidates, rel)
+		}
+	} else {
+		sort.Strings(projects)
+		target = filepath.Base(projects[0])
+		proj.Name = strings.TrimSuffix(target, filepath.Ext(target))
+		candidates = []string{target}
+	}
+	sortWorkspaces(proj.Workspaces)
+
+	var runnable []string
+	for _, rel := range candidates {
+		if isRunnableDotnetProject(filepath.Join(path, filepath.FromSlash(rel))) {
+			runnable = append(runnable, rel)
+		}
+	}
+	runProject := ""
+	if len(runnable) == 1 {
+		runProject = runnable[0]
+	}
+	proj.Commands = DefaultDotnetCommands(target, runProject)
+	return proj
+}
+
+// isRunnableDotnetProject reports whether a project file builds an
+// executable or web app that isn't a test project.
+func isRunnableDotnetProject(projPath string) bool {
+	data, err := os.ReadFile(projPath)
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	if strings.Contains(content, "Microsoft.NET.Test.Sdk") || strings.Contains(content, "<IsTestProject>true") {
+		return false
+	}
+	return strings.Contains(content, "<OutputType>Exe</OutputType>") ||
+		strings.Contains(content, "<OutputType>WinExe</OutputType>") ||
+		strings.Contains(content, `Sdk="Microsoft.NET.Sdk.Web"`) ||
+		strings.Contains(content, `Sdk="Microsoft.NET.Sdk.Worker"`)
+}
+
+// tomlSectionValue returns a string key of a [section] in a TOML file.
+func tomlSectionValue(data []byte, section, key string) string {
+	inSection := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "["+section+"]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == key {
+			return strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	return ""
+}
+
+// tomlSectionList returns a string array key of a [section] in a TOML file,
+// which may span lines.
+func tomlSectionList(data []byte, section, key string) []string {
+	inSection := false
+	var value strings.Builder
+	collecting := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if collecting {
+			value.WriteString(trimmed)
+			if strings.Contains(trimmed, "]") {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			inSection = trimmed == "["+section+"]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if k, v, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(k) == key {
+			value.WriteString(strings.TrimSpace(v))
+			if strings.Contains(v, "]") {
+				break
+			}
+			collecting = true
+		}
+	}
+	list := strings.Trim(value.String(), "[]")
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.Trim(strings.TrimSpace(item), `"'`)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
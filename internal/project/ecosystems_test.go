@@ -0,0 +1,315 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under dir from relative paths to contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetect_RustWithCargoMake(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Cargo.toml": `[package]
+name = "crabby"
+
+[workspace]
+members = [
+    "crates/*",
+]
+`,
+		"crates/core/Cargo.toml": "[package]\nname = \"crabby-core\"\n",
+		"Makefile.toml": `[tasks.ci]
+description = "Everything CI runs"
+
+[tasks.test]
+command = "cargo"
+
+[tasks.helper]
+private = true
+`,
+	})
+
+	proj, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj.Type != ProjectRust || proj.Name != "crabby" {
+		t.Fatalf("got %s %q, want rust crabby", proj.Type, proj.Name)
+	}
+	if def := GetCommandByName(proj, "test"); def == nil || !reflect.DeepEqual(def.Args, []string{"make", "test"}) {
+		t.Errorf("test = %+v, want cargo make test", def)
+	}
+	if def := GetCommandByName(proj, "ci"); def == nil || def.Description != "Everything CI runs" {
+		t.Errorf("ci = %+v", def)
+	}
+	if HasCommand(proj, "helper") {
+		t.Error("private task listed")
+	}
+	if !HasCommand(proj, "lint") {
+		t.Error("expected default clippy lint")
+	}
+	want := []Workspace{{Name: "crabby-core", Path: "crates/core"}}
+	if !reflect.DeepEqual(proj.Workspaces, want) {
+		t.Errorf("workspaces = %+v, want %+v", proj.Workspaces, want)
+	}
+}
+
+func TestDetect_PythonManagers(t *testing.T) {
+	tests := []struct {
+		file    string
+		manager string
+		install []string
+	}{
+		{"poetry.lock", "poetry", []string{"install"}},
+		{"uv.lock", "uv", []string{"sync"}},
+		{"Pipfile", "pipenv", []string{"install"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"pyproject.toml": "[project]\nname = \"snake\"\n", tt.file: ""})
+			proj, err := Detect(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proj.Metadata["manager"] != tt.manager {
+				t.Errorf("manager = %q, want %q", proj.Metadata["manager"], tt.manager)
+			}
+			test := GetCommandByName(proj, "test")
+			if test.Command != tt.manager || !reflect.DeepEqual(test.Args, []string{"run", "pytest", "-v"}) {
+				t.Errorf("test = %s %v", test.Command, test.Args)
+			}
+			if install := GetCommandByName(proj, "install"); !reflect.DeepEqual(install.Args, tt.install) {
+				t.Errorf("install = %v, want %v", install.Args, tt.install)
+			}
+		})
+	}
+}
+
+func TestDetect_PythonToxAndMake(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"requirements.txt": "",
+		"tox.ini": `[tox]
+envlist = py311,
+    lint
+
+[testenv:docs]
+commands = sphinx-build
+`,
+		"Makefile": `.PHONY: test serve
+PY := python
+
+## Run the test suite
+test: deps
+	pytest
+
+serve: ## Start the dev server
+	$(PY) -m app
+
+%.txt: %.in
+	pip-compile $<
+`,
+	})
+
+	proj, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj.Metadata["tox_envs"] != "py311,lint,docs" {
+		t.Errorf("tox_envs = %q", proj.Metadata["tox_envs"])
+	}
+	if def := GetCommandByName(proj, "tox-docs"); def == nil || !reflect.DeepEqual(def.Args, []string{"-e", "docs"}) {
+		t.Errorf("tox-docs = %+v", def)
+	}
+	test := GetCommandByName(proj, "test")
+	if test.Command != "make" || test.Description != "Run the test suite" {
+		t.Errorf("test = %+v, want the make target", test)
+	}
+	if serve := GetCommandByName(proj, "serve"); serve == nil || serve.Description != "Start the dev server" {
+		t.Errorf("serve = %+v", serve)
+	}
+	if proj.Metadata["make_targets"] != "test,serve" {
+		t.Errorf("make_targets = %q", proj.Metadata["make_targets"])
+	}
+}
+
+func TestDetect_Gradle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"gradlew":          "",
+		"build.gradle.kts": `plugins { id("org.springframework.boot") version "3.2.0" }`,
+		"settings.gradle.kts": `rootProject.name = "shop"
+include("api", ":libs:common")
+`,
+	})
+
+	proj, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj.Type != ProjectJava || proj.Name != "shop" || proj.Metadata["build_tool"] != "gradle" {
+		t.Fatalf("got %s %q %v", proj.Type, proj.Name, proj.Metadata)
+	}
+	run := GetCommandByName(proj, "run")
+	if run.Command != "./gradlew" || !reflect.DeepEqual(run.Args, []string{"bootRun"}) {
+		t.Errorf("run = %s %v, want ./gradlew bootRun", run.Command, run.Args)
+	}
+	want := []Workspace{{Name: "api", Path: "api"}, {Name: "common", Path: "libs/common"}}
+	if !reflect.DeepEqual(proj.Workspaces, want) {
+		t.Errorf("workspaces = %+v, want %+v", proj.Workspaces, want)
+	}
+}
+
+func TestDetect_Maven(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pom.xml": `<project>
+  <parent><artifactId>spring-boot-starter-parent</artifactId></parent>
+  <artifactId>billing</artifactId>
+  <modules><module>service</module></modules>
+</project>`,
+		"service/pom.xml": `<project><artifactId>billing-service</artifactId></project>`,
+	})
+
+	proj, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj.Name != "billing" || proj.Metadata["build_tool"] != "maven" {
+		t.Fatalf("got %q %v", proj.Name, proj.Metadata)
+	}
+	if test := GetCommandByName(proj, "test"); test.Command != "mvn" {
+		t.Errorf("test command = %q, want mvn", test.Command)
+	}
+	want := []Workspace{{Name: "billing-service", Path: "service"}}
+	if !reflect.DeepEqual(proj.Workspaces, want) {
+		t.Errorf("workspaces = %+v, want %+v", proj.Workspaces, want)
+	}
+}
+
+func TestDetect_DotnetSolution(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Store.sln": `Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Store.Web", "src\Store.Web\Store.Web.csproj", "{1}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Store.Tests", "tests\Store.Tests\Store.Tests.csproj", "{2}"
+EndProject
+`,
+		"src/Store.Web/Store.Web.csproj":       `<Project Sdk="Microsoft.NET.Sdk.Web"></Project>`,
+		"tests/Store.Tests/Store.Tests.csproj": `<Project Sdk="Microsoft.NET.Sdk"><OutputType>Exe</OutputType><PackageReference Include="Microsoft.NET.Test.Sdk" /></Project>`,
+	})
+
+	proj, err := Detect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proj.Type != ProjectDotnet || proj.Name != "Store" {
+		t.Fatalf("got %s %q", proj.Type, proj.Name)
+	}
+	if build := GetCommandByName(proj, "build"); !reflect.DeepEqual(build.Args, []string{"build", "Store.sln"}) {
+		t.Errorf("build args = %v", build.Args)
+	}
+	run := GetCommandByName(proj, "run")
+	if run == nil || !reflect.DeepEqual(run.Args, []string{"run", "--project", "src/Store.Web/Store.Web.csproj"}) {
+		t.Errorf("run = %+v", run)
+	}
+	if len(proj.Workspaces) != 2 || proj.Workspaces[0].Path != "src/Store.Web" {
+		t.Errorf("workspaces = %+v", proj.Workspaces)
+	}
+}
+
+func TestDetect_NodeMonorepos(t *testing.T) {
+	t.Run("pnpm", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"package.json":                     `{"name": "root"}`,
+			"pnpm-lock.yaml":                   "",
+			"pnpm-workspace.yaml":              "packages:\n  - 'apps/*'\n  - \"packages/**\"\n  - '!packages/legacy'\n",
+			"apps/web/package.json":            `{"name": "@acme/web", "scripts": {"dev": "vite", "build": "vite build"}}`,
+			"packages/ui/package.json":         `{"name": "@acme/ui", "scripts": {"test": "vitest"}}`,
+			"packages/legacy/package.json":     `{"name": "legacy"}`,
+			"packages/tools/lint/package.json": `{"name": "@acme/lint"}`,
+		})
+		proj, err := Detect(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proj.Metadata["monorepo"] != "pnpm" {
+			t.Errorf("monorepo = %q, want pnpm", proj.Metadata["monorepo"])
+		}
+		want := []Workspace{
+			{Name: "@acme/web", Path: "apps/web", Scripts: []string{"build", "dev"}},
+			{Name: "@acme/lint", Path: "packages/tools/lint"},
+			{Name: "@acme/ui", Path: "packages/ui", Scripts: []string{"test"}},
+		}
+		if !reflect.DeepEqual(proj.Workspaces, want) {
+			t.Errorf("workspaces = %+v, want %+v", proj.Workspaces, want)
+		}
+	})
+
+	t.Run("turbo", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"package.json":           `{"name": "root", "workspaces": {"packages": ["apps/*"]}}`,
+			"turbo.json":             `{"tasks": {"build": {}, "lint": {}, "web#build": {}}}`,
+			"apps/docs/package.json": `{"name": "docs", "scripts": {"dev": "next dev"}}`,
+		})
+		proj, err := Detect(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proj.Metadata["monorepo"] != "turbo" || proj.Metadata["turbo_tasks"] != "build,lint" {
+			t.Errorf("metadata = %v", proj.Metadata)
+		}
+		if len(proj.Workspaces) != 1 || proj.Workspaces[0].Name != "docs" {
+			t.Errorf("workspaces = %+v", proj.Workspaces)
+		}
+	})
+
+	t.Run("nx", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"package.json":          `{"name": "root"}`,
+			"nx.json":               `{}`,
+			"apps/api/project.json": `{"name": "api", "targets": {"serve": {}, "test": {}}}`,
+		})
+		proj, err := Detect(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Workspace{{Name: "api", Path: "apps/api", Scripts: []string{"serve", "test"}}}
+		if proj.Metadata["monorepo"] != "nx" || !reflect.DeepEqual(proj.Workspaces, want) {
+			t.Errorf("got %v %+v", proj.Metadata, proj.Workspaces)
+		}
+	})
+}
+
+func TestParseMakeTargets_SkipsPatternsAndVariables(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Makefile": "CC := gcc\nall build: main.o\n\n%.o: %.c\n\tcc -c $<\nbuild/out: x\n"})
+	var names []string
+	for _, target := range parseMakeTargets(dir) {
+		names = append(names, target.name)
+	}
+	if got := strings.Join(names, ","); got != "all,build" {
+		t.Errorf("targets = %q, want all,build", got)
+	}
+}
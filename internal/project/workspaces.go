@@ -0,0 +1,247 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Workspace is a package of a monorepo or a project of a solution.
+type Workspace struct {
+	// Name is the package or project name.
+	Name string `json:"name"`
+	// Path is the workspace directory, relative to the project root.
+	Path string `json:"path"`
+	// Scripts are the script names the workspace defines (package.json
+	// scripts, Nx targets).
+	Scripts []string `json:"scripts,omitempty"`
+}
+
+// detectNodeWorkspaces fills in the monorepo tool and the workspaces of a
+// Node project: pnpm-workspace.yaml, package.json workspaces, turbo and Nx.
+func detectNodeWorkspaces(proj *Project) {
+	path := proj.Path
+	patterns := parsePnpmWorkspaces(filepath.Join(path, "pnpm-workspace.yaml"))
+	tool := ""
+	if len(patterns) > 0 {
+		tool = "pnpm"
+	} else if patterns = parsePackageJsonWorkspaces(filepath.Join(path, "package.json")); len(patterns) > 0 {
+		tool = proj.PackageManager
+	}
+
+	if fileExists(filepath.Join(path, "turbo.json")) {
+		tool = "turbo"
+		if tasks := parseTurboTasks(filepath.Join(path, "turbo.json")); len(tasks) > 0 {
+			proj.Metadata["turbo_tasks"] = strings.Join(tasks, ",")
+		}
+	}
+	if fileExists(filepath.Join(path, "nx.json")) {
+		tool = "nx"
+		if len(patterns) == 0 {
+			patterns = []string{"apps/*", "libs/*", "packages/*"}
+		}
+	}
+	if tool == "" {
+		return
+	}
+	proj.Metadata["monorepo"] = tool
+
+	seen := make(map[string]bool)
+	var excluded []string
+	for _, pattern := range patterns {
+		if p, ok := strings.CutPrefix(pattern, "!"); ok {
+			excluded = append(excluded, p)
+		}
+	}
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+		for _, dir := range expandWorkspacePattern(path, pattern) {
+			rel := relSlash(path, dir)
+			if seen[rel] || matchesAny(excluded, rel) {
+				continue
+			}
+			if ws, ok := readNodeWorkspace(dir, rel); ok {
+				seen[rel] = true
+				proj.Workspaces = append(proj.Workspaces, ws)
+			}
+		}
+	}
+	sortWorkspaces(proj.Workspaces)
+}
+
+// readNodeWorkspace reads a workspace's package.json and Nx project.json.
+func readNodeWorkspace(dir, rel string) (Workspace, bool) {
+	ws := Workspace{Name: filepath.Base(dir), Path: rel}
+	found := false
+	scripts := make(map[string]bool)
+
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		found = true
+		var pkg struct {
+			Name    string            `json:"name"`
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			if pkg.Name != "" {
+				ws.Name = pkg.Name
+			}
+			for name := range pkg.Scripts {
+				scripts[name] = true
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "project.json")); err == nil {
+		found = true
+		var nxProject struct {
+			Name    string                     `json:"name"`
+			Targets map[string]json.RawMessage `json:"targets"`
+		}
+		if json.Unmarshal(data, &nxProject) == nil {
+			if nxProject.Name != "" {
+				ws.Name = nxProject.Name
+			}
+			for name := range nxProject.Targets {
+				scripts[name] = true
+			}
+		}
+	}
+
+	for name := range scripts {
+		ws.Scripts = append(ws.Scripts, name)
+	}
+	sort.Strings(ws.Scripts)
+	return ws, found
+}
+
+// parsePnpmWorkspaces returns the packages globs of pnpm-workspace.yaml.
+func parsePnpmWorkspaces(yamlPath string) []string {
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return nil
+	}
+	var patterns []string
+	inPackages := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			inPackages = strings.HasPrefix(trimmed, "packages:")
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok && inPackages {
+			if i := strings.Index(item, " #"); i >= 0 {
+				item = item[:i]
+			}
+			patterns = append(patterns, strings.Trim(strings.TrimSpace(item), `"'`))
+		}
+	}
+	return patterns
+}
+
+// parsePackageJsonWorkspaces returns the workspaces globs of package.json,
+// as an array or as {"packages": [...]}.
+func parsePackageJsonWorkspaces(packagePath string) []string {
+	data, err := os.ReadFile(packagePath)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &pkg) != nil || len(pkg.Workspaces) == 0 {
+		return nil
+	}
+	var patterns []string
+	if json.Unmarshal(pkg.Workspaces, &patterns) == nil {
+		return patterns
+	}
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	json.Unmarshal(pkg.Workspaces, &object)
+	return object.Packages
+}
+
+// parseTurboTasks returns the task names of turbo.json ("tasks", or
+// "pipeline" before turbo 2).
+func parseTurboTasks(turboPath string) []string {
+	data, err := os.ReadFile(turboPath)
+	if err != nil {
+		return nil
+	}
+	var turbo struct {
+		Tasks    map[string]json.RawMessage `json:"tasks"`
+		Pipeline map[string]json.RawMessage `json:"pipeline"`
+	}
+	if json.Unmarshal(data, &turbo) != nil {
+		return nil
+	}
+	tasks := turbo.Tasks
+	if len(tasks) == 0 {
+		tasks = turbo.Pipeline
+	}
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		// Package-scoped tasks ("web#build") are variants of a task
+		if !strings.Contains(name, "#") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandWorkspacePattern returns the directories matching a workspace glob
+// relative to root. A trailing /** matches directories at any depth.
+func expandWorkspacePattern(root, pattern string) []string {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	var globs []string
+	if base, ok := strings.CutSuffix(pattern, "/**"); ok {
+		globs = []string{base + "/*", base + "/*/*", base + "/*/*/*"}
+	} else {
+		globs = []string{pattern}
+	}
+	var dirs []string
+	for _, g := range globs {
+		matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(g)))
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.IsDir() && !strings.Contains(m, "node_modules") {
+				dirs = append(dirs, m)
+			}
+		}
+	}
+	return dirs
+}
+
+// matchesAny reports whether rel matches one of the globs.
+func matchesAny(globs []string, rel string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(strings.TrimPrefix(g, "./"), rel); ok {
+			return true
+		}
+		if base, ok := strings.CutSuffix(g, "/**"); ok && strings.HasPrefix(rel, strings.TrimPrefix(base, "./")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// relSlash returns dir relative to root with forward slashes.
+func relSlash(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// sortWorkspaces orders workspaces by path.
+func sortWorkspaces(list []Workspace) {
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+}
@@ -11,7 +11,33 @@ const (
 	VerbOverlay     = "OVERLAY"
 	VerbStatus      = "STATUS" // Full daemon status (Hub's INFO is minimal)
 	VerbStore       = "STORE"
-	VerbAutomate    = "AUTOMATE" // Agent-based automation processing
+	VerbAutomate    = "AUTOMATE"    // Agent-based automation processing
+	VerbExpose      = "EXPOSE"      // Composite process + proxy + tunnel workflow
+	VerbTest        = "TEST"        // Test result recording and history
+	VerbFlaky       = "FLAKY"       // Flaky test report
+	VerbBench       = "BENCH"       // Benchmark tracking and regression detection
+	VerbProfile     = "PROFILE"     // CPU/heap profile capture from managed processes
+	VerbGraph       = "GRAPH"       // Dependency graph between managed entities
+	VerbMock        = "MOCK"        // Canned responses for proxied endpoints
+	VerbHelp        = "HELP"        // Machine-readable usage of daemon commands
+	VerbWorkspace   = "WORKSPACE"   // Disposable copies of a project for experiments
+	VerbCompare     = "COMPARE"     // Same script against two working states
+	VerbRemote      = "REMOTE"      // Scripts on a remote checkout over ssh
+	VerbK8s         = "K8S"         // kubectl port-forwards, pod logs and restarts
+	VerbStack       = "STACK"       // Groups of scripts started in dependency order
+	VerbDouble      = "DOUBLE"      // Stand-ins for third-party APIs
+	VerbWatch       = "WATCH"       // Re-run scripts when project files change
+	VerbDaemon      = "DAEMON"      // Diagnostics of the daemon itself
+	VerbResolve     = "RESOLVE"     // Entity type and full ID of a partial ID
+	VerbWait        = "WAIT"        // Block until the next matching event
+	VerbStatusLite  = "STATUS-LITE" // Cheap per-project counts for status bars
+	VerbScreenshot  = "SCREENSHOT"  // Screenshots of proxied pages stored with thumbnails
+	VerbA11y        = "A11Y"        // Accessibility audits of proxied pages
+	VerbRecord      = "RECORD"      // Recorded browser flows and their replays
+	VerbEvents      = "EVENTS"      // Log of what the daemon started, stopped and changed
+	VerbGit         = "GIT"         // Status, diffs and history of the project's repository
+	VerbHook        = "HOOK"        // Scripts run by the git hooks agnt installs
+	VerbConfig      = "CONFIG"      // Checks and reloads of the project's .agnt.kdl
 )
 
 // Agnt-specific sub-verbs (beyond those in go-cli-server).
@@ -35,14 +61,98 @@ const (
 	SubVerbTasks         = "TASKS"
 	SubVerbFind          = "FIND"
 	SubVerbAttach        = "ATTACH"
-	SubVerbURL           = "URL"     // Report detected URL from agnt run session
-	SubVerbGetAll        = "GET-ALL" // Get all entries in a scope
-	SubVerbDelete        = "DELETE"  // Delete an entry from a scope
-	SubVerbProcess       = "PROCESS" // Process a single automation task
-	SubVerbBatch         = "BATCH"   // Process multiple automation tasks
-	SubVerbRestart       = "RESTART" // Restart a process or proxy
+	SubVerbURL           = "URL"       // Report detected URL from agnt run session
+	SubVerbGetAll        = "GET-ALL"   // Get all entries in a scope
+	SubVerbDelete        = "DELETE"    // Delete an entry from a scope
+	SubVerbProcess       = "PROCESS"   // Process a single automation task
+	SubVerbBatch         = "BATCH"     // Process multiple automation tasks
+	SubVerbRestart       = "RESTART"   // Restart a process or proxy
+	SubVerbResume        = "RESUME"    // Resume a paused tunnel or scheduled task
+	SubVerbPause         = "PAUSE"     // Hold a scheduled task without cancelling it
+	SubVerbRecord        = "RECORD"    // Record test results from process output
+	SubVerbHistory       = "HISTORY"   // Per-test outcome history
+	SubVerbRun           = "RUN"       // Run a test suite, optionally sharded
+	SubVerbReport        = "REPORT"    // Compare the latest benchmark run with its baseline
+	SubVerbCPU           = "CPU"       // Capture a CPU profile
+	SubVerbHeap          = "HEAP"      // Capture a heap profile
+	SubVerbProfile       = "PROFILE"   // Profile the daemon itself
+	SubVerbCrash         = "CRASH"     // Crash reports of managed processes
+	SubVerbShow          = "SHOW"      // Show the dependency graph
+	SubVerbImpact        = "IMPACT"    // Dependents of an entity
+	SubVerbAggregate     = "AGGREGATE" // Traffic breakdown of a proxy
+	SubVerbPreview       = "PREVIEW"   // Dry run of chaos rules against logged traffic
+	SubVerbDigest        = "DIGEST"    // Periodic activity digest of a session
+	SubVerbReplay        = "REPLAY"    // Re-issue a logged request
+	SubVerbAdd           = "ADD"       // Add a rule
+	SubVerbRemove        = "REMOVE"    // Remove a rule
+	SubVerbClipboard     = "CLIPBOARD" // Text moved between pages and a session
+	SubVerbLabel         = "LABEL"     // Set or remove labels of a process, proxy or tunnel
+	SubVerbRoutes        = "ROUTES"    // Path routes of a proxy
+	SubVerbUI            = "UI"        // Overlay language and theme of a proxy
+	SubVerbCreate        = "CREATE"    // Create a workspace
+	SubVerbSupervise     = "SUPERVISE" // Health check and restart policy of a process
+	SubVerbMetrics       = "METRICS"   // CPU and memory history of a process
+	SubVerbResults       = "RESULTS"   // Test results and build errors in a process's output
+	SubVerbError         = "ERROR"     // Frontend error, 5xx response or process failure
+	SubVerbForward       = "FORWARD"   // Forward a remote port
+	SubVerbLogs          = "LOGS"      // Ingest pod logs as process output
+	SubVerbEvents        = "EVENTS"    // Disconnect and reconnect log of tunnels
+	SubVerbIntercept     = "INTERCEPT" // Pause matching proxy requests for inspection
+	SubVerbModify        = "MODIFY"    // Edit a paused request
+	SubVerbReject        = "REJECT"    // Answer a paused request without calling the target
+	SubVerbScenario      = "SCENARIO"  // Timed phases of chaos rules
+	SubVerbSnapshot      = "SNAPSHOT"  // Capture a serialized DOM of a page
+	SubVerbSnapshots     = "SNAPSHOTS" // List kept DOM snapshots
+	SubVerbDiff          = "DIFF"      // Compare two DOM snapshots
+	SubVerbCapture       = "CAPTURE"   // Capture a screenshot of a page
+	SubVerbAudit         = "AUDIT"     // Audit a page for accessibility violations
+	SubVerbCoverage      = "COVERAGE"  // CSS rules no element matched during a page's session
+
+	// SubVerbWaitForIdle waits until a page's network and DOM activity settle.
+	SubVerbWaitForIdle = "WAIT-FOR-IDLE"
+
+	// SubVerbTranscript searches the recorded terminal output of a session.
+	SubVerbTranscript = "TRANSCRIPT"
+
+	// Terminals hosted by the daemon for agnt run --detachable: start one,
+	// list them, and detach the terminal attached to one.
+	SubVerbHost   = "HOST"
+	SubVerbHosted = "HOSTED"
+	SubVerbDetach = "DETACH"
+
+	// SubVerbTag sets and removes the tags and description of a session.
+	SubVerbTag = "TAG"
+
+	// SubVerbAlert manages the error spike alert rules of a session.
+	SubVerbAlert = "ALERT"
+
+	// SubVerbSubscribe streams events as they happen.
+	SubVerbSubscribe = "SUBSCRIBE"
+
+	// SubVerbLog and SubVerbChanged list commits, and the files changed
+	// since a commit.
+	SubVerbLog     = "LOG"
+	SubVerbChanged = "CHANGED"
+
+	// SubVerbValidate checks a configuration without applying it, and
+	// SubVerbReload applies its changes.
+	SubVerbValidate = "VALIDATE"
+	SubVerbReload   = "RELOAD"
 )
 
+// DryRunArg, given as a trailing arg to a destructive command (PROC
+// CLEANUP-PORT, STOP-ALL, CHAOS PRESET, SET and CLEAR), reports what the
+// command would change instead of changing it.
+const DryRunArg = "dry-run"
+
+// AffectedEntity is something a destructive command would change, as
+// reported when it runs with the dry-run arg.
+type AffectedEntity struct {
+	Kind   string `json:"kind"` // process, proxy, tunnel, pid or chaos_rule
+	ID     string `json:"id"`
+	Detail string `json:"detail,omitempty"`
+}
+
 // ProxyStartConfig represents configuration for a PROXY START command.
 type ProxyStartConfig struct {
 	ID          string        `json:"id"`
@@ -85,28 +195,252 @@ type ToastConfig struct {
 	Title    string `json:"title,omitempty"`    // Toast title (optional)
 	Message  string `json:"message"`            // Toast message
 	Duration int    `json:"duration,omitempty"` // Duration in ms (0 for default)
+	// Buttons on the toast; a click is typed into Session, or returned to
+	// the caller when WaitMs is set
+	Actions []ToastAction `json:"actions,omitempty"`
+	Session string        `json:"session,omitempty"` // Session a click is typed into (default: the proxy's project session)
+	WaitMs  int           `json:"wait_ms,omitempty"` // Wait this long for a click and return it
+}
+
+// ToastAction is a button on a toast. Clicking it sends Callback back to
+// the daemon.
+type ToastAction struct {
+	Label    string `json:"label"`
+	Callback string `json:"callback"`
 }
 
 // TunnelStartConfig represents configuration for a TUNNEL START command.
 type TunnelStartConfig struct {
 	ID         string `json:"id"`                    // Tunnel ID (usually same as proxy ID)
-	Provider   string `json:"provider"`              // "cloudflare" or "ngrok"
+	Provider   string `json:"provider"`              // "cloudflare", "ngrok", "localtunnel", "tailscale" or "custom"
 	LocalPort  int    `json:"local_port"`            // Local port to tunnel
 	LocalHost  string `json:"local_host,omitempty"`  // Local host (default: localhost)
 	BinaryPath string `json:"binary_path,omitempty"` // Optional path to tunnel binary
 	ProxyID    string `json:"proxy_id,omitempty"`    // Optional proxy ID to auto-configure public_url
+	Command    string `json:"command,omitempty"`     // Custom provider: command line, {{PORT}} replaced by the port to expose
+	URLPattern string `json:"url_pattern,omitempty"` // Custom provider: regexp finding the public URL in the output (default: first https URL)
+	MaxBytes   int64  `json:"max_bytes,omitempty"`   // Optional bandwidth cap (bytes in + out); pauses the tunnel when exceeded
+	Expires    string `json:"expires,omitempty"`     // Optional TTL (e.g. "2h"); auto-stops the tunnel and clears the proxy's public URL
+	Labels     Labels `json:"labels,omitempty"`      // Optional labels, as with TUNNEL LABEL
+	// NoReconnect leaves the tunnel failed when the provider drops instead of restarting it
+	NoReconnect bool `json:"no_reconnect,omitempty"`
+	// AccessToken and BasicAuth ("user:password") make the linked proxy require
+	// credentials of requests through the tunnel; "auto" generates a token
+	AccessToken string `json:"access_token,omitempty"`
+	BasicAuth   string `json:"basic_auth,omitempty"`
+}
+
+// ProcOutputRequest represents a PROC OUTPUT command. With Follow the daemon
+// sends the current output, then streams new lines as CHUNK frames until the
+// process exits, the client disconnects or TimeoutMs passes.
+type ProcOutputRequest struct {
+	OutputFilter
+	Follow    bool `json:"follow,omitempty"`
+	TimeoutMs int  `json:"timeout_ms,omitempty"` // Follow duration limit (default: until exit)
+}
+
+// ExposeStartConfig represents configuration for an EXPOSE START command.
+type ExposeStartConfig struct {
+	ID         string `json:"id"`                    // Exposure ID (defaults the script name)
+	Script     string `json:"script,omitempty"`      // Script to run (default: ID); ignored when TargetURL is set
+	TargetURL  string `json:"target_url,omitempty"`  // Expose an already-running URL instead of a script
+	Provider   string `json:"provider"`              // "cloudflare", "ngrok", "localtunnel", "tailscale" or "custom"
+	BinaryPath string `json:"binary_path,omitempty"` // Optional path to tunnel binary
+	Command    string `json:"command,omitempty"`     // Custom provider: command line, {{PORT}} replaced by the port to expose
+	URLPattern string `json:"url_pattern,omitempty"` // Custom provider: regexp finding the public URL in the output
+	MaxBytes   int64  `json:"max_bytes,omitempty"`   // Optional tunnel bandwidth cap
+	Expires    string `json:"expires,omitempty"`     // Optional tunnel TTL (e.g. "2h")
+	NoAuth     bool   `json:"no_auth,omitempty"`     // Skip access token protection
+	Encrypt    bool   `json:"encrypt,omitempty"`     // Encrypt instrumentation payloads
+	Path       string `json:"path,omitempty"`        // Project path when no session is attached
+}
+
+// TestRecordConfig represents configuration for a TEST RECORD command.
+type TestRecordConfig struct {
+	ProcessID string `json:"-"`                // Process whose output holds the results
+	Output    string `json:"output,omitempty"` // Raw runner output instead of a process
+	Source    string `json:"source,omitempty"` // Label for the run (default: process ID)
+	Path      string `json:"path,omitempty"`   // Project path when no session is attached
+}
+
+// TestRunConfig represents configuration for a TEST RUN command.
+type TestRunConfig struct {
+	Shards   int    `json:"shards,omitempty"`   // Number of parallel shards (default: 1)
+	Timeout  string `json:"timeout,omitempty"`  // Overall timeout such as "5m" (default: 10m)
+	Affected bool   `json:"affected,omitempty"` // Only run tests affected by changed files
+	Base     string `json:"base,omitempty"`     // With Affected: also include changes since this ref
+	DryRun   bool   `json:"dry_run,omitempty"`  // With Affected: report the selection without running
+	Path     string `json:"path,omitempty"`     // Project path when no session is attached
+}
+
+// BenchConfig represents configuration for BENCH RUN and BENCH REPORT commands.
+// Fields override the suite's settings from .agnt.kdl.
+type BenchConfig struct {
+	Name      string  `json:"-"`                   // Configured suite (default: the only one, or Go benchmarks)
+	Bench     string  `json:"bench,omitempty"`     // go test -bench pattern
+	Package   string  `json:"package,omitempty"`   // Go package pattern
+	Count     int     `json:"count,omitempty"`     // Samples per benchmark
+	Baseline  string  `json:"baseline,omitempty"`  // git ref to compare against
+	Threshold float64 `json:"threshold,omitempty"` // Minimum slowdown in percent to report
+	Timeout   string  `json:"timeout,omitempty"`   // BENCH RUN timeout such as "10m" (default: 20m)
+	Path      string  `json:"path,omitempty"`      // Project path when no session is attached
+}
+
+// Restart policies of a supervised process.
+const (
+	RestartNever     = "never"      // Only health is reported
+	RestartOnFailure = "on-failure" // Restart on a non-zero exit or when unhealthy
+	RestartAlways    = "always"     // Restart whenever the process exits
+)
+
+// HealthCheck probes a process: an HTTP GET answered below 400, or a TCP
+// port accepting connections.
+type HealthCheck struct {
+	URL              string `json:"url,omitempty"`
+	Port             int    `json:"port,omitempty"`
+	IntervalMs       int    `json:"interval_ms,omitempty"`       // Between probes (default: 5000)
+	TimeoutMs        int    `json:"timeout_ms,omitempty"`        // Per probe (default: 2000)
+	FailureThreshold int    `json:"failure_threshold,omitempty"` // Consecutive failures before unhealthy (default: 3)
+}
+
+// SuperviseConfig represents configuration for a PROC SUPERVISE command.
+type SuperviseConfig struct {
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+	Restart     string       `json:"restart,omitempty"`      // never (default), on-failure or always
+	MaxRestarts int          `json:"max_restarts,omitempty"` // Restarts before giving up (default: 5)
+}
+
+// CompareConfig represents configuration for a COMPARE RUN command. The
+// script runs in the project and in a workspace at Ref (or an existing
+// Workspace) side by side.
+type CompareConfig struct {
+	Script    string   `json:"script,omitempty"`     // .agnt.kdl or detected script name
+	Run       string   `json:"run,omitempty"`        // Shell command instead of a script
+	Command   string   `json:"command,omitempty"`    // Executable instead of a script
+	Args      []string `json:"args,omitempty"`       // Arguments of command, or extra script args
+	Ref       string   `json:"ref,omitempty"`        // git ref of the base (default: HEAD)
+	Workspace string   `json:"workspace,omitempty"`  // Existing workspace as the base instead of ref
+	BundleDir string   `json:"bundle_dir,omitempty"` // Build output directory to measure, project-relative
+	Timeout   string   `json:"timeout,omitempty"`    // Timeout such as "10m" (default: 10m)
+	Path      string   `json:"path,omitempty"`       // Project path when no session is attached
+}
+
+// GitRequest represents the payload of the GIT commands, which run git in
+// the session's project.
+type GitRequest struct {
+	Ref    string   `json:"ref,omitempty"`    // DIFF: commit to compare with; LOG: start from; CHANGED: base (default HEAD)
+	Since  string   `json:"since,omitempty"`  // LOG: only later commits; CHANGED: base is the last commit before ("2 hours ago")
+	Staged bool     `json:"staged,omitempty"` // DIFF: the index instead of the working tree
+	Patch  bool     `json:"patch,omitempty"`  // DIFF: include the patch text (up to 64KB)
+	Author string   `json:"author,omitempty"` // LOG: only commits whose author matches
+	Limit  int      `json:"limit,omitempty"`  // LOG: commits returned (default 20, max 500)
+	Paths  []string `json:"paths,omitempty"`  // Only these project-relative paths
+	Path   string   `json:"path,omitempty"`   // Project path when no session is attached
+}
+
+// RemoteRunConfig represents configuration for a REMOTE RUN command. The
+// command runs over ssh on the checkout named by the remote block of the
+// project's .agnt.kdl.
+type RemoteRunConfig struct {
+	ID      string   `json:"id,omitempty"`      // Process ID (default: the script or command name)
+	Script  string   `json:"script,omitempty"`  // .agnt.kdl or detected script name
+	Run     string   `json:"run,omitempty"`     // Shell command instead of a script
+	Command string   `json:"command,omitempty"` // Executable instead of a script
+	Args    []string `json:"args,omitempty"`    // Arguments of command, or extra script args
+	Mode    string   `json:"mode,omitempty"`    // background (default) or foreground
+	Path    string   `json:"path,omitempty"`    // Project path when no session is attached
+}
+
+// K8sForwardConfig represents configuration for a K8S FORWARD command: a
+// kubectl port-forward run as a managed process.
+type K8sForwardConfig struct {
+	ID        string `json:"id,omitempty"`         // Entity ID (default: derived from resource and port)
+	Resource  string `json:"resource"`             // svc/api, deploy/api or pod/api-0
+	Port      int    `json:"port"`                 // Port of the resource
+	LocalPort int    `json:"local_port,omitempty"` // Loopback port (default: port if free)
+	Context   string `json:"context,omitempty"`    // kubectl context (default: current)
+	Namespace string `json:"namespace,omitempty"`  // Namespace (default: the context's)
+	Path      string `json:"path,omitempty"`       // Project path when no session is attached
+}
+
+// K8sLogsConfig represents configuration for a K8S LOGS command: the logs of
+// the pods matching a selector are followed as a managed process's output,
+// and their container restarts are recorded.
+type K8sLogsConfig struct {
+	ID        string `json:"id,omitempty"`        // Entity ID (default: derived from the selector)
+	Selector  string `json:"selector"`            // Label selector such as app=api
+	Container string `json:"container,omitempty"` // One container (default: all)
+	Tail      int    `json:"tail,omitempty"`      // Lines of existing logs per container (default: 100)
+	Context   string `json:"context,omitempty"`   // kubectl context (default: current)
+	Namespace string `json:"namespace,omitempty"` // Namespace (default: the context's)
+	Path      string `json:"path,omitempty"`      // Project path when no session is attached
+}
+
+// DoubleStartConfig represents configuration for a DOUBLE START command. Each
+// field overrides the double of that name in .agnt.kdl, if any.
+type DoubleStartConfig struct {
+	Profile     string `json:"profile,omitempty"`      // Built-in profile (default: the double's name)
+	ProfileFile string `json:"profile_file,omitempty"` // JSON profile instead of a built-in one
+	Port        int    `json:"port,omitempty"`         // Port (default: any free port)
+	WebhookURL  string `json:"webhook_url,omitempty"`  // Where webhooks are posted
+	LatencyMs   string `json:"latency_ms,omitempty"`   // Latency range such as 50-200
+	Seed        int64  `json:"seed,omitempty"`         // Seed of IDs and latencies
+}
+
+// WatchConfig represents optional configuration for a WATCH ADD command.
+type WatchConfig struct {
+	DebounceMs int   `json:"debounce_ms,omitempty"` // Quiet time after the last change (default: 300)
+	Notify     *bool `json:"notify,omitempty"`      // Tell sessions what changed (default: only without a script)
+}
+
+// WaitErrorConfig represents configuration for a WAIT ERROR command.
+type WaitErrorConfig struct {
+	Kinds     []string `json:"kinds,omitempty"`      // frontend_error, http_5xx, process_failure (default: all)
+	Source    string   `json:"source,omitempty"`     // Only this proxy or process; an ID part is enough
+	Match     string   `json:"match,omitempty"`      // Regex over the message, URL and stack
+	TimeoutMs int      `json:"timeout_ms,omitempty"` // How long to wait (default: 60000, max: 300000)
+	Global    bool     `json:"global,omitempty"`     // Errors of all projects, not only the session's
+	Path      string   `json:"path,omitempty"`       // Project path when no session is attached
+}
+
+// EventsFilter represents the filter of EVENTS QUERY and EVENTS SUBSCRIBE.
+type EventsFilter struct {
+	Types     []string `json:"types,omitempty"`      // Event types, or families like "process" (default: all)
+	Source    string   `json:"source,omitempty"`     // Only this process, proxy, tunnel or session; an ID part is enough
+	Labels    Labels   `json:"labels,omitempty"`     // Only events of processes, proxies and tunnels with every label
+	Since     int64    `json:"since,omitempty"`      // Only events after this sequence number
+	Limit     int      `json:"limit,omitempty"`      // QUERY: newest events returned (default: 100); SUBSCRIBE: events before the stream ends
+	TimeoutMs int      `json:"timeout_ms,omitempty"` // SUBSCRIBE: how long to stream (default: 60000, max: 600000)
+	Global    bool     `json:"global,omitempty"`     // Events of all projects, not only the session's
+	Path      string   `json:"path,omitempty"`       // Project path when no session is attached
+}
+
+// ProfileConfig represents configuration for PROFILE CPU and PROFILE HEAP commands.
+type ProfileConfig struct {
+	Seconds int    `json:"seconds,omitempty"` // Sampling duration (default: 10, max: 60)
+	Address string `json:"address,omitempty"` // pprof base URL or Node inspector ws:// URL override
+	Top     int    `json:"top,omitempty"`     // Functions in the summary (default: 20)
+	Path    string `json:"path,omitempty"`    // Project path when no session is attached
 }
 
 // ChaosRuleConfig represents configuration for a CHAOS ADD-RULE command.
 type ChaosRuleConfig struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name,omitempty"`
-	Type        string   `json:"type"` // latency, out_of_order, slow_drip, disconnect, http_error, truncate, etc.
+	Type        string   `json:"type"` // latency, bandwidth_limit, out_of_order, slow_drip, disconnect, connection_reset, dns_failure, http_error, truncate, etc.
 	Enabled     bool     `json:"enabled"`
 	URLPattern  string   `json:"url_pattern,omitempty"`
 	Methods     []string `json:"methods,omitempty"`
 	Probability float64  `json:"probability,omitempty"` // 0.0-1.0, default 1.0
 
+	// Page session IDs (page-N) or browser session IDs the rule is limited to
+	Sessions []string `json:"sessions,omitempty"`
+
+	// Field matchers; every one must match
+	Headers    []ChaosMatcherConfig `json:"headers,omitempty"`
+	Query      []ChaosMatcherConfig `json:"query,omitempty"`
+	BodyFields []ChaosMatcherConfig `json:"body_fields,omitempty"`
+
 	// Latency config
 	MinLatencyMs int `json:"min_latency_ms,omitempty"`
 	MaxLatencyMs int `json:"max_latency_ms,omitempty"`
@@ -116,10 +450,16 @@ type ChaosRuleConfig struct {
 	BytesPerMs int `json:"bytes_per_ms,omitempty"`
 	ChunkSize  int `json:"chunk_size,omitempty"`
 
-	// Connection drop config
+	// Bandwidth limit config
+	BandwidthKbps int `json:"bandwidth_kbps,omitempty"`
+
+	// Connection drop and reset config
 	DropAfterPercent float64 `json:"drop_after_percent,omitempty"`
 	DropAfterBytes   int64   `json:"drop_after_bytes,omitempty"`
 
+	// DNS failure config
+	Hosts []string `json:"hosts,omitempty"`
+
 	// Error injection config
 	ErrorCodes   []int  `json:"error_codes,omitempty"`
 	ErrorMessage string `json:"error_message,omitempty"`
@@ -135,6 +475,13 @@ type ChaosRuleConfig struct {
 	StaleDelayMs int64 `json:"stale_delay_ms,omitempty"`
 }
 
+// ChaosMatcherConfig matches a request header, query parameter or JSON body
+// field of a chaos rule.
+type ChaosMatcherConfig struct {
+	Name  string `json:"name"`            // Header, query parameter, or dotted JSON path
+	Value string `json:"value,omitempty"` // Regex; empty matches any present value
+}
+
 // ChaosConfigPayload represents the full chaos configuration for SET command.
 type ChaosConfigPayload struct {
 	Enabled     bool               `json:"enabled"`
@@ -144,13 +491,30 @@ type ChaosConfigPayload struct {
 	LoggingMode int                `json:"logging_mode,omitempty"` // 0=silent, 1=testing, 2=coordinated
 }
 
+// ChaosScenarioPayload is a timed sequence of chaos phases for CHAOS SCENARIO START.
+type ChaosScenarioPayload struct {
+	Name   string              `json:"name"`
+	Phases []ChaosPhasePayload `json:"phases"`
+	Loop   bool                `json:"loop,omitempty"`
+}
+
+// ChaosPhasePayload is one phase of a ChaosScenarioPayload.
+type ChaosPhasePayload struct {
+	Name       string             `json:"name,omitempty"`
+	DurationMs int64              `json:"duration_ms"`
+	Preset     string             `json:"preset,omitempty"`
+	Rules      []*ChaosRuleConfig `json:"rules,omitempty"`
+}
+
 // SessionRegisterConfig represents configuration for a SESSION REGISTER command.
 // This extends the base hub SessionRegisterConfig with agnt-specific fields.
 type SessionRegisterConfig struct {
-	OverlayPath string   `json:"overlay_path"`   // Unix socket path for overlay
+	OverlayPath string   `json:"overlay_path"`   // Overlay endpoint: socket path, named pipe or tcp://127.0.0.1:port
 	ProjectPath string   `json:"project_path"`   // Directory where session was started
 	Command     string   `json:"command"`        // Command being run (e.g., "claude")
 	Args        []string `json:"args,omitempty"` // Command arguments
+	Tags        Labels   `json:"tags,omitempty"` // Tags such as role=frontend, for SESSION LIST and FIND
+	Description string   `json:"description,omitempty"`
 }
 
 // SessionScheduleConfig represents configuration for a SESSION SCHEDULE command.
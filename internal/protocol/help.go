@@ -0,0 +1,158 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CommandHelp is the machine-readable usage of a daemon command, as returned
+// by HELP.
+type CommandHelp struct {
+	Verb        string        `json:"verb"`
+	Description string        `json:"description"`
+	Usage       string        `json:"usage,omitempty"` // For verbs without sub-verbs
+	Args        []ArgHelp     `json:"args,omitempty"`
+	Data        *Schema       `json:"data,omitempty"`
+	DataText    string        `json:"data_text,omitempty"` // Non-JSON payload, e.g. JavaScript source
+	Examples    []string      `json:"examples,omitempty"`
+	SubVerbs    []SubVerbHelp `json:"sub_verbs,omitempty"`
+	Builtin     bool          `json:"builtin,omitempty"` // Served by the hub rather than agnt
+}
+
+// SubVerbHelp is the usage of one sub-verb of a command.
+type SubVerbHelp struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Usage       string    `json:"usage"`
+	Args        []ArgHelp `json:"args,omitempty"`
+	Data        *Schema   `json:"data,omitempty"`
+	DataText    string    `json:"data_text,omitempty"`
+	Examples    []string  `json:"examples,omitempty"`
+}
+
+// ArgHelp describes a positional argument.
+type ArgHelp struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+	Variadic    bool   `json:"variadic,omitempty"`
+}
+
+// FormatUsage renders a usage line such as "PROC STOP <process_id> [force]".
+func FormatUsage(verb, subVerb string, args []ArgHelp) string {
+	parts := []string{verb}
+	if subVerb != "" {
+		parts = append(parts, subVerb)
+	}
+	for _, a := range args {
+		name := a.Name
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Schema is a JSON Schema describing a command's JSON payload.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf derives the JSON Schema of the type of v from its json tags. v is
+// normally the zero value of the type a handler decodes its payload into.
+// No property is marked required: handlers decode with DecodePayload, which
+// rejects wrong types but only warns about unknown fields, and check
+// required values themselves.
+func SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: "object"} // Recursive type
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(s, t, seen)
+		return s
+	}
+	return &Schema{} // interface{} and anything else: any value
+}
+
+// addFields adds the JSON-visible fields of struct type t to s, flattening
+// embedded structs the way encoding/json does.
+func addFields(s *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, seen)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = schemaOf(f.Type, seen)
+	}
+}
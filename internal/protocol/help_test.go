@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatUsage(t *testing.T) {
+	args := []ArgHelp{
+		{Name: "process_id"},
+		{Name: "force", Optional: true},
+		{Name: "args", Optional: true, Variadic: true},
+	}
+	if got := FormatUsage("PROC", "STOP", args); got != "PROC STOP <process_id> [force] [args...]" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+	if got := FormatUsage("STATUS", "", nil); got != "STATUS" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+}
+
+type schemaInner struct {
+	Name string `json:"name"`
+}
+
+type schemaNode struct {
+	Children []*schemaNode `json:"children"`
+}
+
+type schemaSample struct {
+	schemaInner
+	ID       string          `json:"id,omitempty"`
+	Count    int             `json:"count"`
+	Ratio    float64         `json:"ratio"`
+	On       bool            `json:"on"`
+	Tags     []string        `json:"tags"`
+	Labels   map[string]int  `json:"labels"`
+	At       time.Time       `json:"at"`
+	Raw      json.RawMessage `json:"raw"`
+	Blob     []byte          `json:"blob"`
+	Any      interface{}     `json:"any"`
+	Node     *schemaNode     `json:"node"`
+	Untagged string
+	Skipped  string `json:"-"`
+	private  string
+	Nested   map[string]schemaInner `json:"nested"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	if SchemaOf(nil) != nil {
+		t.Error("Expected nil schema for nil value")
+	}
+
+	s := SchemaOf(schemaSample{})
+	if s.Type != "object" {
+		t.Fatalf("Expected object, got %q", s.Type)
+	}
+	want := map[string]string{
+		"name":     "string",
+		"id":       "string",
+		"count":    "integer",
+		"ratio":    "number",
+		"on":       "boolean",
+		"tags":     "array",
+		"labels":   "object",
+		"at":       "string",
+		"raw":      "",
+		"blob":     "string",
+		"any":      "",
+		"node":     "object",
+		"Untagged": "string",
+		"nested":   "object",
+	}
+	if len(s.Properties) != len(want) {
+		t.Errorf("Expected %d properties, got %d: %v", len(want), len(s.Properties), s.Properties)
+	}
+	for name, typ := range want {
+		p, ok := s.Properties[name]
+		if !ok {
+			t.Errorf("Missing property %q", name)
+			continue
+		}
+		if p.Type != typ {
+			t.Errorf("Property %q: expected type %q, got %q", name, typ, p.Type)
+		}
+	}
+	if s.Properties["tags"].Items.Type != "string" {
+		t.Error("Expected string items for tags")
+	}
+	if s.Properties["labels"].AdditionalProperties.Type != "integer" {
+		t.Error("Expected integer values for labels")
+	}
+	if s.Properties["at"].Format != "date-time" || s.Properties["blob"].Format != "byte" {
+		t.Error("Expected date-time and byte formats")
+	}
+	if s.Properties["nested"].AdditionalProperties.Properties["name"] == nil {
+		t.Error("Expected map values to be described")
+	}
+
+	// Recursive types terminate
+	child := s.Properties["node"].Properties["children"].Items
+	if child.Type != "object" || child.Properties != nil {
+		t.Errorf("Expected opaque object for recursive type, got %+v", child)
+	}
+}
@@ -147,6 +147,32 @@ func init() {
 		VerbOverlay,
 		VerbStatus,
 		VerbStore,
+		VerbExpose,
+		VerbTest,
+		VerbFlaky,
+		VerbBench,
+		VerbProfile,
+		VerbGraph,
+		VerbMock,
+		VerbHelp,
+		VerbWorkspace,
+		VerbCompare,
+		VerbRemote,
+		VerbK8s,
+		VerbStack,
+		VerbDouble,
+		VerbWatch,
+		VerbDaemon,
+		VerbResolve,
+		VerbWait,
+		VerbStatusLite,
+		VerbScreenshot,
+		VerbA11y,
+		VerbRecord,
+		VerbEvents,
+		VerbGit,
+		VerbHook,
+		VerbConfig,
 	)
 
 	// Register agnt-specific sub-verbs.
@@ -173,5 +199,57 @@ func init() {
 		SubVerbURL,
 		SubVerbGetAll,
 		SubVerbDelete,
+		SubVerbResume,
+		SubVerbPause,
+		SubVerbRecord,
+		SubVerbHistory,
+		SubVerbRun,
+		SubVerbReport,
+		SubVerbCPU,
+		SubVerbHeap,
+		SubVerbProfile,
+		SubVerbCrash,
+		SubVerbShow,
+		SubVerbImpact,
+		SubVerbAggregate,
+		SubVerbPreview,
+		SubVerbDigest,
+		SubVerbReplay,
+		SubVerbAdd,
+		SubVerbRemove,
+		SubVerbClipboard,
+		SubVerbLabel,
+		SubVerbRoutes,
+		SubVerbUI,
+		SubVerbWaitForIdle,
+		SubVerbCreate,
+		SubVerbSupervise,
+		SubVerbMetrics,
+		SubVerbResults,
+		SubVerbError,
+		SubVerbForward,
+		SubVerbLogs,
+		SubVerbEvents,
+		SubVerbIntercept,
+		SubVerbModify,
+		SubVerbReject,
+		SubVerbScenario,
+		SubVerbSnapshot,
+		SubVerbSnapshots,
+		SubVerbDiff,
+		SubVerbCapture,
+		SubVerbAudit,
+		SubVerbCoverage,
+		SubVerbTranscript,
+		SubVerbHost,
+		SubVerbHosted,
+		SubVerbDetach,
+		SubVerbTag,
+		SubVerbAlert,
+		SubVerbSubscribe,
+		SubVerbLog,
+		SubVerbChanged,
+		SubVerbValidate,
+		SubVerbReload,
 	)
 }
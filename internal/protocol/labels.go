@@ -0,0 +1,95 @@
+package protocol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Label limits.
+const (
+	MaxLabels           = 32  // Labels per entity
+	MaxLabelKeyLength   = 63  // Characters in a key
+	MaxLabelValueLength = 128 // Characters in a value
+)
+
+// Labels are free-form key=value tags on processes, proxies and tunnels,
+// such as area=checkout or owner=payments, used to filter LIST results.
+type Labels map[string]string
+
+// Validate checks label count, key characters and lengths.
+func (l Labels) Validate() error {
+	if len(l) > MaxLabels {
+		return fmt.Errorf("%d labels, over the limit of %d", len(l), MaxLabels)
+	}
+	for key, value := range l {
+		if err := validateLabelKey(key); err != nil {
+			return err
+		}
+		if len(value) > MaxLabelValueLength {
+			return fmt.Errorf("label %s: value over %d characters", key, MaxLabelValueLength)
+		}
+	}
+	return nil
+}
+
+// validateLabelKey allows letters, digits and - _ . / in keys.
+func validateLabelKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("label key is empty")
+	}
+	if len(key) > MaxLabelKeyLength {
+		return fmt.Errorf("label key %q over %d characters", key, MaxLabelKeyLength)
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == '/':
+		default:
+			return fmt.Errorf("label key %q: use letters, digits, -, _, . and /", key)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether l has every label of selector. An empty selector
+// value matches any value of the key.
+func (l Labels) Matches(selector Labels) bool {
+	for key, want := range selector {
+		got, ok := l[key]
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders labels as sorted key=value pairs.
+func (l Labels) String() string {
+	pairs := make([]string, 0, len(l))
+	for key, value := range l {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ParseLabels reads a selector or label list such as "area=checkout,owner"
+// (a bare key matches any value).
+func ParseLabels(s string) (Labels, error) {
+	labels := Labels{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return labels, labels.Validate()
+}
+
+// ListFilter scopes PROC, PROXY and TUNNEL LIST by directory and labels.
+type ListFilter struct {
+	DirectoryFilter
+	Labels Labels `json:"labels,omitempty"` // Every label must match
+}
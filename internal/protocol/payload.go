@@ -0,0 +1,226 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PayloadError describes a JSON payload that doesn't fit the type its
+// command decodes it into.
+type PayloadError struct {
+	Field    string  `json:"field,omitempty"`    // JSON path, such as "rule.status" or "rules[0]"
+	Expected string  `json:"expected,omitempty"` // JSON type the field takes
+	Got      string  `json:"got,omitempty"`      // JSON type that was sent
+	Offset   int64   `json:"offset,omitempty"`   // Byte offset of a syntax error
+	Reason   string  `json:"reason"`
+	Schema   *Schema `json:"schema,omitempty"` // The expected payload
+}
+
+func (e *PayloadError) Error() string {
+	return e.Reason
+}
+
+// Fields lists the payload's top-level fields as "name (type)", sorted.
+func (e *PayloadError) Fields() []string {
+	if e.Schema == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(e.Schema.Properties))
+	for name, prop := range e.Schema.Properties {
+		fields = append(fields, fmt.Sprintf("%s (%s)", name, schemaTypeName(prop)))
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// DecodePayload decodes a command's JSON payload into v, a pointer. An empty
+// payload leaves v untouched. Malformed JSON and values of the wrong type
+// return a *PayloadError. Fields v doesn't have are not an error, so older
+// daemons accept payloads of newer clients; they are returned as warnings
+// naming their JSON path and the closest known field.
+func DecodePayload(data []byte, v interface{}) (warnings []string, err error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	schema := SchemaOf(v)
+
+	if err := json.Unmarshal(data, v); err != nil {
+		perr := &PayloadError{Schema: schema}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			perr.Offset = syntaxErr.Offset
+			perr.Reason = fmt.Sprintf("malformed JSON at byte %d: %v", syntaxErr.Offset, syntaxErr)
+		case errors.As(err, &typeErr):
+			perr.Field = jsonPath(typeErr.Field)
+			perr.Got = typeErr.Value
+			perr.Expected = schemaTypeName(schemaOf(typeErr.Type, map[reflect.Type]bool{}))
+			if perr.Field == "" {
+				perr.Reason = fmt.Sprintf("payload must be %s, got %s", article(perr.Expected), perr.Got)
+			} else {
+				perr.Reason = fmt.Sprintf("field %q must be %s, got %s", perr.Field, article(perr.Expected), perr.Got)
+			}
+		default:
+			perr.Reason = err.Error()
+		}
+		return nil, perr
+	}
+
+	var generic interface{}
+	if json.Unmarshal(data, &generic) == nil {
+		warnings = unknownFields(generic, schema, "", warnings)
+	}
+	return warnings, nil
+}
+
+// jsonPath turns encoding/json's dotted field path ("rules.0.status") into
+// the form warnings use ("rules[0].status").
+func jsonPath(field string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// unknownFields appends a warning for each object key of value that schema
+// doesn't describe. encoding/json matches keys case-insensitively, so a key
+// differing only in case is known.
+func unknownFields(value interface{}, schema *Schema, path string, warnings []string) []string {
+	if schema == nil {
+		return warnings
+	}
+	switch val := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if schema.AdditionalProperties != nil {
+				warnings = unknownFields(val[k], schema.AdditionalProperties, path+k+".", warnings)
+				continue
+			}
+			if schema.Properties == nil {
+				continue
+			}
+			prop, ok := lookupProperty(schema, k)
+			if !ok {
+				warning := fmt.Sprintf("unknown field %q ignored", path+k)
+				if near := closestProperty(schema, k); near != "" {
+					warning += fmt.Sprintf(" (did you mean %q?)", path+near)
+				}
+				warnings = append(warnings, warning)
+				continue
+			}
+			warnings = unknownFields(val[k], prop, path+k+".", warnings)
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			base := strings.TrimSuffix(path, ".")
+			for i, item := range val {
+				warnings = unknownFields(item, schema.Items, fmt.Sprintf("%s[%d].", base, i), warnings)
+			}
+		}
+	}
+	return warnings
+}
+
+// lookupProperty finds a property by exact name, then case-insensitively.
+func lookupProperty(schema *Schema, name string) (*Schema, bool) {
+	if prop, ok := schema.Properties[name]; ok {
+		return prop, true
+	}
+	for k, prop := range schema.Properties {
+		if strings.EqualFold(k, name) {
+			return prop, true
+		}
+	}
+	return nil, false
+}
+
+// closestProperty returns the property name equal to name but for case and
+// separators, or else the nearest one within two edits, if any.
+func closestProperty(schema *Schema, name string) string {
+	best, bestDist := "", 3
+	normalized := normalizeFieldName(name)
+	for k := range schema.Properties {
+		if normalizeFieldName(k) == normalized {
+			return k
+		}
+		if d := EditDistance(strings.ToLower(k), strings.ToLower(name)); d < bestDist || (d == bestDist && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	if bestDist > 2 {
+		return ""
+	}
+	return best
+}
+
+// normalizeFieldName drops separators and case, so processId, process-id
+// and PROCESS_ID compare equal.
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", ".", "").Replace(name))
+}
+
+// EditDistance is the Levenshtein distance between a and b, in bytes.
+func EditDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// schemaTypeName names a schema's JSON type for messages.
+func schemaTypeName(s *Schema) string {
+	switch {
+	case s == nil || s.Type == "":
+		return "any"
+	case s.Type == "array" && s.Items != nil && s.Items.Type != "":
+		return "array of " + s.Items.Type
+	case s.Format == "date-time":
+		return "string (RFC 3339 time)"
+	}
+	return s.Type
+}
+
+// article prefixes a type name with "a" or "an".
+func article(typeName string) string {
+	switch {
+	case typeName == "any":
+		return "any value"
+	case strings.IndexAny(typeName[:1], "aeiou") == 0:
+		return "an " + typeName
+	}
+	return "a " + typeName
+}
@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type payloadRule struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+}
+
+type payloadSample struct {
+	ProcessID string            `json:"process_id"`
+	Seconds   int               `json:"seconds,omitempty"`
+	Rules     []payloadRule     `json:"rules,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+func TestDecodePayload(t *testing.T) {
+	var got payloadSample
+	warnings, err := DecodePayload([]byte(`{"process_id":"dev","SECONDS":5,"rules":[{"id":"a","stauts":500}],"env":{"A":"1"}}`), &got)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if got.ProcessID != "dev" || got.Seconds != 5 || len(got.Rules) != 1 || got.Env["A"] != "1" {
+		t.Errorf("decoded %+v", got)
+	}
+	want := []string{`unknown field "rules[0].stauts" ignored (did you mean "rules[0].status"?)`}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestDecodePayload_Empty(t *testing.T) {
+	v := payloadSample{ProcessID: "keep"}
+	for _, data := range []string{"", "  ", "null"} {
+		if warnings, err := DecodePayload([]byte(data), &v); err != nil || warnings != nil {
+			t.Errorf("DecodePayload(%q) = %v, %v", data, warnings, err)
+		}
+	}
+	if v.ProcessID != "keep" {
+		t.Errorf("empty payload changed v: %+v", v)
+	}
+}
+
+func TestDecodePayload_SeparatorSuggestion(t *testing.T) {
+	var v payloadSample
+	warnings, _ := DecodePayload([]byte(`{"processId":"dev","totally_different":1}`), &v)
+	want := []string{
+		`unknown field "processId" ignored (did you mean "process_id"?)`,
+		`unknown field "totally_different" ignored`,
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestDecodePayload_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		field    string
+		expected string
+		reason   string
+	}{
+		{"wrong type", `{"seconds":"ten"}`, "seconds", "integer", `field "seconds" must be an integer, got string`},
+		{"nested", `{"rules":[{"status":"500"}]}`, "rules[0].status", "integer", `field "rules[0].status" must be an integer, got string`},
+		{"not an object", `[1,2]`, "", "object", "payload must be an object, got array"},
+		{"malformed", `{"seconds":`, "", "", "malformed JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v payloadSample
+			_, err := DecodePayload([]byte(tt.data), &v)
+			var perr *PayloadError
+			if !errors.As(err, &perr) {
+				t.Fatalf("err = %v, want *PayloadError", err)
+			}
+			if perr.Field != tt.field || perr.Expected != tt.expected || !strings.Contains(perr.Reason, tt.reason) {
+				t.Errorf("got field=%q expected=%q reason=%q", perr.Field, perr.Expected, perr.Reason)
+			}
+			fields := perr.Fields()
+			if len(fields) != 4 || fields[2] != "rules (array of object)" {
+				t.Errorf("fields = %q", fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// A11yAuditTimeout bounds the wait for the page to finish the audit,
+	// including loading axe-core. It stays below the daemon client's request
+	// timeout.
+	A11yAuditTimeout = 20 * time.Second
+	// defaultA11ySamples is how many selectors a rule lists by default.
+	defaultA11ySamples = 3
+	// maxA11ySamples caps the selectors a rule lists.
+	maxA11ySamples = 20
+)
+
+// A11yAuditOptions configures AuditAccessibility.
+type A11yAuditOptions struct {
+	Selector string `json:"selector,omitempty"` // Only audit this element and its descendants
+	Level    string `json:"level,omitempty"`    // WCAG level: a, aa (default) or aaa
+	Mode     string `json:"mode,omitempty"`     // standard (axe-core, default), basic, fast or comprehensive
+	Samples  int    `json:"samples,omitempty"`  // Selectors listed per rule (default 3, max 20)
+}
+
+// A11yReport is an accessibility audit of a page, with violations grouped
+// by rule.
+type A11yReport struct {
+	SessionID      string     `json:"session_id,omitempty"`
+	URL            string     `json:"url"`
+	Selector       string     `json:"selector,omitempty"`
+	Engine         string     `json:"engine"` // axe-core, basic, fast or comprehensive
+	Level          string     `json:"level,omitempty"`
+	FallbackReason string     `json:"fallback_reason,omitempty"` // Why axe-core was not used
+	Score          int        `json:"score"`
+	Grade          string     `json:"grade,omitempty"`
+	Violations     int        `json:"violations"` // Failing elements across all rules
+	Errors         int        `json:"errors"`
+	Warnings       int        `json:"warnings"`
+	Passed         int        `json:"passed,omitempty"`     // Rules passed (axe-core)
+	Incomplete     int        `json:"incomplete,omitempty"` // Rules needing manual review (axe-core)
+	Rules          []A11yRule `json:"rules"`                // Errors first, then by count
+	Timestamp      time.Time  `json:"timestamp"`
+}
+
+// A11yRule is one rule failed by elements of the page.
+type A11yRule struct {
+	Rule      string   `json:"rule"` // e.g. color-contrast, image-alt
+	Severity  string   `json:"severity"`
+	Count     int      `json:"count"`
+	Message   string   `json:"message"`
+	Fix       string   `json:"fix,omitempty"`
+	WCAG      string   `json:"wcag,omitempty"`
+	HelpURL   string   `json:"help_url,omitempty"`
+	Selectors []string `json:"selectors"` // The first failing elements
+}
+
+// a11yAuditResult is the raw result of __devtool.auditAccessibility.
+type a11yAuditResult struct {
+	Mode           string `json:"mode"`
+	Level          string `json:"level"`
+	Score          int    `json:"score"`
+	Grade          string `json:"grade"`
+	Fallback       bool   `json:"fallback"`
+	FallbackReason string `json:"fallbackReason"`
+	Fixable        []struct {
+		Type     string `json:"type"`
+		Severity string `json:"severity"`
+		Selector string `json:"selector"`
+		Message  string `json:"message"`
+		Fix      string `json:"fix"`
+		WCAG     string `json:"wcag"`
+		HelpURL  string `json:"helpUrl"`
+	} `json:"fixable"`
+	Stats struct {
+		Passed     int `json:"passed"`
+		Incomplete int `json:"incomplete"`
+	} `json:"stats"`
+}
+
+// AuditAccessibility runs the injected accessibility audit in a page and
+// groups its violations by rule. An empty sessionID audits whichever
+// connected page answers first.
+func (ps *ProxyServer) AuditAccessibility(ctx context.Context, sessionID string, opts A11yAuditOptions) (A11yReport, error) {
+	raw, url, err := ps.evalInPage(ctx, sessionID, a11yAuditScript(opts), A11yAuditTimeout)
+	if err != nil {
+		return A11yReport{}, fmt.Errorf("accessibility audit failed: %w", err)
+	}
+	report, err := parseA11yAudit(raw, opts.Samples)
+	if err != nil {
+		return A11yReport{}, err
+	}
+	report.SessionID = sessionID
+	report.URL = url
+	report.Selector = opts.Selector
+	report.Timestamp = time.Now()
+	return report, nil
+}
+
+// a11yAuditScript returns the code that runs the audit in its detailed form
+// and, with a selector, keeps only the issues of elements inside it.
+func a11yAuditScript(opts A11yAuditOptions) string {
+	args, _ := json.Marshal(map[string]interface{}{
+		"mode":     opts.Mode,
+		"level":    opts.Level,
+		"selector": opts.Selector,
+		"raw":      true,
+	})
+	return fmt.Sprintf(`(function() {
+  var opts = %s;
+  var scope = null;
+  if (opts.selector) {
+    scope = document.querySelector(opts.selector);
+    if (!scope) throw new Error('No element matches ' + opts.selector);
+  }
+  if (opts.mode === 'basic') opts.useBasic = true;
+  if (!opts.mode) delete opts.mode;
+  if (!opts.level) delete opts.level;
+  return window.__devtool.auditAccessibility(opts).then(function(result) {
+    if (scope && result && result.fixable) {
+      result.fixable = result.fixable.filter(function(issue) {
+        var el = null;
+        try { el = document.querySelector(issue.selector); } catch (e) {}
+        return el !== null && scope.contains(el);
+      });
+    }
+    return result;
+  });
+})()`, args)
+}
+
+// parseA11yAudit groups the raw audit result by rule, listing up to samples
+// selectors per rule.
+func parseA11yAudit(raw []byte, samples int) (A11yReport, error) {
+	var result a11yAuditResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return A11yReport{}, fmt.Errorf("invalid audit result from the page: %w", err)
+	}
+	if samples <= 0 {
+		samples = defaultA11ySamples
+	}
+	samples = min(samples, maxA11ySamples)
+
+	report := A11yReport{
+		Engine:     result.Mode,
+		Level:      result.Level,
+		Score:      result.Score,
+		Grade:      result.Grade,
+		Passed:     result.Stats.Passed,
+		Incomplete: result.Stats.Incomplete,
+		Rules:      []A11yRule{},
+	}
+	if result.Fallback {
+		report.FallbackReason = result.FallbackReason
+	}
+
+	byRule := make(map[string]int)
+	for _, issue := range result.Fixable {
+		report.Violations++
+		switch issue.Severity {
+		case "error":
+			report.Errors++
+		case "warning":
+			report.Warnings++
+		}
+
+		i, ok := byRule[issue.Type]
+		if !ok {
+			i = len(report.Rules)
+			byRule[issue.Type] = i
+			report.Rules = append(report.Rules, A11yRule{
+				Rule:      issue.Type,
+				Severity:  issue.Severity,
+				Message:   issue.Message,
+				Fix:       issue.Fix,
+				WCAG:      issue.WCAG,
+				HelpURL:   issue.HelpURL,
+				Selectors: []string{},
+			})
+		}
+		rule := &report.Rules[i]
+		rule.Count++
+		if issue.Severity == "error" {
+			// A rule is as severe as its worst element
+			rule.Severity = "error"
+		}
+		if len(rule.Selectors) < samples && issue.Selector != "" {
+			rule.Selectors = append(rule.Selectors, issue.Selector)
+		}
+	}
+
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		ei, ej := report.Rules[i].Severity == "error", report.Rules[j].Severity == "error"
+		if ei != ej {
+			return ei
+		}
+		return report.Rules[i].Count > report.Rules[j].Count
+	})
+	return report, nil
+}
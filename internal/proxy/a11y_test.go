@@ -0,0 +1,55 @@
+package proxy
+
+import "testing"
+
+func TestParseA11yAudit(t *testing.T) {
+	raw := []byte(`{
+		"mode": "axe-core",
+		"level": "aa",
+		"score": 71,
+		"grade": "C",
+		"fixable": [
+			{"type": "color-contrast", "severity": "warning", "selector": "p.a", "message": "Contrast"},
+			{"type": "image-alt", "severity": "error", "selector": "img.1", "message": "Alt text", "wcag": "1.1.1", "helpUrl": "https://example.com/image-alt"},
+			{"type": "color-contrast", "severity": "warning", "selector": "p.b"},
+			{"type": "color-contrast", "severity": "warning", "selector": "p.c"},
+			{"type": "color-contrast", "severity": "warning", "selector": "p.d"},
+			{"type": "label", "severity": "warning", "selector": "input"},
+			{"type": "label", "severity": "error", "selector": "select"}
+		],
+		"stats": {"passed": 40, "incomplete": 2}
+	}`)
+
+	report, err := parseA11yAudit(raw, 0)
+	if err != nil {
+		t.Fatalf("parseA11yAudit failed: %v", err)
+	}
+	if report.Engine != "axe-core" || report.Score != 71 || report.Passed != 40 || report.Incomplete != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.Violations != 7 || report.Errors != 2 || report.Warnings != 5 {
+		t.Errorf("Unexpected totals: %d violations, %d errors, %d warnings", report.Violations, report.Errors, report.Warnings)
+	}
+	if len(report.Rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %+v", report.Rules)
+	}
+	if report.Rules[0].Rule != "label" || report.Rules[0].Severity != "error" || report.Rules[0].Count != 2 {
+		t.Errorf("Expected the rule with an error and more elements first, got %+v", report.Rules[0])
+	}
+	if report.Rules[1].Rule != "image-alt" || report.Rules[1].WCAG != "1.1.1" || report.Rules[1].HelpURL == "" {
+		t.Errorf("Unexpected second rule: %+v", report.Rules[1])
+	}
+	contrast := report.Rules[2]
+	if contrast.Count != 4 || len(contrast.Selectors) != defaultA11ySamples || contrast.Selectors[0] != "p.a" {
+		t.Errorf("Expected 4 contrast failures with %d samples, got %+v", defaultA11ySamples, contrast)
+	}
+
+	report, _ = parseA11yAudit([]byte(`{"mode": "basic", "fallback": true, "fallbackReason": "blocked by CSP", "fixable": []}`), 5)
+	if report.FallbackReason != "blocked by CSP" || report.Rules == nil || report.Violations != 0 {
+		t.Errorf("Unexpected fallback report: %+v", report)
+	}
+
+	if _, err := parseA11yAudit([]byte(`"not an object"`), 0); err == nil {
+		t.Error("Expected an error for an invalid result")
+	}
+}
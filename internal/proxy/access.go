@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// AccessTokenParam is the query parameter that grants access to a protected proxy.
+	AccessTokenParam = "agnt_access"
+
+	// accessTokenCookie remembers a granted access token for later requests,
+	// including the metrics WebSocket.
+	accessTokenCookie = "__agnt_access"
+
+	// tunnelAuthCookie remembers a granted tunnel token, like accessTokenCookie.
+	tunnelAuthCookie = "__agnt_tunnel_access"
+)
+
+// TunnelAuth holds the credentials required of requests that arrive through a
+// tunnel. Requests from this machine are never asked for them. With both a
+// token and basic auth set, either one grants access.
+type TunnelAuth struct {
+	// Token is presented once as the agnt_access query parameter (which sets a
+	// cookie), as the cookie, or as an "Authorization: Bearer" header.
+	Token string `json:"token,omitempty"`
+	// Username and Password are checked as HTTP basic auth.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// IsZero reports whether no credentials are set.
+func (a TunnelAuth) IsZero() bool {
+	return a.Token == "" && a.Username == "" && a.Password == ""
+}
+
+// Validate checks that basic auth has both a username and a password.
+func (a TunnelAuth) Validate() error {
+	if (a.Username == "") != (a.Password == "") {
+		return fmt.Errorf("basic auth requires both a username and a password")
+	}
+	if strings.Contains(a.Username, ":") {
+		return fmt.Errorf("basic auth username must not contain ':'")
+	}
+	return nil
+}
+
+// Mode names the credentials in use: "token", "basic", "token+basic", or ""
+// without protection.
+func (a TunnelAuth) Mode() string {
+	switch {
+	case a.Token != "" && a.Username != "":
+		return "token+basic"
+	case a.Token != "":
+		return "token"
+	case a.Username != "":
+		return "basic"
+	default:
+		return ""
+	}
+}
+
+// SetAccessToken protects the proxy with an access token. Requests must carry the
+// token as the agnt_access query parameter once (which sets a cookie) or the cookie.
+// An empty token removes protection.
+func (ps *ProxyServer) SetAccessToken(token string) {
+	ps.accessToken.Store(&token)
+}
+
+// AccessToken returns the current access token, or "" if the proxy is unprotected.
+func (ps *ProxyServer) AccessToken() string {
+	if ptr := ps.accessToken.Load(); ptr != nil {
+		return *ptr
+	}
+	return ""
+}
+
+// SetTunnelAuth requires credentials of requests arriving through a tunnel,
+// leaving requests from this machine unauthenticated. A zero TunnelAuth
+// removes the requirement.
+func (ps *ProxyServer) SetTunnelAuth(auth TunnelAuth) {
+	ps.tunnelAuth.Store(&auth)
+}
+
+// TunnelAuth returns the credentials required through a tunnel, if any.
+func (ps *ProxyServer) TunnelAuth() TunnelAuth {
+	if ptr := ps.tunnelAuth.Load(); ptr != nil {
+		return *ptr
+	}
+	return TunnelAuth{}
+}
+
+// accessGuard wraps a handler with access token enforcement, and with tunnel
+// credentials for requests that do not come from this machine.
+func (ps *ProxyServer) accessGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := ps.AccessToken(); token != "" {
+			granted, answered := checkToken(w, r, token, accessTokenCookie)
+			if answered {
+				return
+			}
+			if !granted {
+				deny(w, "This agnt proxy is protected. Open the share link that includes the access token.\n")
+				return
+			}
+		}
+
+		if auth := ps.TunnelAuth(); !auth.IsZero() && !isLocalRequest(r) {
+			granted, answered := checkTunnelAuth(w, r, auth)
+			if answered {
+				return
+			}
+			if !granted {
+				if auth.Username != "" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="agnt tunnel", charset="UTF-8"`)
+				}
+				deny(w, "This dev server is shared through a protected tunnel. Sign in, or open the share link that includes the access token.\n")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkToken looks for token in the cookie or the query parameter. A token in
+// the query sets the cookie, and page loads are redirected without it, which
+// answers the request.
+func checkToken(w http.ResponseWriter, r *http.Request, token, cookieName string) (granted, answered bool) {
+	if cookie, err := r.Cookie(cookieName); err == nil && tokensEqual(cookie.Value, token) {
+		return true, false
+	}
+
+	query := r.URL.Query()
+	given := query.Get(AccessTokenParam)
+	if given == "" || !tokensEqual(given, token) {
+		return false, false
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	// Strip the token from the address bar for page loads
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		query.Del(AccessTokenParam)
+		redirect := *r.URL
+		redirect.RawQuery = query.Encode()
+		http.Redirect(w, r, redirect.RequestURI(), http.StatusFound)
+		return true, true
+	}
+	return true, false
+}
+
+// checkTunnelAuth accepts basic auth or the token (including as a bearer
+// token). Accepted Authorization headers are not passed on to the app.
+func checkTunnelAuth(w http.ResponseWriter, r *http.Request, auth TunnelAuth) (granted, answered bool) {
+	if auth.Username != "" {
+		if user, pass, ok := r.BasicAuth(); ok && tokensEqual(user, auth.Username) && tokensEqual(pass, auth.Password) {
+			r.Header.Del("Authorization")
+			return true, false
+		}
+	}
+	if auth.Token == "" {
+		return false, false
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && tokensEqual(bearer, auth.Token) {
+		r.Header.Del("Authorization")
+		return true, false
+	}
+	return checkToken(w, r, auth.Token, tunnelAuthCookie)
+}
+
+// forwardingHeaders are added by tunnel clients and reverse proxies, so a
+// request carrying one did not come straight from this machine.
+var forwardingHeaders = []string{"X-Forwarded-For", "Forwarded", "X-Real-Ip", "Cf-Connecting-Ip", "Tailscale-Funnel-Request"}
+
+// isLocalRequest reports whether r came straight from this machine: a
+// loopback peer addressing a loopback host, without forwarding headers.
+// Tunnel clients connect over loopback too, but send the public host name.
+func isLocalRequest(r *http.Request) bool {
+	if !isLoopbackHost(r.RemoteAddr) || !isLoopbackHost(r.Host) {
+		return false
+	}
+	for _, h := range forwardingHeaders {
+		if r.Header.Get(h) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isLoopbackHost reports whether a host or host:port names this machine.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// deny answers 401 with a plain text explanation.
+func deny(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte(message))
+}
+
+// tokensEqual compares tokens in constant time.
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// NewAccessToken returns a random token suitable for SetAccessToken.
+func NewAccessToken() string {
+	return generateSessionToken()
+}
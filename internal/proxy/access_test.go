@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newAccessTestHandler(t *testing.T, token string) http.Handler {
+	t.Helper()
+	ps := newGuardTestProxy(t)
+	ps.SetAccessToken(token)
+	return ps.accessGuard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestAccessGuard_NoToken(t *testing.T) {
+	h := newAccessTestHandler(t, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 without protection, got %d", rec.Code)
+	}
+}
+
+func TestAccessGuard_RejectsMissingAndWrongToken(t *testing.T) {
+	h := newAccessTestHandler(t, "secret")
+
+	for _, target := range []string{"/", "/?agnt_access=wrong"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", target, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: accessTokenCookie, Value: "wrong"})
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong cookie: expected 401, got %d", rec.Code)
+	}
+}
+
+func TestAccessGuard_QueryTokenSetsCookieAndRedirects(t *testing.T) {
+	h := newAccessTestHandler(t, "secret")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/app?page=2&agnt_access=secret", nil))
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("Expected 302, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/app?page=2" {
+		t.Errorf("Expected token stripped from redirect, got %q", loc)
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != accessTokenCookie || cookies[0].Value != "secret" {
+		t.Fatalf("Expected access cookie, got %v", cookies)
+	}
+	if !cookies[0].HttpOnly {
+		t.Error("Expected HttpOnly cookie")
+	}
+
+	// The cookie grants access on later requests
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/app", nil)
+	req.AddCookie(cookies[0])
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with cookie, got %d", rec.Code)
+	}
+}
+
+func TestAccessGuard_QueryTokenOnPostPassesThrough(t *testing.T) {
+	h := newAccessTestHandler(t, "secret")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/api?agnt_access=secret", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+}
+
+func TestProxyConfig_AccessToken(t *testing.T) {
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:          "access-config",
+		TargetURL:   "http://localhost:3000",
+		AccessToken: "abc",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	if ps.AccessToken() != "abc" {
+		t.Errorf("Expected access token from config, got %q", ps.AccessToken())
+	}
+	if !ps.Stats().Protected {
+		t.Error("Expected stats to report protection")
+	}
+}
+
+func newTunnelAuthTestHandler(t *testing.T, auth TunnelAuth) http.Handler {
+	t.Helper()
+	ps := newGuardTestProxy(t)
+	ps.SetTunnelAuth(auth)
+	return ps.accessGuard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Expected the tunnel credentials to be removed before the app")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// tunnelRequest returns a request as a tunnel client relays it: over loopback
+// but for the public host name.
+func tunnelRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = "127.0.0.1:50123"
+	req.Host = "demo.trycloudflare.com"
+	return req
+}
+
+func TestTunnelAuth_LocalRequestsPass(t *testing.T) {
+	h := newTunnelAuthTestHandler(t, TunnelAuth{Token: "secret"})
+
+	for _, host := range []string{"localhost:45123", "127.0.0.1:45123", "[::1]:45123", "app.localhost"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "127.0.0.1:50123"
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 from this machine, got %d", host, rec.Code)
+		}
+	}
+
+	// Loopback with a forwarding header came through a tunnel
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "127.0.0.1:50123"
+	req.Host = "127.0.0.1:45123"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a forwarded request, got %d", rec.Code)
+	}
+}
+
+func TestTunnelAuth_Token(t *testing.T) {
+	h := newTunnelAuthTestHandler(t, TunnelAuth{Token: "secret"})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, tunnelRequest("GET", "/"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without the token, got %d", rec.Code)
+	}
+	if rec.Header().Get("WWW-Authenticate") != "" {
+		t.Error("Expected no basic auth challenge for token-only protection")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, tunnelRequest("GET", "/admin?agnt_access=secret"))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/admin" {
+		t.Fatalf("Expected a redirect without the token, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tunnelAuthCookie {
+		t.Fatalf("Expected the tunnel cookie, got %v", cookies)
+	}
+
+	req := tunnelRequest("GET", "/admin")
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the cookie, got %d", rec.Code)
+	}
+
+	req = tunnelRequest("POST", "/api")
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with a bearer token, got %d", rec.Code)
+	}
+}
+
+func TestTunnelAuth_Basic(t *testing.T) {
+	h := newTunnelAuthTestHandler(t, TunnelAuth{Username: "dev", Password: "pw"})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, tunnelRequest("GET", "/"))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("Expected a basic auth challenge, got %d %v", rec.Code, rec.Header())
+	}
+
+	req := tunnelRequest("GET", "/")
+	req.SetBasicAuth("dev", "wrong")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong password, got %d", rec.Code)
+	}
+
+	req = tunnelRequest("GET", "/")
+	req.SetBasicAuth("dev", "pw")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the credentials, got %d", rec.Code)
+	}
+}
+
+func TestTunnelAuthValidate(t *testing.T) {
+	for _, auth := range []TunnelAuth{{Username: "dev"}, {Password: "pw"}, {Username: "a:b", Password: "pw"}} {
+		if err := auth.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", auth)
+		}
+	}
+	if mode := (TunnelAuth{Token: "x", Username: "dev", Password: "pw"}).Mode(); mode != "token+basic" {
+		t.Errorf("Unexpected mode %q", mode)
+	}
+}
@@ -134,7 +134,8 @@ func UpdateAuditSummary() error {
 	summary.WriteString("├── SUMMARY.md           (this file)\n")
 	summary.WriteString("├── screenshots/         (captured screenshots)\n")
 	summary.WriteString("│   ├── screenshot-*.png\n")
-	summary.WriteString("│   └── sketch-*.png\n")
+	summary.WriteString("│   ├── sketch-*.png\n")
+	summary.WriteString("│   └── thumbnails/      (scaled-down copies of requested screenshots)\n")
 	summary.WriteString("└── audit-*.json         (detailed audit results)\n")
 	summary.WriteString("```\n\n")
 	summary.WriteString("## Files\n\n")
@@ -151,6 +152,9 @@ func UpdateAuditSummary() error {
 				screenshotDir := filepath.Join(auditDir, "screenshots")
 				screenshotFiles, _ := os.ReadDir(screenshotDir)
 				for _, sf := range screenshotFiles {
+					if sf.IsDir() {
+						continue
+					}
 					screenshots = append(screenshots, fmt.Sprintf("screenshots/%s", sf.Name()))
 				}
 			}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// bannerBranchTTL is how long the git branch shown in the banner is cached,
+// so page loads don't each run git.
+const bannerBranchTTL = 30 * time.Second
+
+// EnvironmentBanner configures the strip the overlay draws on proxied pages,
+// so whoever looks at a page knows it's the instrumented dev instance and
+// whether chaos rules or a public tunnel are in effect.
+type EnvironmentBanner struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Label    string `json:"label,omitempty"`     // Environment name (default: the proxy ID)
+	Position string `json:"position,omitempty"`  // top or bottom (default: bottom)
+	Color    string `json:"color,omitempty"`     // CSS background color
+	NoBranch bool   `json:"no_branch,omitempty"` // Don't show the git branch
+}
+
+// BannerState is what the page banner shows.
+type BannerState struct {
+	Label    string   `json:"label"`
+	Branch   string   `json:"branch,omitempty"`
+	Position string   `json:"position,omitempty"`
+	Color    string   `json:"color,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Validate checks the banner options.
+func (b EnvironmentBanner) Validate() error {
+	switch b.Position {
+	case "", "top", "bottom":
+		return nil
+	}
+	return fmt.Errorf("invalid banner position %q: use top or bottom", b.Position)
+}
+
+// gitBranchCache caches the branch checked out in a directory.
+type gitBranchCache struct {
+	mu     sync.Mutex
+	branch string
+	at     time.Time
+}
+
+// get returns the branch checked out in dir, or "" outside a repository.
+func (c *gitBranchCache) get(dir string, now time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.at.IsZero() && now.Sub(c.at) < bannerBranchTTL {
+		return c.branch
+	}
+	c.branch, c.at = gitBranch(dir), now
+	return c.branch
+}
+
+func gitBranch(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// BannerState returns what the page banner shows, or false when the proxy
+// has no banner.
+func (ps *ProxyServer) BannerState() (BannerState, bool) {
+	config := ps.banner
+	if !config.Enabled {
+		return BannerState{}, false
+	}
+	state := BannerState{
+		Label:    config.Label,
+		Position: config.Position,
+		Color:    config.Color,
+	}
+	if state.Label == "" {
+		state.Label = ps.ID
+	}
+	if !config.NoBranch {
+		state.Branch = ps.bannerBranch.get(ps.Path, time.Now())
+	}
+	if ps.chaosEngine.IsEnabled() {
+		state.Warnings = append(state.Warnings, "Chaos rules active")
+	}
+	if url := ps.exposedURL(); url != "" {
+		state.Warnings = append(state.Warnings, "Exposed at "+url)
+	}
+	return state, true
+}
+
+// exposedURL returns the public URL the proxy is reachable at, if any.
+func (ps *ProxyServer) exposedURL() string {
+	if url := ps.TunnelURL(); url != "" {
+		return url
+	}
+	return ps.PublicURL
+}
+
+// bannerAttribute returns the banner state encoded for the injected script
+// tag, or "" when the proxy has no banner.
+func (ps *ProxyServer) bannerAttribute() string {
+	state, ok := ps.BannerState()
+	if !ok {
+		return ""
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// bannerMessage returns the WebSocket message carrying the banner state.
+func (ps *ProxyServer) bannerMessage() []byte {
+	state, ok := ps.BannerState()
+	if !ok {
+		return nil
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":    "banner",
+		"payload": state,
+	})
+	return data
+}
+
+// BroadcastBanner sends the current banner state to all connected browser
+// clients, after the conditions it warns about change. Returns the number of
+// clients that received it.
+func (ps *ProxyServer) BroadcastBanner() int {
+	message := ps.bannerMessage()
+	if message == nil {
+		return 0
+	}
+	sentCount := 0
+	ps.wsConns.Range(func(key, value interface{}) bool {
+		conn := value.(*websocket.Conn)
+		if err := conn.WriteMessage(websocket.TextMessage, message); err == nil {
+			sentCount++
+		}
+		return true
+	})
+	return sentCount
+}
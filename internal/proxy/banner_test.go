@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBannerState(t *testing.T) {
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: "http://localhost:3000"})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	if _, ok := ps.BannerState(); ok {
+		t.Error("Expected no banner unless enabled")
+	}
+
+	ps, err = NewProxyServer(ProxyConfig{
+		ID:        "app",
+		TargetURL: "http://localhost:3000",
+		Banner:    EnvironmentBanner{Enabled: true, Position: "top", NoBranch: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	state, ok := ps.BannerState()
+	if !ok || state.Label != "app" || state.Position != "top" || len(state.Warnings) != 0 {
+		t.Errorf("Unexpected banner %+v", state)
+	}
+
+	ps.ChaosEngine().Enable()
+	ps.SetPublicURL("https://abc.trycloudflare.com")
+	state, _ = ps.BannerState()
+	want := []string{"Chaos rules active", "Exposed at https://abc.trycloudflare.com"}
+	if strings.Join(state.Warnings, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected warnings %q, got %q", want, state.Warnings)
+	}
+
+	ps.ChaosEngine().Clear()
+	state, _ = ps.BannerState()
+	if len(state.Warnings) != 1 {
+		t.Errorf("Expected the chaos warning gone after clear, got %q", state.Warnings)
+	}
+
+	if _, err := NewProxyServer(ProxyConfig{ID: "bad", TargetURL: "http://localhost:3000", Banner: EnvironmentBanner{Enabled: true, Position: "left"}}); err == nil {
+		t.Error("Expected error for an invalid position")
+	}
+}
+
+func TestBannerInjected(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><head></head><body>hi</body></html>")
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:        "app",
+		TargetURL: backend.URL,
+		Banner:    EnvironmentBanner{Enabled: true, Label: `"staging" <data>`, NoBranch: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("GET", "/", nil))
+
+	match := regexp.MustCompile(`data-devtool-banner="([^"]*)"`).FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatal("Expected the banner attribute on the injected script")
+	}
+	if got := html.UnescapeString(match[1]); !strings.Contains(got, `"label":"\"staging\" \u003cdata\u003e"`) {
+		t.Errorf("Unexpected banner attribute %s", got)
+	}
+}
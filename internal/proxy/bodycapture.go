@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxBodyBytes is how much of a request or response body the traffic
+// log keeps by default.
+const DefaultMaxBodyBytes = 10 * 1024
+
+// bodyTruncatedMarker ends a captured body that was cut at its limit.
+const bodyTruncatedMarker = "... [truncated]"
+
+// redactedValue replaces the value of a redacted header.
+const redactedValue = "[redacted]"
+
+// defaultCaptureTypes are the media types whose bodies are captured unless
+// BodyCapture.ContentTypes says otherwise: text formats a person can read.
+var defaultCaptureTypes = []string{
+	"text/",
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"application/xml",
+	"application/javascript",
+	"application/graphql",
+	"+json",
+	"+xml",
+}
+
+// defaultRedactHeaders carry credentials and are masked in the traffic log.
+var defaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// BodyCapture configures what the traffic log keeps of request and response
+// bodies and headers, so proxylog queries show what was actually sent and
+// received without filling memory with binaries or leaking credentials.
+type BodyCapture struct {
+	// Disabled keeps bodies out of the log entirely.
+	Disabled bool `json:"disabled,omitempty"`
+	// MaxRequestBytes and MaxResponseBytes cap each captured body (default
+	// 10KB); longer bodies are truncated.
+	MaxRequestBytes  int `json:"max_request_bytes,omitempty"`
+	MaxResponseBytes int `json:"max_response_bytes,omitempty"`
+	// ContentTypes lists the media types whose bodies are captured, matched
+	// as prefixes ("text/", "application/json") or suffixes ("+json").
+	// Default: text, JSON, XML, form, JavaScript and GraphQL bodies.
+	ContentTypes []string `json:"content_types,omitempty"`
+	// RedactHeaders are further headers whose values are masked, on top of
+	// Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string `json:"redact_headers,omitempty"`
+	// NoRedact logs credential headers as sent.
+	NoRedact bool `json:"no_redact,omitempty"`
+}
+
+// Validate checks the capture limits.
+func (c BodyCapture) Validate() error {
+	if c.MaxRequestBytes < 0 || c.MaxResponseBytes < 0 {
+		return fmt.Errorf("invalid body capture limit: must not be negative")
+	}
+	return nil
+}
+
+func (c BodyCapture) maxRequestBytes() int {
+	if c.MaxRequestBytes > 0 {
+		return c.MaxRequestBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+func (c BodyCapture) maxResponseBytes() int {
+	if c.MaxResponseBytes > 0 {
+		return c.MaxResponseBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// captures reports whether bodies of contentType are kept. Bodies without a
+// content type are kept when they are valid UTF-8, which is checked by
+// captureBody.
+func (c BodyCapture) captures(contentType string) bool {
+	if c.Disabled {
+		return false
+	}
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	types := c.ContentTypes
+	if len(types) == 0 {
+		types = defaultCaptureTypes
+	}
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if strings.HasPrefix(mediaType, t) || (strings.HasPrefix(t, "+") && strings.HasSuffix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// captureBody returns the part of body the log keeps.
+func (c BodyCapture) captureBody(body, contentType string, limit int) string {
+	if body == "" || !c.captures(contentType) {
+		return ""
+	}
+	if contentType == "" && !utf8.ValidString(body) {
+		return "" // Unlabeled binary
+	}
+	if len(body) > limit {
+		if strings.HasSuffix(body, bodyTruncatedMarker) && len(body)-len(bodyTruncatedMarker) <= limit {
+			return body // Already trimmed
+		}
+		return string(trimPartialRune([]byte(body[:limit]))) + bodyTruncatedMarker
+	}
+	return body
+}
+
+// redact returns headers with credential values masked.
+func (c BodyCapture) redact(headers map[string]string) map[string]string {
+	names := c.RedactHeaders
+	if !c.NoRedact {
+		names = append(append([]string(nil), defaultRedactHeaders...), names...)
+	}
+	if len(names) == 0 {
+		return headers
+	}
+
+	var redacted map[string]string
+	for name := range headers {
+		for _, r := range names {
+			if !strings.EqualFold(name, r) {
+				continue
+			}
+			if redacted == nil {
+				redacted = make(map[string]string, len(headers))
+				for k, v := range headers {
+					redacted[k] = v
+				}
+			}
+			redacted[name] = redactedValue
+		}
+	}
+	if redacted == nil {
+		return headers
+	}
+	return redacted
+}
+
+// trim cuts the bodies of entry down to what the log keeps, using the
+// content types in its headers. Trimming twice gives the same result.
+func (c BodyCapture) trim(entry *HTTPLogEntry) {
+	entry.RequestBody = c.captureBody(entry.RequestBody, headerValue(entry.RequestHeaders, "Content-Type"), c.maxRequestBytes())
+	entry.ResponseBody = c.captureBody(entry.ResponseBody, headerValue(entry.ResponseHeaders, "Content-Type"), c.maxResponseBytes())
+	if encoded(entry.RequestHeaders) {
+		entry.RequestBody = ""
+	}
+	if encoded(entry.ResponseHeaders) {
+		entry.ResponseBody = "" // Compressed bytes aren't readable
+	}
+}
+
+// apply trims entry to what the log keeps and masks its credentials.
+func (c BodyCapture) apply(entry *HTTPLogEntry) {
+	c.trim(entry)
+	entry.RequestHeaders = c.redact(entry.RequestHeaders)
+	entry.ResponseHeaders = c.redact(entry.ResponseHeaders)
+}
+
+// encoded reports whether a logged body is content-encoded.
+func encoded(headers map[string]string) bool {
+	enc := headerValue(headers, "Content-Encoding")
+	return enc != "" && !strings.EqualFold(enc, "identity")
+}
+
+// peekBody reads the start of body for the log, one byte past limit so the
+// logger sees it was longer, and returns a body that replays what was read.
+func peekBody(body io.ReadCloser, limit int) (string, io.ReadCloser) {
+	data, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	return string(data), struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), body), body}
+}
+
+// headerValue looks up a header in a logged header map.
+func headerValue(headers map[string]string, name string) string {
+	if v, ok := headers[http.CanonicalHeaderKey(name)]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyCaptureApply(t *testing.T) {
+	entry := HTTPLogEntry{
+		RequestHeaders: map[string]string{
+			"Content-Type":  "application/json; charset=utf-8",
+			"Authorization": "Bearer secret",
+			"X-Api-Key":     "key",
+		},
+		RequestBody: `{"name":"héllo"}`,
+		ResponseHeaders: map[string]string{
+			"Content-Type": "image/png",
+			"Set-Cookie":   "sid=abc",
+		},
+		ResponseBody: "\x89PNG",
+	}
+	headers := entry.RequestHeaders
+
+	c := BodyCapture{MaxRequestBytes: 11, RedactHeaders: []string{"x-api-key"}}
+	c.apply(&entry)
+	if entry.RequestBody != `{"name":"h`+bodyTruncatedMarker {
+		t.Errorf("Expected the body cut before the partial rune, got %q", entry.RequestBody)
+	}
+	if entry.ResponseBody != "" {
+		t.Errorf("Expected the image body dropped, got %q", entry.ResponseBody)
+	}
+	if entry.RequestHeaders["Authorization"] != redactedValue || entry.RequestHeaders["X-Api-Key"] != redactedValue || entry.ResponseHeaders["Set-Cookie"] != redactedValue {
+		t.Errorf("Expected credentials masked, got %v %v", entry.RequestHeaders, entry.ResponseHeaders)
+	}
+	if headers["Authorization"] != "Bearer secret" {
+		t.Error("Expected the caller's headers left untouched")
+	}
+
+	// Trimming is stable
+	body := entry.RequestBody
+	c.apply(&entry)
+	if entry.RequestBody != body {
+		t.Errorf("Expected a second trim to keep %q, got %q", body, entry.RequestBody)
+	}
+
+	entry = HTTPLogEntry{
+		RequestHeaders:  map[string]string{"Cookie": "a=b"},
+		ResponseHeaders: map[string]string{"Content-Type": "text/html", "Content-Encoding": "gzip"},
+		ResponseBody:    "\x1f\x8b",
+	}
+	BodyCapture{NoRedact: true}.apply(&entry)
+	if entry.RequestHeaders["Cookie"] != "a=b" || entry.ResponseBody != "" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	if !(BodyCapture{}).captures("application/problem+json") || (BodyCapture{ContentTypes: []string{"text/"}}).captures("application/json") {
+		t.Error("Unexpected content type matching")
+	}
+	if err := (BodyCapture{MaxResponseBytes: -1}).Validate(); err == nil {
+		t.Error("Expected error for a negative limit")
+	}
+}
+
+func TestBodyCaptureProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"received":`+string(body)+`}`)
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:          "app",
+		TargetURL:   backend.URL,
+		BodyCapture: BodyCapture{MaxRequestBytes: 4, MaxResponseBytes: 64},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/api", strings.NewReader("123456789"))
+	req.ContentLength = -1 // Unknown length is captured too
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", "session=secret")
+	rec := httptest.NewRecorder()
+	ps.handleProxy(rec, req)
+
+	if rec.Body.String() != `{"received":123456789}` {
+		t.Fatalf("Expected the whole body forwarded, got %q", rec.Body.String())
+	}
+	entries := ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}})
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0].HTTP
+	if entry.RequestBody != "1234"+bodyTruncatedMarker || entry.ResponseBody != `{"received":123456789}` {
+		t.Errorf("Unexpected bodies %q / %q", entry.RequestBody, entry.ResponseBody)
+	}
+	if entry.RequestHeaders["Cookie"] != redactedValue {
+		t.Errorf("Expected the cookie masked, got %q", entry.RequestHeaders["Cookie"])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"net/http"
 	"regexp"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,10 @@ const (
 	ChaosDisconnect ChaosType = "disconnect"  // Drop connection mid-response
 	ChaosSlowClose  ChaosType = "slow_close"  // Delay TCP close
 
+	ChaosBandwidthLimit  ChaosType = "bandwidth_limit"  // Sustained kbps cap over the whole response
+	ChaosConnectionReset ChaosType = "connection_reset" // TCP RST mid-response
+	ChaosDNSFailure      ChaosType = "dns_failure"      // Target host doesn't resolve
+
 	// Response timing
 	ChaosSlowDrip   ChaosType = "slow_drip"    // Trickle bytes slowly
 	ChaosTimeout    ChaosType = "timeout"      // Never respond (simulate timeout)
@@ -62,6 +67,16 @@ type ChaosRule struct {
 	Methods     []string `json:"methods,omitempty"`     // HTTP methods (empty = all)
 	Probability float64  `json:"probability,omitempty"` // 0.0-1.0, default 1.0
 
+	// Sessions limits the rule to requests from these page sessions (page-N,
+	// as tracked by the PageTracker) or browser sessions (the __devtool_sid
+	// cookie). Empty applies to everyone using the proxy.
+	Sessions []string `json:"sessions,omitempty"`
+
+	// Field matchers; every one must match
+	Headers    []ChaosMatcher `json:"headers,omitempty"`     // Request headers
+	Query      []ChaosMatcher `json:"query,omitempty"`       // Query parameters
+	BodyFields []ChaosMatcher `json:"body_fields,omitempty"` // Dotted paths into a JSON request body
+
 	// Latency config
 	MinLatencyMs int `json:"min_latency_ms,omitempty"`
 	MaxLatencyMs int `json:"max_latency_ms,omitempty"`
@@ -71,10 +86,16 @@ type ChaosRule struct {
 	BytesPerMs int `json:"bytes_per_ms,omitempty"` // Bytes to write per millisecond
 	ChunkSize  int `json:"chunk_size,omitempty"`   // Size of each write chunk
 
-	// Connection drop config
+	// Bandwidth limit config
+	BandwidthKbps int `json:"bandwidth_kbps,omitempty"` // Kilobits per second (default 400, like slow 3G)
+
+	// Connection drop and reset config
 	DropAfterPercent float64 `json:"drop_after_percent,omitempty"` // Drop after % of body
 	DropAfterBytes   int64   `json:"drop_after_bytes,omitempty"`   // Drop after N bytes
 
+	// DNS failure config
+	Hosts []string `json:"hosts,omitempty"` // Target hosts that fail (empty = all; *.example.com matches subdomains)
+
 	// Error injection config
 	ErrorCodes   []int  `json:"error_codes,omitempty"` // HTTP status codes
 	ErrorMessage string `json:"error_message,omitempty"`
@@ -132,6 +153,18 @@ type ChaosEngine struct {
 
 	// Optional logger for chaos testing mode
 	logger *TrafficLogger
+
+	// onChange is called after chaos is switched on or off or reconfigured
+	onChange func()
+
+	// pageSessionOf resolves a browser session ID to its page session, for
+	// rules scoped to page sessions
+	pageSessionOf func(browserSessionID string) string
+
+	// Scenario runner; scenarioMu is taken before mu, never while holding it
+	scenarioMu      sync.Mutex
+	scenario        *scenarioRun
+	onScenarioEvent func(ChaosScenarioEvent)
 }
 
 // chaosRuleState holds a rule with atomic enabled state
@@ -165,11 +198,20 @@ func NewChaosEngine(logger *TrafficLogger) *ChaosEngine {
 // Enable enables chaos injection
 func (ce *ChaosEngine) Enable() {
 	ce.enabled.Store(true)
+	ce.changed()
 }
 
 // Disable disables chaos injection
 func (ce *ChaosEngine) Disable() {
 	ce.enabled.Store(false)
+	ce.changed()
+}
+
+// changed notifies the owner of a change in chaos state.
+func (ce *ChaosEngine) changed() {
+	if ce.onChange != nil {
+		ce.onChange()
+	}
 }
 
 // IsEnabled returns whether chaos is enabled
@@ -189,18 +231,15 @@ func (ce *ChaosEngine) GetLoggingMode() LoggingMode {
 
 // SetConfig sets the chaos configuration
 func (ce *ChaosEngine) SetConfig(config *ChaosConfig) error {
+	defer ce.changed() // After the unlock
 	ce.mu.Lock()
 	defer ce.mu.Unlock()
 
 	// Compile URL patterns
 	rules := make([]*chaosRuleState, 0, len(config.Rules))
 	for _, r := range config.Rules {
-		if r.URLPattern != "" {
-			regex, err := regexp.Compile(r.URLPattern)
-			if err != nil {
-				return err
-			}
-			r.urlRegex = regex
+		if err := r.compile(); err != nil {
+			return err
 		}
 
 		// Set defaults
@@ -235,6 +274,7 @@ func (ce *ChaosEngine) GetConfig() *ChaosConfig {
 
 // Clear clears all chaos rules
 func (ce *ChaosEngine) Clear() {
+	defer ce.changed() // After the unlock
 	ce.mu.Lock()
 	defer ce.mu.Unlock()
 
@@ -251,12 +291,8 @@ func (ce *ChaosEngine) AddRule(rule *ChaosRule) error {
 	ce.mu.Lock()
 	defer ce.mu.Unlock()
 
-	if rule.URLPattern != "" {
-		regex, err := regexp.Compile(rule.URLPattern)
-		if err != nil {
-			return err
-		}
-		rule.urlRegex = regex
+	if err := rule.compile(); err != nil {
+		return err
 	}
 
 	if rule.Probability == 0 {
@@ -363,21 +399,32 @@ func (ce *ChaosEngine) MatchingRules(req *http.Request) []*ChaosRule {
 	}
 
 	var matches []*ChaosRule
+	var fields *chaosRequestFields
 	for _, state := range ce.rules {
 		if !state.enabled.Load() {
 			continue
 		}
 
 		rule := state.rule
-		if ce.ruleMatches(rule, req) {
-			// Check probability
-			if rule.Probability < 1.0 && ce.rng.Float64() > rule.Probability {
+		if !ce.ruleMatches(rule, req) {
+			continue
+		}
+		if rule.hasFieldMatchers() {
+			if fields == nil {
+				fields = newChaosRequestFields(req, ce.pageSessionOf)
+			}
+			if !rule.matchesFields(fields) {
 				continue
 			}
+		}
 
-			matches = append(matches, rule)
-			state.applied.Add(1)
+		// Check probability
+		if rule.Probability < 1.0 && ce.rng.Float64() > rule.Probability {
+			continue
 		}
+
+		matches = append(matches, rule)
+		state.applied.Add(1)
 	}
 
 	if len(matches) > 0 {
@@ -389,11 +436,16 @@ func (ce *ChaosEngine) MatchingRules(req *http.Request) []*ChaosRule {
 
 // ruleMatches checks if a rule matches the request
 func (ce *ChaosEngine) ruleMatches(rule *ChaosRule, req *http.Request) bool {
+	return rule.matches(req.Method, req.URL.String())
+}
+
+// matches checks the rule's methods and URL pattern.
+func (rule *ChaosRule) matches(method, url string) bool {
 	// Check method
 	if len(rule.Methods) > 0 {
 		methodMatch := false
 		for _, m := range rule.Methods {
-			if m == req.Method {
+			if m == method {
 				methodMatch = true
 				break
 			}
@@ -405,7 +457,7 @@ func (ce *ChaosEngine) ruleMatches(rule *ChaosRule, req *http.Request) bool {
 
 	// Check URL pattern
 	if rule.urlRegex != nil {
-		if !rule.urlRegex.MatchString(req.URL.String()) {
+		if !rule.urlRegex.MatchString(url) {
 			return false
 		}
 	}
@@ -497,6 +549,77 @@ func (ce *ChaosEngine) GetDropConfig(rules []*ChaosRule) (afterPercent float64,
 	return 0, 0
 }
 
+// GetResetConfig returns where a connection_reset rule resets the
+// connection, counting it as a drop.
+func (ce *ChaosEngine) GetResetConfig(rules []*ChaosRule) (afterPercent float64, afterBytes int64) {
+	for _, rule := range rules {
+		if rule.Type != ChaosConnectionReset {
+			continue
+		}
+
+		ce.stats.dropsInjected.Add(1)
+		if rule.DropAfterPercent > 0 {
+			return rule.DropAfterPercent, 0
+		}
+		if rule.DropAfterBytes > 0 {
+			return 0, rule.DropAfterBytes
+		}
+		// Default: reset after 50%
+		return 0.5, 0
+	}
+	return 0, 0
+}
+
+// GetBandwidthLimit returns the lowest kbps cap of the matching
+// bandwidth_limit rules, or 0 for none. bandwidth is the older name.
+func (ce *ChaosEngine) GetBandwidthLimit(rules []*ChaosRule) int {
+	limit := 0
+	for _, rule := range rules {
+		if rule.Type != ChaosBandwidthLimit && rule.Type != ChaosBandwidth {
+			continue
+		}
+
+		kbps := rule.BandwidthKbps
+		if kbps <= 0 {
+			kbps = 400 // Default: slow 3G downlink
+		}
+		if limit == 0 || kbps < limit {
+			limit = kbps
+		}
+	}
+	return limit
+}
+
+// DNSFailure reports whether a dns_failure rule fails the lookup of host.
+func (ce *ChaosEngine) DNSFailure(rules []*ChaosRule, host string) bool {
+	for _, rule := range rules {
+		if rule.Type == ChaosDNSFailure && rule.matchesHost(host) {
+			ce.stats.errorsInjected.Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHost checks host against the rule's hosts; *.example.com matches
+// subdomains of example.com.
+func (rule *ChaosRule) matchesHost(host string) bool {
+	if len(rule.Hosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, h := range rule.Hosts {
+		h = strings.ToLower(h)
+		if suffix, ok := strings.CutPrefix(h, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
 // GetSlowDripConfig returns slow-drip configuration
 func (ce *ChaosEngine) GetSlowDripConfig(rules []*ChaosRule) (bytesPerMs, chunkSize int) {
 	for _, rule := range rules {
@@ -0,0 +1,230 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxChaosMatchBody caps how much of a request body JSON body matchers read.
+// Larger bodies never match a body matcher.
+const maxChaosMatchBody = 1 << 20
+
+// ChaosMatcher matches one request header, query parameter or JSON body
+// field. Value is a regex; an empty value matches any request that has the
+// field at all.
+type ChaosMatcher struct {
+	Name  string `json:"name"`            // Header, query parameter, or dotted JSON path ("variables.input.tenant")
+	Value string `json:"value,omitempty"` // Regex matched against the value
+
+	valueRegex *regexp.Regexp
+}
+
+// compile compiles the rule's URL pattern and matchers.
+func (rule *ChaosRule) compile() error {
+	if rule.URLPattern != "" {
+		regex, err := regexp.Compile(rule.URLPattern)
+		if err != nil {
+			return err
+		}
+		rule.urlRegex = regex
+	}
+	for _, matchers := range [][]ChaosMatcher{rule.Headers, rule.Query, rule.BodyFields} {
+		for i := range matchers {
+			m := &matchers[i]
+			if m.Name == "" {
+				return fmt.Errorf("matcher name is required")
+			}
+			m.valueRegex = nil
+			if m.Value != "" {
+				regex, err := regexp.Compile(m.Value)
+				if err != nil {
+					return fmt.Errorf("matcher %s: %w", m.Name, err)
+				}
+				m.valueRegex = regex
+			}
+		}
+	}
+	return nil
+}
+
+// hasFieldMatchers reports whether the rule looks past the method and URL.
+func (rule *ChaosRule) hasFieldMatchers() bool {
+	return len(rule.Sessions) > 0 || len(rule.Headers) > 0 || len(rule.Query) > 0 || len(rule.BodyFields) > 0
+}
+
+// chaosRequestFields gives rule matchers the session, headers, query and
+// JSON body of a request, live or logged. The body is parsed on first use.
+type chaosRequestFields struct {
+	sessions []string // Browser and page session IDs of the request
+	header   func(name string) []string
+	query    url.Values
+	body     func() []byte
+
+	parsed   bool
+	bodyJSON interface{}
+	bodyOK   bool
+}
+
+// newChaosRequestFields reads the fields of a live request. Reading the body
+// puts it back so the target still receives all of it.
+func newChaosRequestFields(req *http.Request, pageSessionOf func(string) string) *chaosRequestFields {
+	var sessions []string
+	if cookie, err := req.Cookie(browserSessionCookie); err == nil && cookie.Value != "" {
+		sessions = append(sessions, cookie.Value)
+		if pageSessionOf != nil {
+			if page := pageSessionOf(cookie.Value); page != "" {
+				sessions = append(sessions, page)
+			}
+		}
+	}
+	return &chaosRequestFields{
+		sessions: sessions,
+		header:   func(name string) []string { return req.Header.Values(name) },
+		query:    req.URL.Query(),
+		body: func() []byte {
+			if req.Body == nil || req.Body == http.NoBody {
+				return nil
+			}
+			var data string
+			data, req.Body = peekBody(req.Body, maxChaosMatchBody)
+			return []byte(data)
+		},
+	}
+}
+
+// loggedChaosRequestFields reads the fields of a logged request. The log
+// masks cookies, so sessionsOf supplies the request's sessions when known.
+func loggedChaosRequestFields(entry HTTPLogEntry, sessionsOf func(HTTPLogEntry) []string) *chaosRequestFields {
+	var query url.Values
+	if u, err := url.Parse(entry.URL); err == nil {
+		query = u.Query()
+	}
+	var sessions []string
+	if sessionsOf != nil {
+		sessions = sessionsOf(entry)
+	}
+	if sid := extractBrowserSessionID(entry.RequestHeaders); sid != "" && sid != redactedValue {
+		sessions = append(sessions, sid)
+	}
+	return &chaosRequestFields{
+		sessions: sessions,
+		header: func(name string) []string {
+			if v := headerValue(entry.RequestHeaders, name); v != "" {
+				return []string{v}
+			}
+			return nil
+		},
+		query: query,
+		body:  func() []byte { return []byte(entry.RequestBody) },
+	}
+}
+
+// json returns the request body parsed as JSON.
+func (f *chaosRequestFields) json() (interface{}, bool) {
+	if !f.parsed {
+		f.parsed = true
+		if data := f.body(); len(data) > 0 && len(data) <= maxChaosMatchBody {
+			f.bodyOK = json.Unmarshal(data, &f.bodyJSON) == nil
+		}
+	}
+	return f.bodyJSON, f.bodyOK
+}
+
+// matchesFields checks the rule's sessions and its header, query and body
+// matchers; all of them must match.
+func (rule *ChaosRule) matchesFields(f *chaosRequestFields) bool {
+	if len(rule.Sessions) > 0 && !rule.matchesSession(f) {
+		return false
+	}
+	for _, m := range rule.Headers {
+		if !m.matchesAny(f.header(m.Name)) {
+			return false
+		}
+	}
+	for _, m := range rule.Query {
+		if !m.matchesAny(f.query[m.Name]) {
+			return false
+		}
+	}
+	if len(rule.BodyFields) > 0 {
+		body, ok := f.json()
+		if !ok {
+			return false
+		}
+		for _, m := range rule.BodyFields {
+			if !m.matchesAny(jsonFieldValues(body, strings.Split(m.Name, "."))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesSession reports whether the request comes from one of the rule's
+// sessions. Requests without a browser session cookie never match.
+func (rule *ChaosRule) matchesSession(f *chaosRequestFields) bool {
+	for _, id := range f.sessions {
+		if containsString(rule.Sessions, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether one of values matches.
+func (m *ChaosMatcher) matchesAny(values []string) bool {
+	for _, v := range values {
+		if m.valueRegex == nil || m.valueRegex.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldValues returns the values at a dotted path in parsed JSON, as
+// strings. A numeric segment indexes an array; any other segment applies to
+// every element, so "operationName" also matches a batch of GraphQL queries.
+func jsonFieldValues(value interface{}, path []string) []string {
+	if len(path) == 0 {
+		switch v := value.(type) {
+		case string:
+			return []string{v}
+		case float64:
+			return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+		case []interface{}:
+			var values []string
+			for _, elem := range v {
+				values = append(values, jsonFieldValues(elem, nil)...)
+			}
+			return values
+		default:
+			data, _ := json.Marshal(v)
+			return []string{string(data)}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if field, ok := v[path[0]]; ok {
+			return jsonFieldValues(field, path[1:])
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i >= 0 && i < len(v) {
+				return jsonFieldValues(v[i], path[1:])
+			}
+			return nil
+		}
+		var values []string
+		for _, elem := range v {
+			values = append(values, jsonFieldValues(elem, path)...)
+		}
+		return values
+	}
+	return nil
+}
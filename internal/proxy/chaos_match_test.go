@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChaosEngine_FieldMatchers(t *testing.T) {
+	engine := NewChaosEngine(nil)
+	config := &ChaosConfig{
+		Enabled: true,
+		Rules: []*ChaosRule{
+			{ID: "tenant", Type: ChaosLatency, Enabled: true, Headers: []ChaosMatcher{{Name: "x-tenant", Value: "^acme$"}}},
+			{ID: "debug", Type: ChaosLatency, Enabled: true, Query: []ChaosMatcher{{Name: "debug"}}},
+			{ID: "checkout", Type: ChaosHTTPError, Enabled: true, URLPattern: "/graphql", BodyFields: []ChaosMatcher{{Name: "operationName", Value: "^Checkout$"}, {Name: "variables.cart.items.0.sku", Value: "SKU-1"}}},
+		},
+	}
+	if err := engine.SetConfig(config); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	matched := func(method, target, body string, headers map[string]string) []string {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		var ids []string
+		for _, rule := range engine.MatchingRules(req) {
+			ids = append(ids, rule.ID)
+		}
+		if rest, _ := io.ReadAll(req.Body); string(rest) != body {
+			t.Errorf("Expected the body intact after matching, got %q", rest)
+		}
+		return ids
+	}
+
+	checkout := `{"operationName":"Checkout","variables":{"cart":{"items":[{"sku":"SKU-1"}]}}}`
+	for name, tc := range map[string]struct {
+		method, target, body string
+		headers              map[string]string
+		want                 []string
+	}{
+		"header":           {"GET", "/api", "", map[string]string{"X-Tenant": "acme"}, []string{"tenant"}},
+		"other tenant":     {"GET", "/api", "", map[string]string{"X-Tenant": "acme-2"}, nil},
+		"query present":    {"GET", "/api?debug=", "", nil, []string{"debug"}},
+		"graphql":          {"POST", "/graphql", checkout, nil, []string{"checkout"}},
+		"graphql batch":    {"POST", "/graphql", "[{\"operationName\":\"Cart\"}," + checkout + "]", nil, []string{"checkout"}},
+		"other operation":  {"POST", "/graphql", `{"operationName":"Cart"}`, nil, nil},
+		"not json":         {"POST", "/graphql", `operationName=Checkout`, nil, nil},
+		"everything fails": {"POST", "/api", checkout, nil, nil},
+	} {
+		if got := matched(tc.method, tc.target, tc.body, tc.headers); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, got)
+		}
+	}
+
+	if err := engine.AddRule(&ChaosRule{ID: "bad", Type: ChaosLatency, Headers: []ChaosMatcher{{Name: "x", Value: "("}}}); err == nil {
+		t.Error("Expected an invalid matcher regex to be rejected")
+	}
+	if err := engine.AddRule(&ChaosRule{ID: "bad", Type: ChaosLatency, Query: []ChaosMatcher{{Value: "x"}}}); err == nil {
+		t.Error("Expected a matcher without a name to be rejected")
+	}
+}
+
+func TestJSONFieldValues(t *testing.T) {
+	var body interface{}
+	json.Unmarshal([]byte(`[{"op":"A","n":1.5,"ok":true,"tags":["x","y"],"obj":{"k":null}},{"op":"B"}]`), &body)
+
+	for path, want := range map[string][]string{
+		"op":      {"A", "B"},
+		"1.op":    {"B"},
+		"n":       {"1.5"},
+		"ok":      {"true"},
+		"tags":    {"x", "y"},
+		"tags.1":  {"y"},
+		"obj":     {`{"k":null}`},
+		"obj.k":   {"null"},
+		"missing": nil,
+		"5.op":    nil,
+	} {
+		if got := jsonFieldValues(body, strings.Split(path, ".")); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", path, want, got)
+		}
+	}
+}
+
+func TestPreviewChaos_FieldMatchers(t *testing.T) {
+	entries := []HTTPLogEntry{
+		{Method: "POST", URL: "/graphql", RequestHeaders: map[string]string{"X-Tenant": "acme"}, RequestBody: `{"operationName":"Checkout"}`},
+		{Method: "POST", URL: "/graphql", RequestHeaders: map[string]string{"X-Tenant": "other"}, RequestBody: `{"operationName":"Checkout"}`},
+		{Method: "POST", URL: "/graphql?tenant=acme", RequestBody: `{"operationName":"Cart"}`},
+	}
+	config := &ChaosConfig{Rules: []*ChaosRule{
+		{ID: "checkout", Type: ChaosHTTPError, Enabled: true, Headers: []ChaosMatcher{{Name: "x-tenant", Value: "acme"}}, BodyFields: []ChaosMatcher{{Name: "operationName", Value: "Checkout"}}},
+		{ID: "query", Type: ChaosLatency, Enabled: true, Query: []ChaosMatcher{{Name: "tenant", Value: "acme"}}},
+	}}
+	preview, err := PreviewChaos(config, entries)
+	if err != nil {
+		t.Fatalf("PreviewChaos failed: %v", err)
+	}
+	if preview.Rules[0].Matched != 1 || preview.Rules[1].Matched != 1 || preview.Matched != 2 {
+		t.Errorf("Unexpected preview %+v", preview)
+	}
+}
+
+func TestChaosEngine_SessionScope(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html><body>app</body></html>")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "{}")
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	request := func(target, sid string) *http.Request {
+		req := httptest.NewRequest("GET", target, nil)
+		if sid != "" {
+			req.Header.Set("Cookie", "theme=dark; __devtool_sid="+sid)
+		}
+		return req
+	}
+
+	// My tab loads the page and calls the API; so does a teammate's
+	for _, sid := range []string{"mine", "teammate"} {
+		ps.handleProxy(httptest.NewRecorder(), request("/", sid))
+		ps.handleProxy(httptest.NewRecorder(), request("/api/orders", sid))
+	}
+	page := ps.pageTracker.findSessionByBrowserSession("mine")
+	if page == "" || page == ps.pageTracker.findSessionByBrowserSession("teammate") {
+		t.Fatalf("Expected a page session of my own, got %q", page)
+	}
+
+	engine := ps.ChaosEngine()
+	config := &ChaosConfig{Enabled: true, Rules: []*ChaosRule{
+		{ID: "page", Type: ChaosHTTPError, Enabled: true, URLPattern: "^/api/", Sessions: []string{page}},
+		{ID: "browser", Type: ChaosLatency, Enabled: true, URLPattern: "^/api/", Sessions: []string{"mine"}},
+	}}
+	if err := engine.SetConfig(config); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	// The log masks cookies; the preview finds sessions through the tracker
+	preview, err := ps.PreviewChaos(nil)
+	if err != nil {
+		t.Fatalf("PreviewChaos failed: %v", err)
+	}
+	if preview.Rules[0].Matched != 1 || preview.Rules[1].Matched != 1 {
+		t.Errorf("Expected the preview to match my API call only, got %+v", preview.Rules)
+	}
+
+	for sid, want := range map[string]int{"mine": 2, "teammate": 0, "": 0} {
+		if got := len(engine.MatchingRules(request("/api/orders", sid))); got != want {
+			t.Errorf("Session %q: expected %d rules, got %d", sid, want, got)
+		}
+	}
+}
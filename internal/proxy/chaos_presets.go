@@ -153,6 +153,48 @@ var ChaosPresets = map[string]*ChaosConfig{
 		LoggingMode: LoggingModeTesting,
 	},
 
+	// slow-3g matches the browsers' Slow 3G profile: 400ms round trips and a
+	// 400kbps downlink sustained over the whole response
+	"slow-3g": {
+		Enabled: true,
+		Rules: []*ChaosRule{
+			{
+				ID:           "slow-3g-latency",
+				Name:         "Slow 3G Round Trip",
+				Type:         ChaosLatency,
+				Enabled:      true,
+				Probability:  1.0,
+				MinLatencyMs: 400,
+				MaxLatencyMs: 600,
+			},
+			{
+				ID:            "slow-3g-bandwidth",
+				Name:          "Slow 3G Downlink",
+				Type:          ChaosBandwidthLimit,
+				Enabled:       true,
+				Probability:   1.0,
+				BandwidthKbps: 400,
+			},
+		},
+		LoggingMode: LoggingModeTesting,
+	},
+
+	// connection-resets simulates peers that reset connections mid-transfer
+	"connection-resets": {
+		Enabled: true,
+		Rules: []*ChaosRule{
+			{
+				ID:               "reset-mid-response",
+				Name:             "Reset Connection Mid-Response",
+				Type:             ChaosConnectionReset,
+				Enabled:          true,
+				Probability:      0.1, // 10% reset rate
+				DropAfterPercent: 0.5, // Reset after 50% of response
+			},
+		},
+		LoggingMode: LoggingModeTesting,
+	},
+
 	// connection-drops simulates unstable connections that drop mid-transfer
 	"connection-drops": {
 		Enabled: true,
@@ -339,6 +381,18 @@ func copyConfig(src *ChaosConfig) *ChaosConfig {
 	return dst
 }
 
+// copyMatchers copies chaos field matchers; they are compiled again when used.
+func copyMatchers(src []ChaosMatcher) []ChaosMatcher {
+	if len(src) == 0 {
+		return nil
+	}
+	dst := make([]ChaosMatcher, len(src))
+	for i, m := range src {
+		dst[i] = ChaosMatcher{Name: m.Name, Value: m.Value}
+	}
+	return dst
+}
+
 // copyRule creates a deep copy of a ChaosRule
 func copyRule(src *ChaosRule) *ChaosRule {
 	if src == nil {
@@ -364,6 +418,7 @@ func copyRule(src *ChaosRule) *ChaosRule {
 		ReorderMinRequests: src.ReorderMinRequests,
 		ReorderMaxWaitMs:   src.ReorderMaxWaitMs,
 		StaleDelayMs:       src.StaleDelayMs,
+		BandwidthKbps:      src.BandwidthKbps,
 	}
 
 	if len(src.Methods) > 0 {
@@ -376,5 +431,19 @@ func copyRule(src *ChaosRule) *ChaosRule {
 		copy(dst.ErrorCodes, src.ErrorCodes)
 	}
 
+	if len(src.Hosts) > 0 {
+		dst.Hosts = make([]string, len(src.Hosts))
+		copy(dst.Hosts, src.Hosts)
+	}
+
+	if len(src.Sessions) > 0 {
+		dst.Sessions = make([]string, len(src.Sessions))
+		copy(dst.Sessions, src.Sessions)
+	}
+
+	dst.Headers = copyMatchers(src.Headers)
+	dst.Query = copyMatchers(src.Query)
+	dst.BodyFields = copyMatchers(src.BodyFields)
+
 	return dst
 }
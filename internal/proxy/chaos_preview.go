@@ -0,0 +1,307 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chaosPreviewEndpoints is how many of a rule's busiest matched endpoints a
+// preview lists.
+const chaosPreviewEndpoints = 5
+
+// ChaosPreview is a dry run of chaos rules against logged traffic: which
+// requests each rule would have matched and what to expect once enabled.
+// Expected values are weighted by rule probability and global odds.
+type ChaosPreview struct {
+	Requests         int                `json:"requests"`          // Logged HTTP requests evaluated
+	Since            time.Time          `json:"since,omitempty"`   // Oldest request evaluated
+	Matched          int                `json:"matched"`           // Requests matched by at least one enabled rule
+	ExpectedAffected float64            `json:"expected_affected"` // Requests expected to be hit by any rule
+	AffectedPercent  float64            `json:"affected_percent"`
+	Rules            []ChaosRulePreview `json:"rules"`
+}
+
+// ChaosRulePreview is the blast radius of a single rule.
+type ChaosRulePreview struct {
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name,omitempty"`
+	Type            ChaosType              `json:"type"`
+	Enabled         bool                   `json:"enabled"`
+	Matched         int                    `json:"matched"`     // Requests matching methods and URL pattern
+	Probability     float64                `json:"probability"` // Per matched request, including global odds
+	ExpectedHits    float64                `json:"expected_hits"`
+	ExpectedDelayMs float64                `json:"expected_delay_ms,omitempty"` // Total added latency
+	ExpectedErrors  float64                `json:"expected_errors,omitempty"`   // Requests answered with an error status
+	Impact          string                 `json:"impact"`
+	Endpoints       []ChaosPreviewEndpoint `json:"endpoints,omitempty"` // Busiest matched endpoints
+}
+
+// ChaosPreviewEndpoint counts matched requests to one method and path.
+type ChaosPreviewEndpoint struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Count  int    `json:"count"`
+}
+
+// PreviewChaos evaluates config against logged requests without applying it.
+// Rules are previewed whether or not config is enabled; disabled rules are
+// listed with their matches but no expected hits.
+func PreviewChaos(config *ChaosConfig, entries []HTTPLogEntry) (*ChaosPreview, error) {
+	return previewChaos(config, entries, nil)
+}
+
+// previewChaos is PreviewChaos finding the sessions of logged requests with
+// sessionsOf.
+func previewChaos(config *ChaosConfig, entries []HTTPLogEntry, sessionsOf func(HTTPLogEntry) []string) (*ChaosPreview, error) {
+	config = copyConfig(config)
+	if config == nil {
+		config = &ChaosConfig{}
+	}
+	for _, r := range config.Rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return previewRules(config.Rules, config.GlobalOdds, entries, sessionsOf), nil
+}
+
+// Preview evaluates the engine's current rules against logged requests,
+// as a check before enabling chaos.
+func (ce *ChaosEngine) Preview(entries []HTTPLogEntry) *ChaosPreview {
+	return ce.preview(entries, nil)
+}
+
+// preview is Preview finding the sessions of logged requests with sessionsOf.
+func (ce *ChaosEngine) preview(entries []HTTPLogEntry, sessionsOf func(HTTPLogEntry) []string) *ChaosPreview {
+	ce.mu.RLock()
+	rules := make([]*ChaosRule, len(ce.rules))
+	for i, state := range ce.rules {
+		rule := *state.rule
+		rule.Enabled = state.enabled.Load()
+		rules[i] = &rule
+	}
+	var globalOdds float64
+	if ce.config != nil {
+		globalOdds = ce.config.GlobalOdds
+	}
+	ce.mu.RUnlock()
+
+	return previewRules(rules, globalOdds, entries, sessionsOf)
+}
+
+// PreviewChaos evaluates config, or the current chaos rules when nil,
+// against the HTTP requests in the traffic log.
+func (ps *ProxyServer) PreviewChaos(config *ChaosConfig) (*ChaosPreview, error) {
+	logged := ps.logger.Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}})
+	entries := make([]HTTPLogEntry, 0, len(logged))
+	for _, entry := range logged {
+		if entry.HTTP != nil {
+			entries = append(entries, *entry.HTTP)
+		}
+	}
+	if config == nil {
+		return ps.chaosEngine.preview(entries, ps.loggedSessions()), nil
+	}
+	return previewChaos(config, entries, ps.loggedSessions())
+}
+
+// loggedSessions finds the sessions of logged requests the page tracker
+// still holds.
+func (ps *ProxyServer) loggedSessions() func(HTTPLogEntry) []string {
+	ids := ps.pageTracker.entrySessions()
+	return func(entry HTTPLogEntry) []string {
+		return ids[entry.ID]
+	}
+}
+
+func previewRules(rules []*ChaosRule, globalOdds float64, entries []HTTPLogEntry, sessionsOf func(HTTPLogEntry) []string) *ChaosPreview {
+	odds := 1.0
+	if globalOdds > 0 && globalOdds < 1.0 {
+		odds = globalOdds
+	}
+
+	preview := &ChaosPreview{
+		Rules: make([]ChaosRulePreview, len(rules)),
+	}
+	endpoints := make([]map[ChaosPreviewEndpoint]int, len(rules))
+	for i, rule := range rules {
+		preview.Rules[i] = ChaosRulePreview{
+			ID:          rule.ID,
+			Name:        rule.Name,
+			Type:        rule.Type,
+			Enabled:     rule.Enabled,
+			Probability: odds * ruleProbability(rule),
+			Impact:      chaosImpact(rule),
+		}
+		endpoints[i] = make(map[ChaosPreviewEndpoint]int)
+	}
+
+	for _, entry := range entries {
+		path := urlPath(entry.URL)
+		if isDevtoolPath(path) {
+			continue // Chaos never touches these
+		}
+		preview.Requests++
+		if preview.Since.IsZero() || entry.Timestamp.Before(preview.Since) {
+			preview.Since = entry.Timestamp
+		}
+
+		// Rules roll independently once the global odds pass
+		unaffected := 1.0
+		matched := false
+		var fields *chaosRequestFields
+		for i, rule := range rules {
+			if !rule.matches(entry.Method, entry.URL) {
+				continue
+			}
+			if rule.hasFieldMatchers() {
+				if fields == nil {
+					fields = loggedChaosRequestFields(entry, sessionsOf)
+				}
+				if !rule.matchesFields(fields) {
+					continue
+				}
+			}
+			preview.Rules[i].Matched++
+			endpoints[i][ChaosPreviewEndpoint{Method: entry.Method, Path: path}]++
+			if rule.Enabled {
+				matched = true
+				unaffected *= 1 - ruleProbability(rule)
+			}
+		}
+		if matched {
+			preview.Matched++
+			preview.ExpectedAffected += odds * (1 - unaffected)
+		}
+	}
+
+	for i, rule := range rules {
+		rp := &preview.Rules[i]
+		if rule.Enabled {
+			rp.ExpectedHits = round2(float64(rp.Matched) * rp.Probability)
+		}
+		switch rule.Type {
+		case ChaosLatency:
+			rp.ExpectedDelayMs = round2(rp.ExpectedHits * float64(rule.MinLatencyMs+rule.MaxLatencyMs) / 2)
+		case ChaosStale:
+			rp.ExpectedDelayMs = round2(rp.ExpectedHits * float64(rule.StaleDelayMs))
+		case ChaosHTTPError:
+			if len(rule.ErrorCodes) > 0 {
+				rp.ExpectedErrors = rp.ExpectedHits
+			}
+		case ChaosRateLimit:
+			rp.ExpectedErrors = rp.ExpectedHits
+		}
+		rp.Probability = round2(rp.Probability)
+		rp.Endpoints = topEndpoints(endpoints[i])
+	}
+
+	preview.ExpectedAffected = round2(preview.ExpectedAffected)
+	if preview.Requests > 0 {
+		preview.AffectedPercent = round2(100 * preview.ExpectedAffected / float64(preview.Requests))
+	}
+	return preview
+}
+
+// ruleProbability returns the chance a matched request triggers the rule,
+// with the defaults SetConfig applies.
+func ruleProbability(rule *ChaosRule) float64 {
+	switch {
+	case rule.Probability <= 0:
+		return 1.0
+	case rule.Probability > 1:
+		return 1.0
+	}
+	return rule.Probability
+}
+
+// chaosImpact describes what a rule does to a request it hits.
+func chaosImpact(rule *ChaosRule) string {
+	switch rule.Type {
+	case ChaosLatency:
+		return fmt.Sprintf("adds %d-%dms latency", rule.MinLatencyMs, rule.MaxLatencyMs)
+	case ChaosStale:
+		return fmt.Sprintf("delays the response %s", time.Duration(rule.StaleDelayMs)*time.Millisecond)
+	case ChaosHTTPError:
+		if len(rule.ErrorCodes) == 0 {
+			return "no effect without error_codes"
+		}
+		parts := make([]string, len(rule.ErrorCodes))
+		for i, code := range rule.ErrorCodes {
+			parts[i] = fmt.Sprint(code)
+		}
+		return "responds with HTTP " + strings.Join(parts, "/")
+	case ChaosRateLimit:
+		return "responds with HTTP 429"
+	case ChaosTimeout:
+		return "never responds"
+	case ChaosPacketLoss:
+		return "drops the request"
+	case ChaosDisconnect, ChaosChunkedAbort, ChaosPartialBody:
+		return "cuts the connection mid-response"
+	case ChaosTruncate:
+		return "truncates the response body"
+	case ChaosBitFlip, ChaosCorruptJSON:
+		return "corrupts the response body"
+	case ChaosBandwidth, ChaosSlowDrip:
+		return "slows the response transfer"
+	case ChaosBandwidthLimit:
+		kbps := rule.BandwidthKbps
+		if kbps <= 0 {
+			kbps = 400
+		}
+		return fmt.Sprintf("caps the response transfer at %dkbps", kbps)
+	case ChaosConnectionReset:
+		return "resets the connection mid-response"
+	case ChaosDNSFailure:
+		if len(rule.Hosts) > 0 {
+			return "fails to resolve " + strings.Join(rule.Hosts, ", ")
+		}
+		return "fails to resolve the target host"
+	case ChaosSlowClose:
+		return "delays closing the connection"
+	case ChaosOutOfOrder:
+		return "reorders concurrent responses"
+	case ChaosHeaderBomb:
+		return "floods the response with headers"
+	}
+	return string(rule.Type)
+}
+
+// urlPath strips the query from a logged URL.
+func urlPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Path == "" {
+		return raw
+	}
+	return u.Path
+}
+
+func topEndpoints(counts map[ChaosPreviewEndpoint]int) []ChaosPreviewEndpoint {
+	list := make([]ChaosPreviewEndpoint, 0, len(counts))
+	for endpoint, count := range counts {
+		endpoint.Count = count
+		list = append(list, endpoint)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Method < list[j].Method
+	})
+	if len(list) > chaosPreviewEndpoints {
+		list = list[:chaosPreviewEndpoints]
+	}
+	return list
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreviewChaos(t *testing.T) {
+	now := time.Now()
+	var entries []HTTPLogEntry
+	for i := 0; i < 6; i++ {
+		entries = append(entries, HTTPLogEntry{Timestamp: now, Method: "GET", URL: "/api/users?page=1"})
+	}
+	for i := 0; i < 2; i++ {
+		entries = append(entries, HTTPLogEntry{Timestamp: now, Method: "POST", URL: "/api/orders"})
+	}
+	entries = append(entries,
+		HTTPLogEntry{Timestamp: now.Add(-time.Minute), Method: "GET", URL: "/index.html"},
+		HTTPLogEntry{Timestamp: now, Method: "GET", URL: "/__devtool/metrics"},
+	)
+
+	config := &ChaosConfig{
+		GlobalOdds: 0.5,
+		Rules: []*ChaosRule{
+			{ID: "slow", Type: ChaosLatency, Enabled: true, URLPattern: "/api/", MinLatencyMs: 100, MaxLatencyMs: 300},
+			{ID: "errors", Type: ChaosHTTPError, Enabled: true, URLPattern: "/api/orders", Methods: []string{"POST"}, Probability: 0.5, ErrorCodes: []int{500, 503}},
+			{ID: "off", Type: ChaosTimeout, Enabled: false},
+		},
+	}
+	preview, err := PreviewChaos(config, entries)
+	if err != nil {
+		t.Fatalf("PreviewChaos failed: %v", err)
+	}
+	if config.Rules[0].urlRegex != nil {
+		t.Error("Expected the previewed config left untouched")
+	}
+
+	if preview.Requests != 9 || preview.Matched != 8 || !preview.Since.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Unexpected totals %+v", preview)
+	}
+	// 8 API requests at 50% global odds; the order rule only adds to requests
+	// the latency rule already hits
+	if preview.ExpectedAffected != 4 || preview.AffectedPercent != 44.44 {
+		t.Errorf("Expected 4 affected (44.44%%), got %v (%v%%)", preview.ExpectedAffected, preview.AffectedPercent)
+	}
+
+	slow := preview.Rules[0]
+	if slow.Matched != 8 || slow.Probability != 0.5 || slow.ExpectedHits != 4 || slow.ExpectedDelayMs != 800 {
+		t.Errorf("Unexpected latency preview %+v", slow)
+	}
+	if len(slow.Endpoints) != 2 || slow.Endpoints[0] != (ChaosPreviewEndpoint{Method: "GET", Path: "/api/users", Count: 6}) {
+		t.Errorf("Unexpected endpoints %+v", slow.Endpoints)
+	}
+
+	errors := preview.Rules[1]
+	if errors.Matched != 2 || errors.ExpectedErrors != 0.5 || errors.Impact != "responds with HTTP 500/503" {
+		t.Errorf("Unexpected error preview %+v", errors)
+	}
+
+	off := preview.Rules[2]
+	if off.Matched != 9 || off.ExpectedHits != 0 {
+		t.Errorf("Expected a disabled rule to match without expected hits, got %+v", off)
+	}
+
+	if _, err := PreviewChaos(&ChaosConfig{Rules: []*ChaosRule{{ID: "bad", URLPattern: "("}}}, entries); err == nil {
+		t.Error("Expected error for an invalid URL pattern")
+	}
+}
+
+func TestProxyPreviewChaos(t *testing.T) {
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: "http://localhost:3000"})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	ps.Logger().LogHTTP(HTTPLogEntry{ID: "1", Timestamp: time.Now(), Method: "GET", URL: "/api/items"})
+	ps.Logger().LogHTTP(HTTPLogEntry{ID: "2", Timestamp: time.Now(), Method: "GET", URL: "/app.js"})
+
+	if err := ps.ChaosEngine().AddRule(&ChaosRule{ID: "drop", Type: ChaosPacketLoss, Enabled: true, URLPattern: "^/api/", Probability: 0.25}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	preview, err := ps.PreviewChaos(nil)
+	if err != nil {
+		t.Fatalf("PreviewChaos failed: %v", err)
+	}
+	if preview.Requests != 2 || len(preview.Rules) != 1 || preview.Rules[0].Matched != 1 || preview.ExpectedAffected != 0.25 {
+		t.Errorf("Unexpected preview of the current rules %+v", preview)
+	}
+	if ps.ChaosEngine().IsEnabled() || ps.ChaosEngine().GetStats().TotalRequests != 0 {
+		t.Error("Expected the preview not to enable chaos or count requests")
+	}
+
+	preview, err = ps.PreviewChaos(GetPreset("flaky-api"))
+	if err != nil {
+		t.Fatalf("PreviewChaos failed: %v", err)
+	}
+	if len(preview.Rules) == 0 {
+		t.Error("Expected the preset's rules previewed")
+	}
+}
@@ -0,0 +1,341 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxScenarioEvents is how many phase events a scenario run keeps.
+const maxScenarioEvents = 100
+
+// ChaosScenario steps a proxy's chaos configuration through timed phases,
+// e.g. two minutes of latency, then 30 seconds of 503s, then recovery.
+type ChaosScenario struct {
+	Name   string       `json:"name"`
+	Phases []ChaosPhase `json:"phases"`
+	Loop   bool         `json:"loop,omitempty"` // Start over after the last phase
+}
+
+// ChaosPhase is one step of a scenario. A phase with neither rules nor a
+// preset runs without chaos, which is how a scenario recovers.
+type ChaosPhase struct {
+	Name       string       `json:"name,omitempty"`
+	DurationMs int64        `json:"duration_ms"`      // 0 on the last phase holds it until stopped
+	Preset     string       `json:"preset,omitempty"` // Use a preset's rules
+	Rules      []*ChaosRule `json:"rules,omitempty"`  // Always enabled while the phase runs
+}
+
+// Validate checks a scenario before it runs.
+func (s *ChaosScenario) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario name is required")
+	}
+	if len(s.Phases) == 0 {
+		return fmt.Errorf("scenario %q has no phases", s.Name)
+	}
+	for i, phase := range s.Phases {
+		last := i == len(s.Phases)-1
+		switch {
+		case phase.DurationMs < 0:
+			return fmt.Errorf("phase %d: duration_ms must not be negative", i+1)
+		case phase.DurationMs == 0 && (!last || s.Loop):
+			return fmt.Errorf("phase %d: duration_ms is required except on the last phase of a scenario that does not loop", i+1)
+		case phase.Preset != "" && len(phase.Rules) > 0:
+			return fmt.Errorf("phase %d: use either preset or rules, not both", i+1)
+		case phase.Preset != "" && ChaosPresets[phase.Preset] == nil:
+			return fmt.Errorf("phase %d: unknown preset %q", i+1, phase.Preset)
+		}
+		for _, rule := range phase.Rules {
+			if rule == nil || rule.Type == "" {
+				return fmt.Errorf("phase %d: every rule needs a type", i+1)
+			}
+			if err := copyRule(rule).compile(); err != nil {
+				return fmt.Errorf("phase %d: rule %q: %w", i+1, rule.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// config returns the chaos configuration phase i applies.
+func (s *ChaosScenario) config(i int) *ChaosConfig {
+	phase := s.Phases[i]
+	if phase.Preset != "" {
+		return GetPreset(phase.Preset)
+	}
+	config := &ChaosConfig{LoggingMode: LoggingModeTesting}
+	for _, rule := range phase.Rules {
+		rule = copyRule(rule)
+		rule.Enabled = true
+		config.Rules = append(config.Rules, rule)
+	}
+	config.Enabled = len(config.Rules) > 0
+	return config
+}
+
+// phaseName returns a phase's name, or its 1-based number.
+func (s *ChaosScenario) phaseName(i int) string {
+	if name := s.Phases[i].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("phase %d", i+1)
+}
+
+// ChaosScenarioEvent records a scenario starting a phase or ending.
+type ChaosScenarioEvent struct {
+	Time      time.Time `json:"time"`
+	Scenario  string    `json:"scenario"`
+	Type      string    `json:"type"` // phase, finished, stopped
+	Phase     int       `json:"phase,omitempty"`
+	PhaseName string    `json:"phase_name,omitempty"`
+	Loop      int       `json:"loop,omitempty"`
+	Rules     int       `json:"rules"`
+}
+
+// ChaosScenarioStatus reports the current or most recent scenario run.
+type ChaosScenarioStatus struct {
+	Name        string               `json:"name"`
+	Running     bool                 `json:"running"`
+	StartedAt   time.Time            `json:"started_at"`
+	EndedAt     *time.Time           `json:"ended_at,omitempty"`
+	Phase       int                  `json:"phase"` // 1-based
+	PhaseName   string               `json:"phase_name"`
+	PhaseCount  int                  `json:"phase_count"`
+	PhaseEndsAt *time.Time           `json:"phase_ends_at,omitempty"`
+	Loop        int                  `json:"loop,omitempty"`
+	Events      []ChaosScenarioEvent `json:"events"`
+}
+
+// scenarioRun is the state of one scenario run.
+type scenarioRun struct {
+	scenario ChaosScenario
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	// Restored when the run ends
+	previous        *ChaosConfig
+	previousEnabled bool
+
+	// Guarded by ChaosEngine.scenarioMu
+	status ChaosScenarioStatus
+}
+
+// SetOnScenarioEvent registers a callback for scenario phase changes.
+func (ce *ChaosEngine) SetOnScenarioEvent(fn func(ChaosScenarioEvent)) {
+	ce.scenarioMu.Lock()
+	defer ce.scenarioMu.Unlock()
+	ce.onScenarioEvent = fn
+}
+
+// StartScenario runs a scenario, applying its first phase right away. The
+// configuration in effect before is restored when the scenario finishes or
+// is stopped; chaos changes made meanwhile last until the next phase.
+func (ce *ChaosEngine) StartScenario(scenario ChaosScenario) error {
+	if err := scenario.Validate(); err != nil {
+		return err
+	}
+
+	ce.scenarioMu.Lock()
+	defer ce.scenarioMu.Unlock()
+	if ce.scenario != nil && ce.scenario.status.Running {
+		return fmt.Errorf("scenario %q is running; stop it first", ce.scenario.scenario.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &scenarioRun{
+		scenario:        scenario,
+		cancel:          cancel,
+		done:            make(chan struct{}),
+		previous:        copyConfig(ce.GetConfig()),
+		previousEnabled: ce.IsEnabled(),
+		status: ChaosScenarioStatus{
+			Name:       scenario.Name,
+			Running:    true,
+			StartedAt:  time.Now(),
+			PhaseCount: len(scenario.Phases),
+		},
+	}
+	ce.scenario = run
+	if err := ce.applyPhaseLocked(run, 0); err != nil {
+		cancel()
+		run.status.Running = false
+		close(run.done)
+		return err
+	}
+	go ce.runScenario(ctx, run)
+	return nil
+}
+
+// StopScenario stops the running scenario and restores the configuration in
+// effect before it started.
+func (ce *ChaosEngine) StopScenario() (ChaosScenarioStatus, error) {
+	ce.scenarioMu.Lock()
+	run := ce.scenario
+	if run == nil || !run.status.Running {
+		ce.scenarioMu.Unlock()
+		return ChaosScenarioStatus{}, fmt.Errorf("no scenario is running")
+	}
+	ce.endScenarioLocked(run, "stopped")
+	status := run.status.clone()
+	ce.scenarioMu.Unlock()
+
+	<-run.done
+	return status, nil
+}
+
+// ScenarioStatus returns the current or most recent scenario run.
+func (ce *ChaosEngine) ScenarioStatus() (ChaosScenarioStatus, bool) {
+	ce.scenarioMu.Lock()
+	defer ce.scenarioMu.Unlock()
+	if ce.scenario == nil {
+		return ChaosScenarioStatus{}, false
+	}
+	return ce.scenario.status.clone(), true
+}
+
+// runScenario steps run through its phases until it ends or is stopped.
+func (ce *ChaosEngine) runScenario(ctx context.Context, run *scenarioRun) {
+	defer close(run.done)
+
+	phases := run.scenario.Phases
+	i := 0
+	for {
+		duration := time.Duration(phases[i].DurationMs) * time.Millisecond
+		if duration == 0 {
+			// The last phase holds until stopped
+			<-ctx.Done()
+			return
+		}
+		timer := time.NewTimer(duration)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		ce.scenarioMu.Lock()
+		if ctx.Err() != nil {
+			ce.scenarioMu.Unlock()
+			return
+		}
+		if i++; i == len(phases) {
+			if !run.scenario.Loop {
+				ce.endScenarioLocked(run, "finished")
+				ce.scenarioMu.Unlock()
+				return
+			}
+			i = 0
+			run.status.Loop++
+		}
+		if err := ce.applyPhaseLocked(run, i); err != nil {
+			// Validated up front, so only a broken preset gets here
+			ce.endScenarioLocked(run, "stopped")
+			ce.scenarioMu.Unlock()
+			return
+		}
+		ce.scenarioMu.Unlock()
+	}
+}
+
+// applyPhaseLocked switches the engine to phase i of run.
+func (ce *ChaosEngine) applyPhaseLocked(run *scenarioRun, i int) error {
+	config := run.scenario.config(i)
+	if err := ce.SetConfig(config); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	run.status.Phase = i + 1
+	run.status.PhaseName = run.scenario.phaseName(i)
+	run.status.PhaseEndsAt = nil
+	if ms := run.scenario.Phases[i].DurationMs; ms > 0 {
+		ends := now.Add(time.Duration(ms) * time.Millisecond)
+		run.status.PhaseEndsAt = &ends
+	}
+	ce.recordScenarioEventLocked(run, ChaosScenarioEvent{
+		Time:      now,
+		Type:      "phase",
+		Phase:     i + 1,
+		PhaseName: run.status.PhaseName,
+		Rules:     len(config.Rules),
+	})
+	return nil
+}
+
+// endScenarioLocked restores the configuration run replaced and marks it
+// ended with the given event type.
+func (ce *ChaosEngine) endScenarioLocked(run *scenarioRun, eventType string) {
+	run.cancel()
+	if run.previous != nil {
+		ce.SetConfig(run.previous)
+	} else {
+		ce.mu.Lock()
+		ce.config = nil
+		ce.rules = nil
+		ce.mu.Unlock()
+	}
+	// ENABLE/DISABLE are not part of the config, so restore them separately
+	ce.enabled.Store(run.previousEnabled)
+	ce.changed()
+
+	now := time.Now()
+	run.status.Running = false
+	run.status.EndedAt = &now
+	run.status.PhaseEndsAt = nil
+	ce.recordScenarioEventLocked(run, ChaosScenarioEvent{
+		Time:      now,
+		Type:      eventType,
+		Phase:     run.status.Phase,
+		PhaseName: run.status.PhaseName,
+	})
+}
+
+// recordScenarioEventLocked keeps, logs and reports a scenario event.
+func (ce *ChaosEngine) recordScenarioEventLocked(run *scenarioRun, event ChaosScenarioEvent) {
+	event.Scenario = run.scenario.Name
+	event.Loop = run.status.Loop
+	run.status.Events = append(run.status.Events, event)
+	if len(run.status.Events) > maxScenarioEvents {
+		run.status.Events = append([]ChaosScenarioEvent(nil), run.status.Events[len(run.status.Events)-maxScenarioEvents:]...)
+	}
+
+	if ce.logger != nil {
+		ce.logger.LogCustom(CustomLog{
+			ID:        fmt.Sprintf("chaos-scenario-%d", event.Time.UnixNano()),
+			Timestamp: event.Time,
+			Level:     "info",
+			Message:   event.Message(),
+			Data: map[string]interface{}{
+				"event":      "chaos_scenario_" + event.Type,
+				"scenario":   event.Scenario,
+				"phase":      event.Phase,
+				"phase_name": event.PhaseName,
+				"loop":       event.Loop,
+				"rules":      event.Rules,
+			},
+		})
+	}
+	if ce.onScenarioEvent != nil {
+		go ce.onScenarioEvent(event)
+	}
+}
+
+// Message describes the event in one line.
+func (e ChaosScenarioEvent) Message() string {
+	switch e.Type {
+	case "phase":
+		return fmt.Sprintf("Chaos scenario %q: %s started (%d rules)", e.Scenario, e.PhaseName, e.Rules)
+	case "finished":
+		return fmt.Sprintf("Chaos scenario %q finished", e.Scenario)
+	default:
+		return fmt.Sprintf("Chaos scenario %q %s during %s", e.Scenario, e.Type, e.PhaseName)
+	}
+}
+
+// clone copies the status so callers can read it without the lock.
+func (s ChaosScenarioStatus) clone() ChaosScenarioStatus {
+	s.Events = append([]ChaosScenarioEvent(nil), s.Events...)
+	return s
+}
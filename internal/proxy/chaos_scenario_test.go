@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+// waitForPhase waits until the engine's scenario reaches phase or ends.
+func waitForPhase(t *testing.T, engine *ChaosEngine, phase int, running bool) ChaosScenarioStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status, ok := engine.ScenarioStatus(); ok && status.Phase == phase && status.Running == running {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	status, _ := engine.ScenarioStatus()
+	t.Fatalf("Expected phase %d (running %v), got %+v", phase, running, status)
+	return status
+}
+
+func TestChaosScenario(t *testing.T) {
+	engine := NewChaosEngine(nil)
+	previous := &ChaosConfig{Enabled: false, Rules: []*ChaosRule{{ID: "mine", Type: ChaosLatency, Enabled: true, MinLatencyMs: 1}}}
+	if err := engine.SetConfig(previous); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	events := make(chan ChaosScenarioEvent, 10)
+	engine.SetOnScenarioEvent(func(e ChaosScenarioEvent) { events <- e })
+
+	scenario := ChaosScenario{
+		Name: "outage",
+		Phases: []ChaosPhase{
+			{Name: "slow", DurationMs: 50, Rules: []*ChaosRule{{ID: "lat", Type: ChaosLatency, MinLatencyMs: 500}}},
+			{Name: "errors", DurationMs: 50, Rules: []*ChaosRule{{ID: "503", Type: ChaosHTTPError, ErrorCodes: []int{503}, Probability: 0.2}}},
+			{Name: "recover", DurationMs: 50},
+		},
+	}
+	if err := engine.StartScenario(scenario); err != nil {
+		t.Fatalf("StartScenario failed: %v", err)
+	}
+	if err := engine.StartScenario(scenario); err == nil {
+		t.Error("Expected a second scenario to be refused while one runs")
+	}
+
+	status := waitForPhase(t, engine, 1, true)
+	if !engine.IsEnabled() || status.PhaseName != "slow" || status.PhaseCount != 3 || status.PhaseEndsAt == nil {
+		t.Errorf("Unexpected first phase %+v", status)
+	}
+	if config := engine.GetConfig(); len(config.Rules) != 1 || config.Rules[0].ID != "lat" || !config.Rules[0].Enabled {
+		t.Errorf("Expected the phase rules enabled, got %+v", config.Rules)
+	}
+
+	status = waitForPhase(t, engine, 3, false)
+	if engine.IsEnabled() {
+		t.Error("Expected chaos switched back off")
+	}
+	if config := engine.GetConfig(); len(config.Rules) != 1 || config.Rules[0].ID != "mine" {
+		t.Errorf("Expected the previous config restored, got %+v", config)
+	}
+	var types []string
+	for _, e := range status.Events {
+		types = append(types, e.Type+":"+e.PhaseName)
+	}
+	if got := len(types); got != 4 || types[1] != "phase:errors" || types[3] != "finished:recover" {
+		t.Errorf("Unexpected events %v", types)
+	}
+	for range types {
+		select {
+		case <-events:
+		case <-time.After(time.Second):
+			t.Fatal("Expected every event reported")
+		}
+	}
+
+	if _, err := engine.StopScenario(); err == nil {
+		t.Error("Expected nothing to stop")
+	}
+}
+
+func TestChaosScenarioStop(t *testing.T) {
+	engine := NewChaosEngine(nil)
+	scenario := ChaosScenario{
+		Name: "flaky",
+		Loop: true,
+		Phases: []ChaosPhase{
+			{DurationMs: 20, Preset: "flaky-api"},
+			{DurationMs: 20},
+		},
+	}
+	if err := engine.StartScenario(scenario); err != nil {
+		t.Fatalf("StartScenario failed: %v", err)
+	}
+	waitForPhase(t, engine, 2, true)
+	waitForPhase(t, engine, 1, true)
+
+	status, err := engine.StopScenario()
+	if err != nil {
+		t.Fatalf("StopScenario failed: %v", err)
+	}
+	if status.Running || status.Loop < 1 || status.Events[len(status.Events)-1].Type != "stopped" {
+		t.Errorf("Unexpected stopped status %+v", status)
+	}
+	if engine.IsEnabled() || engine.GetConfig() != nil {
+		t.Errorf("Expected no chaos after stopping, got %+v", engine.GetConfig())
+	}
+}
+
+func TestChaosScenarioValidate(t *testing.T) {
+	rule := []*ChaosRule{{ID: "x", Type: ChaosLatency}}
+	for name, bad := range map[string]ChaosScenario{
+		"name":        {Phases: []ChaosPhase{{DurationMs: 1}}},
+		"phases":      {Name: "x"},
+		"duration":    {Name: "x", Phases: []ChaosPhase{{}, {DurationMs: 1}}},
+		"loop hold":   {Name: "x", Loop: true, Phases: []ChaosPhase{{}}},
+		"preset":      {Name: "x", Phases: []ChaosPhase{{DurationMs: 1, Preset: "nope"}}},
+		"both":        {Name: "x", Phases: []ChaosPhase{{DurationMs: 1, Preset: "flaky-api", Rules: rule}}},
+		"rule type":   {Name: "x", Phases: []ChaosPhase{{DurationMs: 1, Rules: []*ChaosRule{{ID: "x"}}}}},
+		"rule regexp": {Name: "x", Phases: []ChaosPhase{{DurationMs: 1, Rules: []*ChaosRule{{ID: "x", Type: ChaosLatency, URLPattern: "("}}}}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	hold := ChaosScenario{Name: "x", Phases: []ChaosPhase{{DurationMs: 1, Rules: rule}, {}}}
+	if err := hold.Validate(); err != nil {
+		t.Errorf("Expected the last phase to hold without a duration: %v", err)
+	}
+}
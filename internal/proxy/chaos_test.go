@@ -3,7 +3,9 @@ package proxy
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -814,3 +816,109 @@ func (w *testResponseWriter) Write(p []byte) (int, error) {
 }
 
 func (w *testResponseWriter) WriteHeader(code int) {}
+
+// chaosProxy serves a proxy to a backend answering size bytes, with chaos
+// enabled and rule added.
+func chaosProxy(t *testing.T, size int, rule *ChaosRule) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(bytes.Repeat([]byte("x"), size))
+	}))
+	t.Cleanup(backend.Close)
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	ps.ChaosEngine().Enable()
+	if err := ps.ChaosEngine().AddRule(rule); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	front := httptest.NewServer(http.HandlerFunc(ps.handleProxy))
+	t.Cleanup(front.Close)
+	return front
+}
+
+func TestChaosIntegration_BandwidthLimit(t *testing.T) {
+	// 160kbps is 20000 bytes/s, so 8000 bytes take about 400ms
+	front := chaosProxy(t, 8000, &ChaosRule{ID: "bw", Type: ChaosBandwidthLimit, Enabled: true, BandwidthKbps: 160})
+
+	start := time.Now()
+	resp, err := http.Get(front.URL + "/file")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	if err != nil || len(body) != 8000 {
+		t.Fatalf("Expected the whole body, got %d bytes, err %v", len(body), err)
+	}
+	if elapsed < 300*time.Millisecond {
+		t.Errorf("Expected the transfer held to the cap, took %s", elapsed)
+	}
+}
+
+func TestChaosIntegration_ConnectionReset(t *testing.T) {
+	front := chaosProxy(t, 64*1024, &ChaosRule{ID: "rst", Type: ChaosConnectionReset, Enabled: true, DropAfterBytes: 1024})
+
+	resp, err := http.Get(front.URL + "/file")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err == nil {
+		t.Fatalf("Expected the transfer to fail, got %d bytes", len(body))
+	}
+	if len(body) > 1024 {
+		t.Errorf("Expected at most 1024 bytes before the reset, got %d", len(body))
+	}
+}
+
+func TestChaosTransport_DNSFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	engine := NewChaosEngine(nil)
+	engine.Enable()
+	engine.AddRule(&ChaosRule{ID: "dns", Type: ChaosDNSFailure, Enabled: true, Hosts: []string{"*.example.com"}})
+	transport := NewChaosTransport(http.DefaultTransport, engine)
+
+	req := httptest.NewRequest("GET", "http://api.example.com/users", nil)
+	_, err := transport.RoundTrip(req)
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound || dnsErr.Name != "api.example.com" {
+		t.Fatalf("Expected a not-found DNS error, got %v", err)
+	}
+
+	// Other hosts resolve
+	resp, err := transport.RoundTrip(httptest.NewRequest("GET", server.URL, nil))
+	if err != nil {
+		t.Fatalf("Expected unlisted hosts to pass, got %v", err)
+	}
+	resp.Body.Close()
+
+	if stats := engine.GetStats(); stats.ErrorsInjected != 1 {
+		t.Errorf("Expected one injected error, got %d", stats.ErrorsInjected)
+	}
+}
+
+func TestChaosEngine_GetBandwidthLimit(t *testing.T) {
+	engine := NewChaosEngine(nil)
+	rules := []*ChaosRule{
+		{Type: ChaosBandwidthLimit, BandwidthKbps: 1600},
+		{Type: ChaosBandwidth},
+	}
+	if got := engine.GetBandwidthLimit(rules); got != 400 {
+		t.Errorf("Expected the lowest cap, with bandwidth defaulting to 400, got %d", got)
+	}
+	if got := engine.GetBandwidthLimit(nil); got != 0 {
+		t.Errorf("Expected no cap without rules, got %d", got)
+	}
+}
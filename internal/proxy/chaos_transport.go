@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -51,6 +52,11 @@ func (ct *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, &chaosError{message: "chaos: connection dropped (packet loss)"}
 	}
 
+	// DNS failure - fail like a dial to a host that doesn't resolve
+	if host := req.URL.Hostname(); ct.engine.DNSFailure(rules, host) {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}}
+	}
+
 	// Check for stale delay (very long delays)
 	if staleDelay := ct.engine.GetStaleDelay(rules); staleDelay > 0 {
 		select {
@@ -122,10 +122,115 @@ func (sdw *SlowDripWriter) BytesWritten() int64 {
 	return sdw.written
 }
 
+// BandwidthLimitWriter wraps http.ResponseWriter to hold a response to a
+// sustained rate. Unlike SlowDripWriter, which sleeps per chunk, it paces
+// against the first byte, so pauses in a streamed response count toward the
+// budget and the average rate stays at the cap.
+type BandwidthLimitWriter struct {
+	w           http.ResponseWriter
+	bytesPerSec float64
+	chunkSize   int
+	ctx         context.Context
+	start       time.Time
+	written     int64
+	headersSent atomic.Bool
+}
+
+// NewBandwidthLimitWriter creates a writer capped at kbps kilobits per second.
+func NewBandwidthLimitWriter(w http.ResponseWriter, kbps int, ctx context.Context) *BandwidthLimitWriter {
+	if kbps <= 0 {
+		kbps = 400 // Default: slow 3G downlink
+	}
+	bytesPerSec := float64(kbps) * 1000 / 8
+	// Write about 50ms worth at a time for a smooth rate
+	chunkSize := int(bytesPerSec / 20)
+	if chunkSize < 256 {
+		chunkSize = 256
+	}
+	return &BandwidthLimitWriter{
+		w:           w,
+		bytesPerSec: bytesPerSec,
+		chunkSize:   chunkSize,
+		ctx:         ctx,
+	}
+}
+
+// Header returns the header map
+func (bw *BandwidthLimitWriter) Header() http.Header {
+	return bw.w.Header()
+}
+
+// WriteHeader sends the HTTP response header with the provided status code
+func (bw *BandwidthLimitWriter) WriteHeader(statusCode int) {
+	if bw.headersSent.CompareAndSwap(false, true) {
+		bw.w.WriteHeader(statusCode)
+	}
+}
+
+// Write writes data in chunks, waiting until each chunk is due at the capped rate
+func (bw *BandwidthLimitWriter) Write(p []byte) (int, error) {
+	if !bw.headersSent.Load() {
+		bw.WriteHeader(http.StatusOK)
+	}
+	if bw.start.IsZero() {
+		bw.start = time.Now()
+	}
+
+	written := 0
+	for written < len(p) {
+		end := written + bw.chunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+
+		// The chunk may go out once the bytes before it have had their time
+		due := bw.start.Add(time.Duration(float64(bw.written) / bw.bytesPerSec * float64(time.Second)))
+		if wait := time.Until(due); wait > 0 {
+			select {
+			case <-bw.ctx.Done():
+				return written, bw.ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		n, err := bw.w.Write(p[written:end])
+		written += n
+		bw.written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		if flusher, ok := bw.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	return written, nil
+}
+
+// Flush implements http.Flusher
+func (bw *BandwidthLimitWriter) Flush() {
+	if flusher, ok := bw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (bw *BandwidthLimitWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := bw.w.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("underlying ResponseWriter does not support hijacking")
+}
+
+// BytesWritten returns the total bytes written
+func (bw *BandwidthLimitWriter) BytesWritten() int64 {
+	return bw.written
+}
+
 // ConnectionDropWriter wraps http.ResponseWriter to drop connection mid-response.
 // This simulates network failures, connection resets, or abrupt disconnections.
 type ConnectionDropWriter struct {
 	w                http.ResponseWriter
+	reset            bool    // Abort with a TCP RST instead of a FIN
 	dropAfterPercent float64 // Drop after this percentage of expected body
 	dropAfterBytes   int64   // Drop after this many bytes (takes precedence)
 	expectedSize     int64   // Expected total response size
@@ -156,6 +261,15 @@ func NewConnectionDropWriter(w http.ResponseWriter, dropAfterPercent float64, dr
 	return cdw
 }
 
+// NewConnectionResetWriter creates a writer that resets the connection
+// where a ConnectionDropWriter would close it, so the client sees
+// ECONNRESET instead of an early EOF.
+func NewConnectionResetWriter(w http.ResponseWriter, resetAfterPercent float64, resetAfterBytes int64, expectedSize int64) *ConnectionDropWriter {
+	cdw := NewConnectionDropWriter(w, resetAfterPercent, resetAfterBytes, expectedSize)
+	cdw.reset = true
+	return cdw
+}
+
 // Header returns the header map
 func (cdw *ConnectionDropWriter) Header() http.Header {
 	return cdw.w.Header()
@@ -218,6 +332,9 @@ func (cdw *ConnectionDropWriter) drop() {
 	if hijacker, ok := cdw.w.(http.Hijacker); ok {
 		conn, _, err := hijacker.Hijack()
 		if err == nil && conn != nil {
+			if cdw.reset {
+				setLingerZero(conn)
+			}
 			conn.Close()
 		}
 	}
@@ -240,6 +357,16 @@ func (cdw *ConnectionDropWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return nil, nil, errors.New("underlying ResponseWriter does not support hijacking")
 }
 
+// setLingerZero makes Close send a TCP RST instead of a FIN.
+func setLingerZero(conn net.Conn) {
+	if wrapped, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = wrapped.NetConn() // TLS
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+}
+
 // IsDropped returns whether the connection has been dropped
 func (cdw *ConnectionDropWriter) IsDropped() bool {
 	return cdw.dropped.Load()
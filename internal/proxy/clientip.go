@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// defaultTrustedProxies are trusted when none are configured. Tunnel agents
+// (cloudflared, ngrok, tailscale) connect to the proxy from this machine.
+var defaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// clientIPHeaders are single-address headers set by tunnel providers and
+// other proxies, checked before X-Forwarded-For.
+var clientIPHeaders = []string{"CF-Connecting-IP", "True-Client-IP", "X-Real-IP"}
+
+// ClientInfo describes the browser behind any trusted proxies.
+type ClientInfo struct {
+	IP    string `json:"ip"`
+	Proto string `json:"proto"` // "http" or "https" as seen by the browser
+	Host  string `json:"host"`  // Host the browser requested
+}
+
+// parseTrustedProxies parses IPs and CIDRs. nil means the defaults; "none"
+// trusts no proxy.
+func parseTrustedProxies(list []string) ([]*net.IPNet, error) {
+	if list == nil {
+		list = defaultTrustedProxies
+	}
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if strings.EqualFold(entry, "none") {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: IP or CIDR required", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// ValidateTrustedProxies reports the first invalid trusted proxy entry.
+func ValidateTrustedProxies(list []string) error {
+	_, err := parseTrustedProxies(list)
+	return err
+}
+
+// trustedAddr reports whether a peer address may set forwarding headers.
+func (ps *ProxyServer) trustedAddr(addr string) bool {
+	ip := net.ParseIP(hostnameOf(addr))
+	if ip == nil {
+		return false
+	}
+	for _, n := range ps.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientInfo returns the browser's address, protocol and requested host.
+// Forwarding headers are honored only from trusted proxies; X-Forwarded-For
+// is read right to left, skipping trusted hops, so a client cannot spoof its
+// address by prepending entries.
+func (ps *ProxyServer) clientInfo(r *http.Request) ClientInfo {
+	info := ClientInfo{IP: hostnameOf(r.RemoteAddr), Proto: "http", Host: r.Host}
+	if r.TLS != nil {
+		info.Proto = "https"
+	}
+	if !ps.trustedAddr(r.RemoteAddr) {
+		return info
+	}
+
+	found := false
+	for _, h := range clientIPHeaders {
+		if ip := strings.TrimSpace(r.Header.Get(h)); net.ParseIP(ip) != nil {
+			info.IP, found = ip, true
+			break
+		}
+	}
+	if !found {
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			info.IP = hop
+			if !ps.trustedAddr(hop) {
+				break
+			}
+		}
+	}
+
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	switch proto = strings.ToLower(strings.TrimSpace(proto)); {
+	case proto == "http" || proto == "https":
+		info.Proto = proto
+	case strings.Contains(r.Header.Get("CF-Visitor"), `"https"`):
+		info.Proto = "https"
+	}
+	if host, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ","); strings.TrimSpace(host) != "" {
+		info.Host = strings.TrimSpace(host)
+	}
+	return info
+}
+
+// setForwardedHeaders gives the upstream a consistent forwarding header set
+// for a request from info. Forwarding headers from untrusted peers are
+// dropped; the reverse proxy then appends the peer to X-Forwarded-For.
+func (ps *ProxyServer) setForwardedHeaders(req *http.Request, info ClientInfo) {
+	if !ps.trustedAddr(req.RemoteAddr) {
+		req.Header.Del("X-Forwarded-For")
+		for _, h := range clientIPHeaders {
+			req.Header.Del(h)
+		}
+	}
+	req.Header.Set("X-Real-IP", info.IP)
+	req.Header.Set("X-Forwarded-Host", info.Host)
+	req.Header.Set("X-Forwarded-Proto", info.Proto)
+}
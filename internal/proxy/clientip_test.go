@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientInfo(t *testing.T) {
+	trusted, err := parseTrustedProxies(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := &ProxyServer{trustedProxies: trusted}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    ClientInfo
+	}{
+		{
+			name:   "direct request",
+			remote: "192.168.1.20:5000",
+			want:   ClientInfo{IP: "192.168.1.20", Proto: "http", Host: "localhost:8080"},
+		},
+		{
+			name:    "untrusted peer cannot spoof",
+			remote:  "192.168.1.20:5000",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Forwarded-Proto": "https", "CF-Connecting-IP": "1.2.3.4"},
+			want:    ClientInfo{IP: "192.168.1.20", Proto: "http", Host: "localhost:8080"},
+		},
+		{
+			name:    "cloudflare tunnel",
+			remote:  "127.0.0.1:40000",
+			headers: map[string]string{"CF-Connecting-IP": "203.0.113.7", "X-Forwarded-For": "203.0.113.7", "CF-Visitor": `{"scheme":"https"}`},
+			want:    ClientInfo{IP: "203.0.113.7", Proto: "https", Host: "localhost:8080"},
+		},
+		{
+			name:    "forwarded chain read right to left",
+			remote:  "127.0.0.1:40000",
+			headers: map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.9, 127.0.0.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "abc.ngrok.app"},
+			want:    ClientInfo{IP: "198.51.100.9", Proto: "https", Host: "abc.ngrok.app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://localhost:8080/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := ps.clientInfo(r); got != tt.want {
+				t.Errorf("clientInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5", "::1"})
+	if err != nil || len(nets) != 3 {
+		t.Fatalf("parseTrustedProxies = %v, %v", nets, err)
+	}
+	if nets, err := parseTrustedProxies([]string{"none"}); err != nil || len(nets) != 0 {
+		t.Errorf("Expected none to trust nothing, got %v %v", nets, err)
+	}
+	if err := ValidateTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for invalid entry")
+	}
+}
+
+func TestForwardedHeaders(t *testing.T) {
+	var got http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "fwd", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	// Tunnel agent on loopback forwarding a browser request
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "127.0.0.1:40000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.Header.Set("X-Forwarded-Proto", "https")
+	ps.proxy.ServeHTTP(httptest.NewRecorder(), r)
+	if xff := got.Get("X-Forwarded-For"); xff != "203.0.113.7, 127.0.0.1" {
+		t.Errorf("Expected peer appended once, got %q", xff)
+	}
+	if got.Get("X-Real-IP") != "203.0.113.7" || got.Get("X-Forwarded-Proto") != "https" {
+		t.Errorf("Unexpected forwarding headers %v", got)
+	}
+
+	// Untrusted peer: its forwarding headers are dropped
+	r = httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.168.1.20:5000"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	r.Header.Set("X-Real-IP", "1.2.3.4")
+	ps.proxy.ServeHTTP(httptest.NewRecorder(), r)
+	if xff := got.Get("X-Forwarded-For"); xff != "192.168.1.20" {
+		t.Errorf("Expected spoofed chain dropped, got %q", xff)
+	}
+	if got.Get("X-Real-IP") != "192.168.1.20" || got.Get("X-Forwarded-Proto") != "http" {
+		t.Errorf("Unexpected forwarding headers %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MaxClipboardBytes bounds the text moved between pages and terminal
+// sessions in either direction.
+const MaxClipboardBytes = 64 * 1024
+
+// Clipboard sources.
+const (
+	ClipboardFromPage     = "page"     // Copied in the floating panel
+	ClipboardFromTerminal = "terminal" // Shared from the wrapped terminal session
+)
+
+// ClipboardEntry is text moved between a proxied page and the terminal
+// session of its project.
+type ClipboardEntry struct {
+	Text      string    `json:"text"`
+	Source    string    `json:"source"`        // page or terminal
+	URL       string    `json:"url,omitempty"` // Page the text was copied on
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Validate checks the source and the size limit.
+func (c ClipboardEntry) Validate() error {
+	if c.Source != ClipboardFromPage && c.Source != ClipboardFromTerminal {
+		return fmt.Errorf("invalid clipboard source %q: use page or terminal", c.Source)
+	}
+	if c.Text == "" {
+		return fmt.Errorf("clipboard text is empty")
+	}
+	if len(c.Text) > MaxClipboardBytes {
+		return fmt.Errorf("clipboard text is %d bytes, over the %d byte limit", len(c.Text), MaxClipboardBytes)
+	}
+	return nil
+}
+
+// handleClipboard relays a "clipboard" message from the floating panel and
+// tells the page whether the text reached the terminal.
+func (ps *ProxyServer) handleClipboard(conn *websocket.Conn, data map[string]interface{}, pageURL string) {
+	clip := ClipboardEntry{
+		Text:      getStringField(data, "text"),
+		Source:    ClipboardFromPage,
+		URL:       pageURL,
+		Timestamp: time.Now(),
+	}
+
+	err := clip.Validate()
+	if err == nil {
+		if bridge := ps.sessionBridge(); bridge == nil {
+			err = fmt.Errorf("clipboard relay not available: no terminal session")
+		} else {
+			err = bridge.RelayClipboard(ps, clip, getBoolField(data, "enter"))
+		}
+	}
+
+	resp := map[string]interface{}{
+		"type":    "clipboard_result",
+		"payload": map[string]interface{}{"success": err == nil, "bytes": len(clip.Text)},
+	}
+	if err != nil {
+		resp["payload"].(map[string]interface{})["error"] = err.Error()
+	}
+	conn.WriteJSON(resp)
+}
+
+// BroadcastClipboard offers text shared from the terminal to the floating
+// panel of all connected pages. Returns the number of pages reached.
+func (ps *ProxyServer) BroadcastClipboard(clip ClipboardEntry) (int, error) {
+	if err := clip.Validate(); err != nil {
+		return 0, err
+	}
+	messageBytes, err := json.Marshal(map[string]interface{}{
+		"type":    "clipboard",
+		"payload": clip,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal clipboard: %w", err)
+	}
+
+	sentCount := 0
+	ps.wsConns.Range(func(key, value interface{}) bool {
+		conn := value.(*websocket.Conn)
+		if err := conn.WriteMessage(websocket.TextMessage, messageBytes); err == nil {
+			sentCount++
+		}
+		return true
+	})
+	return sentCount, nil
+}
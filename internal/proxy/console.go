@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxConsoleMessage is the bytes of a console message kept.
+	maxConsoleMessage = 2000
+	// maxConsoleArgs is the arguments of a console call kept.
+	maxConsoleArgs = 10
+	// maxConsoleTop is how many distinct messages a ConsoleSummary lists.
+	maxConsoleTop = 10
+)
+
+// consoleLevels are the console methods the injected script forwards.
+// console.error is logged as a frontend error instead.
+var consoleLevels = map[string]bool{"log": true, "info": true, "warn": true, "debug": true}
+
+// ConsoleEntry is one console.log, info, warn or debug call on a page.
+type ConsoleEntry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`             // log, info, warn, debug
+	Message   string    `json:"message"`           // Arguments serialized and joined by spaces
+	Args      []string  `json:"args,omitempty"`    // Each argument serialized, when there are several
+	Source    string    `json:"source,omitempty"`  // Location of the call, e.g. "http://localhost:3000/app.js:12:5"
+	Dropped   int       `json:"dropped,omitempty"` // Calls the page dropped before this one to stay under its rate limit
+	URL       string    `json:"url"`               // Page URL
+}
+
+// newConsoleEntry builds an entry from a page's console message.
+func newConsoleEntry(id string, timestamp time.Time, url string, data map[string]interface{}) ConsoleEntry {
+	level := getStringField(data, "level")
+	if !consoleLevels[level] {
+		level = "log"
+	}
+	entry := ConsoleEntry{
+		ID:        id,
+		Timestamp: timestamp,
+		Level:     level,
+		Message:   clipConsole(getStringField(data, "message")),
+		Source:    getStringField(data, "source"),
+		Dropped:   getIntField(data, "dropped"),
+		URL:       url,
+	}
+	if args, ok := data["args"].([]interface{}); ok && len(args) > 1 {
+		for _, arg := range args[:min(len(args), maxConsoleArgs)] {
+			if s, ok := arg.(string); ok {
+				entry.Args = append(entry.Args, clipConsole(s))
+			}
+		}
+	}
+	return entry
+}
+
+// clipConsole shortens an overlong console message.
+func clipConsole(s string) string {
+	if len(s) <= maxConsoleMessage {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxConsoleMessage], "") + "..."
+}
+
+// ConsoleSummary breaks console output down by level and by message, so
+// noisy logging stands out.
+type ConsoleSummary struct {
+	Count   int                  `json:"count"`
+	Dropped int                  `json:"dropped,omitempty"` // Calls pages dropped to stay under the rate limit
+	ByLevel map[string]int       `json:"by_level"`
+	Top     []ConsoleMessageStat `json:"top,omitempty"` // Most frequent messages first
+}
+
+// ConsoleMessageStat counts one distinct console message. Messages differing
+// only in numbers count as the same message.
+type ConsoleMessageStat struct {
+	Level   string `json:"level"`
+	Message string `json:"message"` // The first occurrence
+	Source  string `json:"source,omitempty"`
+	Count   int    `json:"count"`
+}
+
+// consoleDigits matches the numbers ignored when grouping messages.
+var consoleDigits = regexp.MustCompile(`[0-9]+`)
+
+// SummarizeConsole aggregates console entries.
+func SummarizeConsole(entries []ConsoleEntry) ConsoleSummary {
+	summary := ConsoleSummary{ByLevel: make(map[string]int)}
+	stats := make(map[string]*ConsoleMessageStat)
+	var order []string
+	for _, e := range entries {
+		summary.Count++
+		summary.Dropped += e.Dropped
+		summary.ByLevel[e.Level]++
+
+		message := e.Message
+		if len(message) > 100 {
+			message = message[:100]
+		}
+		key := e.Level + "\x00" + consoleDigits.ReplaceAllString(message, "#")
+		if stat, ok := stats[key]; ok {
+			stat.Count++
+			continue
+		}
+		stats[key] = &ConsoleMessageStat{Level: e.Level, Message: e.Message, Source: e.Source, Count: 1}
+		order = append(order, key)
+	}
+
+	for _, key := range order {
+		summary.Top = append(summary.Top, *stats[key])
+	}
+	sort.SliceStable(summary.Top, func(i, j int) bool { return summary.Top[i].Count > summary.Top[j].Count })
+	if len(summary.Top) > maxConsoleTop {
+		summary.Top = summary.Top[:maxConsoleTop]
+	}
+	return summary
+}
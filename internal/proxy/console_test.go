@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewConsoleEntry(t *testing.T) {
+	now := time.Now()
+	entry := newConsoleEntry("c1", now, "http://localhost:3000/", map[string]interface{}{
+		"level":   "warn",
+		"message": "count 3",
+		"args":    []interface{}{"count", "3"},
+		"source":  "http://localhost:3000/app.js:12:5",
+		"dropped": float64(4),
+	})
+	if entry.Level != "warn" || entry.Message != "count 3" || entry.Dropped != 4 || entry.Source != "http://localhost:3000/app.js:12:5" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if len(entry.Args) != 2 || entry.Args[1] != "3" {
+		t.Errorf("Expected both arguments kept, got %v", entry.Args)
+	}
+
+	entry = newConsoleEntry("c2", now, "", map[string]interface{}{
+		"level":   "error",
+		"message": strings.Repeat("x", maxConsoleMessage+50),
+		"args":    []interface{}{"only"},
+	})
+	if entry.Level != "log" {
+		t.Errorf("Expected an unknown level logged as log, got %q", entry.Level)
+	}
+	if len(entry.Message) != maxConsoleMessage+3 || !strings.HasSuffix(entry.Message, "...") {
+		t.Errorf("Expected the message clipped, got %d bytes", len(entry.Message))
+	}
+	if entry.Args != nil {
+		t.Errorf("Expected a single argument left out of args, got %v", entry.Args)
+	}
+}
+
+func TestSummarizeConsole(t *testing.T) {
+	entries := []ConsoleEntry{
+		{Level: "log", Message: "render 1"},
+		{Level: "warn", Message: "slow", Dropped: 7},
+		{Level: "log", Message: "render 2"},
+		{Level: "log", Message: "render 30"},
+		{Level: "warn", Message: "render 4"},
+	}
+	summary := SummarizeConsole(entries)
+	if summary.Count != 5 || summary.Dropped != 7 || summary.ByLevel["log"] != 3 || summary.ByLevel["warn"] != 2 {
+		t.Errorf("Unexpected totals: %+v", summary)
+	}
+	if len(summary.Top) != 3 {
+		t.Fatalf("Expected 3 distinct messages, got %+v", summary.Top)
+	}
+	if top := summary.Top[0]; top.Message != "render 1" || top.Count != 3 {
+		t.Errorf("Expected renders grouped first, got %+v", top)
+	}
+	if summary.Top[1].Message != "slow" || summary.Top[2].Level != "warn" {
+		t.Errorf("Expected ties kept in order of first occurrence, got %+v", summary.Top[1:])
+	}
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+)
+
+// maxCookieRewriteEvents is the number of cookie rewrites kept per proxy.
+const maxCookieRewriteEvents = 20
+
+// Cookie attribute policies for CookieRewrite.Secure and SameSite.
+const (
+	CookiePolicyAuto = "auto"
+	CookiePolicyKeep = "keep"
+)
+
+// CookieRewrite configures how upstream Set-Cookie headers are adapted to the
+// origin the browser uses, so auth flows work through the proxy and tunnels.
+type CookieRewrite struct {
+	// Disabled passes Set-Cookie headers through unchanged.
+	Disabled bool `json:"disabled,omitempty"`
+	// Domains maps upstream cookie domains to the domain set instead; an empty
+	// value removes the attribute. Domains of the upstream host are always
+	// removed so the cookie applies to the proxy host.
+	Domains map[string]string `json:"domains,omitempty"`
+	// Secure is "auto" (default) to drop Secure when the browser uses plain
+	// http and add it to SameSite=None cookies over https, or "keep".
+	Secure string `json:"secure,omitempty"`
+	// SameSite is "auto" (default) to relax SameSite=None to Lax on cookies
+	// that cannot be Secure, "keep", or "lax", "strict" or "none" to force it.
+	SameSite string `json:"same_site,omitempty"`
+}
+
+// Validate reports an invalid policy.
+func (c CookieRewrite) Validate() error {
+	switch strings.ToLower(c.Secure) {
+	case "", CookiePolicyAuto, CookiePolicyKeep:
+	default:
+		return fmt.Errorf("invalid cookie secure policy %q: use auto or keep", c.Secure)
+	}
+	switch strings.ToLower(c.SameSite) {
+	case "", CookiePolicyAuto, CookiePolicyKeep, "lax", "strict", "none":
+	default:
+		return fmt.Errorf("invalid cookie same_site policy %q: use auto, keep, lax, strict or none", c.SameSite)
+	}
+	return nil
+}
+
+// CookieRewriteEvent records the changes made to one Set-Cookie header.
+type CookieRewriteEvent struct {
+	Cookie  string    `json:"cookie"`
+	Host    string    `json:"host,omitempty"` // Host the browser used
+	Changes []string  `json:"changes"`
+	Time    time.Time `json:"time"`
+}
+
+// CookieRewrites returns the number of Set-Cookie headers rewritten and the
+// most recent rewrites, oldest first.
+func (ps *ProxyServer) CookieRewrites() (int64, []CookieRewriteEvent) {
+	ps.cookieEventsMu.Lock()
+	defer ps.cookieEventsMu.Unlock()
+	return ps.cookieRewrites.Load(), append([]CookieRewriteEvent(nil), ps.cookieEvents...)
+}
+
+func (ps *ProxyServer) recordCookieRewrite(ev CookieRewriteEvent) {
+	ps.cookieRewrites.Add(1)
+	ps.cookieEventsMu.Lock()
+	ps.cookieEvents = append(ps.cookieEvents, ev)
+	if len(ps.cookieEvents) > maxCookieRewriteEvents {
+		ps.cookieEvents = ps.cookieEvents[len(ps.cookieEvents)-maxCookieRewriteEvents:]
+	}
+	ps.cookieEventsMu.Unlock()
+	debug.Log("proxy", "proxy %s rewrote cookie %s for %s: %s", ps.ID, ev.Cookie, ev.Host, strings.Join(ev.Changes, "; "))
+}
+
+// secureAccess reports whether the browser reached the proxy at requestHost
+// over https, which is the case for a tunnel's https public URL.
+func (ps *ProxyServer) secureAccess(requestHost string) bool {
+	if ps.PublicURL == "" || requestHost == "" {
+		return false
+	}
+	parsed, err := url.Parse(ps.PublicURL)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	return strings.EqualFold(parsed.Hostname(), hostnameOf(requestHost))
+}
+
+// rewriteCookie applies policy to one Set-Cookie value. upstreamHost is the
+// hostname of the server that set it. Untouched attributes keep their
+// formatting. Returns the cookie and a description of each change.
+func rewriteCookie(cookie, upstreamHost string, policy CookieRewrite, secureAccess bool) (string, []string) {
+	parts := strings.Split(cookie, ";")
+	out := []string{parts[0]}
+	var changes []string
+	keepSecure := strings.ToLower(policy.Secure) == CookiePolicyKeep
+	secure, sameSite, sameSiteIdx := false, "", -1
+
+	for _, part := range parts[1:] {
+		trimmed := strings.TrimSpace(part)
+		lower := strings.ToLower(trimmed)
+		switch {
+		case strings.HasPrefix(lower, "domain="):
+			domain := strings.TrimPrefix(strings.TrimPrefix(lower, "domain="), ".")
+			if mapped, ok := lookupCookieDomain(policy.Domains, domain); ok {
+				if mapped == "" {
+					changes = append(changes, "removed Domain="+domain)
+					continue
+				}
+				changes = append(changes, "Domain="+domain+" -> "+mapped)
+				out = append(out, " Domain="+mapped)
+				continue
+			}
+			// Domain of the upstream: drop it so the cookie applies to the proxy host
+			if strings.Contains(upstreamHost, domain) || strings.Contains(domain, upstreamHost) {
+				changes = append(changes, "removed Domain="+domain)
+				continue
+			}
+		case lower == "secure":
+			if !keepSecure && !secureAccess {
+				// Browsers reject Secure cookies set over http on non-localhost hosts
+				changes = append(changes, "removed Secure for http access")
+				continue
+			}
+			secure = true
+		case strings.HasPrefix(lower, "samesite="):
+			sameSite = strings.TrimPrefix(lower, "samesite=")
+			sameSiteIdx = len(out)
+		}
+		out = append(out, part)
+	}
+
+	sameSitePolicy := strings.ToLower(policy.SameSite)
+	want := sameSite
+	switch sameSitePolicy {
+	case "", CookiePolicyAuto:
+		if sameSite == "none" && !secure {
+			if secureAccess && !keepSecure {
+				out = append(out, " Secure")
+				changes = append(changes, "added Secure required by SameSite=None")
+			} else {
+				// SameSite=None without Secure is rejected outright
+				want = "lax"
+			}
+		}
+	case CookiePolicyKeep:
+	default:
+		want = sameSitePolicy
+	}
+	if want != sameSite {
+		attr := " SameSite=" + strings.ToUpper(want[:1]) + want[1:]
+		if sameSiteIdx >= 0 {
+			out[sameSiteIdx] = attr
+		} else {
+			out = append(out, attr)
+		}
+		from := sameSite
+		if from == "" {
+			from = "unset"
+		}
+		changes = append(changes, "SameSite "+from+" -> "+want)
+	}
+
+	if len(changes) == 0 {
+		return cookie, nil
+	}
+	return strings.Join(out, ";"), changes
+}
+
+// lookupCookieDomain finds domain in a configured mapping, ignoring case and
+// leading dots.
+func lookupCookieDomain(domains map[string]string, domain string) (string, bool) {
+	for from, to := range domains {
+		if strings.EqualFold(strings.TrimPrefix(from, "."), domain) {
+			return to, true
+		}
+	}
+	return "", false
+}
+
+// cookieName returns the name of a Set-Cookie value.
+func cookieName(cookie string) string {
+	name, _, _ := strings.Cut(cookie, "=")
+	return strings.TrimSpace(name)
+}
+
+// rewriteSetCookieHeaders adapts Set-Cookie headers to the origin the browser
+// used, recording each change.
+func (ps *ProxyServer) rewriteSetCookieHeaders(resp *http.Response) {
+	cookies := resp.Header["Set-Cookie"]
+	if len(cookies) == 0 || ps.cookiePolicy.Disabled {
+		return
+	}
+
+	upstreamHost := ps.Target().Hostname()
+	requestHost := ""
+	secure := false
+	if resp.Request != nil {
+		if resp.Request.URL != nil && resp.Request.URL.Host != "" {
+			upstreamHost = resp.Request.URL.Hostname()
+		}
+		requestHost = resp.Request.Header.Get("X-Forwarded-Host")
+		secure = resp.Request.Header.Get("X-Forwarded-Proto") == "https"
+	}
+	secure = secure || ps.secureAccess(requestHost)
+
+	for i, cookie := range cookies {
+		rewritten, changes := rewriteCookie(cookie, upstreamHost, ps.cookiePolicy, secure)
+		if len(changes) == 0 {
+			continue
+		}
+		cookies[i] = rewritten
+		ps.recordCookieRewrite(CookieRewriteEvent{
+			Cookie:  cookieName(cookie),
+			Host:    requestHost,
+			Changes: changes,
+			Time:    time.Now(),
+		})
+	}
+	resp.Header["Set-Cookie"] = cookies
+}
+
+// rewriteCookieDomain removes the Domain attribute of a Set-Cookie header
+// when it names targetHost, leaving other attributes alone.
+func (ps *ProxyServer) rewriteCookieDomain(cookie string, targetHost string) string {
+	policy := CookieRewrite{Domains: ps.cookiePolicy.Domains, Secure: CookiePolicyKeep, SameSite: CookiePolicyKeep}
+	rewritten, _ := rewriteCookie(cookie, targetHost, policy, false)
+	return rewritten
+}
@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRewriteCookie(t *testing.T) {
+	tests := []struct {
+		name     string
+		cookie   string
+		policy   CookieRewrite
+		secure   bool
+		expected string
+		changes  int
+	}{
+		{
+			name:     "secure dropped for http access",
+			cookie:   "sid=1; Path=/; Secure; HttpOnly",
+			expected: "sid=1; Path=/; HttpOnly",
+			changes:  1,
+		},
+		{
+			name:     "samesite none relaxed when secure is dropped",
+			cookie:   "sid=1; Secure; SameSite=None",
+			expected: "sid=1; SameSite=Lax",
+			changes:  2,
+		},
+		{
+			name:     "secure added to samesite none over https",
+			cookie:   "sid=1; SameSite=None",
+			secure:   true,
+			expected: "sid=1; SameSite=None; Secure",
+			changes:  1,
+		},
+		{
+			name:     "secure kept over https",
+			cookie:   "sid=1; Secure; SameSite=None",
+			secure:   true,
+			expected: "sid=1; Secure; SameSite=None",
+		},
+		{
+			name:     "keep policy leaves attributes alone",
+			cookie:   "sid=1; Secure; SameSite=None",
+			policy:   CookieRewrite{Secure: "keep", SameSite: "keep"},
+			expected: "sid=1; Secure; SameSite=None",
+		},
+		{
+			name:     "forced samesite",
+			cookie:   "sid=1; Path=/",
+			policy:   CookieRewrite{SameSite: "strict"},
+			expected: "sid=1; Path=/; SameSite=Strict",
+			changes:  1,
+		},
+		{
+			name:     "mapped domain",
+			cookie:   "sid=1; Domain=.auth.example.test; Path=/",
+			policy:   CookieRewrite{Domains: map[string]string{".auth.example.test": "localhost"}},
+			expected: "sid=1; Domain=localhost; Path=/",
+			changes:  1,
+		},
+		{
+			name:     "mapped domain removed",
+			cookie:   "sid=1; Domain=example.test; Path=/",
+			policy:   CookieRewrite{Domains: map[string]string{"example.test": ""}},
+			expected: "sid=1; Path=/",
+			changes:  1,
+		},
+		{
+			name:     "upstream domain removed",
+			cookie:   "sid=1; Domain=app.local",
+			expected: "sid=1",
+			changes:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, changes := rewriteCookie(tt.cookie, "app.local", tt.policy, tt.secure)
+			if result != tt.expected {
+				t.Errorf("rewriteCookie(%q) = %q, want %q", tt.cookie, result, tt.expected)
+			}
+			if len(changes) != tt.changes {
+				t.Errorf("Expected %d changes, got %v", tt.changes, changes)
+			}
+		})
+	}
+}
+
+func TestCookieRewriteValidate(t *testing.T) {
+	if err := (CookieRewrite{Secure: "auto", SameSite: "Lax"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := (CookieRewrite{Secure: "strip"}).Validate(); err == nil {
+		t.Error("Expected error for unknown secure policy")
+	}
+	if err := (CookieRewrite{SameSite: "sometimes"}).Validate(); err == nil {
+		t.Error("Expected error for unknown same_site policy")
+	}
+}
+
+func TestRewriteSetCookieHeaders_Tunnel(t *testing.T) {
+	ps := newTestProxyServer("http://localhost:3000", ":8080")
+	ps.PublicURL = "https://abc.trycloudflare.com"
+
+	newResp := func(host string) *http.Response {
+		upstream, _ := url.Parse("http://localhost:3000/login")
+		resp := &http.Response{
+			Header:  make(http.Header),
+			Request: &http.Request{URL: upstream, Header: make(http.Header)},
+		}
+		resp.Request.Header.Set("X-Forwarded-Host", host)
+		resp.Header.Add("Set-Cookie", "sid=1; Secure; SameSite=None")
+		return resp
+	}
+
+	resp := newResp("abc.trycloudflare.com")
+	ps.rewriteSetCookieHeaders(resp)
+	if got := resp.Header.Get("Set-Cookie"); got != "sid=1; Secure; SameSite=None" {
+		t.Errorf("Expected cookie unchanged through https tunnel, got %q", got)
+	}
+
+	resp = newResp("localhost:8080")
+	ps.rewriteSetCookieHeaders(resp)
+	if got := resp.Header.Get("Set-Cookie"); got != "sid=1; SameSite=Lax" {
+		t.Errorf("Expected cookie adapted for http access, got %q", got)
+	}
+
+	count, events := ps.CookieRewrites()
+	if count != 1 || len(events) != 1 || events[0].Cookie != "sid" || events[0].Host != "localhost:8080" {
+		t.Errorf("Unexpected cookie rewrite log %d %+v", count, events)
+	}
+	if !strings.Contains(strings.Join(events[0].Changes, ","), "Secure") {
+		t.Errorf("Expected Secure change to be logged, got %v", events[0].Changes)
+	}
+}
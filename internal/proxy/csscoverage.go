@@ -0,0 +1,173 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// CSSCoverageTimeout bounds the wait for the page to check its rules.
+	CSSCoverageTimeout = 15 * time.Second
+	// defaultCSSCoverageLimit is how many unused rules a file lists by default.
+	defaultCSSCoverageLimit = 20
+	// maxCSSCoverageLimit caps the unused rules a file lists.
+	maxCSSCoverageLimit = 200
+)
+
+// CSSCoverageOptions configures CSSCoverage.
+type CSSCoverageOptions struct {
+	Limit int `json:"limit,omitempty"` // Unused rules listed per file (default 20, max 200)
+}
+
+// CSSCoverageReport lists the style rules of a page that matched no element
+// on any page of its browser tab, by stylesheet.
+type CSSCoverageReport struct {
+	SessionID     string                 `json:"session_id,omitempty"`
+	URL           string                 `json:"url"`
+	Pages         []string               `json:"pages"`   // Paths of the tab's pages whose DOM was checked
+	Samples       int                    `json:"samples"` // Times the DOM was checked
+	Rules         int                    `json:"rules"`
+	UsedRules     int                    `json:"used_rules"`
+	UnusedRules   int                    `json:"unused_rules"`
+	Bytes         int                    `json:"bytes"`
+	UnusedBytes   int                    `json:"unused_bytes"`
+	UnusedPercent float64                `json:"unused_percent"`        // Of bytes
+	OtherRules    int                    `json:"other_rules,omitempty"` // @font-face, @keyframes and the like, not checked
+	Truncated     bool                   `json:"truncated,omitempty"`   // Rules beyond 20000 were not checked
+	Files         []CSSFileCoverage      `json:"files"`                 // Most unused bytes first
+	Inaccessible  []CSSInaccessibleSheet `json:"inaccessible,omitempty"`
+	Timestamp     time.Time              `json:"timestamp"`
+}
+
+// CSSFileCoverage is the coverage of one stylesheet.
+type CSSFileCoverage struct {
+	File          string          `json:"file"` // URL, or "<style> N on <path>"
+	Inline        bool            `json:"inline,omitempty"`
+	Rules         int             `json:"rules"`
+	UsedRules     int             `json:"used_rules"`
+	UnusedRules   int             `json:"unused_rules"`
+	Bytes         int             `json:"bytes"`
+	UnusedBytes   int             `json:"unused_bytes"`
+	UnusedPercent float64         `json:"unused_percent"`
+	Unused        []CSSUnusedRule `json:"unused,omitempty"` // Largest first
+}
+
+// CSSUnusedRule is a style rule whose selectors matched nothing.
+type CSSUnusedRule struct {
+	Selector string `json:"selector"`
+	Media    string `json:"media,omitempty"` // Enclosing @media, @supports or @container conditions
+	Bytes    int    `json:"bytes"`
+}
+
+// CSSInaccessibleSheet is a stylesheet the page can't read.
+type CSSInaccessibleSheet struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// cssCoverageResult is the raw result of __devtool.cssCoverage.
+type cssCoverageResult struct {
+	Pages      []string `json:"pages"`
+	Samples    int      `json:"samples"`
+	OtherRules int      `json:"otherRules"`
+	Truncated  bool     `json:"truncated"`
+	Sheets     []struct {
+		File        string          `json:"file"`
+		Inline      bool            `json:"inline"`
+		Rules       int             `json:"rules"`
+		UsedRules   int             `json:"usedRules"`
+		UnusedRules int             `json:"unusedRules"`
+		Bytes       int             `json:"bytes"`
+		UnusedBytes int             `json:"unusedBytes"`
+		Unused      []CSSUnusedRule `json:"unused"`
+	} `json:"sheets"`
+	Inaccessible []CSSInaccessibleSheet `json:"inaccessible"`
+}
+
+// CSSCoverage reports which style rules of a page matched no element while
+// its browser tab's session lasted. The injected script checks the rules
+// against the DOM at load and after DOM changes, and remembers matches
+// across the tab's pages. A rule counts as used when the element it styles
+// exists, whatever its @media condition or :hover-like state, so styles for
+// other viewports and interactions aren't reported unused.
+func (ps *ProxyServer) CSSCoverage(ctx context.Context, sessionID string, opts CSSCoverageOptions) (CSSCoverageReport, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultCSSCoverageLimit
+	}
+	limit = min(limit, maxCSSCoverageLimit)
+
+	code := fmt.Sprintf("window.__devtool.cssCoverage({limit: %d})", limit)
+	raw, url, err := ps.evalInPage(ctx, sessionID, code, CSSCoverageTimeout)
+	if err != nil {
+		return CSSCoverageReport{}, fmt.Errorf("css coverage failed: %w", err)
+	}
+	report, err := parseCSSCoverage(raw, limit)
+	if err != nil {
+		return CSSCoverageReport{}, err
+	}
+	report.SessionID = sessionID
+	report.URL = url
+	report.Timestamp = time.Now()
+	return report, nil
+}
+
+// parseCSSCoverage totals the raw coverage and orders files by unused bytes
+// and their unused rules by size.
+func parseCSSCoverage(raw []byte, limit int) (CSSCoverageReport, error) {
+	var result cssCoverageResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return CSSCoverageReport{}, fmt.Errorf("invalid coverage result from the page: %w", err)
+	}
+
+	report := CSSCoverageReport{
+		Pages:        result.Pages,
+		Samples:      result.Samples,
+		OtherRules:   result.OtherRules,
+		Truncated:    result.Truncated,
+		Files:        []CSSFileCoverage{},
+		Inaccessible: result.Inaccessible,
+	}
+	if report.Pages == nil {
+		report.Pages = []string{}
+	}
+	for _, sheet := range result.Sheets {
+		file := CSSFileCoverage{
+			File:          sheet.File,
+			Inline:        sheet.Inline,
+			Rules:         sheet.Rules,
+			UsedRules:     sheet.UsedRules,
+			UnusedRules:   sheet.UnusedRules,
+			Bytes:         sheet.Bytes,
+			UnusedBytes:   sheet.UnusedBytes,
+			UnusedPercent: percentOf(sheet.UnusedBytes, sheet.Bytes),
+			Unused:        sheet.Unused,
+		}
+		sort.SliceStable(file.Unused, func(i, j int) bool { return file.Unused[i].Bytes > file.Unused[j].Bytes })
+		if len(file.Unused) > limit {
+			file.Unused = file.Unused[:limit]
+		}
+		report.Files = append(report.Files, file)
+
+		report.Rules += sheet.Rules
+		report.UsedRules += sheet.UsedRules
+		report.UnusedRules += sheet.UnusedRules
+		report.Bytes += sheet.Bytes
+		report.UnusedBytes += sheet.UnusedBytes
+	}
+	report.UnusedPercent = percentOf(report.UnusedBytes, report.Bytes)
+	sort.SliceStable(report.Files, func(i, j int) bool { return report.Files[i].UnusedBytes > report.Files[j].UnusedBytes })
+	return report, nil
+}
+
+// percentOf returns part as a percentage of total, to one decimal.
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import "testing"
+
+func TestParseCSSCoverage(t *testing.T) {
+	raw := []byte(`{
+		"pages": ["/", "/cart"],
+		"samples": 4,
+		"otherRules": 3,
+		"sheets": [
+			{"file": "http://localhost/app.css", "rules": 10, "usedRules": 9, "unusedRules": 1, "bytes": 1000, "unusedBytes": 50,
+			 "unused": [{"selector": ".old", "bytes": 50}]},
+			{"file": "<style> 1 on /", "inline": true, "rules": 4, "usedRules": 1, "unusedRules": 3, "bytes": 400, "unusedBytes": 300,
+			 "unused": [{"selector": ".a", "bytes": 20}, {"selector": ".b", "media": "(max-width: 600px)", "bytes": 200}, {"selector": ".c", "bytes": 80}]}
+		],
+		"inaccessible": [{"file": "https://cdn.example.com/x.css", "reason": "cross-origin stylesheet without CORS"}]
+	}`)
+
+	report, err := parseCSSCoverage(raw, 2)
+	if err != nil {
+		t.Fatalf("parseCSSCoverage failed: %v", err)
+	}
+	if report.Rules != 14 || report.UsedRules != 10 || report.UnusedRules != 4 || report.Bytes != 1400 || report.UnusedBytes != 350 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if report.UnusedPercent != 25 || report.Samples != 4 || len(report.Pages) != 2 || report.OtherRules != 3 {
+		t.Errorf("Unexpected summary: %+v", report)
+	}
+	if len(report.Files) != 2 || !report.Files[0].Inline || report.Files[0].UnusedPercent != 75 {
+		t.Fatalf("Expected the inline sheet with most unused bytes first, got %+v", report.Files)
+	}
+	unused := report.Files[0].Unused
+	if len(unused) != 2 || unused[0].Selector != ".b" || unused[0].Media != "(max-width: 600px)" || unused[1].Selector != ".c" {
+		t.Errorf("Expected the 2 largest unused rules, got %+v", unused)
+	}
+	if report.Files[1].UnusedPercent != 5 {
+		t.Errorf("Expected 5%% unused, got %v", report.Files[1].UnusedPercent)
+	}
+	if len(report.Inaccessible) != 1 {
+		t.Errorf("Expected the cross-origin sheet reported, got %+v", report.Inaccessible)
+	}
+
+	report, _ = parseCSSCoverage([]byte(`{"sheets": []}`), 20)
+	if report.UnusedPercent != 0 || report.Files == nil || report.Pages == nil {
+		t.Errorf("Unexpected empty report: %+v", report)
+	}
+}
@@ -0,0 +1,441 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DOM snapshot limits.
+const (
+	// maxDOMSnapshots is how many snapshots a proxy keeps; the oldest go first.
+	maxDOMSnapshots = 20
+	// maxDOMDiffChanges bounds each list of a DOMDiff.
+	maxDOMDiffChanges = 200
+	// DOMSnapshotTimeout bounds the wait for the page to answer. It stays below
+	// the daemon client's request timeout.
+	DOMSnapshotTimeout = 20 * time.Second
+	// maxDOMText is how much of an element's text a change reports.
+	maxDOMText = 200
+	// maxDOMAlignCells bounds the alignment table of two child lists; longer
+	// lists are compared position by position.
+	maxDOMAlignCells = 1 << 20
+)
+
+// ignoredDOMTags are left out of snapshots, matching what the mutation
+// tracker ignores: they change without changing what the page shows.
+var ignoredDOMTags = map[string]bool{"script": true, "style": true, "link": true}
+
+// DOMSnapshotOptions configures CaptureDOMSnapshot.
+type DOMSnapshotOptions struct {
+	Name     string `json:"name,omitempty"`     // Label shown when listing snapshots
+	Selector string `json:"selector,omitempty"` // Root element (default: body)
+	Styles   bool   `json:"styles,omitempty"`   // Include key computed styles of every element
+}
+
+// DOMNode is a serialized element, as __devtool.diagnostics.captureDOMSnapshot
+// returns it.
+type DOMNode struct {
+	Tag      string            `json:"tag"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Text     string            `json:"text,omitempty"` // Own text, not that of children
+	Styles   map[string]string `json:"styles,omitempty"`
+	Children []*DOMNode        `json:"children,omitempty"`
+}
+
+// DOMSnapshot is a serialized DOM captured from a page.
+type DOMSnapshot struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	URL       string    `json:"url"`
+	Selector  string    `json:"selector"`
+	Styles    bool      `json:"styles,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Elements  int       `json:"elements"`
+	Root      *DOMNode  `json:"root,omitempty"` // Left out of listings
+}
+
+// summary returns the snapshot without its tree.
+func (s *DOMSnapshot) summary() DOMSnapshot {
+	out := *s
+	out.Root = nil
+	return out
+}
+
+// DOMDiff reports how the elements of two snapshots differ. An added or
+// removed subtree is reported once, at its root.
+type DOMDiff struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Added     []DOMChange `json:"added"`
+	Removed   []DOMChange `json:"removed"`
+	Changed   []DOMChange `json:"changed"`
+	Unchanged int         `json:"unchanged"`
+	Truncated bool        `json:"truncated,omitempty"` // A list hit maxDOMDiffChanges
+}
+
+// DOMChange is one added, removed or changed element.
+type DOMChange struct {
+	Path     string           `json:"path"`               // e.g. "body > div#app > ul[2] > li[3]"
+	Text     string           `json:"text,omitempty"`     // Own text of an added or removed element
+	Elements int              `json:"elements,omitempty"` // Size of an added or removed subtree
+	Fields   []DOMFieldChange `json:"fields,omitempty"`   // What changed on a changed element
+}
+
+// DOMFieldChange is one changed text, attribute or style of an element.
+type DOMFieldChange struct {
+	Field string `json:"field"` // "text", "attr:<name>" or "style:<name>"
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// domSnapshotStore keeps a proxy's most recent DOM snapshots.
+type domSnapshotStore struct {
+	mu        sync.Mutex
+	snapshots []*DOMSnapshot
+	seq       int
+}
+
+// CaptureDOMSnapshot serializes the DOM of a page and keeps the snapshot. An
+// empty sessionID captures whichever connected page answers first.
+func (ps *ProxyServer) CaptureDOMSnapshot(ctx context.Context, sessionID string, opts DOMSnapshotOptions) (DOMSnapshot, error) {
+	if opts.Selector == "" {
+		opts.Selector = "body"
+	}
+
+	raw, url, err := ps.evalInPage(ctx, sessionID, domSnapshotScript(opts), DOMSnapshotTimeout)
+	if err != nil {
+		return DOMSnapshot{}, fmt.Errorf("snapshot failed: %w", err)
+	}
+	snapshot, err := parseDOMSnapshot(raw)
+	if err != nil {
+		return DOMSnapshot{}, err
+	}
+	snapshot.Name = opts.Name
+	snapshot.SessionID = sessionID
+	snapshot.Selector = opts.Selector
+	snapshot.Styles = opts.Styles
+	if snapshot.URL == "" {
+		snapshot.URL = url
+	}
+	return ps.domSnapshots.add(snapshot), nil
+}
+
+// domSnapshotScript returns the code that serializes the DOM under the
+// selector with the injected diagnostics module.
+func domSnapshotScript(opts DOMSnapshotOptions) string {
+	selector, _ := json.Marshal(opts.Selector)
+	return fmt.Sprintf(`(function() {
+  var root = document.querySelector(%s);
+  if (!root) throw new Error('No element matches ' + %s);
+  return window.__devtool.diagnostics.captureDOMSnapshot({root: root, includeStyles: %t});
+})()`, selector, selector, opts.Styles)
+}
+
+// parseDOMSnapshot decodes the result of captureDOMSnapshot, leaving out the
+// overlay's own elements and ignored tags.
+func parseDOMSnapshot(raw []byte) (*DOMSnapshot, error) {
+	var result struct {
+		URL   string   `json:"url"`
+		Root  *DOMNode `json:"root"`
+		Error string   `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid snapshot from the page: %w", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("snapshot failed in the page: %s", result.Error)
+	}
+	if result.Root == nil {
+		return nil, fmt.Errorf("page returned an empty snapshot")
+	}
+	pruneDOMNode(result.Root)
+	return &DOMSnapshot{URL: result.URL, Root: result.Root, Elements: result.Root.count()}, nil
+}
+
+// pruneDOMNode drops ignored and overlay elements below node.
+func pruneDOMNode(node *DOMNode) {
+	kept := node.Children[:0]
+	for _, child := range node.Children {
+		if child == nil || ignoredDOMTags[child.Tag] || child.isOverlay() {
+			continue
+		}
+		pruneDOMNode(child)
+		kept = append(kept, child)
+	}
+	node.Children = kept
+}
+
+// isOverlay reports whether the element belongs to the injected overlay.
+func (n *DOMNode) isOverlay() bool {
+	return strings.HasPrefix(n.Attrs["id"], "__devtool") || strings.Contains(n.Attrs["class"], "__devtool")
+}
+
+// count returns the number of elements in the subtree.
+func (n *DOMNode) count() int {
+	total := 1
+	for _, child := range n.Children {
+		total += child.count()
+	}
+	return total
+}
+
+// add assigns the snapshot an ID and keeps it, dropping the oldest beyond
+// maxDOMSnapshots.
+func (s *domSnapshotStore) add(snapshot *DOMSnapshot) DOMSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	snapshot.ID = fmt.Sprintf("dom-%d", s.seq)
+	snapshot.Timestamp = time.Now()
+	s.snapshots = append(s.snapshots, snapshot)
+	if len(s.snapshots) > maxDOMSnapshots {
+		s.snapshots = append([]*DOMSnapshot(nil), s.snapshots[len(s.snapshots)-maxDOMSnapshots:]...)
+	}
+	return snapshot.summary()
+}
+
+// get returns a kept snapshot.
+func (s *domSnapshotStore) get(id string) (*DOMSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, snapshot := range s.snapshots {
+		if snapshot.ID == id {
+			return snapshot, true
+		}
+	}
+	return nil, false
+}
+
+// DOMSnapshots lists the kept snapshots, oldest first, without their trees.
+func (ps *ProxyServer) DOMSnapshots() []DOMSnapshot {
+	ps.domSnapshots.mu.Lock()
+	defer ps.domSnapshots.mu.Unlock()
+	out := make([]DOMSnapshot, 0, len(ps.domSnapshots.snapshots))
+	for _, snapshot := range ps.domSnapshots.snapshots {
+		out = append(out, snapshot.summary())
+	}
+	return out
+}
+
+// DiffDOMSnapshots compares two kept snapshots.
+func (ps *ProxyServer) DiffDOMSnapshots(fromID, toID string) (*DOMDiff, error) {
+	from, ok := ps.domSnapshots.get(fromID)
+	if !ok {
+		return nil, fmt.Errorf("snapshot not found: %s", fromID)
+	}
+	to, ok := ps.domSnapshots.get(toID)
+	if !ok {
+		return nil, fmt.Errorf("snapshot not found: %s", toID)
+	}
+	return DiffDOM(from, to), nil
+}
+
+// DiffDOM compares two snapshots. Children are aligned by tag and id, so an
+// element inserted into a list is reported once instead of as a change to
+// every sibling after it.
+func DiffDOM(from, to *DOMSnapshot) *DOMDiff {
+	diff := &DOMDiff{From: from.ID, To: to.ID, Added: []DOMChange{}, Removed: []DOMChange{}, Changed: []DOMChange{}}
+	rootPath := from.Root.Tag
+	if from.Root.Tag != to.Root.Tag {
+		diff.add(&diff.Removed, domChangeOf(rootPath, from.Root))
+		diff.add(&diff.Added, domChangeOf(to.Root.Tag, to.Root))
+		return diff
+	}
+	diff.compare(rootPath, from.Root, to.Root)
+	return diff
+}
+
+// compare diffs two elements at the same path and recurses into their
+// aligned children.
+func (d *DOMDiff) compare(path string, a, b *DOMNode) {
+	if fields := domFieldChanges(a, b); len(fields) > 0 {
+		d.add(&d.Changed, DOMChange{Path: path, Fields: fields})
+	} else {
+		d.Unchanged++
+	}
+
+	aPaths, bPaths := domChildPaths(path, a.Children), domChildPaths(path, b.Children)
+	i, j := 0, 0
+	for _, pair := range alignDOMChildren(a.Children, b.Children) {
+		for ; i < pair[0]; i++ {
+			d.add(&d.Removed, domChangeOf(aPaths[i], a.Children[i]))
+		}
+		for ; j < pair[1]; j++ {
+			d.add(&d.Added, domChangeOf(bPaths[j], b.Children[j]))
+		}
+		d.compare(bPaths[j], a.Children[i], b.Children[j])
+		i, j = i+1, j+1
+	}
+	for ; i < len(a.Children); i++ {
+		d.add(&d.Removed, domChangeOf(aPaths[i], a.Children[i]))
+	}
+	for ; j < len(b.Children); j++ {
+		d.add(&d.Added, domChangeOf(bPaths[j], b.Children[j]))
+	}
+}
+
+// add appends a change unless the list is full.
+func (d *DOMDiff) add(list *[]DOMChange, change DOMChange) {
+	if len(*list) >= maxDOMDiffChanges {
+		d.Truncated = true
+		return
+	}
+	*list = append(*list, change)
+}
+
+// domChangeOf describes an added or removed subtree.
+func domChangeOf(path string, n *DOMNode) DOMChange {
+	return DOMChange{Path: path, Text: clipDOMText(n.Text), Elements: n.count()}
+}
+
+// domFieldChanges lists the text, attribute and style differences of two
+// elements, in a stable order.
+func domFieldChanges(a, b *DOMNode) []DOMFieldChange {
+	var fields []DOMFieldChange
+	if a.Text != b.Text {
+		fields = append(fields, DOMFieldChange{Field: "text", From: clipDOMText(a.Text), To: clipDOMText(b.Text)})
+	}
+	fields = append(fields, domMapChanges("attr:", a.Attrs, b.Attrs)...)
+	fields = append(fields, domMapChanges("style:", a.Styles, b.Styles)...)
+	return fields
+}
+
+// clipDOMText shortens text for a change report.
+func clipDOMText(s string) string {
+	if len(s) <= maxDOMText {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxDOMText], "") + "..."
+}
+
+// domMapChanges lists the differing keys of two attribute or style maps.
+func domMapChanges(prefix string, a, b map[string]string) []DOMFieldChange {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var fields []DOMFieldChange
+	for _, k := range keys {
+		av, aok := a[k]
+		bv, bok := b[k]
+		if av != bv || aok != bok {
+			fields = append(fields, DOMFieldChange{Field: prefix + k, From: av, To: bv})
+		}
+	}
+	return fields
+}
+
+// domKey identifies an element among its siblings: its tag, and its id when
+// it has one.
+func (n *DOMNode) domKey() string {
+	if id := n.Attrs["id"]; id != "" {
+		return n.Tag + "#" + id
+	}
+	return n.Tag
+}
+
+// contentKey identifies an element by what it shows; siblings with equal
+// content keys are taken to be the same element.
+func (n *DOMNode) contentKey() string {
+	return n.domKey() + "\x00" + n.Attrs["class"] + "\x00" + n.Text
+}
+
+// domChildPaths returns the paths of children under parent: "tag#id" when an
+// element has an id, otherwise "tag[n]" counting siblings of the same tag.
+func domChildPaths(parent string, children []*DOMNode) []string {
+	paths := make([]string, len(children))
+	seen := make(map[string]int)
+	for i, child := range children {
+		segment := child.domKey()
+		if child.Attrs["id"] == "" {
+			seen[child.Tag]++
+			segment = fmt.Sprintf("%s[%d]", child.Tag, seen[child.Tag])
+		}
+		paths[i] = parent + " > " + segment
+	}
+	return paths
+}
+
+// alignDOMChildren pairs the children of two elements. Children with the
+// same content are paired first, so an insertion into a list of items does
+// not shift the rest; the rest are paired by tag and id within the gaps
+// between those. Each pair holds an index into a and one into b, both
+// increasing.
+func alignDOMChildren(a, b []*DOMNode) [][2]int {
+	var pairs [][2]int
+	ai, bi := 0, 0
+	gap := func(aEnd, bEnd int) {
+		for _, p := range lcsPairs(a[ai:aEnd], b[bi:bEnd], (*DOMNode).domKey) {
+			pairs = append(pairs, [2]int{ai + p[0], bi + p[1]})
+		}
+	}
+	for _, anchor := range lcsPairs(a, b, (*DOMNode).contentKey) {
+		gap(anchor[0], anchor[1])
+		pairs = append(pairs, anchor)
+		ai, bi = anchor[0]+1, anchor[1]+1
+	}
+	gap(len(a), len(b))
+	return pairs
+}
+
+// lcsPairs pairs a and b along the longest common subsequence of their keys.
+// Lists too long to align are compared position by position.
+func lcsPairs(a, b []*DOMNode, key func(*DOMNode) string) [][2]int {
+	var pairs [][2]int
+	ak, bk := make([]string, len(a)), make([]string, len(b))
+	for i, n := range a {
+		ak[i] = key(n)
+	}
+	for j, n := range b {
+		bk[j] = key(n)
+	}
+	if (len(a)+1)*(len(b)+1) > maxDOMAlignCells {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if ak[i] == bk[i] {
+				pairs = append(pairs, [2]int{i, i})
+			}
+		}
+		return pairs
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if ak[i] == bk[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case ak[i] == bk[j]:
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// el builds a DOMNode for tests: "li#x.done" gives tag, id and class.
+func el(spec, text string, children ...*DOMNode) *DOMNode {
+	n := &DOMNode{Attrs: map[string]string{}, Text: text, Children: children}
+	if i := strings.Index(spec, "."); i >= 0 {
+		n.Attrs["class"] = spec[i+1:]
+		spec = spec[:i]
+	}
+	if i := strings.Index(spec, "#"); i >= 0 {
+		n.Attrs["id"] = spec[i+1:]
+		spec = spec[:i]
+	}
+	n.Tag = spec
+	return n
+}
+
+func TestParseDOMSnapshot(t *testing.T) {
+	raw := `{"url":"http://localhost:3000/","root":{"tag":"body","attrs":{},"text":"","children":[
+		{"tag":"div","attrs":{"id":"app"},"text":"Hi","children":[{"tag":"script","attrs":{},"text":"","children":[]}]},
+		{"tag":"div","attrs":{"id":"__devtool-overlays"},"text":"","children":[]},
+		{"tag":"span","attrs":{"class":"__devtool-badge"},"text":"","children":[]}
+	]}}`
+	snapshot, err := parseDOMSnapshot([]byte(raw))
+	if err != nil {
+		t.Fatalf("parseDOMSnapshot failed: %v", err)
+	}
+	if snapshot.Elements != 2 || len(snapshot.Root.Children) != 1 || len(snapshot.Root.Children[0].Children) != 0 {
+		t.Errorf("Expected scripts and overlay elements left out, got %d elements: %+v", snapshot.Elements, snapshot.Root.Children)
+	}
+	if snapshot.URL != "http://localhost:3000/" {
+		t.Errorf("Unexpected URL %q", snapshot.URL)
+	}
+
+	if _, err := parseDOMSnapshot([]byte(`{"error":"Diagnostics not loaded"}`)); err == nil {
+		t.Error("Expected the page's error reported")
+	}
+}
+
+func TestDiffDOM(t *testing.T) {
+	from := &DOMSnapshot{ID: "dom-1", Root: el("body", "",
+		el("h1", "Orders"),
+		el("ul",
+			"",
+			el("li", "one"),
+			el("li", "two"),
+			el("li", "three"),
+		),
+		el("div#footer", "v1"),
+		el("aside", "", el("p", "tip")),
+	)}
+	to := &DOMSnapshot{ID: "dom-2", Root: el("body", "",
+		el("h1", "Orders"),
+		el("ul",
+			"",
+			el("li", "zero"), // Inserted at the top
+			el("li", "one"),
+			el("li.done", "two"),
+			el("li", "three"),
+		),
+		el("div#footer", "v2"),
+	)}
+	to.Root.Children[0].Styles = map[string]string{"color": "red"}
+
+	diff := DiffDOM(from, to)
+	paths := func(changes []DOMChange) []string {
+		var out []string
+		for _, c := range changes {
+			out = append(out, c.Path)
+		}
+		return out
+	}
+	if got := paths(diff.Added); len(got) != 1 || got[0] != "body > ul[1] > li[1]" || diff.Added[0].Text != "zero" {
+		t.Errorf("Expected only the inserted item added, got %v", got)
+	}
+	if got := paths(diff.Removed); len(got) != 1 || got[0] != "body > aside[1]" || diff.Removed[0].Elements != 2 {
+		t.Errorf("Expected the aside removed as one subtree, got %+v", diff.Removed)
+	}
+
+	changed := make(map[string]string)
+	for _, c := range diff.Changed {
+		var fields []string
+		for _, f := range c.Fields {
+			fields = append(fields, fmt.Sprintf("%s=%s->%s", f.Field, f.From, f.To))
+		}
+		changed[c.Path] = strings.Join(fields, ",")
+	}
+	want := map[string]string{
+		"body > h1[1]":         "style:color=->red",
+		"body > ul[1] > li[3]": "attr:class=->done",
+		"body > div#footer":    "text=v1->v2",
+	}
+	if len(changed) != len(want) {
+		t.Errorf("Expected %d changed elements, got %v", len(want), changed)
+	}
+	for path, fields := range want {
+		if changed[path] != fields {
+			t.Errorf("%s: expected %s, got %q", path, fields, changed[path])
+		}
+	}
+	if diff.Unchanged != 4 {
+		t.Errorf("Expected body, ul and two items unchanged, got %d", diff.Unchanged)
+	}
+}
+
+func TestDiffDOMTruncated(t *testing.T) {
+	from := &DOMSnapshot{Root: el("body", "")}
+	to := &DOMSnapshot{Root: el("body", "")}
+	for i := 0; i < maxDOMDiffChanges+10; i++ {
+		to.Root.Children = append(to.Root.Children, el("p", ""))
+	}
+	diff := DiffDOM(from, to)
+	if len(diff.Added) != maxDOMDiffChanges || !diff.Truncated {
+		t.Errorf("Expected %d additions and truncated, got %d (%v)", maxDOMDiffChanges, len(diff.Added), diff.Truncated)
+	}
+}
+
+func TestDOMSnapshotStore(t *testing.T) {
+	ps := &ProxyServer{}
+	for i := 0; i < maxDOMSnapshots+2; i++ {
+		ps.domSnapshots.add(&DOMSnapshot{Root: el("body", fmt.Sprint(i))})
+	}
+	list := ps.DOMSnapshots()
+	if len(list) != maxDOMSnapshots || list[0].ID != "dom-3" || list[0].Root != nil {
+		t.Errorf("Expected the oldest snapshots dropped and trees left out, got %d starting at %s", len(list), list[0].ID)
+	}
+	if _, err := ps.DiffDOMSnapshots("dom-1", "dom-3"); err == nil {
+		t.Error("Expected a dropped snapshot to be missing")
+	}
+	if diff, err := ps.DiffDOMSnapshots("dom-3", "dom-4"); err != nil || len(diff.Changed) != 1 {
+		t.Errorf("Expected the body text changed, got %+v (%v)", diff, err)
+	}
+}
@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+)
+
+// errorCountWindow is the longest window RecentErrors counts over.
+const errorCountWindow = 60 * time.Minute
+
+// errorCounter counts errors in one-minute buckets, so counting the recent
+// ones costs the same however many were logged.
+type errorCounter struct {
+	mu      sync.Mutex
+	buckets [int(errorCountWindow/time.Minute) + 1]struct {
+		minute int64
+		n      int
+	}
+}
+
+func (c *errorCounter) add(t time.Time) {
+	minute := t.Unix() / 60
+	b := &c.buckets[minute%int64(len(c.buckets))]
+	c.mu.Lock()
+	if b.minute != minute {
+		b.minute, b.n = minute, 0
+	}
+	b.n++
+	c.mu.Unlock()
+}
+
+// since counts the errors of the minutes within window before now. The
+// oldest minute counts whole, so the window is up to a minute longer.
+func (c *errorCounter) since(now time.Time, window time.Duration) int {
+	window = min(window, errorCountWindow)
+	last := now.Unix() / 60
+	first := now.Add(-window).Unix() / 60
+	n := 0
+	c.mu.Lock()
+	for _, b := range c.buckets {
+		if b.minute >= first && b.minute <= last {
+			n += b.n
+		}
+	}
+	c.mu.Unlock()
+	return n
+}
+
+func (c *errorCounter) reset() {
+	c.mu.Lock()
+	clear(c.buckets[:])
+	c.mu.Unlock()
+}
+
+// isErrorEntry reports whether an entry counts as an error: a frontend
+// error, or a request that got a 5xx response or none.
+func isErrorEntry(entry LogEntry) bool {
+	switch {
+	case entry.Type == LogTypeError:
+		return true
+	case entry.Type == LogTypeHTTP && entry.HTTP != nil:
+		return entry.HTTP.StatusCode >= 500 || (entry.HTTP.StatusCode == 0 && entry.HTTP.Error != "")
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorCounter_Window(t *testing.T) {
+	var c errorCounter
+	now := time.Date(2026, 1, 2, 10, 30, 0, 0, time.UTC)
+
+	c.add(now.Add(-90 * time.Minute)) // Overwritten bucket, outside any window
+	c.add(now.Add(-20 * time.Minute))
+	c.add(now.Add(-10 * time.Minute))
+	c.add(now.Add(-10 * time.Minute))
+	c.add(now)
+
+	if got := c.since(now, 15*time.Minute); got != 3 {
+		t.Errorf("15m = %d, want 3", got)
+	}
+	if got := c.since(now, 30*time.Minute); got != 4 {
+		t.Errorf("30m = %d, want 4", got)
+	}
+	// A window past the buckets counts what they hold.
+	if got := c.since(now, 24*time.Hour); got != 4 {
+		t.Errorf("24h = %d, want 4", got)
+	}
+	// Buckets of an earlier hour are stale once the clock moves on.
+	if got := c.since(now.Add(2*time.Hour), 15*time.Minute); got != 0 {
+		t.Errorf("2h later = %d, want 0", got)
+	}
+
+	c.reset()
+	if got := c.since(now, 15*time.Minute); got != 0 {
+		t.Errorf("after reset = %d, want 0", got)
+	}
+}
+
+func TestTrafficLogger_RecentErrors(t *testing.T) {
+	logger := NewTrafficLogger(2)
+
+	logger.LogError(FrontendError{Message: "boom"})
+	logger.LogHTTP(HTTPLogEntry{Method: "GET", URL: "/ok", StatusCode: 200})
+	logger.LogHTTP(HTTPLogEntry{Method: "GET", URL: "/missing", StatusCode: 404})
+	logger.LogHTTP(HTTPLogEntry{Method: "POST", URL: "/api", StatusCode: 503})
+	logger.LogHTTP(HTTPLogEntry{Method: "GET", URL: "/down", Error: "connection refused"})
+
+	// Entries the ring buffer dropped still count.
+	if got := logger.RecentErrors(15 * time.Minute); got != 3 {
+		t.Errorf("RecentErrors = %d, want 3", got)
+	}
+	logger.Clear()
+	if got := logger.RecentErrors(15 * time.Minute); got != 0 {
+		t.Errorf("RecentErrors after Clear = %d, want 0", got)
+	}
+}
@@ -0,0 +1,232 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Page idle defaults. Mutations reach the proxy in batches up to a second
+// apart, so the quiet window must be longer than that to mean anything.
+const (
+	DefaultIdleQuiet   = 1500 * time.Millisecond
+	DefaultIdleTimeout = 10 * time.Second
+	// MaxIdleTimeout stays below the daemon client's request timeout.
+	MaxIdleTimeout = 25 * time.Second
+
+	// idlePollInterval is how often WaitForIdle re-checks activity.
+	idlePollInterval = 50 * time.Millisecond
+	// maxActivityPages bounds the page sessions tracked before idle ones are
+	// pruned.
+	maxActivityPages = 200
+)
+
+// IdleOptions configures WaitForIdle.
+type IdleOptions struct {
+	// QuietMs is how long network and DOM must stay quiet (default 1500)
+	QuietMs int `json:"quiet_ms,omitempty"`
+	// TimeoutMs bounds the wait (default 10000, max 25000)
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+	// MaxInflight is the number of requests that may still be open, for
+	// pages that keep a long poll running (default 0)
+	MaxInflight int `json:"max_inflight,omitempty"`
+}
+
+func (o IdleOptions) quiet() time.Duration {
+	if o.QuietMs > 0 {
+		return time.Duration(o.QuietMs) * time.Millisecond
+	}
+	return DefaultIdleQuiet
+}
+
+func (o IdleOptions) timeout() time.Duration {
+	if o.TimeoutMs > 0 {
+		return min(time.Duration(o.TimeoutMs)*time.Millisecond, MaxIdleTimeout)
+	}
+	return DefaultIdleTimeout
+}
+
+// PageActivity is the network and DOM activity of a page session, or of the
+// whole proxy when SessionID is empty.
+type PageActivity struct {
+	SessionID    string    `json:"session_id,omitempty"`
+	Inflight     int       `json:"inflight"`
+	LastRequest  time.Time `json:"last_request,omitempty"`
+	LastMutation time.Time `json:"last_mutation,omitempty"`
+	QuietMs      int64     `json:"quiet_ms"` // Time since the last activity
+}
+
+// IdleResult is the outcome of WaitForIdle.
+type IdleResult struct {
+	PageActivity
+	Idle     bool  `json:"idle"`
+	WaitedMs int64 `json:"waited_ms"`
+}
+
+// pageActivity is the mutable state behind PageActivity.
+type pageActivity struct {
+	inflight     int
+	lastRequest  time.Time
+	lastMutation time.Time
+}
+
+func (pa *pageActivity) last() time.Time {
+	if pa.lastMutation.After(pa.lastRequest) {
+		return pa.lastMutation
+	}
+	return pa.lastRequest
+}
+
+// activityTracker counts in-flight requests and DOM mutations per page
+// session. Every event is also counted under the empty session, which covers
+// the whole proxy.
+type activityTracker struct {
+	mu    sync.Mutex
+	pages map[string]*pageActivity
+}
+
+// pageLocked returns the activity of a session, creating it.
+func (at *activityTracker) pageLocked(sessionID string, now time.Time) *pageActivity {
+	if at.pages == nil {
+		at.pages = make(map[string]*pageActivity)
+	}
+	pa := at.pages[sessionID]
+	if pa == nil {
+		if len(at.pages) >= maxActivityPages {
+			at.pruneLocked(now.Add(-time.Minute))
+		}
+		pa = &pageActivity{}
+		at.pages[sessionID] = pa
+	}
+	return pa
+}
+
+// pruneLocked drops sessions with nothing in flight and no activity since
+// cutoff.
+func (at *activityTracker) pruneLocked(cutoff time.Time) {
+	for id, pa := range at.pages {
+		if id != "" && pa.inflight == 0 && pa.last().Before(cutoff) {
+			delete(at.pages, id)
+		}
+	}
+}
+
+// requestStarted counts a request as in flight and returns the func that
+// ends it.
+func (at *activityTracker) requestStarted(sessionID string, now time.Time) func() {
+	ids := []string{""}
+	if sessionID != "" {
+		ids = append(ids, sessionID)
+	}
+
+	at.mu.Lock()
+	for _, id := range ids {
+		pa := at.pageLocked(id, now)
+		pa.inflight++
+		pa.lastRequest = now
+	}
+	at.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			end := time.Now()
+			at.mu.Lock()
+			defer at.mu.Unlock()
+			for _, id := range ids {
+				if pa := at.pages[id]; pa != nil && pa.inflight > 0 {
+					pa.inflight--
+					pa.lastRequest = end
+				}
+			}
+		})
+	}
+}
+
+// mutated records DOM activity reported by a page.
+func (at *activityTracker) mutated(sessionID string, now time.Time) {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	at.pageLocked("", now).lastMutation = now
+	if sessionID != "" {
+		at.pageLocked(sessionID, now).lastMutation = now
+	}
+}
+
+// snapshot returns the activity of a session as of now.
+func (at *activityTracker) snapshot(sessionID string, now time.Time) PageActivity {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	pa := at.pages[sessionID]
+	if pa == nil {
+		pa = at.pages[""] // Nothing seen for the session yet
+	}
+	out := PageActivity{SessionID: sessionID, QuietMs: -1} // -1: never active
+	if pa == nil {
+		return out
+	}
+	out.Inflight = pa.inflight
+	out.LastRequest = pa.lastRequest
+	out.LastMutation = pa.lastMutation
+	if last := pa.last(); !last.IsZero() {
+		out.QuietMs = now.Sub(last).Milliseconds()
+	}
+	return out
+}
+
+// isIdle reports whether activity satisfies opts.
+func (a PageActivity) isIdle(opts IdleOptions) bool {
+	return a.Inflight <= opts.MaxInflight && (a.QuietMs < 0 || a.QuietMs >= opts.quiet().Milliseconds())
+}
+
+// isStreamingRequest reports whether a request is expected to stay open, so
+// it must not count as in flight.
+func isStreamingRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// trackInflight counts a proxied request as activity of the page that made
+// it. Call the returned func when the response is complete.
+func (ps *ProxyServer) trackInflight(r *http.Request, reqHeaders map[string]string) func() {
+	if isStreamingRequest(r) {
+		return func() {}
+	}
+	sessionID := ""
+	if r.Header.Get("Sec-Fetch-Dest") != "document" {
+		sessionID = ps.pageTracker.findSessionForResource(HTTPLogEntry{URL: r.URL.String(), RequestHeaders: reqHeaders})
+	}
+	return ps.activity.requestStarted(sessionID, time.Now())
+}
+
+// PageActivity returns the current network and DOM activity of a page
+// session, or of the whole proxy when sessionID is empty.
+func (ps *ProxyServer) PageActivity(sessionID string) PageActivity {
+	return ps.activity.snapshot(sessionID, time.Now())
+}
+
+// WaitForIdle blocks until the page session (or, with an empty sessionID,
+// every page on the proxy) has at most opts.MaxInflight requests open and no
+// requests or DOM mutations for opts.QuietMs. It returns with Idle false when
+// the timeout passes first, and ctx's error when ctx is canceled.
+func (ps *ProxyServer) WaitForIdle(ctx context.Context, sessionID string, opts IdleOptions) (IdleResult, error) {
+	start := time.Now()
+	deadline := start.Add(opts.timeout())
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		activity := ps.activity.snapshot(sessionID, now)
+		idle := activity.isIdle(opts)
+		if idle || !now.Before(deadline) {
+			return IdleResult{PageActivity: activity, Idle: idle, WaitedMs: now.Sub(start).Milliseconds()}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return IdleResult{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestActivityTracker(t *testing.T) {
+	var at activityTracker
+	now := time.Now()
+
+	if a := at.snapshot("", now); !a.isIdle(IdleOptions{}) {
+		t.Errorf("Expected a proxy with no traffic to be idle, got %+v", a)
+	}
+
+	done := at.requestStarted("page-1", now)
+	if a := at.snapshot("page-1", now); a.Inflight != 1 || a.isIdle(IdleOptions{}) {
+		t.Errorf("Expected one request in flight, got %+v", a)
+	}
+	if a := at.snapshot("page-1", now.Add(time.Second)); !a.isIdle(IdleOptions{MaxInflight: 1, QuietMs: 500}) {
+		t.Errorf("Expected max_inflight to allow the open request, got %+v", a)
+	}
+	if a := at.snapshot("", now); a.Inflight != 1 {
+		t.Errorf("Expected the request counted proxy-wide, got %+v", a)
+	}
+	done()
+	done() // Ending twice must not go negative
+	if a := at.snapshot("page-1", time.Now()); a.Inflight != 0 {
+		t.Errorf("Expected nothing in flight, got %+v", a)
+	}
+
+	at.mutated("page-2", now)
+	later := now.Add(DefaultIdleQuiet)
+	if a := at.snapshot("page-2", now.Add(100*time.Millisecond)); a.isIdle(IdleOptions{}) {
+		t.Errorf("Expected a recent mutation to block idle, got %+v", a)
+	}
+	if a := at.snapshot("page-2", later); !a.isIdle(IdleOptions{}) {
+		t.Errorf("Expected idle after the quiet window, got %+v", a)
+	}
+}
+
+func TestWaitForIdle(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("{}"))
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "idle", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	served := make(chan struct{})
+	go func() {
+		ps.handleProxy(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/slow", nil))
+		close(served)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for ps.PageActivity("").Inflight == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Times out while the request is open
+	result, err := ps.WaitForIdle(context.Background(), "", IdleOptions{QuietMs: 50, TimeoutMs: 100})
+	if err != nil || result.Idle || result.Inflight != 1 {
+		t.Fatalf("Expected timeout with one request in flight, got %+v, %v", result, err)
+	}
+
+	// Settles once the response is done and the quiet window passes
+	close(release)
+	<-served
+	result, err = ps.WaitForIdle(context.Background(), "", IdleOptions{QuietMs: 50, TimeoutMs: 2000})
+	if err != nil || !result.Idle || result.Inflight != 0 || result.WaitedMs < 25 {
+		t.Errorf("Expected idle after the quiet window, got %+v, %v", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ps.activity.mutated("", time.Now())
+	if _, err := ps.WaitForIdle(ctx, "", IdleOptions{}); err == nil {
+		t.Error("Expected canceled context error")
+	}
+}
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"bytes"
+	"html"
 	"strings"
 	"sync"
 
@@ -27,8 +28,43 @@ func instrumentationScript() string {
 // The wsPort parameter is deprecated and unused (kept for backward compatibility).
 // The script now uses relative URLs via window.location.host.
 func InjectInstrumentation(body []byte, wsPort int) []byte {
+	return injectScript(body, instrumentationScript())
+}
+
+// InjectionSession carries per-proxy values handed to the injected script.
+type InjectionSession struct {
+	Token     string // Session token presented on the metrics WebSocket
+	PublicKey string // Proxy public key for payload encryption (empty when disabled)
+	Banner    string // Environment banner state as JSON (empty when disabled)
+	UI        string // Overlay language and theme as JSON (empty for the defaults)
+}
+
+// InjectInstrumentationWithSession adds monitoring JavaScript carrying the proxy's
+// session values. They are placed as attributes on the main script tag, read once
+// by the core module, and removed from the DOM.
+func InjectInstrumentationWithSession(body []byte, session InjectionSession) []byte {
 	script := instrumentationScript()
+	var attrs strings.Builder
+	if session.Token != "" {
+		attrs.WriteString(` data-devtool-token="` + html.EscapeString(session.Token) + `"`)
+	}
+	if session.PublicKey != "" {
+		attrs.WriteString(` data-devtool-pubkey="` + html.EscapeString(session.PublicKey) + `"`)
+	}
+	if session.Banner != "" {
+		attrs.WriteString(` data-devtool-banner="` + html.EscapeString(session.Banner) + `"`)
+	}
+	if session.UI != "" {
+		attrs.WriteString(` data-devtool-ui="` + html.EscapeString(session.UI) + `"`)
+	}
+	if attrs.Len() > 0 {
+		script = strings.Replace(script, "<script>\n", "<script"+attrs.String()+">\n", 1)
+	}
+	return injectScript(body, script)
+}
 
+// injectScript inserts script into body at the most appropriate location.
+func injectScript(body []byte, script string) []byte {
 	// Try to inject before </head>
 	if idx := bytes.Index(body, []byte("</head>")); idx != -1 {
 		result := make([]byte, 0, len(body)+len(script))
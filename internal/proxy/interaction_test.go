@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInteractionEvent_MasksPasswordValue(t *testing.T) {
+	data := map[string]interface{}{
+		"event_type": "input",
+		"value":      "hunter2",
+		"target": map[string]interface{}{
+			"selector":   "#pw",
+			"tag":        "input",
+			"attributes": map[string]interface{}{"type": "password"},
+		},
+	}
+
+	event := parseInteractionEvent(data, "int-1", time.Now(), "http://localhost/")
+
+	if event.Value != maskedValue {
+		t.Errorf("Expected value %q, got %q", maskedValue, event.Value)
+	}
+	if !event.Masked {
+		t.Error("Expected event to be marked as masked")
+	}
+	if event.MaskReason != "password" {
+		t.Errorf("Expected mask reason 'password', got %q", event.MaskReason)
+	}
+}
+
+func TestParseInteractionEvent_MasksSensitiveAutocomplete(t *testing.T) {
+	data := map[string]interface{}{
+		"event_type": "keydown",
+		"value":      "4111",
+		"key":        map[string]interface{}{"key": "4", "code": "Digit4"},
+		"target": map[string]interface{}{
+			"tag":        "input",
+			"text":       "4111",
+			"attributes": map[string]interface{}{"type": "text", "autocomplete": "billing cc-number"},
+		},
+	}
+
+	event := parseInteractionEvent(data, "int-2", time.Now(), "http://localhost/")
+
+	if event.MaskReason != "autocomplete:cc-number" {
+		t.Errorf("Expected mask reason 'autocomplete:cc-number', got %q", event.MaskReason)
+	}
+	if event.Value != maskedValue {
+		t.Errorf("Expected value %q, got %q", maskedValue, event.Value)
+	}
+	if event.Key == nil || event.Key.Key != maskedValue || event.Key.Code != "" {
+		t.Errorf("Expected masked key, got %+v", event.Key)
+	}
+	if event.Target.Text != "" {
+		t.Errorf("Expected target text to be cleared, got %q", event.Target.Text)
+	}
+}
+
+func TestParseInteractionEvent_KeepsClientMaskReason(t *testing.T) {
+	data := map[string]interface{}{
+		"event_type":  "input",
+		"value":       "[masked]",
+		"masked":      true,
+		"mask_reason": "selector:[data-private]",
+		"target": map[string]interface{}{
+			"tag": "input",
+		},
+	}
+
+	event := parseInteractionEvent(data, "int-3", time.Now(), "http://localhost/")
+
+	if event.MaskReason != "selector:[data-private]" {
+		t.Errorf("Expected client mask reason to be preserved, got %q", event.MaskReason)
+	}
+}
+
+func TestParseInteractionEvent_PlainFieldUnmasked(t *testing.T) {
+	data := map[string]interface{}{
+		"event_type": "input",
+		"value":      "alice",
+		"target": map[string]interface{}{
+			"tag":        "input",
+			"attributes": map[string]interface{}{"type": "text", "autocomplete": "username"},
+		},
+	}
+
+	event := parseInteractionEvent(data, "int-4", time.Now(), "http://localhost/")
+
+	if event.Masked {
+		t.Error("Expected plain field not to be masked")
+	}
+	if event.Value != "alice" {
+		t.Errorf("Expected value 'alice', got %q", event.Value)
+	}
+}
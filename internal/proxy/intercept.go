@@ -0,0 +1,568 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// InterceptHeader marks a response to a rejected intercepted request with the
+// ID of the rule that paused it.
+const InterceptHeader = "X-Devtool-Intercept"
+
+const (
+	// defaultInterceptTimeout is how long a paused request waits for a decision
+	// before it continues with the edits made so far.
+	defaultInterceptTimeout = time.Minute
+	// maxInterceptTimeout caps a rule's timeout_ms.
+	maxInterceptTimeout = 10 * time.Minute
+	// maxInterceptBody is how much of a paused request's body is shown and
+	// editable; longer bodies are shown truncated and stream through unchanged
+	// unless replaced.
+	maxInterceptBody = 1 << 20
+	// maxPendingIntercepts is how many requests may be paused at once. Further
+	// matching requests pass through so a forgotten rule can't stall the app.
+	maxPendingIntercepts = 100
+)
+
+// InterceptRule pauses matching requests before they reach the target so an
+// agent can inspect them and resume, modify or reject them.
+type InterceptRule struct {
+	ID         string   `json:"id"`                   // Generated when empty
+	Methods    []string `json:"methods,omitempty"`    // HTTP methods (empty = all)
+	URLPattern string   `json:"url_pattern"`          // Regex matched against the path and query
+	TimeoutMs  int      `json:"timeout_ms,omitempty"` // Default 60000; on timeout the request continues
+	Hits       int64    `json:"hits"`                 // Requests paused (output only)
+}
+
+// InterceptEdit changes a paused request. Empty fields keep the request as is.
+type InterceptEdit struct {
+	Method        string            `json:"method,omitempty"`
+	URL           string            `json:"url,omitempty"` // Path and query, e.g. /api/users?page=2
+	SetHeaders    map[string]string `json:"set_headers,omitempty"`
+	RemoveHeaders []string          `json:"remove_headers,omitempty"`
+	Body          *string           `json:"body,omitempty"` // Replaces the whole body
+}
+
+// InterceptedRequest is a paused request as the agent sees it, with any
+// edits applied.
+type InterceptedRequest struct {
+	ID            string            `json:"id"`
+	RuleID        string            `json:"rule_id"`
+	Method        string            `json:"method"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers"`
+	Body          string            `json:"body,omitempty"`
+	BodyTruncated bool              `json:"body_truncated,omitempty"`
+	PausedAt      time.Time         `json:"paused_at"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	Modified      bool              `json:"modified,omitempty"`
+}
+
+// interceptDecision ends a pause: the request continues with edit applied,
+// or is answered with status and body when reject is set.
+type interceptDecision struct {
+	edit   InterceptEdit
+	reject bool
+	status int
+	body   string
+}
+
+// interceptRuleState holds a compiled rule and its hit counter.
+type interceptRuleState struct {
+	rule     InterceptRule
+	urlRegex *regexp.Regexp
+	hits     atomic.Int64
+}
+
+// pendingIntercept is a paused request waiting for a decision.
+type pendingIntercept struct {
+	view    InterceptedRequest
+	edit    InterceptEdit // Edits so far, merged
+	decided chan interceptDecision
+}
+
+// InterceptEngine holds the intercept rules of a proxy and the requests they
+// have paused. The first matching rule, in the order added, pauses a request.
+type InterceptEngine struct {
+	mu      sync.Mutex
+	rules   []*interceptRuleState
+	pending map[string]*pendingIntercept
+	seq     atomic.Int64
+	ruleSeq atomic.Int64
+	// paused is closed and replaced whenever a request is paused
+	paused  chan struct{}
+	onPause func(InterceptedRequest)
+}
+
+// NewInterceptEngine creates an empty intercept engine.
+func NewInterceptEngine() *InterceptEngine {
+	return &InterceptEngine{
+		pending: make(map[string]*pendingIntercept),
+		paused:  make(chan struct{}),
+	}
+}
+
+// Validate checks a rule and fills in defaults.
+func (rule *InterceptRule) Validate() error {
+	if rule.URLPattern == "" {
+		return fmt.Errorf("intercept rule %s: url_pattern is required", rule.ID)
+	}
+	if rule.TimeoutMs == 0 {
+		rule.TimeoutMs = int(defaultInterceptTimeout.Milliseconds())
+	}
+	if rule.TimeoutMs < 0 || time.Duration(rule.TimeoutMs)*time.Millisecond > maxInterceptTimeout {
+		return fmt.Errorf("intercept rule %s: timeout_ms must be between 1 and %d", rule.ID, maxInterceptTimeout.Milliseconds())
+	}
+	for i, m := range rule.Methods {
+		rule.Methods[i] = strings.ToUpper(m)
+	}
+	return nil
+}
+
+// Validate checks an edit before it is applied to a paused request.
+func (edit *InterceptEdit) Validate() error {
+	if edit.Method != "" {
+		edit.Method = strings.ToUpper(edit.Method)
+		if strings.ContainsAny(edit.Method, " \t\r\n") {
+			return fmt.Errorf("invalid method %q", edit.Method)
+		}
+	}
+	if edit.URL != "" {
+		if !strings.HasPrefix(edit.URL, "/") {
+			return fmt.Errorf("url must be a path starting with /, got %q", edit.URL)
+		}
+		if _, err := url.ParseRequestURI(edit.URL); err != nil {
+			return fmt.Errorf("invalid url: %w", err)
+		}
+	}
+	for name := range edit.SetHeaders {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	return nil
+}
+
+// AddRule adds a rule, replacing any rule with the same ID in place, and
+// returns it with its defaults filled in.
+func (ie *InterceptEngine) AddRule(rule InterceptRule) (InterceptRule, error) {
+	if err := rule.Validate(); err != nil {
+		return InterceptRule{}, err
+	}
+	regex, err := regexp.Compile(rule.URLPattern)
+	if err != nil {
+		return InterceptRule{}, fmt.Errorf("intercept rule %s: invalid url_pattern: %w", rule.ID, err)
+	}
+	if rule.ID == "" {
+		rule.ID = fmt.Sprintf("rule-%d", ie.ruleSeq.Add(1))
+	}
+	rule.Hits = 0
+	state := &interceptRuleState{rule: rule, urlRegex: regex}
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	for i, r := range ie.rules {
+		if r.rule.ID == rule.ID {
+			ie.rules[i] = state
+			return rule, nil
+		}
+	}
+	ie.rules = append(ie.rules, state)
+	return rule, nil
+}
+
+// SetOnPause sets a callback run, in its own goroutine, for every request
+// a rule pauses.
+func (ie *InterceptEngine) SetOnPause(fn func(InterceptedRequest)) {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	ie.onPause = fn
+}
+
+// RemoveRule removes a rule by ID. Requests it paused continue with the
+// edits made so far.
+func (ie *InterceptEngine) RemoveRule(ruleID string) bool {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+
+	for i, r := range ie.rules {
+		if r.rule.ID == ruleID {
+			ie.rules = append(ie.rules[:i], ie.rules[i+1:]...)
+			ie.releaseLocked(func(p *pendingIntercept) bool { return p.view.RuleID == ruleID })
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the configured rules with their hit counts.
+func (ie *InterceptEngine) Rules() []InterceptRule {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+
+	rules := make([]InterceptRule, len(ie.rules))
+	for i, r := range ie.rules {
+		rules[i] = r.rule
+		rules[i].Methods = append([]string(nil), r.rule.Methods...)
+		rules[i].Hits = r.hits.Load()
+	}
+	return rules
+}
+
+// Clear removes all rules and lets every paused request continue.
+func (ie *InterceptEngine) Clear() {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	ie.rules = nil
+	ie.releaseLocked(func(*pendingIntercept) bool { return true })
+}
+
+// releaseLocked resumes the paused requests selected by match.
+func (ie *InterceptEngine) releaseLocked(match func(*pendingIntercept) bool) {
+	for id, p := range ie.pending {
+		if match(p) {
+			delete(ie.pending, id)
+			p.decided <- interceptDecision{edit: p.edit}
+		}
+	}
+}
+
+// Match returns the rule that pauses a request, counting the hit.
+func (ie *InterceptEngine) Match(method, url string) (InterceptRule, bool) {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+
+	for _, r := range ie.rules {
+		if len(r.rule.Methods) > 0 && !containsString(r.rule.Methods, method) {
+			continue
+		}
+		if !r.urlRegex.MatchString(url) {
+			continue
+		}
+		r.hits.Add(1)
+		return r.rule, true
+	}
+	return InterceptRule{}, false
+}
+
+// Pending returns the paused requests, oldest first.
+func (ie *InterceptEngine) Pending() []InterceptedRequest {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	return ie.pendingLocked()
+}
+
+func (ie *InterceptEngine) pendingLocked() []InterceptedRequest {
+	requests := make([]InterceptedRequest, 0, len(ie.pending))
+	for _, p := range ie.pending {
+		view := p.view
+		view.Headers = make(map[string]string, len(p.view.Headers))
+		for k, v := range p.view.Headers {
+			view.Headers[k] = v
+		}
+		requests = append(requests, view)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].PausedAt.Before(requests[j].PausedAt)
+	})
+	return requests
+}
+
+// Wait returns the paused requests as soon as there is at least one, or
+// whatever is pending once ctx is done.
+func (ie *InterceptEngine) Wait(ctx context.Context) []InterceptedRequest {
+	for {
+		ie.mu.Lock()
+		if len(ie.pending) > 0 {
+			requests := ie.pendingLocked()
+			ie.mu.Unlock()
+			return requests
+		}
+		paused := ie.paused
+		ie.mu.Unlock()
+
+		select {
+		case <-paused:
+		case <-ctx.Done():
+			return ie.Pending()
+		}
+	}
+}
+
+// Modify applies an edit to a paused request, which stays paused.
+func (ie *InterceptEngine) Modify(id string, edit InterceptEdit) (InterceptedRequest, error) {
+	if err := edit.Validate(); err != nil {
+		return InterceptedRequest{}, err
+	}
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	p, ok := ie.pending[id]
+	if !ok {
+		return InterceptedRequest{}, fmt.Errorf("no paused request %s", id)
+	}
+	p.merge(edit)
+	return p.view, nil
+}
+
+// Resume lets a paused request continue, applying edit on top of earlier
+// modifications when given.
+func (ie *InterceptEngine) Resume(id string, edit *InterceptEdit) error {
+	if edit != nil {
+		if err := edit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	p, ok := ie.pending[id]
+	if !ok {
+		return fmt.Errorf("no paused request %s", id)
+	}
+	if edit != nil {
+		p.merge(*edit)
+	}
+	delete(ie.pending, id)
+	p.decided <- interceptDecision{edit: p.edit}
+	return nil
+}
+
+// Reject answers a paused request with status (default 403) and body
+// without calling the target.
+func (ie *InterceptEngine) Reject(id string, status int, body string) error {
+	if status == 0 {
+		status = http.StatusForbidden
+	}
+	if status < 100 || status > 599 {
+		return fmt.Errorf("invalid status %d", status)
+	}
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	p, ok := ie.pending[id]
+	if !ok {
+		return fmt.Errorf("no paused request %s", id)
+	}
+	delete(ie.pending, id)
+	p.decided <- interceptDecision{reject: true, status: status, body: body}
+	return nil
+}
+
+// pause registers a paused request. It returns false when too many requests
+// are already paused.
+func (ie *InterceptEngine) pause(rule InterceptRule, r *http.Request, body string, truncated bool) (*pendingIntercept, bool) {
+	headers := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+	now := time.Now()
+	p := &pendingIntercept{
+		view: InterceptedRequest{
+			ID:            fmt.Sprintf("int-%d", ie.seq.Add(1)),
+			RuleID:        rule.ID,
+			Method:        r.Method,
+			URL:           r.URL.RequestURI(),
+			Headers:       headers,
+			Body:          body,
+			BodyTruncated: truncated,
+			PausedAt:      now,
+			ExpiresAt:     now.Add(time.Duration(rule.TimeoutMs) * time.Millisecond),
+		},
+		decided: make(chan interceptDecision, 1),
+	}
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	if len(ie.pending) >= maxPendingIntercepts {
+		return nil, false
+	}
+	ie.pending[p.view.ID] = p
+	close(ie.paused)
+	ie.paused = make(chan struct{})
+	if ie.onPause != nil {
+		go ie.onPause(p.view)
+	}
+	return p, true
+}
+
+// finish removes a paused request that timed out or whose client went away.
+// If a decision raced in first, that decision is returned instead.
+func (ie *InterceptEngine) finish(p *pendingIntercept) interceptDecision {
+	ie.mu.Lock()
+	if _, ok := ie.pending[p.view.ID]; ok {
+		delete(ie.pending, p.view.ID)
+		ie.mu.Unlock()
+		return interceptDecision{edit: p.edit}
+	}
+	ie.mu.Unlock()
+	return <-p.decided
+}
+
+// merge folds edit into the request's accumulated edits and its view.
+func (p *pendingIntercept) merge(edit InterceptEdit) {
+	if edit.Method != "" {
+		p.edit.Method = edit.Method
+		p.view.Method = edit.Method
+	}
+	if edit.URL != "" {
+		p.edit.URL = edit.URL
+		p.view.URL = edit.URL
+	}
+	for _, name := range edit.RemoveHeaders {
+		name = http.CanonicalHeaderKey(name)
+		delete(p.edit.SetHeaders, name)
+		delete(p.view.Headers, name)
+		if !containsString(p.edit.RemoveHeaders, name) {
+			p.edit.RemoveHeaders = append(p.edit.RemoveHeaders, name)
+		}
+	}
+	for name, value := range edit.SetHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if p.edit.SetHeaders == nil {
+			p.edit.SetHeaders = make(map[string]string)
+		}
+		p.edit.SetHeaders[name] = value
+		p.view.Headers[name] = value
+	}
+	if edit.Body != nil {
+		body := *edit.Body
+		p.edit.Body = &body
+		p.view.Body = body
+		p.view.BodyTruncated = false
+	}
+	p.view.Modified = true
+}
+
+// apply changes r as the edit describes.
+func (edit InterceptEdit) apply(r *http.Request) {
+	if edit.Method != "" {
+		r.Method = edit.Method
+	}
+	if edit.URL != "" {
+		if u, err := url.ParseRequestURI(edit.URL); err == nil {
+			r.URL.Path = u.Path
+			r.URL.RawPath = u.RawPath
+			r.URL.RawQuery = u.RawQuery
+			r.RequestURI = u.RequestURI()
+		}
+	}
+	for _, name := range edit.RemoveHeaders {
+		r.Header.Del(name)
+	}
+	for name, value := range edit.SetHeaders {
+		if name == "Host" {
+			r.Host = value
+			continue
+		}
+		r.Header.Set(name, value)
+	}
+	if edit.Body != nil {
+		r.Body = io.NopCloser(strings.NewReader(*edit.Body))
+		r.ContentLength = int64(len(*edit.Body))
+		r.TransferEncoding = nil
+		r.Header.Del("Content-Length")
+	}
+}
+
+// containsString reports whether list holds s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// InterceptEngine returns the intercept engine for this proxy server.
+func (ps *ProxyServer) InterceptEngine() *InterceptEngine {
+	return ps.interceptEngine
+}
+
+// interceptRequest pauses r until the agent decides on it or the rule times
+// out, then applies the edits. It returns false when the request was
+// answered here, rejected or abandoned by the client, and must not be proxied.
+func (ps *ProxyServer) interceptRequest(w http.ResponseWriter, r *http.Request, rule InterceptRule, reqID string) bool {
+	var body string
+	truncated := false
+	if r.Body != nil && r.Body != http.NoBody {
+		data, _ := io.ReadAll(io.LimitReader(r.Body, maxInterceptBody+1))
+		if len(data) > maxInterceptBody {
+			truncated = true
+			body = string(data[:maxInterceptBody])
+		} else {
+			body = string(data)
+		}
+		// Replay what was read ahead of the rest
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	}
+
+	p, ok := ps.interceptEngine.pause(rule, r, body, truncated)
+	if !ok {
+		return true
+	}
+
+	timer := time.NewTimer(time.Duration(rule.TimeoutMs) * time.Millisecond)
+	defer timer.Stop()
+
+	var decision interceptDecision
+	select {
+	case decision = <-p.decided:
+	case <-timer.C:
+		decision = ps.interceptEngine.finish(p)
+	case <-r.Context().Done():
+		ps.interceptEngine.finish(p)
+		return false
+	}
+
+	if !decision.reject {
+		decision.edit.apply(r)
+		return true
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set(InterceptHeader, rule.ID)
+	if decision.body == "" {
+		decision.body = fmt.Sprintf("Request rejected by intercept rule %s", rule.ID)
+	}
+	w.WriteHeader(decision.status)
+	io.WriteString(w, decision.body)
+
+	reqHeaders := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		reqHeaders[k] = strings.Join(v, ", ")
+	}
+	client := ps.clientInfo(r)
+	entry := HTTPLogEntry{
+		ID:              reqID,
+		Timestamp:       p.view.PausedAt,
+		Method:          r.Method,
+		URL:             r.URL.String(),
+		RequestHeaders:  reqHeaders,
+		RequestBody:     body,
+		ClientIP:        client.IP,
+		Protocol:        client.Proto,
+		StatusCode:      decision.status,
+		ResponseHeaders: map[string]string{InterceptHeader: rule.ID},
+		ResponseBody:    decision.body,
+		Duration:        time.Since(p.view.PausedAt),
+	}
+	ps.logger.BodyCapture().trim(&entry)
+	ps.logger.LogHTTP(entry)
+	return false
+}
@@ -0,0 +1,194 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForIntercept waits until one request is paused and returns it.
+func waitForIntercept(t *testing.T, ie *InterceptEngine) InterceptedRequest {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pending := ie.Wait(ctx)
+	if len(pending) != 1 {
+		t.Fatalf("Expected one paused request, got %+v", pending)
+	}
+	return pending[0]
+}
+
+func TestProxyIntercept(t *testing.T) {
+	type seen struct {
+		method, uri, token, agent, body string
+	}
+	requests := make(chan seen, 4)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- seen{r.Method, r.URL.RequestURI(), r.Header.Get("X-Token"), r.Header.Get("User-Agent"), string(body)}
+		w.Write([]byte("real"))
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	ie := ps.InterceptEngine()
+	if _, err := ie.AddRule(InterceptRule{ID: "orders", Methods: []string{"post"}, URLPattern: `^/api/orders`}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	notified := make(chan InterceptedRequest, 4)
+	ie.SetOnPause(func(req InterceptedRequest) { notified <- req })
+
+	serve := func(req *http.Request) <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			ps.handleProxy(rec, req)
+			done <- rec
+		}()
+		return done
+	}
+
+	// Modify, then resume with one more edit
+	req := httptest.NewRequest("POST", "/api/orders?draft=1", strings.NewReader(`{"qty":1}`))
+	req.Header.Set("User-Agent", "test")
+	done := serve(req)
+	paused := waitForIntercept(t, ie)
+	if got := <-notified; got.ID != paused.ID {
+		t.Errorf("Expected the pause reported, got %+v", got)
+	}
+	if paused.RuleID != "orders" || paused.Method != "POST" || paused.URL != "/api/orders?draft=1" || paused.Body != `{"qty":1}` || paused.Headers["User-Agent"] != "test" {
+		t.Errorf("Unexpected paused request %+v", paused)
+	}
+
+	body := `{"qty":5}`
+	view, err := ie.Modify(paused.ID, InterceptEdit{URL: "/api/orders", SetHeaders: map[string]string{"x-token": "abc"}, Body: &body})
+	if err != nil {
+		t.Fatalf("Modify failed: %v", err)
+	}
+	if !view.Modified || view.Headers["X-Token"] != "abc" || view.Body != body {
+		t.Errorf("Expected the edit in the view, got %+v", view)
+	}
+	if err := ie.Resume(paused.ID, &InterceptEdit{RemoveHeaders: []string{"user-agent"}}); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if rec := <-done; rec.Body.String() != "real" {
+		t.Errorf("Expected the resumed request proxied, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := <-requests; got.method != "POST" || got.uri != "/api/orders" || got.token != "abc" || got.agent != "" || got.body != body {
+		t.Errorf("Expected the edits to reach the backend, got %+v", got)
+	}
+
+	// Reject answers without calling the backend
+	done = serve(httptest.NewRequest("POST", "/api/orders", strings.NewReader("x")))
+	paused = waitForIntercept(t, ie)
+	if err := ie.Reject(paused.ID, http.StatusConflict, "no"); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	rec := <-done
+	if rec.Code != http.StatusConflict || rec.Body.String() != "no" || rec.Header().Get(InterceptHeader) != "orders" {
+		t.Errorf("Unexpected rejection %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if err := ie.Resume(paused.ID, nil); err == nil {
+		t.Error("Expected a decided request to be gone")
+	}
+
+	// Unmatched methods pass straight through
+	rec = httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("GET", "/api/orders", nil))
+	if rec.Body.String() != "real" {
+		t.Errorf("Expected GET proxied, got %q", rec.Body.String())
+	}
+	<-requests
+
+	// Removing the rule lets its paused requests continue
+	done = serve(httptest.NewRequest("POST", "/api/orders", nil))
+	waitForIntercept(t, ie)
+	if !ie.RemoveRule("orders") || ie.RemoveRule("orders") {
+		t.Error("Expected RemoveRule to remove the rule once")
+	}
+	if rec := <-done; rec.Body.String() != "real" {
+		t.Errorf("Expected the released request proxied, got %q", rec.Body.String())
+	}
+	<-requests
+	if len(ie.Pending()) != 0 {
+		t.Error("Expected nothing paused")
+	}
+}
+
+func TestProxyInterceptTimeout(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Edited")))
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	ie := ps.InterceptEngine()
+	rule, err := ie.AddRule(InterceptRule{URLPattern: `.`, TimeoutMs: 200})
+	if err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	if rule.ID != "rule-1" {
+		t.Errorf("Expected a generated rule ID, got %q", rule.ID)
+	}
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		ps.handleProxy(rec, httptest.NewRequest("GET", "/", nil))
+		done <- rec
+	}()
+	paused := waitForIntercept(t, ie)
+	if _, err := ie.Modify(paused.ID, InterceptEdit{SetHeaders: map[string]string{"X-Edited": "yes"}}); err != nil {
+		t.Fatalf("Modify failed: %v", err)
+	}
+
+	select {
+	case rec := <-done:
+		if rec.Body.String() != "yes" {
+			t.Errorf("Expected the request to continue with its edits on timeout, got %q", rec.Body.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the paused request to time out")
+	}
+	if rules := ie.Rules(); len(rules) != 1 || rules[0].Hits != 1 {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+}
+
+func TestInterceptValidation(t *testing.T) {
+	ie := NewInterceptEngine()
+	for _, bad := range []InterceptRule{
+		{ID: "x"},
+		{ID: "x", URLPattern: "("},
+		{ID: "x", URLPattern: "/", TimeoutMs: -1},
+		{ID: "x", URLPattern: "/", TimeoutMs: int(time.Hour.Milliseconds())},
+	} {
+		if _, err := ie.AddRule(bad); err == nil {
+			t.Errorf("Expected an error for %+v", bad)
+		}
+	}
+
+	for _, bad := range []InterceptEdit{
+		{URL: "http://other/"},
+		{Method: "GE T"},
+		{SetHeaders: map[string]string{"Bad Name": "x"}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", bad)
+		}
+	}
+
+	if err := ie.Reject("int-1", 0, ""); err == nil {
+		t.Error("Expected an error for an unknown request")
+	}
+}
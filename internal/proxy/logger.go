@@ -44,6 +44,12 @@ const (
 	LogTypeDesignRequest LogEntryType = "design_request"
 	// LogTypeDesignChat represents a chat message about the selected element.
 	LogTypeDesignChat LogEntryType = "design_chat"
+	// LogTypeWebSocket represents a message on a proxied WebSocket connection.
+	LogTypeWebSocket LogEntryType = "ws_message"
+	// LogTypeSSE represents an event on a proxied Server-Sent Events stream.
+	LogTypeSSE LogEntryType = "sse_event"
+	// LogTypeConsole represents console output of a page.
+	LogTypeConsole LogEntryType = "console"
 )
 
 // HTTPLogEntry represents a logged HTTP request/response pair.
@@ -54,10 +60,13 @@ type HTTPLogEntry struct {
 	URL             string            `json:"url"`
 	RequestHeaders  map[string]string `json:"request_headers"`
 	RequestBody     string            `json:"request_body,omitempty"`
+	ClientIP        string            `json:"client_ip,omitempty"` // Browser address behind trusted proxies
+	Protocol        string            `json:"protocol,omitempty"`  // Scheme the browser used
 	StatusCode      int               `json:"status_code"`
 	ResponseHeaders map[string]string `json:"response_headers"`
 	ResponseBody    string            `json:"response_body,omitempty"`
 	Duration        time.Duration     `json:"duration"`
+	Timing          *RequestTiming    `json:"timing,omitempty"` // Upstream and proxy phases of Duration
 	Error           string            `json:"error,omitempty"`
 }
 
@@ -145,15 +154,17 @@ type ExecutionResponse struct {
 
 // InteractionEvent represents a user interaction (click, keyboard, etc.).
 type InteractionEvent struct {
-	ID        string                 `json:"id"`
-	Timestamp time.Time              `json:"timestamp"`
-	EventType string                 `json:"event_type"` // click, dblclick, keydown, input, scroll, focus, blur, submit, contextmenu, mousemove
-	Target    InteractionTarget      `json:"target"`
-	Position  *InteractionPosition   `json:"position,omitempty"` // For mouse events
-	Key       *KeyboardInfo          `json:"key,omitempty"`      // For keyboard events
-	Value     string                 `json:"value,omitempty"`    // For input events (sanitized, no passwords)
-	URL       string                 `json:"url"`
-	Data      map[string]interface{} `json:"data,omitempty"` // Extra data (scroll_position, etc.)
+	ID         string                 `json:"id"`
+	Timestamp  time.Time              `json:"timestamp"`
+	EventType  string                 `json:"event_type"` // click, dblclick, keydown, input, scroll, focus, blur, submit, contextmenu, mousemove
+	Target     InteractionTarget      `json:"target"`
+	Position   *InteractionPosition   `json:"position,omitempty"`    // For mouse events
+	Key        *KeyboardInfo          `json:"key,omitempty"`         // For keyboard events
+	Value      string                 `json:"value,omitempty"`       // For input events (sanitized, no passwords)
+	Masked     bool                   `json:"masked,omitempty"`      // Value/key was masked at capture time
+	MaskReason string                 `json:"mask_reason,omitempty"` // password, autocomplete:<token>, selector:<sel>
+	URL        string                 `json:"url"`
+	Data       map[string]interface{} `json:"data,omitempty"` // Extra data (scroll_position, etc.)
 }
 
 // InteractionTarget describes the DOM element that was interacted with.
@@ -388,6 +399,9 @@ type LogEntry struct {
 	DesignState       *DesignState       `json:"design_state,omitempty"`
 	DesignRequest     *DesignRequest     `json:"design_request,omitempty"`
 	DesignChat        *DesignChat        `json:"design_chat,omitempty"`
+	WebSocket         *WebSocketMessage  `json:"ws_message,omitempty"`
+	SSE               *ServerSentEvent   `json:"sse_event,omitempty"`
+	Console           *ConsoleEntry      `json:"console,omitempty"`
 }
 
 // TrafficLogger stores proxy traffic logs with bounded memory.
@@ -397,6 +411,11 @@ type TrafficLogger struct {
 	head    atomic.Int64 // Next write position
 	count   atomic.Int64 // Total entries written (for ID generation)
 	mu      sync.RWMutex // Protects entries slice
+	capture atomic.Pointer[BodyCapture]
+	routes  routeStats              // Latency and errors by route
+	disk    atomic.Pointer[diskLog] // Persisted copy, if enabled
+	hook    atomic.Pointer[func(LogEntry)]
+	errors  errorCounter // Frontend errors and 5xx responses by minute
 }
 
 // NewTrafficLogger creates a new logger with specified max entries.
@@ -410,8 +429,55 @@ func NewTrafficLogger(maxSize int) *TrafficLogger {
 	}
 }
 
-// LogHTTP adds an HTTP request/response log entry.
+// SetBodyCapture sets what HTTP entries keep of bodies and headers.
+func (tl *TrafficLogger) SetBodyCapture(c BodyCapture) {
+	tl.capture.Store(&c)
+}
+
+// BodyCapture returns what HTTP entries keep of bodies and headers.
+func (tl *TrafficLogger) BodyCapture() BodyCapture {
+	if c := tl.capture.Load(); c != nil {
+		return *c
+	}
+	return BodyCapture{}
+}
+
+// Persist also writes entries to segment files in dir, and makes Query
+// return the persisted entries the ring has dropped, including those of
+// earlier runs.
+func (tl *TrafficLogger) Persist(dir string, config LogPersistence) error {
+	dl, err := openDiskLog(dir, config)
+	if err != nil {
+		return err
+	}
+	if old := tl.disk.Swap(dl); old != nil {
+		old.close()
+	}
+	return nil
+}
+
+// SetHook sets a function called with each entry once it is stored, or
+// removes it when nil. It runs on the goroutine logging, so it must not block.
+func (tl *TrafficLogger) SetHook(hook func(LogEntry)) {
+	if hook == nil {
+		tl.hook.Store(nil)
+		return
+	}
+	tl.hook.Store(&hook)
+}
+
+// Close closes the persisted log's open segment. Logging reopens it.
+func (tl *TrafficLogger) Close() {
+	if dl := tl.disk.Load(); dl != nil {
+		dl.close()
+	}
+}
+
+// LogHTTP adds an HTTP request/response log entry. Bodies are trimmed and
+// credential headers masked according to the body capture settings.
 func (tl *TrafficLogger) LogHTTP(entry HTTPLogEntry) {
+	tl.BodyCapture().apply(&entry)
+	tl.routes.record(&entry)
 	tl.log(LogEntry{
 		Type: LogTypeHTTP,
 		HTTP: &entry,
@@ -546,6 +612,30 @@ func (tl *TrafficLogger) LogDesignChat(entry DesignChat) {
 	})
 }
 
+// LogWebSocket adds a WebSocket message entry.
+func (tl *TrafficLogger) LogWebSocket(entry WebSocketMessage) {
+	tl.log(LogEntry{
+		Type:      LogTypeWebSocket,
+		WebSocket: &entry,
+	})
+}
+
+// LogSSE adds a Server-Sent Event entry.
+func (tl *TrafficLogger) LogSSE(entry ServerSentEvent) {
+	tl.log(LogEntry{
+		Type: LogTypeSSE,
+		SSE:  &entry,
+	})
+}
+
+// LogConsole adds a console output entry.
+func (tl *TrafficLogger) LogConsole(entry ConsoleEntry) {
+	tl.log(LogEntry{
+		Type:    LogTypeConsole,
+		Console: &entry,
+	})
+}
+
 // log adds an entry to the circular buffer.
 func (tl *TrafficLogger) log(entry LogEntry) {
 	pos := tl.head.Add(1) - 1
@@ -556,9 +646,20 @@ func (tl *TrafficLogger) log(entry LogEntry) {
 	tl.mu.Unlock()
 
 	tl.count.Add(1)
+	if isErrorEntry(entry) {
+		tl.errors.add(time.Now())
+	}
+
+	if dl := tl.disk.Load(); dl != nil {
+		dl.append(pos, entry)
+	}
+	if hook := tl.hook.Load(); hook != nil {
+		(*hook)(entry)
+	}
 }
 
-// Query retrieves log entries matching the filter.
+// Query retrieves log entries matching the filter. With persistence,
+// entries no longer in memory are read from disk first.
 func (tl *TrafficLogger) Query(filter LogFilter) []LogEntry {
 	tl.mu.RLock()
 	defer tl.mu.RUnlock()
@@ -567,6 +668,9 @@ func (tl *TrafficLogger) Query(filter LogFilter) []LogEntry {
 	available := int(min(total, int64(tl.maxSize)))
 
 	var results []LogEntry
+	if dl := tl.disk.Load(); dl != nil {
+		results = dl.query(filter, dl.baseSeq+total-int64(available))
+	}
 	for i := 0; i < available; i++ {
 		entry := tl.entries[i]
 		if filter.Matches(entry) {
@@ -588,18 +692,43 @@ func (tl *TrafficLogger) Clear() {
 	for i := range tl.entries {
 		tl.entries[i] = LogEntry{}
 	}
+	tl.routes.reset()
+	tl.errors.reset()
+	if dl := tl.disk.Load(); dl != nil {
+		dl.clear()
+	}
 }
 
 // Stats returns logger statistics.
 func (tl *TrafficLogger) Stats() LoggerStats {
 	total := tl.count.Load()
 	available := int(min(total, int64(tl.maxSize)))
-	return LoggerStats{
+	stats := LoggerStats{
 		TotalEntries:     total,
 		AvailableEntries: int64(available),
 		MaxSize:          int64(tl.maxSize),
 		Dropped:          max(0, total-int64(tl.maxSize)),
 	}
+	if dl := tl.disk.Load(); dl != nil {
+		disk := dl.stats()
+		stats.Disk = &disk
+	}
+	return stats
+}
+
+// RecentErrors counts the frontend errors and 5xx responses logged within
+// window (at most an hour), to the minute, including entries the log has
+// since dropped. It doesn't read the log, so it is cheap to poll.
+func (tl *TrafficLogger) RecentErrors(window time.Duration) int {
+	return tl.errors.since(time.Now(), window)
+}
+
+// RouteStats returns request counts, error rates and latency percentiles
+// by route, slowest first unless filter orders them otherwise. Routes
+// aggregate all traffic since the log was cleared, including entries the
+// log has since dropped.
+func (tl *TrafficLogger) RouteStats(filter RouteStatsFilter) []RouteStats {
+	return tl.routes.stats(filter)
 }
 
 // LoggerStats holds logger statistics.
@@ -608,6 +737,8 @@ type LoggerStats struct {
 	AvailableEntries int64 `json:"available_entries"`
 	MaxSize          int64 `json:"max_size"`
 	Dropped          int64 `json:"dropped"`
+
+	Disk *DiskLogStats `json:"disk,omitempty"` // Persisted log, if enabled
 }
 
 // LogFilter specifies criteria for querying logs.
@@ -710,6 +841,18 @@ func (f LogFilter) Matches(entry LogEntry) bool {
 		if entry.DesignChat != nil {
 			timestamp = entry.DesignChat.Timestamp
 		}
+	case LogTypeWebSocket:
+		if entry.WebSocket != nil {
+			timestamp = entry.WebSocket.Timestamp
+		}
+	case LogTypeSSE:
+		if entry.SSE != nil {
+			timestamp = entry.SSE.Timestamp
+		}
+	case LogTypeConsole:
+		if entry.Console != nil {
+			timestamp = entry.Console.Timestamp
+		}
 	}
 
 	if f.Since != nil && timestamp.Before(*f.Since) {
@@ -757,6 +900,20 @@ func (f LogFilter) Matches(entry LogEntry) bool {
 		}
 	}
 
+	// URL pattern filter for WebSocket messages
+	if entry.Type == LogTypeWebSocket && entry.WebSocket != nil && f.URLPattern != "" {
+		if !contains(entry.WebSocket.URL, f.URLPattern) {
+			return false
+		}
+	}
+
+	// URL pattern filter for Server-Sent Events
+	if entry.Type == LogTypeSSE && entry.SSE != nil && f.URLPattern != "" {
+		if !contains(entry.SSE.URL, f.URLPattern) {
+			return false
+		}
+	}
+
 	// Interaction type filter
 	if entry.Type == LogTypeInteraction && entry.Interaction != nil && len(f.InteractionTypes) > 0 {
 		match := false
@@ -0,0 +1,342 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log persistence defaults.
+const (
+	DefaultLogSegmentBytes = 4 << 20 // Size at which a segment file is rotated
+	DefaultLogSegments     = 8       // Segments kept per proxy
+)
+
+// logSegmentExt names the JSONL segment files of a persisted log.
+const logSegmentExt = ".jsonl"
+
+// LogPersistence keeps a copy of the traffic log on disk as segmented JSONL
+// files, so entries survive daemon restarts and outlive the in-memory ring.
+// PROXYLOG QUERY reads the older entries back from disk transparently.
+type LogPersistence struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Dir holds the segment files (default: .agnt/logs/<proxy id> in the
+	// proxy's project).
+	Dir string `json:"dir,omitempty"`
+	// SegmentBytes is the size at which a segment is closed and a new one
+	// started (default 4MB).
+	SegmentBytes int64 `json:"segment_bytes,omitempty"`
+	// MaxSegments is how many segments are kept; the oldest is deleted
+	// when a new one starts (default 8).
+	MaxSegments int `json:"max_segments,omitempty"`
+	// MaxAgeHours deletes segments last written longer ago (0: no limit).
+	MaxAgeHours int `json:"max_age_hours,omitempty"`
+}
+
+// Validate checks the retention settings.
+func (p LogPersistence) Validate() error {
+	if p.SegmentBytes < 0 || p.MaxSegments < 0 || p.MaxAgeHours < 0 {
+		return fmt.Errorf("invalid log persistence: limits must not be negative")
+	}
+	return nil
+}
+
+// DefaultLogDir returns where a proxy's log is persisted in a project.
+func DefaultLogDir(projectPath, proxyID string) string {
+	return filepath.Join(projectPath, ".agnt", "logs", sanitizeFilename(proxyID))
+}
+
+func (p LogPersistence) segmentBytes() int64 {
+	if p.SegmentBytes > 0 {
+		return p.SegmentBytes
+	}
+	return DefaultLogSegmentBytes
+}
+
+func (p LogPersistence) maxSegments() int {
+	if p.MaxSegments > 0 {
+		return p.MaxSegments
+	}
+	return DefaultLogSegments
+}
+
+// DiskLogStats describes the persisted part of a traffic log.
+type DiskLogStats struct {
+	Dir      string `json:"dir"`
+	Segments int    `json:"segments"`
+	Bytes    int64  `json:"bytes"`
+	Written  int64  `json:"written"` // Entries persisted since the proxy started
+	Errors   int64  `json:"errors,omitempty"`
+	LastErr  string `json:"last_error,omitempty"`
+}
+
+// diskRecord is one line of a segment: the entry and its log-wide sequence
+// number, which continues across restarts.
+type diskRecord struct {
+	Seq int64 `json:"seq"`
+	LogEntry
+}
+
+// diskLog appends traffic log entries to rotating segment files.
+type diskLog struct {
+	mu      sync.Mutex
+	config  LogPersistence
+	dir     string
+	file    *os.File
+	index   int   // Number of the open segment
+	size    int64 // Bytes in the open segment
+	baseSeq int64 // Sequence of the first entry logged by this process
+	written int64
+	errors  int64
+	lastErr string
+}
+
+// openDiskLog prepares dir for appending, continuing the sequence of the
+// segments already there.
+func openDiskLog(dir string, config LogPersistence) (*diskLog, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	dl := &diskLog{config: config, dir: dir}
+	segments, err := dl.segments()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(segments); n > 0 {
+		dl.index = segments[n-1]
+	}
+	for i := len(segments) - 1; i >= 0 && dl.baseSeq == 0; i-- {
+		dl.baseSeq = lastSeq(dl.segmentPath(segments[i])) + 1
+	}
+	dl.prune()
+	return dl, nil
+}
+
+// segments returns the segment numbers in dir, oldest first.
+func (dl *diskLog) segments() ([]int, error) {
+	names, err := os.ReadDir(dl.dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []int
+	for _, e := range names {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, logSegmentExt) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(name, logSegmentExt)); err == nil {
+			segments = append(segments, n)
+		}
+	}
+	sort.Ints(segments)
+	return segments, nil
+}
+
+func (dl *diskLog) segmentPath(index int) string {
+	return filepath.Join(dl.dir, fmt.Sprintf("%06d%s", index, logSegmentExt))
+}
+
+// append writes an entry with its in-process position, rotating the
+// segment when it is full.
+func (dl *diskLog) append(pos int64, entry LogEntry) {
+	line, err := json.Marshal(diskRecord{Seq: dl.baseSeq + pos, LogEntry: entry})
+	if err != nil {
+		dl.fail(err)
+		return
+	}
+	line = append(line, '\n')
+
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if dl.file == nil || dl.size+int64(len(line)) > dl.config.segmentBytes() && dl.size > 0 {
+		if err := dl.rotate(); err != nil {
+			dl.failLocked(err)
+			return
+		}
+	}
+	n, err := dl.file.Write(line)
+	dl.size += int64(n)
+	if err != nil {
+		dl.failLocked(err)
+		return
+	}
+	dl.written++
+}
+
+// rotate opens the next segment, or reopens the last one after a restart
+// while it has room.
+func (dl *diskLog) rotate() error {
+	if dl.file != nil {
+		dl.file.Close()
+		dl.file = nil
+		dl.index++
+	} else if dl.index == 0 {
+		dl.index = 1
+	} else if info, err := os.Stat(dl.segmentPath(dl.index)); err == nil && info.Size() >= dl.config.segmentBytes() {
+		dl.index++
+	}
+
+	f, err := os.OpenFile(dl.segmentPath(dl.index), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	dl.file = f
+	dl.size = info.Size()
+	dl.prune()
+	return nil
+}
+
+// prune deletes segments beyond the retention limits, never the open one.
+func (dl *diskLog) prune() {
+	segments, err := dl.segments()
+	if err != nil {
+		return
+	}
+	maxAge := time.Duration(dl.config.MaxAgeHours) * time.Hour
+	for i, index := range segments {
+		if index == dl.index {
+			break
+		}
+		path := dl.segmentPath(index)
+		expired := len(segments)-i > dl.config.maxSegments()
+		if !expired && maxAge > 0 {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > maxAge {
+				expired = true
+			}
+		}
+		if expired {
+			os.Remove(path)
+		}
+	}
+}
+
+// query returns persisted entries matching filter whose sequence is below
+// before, oldest first.
+func (dl *diskLog) query(filter LogFilter, before int64) []LogEntry {
+	dl.mu.Lock()
+	segments, _ := dl.segments()
+	dl.mu.Unlock()
+
+	var results []LogEntry
+	for _, index := range segments {
+		path := dl.segmentPath(index)
+		if filter.Since != nil {
+			// Segments last written before the window hold nothing in it
+			if info, err := os.Stat(path); err != nil || info.ModTime().Before(*filter.Since) {
+				continue
+			}
+		}
+		done := false
+		scanSegment(path, func(rec diskRecord) bool {
+			if rec.Seq >= before {
+				done = true
+				return false
+			}
+			if filter.Matches(rec.LogEntry) {
+				results = append(results, rec.LogEntry)
+			}
+			return true
+		})
+		if done {
+			break
+		}
+	}
+	return results
+}
+
+// clear deletes every segment.
+func (dl *diskLog) clear() {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if dl.file != nil {
+		dl.file.Close()
+		dl.file = nil
+	}
+	segments, _ := dl.segments()
+	for _, index := range segments {
+		os.Remove(dl.segmentPath(index))
+	}
+	dl.index = 0
+	dl.size = 0
+}
+
+// close closes the open segment; the next append reopens it.
+func (dl *diskLog) close() {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if dl.file != nil {
+		dl.file.Close()
+		dl.file = nil
+	}
+}
+
+func (dl *diskLog) stats() DiskLogStats {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	stats := DiskLogStats{Dir: dl.dir, Written: dl.written, Errors: dl.errors, LastErr: dl.lastErr}
+	segments, _ := dl.segments()
+	for _, index := range segments {
+		if info, err := os.Stat(dl.segmentPath(index)); err == nil {
+			stats.Segments++
+			stats.Bytes += info.Size()
+		}
+	}
+	return stats
+}
+
+func (dl *diskLog) fail(err error) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.failLocked(err)
+}
+
+func (dl *diskLog) failLocked(err error) {
+	dl.errors++
+	dl.lastErr = err.Error()
+}
+
+// scanSegment decodes the records of a segment until fn returns false.
+// Lines that don't decode, such as one cut short by a crash, are skipped.
+func scanSegment(path string, fn func(diskRecord) bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var rec diskRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) != nil {
+			continue
+		}
+		if !fn(rec) {
+			return
+		}
+	}
+}
+
+// lastSeq returns the highest sequence in a segment, or -1.
+func lastSeq(path string) int64 {
+	seq := int64(-1)
+	scanSegment(path, func(rec diskRecord) bool {
+		seq = max(seq, rec.Seq)
+		return true
+	})
+	return seq
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func logCustom(tl *TrafficLogger, from, to int) {
+	for i := from; i < to; i++ {
+		tl.LogCustom(CustomLog{ID: fmt.Sprintf("log-%02d", i), Timestamp: time.Now(), Level: "info", Message: "entry"})
+	}
+}
+
+func customIDs(entries []LogEntry) []string {
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.Custom.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestTrafficLogger_Persist(t *testing.T) {
+	dir := t.TempDir()
+	config := LogPersistence{Enabled: true, SegmentBytes: 400}
+
+	tl := NewTrafficLogger(3)
+	if err := tl.Persist(dir, config); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	logCustom(tl, 0, 10)
+
+	// Dropped entries come back from disk without duplicating memory
+	ids := customIDs(tl.Query(LogFilter{}))
+	if len(ids) != 10 || ids[0] != "log-00" || ids[9] != "log-09" {
+		t.Fatalf("Expected all 10 entries, got %v", ids)
+	}
+	stats := tl.Stats()
+	if stats.Disk == nil || stats.Disk.Written != 10 || stats.Disk.Segments < 2 {
+		t.Errorf("Expected 10 entries over several segments, got %+v", stats.Disk)
+	}
+	tl.Close()
+
+	// A new logger (daemon restart) reads the earlier run and continues it
+	tl = NewTrafficLogger(3)
+	if err := tl.Persist(dir, config); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	if ids := customIDs(tl.Query(LogFilter{})); len(ids) != 10 {
+		t.Errorf("Expected the previous run's entries, got %v", ids)
+	}
+	logCustom(tl, 10, 15)
+	if ids := customIDs(tl.Query(LogFilter{})); len(ids) != 15 || ids[14] != "log-14" {
+		t.Errorf("Expected 15 entries, got %v", ids)
+	}
+	if got := tl.Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}}); len(got) != 0 {
+		t.Errorf("Expected the filter applied to disk entries, got %d", len(got))
+	}
+
+	tl.Clear()
+	if got := tl.Query(LogFilter{}); len(got) != 0 {
+		t.Errorf("Expected no entries after clear, got %d", len(got))
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+logSegmentExt))
+	if len(files) != 0 {
+		t.Errorf("Expected segments deleted, got %v", files)
+	}
+	tl.Close()
+}
+
+func TestTrafficLogger_PersistRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	tl := NewTrafficLogger(2)
+	if err := tl.Persist(dir, LogPersistence{Enabled: true, SegmentBytes: 200, MaxSegments: 2}); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	defer tl.Close()
+	logCustom(tl, 0, 20)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+logSegmentExt))
+	if len(files) != 2 {
+		t.Errorf("Expected 2 segments kept, got %d", len(files))
+	}
+	ids := customIDs(tl.Query(LogFilter{}))
+	if len(ids) == 0 || len(ids) >= 20 || ids[len(ids)-1] != "log-19" {
+		t.Errorf("Expected the oldest entries pruned and the newest kept, got %v", ids)
+	}
+
+	// Segments past their age are pruned when the log is opened
+	old := time.Now().Add(-3 * time.Hour)
+	for _, f := range files {
+		os.Chtimes(f, old, old)
+	}
+	tl.Close()
+	if err := tl.Persist(dir, LogPersistence{Enabled: true, SegmentBytes: 200, MaxSegments: 2, MaxAgeHours: 1}); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	files, _ = filepath.Glob(filepath.Join(dir, "*"+logSegmentExt))
+	if len(files) != 1 {
+		t.Errorf("Expected only the last segment kept, got %v", files)
+	}
+
+	if err := (LogPersistence{MaxSegments: -1}).Validate(); err == nil {
+		t.Error("Expected error for negative max_segments")
+	}
+}
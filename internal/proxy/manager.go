@@ -25,6 +25,13 @@ type ProxyManager struct {
 
 	shutdownOnce sync.Once
 	shuttingDown atomic.Bool
+
+	// Installed on every proxy (see SessionBridge)
+	bridge atomic.Pointer[SessionBridge]
+	// Called with each entry a proxy logs (see SetLogHook)
+	logHook atomic.Pointer[func(*ProxyServer, LogEntry)]
+	// Called when a proxy is created or stopped (see SetLifecycleHook)
+	lifecycleHook atomic.Pointer[func(*ProxyServer, bool)]
 }
 
 // NewProxyManager creates a new proxy manager.
@@ -49,6 +56,13 @@ func (pm *ProxyManager) Create(ctx context.Context, config ProxyConfig) (*ProxyS
 		return nil, err
 	}
 
+	if bridge := pm.bridge.Load(); bridge != nil {
+		proxy.SetSessionBridge(*bridge)
+	}
+	if hook := pm.logHook.Load(); hook != nil {
+		proxy.setLogHook(*hook)
+	}
+
 	// Start proxy
 	if err := proxy.Start(ctx); err != nil {
 		return nil, err
@@ -59,9 +73,44 @@ func (pm *ProxyManager) Create(ctx context.Context, config ProxyConfig) (*ProxyS
 	pm.activeCount.Add(1)
 	pm.totalStarted.Add(1)
 
+	if hook := pm.lifecycleHook.Load(); hook != nil {
+		(*hook)(proxy, true)
+	}
+
 	return proxy, nil
 }
 
+// SetLifecycleHook installs hook, called with running true after a proxy is
+// created and false after Stop removes one. It must not block.
+func (pm *ProxyManager) SetLifecycleHook(hook func(ps *ProxyServer, running bool)) {
+	pm.lifecycleHook.Store(&hook)
+}
+
+// SetLogHook installs hook on the traffic logger of current and future
+// proxies, which call it with each entry they log. It must not block.
+func (pm *ProxyManager) SetLogHook(hook func(ps *ProxyServer, entry LogEntry)) {
+	pm.logHook.Store(&hook)
+	pm.proxies.Range(func(_, value interface{}) bool {
+		value.(*ProxyServer).setLogHook(hook)
+		return true
+	})
+}
+
+// setLogHook hands the proxy's logged entries to hook.
+func (ps *ProxyServer) setLogHook(hook func(ps *ProxyServer, entry LogEntry)) {
+	ps.logger.SetHook(func(entry LogEntry) { hook(ps, entry) })
+}
+
+// SetSessionBridge installs the session bridge on current and future
+// proxies.
+func (pm *ProxyManager) SetSessionBridge(bridge SessionBridge) {
+	pm.bridge.Store(&bridge)
+	pm.proxies.Range(func(_, value interface{}) bool {
+		value.(*ProxyServer).SetSessionBridge(bridge)
+		return true
+	})
+}
+
 // Get retrieves a proxy by ID with fuzzy matching support.
 // First tries exact match, then looks for proxies where the ID contains
 // the search string as a component (for compound IDs like "project:name:host-port").
@@ -143,6 +192,10 @@ func (pm *ProxyManager) Stop(ctx context.Context, id string) error {
 	pm.proxies.Delete(id)
 	pm.activeCount.Add(-1)
 
+	if hook := pm.lifecycleHook.Load(); hook != nil {
+		(*hook)(proxy, false)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MockHeader marks a mocked response with the ID of the rule that served it.
+const MockHeader = "X-Devtool-Mock"
+
+// maxMockDelay caps how long a mock rule may hold a response.
+const maxMockDelay = 5 * time.Minute
+
+// MockRule answers matching requests with a canned response instead of
+// calling the target, for endpoints that don't exist yet.
+type MockRule struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name,omitempty"`
+	Methods    []string          `json:"methods,omitempty"`  // HTTP methods (empty = all)
+	URLPattern string            `json:"url_pattern"`        // Regex matched against the path and query
+	Status     int               `json:"status,omitempty"`   // Default 200
+	Headers    map[string]string `json:"headers,omitempty"`  // Content-Type defaults to JSON for JSON-looking bodies
+	Body       string            `json:"body,omitempty"`     // Response body
+	DelayMs    int               `json:"delay_ms,omitempty"` // Wait before responding
+	Hits       int64             `json:"hits"`               // Requests served (output only)
+}
+
+// mockRuleState holds a compiled rule and its hit counter.
+type mockRuleState struct {
+	rule     MockRule
+	urlRegex *regexp.Regexp
+	hits     atomic.Int64
+}
+
+// MockEngine holds the mock rules of a proxy. The first matching rule, in
+// the order added, serves the request.
+type MockEngine struct {
+	mu    sync.RWMutex
+	rules []*mockRuleState
+}
+
+// NewMockEngine creates an empty mock engine.
+func NewMockEngine() *MockEngine {
+	return &MockEngine{}
+}
+
+// Validate checks a rule and fills in defaults.
+func (rule *MockRule) Validate() error {
+	if rule.ID == "" {
+		return fmt.Errorf("mock rule id is required")
+	}
+	if rule.URLPattern == "" {
+		return fmt.Errorf("mock rule %s: url_pattern is required", rule.ID)
+	}
+	if rule.Status == 0 {
+		rule.Status = http.StatusOK
+	}
+	if rule.Status < 100 || rule.Status > 599 {
+		return fmt.Errorf("mock rule %s: invalid status %d", rule.ID, rule.Status)
+	}
+	if rule.DelayMs < 0 || time.Duration(rule.DelayMs)*time.Millisecond > maxMockDelay {
+		return fmt.Errorf("mock rule %s: delay_ms must be between 0 and %d", rule.ID, maxMockDelay.Milliseconds())
+	}
+	for i, m := range rule.Methods {
+		rule.Methods[i] = strings.ToUpper(m)
+	}
+	return nil
+}
+
+// AddRule adds a rule, replacing any rule with the same ID in place.
+func (me *MockEngine) AddRule(rule MockRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	regex, err := regexp.Compile(rule.URLPattern)
+	if err != nil {
+		return fmt.Errorf("mock rule %s: invalid url_pattern: %w", rule.ID, err)
+	}
+	rule.Hits = 0
+	state := &mockRuleState{rule: rule, urlRegex: regex}
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	for i, r := range me.rules {
+		if r.rule.ID == rule.ID {
+			me.rules[i] = state
+			return nil
+		}
+	}
+	me.rules = append(me.rules, state)
+	return nil
+}
+
+// RemoveRule removes a rule by ID.
+func (me *MockEngine) RemoveRule(ruleID string) bool {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	for i, r := range me.rules {
+		if r.rule.ID == ruleID {
+			me.rules = append(me.rules[:i], me.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the configured rules with their hit counts.
+func (me *MockEngine) Rules() []MockRule {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	rules := make([]MockRule, len(me.rules))
+	for i, r := range me.rules {
+		rules[i] = r.rule
+		rules[i].Methods = append([]string(nil), r.rule.Methods...)
+		rules[i].Hits = r.hits.Load()
+	}
+	return rules
+}
+
+// Clear removes all rules.
+func (me *MockEngine) Clear() {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.rules = nil
+}
+
+// Match returns the rule that serves a request, counting the hit.
+func (me *MockEngine) Match(method, url string) (MockRule, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	for _, r := range me.rules {
+		if !r.matches(method, url) {
+			continue
+		}
+		r.hits.Add(1)
+		return r.rule, true
+	}
+	return MockRule{}, false
+}
+
+func (r *mockRuleState) matches(method, url string) bool {
+	if len(r.rule.Methods) > 0 {
+		methodMatch := false
+		for _, m := range r.rule.Methods {
+			if m == method {
+				methodMatch = true
+				break
+			}
+		}
+		if !methodMatch {
+			return false
+		}
+	}
+	return r.urlRegex.MatchString(url)
+}
+
+// MockEngine returns the mock engine for this proxy server.
+func (ps *ProxyServer) MockEngine() *MockEngine {
+	return ps.mockEngine
+}
+
+// serveMock writes the canned response of rule and logs it like a proxied
+// response. entry holds the request side of the log entry.
+func (ps *ProxyServer) serveMock(w http.ResponseWriter, r *http.Request, rule MockRule, entry HTTPLogEntry) {
+	if rule.DelayMs > 0 {
+		select {
+		case <-time.After(time.Duration(rule.DelayMs) * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	header := w.Header()
+	for name, value := range rule.Headers {
+		header.Set(name, value)
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", mockContentType(rule.Body))
+	}
+	header.Set(MockHeader, rule.ID)
+	w.WriteHeader(rule.Status)
+	w.Write([]byte(rule.Body))
+
+	entry.StatusCode = rule.Status
+	entry.ResponseHeaders = make(map[string]string, len(header))
+	for k, v := range header {
+		entry.ResponseHeaders[k] = strings.Join(v, ", ")
+	}
+	entry.ResponseBody = rule.Body
+	entry.Duration = time.Since(entry.Timestamp)
+	ps.traffic.record(r.Method, r.URL.Path, header.Get("Content-Type"), int64(len(rule.Body)))
+
+	ps.logger.BodyCapture().trim(&entry)
+	ps.logger.LogHTTP(entry)
+	ps.noteReplay(r, entry)
+	ps.pageTracker.TrackHTTPRequest(entry)
+}
+
+// mockContentType picks a Content-Type for a rule that didn't set one.
+func mockContentType(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
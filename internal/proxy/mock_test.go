@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProxyMock(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte("real"))
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	mocks := ps.MockEngine()
+
+	if err := mocks.AddRule(MockRule{ID: "users", Methods: []string{"get"}, URLPattern: `^/api/users(\?|$)`, Body: `[{"id":1}]`}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	if err := mocks.AddRule(MockRule{ID: "create", Methods: []string{"POST"}, URLPattern: `^/api/users$`, Status: 201, Headers: map[string]string{"Location": "/api/users/2"}, Body: "created"}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("GET", "/api/users?page=1", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != `[{"id":1}]` {
+		t.Errorf("Expected the canned response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get(MockHeader) != "users" {
+		t.Errorf("Unexpected mock headers %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("POST", "/api/users", nil))
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/api/users/2" || rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("Unexpected mock response %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("GET", "/api/orders", nil))
+	if rec.Body.String() != "real" {
+		t.Errorf("Expected unmatched requests proxied, got %q", rec.Body.String())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected only the unmatched request to reach the backend, got %d calls", calls.Load())
+	}
+
+	entries := ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}})
+	if len(entries) != 3 || entries[0].HTTP.ResponseBody != `[{"id":1}]` || entries[0].HTTP.ResponseHeaders[MockHeader] != "users" {
+		t.Errorf("Expected mocked responses logged, got %+v", entries)
+	}
+
+	// Same ID replaces the rule and resets its count
+	rules := mocks.Rules()
+	if len(rules) != 2 || rules[0].Hits != 1 || rules[0].Methods[0] != "GET" {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+	if err := mocks.AddRule(MockRule{ID: "users", URLPattern: `^/api/users`, Status: 503}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	if rules := mocks.Rules(); len(rules) != 2 || rules[0].Status != 503 || rules[0].Hits != 0 {
+		t.Errorf("Expected the rule replaced in place, got %+v", rules)
+	}
+
+	if !mocks.RemoveRule("users") || mocks.RemoveRule("users") {
+		t.Error("Expected RemoveRule to remove the rule once")
+	}
+	mocks.Clear()
+	if len(mocks.Rules()) != 0 {
+		t.Error("Expected Clear to remove all rules")
+	}
+
+	for _, bad := range []MockRule{
+		{URLPattern: "/x"},
+		{ID: "a"},
+		{ID: "a", URLPattern: "("},
+		{ID: "a", URLPattern: "/x", Status: 42},
+		{ID: "a", URLPattern: "/x", DelayMs: -1},
+	} {
+		if err := mocks.AddRule(bad); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+}
@@ -2,15 +2,16 @@ package proxy
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
-	"net"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/standardbeagle/agnt/internal/endpoint"
 )
 
-// OverlayNotifier sends events to the agent overlay server via Unix socket.
+// OverlayNotifier sends events to the agent overlay server via its socket,
+// named pipe or loopback port.
 type OverlayNotifier struct {
 	socketPath string
 	client     *http.Client
@@ -33,8 +34,9 @@ func NewOverlayNotifier() *OverlayNotifier {
 	}
 }
 
-// SetEndpoint sets the overlay socket path.
-// Example: "/run/user/1000/devtool-overlay.sock" or "\\.\pipe\devtool-overlay-user"
+// SetEndpoint sets the overlay endpoint (see package endpoint).
+// Example: "/run/user/1000/devtool-overlay.sock", "\\.\pipe\devtool-overlay-user"
+// or "tcp://127.0.0.1:51234"
 func (n *OverlayNotifier) SetEndpoint(socketPath string) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -42,14 +44,9 @@ func (n *OverlayNotifier) SetEndpoint(socketPath string) {
 	n.enabled = socketPath != ""
 
 	if n.enabled {
-		// Create HTTP client with Unix socket transport
 		n.client = &http.Client{
-			Timeout: 5 * time.Second,
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-					return net.Dial("unix", socketPath)
-				},
-			},
+			Timeout:   5 * time.Second,
+			Transport: endpoint.Transport(socketPath),
 		}
 	} else {
 		n.client = nil
@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// OverlayUI configures the language and look of the overlay injected into
+// proxied pages: the floating indicator, its panel, toasts and the banner.
+type OverlayUI struct {
+	Language string            `json:"language,omitempty"` // en (default), de, es, fr, ja, pt; "pt-BR" falls back to "pt", then English
+	Messages map[string]string `json:"messages,omitempty"` // Strings by key, over the language's (see OverlayUIMessageKeys)
+	Theme    string            `json:"theme,omitempty"`    // auto (default: follows the OS), light or dark
+	Position string            `json:"position,omitempty"` // bottom-left (default), bottom-right, top-left or top-right
+	Accent   string            `json:"accent,omitempty"`   // CSS color of the indicator and highlights
+	Minimal  bool              `json:"minimal,omitempty"`  // Small indicator, no output previews, only error and warning toasts
+
+	// ReducedMotion turns off the overlay's animations even where the OS
+	// doesn't ask for reduced motion (it always follows the OS when it does).
+	ReducedMotion bool `json:"reduced_motion,omitempty"`
+	// Hidden draws nothing: no indicator, panel, toasts or banner. Pages are
+	// still instrumented, so logs, audits and page sessions keep working.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// OverlayUIState is what the overlay applies: the options with the
+// language's strings resolved.
+type OverlayUIState struct {
+	Language string            `json:"language"`
+	Messages map[string]string `json:"messages,omitempty"`
+	Theme    string            `json:"theme"`
+	Position string            `json:"position"`
+	Accent   string            `json:"accent,omitempty"`
+	Minimal  bool              `json:"minimal,omitempty"`
+
+	ReducedMotion bool `json:"reduced_motion,omitempty"`
+	Hidden        bool `json:"hidden,omitempty"`
+}
+
+var (
+	overlayThemes    = []string{"auto", "light", "dark"}
+	overlayPositions = []string{"bottom-left", "bottom-right", "top-left", "top-right"}
+)
+
+// IsZero reports whether the overlay keeps its defaults.
+func (u OverlayUI) IsZero() bool {
+	return u.Language == "" && len(u.Messages) == 0 && u.Theme == "" && u.Position == "" && u.Accent == "" && !u.Minimal &&
+		!u.ReducedMotion && !u.Hidden
+}
+
+// Validate checks the overlay options.
+func (u OverlayUI) Validate() error {
+	if u.Theme != "" && !slices.Contains(overlayThemes, u.Theme) {
+		return fmt.Errorf("invalid overlay theme %q: use %s", u.Theme, strings.Join(overlayThemes, ", "))
+	}
+	if u.Position != "" && !slices.Contains(overlayPositions, u.Position) {
+		return fmt.Errorf("invalid overlay position %q: use %s", u.Position, strings.Join(overlayPositions, ", "))
+	}
+	if u.Accent != "" && strings.ContainsAny(u.Accent, ";{}<>\"") {
+		return fmt.Errorf("invalid overlay accent %q: use a CSS color", u.Accent)
+	}
+	for key := range u.Messages {
+		if _, ok := overlayMessagesEN[key]; !ok {
+			return fmt.Errorf("unknown overlay message key %q (see OverlayUIMessageKeys)", key)
+		}
+	}
+	return nil
+}
+
+// State resolves the options into what the overlay applies. Messages hold
+// only strings that differ from English, which the overlay has built in.
+func (u OverlayUI) State() OverlayUIState {
+	state := OverlayUIState{
+		Language: "en",
+		Theme:    u.Theme,
+		Position: u.Position,
+		Accent:   u.Accent,
+		Minimal:  u.Minimal,
+
+		ReducedMotion: u.ReducedMotion,
+		Hidden:        u.Hidden,
+	}
+	if state.Theme == "" {
+		state.Theme = "auto"
+	}
+	if state.Position == "" {
+		state.Position = "bottom-left"
+	}
+	messages := map[string]string{}
+	if lang, catalog := overlayCatalog(u.Language); catalog != nil {
+		state.Language = lang
+		maps.Copy(messages, catalog)
+	}
+	maps.Copy(messages, u.Messages)
+	if len(messages) > 0 {
+		state.Messages = messages
+	}
+	return state
+}
+
+// overlayCatalog returns the built-in strings of a language tag, trying
+// "pt-BR" and then "pt", or nil for English and unknown languages.
+func overlayCatalog(tag string) (string, map[string]string) {
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	for tag != "" {
+		if catalog, ok := overlayCatalogs[tag]; ok {
+			return tag, catalog
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return "", nil
+}
+
+// OverlayUIMessageKeys lists the keys of the overlay's strings.
+func OverlayUIMessageKeys() []string {
+	keys := slices.Collect(maps.Keys(overlayMessagesEN))
+	sort.Strings(keys)
+	return keys
+}
+
+// OverlayUILanguages lists the languages with built-in strings.
+func OverlayUILanguages() []string {
+	langs := append([]string{"en"}, slices.Collect(maps.Keys(overlayCatalogs))...)
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// OverlayUI returns the proxy's overlay options.
+func (ps *ProxyServer) OverlayUI() OverlayUI {
+	if ui := ps.overlayUI.Load(); ui != nil {
+		return *ui
+	}
+	return OverlayUI{}
+}
+
+// SetOverlayUI replaces the overlay options and applies them to the pages
+// connected now. Returns the number of pages that received them.
+func (ps *ProxyServer) SetOverlayUI(ui OverlayUI) (int, error) {
+	if err := ui.Validate(); err != nil {
+		return 0, err
+	}
+	ps.overlayUI.Store(&ui)
+
+	message := ps.overlayUIMessage()
+	sentCount := 0
+	ps.wsConns.Range(func(key, value interface{}) bool {
+		conn := value.(*websocket.Conn)
+		if err := conn.WriteMessage(websocket.TextMessage, message); err == nil {
+			sentCount++
+		}
+		return true
+	})
+	return sentCount, nil
+}
+
+// overlayUIAttribute returns the overlay state encoded for the injected
+// script tag, or "" when the overlay keeps its defaults.
+func (ps *ProxyServer) overlayUIAttribute() string {
+	ui := ps.OverlayUI()
+	if ui.IsZero() {
+		return ""
+	}
+	data, err := json.Marshal(ui.State())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// overlayUIMessage returns the WebSocket message carrying the overlay state.
+func (ps *ProxyServer) overlayUIMessage() []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":    "ui",
+		"payload": ps.OverlayUI().State(),
+	})
+	return data
+}
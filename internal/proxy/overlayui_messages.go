@@ -0,0 +1,207 @@
+package proxy
+
+// overlayMessagesEN holds the overlay's strings by key. The overlay has them
+// built in; they define the keys OverlayUI.Messages may set.
+var overlayMessagesEN = map[string]string{
+	"banner.label":             "agnt dev",
+	"indicator.label":          "agnt DevTool",
+	"indicator.disconnected":   "disconnected",
+	"panel.label":              "agnt DevTool panel",
+	"panel.tabs":               "Panel sections",
+	"toast.region":             "agnt notifications",
+	"tab.overview":             "Overview",
+	"tab.errors":               "Errors",
+	"tab.network":              "Network",
+	"tab.performance":          "Perf",
+	"tab.quality":              "Quality",
+	"tab.interactions":         "Interact",
+	"tab.compose":              "Compose",
+	"panel.close":              "Close panel",
+	"compose.placeholder":      "Describe what you need help with... (Ctrl+Enter to send)",
+	"compose.send":             "Send",
+	"compose.send_title":       "Send message (Ctrl+Enter)",
+	"tool.screenshot":          "Screenshot",
+	"tool.element":             "Element",
+	"tool.sketch":              "Sketch",
+	"tool.design":              "Design",
+	"tool.to_terminal":         "To terminal",
+	"tool.to_terminal_title":   "Type the selected page text (or the message) into the agent prompt",
+	"tool.terminal_clip":       "Terminal clip",
+	"tool.terminal_clip_title": "Insert the text last shared from the terminal",
+	"tool.file":                "File",
+	"tool.file_title":          "Upload a file to the project for the agent (or drop it on the message box)",
+	"capture.area_hint":        "Click and drag to select area • ESC to cancel",
+	"capture.element_hint":     "Click an element to select • ESC to cancel",
+	"toast.error":              "Error",
+	"toast.warning":            "Warning",
+	"toast.dismiss":            "Dismiss",
+}
+
+// overlayCatalogs holds the built-in translations by language tag.
+var overlayCatalogs = map[string]map[string]string{
+	"de": {
+		"indicator.label":          "agnt DevTool",
+		"indicator.disconnected":   "getrennt",
+		"panel.label":              "agnt DevTool-Panel",
+		"panel.tabs":               "Panel-Bereiche",
+		"toast.region":             "agnt-Benachrichtigungen",
+		"tab.overview":             "Übersicht",
+		"tab.errors":               "Fehler",
+		"tab.network":              "Netzwerk",
+		"tab.performance":          "Leistung",
+		"tab.quality":              "Qualität",
+		"tab.interactions":         "Aktionen",
+		"tab.compose":              "Nachricht",
+		"panel.close":              "Panel schließen",
+		"compose.placeholder":      "Beschreibe, wobei du Hilfe brauchst … (Strg+Enter zum Senden)",
+		"compose.send":             "Senden",
+		"compose.send_title":       "Nachricht senden (Strg+Enter)",
+		"tool.screenshot":          "Screenshot",
+		"tool.element":             "Element",
+		"tool.sketch":              "Skizze",
+		"tool.design":              "Design",
+		"tool.to_terminal":         "Ins Terminal",
+		"tool.to_terminal_title":   "Markierten Seitentext (oder die Nachricht) in den Agent-Prompt tippen",
+		"tool.terminal_clip":       "Terminal-Text",
+		"tool.terminal_clip_title": "Zuletzt im Terminal geteilten Text einfügen",
+		"tool.file":                "Datei",
+		"tool.file_title":          "Datei für den Agenten ins Projekt hochladen (oder auf das Nachrichtenfeld ziehen)",
+		"capture.area_hint":        "Bereich mit gedrückter Maustaste auswählen • ESC zum Abbrechen",
+		"capture.element_hint":     "Element anklicken • ESC zum Abbrechen",
+		"toast.error":              "Fehler",
+		"toast.warning":            "Warnung",
+		"toast.dismiss":            "Schließen",
+	},
+	"es": {
+		"indicator.label":          "agnt DevTool",
+		"indicator.disconnected":   "desconectado",
+		"panel.label":              "Panel de agnt DevTool",
+		"panel.tabs":               "Secciones del panel",
+		"toast.region":             "Notificaciones de agnt",
+		"tab.overview":             "Resumen",
+		"tab.errors":               "Errores",
+		"tab.network":              "Red",
+		"tab.performance":          "Rendimiento",
+		"tab.quality":              "Calidad",
+		"tab.interactions":         "Acciones",
+		"tab.compose":              "Mensaje",
+		"panel.close":              "Cerrar panel",
+		"compose.placeholder":      "Describe con qué necesitas ayuda... (Ctrl+Intro para enviar)",
+		"compose.send":             "Enviar",
+		"compose.send_title":       "Enviar mensaje (Ctrl+Intro)",
+		"tool.screenshot":          "Captura",
+		"tool.element":             "Elemento",
+		"tool.sketch":              "Boceto",
+		"tool.design":              "Diseño",
+		"tool.to_terminal":         "Al terminal",
+		"tool.to_terminal_title":   "Escribir el texto seleccionado (o el mensaje) en el prompt del agente",
+		"tool.terminal_clip":       "Texto del terminal",
+		"tool.terminal_clip_title": "Insertar el último texto compartido desde el terminal",
+		"tool.file":                "Archivo",
+		"tool.file_title":          "Subir un archivo al proyecto para el agente (o soltarlo sobre el mensaje)",
+		"capture.area_hint":        "Haz clic y arrastra para seleccionar un área • ESC para cancelar",
+		"capture.element_hint":     "Haz clic en un elemento para seleccionarlo • ESC para cancelar",
+		"toast.error":              "Error",
+		"toast.warning":            "Aviso",
+		"toast.dismiss":            "Cerrar",
+	},
+	"fr": {
+		"indicator.label":          "agnt DevTool",
+		"indicator.disconnected":   "déconnecté",
+		"panel.label":              "Panneau agnt DevTool",
+		"panel.tabs":               "Sections du panneau",
+		"toast.region":             "Notifications agnt",
+		"tab.overview":             "Aperçu",
+		"tab.errors":               "Erreurs",
+		"tab.network":              "Réseau",
+		"tab.performance":          "Perf",
+		"tab.quality":              "Qualité",
+		"tab.interactions":         "Actions",
+		"tab.compose":              "Message",
+		"panel.close":              "Fermer le panneau",
+		"compose.placeholder":      "Décrivez ce pour quoi vous avez besoin d'aide... (Ctrl+Entrée pour envoyer)",
+		"compose.send":             "Envoyer",
+		"compose.send_title":       "Envoyer le message (Ctrl+Entrée)",
+		"tool.screenshot":          "Capture",
+		"tool.element":             "Élément",
+		"tool.sketch":              "Croquis",
+		"tool.design":              "Design",
+		"tool.to_terminal":         "Vers le terminal",
+		"tool.to_terminal_title":   "Saisir le texte sélectionné (ou le message) dans le prompt de l'agent",
+		"tool.terminal_clip":       "Texte du terminal",
+		"tool.terminal_clip_title": "Insérer le dernier texte partagé depuis le terminal",
+		"tool.file":                "Fichier",
+		"tool.file_title":          "Envoyer un fichier au projet pour l'agent (ou le déposer sur le message)",
+		"capture.area_hint":        "Cliquez-glissez pour sélectionner une zone • Échap pour annuler",
+		"capture.element_hint":     "Cliquez sur un élément pour le sélectionner • Échap pour annuler",
+		"toast.error":              "Erreur",
+		"toast.warning":            "Avertissement",
+		"toast.dismiss":            "Fermer",
+	},
+	"ja": {
+		"indicator.label":          "agnt DevTool",
+		"indicator.disconnected":   "切断",
+		"panel.label":              "agnt DevTool パネル",
+		"panel.tabs":               "パネルのセクション",
+		"toast.region":             "agnt の通知",
+		"tab.overview":             "概要",
+		"tab.errors":               "エラー",
+		"tab.network":              "通信",
+		"tab.performance":          "性能",
+		"tab.quality":              "品質",
+		"tab.interactions":         "操作",
+		"tab.compose":              "メッセージ",
+		"panel.close":              "パネルを閉じる",
+		"compose.placeholder":      "手伝ってほしい内容を入力… (Ctrl+Enter で送信)",
+		"compose.send":             "送信",
+		"compose.send_title":       "メッセージを送信 (Ctrl+Enter)",
+		"tool.screenshot":          "スクリーンショット",
+		"tool.element":             "要素",
+		"tool.sketch":              "スケッチ",
+		"tool.design":              "デザイン",
+		"tool.to_terminal":         "ターミナルへ",
+		"tool.to_terminal_title":   "選択したテキスト (またはメッセージ) をエージェントのプロンプトに入力",
+		"tool.terminal_clip":       "ターミナルの共有",
+		"tool.terminal_clip_title": "ターミナルから最後に共有されたテキストを挿入",
+		"tool.file":                "ファイル",
+		"tool.file_title":          "エージェント用にファイルをプロジェクトへアップロード (メッセージ欄へのドロップも可)",
+		"capture.area_hint":        "ドラッグして範囲を選択 • ESC でキャンセル",
+		"capture.element_hint":     "要素をクリックして選択 • ESC でキャンセル",
+		"toast.error":              "エラー",
+		"toast.warning":            "警告",
+		"toast.dismiss":            "閉じる",
+	},
+	"pt": {
+		"indicator.label":          "agnt DevTool",
+		"indicator.disconnected":   "desconectado",
+		"panel.label":              "Painel do agnt DevTool",
+		"panel.tabs":               "Seções do painel",
+		"toast.region":             "Notificações do agnt",
+		"tab.overview":             "Resumo",
+		"tab.errors":               "Erros",
+		"tab.network":              "Rede",
+		"tab.performance":          "Desempenho",
+		"tab.quality":              "Qualidade",
+		"tab.interactions":         "Ações",
+		"tab.compose":              "Mensagem",
+		"panel.close":              "Fechar painel",
+		"compose.placeholder":      "Descreva com o que precisa de ajuda... (Ctrl+Enter para enviar)",
+		"compose.send":             "Enviar",
+		"compose.send_title":       "Enviar mensagem (Ctrl+Enter)",
+		"tool.screenshot":          "Captura",
+		"tool.element":             "Elemento",
+		"tool.sketch":              "Esboço",
+		"tool.design":              "Design",
+		"tool.to_terminal":         "Para o terminal",
+		"tool.to_terminal_title":   "Digitar o texto selecionado (ou a mensagem) no prompt do agente",
+		"tool.terminal_clip":       "Texto do terminal",
+		"tool.terminal_clip_title": "Inserir o último texto compartilhado pelo terminal",
+		"tool.file":                "Arquivo",
+		"tool.file_title":          "Enviar um arquivo ao projeto para o agente (ou soltá-lo na mensagem)",
+		"capture.area_hint":        "Clique e arraste para selecionar uma área • ESC para cancelar",
+		"capture.element_hint":     "Clique em um elemento para selecioná-lo • ESC para cancelar",
+		"toast.error":              "Erro",
+		"toast.warning":            "Aviso",
+		"toast.dismiss":            "Fechar",
+	},
+}
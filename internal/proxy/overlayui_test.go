@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOverlayUIValidate(t *testing.T) {
+	valid := OverlayUI{Language: "de", Theme: "dark", Position: "top-right", Accent: "#0ea5e9", Messages: map[string]string{"banner.label": "shop"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	for name, ui := range map[string]OverlayUI{
+		"theme":    {Theme: "sepia"},
+		"position": {Position: "center"},
+		"accent":   {Accent: "red; display: none"},
+		"message":  {Messages: map[string]string{"tab.unknown": "x"}},
+	} {
+		if err := ui.Validate(); err == nil {
+			t.Errorf("%s: expected an error for %+v", name, ui)
+		}
+	}
+}
+
+func TestOverlayUIState(t *testing.T) {
+	state := OverlayUI{}.State()
+	if state.Language != "en" || state.Theme != "auto" || state.Position != "bottom-left" || state.Messages != nil {
+		t.Errorf("Unexpected defaults %+v", state)
+	}
+
+	state = OverlayUI{Language: "pt-BR", Messages: map[string]string{"tab.errors": "Falhas"}}.State()
+	if state.Language != "pt" {
+		t.Errorf("Expected pt-BR to fall back to pt, got %q", state.Language)
+	}
+	if state.Messages["tab.network"] != "Rede" || state.Messages["tab.errors"] != "Falhas" {
+		t.Errorf("Expected the catalog with the override on top, got %v", state.Messages)
+	}
+
+	if state := (OverlayUI{Language: "ko"}).State(); state.Language != "en" || state.Messages != nil {
+		t.Errorf("Expected English for an unknown language, got %+v", state)
+	}
+
+	ui := OverlayUI{ReducedMotion: true, Hidden: true}
+	if ui.IsZero() {
+		t.Error("Expected reduced motion and hidden to count as non-default options")
+	}
+	if state := ui.State(); !state.ReducedMotion || !state.Hidden {
+		t.Errorf("Expected reduced motion and hidden in the state, got %+v", state)
+	}
+}
+
+func TestOverlayUICatalogsComplete(t *testing.T) {
+	for lang, catalog := range overlayCatalogs {
+		for key := range catalog {
+			if _, ok := overlayMessagesEN[key]; !ok {
+				t.Errorf("%s: unknown key %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestOverlayUIInjected(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><head></head><body>hi</body></html>")
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	attr := regexp.MustCompile(`data-devtool-ui="([^"]*)"`)
+
+	rec := httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("GET", "/", nil))
+	if attr.MatchString(rec.Body.String()) {
+		t.Error("Expected no overlay attribute while the overlay keeps its defaults")
+	}
+
+	if _, err := ps.SetOverlayUI(OverlayUI{Theme: "dark", Position: "bottom-right"}); err != nil {
+		t.Fatalf("SetOverlayUI: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	ps.handleProxy(rec, httptest.NewRequest("GET", "/", nil))
+	match := attr.FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatal("Expected the overlay attribute on the injected script")
+	}
+	if got := html.UnescapeString(match[1]); !strings.Contains(got, `"theme":"dark"`) || !strings.Contains(got, `"position":"bottom-right"`) {
+		t.Errorf("Unexpected overlay attribute %s", got)
+	}
+
+	if _, err := ps.SetOverlayUI(OverlayUI{Theme: "sepia"}); err == nil {
+		t.Error("Expected an invalid theme to be rejected")
+	}
+	if ps.OverlayUI().Theme != "dark" {
+		t.Error("Expected a rejected update to keep the previous options")
+	}
+}
@@ -44,6 +44,7 @@ type PageSession struct {
 	Resources       []HTTPLogEntry     `json:"resources"`
 	Errors          []FrontendError    `json:"errors,omitempty"`
 	Performance     *PerformanceMetric `json:"performance,omitempty"`
+	WebVitals       *WebVitals         `json:"web_vitals,omitempty"` // Of the most recent page load
 
 	// User interaction tracking
 	Interactions     []InteractionEvent `json:"interactions,omitempty"`
@@ -152,6 +153,25 @@ func (pt *PageTracker) TrackPerformance(perf PerformanceMetric, browserSessionID
 	pt.updateSessionWithBrowserID(sessionID, session, browserSessionID)
 }
 
+// TrackWebVitals associates the Core Web Vitals of a page load with a page
+// session, replacing those of earlier loads.
+// browserSessionID is the unique ID from the browser tab's sessionStorage.
+func (pt *PageTracker) TrackWebVitals(vitals WebVitals, browserSessionID string) {
+	sessionID := pt.ResolveSession(browserSessionID, vitals.URL)
+	if sessionID == "" {
+		return
+	}
+
+	val, ok := pt.sessions.Load(sessionID)
+	if !ok {
+		return
+	}
+
+	session := val.(*PageSession)
+	session.WebVitals = &vitals
+	pt.updateSessionWithBrowserID(sessionID, session, browserSessionID)
+}
+
 // TrackInteraction associates a user interaction event with a page session.
 // browserSessionID is the unique ID from the browser tab's sessionStorage.
 func (pt *PageTracker) TrackInteraction(interaction InteractionEvent, browserSessionID string) {
@@ -627,6 +647,39 @@ func itoa(i int) string {
 	return string(buf[pos:])
 }
 
+// entrySessions maps the IDs of tracked HTTP entries to the browser session
+// in their cookie and its page session. Tracked entries keep the cookie the
+// traffic log masks.
+func (pt *PageTracker) entrySessions() map[string][]string {
+	ids := make(map[string][]string)
+	add := func(entry HTTPLogEntry) {
+		sid := extractBrowserSessionID(entry.RequestHeaders)
+		if sid == "" {
+			return
+		}
+		sessions := []string{sid}
+		if page := pt.findSessionByBrowserSession(sid); page != "" {
+			sessions = append(sessions, page)
+		}
+		ids[entry.ID] = sessions
+	}
+	pt.sessions.Range(func(_, value any) bool {
+		session := value.(*PageSession)
+		for _, entry := range session.Navigations {
+			add(entry)
+		}
+		for _, entry := range session.Resources {
+			add(entry)
+		}
+		return true
+	})
+	return ids
+}
+
+// browserSessionCookie carries the browser tab's session ID, set by the
+// injected script.
+const browserSessionCookie = "__devtool_sid"
+
 // extractBrowserSessionID extracts the __devtool_sid cookie from request headers.
 func extractBrowserSessionID(headers map[string]string) string {
 	// Try both capitalized and lowercase header names
@@ -639,12 +692,11 @@ func extractBrowserSessionID(headers map[string]string) string {
 	}
 
 	// Parse cookies - format is "name1=value1; name2=value2"
-	const cookieName = "__devtool_sid"
 	cookies := strings.Split(cookieHeader, ";")
 	for _, cookie := range cookies {
 		cookie = strings.TrimSpace(cookie)
-		if strings.HasPrefix(cookie, cookieName+"=") {
-			return strings.TrimPrefix(cookie, cookieName+"=")
+		if strings.HasPrefix(cookie, browserSessionCookie+"=") {
+			return strings.TrimPrefix(cookie, browserSessionCookie+"=")
 		}
 	}
 	return ""
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PanelResourcePrefix starts the MCP resource URI of a panel attachment:
+// agnt://panel/{proxy_id}/{message_id}/{index}.
+const PanelResourcePrefix = "agnt://panel/"
+
+// PanelAttachmentURI names an attachment of a panel message as an MCP
+// resource.
+func PanelAttachmentURI(proxyID, messageID string, index int) string {
+	return PanelResourcePrefix + url.PathEscape(proxyID) + "/" + url.PathEscape(messageID) + "/" + strconv.Itoa(index)
+}
+
+// ParsePanelAttachmentURI splits a URI made by PanelAttachmentURI.
+func ParsePanelAttachmentURI(uri string) (proxyID, messageID string, index int, err error) {
+	parts := strings.Split(strings.TrimPrefix(uri, PanelResourcePrefix), "/")
+	if !strings.HasPrefix(uri, PanelResourcePrefix) || len(parts) != 3 {
+		return "", "", 0, fmt.Errorf("not a panel attachment URI: %s", uri)
+	}
+	if proxyID, err = url.PathUnescape(parts[0]); err != nil {
+		return "", "", 0, err
+	}
+	if messageID, err = url.PathUnescape(parts[1]); err != nil {
+		return "", "", 0, err
+	}
+	if index, err = strconv.Atoi(parts[2]); err != nil || index < 0 {
+		return "", "", 0, fmt.Errorf("invalid attachment index in %s", uri)
+	}
+	return proxyID, messageID, index, nil
+}
+
+// HasContent reports whether the attachment carries an image or file an
+// assistant can read: a saved file or inline screenshot data.
+func (a PanelAttachment) HasContent() bool {
+	return a.FilePath() != "" || (a.Area != nil && a.Area.Data != "")
+}
+
+// FilePath returns the file the attachment was saved to, if any.
+func (a PanelAttachment) FilePath() string {
+	path, _ := a.Data["file_path"].(string)
+	return path
+}
+
+// linkResources records the resource URI of each attachment with content,
+// so the agent reading the message can fetch it.
+func (m *PanelMessage) linkResources(proxyID string) {
+	for i := range m.Attachments {
+		if !m.Attachments[i].HasContent() {
+			continue
+		}
+		if m.Attachments[i].Data == nil {
+			m.Attachments[i].Data = make(map[string]interface{})
+		}
+		m.Attachments[i].Data["resource_uri"] = PanelAttachmentURI(proxyID, m.ID, i)
+	}
+}
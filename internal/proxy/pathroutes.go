@@ -0,0 +1,199 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PathRoute sends requests whose path starts with a prefix to their own
+// upstream, so one proxy can front an API on :8000 and a dev server on
+// :5173. The longest matching prefix wins; requests matching no path route
+// go to host routes and then the proxy's target.
+type PathRoute struct {
+	// Path is a path prefix ("/api"), matching "/api" and "/api/...". A
+	// trailing "/*" is accepted.
+	Path string `json:"path"`
+	// Target is an upstream URL, or a port on the proxy target's host.
+	// Only its scheme and host are used.
+	Target string `json:"target"`
+	// StripPrefix removes Path from the request path sent upstream.
+	StripPrefix bool `json:"strip_prefix,omitempty"`
+}
+
+// pathRoute is a PathRoute with its target resolved.
+type pathRoute struct {
+	PathRoute
+	target *url.URL
+}
+
+// compilePathRoute normalizes a route's path and resolves its target.
+func compilePathRoute(r PathRoute, defaultTarget *url.URL) (pathRoute, error) {
+	path := strings.TrimSuffix(strings.TrimSpace(r.Path), "*")
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if !strings.HasPrefix(path, "/") || strings.Contains(path, "*") {
+		return pathRoute{}, fmt.Errorf("invalid route path %q: a prefix like /api is required", r.Path)
+	}
+	r.Path = path
+	target, err := resolveRouteTarget(r.Target, defaultTarget)
+	if err != nil {
+		return pathRoute{}, fmt.Errorf("route %s: %w", r.Path, err)
+	}
+	return pathRoute{PathRoute: r, target: target}, nil
+}
+
+// compilePathRoutes compiles routes, longest prefix first. A later route
+// for the same path replaces an earlier one.
+func compilePathRoutes(routes []PathRoute, defaultTarget *url.URL) ([]pathRoute, error) {
+	var compiled []pathRoute
+	for _, r := range routes {
+		route, err := compilePathRoute(r, defaultTarget)
+		if err != nil {
+			return nil, err
+		}
+		compiled = withPathRoute(compiled, route)
+	}
+	return compiled, nil
+}
+
+// withPathRoute returns routes plus route, replacing a route for the same
+// path, ordered longest prefix first.
+func withPathRoute(routes []pathRoute, route pathRoute) []pathRoute {
+	out := make([]pathRoute, 0, len(routes)+1)
+	for _, r := range routes {
+		if r.Path != route.Path {
+			out = append(out, r)
+		}
+	}
+	out = append(out, route)
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(out[i].Path) > len(out[j].Path)
+	})
+	return out
+}
+
+// matches reports whether a request path is routed by r.
+func (r *pathRoute) matches(path string) bool {
+	return r.Path == "/" || path == r.Path || strings.HasPrefix(path, r.Path+"/")
+}
+
+// upstreamPath returns the path sent upstream for a request path.
+func (r *pathRoute) upstreamPath(path string) string {
+	if !r.StripPrefix || r.Path == "/" {
+		return path
+	}
+	if path = strings.TrimPrefix(path, r.Path); path == "" {
+		return "/"
+	}
+	return path
+}
+
+// PathRoutes returns the proxy's path routes, longest prefix first.
+func (ps *ProxyServer) PathRoutes() []PathRoute {
+	ps.pathRoutesMu.RLock()
+	defer ps.pathRoutesMu.RUnlock()
+	routes := make([]PathRoute, len(ps.pathRoutes))
+	for i, r := range ps.pathRoutes {
+		routes[i] = r.PathRoute
+	}
+	return routes
+}
+
+// AddPathRoute adds a path route, replacing the route for the same path.
+func (ps *ProxyServer) AddPathRoute(r PathRoute) (PathRoute, error) {
+	route, err := compilePathRoute(r, ps.Target())
+	if err != nil {
+		return r, err
+	}
+	ps.pathRoutesMu.Lock()
+	defer ps.pathRoutesMu.Unlock()
+	ps.pathRoutes = withPathRoute(ps.pathRoutes, route)
+	return route.PathRoute, nil
+}
+
+// RemovePathRoute removes the route for a path and reports whether it existed.
+func (ps *ProxyServer) RemovePathRoute(path string) bool {
+	path = strings.TrimSuffix(strings.TrimSpace(path), "*")
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	ps.pathRoutesMu.Lock()
+	defer ps.pathRoutesMu.Unlock()
+	for i, r := range ps.pathRoutes {
+		if r.Path == path {
+			ps.pathRoutes = append(ps.pathRoutes[:i:i], ps.pathRoutes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// pathRouteFor returns the route for a request path, or nil.
+func (ps *ProxyServer) pathRouteFor(path string) *pathRoute {
+	ps.pathRoutesMu.RLock()
+	defer ps.pathRoutesMu.RUnlock()
+	for i := range ps.pathRoutes {
+		if ps.pathRoutes[i].matches(path) {
+			route := ps.pathRoutes[i]
+			return &route
+		}
+	}
+	return nil
+}
+
+// applyPathRoute points a request at its path route's upstream and reports
+// whether one matched. path is the request path before the director ran.
+func (ps *ProxyServer) applyPathRoute(req *http.Request, path string) bool {
+	route := ps.pathRouteFor(path)
+	if route == nil {
+		return false
+	}
+	req.URL.Scheme = route.target.Scheme
+	req.URL.Host = route.target.Host
+	req.URL.Path = route.upstreamPath(path)
+	req.URL.RawPath = ""
+	req.Host = route.target.Host
+	return true
+}
+
+// rewritePathRoutedURL rewrites an absolute URL pointing at a path route's
+// upstream to the proxy, restoring a stripped prefix.
+func (ps *ProxyServer) rewritePathRoutedURL(rawURL string) string {
+	ps.pathRoutesMu.RLock()
+	defer ps.pathRoutesMu.RUnlock()
+	if len(ps.pathRoutes) == 0 {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	for _, r := range ps.pathRoutes {
+		if !strings.EqualFold(parsed.Host, r.target.Host) {
+			continue
+		}
+		if r.StripPrefix && r.Path != "/" {
+			parsed.Path = r.Path + parsed.Path
+			parsed.RawPath = ""
+		}
+		parsed.Scheme = ps.getProxyScheme()
+		parsed.Host = ps.getProxyHost()
+		return parsed.String()
+	}
+	return rawURL
+}
+
+// ValidatePathRoutes reports the first invalid path route for a proxy to
+// targetURL.
+func ValidatePathRoutes(routes []PathRoute, targetURL string) error {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("invalid target URL: %w", err)
+	}
+	_, err = compilePathRoutes(routes, target)
+	return err
+}
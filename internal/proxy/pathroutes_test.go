@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCompilePathRoutes(t *testing.T) {
+	target, _ := url.Parse("http://localhost:5173")
+	routes, err := compilePathRoutes([]PathRoute{
+		{Path: "/api/*", Target: "8000"},
+		{Path: "/api/admin", Target: "http://127.0.0.1:9000/ignored"},
+		{Path: "/api/", Target: "8001"},
+	}, target)
+	if err != nil {
+		t.Fatalf("compilePathRoutes failed: %v", err)
+	}
+	if len(routes) != 2 || routes[0].Path != "/api/admin" || routes[0].target.String() != "http://127.0.0.1:9000" {
+		t.Fatalf("Expected longest prefix first, got %+v", routes)
+	}
+	if routes[1].Path != "/api" || routes[1].target.String() != "http://localhost:8001" {
+		t.Errorf("Expected the later /api route to replace the first, got %+v", routes[1])
+	}
+
+	for _, tc := range []struct {
+		path string
+		want bool
+	}{
+		{"/api", true},
+		{"/api/users", true},
+		{"/apiary", false},
+		{"/", false},
+	} {
+		if got := routes[1].matches(tc.path); got != tc.want {
+			t.Errorf("matches(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+
+	for _, bad := range []PathRoute{
+		{Path: "api", Target: "8000"},
+		{Path: "/a*b", Target: "8000"},
+		{Path: "/api", Target: "localhost"},
+	} {
+		if _, err := compilePathRoutes([]PathRoute{bad}, target); err == nil {
+			t.Errorf("Expected error for route %+v", bad)
+		}
+	}
+}
+
+func TestPathRouting(t *testing.T) {
+	var apiBase string
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/redirect" {
+				http.Redirect(w, r, apiBase+"/login", http.StatusFound)
+				return
+			}
+			io.WriteString(w, name+" "+r.URL.Path)
+		}
+	}
+	vite := httptest.NewServer(handler("vite"))
+	defer vite.Close()
+	api := httptest.NewServer(handler("api"))
+	defer api.Close()
+	apiBase = api.URL
+
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:         "paths",
+		TargetURL:  vite.URL,
+		ListenPort: 8080,
+		PathRoutes: []PathRoute{{Path: "/api", Target: api.URL}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Host = "localhost:8080"
+		rec := httptest.NewRecorder()
+		ps.proxy.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for path, want := range map[string]string{
+		"/api/users": "api /api/users",
+		"/src/main":  "vite /src/main",
+		"/apiary":    "vite /apiary",
+	} {
+		if body := get(path).Body.String(); body != want {
+			t.Errorf("%s: expected %q, got %q", path, want, body)
+		}
+	}
+
+	// Routes change at runtime; stripped prefixes come back in redirects
+	if _, err := ps.AddPathRoute(PathRoute{Path: "/api", Target: api.URL, StripPrefix: true}); err != nil {
+		t.Fatalf("AddPathRoute failed: %v", err)
+	}
+	if body := get("/api/users").Body.String(); body != "api /users" {
+		t.Errorf("Expected prefix stripped, got %q", body)
+	}
+	if loc := get("/api/redirect").Header().Get("Location"); loc != "http://localhost:8080/api/login" {
+		t.Errorf("Expected redirect through the proxy, got %q", loc)
+	}
+	if routes := ps.Stats().PathRoutes; len(routes) != 1 || !routes[0].StripPrefix {
+		t.Errorf("Unexpected path routes in stats %+v", routes)
+	}
+
+	if !ps.RemovePathRoute("/api/*") || ps.RemovePathRoute("/api") {
+		t.Error("Expected the route removed once")
+	}
+	if body := get("/api/users").Body.String(); body != "vite /api/users" {
+		t.Errorf("Expected the target after removal, got %q", body)
+	}
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Payload encryption protects instrumentation traffic from the injected script
+// to the proxy when it crosses third-party infrastructure (e.g. a tunnel).
+//
+// Key exchange: the proxy holds a P-256 key pair and injects its public key
+// into each page. On every WebSocket connection the script generates an
+// ephemeral key pair, sends its public key in a plaintext "key_exchange"
+// message, and both sides derive an AES-256-GCM key as SHA-256 of the ECDH
+// shared secret. Afterwards text messages are sent as
+// {"type":"encrypted","data":{"payload":"<base64 nonce||ciphertext>"}} and
+// binary frames as raw nonce||ciphertext.
+//
+// Only browser-to-proxy messages are encrypted; commands sent by the proxy to
+// the page are not. A tunnel provider that actively rewrites the injected
+// public key can still intercept traffic; passive observers cannot.
+
+// payloadNonceSize is the AES-GCM nonce size used by the injected script.
+const payloadNonceSize = 12
+
+// payloadKeyPair is the proxy's long-lived key pair for payload encryption.
+type payloadKeyPair struct {
+	priv *ecdh.PrivateKey
+}
+
+// newPayloadKeyPair generates a P-256 key pair for payload encryption.
+func newPayloadKeyPair() (*payloadKeyPair, error) {
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate payload key: %w", err)
+	}
+	return &payloadKeyPair{priv: priv}, nil
+}
+
+// PublicKeyBase64 returns the uncompressed public key, base64 encoded for injection.
+func (kp *payloadKeyPair) PublicKeyBase64() string {
+	return base64.StdEncoding.EncodeToString(kp.priv.PublicKey().Bytes())
+}
+
+// DeriveCipher derives the per-connection AEAD from the browser's public key.
+func (kp *payloadKeyPair) DeriveCipher(peerPublicKey string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(peerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key encoding: %w", err)
+	}
+	peer, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+	secret, err := kp.priv.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, payloadNonceSize)
+}
+
+// openPayload decrypts a nonce||ciphertext frame.
+func openPayload(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < payloadNonceSize+aead.Overhead() {
+		return nil, errors.New("encrypted payload too short")
+	}
+	return aead.Open(nil, sealed[:payloadNonceSize], sealed[payloadNonceSize:], nil)
+}
+
+// openPayloadBase64 decrypts a base64-encoded nonce||ciphertext payload.
+func openPayloadBase64(aead cipher.AEAD, payload string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted payload encoding: %w", err)
+	}
+	return openPayload(aead, sealed)
+}
@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// browserSide mimics the injected script's half of the key exchange.
+func browserSide(t *testing.T, serverPub string) (publicKey string, aead cipher.AEAD) {
+	t.Helper()
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(serverPub)
+	peer, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	secret, err := priv.ECDH(peer)
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+	key := sha256.Sum256(secret)
+	block, _ := aes.NewCipher(key[:])
+	aead, _ = cipher.NewGCM(block)
+	return base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()), aead
+}
+
+func seal(aead cipher.AEAD, plain []byte) []byte {
+	nonce := make([]byte, payloadNonceSize)
+	rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plain, nil)
+}
+
+func TestPayloadKeyPair_RoundTrip(t *testing.T) {
+	kp, err := newPayloadKeyPair()
+	if err != nil {
+		t.Fatalf("newPayloadKeyPair: %v", err)
+	}
+
+	browserPub, browserAEAD := browserSide(t, kp.PublicKeyBase64())
+	serverAEAD, err := kp.DeriveCipher(browserPub)
+	if err != nil {
+		t.Fatalf("DeriveCipher: %v", err)
+	}
+
+	sealed := base64.StdEncoding.EncodeToString(seal(browserAEAD, []byte(`{"type":"custom_log"}`)))
+	plain, err := openPayloadBase64(serverAEAD, sealed)
+	if err != nil {
+		t.Fatalf("openPayloadBase64: %v", err)
+	}
+	if string(plain) != `{"type":"custom_log"}` {
+		t.Errorf("Unexpected plaintext %q", plain)
+	}
+
+	if _, err := openPayload(serverAEAD, []byte("short")); err == nil {
+		t.Error("Expected error for truncated payload")
+	}
+	if _, err := kp.DeriveCipher("not-base64!"); err == nil {
+		t.Error("Expected error for invalid peer key")
+	}
+}
+
+func TestProxy_EncryptedMetrics(t *testing.T) {
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:         "encrypted-proxy",
+		TargetURL:  "http://127.0.0.1:1",
+		ListenPort: 0,
+		Encrypt:    true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(func() { cancel() })
+	if err := ps.Start(ctx); err != nil {
+		t.Fatalf("Failed to start proxy: %v", err)
+	}
+	t.Cleanup(func() { ps.Stop(ctx) })
+	<-ps.Ready()
+
+	if !ps.Stats().Encrypted {
+		t.Error("Expected stats to report encryption")
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s/__devtool_metrics", ps.ListenAddr), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer ws.Close()
+
+	// Plaintext before the key exchange is dropped
+	ws.WriteJSON(map[string]interface{}{
+		"type": "custom_log",
+		"data": map[string]interface{}{"level": "info", "message": "plaintext"},
+	})
+
+	browserPub, aead := browserSide(t, ps.injectionSession().PublicKey)
+	ws.WriteJSON(map[string]interface{}{
+		"type": "key_exchange",
+		"data": map[string]interface{}{"public_key": browserPub},
+	})
+
+	inner, _ := json.Marshal(map[string]interface{}{
+		"type": "custom_log",
+		"data": map[string]interface{}{"level": "info", "message": "secret"},
+	})
+	ws.WriteJSON(map[string]interface{}{
+		"type": "encrypted",
+		"data": map[string]interface{}{"payload": base64.StdEncoding.EncodeToString(seal(aead, inner))},
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		entries := ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeCustom}})
+		if len(entries) > 0 {
+			if len(entries) != 1 || entries[0].Custom.Message != "secret" {
+				t.Fatalf("Expected only the decrypted log entry, got %+v", entries)
+			}
+			if ps.Stats().WSDropped != 1 {
+				t.Errorf("Expected 1 dropped plaintext message, got %d", ps.Stats().WSDropped)
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for decrypted custom log")
+}
@@ -0,0 +1,579 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RecordingDir holds stored recordings, relative to the proxy's project.
+	RecordingDir = ".agnt/recordings"
+	// MaxRecordingSteps caps the steps of one recording.
+	MaxRecordingSteps = 500
+	// RecordingFlushWait is how long StopRecording waits for the page's last
+	// batch of interactions, which the page sends every second.
+	RecordingFlushWait = 1500 * time.Millisecond
+	// DefaultReplayStepTimeout is how long a replayed step waits for its
+	// element or page.
+	DefaultReplayStepTimeout = 5 * time.Second
+	// MaxReplayStepTimeout caps the wait of a replayed step.
+	MaxReplayStepTimeout = 60 * time.Second
+	// MaxReplayDuration caps a whole replay.
+	MaxReplayDuration = 5 * time.Minute
+	// replaySettle is how long a navigate step waits for the navigation the
+	// previous step started before loading the URL itself.
+	replaySettle = 2 * time.Second
+	// replayPoll is the wait for one answer while a page may be reloading.
+	replayPoll = time.Second
+)
+
+// Replay step statuses.
+const (
+	ReplayPassed  = "passed"
+	ReplayFailed  = "failed"
+	ReplaySkipped = "skipped"
+)
+
+// replayKeys are the keys a recording keeps; typed text is recorded as fill
+// steps instead.
+var replayKeys = map[string]bool{"Enter": true, "Escape": true, "Tab": true}
+
+// recordingNamePattern is what a recording's file name may contain.
+var recordingNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// RecordOptions configures StartRecording.
+type RecordOptions struct {
+	Name string `json:"name,omitempty"` // File name of the recording (default: recording-<time>)
+}
+
+// RecordingReplayOptions configures ReplayRecording.
+type RecordingReplayOptions struct {
+	Values            map[string]string `json:"values,omitempty"`              // Values of fields masked when recorded, by selector
+	StepTimeoutMs     int               `json:"step_timeout_ms,omitempty"`     // Wait per step for its element or page (default 5000, max 60000)
+	ContinueOnFailure bool              `json:"continue_on_failure,omitempty"` // Run the remaining steps after a failure instead of skipping them
+}
+
+// Recording is a flow of user actions on a page that can be replayed.
+type Recording struct {
+	Name      string          `json:"name"`
+	ProxyID   string          `json:"proxy_id"`
+	SessionID string          `json:"session_id,omitempty"`
+	Steps     []RecordingStep `json:"steps"`
+	Truncated bool            `json:"truncated,omitempty"` // Steps beyond MaxRecordingSteps were dropped
+	Started   time.Time       `json:"started"`
+	Stopped   time.Time       `json:"stopped,omitempty"`
+	FilePath  string          `json:"file_path,omitempty"`
+}
+
+// RecordingStep is one replayable action.
+type RecordingStep struct {
+	Action   string `json:"action"` // navigate, click, dblclick, fill or press
+	Selector string `json:"selector,omitempty"`
+	Text     string `json:"text,omitempty"`   // Element text when recorded
+	Value    string `json:"value,omitempty"`  // fill
+	Masked   bool   `json:"masked,omitempty"` // The fill value was masked when recorded
+	Key      string `json:"key,omitempty"`    // press
+	URL      string `json:"url,omitempty"`    // navigate: path and query
+	Method   string `json:"method,omitempty"` // navigate: non-GET navigations are awaited, never loaded
+}
+
+// RecordingStatus describes the recording in progress.
+type RecordingStatus struct {
+	Name      string    `json:"name"`
+	SessionID string    `json:"session_id,omitempty"` // Empty until a page acts
+	Events    int       `json:"events"`
+	Started   time.Time `json:"started"`
+}
+
+// RecordingFile is a stored recording.
+type RecordingFile struct {
+	Name     string    `json:"name"`
+	FilePath string    `json:"file_path"`
+	Steps    int       `json:"steps"`
+	StartURL string    `json:"start_url,omitempty"`
+	ModTime  time.Time `json:"mod_time"`
+}
+
+// RecordingReplay reports a replay of a recording step by step.
+type RecordingReplay struct {
+	Name       string                `json:"name"`
+	SessionID  string                `json:"session_id,omitempty"`
+	Passed     bool                  `json:"passed"`
+	Total      int                   `json:"total"`
+	Failed     int                   `json:"failed"`
+	Skipped    int                   `json:"skipped"`
+	Steps      []RecordingReplayStep `json:"steps"`
+	DurationMs int64                 `json:"duration_ms"`
+	Timestamp  time.Time             `json:"timestamp"`
+}
+
+// RecordingReplayStep is the outcome of one replayed step.
+type RecordingReplayStep struct {
+	Index      int    `json:"index"` // From 1
+	Action     string `json:"action"`
+	Target     string `json:"target"` // Selector, or URL of a navigate step
+	Status     string `json:"status"` // passed, failed or skipped
+	Error      string `json:"error,omitempty"`
+	URL        string `json:"url,omitempty"` // Page URL after the step
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// recordedEvent is an interaction or navigation captured while recording.
+type recordedEvent struct {
+	at          time.Time
+	interaction *InteractionEvent
+	navigation  *HTTPLogEntry
+}
+
+// activeRecording collects the events of the recording in progress.
+type activeRecording struct {
+	mu        sync.Mutex
+	name      string
+	sessionID string
+	started   time.Time
+	events    []recordedEvent
+}
+
+// StartRecording starts recording the clicks, inputs, keys and navigations
+// of a page session. With an empty sessionID the first page to act is
+// recorded. A proxy records one flow at a time.
+func (ps *ProxyServer) StartRecording(sessionID string, opts RecordOptions) (RecordingStatus, error) {
+	name := opts.Name
+	if name == "" {
+		name = "recording-" + time.Now().Format("20060102-150405")
+	}
+	if !recordingNamePattern.MatchString(name) {
+		return RecordingStatus{}, fmt.Errorf("invalid recording name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+
+	rec := &activeRecording{name: name, sessionID: sessionID, started: time.Now()}
+	if sessionID != "" {
+		session, ok := ps.pageTracker.GetSession(sessionID)
+		if !ok {
+			return RecordingStatus{}, fmt.Errorf("session not found: %s", sessionID)
+		}
+		// Replays start from the page the recording started on
+		rec.events = append(rec.events, recordedEvent{
+			at:         rec.started,
+			navigation: &HTTPLogEntry{Method: http.MethodGet, URL: session.URL},
+		})
+	}
+	if !ps.recording.CompareAndSwap(nil, rec) {
+		return RecordingStatus{}, fmt.Errorf("proxy %s is already recording %s", ps.ID, ps.recording.Load().name)
+	}
+	return rec.status(), nil
+}
+
+// RecordingStatus returns the recording in progress, if any.
+func (ps *ProxyServer) RecordingStatus() (RecordingStatus, bool) {
+	rec := ps.recording.Load()
+	if rec == nil {
+		return RecordingStatus{}, false
+	}
+	return rec.status(), true
+}
+
+// StopRecording ends the recording in progress and stores it in the
+// project's .agnt/recordings.
+func (ps *ProxyServer) StopRecording(ctx context.Context) (Recording, error) {
+	rec := ps.recording.Load()
+	if rec == nil {
+		return Recording{}, fmt.Errorf("proxy %s is not recording", ps.ID)
+	}
+	stopped := time.Now()
+	select {
+	case <-time.After(RecordingFlushWait):
+	case <-ctx.Done():
+	}
+	if !ps.recording.CompareAndSwap(rec, nil) {
+		return Recording{}, fmt.Errorf("proxy %s is not recording", ps.ID)
+	}
+
+	rec.mu.Lock()
+	var events []recordedEvent
+	for _, e := range rec.events {
+		if !e.at.After(stopped) {
+			events = append(events, e)
+		}
+	}
+	recording := Recording{
+		Name:      rec.name,
+		ProxyID:   ps.ID,
+		SessionID: rec.sessionID,
+		Started:   rec.started,
+		Stopped:   stopped,
+	}
+	rec.mu.Unlock()
+
+	recording.Steps, recording.Truncated = buildRecordingSteps(events)
+	if len(recording.Steps) == 0 {
+		return recording, fmt.Errorf("nothing was recorded: no page acted through proxy %s", ps.ID)
+	}
+
+	dir, err := ps.recordingDir()
+	if err != nil {
+		return recording, err
+	}
+	recording.FilePath = filepath.Join(dir, recording.Name+".json")
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return recording, err
+	}
+	if err := os.WriteFile(recording.FilePath, data, 0644); err != nil {
+		return recording, fmt.Errorf("failed to store recording: %w", err)
+	}
+	return recording, nil
+}
+
+// Recordings lists the stored recordings of the proxy's project.
+func (ps *ProxyServer) Recordings() ([]RecordingFile, error) {
+	dir, err := ps.recordingDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []RecordingFile{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		rec, err := readRecording(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		file := RecordingFile{Name: name, FilePath: rec.FilePath, Steps: len(rec.Steps), ModTime: info.ModTime()}
+		if len(rec.Steps) > 0 && rec.Steps[0].Action == "navigate" {
+			file.StartURL = rec.Steps[0].URL
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.After(files[j].ModTime) })
+	return files, nil
+}
+
+// LoadRecording reads a stored recording by name.
+func (ps *ProxyServer) LoadRecording(name string) (Recording, error) {
+	if !recordingNamePattern.MatchString(name) {
+		return Recording{}, fmt.Errorf("invalid recording name %q", name)
+	}
+	dir, err := ps.recordingDir()
+	if err != nil {
+		return Recording{}, err
+	}
+	rec, err := readRecording(filepath.Join(dir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Recording{}, fmt.Errorf("recording not found: %s", name)
+	}
+	return rec, err
+}
+
+// ReplayRecording runs a recording's steps in a page session through the
+// injected instrumentation, one at a time, and reports each. After a failed
+// step the rest are skipped unless opts.ContinueOnFailure is set. An empty
+// sessionID replays in whichever page answers first.
+func (ps *ProxyServer) ReplayRecording(ctx context.Context, sessionID string, rec Recording, opts RecordingReplayOptions) RecordingReplay {
+	ctx, cancel := context.WithTimeout(ctx, MaxReplayDuration)
+	defer cancel()
+
+	timeout := DefaultReplayStepTimeout
+	if opts.StepTimeoutMs > 0 {
+		timeout = min(time.Duration(opts.StepTimeoutMs)*time.Millisecond, MaxReplayStepTimeout)
+	}
+
+	start := time.Now()
+	result := RecordingReplay{
+		Name:      rec.Name,
+		SessionID: sessionID,
+		Total:     len(rec.Steps),
+		Steps:     make([]RecordingReplayStep, 0, len(rec.Steps)),
+		Timestamp: start,
+	}
+	for i, step := range rec.Steps {
+		rs := RecordingReplayStep{Index: i + 1, Action: step.Action, Target: step.Selector}
+		if step.Action == "navigate" {
+			rs.Target = step.URL
+		}
+		if (result.Failed > 0 && !opts.ContinueOnFailure) || ctx.Err() != nil {
+			rs.Status = ReplaySkipped
+			result.Skipped++
+			result.Steps = append(result.Steps, rs)
+			continue
+		}
+
+		stepStart := time.Now()
+		var err error
+		if step.Action == "navigate" {
+			rs.URL, err = ps.replayNavigate(ctx, sessionID, step, i == 0, timeout)
+		} else {
+			rs.URL, err = ps.replayAction(ctx, sessionID, step, opts.Values, timeout)
+		}
+		rs.DurationMs = time.Since(stepStart).Milliseconds()
+		rs.Status = ReplayPassed
+		if err != nil {
+			rs.Status = ReplayFailed
+			rs.Error = err.Error()
+			result.Failed++
+		}
+		result.Steps = append(result.Steps, rs)
+	}
+	result.Passed = result.Failed == 0 && result.Skipped == 0
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// replayAction performs a click, fill or key press in the page.
+func (ps *ProxyServer) replayAction(ctx context.Context, sessionID string, step RecordingStep, values map[string]string, timeout time.Duration) (string, error) {
+	if step.Masked {
+		value, ok := values[step.Selector]
+		if !ok {
+			return "", fmt.Errorf("the value of %s was masked when recorded; pass it in values", step.Selector)
+		}
+		step.Value = value
+	}
+	args, _ := json.Marshal(step)
+	code := fmt.Sprintf("window.__devtool.replayStep(%s, %d)", args, timeout.Milliseconds())
+	raw, _, err := ps.evalInPage(ctx, sessionID, code, timeout+replayPoll)
+	if err != nil {
+		return "", err
+	}
+	var page struct {
+		URL string `json:"url"`
+	}
+	json.Unmarshal(raw, &page)
+	return page.URL, nil
+}
+
+// replayNavigate waits for the page to reach a navigate step's URL, giving
+// the navigation the previous step started time to happen before loading
+// the URL itself.
+func (ps *ProxyServer) replayNavigate(ctx context.Context, sessionID string, step RecordingStep, first bool, timeout time.Duration) (string, error) {
+	settle := replaySettle
+	if first {
+		settle = 0
+	}
+	if href, ok := ps.waitForPageURL(ctx, sessionID, step.URL, settle); ok {
+		return href, nil
+	}
+	if step.Method != "" && step.Method != http.MethodGet {
+		return "", fmt.Errorf("page did not navigate to %s %s", step.Method, step.URL)
+	}
+
+	// The page unloads while answering, so the exec may not come back
+	target, _ := json.Marshal(step.URL)
+	ps.evalInPage(ctx, sessionID, fmt.Sprintf("window.location.assign(%s)", target), replayPoll)
+	if href, ok := ps.waitForPageURL(ctx, sessionID, step.URL, timeout); ok {
+		return href, nil
+	}
+	return "", fmt.Errorf("page did not load %s within %s", step.URL, timeout)
+}
+
+// waitForPageURL polls the page until its path and query are want's, for up
+// to wait. It asks at least once.
+func (ps *ProxyServer) waitForPageURL(ctx context.Context, sessionID, want string, wait time.Duration) (string, bool) {
+	deadline := time.Now().Add(wait)
+	for {
+		raw, _, err := ps.evalInPage(ctx, sessionID, `({href: location.href, path: location.pathname + location.search})`, replayPoll)
+		if err == nil {
+			var page struct {
+				Href string `json:"href"`
+				Path string `json:"path"`
+			}
+			if json.Unmarshal(raw, &page) == nil && page.Path == want {
+				return page.Href, true
+			}
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return "", false
+		}
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return "", false
+		}
+	}
+}
+
+// recordInteraction adds a page's interaction to the recording in progress.
+// clientTime is when the page saw it, which orders it against navigations
+// better than when its batch arrived.
+func (ps *ProxyServer) recordInteraction(sessionID string, interaction InteractionEvent, clientTime time.Time) {
+	rec := ps.recording.Load()
+	if rec == nil || sessionID == "" {
+		return
+	}
+	at := interaction.Timestamp
+	if !clientTime.IsZero() {
+		at = clientTime
+	}
+	rec.add(sessionID, recordedEvent{at: at, interaction: &interaction}, interaction.URL)
+}
+
+// recordNavigation adds a page load to the recording in progress.
+func (ps *ProxyServer) recordNavigation(entry HTTPLogEntry) {
+	rec := ps.recording.Load()
+	if rec == nil || !isNavigationRequest(entry) {
+		return
+	}
+	sessionID := ps.pageTracker.ResolveSession(extractBrowserSessionID(entry.RequestHeaders), entry.URL)
+	if sessionID == "" {
+		return
+	}
+	rec.add(sessionID, recordedEvent{at: entry.Timestamp, navigation: &entry}, "")
+}
+
+// add appends an event of a page session, binding the recording to the
+// first session that acts. An interaction that binds it starts the
+// recording at its page.
+func (rec *activeRecording) add(sessionID string, event recordedEvent, pageURL string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.sessionID == "" {
+		rec.sessionID = sessionID
+		if pageURL != "" {
+			rec.events = append(rec.events, recordedEvent{
+				at:         event.at.Add(-time.Millisecond),
+				navigation: &HTTPLogEntry{Method: http.MethodGet, URL: pageURL},
+			})
+		}
+	}
+	if rec.sessionID != sessionID || len(rec.events) > 4*MaxRecordingSteps {
+		return
+	}
+	rec.events = append(rec.events, event)
+}
+
+func (rec *activeRecording) status() RecordingStatus {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return RecordingStatus{Name: rec.name, SessionID: rec.sessionID, Events: len(rec.events), Started: rec.started}
+}
+
+// buildRecordingSteps turns captured events into replayable steps: clicks,
+// the final value of each edit of a field, Enter, Escape and Tab presses,
+// and page loads. Scrolls, focus changes and pointer moves are left out.
+func buildRecordingSteps(events []recordedEvent) ([]RecordingStep, bool) {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	steps := []RecordingStep{}
+	for _, e := range events {
+		if nav := e.navigation; nav != nil {
+			step := RecordingStep{Action: "navigate", URL: pathAndQuery(nav.URL)}
+			if nav.Method != "" && nav.Method != http.MethodGet {
+				step.Method = nav.Method
+			}
+			if n := len(steps); n > 0 && steps[n-1] == step {
+				continue
+			}
+			steps = append(steps, step)
+			continue
+		}
+
+		in := e.interaction
+		if in.Target.Selector == "" {
+			continue
+		}
+		switch in.EventType {
+		case "click", "dblclick":
+			steps = append(steps, RecordingStep{Action: in.EventType, Selector: in.Target.Selector, Text: in.Target.Text})
+		case "input":
+			fill := RecordingStep{Action: "fill", Selector: in.Target.Selector, Value: in.Value, Masked: in.Masked}
+			if fill.Masked {
+				fill.Value = ""
+			}
+			// Inputs are sent after typing pauses, so a key pressed right
+			// after typing arrives first; the fill goes before it
+			at := len(steps)
+			for at > 0 && steps[at-1].Action == "press" && steps[at-1].Selector == fill.Selector {
+				at--
+			}
+			if at > 0 && steps[at-1].Action == "fill" && steps[at-1].Selector == fill.Selector {
+				steps[at-1] = fill
+				continue
+			}
+			steps = slices.Insert(steps, at, fill)
+		case "keydown":
+			if in.Key != nil && replayKeys[in.Key.Key] {
+				steps = append(steps, RecordingStep{Action: "press", Selector: in.Target.Selector, Key: in.Key.Key})
+			}
+		}
+	}
+
+	if len(steps) > MaxRecordingSteps {
+		return steps[:MaxRecordingSteps], true
+	}
+	return steps, false
+}
+
+// isNavigationRequest reports whether a request loaded a page, as opposed to
+// HTML fetched by a script.
+func isNavigationRequest(entry HTTPLogEntry) bool {
+	if !isDocumentRequest(entry) {
+		return false
+	}
+	for name, value := range entry.RequestHeaders {
+		switch strings.ToLower(name) {
+		case "sec-fetch-dest":
+			if value != "document" {
+				return false
+			}
+		case "x-requested-with", "hx-request":
+			return false
+		}
+	}
+	return true
+}
+
+// pathAndQuery strips the scheme and host from a URL, so a recording replays
+// through any proxy of the app.
+func pathAndQuery(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.RequestURI()
+}
+
+// recordingDir returns the proxy project's recording directory, creating it.
+func (ps *ProxyServer) recordingDir() (string, error) {
+	if ps.Path == "" {
+		return "", fmt.Errorf("proxy %s has no project directory", ps.ID)
+	}
+	dir := filepath.Join(ps.Path, RecordingDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func readRecording(path string) (Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Recording{}, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Recording{}, fmt.Errorf("invalid recording %s: %w", filepath.Base(path), err)
+	}
+	rec.FilePath = path
+	return rec, nil
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBuildRecordingSteps(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	interaction := func(ms int, eventType, selector string) recordedEvent {
+		return recordedEvent{at: at(ms), interaction: &InteractionEvent{EventType: eventType, Target: InteractionTarget{Selector: selector, Text: "Go"}}}
+	}
+	input := func(ms int, selector, value string, masked bool) recordedEvent {
+		e := interaction(ms, "input", selector)
+		e.interaction.Value = value
+		e.interaction.Masked = masked
+		return e
+	}
+	key := func(ms int, selector, k string) recordedEvent {
+		e := interaction(ms, "keydown", selector)
+		e.interaction.Key = &KeyboardInfo{Key: k}
+		return e
+	}
+	navigation := func(ms int, method, url string) recordedEvent {
+		return recordedEvent{at: at(ms), navigation: &HTTPLogEntry{Method: method, URL: url}}
+	}
+
+	// Out of order, as batches arrive: the fill of #q lands after its Enter
+	events := []recordedEvent{
+		navigation(0, "GET", "http://localhost:3000/search?x=1"),
+		interaction(100, "focus", "#q"),
+		input(200, "#q", "sh", false),
+		key(500, "#q", "Enter"),
+		input(600, "#q", "shoes", false),
+		navigation(900, "GET", "http://localhost:3000/results?q=shoes"),
+		navigation(950, "GET", "http://localhost:3000/results?q=shoes"),
+		interaction(800, "mousemove", "body"),
+		interaction(1000, "click", "#buy"),
+		key(1100, "#card", "4"),
+		input(1200, "#card", "[masked]", true),
+		interaction(1300, "click", ""),
+		navigation(1400, "POST", "http://localhost:3000/checkout"),
+	}
+
+	steps, truncated := buildRecordingSteps(events)
+	if truncated {
+		t.Error("Expected no truncation")
+	}
+	want := []RecordingStep{
+		{Action: "navigate", URL: "/search?x=1"},
+		{Action: "fill", Selector: "#q", Value: "shoes"},
+		{Action: "press", Selector: "#q", Key: "Enter"},
+		{Action: "navigate", URL: "/results?q=shoes"},
+		{Action: "click", Selector: "#buy", Text: "Go"},
+		{Action: "fill", Selector: "#card", Masked: true},
+		{Action: "navigate", URL: "/checkout", Method: "POST"},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("Expected %d steps, got %+v", len(want), steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("Step %d: expected %+v, got %+v", i+1, want[i], steps[i])
+		}
+	}
+
+	events = nil
+	for i := 0; i < MaxRecordingSteps+10; i++ {
+		events = append(events, interaction(i, "click", fmt.Sprintf("#b%d", i)))
+	}
+	if steps, truncated := buildRecordingSteps(events); !truncated || len(steps) != MaxRecordingSteps {
+		t.Errorf("Expected %d steps and truncation, got %d, %v", MaxRecordingSteps, len(steps), truncated)
+	}
+}
+
+func TestIsNavigationRequest(t *testing.T) {
+	html := map[string]string{"Content-Type": "text/html; charset=utf-8"}
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"page load", map[string]string{"Sec-Fetch-Dest": "document"}, true},
+		{"old browser", map[string]string{}, true},
+		{"fetched HTML", map[string]string{"Sec-Fetch-Dest": "empty"}, false},
+		{"htmx", map[string]string{"HX-Request": "true"}, false},
+		{"jQuery", map[string]string{"X-Requested-With": "XMLHttpRequest"}, false},
+	}
+	for _, tt := range tests {
+		entry := HTTPLogEntry{URL: "http://localhost/", RequestHeaders: tt.headers, ResponseHeaders: html}
+		if got := isNavigationRequest(entry); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+	if isNavigationRequest(HTTPLogEntry{URL: "http://localhost/api", ResponseHeaders: map[string]string{"Content-Type": "application/json"}}) {
+		t.Error("Expected JSON not to be a navigation")
+	}
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ReplayHeader marks a replayed request with the ID of the entry it replays,
+// for the backend and in the traffic log.
+const ReplayHeader = "X-Devtool-Replay"
+
+// replayEntryKey is the request context key under which handleProxy hands
+// the log entry of a replayed request back to Replay.
+type replayEntryKey struct{}
+
+// ReplayOverrides changes a request before it is replayed.
+type ReplayOverrides struct {
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url,omitempty"`     // Path and query to request instead
+	Headers map[string]string `json:"headers,omitempty"` // Set these headers; an empty value removes one
+	Body    *string           `json:"body,omitempty"`    // Send this body instead of the logged one
+}
+
+// ReplayResult pairs a logged request with the response to its replay.
+type ReplayResult struct {
+	Original HTTPLogEntry `json:"original"`
+	Replay   HTTPLogEntry `json:"replay"`
+	// DroppedHeaders were redacted in the log and not resent; pass them as
+	// overrides to include them.
+	DroppedHeaders []string `json:"dropped_headers,omitempty"`
+}
+
+// replaySkipHeaders aren't copied from the logged request: they describe
+// the original connection or are recomputed for the replay. Accept-Encoding
+// is dropped so the logged response stays readable.
+var replaySkipHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Keep-Alive",
+	"Te",
+	"Trailer",
+	"Accept-Encoding",
+	ReplayHeader,
+}
+
+// Replay re-issues the logged HTTP request entryID against the target,
+// through the same path as browser traffic, and returns the logged result.
+// Credentials masked in the log aren't resent, and a body the log truncated
+// or didn't keep must be given as an override.
+func (ps *ProxyServer) Replay(ctx context.Context, entryID string, overrides ReplayOverrides) (*ReplayResult, error) {
+	original, ok := ps.findHTTPEntry(entryID)
+	if !ok {
+		return nil, fmt.Errorf("log entry %q not found", entryID)
+	}
+	if original.StatusCode == http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("log entry %q is a WebSocket upgrade and can't be replayed", entryID)
+	}
+
+	body := original.RequestBody
+	if overrides.Body != nil {
+		body = *overrides.Body
+	} else if strings.HasSuffix(body, bodyTruncatedMarker) {
+		return nil, fmt.Errorf("the request body of %q was truncated in the log; pass the body as an override", entryID)
+	} else if n, _ := strconv.Atoi(headerValue(original.RequestHeaders, "Content-Length")); n > 0 && body == "" {
+		return nil, fmt.Errorf("the request body of %q wasn't logged; pass the body as an override", entryID)
+	}
+
+	method := original.Method
+	if overrides.Method != "" {
+		method = strings.ToUpper(overrides.Method)
+	}
+	target := original.URL
+	if overrides.URL != "" {
+		target = overrides.URL
+	}
+
+	var captured HTTPLogEntry
+	ctx = context.WithValue(ctx, replayEntryKey{}, &captured)
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid replay request: %w", err)
+	}
+	req.ContentLength = int64(len(body))
+	req.RemoteAddr = "127.0.0.1:0"
+	req.RequestURI = req.URL.RequestURI()
+
+	result := &ReplayResult{Original: *original}
+	for name, value := range original.RequestHeaders {
+		if replaySkipped(name) {
+			continue
+		}
+		if value == redactedValue {
+			result.DroppedHeaders = append(result.DroppedHeaders, name)
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	for name, value := range overrides.Headers {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+		for i, dropped := range result.DroppedHeaders {
+			if strings.EqualFold(dropped, name) {
+				result.DroppedHeaders = append(result.DroppedHeaders[:i], result.DroppedHeaders[i+1:]...)
+				break
+			}
+		}
+	}
+	req.Header.Set(ReplayHeader, entryID)
+
+	ps.handleProxy(&discardResponseWriter{header: make(http.Header)}, req)
+	if captured.ID == "" {
+		return nil, fmt.Errorf("replay of %q was not logged", entryID)
+	}
+	result.Replay = captured
+	return result, nil
+}
+
+// findHTTPEntry looks up a logged HTTP request by ID.
+func (ps *ProxyServer) findHTTPEntry(id string) (*HTTPLogEntry, bool) {
+	for _, entry := range ps.logger.Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}}) {
+		if entry.HTTP != nil && entry.HTTP.ID == id {
+			return entry.HTTP, true
+		}
+	}
+	return nil, false
+}
+
+// noteReplay hands the log entry of a replayed request back to Replay, as
+// the log keeps it.
+func (ps *ProxyServer) noteReplay(r *http.Request, entry HTTPLogEntry) {
+	if captured, ok := r.Context().Value(replayEntryKey{}).(*HTTPLogEntry); ok {
+		ps.logger.BodyCapture().apply(&entry)
+		*captured = entry
+	}
+}
+
+func replaySkipped(name string) bool {
+	for _, skip := range replaySkipHeaders {
+		if strings.EqualFold(name, skip) {
+			return true
+		}
+	}
+	return false
+}
+
+// discardResponseWriter receives a replayed response, which is only logged.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProxyReplay(t *testing.T) {
+	var calls atomic.Int32
+	var lastAuth, lastReplay, lastBody atomic.Value
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lastAuth.Store(r.Header.Get("Authorization"))
+		lastReplay.Store(r.Header.Get(ReplayHeader))
+		lastBody.Store(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `{"error":"flaky"}`)
+			return
+		}
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/orders?id=1", strings.NewReader(`{"qty":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	ps.handleProxy(httptest.NewRecorder(), req)
+
+	entries := ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}})
+	if len(entries) != 1 || entries[0].HTTP.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected the failed request logged, got %+v", entries)
+	}
+	id := entries[0].HTTP.ID
+
+	result, err := ps.Replay(context.Background(), id, ReplayOverrides{})
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Replay.StatusCode != http.StatusOK || result.Replay.ResponseBody != `{"ok":true}` || result.Replay.ID == id {
+		t.Errorf("Unexpected replay %+v", result.Replay)
+	}
+	if result.Replay.Method != "POST" || result.Replay.URL != "/api/orders?id=1" || lastBody.Load() != `{"qty":2}` {
+		t.Errorf("Expected the logged request re-issued, got %s %s %v", result.Replay.Method, result.Replay.URL, lastBody.Load())
+	}
+	if lastAuth.Load() != "" || len(result.DroppedHeaders) != 1 || result.DroppedHeaders[0] != "Authorization" {
+		t.Errorf("Expected the masked credential dropped, got %v / %v", lastAuth.Load(), result.DroppedHeaders)
+	}
+	if lastReplay.Load() != id || result.Replay.RequestHeaders["Authorization"] != "" {
+		t.Errorf("Unexpected replay headers %v", result.Replay.RequestHeaders)
+	}
+	if n := len(ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}})); n != 2 {
+		t.Errorf("Expected the replay logged, got %d entries", n)
+	}
+
+	body := `{"qty":3}`
+	result, err = ps.Replay(context.Background(), id, ReplayOverrides{
+		Method:  "put",
+		Headers: map[string]string{"Authorization": "Bearer dev"},
+		Body:    &body,
+	})
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Replay.Method != "PUT" || lastAuth.Load() != "Bearer dev" || lastBody.Load() != body || len(result.DroppedHeaders) != 0 {
+		t.Errorf("Expected the overrides sent, got %s %v %v %v", result.Replay.Method, lastAuth.Load(), lastBody.Load(), result.DroppedHeaders)
+	}
+
+	if _, err := ps.Replay(context.Background(), "req-missing", ReplayOverrides{}); err == nil {
+		t.Error("Expected error for an unknown entry")
+	}
+
+	ps.Logger().LogHTTP(HTTPLogEntry{ID: "cut", Method: "POST", URL: "/api", RequestBody: "abc" + bodyTruncatedMarker})
+	if _, err := ps.Replay(context.Background(), "cut", ReplayOverrides{}); err == nil {
+		t.Error("Expected error for a truncated body without an override")
+	}
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+)
+
+// maxRetargetEvents is the number of retarget events kept per proxy.
+const maxRetargetEvents = 10
+
+// RetargetEvent records a change of the proxy's target.
+type RetargetEvent struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Target returns the URL requests are currently forwarded to.
+func (ps *ProxyServer) Target() *url.URL {
+	if t := ps.target.Load(); t != nil {
+		return t
+	}
+	return ps.TargetURL
+}
+
+// AutoRetarget reports whether the daemon may move the proxy to a new port
+// when its dev server comes back on one.
+func (ps *ProxyServer) AutoRetarget() bool {
+	return !ps.noRetarget.Load()
+}
+
+// SetAutoRetarget enables or disables automatic retargeting.
+func (ps *ProxyServer) SetAutoRetarget(enabled bool) {
+	ps.noRetarget.Store(!enabled)
+}
+
+// Retarget forwards subsequent requests to a new scheme and host. The path of
+// the original target is kept. Returns the previous target.
+func (ps *ProxyServer) Retarget(rawURL, reason string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid target URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid target URL %q: scheme and host required", rawURL)
+	}
+
+	old := ps.Target()
+	next := *old
+	next.Scheme = u.Scheme
+	next.Host = u.Host
+	ps.target.Store(&next)
+	ps.unreachableSince.Store(0)
+
+	ps.retargetsMu.Lock()
+	ps.retargets = append(ps.retargets, RetargetEvent{
+		From:   old.String(),
+		To:     next.String(),
+		Reason: reason,
+		Time:   time.Now(),
+	})
+	if len(ps.retargets) > maxRetargetEvents {
+		ps.retargets = ps.retargets[len(ps.retargets)-maxRetargetEvents:]
+	}
+	ps.retargetsMu.Unlock()
+
+	debug.Log("proxy", "proxy %s retargeted %s -> %s (%s)", ps.ID, old, &next, reason)
+	return old.String(), nil
+}
+
+// Retargets returns the proxy's recent retarget events, oldest first.
+func (ps *ProxyServer) Retargets() []RetargetEvent {
+	ps.retargetsMu.Lock()
+	defer ps.retargetsMu.Unlock()
+	return append([]RetargetEvent(nil), ps.retargets...)
+}
+
+// TargetUnreachableSince returns when requests started failing to connect to
+// the target, or the zero time if the last request reached it.
+func (ps *ProxyServer) TargetUnreachableSince() time.Time {
+	if ns := ps.unreachableSince.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// markTargetUnreachable records a failed connection to the target.
+func (ps *ProxyServer) markTargetUnreachable() {
+	ps.unreachableSince.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// markTargetReachable records a response from the target.
+func (ps *ProxyServer) markTargetReachable() {
+	ps.unreachableSince.Store(0)
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// closedPortURL returns a localhost URL nothing listens on.
+func closedPortURL(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return "http://" + addr
+}
+
+func TestRetarget(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "host="+r.Host+" path="+r.URL.Path)
+	}))
+	defer backend.Close()
+
+	oldURL := closedPortURL(t)
+	ps, err := NewProxyServer(ProxyConfig{ID: "retarget", TargetURL: oldURL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	if !ps.AutoRetarget() {
+		t.Error("Expected auto-retarget to be enabled by default")
+	}
+
+	rec := httptest.NewRecorder()
+	ps.proxy.ServeHTTP(rec, httptest.NewRequest("GET", "/page", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502 from closed target, got %d", rec.Code)
+	}
+	if ps.TargetUnreachableSince().IsZero() {
+		t.Fatal("Expected connection failure to be recorded")
+	}
+
+	old, err := ps.Retarget(backend.URL, "moved")
+	if err != nil {
+		t.Fatalf("Retarget failed: %v", err)
+	}
+	if old != oldURL {
+		t.Errorf("Expected previous target %s, got %s", oldURL, old)
+	}
+	if !ps.TargetUnreachableSince().IsZero() {
+		t.Error("Expected retarget to clear the failure")
+	}
+
+	rec = httptest.NewRecorder()
+	ps.proxy.ServeHTTP(rec, httptest.NewRequest("GET", "/page", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 after retarget, got %d: %s", rec.Code, rec.Body.String())
+	}
+	host := strings.TrimPrefix(backend.URL, "http://")
+	if got := rec.Body.String(); got != "host="+host+" path=/page" {
+		t.Errorf("Unexpected backend response %q", got)
+	}
+
+	stats := ps.Stats()
+	if stats.TargetURL != backend.URL {
+		t.Errorf("Expected stats target %s, got %s", backend.URL, stats.TargetURL)
+	}
+	if len(stats.Retargets) != 1 || stats.Retargets[0].From != oldURL || stats.Retargets[0].Reason != "moved" {
+		t.Errorf("Unexpected retarget events %+v", stats.Retargets)
+	}
+	if ps.TargetURL.String() != oldURL {
+		t.Errorf("Expected TargetURL to keep the original target, got %s", ps.TargetURL)
+	}
+}
+
+func TestRetarget_Invalid(t *testing.T) {
+	ps, err := NewProxyServer(ProxyConfig{ID: "retarget-invalid", TargetURL: "http://localhost:3000", NoRetarget: true})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	if ps.AutoRetarget() {
+		t.Error("Expected NoRetarget to disable auto-retarget")
+	}
+	if _, err := ps.Retarget("localhost:3001", ""); err == nil {
+		t.Error("Expected error for URL without scheme")
+	}
+	if ps.Target().String() != "http://localhost:3000" {
+		t.Errorf("Target changed after failed retarget: %s", ps.Target())
+	}
+}
@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HostRoute sends requests whose Host matches a pattern to their own
+// upstream, for apps that rely on subdomains (tenant1.localhost,
+// auth.localhost). Requests matching no route go to the proxy's target.
+type HostRoute struct {
+	// Host is a hostname ("auth.localhost"), a wildcard matching any
+	// subdomain ("*.localhost"), or "*" for any host. Ports are ignored.
+	Host string `json:"host"`
+	// Target is an upstream URL, or a port on the proxy target's host.
+	// Only its scheme and host are used.
+	Target string `json:"target"`
+	// HostHeader is the Host sent upstream: empty keeps the requested
+	// hostname with the target's port (tenant1.localhost:3000), "target"
+	// sends the target's host, anything else is sent verbatim.
+	HostHeader string `json:"host_header,omitempty"`
+}
+
+// hostRoute is a HostRoute with its target resolved.
+type hostRoute struct {
+	HostRoute
+	target *url.URL
+}
+
+// compileRoutes resolves route targets against the proxy target. Exact
+// hostnames are matched before wildcards; otherwise order is kept.
+func compileRoutes(routes []HostRoute, defaultTarget *url.URL) ([]hostRoute, error) {
+	compiled := make([]hostRoute, 0, len(routes))
+	for _, r := range routes {
+		r.Host = strings.ToLower(strings.TrimSpace(r.Host))
+		if r.Host == "" {
+			return nil, fmt.Errorf("route has no host pattern")
+		}
+		if i := strings.LastIndex(r.Host, "*"); i > 0 || (i == 0 && r.Host != "*" && !strings.HasPrefix(r.Host, "*.")) {
+			return nil, fmt.Errorf("invalid route host %q: only a leading \"*.\" wildcard is supported", r.Host)
+		}
+		target, err := resolveRouteTarget(r.Target, defaultTarget)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", r.Host, err)
+		}
+		compiled = append(compiled, hostRoute{HostRoute: r, target: target})
+	}
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return !compiled[i].wildcard() && compiled[j].wildcard()
+	})
+	return compiled, nil
+}
+
+// resolveRouteTarget parses a route target URL or bare port.
+func resolveRouteTarget(raw string, defaultTarget *url.URL) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	port := strings.TrimPrefix(raw, ":")
+	if n, err := strconv.Atoi(port); err == nil {
+		if n <= 0 || n > 65535 {
+			return nil, fmt.Errorf("invalid target port %d", n)
+		}
+		return &url.URL{Scheme: defaultTarget.Scheme, Host: net.JoinHostPort(defaultTarget.Hostname(), port)}, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid target %q: URL with scheme and host, or port, required", raw)
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}, nil
+}
+
+func (r *hostRoute) wildcard() bool {
+	return strings.HasPrefix(r.Host, "*")
+}
+
+// matches reports whether hostname (without port) is routed by r.
+func (r *hostRoute) matches(hostname string) bool {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	switch {
+	case r.Host == "*":
+		return true
+	case strings.HasPrefix(r.Host, "*."):
+		return strings.HasSuffix(hostname, r.Host[1:]) && len(hostname) > len(r.Host)-1
+	}
+	return hostname == r.Host
+}
+
+// hostHeader returns the Host sent upstream for a request to requestHost.
+func (r *hostRoute) hostHeader(requestHost string) string {
+	switch r.HostHeader {
+	case "":
+		return net.JoinHostPort(hostnameOf(requestHost), urlPortString(r.target))
+	case "target":
+		return r.target.Host
+	}
+	return r.HostHeader
+}
+
+// Routes returns the proxy's host routes.
+func (ps *ProxyServer) Routes() []HostRoute {
+	routes := make([]HostRoute, len(ps.routes))
+	for i, r := range ps.routes {
+		routes[i] = r.HostRoute
+	}
+	return routes
+}
+
+// routeFor returns the route for a request Host, or nil for the proxy target.
+func (ps *ProxyServer) routeFor(host string) *hostRoute {
+	hostname := hostnameOf(host)
+	for i := range ps.routes {
+		if ps.routes[i].matches(hostname) {
+			return &ps.routes[i]
+		}
+	}
+	return nil
+}
+
+// rewriteRoutedURL rewrites an absolute URL pointing at a routed upstream to
+// the matching proxy host, keeping the subdomain. requestHost is the Host the
+// browser used and names the proxy host for upstreams serving several hosts.
+func (ps *ProxyServer) rewriteRoutedURL(rawURL, requestHost string) string {
+	if len(ps.routes) == 0 {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	hostname := parsed.Hostname()
+	for i := range ps.routes {
+		r := &ps.routes[i]
+		proxyHostname := ""
+		switch {
+		case r.Host != "*" && r.matches(hostname) && urlPortString(parsed) == urlPortString(r.target):
+			proxyHostname = hostname
+		case strings.EqualFold(parsed.Host, r.target.Host):
+			if !r.wildcard() {
+				proxyHostname = r.Host
+			} else if reqHostname := hostnameOf(requestHost); requestHost != "" && r.matches(reqHostname) {
+				proxyHostname = reqHostname
+			}
+		}
+		if proxyHostname == "" {
+			continue
+		}
+		// Subdomains resolve only locally, never through a tunnel's public URL
+		parsed.Scheme = "http"
+		parsed.Host = net.JoinHostPort(proxyHostname, ps.listenPort())
+		return parsed.String()
+	}
+	return rawURL
+}
+
+// listenPort returns the port the proxy listens on.
+func (ps *ProxyServer) listenPort() string {
+	if _, port, err := net.SplitHostPort(ps.ListenAddr); err == nil {
+		return port
+	}
+	return "8080"
+}
+
+// hostnameOf strips the port from a Host value.
+func hostnameOf(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.Trim(host, "[]")
+}
+
+// urlPortString returns the URL's port, defaulting by scheme.
+func urlPortString(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" || u.Scheme == "wss" {
+		return "443"
+	}
+	return "80"
+}
+
+// ValidateRoutes reports the first invalid route for a proxy to targetURL.
+func ValidateRoutes(routes []HostRoute, targetURL string) error {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("invalid target URL: %w", err)
+	}
+	_, err = compileRoutes(routes, target)
+	return err
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCompileRoutes(t *testing.T) {
+	target, _ := url.Parse("http://localhost:3000")
+	routes, err := compileRoutes([]HostRoute{
+		{Host: "*.localhost", Target: "3000"},
+		{Host: "Auth.localhost", Target: "http://127.0.0.1:4000/ignored"},
+	}, target)
+	if err != nil {
+		t.Fatalf("compileRoutes failed: %v", err)
+	}
+	if routes[0].Host != "auth.localhost" || routes[0].target.String() != "http://127.0.0.1:4000" {
+		t.Errorf("Expected exact host first with resolved target, got %+v", routes[0])
+	}
+	if routes[1].target.String() != "http://localhost:3000" {
+		t.Errorf("Expected bare port on target host, got %s", routes[1].target)
+	}
+
+	for _, tc := range []struct {
+		host string
+		want bool
+	}{
+		{"tenant1.localhost", true},
+		{"a.b.localhost", true},
+		{"localhost", false},
+		{"notlocalhost", false},
+	} {
+		if got := routes[1].matches(tc.host); got != tc.want {
+			t.Errorf("matches(%q) = %v, want %v", tc.host, got, tc.want)
+		}
+	}
+
+	for _, bad := range []HostRoute{
+		{Host: "", Target: "3000"},
+		{Host: "app.*.localhost", Target: "3000"},
+		{Host: "*app.localhost", Target: "3000"},
+		{Host: "app.localhost", Target: "70000"},
+		{Host: "app.localhost", Target: "localhost"},
+	} {
+		if _, err := compileRoutes([]HostRoute{bad}, target); err == nil {
+			t.Errorf("Expected error for route %+v", bad)
+		}
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	var authBase string
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/redirect" {
+				// Upstreams redirect to their own address
+				http.Redirect(w, r, authBase+"/login", http.StatusFound)
+				return
+			}
+			io.WriteString(w, name+" "+r.Host)
+		}
+	}
+	app := httptest.NewServer(handler("app"))
+	defer app.Close()
+	auth := httptest.NewServer(handler("auth"))
+	defer auth.Close()
+	authBase = auth.URL
+	appURL, _ := url.Parse(app.URL)
+	authURL, _ := url.Parse(auth.URL)
+
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:         "routes",
+		TargetURL:  app.URL,
+		ListenPort: 8080,
+		Routes: []HostRoute{
+			{Host: "*.localhost", Target: appURL.Port()},
+			{Host: "auth.localhost", Target: auth.URL},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	get := func(host, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		ps.proxy.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for host, want := range map[string]string{
+		"auth.localhost:8080":    "auth auth.localhost:" + authURL.Port(),
+		"tenant1.localhost:8080": "app tenant1.localhost:" + appURL.Port(),
+	} {
+		if body := get(host, "/").Body.String(); body != want {
+			t.Errorf("Host %s: expected %q, got %q", host, want, body)
+		}
+	}
+	if body := get("localhost:8080", "/").Body.String(); body[:4] != "app " {
+		t.Errorf("Expected unrouted host to reach the target, got %q", body)
+	}
+
+	rec := get("auth.localhost:8080", "/redirect")
+	if loc := rec.Header().Get("Location"); loc != "http://auth.localhost:8080/login" {
+		t.Errorf("Expected redirect to routed proxy host, got %q", loc)
+	}
+
+	if routes := ps.Stats().Routes; len(routes) != 2 || routes[0].Host != "auth.localhost" {
+		t.Errorf("Unexpected routes in stats %+v", routes)
+	}
+}
+
+func TestHostRouteHeader(t *testing.T) {
+	target, _ := url.Parse("http://127.0.0.1:4000")
+	r := hostRoute{HostRoute: HostRoute{Host: "*.localhost"}, target: target}
+	if got := r.hostHeader("a.localhost:8080"); got != "a.localhost:4000" {
+		t.Errorf("Expected requested hostname with target port, got %q", got)
+	}
+	r.HostHeader = "target"
+	if got := r.hostHeader("a.localhost:8080"); got != "127.0.0.1:4000" {
+		t.Errorf("Expected target host, got %q", got)
+	}
+	r.HostHeader = "api.example.test"
+	if got := r.hostHeader("a.localhost:8080"); got != "api.example.test" {
+		t.Errorf("Expected literal host, got %q", got)
+	}
+}
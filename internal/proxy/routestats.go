@@ -0,0 +1,299 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRoutes bounds the routes tracked per proxy; requests to further
+	// routes are counted under otherRoute.
+	maxRoutes = 500
+	// otherRoute collects requests once maxRoutes routes are tracked.
+	otherRoute = "(other)"
+	// defaultRouteStatsLimit is the number of routes reported by default.
+	defaultRouteStatsLimit = 20
+)
+
+// latencyBuckets are the upper bounds, in milliseconds, of the latency
+// histogram of a route. Slower requests fall in a final open bucket.
+var latencyBuckets = [...]float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// Route stats sort orders.
+const (
+	RouteSortP50       = "p50"
+	RouteSortP95       = "p95"
+	RouteSortP99       = "p99"
+	RouteSortCount     = "count"
+	RouteSortErrors    = "errors"
+	RouteSortErrorRate = "error_rate"
+	RouteSortProxy     = "proxy" // Average time spent in the proxy
+)
+
+// RouteStats is the latency and error profile of one route: a method and a
+// path with IDs replaced by :id. Percentiles are estimated from a histogram.
+type RouteStats struct {
+	Method       string    `json:"method"`
+	Route        string    `json:"route"`
+	Count        int64     `json:"count"`
+	Errors       int64     `json:"errors"`        // 5xx responses and failed requests
+	ClientErrors int64     `json:"client_errors"` // 4xx responses
+	ErrorRate    float64   `json:"error_rate"`    // Errors per request, 0-1
+	AvgMs        float64   `json:"avg_ms"`
+	P50Ms        float64   `json:"p50_ms"`
+	P95Ms        float64   `json:"p95_ms"`
+	P99Ms        float64   `json:"p99_ms"`
+	MaxMs        float64   `json:"max_ms"`
+	LastSeen     time.Time `json:"last_seen"`
+	// Phases averages the timing of the requests that reached upstream.
+	Phases *RoutePhases `json:"phases,omitempty"`
+}
+
+// RoutePhases is the average upstream and proxy time of a route's requests,
+// in milliseconds.
+type RoutePhases struct {
+	Count       int64   `json:"count"`        // Requests with timing
+	ConnectMs   float64 `json:"connect_ms"`   // DNS, TCP connect and TLS
+	TTFBMs      float64 `json:"ttfb_ms"`      // Backend time to first byte
+	BodyMs      float64 `json:"body_ms"`      // Upstream body transfer
+	UpstreamMs  float64 `json:"upstream_ms"`  // Sum of the above
+	InjectMs    float64 `json:"inject_ms"`    // HTML rewriting and injection
+	ProxyMs     float64 `json:"proxy_ms"`     // Time in the proxy, including injection
+	ReusedConns float64 `json:"reused_conns"` // Share of kept-alive connections, 0-1
+}
+
+// RouteStatsFilter selects and orders the routes of PROXYLOG STATS.
+type RouteStatsFilter struct {
+	SortBy   string `json:"sort_by,omitempty"`   // p50, p95 (default), p99, count, errors, error_rate, proxy
+	Limit    int    `json:"limit,omitempty"`     // Routes returned (default 20, -1 for all)
+	MinCount int64  `json:"min_count,omitempty"` // Skip routes with fewer requests
+}
+
+// Validate checks the sort order.
+func (f RouteStatsFilter) Validate() error {
+	switch f.SortBy {
+	case "", RouteSortP50, RouteSortP95, RouteSortP99, RouteSortCount, RouteSortErrors, RouteSortErrorRate, RouteSortProxy:
+		return nil
+	}
+	return fmt.Errorf("invalid sort_by %q: use p50, p95, p99, count, errors, error_rate or proxy", f.SortBy)
+}
+
+// routeKey identifies the requests aggregated together.
+type routeKey struct {
+	method, route string
+}
+
+// routeHistogram accumulates the requests of one route.
+type routeHistogram struct {
+	buckets      [len(latencyBuckets) + 1]int64
+	count        int64
+	errors       int64
+	clientErrors int64
+	totalMs      float64
+	minMs        float64
+	maxMs        float64
+	lastSeen     time.Time
+	phases       phaseTotals
+}
+
+// phaseTotals sums the timing of a route's requests.
+type phaseTotals struct {
+	count                                        int64
+	reused                                       int64
+	connect, ttfb, body, upstream, inject, proxy time.Duration
+}
+
+func (p *phaseTotals) add(t *RequestTiming) {
+	p.count++
+	if t.Reused {
+		p.reused++
+	}
+	p.connect += t.DNS + t.Connect + t.TLS
+	p.ttfb += t.TTFB
+	p.body += t.Body
+	p.upstream += t.Upstream
+	p.inject += t.Inject
+	p.proxy += t.Proxy
+}
+
+// averages returns the average phases, or nil without timed requests.
+func (p *phaseTotals) averages() *RoutePhases {
+	if p.count == 0 {
+		return nil
+	}
+	avg := func(d time.Duration) float64 {
+		return roundTo(float64(d)/float64(time.Millisecond)/float64(p.count), 10)
+	}
+	return &RoutePhases{
+		Count:       p.count,
+		ConnectMs:   avg(p.connect),
+		TTFBMs:      avg(p.ttfb),
+		BodyMs:      avg(p.body),
+		UpstreamMs:  avg(p.upstream),
+		InjectMs:    avg(p.inject),
+		ProxyMs:     avg(p.proxy),
+		ReusedConns: roundTo(float64(p.reused)/float64(p.count), 100),
+	}
+}
+
+// routeStats aggregates logged HTTP traffic by route.
+type routeStats struct {
+	mu     sync.Mutex
+	routes map[routeKey]*routeHistogram
+}
+
+// record adds a logged request. WebSocket upgrades aren't counted: their
+// duration isn't a response time.
+func (rs *routeStats) record(entry *HTTPLogEntry) {
+	if entry.StatusCode == http.StatusSwitchingProtocols {
+		return
+	}
+	key := routeKey{method: entry.Method, route: stormEndpoint(entry.URL)}
+	ms := float64(entry.Duration) / float64(time.Millisecond)
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.routes == nil {
+		rs.routes = make(map[routeKey]*routeHistogram)
+	}
+	h := rs.routes[key]
+	if h == nil {
+		if len(rs.routes) >= maxRoutes {
+			key = routeKey{method: "*", route: otherRoute}
+			h = rs.routes[key]
+		}
+		if h == nil {
+			h = &routeHistogram{}
+			rs.routes[key] = h
+		}
+	}
+
+	bucket := sort.SearchFloat64s(latencyBuckets[:], ms)
+	h.buckets[bucket]++
+	if h.count == 0 || ms < h.minMs {
+		h.minMs = ms
+	}
+	h.count++
+	h.totalMs += ms
+	h.maxMs = math.Max(h.maxMs, ms)
+	h.lastSeen = entry.Timestamp
+	if entry.Timing != nil {
+		h.phases.add(entry.Timing)
+	}
+	switch {
+	case entry.Error != "" || entry.StatusCode >= 500:
+		h.errors++
+	case entry.StatusCode >= 400:
+		h.clientErrors++
+	}
+}
+
+// reset discards the aggregates.
+func (rs *routeStats) reset() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.routes = nil
+}
+
+// stats returns the routes selected by filter, slowest first by default.
+func (rs *routeStats) stats(filter RouteStatsFilter) []RouteStats {
+	rs.mu.Lock()
+	result := make([]RouteStats, 0, len(rs.routes))
+	for key, h := range rs.routes {
+		if h.count < filter.MinCount {
+			continue
+		}
+		result = append(result, RouteStats{
+			Method:       key.method,
+			Route:        key.route,
+			Count:        h.count,
+			Errors:       h.errors,
+			ClientErrors: h.clientErrors,
+			ErrorRate:    roundTo(float64(h.errors)/float64(h.count), 1000),
+			AvgMs:        roundTo(h.totalMs/float64(h.count), 10),
+			P50Ms:        roundTo(h.percentile(0.50), 10),
+			P95Ms:        roundTo(h.percentile(0.95), 10),
+			P99Ms:        roundTo(h.percentile(0.99), 10),
+			MaxMs:        roundTo(h.maxMs, 10),
+			LastSeen:     h.lastSeen,
+			Phases:       h.phases.averages(),
+		})
+	}
+	rs.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if va, vb := a.sortValue(filter.SortBy), b.sortValue(filter.SortBy); va != vb {
+			return va > vb
+		}
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		return a.Method < b.Method
+	})
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultRouteStatsLimit
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func (s RouteStats) sortValue(sortBy string) float64 {
+	switch sortBy {
+	case RouteSortP50:
+		return s.P50Ms
+	case RouteSortP99:
+		return s.P99Ms
+	case RouteSortCount:
+		return float64(s.Count)
+	case RouteSortErrors:
+		return float64(s.Errors)
+	case RouteSortErrorRate:
+		return s.ErrorRate
+	case RouteSortProxy:
+		if s.Phases == nil {
+			return 0
+		}
+		return s.Phases.ProxyMs
+	default:
+		return s.P95Ms
+	}
+}
+
+// percentile estimates the latency below which fraction p of requests
+// fall, interpolating within the histogram bucket it lands in.
+func (h *routeHistogram) percentile(p float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := p * float64(h.count)
+	var seen int64
+	for i, n := range h.buckets {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		lower, upper := h.minMs, h.maxMs
+		if i > 0 {
+			lower = math.Max(latencyBuckets[i-1], h.minMs)
+		}
+		if i < len(latencyBuckets) {
+			upper = math.Min(latencyBuckets[i], h.maxMs)
+		}
+		return lower + (upper-lower)*(rank-float64(seen))/float64(n)
+	}
+	return h.maxMs
+}
+
+// roundTo rounds v to 1/scale.
+func roundTo(v float64, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
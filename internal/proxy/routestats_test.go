@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRouteStats(t *testing.T) {
+	logger := NewTrafficLogger(10)
+	log := func(method, url string, status int, d time.Duration) {
+		logger.LogHTTP(HTTPLogEntry{Method: method, URL: url, StatusCode: status, Duration: d, Timestamp: time.Now()})
+	}
+
+	// 100 user lookups by ID: 90 fast, 10 slow
+	for i := 0; i < 100; i++ {
+		d := 20 * time.Millisecond
+		if i >= 90 {
+			d = 800 * time.Millisecond
+		}
+		log("GET", fmt.Sprintf("/api/users/%d?fields=all", i), http.StatusOK, d)
+	}
+	for i := 0; i < 4; i++ {
+		log("POST", "/api/orders", http.StatusInternalServerError, 50*time.Millisecond)
+	}
+	log("POST", "/api/orders", http.StatusBadRequest, 50*time.Millisecond)
+	log("GET", "/ws", http.StatusSwitchingProtocols, time.Hour)
+
+	routes := logger.RouteStats(RouteStatsFilter{})
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %+v", routes)
+	}
+	users := routes[0]
+	if users.Method != "GET" || users.Route != "/api/users/:id" || users.Count != 100 {
+		t.Fatalf("Expected the slow user route first, got %+v", users)
+	}
+	if users.P50Ms < 10 || users.P50Ms > 25 {
+		t.Errorf("Expected p50 near 20ms, got %v", users.P50Ms)
+	}
+	if users.P95Ms < 500 || users.P95Ms > 800 || users.P99Ms < users.P95Ms || users.MaxMs != 800 {
+		t.Errorf("Expected p95/p99 in the slow bucket, got p95=%v p99=%v max=%v", users.P95Ms, users.P99Ms, users.MaxMs)
+	}
+	if users.AvgMs != 98 || users.ErrorRate != 0 {
+		t.Errorf("Unexpected avg %v / error rate %v", users.AvgMs, users.ErrorRate)
+	}
+
+	orders := routes[1]
+	if orders.Count != 5 || orders.Errors != 4 || orders.ClientErrors != 1 || orders.ErrorRate != 0.8 {
+		t.Errorf("Unexpected order route %+v", orders)
+	}
+	if orders.P50Ms != 50 || orders.P99Ms != 50 {
+		t.Errorf("Expected exact percentiles for identical latencies, got %+v", orders)
+	}
+
+	if routes := logger.RouteStats(RouteStatsFilter{SortBy: RouteSortErrorRate, Limit: 1}); len(routes) != 1 || routes[0].Route != "/api/orders" {
+		t.Errorf("Expected the failing route first, got %+v", routes)
+	}
+	if routes := logger.RouteStats(RouteStatsFilter{MinCount: 10}); len(routes) != 1 {
+		t.Errorf("Expected routes below min_count skipped, got %+v", routes)
+	}
+	if err := (RouteStatsFilter{SortBy: "slowest"}).Validate(); err == nil {
+		t.Error("Expected error for an unknown sort order")
+	}
+
+	// Aggregates outlive the entries the log drops, until it's cleared
+	if stats := logger.Stats(); stats.Dropped == 0 {
+		t.Fatal("Expected the small log to drop entries")
+	}
+	logger.Clear()
+	if routes := logger.RouteStats(RouteStatsFilter{}); len(routes) != 0 {
+		t.Errorf("Expected Clear to reset route stats, got %+v", routes)
+	}
+}
+
+func TestRouteStatsPhases(t *testing.T) {
+	logger := NewTrafficLogger(10)
+	logger.LogHTTP(HTTPLogEntry{Method: "GET", URL: "/", StatusCode: 200, Duration: 100 * time.Millisecond, Timestamp: time.Now(),
+		Timing: &RequestTiming{Connect: 10 * time.Millisecond, TTFB: 50 * time.Millisecond, Upstream: 60 * time.Millisecond, Inject: 30 * time.Millisecond, Proxy: 40 * time.Millisecond}})
+	logger.LogHTTP(HTTPLogEntry{Method: "GET", URL: "/", StatusCode: 200, Duration: 40 * time.Millisecond, Timestamp: time.Now(),
+		Timing: &RequestTiming{TTFB: 30 * time.Millisecond, Upstream: 30 * time.Millisecond, Proxy: 10 * time.Millisecond, Reused: true}})
+	logger.LogHTTP(HTTPLogEntry{Method: "GET", URL: "/mocked", StatusCode: 200, Duration: 500 * time.Millisecond, Timestamp: time.Now()})
+
+	routes := logger.RouteStats(RouteStatsFilter{SortBy: RouteSortProxy})
+	if len(routes) != 2 || routes[0].Route != "/" {
+		t.Fatalf("Expected the timed route first by proxy time, got %+v", routes)
+	}
+	p := routes[0].Phases
+	if p == nil || p.Count != 2 || p.ConnectMs != 5 || p.TTFBMs != 40 || p.UpstreamMs != 45 || p.InjectMs != 15 || p.ProxyMs != 25 || p.ReusedConns != 0.5 {
+		t.Errorf("Unexpected phases %+v", p)
+	}
+	if routes[1].Phases != nil {
+		t.Errorf("Expected no phases without timing, got %+v", routes[1].Phases)
+	}
+}
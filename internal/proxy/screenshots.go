@@ -0,0 +1,418 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Decode JPEG captures for thumbnails
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Screenshot capture and storage limits.
+const (
+	// ScreenshotTimeout bounds the wait for the page to render the capture.
+	// It stays below the daemon client's request timeout.
+	ScreenshotTimeout = 20 * time.Second
+	// MaxScreenshotFiles is how many screenshot files a proxy keeps; the
+	// oldest are deleted first.
+	MaxScreenshotFiles = 100
+	// ScreenshotRetention is how long screenshot files are kept.
+	ScreenshotRetention = 7 * 24 * time.Hour
+	// maxScreenshotHeight caps a full-page capture, in CSS pixels; canvases
+	// much taller fail to render in most browsers.
+	maxScreenshotHeight = 16000
+	// thumbnailWidth and thumbnailHeight bound the thumbnail of a screenshot.
+	thumbnailWidth  = 320
+	thumbnailHeight = 960
+	// defaultAnnotations and maxAnnotations bound the interactions outlined.
+	defaultAnnotations = 5
+	maxAnnotations     = 20
+)
+
+// ScreenshotOptions configures CaptureScreenshot.
+type ScreenshotOptions struct {
+	Name          string `json:"name,omitempty"`           // Part of the file name (default: capture time)
+	Selector      string `json:"selector,omitempty"`       // Crop to this element (default: the whole page)
+	Viewport      bool   `json:"viewport,omitempty"`       // Capture only the visible viewport instead of the full page
+	Annotate      bool   `json:"annotate,omitempty"`       // Outline the targets of the page's recent interactions
+	AnnotateLimit int    `json:"annotate_limit,omitempty"` // Interactions to outline (default 5, max 20)
+	Format        string `json:"format,omitempty"`         // png (default) or jpeg
+}
+
+// PageScreenshot is a screenshot captured on request and stored on disk.
+type PageScreenshot struct {
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	SessionID     string                 `json:"session_id,omitempty"`
+	URL           string                 `json:"url"`
+	Selector      string                 `json:"selector"`
+	Viewport      bool                   `json:"viewport,omitempty"`
+	Width         int                    `json:"width"` // Image pixels
+	Height        int                    `json:"height"`
+	Format        string                 `json:"format"`
+	Truncated     bool                   `json:"truncated,omitempty"` // The page was taller than a capture can be
+	FilePath      string                 `json:"file_path"`
+	ThumbnailPath string                 `json:"thumbnail_path,omitempty"`
+	Thumbnail     []byte                 `json:"thumbnail,omitempty"` // PNG at most 320x960, base64 in JSON
+	Annotations   []ScreenshotAnnotation `json:"annotations,omitempty"`
+	Timestamp     time.Time              `json:"timestamp"`
+}
+
+// ScreenshotAnnotation is an interaction target outlined on a screenshot and
+// numbered by Index, the most recent interaction first.
+type ScreenshotAnnotation struct {
+	Index    int    `json:"index"`
+	Label    string `json:"label"` // e.g. "click button#submit"
+	Selector string `json:"selector"`
+	Visible  bool   `json:"visible"` // Found on the page and inside the captured area
+	X        int    `json:"x,omitempty"`
+	Y        int    `json:"y,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+}
+
+// ScreenshotFile is a stored screenshot of a proxy.
+type ScreenshotFile struct {
+	Name          string    `json:"name"`
+	FilePath      string    `json:"file_path"`
+	ThumbnailPath string    `json:"thumbnail_path,omitempty"`
+	Size          int64     `json:"size"`
+	ModTime       time.Time `json:"mod_time"`
+}
+
+// CaptureScreenshot renders a page, optionally cropped to an element and
+// with recent interaction targets outlined, stores the image with a
+// thumbnail and logs it. An empty sessionID captures whichever connected
+// page answers first.
+func (ps *ProxyServer) CaptureScreenshot(ctx context.Context, sessionID string, opts ScreenshotOptions) (PageScreenshot, error) {
+	if opts.Format != "jpeg" {
+		opts.Format = "png"
+	}
+	var targets []ScreenshotAnnotation
+	if opts.Annotate {
+		targets = ps.annotationTargets(sessionID, opts.AnnotateLimit)
+	}
+
+	raw, url, err := ps.evalInPage(ctx, sessionID, screenshotScript(opts, targets), ScreenshotTimeout)
+	if err != nil {
+		return PageScreenshot{}, fmt.Errorf("screenshot failed: %w", err)
+	}
+	var result struct {
+		URL         string                 `json:"url"`
+		Width       int                    `json:"width"`
+		Height      int                    `json:"height"`
+		Truncated   bool                   `json:"truncated"`
+		Annotations []ScreenshotAnnotation `json:"annotations"`
+		Data        string                 `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return PageScreenshot{}, fmt.Errorf("invalid screenshot from the page: %w", err)
+	}
+	data, err := decodeDataURL(result.Data)
+	if err != nil {
+		return PageScreenshot{}, err
+	}
+
+	timestamp := time.Now()
+	shot := PageScreenshot{
+		ID:          fmt.Sprintf("shot-%d", ps.screenshotSeq.Add(1)),
+		Name:        opts.Name,
+		SessionID:   sessionID,
+		URL:         result.URL,
+		Selector:    opts.Selector,
+		Viewport:    opts.Viewport,
+		Width:       result.Width,
+		Height:      result.Height,
+		Format:      opts.Format,
+		Truncated:   result.Truncated,
+		Annotations: result.Annotations,
+		Timestamp:   timestamp,
+	}
+	if shot.Name == "" {
+		shot.Name = timestamp.Format("20060102-150405.000")
+	}
+	if shot.Selector == "" {
+		shot.Selector = "body"
+	}
+	if shot.URL == "" {
+		shot.URL = url
+	}
+
+	ext := "png"
+	if opts.Format == "jpeg" {
+		ext = "jpg"
+	}
+	if shot.FilePath, err = ps.writeScreenshot(shot.Name, ext, data); err != nil {
+		return PageScreenshot{}, err
+	}
+	// A missing thumbnail leaves the screenshot usable
+	if thumb, err := makeThumbnail(data, thumbnailWidth, thumbnailHeight); err == nil {
+		shot.Thumbnail = thumb
+		dir := filepath.Join(filepath.Dir(shot.FilePath), "thumbnails")
+		if err := os.MkdirAll(dir, 0755); err == nil {
+			path := filepath.Join(dir, strings.TrimSuffix(filepath.Base(shot.FilePath), filepath.Ext(shot.FilePath))+".png")
+			if os.WriteFile(path, thumb, 0644) == nil {
+				shot.ThumbnailPath = path
+			}
+		}
+	}
+
+	ps.logger.LogScreenshot(Screenshot{
+		ID:        shot.ID,
+		Timestamp: timestamp,
+		Name:      shot.Name,
+		FilePath:  shot.FilePath,
+		URL:       shot.URL,
+		Width:     shot.Width,
+		Height:    shot.Height,
+		Format:    shot.Format,
+		Selector:  shot.Selector,
+	})
+	return shot, nil
+}
+
+// annotationTargets returns the distinct targets of the most recent
+// interactions on a page session, or on any page when sessionID is empty.
+func (ps *ProxyServer) annotationTargets(sessionID string, limit int) []ScreenshotAnnotation {
+	if limit <= 0 {
+		limit = defaultAnnotations
+	}
+	limit = min(limit, maxAnnotations)
+	pageURL := ""
+	if session, ok := ps.pageTracker.GetSession(sessionID); ok {
+		pageURL = session.URL
+	}
+
+	entries := ps.logger.Query(LogFilter{Types: []LogEntryType{LogTypeInteraction}})
+	var events []InteractionEvent
+	for _, entry := range entries {
+		if entry.Interaction != nil {
+			events = append(events, *entry.Interaction)
+		}
+	}
+	// The ring buffer returns entries in slot order
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	return interactionTargets(events, pageURL, limit)
+}
+
+// interactionTargets picks the targets to outline from interactions given
+// oldest first: the newest interaction of each distinct selector on pageURL (any
+// page when empty), numbered newest first. Mouse moves and scrolls are left
+// out; they don't point at an element the user meant.
+func interactionTargets(events []InteractionEvent, pageURL string, limit int) []ScreenshotAnnotation {
+	var targets []ScreenshotAnnotation
+	seen := make(map[string]bool)
+	for i := len(events) - 1; i >= 0 && len(targets) < limit; i-- {
+		e := events[i]
+		selector := e.Target.Selector
+		if selector == "" || seen[selector] || e.EventType == "mousemove" || e.EventType == "scroll" {
+			continue
+		}
+		if pageURL != "" && e.URL != pageURL {
+			continue
+		}
+		seen[selector] = true
+		targets = append(targets, ScreenshotAnnotation{
+			Index:    len(targets) + 1,
+			Label:    e.EventType + " " + selector,
+			Selector: selector,
+		})
+	}
+	return targets
+}
+
+// screenshotScript returns the code that renders the capture with the
+// injected API and returns it as a data URL.
+func screenshotScript(opts ScreenshotOptions, targets []ScreenshotAnnotation) string {
+	type annotate struct {
+		Selector string `json:"selector"`
+		Label    string `json:"label"`
+	}
+	args := struct {
+		Selector  string     `json:"selector,omitempty"`
+		Viewport  bool       `json:"viewport"`
+		Format    string     `json:"format"`
+		MaxHeight int        `json:"maxHeight"`
+		Annotate  []annotate `json:"annotate"`
+	}{Selector: opts.Selector, Viewport: opts.Viewport, Format: opts.Format, MaxHeight: maxScreenshotHeight, Annotate: []annotate{}}
+	for _, t := range targets {
+		args.Annotate = append(args.Annotate, annotate{Selector: t.Selector, Label: t.Label})
+	}
+	encoded, _ := json.Marshal(args)
+	return fmt.Sprintf("window.__devtool.screenshotData(%s)", encoded)
+}
+
+// decodeDataURL returns the bytes of a base64 data URL.
+func decodeDataURL(dataURL string) ([]byte, error) {
+	if !strings.HasPrefix(dataURL, "data:") {
+		return nil, fmt.Errorf("invalid data URL")
+	}
+	commaIdx := strings.Index(dataURL, ",")
+	if commaIdx == -1 {
+		return nil, fmt.Errorf("invalid data URL format")
+	}
+	data, err := base64.StdEncoding.DecodeString(dataURL[commaIdx+1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64: %w", err)
+	}
+	return data, nil
+}
+
+// screenshotDir returns the managed directory screenshots are stored in,
+// .agnt/audit/screenshots, falling back to the temp dir.
+func screenshotDir() string {
+	auditDir, err := GetAuditDir()
+	if err != nil {
+		auditDir = os.TempDir()
+	}
+	dir := filepath.Join(auditDir, "screenshots")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return auditDir
+	}
+	return dir
+}
+
+// screenshotPrefix starts the file names of the proxy's screenshots.
+func (ps *ProxyServer) screenshotPrefix() string {
+	return fmt.Sprintf("screenshot-%s-", ps.ID)
+}
+
+// writeScreenshot stores an image among the proxy's screenshots and applies
+// the retention limits.
+func (ps *ProxyServer) writeScreenshot(name, ext string, data []byte) (string, error) {
+	dir := screenshotDir()
+	filePath := filepath.Join(dir, ps.screenshotPrefix()+sanitizeFilename(name)+"."+ext)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	pruneScreenshots(dir, ps.screenshotPrefix(), MaxScreenshotFiles, ScreenshotRetention, time.Now())
+	return filePath, nil
+}
+
+// Screenshots lists the proxy's stored screenshots, newest first.
+func (ps *ProxyServer) Screenshots() ([]ScreenshotFile, error) {
+	files, err := listScreenshots(screenshotDir(), ps.screenshotPrefix())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ScreenshotFile, len(files))
+	for i, f := range files {
+		out[i] = ScreenshotFile{
+			Name:     strings.TrimPrefix(strings.TrimSuffix(f.name, filepath.Ext(f.name)), ps.screenshotPrefix()),
+			FilePath: f.path,
+			Size:     f.size,
+			ModTime:  f.modTime,
+		}
+		if _, err := os.Stat(f.thumbnail); err == nil {
+			out[i].ThumbnailPath = f.thumbnail
+		}
+	}
+	return out, nil
+}
+
+// storedScreenshot is a screenshot file found in the managed directory.
+type storedScreenshot struct {
+	name      string
+	path      string
+	thumbnail string
+	size      int64
+	modTime   time.Time
+}
+
+// listScreenshots returns the files in dir starting with prefix, newest first.
+func listScreenshots(dir, prefix string) ([]storedScreenshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read screenshot directory: %w", err)
+	}
+	var files []storedScreenshot
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := entry.Name()
+		files = append(files, storedScreenshot{
+			name:      name,
+			path:      filepath.Join(dir, name),
+			thumbnail: filepath.Join(dir, "thumbnails", strings.TrimSuffix(name, filepath.Ext(name))+".png"),
+			size:      info.Size(),
+			modTime:   info.ModTime(),
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	return files, nil
+}
+
+// pruneScreenshots deletes the files in dir starting with prefix that are
+// older than maxAge or beyond the newest keep, with their thumbnails. It
+// returns the deleted paths.
+func pruneScreenshots(dir, prefix string, keep int, maxAge time.Duration, now time.Time) []string {
+	files, err := listScreenshots(dir, prefix)
+	if err != nil {
+		return nil
+	}
+	var removed []string
+	for i, f := range files {
+		if i < keep && now.Sub(f.modTime) <= maxAge {
+			continue
+		}
+		if os.Remove(f.path) == nil {
+			removed = append(removed, f.path)
+		}
+		os.Remove(f.thumbnail)
+	}
+	return removed
+}
+
+// makeThumbnail scales a PNG or JPEG image down to fit maxWidth x maxHeight,
+// averaging the source pixels each thumbnail pixel covers, and encodes it as
+// PNG. Smaller images keep their size.
+func makeThumbnail(data []byte, maxWidth, maxHeight int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	scale := min(1, float64(maxWidth)/float64(w), float64(maxHeight)/float64(h))
+	tw, th := max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMakeThumbnail(t *testing.T) {
+	// Left half black, right half white
+	src := image.NewRGBA(image.Rect(0, 0, 1280, 400))
+	for y := 0; y < 400; y++ {
+		for x := 640; x < 1280; x++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := makeThumbnail(buf.Bytes(), 320, 960)
+	if err != nil {
+		t.Fatalf("makeThumbnail failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 100 {
+		t.Errorf("Expected 320x100, got %dx%d", b.Dx(), b.Dy())
+	}
+	if r, _, _, _ := img.At(10, 50).RGBA(); r != 0 {
+		t.Errorf("Expected the left side black, got %d", r)
+	}
+	if r, _, _, _ := img.At(310, 50).RGBA(); r != 0xffff {
+		t.Errorf("Expected the right side white, got %d", r)
+	}
+
+	// A tall page is fitted to the height
+	thumb, _ = makeThumbnail(encodePNG(t, 1000, 20000), 320, 960)
+	if cfg, _ := png.DecodeConfig(bytes.NewReader(thumb)); cfg.Height != 960 || cfg.Width != 48 {
+		t.Errorf("Expected 48x960, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	// A small image keeps its size
+	thumb, _ = makeThumbnail(encodePNG(t, 100, 50), 320, 960)
+	if cfg, _ := png.DecodeConfig(bytes.NewReader(thumb)); cfg.Width != 100 || cfg.Height != 50 {
+		t.Errorf("Expected 100x50, got %dx%d", cfg.Width, cfg.Height)
+	}
+
+	if _, err := makeThumbnail([]byte("not an image"), 320, 960); err == nil {
+		t.Error("Expected an error for invalid image data")
+	}
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPruneScreenshots(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "thumbnails"), 0755)
+	now := time.Now()
+	write := func(name string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("x"), 0644)
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+	for i := 0; i < 4; i++ {
+		write(fmt.Sprintf("screenshot-app-%d.png", i), time.Duration(i)*time.Hour)
+	}
+	write("screenshot-app-old.png", 8*24*time.Hour)
+	os.WriteFile(filepath.Join(dir, "thumbnails", "screenshot-app-3.png"), []byte("x"), 0644)
+	write("screenshot-other-1.png", 30*24*time.Hour)
+
+	removed := pruneScreenshots(dir, "screenshot-app-", 3, 7*24*time.Hour, now)
+	if len(removed) != 2 {
+		t.Errorf("Expected the oldest beyond 3 and the expired one removed, got %v", removed)
+	}
+	for _, name := range []string{"screenshot-app-3.png", "screenshot-app-old.png", filepath.Join("thumbnails", "screenshot-app-3.png")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s deleted", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "screenshot-other-1.png")); err != nil {
+		t.Error("Expected another proxy's screenshots kept")
+	}
+
+	files, _ := listScreenshots(dir, "screenshot-app-")
+	if len(files) != 3 || files[0].name != "screenshot-app-0.png" {
+		t.Errorf("Expected 3 files, newest first, got %+v", files)
+	}
+}
+
+func TestInteractionTargets(t *testing.T) {
+	event := func(eventType, selector, url string) InteractionEvent {
+		return InteractionEvent{EventType: eventType, Target: InteractionTarget{Selector: selector}, URL: url}
+	}
+	events := []InteractionEvent{
+		event("click", "#menu", "http://localhost/a"),
+		event("input", "#email", "http://localhost/a"),
+		event("click", "#other", "http://localhost/b"),
+		event("mousemove", "#canvas", "http://localhost/a"),
+		event("click", "#menu", "http://localhost/a"),
+		event("scroll", "body", "http://localhost/a"),
+		event("click", "", "http://localhost/a"),
+	}
+
+	targets := interactionTargets(events, "http://localhost/a", 5)
+	if len(targets) != 2 {
+		t.Fatalf("Expected #menu and #email, got %+v", targets)
+	}
+	if targets[0].Index != 1 || targets[0].Label != "click #menu" || targets[1].Label != "input #email" {
+		t.Errorf("Expected newest first, got %+v", targets)
+	}
+
+	if targets := interactionTargets(events, "", 2); len(targets) != 2 || targets[1].Selector != "#other" {
+		t.Errorf("Expected any page and the limit applied, got %+v", targets)
+	}
+}
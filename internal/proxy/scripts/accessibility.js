@@ -934,8 +934,11 @@
       runOnly: {
         type: 'tag',
         values: runOnly
-      },
-      // Exclude agnt/devtool UI elements from audit
+      }
+    };
+
+    // Exclude agnt/devtool UI elements from audit
+    var context = {
       exclude: [
         ['#__devtool-indicator'],
         ['#__devtool-panel'],
@@ -947,10 +950,10 @@
 
     // Allow custom element selection
     if (options.selector) {
-      axeOptions.selector = options.selector;
+      context.include = [[options.selector]];
     }
 
-    return window.axe.run(axeOptions).then(function(results) {
+    return window.axe.run(context, axeOptions).then(function(results) {
       // === AI-OPTIMIZED RESPONSE (DEFAULT) ===
       // Groups issues by type with limited examples for token efficiency
       if (!raw) {
@@ -8,6 +8,8 @@
 
   var MAX_ENTRIES = 100;
   var callBuffer = [];
+  var inflight = 0;
+  var lastActivity = 0;
   var originalFetch = window.fetch;
   var originalXHROpen = XMLHttpRequest.prototype.open;
   var originalXHRSend = XMLHttpRequest.prototype.send;
@@ -22,6 +24,21 @@
     }
   }
 
+  /**
+   * Count a request as in flight; returns the function that ends it
+   */
+  function startRequest() {
+    var ended = false;
+    inflight++;
+    lastActivity = Date.now();
+    return function() {
+      if (ended) return;
+      ended = true;
+      inflight--;
+      lastActivity = Date.now();
+    };
+  }
+
   /**
    * Truncate tokens from URLs for privacy
    */
@@ -61,9 +78,11 @@
       ok: null,
       error: null
     };
+    var endRequest = startRequest();
 
     return originalFetch.apply(this, arguments)
       .then(function(response) {
+        endRequest();
         call.status = response.status;
         call.ok = response.ok;
         call.duration = Date.now() - startTime;
@@ -71,6 +90,7 @@
         return response;
       })
       .catch(function(error) {
+        endRequest();
         call.status = 0;
         call.ok = false;
         call.duration = Date.now() - startTime;
@@ -100,8 +120,10 @@
     }
 
     xhr.__devtool_api.startTime = Date.now();
+    var endRequest = startRequest();
 
     var onLoadEnd = function() {
+      endRequest();
       var call = {
         timestamp: xhr.__devtool_api.startTime,
         url: xhr.__devtool_api.url,
@@ -249,6 +271,14 @@
     callBuffer = [];
   }
 
+  /**
+   * Get the number of fetch/XHR requests still open and when the last one
+   * started or finished
+   */
+  function getInflight() {
+    return { inflight: inflight, lastActivity: lastActivity };
+  }
+
   /**
    * Get deduplicated error summary
    */
@@ -302,6 +332,7 @@
     getCallsByStatus: getCallsByStatus,
     getRecentCalls: getRecentCalls,
     getErrorSummary: getErrorSummary,
+    getInflight: getInflight,
     clear: clear
   };
 })();
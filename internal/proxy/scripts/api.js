@@ -26,6 +26,9 @@
   var store = window.__devtool_store;
   var content = window.__devtool_content;
   var wireframe = window.__devtool_wireframe;
+  var idle = window.__devtool_idle;
+  var coverage = window.__devtool_coverage;
+  var webVitals = window.__devtool_webvitals;
 
   // Main DevTool API
   window.__devtool = {
@@ -111,6 +114,8 @@
 
     selectElement: interactive.selectElement,
     waitForElement: interactive.waitForElement,
+    whenIdle: idle ? idle.whenIdle : function() { return Promise.reject(new Error('Idle module not loaded')); },
+    isIdle: idle ? idle.isIdle : function() { return false; },
     ask: interactive.ask,
     measureBetween: interactive.measureBetween,
 
@@ -143,6 +148,9 @@
     auditSecurity: audit.auditSecurity,
     auditPageQuality: audit.auditPageQuality,
     auditPerformance: audit.auditPerformance,
+    // Style rules that matched no element while the tab's session lasted: {limit: 50}
+    cssCoverage: coverage ? coverage.cssCoverage : function() { return { error: 'Coverage module not loaded' }; },
+    webVitals: webVitals ? webVitals.get : function() { return { error: 'Web vitals module not loaded' }; },
 
     // ========================================================================
     // INTERACTION TRACKING (NEW)
@@ -847,6 +855,200 @@
       });
     },
 
+    /**
+     * Capture a screenshot and return it instead of logging it. Used by the
+     * daemon's SCREENSHOT CAPTURE, which stores the image and its thumbnail.
+     *
+     * Options:
+     * - selector: CSS selector of the element to crop to (default: whole page)
+     * - viewport: Capture only the visible viewport instead of the full page
+     * - maxHeight: Full-page captures stop at this height in CSS pixels
+     * - format: 'png' (default) or 'jpeg'
+     * - annotate: [{selector, label}] elements to outline, numbered from 1
+     *
+     * Resolves to {url, width, height, truncated, annotations, data} where
+     * annotations give each outline's position in image pixels.
+     */
+    screenshotData: function(options) {
+      options = options || {};
+      return new Promise(function(resolve, reject) {
+        if (typeof html2canvas === 'undefined') {
+          reject(new Error('html2canvas not loaded'));
+          return;
+        }
+
+        var target = document.body;
+        if (options.selector) {
+          try {
+            target = document.querySelector(options.selector);
+          } catch (err) {
+            reject(new Error('Invalid selector: ' + options.selector + ' - ' + err.message));
+            return;
+          }
+          if (!target) {
+            reject(new Error('Element not found: ' + options.selector));
+            return;
+          }
+        }
+
+        var doc = document.documentElement;
+        var canvasOptions = {
+          allowTaint: true,
+          useCORS: true,
+          logging: false,
+          scrollX: -window.scrollX,
+          scrollY: -window.scrollY,
+          ignoreElements: function(el) {
+            return typeof el.id === 'string' && el.id.indexOf('__devtool') === 0;
+          }
+        };
+
+        // Captured area in page coordinates, to place the outlines
+        var area;
+        var truncated = false;
+        if (target !== document.body) {
+          var rect = target.getBoundingClientRect();
+          area = {x: rect.left + window.scrollX, y: rect.top + window.scrollY, width: rect.width, height: rect.height};
+        } else if (options.viewport) {
+          area = {x: window.scrollX, y: window.scrollY, width: window.innerWidth, height: window.innerHeight};
+          canvasOptions.windowWidth = doc.scrollWidth;
+          canvasOptions.windowHeight = window.innerHeight;
+          canvasOptions.x = area.x;
+          canvasOptions.y = area.y;
+          canvasOptions.width = area.width;
+          canvasOptions.height = area.height;
+        } else {
+          var maxHeight = options.maxHeight || 16000;
+          truncated = doc.scrollHeight > maxHeight;
+          area = {x: 0, y: 0, width: doc.scrollWidth, height: Math.min(doc.scrollHeight, maxHeight)};
+          canvasOptions.windowWidth = area.width;
+          canvasOptions.windowHeight = area.height;
+          canvasOptions.height = area.height;
+        }
+
+        html2canvas(target, canvasOptions).then(function(canvas) {
+          var scale = area.width > 0 ? canvas.width / area.width : 1;
+          var ctx = canvas.getContext('2d');
+          var annotations = (options.annotate || []).map(function(a, i) {
+            var entry = {index: i + 1, label: a.label, selector: a.selector, visible: false};
+            var el = null;
+            try {
+              el = document.querySelector(a.selector);
+            } catch (e) {
+              return entry;
+            }
+            if (!el) return entry;
+
+            var r = el.getBoundingClientRect();
+            var x = Math.round((r.left + window.scrollX - area.x) * scale);
+            var y = Math.round((r.top + window.scrollY - area.y) * scale);
+            var w = Math.round(r.width * scale);
+            var h = Math.round(r.height * scale);
+            if (w <= 0 || h <= 0 || x >= canvas.width || y >= canvas.height || x + w <= 0 || y + h <= 0) {
+              return entry;
+            }
+            entry.visible = true;
+            entry.x = x;
+            entry.y = y;
+            entry.width = w;
+            entry.height = h;
+
+            // Outline with a numbered badge at its top-left corner
+            var badge = Math.round(18 * scale);
+            ctx.save();
+            ctx.strokeStyle = '#e11d48';
+            ctx.lineWidth = Math.max(2, Math.round(2 * scale));
+            ctx.strokeRect(x, y, w, h);
+            ctx.fillStyle = '#e11d48';
+            ctx.fillRect(x, Math.max(0, y - badge), badge, badge);
+            ctx.fillStyle = '#ffffff';
+            ctx.font = 'bold ' + Math.round(12 * scale) + 'px sans-serif';
+            ctx.textAlign = 'center';
+            ctx.textBaseline = 'middle';
+            ctx.fillText(String(i + 1), x + badge / 2, Math.max(0, y - badge) + badge / 2);
+            ctx.restore();
+            return entry;
+          });
+
+          var format = options.format === 'jpeg' ? 'jpeg' : 'png';
+          resolve({
+            url: window.location.href,
+            width: canvas.width,
+            height: canvas.height,
+            truncated: truncated,
+            annotations: annotations,
+            data: format === 'jpeg' ? canvas.toDataURL('image/jpeg', 0.92) : canvas.toDataURL('image/png')
+          });
+        }).catch(reject);
+      });
+    },
+
+    // ========================================================================
+    // REPLAY
+    // ========================================================================
+
+    /**
+     * Perform one step of a recorded flow. Used by the daemon's RECORD REPLAY,
+     * which handles navigate steps itself.
+     *
+     * Step properties:
+     * - action: 'click', 'dblclick', 'fill' or 'press'
+     * - selector: CSS selector of the element, waited for up to timeout ms
+     * - value: Value of a fill
+     * - key: 'Enter', 'Escape' or 'Tab' for a press
+     *
+     * Resolves to {url, tag}; rejects when the element never appears.
+     */
+    replayStep: function(step, timeout) {
+      return interactive.waitForElement(step.selector, timeout || 5000).then(function(el) {
+        if (el.scrollIntoView) el.scrollIntoView({ block: 'center' });
+        switch (step.action) {
+          case 'click':
+            el.click();
+            break;
+          case 'dblclick':
+            el.dispatchEvent(new MouseEvent('dblclick', { bubbles: true, cancelable: true, view: window }));
+            break;
+          case 'fill':
+            // Checkboxes and radios change through their recorded clicks
+            if (el.type === 'checkbox' || el.type === 'radio') break;
+            if (el.focus) el.focus();
+            if (el.isContentEditable) {
+              el.textContent = step.value || '';
+            } else {
+              // Go through the prototype's setter so frameworks that track
+              // the value (React) see the change
+              var proto = Object.getPrototypeOf(el);
+              var desc = Object.getOwnPropertyDescriptor(proto, 'value');
+              if (desc && desc.set) {
+                desc.set.call(el, step.value || '');
+              } else {
+                el.value = step.value || '';
+              }
+            }
+            el.dispatchEvent(new Event('input', { bubbles: true }));
+            el.dispatchEvent(new Event('change', { bubbles: true }));
+            break;
+          case 'press':
+            var init = { key: step.key, bubbles: true, cancelable: true };
+            var proceed = el.dispatchEvent(new KeyboardEvent('keydown', init));
+            el.dispatchEvent(new KeyboardEvent('keyup', init));
+            // Synthetic key events have no default action
+            if (proceed && step.key === 'Enter' && el.form && el.tagName === 'INPUT') {
+              if (el.form.requestSubmit) {
+                el.form.requestSubmit();
+              } else {
+                el.form.submit();
+              }
+            }
+            break;
+          default:
+            throw new Error('Unknown step action: ' + step.action);
+        }
+        return { url: window.location.href, tag: el.tagName.toLowerCase() };
+      });
+    },
+
     // ========================================================================
     // TOAST NOTIFICATIONS
     // ========================================================================
@@ -860,6 +1062,42 @@
       dismiss: function() {},
       dismissAll: function() {},
       configure: function() {}
+    },
+
+    // ========================================================================
+    // ENVIRONMENT BANNER
+    // ========================================================================
+
+    banner: window.__devtool_banner || {
+      getState: function() { return null; },
+      hide: function() {},
+      show: function() {}
+    },
+
+    // ========================================================================
+    // OVERLAY LANGUAGE AND THEME
+    // ========================================================================
+
+    ui: window.__devtool_ui || {
+      getState: function() { return null; },
+      t: function(key, fallback) { return fallback; },
+      isDark: function() { return false; },
+      themed: function(base) { return base; },
+      position: function() { return 'bottom-left'; },
+      isMinimal: function() { return false; },
+      reducedMotion: function() { return false; },
+      isHidden: function() { return false; },
+      onChange: function() {}
+    },
+
+    // ========================================================================
+    // TIME TRAVEL
+    // ========================================================================
+
+    timeTravel: window.__devtool_timetravel || {
+      domAt: function() { throw new Error('Time travel module not loaded'); },
+      beforeError: function() { throw new Error('Time travel module not loaded'); },
+      getState: function() { return null; }
     }
   };
 
@@ -0,0 +1,137 @@
+// Environment banner for DevTool
+// A thin strip naming the instrumented dev instance (environment, git branch)
+// and warning while chaos rules are active or the proxy is exposed publicly.
+// The proxy hands over the initial state on the script tag and pushes
+// updates over the metrics WebSocket.
+
+(function() {
+  'use strict';
+
+  var core = window.__devtool_core;
+  var ui = window.__devtool_ui;
+
+  var COLORS = {
+    background: '#4f46e5',
+    text: '#ffffff',
+    warning: '#f59e0b',
+    warningText: '#1e293b'
+  };
+
+  var HEIGHT = 22;
+
+  var state = null;
+  var element = null;
+  var hidden = false;
+
+  // Read once from the script tag while it is still the current script
+  function readInitialState() {
+    try {
+      var script = document.currentScript;
+      if (!script) return null;
+      var raw = script.getAttribute('data-devtool-banner');
+      script.removeAttribute('data-devtool-banner');
+      return raw ? JSON.parse(raw) : null;
+    } catch (e) {
+      console.error('[DevTool][Banner] Invalid banner state:', e);
+      return null;
+    }
+  }
+
+  function segment(text, background, color) {
+    var span = document.createElement('span');
+    span.textContent = text;
+    span.style.cssText = [
+      'padding: 0 10px',
+      'line-height: ' + HEIGHT + 'px',
+      'white-space: nowrap',
+      'background: ' + background,
+      'color: ' + color
+    ].join(';');
+    return span;
+  }
+
+  function render() {
+    if (element && element.parentNode) {
+      element.parentNode.removeChild(element);
+    }
+    element = null;
+    if (!state || hidden || !document.body) return;
+
+    element = document.createElement('div');
+    element.id = '__devtool-banner';
+    element.setAttribute('aria-hidden', 'true');
+    element.style.cssText = [
+      'position: fixed',
+      'left: 0',
+      'right: 0',
+      (state.position === 'top' ? 'top: 0' : 'bottom: 0'),
+      'height: ' + HEIGHT + 'px',
+      'z-index: 2147483644',
+      'display: flex',
+      'overflow: hidden',
+      'pointer-events: none',
+      'opacity: 0.92',
+      'font: 600 11px/' + HEIGHT + 'px -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif',
+      'background: ' + (state.color || accent() || COLORS.background)
+    ].join(';');
+
+    var label = (ui ? ui.t('banner.label', 'agnt dev') : 'agnt dev') + ' · ' + (state.label || 'proxy');
+    if (state.branch) {
+      label += ' · ⎇ ' + state.branch;
+    }
+    element.appendChild(segment(label, 'transparent', COLORS.text));
+
+    var warnings = state.warnings || [];
+    for (var i = 0; i < warnings.length; i++) {
+      element.appendChild(segment('⚠ ' + warnings[i], COLORS.warning, COLORS.warningText));
+    }
+
+    document.body.appendChild(element);
+  }
+
+  // The overlay accent, when set, colors the banner unless it has its own
+  function accent() {
+    var uiState = ui && ui.getState();
+    return uiState ? uiState.accent : '';
+  }
+
+  function handleMessage(message) {
+    if (message.type !== 'banner') return;
+    state = message.payload || null;
+    render();
+  }
+
+  state = readInitialState();
+  if (document.body) {
+    render();
+  } else {
+    document.addEventListener('DOMContentLoaded', render);
+  }
+
+  if (core && core.onMessage) {
+    core.onMessage(handleMessage);
+  }
+  if (ui) {
+    ui.onChange(render);
+  }
+
+  window.__devtool_banner = {
+    /**
+     * Current banner state, or null when the proxy has no banner
+     * @returns {Object|null} - {label, branch, position, color, warnings}
+     */
+    getState: function() {
+      return state;
+    },
+    /** Hide the banner for this page view */
+    hide: function() {
+      hidden = true;
+      render();
+    },
+    /** Show the banner again after hide() */
+    show: function() {
+      hidden = false;
+      render();
+    }
+  };
+})();
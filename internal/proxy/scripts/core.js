@@ -48,7 +48,36 @@
       }
     })();
 
+    // Session values issued by the proxy at injection time. Read once from the
+    // script tag and removed so other scripts can't lift them from the DOM.
+    function takeScriptAttribute(name) {
+      try {
+        var script = document.currentScript;
+        if (!script) return '';
+        var value = script.getAttribute(name) || '';
+        script.removeAttribute(name);
+        return value;
+      } catch (e) {
+        return '';
+      }
+    }
+
+    var SESSION_TOKEN = takeScriptAttribute('data-devtool-token');
+
+    // Proxy public key for payload encryption (empty when encryption is off)
+    var ENCRYPTION_PUBKEY = takeScriptAttribute('data-devtool-pubkey');
+    var hasSubtleCrypto = !!(window.crypto && window.crypto.subtle);
+    var encryptionReady = null; // Promise resolving to the AES-GCM key for the current connection
+    var encryptedSendChain = null; // Serializes encrypted sends to preserve message order
+
+    if (ENCRYPTION_PUBKEY && !hasSubtleCrypto) {
+      console.warn('[DevTool] Payload encryption requires a secure context - metrics disabled');
+    }
+
     var WS_URL = protocol + '//' + window.location.host + '/__devtool_metrics';
+    if (SESSION_TOKEN) {
+      WS_URL += '?token=' + encodeURIComponent(SESSION_TOKEN);
+    }
     var ws = null;
     var reconnectAttempts = 0;
     var MAX_RECONNECT_ATTEMPTS = 5;
@@ -151,8 +180,9 @@
       try {
         console.error('[DevTool][INTERNAL]', context, error);
 
-        // Try to send to server if connection is available
-        if (ws && ws.readyState === WebSocket.OPEN) {
+        // Try to send to server if connection is available (plaintext only;
+        // with payload encryption the server would drop it anyway)
+        if (ws && ws.readyState === WebSocket.OPEN && !ENCRYPTION_PUBKEY) {
           safeWebSocketSend(ws, JSON.stringify({
             type: 'error',
             data: {
@@ -172,6 +202,97 @@
       }
     }
 
+    function base64ToBytes(b64) {
+      var bin = atob(b64);
+      var bytes = new Uint8Array(bin.length);
+      for (var i = 0; i < bin.length; i++) {
+        bytes[i] = bin.charCodeAt(i);
+      }
+      return bytes;
+    }
+
+    function bytesToBase64(bytes) {
+      var bin = '';
+      var chunk = 0x8000;
+      for (var i = 0; i < bytes.length; i += chunk) {
+        bin += String.fromCharCode.apply(null, bytes.subarray(i, i + chunk));
+      }
+      return btoa(bin);
+    }
+
+    // Payload encryption key exchange: ephemeral ECDH P-256 key per connection,
+    // AES-256-GCM key = SHA-256(shared secret). Sends our public key in plaintext.
+    function startKeyExchange(socket) {
+      var subtle = window.crypto.subtle;
+      var curve = { name: 'ECDH', namedCurve: 'P-256' };
+      var localPair = null;
+
+      return subtle.generateKey(curve, true, ['deriveBits'])
+        .then(function(pair) {
+          localPair = pair;
+          return subtle.importKey('raw', base64ToBytes(ENCRYPTION_PUBKEY), curve, false, []);
+        })
+        .then(function(serverKey) {
+          return subtle.deriveBits({ name: 'ECDH', public: serverKey }, localPair.privateKey, 256);
+        })
+        .then(function(secret) {
+          return subtle.digest('SHA-256', secret);
+        })
+        .then(function(digest) {
+          return subtle.importKey('raw', digest, { name: 'AES-GCM' }, false, ['encrypt']);
+        })
+        .then(function(aesKey) {
+          return subtle.exportKey('raw', localPair.publicKey).then(function(raw) {
+            safeWebSocketSend(socket, JSON.stringify({
+              type: 'key_exchange',
+              data: { public_key: bytesToBase64(new Uint8Array(raw)) }
+            }));
+            return aesKey;
+          });
+        });
+    }
+
+    // Encrypt data and return nonce||ciphertext as Uint8Array
+    function sealPayload(aesKey, data) {
+      var iv = window.crypto.getRandomValues(new Uint8Array(12));
+      return window.crypto.subtle.encrypt({ name: 'AES-GCM', iv: iv }, aesKey, data)
+        .then(function(ct) {
+          var sealed = new Uint8Array(12 + ct.byteLength);
+          sealed.set(iv, 0);
+          sealed.set(new Uint8Array(ct), 12);
+          return sealed;
+        });
+    }
+
+    // Queue an encrypted send after the key exchange and any earlier sends,
+    // preserving message order.
+    function sendEncrypted(socket, data, binary) {
+      if (!encryptionReady) return false;
+      var keyPromise = encryptionReady;
+      var plain = binary ? data : new TextEncoder().encode(data);
+      encryptedSendChain = (encryptedSendChain || Promise.resolve())
+        .then(function() {
+          return keyPromise;
+        })
+        .then(function(aesKey) {
+          return sealPayload(aesKey, plain);
+        })
+        .then(function(sealed) {
+          if (binary) {
+            safeWebSocketSend(socket, sealed.buffer);
+          } else {
+            safeWebSocketSend(socket, JSON.stringify({
+              type: 'encrypted',
+              data: { payload: bytesToBase64(sealed) }
+            }));
+          }
+        })
+        .catch(function(e) {
+          reportInternalError('payload_encryption_failed', e);
+        });
+      return true;
+    }
+
     // Safe WebSocket send
     function safeWebSocketSend(socket, data) {
       if (!socket || socket.readyState !== WebSocket.OPEN) {
@@ -219,12 +340,21 @@
           }
         }
 
+        if (ENCRYPTION_PUBKEY && !hasSubtleCrypto) {
+          return;
+        }
+
         ws = new WebSocket(WS_URL);
+        encryptionReady = null;
+        encryptedSendChain = null;
 
         ws.onopen = function() {
           try {
             console.log('[DevTool] Metrics connection established');
             reconnectAttempts = 0;
+            if (ENCRYPTION_PUBKEY) {
+              encryptionReady = startKeyExchange(ws);
+            }
             sendPageLoad();
           } catch (e) {
             reportInternalError('onopen_handler_failed', e);
@@ -289,8 +419,9 @@
       if (!message || typeof message !== 'object') return;
 
       try {
-        // Handle execution requests
-        if (message.type === 'execute' && message.code) {
+        // Handle execution requests, unless addressed to another tab
+        if (message.type === 'execute' && message.code &&
+            (!message.session || message.session === getOrCreateSessionId())) {
           executeJavaScript(message.id, message.code);
         }
 
@@ -434,6 +565,9 @@
           session_id: getOrCreateSessionId()
         });
 
+        if (ENCRYPTION_PUBKEY) {
+          return sendEncrypted(ws, message, false);
+        }
         return safeWebSocketSend(ws, message);
       } catch (e) {
         reportInternalError('send_failed', e);
@@ -447,6 +581,9 @@
       if (!ws || ws.readyState !== WebSocket.OPEN) return false;
       if (!data) return false;
 
+      if (ENCRYPTION_PUBKEY) {
+        return sendEncrypted(ws, data, true);
+      }
       return safeWebSocketSend(ws, data);
     }
 
@@ -630,12 +767,107 @@
           } catch (e) {
             reportInternalError('console_warn_override_failed', e);
           }
+          forwardConsole('warn', arguments);
         };
+
+        ['log', 'info', 'debug'].forEach(function(level) {
+          var original = console[level];
+          if (typeof original !== 'function') return;
+          console[level] = function() {
+            original.apply(console, arguments);
+            forwardConsole(level, arguments);
+          };
+        });
       } catch (e) {
         reportInternalError('console_override_setup_failed', e);
       }
     }
 
+    // Console forwarding to the proxy log. Bounded per second so a logging
+    // loop can't flood the connection; the count dropped rides along with
+    // the next message that gets through.
+    var CONSOLE_RATE_LIMIT = 50;
+    var CONSOLE_ARG_LIMIT = 1000;
+    var consoleWindowStart = 0;
+    var consoleWindowCount = 0;
+    var consoleDropped = 0;
+    var forwardingConsole = false;
+
+    function serializeConsoleArg(arg) {
+      var text;
+      if (typeof arg === 'string') {
+        text = arg;
+      } else if (arg instanceof Error) {
+        text = arg.stack || String(arg);
+      } else if (arg && typeof arg === 'object') {
+        try {
+          text = JSON.stringify(arg);
+        } catch (e) {
+          text = String(arg);
+        }
+        if (text === undefined) text = String(arg);
+      } else {
+        text = String(arg);
+      }
+      return text.length > CONSOLE_ARG_LIMIT ? text.substring(0, CONSOLE_ARG_LIMIT) + '...' : text;
+    }
+
+    // consoleSource returns the location of the console call: the first
+    // stack frame outside this script.
+    function consoleSource() {
+      try {
+        var stack = new Error().stack || '';
+        var frames = stack.match(/(?:https?|file):\/\/[^\s()]+:\d+:\d+/g);
+        if (!frames) return '';
+        var own = frames[0].replace(/:\d+:\d+$/, '');
+        for (var i = 1; i < frames.length; i++) {
+          if (frames[i].replace(/:\d+:\d+$/, '') !== own) return frames[i];
+        }
+      } catch (e) {
+        // Stack unavailable
+      }
+      return '';
+    }
+
+    function forwardConsole(level, args) {
+      if (forwardingConsole) return; // Console calls made while sending
+      forwardingConsole = true;
+      try {
+        var now = Date.now();
+        if (now - consoleWindowStart >= 1000) {
+          consoleWindowStart = now;
+          consoleWindowCount = 0;
+        }
+        if (consoleWindowCount >= CONSOLE_RATE_LIMIT) {
+          consoleDropped++;
+          return;
+        }
+
+        var parts = [];
+        for (var i = 0; i < args.length && i < 10; i++) {
+          parts.push(serializeConsoleArg(args[i]));
+        }
+        var message = parts.join(' ');
+        if (message.indexOf('[DevTool]') === 0) return; // Our own output
+
+        consoleWindowCount++;
+        if (send('console', {
+          level: level,
+          message: message,
+          args: parts,
+          source: consoleSource(),
+          dropped: consoleDropped,
+          timestamp: now
+        })) {
+          consoleDropped = 0;
+        }
+      } catch (e) {
+        // Never let forwarding break the page's logging
+      } finally {
+        forwardingConsole = false;
+      }
+    }
+
     // Enhance error tracking to also capture to buffer
     function setupErrorTrackingWithBuffer() {
       setupErrorTracking();
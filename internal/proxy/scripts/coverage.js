@@ -0,0 +1,249 @@
+// CSS coverage for DevTool
+// Records which style rules matched an element while the tab's session
+// lasted, so the daemon can report unused CSS per stylesheet
+
+(function() {
+  'use strict';
+
+  var STORAGE_KEY = '__devtool_css_used';
+  var SAMPLE_DELAY_MS = 2000;
+  var MAX_RULES = 20000;
+  var MAX_PAGES = 50;
+
+  // States that need a user action and generated content; a rule using them
+  // counts as used when the element it styles exists
+  var DYNAMIC_PSEUDO = /::?(hover|focus-visible|focus-within|focus|active|visited|target|before|after|first-line|first-letter|placeholder|selection|marker|backdrop|file-selector-button|-webkit-[a-z-]+|-moz-[a-z-]+|-ms-[a-z-]+)(\([^)]*\))?/gi;
+
+  // {used: {sheetKey: {selectorText: 1}}, pages: [path], samples: n}
+  var state = load();
+  var sampleTimer = null;
+
+  function load() {
+    try {
+      var stored = sessionStorage.getItem(STORAGE_KEY);
+      if (stored) {
+        var parsed = JSON.parse(stored);
+        if (parsed && parsed.used) return parsed;
+      }
+    } catch (e) {}
+    return { used: {}, pages: [], samples: 0 };
+  }
+
+  function save() {
+    try {
+      sessionStorage.setItem(STORAGE_KEY, JSON.stringify(state));
+    } catch (e) {
+      // Quota exceeded; coverage of this page still counts until it unloads
+    }
+  }
+
+  function hashText(text) {
+    var h = 0;
+    for (var i = 0; i < text.length; i++) {
+      h = (h * 31 + text.charCodeAt(i)) | 0;
+    }
+    return (h >>> 0).toString(36);
+  }
+
+  function isDevtoolSheet(sheet) {
+    var node = sheet.ownerNode;
+    var id = node && node.id || '';
+    return id.indexOf('__devtool') === 0;
+  }
+
+  // Stable key of a stylesheet across the tab's pages: its URL without
+  // query, or a hash of an inline sheet's text
+  function sheetKey(sheet) {
+    if (sheet.href) {
+      return sheet.href.split('#')[0].split('?')[0];
+    }
+    var text = sheet.ownerNode && sheet.ownerNode.textContent || '';
+    return 'inline:' + hashText(text);
+  }
+
+  // Calls fn(rule, media) for every style rule of an accessible sheet,
+  // inside @media, @supports, @layer and @container too
+  function walkRules(rules, media, fn, counts) {
+    for (var i = 0; i < rules.length; i++) {
+      var rule = rules[i];
+      if (rule.selectorText !== undefined) {
+        fn(rule, media);
+        continue;
+      }
+      if (rule.cssRules) {
+        var condition = rule.conditionText || (rule.media && rule.media.mediaText) || '';
+        var label = condition ? (media ? media + ' and ' + condition : condition) : media;
+        walkRules(rule.cssRules, label, fn, counts);
+        continue;
+      }
+      counts.otherRules++;
+    }
+  }
+
+  // Splits a selector list on its top-level commas
+  function splitSelectors(text) {
+    var parts = [];
+    var depth = 0;
+    var start = 0;
+    for (var i = 0; i < text.length; i++) {
+      var c = text.charAt(i);
+      if (c === '(' || c === '[') depth++;
+      else if (c === ')' || c === ']') depth--;
+      else if (c === ',' && depth === 0) {
+        parts.push(text.slice(start, i));
+        start = i + 1;
+      }
+    }
+    parts.push(text.slice(start));
+    return parts;
+  }
+
+  // Whether any selector of the list matches an element now. Selectors the
+  // browser can't query count as matching, so they are never reported unused.
+  function matches(selectorText) {
+    var parts = splitSelectors(selectorText);
+    for (var i = 0; i < parts.length; i++) {
+      var part = parts[i].replace(DYNAMIC_PSEUDO, '').trim();
+      if (!part || /[>+~]$/.test(part)) part += '*';
+      try {
+        if (document.querySelector(part)) return true;
+      } catch (e) {
+        return true;
+      }
+    }
+    return false;
+  }
+
+  function forEachSheet(fn) {
+    var sheets = document.styleSheets;
+    for (var i = 0; i < sheets.length; i++) {
+      var sheet = sheets[i];
+      if (isDevtoolSheet(sheet)) continue;
+      var rules = null;
+      var error = null;
+      try {
+        rules = sheet.cssRules;
+      } catch (e) {
+        error = 'cross-origin stylesheet without CORS';
+      }
+      fn(sheet, rules, error);
+    }
+  }
+
+  /**
+   * Check the rules not yet seen matching against the current DOM
+   */
+  function sample() {
+    var checked = 0;
+    forEachSheet(function(sheet, rules) {
+      if (!rules) return;
+      var key = sheetKey(sheet);
+      var used = state.used[key] || (state.used[key] = {});
+      walkRules(rules, '', function(rule) {
+        if (checked >= MAX_RULES || used[rule.selectorText]) return;
+        checked++;
+        if (matches(rule.selectorText)) used[rule.selectorText] = 1;
+      }, { otherRules: 0 });
+    });
+
+    var path = location.pathname + location.search;
+    if (state.pages.indexOf(path) === -1 && state.pages.length < MAX_PAGES) {
+      state.pages.push(path);
+    }
+    state.samples++;
+    save();
+  }
+
+  function scheduleSample() {
+    if (sampleTimer) return;
+    sampleTimer = setTimeout(function() {
+      sampleTimer = null;
+      if (!document.hidden) sample();
+    }, SAMPLE_DELAY_MS);
+  }
+
+  /**
+   * Coverage of the current page's stylesheets over the tab's session
+   * @param {Object} [options] - {limit: 50} unused rules listed per sheet
+   * @returns {Object} - {url, pages, samples, otherRules, truncated, sheets, inaccessible}
+   */
+  function cssCoverage(options) {
+    options = options || {};
+    var limit = options.limit > 0 ? options.limit : 50;
+    sample();
+
+    var result = {
+      url: location.href,
+      pages: state.pages.slice(),
+      samples: state.samples,
+      otherRules: 0,
+      truncated: false,
+      sheets: [],
+      inaccessible: []
+    };
+    var total = 0;
+    var inlineIndex = 0;
+
+    forEachSheet(function(sheet, rules, error) {
+      var inline = !sheet.href;
+      var file = inline ? '<style> ' + (++inlineIndex) + ' on ' + location.pathname : sheet.href;
+      if (!rules) {
+        result.inaccessible.push({ file: file, reason: error });
+        return;
+      }
+      var used = state.used[sheetKey(sheet)] || {};
+      var entry = { file: file, inline: inline, rules: 0, usedRules: 0, bytes: 0, unusedBytes: 0, unusedRules: 0, unused: [] };
+      var counts = { otherRules: 0 };
+      walkRules(rules, '', function(rule, media) {
+        if (total >= MAX_RULES) {
+          result.truncated = true;
+          return;
+        }
+        total++;
+        var bytes = rule.cssText.length;
+        entry.rules++;
+        entry.bytes += bytes;
+        if (used[rule.selectorText]) {
+          entry.usedRules++;
+          return;
+        }
+        entry.unusedRules++;
+        entry.unusedBytes += bytes;
+        entry.unused.push({ selector: rule.selectorText, media: media || undefined, bytes: bytes });
+      }, counts);
+      // Keep the largest, which pruning gains most from
+      entry.unused.sort(function(a, b) { return b.bytes - a.bytes; });
+      entry.unused = entry.unused.slice(0, limit);
+      result.otherRules += counts.otherRules;
+      result.sheets.push(entry);
+    });
+
+    return result;
+  }
+
+  function start() {
+    sample();
+    if (typeof MutationObserver === 'undefined') return;
+    try {
+      new MutationObserver(scheduleSample).observe(document.documentElement, {
+        childList: true,
+        subtree: true,
+        attributes: true,
+        attributeFilter: ['class', 'id', 'open', 'hidden', 'disabled', 'checked', 'aria-expanded', 'aria-selected']
+      });
+    } catch (e) {
+      console.error('[DevTool][Coverage] Failed to observe mutations:', e);
+    }
+  }
+
+  if (document.readyState === 'complete') {
+    setTimeout(start, 0);
+  } else {
+    window.addEventListener('load', start);
+  }
+
+  window.__devtool_coverage = {
+    cssCoverage: cssCoverage,
+    sample: sample
+  };
+})();
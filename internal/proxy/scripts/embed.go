@@ -15,6 +15,9 @@ var (
 	//go:embed utils.js
 	utilsJS string
 
+	//go:embed ui.js
+	uiJS string
+
 	//go:embed overlay.js
 	overlayJS string
 
@@ -93,6 +96,21 @@ var (
 	//go:embed wireframe.js
 	wireframeJS string
 
+	//go:embed idle.js
+	idleJS string
+
+	//go:embed coverage.js
+	coverageJS string
+
+	//go:embed webvitals.js
+	webVitalsJS string
+
+	//go:embed banner.js
+	bannerJS string
+
+	//go:embed timetravel.js
+	timeTravelJS string
+
 	//go:embed api.js
 	apiJS string
 )
@@ -135,142 +153,172 @@ func buildCombinedScript() string {
 	sb.WriteString(wrapModule(coreJS))
 	sb.WriteString("\n\n")
 
-	// 2. Framework detector (no dependencies, used by indicator)
+	// 2. Overlay UI (language and theme, depends on core; read by toast, indicator, banner)
+	sb.WriteString("  // Overlay UI module\n")
+	sb.WriteString(wrapModule(uiJS))
+	sb.WriteString("\n\n")
+
+	// 3. Framework detector (no dependencies, used by indicator)
 	sb.WriteString("  // Framework detector module\n")
 	sb.WriteString(wrapModule(frameworkDetectorJS))
 	sb.WriteString("\n\n")
 
-	// 3. API tracker (no dependencies, used by indicator)
+	// 4. API tracker (no dependencies, used by indicator)
 	sb.WriteString("  // API tracker module\n")
 	sb.WriteString(wrapModule(apiTrackerJS))
 	sb.WriteString("\n\n")
 
-	// 4. Utils (shared helpers)
+	// 5. Utils (shared helpers)
 	sb.WriteString("  // Utils module\n")
 	sb.WriteString(wrapModule(utilsJS))
 	sb.WriteString("\n\n")
 
-	// 5. Overlay (visual system, depends on utils)
+	// 6. Overlay (visual system, depends on utils)
 	sb.WriteString("  // Overlay module\n")
 	sb.WriteString(wrapModule(overlayJS))
 	sb.WriteString("\n\n")
 
-	// 6. Inspection (depends on utils)
+	// 7. Inspection (depends on utils)
 	sb.WriteString("  // Inspection module\n")
 	sb.WriteString(wrapModule(inspectionJS))
 	sb.WriteString("\n\n")
 
-	// 7. Tree (depends on utils)
+	// 8. Tree (depends on utils)
 	sb.WriteString("  // Tree module\n")
 	sb.WriteString(wrapModule(treeJS))
 	sb.WriteString("\n\n")
 
-	// 8. Visual (depends on utils)
+	// 9. Visual (depends on utils)
 	sb.WriteString("  // Visual module\n")
 	sb.WriteString(wrapModule(visualJS))
 	sb.WriteString("\n\n")
 
-	// 9. Layout (depends on utils, inspection, visual)
+	// 10. Layout (depends on utils, inspection, visual)
 	sb.WriteString("  // Layout module\n")
 	sb.WriteString(wrapModule(layoutJS))
 	sb.WriteString("\n\n")
 
-	// 10. Interactive (depends on utils)
+	// 11. Interactive (depends on utils)
 	sb.WriteString("  // Interactive module\n")
 	sb.WriteString(wrapModule(interactiveJS))
 	sb.WriteString("\n\n")
 
-	// 11. Capture (depends on utils)
+	// 12. Capture (depends on utils)
 	sb.WriteString("  // Capture module\n")
 	sb.WriteString(wrapModule(captureJS))
 	sb.WriteString("\n\n")
 
-	// 12. Accessibility (depends on utils)
+	// 13. Accessibility (depends on utils)
 	sb.WriteString("  // Accessibility module\n")
 	sb.WriteString(wrapModule(accessibilityJS))
 	sb.WriteString("\n\n")
 
-	// 13. Audit (depends on utils)
+	// 14. Audit (depends on utils)
 	sb.WriteString("  // Audit module\n")
 	sb.WriteString(wrapModule(auditJS))
 	sb.WriteString("\n\n")
 
-	// 14. Interaction tracking (depends on utils, core)
+	// 15. Interaction tracking (depends on utils, core)
 	sb.WriteString("  // Interaction tracking module\n")
 	sb.WriteString(wrapModule(interactionJS))
 	sb.WriteString("\n\n")
 
-	// 15. Mutation tracking (depends on utils, core)
+	// 16. Mutation tracking (depends on utils, core)
 	sb.WriteString("  // Mutation tracking module\n")
 	sb.WriteString(wrapModule(mutationJS))
 	sb.WriteString("\n\n")
 
-	// 16. Toast notifications (no dependencies)
+	// 17. Toast notifications (no dependencies)
 	sb.WriteString("  // Toast notification module\n")
 	sb.WriteString(wrapModule(toastJS))
 	sb.WriteString("\n\n")
 
-	// 17. Voice transcription (depends on core)
+	// 18. Voice transcription (depends on core)
 	sb.WriteString("  // Voice transcription module\n")
 	sb.WriteString(wrapModule(voiceJS))
 	sb.WriteString("\n\n")
 
-	// 18. Sketch mode (depends on core, voice)
+	// 19. Sketch mode (depends on core, voice)
 	sb.WriteString("  // Sketch mode module\n")
 	sb.WriteString(wrapModule(sketchJS))
 	sb.WriteString("\n\n")
 
-	// 19. Design mode (depends on core, utils)
+	// 20. Design mode (depends on core, utils)
 	sb.WriteString("  // Design mode module\n")
 	sb.WriteString(wrapModule(designJS))
 	sb.WriteString("\n\n")
 
-	// 20. Floating indicator (depends on core, utils, sketch, design, toast, framework-detector, api-tracker)
+	// 21. Floating indicator (depends on core, utils, sketch, design, toast, framework-detector, api-tracker)
 	sb.WriteString("  // Floating indicator module\n")
 	sb.WriteString(wrapModule(indicatorJS))
 	sb.WriteString("\n\n")
 
-	// 21. Snapshot helper (depends on core)
+	// 22. Snapshot helper (depends on core)
 	sb.WriteString("  // Snapshot helper module\n")
 	sb.WriteString(wrapModule(snapshotHelperJS))
 	sb.WriteString("\n\n")
 
-	// 22. Diagnostics (depends on utils, core)
+	// 23. Diagnostics (depends on utils, core)
 	sb.WriteString("  // Diagnostics module\n")
 	sb.WriteString(wrapModule(diagnosticsJS))
 	sb.WriteString("\n\n")
 
-	// 23. Session management (depends on core)
+	// 24. Session management (depends on core)
 	sb.WriteString("  // Session management module\n")
 	sb.WriteString(wrapModule(sessionJS))
 	sb.WriteString("\n\n")
 
-	// 24. Store (depends on core)
+	// 25. Store (depends on core)
 	sb.WriteString("  // Store module\n")
 	sb.WriteString(wrapModule(storeJS))
 	sb.WriteString("\n\n")
 
-	// 25. Content extraction (depends on utils)
+	// 26. Content extraction (depends on utils)
 	sb.WriteString("  // Content extraction module\n")
 	sb.WriteString(wrapModule(contentJS))
 	sb.WriteString("\n\n")
 
-	// 26. Text fragility analysis (depends on utils)
+	// 27. Text fragility analysis (depends on utils)
 	sb.WriteString("  // Text fragility module\n")
 	sb.WriteString(wrapModule(textFragilityJS))
 	sb.WriteString("\n\n")
 
-	// 27. Responsive risk analysis (depends on utils)
+	// 28. Responsive risk analysis (depends on utils)
 	sb.WriteString("  // Responsive risk module\n")
 	sb.WriteString(wrapModule(responsiveRiskJS))
 	sb.WriteString("\n\n")
 
-	// 28. Wireframe generation (depends on utils)
+	// 29. Wireframe generation (depends on utils)
 	sb.WriteString("  // Wireframe generation module\n")
 	sb.WriteString(wrapModule(wireframeJS))
 	sb.WriteString("\n\n")
 
-	// 29. API (assembles all modules, must be last)
+	// 30. Idle detection (depends on api-tracker)
+	sb.WriteString("  // Idle detection module\n")
+	sb.WriteString(wrapModule(idleJS))
+	sb.WriteString("\n\n")
+
+	// 31. CSS coverage (standalone)
+	sb.WriteString("  // CSS coverage module\n")
+	sb.WriteString(wrapModule(coverageJS))
+	sb.WriteString("\n\n")
+
+	// 32. Core Web Vitals (depends on core, utils)
+	sb.WriteString("  // Web vitals module\n")
+	sb.WriteString(wrapModule(webVitalsJS))
+	sb.WriteString("\n\n")
+
+	// 33. Environment banner (depends on core)
+	sb.WriteString("  // Environment banner module\n")
+	sb.WriteString(wrapModule(bannerJS))
+	sb.WriteString("\n\n")
+
+	// 34. Time-travel DOM reconstruction (standalone)
+	sb.WriteString("  // Time travel module\n")
+	sb.WriteString(wrapModule(timeTravelJS))
+	sb.WriteString("\n\n")
+
+	// 35. API (assembles all modules, must be last)
 	sb.WriteString("  // API assembly module\n")
 	sb.WriteString(wrapModule(apiJS))
 	sb.WriteString("\n")
@@ -326,6 +374,7 @@ func wrapModule(js string) string {
 func GetScriptNames() []string {
 	return []string{
 		"core.js",
+		"ui.js",
 		"framework-detector.js",
 		"api-tracker.js",
 		"utils.js",
@@ -353,6 +402,9 @@ func GetScriptNames() []string {
 		"text-fragility.js",
 		"responsive-risk.js",
 		"wireframe.js",
+		"idle.js",
+		"banner.js",
+		"timetravel.js",
 		"api.js",
 	}
 }
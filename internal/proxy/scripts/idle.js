@@ -0,0 +1,125 @@
+// Page idle detection for DevTool
+// Resolves when network requests and DOM mutations have settled, so
+// screenshots and snapshots are taken of a finished page
+
+(function() {
+  'use strict';
+
+  var api = window.__devtool_api;
+
+  var DEFAULT_QUIET_MS = 500;
+  var DEFAULT_TIMEOUT_MS = 10000;
+  var POLL_MS = 50;
+
+  var loadedAt = Date.now();
+  var lastMutation = 0;
+  var mutationCount = 0;
+  var observer = null;
+
+  // Mutations of DevTool's own overlays, toasts and indicator don't count
+  function isDevtoolNode(node) {
+    var el = node && node.nodeType === 1 ? node : node && node.parentElement;
+    while (el) {
+      var id = el.id || '';
+      var cls = typeof el.className === 'string' ? el.className : '';
+      if (id.indexOf('__devtool') === 0 || cls.indexOf('__devtool') !== -1) {
+        return true;
+      }
+      el = el.parentElement;
+    }
+    return false;
+  }
+
+  function startObserver() {
+    if (observer || typeof MutationObserver === 'undefined' || !document.documentElement) return;
+    try {
+      observer = new MutationObserver(function(records) {
+        for (var i = 0; i < records.length; i++) {
+          if (!isDevtoolNode(records[i].target)) {
+            lastMutation = Date.now();
+            mutationCount++;
+            return;
+          }
+        }
+      });
+      observer.observe(document.documentElement, {
+        childList: true,
+        subtree: true,
+        attributes: true,
+        characterData: true
+      });
+    } catch (e) {
+      console.error('[DevTool][Idle] Failed to observe mutations:', e);
+    }
+  }
+
+  /**
+   * Current network and DOM activity of the page
+   * @returns {Object} - {inflight, quietMs, sinceNetworkMs, sinceMutationMs, mutations}
+   */
+  function getState() {
+    var now = Date.now();
+    var network = api && typeof api.getInflight === 'function' ? api.getInflight() : { inflight: 0, lastActivity: 0 };
+    var last = Math.max(network.lastActivity, lastMutation);
+    return {
+      inflight: network.inflight,
+      quietMs: now - (last || loadedAt),
+      sinceNetworkMs: network.lastActivity ? now - network.lastActivity : null,
+      sinceMutationMs: lastMutation ? now - lastMutation : null,
+      mutations: mutationCount
+    };
+  }
+
+  /**
+   * Whether the page is idle now
+   * @param {Object} [options] - {quietMs: 500, maxInflight: 0}
+   * @returns {boolean}
+   */
+  function isIdle(options) {
+    options = options || {};
+    var quietMs = options.quietMs > 0 ? options.quietMs : DEFAULT_QUIET_MS;
+    var maxInflight = options.maxInflight > 0 ? options.maxInflight : 0;
+    var state = getState();
+    return document.readyState === 'complete' && state.inflight <= maxInflight && state.quietMs >= quietMs;
+  }
+
+  /**
+   * Wait until the page has no fetch/XHR requests in flight and no DOM
+   * mutations for quietMs. Resolves (never rejects) with idle: false when
+   * the timeout passes first.
+   * @param {Object} [options] - {quietMs: 500, timeoutMs: 10000, maxInflight: 0}
+   * @returns {Promise<Object>} - {idle, waitedMs, inflight, quietMs, ...}
+   */
+  function whenIdle(options) {
+    options = options || {};
+    var timeoutMs = options.timeoutMs > 0 ? options.timeoutMs : DEFAULT_TIMEOUT_MS;
+    var start = Date.now();
+
+    return new Promise(function(resolve) {
+      function check() {
+        var idle = isIdle(options);
+        var waited = Date.now() - start;
+        if (idle || waited >= timeoutMs) {
+          var state = getState();
+          state.idle = idle;
+          state.waitedMs = waited;
+          resolve(state);
+          return;
+        }
+        setTimeout(check, POLL_MS);
+      }
+      check();
+    });
+  }
+
+  startObserver();
+  if (!observer) {
+    document.addEventListener('DOMContentLoaded', startObserver);
+  }
+
+  window.__devtool_idle = {
+    whenIdle: whenIdle,
+    isIdle: isIdle,
+    getState: getState
+  };
+})();
@@ -7,6 +7,7 @@
 
   var core = window.__devtool_core;
   var utils = window.__devtool_utils;
+  var ui = window.__devtool_ui;
 
   // Generate unique IDs for attachments
   function generateId() {
@@ -23,6 +24,7 @@
     isDragging: false,
     dragOffset: { x: 0, y: 0 },
     position: { x: 20, y: 20 },
+    isPlaced: false, // Dragged by the user, so the configured corner no longer applies
     isVisible: true,
     isActive: false, // AI tool activity state
     activityTimeout: null,
@@ -33,25 +35,36 @@
     // Tab management
     activeTab: 'overview', // overview|errors|network|performance|quality|interactions|compose
     tabUpdateInterval: null, // Update interval for active tab
-    lastAuditResults: null // Cache audit results
+    lastAuditResults: null, // Cache audit results
+    // Clipboard bridge with the terminal session
+    lastSelection: '', // Last text selected on the page, outside the panel
+    terminalClip: null // Last text shared from the terminal { text, timestamp }
+  };
+
+  // Text moved to or from the terminal is capped by the daemon
+  var MAX_CLIPBOARD_BYTES = 64 * 1024;
+  // Files dropped on the panel are capped by the proxy
+  var MAX_UPLOAD_BYTES = 20 * 1024 * 1024;
+
+  // Light theme colors; the overlay theme and accent apply over them
+  var COLORS = {
+    primary: '#6366f1',      // Indigo
+    primaryDark: '#4f46e5',
+    secondary: '#64748b',    // Slate
+    success: '#22c55e',
+    error: '#ef4444',
+    active: '#f59e0b',       // Amber - for activity state
+    surface: '#ffffff',
+    surfaceAlt: '#f8fafc',
+    border: '#e2e8f0',
+    text: '#1e293b',
+    textMuted: '#64748b',
+    textInverse: '#ffffff'
   };
 
   // Design tokens - consistent visual language
   var TOKENS = {
-    colors: {
-      primary: '#6366f1',      // Indigo
-      primaryDark: '#4f46e5',
-      secondary: '#64748b',    // Slate
-      success: '#22c55e',
-      error: '#ef4444',
-      active: '#f59e0b',       // Amber - for activity state
-      surface: '#ffffff',
-      surfaceAlt: '#f8fafc',
-      border: '#e2e8f0',
-      text: '#1e293b',
-      textMuted: '#64748b',
-      textInverse: '#ffffff'
-    },
+    colors: ui ? ui.themed(COLORS) : COLORS,
     radius: {
       sm: '6px',
       md: '10px',
@@ -73,530 +86,536 @@
     }
   };
 
-  // Styles
-  var STYLES = {
-    // The floating bug - entry point
-    bug: [
-      'position: fixed',
-      'width: 52px',
-      'height: 52px',
-      'border-radius: ' + TOKENS.radius.full,
-      'background: ' + TOKENS.colors.primary,
-      'box-shadow: ' + TOKENS.shadow.lg + ', ' + TOKENS.shadow.glow,
-      'cursor: pointer',
-      'z-index: 2147483646',
-      'display: flex',
-      'align-items: center',
-      'justify-content: center',
-      'transition: transform 0.2s ease, box-shadow 0.2s ease',
-      'user-select: none'
-    ].join(';'),
-
-    statusDot: [
-      'position: absolute',
-      'top: 0',
-      'right: 0',
-      'width: 14px',
-      'height: 14px',
-      'border-radius: ' + TOKENS.radius.full,
-      'border: 2.5px solid ' + TOKENS.colors.surface,
-      'transition: background-color 0.3s ease'
-    ].join(';'),
-
-    // Activity ring - pulses when AI is working
-    activityRing: [
-      'position: absolute',
-      'top: -4px',
-      'left: -4px',
-      'right: -4px',
-      'bottom: -4px',
-      'border-radius: ' + TOKENS.radius.full,
-      'border: 2px solid ' + TOKENS.colors.active,
-      'opacity: 0',
-      'pointer-events: none'
-    ].join(';'),
-
-    // Output preview - floating next to the bug when AI is outputting
-    outputPreview: [
-      'position: fixed',
-      'max-width: 400px',
-      'min-width: 200px',
-      'background: rgba(30, 41, 59, 0.95)',
-      'color: #e2e8f0',
-      'border-radius: ' + TOKENS.radius.md,
-      'padding: 10px 14px',
-      'font-family: ui-monospace, SFMono-Regular, Menlo, Monaco, Consolas, monospace',
-      'font-size: 12px',
-      'line-height: 1.5',
-      'box-shadow: ' + TOKENS.shadow.lg,
-      'z-index: 2147483645',
-      'pointer-events: none',
-      'opacity: 0',
-      'transform: translateX(10px)',
-      'transition: opacity 0.2s ease, transform 0.2s ease',
-      'overflow: hidden',
-      'white-space: pre-wrap',
-      'word-break: break-word',
-      'backdrop-filter: blur(8px)'
-    ].join(';'),
-
-    outputPreviewVisible: [
-      'opacity: 1',
-      'transform: translateX(0)'
-    ].join(';'),
-
-    // Panel - the main interface
-    panel: [
-      'position: fixed',
-      'width: 380px',
-      'background: ' + TOKENS.colors.surface,
-      'border-radius: ' + TOKENS.radius.lg,
-      'box-shadow: ' + TOKENS.shadow.lg,
-      'z-index: 2147483645',
-      'overflow: visible',
-      'font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif',
-      'font-size: 14px',
-      'color: ' + TOKENS.colors.text,
-      'transition: opacity 0.2s ease, transform 0.2s ease'
-    ].join(';'),
-
-    // Header - minimal, functional
-    header: [
-      'display: flex',
-      'align-items: center',
-      'justify-content: space-between',
-      'padding: ' + TOKENS.spacing.md + ' ' + TOKENS.spacing.lg,
-      'background: ' + TOKENS.colors.surfaceAlt,
-      'border-bottom: 1px solid ' + TOKENS.colors.border
-    ].join(';'),
-
-    headerTitle: [
-      'font-weight: 600',
-      'font-size: 13px',
-      'color: ' + TOKENS.colors.textMuted,
-      'text-transform: uppercase',
-      'letter-spacing: 0.5px'
-    ].join(';'),
-
-    closeBtn: [
-      'background: none',
-      'border: none',
-      'color: ' + TOKENS.colors.textMuted,
-      'cursor: pointer',
-      'padding: 4px',
-      'border-radius: ' + TOKENS.radius.sm,
-      'display: flex',
-      'transition: background 0.15s ease'
-    ].join(';'),
-
-    // Compose area - the main content
-    compose: [
-      'padding: ' + TOKENS.spacing.lg
-    ].join(';'),
-
-    // Message card - groups message + attachments (Gestalt: Common Region)
-    messageCard: [
-      'border: 1px solid ' + TOKENS.colors.border,
-      'border-radius: ' + TOKENS.radius.md,
-      'background: ' + TOKENS.colors.surface,
-      'overflow: hidden',
-      'transition: border-color 0.2s ease, box-shadow 0.2s ease'
-    ].join(';'),
-
-    messageCardFocused: [
-      'border-color: ' + TOKENS.colors.primary,
-      'box-shadow: 0 0 0 3px rgba(99,102,241,0.1)'
-    ].join(';'),
-
-    // Text input within card
-    textarea: [
-      'width: 100%',
-      'min-height: 80px',
-      'padding: ' + TOKENS.spacing.md,
-      'border: none',
-      'outline: none',
-      'resize: none',
-      'font-size: 14px',
-      'font-family: inherit',
-      'line-height: 1.5',
-      'color: ' + TOKENS.colors.text,
-      'background: transparent',
-      'box-sizing: border-box'
-    ].join(';'),
-
-    // Attachment chips area (Gestalt: Proximity - grouped with message)
-    attachmentArea: [
-      'padding: 0 ' + TOKENS.spacing.md + ' ' + TOKENS.spacing.md,
-      'display: flex',
-      'flex-wrap: wrap',
-      'gap: ' + TOKENS.spacing.sm
-    ].join(';'),
-
-    // Individual attachment chip
-    chip: [
-      'display: inline-flex',
-      'align-items: center',
-      'gap: 6px',
-      'padding: 5px 10px',
-      'background: ' + TOKENS.colors.surfaceAlt,
-      'border: 1px solid ' + TOKENS.colors.border,
-      'border-radius: ' + TOKENS.radius.full,
-      'font-size: 12px',
-      'color: ' + TOKENS.colors.text,
-      'max-width: 200px',
-      'overflow: hidden'
-    ].join(';'),
-
-    chipIcon: [
-      'flex-shrink: 0',
-      'width: 14px',
-      'height: 14px'
-    ].join(';'),
-
-    chipLabel: [
-      'white-space: nowrap',
-      'overflow: hidden',
-      'text-overflow: ellipsis'
-    ].join(';'),
-
-    chipRemove: [
-      'flex-shrink: 0',
-      'background: none',
-      'border: none',
-      'padding: 0',
-      'cursor: pointer',
-      'color: ' + TOKENS.colors.textMuted,
-      'display: flex',
-      'transition: color 0.15s ease'
-    ].join(';'),
-
-    // Toolbar - secondary actions (Gestalt: Similarity)
-    // Flexbox with wrap for responsive fit
-    toolbar: [
-      'display: flex',
-      'flex-wrap: wrap',
-      'align-items: center',
-      'gap: 6px',
-      'padding: 10px ' + TOKENS.spacing.md,
-      'background: ' + TOKENS.colors.surfaceAlt,
-      'border-top: 1px solid ' + TOKENS.colors.border
-    ].join(';'),
-
-    // Container for action buttons (left side)
-    toolbarActions: [
-      'display: flex',
-      'flex-wrap: wrap',
-      'align-items: center',
-      'gap: 6px',
-      'flex: 1 1 auto',
-      'min-width: 0'
-    ].join(';'),
-
-    toolBtn: [
-      'display: inline-flex',
-      'align-items: center',
-      'justify-content: center',
-      'gap: 4px',
-      'padding: 6px 10px',
-      'background: transparent',
-      'border: 1px solid ' + TOKENS.colors.border,
-      'border-radius: ' + TOKENS.radius.sm,
-      'font-size: 12px',
-      'font-weight: 500',
-      'color: ' + TOKENS.colors.textMuted,
-      'cursor: pointer',
-      'transition: all 0.15s ease',
-      'white-space: nowrap'
-    ].join(';'),
-
-    // Primary send button - visual hierarchy (most prominent)
-    sendBtn: [
-      'display: inline-flex',
-      'align-items: center',
-      'justify-content: center',
-      'gap: 5px',
-      'padding: 8px 14px',
-      'background: ' + TOKENS.colors.primary,
-      'border: none',
-      'border-radius: ' + TOKENS.radius.sm,
-      'font-size: 13px',
-      'font-weight: 600',
-      'color: ' + TOKENS.colors.textInverse,
-      'cursor: pointer',
-      'transition: background 0.15s ease, transform 0.1s ease',
-      'white-space: nowrap',
-      'margin-left: auto'
-    ].join(';'),
-
-    // Selection overlays
-    overlay: [
-      'position: fixed',
-      'top: 0',
-      'left: 0',
-      'right: 0',
-      'bottom: 0',
-      'z-index: 2147483647',
-      'cursor: crosshair'
-    ].join(';'),
-
-    overlayDimmed: [
-      'background: rgba(0, 0, 0, 0.4)'
-    ].join(';'),
-
-    selectionBox: [
-      'position: absolute',
-      'border: 2px solid ' + TOKENS.colors.primary,
-      'background: rgba(99, 102, 241, 0.15)',
-      'border-radius: 4px',
-      'pointer-events: none'
-    ].join(';'),
-
-    elementHighlight: [
-      'position: absolute',
-      'border: 2px solid ' + TOKENS.colors.primary,
-      'background: rgba(99, 102, 241, 0.1)',
-      'pointer-events: none',
-      'border-radius: 4px',
-      'z-index: 2147483647'
-    ].join(';'),
-
-    tooltip: [
-      'position: absolute',
-      'background: ' + TOKENS.colors.text,
-      'color: ' + TOKENS.colors.textInverse,
-      'padding: 4px 8px',
-      'border-radius: ' + TOKENS.radius.sm,
-      'font-size: 11px',
-      'font-family: ui-monospace, monospace',
-      'white-space: nowrap',
-      'pointer-events: none'
-    ].join(';'),
-
-    // Instructions bar during selection
-    instructionBar: [
-      'position: fixed',
-      'bottom: 20px',
-      'left: 50%',
-      'transform: translateX(-50%)',
-      'background: ' + TOKENS.colors.text,
-      'color: ' + TOKENS.colors.textInverse,
-      'padding: 10px 20px',
-      'border-radius: ' + TOKENS.radius.full,
-      'font-size: 13px',
-      'font-weight: 500',
-      'z-index: 2147483647',
-      'box-shadow: ' + TOKENS.shadow.lg
-    ].join(';'),
-
-    // Dropdown container
-    dropdownContainer: [
-      'position: relative',
-      'display: inline-block'
-    ].join(';'),
-
-    // Dropdown button with chevron
-    dropdownBtn: [
-      'display: inline-flex',
-      'align-items: center',
-      'justify-content: center',
-      'gap: 4px',
-      'padding: 6px 10px',
-      'background: transparent',
-      'border: 1px solid ' + TOKENS.colors.border,
-      'border-radius: ' + TOKENS.radius.sm,
-      'font-size: 12px',
-      'font-weight: 500',
-      'color: ' + TOKENS.colors.textMuted,
-      'cursor: pointer',
-      'transition: all 0.15s ease',
-      'white-space: nowrap'
-    ].join(';'),
-
-    // Dropdown menu
-    dropdownMenu: [
-      'position: absolute',
-      'bottom: calc(100% + 4px)',
-      'left: 0',
-      'min-width: 180px',
-      'background: ' + TOKENS.colors.surface,
-      'border: 1px solid ' + TOKENS.colors.border,
-      'border-radius: ' + TOKENS.radius.md,
-      'box-shadow: ' + TOKENS.shadow.lg,
-      'z-index: 2147483648',
-      'overflow: hidden',
-      'opacity: 0',
-      'transform: translateY(4px)',
-      'pointer-events: none',
-      'transition: opacity 0.15s ease, transform 0.15s ease'
-    ].join(';'),
-
-    dropdownMenuVisible: [
-      'opacity: 1',
-      'transform: translateY(0)',
-      'pointer-events: auto'
-    ].join(';'),
-
-    // Dropdown menu item
-    dropdownItem: [
-      'display: flex',
-      'align-items: center',
-      'gap: 8px',
-      'padding: 10px 12px',
-      'font-size: 13px',
-      'color: ' + TOKENS.colors.text,
-      'cursor: pointer',
-      'transition: background 0.1s ease',
-      'border: none',
-      'background: none',
-      'width: 100%',
-      'text-align: left'
-    ].join(';'),
-
-    dropdownItemHover: [
-      'background: ' + TOKENS.colors.surfaceAlt
-    ].join(';'),
-
-    // Dropdown section header
-    dropdownHeader: [
-      'padding: 6px 12px',
-      'font-size: 10px',
-      'font-weight: 600',
-      'color: ' + TOKENS.colors.textMuted,
-      'text-transform: uppercase',
-      'letter-spacing: 0.5px',
-      'border-bottom: 1px solid ' + TOKENS.colors.border,
-      'background: ' + TOKENS.colors.surfaceAlt
-    ].join(';'),
-
-    // Tab styles
-    tabBar: [
-      'display: flex',
-      'align-items: center',
-      'background: ' + TOKENS.colors.surfaceAlt,
-      'border-bottom: 1px solid ' + TOKENS.colors.border,
-      'overflow-x: auto',
-      'overflow-y: hidden',
-      'padding: 0 ' + TOKENS.spacing.sm,
-      'gap: ' + TOKENS.spacing.xs
-    ].join(';'),
-
-    tab: [
-      'padding: ' + TOKENS.spacing.sm + ' ' + TOKENS.spacing.md,
-      'font-size: 12px',
-      'font-weight: 500',
-      'border: none',
-      'background: transparent',
-      'color: ' + TOKENS.colors.textMuted,
-      'cursor: pointer',
-      'border-bottom: 2px solid transparent',
-      'transition: color 0.15s ease, border-color 0.15s ease',
-      'white-space: nowrap',
-      'position: relative',
-      'display: flex',
-      'align-items: center',
-      'gap: 4px'
-    ].join(';'),
-
-    tabActive: [
-      'color: ' + TOKENS.colors.primary,
-      'border-bottom-color: ' + TOKENS.colors.primary
-    ].join(';'),
-
-    tabBadge: [
-      'min-width: 16px',
-      'height: 16px',
-      'padding: 0 4px',
-      'font-size: 10px',
-      'font-weight: 600',
-      'border-radius: ' + TOKENS.radius.full,
-      'display: inline-flex',
-      'align-items: center',
-      'justify-content: center',
-      'line-height: 1'
-    ].join(';'),
-
-    tabBadgeRed: [
-      'background: ' + TOKENS.colors.error,
-      'color: white'
-    ].join(';'),
-
-    tabBadgeYellow: [
-      'background: ' + TOKENS.colors.active,
-      'color: white'
-    ].join(';'),
-
-    tabBadgeGreen: [
-      'background: ' + TOKENS.colors.success,
-      'color: white'
-    ].join(';'),
-
-    tabContent: [
-      'padding: ' + TOKENS.spacing.lg,
-      'max-height: 400px',
-      'overflow-y: auto',
-      'overflow-x: hidden'
-    ].join(';'),
-
-    tabCloseBtn: [
-      'margin-left: auto',
-      'background: none',
-      'border: none',
-      'color: ' + TOKENS.colors.textMuted,
-      'cursor: pointer',
-      'padding: 4px',
-      'display: flex',
-      'flex-shrink: 0'
-    ].join(';'),
-
-    // Tab content specific styles
-    healthCard: [
-      'background: ' + TOKENS.colors.surfaceAlt,
-      'border: 1px solid ' + TOKENS.colors.border,
-      'border-radius: ' + TOKENS.radius.sm,
-      'padding: ' + TOKENS.spacing.md,
-      'margin-bottom: ' + TOKENS.spacing.sm
-    ].join(';'),
-
-    healthLabel: [
-      'font-size: 11px',
-      'color: ' + TOKENS.colors.textMuted,
-      'margin-bottom: 4px',
-      'text-transform: uppercase',
-      'letter-spacing: 0.5px'
-    ].join(';'),
-
-    healthValue: [
-      'font-size: 20px',
-      'font-weight: 600',
-      'color: ' + TOKENS.colors.text
-    ].join(';'),
-
-    errorItem: [
-      'padding: ' + TOKENS.spacing.sm,
-      'border-bottom: 1px solid ' + TOKENS.colors.border,
-      'font-size: 12px',
-      'cursor: pointer',
-      'transition: background 0.15s ease'
-    ].join(';'),
-
-    errorMessage: [
-      'color: ' + TOKENS.colors.text,
-      'margin-bottom: 4px',
-      'font-weight: 500'
-    ].join(';'),
-
-    errorMeta: [
-      'color: ' + TOKENS.colors.textMuted,
-      'font-size: 11px'
-    ].join(';'),
-
-    emptyState: [
-      'text-align: center',
-      'padding: ' + TOKENS.spacing.xl,
-      'color: ' + TOKENS.colors.textMuted,
-      'font-size: 13px'
-    ].join(';')
-  };
+  // Styles, rebuilt when the overlay theme changes
+  var STYLES = buildStyles();
+
+  function buildStyles() {
+    return {
+      // The floating bug - entry point
+      bug: [
+        'position: fixed',
+        'width: 52px',
+        'height: 52px',
+        'border-radius: ' + TOKENS.radius.full,
+        'background: ' + TOKENS.colors.primary,
+        'box-shadow: ' + TOKENS.shadow.lg + ', ' + TOKENS.shadow.glow,
+        'cursor: pointer',
+        'z-index: 2147483646',
+        'display: flex',
+        'align-items: center',
+        'justify-content: center',
+        'transition: transform 0.2s ease, box-shadow 0.2s ease',
+        'user-select: none'
+      ].join(';'),
+
+      statusDot: [
+        'position: absolute',
+        'top: 0',
+        'right: 0',
+        'width: 14px',
+        'height: 14px',
+        'border-radius: ' + TOKENS.radius.full,
+        'border: 2.5px solid ' + TOKENS.colors.surface,
+        'transition: background-color 0.3s ease'
+      ].join(';'),
+
+      // Activity ring - pulses when AI is working
+      activityRing: [
+        'position: absolute',
+        'top: -4px',
+        'left: -4px',
+        'right: -4px',
+        'bottom: -4px',
+        'border-radius: ' + TOKENS.radius.full,
+        'border: 2px solid ' + TOKENS.colors.active,
+        'opacity: 0',
+        'pointer-events: none'
+      ].join(';'),
+
+      // Output preview - floating next to the bug when AI is outputting
+      outputPreview: [
+        'position: fixed',
+        'max-width: 400px',
+        'min-width: 200px',
+        'background: rgba(30, 41, 59, 0.95)',
+        'color: #e2e8f0',
+        'border-radius: ' + TOKENS.radius.md,
+        'padding: 10px 14px',
+        'font-family: ui-monospace, SFMono-Regular, Menlo, Monaco, Consolas, monospace',
+        'font-size: 12px',
+        'line-height: 1.5',
+        'box-shadow: ' + TOKENS.shadow.lg,
+        'z-index: 2147483645',
+        'pointer-events: none',
+        'opacity: 0',
+        'transform: translateX(10px)',
+        'transition: opacity 0.2s ease, transform 0.2s ease',
+        'overflow: hidden',
+        'white-space: pre-wrap',
+        'word-break: break-word',
+        'backdrop-filter: blur(8px)'
+      ].join(';'),
+
+      outputPreviewVisible: [
+        'opacity: 1',
+        'transform: translateX(0)'
+      ].join(';'),
+
+      // Panel - the main interface
+      panel: [
+        'position: fixed',
+        'width: 380px',
+        'background: ' + TOKENS.colors.surface,
+        'border-radius: ' + TOKENS.radius.lg,
+        'box-shadow: ' + TOKENS.shadow.lg,
+        'z-index: 2147483645',
+        'overflow: visible',
+        'font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif',
+        'font-size: 14px',
+        'color: ' + TOKENS.colors.text,
+        'transition: opacity 0.2s ease, transform 0.2s ease'
+      ].join(';'),
+
+      // Header - minimal, functional
+      header: [
+        'display: flex',
+        'align-items: center',
+        'justify-content: space-between',
+        'padding: ' + TOKENS.spacing.md + ' ' + TOKENS.spacing.lg,
+        'background: ' + TOKENS.colors.surfaceAlt,
+        'border-bottom: 1px solid ' + TOKENS.colors.border
+      ].join(';'),
+
+      headerTitle: [
+        'font-weight: 600',
+        'font-size: 13px',
+        'color: ' + TOKENS.colors.textMuted,
+        'text-transform: uppercase',
+        'letter-spacing: 0.5px'
+      ].join(';'),
+
+      closeBtn: [
+        'background: none',
+        'border: none',
+        'color: ' + TOKENS.colors.textMuted,
+        'cursor: pointer',
+        'padding: 4px',
+        'border-radius: ' + TOKENS.radius.sm,
+        'display: flex',
+        'transition: background 0.15s ease'
+      ].join(';'),
+
+      // Compose area - the main content
+      compose: [
+        'padding: ' + TOKENS.spacing.lg
+      ].join(';'),
+
+      // Message card - groups message + attachments (Gestalt: Common Region)
+      messageCard: [
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: ' + TOKENS.radius.md,
+        'background: ' + TOKENS.colors.surface,
+        'overflow: hidden',
+        'transition: border-color 0.2s ease, box-shadow 0.2s ease'
+      ].join(';'),
+
+      messageCardFocused: [
+        'border-color: ' + TOKENS.colors.primary,
+        'box-shadow: 0 0 0 3px rgba(99,102,241,0.1)'
+      ].join(';'),
+
+      // Text input within card
+      textarea: [
+        'width: 100%',
+        'min-height: 80px',
+        'padding: ' + TOKENS.spacing.md,
+        'border: none',
+        'outline: none',
+        'resize: none',
+        'font-size: 14px',
+        'font-family: inherit',
+        'line-height: 1.5',
+        'color: ' + TOKENS.colors.text,
+        'background: transparent',
+        'box-sizing: border-box'
+      ].join(';'),
+
+      // Attachment chips area (Gestalt: Proximity - grouped with message)
+      attachmentArea: [
+        'padding: 0 ' + TOKENS.spacing.md + ' ' + TOKENS.spacing.md,
+        'display: flex',
+        'flex-wrap: wrap',
+        'gap: ' + TOKENS.spacing.sm
+      ].join(';'),
+
+      // Individual attachment chip
+      chip: [
+        'display: inline-flex',
+        'align-items: center',
+        'gap: 6px',
+        'padding: 5px 10px',
+        'background: ' + TOKENS.colors.surfaceAlt,
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: ' + TOKENS.radius.full,
+        'font-size: 12px',
+        'color: ' + TOKENS.colors.text,
+        'max-width: 200px',
+        'overflow: hidden'
+      ].join(';'),
+
+      chipIcon: [
+        'flex-shrink: 0',
+        'width: 14px',
+        'height: 14px'
+      ].join(';'),
+
+      chipLabel: [
+        'white-space: nowrap',
+        'overflow: hidden',
+        'text-overflow: ellipsis'
+      ].join(';'),
+
+      chipRemove: [
+        'flex-shrink: 0',
+        'background: none',
+        'border: none',
+        'padding: 0',
+        'cursor: pointer',
+        'color: ' + TOKENS.colors.textMuted,
+        'display: flex',
+        'transition: color 0.15s ease'
+      ].join(';'),
+
+      // Toolbar - secondary actions (Gestalt: Similarity)
+      // Flexbox with wrap for responsive fit
+      toolbar: [
+        'display: flex',
+        'flex-wrap: wrap',
+        'align-items: center',
+        'gap: 6px',
+        'padding: 10px ' + TOKENS.spacing.md,
+        'background: ' + TOKENS.colors.surfaceAlt,
+        'border-top: 1px solid ' + TOKENS.colors.border
+      ].join(';'),
+
+      // Container for action buttons (left side)
+      toolbarActions: [
+        'display: flex',
+        'flex-wrap: wrap',
+        'align-items: center',
+        'gap: 6px',
+        'flex: 1 1 auto',
+        'min-width: 0'
+      ].join(';'),
+
+      toolBtn: [
+        'display: inline-flex',
+        'align-items: center',
+        'justify-content: center',
+        'gap: 4px',
+        'padding: 6px 10px',
+        'background: transparent',
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: ' + TOKENS.radius.sm,
+        'font-size: 12px',
+        'font-weight: 500',
+        'color: ' + TOKENS.colors.textMuted,
+        'cursor: pointer',
+        'transition: all 0.15s ease',
+        'white-space: nowrap'
+      ].join(';'),
+
+      // Primary send button - visual hierarchy (most prominent)
+      sendBtn: [
+        'display: inline-flex',
+        'align-items: center',
+        'justify-content: center',
+        'gap: 5px',
+        'padding: 8px 14px',
+        'background: ' + TOKENS.colors.primary,
+        'border: none',
+        'border-radius: ' + TOKENS.radius.sm,
+        'font-size: 13px',
+        'font-weight: 600',
+        'color: ' + TOKENS.colors.textInverse,
+        'cursor: pointer',
+        'transition: background 0.15s ease, transform 0.1s ease',
+        'white-space: nowrap',
+        'margin-left: auto'
+      ].join(';'),
+
+      // Selection overlays
+      overlay: [
+        'position: fixed',
+        'top: 0',
+        'left: 0',
+        'right: 0',
+        'bottom: 0',
+        'z-index: 2147483647',
+        'cursor: crosshair'
+      ].join(';'),
+
+      overlayDimmed: [
+        'background: rgba(0, 0, 0, 0.4)'
+      ].join(';'),
+
+      selectionBox: [
+        'position: absolute',
+        'border: 2px solid ' + TOKENS.colors.primary,
+        'background: rgba(99, 102, 241, 0.15)',
+        'border-radius: 4px',
+        'pointer-events: none'
+      ].join(';'),
+
+      elementHighlight: [
+        'position: absolute',
+        'border: 2px solid ' + TOKENS.colors.primary,
+        'background: rgba(99, 102, 241, 0.1)',
+        'pointer-events: none',
+        'border-radius: 4px',
+        'z-index: 2147483647'
+      ].join(';'),
+
+      tooltip: [
+        'position: absolute',
+        'background: ' + TOKENS.colors.text,
+        'color: ' + TOKENS.colors.textInverse,
+        'padding: 4px 8px',
+        'border-radius: ' + TOKENS.radius.sm,
+        'font-size: 11px',
+        'font-family: ui-monospace, monospace',
+        'white-space: nowrap',
+        'pointer-events: none'
+      ].join(';'),
+
+      // Instructions bar during selection
+      instructionBar: [
+        'position: fixed',
+        'bottom: 20px',
+        'left: 50%',
+        'transform: translateX(-50%)',
+        'background: ' + TOKENS.colors.text,
+        'color: ' + TOKENS.colors.textInverse,
+        'padding: 10px 20px',
+        'border-radius: ' + TOKENS.radius.full,
+        'font-size: 13px',
+        'font-weight: 500',
+        'z-index: 2147483647',
+        'box-shadow: ' + TOKENS.shadow.lg
+      ].join(';'),
+
+      // Dropdown container
+      dropdownContainer: [
+        'position: relative',
+        'display: inline-block'
+      ].join(';'),
+
+      // Dropdown button with chevron
+      dropdownBtn: [
+        'display: inline-flex',
+        'align-items: center',
+        'justify-content: center',
+        'gap: 4px',
+        'padding: 6px 10px',
+        'background: transparent',
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: ' + TOKENS.radius.sm,
+        'font-size: 12px',
+        'font-weight: 500',
+        'color: ' + TOKENS.colors.textMuted,
+        'cursor: pointer',
+        'transition: all 0.15s ease',
+        'white-space: nowrap'
+      ].join(';'),
+
+      // Dropdown menu
+      dropdownMenu: [
+        'position: absolute',
+        'bottom: calc(100% + 4px)',
+        'left: 0',
+        'min-width: 180px',
+        'background: ' + TOKENS.colors.surface,
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: ' + TOKENS.radius.md,
+        'box-shadow: ' + TOKENS.shadow.lg,
+        'z-index: 2147483648',
+        'overflow: hidden',
+        'opacity: 0',
+        'transform: translateY(4px)',
+        'pointer-events: none',
+        'visibility: hidden',
+        'transition: opacity 0.15s ease, transform 0.15s ease, visibility 0.15s'
+      ].join(';'),
+
+      dropdownMenuVisible: [
+        'opacity: 1',
+        'transform: translateY(0)',
+        'pointer-events: auto',
+        'visibility: visible'
+      ].join(';'),
+
+      // Dropdown menu item
+      dropdownItem: [
+        'display: flex',
+        'align-items: center',
+        'gap: 8px',
+        'padding: 10px 12px',
+        'font-size: 13px',
+        'color: ' + TOKENS.colors.text,
+        'cursor: pointer',
+        'transition: background 0.1s ease',
+        'border: none',
+        'background: none',
+        'width: 100%',
+        'text-align: left'
+      ].join(';'),
+
+      dropdownItemHover: [
+        'background: ' + TOKENS.colors.surfaceAlt
+      ].join(';'),
+
+      // Dropdown section header
+      dropdownHeader: [
+        'padding: 6px 12px',
+        'font-size: 10px',
+        'font-weight: 600',
+        'color: ' + TOKENS.colors.textMuted,
+        'text-transform: uppercase',
+        'letter-spacing: 0.5px',
+        'border-bottom: 1px solid ' + TOKENS.colors.border,
+        'background: ' + TOKENS.colors.surfaceAlt
+      ].join(';'),
+
+      // Tab styles
+      tabBar: [
+        'display: flex',
+        'align-items: center',
+        'background: ' + TOKENS.colors.surfaceAlt,
+        'border-bottom: 1px solid ' + TOKENS.colors.border,
+        'overflow-x: auto',
+        'overflow-y: hidden',
+        'padding: 0 ' + TOKENS.spacing.sm,
+        'gap: ' + TOKENS.spacing.xs
+      ].join(';'),
+
+      tab: [
+        'padding: ' + TOKENS.spacing.sm + ' ' + TOKENS.spacing.md,
+        'font-size: 12px',
+        'font-weight: 500',
+        'border: none',
+        'background: transparent',
+        'color: ' + TOKENS.colors.textMuted,
+        'cursor: pointer',
+        'border-bottom: 2px solid transparent',
+        'transition: color 0.15s ease, border-color 0.15s ease',
+        'white-space: nowrap',
+        'position: relative',
+        'display: flex',
+        'align-items: center',
+        'gap: 4px'
+      ].join(';'),
+
+      tabActive: [
+        'color: ' + TOKENS.colors.primary,
+        'border-bottom-color: ' + TOKENS.colors.primary
+      ].join(';'),
+
+      tabBadge: [
+        'min-width: 16px',
+        'height: 16px',
+        'padding: 0 4px',
+        'font-size: 10px',
+        'font-weight: 600',
+        'border-radius: ' + TOKENS.radius.full,
+        'display: inline-flex',
+        'align-items: center',
+        'justify-content: center',
+        'line-height: 1'
+      ].join(';'),
+
+      tabBadgeRed: [
+        'background: ' + TOKENS.colors.error,
+        'color: white'
+      ].join(';'),
+
+      tabBadgeYellow: [
+        'background: ' + TOKENS.colors.active,
+        'color: white'
+      ].join(';'),
+
+      tabBadgeGreen: [
+        'background: ' + TOKENS.colors.success,
+        'color: white'
+      ].join(';'),
+
+      tabContent: [
+        'padding: ' + TOKENS.spacing.lg,
+        'max-height: 400px',
+        'overflow-y: auto',
+        'overflow-x: hidden'
+      ].join(';'),
+
+      tabCloseBtn: [
+        'margin-left: auto',
+        'background: none',
+        'border: none',
+        'color: ' + TOKENS.colors.textMuted,
+        'cursor: pointer',
+        'padding: 4px',
+        'display: flex',
+        'flex-shrink: 0'
+      ].join(';'),
+
+      // Tab content specific styles
+      healthCard: [
+        'background: ' + TOKENS.colors.surfaceAlt,
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: ' + TOKENS.radius.sm,
+        'padding: ' + TOKENS.spacing.md,
+        'margin-bottom: ' + TOKENS.spacing.sm
+      ].join(';'),
+
+      healthLabel: [
+        'font-size: 11px',
+        'color: ' + TOKENS.colors.textMuted,
+        'margin-bottom: 4px',
+        'text-transform: uppercase',
+        'letter-spacing: 0.5px'
+      ].join(';'),
+
+      healthValue: [
+        'font-size: 20px',
+        'font-weight: 600',
+        'color: ' + TOKENS.colors.text
+      ].join(';'),
+
+      errorItem: [
+        'padding: ' + TOKENS.spacing.sm,
+        'border-bottom: 1px solid ' + TOKENS.colors.border,
+        'font-size: 12px',
+        'cursor: pointer',
+        'transition: background 0.15s ease'
+      ].join(';'),
+
+      errorMessage: [
+        'color: ' + TOKENS.colors.text,
+        'margin-bottom: 4px',
+        'font-weight: 500'
+      ].join(';'),
+
+      errorMeta: [
+        'color: ' + TOKENS.colors.textMuted,
+        'font-size: 11px'
+      ].join(';'),
+
+      emptyState: [
+        'text-align: center',
+        'padding: ' + TOKENS.spacing.xl,
+        'color: ' + TOKENS.colors.textMuted,
+        'font-size: 13px'
+      ].join(';')
+    };
+  }
 
   // Icons (compact SVGs)
   var ICONS = {
@@ -606,6 +625,8 @@
     screenshot: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><rect x="3" y="3" width="18" height="18" rx="2"/><circle cx="8.5" cy="8.5" r="1.5"/><path d="M21 15l-5-5L5 21"/></svg>',
     element: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><polyline points="16 18 22 12 16 6"/><polyline points="8 6 2 12 8 18"/></svg>',
     sketch: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M12 19l7-7 3 3-7 7-3-3z"/><path d="M18 13l-1.5-7.5L2 2l3.5 14.5L13 18l5-5z"/><path d="M2 2l7.586 7.586"/><circle cx="11" cy="11" r="2"/></svg>',
+    clipboard: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><rect x="8" y="2" width="8" height="4" rx="1"/><path d="M16 4h2a2 2 0 0 1 2 2v14a2 2 0 0 1-2 2H6a2 2 0 0 1-2-2V6a2 2 0 0 1 2-2h2"/></svg>',
+    file: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M21.44 11.05l-9.19 9.19a6 6 0 0 1-8.49-8.49l9.19-9.19a4 4 0 0 1 5.66 5.66l-9.2 9.19a2 2 0 0 1-2.83-2.83l8.49-8.48"/></svg>',
     design: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M12 20h9"/><path d="M16.5 3.5a2.121 2.121 0 0 1 3 3L7 19l-4 1 1-4L16.5 3.5z"/></svg>',
     x: '<svg width="12" height="12" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2.5"><path d="M18 6L6 18M6 6l12 12"/></svg>',
     actions: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><circle cx="12" cy="12" r="3"/><path d="M19.4 15a1.65 1.65 0 0 0 .33 1.82l.06.06a2 2 0 0 1 0 2.83 2 2 0 0 1-2.83 0l-.06-.06a1.65 1.65 0 0 0-1.82-.33 1.65 1.65 0 0 0-1 1.51V21a2 2 0 0 1-2 2 2 2 0 0 1-2-2v-.09A1.65 1.65 0 0 0 9 19.4a1.65 1.65 0 0 0-1.82.33l-.06.06a2 2 0 0 1-2.83 0 2 2 0 0 1 0-2.83l.06-.06a1.65 1.65 0 0 0 .33-1.82 1.65 1.65 0 0 0-1.51-1H3a2 2 0 0 1-2-2 2 2 0 0 1 2-2h.09A1.65 1.65 0 0 0 4.6 9a1.65 1.65 0 0 0-.33-1.82l-.06-.06a2 2 0 0 1 0-2.83 2 2 0 0 1 2.83 0l.06.06a1.65 1.65 0 0 0 1.82.33H9a1.65 1.65 0 0 0 1-1.51V3a2 2 0 0 1 2-2 2 2 0 0 1 2 2v.09a1.65 1.65 0 0 0 1 1.51 1.65 1.65 0 0 0 1.82-.33l.06-.06a2 2 0 0 1 2.83 0 2 2 0 0 1 0 2.83l-.06.06a1.65 1.65 0 0 0-.33 1.82V9a1.65 1.65 0 0 0 1.51 1H21a2 2 0 0 1 2 2 2 2 0 0 1-2 2h-.09a1.65 1.65 0 0 0-1.51 1z"/></svg>',
@@ -614,12 +635,40 @@
     audit: '<svg width="14" height="14" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M9 11l3 3L22 4"/><path d="M21 12v7a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h11"/></svg>'
   };
 
+  // Strings in the overlay's language
+  function t(key, fallback) {
+    return ui ? ui.t(key, fallback) : fallback;
+  }
+
+  // Bug position for the configured corner, as left/bottom offsets
+  function cornerPosition() {
+    var corner = (ui ? ui.position() : 'bottom-left').split('-');
+    return {
+      x: corner[1] === 'right' ? Math.max(0, window.innerWidth - 72) : 20,
+      y: corner[0] === 'top' ? Math.max(0, window.innerHeight - 72) : 20
+    };
+  }
+
+  // Apply a changed overlay theme, language or position by redrawing
+  function applyUI() {
+    TOKENS.colors = ui.themed(COLORS);
+    STYLES = buildStyles();
+    if (!state.container) return;
+    var expanded = state.isExpanded;
+    destroy();
+    if (!state.isPlaced) state.position = cornerPosition();
+    createUI();
+    if (expanded) togglePanel(true);
+  }
+
   // Initialize
   function init() {
     if (state.container) return;
     loadPrefs();
+    if (!state.isPlaced) state.position = cornerPosition();
     createUI();
     setupStatusPolling();
+    trackSelection();
   }
 
   function createUI() {
@@ -630,6 +679,7 @@
     createBug();
     createPanel();
     createOutputPreview();
+    injectFocusStyle();
 
     document.documentElement.appendChild(state.container);
   }
@@ -638,6 +688,7 @@
   function createOutputPreview() {
     var preview = document.createElement('div');
     preview.id = '__devtool-output-preview';
+    preview.setAttribute('aria-hidden', 'true');
     preview.style.cssText = STYLES.outputPreview;
     state.outputPreview = preview;
     state.container.appendChild(preview);
@@ -646,6 +697,7 @@
   // Show output preview with lines floating next to the bug
   function showOutputPreview(lines) {
     if (!state.outputPreview || !state.bug || !lines || lines.length === 0) return;
+    if (ui && ui.isMinimal()) return;
 
     // Format lines with subtle styling
     var html = lines.map(function(line) {
@@ -703,11 +755,24 @@
     bug.style.cssText = STYLES.bug;
     bug.style.left = state.position.x + 'px';
     bug.style.bottom = state.position.y + 'px';
+    if (ui && ui.isMinimal()) {
+      bug.style.width = '36px';
+      bug.style.height = '36px';
+    }
     bug.innerHTML = ICONS.logo;
+    bug.setAttribute('role', 'button');
+    bug.setAttribute('aria-controls', '__devtool-panel');
+    bug.setAttribute('aria-expanded', state.isExpanded ? 'true' : 'false');
+    bug.setAttribute('aria-label', bugLabel());
+    bug.tabIndex = 0;
+    if (bug.firstChild && bug.firstChild.setAttribute) {
+      bug.firstChild.setAttribute('aria-hidden', 'true');
+    }
 
     // Activity ring (pulses when AI is working)
     var ring = document.createElement('div');
     ring.id = '__devtool-activity-ring';
+    ring.setAttribute('aria-hidden', 'true');
     ring.style.cssText = STYLES.activityRing;
     bug.appendChild(ring);
 
@@ -717,12 +782,14 @@
     // Status indicator
     var dot = document.createElement('div');
     dot.id = '__devtool-status';
+    dot.setAttribute('aria-hidden', 'true');
     dot.style.cssText = STYLES.statusDot;
     dot.style.backgroundColor = core.isConnected() ? TOKENS.colors.success : TOKENS.colors.error;
     bug.appendChild(dot);
 
     // Drag and click handling
     bug.addEventListener('mousedown', handleDragStart);
+    bug.addEventListener('keydown', handleBugKey);
     bug.addEventListener('mouseenter', function() {
       if (!state.isDragging) {
         bug.style.transform = 'scale(1.08)';
@@ -738,6 +805,55 @@
     state.container.appendChild(bug);
   }
 
+  // Accessible name of the bug, with the connection state when it is down
+  function bugLabel() {
+    var label = t('indicator.label', 'agnt DevTool');
+    if (!core.isConnected()) {
+      label += ', ' + t('indicator.disconnected', 'disconnected');
+    }
+    return label;
+  }
+
+  // Enter and Space open the panel; arrow keys move the bug (Shift: faster)
+  function handleBugKey(e) {
+    if (e.key === 'Enter' || e.key === ' ') {
+      e.preventDefault();
+      togglePanel();
+      return;
+    }
+    var step = e.shiftKey ? 50 : 10;
+    var dx = 0;
+    var dy = 0;
+    switch (e.key) {
+      case 'ArrowLeft': dx = -step; break;
+      case 'ArrowRight': dx = step; break;
+      case 'ArrowUp': dy = step; break;
+      case 'ArrowDown': dy = -step; break;
+      default: return;
+    }
+    e.preventDefault();
+    var x = Math.max(0, Math.min(state.position.x + dx, window.innerWidth - 52));
+    var y = Math.max(0, Math.min(state.position.y + dy, window.innerHeight - 52));
+    state.position = { x: x, y: y };
+    state.isPlaced = true;
+    state.bug.style.left = x + 'px';
+    state.bug.style.bottom = y + 'px';
+    updatePanelPosition();
+    savePrefs();
+  }
+
+  // Visible focus ring for keyboard users, in the theme's primary color
+  function injectFocusStyle() {
+    var style = document.getElementById('__devtool-focus-style');
+    if (!style) {
+      style = document.createElement('style');
+      style.id = '__devtool-focus-style';
+      document.head.appendChild(style);
+    }
+    style.textContent = '#__devtool-indicator :focus-visible { outline: 2px solid ' +
+      TOKENS.colors.primary + ' !important; outline-offset: 2px; }';
+  }
+
   // Inject CSS keyframes for activity animation
   function injectActivityAnimation() {
     if (document.getElementById('__devtool-activity-style')) return;
@@ -803,7 +919,16 @@
   function createPanel() {
     var panel = document.createElement('div');
     panel.id = '__devtool-panel';
+    panel.setAttribute('role', 'dialog');
+    panel.setAttribute('aria-label', t('panel.label', 'agnt DevTool panel'));
     panel.style.cssText = STYLES.panel + '; display: flex; flex-direction: column;';
+    panel.addEventListener('keydown', function(e) {
+      if (e.key === 'Escape') {
+        e.stopPropagation();
+        togglePanel(false);
+        if (state.bug) state.bug.focus();
+      }
+    });
     panel.style.display = 'none';
     panel.style.opacity = '0';
     panel.style.transform = 'translateY(8px)';
@@ -815,6 +940,8 @@
     // Tab content area
     var tabContent = document.createElement('div');
     tabContent.id = '__devtool-tab-content';
+    tabContent.setAttribute('role', 'tabpanel');
+    tabContent.tabIndex = 0;
     tabContent.style.cssText = STYLES.tabContent;
     panel.appendChild(tabContent);
 
@@ -835,41 +962,53 @@
     switchTab(state.activeTab);
   }
 
+  var TABS = [
+    { id: 'overview', label: 'Overview' },
+    { id: 'errors', label: 'Errors' },
+    { id: 'network', label: 'Network' },
+    { id: 'performance', label: 'Perf' },
+    { id: 'quality', label: 'Quality' },
+    { id: 'interactions', label: 'Interact' },
+    { id: 'compose', label: 'Compose' }
+  ];
+
   function createTabBar() {
     var tabBar = document.createElement('div');
     tabBar.style.cssText = STYLES.tabBar;
 
-    var tabs = [
-      { id: 'overview', label: 'Overview' },
-      { id: 'errors', label: 'Errors' },
-      { id: 'network', label: 'Network' },
-      { id: 'performance', label: 'Perf' },
-      { id: 'quality', label: 'Quality' },
-      { id: 'interactions', label: 'Interact' },
-      { id: 'compose', label: 'Compose' }
-    ];
+    var tabList = document.createElement('div');
+    tabList.setAttribute('role', 'tablist');
+    tabList.setAttribute('aria-label', t('panel.tabs', 'Panel sections'));
+    tabList.style.cssText = 'display: contents';
+    tabList.addEventListener('keydown', handleTabKey);
+    tabBar.appendChild(tabList);
 
-    tabs.forEach(function(tabInfo) {
+    TABS.forEach(function(tabInfo) {
       var tab = document.createElement('button');
       tab.id = '__devtool-tab-' + tabInfo.id;
+      tab.setAttribute('role', 'tab');
+      tab.setAttribute('aria-controls', '__devtool-tab-content');
       tab.style.cssText = STYLES.tab;
-      tab.textContent = tabInfo.label;
+      tab.textContent = t('tab.' + tabInfo.id, tabInfo.label);
       tab.onclick = function() { switchTab(tabInfo.id); };
 
-      // Highlight active tab
-      if (state.activeTab === tabInfo.id) {
+      // Highlight active tab; only it is in the tab order
+      var selected = state.activeTab === tabInfo.id;
+      tab.setAttribute('aria-selected', selected ? 'true' : 'false');
+      tab.tabIndex = selected ? 0 : -1;
+      if (selected) {
         tab.style.cssText = STYLES.tab + ';' + STYLES.tabActive;
       }
 
-      tabBar.appendChild(tab);
+      tabList.appendChild(tab);
     });
 
     // Close button at the end
     var closeBtn = document.createElement('button');
     closeBtn.style.cssText = STYLES.tabCloseBtn;
     closeBtn.innerHTML = ICONS.close;
-    closeBtn.setAttribute('aria-label', 'Close panel');
-    closeBtn.title = 'Close panel';
+    closeBtn.setAttribute('aria-label', t('panel.close', 'Close panel'));
+    closeBtn.title = t('panel.close', 'Close panel');
     closeBtn.onclick = function(e) { e.stopPropagation(); togglePanel(false); };
     closeBtn.onmouseenter = function() { closeBtn.style.color = TOKENS.colors.text; };
     closeBtn.onmouseleave = function() { closeBtn.style.color = TOKENS.colors.textMuted; };
@@ -889,21 +1028,20 @@
     }
 
     // Update tab bar highlighting
-    var tabs = ['overview', 'errors', 'network', 'performance', 'quality', 'interactions', 'compose'];
-    tabs.forEach(function(id) {
-      var tab = document.getElementById('__devtool-tab-' + id);
+    TABS.forEach(function(tabInfo) {
+      var tab = document.getElementById('__devtool-tab-' + tabInfo.id);
       if (tab) {
-        if (id === tabId) {
-          tab.style.cssText = STYLES.tab + ';' + STYLES.tabActive;
-        } else {
-          tab.style.cssText = STYLES.tab;
-        }
+        var selected = tabInfo.id === tabId;
+        tab.style.cssText = selected ? STYLES.tab + ';' + STYLES.tabActive : STYLES.tab;
+        tab.setAttribute('aria-selected', selected ? 'true' : 'false');
+        tab.tabIndex = selected ? 0 : -1;
       }
     });
 
     // Render tab content
     var content = document.getElementById('__devtool-tab-content');
     if (!content) return;
+    content.setAttribute('aria-labelledby', '__devtool-tab-' + tabId);
 
     content.innerHTML = '';
 
@@ -936,6 +1074,26 @@
     startTabUpdates();
   }
 
+  // Arrow keys, Home and End move between tabs (WAI-ARIA tabs pattern)
+  function handleTabKey(e) {
+    var index = -1;
+    for (var i = 0; i < TABS.length; i++) {
+      if (TABS[i].id === state.activeTab) index = i;
+    }
+    var next;
+    switch (e.key) {
+      case 'ArrowRight': next = (index + 1) % TABS.length; break;
+      case 'ArrowLeft': next = (index - 1 + TABS.length) % TABS.length; break;
+      case 'Home': next = 0; break;
+      case 'End': next = TABS.length - 1; break;
+      default: return;
+    }
+    e.preventDefault();
+    switchTab(TABS[next].id);
+    var tab = document.getElementById('__devtool-tab-' + TABS[next].id);
+    if (tab) tab.focus();
+  }
+
   function startTabUpdates() {
     // Clear existing interval
     if (state.tabUpdateInterval) {
@@ -1389,7 +1547,7 @@
     var textarea = document.createElement('textarea');
     textarea.id = '__devtool-message';
     textarea.style.cssText = STYLES.textarea;
-    textarea.placeholder = 'Describe what you need help with... (Ctrl+Enter to send)';
+    textarea.placeholder = t('compose.placeholder', 'Describe what you need help with... (Ctrl+Enter to send)');
     textarea.onfocus = function() {
       card.style.cssText = STYLES.messageCard + ';' + STYLES.messageCardFocused;
     };
@@ -1431,6 +1589,7 @@
 
     compose.appendChild(card);
     container.appendChild(compose);
+    setupFileDrop(card);
 
     // Toolbar with actions
     var toolbar = document.createElement('div');
@@ -1441,24 +1600,34 @@
     actionsContainer.style.cssText = STYLES.toolbarActions;
 
     // Tool buttons (Gestalt: Similarity - all secondary actions look alike)
-    var screenshotBtn = createToolBtn('Screenshot', ICONS.screenshot, startScreenshotMode);
-    var elementBtn = createToolBtn('Element', ICONS.element, startElementMode);
-    var sketchBtn = createToolBtn('Sketch', ICONS.sketch, openSketch);
-    var designBtn = createToolBtn('Design', ICONS.design, startDesignMode);
+    var screenshotBtn = createToolBtn(t('tool.screenshot', 'Screenshot'), ICONS.screenshot, startScreenshotMode);
+    var elementBtn = createToolBtn(t('tool.element', 'Element'), ICONS.element, startElementMode);
+    var sketchBtn = createToolBtn(t('tool.sketch', 'Sketch'), ICONS.sketch, openSketch);
+    var designBtn = createToolBtn(t('tool.design', 'Design'), ICONS.design, startDesignMode);
+    var toTerminalBtn = createToolBtn(t('tool.to_terminal', 'To terminal'), ICONS.clipboard, sendSelectionToTerminal);
+    toTerminalBtn.title = t('tool.to_terminal_title', 'Type the selected page text (or the message) into the agent prompt');
+    var fromTerminalBtn = createToolBtn(t('tool.terminal_clip', 'Terminal clip'), ICONS.clipboard, pasteTerminalClip);
+    fromTerminalBtn.title = t('tool.terminal_clip_title', 'Insert the text last shared from the terminal');
+    var fileBtn = createToolBtn(t('tool.file', 'File'), ICONS.file, pickFile);
+    fileBtn.title = t('tool.file_title', 'Upload a file to the project for the agent (or drop it on the message box)');
     var auditDropdown = createActionsDropdown();
 
     actionsContainer.appendChild(screenshotBtn);
     actionsContainer.appendChild(elementBtn);
     actionsContainer.appendChild(sketchBtn);
     actionsContainer.appendChild(designBtn);
+    actionsContainer.appendChild(toTerminalBtn);
+    actionsContainer.appendChild(fromTerminalBtn);
+    actionsContainer.appendChild(fileBtn);
     actionsContainer.appendChild(auditDropdown);
     toolbar.appendChild(actionsContainer);
 
     // Send button (visual hierarchy - primary action, auto-pushed right via margin-left: auto)
     var sendBtn = document.createElement('button');
     sendBtn.style.cssText = STYLES.sendBtn;
-    sendBtn.innerHTML = ICONS.send + ' Send';
-    sendBtn.title = 'Send message (Ctrl+Enter)';
+    sendBtn.innerHTML = ICONS.send + ' ';
+    sendBtn.appendChild(document.createTextNode(t('compose.send', 'Send')));
+    sendBtn.title = t('compose.send_title', 'Send message (Ctrl+Enter)');
     sendBtn.onclick = handleSend;
     sendBtn.onmouseenter = function() { sendBtn.style.background = TOKENS.colors.primaryDark; };
     sendBtn.onmouseleave = function() { sendBtn.style.background = TOKENS.colors.primary; };
@@ -1664,11 +1833,15 @@
     var btn = document.createElement('button');
     btn.style.cssText = STYLES.dropdownBtn;
     btn.innerHTML = ICONS.actions + ' Audit ' + ICONS.chevronDown;
+    btn.setAttribute('aria-haspopup', 'menu');
+    btn.setAttribute('aria-expanded', 'false');
+    btn.setAttribute('aria-controls', '__devtool-audit-menu');
     container.appendChild(btn);
 
     var menu = document.createElement('div');
     menu.style.cssText = STYLES.dropdownMenu + ';max-height:400px;overflow-y:auto';
     menu.id = '__devtool-audit-menu';
+    menu.setAttribute('role', 'menu');
 
     // Group actions by category
     var sections = [
@@ -1683,6 +1856,7 @@
     sections.forEach(function(section, sectionIndex) {
       // Add section header
       var header = document.createElement('div');
+      header.setAttribute('role', 'presentation');
       header.style.cssText = STYLES.dropdownHeader;
       if (sectionIndex > 0) {
         header.style.borderTop = '1px solid ' + TOKENS.colors.border;
@@ -1696,6 +1870,8 @@
         if (!action) return;
 
         var item = document.createElement('button');
+        item.setAttribute('role', 'menuitem');
+        item.tabIndex = -1;
         item.style.cssText = STYLES.dropdownItem;
         item.innerHTML = action.label;
         item.title = action.description;
@@ -1724,6 +1900,7 @@
 
     function openDropdown() {
       isOpen = true;
+      btn.setAttribute('aria-expanded', 'true');
       menu.style.cssText = STYLES.dropdownMenu + ';' + STYLES.dropdownMenuVisible;
       btn.style.background = TOKENS.colors.surface;
       btn.style.borderColor = TOKENS.colors.primary;
@@ -1733,6 +1910,7 @@
 
     function closeDropdown() {
       isOpen = false;
+      btn.setAttribute('aria-expanded', 'false');
       menu.style.cssText = STYLES.dropdownMenu;
       btn.style.background = 'transparent';
       btn.style.borderColor = TOKENS.colors.border;
@@ -1751,6 +1929,36 @@
       isOpen ? closeDropdown() : openDropdown();
     };
 
+    // Menu keys: arrows move between items, Escape closes back to the button
+    function focusItem(delta) {
+      var items = Array.prototype.slice.call(menu.querySelectorAll('[role="menuitem"]'));
+      var index = items.indexOf(document.activeElement);
+      var next = index < 0 ? (delta > 0 ? 0 : items.length - 1) : (index + delta + items.length) % items.length;
+      if (items[next]) items[next].focus();
+    }
+
+    btn.onkeydown = function(e) {
+      if (e.key === 'ArrowDown' || e.key === 'ArrowUp') {
+        e.preventDefault();
+        if (!isOpen) openDropdown();
+        focusItem(e.key === 'ArrowDown' ? 1 : -1);
+      }
+    };
+
+    menu.onkeydown = function(e) {
+      if (e.key === 'ArrowDown' || e.key === 'ArrowUp') {
+        e.preventDefault();
+        focusItem(e.key === 'ArrowDown' ? 1 : -1);
+      } else if (e.key === 'Escape') {
+        e.preventDefault();
+        e.stopPropagation();
+        closeDropdown();
+        btn.focus();
+      } else if (e.key === 'Tab') {
+        closeDropdown();
+      }
+    };
+
     btn.onmouseenter = function() {
       if (!isOpen) {
         btn.style.background = TOKENS.colors.surface;
@@ -2265,6 +2473,133 @@
     togglePanel(false);
   }
 
+  // Clipboard bridge - page text to the agent prompt and back
+  function trackSelection() {
+    document.addEventListener('selectionchange', function() {
+      var selection = window.getSelection();
+      if (!selection || selection.isCollapsed) return;
+      // Selections inside the panel aren't page text
+      if (state.container && state.container.contains(selection.anchorNode)) return;
+      var text = selection.toString().trim();
+      if (text) state.lastSelection = text;
+    });
+  }
+
+  function notify(kind, message) {
+    var toast = window.__devtool_toast;
+    if (toast && toast[kind]) toast[kind](message);
+  }
+
+  // Types the selected page text, or the message being composed, into the
+  // terminal session without submitting it
+  function sendSelectionToTerminal() {
+    var textarea = document.getElementById('__devtool-message');
+    var text = state.lastSelection || (textarea ? textarea.value.trim() : '');
+    if (!text) {
+      notify('warning', 'Select text on the page first');
+      return;
+    }
+    if (new Blob([text]).size > MAX_CLIPBOARD_BYTES) {
+      notify('error', 'Selection is over the 64KB clipboard limit');
+      return;
+    }
+    core.send('clipboard', { text: text, enter: false });
+    state.lastSelection = '';
+  }
+
+  // Inserts the text last shared from the terminal into the message box and
+  // the page clipboard
+  function pasteTerminalClip() {
+    if (!state.terminalClip) {
+      notify('info', 'Nothing shared from the terminal yet (agnt session clip)');
+      return;
+    }
+    var textarea = document.getElementById('__devtool-message');
+    if (textarea) {
+      textarea.value = textarea.value ? textarea.value + '\n' + state.terminalClip.text : state.terminalClip.text;
+      textarea.oninput();
+      textarea.focus();
+    }
+    if (navigator.clipboard && navigator.clipboard.writeText) {
+      navigator.clipboard.writeText(state.terminalClip.text).catch(function() {});
+    }
+  }
+
+  function handleClipboardMessage(message) {
+    var payload = message.payload || message;
+    if (message.type === 'clipboard') {
+      state.terminalClip = payload;
+      showOutputPreview(['Terminal clip ready (' + payload.text.length + ' chars)'].concat(payload.text.split('\n').slice(0, 3)));
+    } else if (payload.success) {
+      notify('success', 'Sent to the terminal');
+    } else {
+      notify('error', payload.error || 'Failed to send to the terminal');
+    }
+  }
+
+  // File uploads - stored under the project, with a panel message telling
+  // the agent where
+  function setupFileDrop(target) {
+    target.addEventListener('dragover', function(e) {
+      if (!e.dataTransfer || Array.prototype.indexOf.call(e.dataTransfer.types, 'Files') === -1) return;
+      e.preventDefault();
+      target.style.outline = '2px dashed ' + TOKENS.colors.primary;
+    });
+    target.addEventListener('dragleave', function() {
+      target.style.outline = '';
+    });
+    target.addEventListener('drop', function(e) {
+      target.style.outline = '';
+      if (!e.dataTransfer || !e.dataTransfer.files || e.dataTransfer.files.length === 0) return;
+      e.preventDefault();
+      Array.prototype.forEach.call(e.dataTransfer.files, uploadFile);
+    });
+  }
+
+  function pickFile() {
+    var input = document.createElement('input');
+    input.type = 'file';
+    input.multiple = true;
+    input.onchange = function() {
+      Array.prototype.forEach.call(input.files, uploadFile);
+    };
+    input.click();
+  }
+
+  // Sends a file with the message being composed as its note
+  function uploadFile(file) {
+    if (file.size > MAX_UPLOAD_BYTES) {
+      notify('error', file.name + ' is over the 20 MB upload limit');
+      return;
+    }
+    var reader = new FileReader();
+    reader.onload = function() {
+      var textarea = document.getElementById('__devtool-message');
+      var note = textarea ? textarea.value.trim() : '';
+      if (textarea) textarea.value = '';
+      core.send('upload', {
+        name: file.name,
+        mime_type: file.type,
+        data: reader.result,
+        message: note
+      });
+      notify('info', 'Uploading ' + file.name + '...');
+    };
+    reader.onerror = function() {
+      notify('error', 'Failed to read ' + file.name);
+    };
+    reader.readAsDataURL(file);
+  }
+
+  function handleUploadResult(message) {
+    var payload = message.payload || message;
+    if (payload.success) {
+      notify('success', payload.name + ' saved to ' + payload.file_path);
+    } else {
+      notify('error', (payload.name ? payload.name + ': ' : '') + (payload.error || 'upload failed'));
+    }
+  }
+
   // Screenshot mode
   function startScreenshotMode() {
     togglePanel(false);
@@ -2298,7 +2633,7 @@
 
     var instructions = document.createElement('div');
     instructions.style.cssText = STYLES.instructionBar;
-    instructions.textContent = 'Click and drag to select area \u2022 ESC to cancel';
+    instructions.textContent = t('capture.area_hint', 'Click and drag to select area \u2022 ESC to cancel');
     overlay.appendChild(instructions);
 
     var start = null;
@@ -2381,7 +2716,7 @@
 
     var instructions = document.createElement('div');
     instructions.style.cssText = STYLES.instructionBar;
-    instructions.textContent = 'Click an element to select \u2022 ESC to cancel';
+    instructions.textContent = t('capture.element_hint', 'Click an element to select \u2022 ESC to cancel');
     overlay.appendChild(instructions);
 
     var hovered = null;
@@ -2510,6 +2845,9 @@
   function togglePanel(show) {
     var shouldShow = show !== undefined ? show : !state.isExpanded;
     state.isExpanded = shouldShow;
+    if (state.bug) {
+      state.bug.setAttribute('aria-expanded', shouldShow ? 'true' : 'false');
+    }
 
     if (shouldShow) {
       updatePanelPosition();
@@ -2517,6 +2855,11 @@
       requestAnimationFrame(function() {
         state.panel.style.opacity = '1';
         state.panel.style.transform = 'translateY(0)';
+        // Opened from the keyboard: continue on the active tab
+        if (document.activeElement === state.bug) {
+          var tab = document.getElementById('__devtool-tab-' + state.activeTab);
+          if (tab) tab.focus();
+        }
       });
       // Start tab updates
       startTabUpdates();
@@ -2583,6 +2926,7 @@
       document.removeEventListener('mouseup', onUp);
 
       if (dragged) {
+        state.isPlaced = true;
         savePrefs();
         setTimeout(function() { state.isDragging = false; }, 0);
       } else {
@@ -2601,12 +2945,21 @@
       if (dot) {
         dot.style.backgroundColor = core.isConnected() ? TOKENS.colors.success : TOKENS.colors.error;
       }
+      if (state.bug) {
+        var label = bugLabel();
+        if (state.bug.getAttribute('aria-label') !== label) {
+          state.bug.setAttribute('aria-label', label);
+        }
+      }
     }, 1000);
 
     // Register message handler for activity state
     if (core && core.onMessage) {
       core.onMessage(handleMessage);
     }
+    if (ui) {
+      ui.onChange(applyUI);
+    }
   }
 
   // Handle incoming WebSocket messages
@@ -2619,6 +2972,10 @@
       if (payload.lines && Array.isArray(payload.lines)) {
         showOutputPreview(payload.lines);
       }
+    } else if (message.type === 'clipboard' || message.type === 'clipboard_result') {
+      handleClipboardMessage(message);
+    } else if (message.type === 'upload_result') {
+      handleUploadResult(message);
     }
   }
 
@@ -2637,7 +2994,10 @@
       var saved = localStorage.getItem('__devtool_prefs');
       if (saved) {
         var prefs = JSON.parse(saved);
-        if (prefs.position) state.position = prefs.position;
+        if (prefs.position) {
+          state.position = prefs.position;
+          state.isPlaced = true;
+        }
         if (typeof prefs.isVisible === 'boolean') state.isVisible = prefs.isVisible;
       }
     } catch (e) {}
@@ -58,9 +58,21 @@
       mouseMoveWindow: 60000,
       mouseMoveInterval: 100,
       sendBatchSize: 10,
-      sendInterval: 1000
+      sendInterval: 1000,
+      // Additional CSS selectors whose values are masked at capture time.
+      // Password fields and sensitive autocomplete hints are always masked.
+      maskSelectors: ['[data-devtool-mask]', '[data-private]']
     };
 
+    // Autocomplete tokens that indicate credentials or payment data.
+    var SENSITIVE_AUTOCOMPLETE = [
+      'current-password', 'new-password', 'one-time-code',
+      'cc-number', 'cc-csc', 'cc-exp', 'cc-exp-month', 'cc-exp-year',
+      'cc-name', 'cc-given-name', 'cc-family-name', 'cc-type'
+    ];
+
+    var MASKED_VALUE = '[masked]';
+
     // State
     var mouseMoveBuffer = [];
     var lastInteractionTime = 0;
@@ -106,6 +118,46 @@
       return null;
     }
 
+    // Determine whether an element holds sensitive input.
+    // Returns the reason ('password', 'autocomplete:<token>', 'selector:<sel>') or null.
+    function getMaskReason(el) {
+      if (!el || !(el instanceof HTMLElement)) return null;
+
+      try {
+        var type = String(safeGetAttribute(el, 'type') || el.type || '').toLowerCase();
+        if (type === 'password') {
+          return 'password';
+        }
+
+        var autocomplete = safeGetAttribute(el, 'autocomplete');
+        if (autocomplete) {
+          var tokens = String(autocomplete).toLowerCase().split(/\s+/);
+          for (var i = 0; i < tokens.length; i++) {
+            if (SENSITIVE_AUTOCOMPLETE.indexOf(tokens[i]) !== -1) {
+              return 'autocomplete:' + tokens[i];
+            }
+          }
+        }
+
+        var selectors = config.maskSelectors || [];
+        for (var j = 0; j < selectors.length; j++) {
+          try {
+            if (typeof selectors[j] === 'string' && typeof el.matches === 'function' &&
+                (el.matches(selectors[j]) || (el.closest && el.closest(selectors[j])))) {
+              return 'selector:' + selectors[j];
+            }
+          } catch (e) {
+            // Invalid selector - skip
+          }
+        }
+      } catch (e) {
+        reportError('getMaskReason_failed', e);
+        // Fail closed: treat as sensitive if detection itself fails
+        return 'error';
+      }
+      return null;
+    }
+
     // Generate target info with comprehensive error handling
     function getTargetInfo(el) {
       if (!el || !(el instanceof HTMLElement)) return null;
@@ -121,7 +173,7 @@
         }
 
         var attrs = {};
-        var relevantAttrs = ['href', 'src', 'type', 'name', 'placeholder', 'role', 'aria-label'];
+        var relevantAttrs = ['href', 'src', 'type', 'name', 'placeholder', 'role', 'aria-label', 'autocomplete'];
 
         for (var i = 0; i < relevantAttrs.length; i++) {
           try {
@@ -191,12 +243,23 @@
           // Position extraction failed
         }
 
+        // Mask sensitive fields before anything leaves the page
+        var maskReason = getMaskReason(target);
+        if (maskReason) {
+          interaction.masked = true;
+          interaction.mask_reason = maskReason;
+          // Text content of masked elements (e.g. contenteditable) is also private
+          targetInfo.text = undefined;
+        }
+
         // Add key info for keyboard events
         try {
           if (event.key !== undefined) {
+            // Printable keys typed into sensitive fields would reveal the value
+            var hideKey = maskReason && event.key && event.key.length === 1;
             interaction.key = {
-              key: event.key || '',
-              code: event.code || '',
+              key: hideKey ? MASKED_VALUE : (event.key || ''),
+              code: hideKey ? '' : (event.code || ''),
               ctrl: event.ctrlKey || undefined,
               alt: event.altKey || undefined,
               shift: event.shiftKey || undefined,
@@ -220,6 +283,10 @@
           }
         }
 
+        if (maskReason && interaction.value) {
+          interaction.value = MASKED_VALUE;
+        }
+
         // Store locally (circular buffer)
         try {
           if (interactions.length < config.maxHistorySize) {
@@ -320,9 +387,11 @@
             try {
               var value = '';
 
-              // Don't send password values
+              // Never send sensitive values; masking is noted on the entry
               try {
-                if (target.type !== 'password' && target.value) {
+                if (getMaskReason(target)) {
+                  value = target.value ? MASKED_VALUE : '';
+                } else if (target.value) {
                   value = String(target.value).substring(0, config.truncateText);
                 }
               } catch (err) {
@@ -540,6 +609,14 @@
       reportError('setInterval_failed', e);
     }
 
+    // Send what is pending before the page goes away, so the click that
+    // navigated is not lost
+    try {
+      window.addEventListener('pagehide', sendBatch);
+    } catch (e) {
+      reportError('pagehide_listener_failed', e);
+    }
+
     // Initialize
     try {
       attachListeners();
@@ -646,6 +723,23 @@
             }
           },
 
+          addMaskSelector: function(selector) {
+            try {
+              if (typeof selector !== 'string' || !selector) return false;
+              if (config.maskSelectors.indexOf(selector) === -1) {
+                config.maskSelectors.push(selector);
+              }
+              return true;
+            } catch (e) {
+              reportError('addMaskSelector_failed', e);
+              return false;
+            }
+          },
+
+          isMasked: function(el) {
+            return getMaskReason(el) !== null;
+          },
+
           clear: function() {
             try {
               interactions = [];
@@ -0,0 +1,262 @@
+// Time-travel DOM reconstruction for DevTool
+// Journals DOM mutations together with the nodes they touched, so the page
+// can be shown as it looked at an earlier moment: the live document is cloned
+// and every mutation after that moment is undone on the clone.
+// Form values, canvas contents and scroll positions aren't DOM mutations and
+// show their current state.
+
+(function() {
+  'use strict';
+
+  var MAX_RECORDS = 5000;
+  var DEFAULT_MAX_LENGTH = 100000;
+
+  var journal = [];
+  var coveredSince = Date.now(); // Rewinds to any time from here on are exact
+  var observer = null;
+
+  // Mutations of DevTool's own overlays, toasts and indicator aren't journaled
+  function isDevtoolNode(node) {
+    var el = node && node.nodeType === 1 ? node : node && node.parentElement;
+    while (el) {
+      var id = el.id || '';
+      var cls = typeof el.className === 'string' ? el.className : '';
+      if (id.indexOf('__devtool') === 0 || cls.indexOf('__devtool') !== -1) {
+        return true;
+      }
+      el = el.parentElement;
+    }
+    return false;
+  }
+
+  function toArray(list) {
+    var arr = [];
+    for (var i = 0; list && i < list.length; i++) {
+      arr.push(list[i]);
+    }
+    return arr;
+  }
+
+  function record(records) {
+    var now = Date.now();
+    for (var i = 0; i < records.length; i++) {
+      var r = records[i];
+      if (isDevtoolNode(r.target)) continue;
+      journal.push({
+        time: now,
+        type: r.type,
+        target: r.target,
+        added: toArray(r.addedNodes),
+        removed: toArray(r.removedNodes),
+        previousSibling: r.previousSibling,
+        nextSibling: r.nextSibling,
+        attributeName: r.attributeName,
+        attributeNamespace: r.attributeNamespace,
+        oldValue: r.oldValue
+      });
+    }
+    if (journal.length > MAX_RECORDS) {
+      var evicted = journal.splice(0, journal.length - MAX_RECORDS);
+      coveredSince = evicted[evicted.length - 1].time;
+    }
+  }
+
+  function startObserver() {
+    if (observer || typeof MutationObserver === 'undefined' || !document.documentElement) return;
+    try {
+      observer = new MutationObserver(record);
+      observer.observe(document.documentElement, {
+        childList: true,
+        subtree: true,
+        attributes: true,
+        attributeOldValue: true,
+        characterData: true,
+        characterDataOldValue: true
+      });
+    } catch (e) {
+      console.error('[DevTool][TimeTravel] Failed to observe mutations:', e);
+    }
+  }
+
+  // Accepts epoch milliseconds, a negative offset in ms from now, a Date or
+  // a date string (e.g. a proxylog timestamp)
+  function toTime(when) {
+    if (when instanceof Date) return when.getTime();
+    if (typeof when === 'number') return when < 0 ? Date.now() + when : when;
+    if (typeof when === 'string') {
+      var parsed = Date.parse(when);
+      if (!isNaN(parsed)) return parsed;
+    }
+    throw new Error('Invalid time: use epoch ms, a negative offset in ms, a Date or a date string');
+  }
+
+  // Pairs each live node with its clone
+  function mapTree(live, clone, clones) {
+    clones.set(live, clone);
+    var l = live.firstChild;
+    var c = clone.firstChild;
+    while (l && c) {
+      mapTree(l, c, clones);
+      l = l.nextSibling;
+      c = c.nextSibling;
+    }
+  }
+
+  function undo(entry, cloneOf) {
+    var target = cloneOf(entry.target);
+    if (entry.type === 'attributes') {
+      if (entry.oldValue === null) {
+        target.removeAttributeNS(entry.attributeNamespace, entry.attributeName);
+      } else {
+        target.setAttributeNS(entry.attributeNamespace, entry.attributeName, entry.oldValue);
+      }
+      return;
+    }
+    if (entry.type === 'characterData') {
+      target.data = entry.oldValue || '';
+      return;
+    }
+
+    var i;
+    for (i = entry.added.length - 1; i >= 0; i--) {
+      var added = cloneOf(entry.added[i]);
+      if (added.parentNode === target) {
+        target.removeChild(added);
+      }
+    }
+
+    // Put removed nodes back between their old siblings
+    var ref = null;
+    var next = entry.nextSibling && cloneOf(entry.nextSibling);
+    var prev = entry.previousSibling && cloneOf(entry.previousSibling);
+    if (next && next.parentNode === target) {
+      ref = next;
+    } else if (prev && prev.parentNode === target) {
+      ref = prev.nextSibling;
+    }
+    for (i = 0; i < entry.removed.length; i++) {
+      target.insertBefore(cloneOf(entry.removed[i]), ref);
+    }
+  }
+
+  function strip(root, keepScripts) {
+    var selector = '[id^="__devtool"], [class*="__devtool"]' + (keepScripts ? '' : ', script');
+    var nodes = root.querySelectorAll(selector);
+    for (var i = 0; i < nodes.length; i++) {
+      if (nodes[i].parentNode) {
+        nodes[i].parentNode.removeChild(nodes[i]);
+      }
+    }
+  }
+
+  /**
+   * Reconstruct the DOM as it was at a moment of this page view
+   * @param {number|string|Date} when - Epoch ms, negative ms from now (-5000), Date or date string
+   * @param {Object} [options] - {selector, maxLength: 100000, keepScripts: false}
+   * @returns {Object} - {html, at, exact, coveredSince, undone, truncated, url}
+   */
+  function domAt(when, options) {
+    options = options || {};
+    var at = toTime(when);
+    var maxLength = options.maxLength > 0 ? options.maxLength : DEFAULT_MAX_LENGTH;
+
+    // Deliver pending records before rewinding
+    if (observer && typeof observer.takeRecords === 'function') {
+      record(observer.takeRecords());
+    }
+
+    var clones = typeof Map !== 'undefined' ? new Map() : null;
+    if (!clones) {
+      throw new Error('Time travel requires Map support');
+    }
+    var root = document.documentElement.cloneNode(true);
+    mapTree(document.documentElement, root, clones);
+
+    // Nodes no longer in the document are cloned as they are now; earlier
+    // changes to them are undone like any other
+    function cloneOf(node) {
+      var clone = clones.get(node);
+      if (!clone) {
+        clone = node.cloneNode(true);
+        mapTree(node, clone, clones);
+      }
+      return clone;
+    }
+
+    var undone = 0;
+    for (var i = journal.length - 1; i >= 0 && journal[i].time > at; i--) {
+      try {
+        undo(journal[i], cloneOf);
+        undone++;
+      } catch (e) {
+        // A record that no longer applies; keep rewinding
+      }
+    }
+
+    var node = root;
+    if (options.selector) {
+      node = root.querySelector(options.selector);
+      if (!node) {
+        throw new Error('No element matched ' + options.selector + ' at that time');
+      }
+    }
+    strip(node, options.keepScripts);
+
+    var html = node === root ? '<!DOCTYPE html>\n' + root.outerHTML : node.outerHTML;
+    var truncated = html.length > maxLength;
+    return {
+      html: truncated ? html.substring(0, maxLength) : html,
+      at: at,
+      exact: at >= coveredSince,
+      coveredSince: coveredSince,
+      undone: undone,
+      truncated: truncated,
+      url: location.href
+    };
+  }
+
+  /**
+   * Reconstruct the DOM just before a JavaScript error of this page view
+   * @param {number} [index] - Error index from __devtool_errors.getJSErrors() (default: the latest)
+   * @param {Object} [options] - Same as domAt
+   * @returns {Object} - domAt result plus the error
+   */
+  function beforeError(index, options) {
+    var errors = window.__devtool_errors ? window.__devtool_errors.getJSErrors() : [];
+    if (errors.length === 0) {
+      throw new Error('No JavaScript errors recorded on this page');
+    }
+    var error = errors[typeof index === 'number' ? index : errors.length - 1];
+    if (!error) {
+      throw new Error('No error at index ' + index + ' (' + errors.length + ' recorded)');
+    }
+    var result = domAt(error.timestamp - 1, options);
+    result.error = {
+      message: error.message,
+      source: error.source,
+      lineno: error.lineno,
+      timestamp: error.timestamp
+    };
+    return result;
+  }
+
+  /**
+   * How far back the page can be rewound
+   * @returns {Object} - {records, coveredSince, oldest}
+   */
+  function getState() {
+    return {
+      records: journal.length,
+      coveredSince: coveredSince,
+      oldest: journal.length ? journal[0].time : null
+    };
+  }
+
+  startObserver();
+
+  window.__devtool_timetravel = {
+    domAt: domAt,
+    beforeError: beforeError,
+    getState: getState
+  };
+})();
@@ -4,10 +4,12 @@
 (function() {
   'use strict';
 
+  var ui = window.__devtool_ui;
+
   // Configuration (can be overridden via __devtool.toast.configure())
   var config = {
     duration: 4000,
-    position: 'bottom-right', // top-right, top-left, bottom-right, bottom-left
+    position: toastPosition(), // top-right, top-left, bottom-right, bottom-left
     maxVisible: 3,
     gap: 10
   };
@@ -19,18 +21,21 @@
     nextId: 1
   };
 
+  // Light theme colors; the overlay theme and accent apply over them
+  var COLORS = {
+    surface: '#ffffff',
+    text: '#1e293b',
+    textMuted: '#64748b',
+    border: '#e2e8f0',
+    success: '#22c55e',
+    error: '#ef4444',
+    warning: '#f59e0b',
+    info: '#3b82f6'
+  };
+
   // Design tokens (shared with indicator)
   var TOKENS = {
-    colors: {
-      surface: '#ffffff',
-      text: '#1e293b',
-      textMuted: '#64748b',
-      border: '#e2e8f0',
-      success: '#22c55e',
-      error: '#ef4444',
-      warning: '#f59e0b',
-      info: '#3b82f6'
-    },
+    colors: ui ? ui.themed(COLORS) : COLORS,
     radius: {
       md: '10px'
     },
@@ -39,92 +44,137 @@
     }
   };
 
-  // Styles
-  var STYLES = {
-    container: [
-      'position: fixed',
-      'z-index: 2147483647',
-      'display: flex',
-      'flex-direction: column',
-      'gap: 10px',
-      'pointer-events: none',
-      'max-width: 380px',
-      'width: 100%',
-      'padding: 20px'
-    ].join(';'),
-
-    toast: [
-      'display: flex',
-      'align-items: flex-start',
-      'gap: 12px',
-      'padding: 14px 16px',
-      'background: ' + TOKENS.colors.surface,
-      'border-radius: ' + TOKENS.radius.md,
-      'box-shadow: ' + TOKENS.shadow.lg,
-      'border-left: 4px solid ' + TOKENS.colors.info,
-      'font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif',
-      'font-size: 14px',
-      'color: ' + TOKENS.colors.text,
-      'pointer-events: auto',
-      'opacity: 0',
-      'transform: translateX(100%)',
-      'transition: opacity 0.3s ease, transform 0.3s ease'
-    ].join(';'),
-
-    toastVisible: [
-      'opacity: 1',
-      'transform: translateX(0)'
-    ].join(';'),
-
-    toastExiting: [
-      'opacity: 0',
-      'transform: translateX(100%)'
-    ].join(';'),
-
-    icon: [
-      'flex-shrink: 0',
-      'width: 20px',
-      'height: 20px'
-    ].join(';'),
-
-    content: [
-      'flex: 1',
-      'min-width: 0'
-    ].join(';'),
-
-    title: [
-      'font-weight: 600',
-      'margin-bottom: 2px',
-      'line-height: 1.3'
-    ].join(';'),
-
-    message: [
-      'color: ' + TOKENS.colors.textMuted,
-      'line-height: 1.4',
-      'word-wrap: break-word'
-    ].join(';'),
-
-    closeBtn: [
-      'flex-shrink: 0',
-      'background: none',
-      'border: none',
-      'padding: 2px',
-      'cursor: pointer',
-      'color: ' + TOKENS.colors.textMuted,
-      'opacity: 0.6',
-      'transition: opacity 0.15s ease'
-    ].join(';'),
-
-    progress: [
-      'position: absolute',
-      'bottom: 0',
-      'left: 0',
-      'height: 3px',
-      'background: currentColor',
-      'opacity: 0.3',
-      'border-radius: 0 0 0 ' + TOKENS.radius.md
-    ].join(';')
-  };
+  // Styles, rebuilt when the overlay theme changes
+  var STYLES = buildStyles();
+
+  function buildStyles() {
+    return {
+      container: [
+        'position: fixed',
+        'z-index: 2147483647',
+        'display: flex',
+        'flex-direction: column',
+        'gap: 10px',
+        'pointer-events: none',
+        'max-width: 380px',
+        'width: 100%',
+        'padding: 20px'
+      ].join(';'),
+
+      toast: [
+        'display: flex',
+        'align-items: flex-start',
+        'gap: 12px',
+        'padding: 14px 16px',
+        'background: ' + TOKENS.colors.surface,
+        'border-radius: ' + TOKENS.radius.md,
+        'box-shadow: ' + TOKENS.shadow.lg,
+        'border-left: 4px solid ' + TOKENS.colors.info,
+        'font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif',
+        'font-size: 14px',
+        'color: ' + TOKENS.colors.text,
+        'pointer-events: auto',
+        'opacity: 0',
+        'transform: translateX(100%)',
+        'transition: opacity 0.3s ease, transform 0.3s ease'
+      ].join(';'),
+
+      toastVisible: [
+        'opacity: 1',
+        'transform: translateX(0)'
+      ].join(';'),
+
+      toastExiting: [
+        'opacity: 0',
+        'transform: translateX(100%)'
+      ].join(';'),
+
+      icon: [
+        'flex-shrink: 0',
+        'width: 20px',
+        'height: 20px'
+      ].join(';'),
+
+      content: [
+        'flex: 1',
+        'min-width: 0'
+      ].join(';'),
+
+      title: [
+        'font-weight: 600',
+        'margin-bottom: 2px',
+        'line-height: 1.3'
+      ].join(';'),
+
+      message: [
+        'color: ' + TOKENS.colors.textMuted,
+        'line-height: 1.4',
+        'word-wrap: break-word'
+      ].join(';'),
+
+      actions: [
+        'display: flex',
+        'flex-wrap: wrap',
+        'gap: 8px',
+        'margin-top: 10px'
+      ].join(';'),
+
+      actionBtn: [
+        'padding: 4px 12px',
+        'border: 1px solid ' + TOKENS.colors.border,
+        'border-radius: 6px',
+        'background: ' + TOKENS.colors.surface,
+        'color: ' + TOKENS.colors.text,
+        'font: inherit',
+        'font-size: 13px',
+        'font-weight: 500',
+        'cursor: pointer'
+      ].join(';'),
+
+      closeBtn: [
+        'flex-shrink: 0',
+        'background: none',
+        'border: none',
+        'padding: 2px',
+        'cursor: pointer',
+        'color: ' + TOKENS.colors.textMuted,
+        'opacity: 0.6',
+        'transition: opacity 0.15s ease'
+      ].join(';'),
+
+      progress: [
+        'position: absolute',
+        'bottom: 0',
+        'left: 0',
+        'height: 3px',
+        'background: currentColor',
+        'opacity: 0.3',
+        'border-radius: 0 0 0 ' + TOKENS.radius.md
+      ].join(';')
+    };
+  }
+
+  // Strings in the overlay's language
+  function t(key, fallback) {
+    return ui ? ui.t(key, fallback) : fallback;
+  }
+
+  // Toasts sit on the indicator's side of the screen, in the other corner
+  function toastPosition() {
+    var corner = (ui ? ui.position() : 'bottom-left').split('-');
+    return corner[0] + '-' + (corner[1] === 'left' ? 'right' : 'left');
+  }
+
+  // Apply a changed overlay theme or position
+  function applyUI() {
+    TOKENS.colors = ui.themed(COLORS);
+    STYLES = buildStyles();
+    config.position = toastPosition();
+    if (state.container) {
+      state.container.style.cssText = STYLES.container;
+      updatePosition();
+    }
+  }
 
   // Icons
   var ICONS = {
@@ -141,6 +191,9 @@
 
     state.container = document.createElement('div');
     state.container.id = '__devtool-toast-container';
+    state.container.setAttribute('role', 'region');
+    state.container.setAttribute('aria-label', t('toast.region', 'agnt notifications'));
+    state.container.setAttribute('aria-live', 'polite');
     state.container.style.cssText = STYLES.container;
     updatePosition();
 
@@ -184,6 +237,7 @@
   // Create a toast element
   function createToastElement(options) {
     var toast = document.createElement('div');
+    toast.setAttribute('role', options.type === 'error' || options.type === 'warning' ? 'alert' : 'status');
     toast.style.cssText = STYLES.toast;
     toast.style.position = 'relative';
 
@@ -194,6 +248,7 @@
     // Icon
     if (options.type && ICONS[options.type]) {
       var icon = document.createElement('div');
+      icon.setAttribute('aria-hidden', 'true');
       icon.style.cssText = STYLES.icon;
       icon.innerHTML = ICONS[options.type];
       toast.appendChild(icon);
@@ -217,19 +272,37 @@
       content.appendChild(message);
     }
 
+    // Action buttons; a click goes back to the daemon
+    var actionBtns = [];
+    if (options.actions && options.actions.length) {
+      var actions = document.createElement('div');
+      actions.style.cssText = STYLES.actions;
+      options.actions.forEach(function(action) {
+        var btn = document.createElement('button');
+        btn.type = 'button';
+        btn.style.cssText = STYLES.actionBtn;
+        btn.textContent = action.label;
+        actions.appendChild(btn);
+        actionBtns.push({ element: btn, action: action });
+      });
+      content.appendChild(actions);
+    }
+
     toast.appendChild(content);
 
     // Close button
     var closeBtn = document.createElement('button');
     closeBtn.style.cssText = STYLES.closeBtn;
     closeBtn.innerHTML = ICONS.close;
+    closeBtn.setAttribute('aria-label', t('toast.dismiss', 'Dismiss'));
     closeBtn.onmouseenter = function() { closeBtn.style.opacity = '1'; };
     closeBtn.onmouseleave = function() { closeBtn.style.opacity = '0.6'; };
     toast.appendChild(closeBtn);
 
-    // Progress bar (optional)
-    if (options.showProgress !== false) {
+    // Progress bar (optional, and never animated under reduced motion)
+    if (options.showProgress !== false && !options.sticky && !(ui && ui.reducedMotion())) {
       var progress = document.createElement('div');
+      progress.setAttribute('aria-hidden', 'true');
       progress.style.cssText = STYLES.progress;
       progress.style.color = borderColor;
       progress.style.width = '100%';
@@ -242,7 +315,7 @@
       });
     }
 
-    return { element: toast, closeBtn: closeBtn };
+    return { element: toast, closeBtn: closeBtn, actionBtns: actionBtns };
   }
 
   // Show a toast
@@ -254,9 +327,20 @@
       options = { message: options };
     }
 
+    // A minimal overlay only interrupts for problems
+    if (ui && ui.isMinimal() && options.type !== 'error' && options.type !== 'warning') {
+      return null;
+    }
+
     var id = state.nextId++;
     var duration = options.duration || config.duration;
 
+    // A toast asking something stays until answered or dismissed
+    if (options.actions && options.actions.length && !options.duration) {
+      duration = 0;
+      options.sticky = true;
+    }
+
     // Remove excess toasts
     while (state.toasts.length >= config.maxVisible) {
       dismiss(state.toasts[0].id);
@@ -266,6 +350,7 @@
     var toastData = createToastElement(options);
     var toastObj = {
       id: id,
+      serverId: options.id,
       element: toastData.element,
       timer: null
     };
@@ -275,6 +360,23 @@
       dismiss(id);
     };
 
+    toastData.actionBtns.forEach(function(btn) {
+      btn.element.onclick = function() {
+        var core = window.__devtool_core;
+        if (options.id && core && core.send) {
+          core.send('toast_action', {
+            toast_id: options.id,
+            callback: btn.action.callback,
+            label: btn.action.label
+          });
+        }
+        if (options.onAction) {
+          options.onAction(btn.action);
+        }
+        dismiss(id);
+      };
+    });
+
     // Add to state and DOM
     state.toasts.push(toastObj);
     state.container.appendChild(toastData.element);
@@ -292,21 +394,32 @@
       }, duration);
     }
 
-    // Pause timer on hover
-    toastData.element.onmouseenter = function() {
+    // Pause timer on hover or keyboard focus
+    function pause() {
       if (toastObj.timer) {
         clearTimeout(toastObj.timer);
         toastObj.timer = null;
       }
-    };
+    }
 
-    toastData.element.onmouseleave = function() {
+    function resume() {
       if (duration > 0 && !toastObj.timer) {
         toastObj.timer = setTimeout(function() {
           dismiss(id);
         }, 1000); // Short delay after hover
       }
-    };
+    }
+
+    toastData.element.onmouseenter = pause;
+    toastData.element.onmouseleave = resume;
+    toastData.element.addEventListener('focusin', pause);
+    toastData.element.addEventListener('focusout', resume);
+    toastData.element.addEventListener('keydown', function(e) {
+      if (e.key === 'Escape') {
+        e.stopPropagation();
+        dismiss(id);
+      }
+    });
 
     return id;
   }
@@ -368,11 +481,11 @@
   }
 
   function error(message, title) {
-    return show({ type: 'error', message: message, title: title || 'Error' });
+    return show({ type: 'error', message: message, title: title || t('toast.error', 'Error') });
   }
 
   function warning(message, title) {
-    return show({ type: 'warning', message: message, title: title || 'Warning' });
+    return show({ type: 'warning', message: message, title: title || t('toast.warning', 'Warning') });
   }
 
   function info(message, title) {
@@ -387,8 +500,22 @@
         type: payload.type || 'info',
         title: payload.title,
         message: payload.message,
-        duration: payload.duration
+        duration: payload.duration,
+        id: payload.id,
+        actions: payload.actions
       });
+    } else if (message.type === 'toast_dismiss' && message.payload) {
+      // Answered on another page
+      dismissByServerId(message.payload.id);
+    }
+  }
+
+  function dismissByServerId(serverId) {
+    for (var i = 0; i < state.toasts.length; i++) {
+      if (serverId && state.toasts[i].serverId === serverId) {
+        dismiss(state.toasts[i].id);
+        return;
+      }
     }
   }
 
@@ -396,6 +523,9 @@
   if (window.__devtool_core && window.__devtool_core.onMessage) {
     window.__devtool_core.onMessage(handleMessage);
   }
+  if (ui) {
+    ui.onChange(applyUI);
+  }
 
   // Export
   window.__devtool_toast = {
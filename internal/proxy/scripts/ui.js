@@ -0,0 +1,172 @@
+// Overlay language and theme for DevTool
+// Strings, colors and placement of the injected UI, set per proxy (PROXY UI).
+// The proxy hands over the initial state on the script tag and pushes
+// updates over the metrics WebSocket; the indicator, toasts and banner read
+// it when they draw and redraw on change.
+
+(function() {
+  'use strict';
+
+  var core = window.__devtool_core;
+
+  // Colors the dark theme replaces; modules keep their own for the rest
+  var DARK = {
+    surface: '#1e293b',
+    surfaceAlt: '#0f172a',
+    border: '#334155',
+    text: '#e2e8f0',
+    textMuted: '#94a3b8'
+  };
+
+  // Roots of everything the overlay draws
+  var ROOTS = ['#__devtool-indicator', '#__devtool-toast-container', '#__devtool-banner'];
+
+  var state = readInitialState();
+  var listeners = [];
+  var darkQuery = window.matchMedia ? window.matchMedia('(prefers-color-scheme: dark)') : null;
+  var motionQuery = window.matchMedia ? window.matchMedia('(prefers-reduced-motion: reduce)') : null;
+
+  // Read once from the script tag while it is still the current script
+  function readInitialState() {
+    try {
+      var script = document.currentScript;
+      if (!script) return null;
+      var raw = script.getAttribute('data-devtool-ui');
+      script.removeAttribute('data-devtool-ui');
+      return raw ? JSON.parse(raw) : null;
+    } catch (e) {
+      console.error('[DevTool][UI] Invalid overlay state:', e);
+      return null;
+    }
+  }
+
+  function t(key, fallback) {
+    var messages = state && state.messages;
+    return (messages && messages[key]) || fallback;
+  }
+
+  function isDark() {
+    var theme = state ? state.theme : 'auto';
+    if (theme === 'dark') return true;
+    if (theme === 'light') return false;
+    return !!(darkQuery && darkQuery.matches);
+  }
+
+  // A copy of a module's light colors with the theme and accent applied
+  function themed(base) {
+    var colors = {};
+    var key;
+    for (key in base) colors[key] = base[key];
+    if (isDark()) {
+      for (key in DARK) {
+        if (key in colors) colors[key] = DARK[key];
+      }
+    }
+    if (state && state.accent) {
+      if ('primary' in colors) colors.primary = state.accent;
+      if ('primaryDark' in colors) colors.primaryDark = state.accent;
+      if ('info' in colors) colors.info = state.accent;
+    }
+    return colors;
+  }
+
+  function position() {
+    return (state && state.position) || 'bottom-left';
+  }
+
+  function reducedMotion() {
+    if (state && state.reduced_motion) return true;
+    return !!(motionQuery && motionQuery.matches);
+  }
+
+  function isHidden() {
+    return !!(state && state.hidden);
+  }
+
+  // One stylesheet turns off animations and, when hidden, the overlay itself.
+  // Its !important rules win over the modules' inline styles.
+  function applyGlobalStyle() {
+    var rules = [];
+    if (reducedMotion()) {
+      var all = ROOTS.map(function(root) { return root + ', ' + root + ' *'; }).join(', ');
+      rules.push(all + ' { transition: none !important; animation: none !important; scroll-behavior: auto !important; }');
+    }
+    if (isHidden()) {
+      rules.push(ROOTS.join(', ') + ' { display: none !important; }');
+    }
+
+    var style = document.getElementById('__devtool-ui-style');
+    if (rules.length === 0) {
+      if (style && style.parentNode) style.parentNode.removeChild(style);
+      return;
+    }
+    if (!style) {
+      style = document.createElement('style');
+      style.id = '__devtool-ui-style';
+      (document.head || document.documentElement).appendChild(style);
+    }
+    style.textContent = rules.join('\n');
+  }
+
+  function notify() {
+    applyGlobalStyle();
+    for (var i = 0; i < listeners.length; i++) {
+      try {
+        listeners[i](state);
+      } catch (e) {
+        console.error('[DevTool][UI] Listener failed:', e);
+      }
+    }
+  }
+
+  function handleMessage(message) {
+    if (message.type !== 'ui') return;
+    var next = message.payload || null;
+    if (JSON.stringify(next) === JSON.stringify(state)) return;
+    state = next;
+    notify();
+  }
+
+  if (core && core.onMessage) {
+    core.onMessage(handleMessage);
+  }
+  if (darkQuery && darkQuery.addEventListener) {
+    darkQuery.addEventListener('change', function() {
+      if (!state || state.theme === 'auto') notify();
+    });
+  }
+  if (motionQuery && motionQuery.addEventListener) {
+    motionQuery.addEventListener('change', notify);
+  }
+  applyGlobalStyle();
+
+  window.__devtool_ui = {
+    /**
+     * Current overlay state, or null while the proxy keeps the defaults
+     * @returns {Object|null} - {language, messages, theme, position, accent, minimal, reduced_motion, hidden}
+     */
+    getState: function() {
+      return state;
+    },
+    /** The string for key in the overlay's language, or fallback (English) */
+    t: t,
+    /** Whether the overlay draws dark: theme dark, or auto on a dark OS */
+    isDark: isDark,
+    /** Colors with the theme and accent applied over base */
+    themed: themed,
+    /** Corner of the indicator: bottom-left, bottom-right, top-left or top-right */
+    position: position,
+    /** Whether the overlay stays out of the way */
+    isMinimal: function() {
+      return !!(state && state.minimal);
+    },
+    /** Whether to skip animations: the reduced_motion option or the OS setting */
+    reducedMotion: reducedMotion,
+    /** Whether the overlay draws nothing while instrumentation keeps running */
+    isHidden: isHidden,
+    /** Call fn(state) when the overlay state or the OS theme or motion setting changes */
+    onChange: function(fn) {
+      listeners.push(fn);
+    }
+  };
+})();
@@ -0,0 +1,213 @@
+// Core Web Vitals for DevTool
+// Measures LCP, CLS, INP, FCP and TTFB of the page and reports them to the
+// proxy as they change, so the page session carries its vitals
+
+(function() {
+  'use strict';
+
+  var core = window.__devtool_core;
+  var utils = window.__devtool_utils;
+
+  if (!core || typeof core.send !== 'function') {
+    console.error('[DevTool][WebVitals] Missing or invalid core dependency');
+    return;
+  }
+  if (typeof PerformanceObserver === 'undefined') {
+    return;
+  }
+
+  var SEND_DELAY_MS = 1000;
+  // Attempts while the WebSocket is not connected yet
+  var MAX_RETRIES = 30;
+  // Longest interactions kept to estimate the 98th percentile
+  var MAX_INTERACTIONS = 10;
+
+  var vitals = {
+    lcp: null,
+    lcp_element: null,
+    cls: null,
+    inp: null,
+    inp_target: null,
+    inp_event: null,
+    fcp: null,
+    ttfb: null,
+    interactions: 0
+  };
+  var sendTimer = null;
+  var lastSent = '';
+  var retries = 0;
+
+  // CLS session windows: shifts less than 1s apart, 5s at most
+  var clsWindowValue = 0;
+  var clsWindowFirst = 0;
+  var clsWindowLast = 0;
+
+  // Longest duration per interaction ID, longest first
+  var interactions = [];
+  var interactionCount = 0;
+
+  function selectorOf(el) {
+    if (!el || !utils || typeof utils.generateSelector !== 'function') return null;
+    try {
+      return utils.generateSelector(el);
+    } catch (e) {
+      return null;
+    }
+  }
+
+  function observe(type, fn, options) {
+    try {
+      var po = new PerformanceObserver(function(list) {
+        try {
+          fn(list.getEntries());
+        } catch (e) {
+          console.error('[DevTool][WebVitals] Failed to handle ' + type + ' entries:', e);
+        }
+      });
+      var opts = { type: type, buffered: true };
+      for (var k in options) opts[k] = options[k];
+      po.observe(opts);
+      return po;
+    } catch (e) {
+      // Entry type not supported by this browser
+      return null;
+    }
+  }
+
+  function onLCP(entries) {
+    var last = entries[entries.length - 1];
+    if (!last) return;
+    vitals.lcp = Math.round(last.startTime);
+    vitals.lcp_element = selectorOf(last.element);
+    scheduleSend();
+  }
+
+  function onLayoutShift(entries) {
+    for (var i = 0; i < entries.length; i++) {
+      var entry = entries[i];
+      if (entry.hadRecentInput) continue;
+      if (clsWindowValue && entry.startTime - clsWindowLast < 1000 && entry.startTime - clsWindowFirst < 5000) {
+        clsWindowValue += entry.value;
+      } else {
+        clsWindowValue = entry.value;
+        clsWindowFirst = entry.startTime;
+      }
+      clsWindowLast = entry.startTime;
+      if (vitals.cls === null || clsWindowValue > vitals.cls) {
+        vitals.cls = clsWindowValue;
+      }
+    }
+    scheduleSend();
+  }
+
+  function onEvent(entries) {
+    for (var i = 0; i < entries.length; i++) {
+      var entry = entries[i];
+      if (!entry.interactionId) continue;
+      var existing = null;
+      for (var j = 0; j < interactions.length; j++) {
+        if (interactions[j].id === entry.interactionId) {
+          existing = interactions[j];
+          break;
+        }
+      }
+      if (existing) {
+        if (entry.duration > existing.duration) {
+          existing.duration = entry.duration;
+          existing.target = entry.target;
+          existing.name = entry.name;
+        }
+      } else {
+        interactionCount++;
+        interactions.push({ id: entry.interactionId, duration: entry.duration, target: entry.target, name: entry.name });
+      }
+    }
+    interactions.sort(function(a, b) { return b.duration - a.duration; });
+    interactions = interactions.slice(0, MAX_INTERACTIONS);
+
+    // Ignore one of the longest interactions for every 50, as INP does
+    var worst = interactions[Math.min(interactions.length - 1, Math.floor(interactionCount / 50))];
+    if (worst) {
+      vitals.inp = Math.round(worst.duration);
+      vitals.inp_target = selectorOf(worst.target);
+      vitals.inp_event = worst.name;
+    }
+    vitals.interactions = interactionCount;
+    scheduleSend();
+  }
+
+  function onPaint(entries) {
+    for (var i = 0; i < entries.length; i++) {
+      if (entries[i].name === 'first-contentful-paint') {
+        vitals.fcp = Math.round(entries[i].startTime);
+        scheduleSend();
+      }
+    }
+  }
+
+  function measureTTFB() {
+    try {
+      var nav = performance.getEntriesByType && performance.getEntriesByType('navigation')[0];
+      if (nav && nav.responseStart > 0) {
+        vitals.ttfb = Math.round(Math.max(0, nav.responseStart - (nav.activationStart || 0)));
+        return;
+      }
+      var timing = performance.timing;
+      if (timing && timing.responseStart && timing.navigationStart) {
+        vitals.ttfb = Math.max(0, timing.responseStart - timing.navigationStart);
+      }
+    } catch (e) {}
+  }
+
+  function send() {
+    if (sendTimer) {
+      clearTimeout(sendTimer);
+      sendTimer = null;
+    }
+    var data = {};
+    for (var k in vitals) {
+      if (vitals[k] !== null) data[k] = vitals[k];
+    }
+    if (data.cls !== undefined) data.cls = Math.round(data.cls * 10000) / 10000;
+    var key = JSON.stringify(data);
+    if (key === lastSent) return;
+    if (!core.send('web_vitals', data)) {
+      if (retries++ < MAX_RETRIES) scheduleSend();
+      return;
+    }
+    retries = 0;
+    lastSent = key;
+  }
+
+  function scheduleSend() {
+    if (sendTimer) return;
+    sendTimer = setTimeout(send, SEND_DELAY_MS);
+  }
+
+  measureTTFB();
+  observe('paint', onPaint);
+  observe('largest-contentful-paint', onLCP);
+  observe('layout-shift', onLayoutShift);
+  observe('event', onEvent, { durationThreshold: 40 });
+  observe('first-input', onEvent);
+  scheduleSend();
+
+  // Vitals are final when the page is hidden or unloads
+  document.addEventListener('visibilitychange', function() {
+    if (document.visibilityState === 'hidden') send();
+  });
+  window.addEventListener('pagehide', send);
+
+  window.__devtool_webvitals = {
+    /**
+     * Core Web Vitals measured so far
+     * @returns {Object} - {lcp, cls, inp, fcp, ttfb} in ms (cls unitless), with attribution
+     */
+    get: function() {
+      var copy = {};
+      for (var k in vitals) copy[k] = vitals[k];
+      return copy;
+    },
+    flush: send
+  };
+})();
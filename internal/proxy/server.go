@@ -6,9 +6,10 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"crypto/cipher"
 	"crypto/tls"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io"
@@ -34,7 +35,7 @@ import (
 // ProxyServer is a reverse proxy that logs traffic and injects instrumentation.
 type ProxyServer struct {
 	ID          string
-	TargetURL   *url.URL
+	TargetURL   *url.URL // Target at creation; Target returns the current one
 	ListenAddr  string
 	Path        string
 	BindAddress string // Bind address used (127.0.0.1 or 0.0.0.0)
@@ -78,8 +79,83 @@ type ProxyServer struct {
 	// Chaos engine for failure injection
 	chaosEngine *ChaosEngine
 
+	// Mock rules answering requests without calling the target
+	mockEngine *MockEngine
+
+	// Intercept rules that pause requests for inspection
+	interceptEngine *InterceptEngine
+
 	// Session client factory for handling session API requests from browser
 	sessionClientFactory SessionClientFactory
+
+	// Hands panel clipboard text and uploads to the terminal session
+	bridge atomic.Pointer[SessionBridge]
+
+	// Per-proxy token issued to injected pages for metrics WebSocket auth
+	sessionToken string
+	wsRejected   atomic.Int64 // WebSocket upgrades rejected by origin/token checks
+	wsDropped    atomic.Int64 // Messages dropped by size/rate limits
+
+	// Payload encryption key pair (nil when encryption is disabled)
+	payloadKeys *payloadKeyPair
+
+	// Optional access token protecting all proxy endpoints
+	accessToken atomic.Pointer[string]
+
+	// Optional credentials required of requests arriving through a tunnel
+	tunnelAuth atomic.Pointer[TunnelAuth]
+
+	// Current target after retargeting (nil until the first Retarget)
+	target           atomic.Pointer[url.URL]
+	noRetarget       atomic.Bool
+	unreachableSince atomic.Int64 // UnixNano of the first failed connection, 0 when reachable
+	retargets        []RetargetEvent
+	retargetsMu      sync.Mutex
+
+	// Host-based routes to other upstreams (see HostRoute)
+	routes []hostRoute
+
+	// Path-based routes to other upstreams (see PathRoute), longest first
+	pathRoutes   []pathRoute
+	pathRoutesMu sync.RWMutex
+
+	// Peers allowed to set forwarding headers (see clientInfo)
+	trustedProxies []*net.IPNet
+
+	// Set-Cookie rewriting (see CookieRewrite)
+	cookiePolicy   CookieRewrite
+	cookieRewrites atomic.Int64
+	cookieEvents   []CookieRewriteEvent
+	cookieEventsMu sync.Mutex
+
+	// Upstream URL rewriting (see URLRewrite)
+	urlPolicy   URLRewrite
+	urlRewrites urlRewriteCounters
+
+	// Response bytes by content type (see Traffic)
+	traffic trafficCounter
+
+	// Bursts of repeated requests (see RequestStorms)
+	storms stormDetector
+
+	// In-flight requests and DOM activity per page (see WaitForIdle)
+	activity activityTracker
+
+	// Serialized DOM snapshots of pages (see CaptureDOMSnapshot)
+	domSnapshots domSnapshotStore
+
+	// Numbers the screenshots taken by CaptureScreenshot
+	screenshotSeq atomic.Int64
+
+	// Recording in progress (see StartRecording)
+	recording atomic.Pointer[activeRecording]
+
+	// Environment banner drawn on proxied pages (see BannerState)
+	banner       EnvironmentBanner
+	bannerBranch gitBranchCache
+
+	// Language and look of the injected overlay (see SetOverlayUI)
+	overlayUI atomic.Pointer[OverlayUI]
 }
 
 // ProxyConfig holds configuration for creating a proxy server.
@@ -88,12 +164,27 @@ type ProxyConfig struct {
 	TargetURL   string
 	ListenPort  int
 	MaxLogSize  int
-	AutoRestart bool   // Enable automatic restart on crash (default: true)
-	Path        string // Working directory where proxy was created
-	BindAddress string // Bind address: "127.0.0.1" (default, localhost only) or "0.0.0.0" (all interfaces)
-	PublicURL   string // Optional public URL for tunnel services (e.g., "https://abc123.trycloudflare.com")
-	VerifyTLS   bool   // Verify TLS certificates (default: false, accepts self-signed/expired certs for dev)
-	Tunnel      *protocol.TunnelConfig
+	AutoRestart bool        // Enable automatic restart on crash (default: true)
+	Path        string      // Working directory where proxy was created
+	BindAddress string      // Bind address: "127.0.0.1" (default, localhost only) or "0.0.0.0" (all interfaces)
+	PublicURL   string      // Optional public URL for tunnel services (e.g., "https://abc123.trycloudflare.com")
+	VerifyTLS   bool        // Verify TLS certificates (default: false, accepts self-signed/expired certs for dev)
+	Encrypt     bool        // Encrypt instrumentation payloads from the injected script (for tunnel exposure)
+	AccessToken string      // Optional token required to access the proxy (see SetAccessToken)
+	NoRetarget  bool        // Disable automatic retargeting when the dev server moves to a new port
+	Routes      []HostRoute // Send matching Hosts (e.g. "*.localhost") to other upstreams
+	PathRoutes  []PathRoute // Send matching paths (e.g. "/api") to other upstreams
+	// TrustedProxies lists IPs/CIDRs whose forwarding headers are honored
+	// (nil: loopback, where tunnel agents connect from; "none": no proxy)
+	TrustedProxies []string
+	Cookies        CookieRewrite     // Set-Cookie domain, Secure and SameSite rewriting
+	URLRewrite     URLRewrite        // Location and body URL rewriting options
+	Storms         StormDetection    // Request storm (N+1, refetch loop) detection
+	Banner         EnvironmentBanner // Environment banner drawn on proxied pages
+	UI             OverlayUI         // Language and theme of the injected overlay
+	BodyCapture    BodyCapture       // Request/response bodies and headers kept in the traffic log
+	PersistLogs    LogPersistence    // Disk copy of the traffic log under the project
+	Tunnel         *protocol.TunnelConfig
 }
 
 // DefaultPortForURL computes a stable default port based on the target URL.
@@ -159,11 +250,76 @@ func NewProxyServer(config ProxyConfig) (*ProxyServer, error) {
 		restarts:        make([]time.Time, 0, 5),
 		overlayNotifier: NewOverlayNotifier(),
 		chaosEngine:     NewChaosEngine(logger),
-		wsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow all origins for development
-			},
-		},
+		mockEngine:      NewMockEngine(),
+		interceptEngine: NewInterceptEngine(),
+		sessionToken:    generateSessionToken(),
+	}
+	ps.wsUpgrader = websocket.Upgrader{
+		CheckOrigin: ps.checkWebSocketOrigin,
+	}
+
+	if config.AccessToken != "" {
+		ps.SetAccessToken(config.AccessToken)
+	}
+	ps.SetAutoRetarget(!config.NoRetarget)
+
+	if len(config.Routes) > 0 {
+		routes, err := compileRoutes(config.Routes, targetURL)
+		if err != nil {
+			return nil, err
+		}
+		ps.routes = routes
+	}
+	if len(config.PathRoutes) > 0 {
+		routes, err := compilePathRoutes(config.PathRoutes, targetURL)
+		if err != nil {
+			return nil, err
+		}
+		ps.pathRoutes = routes
+	}
+	trusted, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	ps.trustedProxies = trusted
+	if err := config.Cookies.Validate(); err != nil {
+		return nil, err
+	}
+	ps.cookiePolicy = config.Cookies
+	ps.urlPolicy = config.URLRewrite
+	ps.storms.config = config.Storms
+	if err := config.Banner.Validate(); err != nil {
+		return nil, err
+	}
+	ps.banner = config.Banner
+	if !config.UI.IsZero() {
+		if err := config.UI.Validate(); err != nil {
+			return nil, err
+		}
+		ps.overlayUI.Store(&config.UI)
+	}
+	if err := config.BodyCapture.Validate(); err != nil {
+		return nil, err
+	}
+	logger.SetBodyCapture(config.BodyCapture)
+	if config.PersistLogs.Enabled {
+		dir := config.PersistLogs.Dir
+		if dir == "" {
+			dir = DefaultLogDir(config.Path, config.ID)
+		}
+		if err := logger.Persist(dir, config.PersistLogs); err != nil {
+			return nil, err
+		}
+	}
+	ps.chaosEngine.onChange = func() { ps.BroadcastBanner() }
+	ps.chaosEngine.pageSessionOf = ps.pageTracker.findSessionByBrowserSession
+
+	if config.Encrypt {
+		keys, err := newPayloadKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		ps.payloadKeys = keys
 	}
 
 	// Create reverse proxy with custom Director for proper Host handling
@@ -227,29 +383,30 @@ func NewProxyServer(config ProxyConfig) (*ProxyServer, error) {
 		// Capture original Host BEFORE director modifies it
 		// This is the proxy's host (e.g., localhost:8080)
 		originalHost := req.Host
+		originalPath := req.URL.Path
 
 		// Call original director (sets URL, Host to target, etc.)
 		originalDirector(req)
 
-		// Ensure Host header matches target (critical for WordPress and other apps)
-		req.Host = targetURL.Host
-
-		// Add/update X-Forwarded headers for applications that need them
-		// These help apps know the original request came through a proxy
-		if clientIP, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
-			if prior := req.Header.Get("X-Forwarded-For"); prior != "" {
-				req.Header.Set("X-Forwarded-For", prior+", "+clientIP)
-			} else {
-				req.Header.Set("X-Forwarded-For", clientIP)
+		if !ps.applyPathRoute(req, originalPath) {
+			// Follow the current target, which moves when the proxy is retargeted
+			target := ps.Target()
+			upstreamHost := target.Host
+			if route := ps.routeFor(originalHost); route != nil {
+				target = route.target
+				upstreamHost = route.hostHeader(originalHost)
 			}
-		}
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
 
-		// Set X-Forwarded-Host to the proxy's host (original request host)
-		// This tells backend apps the host the client originally connected to
-		req.Header.Set("X-Forwarded-Host", originalHost)
+			// Ensure Host header matches target (critical for WordPress and other apps)
+			req.Host = upstreamHost
+		}
 
-		// Set protocol - proxy is HTTP
-		req.Header.Set("X-Forwarded-Proto", "http")
+		// Add/update X-Forwarded headers for applications that need them.
+		// Behind a trusted tunnel these carry the browser's address, protocol
+		// and host; the reverse proxy appends the peer to X-Forwarded-For.
+		ps.setForwardedHeaders(req, ps.clientInfo(req))
 
 		// Filter Accept-Encoding to only include formats we can decompress
 		// This prevents the backend from sending unsupported formats
@@ -331,7 +488,7 @@ func (ps *ProxyServer) Start(ctx context.Context) error {
 
 	ps.httpServer = &http.Server{
 		Addr:    ps.ListenAddr,
-		Handler: mux,
+		Handler: ps.accessGuard(mux),
 		BaseContext: func(l net.Listener) context.Context {
 			return ctx
 		},
@@ -474,6 +631,7 @@ func (ps *ProxyServer) Stop(ctx context.Context) error {
 	if ps.tunnel != nil {
 		ps.tunnel.Stop()
 	}
+	ps.chaosEngine.StopScenario()
 
 	if ps.cancelFunc != nil {
 		ps.cancelFunc()
@@ -481,6 +639,7 @@ func (ps *ProxyServer) Stop(ctx context.Context) error {
 
 	err := ps.httpServer.Shutdown(ctx)
 	ps.running.Store(false)
+	ps.logger.Close()
 	return err
 }
 
@@ -542,6 +701,7 @@ func (ps *ProxyServer) SetOverlayEndpoint(endpoint string) {
 // Example: "https://abc123.trycloudflare.com"
 func (ps *ProxyServer) SetPublicURL(publicURL string) {
 	ps.PublicURL = publicURL
+	ps.BroadcastBanner()
 }
 
 // SetSessionClientFactory sets the factory for creating session clients.
@@ -559,7 +719,7 @@ func (ps *ProxyServer) OverlayNotifier() *OverlayNotifier {
 func (ps *ProxyServer) Stats() ProxyStats {
 	stats := ProxyStats{
 		ID:            ps.ID,
-		TargetURL:     ps.TargetURL.String(),
+		TargetURL:     ps.Target().String(),
 		ListenAddr:    ps.ListenAddr,
 		Path:          ps.Path,
 		BindAddress:   ps.BindAddress,
@@ -569,7 +729,21 @@ func (ps *ProxyServer) Stats() ProxyStats {
 		TotalRequests: ps.requestSeq.Load(),
 		LoggerStats:   ps.logger.Stats(),
 		AutoRestart:   ps.autoRestart,
-	}
+		Encrypted:     ps.payloadKeys != nil,
+		Protected:     ps.AccessToken() != "",
+		TunnelAuth:    ps.TunnelAuth().Mode(),
+		WSRejected:    ps.wsRejected.Load(),
+		WSDropped:     ps.wsDropped.Load(),
+		AutoRetarget:  ps.AutoRetarget(),
+		Retargets:     ps.Retargets(),
+		Routes:        ps.Routes(),
+		PathRoutes:    ps.PathRoutes(),
+		URLRewrites:   ps.URLRewrites(),
+		Traffic:       ps.Traffic(),
+		RequestStorms: ps.RequestStorms(),
+	}
+
+	stats.CookieRewrites, stats.CookieChanges = ps.CookieRewrites()
 
 	// Include last error if server crashed
 	if errVal := ps.lastError.Load(); errVal != nil {
@@ -594,19 +768,33 @@ func (ps *ProxyServer) Stats() ProxyStats {
 
 // ProxyStats holds proxy statistics.
 type ProxyStats struct {
-	ID            string        `json:"id"`
-	TargetURL     string        `json:"target_url"`
-	ListenAddr    string        `json:"listen_addr"`
-	Path          string        `json:"path,omitempty"`         // Working directory where proxy was created
-	BindAddress   string        `json:"bind_address,omitempty"` // Bind address (127.0.0.1 or 0.0.0.0)
-	PublicURL     string        `json:"public_url,omitempty"`   // Public URL for tunnels
-	Running       bool          `json:"running"`
-	Uptime        time.Duration `json:"uptime"`
-	TotalRequests int64         `json:"total_requests"`
-	LoggerStats   LoggerStats   `json:"logger_stats"`
-	LastError     string        `json:"last_error,omitempty"` // Set if server crashed
-	RestartCount  int           `json:"restart_count"`        // Number of restarts in current window
-	AutoRestart   bool          `json:"auto_restart"`         // Whether auto-restart is enabled
+	ID             string               `json:"id"`
+	TargetURL      string               `json:"target_url"`
+	ListenAddr     string               `json:"listen_addr"`
+	Path           string               `json:"path,omitempty"`         // Working directory where proxy was created
+	BindAddress    string               `json:"bind_address,omitempty"` // Bind address (127.0.0.1 or 0.0.0.0)
+	PublicURL      string               `json:"public_url,omitempty"`   // Public URL for tunnels
+	Running        bool                 `json:"running"`
+	Uptime         time.Duration        `json:"uptime"`
+	TotalRequests  int64                `json:"total_requests"`
+	LoggerStats    LoggerStats          `json:"logger_stats"`
+	LastError      string               `json:"last_error,omitempty"`      // Set if server crashed
+	RestartCount   int                  `json:"restart_count"`             // Number of restarts in current window
+	AutoRestart    bool                 `json:"auto_restart"`              // Whether auto-restart is enabled
+	Encrypted      bool                 `json:"encrypted,omitempty"`       // Instrumentation payloads are encrypted
+	Protected      bool                 `json:"protected,omitempty"`       // Access token required
+	TunnelAuth     string               `json:"tunnel_auth,omitempty"`     // Credentials required through the tunnel: token, basic or token+basic
+	WSRejected     int64                `json:"ws_rejected,omitempty"`     // Metrics WebSocket upgrades rejected (origin/token)
+	WSDropped      int64                `json:"ws_dropped,omitempty"`      // Metrics messages dropped (size/rate limits)
+	AutoRetarget   bool                 `json:"auto_retarget"`             // Whether the daemon may follow the dev server to a new port
+	Retargets      []RetargetEvent      `json:"retargets,omitempty"`       // Recent target changes
+	Routes         []HostRoute          `json:"routes,omitempty"`          // Host-based routes to other upstreams
+	PathRoutes     []PathRoute          `json:"path_routes,omitempty"`     // Path-based routes to other upstreams
+	CookieRewrites int64                `json:"cookie_rewrites,omitempty"` // Set-Cookie headers rewritten
+	CookieChanges  []CookieRewriteEvent `json:"cookie_changes,omitempty"`  // Recent cookie rewrites
+	URLRewrites    URLRewriteStats      `json:"url_rewrites"`              // Upstream URLs rewritten to the proxy
+	Traffic        TrafficStats         `json:"traffic"`                   // Response bytes by content type
+	RequestStorms  []RequestStorm       `json:"request_storms,omitempty"`  // Bursts of repeated requests
 }
 
 // handleProxy handles HTTP requests and logs traffic.
@@ -619,6 +807,15 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 	isWebSocket := strings.ToLower(r.Header.Get("Upgrade")) == "websocket" &&
 		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 
+	// Intercepted requests wait for the agent, who may edit or reject them
+	if !isWebSocket {
+		if rule, ok := ps.interceptEngine.Match(r.Method, r.URL.String()); ok && !ps.interceptRequest(w, r, rule, reqID) {
+			return
+		}
+	}
+
+	client := ps.clientInfo(r)
+
 	// Capture request
 	reqHeaders := make(map[string]string)
 	for k, v := range r.Header {
@@ -626,13 +823,10 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var reqBody string
-	if !isWebSocket && r.Body != nil && r.ContentLength > 0 && r.ContentLength < 10*1024 { // Limit to 10KB
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err == nil {
-			reqBody = string(bodyBytes)
-			// Restore body for proxy
-			r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-		}
+	capture := ps.logger.BodyCapture()
+	if !isWebSocket && r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 && capture.captures(r.Header.Get("Content-Type")) {
+		// Read what the log keeps; the proxy replays it ahead of the rest
+		reqBody, r.Body = peekBody(r.Body, capture.maxRequestBytes())
 	}
 
 	// For WebSocket upgrades, proxy directly without response recording
@@ -644,6 +838,8 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 			Method:         r.Method,
 			URL:            r.URL.String(),
 			RequestHeaders: reqHeaders,
+			ClientIP:       client.IP,
+			Protocol:       client.Proto,
 			StatusCode:     http.StatusSwitchingProtocols,
 			Duration:       0,
 		})
@@ -653,6 +849,21 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Mocked endpoints answer without calling the backend
+	if rule, ok := ps.mockEngine.Match(r.Method, r.URL.String()); ok {
+		ps.serveMock(w, r, rule, HTTPLogEntry{
+			ID:             reqID,
+			Timestamp:      startTime,
+			Method:         r.Method,
+			URL:            r.URL.String(),
+			RequestHeaders: reqHeaders,
+			RequestBody:    reqBody,
+			ClientIP:       client.IP,
+			Protocol:       client.Proto,
+		})
+		return
+	}
+
 	// Check for chaos rules that apply to this request
 	chaosRules := ps.chaosEngine.MatchingRules(r)
 
@@ -667,17 +878,21 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(errorMsg))
 
 		// Log the chaos-injected error
-		ps.logger.LogHTTP(HTTPLogEntry{
+		chaosEntry := HTTPLogEntry{
 			ID:             reqID,
 			Timestamp:      startTime,
 			Method:         r.Method,
 			URL:            r.URL.String(),
 			RequestHeaders: reqHeaders,
 			RequestBody:    reqBody,
+			ClientIP:       client.IP,
+			Protocol:       client.Proto,
 			StatusCode:     errorCode,
 			ResponseBody:   errorMsg,
 			Duration:       time.Since(startTime),
-		})
+		}
+		ps.logger.LogHTTP(chaosEntry)
+		ps.noteReplay(r, chaosEntry)
 		return
 	}
 
@@ -688,14 +903,20 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 		body:           &bytes.Buffer{},
 	}
 
-	// Wrap with chaos writers if needed
-	var chaosWriter http.ResponseWriter = recorder
+	// Wrap the client connection with chaos writers if needed; the recorder
+	// logs the full body ahead of them
+	var chaosWriter http.ResponseWriter = w
 
 	// Slow-drip chaos - stream bytes slowly
 	if bytesPerMs, chunkSize := ps.chaosEngine.GetSlowDripConfig(chaosRules); bytesPerMs > 0 {
 		chaosWriter = NewSlowDripWriter(chaosWriter, bytesPerMs, chunkSize, r.Context())
 	}
 
+	// Bandwidth chaos - hold the whole response to a kbps rate
+	if kbps := ps.chaosEngine.GetBandwidthLimit(chaosRules); kbps > 0 {
+		chaosWriter = NewBandwidthLimitWriter(chaosWriter, kbps, r.Context())
+	}
+
 	// Connection drop chaos - drop connection mid-response
 	if afterPercent, afterBytes := ps.chaosEngine.GetDropConfig(chaosRules); afterPercent > 0 || afterBytes > 0 {
 		// We need to estimate content length for percentage-based drops
@@ -704,6 +925,12 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 		chaosWriter = NewConnectionDropWriter(chaosWriter, afterPercent, afterBytes, expectedSize)
 	}
 
+	// Connection reset chaos - abort with a TCP RST mid-response
+	if afterPercent, afterBytes := ps.chaosEngine.GetResetConfig(chaosRules); afterPercent > 0 || afterBytes > 0 {
+		expectedSize := int64(10 * 1024) // Default 10KB estimate
+		chaosWriter = NewConnectionResetWriter(chaosWriter, afterPercent, afterBytes, expectedSize)
+	}
+
 	// Truncation chaos - truncate response body
 	if truncatePercent := ps.chaosEngine.GetTruncateConfig(chaosRules); truncatePercent > 0 {
 		expectedSize := int64(10 * 1024) // Default 10KB estimate
@@ -711,14 +938,17 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update recorder to use chaos writer for actual writes
-	if chaosWriter != recorder {
-		recorder.ResponseWriter = chaosWriter
-	}
+	recorder.ResponseWriter = chaosWriter
 
-	// Proxy the request
+	// Proxy the request, counting it as page activity while in flight
+	timer := &requestTimer{}
+	r = r.WithContext(withRequestTimer(r.Context(), timer))
+	done := ps.trackInflight(r, reqHeaders)
 	ps.proxy.ServeHTTP(recorder, r)
+	done()
 
 	duration := time.Since(startTime)
+	ps.traffic.record(r.Method, r.URL.Path, recorder.Header().Get("Content-Type"), int64(recorder.body.Len()))
 
 	// Capture response
 	respHeaders := make(map[string]string)
@@ -726,11 +956,6 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 		respHeaders[k] = strings.Join(v, ", ")
 	}
 
-	respBody := recorder.body.String()
-	if len(respBody) > 10*1024 { // Truncate large responses
-		respBody = respBody[:10*1024] + "... [truncated]"
-	}
-
 	// Log the HTTP transaction
 	httpEntry := HTTPLogEntry{
 		ID:              reqID,
@@ -739,25 +964,56 @@ func (ps *ProxyServer) handleProxy(w http.ResponseWriter, r *http.Request) {
 		URL:             r.URL.String(),
 		RequestHeaders:  reqHeaders,
 		RequestBody:     reqBody,
+		ClientIP:        client.IP,
+		Protocol:        client.Proto,
 		StatusCode:      recorder.statusCode,
 		ResponseHeaders: respHeaders,
-		ResponseBody:    respBody,
+		ResponseBody:    recorder.body.String(),
 		Duration:        duration,
+		Timing:          timer.timing(duration),
 	}
+	// Page sessions keep entries too; the logger also masks credentials
+	capture.trim(&httpEntry)
 	ps.logger.LogHTTP(httpEntry)
+	ps.noteReplay(r, httpEntry)
 
 	// Track page session
 	ps.pageTracker.TrackHTTPRequest(httpEntry)
+	ps.recordNavigation(httpEntry)
+	ps.detectStorm(httpEntry)
 }
 
 // modifyResponse rewrites URLs and injects JavaScript into HTML responses.
 func (ps *ProxyServer) modifyResponse(resp *http.Response) error {
+	ps.markTargetReachable()
+
 	// Rewrite Location header for redirects
 	ps.rewriteLocationHeader(resp)
 
 	// Rewrite Set-Cookie headers for domain/path
 	ps.rewriteSetCookieHeaders(resp)
 
+	// Log the frames of upgraded WebSocket connections
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		ps.tapWebSocket(resp)
+		return nil
+	}
+
+	// Log the events of Server-Sent Event streams
+	if isEventStream(resp) {
+		ps.tapSSE(resp)
+		return nil
+	}
+
+	// Time the upstream body, and the injection once it's read
+	var timer *requestTimer
+	if resp.Request != nil {
+		timer = requestTimerFrom(resp.Request.Context())
+	}
+	if timer != nil {
+		resp.Body = timer.wrapBody(resp.Body)
+	}
+
 	contentType := resp.Header.Get("Content-Type")
 	if !ShouldInject(contentType) {
 		return nil
@@ -806,20 +1062,19 @@ func (ps *ProxyServer) modifyResponse(resp *http.Response) error {
 		return err
 	}
 	resp.Body.Close()
-
-	// Extract port from ListenAddr (handles both :port and [::]:port formats)
-	port := 8080
-	if lastColon := strings.LastIndex(ps.ListenAddr, ":"); lastColon != -1 {
-		if p, err := strconv.Atoi(ps.ListenAddr[lastColon+1:]); err == nil {
-			port = p
-		}
+	if timer != nil {
+		timer.mark(&timer.injectStart)
+		defer timer.mark(&timer.injectDone)
 	}
 
 	// Rewrite absolute URLs in HTML content pointing to target back to proxy
-	modifiedBody := ps.rewriteURLsInBody(bodyBytes)
+	modifiedBody := bodyBytes
+	if !ps.urlPolicy.NoBody {
+		modifiedBody = ps.rewriteURLsInBody(bodyBytes)
+	}
 
-	// Inject instrumentation
-	modifiedBody = InjectInstrumentation(modifiedBody, port)
+	// Inject instrumentation with this proxy's session token (and encryption key)
+	modifiedBody = InjectInstrumentationWithSession(modifiedBody, ps.injectionSession())
 
 	// Update response with uncompressed modified content
 	resp.Body = io.NopCloser(bytes.NewReader(modifiedBody))
@@ -832,68 +1087,6 @@ func (ps *ProxyServer) modifyResponse(resp *http.Response) error {
 	return nil
 }
 
-// rewriteLocationHeader rewrites Location headers to point to the proxy instead of the target.
-func (ps *ProxyServer) rewriteLocationHeader(resp *http.Response) {
-	location := resp.Header.Get("Location")
-	if location == "" {
-		return
-	}
-
-	rewritten := ps.rewriteURL(location)
-	if rewritten != location {
-		resp.Header.Set("Location", rewritten)
-	}
-}
-
-// rewriteSetCookieHeaders rewrites Set-Cookie headers to work with the proxy domain.
-func (ps *ProxyServer) rewriteSetCookieHeaders(resp *http.Response) {
-	cookies := resp.Header["Set-Cookie"]
-	if len(cookies) == 0 {
-		return
-	}
-
-	targetHost := ps.TargetURL.Hostname()
-
-	for i, cookie := range cookies {
-		// Remove or rewrite Domain attribute if it matches target
-		// This allows cookies to work on localhost proxy
-		if strings.Contains(strings.ToLower(cookie), "domain=") {
-			// Parse and rebuild cookie without domain restriction
-			// or with proxy domain
-			cookies[i] = ps.rewriteCookieDomain(cookie, targetHost)
-		}
-	}
-
-	resp.Header["Set-Cookie"] = cookies
-}
-
-// rewriteCookieDomain removes or rewrites the Domain attribute in a Set-Cookie header.
-func (ps *ProxyServer) rewriteCookieDomain(cookie string, targetHost string) string {
-	// Split cookie into parts
-	parts := strings.Split(cookie, ";")
-	var newParts []string
-
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		lower := strings.ToLower(trimmed)
-
-		// Skip domain attributes that match target host
-		if strings.HasPrefix(lower, "domain=") {
-			domainValue := strings.TrimPrefix(lower, "domain=")
-			domainValue = strings.TrimPrefix(domainValue, ".") // Remove leading dot
-
-			// If domain matches target, remove it entirely (allows cookie on any domain)
-			if strings.Contains(targetHost, domainValue) || strings.Contains(domainValue, targetHost) {
-				continue
-			}
-		}
-
-		newParts = append(newParts, part)
-	}
-
-	return strings.Join(newParts, ";")
-}
-
 // rewriteURL rewrites a URL from the target server to the proxy server.
 func (ps *ProxyServer) rewriteURL(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
@@ -908,7 +1101,7 @@ func (ps *ProxyServer) rewriteURL(rawURL string) string {
 	}
 
 	// Check if this URL points to our target
-	targetHost := ps.TargetURL.Host
+	targetHost := ps.Target().Host
 	if parsed.Host != targetHost {
 		// Different host, don't rewrite
 		return rawURL
@@ -938,11 +1131,7 @@ func (ps *ProxyServer) getProxyHost() string {
 
 	// ListenAddr is in format "addr:port" or "[::]:port"
 	// We need to return "localhost:port" for redirect purposes
-	port := "8080"
-	if lastColon := strings.LastIndex(ps.ListenAddr, ":"); lastColon != -1 {
-		port = ps.ListenAddr[lastColon+1:]
-	}
-	return "localhost:" + port
+	return "localhost:" + ps.listenPort()
 }
 
 // getProxyScheme returns the scheme (http/https) for the proxy server.
@@ -956,45 +1145,6 @@ func (ps *ProxyServer) getProxyScheme() string {
 	return "http"
 }
 
-// rewriteURLsInBody rewrites absolute URLs in HTML/JS content from target to proxy.
-func (ps *ProxyServer) rewriteURLsInBody(body []byte) []byte {
-	// Guard against nil TargetURL (can happen in tests with partial setup)
-	if ps.TargetURL == nil {
-		return body
-	}
-
-	targetHost := ps.TargetURL.Host
-	if targetHost == "" {
-		return body
-	}
-
-	proxyHost := ps.getProxyHost()
-	proxyScheme := ps.getProxyScheme()
-
-	// Rewrite common URL patterns pointing to target
-	// http://target:port -> scheme://proxyhost
-	// https://target:port -> scheme://proxyhost
-
-	// Build replacement patterns
-	targetHTTP := "http://" + targetHost
-	targetHTTPS := "https://" + targetHost
-	proxyURL := proxyScheme + "://" + proxyHost
-
-	// Replace URLs (simple byte replacement for performance)
-	result := bytes.ReplaceAll(body, []byte(targetHTTPS), []byte(proxyURL))
-	result = bytes.ReplaceAll(result, []byte(targetHTTP), []byte(proxyURL))
-
-	// Also handle URLs with escaped slashes (common in JSON)
-	targetHTTPEscaped := strings.ReplaceAll(targetHTTP, "/", "\\/")
-	targetHTTPSEscaped := strings.ReplaceAll(targetHTTPS, "/", "\\/")
-	proxyURLEscaped := strings.ReplaceAll(proxyURL, "/", "\\/")
-
-	result = bytes.ReplaceAll(result, []byte(targetHTTPSEscaped), []byte(proxyURLEscaped))
-	result = bytes.ReplaceAll(result, []byte(targetHTTPEscaped), []byte(proxyURLEscaped))
-
-	return result
-}
-
 // errorHandler handles proxy errors.
 func (ps *ProxyServer) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
 	seq := ps.requestSeq.Add(1)
@@ -1006,6 +1156,11 @@ func (ps *ProxyServer) errorHandler(w http.ResponseWriter, r *http.Request, err
 	// These happen when dev servers restart, connections timeout, etc.
 	isTransient := isTransientConnectionError(errStr)
 
+	if strings.Contains(errStr, "connection refused") {
+		ps.markTargetUnreachable()
+	}
+	target := ps.Target()
+
 	ps.logger.LogHTTP(HTTPLogEntry{
 		ID:         reqID,
 		Timestamp:  time.Now(),
@@ -1019,16 +1174,16 @@ func (ps *ProxyServer) errorHandler(w http.ResponseWriter, r *http.Request, err
 	var userMsg string
 
 	if strings.Contains(errStr, "context canceled") {
-		userMsg = fmt.Sprintf("Proxy Error: Request canceled. The proxy may be shutting down, or the target server (%s) is unavailable.", ps.TargetURL.String())
+		userMsg = fmt.Sprintf("Proxy Error: Request canceled. The proxy may be shutting down, or the target server (%s) is unavailable.", target.String())
 	} else if strings.Contains(errStr, "connection refused") {
-		userMsg = fmt.Sprintf("Proxy Error: Cannot connect to target server %s. Make sure the server is running.", ps.TargetURL.String())
+		userMsg = fmt.Sprintf("Proxy Error: Cannot connect to target server %s. Make sure the server is running.", target.String())
 	} else if strings.Contains(errStr, "no such host") {
-		userMsg = fmt.Sprintf("Proxy Error: Cannot resolve target host %s. Check the target URL.", ps.TargetURL.String())
+		userMsg = fmt.Sprintf("Proxy Error: Cannot resolve target host %s. Check the target URL.", target.String())
 	} else if isTransient {
 		// Friendly message for transient errors - these are normal during development
-		userMsg = fmt.Sprintf("Connection to %s was interrupted. This often happens when the dev server restarts. Refresh to retry.", ps.TargetURL.Host)
+		userMsg = fmt.Sprintf("Connection to %s was interrupted. This often happens when the dev server restarts. Refresh to retry.", target.Host)
 	} else {
-		userMsg = fmt.Sprintf("Proxy Error: %s (target: %s)", errStr, ps.TargetURL.String())
+		userMsg = fmt.Sprintf("Proxy Error: %s (target: %s)", errStr, target.String())
 	}
 
 	http.Error(w, userMsg, http.StatusBadGateway)
@@ -1067,6 +1222,12 @@ func isTransientConnectionError(errStr string) bool {
 func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	debug.Log("proxy", "WebSocket connection attempt from %s to proxy %s", r.RemoteAddr, ps.ID)
 
+	if !ps.checkSessionToken(r) {
+		debug.Log("proxy", "WebSocket rejected for proxy %s: invalid session token (origin=%s)", ps.ID, r.Header.Get("Origin"))
+		http.Error(w, "invalid session token", http.StatusForbidden)
+		return
+	}
+
 	conn, err := ps.wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		debug.Log("proxy", "WebSocket upgrade failed for proxy %s: %v", ps.ID, err)
@@ -1074,6 +1235,13 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
+	// Frames over the limit close the connection; excess message rates are dropped
+	conn.SetReadLimit(wsMaxMessageSize)
+	limiter := newWSRateLimiter(wsMessageRate, wsMessageBurst)
+
+	// Per-connection cipher, set by the key exchange when payload encryption is on
+	var payloadCipher cipher.AEAD
+
 	// Store connection for sending messages
 	connID := fmt.Sprintf("conn-%d", time.Now().UnixNano())
 	ps.wsConns.Store(connID, conn)
@@ -1084,6 +1252,15 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		debug.Log("proxy", "WebSocket client disconnected: proxy=%s connID=%s", ps.ID, connID)
 	}()
 
+	// Bring the banner up to date for pages loaded before it last changed
+	if message := ps.bannerMessage(); message != nil {
+		conn.WriteMessage(websocket.TextMessage, message)
+	}
+	// Likewise the overlay options, once they have been set
+	if ps.overlayUI.Load() != nil {
+		conn.WriteMessage(websocket.TextMessage, ps.overlayUIMessage())
+	}
+
 	// Cleanup voice session on disconnect
 	defer func() {
 		if session, ok := ps.voiceSessions.LoadAndDelete(connID); ok {
@@ -1095,11 +1272,34 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	for {
 		messageType, rawMessage, err := conn.ReadMessage()
 		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				ps.wsDropped.Add(1)
+				debug.Log("proxy", "WebSocket message too large on proxy %s connID=%s", ps.ID, connID)
+			}
 			break
 		}
 
+		if !limiter.Allow() {
+			if ps.wsDropped.Add(1)%wsMessageBurst == 1 {
+				debug.Log("proxy", "WebSocket rate limit exceeded on proxy %s connID=%s", ps.ID, connID)
+			}
+			continue
+		}
+
 		// Handle binary audio data for voice sessions
 		if messageType == websocket.BinaryMessage {
+			if ps.payloadKeys != nil {
+				if payloadCipher == nil {
+					ps.wsDropped.Add(1)
+					continue
+				}
+				plain, err := openPayload(payloadCipher, rawMessage)
+				if err != nil {
+					ps.wsDropped.Add(1)
+					continue
+				}
+				rawMessage = plain
+			}
 			if session, ok := ps.voiceSessions.Load(connID); ok {
 				session.(*VoiceSession).SendAudio(rawMessage)
 			}
@@ -1118,6 +1318,38 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		// With payload encryption enabled, only the key exchange may arrive in plaintext
+		if ps.payloadKeys != nil {
+			switch msg.Type {
+			case "key_exchange":
+				aead, err := ps.payloadKeys.DeriveCipher(getStringField(msg.Data, "public_key"))
+				if err != nil {
+					debug.Log("proxy", "Payload key exchange failed on proxy %s connID=%s: %v", ps.ID, connID, err)
+					continue
+				}
+				payloadCipher = aead
+				continue
+			case "encrypted":
+				if payloadCipher == nil {
+					ps.wsDropped.Add(1)
+					continue
+				}
+				plain, err := openPayloadBase64(payloadCipher, getStringField(msg.Data, "payload"))
+				if err != nil {
+					ps.wsDropped.Add(1)
+					debug.Log("proxy", "Payload decryption failed on proxy %s connID=%s: %v", ps.ID, connID, err)
+					continue
+				}
+				msg.Type, msg.Data, msg.URL, msg.SessionID = "", nil, "", ""
+				if err := json.Unmarshal(plain, &msg); err != nil {
+					continue
+				}
+			default:
+				ps.wsDropped.Add(1)
+				continue
+			}
+		}
+
 		seq := ps.requestSeq.Add(1)
 		id := fmt.Sprintf("metric-%d", seq)
 		timestamp := time.Now()
@@ -1138,6 +1370,9 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			ps.logger.LogError(errEntry)
 			ps.pageTracker.TrackError(errEntry, msg.SessionID)
 
+		case "console":
+			ps.logger.LogConsole(newConsoleEntry(id, timestamp, msg.URL, msg.Data))
+
 		case "performance":
 			metric := PerformanceMetric{
 				ID:                   id,
@@ -1167,6 +1402,9 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			ps.logger.LogPerformance(metric)
 			ps.pageTracker.TrackPerformance(metric, msg.SessionID)
 
+		case "web_vitals":
+			ps.pageTracker.TrackWebVitals(parseWebVitals(msg.Data, msg.URL, timestamp), msg.SessionID)
+
 		case "custom_log":
 			ps.logger.LogCustom(CustomLog{
 				ID:        id,
@@ -1264,6 +1502,13 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 					interaction := parseInteractionEvent(em, id, timestamp, msg.URL)
 					ps.logger.LogInteraction(interaction)
 					ps.pageTracker.TrackInteraction(interaction, msg.SessionID)
+					if ps.recording.Load() != nil {
+						var clientTime time.Time
+						if ms := getIntField(em, "timestamp"); ms > 0 {
+							clientTime = time.UnixMilli(int64(ms))
+						}
+						ps.recordInteraction(ps.pageTracker.ResolveSession(msg.SessionID, msg.URL), interaction, clientTime)
+					}
 				}
 			}
 
@@ -1277,6 +1522,9 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 					ps.pageTracker.TrackMutation(mutation, msg.SessionID)
 				}
 			}
+			if len(events) > 0 {
+				ps.activity.mutated(ps.pageTracker.ResolveSession(msg.SessionID, msg.URL), time.Now())
+			}
 
 		case "panel_message":
 			// Handle message from floating indicator panel
@@ -1297,6 +1545,7 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 			}
+			panelMsg.linkResources(ps.ID)
 
 			ps.logger.LogPanelMessage(panelMsg)
 
@@ -1384,6 +1633,18 @@ func (ps *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 				_ = ps.overlayNotifier.NotifyDesignChat(ps.ID, &designChat)
 			}
 
+		case "clipboard":
+			// Text copied in the floating panel, for the terminal session
+			go ps.handleClipboard(conn, msg.Data, msg.URL)
+
+		case "toast_action":
+			// Button clicked on a toast
+			go ps.handleToastAction(msg.Data, msg.URL)
+
+		case "upload":
+			// File dropped on the floating panel, stored under the project
+			go ps.handleUpload(conn, msg.Data, msg.URL)
+
 		case "session_request":
 			// Handle session API requests from browser
 			go ps.handleSessionRequest(conn, msg.Data)
@@ -1789,6 +2050,14 @@ func (rr *responseRecorder) Write(b []byte) (int, error) {
 	return rr.ResponseWriter.Write(b)
 }
 
+// Flush implements http.Flusher so streamed responses, such as Server-Sent
+// Events, reach the browser as they arrive.
+func (rr *responseRecorder) Flush() {
+	if flusher, ok := rr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // Hijack implements http.Hijacker for WebSocket support.
 func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	hijacker, ok := rr.ResponseWriter.(http.Hijacker)
@@ -1865,49 +2134,11 @@ func getFloatField(data map[string]interface{}, key string) float64 {
 // The file is stored in the project's .agnt/audit folder for easy access by AI agents.
 func (ps *ProxyServer) saveScreenshot(name string, dataURL string) (string, error) {
 	// Parse data URL (format: data:image/png;base64,...)
-	if !strings.HasPrefix(dataURL, "data:") {
-		return "", fmt.Errorf("invalid data URL")
-	}
-
-	// Find base64 data after comma
-	commaIdx := strings.Index(dataURL, ",")
-	if commaIdx == -1 {
-		return "", fmt.Errorf("invalid data URL format")
-	}
-
-	// Decode base64 data
-	base64Data := dataURL[commaIdx+1:]
-	imageData, err := base64.StdEncoding.DecodeString(base64Data)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode base64: %w", err)
-	}
-
-	// Get audit directory (.agnt/audit)
-	auditDir, err := GetAuditDir()
+	imageData, err := decodeDataURL(dataURL)
 	if err != nil {
-		// Fallback to temp dir if audit directory unavailable
-		auditDir = os.TempDir()
+		return "", err
 	}
-
-	// Create screenshots subdirectory for better organization
-	screenshotDir := filepath.Join(auditDir, "screenshots")
-	if err := os.MkdirAll(screenshotDir, 0755); err != nil {
-		// Fallback to audit dir root if subdirectory creation fails
-		screenshotDir = auditDir
-	}
-
-	// Sanitize filename
-	safeName := sanitizeFilename(name)
-	filename := fmt.Sprintf("screenshot-%s-%s.png", ps.ID, safeName)
-	filePath := filepath.Join(screenshotDir, filename)
-
-	// Write to file
-	err = os.WriteFile(filePath, imageData, 0644)
-	if err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return filePath, nil
+	return ps.writeScreenshot(name, "png", imageData)
 }
 
 // LargeResultThreshold is the size in bytes above which results are saved to file.
@@ -1935,7 +2166,13 @@ func (ps *ProxyServer) saveLargeResult(execID string, result string) (string, er
 // ExecuteJavaScript sends JavaScript code to all connected clients for execution.
 // Returns the execution ID and a channel that will receive the result.
 func (ps *ProxyServer) ExecuteJavaScript(code string) (string, <-chan *ExecutionResult, error) {
-	debug.Log("proxy", "ExecuteJavaScript: proxy=%s code_len=%d", ps.ID, len(code))
+	return ps.executeJavaScript(code, "")
+}
+
+// executeJavaScript sends code for execution. A non-empty browserSession
+// limits it to the tab with that session ID; other tabs ignore it.
+func (ps *ProxyServer) executeJavaScript(code, browserSession string) (string, <-chan *ExecutionResult, error) {
+	debug.Log("proxy", "ExecuteJavaScript: proxy=%s code_len=%d session=%s", ps.ID, len(code), browserSession)
 	execID := fmt.Sprintf("exec-%d", time.Now().UnixNano())
 
 	// Create result channel for this execution
@@ -1947,6 +2184,9 @@ func (ps *ProxyServer) ExecuteJavaScript(code string) (string, <-chan *Execution
 		"id":   execID,
 		"code": code,
 	}
+	if browserSession != "" {
+		message["session"] = browserSession
+	}
 
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
@@ -1976,6 +2216,55 @@ func (ps *ProxyServer) ExecuteJavaScript(code string) (string, <-chan *Execution
 	return execID, resultChan, nil
 }
 
+// evalInPage runs code in a page session, or in whichever connected page
+// answers first when sessionID is empty, and waits for its result. Results
+// too large to inline are read back from their file. It also returns the
+// URL of the page that answered.
+func (ps *ProxyServer) evalInPage(ctx context.Context, sessionID, code string, timeout time.Duration) ([]byte, string, error) {
+	browserSession := ""
+	if sessionID != "" {
+		session, ok := ps.pageTracker.GetSession(sessionID)
+		if !ok {
+			return nil, "", fmt.Errorf("session not found: %s", sessionID)
+		}
+		if session.BrowserSession == "" {
+			return nil, "", fmt.Errorf("page session %s has no browser session yet; reload the page", sessionID)
+		}
+		browserSession = session.BrowserSession
+	}
+
+	execID, results, err := ps.executeJavaScript(code, browserSession)
+	if err != nil {
+		return nil, "", err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var result *ExecutionResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		ps.pendingExecs.Delete(execID)
+		return nil, "", ctx.Err()
+	case <-timer.C:
+		ps.pendingExecs.Delete(execID)
+		return nil, "", fmt.Errorf("page did not answer within %s", timeout)
+	}
+	if result == nil {
+		return nil, "", fmt.Errorf("execution channel closed")
+	}
+	if result.Error != "" {
+		return nil, "", fmt.Errorf("failed in the page: %s", result.Error)
+	}
+
+	raw := []byte(result.Result)
+	if result.FilePath != "" {
+		if raw, err = os.ReadFile(result.FilePath); err != nil {
+			return nil, "", fmt.Errorf("failed to read result: %w", err)
+		}
+	}
+	return raw, result.URL, nil
+}
+
 // BroadcastActivityState sends an activity state update to all connected browser clients.
 // Returns the number of clients that received the update.
 func (ps *ProxyServer) BroadcastActivityState(active bool) int {
@@ -2007,58 +2296,7 @@ func (ps *ProxyServer) BroadcastActivityState(active bool) int {
 // BroadcastToast sends a toast notification to all connected browser clients.
 // Returns the number of clients that received the toast.
 func (ps *ProxyServer) BroadcastToast(toastType, title, message string, duration int) (int, error) {
-	debug.Log("proxy", "BroadcastToast called: proxy=%s type=%s title=%q message=%q", ps.ID, toastType, title, message)
-
-	// Count connected clients first for debugging
-	connCount := 0
-	ps.wsConns.Range(func(key, value interface{}) bool {
-		connCount++
-		return true
-	})
-	debug.Log("proxy", "BroadcastToast: %d WebSocket clients connected to proxy %s", connCount, ps.ID)
-
-	// Build toast message
-	toast := map[string]interface{}{
-		"type": "toast",
-		"payload": map[string]interface{}{
-			"type":    toastType,
-			"title":   title,
-			"message": message,
-		},
-	}
-
-	// Only include duration if non-zero
-	if duration > 0 {
-		toast["payload"].(map[string]interface{})["duration"] = duration
-	}
-
-	messageBytes, err := json.Marshal(toast)
-	if err != nil {
-		debug.Error("proxy", "BroadcastToast: failed to marshal: %v", err)
-		return 0, fmt.Errorf("failed to marshal toast: %w", err)
-	}
-
-	// Send to all connected clients
-	sentCount := 0
-	failCount := 0
-	ps.wsConns.Range(func(key, value interface{}) bool {
-		conn := value.(*websocket.Conn)
-		err := conn.WriteMessage(websocket.TextMessage, messageBytes)
-		if err == nil {
-			sentCount++
-			debug.Log("proxy", "BroadcastToast: sent to client %v", key)
-		} else {
-			failCount++
-			debug.Log("proxy", "BroadcastToast: failed to send to client %v: %v", key, err)
-		}
-		return true
-	})
-
-	debug.Log("proxy", "BroadcastToast: sent=%d failed=%d total=%d", sentCount, failCount, connCount)
-
-	// Return success even with no clients - caller can check sent_count
-	// This makes toast a "best effort" operation that doesn't fail builds/workflows
-	return sentCount, nil
+	return ps.SendToast(Toast{Type: toastType, Title: title, Message: message, Duration: duration})
 }
 
 // BroadcastOutputPreview sends output preview lines to all connected browser clients.
@@ -2161,15 +2399,72 @@ func parseInteractionEvent(data map[string]interface{}, id string, timestamp tim
 
 	// Parse value (for input events)
 	event.Value = getStringField(data, "value")
+	event.Masked = getBoolField(data, "masked")
+	event.MaskReason = getStringField(data, "mask_reason")
 
 	// Parse extra data
 	if extraData, ok := data["data"].(map[string]interface{}); ok {
 		event.Data = extraData
 	}
 
+	maskSensitiveInteraction(&event)
+
 	return event
 }
 
+// maskedValue replaces sensitive input values in interaction entries.
+const maskedValue = "[masked]"
+
+// sensitiveAutocompleteHints are autocomplete tokens that mark credential or payment fields.
+var sensitiveAutocompleteHints = map[string]bool{
+	"current-password": true,
+	"new-password":     true,
+	"one-time-code":    true,
+	"cc-number":        true,
+	"cc-csc":           true,
+	"cc-exp":           true,
+	"cc-exp-month":     true,
+	"cc-exp-year":      true,
+	"cc-name":          true,
+	"cc-given-name":    true,
+	"cc-family-name":   true,
+	"cc-type":          true,
+}
+
+// maskSensitiveInteraction enforces value masking server-side.
+// The injected script masks at capture time; this catches pages running an
+// older cached script that still sends raw values for sensitive fields.
+func maskSensitiveInteraction(event *InteractionEvent) {
+	if !event.Masked {
+		attrs := event.Target.Attributes
+		if strings.EqualFold(attrs["type"], "password") {
+			event.Masked = true
+			event.MaskReason = "password"
+		} else {
+			for _, token := range strings.Fields(strings.ToLower(attrs["autocomplete"])) {
+				if sensitiveAutocompleteHints[token] {
+					event.Masked = true
+					event.MaskReason = "autocomplete:" + token
+					break
+				}
+			}
+		}
+	}
+
+	if !event.Masked {
+		return
+	}
+
+	if event.Value != "" {
+		event.Value = maskedValue
+	}
+	if event.Key != nil && len([]rune(event.Key.Key)) == 1 {
+		event.Key.Key = maskedValue
+		event.Key.Code = ""
+	}
+	event.Target.Text = ""
+}
+
 // parseMutationEvent parses a mutation event from JSON data.
 func parseMutationEvent(data map[string]interface{}, id string, timestamp time.Time, url string) MutationEvent {
 	event := MutationEvent{
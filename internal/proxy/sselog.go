@@ -0,0 +1,223 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxSSEPreview is the data bytes kept per Server-Sent Event.
+	maxSSEPreview = 512
+	// maxSSELine is the bytes of a line kept for parsing; the rest of a
+	// longer line is only counted.
+	maxSSELine = maxSSEPreview + 64
+)
+
+// ServerSentEvent is one event on a proxied text/event-stream response,
+// logged when the blank line ending it arrives.
+type ServerSentEvent struct {
+	ID          string    `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	StreamID    string    `json:"stream_id"`
+	URL         string    `json:"url"`
+	Event       string    `json:"event"`              // Event name; "message" when unnamed
+	EventID     string    `json:"event_id,omitempty"` // The stream's id: field
+	DataSize    int64     `json:"data_size"`          // Bytes of data, lines joined by newlines
+	Preview     string    `json:"preview,omitempty"`
+	Truncated   bool      `json:"truncated,omitempty"`
+	Retry       int       `json:"retry,omitempty"` // Reconnection time set by the stream, in ms
+	SinceOpenMs int64     `json:"since_open_ms"`   // Time since the response started
+}
+
+// sseStreamSeq numbers proxied event streams.
+var sseStreamSeq atomic.Int64
+
+// isEventStream reports whether resp is an uncompressed Server-Sent Events
+// stream.
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		return false
+	}
+	encoding := strings.ToLower(resp.Header.Get("Content-Encoding"))
+	return encoding == "" || encoding == "identity"
+}
+
+// tapSSE logs the events of a Server-Sent Events response as they pass
+// through, without changing the stream.
+func (ps *ProxyServer) tapSSE(resp *http.Response) {
+	url := ""
+	if resp.Request != nil {
+		url = resp.Request.URL.RequestURI()
+	}
+	resp.Body = &sseTap{
+		ReadCloser: resp.Body,
+		parser: &sseParser{
+			logger: ps.logger,
+			id:     fmt.Sprintf("sse-%d", sseStreamSeq.Add(1)),
+			url:    url,
+			opened: time.Now(),
+		},
+	}
+}
+
+// sseTap parses the events read from an event stream.
+type sseTap struct {
+	io.ReadCloser
+	parser *sseParser
+}
+
+func (t *sseTap) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		t.parser.feed(p[:n])
+	}
+	return n, err
+}
+
+// sseParser incrementally parses an event stream (HTML Living Standard,
+// "Parsing an event stream").
+type sseParser struct {
+	logger *TrafficLogger
+	id     string
+	url    string
+	opened time.Time
+	seq    int64
+
+	mu       sync.Mutex
+	line     []byte // Kept bytes of the current line
+	lineLen  int64  // Bytes of the current line, kept or not
+	afterCR  bool   // Last byte was CR; a following LF ends the same line
+	started  bool   // Past the optional byte order mark
+	event    string
+	eventID  string
+	retry    int
+	hasData  bool
+	dataSize int64
+	preview  []byte
+}
+
+func (p *sseParser) feed(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.started && len(data) > 0 {
+		p.started = true
+		data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	}
+	for len(data) > 0 {
+		if p.afterCR && data[0] == '\n' {
+			data = data[1:]
+		}
+		p.afterCR = false
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			p.appendLine(data)
+			return
+		}
+		p.appendLine(data[:i])
+		p.afterCR = data[i] == '\r'
+		data = data[i+1:]
+		p.endLine()
+	}
+}
+
+// appendLine adds bytes to the current line, keeping up to maxSSELine.
+func (p *sseParser) appendLine(b []byte) {
+	if keep := min(maxSSELine-len(p.line), len(b)); keep > 0 {
+		p.line = append(p.line, b[:keep]...)
+	}
+	p.lineLen += int64(len(b))
+}
+
+// endLine processes a complete line.
+func (p *sseParser) endLine() {
+	line, length := p.line, p.lineLen
+	p.line, p.lineLen = p.line[:0], 0
+
+	if length == 0 {
+		p.dispatch()
+		return
+	}
+	if line[0] == ':' {
+		return // Comment, often a keep-alive
+	}
+	field, value := string(line), []byte(nil)
+	valueLen := int64(0)
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		field, value = string(line[:i]), line[i+1:]
+		valueLen = length - int64(i) - 1
+		if len(value) > 0 && value[0] == ' ' {
+			value = value[1:]
+			valueLen--
+		}
+	}
+
+	switch field {
+	case "event":
+		p.event = string(value)
+	case "data":
+		if p.hasData {
+			p.dataSize++
+			p.appendPreview([]byte("\n"))
+		}
+		p.hasData = true
+		p.dataSize += valueLen
+		p.appendPreview(value)
+	case "id":
+		if !bytes.ContainsRune(value, 0) {
+			p.eventID = string(value)
+		}
+	case "retry":
+		if ms, err := strconv.Atoi(string(value)); err == nil && ms >= 0 {
+			p.retry = ms
+		}
+	}
+}
+
+func (p *sseParser) appendPreview(b []byte) {
+	if keep := min(maxSSEPreview-len(p.preview), len(b)); keep > 0 {
+		p.preview = append(p.preview, b[:keep]...)
+	}
+}
+
+// dispatch logs the pending event. Blank lines without data only reset the
+// event name, as in a browser.
+func (p *sseParser) dispatch() {
+	defer func() {
+		p.event, p.hasData, p.dataSize, p.preview, p.retry = "", false, 0, nil, 0
+	}()
+	if !p.hasData {
+		return
+	}
+
+	now := time.Now()
+	p.seq++
+	entry := ServerSentEvent{
+		ID:          fmt.Sprintf("%s-%d", p.id, p.seq),
+		Timestamp:   now,
+		StreamID:    p.id,
+		URL:         p.url,
+		Event:       p.event,
+		EventID:     p.eventID,
+		DataSize:    p.dataSize,
+		Retry:       p.retry,
+		SinceOpenMs: now.Sub(p.opened).Milliseconds(),
+	}
+	if entry.Event == "" {
+		entry.Event = "message"
+	}
+	preview, truncated := p.preview, int64(len(p.preview)) < p.dataSize
+	if truncated {
+		preview = trimPartialRune(preview)
+	}
+	entry.Preview, entry.Truncated = string(preview), truncated
+	p.logger.LogSSE(entry)
+}
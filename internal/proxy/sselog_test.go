@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSSEParser() (*sseParser, *TrafficLogger) {
+	logger := NewTrafficLogger(100)
+	return &sseParser{logger: logger, id: "sse-test", url: "/chat", opened: time.Now()}, logger
+}
+
+func sseEvents(logger *TrafficLogger) []ServerSentEvent {
+	var out []ServerSentEvent
+	for _, e := range logger.Query(LogFilter{Types: []LogEntryType{LogTypeSSE}}) {
+		out = append(out, *e.SSE)
+	}
+	return out
+}
+
+func TestSSEParser(t *testing.T) {
+	p, logger := newTestSSEParser()
+	stream := "\xEF\xBB\xBF: keep-alive\n\n" +
+		"data: hello\n\n" +
+		"event: token\r\nid: 7\r\ndata: {\"t\":\"a\"}\r\ndata:b\r\n\r\n" +
+		"retry: 3000\nevent: done\n\n" +
+		"data: " + strings.Repeat("x", 1000) + "\n\n" +
+		"data: unfinished"
+
+	// Split mid-line and between CR and LF
+	for _, chunk := range []string{stream[:10], stream[10:37], stream[37:58], stream[58:]} {
+		p.feed([]byte(chunk))
+	}
+
+	events := sseEvents(logger)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+	if e := events[0]; e.Event != "message" || e.Preview != "hello" || e.DataSize != 5 || e.ID != "sse-test-1" {
+		t.Errorf("Unexpected unnamed event %+v", e)
+	}
+	if e := events[1]; e.Event != "token" || e.EventID != "7" || e.Preview != "{\"t\":\"a\"}\nb" || e.DataSize != 11 {
+		t.Errorf("Unexpected named event %+v", e)
+	}
+	if e := events[2]; e.Event != "message" || e.EventID != "7" || e.DataSize != 1000 || !e.Truncated || len(e.Preview) != maxSSEPreview {
+		t.Errorf("Expected a truncated preview of a long event, got size=%d truncated=%v preview=%d", e.DataSize, e.Truncated, len(e.Preview))
+	}
+}
+
+func TestSSEProxyLogging(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "event: token\ndata: t%d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "sse", TargetURL: upstream.URL, ListenPort: 8080})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	req := httptest.NewRequest("GET", "/chat?stream=1", nil)
+	rec := httptest.NewRecorder()
+	ps.proxy.ServeHTTP(rec, req)
+
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), "data: t3") {
+		t.Fatalf("Expected the stream passed through unchanged, got %q", body)
+	}
+	events := sseEvents(ps.logger)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events logged, got %+v", events)
+	}
+	for i, e := range events {
+		if e.Event != "token" || e.Preview != fmt.Sprintf("t%d", i+1) || e.URL != "/chat?stream=1" || e.StreamID != events[0].StreamID {
+			t.Errorf("Unexpected event %d: %+v", i, e)
+		}
+	}
+	if got := ps.logger.Query(LogFilter{Types: []LogEntryType{LogTypeSSE}, URLPattern: "/other"}); len(got) != 0 {
+		t.Errorf("Expected the URL filter to exclude the events, got %d", len(got))
+	}
+}
@@ -0,0 +1,301 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+)
+
+// Request storm detection defaults.
+const (
+	DefaultStormThreshold = 20
+	DefaultStormWindow    = 5 * time.Second
+
+	// maxRequestStorms is the number of storms kept per proxy.
+	maxRequestStorms = 50
+	// maxStormKeys bounds the endpoints tracked before idle ones are pruned.
+	maxStormKeys = 500
+)
+
+// Kinds of request storm.
+const (
+	// StormDuplicate is the same URL requested over and over, typically a
+	// render loop refetching.
+	StormDuplicate = "duplicate"
+	// StormNPlusOne is one endpoint requested for many different IDs or
+	// parameters, typically a fetch per list item.
+	StormNPlusOne = "n+1"
+)
+
+// StormDetection configures detection of request storms: bursts of identical
+// or near-identical requests from one page.
+type StormDetection struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Threshold is the number of requests to one endpoint within the window
+	// that makes a storm (default 20).
+	Threshold int `json:"threshold,omitempty"`
+	// WindowMs is the detection window in milliseconds (default 5000).
+	WindowMs int `json:"window_ms,omitempty"`
+	// Toast shows a warning in the page when a storm starts.
+	Toast bool `json:"toast,omitempty"`
+}
+
+func (c StormDetection) threshold() int {
+	if c.Threshold > 0 {
+		return c.Threshold
+	}
+	return DefaultStormThreshold
+}
+
+func (c StormDetection) window() time.Duration {
+	if c.WindowMs > 0 {
+		return time.Duration(c.WindowMs) * time.Millisecond
+	}
+	return DefaultStormWindow
+}
+
+// RequestStorm is an endpoint hit in a burst from one page session.
+type RequestStorm struct {
+	Kind         string    `json:"kind"` // StormDuplicate or StormNPlusOne
+	Method       string    `json:"method"`
+	Endpoint     string    `json:"endpoint"` // Path with IDs replaced by :id
+	SessionID    string    `json:"session_id,omitempty"`
+	PageURL      string    `json:"page_url,omitempty"`
+	Count        int       `json:"count"`         // Requests since the storm started
+	DistinctURLs int       `json:"distinct_urls"` // Most distinct URLs seen in one window
+	Example      string    `json:"example"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// stormKey identifies the requests counted together.
+type stormKey struct {
+	session, method, endpoint string
+}
+
+// stormHit is one request in a window.
+type stormHit struct {
+	at  time.Time
+	url string
+}
+
+// stormWindow holds the recent requests for one key.
+type stormWindow struct {
+	hits   []stormHit
+	active *RequestStorm // Current storm, nil when quiet
+}
+
+// stormDetector counts requests per page and endpoint in a sliding window.
+type stormDetector struct {
+	mu      sync.Mutex
+	config  StormDetection
+	windows map[stormKey]*stormWindow
+	storms  []*RequestStorm
+}
+
+// observe records a request. It returns the storm the request belongs to, if
+// any, and whether the storm started with this request.
+func (sd *stormDetector) observe(sessionID, pageURL, method, rawURL string, now time.Time) (*RequestStorm, bool) {
+	if sd.config.Disabled {
+		return nil, false
+	}
+	threshold, window := sd.config.threshold(), sd.config.window()
+	key := stormKey{session: sessionID, method: method, endpoint: stormEndpoint(rawURL)}
+
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if sd.windows == nil {
+		sd.windows = make(map[stormKey]*stormWindow)
+	}
+	w := sd.windows[key]
+	if w == nil {
+		if len(sd.windows) >= maxStormKeys {
+			sd.pruneLocked(now.Add(-window))
+		}
+		w = &stormWindow{}
+		sd.windows[key] = w
+	}
+
+	cutoff := now.Add(-window)
+	drop := 0
+	for drop < len(w.hits) && !w.hits[drop].at.After(cutoff) {
+		drop++
+	}
+	w.hits = append(w.hits[drop:], stormHit{at: now, url: rawURL})
+	if len(w.hits) > 4*threshold {
+		w.hits = w.hits[len(w.hits)-4*threshold:]
+	}
+
+	if w.active != nil && w.active.LastSeen.Before(cutoff) {
+		w.active = nil // Quiet for a full window: the storm is over
+	}
+	if w.active != nil {
+		w.active.Count++
+		w.active.LastSeen = now
+		if d := distinctURLs(w.hits); d > w.active.DistinctURLs {
+			w.active.DistinctURLs = d
+			w.active.Kind = stormKind(d)
+		}
+		storm := *w.active
+		return &storm, false
+	}
+	if len(w.hits) < threshold {
+		return nil, false
+	}
+
+	d := distinctURLs(w.hits)
+	storm := &RequestStorm{
+		Kind:         stormKind(d),
+		Method:       method,
+		Endpoint:     key.endpoint,
+		SessionID:    sessionID,
+		PageURL:      pageURL,
+		Count:        len(w.hits),
+		DistinctURLs: d,
+		Example:      rawURL,
+		FirstSeen:    w.hits[0].at,
+		LastSeen:     now,
+	}
+	w.active = storm
+	sd.storms = append(sd.storms, storm)
+	if len(sd.storms) > maxRequestStorms {
+		sd.storms = sd.storms[len(sd.storms)-maxRequestStorms:]
+	}
+	started := *storm
+	return &started, true
+}
+
+// pruneLocked drops windows with no requests since cutoff.
+func (sd *stormDetector) pruneLocked(cutoff time.Time) {
+	for k, w := range sd.windows {
+		if len(w.hits) == 0 || !w.hits[len(w.hits)-1].at.After(cutoff) {
+			delete(sd.windows, k)
+		}
+	}
+}
+
+// list returns the detected storms, most requests first.
+func (sd *stormDetector) list() []RequestStorm {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	out := make([]RequestStorm, 0, len(sd.storms))
+	for _, s := range sd.storms {
+		out = append(out, *s)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+func (sd *stormDetector) reset() {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	sd.windows = nil
+	sd.storms = nil
+}
+
+func distinctURLs(hits []stormHit) int {
+	seen := make(map[string]struct{}, len(hits))
+	for _, h := range hits {
+		seen[h.url] = struct{}{}
+	}
+	return len(seen)
+}
+
+func stormKind(distinct int) string {
+	if distinct > 1 {
+		return StormNPlusOne
+	}
+	return StormDuplicate
+}
+
+// stormEndpoint reduces a URL to its path with ID-like segments replaced by
+// ":id", so /api/users/1 and /api/users/2 count as one endpoint.
+func stormEndpoint(rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if isIDSegment(seg) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isIDSegment reports whether a path segment looks like a record ID: a
+// number, a UUID or a long hex string.
+func isIDSegment(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	digits, hex := true, true
+	for _, c := range seg {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F':
+			digits = false
+		case c == '-':
+			digits, hex = false, hex && len(seg) == 36
+		default:
+			return false
+		}
+	}
+	return digits || hex && len(seg) >= 16
+}
+
+// RequestStorms returns the request storms detected on this proxy, most
+// requests first.
+func (ps *ProxyServer) RequestStorms() []RequestStorm {
+	return ps.storms.list()
+}
+
+// ResetRequestStorms discards detected storms and request counts.
+func (ps *ProxyServer) ResetRequestStorms() {
+	ps.storms.reset()
+}
+
+// detectStorm feeds a completed request to the storm detector and reports a
+// storm when one starts.
+func (ps *ProxyServer) detectStorm(entry HTTPLogEntry) {
+	if isDocumentRequest(entry) {
+		return
+	}
+	sessionID, pageURL := "", ""
+	if id := ps.pageTracker.findSessionForResource(entry); id != "" {
+		if session, ok := ps.pageTracker.GetSession(id); ok {
+			sessionID, pageURL = id, session.URL
+		}
+	}
+	storm, started := ps.storms.observe(sessionID, pageURL, entry.Method, entry.URL, entry.Timestamp)
+	if !started {
+		return
+	}
+
+	msg := fmt.Sprintf("%s %s requested %d times in %s (%s)", storm.Method, storm.Endpoint, storm.Count, ps.storms.config.window(), storm.Kind)
+	debug.Log("proxy", "proxy %s request storm: %s", ps.ID, msg)
+	ps.logger.LogCustom(CustomLog{
+		ID:        fmt.Sprintf("storm-%d", time.Now().UnixNano()),
+		Timestamp: storm.LastSeen,
+		Level:     "warn",
+		Message:   "Request storm: " + msg,
+		Data: map[string]interface{}{
+			"kind":          storm.Kind,
+			"method":        storm.Method,
+			"endpoint":      storm.Endpoint,
+			"count":         storm.Count,
+			"distinct_urls": storm.DistinctURLs,
+			"session_id":    storm.SessionID,
+		},
+		URL: storm.PageURL,
+	})
+	if ps.storms.config.Toast {
+		go ps.BroadcastToast("warning", "Request storm", msg, 0)
+	}
+}
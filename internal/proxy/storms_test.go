@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStormEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:3000/api/users/42?include=posts": "/api/users/:id",
+		"/api/orgs/7/members/1234":                         "/api/orgs/:id/members/:id",
+		"/api/items/3f2b8c1e-9a4d-4c1b-8e2f-1a2b3c4d5e6f":  "/api/items/:id",
+		"/api/blobs/0123456789abcdef0123":                  "/api/blobs/:id",
+		"/api/feed":                                        "/api/feed",
+		"/api/reports/2024-01-01":                          "/api/reports/2024-01-01",
+		"/api/cafe":                                        "/api/cafe",
+	}
+	for in, want := range tests {
+		if got := stormEndpoint(in); got != want {
+			t.Errorf("stormEndpoint(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestStormDetector(t *testing.T) {
+	sd := stormDetector{config: StormDetection{Threshold: 5, WindowMs: 1000}}
+	start := time.Now()
+
+	// Refetch loop: the same URL over and over
+	var started int
+	for i := 0; i < 8; i++ {
+		storm, isNew := sd.observe("page-1", "http://localhost/", "GET", "/api/me", start.Add(time.Duration(i)*10*time.Millisecond))
+		if isNew {
+			started++
+			if i != 4 || storm.Kind != StormDuplicate || storm.Count != 5 {
+				t.Errorf("Unexpected storm start at request %d: %+v", i, storm)
+			}
+		}
+	}
+	if started != 1 {
+		t.Fatalf("Expected one storm, got %d", started)
+	}
+
+	// N+1: one fetch per list item
+	for i := 0; i < 6; i++ {
+		sd.observe("page-1", "http://localhost/", "GET", fmt.Sprintf("/api/users/%d", i), start.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	// Spread over more than the window: not a storm
+	for i := 0; i < 10; i++ {
+		sd.observe("page-1", "http://localhost/", "GET", "/api/poll", start.Add(time.Duration(i)*time.Second))
+	}
+
+	storms := sd.list()
+	if len(storms) != 2 {
+		t.Fatalf("Expected 2 storms, got %+v", storms)
+	}
+	if storms[0].Endpoint != "/api/me" || storms[0].Count != 8 {
+		t.Errorf("Expected /api/me first with 8 requests, got %+v", storms[0])
+	}
+	if storms[1].Kind != StormNPlusOne || storms[1].Endpoint != "/api/users/:id" || storms[1].DistinctURLs != 6 {
+		t.Errorf("Expected n+1 storm on /api/users/:id, got %+v", storms[1])
+	}
+
+	// After a quiet window the same endpoint can start a new storm
+	later := start.Add(10 * time.Second)
+	for i := 0; i < 5; i++ {
+		sd.observe("page-1", "http://localhost/", "GET", "/api/me", later.Add(time.Duration(i)*time.Millisecond))
+	}
+	if n := len(sd.list()); n != 3 {
+		t.Errorf("Expected a new storm after a quiet window, got %d storms", n)
+	}
+
+	sd.reset()
+	if n := len(sd.list()); n != 0 {
+		t.Errorf("Expected no storms after reset, got %d", n)
+	}
+}
+
+func TestStormDetectorDisabled(t *testing.T) {
+	sd := stormDetector{config: StormDetection{Disabled: true, Threshold: 2}}
+	for i := 0; i < 10; i++ {
+		if _, started := sd.observe("", "", "GET", "/api/me", time.Now()); started {
+			t.Fatal("Expected no storms when disabled")
+		}
+	}
+}
+
+func TestStormLoggedByProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "{}")
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "storm", TargetURL: backend.URL, Storms: StormDetection{Threshold: 3}})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		ps.handleProxy(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/api/items/%d", i), nil))
+	}
+
+	storms := ps.Stats().RequestStorms
+	if len(storms) != 1 || storms[0].Endpoint != "/api/items/:id" {
+		t.Fatalf("Unexpected storms %+v", storms)
+	}
+	logs := ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeCustom}})
+	if len(logs) != 1 || logs[0].Custom == nil || logs[0].Custom.Level != "warn" {
+		t.Errorf("Expected one warn log for the storm, got %+v", logs)
+	}
+}
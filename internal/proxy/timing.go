@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTiming splits the duration of a proxied request into phases, so a
+// slowdown can be attributed to the backend or to the proxy. Phases that
+// didn't happen are zero: DNS, Connect and TLS when a kept-alive connection
+// was reused.
+type RequestTiming struct {
+	DNS     time.Duration `json:"dns,omitempty"`     // Resolving the upstream host
+	Connect time.Duration `json:"connect,omitempty"` // TCP connect to upstream
+	TLS     time.Duration `json:"tls,omitempty"`     // TLS handshake with upstream
+	TTFB    time.Duration `json:"ttfb"`              // Request written to first response byte
+	Body    time.Duration `json:"body,omitempty"`    // First byte to the end of the response body
+	// Upstream is the sum of the phases above.
+	Upstream time.Duration `json:"upstream"`
+	// Inject is the rewriting and script injection of HTML responses.
+	Inject time.Duration `json:"inject,omitempty"`
+	// Proxy is the rest of the duration: time spent in the proxy, including
+	// injection, chaos rules and writing to the browser.
+	Proxy  time.Duration `json:"proxy"`
+	Reused bool          `json:"reused,omitempty"` // Kept-alive connection reused
+}
+
+// requestTimer records the phase boundaries of one proxied request. The
+// transport's trace hooks and the body reader run on other goroutines.
+type requestTimer struct {
+	mu                        sync.Mutex
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	wroteRequest, firstByte   time.Time
+	bodyDone                  time.Time
+	injectStart, injectDone   time.Time
+	reused                    bool
+}
+
+type requestTimerKey struct{}
+
+// withRequestTimer returns a context tracing the upstream round trip into t.
+func withRequestTimer(ctx context.Context, t *requestTimer) context.Context {
+	ctx = context.WithValue(ctx, requestTimerKey{}, t)
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart: func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.mark(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.mark(&t.tlsDone)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	})
+}
+
+// requestTimerFrom returns the timer of a proxied request's context, or nil.
+func requestTimerFrom(ctx context.Context) *requestTimer {
+	t, _ := ctx.Value(requestTimerKey{}).(*requestTimer)
+	return t
+}
+
+// mark sets a phase boundary to now; the first mark wins, so a retried dial
+// doesn't stretch a phase.
+func (t *requestTimer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// wrapBody returns body, marking the end of the upstream body when it's
+// read to the end or closed.
+func (t *requestTimer) wrapBody(body io.ReadCloser) io.ReadCloser {
+	return &timedBody{ReadCloser: body, t: t}
+}
+
+// timing returns the phases of a request that took total.
+func (t *requestTimer) timing(total time.Duration) *RequestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.firstByte.IsZero() {
+		return nil // Never reached upstream (mocked or failed)
+	}
+	rt := &RequestTiming{
+		DNS:     span(t.dnsStart, t.dnsDone),
+		Connect: span(t.connectStart, t.connectDone),
+		TLS:     span(t.tlsStart, t.tlsDone),
+		TTFB:    span(t.wroteRequest, t.firstByte),
+		Body:    span(t.firstByte, t.bodyDone),
+		Inject:  span(t.injectStart, t.injectDone),
+		Reused:  t.reused,
+	}
+	rt.Upstream = rt.DNS + rt.Connect + rt.TLS + rt.TTFB + rt.Body
+	if rt.Upstream > total {
+		rt.Upstream = total
+	}
+	rt.Proxy = total - rt.Upstream
+	return rt
+}
+
+// span returns the time between two marks, or zero if either is missing.
+func span(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// timedBody marks the end of an upstream response body.
+type timedBody struct {
+	io.ReadCloser
+	t *requestTimer
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.t.mark(&b.t.bodyDone)
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.t.mark(&b.t.bodyDone)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestTimingProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(60 * time.Millisecond) // Backend think time
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond) // Slow body
+		io.WriteString(w, "<html><head></head><body>hi</body></html>")
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "app", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	ps.handleProxy(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	entries := ps.Logger().Query(LogFilter{Types: []LogEntryType{LogTypeHTTP}})
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0].HTTP
+	timing := entry.Timing
+	if timing == nil {
+		t.Fatal("Expected timing on the entry")
+	}
+	if timing.TTFB < 60*time.Millisecond || timing.Body < 30*time.Millisecond {
+		t.Errorf("Expected backend time in ttfb and body, got %+v", timing)
+	}
+	if timing.Connect == 0 || timing.Reused {
+		t.Errorf("Expected a fresh connection, got %+v", timing)
+	}
+	if timing.Upstream+timing.Proxy != entry.Duration || timing.Upstream < timing.TTFB+timing.Body {
+		t.Errorf("Expected upstream and proxy to add up to %v, got %+v", entry.Duration, timing)
+	}
+	if timing.Inject == 0 {
+		t.Errorf("Expected injection timed for HTML, got %+v", timing)
+	}
+}
+
+func TestRequestTimingNotReached(t *testing.T) {
+	timer := &requestTimer{}
+	if timer.timing(time.Second) != nil {
+		t.Error("Expected no timing for a request that never reached upstream")
+	}
+	now := time.Now()
+	timer.wroteRequest, timer.firstByte, timer.bodyDone = now, now.Add(40*time.Millisecond), now.Add(50*time.Millisecond)
+	timing := timer.timing(30 * time.Millisecond)
+	if timing.Upstream != 30*time.Millisecond || timing.Proxy != 0 {
+		t.Errorf("Expected upstream capped at the total, got %+v", timing)
+	}
+}
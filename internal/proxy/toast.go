@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// MaxToastActions bounds the buttons on one toast.
+const MaxToastActions = 3
+
+// Toast is a notification shown on connected pages. A toast with actions
+// needs an ID: a click sends it back with the action's callback.
+type Toast struct {
+	ID       string                 `json:"id,omitempty"`
+	Type     string                 `json:"type"` // success, error, warning, info
+	Title    string                 `json:"title,omitempty"`
+	Message  string                 `json:"message"`
+	Duration int                    `json:"duration,omitempty"` // Duration in ms (0 for default)
+	Actions  []protocol.ToastAction `json:"actions,omitempty"`
+}
+
+// Validate checks the actions.
+func (t Toast) Validate() error {
+	if len(t.Actions) == 0 {
+		return nil
+	}
+	if t.ID == "" {
+		return fmt.Errorf("a toast with actions needs an ID")
+	}
+	if len(t.Actions) > MaxToastActions {
+		return fmt.Errorf("a toast takes at most %d actions, got %d", MaxToastActions, len(t.Actions))
+	}
+	for _, a := range t.Actions {
+		if a.Label == "" || a.Callback == "" {
+			return fmt.Errorf("toast actions need a label and a callback")
+		}
+	}
+	return nil
+}
+
+// ToastClick is a toast action a user clicked on a page.
+type ToastClick struct {
+	ToastID   string    `json:"toast_id"`
+	Callback  string    `json:"callback"`
+	Label     string    `json:"label,omitempty"`
+	URL       string    `json:"url,omitempty"` // Page the toast was clicked on
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SendToast shows a toast on all connected pages. Returns the number of
+// pages reached; a toast nobody sees is not an error.
+func (ps *ProxyServer) SendToast(toast Toast) (int, error) {
+	debug.Log("proxy", "SendToast: proxy=%s type=%s title=%q message=%q actions=%d", ps.ID, toast.Type, toast.Title, toast.Message, len(toast.Actions))
+	if err := toast.Validate(); err != nil {
+		return 0, err
+	}
+	messageBytes, err := json.Marshal(map[string]interface{}{
+		"type":    "toast",
+		"payload": toast,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal toast: %w", err)
+	}
+
+	sentCount := 0
+	ps.wsConns.Range(func(key, value interface{}) bool {
+		conn := value.(*websocket.Conn)
+		if err := conn.WriteMessage(websocket.TextMessage, messageBytes); err == nil {
+			sentCount++
+		} else {
+			debug.Log("proxy", "SendToast: failed to send to client %v: %v", key, err)
+		}
+		return true
+	})
+	return sentCount, nil
+}
+
+// DismissToast removes a toast from all connected pages, once one of them
+// has answered it.
+func (ps *ProxyServer) DismissToast(id string) {
+	messageBytes, _ := json.Marshal(map[string]interface{}{
+		"type":    "toast_dismiss",
+		"payload": map[string]string{"id": id},
+	})
+	ps.wsConns.Range(func(key, value interface{}) bool {
+		_ = value.(*websocket.Conn).WriteMessage(websocket.TextMessage, messageBytes)
+		return true
+	})
+}
+
+// handleToastAction hands a "toast_action" message from a page to the
+// session bridge.
+func (ps *ProxyServer) handleToastAction(data map[string]interface{}, pageURL string) {
+	click := ToastClick{
+		ToastID:   getStringField(data, "toast_id"),
+		Callback:  getStringField(data, "callback"),
+		Label:     getStringField(data, "label"),
+		URL:       pageURL,
+		Timestamp: time.Now(),
+	}
+	if click.ToastID == "" || click.Callback == "" {
+		return
+	}
+	bridge := ps.sessionBridge()
+	if bridge == nil {
+		return
+	}
+	if err := bridge.RelayToastAction(ps, click); err != nil {
+		debug.Log("proxy", "toast action %s/%s not relayed: %v", click.ToastID, click.Callback, err)
+		return
+	}
+	ps.DismissToast(click.ToastID)
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLargestResponses is the number of largest responses tracked.
+	maxLargestResponses = 10
+	// maxContentTypes bounds the content type breakdown; further types are
+	// counted as "other".
+	maxContentTypes = 50
+)
+
+// ContentTypeTraffic is the response volume of one content type.
+type ContentTypeTraffic struct {
+	ContentType string  `json:"content_type"`
+	Responses   int64   `json:"responses"`
+	Bytes       int64   `json:"bytes"`
+	Percent     float64 `json:"percent"` // Share of all response bytes
+}
+
+// LargeResponse is one of the largest responses served, keyed by method and
+// path so cache-busting query strings count as re-fetches.
+type LargeResponse struct {
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	ContentType string    `json:"content_type,omitempty"`
+	Bytes       int64     `json:"bytes"`   // Largest size seen
+	Fetches     int64     `json:"fetches"` // Times fetched while tracked
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// TrafficStats breaks down response bytes by content type, largest first.
+type TrafficStats struct {
+	Responses     int64                `json:"responses"`
+	TotalBytes    int64                `json:"total_bytes"`
+	ByContentType []ContentTypeTraffic `json:"by_content_type,omitempty"`
+	Largest       []LargeResponse      `json:"largest,omitempty"`
+}
+
+// trafficCounter accumulates TrafficStats.
+type trafficCounter struct {
+	mu        sync.Mutex
+	responses int64
+	total     int64
+	byType    map[string]*ContentTypeTraffic
+	largest   []LargeResponse
+}
+
+// record counts a response of size bytes.
+func (tc *trafficCounter) record(method, path, contentType string, size int64) {
+	contentType = normalizeContentType(contentType)
+	now := time.Now()
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.byType == nil {
+		tc.byType = make(map[string]*ContentTypeTraffic)
+	}
+	tc.responses++
+	tc.total += size
+
+	ct := tc.byType[contentType]
+	if ct == nil {
+		if len(tc.byType) >= maxContentTypes {
+			contentType = "other"
+			ct = tc.byType[contentType]
+		}
+		if ct == nil {
+			ct = &ContentTypeTraffic{ContentType: contentType}
+			tc.byType[contentType] = ct
+		}
+	}
+	ct.Responses++
+	ct.Bytes += size
+
+	for i := range tc.largest {
+		l := &tc.largest[i]
+		if l.Method == method && l.Path == path {
+			l.Fetches++
+			l.LastSeen = now
+			if size > l.Bytes {
+				l.Bytes, l.ContentType = size, contentType
+			}
+			tc.sortLargest()
+			return
+		}
+	}
+	entry := LargeResponse{Method: method, Path: path, ContentType: contentType, Bytes: size, Fetches: 1, LastSeen: now}
+	switch {
+	case len(tc.largest) < maxLargestResponses:
+		tc.largest = append(tc.largest, entry)
+	case size > tc.largest[len(tc.largest)-1].Bytes:
+		tc.largest[len(tc.largest)-1] = entry
+	default:
+		return
+	}
+	tc.sortLargest()
+}
+
+func (tc *trafficCounter) sortLargest() {
+	sort.SliceStable(tc.largest, func(i, j int) bool { return tc.largest[i].Bytes > tc.largest[j].Bytes })
+}
+
+// stats returns a snapshot with content types ordered by bytes.
+func (tc *trafficCounter) stats() TrafficStats {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	s := TrafficStats{
+		Responses:  tc.responses,
+		TotalBytes: tc.total,
+		Largest:    append([]LargeResponse(nil), tc.largest...),
+	}
+	for _, ct := range tc.byType {
+		c := *ct
+		if tc.total > 0 {
+			c.Percent = float64(c.Bytes) * 100 / float64(tc.total)
+		}
+		s.ByContentType = append(s.ByContentType, c)
+	}
+	sort.Slice(s.ByContentType, func(i, j int) bool {
+		a, b := s.ByContentType[i], s.ByContentType[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.ContentType < b.ContentType
+	})
+	return s
+}
+
+// reset discards the counts.
+func (tc *trafficCounter) reset() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.responses, tc.total = 0, 0
+	tc.byType = nil
+	tc.largest = nil
+}
+
+// normalizeContentType strips parameters: "text/html; charset=utf-8" is
+// counted as "text/html".
+func normalizeContentType(contentType string) string {
+	if contentType == "" {
+		return "unknown"
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// Traffic returns response volume by content type and the largest responses.
+func (ps *ProxyServer) Traffic() TrafficStats {
+	return ps.traffic.stats()
+}
+
+// ResetTraffic discards the traffic breakdown.
+func (ps *ProxyServer) ResetTraffic() {
+	ps.traffic.reset()
+}
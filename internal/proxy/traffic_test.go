@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTrafficCounter(t *testing.T) {
+	var tc trafficCounter
+	tc.record("GET", "/", "text/html; charset=utf-8", 1000)
+	tc.record("GET", "/hero.png", "image/png", 6000)
+	tc.record("GET", "/api/items", "application/json", 3000)
+	tc.record("GET", "/api/items", "application/json", 2000) // re-fetch, smaller
+
+	s := tc.stats()
+	if s.Responses != 4 || s.TotalBytes != 12000 {
+		t.Fatalf("Unexpected totals %+v", s)
+	}
+	if len(s.ByContentType) != 3 || s.ByContentType[0].ContentType != "image/png" || s.ByContentType[0].Percent != 50 {
+		t.Errorf("Expected image/png first at 50%%, got %+v", s.ByContentType)
+	}
+	if s.ByContentType[2].ContentType != "text/html" {
+		t.Errorf("Expected content type parameters stripped, got %+v", s.ByContentType)
+	}
+	if len(s.Largest) != 3 || s.Largest[0].Path != "/hero.png" {
+		t.Fatalf("Unexpected largest responses %+v", s.Largest)
+	}
+	if api := s.Largest[1]; api.Path != "/api/items" || api.Fetches != 2 || api.Bytes != 3000 {
+		t.Errorf("Expected re-fetches folded into one entry, got %+v", api)
+	}
+
+	// Only the largest responses are kept
+	for i := 0; i < maxLargestResponses; i++ {
+		tc.record("GET", fmt.Sprintf("/big/%d", i), "application/json", int64(10000+i))
+	}
+	s = tc.stats()
+	if len(s.Largest) != maxLargestResponses || s.Largest[0].Path != fmt.Sprintf("/big/%d", maxLargestResponses-1) {
+		t.Errorf("Unexpected largest responses %+v", s.Largest)
+	}
+
+	tc.reset()
+	if s := tc.stats(); s.Responses != 0 || len(s.Largest) != 0 {
+		t.Errorf("Expected reset stats, got %+v", s)
+	}
+}
+
+func TestTrafficRecordedByProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, strings.Repeat("x", 2048))
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "traffic", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	ps.handleProxy(httptest.NewRecorder(), httptest.NewRequest("GET", "/data?v=1", nil))
+
+	traffic := ps.Stats().Traffic
+	if traffic.TotalBytes != 2048 || len(traffic.Largest) != 1 || traffic.Largest[0].Path != "/data" {
+		t.Errorf("Unexpected traffic %+v", traffic)
+	}
+}
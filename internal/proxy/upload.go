@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MaxUploadBytes bounds a file dropped on the floating panel. Base64 adds a
+// third, so this keeps the upload under the WebSocket frame limit.
+const MaxUploadBytes = 20 << 20
+
+// SessionBridge hands what users give the floating panel to the terminal
+// session of the proxy's project. The daemon installs it through
+// ProxyManager.SetSessionBridge.
+type SessionBridge interface {
+	// RelayClipboard types text copied in the panel into the session,
+	// submitting it when enter is set.
+	RelayClipboard(ps *ProxyServer, clip ClipboardEntry, enter bool) error
+	// StoreUpload saves a file dropped on the panel under the project and
+	// returns its path.
+	StoreUpload(ps *ProxyServer, upload FileUpload) (string, error)
+	// RelayToastAction hands a clicked toast action to whoever asked for
+	// the toast.
+	RelayToastAction(ps *ProxyServer, click ToastClick) error
+}
+
+// SetSessionBridge sets where panel clipboard text, uploads and toast
+// clicks go.
+func (ps *ProxyServer) SetSessionBridge(bridge SessionBridge) {
+	ps.bridge.Store(&bridge)
+}
+
+// sessionBridge returns the installed bridge, or nil.
+func (ps *ProxyServer) sessionBridge() SessionBridge {
+	if bridge := ps.bridge.Load(); bridge != nil {
+		return *bridge
+	}
+	return nil
+}
+
+// FileUpload is a file dropped on the floating panel.
+type FileUpload struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int    `json:"size"`
+	Data     []byte `json:"-"`
+	URL      string `json:"url,omitempty"` // Page the file was dropped on
+}
+
+// parseFileUpload reads an "upload" message: name, mime_type and data as
+// base64 or a data URL.
+func parseFileUpload(data map[string]interface{}, pageURL string) (FileUpload, error) {
+	upload := FileUpload{
+		Name:     filepath.Base(strings.ReplaceAll(getStringField(data, "name"), "\\", "/")),
+		MimeType: getStringField(data, "mime_type"),
+		URL:      pageURL,
+	}
+	if upload.Name == "" || upload.Name == "." || upload.Name == "/" {
+		return upload, fmt.Errorf("file name is required")
+	}
+
+	encoded := getStringField(data, "data")
+	if strings.HasPrefix(encoded, "data:") {
+		header, payload, ok := strings.Cut(encoded, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return upload, fmt.Errorf("invalid data URL")
+		}
+		if upload.MimeType == "" {
+			upload.MimeType = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		}
+		encoded = payload
+	}
+	if base64.StdEncoding.DecodedLen(len(encoded)) > MaxUploadBytes+2 {
+		return upload, fmt.Errorf("file is over the %d MB upload limit", MaxUploadBytes>>20)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return upload, fmt.Errorf("failed to decode file: %w", err)
+	}
+	if len(decoded) == 0 {
+		return upload, fmt.Errorf("file is empty")
+	}
+	if len(decoded) > MaxUploadBytes {
+		return upload, fmt.Errorf("file is over the %d MB upload limit", MaxUploadBytes>>20)
+	}
+	upload.Data = decoded
+	upload.Size = len(decoded)
+	if upload.MimeType == "" {
+		upload.MimeType = mime.TypeByExtension(filepath.Ext(upload.Name))
+	}
+	return upload, nil
+}
+
+// handleUpload stores a file dropped on the floating panel through the
+// session bridge, then logs and forwards a panel message referencing it so
+// the agent learns where it is.
+func (ps *ProxyServer) handleUpload(conn *websocket.Conn, data map[string]interface{}, pageURL string) {
+	id := fmt.Sprintf("upload-%d", ps.requestSeq.Add(1))
+	upload, err := parseFileUpload(data, pageURL)
+	var path string
+	if err == nil {
+		if bridge := ps.sessionBridge(); bridge == nil {
+			err = fmt.Errorf("uploads not available: no daemon")
+		} else {
+			path, err = bridge.StoreUpload(ps, upload)
+		}
+	}
+
+	payload := map[string]interface{}{"success": err == nil, "name": upload.Name}
+	if err != nil {
+		payload["error"] = err.Error()
+		conn.WriteJSON(map[string]interface{}{"type": "upload_result", "payload": payload})
+		return
+	}
+	payload["file_path"] = path
+	payload["size"] = upload.Size
+
+	message := getStringField(data, "message")
+	if message == "" {
+		message = "Uploaded " + upload.Name
+	}
+	panelMsg := PanelMessage{
+		ID:        id,
+		Timestamp: time.Now(),
+		Message:   message,
+		URL:       pageURL,
+		Attachments: []PanelAttachment{{
+			Type: "file",
+			ID:   id,
+			Text: fmt.Sprintf("%s (%s, %s)", upload.Name, upload.MimeType, formatUploadSize(upload.Size)),
+			Data: map[string]interface{}{
+				"file_path": path,
+				"file_name": filepath.Base(path),
+				"mime_type": upload.MimeType,
+				"size":      upload.Size,
+			},
+		}},
+	}
+	panelMsg.linkResources(ps.ID)
+	ps.logger.LogPanelMessage(panelMsg)
+	if ps.overlayNotifier.IsEnabled() {
+		_ = ps.overlayNotifier.NotifyPanelMessage(ps.ID, &panelMsg)
+	}
+
+	conn.WriteJSON(map[string]interface{}{"type": "upload_result", "payload": payload})
+}
+
+// formatUploadSize renders a byte count for people.
+func formatUploadSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
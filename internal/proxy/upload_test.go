@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestParseFileUpload(t *testing.T) {
+	csv := base64.StdEncoding.EncodeToString([]byte("id,name\n1,Ada\n"))
+
+	upload, err := parseFileUpload(map[string]interface{}{"name": "users.csv", "data": "data:text/csv;base64," + csv}, "http://localhost/")
+	if err != nil {
+		t.Fatalf("parseFileUpload failed: %v", err)
+	}
+	if upload.MimeType != "text/csv" || upload.Size != 14 || string(upload.Data) != "id,name\n1,Ada\n" {
+		t.Errorf("Unexpected upload %+v", upload)
+	}
+
+	// Plain base64, type from the extension, directories dropped from the name
+	upload, err = parseFileUpload(map[string]interface{}{"name": `..\..\mock.png`, "data": csv}, "")
+	if err != nil {
+		t.Fatalf("parseFileUpload failed: %v", err)
+	}
+	if upload.Name != "mock.png" || upload.MimeType != "image/png" {
+		t.Errorf("Expected the base name and an image type, got %+v", upload)
+	}
+
+	tooLarge := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", MaxUploadBytes+1)))
+	for _, data := range []map[string]interface{}{
+		{"data": csv},
+		{"name": "a.txt"},
+		{"name": "a.txt", "data": "not base64!"},
+		{"name": "a.txt", "data": "data:text/plain," + csv},
+		{"name": "a.txt", "data": tooLarge},
+	} {
+		if _, err := parseFileUpload(data, ""); err == nil {
+			t.Errorf("Expected error for %.60v", data)
+		}
+	}
+}
+
+func TestPanelAttachmentURI(t *testing.T) {
+	uri := PanelAttachmentURI("web-0001:dev", "upload-7", 2)
+	proxyID, messageID, index, err := ParsePanelAttachmentURI(uri)
+	if err != nil || proxyID != "web-0001:dev" || messageID != "upload-7" || index != 2 {
+		t.Errorf("round trip of %s = %q %q %d (%v)", uri, proxyID, messageID, index, err)
+	}
+	for _, bad := range []string{"agnt://panel", "agnt://panel/a/b", "agnt://panel/a/b/-1", "file:///a/b/1"} {
+		if _, _, _, err := ParsePanelAttachmentURI(bad); err == nil {
+			t.Errorf("Expected error for %s", bad)
+		}
+	}
+
+	msg := PanelMessage{ID: "metric-3", Attachments: []PanelAttachment{
+		{Type: "element", Selector: "#buy"},
+		{Type: "screenshot", Area: &ScreenshotArea{Data: "data:image/png;base64,iVBO"}},
+		{Type: "file", Data: map[string]interface{}{"file_path": "/src/app/.agnt/uploads/mock.png"}},
+	}}
+	msg.linkResources("app")
+	if msg.Attachments[0].Data != nil {
+		t.Error("Expected no resource for an element")
+	}
+	for i := 1; i < 3; i++ {
+		if got := msg.Attachments[i].Data["resource_uri"]; got != PanelAttachmentURI("app", "metric-3", i) {
+			t.Errorf("attachment %d resource_uri = %v", i, got)
+		}
+	}
+}
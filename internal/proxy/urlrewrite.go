@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// URLRewrite configures rewriting of upstream URLs to the proxy origin, which
+// keeps the browser inside the instrumented proxy after redirects and links.
+type URLRewrite struct {
+	// NoBody leaves absolute URLs in HTML bodies alone. Location headers
+	// are still rewritten.
+	NoBody bool `json:"no_body,omitempty"`
+	// Exclude lists URLs that are never rewritten, such as OAuth callbacks
+	// registered with the upstream origin. Entries starting with "/" match
+	// path prefixes; others match URL prefixes ("http://localhost:3000/cdn/").
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// URLRewriteStats counts URLs rewritten to the proxy origin.
+type URLRewriteStats struct {
+	Location int64 `json:"location"` // Location headers rewritten
+	Body     int64 `json:"body"`     // Absolute URLs rewritten in bodies
+	Excluded int64 `json:"excluded"` // URLs left alone by the exclusion list
+}
+
+// urlRewriteCounters backs URLRewriteStats.
+type urlRewriteCounters struct {
+	location atomic.Int64
+	body     atomic.Int64
+	excluded atomic.Int64
+}
+
+// URLRewrites returns the proxy's URL rewrite counters.
+func (ps *ProxyServer) URLRewrites() URLRewriteStats {
+	return URLRewriteStats{
+		Location: ps.urlRewrites.location.Load(),
+		Body:     ps.urlRewrites.body.Load(),
+		Excluded: ps.urlRewrites.excluded.Load(),
+	}
+}
+
+// urlExcluded reports whether rawURL matches the exclusion list.
+func (ps *ProxyServer) urlExcluded(rawURL string) bool {
+	if len(ps.urlPolicy.Exclude) == 0 {
+		return false
+	}
+	path := ""
+	if parsed, err := url.Parse(rawURL); err == nil {
+		path = parsed.Path
+	}
+	for _, prefix := range ps.urlPolicy.Exclude {
+		if strings.HasPrefix(prefix, "/") {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if strings.HasPrefix(rawURL, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteLocationHeader rewrites Location headers to point to the proxy instead of the target.
+func (ps *ProxyServer) rewriteLocationHeader(resp *http.Response) {
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return
+	}
+
+	rewritten := ps.rewriteURL(location)
+	if rewritten == location && resp.Request != nil {
+		rewritten = ps.rewriteRoutedURL(location, resp.Request.Header.Get("X-Forwarded-Host"))
+	}
+	if rewritten == location {
+		rewritten = ps.rewritePathRoutedURL(location)
+	}
+	if rewritten == location {
+		return
+	}
+	if ps.urlExcluded(location) {
+		ps.urlRewrites.excluded.Add(1)
+		return
+	}
+	resp.Header.Set("Location", rewritten)
+	ps.urlRewrites.location.Add(1)
+}
+
+// rewriteURLsInBody rewrites absolute URLs in HTML/JS content from target to proxy.
+func (ps *ProxyServer) rewriteURLsInBody(body []byte) []byte {
+	// Guard against nil TargetURL (can happen in tests with partial setup)
+	target := ps.Target()
+	if target == nil {
+		return body
+	}
+
+	targetHost := target.Host
+	if targetHost == "" {
+		return body
+	}
+
+	// http://target:port and https://target:port -> scheme://proxyhost, also
+	// with escaped slashes (common in JSON)
+	proxyURL := ps.getProxyScheme() + "://" + ps.getProxyHost()
+	for _, origin := range []string{"https://" + targetHost, "http://" + targetHost} {
+		body = ps.replaceOrigin(body, origin, origin, proxyURL)
+		escape := func(s string) string { return strings.ReplaceAll(s, "/", "\\/") }
+		body = ps.replaceOrigin(body, escape(origin), origin, escape(proxyURL))
+	}
+	return body
+}
+
+// replaceOrigin replaces occurrences of from, an origin as written in the
+// body, with to. origin is the unescaped form used for exclusion matching.
+// Occurrences continuing with more host or port characters belong to another
+// origin and are skipped.
+func (ps *ProxyServer) replaceOrigin(body []byte, from, origin, to string) []byte {
+	fromB := []byte(from)
+	if !bytes.Contains(body, fromB) {
+		return body
+	}
+
+	out := make([]byte, 0, len(body))
+	for {
+		i := bytes.Index(body, fromB)
+		if i < 0 {
+			return append(out, body...)
+		}
+		end := i + len(fromB)
+		rest := body[end:]
+		switch {
+		case len(rest) > 0 && isHostByte(rest[0]):
+			out = append(out, body[:end]...)
+		case ps.urlExcluded(origin + strings.ReplaceAll(string(urlTail(rest)), "\\/", "/")):
+			ps.urlRewrites.excluded.Add(1)
+			out = append(out, body[:end]...)
+		default:
+			ps.urlRewrites.body.Add(1)
+			out = append(out, body[:i]...)
+			out = append(out, to...)
+		}
+		body = rest
+	}
+}
+
+// urlTail returns the path, query and fragment following an origin in text.
+func urlTail(b []byte) []byte {
+	if i := bytes.IndexAny(b, "\"'`<>() \t\r\n"); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
+// isHostByte reports whether c can continue a hostname or port.
+func isHostByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == ':'
+}
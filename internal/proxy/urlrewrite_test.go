@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRewriteURLsInBody_Exclusions(t *testing.T) {
+	ps := newTestProxyServer("http://localhost:3000", ":8080")
+	ps.urlPolicy.Exclude = []string{"/oauth/", "http://localhost:3000/cdn/"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "path prefix excluded",
+			input:    `<a href="http://localhost:3000/oauth/callback">`,
+			expected: `<a href="http://localhost:3000/oauth/callback">`,
+		},
+		{
+			name:     "URL prefix excluded",
+			input:    `<img src="http://localhost:3000/cdn/logo.png">`,
+			expected: `<img src="http://localhost:3000/cdn/logo.png">`,
+		},
+		{
+			name:     "escaped path excluded",
+			input:    `{"cb":"http:\/\/localhost:3000\/oauth\/cb","home":"http:\/\/localhost:3000\/"}`,
+			expected: `{"cb":"http:\/\/localhost:3000\/oauth\/cb","home":"http:\/\/localhost:8080\/"}`,
+		},
+		{
+			name:     "other port on the same host unchanged",
+			input:    `<a href="http://localhost:30001/page">`,
+			expected: `<a href="http://localhost:30001/page">`,
+		},
+		{
+			name:     "bare origin rewritten",
+			input:    `<script>const api = "http://localhost:3000";</script>`,
+			expected: `<script>const api = "http://localhost:8080";</script>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ps.rewriteURLsInBody([]byte(tt.input))
+			if string(result) != tt.expected {
+				t.Errorf("rewriteURLsInBody(%q) = %q, want %q", tt.input, string(result), tt.expected)
+			}
+		})
+	}
+
+	stats := ps.URLRewrites()
+	if stats.Body != 2 || stats.Excluded != 3 {
+		t.Errorf("Unexpected counters %+v", stats)
+	}
+}
+
+func TestRewriteLocationHeader_Exclusions(t *testing.T) {
+	ps := newTestProxyServer("http://localhost:3000", ":8080")
+	ps.urlPolicy.Exclude = []string{"/auth/callback"}
+
+	for location, expected := range map[string]string{
+		"http://localhost:3000/auth/callback?code=1": "http://localhost:3000/auth/callback?code=1",
+		"http://localhost:3000/dashboard":            "http://localhost:8080/dashboard",
+		"/relative":                                  "/relative",
+	} {
+		resp := &http.Response{Header: make(http.Header)}
+		resp.Header.Set("Location", location)
+		ps.rewriteLocationHeader(resp)
+		if got := resp.Header.Get("Location"); got != expected {
+			t.Errorf("Location %q rewritten to %q, want %q", location, got, expected)
+		}
+	}
+
+	if stats := ps.URLRewrites(); stats.Location != 1 || stats.Excluded != 1 {
+		t.Errorf("Unexpected counters %+v", stats)
+	}
+}
@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"math"
+	"time"
+)
+
+// Web vital ratings, as defined by web.dev.
+const (
+	VitalGood             = "good"
+	VitalNeedsImprovement = "needs-improvement"
+	VitalPoor             = "poor"
+)
+
+// vitalThreshold holds the upper bounds of a metric's good and
+// needs-improvement ratings, and its weight in the performance score.
+type vitalThreshold struct {
+	good   float64
+	poor   float64
+	weight float64
+}
+
+// Thresholds from web.dev. The score weights follow Lighthouse's, with INP
+// standing in for Total Blocking Time and TTFB for Speed Index.
+var (
+	lcpThreshold  = vitalThreshold{good: 2500, poor: 4000, weight: 25}
+	clsThreshold  = vitalThreshold{good: 0.1, poor: 0.25, weight: 25}
+	inpThreshold  = vitalThreshold{good: 200, poor: 500, weight: 30}
+	fcpThreshold  = vitalThreshold{good: 1800, poor: 3000, weight: 10}
+	ttfbThreshold = vitalThreshold{good: 800, poor: 1800, weight: 10}
+)
+
+// WebVitals are the Core Web Vitals of a page load, rated and scored.
+type WebVitals struct {
+	URL          string       `json:"url"`
+	Score        int          `json:"score"`  // 0-100, weighted over the metrics measured so far
+	Rating       string       `json:"rating"` // Worst rating of LCP, CLS and INP
+	LCP          *VitalMetric `json:"lcp,omitempty"`
+	CLS          *VitalMetric `json:"cls,omitempty"`
+	INP          *VitalMetric `json:"inp,omitempty"` // Only after an interaction
+	FCP          *VitalMetric `json:"fcp,omitempty"`
+	TTFB         *VitalMetric `json:"ttfb,omitempty"`
+	Interactions int          `json:"interactions,omitempty"` // Interactions INP was measured over
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// VitalMetric is one measured web vital.
+type VitalMetric struct {
+	Value  float64 `json:"value"` // Milliseconds, unitless for CLS
+	Rating string  `json:"rating"`
+	Score  int     `json:"score"`            // 0-100, Lighthouse log-normal scoring
+	Target string  `json:"target,omitempty"` // LCP element or INP event target
+	Event  string  `json:"event,omitempty"`  // INP event type
+}
+
+// parseWebVitals rates the raw vitals a page reports in a web_vitals message.
+func parseWebVitals(data map[string]interface{}, url string, timestamp time.Time) WebVitals {
+	v := WebVitals{
+		URL:          url,
+		Interactions: getIntField(data, "interactions"),
+		UpdatedAt:    timestamp,
+	}
+	metric := func(key string, t vitalThreshold) *VitalMetric {
+		if _, ok := data[key]; !ok {
+			return nil
+		}
+		return rateVital(getFloatField(data, key), t)
+	}
+
+	v.LCP = metric("lcp", lcpThreshold)
+	v.CLS = metric("cls", clsThreshold)
+	v.INP = metric("inp", inpThreshold)
+	v.FCP = metric("fcp", fcpThreshold)
+	v.TTFB = metric("ttfb", ttfbThreshold)
+	if v.LCP != nil {
+		v.LCP.Target = getStringField(data, "lcp_element")
+	}
+	if v.INP != nil {
+		v.INP.Target = getStringField(data, "inp_target")
+		v.INP.Event = getStringField(data, "inp_event")
+	}
+
+	var total, weights float64
+	for _, m := range []struct {
+		metric *VitalMetric
+		t      vitalThreshold
+	}{{v.LCP, lcpThreshold}, {v.CLS, clsThreshold}, {v.INP, inpThreshold}, {v.FCP, fcpThreshold}, {v.TTFB, ttfbThreshold}} {
+		if m.metric == nil {
+			continue
+		}
+		total += float64(m.metric.Score) * m.t.weight
+		weights += m.t.weight
+	}
+	if weights > 0 {
+		v.Score = int(math.Round(total / weights))
+	}
+
+	for _, m := range []*VitalMetric{v.LCP, v.CLS, v.INP} {
+		if m != nil {
+			v.Rating = worseRating(v.Rating, m.Rating)
+		}
+	}
+	return v
+}
+
+// rateVital rates a value against a metric's thresholds.
+func rateVital(value float64, t vitalThreshold) *VitalMetric {
+	m := &VitalMetric{Value: value, Score: vitalScore(value, t)}
+	switch {
+	case value <= t.good:
+		m.Rating = VitalGood
+	case value <= t.poor:
+		m.Rating = VitalNeedsImprovement
+	default:
+		m.Rating = VitalPoor
+	}
+	return m
+}
+
+// vitalScore maps a value onto Lighthouse's log-normal curve, which scores
+// the good threshold 90 and the poor threshold 50.
+func vitalScore(value float64, t vitalThreshold) int {
+	// erfc^-1(0.2), which places the good threshold at the 10th percentile
+	const inverseErfcOneFifth = 0.9061938024368232
+	if value <= 0 {
+		return 100
+	}
+	logRatio := math.Log(value / t.poor)
+	goodLogRatio := -math.Log(t.good / t.poor)
+	standardized := logRatio * inverseErfcOneFifth / goodLogRatio
+	score := (1 - math.Erf(standardized)) / 2
+	return int(math.Round(score * 100))
+}
+
+// worseRating returns the worse of two ratings, an empty one being best.
+func worseRating(a, b string) string {
+	rank := map[string]int{"": 0, VitalGood: 1, VitalNeedsImprovement: 2, VitalPoor: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWebVitals(t *testing.T) {
+	data := map[string]interface{}{
+		"lcp":          float64(3100),
+		"lcp_element":  "img.hero",
+		"cls":          0.02,
+		"inp":          float64(650),
+		"inp_target":   "button#buy",
+		"inp_event":    "click",
+		"ttfb":         float64(120),
+		"interactions": float64(4),
+	}
+
+	v := parseWebVitals(data, "http://localhost:8080/", time.Now())
+	if v.LCP == nil || v.LCP.Rating != VitalNeedsImprovement || v.LCP.Target != "img.hero" {
+		t.Errorf("Unexpected LCP: %+v", v.LCP)
+	}
+	if v.CLS == nil || v.CLS.Rating != VitalGood {
+		t.Errorf("Unexpected CLS: %+v", v.CLS)
+	}
+	if v.INP == nil || v.INP.Rating != VitalPoor || v.INP.Target != "button#buy" || v.INP.Event != "click" {
+		t.Errorf("Unexpected INP: %+v", v.INP)
+	}
+	if v.FCP != nil {
+		t.Errorf("Expected no FCP when the page did not report one, got %+v", v.FCP)
+	}
+	if v.Rating != VitalPoor {
+		t.Errorf("Expected the worst core vital's rating, got %q", v.Rating)
+	}
+	if v.Interactions != 4 {
+		t.Errorf("Expected 4 interactions, got %d", v.Interactions)
+	}
+	if v.Score <= 0 || v.Score >= 90 {
+		t.Errorf("Expected a middling score, got %d", v.Score)
+	}
+}
+
+func TestParseWebVitals_ZeroIsMeasured(t *testing.T) {
+	v := parseWebVitals(map[string]interface{}{"cls": float64(0)}, "http://localhost:8080/", time.Now())
+	if v.CLS == nil || v.CLS.Score != 100 || v.CLS.Rating != VitalGood {
+		t.Errorf("Expected a perfect CLS, got %+v", v.CLS)
+	}
+	if v.Score != 100 || v.Rating != VitalGood {
+		t.Errorf("Expected score 100 and good, got %d %q", v.Score, v.Rating)
+	}
+}
+
+func TestVitalScore(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  int
+	}{
+		{2500, 90},
+		{4000, 50},
+		{0, 100},
+	}
+	for _, tt := range tests {
+		if got := vitalScore(tt.value, lcpThreshold); got != tt.want {
+			t.Errorf("vitalScore(%v) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+	if got := vitalScore(20000, lcpThreshold); got > 5 {
+		t.Errorf("Expected a near-zero score for a very slow LCP, got %d", got)
+	}
+}
+
+func TestPageTracker_TrackWebVitals(t *testing.T) {
+	pt := NewPageTracker(100, 5*time.Minute)
+	pt.TrackHTTPRequest(HTTPLogEntry{
+		ID:              "req-1",
+		Timestamp:       time.Now(),
+		Method:          "GET",
+		URL:             "http://localhost:8080/",
+		RequestHeaders:  map[string]string{"Cookie": "__devtool_sid=sess-1"},
+		ResponseHeaders: map[string]string{"Content-Type": "text/html"},
+		StatusCode:      200,
+	})
+
+	pt.TrackWebVitals(parseWebVitals(map[string]interface{}{"lcp": float64(1200)}, "http://localhost:8080/", time.Now()), "sess-1")
+
+	sessions := pt.GetActiveSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	if sessions[0].WebVitals == nil || sessions[0].WebVitals.LCP == nil || sessions[0].WebVitals.LCP.Value != 1200 {
+		t.Errorf("Expected the session to carry the vitals, got %+v", sessions[0].WebVitals)
+	}
+}
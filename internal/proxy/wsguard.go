@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// wsMaxMessageSize caps a single metrics WebSocket frame.
+	// Screenshots are sent as base64 data URLs, so this stays generous.
+	wsMaxMessageSize = 32 << 20
+
+	// wsMessageRate is the sustained number of messages per second accepted
+	// from a single metrics connection before messages are dropped.
+	wsMessageRate = 200
+
+	// wsMessageBurst is the burst size allowed above the sustained rate.
+	wsMessageBurst = 1000
+
+	// sessionTokenParam is the query parameter carrying the session token.
+	sessionTokenParam = "token"
+)
+
+// generateSessionToken returns a random hex token identifying a proxy instance.
+// The token is embedded in injected pages and must be presented by browser
+// connections to the metrics WebSocket.
+func generateSessionToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand failing is unrecoverable in practice; fall back to a
+		// time-derived value so the proxy still starts.
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(b)
+}
+
+// SessionToken returns the per-proxy token issued to injected pages.
+func (ps *ProxyServer) SessionToken() string {
+	return ps.sessionToken
+}
+
+// injectionSession returns the values embedded in injected pages.
+func (ps *ProxyServer) injectionSession() InjectionSession {
+	session := InjectionSession{Token: ps.sessionToken, Banner: ps.bannerAttribute(), UI: ps.overlayUIAttribute()}
+	if ps.payloadKeys != nil {
+		session.PublicKey = ps.payloadKeys.PublicKeyBase64()
+	}
+	return session
+}
+
+// checkWebSocketOrigin validates the Origin header of a metrics WebSocket upgrade.
+// Requests without an Origin header come from non-browser clients and are allowed;
+// browser requests must originate from the proxy itself or its public/tunnel URL.
+func (ps *ProxyServer) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		ps.wsRejected.Add(1)
+		return false
+	}
+
+	for _, allowed := range ps.allowedOriginHosts(r) {
+		if strings.EqualFold(parsed.Host, allowed) {
+			return true
+		}
+	}
+
+	ps.wsRejected.Add(1)
+	return false
+}
+
+// allowedOriginHosts lists the hosts a browser may legitimately connect from.
+func (ps *ProxyServer) allowedOriginHosts(r *http.Request) []string {
+	hosts := []string{r.Host}
+	if fwd := ps.clientInfo(r).Host; fwd != r.Host {
+		hosts = append(hosts, fwd)
+	}
+	for _, raw := range []string{ps.PublicURL, ps.TunnelURL()} {
+		if raw == "" {
+			continue
+		}
+		if parsed, err := url.Parse(raw); err == nil && parsed.Host != "" {
+			hosts = append(hosts, parsed.Host)
+		}
+	}
+	return hosts
+}
+
+// checkSessionToken verifies the session token on a browser WebSocket upgrade.
+// Non-browser clients (no Origin header) are not subject to the token check.
+func (ps *ProxyServer) checkSessionToken(r *http.Request) bool {
+	if r.Header.Get("Origin") == "" {
+		return true
+	}
+	token := r.URL.Query().Get(sessionTokenParam)
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(ps.sessionToken)) != 1 {
+		ps.wsRejected.Add(1)
+		return false
+	}
+	return true
+}
+
+// wsRateLimiter is a token bucket limiting messages on a single connection.
+type wsRateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newWSRateLimiter creates a rate limiter allowing rate messages per second
+// with the given burst.
+func newWSRateLimiter(rate, burst int) *wsRateLimiter {
+	return &wsRateLimiter{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow reports whether a message arriving now is within the limit.
+func (l *wsRateLimiter) Allow() bool {
+	return l.allowAt(time.Now())
+}
+
+func (l *wsRateLimiter) allowAt(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newGuardTestProxy(t *testing.T) *ProxyServer {
+	t.Helper()
+	ps, err := NewProxyServer(ProxyConfig{
+		ID:         "guard-test",
+		TargetURL:  "http://localhost:3000",
+		ListenPort: 0,
+		PublicURL:  "https://abc123.trycloudflare.com",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	return ps
+}
+
+func TestGenerateSessionToken_Unique(t *testing.T) {
+	a := generateSessionToken()
+	b := generateSessionToken()
+	if len(a) != 32 {
+		t.Errorf("Expected 32 hex chars, got %d", len(a))
+	}
+	if a == b {
+		t.Error("Expected distinct tokens")
+	}
+}
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	ps := newGuardTestProxy(t)
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "", true},
+		{"same host", "http://127.0.0.1:12345", true},
+		{"public url", "https://abc123.trycloudflare.com", true},
+		{"foreign origin", "https://evil.example.com", false},
+		{"malformed origin", "://", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://127.0.0.1:12345/__devtool_metrics", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := ps.checkWebSocketOrigin(req); got != tt.want {
+				t.Errorf("checkWebSocketOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSessionToken(t *testing.T) {
+	ps := newGuardTestProxy(t)
+	token := ps.SessionToken()
+
+	req := httptest.NewRequest("GET", "/__devtool_metrics", nil)
+	if !ps.checkSessionToken(req) {
+		t.Error("Expected non-browser request without Origin to pass")
+	}
+
+	req = httptest.NewRequest("GET", "/__devtool_metrics", nil)
+	req.Header.Set("Origin", "http://example.com")
+	if ps.checkSessionToken(req) {
+		t.Error("Expected browser request without token to be rejected")
+	}
+
+	req = httptest.NewRequest("GET", "/__devtool_metrics?token=wrong", nil)
+	req.Header.Set("Origin", "http://example.com")
+	if ps.checkSessionToken(req) {
+		t.Error("Expected browser request with wrong token to be rejected")
+	}
+
+	req = httptest.NewRequest("GET", "/__devtool_metrics?token="+token, nil)
+	req.Header.Set("Origin", "http://example.com")
+	if !ps.checkSessionToken(req) {
+		t.Error("Expected browser request with valid token to pass")
+	}
+
+	if got := ps.Stats().WSRejected; got != 2 {
+		t.Errorf("Expected 2 rejected upgrades, got %d", got)
+	}
+}
+
+func TestWSRateLimiter(t *testing.T) {
+	limiter := newWSRateLimiter(10, 5)
+	now := limiter.last
+
+	for i := 0; i < 5; i++ {
+		if !limiter.allowAt(now) {
+			t.Fatalf("Expected message %d within burst to be allowed", i)
+		}
+	}
+	if limiter.allowAt(now) {
+		t.Error("Expected message beyond burst to be dropped")
+	}
+
+	// 100ms at 10/s refills one token
+	if !limiter.allowAt(now.Add(100 * time.Millisecond)) {
+		t.Error("Expected message after refill to be allowed")
+	}
+	if limiter.allowAt(now.Add(100 * time.Millisecond)) {
+		t.Error("Expected bucket to be empty again")
+	}
+}
+
+func TestInjectInstrumentationWithSession(t *testing.T) {
+	html := []byte("<html><head></head><body></body></html>")
+	result := string(InjectInstrumentationWithSession(html, InjectionSession{Token: "abc123"}))
+
+	if !strings.Contains(result, `<script data-devtool-token="abc123">`) {
+		t.Error("Expected session token on injected script tag")
+	}
+	if strings.Count(result, `data-devtool-token="`) != 1 {
+		t.Error("Expected token on exactly one script tag")
+	}
+}
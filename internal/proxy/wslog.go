@@ -0,0 +1,342 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxWSPreview is the payload bytes kept per WebSocket message.
+	maxWSPreview = 512
+	// maxWSBinaryPreview is the payload bytes hex-encoded for binary messages.
+	maxWSBinaryPreview = 64
+)
+
+// WebSocket message directions, from the browser's point of view.
+const (
+	WSOutgoing = "outgoing" // Browser to server
+	WSIncoming = "incoming" // Server to browser
+)
+
+// WebSocket opcodes (RFC 6455 section 5.2).
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+var wsOpcodeNames = map[byte]string{
+	wsOpText:   "text",
+	wsOpBinary: "binary",
+	wsOpClose:  "close",
+	wsOpPing:   "ping",
+	wsOpPong:   "pong",
+}
+
+// WebSocketMessage is one message on a proxied WebSocket connection.
+// Fragmented messages are logged once, when the final frame arrives.
+type WebSocketMessage struct {
+	ID           string    `json:"id"`
+	Timestamp    time.Time `json:"timestamp"`
+	ConnectionID string    `json:"connection_id"`
+	URL          string    `json:"url"`       // Upgrade request path
+	Direction    string    `json:"direction"` // WSOutgoing or WSIncoming
+	Opcode       int       `json:"opcode"`
+	OpcodeName   string    `json:"opcode_name"` // text, binary, close, ping, pong
+	Length       int64     `json:"length"`      // Payload bytes
+	Preview      string    `json:"preview,omitempty"`
+	PreviewHex   bool      `json:"preview_hex,omitempty"` // Preview is hex-encoded binary
+	Truncated    bool      `json:"truncated,omitempty"`
+	Compressed   bool      `json:"compressed,omitempty"` // permessage-deflate; no preview
+	CloseCode    int       `json:"close_code,omitempty"`
+	SinceOpenMs  int64     `json:"since_open_ms"` // Time since the connection opened
+}
+
+// wsConnSeq numbers proxied WebSocket connections.
+var wsConnSeq atomic.Int64
+
+// tapWebSocket logs the frames of an upgraded WebSocket connection. The
+// reverse proxy copies both directions through resp.Body, so wrapping it sees
+// every byte without changing any.
+func (ps *ProxyServer) tapWebSocket(resp *http.Response) {
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return
+	}
+	backend, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		return
+	}
+	url := ""
+	if resp.Request != nil {
+		url = resp.Request.URL.RequestURI()
+	}
+	conn := &wsConn{
+		logger: ps.logger,
+		id:     fmt.Sprintf("ws-%d", wsConnSeq.Add(1)),
+		url:    url,
+		opened: time.Now(),
+	}
+	resp.Body = &wsTap{
+		ReadWriteCloser: backend,
+		incoming:        &wsFrameParser{conn: conn, direction: WSIncoming},
+		outgoing:        &wsFrameParser{conn: conn, direction: WSOutgoing},
+	}
+}
+
+// wsConn is the logging state shared by both directions of a connection.
+type wsConn struct {
+	logger *TrafficLogger
+	id     string
+	url    string
+	opened time.Time
+	seq    atomic.Int64
+}
+
+// wsTap parses the frames passing through a backend connection: reads are
+// server frames, writes are browser frames.
+type wsTap struct {
+	io.ReadWriteCloser
+	incoming *wsFrameParser
+	outgoing *wsFrameParser
+}
+
+func (t *wsTap) Read(p []byte) (int, error) {
+	n, err := t.ReadWriteCloser.Read(p)
+	if n > 0 {
+		t.incoming.feed(p[:n])
+	}
+	return n, err
+}
+
+func (t *wsTap) Write(p []byte) (int, error) {
+	t.outgoing.feed(p)
+	return t.ReadWriteCloser.Write(p)
+}
+
+// wsFrameParser incrementally parses the frames of one direction.
+type wsFrameParser struct {
+	mu        sync.Mutex
+	conn      *wsConn
+	direction string
+	failed    bool // Stream isn't valid WebSocket framing; stop parsing
+
+	header    []byte // Pending header bytes
+	inPayload bool
+	remaining int64 // Payload bytes left in the current frame
+	offset    int64 // Payload bytes seen in the current frame
+	fin       bool
+	opcode    byte
+	masked    bool
+	mask      [4]byte
+
+	// Message being assembled; control frames use their own
+	msg     *wsMessageState
+	control *wsMessageState
+}
+
+// wsMessageState accumulates the frames of one message.
+type wsMessageState struct {
+	opcode     byte
+	length     int64
+	preview    []byte
+	compressed bool
+}
+
+func (p *wsFrameParser) feed(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(data) > 0 && !p.failed {
+		if !p.inPayload {
+			data = p.readHeader(data)
+			continue
+		}
+		n := min(int64(len(data)), p.remaining)
+		p.payload(data[:n])
+		data = data[n:]
+		p.remaining -= n
+		if p.remaining == 0 {
+			p.inPayload = false
+			p.endFrame()
+		}
+	}
+}
+
+// readHeader consumes header bytes and returns the rest of data.
+func (p *wsFrameParser) readHeader(data []byte) []byte {
+	need := 2
+	if len(p.header) >= 2 {
+		switch p.header[1] & 0x7F {
+		case 126:
+			need += 2
+		case 127:
+			need += 8
+		}
+		if p.header[1]&0x80 != 0 {
+			need += 4
+		}
+	}
+	take := min(need-len(p.header), len(data))
+	p.header = append(p.header, data[:take]...)
+	data = data[take:]
+	if len(p.header) < need {
+		return data
+	}
+	if need == 2 && (p.header[1]&0x7F >= 126 || p.header[1]&0x80 != 0) {
+		return data // Extended length or mask key still to come
+	}
+
+	h := p.header
+	p.header = nil
+	p.fin = h[0]&0x80 != 0
+	p.opcode = h[0] & 0x0F
+	p.masked = h[1]&0x80 != 0
+	if _, known := wsOpcodeNames[p.opcode]; !known && p.opcode != wsOpContinuation {
+		p.failed = true
+		return nil
+	}
+
+	pos := 2
+	switch length := int64(h[1] & 0x7F); length {
+	case 126:
+		p.remaining = int64(binary.BigEndian.Uint16(h[2:4]))
+		pos = 4
+	case 127:
+		p.remaining = int64(binary.BigEndian.Uint64(h[2:10]) & (1<<63 - 1))
+		pos = 10
+	default:
+		p.remaining = length
+	}
+	if p.masked {
+		copy(p.mask[:], h[pos:pos+4])
+	}
+	p.offset = 0
+	if p.opcode >= wsOpClose && (!p.fin || p.remaining > 125) {
+		p.failed = true // Control frames are never fragmented or long
+		return nil
+	}
+
+	state := p.frameMessage(h[0]&0x40 != 0)
+	if state == nil {
+		p.failed = true
+		return nil
+	}
+	if p.remaining == 0 {
+		p.endFrame()
+	} else {
+		p.inPayload = true
+	}
+	return data
+}
+
+// frameMessage returns the message the current frame belongs to.
+func (p *wsFrameParser) frameMessage(rsv1 bool) *wsMessageState {
+	switch {
+	case p.opcode >= wsOpClose:
+		p.control = &wsMessageState{opcode: p.opcode}
+		return p.control
+	case p.opcode == wsOpContinuation:
+		return p.msg // nil when no message was started
+	default:
+		p.msg = &wsMessageState{opcode: p.opcode, compressed: rsv1}
+		return p.msg
+	}
+}
+
+// current returns the message of the frame being read.
+func (p *wsFrameParser) current() *wsMessageState {
+	if p.opcode >= wsOpClose {
+		return p.control
+	}
+	return p.msg
+}
+
+// payload records frame payload bytes, unmasking the preview.
+func (p *wsFrameParser) payload(data []byte) {
+	m := p.current()
+	m.length += int64(len(data))
+	if keep := min(maxWSPreview-len(m.preview), len(data)); keep > 0 {
+		start := len(m.preview)
+		m.preview = append(m.preview, data[:keep]...)
+		if p.masked {
+			for i := 0; i < keep; i++ {
+				m.preview[start+i] ^= p.mask[(p.offset+int64(i))%4]
+			}
+		}
+	}
+	p.offset += int64(len(data))
+}
+
+// endFrame logs the message when its final frame is complete.
+func (p *wsFrameParser) endFrame() {
+	m := p.current()
+	p.offset = 0
+	if p.opcode >= wsOpClose {
+		p.control = nil
+	} else if p.fin {
+		p.msg = nil
+	} else {
+		return
+	}
+	p.conn.log(p.direction, m)
+}
+
+// log records a complete message.
+func (c *wsConn) log(direction string, m *wsMessageState) {
+	now := time.Now()
+	entry := WebSocketMessage{
+		ID:           fmt.Sprintf("%s-%d", c.id, c.seq.Add(1)),
+		Timestamp:    now,
+		ConnectionID: c.id,
+		URL:          c.url,
+		Direction:    direction,
+		Opcode:       int(m.opcode),
+		OpcodeName:   wsOpcodeNames[m.opcode],
+		Length:       m.length,
+		Compressed:   m.compressed,
+		SinceOpenMs:  now.Sub(c.opened).Milliseconds(),
+	}
+
+	preview, truncated := m.preview, int64(len(m.preview)) < m.length
+	if m.opcode == wsOpClose && len(preview) >= 2 {
+		entry.CloseCode = int(binary.BigEndian.Uint16(preview[:2]))
+		preview = preview[2:]
+	}
+	text := preview
+	if truncated {
+		text = trimPartialRune(text)
+	}
+	switch {
+	case m.compressed:
+		// Deflated with per-connection state; the payload can't be previewed
+	case m.opcode != wsOpBinary && utf8.Valid(text):
+		entry.Preview, entry.Truncated = string(text), truncated
+	default:
+		if len(preview) > maxWSBinaryPreview {
+			preview, truncated = preview[:maxWSBinaryPreview], true
+		}
+		entry.Preview, entry.PreviewHex, entry.Truncated = hex.EncodeToString(preview), true, truncated
+	}
+	c.logger.LogWebSocket(entry)
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(b) > 0; i++ {
+		if r, _ := utf8.DecodeLastRune(b); r != utf8.RuneError {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,191 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsFrame builds a WebSocket frame, masking the payload when mask is set.
+func wsFrame(fin bool, opcode byte, payload []byte, mask []byte) []byte {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	maskBit := byte(0)
+	if mask != nil {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if mask == nil {
+		return append(frame, payload...)
+	}
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+func newTestWSParser(direction string) (*wsFrameParser, *TrafficLogger) {
+	logger := NewTrafficLogger(100)
+	conn := &wsConn{logger: logger, id: "ws-test", url: "/socket", opened: time.Now()}
+	return &wsFrameParser{conn: conn, direction: direction}, logger
+}
+
+func wsMessages(logger *TrafficLogger) []WebSocketMessage {
+	var out []WebSocketMessage
+	for _, e := range logger.Query(LogFilter{Types: []LogEntryType{LogTypeWebSocket}}) {
+		out = append(out, *e.WebSocket)
+	}
+	return out
+}
+
+func TestWSFrameParser(t *testing.T) {
+	p, logger := newTestWSParser(WSOutgoing)
+	mask := []byte{1, 2, 3, 4}
+
+	// Masked text frame split mid-header and mid-payload
+	stream := wsFrame(true, wsOpText, []byte(`{"type":"hello"}`), mask)
+	p.feed(stream[:1])
+	p.feed(stream[1:5])
+	p.feed(stream[5:])
+
+	// Fragmented message with a ping between the fragments
+	p.feed(wsFrame(false, wsOpText, []byte("part one, "), mask))
+	p.feed(wsFrame(true, wsOpPing, nil, mask))
+	p.feed(wsFrame(true, wsOpContinuation, []byte("part two"), mask))
+
+	// 16-bit length, previewed up to the limit
+	p.feed(wsFrame(true, wsOpText, []byte(strings.Repeat("x", 1000)), mask))
+
+	msgs := wsMessages(logger)
+	if len(msgs) != 4 {
+		t.Fatalf("Expected 4 messages, got %+v", msgs)
+	}
+	if m := msgs[0]; m.Preview != `{"type":"hello"}` || m.OpcodeName != "text" || m.Direction != WSOutgoing || m.URL != "/socket" {
+		t.Errorf("Unexpected first message %+v", m)
+	}
+	if m := msgs[1]; m.OpcodeName != "ping" || m.Length != 0 {
+		t.Errorf("Expected ping logged before the fragmented message completes, got %+v", m)
+	}
+	if m := msgs[2]; m.Preview != "part one, part two" || m.Length != 18 {
+		t.Errorf("Expected fragments joined, got %+v", m)
+	}
+	if m := msgs[3]; m.Length != 1000 || len(m.Preview) != maxWSPreview || !m.Truncated {
+		t.Errorf("Expected truncated preview, got length %d preview %d truncated %v", m.Length, len(m.Preview), m.Truncated)
+	}
+}
+
+func TestWSFrameParserPreviews(t *testing.T) {
+	p, logger := newTestWSParser(WSIncoming)
+
+	p.feed(wsFrame(true, wsOpBinary, []byte{0xde, 0xad, 0xbe, 0xef}, nil))
+	p.feed(wsFrame(true, wsOpClose, append(binary.BigEndian.AppendUint16(nil, 1001), "going away"...), nil))
+
+	// Compressed messages (RSV1) have no readable preview
+	compressed := wsFrame(true, wsOpText, []byte{0x4a, 0x4c}, nil)
+	compressed[0] |= 0x40
+	p.feed(compressed)
+
+	msgs := wsMessages(logger)
+	if len(msgs) != 3 {
+		t.Fatalf("Expected 3 messages, got %+v", msgs)
+	}
+	if m := msgs[0]; m.Preview != "deadbeef" || !m.PreviewHex {
+		t.Errorf("Expected hex preview, got %+v", m)
+	}
+	if m := msgs[1]; m.CloseCode != 1001 || m.Preview != "going away" {
+		t.Errorf("Expected close code and reason, got %+v", m)
+	}
+	if m := msgs[2]; !m.Compressed || m.Preview != "" {
+		t.Errorf("Expected compressed message without preview, got %+v", m)
+	}
+}
+
+func TestWSFrameParserStopsOnGarbage(t *testing.T) {
+	p, logger := newTestWSParser(WSIncoming)
+	p.feed([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+	p.feed(wsFrame(true, wsOpText, []byte("hi"), nil))
+	if msgs := wsMessages(logger); len(msgs) != 0 {
+		t.Errorf("Expected nothing logged for a non-WebSocket stream, got %+v", msgs)
+	}
+}
+
+func TestWebSocketLoggedByProxy(t *testing.T) {
+	// Backend that upgrades, echoes one frame back and closes
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+		header := make([]byte, 6)
+		if _, err := io.ReadFull(rw, header); err != nil {
+			return
+		}
+		payload := make([]byte, header[1]&0x7F)
+		io.ReadFull(rw, payload)
+		for i := range payload {
+			payload[i] ^= header[2+i%4]
+		}
+		conn.Write(wsFrame(true, wsOpText, payload, nil))
+	}))
+	defer backend.Close()
+
+	ps, err := NewProxyServer(ProxyConfig{ID: "ws", TargetURL: backend.URL})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	front := httptest.NewServer(http.HandlerFunc(ps.handleProxy))
+	defer front.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(front.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial proxy: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET /socket HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected upgrade, got %v, %v", resp, err)
+	}
+	conn.Write(wsFrame(true, wsOpText, []byte("ping me"), []byte{9, 8, 7, 6}))
+	echo := make([]byte, 2+len("ping me"))
+	if _, err := io.ReadFull(br, echo); err != nil {
+		t.Fatalf("Failed to read echo: %v", err)
+	}
+
+	msgs := wsMessages(ps.Logger())
+	if len(msgs) != 2 {
+		t.Fatalf("Expected both directions logged, got %+v", msgs)
+	}
+	if msgs[0].Direction != WSOutgoing || msgs[1].Direction != WSIncoming || msgs[0].ConnectionID != msgs[1].ConnectionID {
+		t.Errorf("Unexpected directions %+v", msgs)
+	}
+	for _, m := range msgs {
+		if m.Preview != "ping me" || m.URL != "/socket" {
+			t.Errorf("Unexpected message %+v", m)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package ptyhost
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// ErrDetached is returned by Conn.Read once the terminal was detached while
+// the process keeps running.
+var ErrDetached = errors.New("detached from session")
+
+// Conn is a terminal's attachment to a hosted process. It reads the
+// process's output and writes its input, like the master side of a PTY.
+type Conn struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+	pending []byte // Output left over from the last frame
+
+	exited   bool
+	exitCode int
+}
+
+// Attach connects to a hosted process's socket with the terminal's size.
+func Attach(socketPath string, cols, rows int) (*Conn, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach: %w", err)
+	}
+	c := &Conn{conn: conn}
+	if err := c.Resize(cols, rows); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Read reads process output. It returns io.EOF once the process exited
+// (see ExitCode) and ErrDetached when the terminal was detached.
+func (c *Conn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		typ, payload, err := readFrame(c.conn)
+		if err != nil {
+			return 0, err
+		}
+		switch typ {
+		case FrameData:
+			c.pending = payload
+		case FrameExit:
+			c.exited = true
+			if len(payload) == 4 {
+				c.exitCode = int(int32(binary.BigEndian.Uint32(payload)))
+			}
+			return 0, io.EOF
+		case FrameDetached:
+			return 0, ErrDetached
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write sends input to the process.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	// Large pastes go out in several frames
+	for off := 0; off < len(p); off += maxFrameSize {
+		end := min(off+maxFrameSize, len(p))
+		if err := writeFrame(c.conn, FrameData, p[off:end]); err != nil {
+			return off, err
+		}
+	}
+	return len(p), nil
+}
+
+// Resize sets the size of the process's terminal.
+func (c *Conn) Resize(cols, rows int) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeFrame(c.conn, FrameResize, resizePayload(cols, rows))
+}
+
+// ExitCode reports the process's exit code after Read returned io.EOF.
+func (c *Conn) ExitCode() (int, bool) {
+	return c.exitCode, c.exited
+}
+
+// Close detaches, leaving the process running.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
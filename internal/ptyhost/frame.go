@@ -0,0 +1,75 @@
+// Package ptyhost runs a command in a pseudo-terminal owned by the daemon,
+// so the terminal that started it can go away and another one can attach
+// to the same process later.
+//
+// A terminal attaches over a Unix socket carrying frames: a type byte, a
+// big-endian uint32 payload length, then the payload.
+package ptyhost
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Frame types.
+const (
+	// FrameData carries terminal input (to the host) or output (from it).
+	FrameData byte = iota
+	// FrameResize carries the terminal size as two big-endian uint16s,
+	// columns then rows. The first frame of an attach must be one.
+	FrameResize
+	// FrameExit tells the terminal the process exited; the payload is its
+	// exit code as a big-endian int32.
+	FrameExit
+	// FrameDetached tells the terminal it was detached, by SESSION DETACH or
+	// because another terminal attached. The process keeps running.
+	FrameDetached
+)
+
+// maxFrameSize bounds a frame's payload, so a broken peer can't make the
+// reader allocate without limit.
+const maxFrameSize = 1 << 20
+
+// writeFrame writes one frame.
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	buf := make([]byte, 5+len(payload))
+	buf[0] = typ
+	binary.BigEndian.PutUint32(buf[1:5], uint32(len(payload)))
+	copy(buf[5:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame reads one frame.
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds %d", n, maxFrameSize)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// resizePayload encodes a terminal size.
+func resizePayload(cols, rows int) []byte {
+	var p [4]byte
+	binary.BigEndian.PutUint16(p[0:2], uint16(cols))
+	binary.BigEndian.PutUint16(p[2:4], uint16(rows))
+	return p[:]
+}
+
+// parseResize decodes a terminal size.
+func parseResize(p []byte) (cols, rows int, err error) {
+	if len(p) != 4 {
+		return 0, 0, fmt.Errorf("resize frame has %d bytes, want 4", len(p))
+	}
+	return int(binary.BigEndian.Uint16(p[0:2])), int(binary.BigEndian.Uint16(p[2:4])), nil
+}
@@ -0,0 +1,340 @@
+package ptyhost
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// tailSize is how much recent output is replayed to a terminal that
+	// attaches, so it doesn't start on a blank screen.
+	tailSize = 64 * 1024
+
+	// writeTimeout bounds a write to the attached terminal; a terminal that
+	// stops reading is detached rather than stalling the process.
+	writeTimeout = 5 * time.Second
+
+	// hangupGrace is how long a process gets to exit after SIGHUP before it
+	// is killed.
+	hangupGrace = 3 * time.Second
+)
+
+// Config describes the command to host.
+type Config struct {
+	Command string   `json:"command"`        // Executable, resolved by the caller
+	Args    []string `json:"args,omitempty"` // Arguments
+	Dir     string   `json:"dir,omitempty"`  // Working directory
+	Env     []string `json:"env,omitempty"`  // Environment (default: the daemon's)
+	Cols    int      `json:"cols,omitempty"` // Initial terminal size (default 80x24)
+	Rows    int      `json:"rows,omitempty"`
+}
+
+// Info describes a hosted process.
+type Info struct {
+	Code       string    `json:"code"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args,omitempty"`
+	Dir        string    `json:"dir,omitempty"`
+	PID        int       `json:"pid"`
+	SocketPath string    `json:"socket_path"`
+	StartedAt  time.Time `json:"started_at"`
+	Attached   bool      `json:"attached"`
+	DetachedAt time.Time `json:"detached_at,omitempty"` // When the last terminal went away
+}
+
+// Host runs one command in a PTY and serves it to attaching terminals.
+type Host struct {
+	code       string
+	cfg        Config
+	socketPath string
+	startedAt  time.Time
+
+	cmd      *exec.Cmd
+	ptmx     *os.File
+	listener net.Listener
+
+	mu         sync.Mutex
+	client     net.Conn // Attached terminal, nil while detached
+	detachedAt time.Time
+	tail       []byte
+	cols, rows int
+
+	done chan struct{}
+}
+
+// start starts the command and listens for terminals on socketPath. onExit
+// runs after the process exited and its socket was removed.
+func start(code, socketPath string, cfg Config, onExit func()) (*Host, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+	if cfg.Cols <= 0 || cfg.Rows <= 0 {
+		cfg.Cols, cfg.Rows = 80, 24
+	}
+
+	// A socket left by a daemon that crashed would fail the listen
+	_ = os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	// Whoever connects controls the terminal: owner only, like the daemon's socket
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", socketPath, err)
+	}
+
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Dir = cfg.Dir
+	cmd.Env = cfg.Env
+	ptmx, err := startPTY(cmd, cfg.Cols, cfg.Rows)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", cfg.Command, err)
+	}
+
+	h := &Host{
+		code:       code,
+		cfg:        cfg,
+		socketPath: socketPath,
+		startedAt:  time.Now(),
+		cmd:        cmd,
+		ptmx:       ptmx,
+		listener:   listener,
+		detachedAt: time.Now(),
+		cols:       cfg.Cols,
+		rows:       cfg.Rows,
+		done:       make(chan struct{}),
+	}
+	go h.acceptLoop()
+	go h.run(onExit)
+	return h, nil
+}
+
+// Info describes the host.
+func (h *Host) Info() Info {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	info := Info{
+		Code:       h.code,
+		Command:    h.cfg.Command,
+		Args:       h.cfg.Args,
+		Dir:        h.cfg.Dir,
+		PID:        h.cmd.Process.Pid,
+		SocketPath: h.socketPath,
+		StartedAt:  h.startedAt,
+		Attached:   h.client != nil,
+	}
+	if h.client == nil {
+		info.DetachedAt = h.detachedAt
+	}
+	return info
+}
+
+// run copies output until the process exits, then tells the terminal and
+// cleans up.
+func (h *Host) run(onExit func()) {
+	readDone := make(chan struct{})
+	go func() {
+		h.readLoop()
+		close(readDone)
+	}()
+
+	exitCode := 0
+	if err := h.cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = -1
+		}
+	}
+	// Output still buffered in the PTY is read before the exit is reported;
+	// a background child holding the terminal open doesn't hold us up
+	select {
+	case <-readDone:
+	case <-time.After(time.Second):
+	}
+	h.ptmx.Close()
+	<-readDone
+
+	h.listener.Close()
+	_ = os.Remove(h.socketPath)
+
+	h.mu.Lock()
+	if client := h.client; client != nil {
+		var code [4]byte
+		binary.BigEndian.PutUint32(code[:], uint32(int32(exitCode)))
+		h.writeLocked(FrameExit, code[:])
+		client.Close()
+		h.client = nil
+	}
+	h.mu.Unlock()
+
+	close(h.done)
+	if onExit != nil {
+		onExit()
+	}
+}
+
+// readLoop forwards process output to the attached terminal, keeping the
+// tail for the next one.
+func (h *Host) readLoop() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := h.ptmx.Read(buf)
+		if n > 0 {
+			h.mu.Lock()
+			h.tail = append(h.tail, buf[:n]...)
+			if len(h.tail) > tailSize {
+				h.tail = append(h.tail[:0], h.tail[len(h.tail)-tailSize:]...)
+			}
+			if h.client != nil {
+				h.writeLocked(FrameData, buf[:n])
+			}
+			h.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// acceptLoop attaches terminals until the host stops listening.
+func (h *Host) acceptLoop() {
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			return
+		}
+		go h.serve(conn)
+	}
+}
+
+// serve attaches a terminal, detaching the previous one, and forwards its
+// input until it goes away.
+func (h *Host) serve(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(writeTimeout))
+	typ, payload, err := readFrame(conn)
+	if err != nil || typ != FrameResize {
+		conn.Close()
+		return
+	}
+	cols, rows, err := parseResize(payload)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	h.mu.Lock()
+	if old := h.client; old != nil {
+		h.writeLocked(FrameDetached, nil)
+		old.Close()
+	}
+	h.client = conn
+	if len(h.tail) > 0 {
+		h.writeLocked(FrameData, h.tail)
+	}
+	h.mu.Unlock()
+
+	// Resizing makes a full-screen program redraw for the new terminal,
+	// even one the same size as the last
+	h.resize(cols, rows, true)
+
+loop:
+	for {
+		typ, payload, err := readFrame(conn)
+		if err != nil {
+			break
+		}
+		switch typ {
+		case FrameData:
+			if _, err := h.ptmx.Write(payload); err != nil {
+				break loop
+			}
+		case FrameResize:
+			if cols, rows, err := parseResize(payload); err == nil {
+				h.resize(cols, rows, false)
+			}
+		}
+	}
+
+	h.mu.Lock()
+	if h.client == conn {
+		h.client = nil
+		h.detachedAt = time.Now()
+	}
+	h.mu.Unlock()
+	conn.Close()
+}
+
+// resize sets the terminal size. With redraw, an unchanged size is nudged
+// so the process still gets SIGWINCH.
+func (h *Host) resize(cols, rows int, redraw bool) {
+	if cols <= 0 || rows <= 0 {
+		return
+	}
+	h.mu.Lock()
+	unchanged := cols == h.cols && rows == h.rows
+	h.cols, h.rows = cols, rows
+	h.mu.Unlock()
+
+	if redraw && unchanged && rows > 1 {
+		_ = setSize(h.ptmx, cols, rows-1)
+	}
+	_ = setSize(h.ptmx, cols, rows)
+}
+
+// Detach disconnects the attached terminal, leaving the process running.
+func (h *Host) Detach() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	client := h.client
+	if client == nil {
+		return false
+	}
+	h.writeLocked(FrameDetached, nil)
+	client.Close()
+	h.client = nil
+	h.detachedAt = time.Now()
+	return true
+}
+
+// writeLocked writes a frame to the attached terminal, detaching it when
+// the write fails (caller must hold mu).
+func (h *Host) writeLocked(typ byte, payload []byte) {
+	if h.client == nil {
+		return
+	}
+	h.client.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := writeFrame(h.client, typ, payload); err != nil {
+		h.client.Close()
+		h.client = nil
+		h.detachedAt = time.Now()
+	}
+}
+
+// Stop hangs up the process, killing it if it doesn't exit, and waits for
+// the host to clean up.
+func (h *Host) Stop() {
+	select {
+	case <-h.done:
+		return
+	default:
+	}
+	_ = hangup(h.cmd.Process)
+	select {
+	case <-h.done:
+		return
+	case <-time.After(hangupGrace):
+	}
+	_ = h.cmd.Process.Kill()
+	<-h.done
+}
@@ -0,0 +1,119 @@
+package ptyhost
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// validCode matches session codes, which also name their attach socket.
+var validCode = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Manager keeps the hosted processes of the daemon, by session code.
+type Manager struct {
+	socketDir string
+
+	mu    sync.Mutex
+	hosts map[string]*Host
+}
+
+// NewManager returns a manager creating attach sockets in socketDir.
+func NewManager(socketDir string) *Manager {
+	return &Manager{socketDir: socketDir, hosts: make(map[string]*Host)}
+}
+
+// SocketPath returns the attach socket of a session.
+func (m *Manager) SocketPath(code string) string {
+	return filepath.Join(m.socketDir, fmt.Sprintf("agnt-pty-%s.sock", code))
+}
+
+// Start hosts a command for a session. A session hosts one process at a
+// time.
+func (m *Manager) Start(code string, cfg Config) (Info, error) {
+	if code == "" {
+		return Info{}, fmt.Errorf("session code is required")
+	} else if !validCode.MatchString(code) {
+		return Info{}, fmt.Errorf("invalid session code %q", code)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.hosts[code]; ok {
+		return Info{}, fmt.Errorf("session %q already hosts a process; attach with agnt run --resume %s", code, code)
+	}
+
+	var h *Host
+	h, err := start(code, m.SocketPath(code), cfg, func() {
+		m.mu.Lock()
+		if m.hosts[code] == h {
+			delete(m.hosts, code)
+		}
+		m.mu.Unlock()
+	})
+	if err != nil {
+		return Info{}, err
+	}
+	m.hosts[code] = h
+	return h.Info(), nil
+}
+
+// Get describes the process a session hosts.
+func (m *Manager) Get(code string) (Info, bool) {
+	m.mu.Lock()
+	h, ok := m.hosts[code]
+	m.mu.Unlock()
+	if !ok {
+		return Info{}, false
+	}
+	return h.Info(), true
+}
+
+// List describes all hosted processes, by session code.
+func (m *Manager) List() []Info {
+	m.mu.Lock()
+	hosts := make([]*Host, 0, len(m.hosts))
+	for _, h := range m.hosts {
+		hosts = append(hosts, h)
+	}
+	m.mu.Unlock()
+
+	infos := make([]Info, 0, len(hosts))
+	for _, h := range hosts {
+		infos = append(infos, h.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// Detach disconnects the terminal attached to a session's process. It
+// reports whether one was attached.
+func (m *Manager) Detach(code string) (bool, error) {
+	m.mu.Lock()
+	h, ok := m.hosts[code]
+	m.mu.Unlock()
+	if !ok {
+		return false, fmt.Errorf("session %q hosts no process", code)
+	}
+	return h.Detach(), nil
+}
+
+// StopAll hangs up every hosted process and waits for them to exit.
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	hosts := make([]*Host, 0, len(m.hosts))
+	for _, h := range m.hosts {
+		hosts = append(hosts, h)
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, h := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Stop()
+		}()
+	}
+	wg.Wait()
+}
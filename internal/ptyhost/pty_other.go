@@ -0,0 +1,24 @@
+//go:build !unix
+
+package ptyhost
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// errUnsupported is returned where the daemon can't host terminals.
+var errUnsupported = errors.New("hosted sessions are not supported on this platform")
+
+func startPTY(cmd *exec.Cmd, cols, rows int) (*os.File, error) {
+	return nil, errUnsupported
+}
+
+func setSize(ptmx *os.File, cols, rows int) error {
+	return errUnsupported
+}
+
+func hangup(p *os.Process) error {
+	return p.Kill()
+}
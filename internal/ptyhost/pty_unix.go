@@ -0,0 +1,28 @@
+//go:build unix
+
+package ptyhost
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// startPTY starts cmd with a new pseudo-terminal as its controlling
+// terminal and returns the master side.
+func startPTY(cmd *exec.Cmd, cols, rows int) (*os.File, error) {
+	return pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// setSize resizes the terminal, which sends the process SIGWINCH.
+func setSize(ptmx *os.File, cols, rows int) error {
+	return pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// hangup tells the process its terminal went away, as closing a terminal
+// window does.
+func hangup(p *os.Process) error {
+	return p.Signal(syscall.SIGHUP)
+}
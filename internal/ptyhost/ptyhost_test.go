@@ -0,0 +1,169 @@
+//go:build unix
+
+package ptyhost
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readUntil reads from c until the output contains want.
+func readUntil(t *testing.T, c *Conn, want string) string {
+	t.Helper()
+	var out bytes.Buffer
+	buf := make([]byte, 1024)
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Contains(out.Bytes(), []byte(want)) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %q, got %q", want, out.String())
+		}
+		n, err := c.Read(buf)
+		out.Write(buf[:n])
+		if err != nil {
+			t.Fatalf("Read failed waiting for %q: %v (got %q)", want, err, out.String())
+		}
+	}
+	return out.String()
+}
+
+func TestHost_AttachInputAndExit(t *testing.T) {
+	m := NewManager(t.TempDir())
+	info, err := m.Start("s1", Config{Command: "/bin/sh", Args: []string{"-c", "read x; echo got-$x; exit 3"}, Env: os.Environ()})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if info.PID == 0 || info.Attached {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+
+	c, err := Attach(info.SocketPath, 80, 24)
+	if err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	readUntil(t, c, "got-hello")
+
+	buf := make([]byte, 1024)
+	for {
+		_, err := c.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected EOF at exit, got %v", err)
+		}
+	}
+	if code, ok := c.ExitCode(); !ok || code != 3 {
+		t.Errorf("ExitCode() = %d, %v, want 3", code, ok)
+	}
+
+	// The host is gone once the process exited
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := m.Get("s1"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the exited host to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager_StartRejectsInvalidCode(t *testing.T) {
+	dir := t.TempDir()
+	daemonSock := filepath.Join(dir, "devtool-mcp.sock")
+	if err := os.WriteFile(daemonSock, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(dir)
+	defer m.StopAll()
+	for _, code := range []string{"x/../devtool-mcp", "../x", ".hidden", "a b", `a\b`} {
+		if _, err := m.Start(code, Config{Command: "/bin/sh", Args: []string{"-c", "exit 0"}}); err == nil {
+			t.Errorf("Expected Start to reject session code %q", code)
+		}
+	}
+	if fi, err := os.Stat(daemonSock); err != nil || !fi.Mode().IsRegular() {
+		t.Errorf("Expected the daemon socket to be left alone: %v", err)
+	}
+}
+
+func TestHost_SocketOwnerOnly(t *testing.T) {
+	old := syscall.Umask(0o002)
+	defer syscall.Umask(old)
+
+	m := NewManager(t.TempDir())
+	info, err := m.Start("s1", Config{Command: "/bin/sh", Args: []string{"-c", "sleep 5"}, Env: os.Environ()})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.StopAll()
+
+	fi, err := os.Stat(info.SocketPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected the attach socket to be 0600, got %o", perm)
+	}
+}
+
+func TestHost_ReattachReplaysOutput(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+	defer m.StopAll()
+
+	info, err := m.Start("s2", Config{Command: "/bin/cat", Env: os.Environ()})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if info.SocketPath != filepath.Join(dir, "agnt-pty-s2.sock") {
+		t.Errorf("Unexpected socket path %s", info.SocketPath)
+	}
+	if _, err := m.Start("s2", Config{Command: "/bin/cat"}); err == nil {
+		t.Error("Expected a second Start of the session to fail")
+	}
+
+	first, err := Attach(info.SocketPath, 80, 24)
+	if err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	first.Write([]byte("before-crash\n"))
+	readUntil(t, first, "before-crash")
+
+	// A second terminal takes over; the first is told it was detached
+	second, err := Attach(info.SocketPath, 100, 30)
+	if err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	defer second.Close()
+	readUntil(t, second, "before-crash")
+
+	buf := make([]byte, 1024)
+	for {
+		_, err := first.Read(buf)
+		if errors.Is(err, ErrDetached) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected ErrDetached, got %v", err)
+		}
+	}
+
+	if detached, err := m.Detach("s2"); err != nil || !detached {
+		t.Errorf("Detach() = %v, %v", detached, err)
+	}
+	if got, _ := m.Get("s2"); got.Attached {
+		t.Error("Expected no terminal attached after Detach")
+	}
+}
@@ -0,0 +1,176 @@
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Forward states.
+const (
+	ForwardStarting = "starting" // ssh started, local port not accepting yet
+	ForwardReady    = "ready"
+	ForwardExited   = "exited" // ssh exited; Ensure starts it again
+)
+
+// readyTimeout bounds how long a forward is watched for readiness.
+const readyTimeout = 15 * time.Second
+
+// Forward is an ssh -L forward of a remote port.
+type Forward struct {
+	Host       string    `json:"host"`
+	RemotePort int       `json:"remote_port"`
+	LocalPort  int       `json:"local_port"`
+	State      string    `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+type forward struct {
+	Forward
+	cmd *exec.Cmd
+}
+
+// Forwards keeps one forward per remote host and port.
+type Forwards struct {
+	mu    sync.Mutex
+	byKey map[string]*forward
+
+	// command builds the ssh process; replaced in tests
+	command func(name string, args ...string) *exec.Cmd
+}
+
+// NewForwards returns an empty set of forwards.
+func NewForwards() *Forwards {
+	return &Forwards{byKey: make(map[string]*forward), command: exec.Command}
+}
+
+func forwardKey(host string, remotePort int) string {
+	return host + ":" + strconv.Itoa(remotePort)
+}
+
+// Ensure returns the local port forwarded to remotePort on t's host,
+// starting ssh when there is no live forward. It returns once ssh starts;
+// the port accepts connections when ssh has connected, which List reports.
+// The remote port number is kept locally when it is free.
+func (f *Forwards) Ensure(t Target, remotePort int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := forwardKey(t.Host, remotePort)
+	localPort := 0
+	if fw, ok := f.byKey[key]; ok {
+		if fw.State != ForwardExited {
+			return fw.LocalPort, nil
+		}
+		localPort = fw.LocalPort // Reconnect on the same port, so mapped URLs stay valid
+	}
+	if localPort == 0 {
+		var err error
+		if localPort, err = freeLocalPort(remotePort); err != nil {
+			return 0, err
+		}
+	}
+
+	cmd := f.command("ssh", t.ForwardArgs(localPort, remotePort)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start ssh: %w", err)
+	}
+	fw := &forward{
+		Forward: Forward{Host: t.Host, RemotePort: remotePort, LocalPort: localPort, State: ForwardStarting, StartedAt: time.Now()},
+		cmd:     cmd,
+	}
+	f.byKey[key] = fw
+
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		close(exited)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		fw.State = ForwardExited
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			fw.Error = msg
+		} else if err != nil {
+			fw.Error = err.Error()
+		}
+	}()
+	go f.watchReady(fw, exited)
+	return localPort, nil
+}
+
+// watchReady marks a forward ready once its local port accepts connections.
+func (f *Forwards) watchReady(fw *forward, exited <-chan struct{}) {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(fw.LocalPort))
+	deadline := time.Now().Add(readyTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-exited:
+			return
+		case <-time.After(200 * time.Millisecond):
+		}
+		if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			conn.Close()
+			f.mu.Lock()
+			if fw.State == ForwardStarting {
+				fw.State = ForwardReady
+			}
+			f.mu.Unlock()
+			return
+		}
+	}
+}
+
+// freeLocalPort returns preferred if it is free on loopback, else any free
+// port.
+func freeLocalPort(preferred int) (int, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(preferred)))
+	if err != nil {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// List returns the forwards by host and remote port.
+func (f *Forwards) List() []Forward {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := make([]Forward, 0, len(f.byKey))
+	for _, fw := range f.byKey {
+		list = append(list, fw.Forward)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Host != list[j].Host {
+			return list[i].Host < list[j].Host
+		}
+		return list[i].RemotePort < list[j].RemotePort
+	})
+	return list
+}
+
+// Close stops the forwards to a host, or all forwards when host is empty.
+func (f *Forwards) Close(host string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, fw := range f.byKey {
+		if host != "" && fw.Host != host {
+			continue
+		}
+		if fw.State != ForwardExited && fw.cmd.Process != nil {
+			fw.cmd.Process.Kill()
+		}
+		delete(f.byKey, key)
+	}
+}
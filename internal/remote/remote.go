@@ -0,0 +1,104 @@
+// Package remote runs project commands on a remote checkout over ssh and
+// forwards the remote ports they listen on to this machine.
+package remote
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Target is a remote checkout reached over ssh, from the remote block of
+// .agnt.kdl.
+type Target struct {
+	// Host is an ssh destination: a ~/.ssh/config alias or [user@]host.
+	Host string `json:"host"`
+	// Path is the checkout on the remote host.
+	Path     string `json:"path"`
+	Port     int    `json:"port,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	// Options are extra ssh -o options, such as "ProxyJump=bastion".
+	Options []string `json:"options,omitempty"`
+}
+
+// Validate checks that the target can be connected to.
+func (t Target) Validate() error {
+	switch {
+	case t.Host == "":
+		return fmt.Errorf("remote host required")
+	case strings.HasPrefix(t.Host, "-"):
+		return fmt.Errorf("invalid remote host %q", t.Host)
+	case t.Path == "":
+		return fmt.Errorf("remote path required")
+	}
+	return nil
+}
+
+// sshArgs returns the connection options shared by commands and forwards.
+// BatchMode fails instead of prompting, since there is no terminal to
+// prompt on.
+func (t Target) sshArgs() []string {
+	args := []string{"-o", "BatchMode=yes", "-o", "ServerAliveInterval=15"}
+	if t.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(t.Port))
+	}
+	if t.Identity != "" {
+		args = append(args, "-i", t.Identity)
+	}
+	for _, opt := range t.Options {
+		args = append(args, "-o", opt)
+	}
+	return args
+}
+
+// Command returns the ssh invocation running command in the remote checkout,
+// or its cwd subdirectory, with env as KEY=VALUE entries. A pseudo-terminal
+// is forced so the remote command gets SIGHUP, and stops, when the ssh
+// process is stopped.
+func (t Target) Command(cwd string, env []string, command string, args []string) (string, []string) {
+	dir := t.Path
+	if cwd != "" {
+		if path.IsAbs(cwd) {
+			dir = cwd
+		} else {
+			dir = path.Join(t.Path, cwd)
+		}
+	}
+
+	var script strings.Builder
+	script.WriteString("cd " + Quote(dir) + " && exec")
+	if len(env) > 0 {
+		sorted := append([]string(nil), env...)
+		sort.Strings(sorted)
+		script.WriteString(" env")
+		for _, kv := range sorted {
+			script.WriteString(" " + Quote(kv))
+		}
+	}
+	script.WriteString(" " + Quote(command))
+	for _, arg := range args {
+		script.WriteString(" " + Quote(arg))
+	}
+
+	sshArgs := append(t.sshArgs(), "-tt", t.Host, "--", script.String())
+	return "ssh", sshArgs
+}
+
+// ForwardArgs returns the ssh args forwarding localPort on this machine's
+// loopback to remotePort on the remote host's loopback.
+func (t Target) ForwardArgs(localPort, remotePort int) []string {
+	spec := fmt.Sprintf("127.0.0.1:%d:localhost:%d", localPort, remotePort)
+	return append(t.sshArgs(), "-N", "-o", "ExitOnForwardFailure=yes", "-L", spec, t.Host)
+}
+
+// Quote quotes s for a POSIX shell.
+func Quote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@,+%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,102 @@
+package remote
+
+import (
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"npm":           "npm",
+		"--port=3000":   "--port=3000",
+		"":              "''",
+		"hello world":   "'hello world'",
+		"it's":          `'it'\''s'`,
+		"$HOME; rm -rf": "'$HOME; rm -rf'",
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	target := Target{Host: "devbox", Path: "/srv/app", Port: 2222, Options: []string{"ProxyJump=bastion"}}
+	name, args := target.Command("web", []string{"PORT=3000", "A=x y"}, "npm", []string{"run", "dev"})
+	if name != "ssh" {
+		t.Fatalf("Expected ssh, got %s", name)
+	}
+	got := strings.Join(args, " ")
+	want := "-o BatchMode=yes -o ServerAliveInterval=15 -p 2222 -o ProxyJump=bastion -tt devbox -- " +
+		"cd /srv/app/web && exec env 'A=x y' PORT=3000 npm run dev"
+	if got != want {
+		t.Errorf("Unexpected ssh args\n got: %s\nwant: %s", got, want)
+	}
+
+	fwd := strings.Join(target.ForwardArgs(4000, 3000), " ")
+	if !strings.Contains(fwd, "-N -o ExitOnForwardFailure=yes -L 127.0.0.1:4000:localhost:3000 devbox") {
+		t.Errorf("Unexpected forward args %s", fwd)
+	}
+
+	for _, bad := range []Target{{Path: "/srv"}, {Host: "devbox"}, {Host: "-oProxyCommand=x", Path: "/srv"}} {
+		if bad.Validate() == nil {
+			t.Errorf("Expected %+v to be invalid", bad)
+		}
+	}
+}
+
+func TestForwards(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep in place of ssh")
+	}
+	f := NewForwards()
+	f.command = func(string, ...string) *exec.Cmd { return exec.Command("sleep", "30") }
+	target := Target{Host: "devbox", Path: "/srv/app"}
+
+	// A taken remote port number gets another local port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	taken := ln.Addr().(*net.TCPAddr).Port
+
+	port, err := f.Ensure(target, taken)
+	if err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	if port == taken || port == 0 {
+		t.Errorf("Expected a free local port other than %d, got %d", taken, port)
+	}
+	again, _ := f.Ensure(target, taken)
+	if again != port {
+		t.Errorf("Expected the live forward reused, got %d and %d", port, again)
+	}
+
+	list := f.List()
+	if len(list) != 1 || list[0].RemotePort != taken || list[0].State != ForwardStarting {
+		t.Errorf("Unexpected forwards %+v", list)
+	}
+
+	f.Close("devbox")
+	if len(f.List()) != 0 {
+		t.Error("Expected the forwards closed")
+	}
+
+	// An exited forward is restarted on the same local port
+	f.command = func(string, ...string) *exec.Cmd { return exec.Command("true") }
+	port, _ = f.Ensure(target, 3000)
+	deadline := time.Now().Add(5 * time.Second)
+	for f.List()[0].State != ForwardExited && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if again, _ := f.Ensure(target, 3000); again != port {
+		t.Errorf("Expected the restarted forward on %d, got %d", port, again)
+	}
+	f.Close("")
+}
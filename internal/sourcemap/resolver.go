@@ -0,0 +1,211 @@
+package sourcemap
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFetchSize caps how much of a script or source map is downloaded.
+const maxFetchSize = 32 << 20
+
+// mappingURLPattern matches the sourceMappingURL comment in a script.
+var mappingURLPattern = regexp.MustCompile(`[#@]\s*sourceMappingURL=(\S+)`)
+
+// Resolver fetches scripts and their source maps over HTTP and resolves frames.
+// Maps are cached per script URL, including failures, for the resolver's lifetime.
+//
+// Frames come from pages, which can report any URL, so a resolver only fetches
+// from the origins it was given and gives up on every fetch once its context
+// is done.
+type Resolver struct {
+	ctx     context.Context
+	client  *http.Client
+	origins map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	m   *Map
+	err error
+}
+
+// NewResolver creates a resolver that fetches from origins (such as
+// "http://localhost:3000") until ctx is done. A nil client uses a client with
+// a short timeout; redirects off the origins are refused either way.
+func NewResolver(ctx context.Context, client *http.Client, origins []string) *Resolver {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	r := &Resolver{
+		ctx:     ctx,
+		origins: make(map[string]bool),
+		cache:   make(map[string]*cacheEntry),
+	}
+	for _, o := range origins {
+		if u, err := url.Parse(o); err == nil && u.Host != "" {
+			r.origins[originOf(u)] = true
+		}
+	}
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !r.origins[originOf(req.URL)] {
+			return fmt.Errorf("redirect to %s: %w", req.URL.Host, errOrigin)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	r.client = &c
+	return r
+}
+
+// errOrigin is returned for URLs outside the resolver's origins.
+var errOrigin = errors.New("origin not allowed")
+
+// originOf returns scheme://host:port for u, with the scheme's default port
+// filled in.
+func originOf(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return strings.ToLower(u.Scheme) + "://" + net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+// Resolve maps a stack frame to its original source position.
+func (r *Resolver) Resolve(frame Frame) (Position, error) {
+	m, err := r.MapFor(frame.URL)
+	if err != nil {
+		return Position{}, err
+	}
+	pos, ok := m.Lookup(frame.Line, frame.Column)
+	if !ok {
+		return Position{}, fmt.Errorf("no mapping for %s:%d:%d", frame.URL, frame.Line, frame.Column)
+	}
+	return pos, nil
+}
+
+// MapFor returns the source map for a script URL.
+func (r *Resolver) MapFor(scriptURL string) (*Map, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[scriptURL]
+	r.mu.Unlock()
+	if ok {
+		return entry.m, entry.err
+	}
+
+	m, err := r.load(scriptURL)
+
+	r.mu.Lock()
+	r.cache[scriptURL] = &cacheEntry{m: m, err: err}
+	r.mu.Unlock()
+	return m, err
+}
+
+func (r *Resolver) load(scriptURL string) (*Map, error) {
+	base, err := url.Parse(scriptURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("cannot fetch script %q", scriptURL)
+	}
+
+	script, header, err := r.fetch(scriptURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := header.Get("SourceMap")
+	if ref == "" {
+		ref = header.Get("X-SourceMap")
+	}
+	if ref == "" {
+		ref = FindMappingURL(script)
+	}
+	if ref == "" {
+		// Bundlers commonly serve the map next to the script without a comment
+		ref = base.Path + ".map"
+	}
+
+	var data []byte
+	if strings.HasPrefix(ref, "data:") {
+		data, err = decodeDataURL(ref)
+	} else {
+		var mapURL *url.URL
+		mapURL, err = base.Parse(ref)
+		if err == nil {
+			data, _, err = r.fetch(mapURL.String())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func (r *Resolver) fetch(target string) ([]byte, http.Header, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !r.origins[originOf(u)] {
+		return nil, nil, fmt.Errorf("GET %s: %w", target, errOrigin)
+	}
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, resp.Header, nil
+}
+
+// FindMappingURL returns the last sourceMappingURL reference in a script.
+func FindMappingURL(script []byte) string {
+	matches := mappingURLPattern.FindAllSubmatch(script, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
+
+// decodeDataURL decodes an inline source map data URL.
+func decodeDataURL(ref string) ([]byte, error) {
+	comma := strings.IndexByte(ref, ',')
+	if comma < 0 {
+		return nil, fmt.Errorf("malformed data URL")
+	}
+	meta, payload := ref[len("data:"):comma], ref[comma+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(payload)
+	}
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
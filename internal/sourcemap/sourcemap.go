@@ -0,0 +1,232 @@
+// Package sourcemap decodes JavaScript source maps (revision 3) and maps
+// browser stack frames back to original source positions.
+package sourcemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Map is a decoded source map.
+type Map struct {
+	File           string
+	SourceRoot     string
+	Sources        []string
+	SourcesContent []string
+	Names          []string
+
+	// lines holds the mapping segments for each generated line (0-based),
+	// sorted by generated column.
+	lines [][]segment
+}
+
+// segment is one decoded mapping entry.
+type segment struct {
+	genCol  int
+	source  int // -1 when the segment has no original position
+	line    int
+	col     int
+	name    int // -1 when the segment has no name
+	hasName bool
+}
+
+// Position is an original source location. Line is 1-based, Column is 0-based.
+type Position struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Name   string `json:"name,omitempty"`
+}
+
+// rawMap is the JSON layout of a revision 3 source map.
+type rawMap struct {
+	Version        int       `json:"version"`
+	File           string    `json:"file"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+	Names          []string  `json:"names"`
+	Mappings       string    `json:"mappings"`
+}
+
+// Parse decodes a revision 3 source map. Index maps (with "sections") are not supported.
+func Parse(data []byte) (*Map, error) {
+	var raw rawMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid source map: %w", err)
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+
+	m := &Map{
+		File:       raw.File,
+		SourceRoot: raw.SourceRoot,
+		Sources:    raw.Sources,
+		Names:      raw.Names,
+	}
+	if len(raw.SourcesContent) > 0 {
+		m.SourcesContent = make([]string, len(raw.SourcesContent))
+		for i, c := range raw.SourcesContent {
+			if c != nil {
+				m.SourcesContent[i] = *c
+			}
+		}
+	}
+
+	lines, err := decodeMappings(raw.Mappings, len(raw.Sources), len(raw.Names))
+	if err != nil {
+		return nil, err
+	}
+	m.lines = lines
+	return m, nil
+}
+
+// Lookup maps a generated position to its original position.
+// Line and column are 1-based, as reported in browser stack traces.
+func (m *Map) Lookup(line, column int) (Position, bool) {
+	if line < 1 || line > len(m.lines) {
+		return Position{}, false
+	}
+	segs := m.lines[line-1]
+	col := column - 1
+	if col < 0 {
+		col = 0
+	}
+
+	// Last segment starting at or before the column
+	i := sort.Search(len(segs), func(i int) bool { return segs[i].genCol > col }) - 1
+	if i < 0 {
+		return Position{}, false
+	}
+	seg := segs[i]
+	if seg.source < 0 {
+		return Position{}, false
+	}
+
+	pos := Position{
+		Source: m.sourcePath(seg.source),
+		Line:   seg.line + 1,
+		Column: seg.col,
+	}
+	if seg.hasName {
+		pos.Name = m.Names[seg.name]
+	}
+	return pos, true
+}
+
+// SourceContent returns the embedded content of a source, if present.
+func (m *Map) SourceContent(source string) (string, bool) {
+	for i := range m.Sources {
+		if m.sourcePath(i) == source && i < len(m.SourcesContent) && m.SourcesContent[i] != "" {
+			return m.SourcesContent[i], true
+		}
+	}
+	return "", false
+}
+
+// sourcePath returns a source name joined with the source root.
+func (m *Map) sourcePath(i int) string {
+	src := m.Sources[i]
+	if m.SourceRoot == "" || strings.Contains(src, "://") {
+		return src
+	}
+	if strings.Contains(m.SourceRoot, "://") {
+		return strings.TrimSuffix(m.SourceRoot, "/") + "/" + strings.TrimPrefix(src, "/")
+	}
+	return path.Join(m.SourceRoot, src)
+}
+
+// decodeMappings decodes the "mappings" field into per-line segments.
+func decodeMappings(mappings string, numSources, numNames int) ([][]segment, error) {
+	var (
+		lines  [][]segment
+		cur    []segment
+		source int
+		line   int
+		col    int
+		name   int
+	)
+
+	for _, group := range strings.Split(mappings, ";") {
+		cur = nil
+		genCol := 0
+		if group != "" {
+			for _, field := range strings.Split(group, ",") {
+				if field == "" {
+					continue
+				}
+				values, err := decodeVLQ(field)
+				if err != nil {
+					return nil, err
+				}
+				genCol += values[0]
+				seg := segment{genCol: genCol, source: -1, name: -1}
+
+				switch len(values) {
+				case 1:
+				case 4, 5:
+					source += values[1]
+					line += values[2]
+					col += values[3]
+					if source < 0 || source >= numSources {
+						return nil, fmt.Errorf("source index %d out of range", source)
+					}
+					seg.source, seg.line, seg.col = source, line, col
+					if len(values) == 5 {
+						name += values[4]
+						if name < 0 || name >= numNames {
+							return nil, fmt.Errorf("name index %d out of range", name)
+						}
+						seg.name, seg.hasName = name, true
+					}
+				default:
+					return nil, fmt.Errorf("invalid mapping segment %q", field)
+				}
+				cur = append(cur, seg)
+			}
+		}
+		sort.SliceStable(cur, func(i, j int) bool { return cur[i].genCol < cur[j].genCol })
+		lines = append(lines, cur)
+	}
+	return lines, nil
+}
+
+const vlqChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes a base64 VLQ field into its values.
+func decodeVLQ(field string) ([]int, error) {
+	var (
+		values []int
+		value  int
+		shift  uint
+	)
+	for i := 0; i < len(field); i++ {
+		digit := strings.IndexByte(vlqChars, field[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid VLQ character %q", field[i])
+		}
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			if shift > 30 {
+				return nil, fmt.Errorf("VLQ value too large in %q", field)
+			}
+			continue
+		}
+		if value&1 != 0 {
+			value = -(value >> 1)
+		} else {
+			value >>= 1
+		}
+		values = append(values, value)
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("truncated VLQ field %q", field)
+	}
+	return values, nil
+}
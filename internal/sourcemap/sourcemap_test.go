@@ -0,0 +1,217 @@
+package sourcemap
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// testMap maps:
+//
+//	line 1 col 0 -> src/app.ts 1:0
+//	line 2 col 0 -> src/app.ts 2:0 (name "handleClick")
+//	line 2 col 4 -> src/util.ts 2:4
+const testMap = `{
+	"version": 3,
+	"file": "app.js",
+	"sourceRoot": "",
+	"sources": ["src/app.ts", "src/util.ts"],
+	"sourcesContent": ["const a = 1;", null],
+	"names": ["handleClick"],
+	"mappings": "AAAA;AACAA,ICAI"
+}`
+
+func TestDecodeVLQ(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"A", []int{0}},
+		{"C", []int{1}},
+		{"D", []int{-1}},
+		{"gB", []int{16}},
+		{"AACA", []int{0, 0, 1, 0}},
+	}
+	for _, tt := range tests {
+		got, err := decodeVLQ(tt.in)
+		if err != nil {
+			t.Fatalf("decodeVLQ(%q) error: %v", tt.in, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("decodeVLQ(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := decodeVLQ("g"); err == nil {
+		t.Error("Expected error for truncated VLQ")
+	}
+	if _, err := decodeVLQ("!"); err == nil {
+		t.Error("Expected error for invalid character")
+	}
+}
+
+func TestParseAndLookup(t *testing.T) {
+	m, err := Parse([]byte(testMap))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	tests := []struct {
+		line, col int
+		want      Position
+	}{
+		{1, 1, Position{Source: "src/app.ts", Line: 1, Column: 0}},
+		{2, 1, Position{Source: "src/app.ts", Line: 2, Column: 0, Name: "handleClick"}},
+		{2, 3, Position{Source: "src/app.ts", Line: 2, Column: 0, Name: "handleClick"}},
+		{2, 9, Position{Source: "src/util.ts", Line: 2, Column: 4}},
+	}
+	for _, tt := range tests {
+		got, ok := m.Lookup(tt.line, tt.col)
+		if !ok {
+			t.Errorf("Lookup(%d, %d) found no mapping", tt.line, tt.col)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%d, %d) = %+v, want %+v", tt.line, tt.col, got, tt.want)
+		}
+	}
+
+	if _, ok := m.Lookup(3, 1); ok {
+		t.Error("Expected no mapping past the last line")
+	}
+
+	if content, ok := m.SourceContent("src/app.ts"); !ok || content != "const a = 1;" {
+		t.Errorf("SourceContent = %q, %v", content, ok)
+	}
+	if _, ok := m.SourceContent("src/util.ts"); ok {
+		t.Error("Expected no content for null sourcesContent entry")
+	}
+}
+
+func TestParse_SourceRoot(t *testing.T) {
+	m, err := Parse([]byte(`{"version":3,"sourceRoot":"webpack:///","sources":["./src/a.js"],"names":[],"mappings":"AAAA"}`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	pos, ok := m.Lookup(1, 1)
+	if !ok || pos.Source != "webpack:///./src/a.js" {
+		t.Errorf("Expected source root applied, got %+v", pos)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{
+		`not json`,
+		`{"version":2,"sources":[],"mappings":""}`,
+		`{"version":3,"sources":[],"names":[],"mappings":"AAAA"}`,
+	} {
+		if _, err := Parse([]byte(in)); err == nil {
+			t.Errorf("Expected error for %s", in)
+		}
+	}
+}
+
+func TestParseStack(t *testing.T) {
+	stack := `TypeError: Cannot read properties of undefined (reading 'x')
+    at handleClick (http://localhost:3000/static/js/main.js:2:9)
+    at async load (http://localhost:3000/static/js/main.js:1:1)
+    at http://localhost:3000/vendor.js:10:20
+handleClick@http://localhost:3000/static/js/main.js:2:9
+@http://localhost:3000/vendor.js:5:6`
+
+	frames := ParseStack(stack)
+	want := []Frame{
+		{Function: "handleClick", URL: "http://localhost:3000/static/js/main.js", Line: 2, Column: 9},
+		{Function: "load", URL: "http://localhost:3000/static/js/main.js", Line: 1, Column: 1},
+		{URL: "http://localhost:3000/vendor.js", Line: 10, Column: 20},
+		{Function: "handleClick", URL: "http://localhost:3000/static/js/main.js", Line: 2, Column: 9},
+		{URL: "http://localhost:3000/vendor.js", Line: 5, Column: 6},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("Expected %d frames, got %d: %+v", len(want), len(frames), frames)
+	}
+	for i := range want {
+		if frames[i] != want[i] {
+			t.Errorf("frame %d = %+v, want %+v", i, frames[i], want[i])
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	inline := base64.StdEncoding.EncodeToString([]byte(testMap))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "x();\ny();\n//# sourceMappingURL=maps/app.js.map\n")
+	})
+	mux.HandleFunc("/maps/app.js.map", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testMap)
+	})
+	mux.HandleFunc("/inline.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "x();\n//# sourceMappingURL=data:application/json;base64,%s\n", inline)
+	})
+	mux.HandleFunc("/nomap.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "x();\n")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := NewResolver(context.Background(), server.Client(), []string{server.URL})
+
+	for _, script := range []string{"/app.js", "/inline.js"} {
+		pos, err := r.Resolve(Frame{URL: server.URL + script, Line: 2, Column: 9})
+		if err != nil {
+			t.Fatalf("%s: Resolve error: %v", script, err)
+		}
+		if pos.Source != "src/util.ts" || pos.Line != 2 {
+			t.Errorf("%s: unexpected position %+v", script, pos)
+		}
+	}
+
+	if _, err := r.Resolve(Frame{URL: server.URL + "/nomap.js", Line: 1, Column: 1}); err == nil {
+		t.Error("Expected error for script without a source map")
+	}
+	if _, err := r.Resolve(Frame{URL: "chrome-extension://abc/x.js", Line: 1, Column: 1}); err == nil {
+		t.Error("Expected error for non-HTTP script")
+	}
+}
+
+func TestResolver_Origins(t *testing.T) {
+	var hits atomic.Int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, testMap)
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "x();\n//# sourceMappingURL=%s/app.js.map\n", other.URL)
+	})
+	mux.HandleFunc("/redirect.js", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/x.js", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := NewResolver(context.Background(), server.Client(), []string{server.URL})
+	for _, script := range []string{other.URL + "/x.js", server.URL + "/app.js", server.URL + "/redirect.js"} {
+		if _, err := r.Resolve(Frame{URL: script, Line: 1, Column: 1}); !errors.Is(err, errOrigin) {
+			t.Errorf("%s: expected the origin to be refused, got %v", script, err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("Expected no requests to another origin, got %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = NewResolver(ctx, server.Client(), []string{server.URL})
+	if _, err := r.Resolve(Frame{URL: server.URL + "/app.js", Line: 1, Column: 1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected no fetches once the context is done, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package sourcemap
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame is one parsed stack frame. Line and Column are 1-based.
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	URL      string `json:"url"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+var (
+	// V8: "    at fn (http://host/app.js:10:5)" or "    at http://host/app.js:10:5"
+	v8Frame = regexp.MustCompile(`^\s*at (?:(.+?) \()?(.+?):(\d+):(\d+)\)?\s*$`)
+	// Firefox/Safari: "fn@http://host/app.js:10:5"
+	geckoFrame = regexp.MustCompile(`^\s*(.*?)@(.+?):(\d+):(\d+)\s*$`)
+)
+
+// ParseStack parses a V8 or Firefox/Safari style stack trace into frames.
+// Lines that are not frames (such as the leading error message) are skipped.
+func ParseStack(stack string) []Frame {
+	var frames []Frame
+	for _, line := range strings.Split(stack, "\n") {
+		m := v8Frame.FindStringSubmatch(line)
+		if m == nil {
+			m = geckoFrame.FindStringSubmatch(line)
+		}
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[3])
+		colNo, _ := strconv.Atoi(m[4])
+		url := m[2]
+		// "at async fn (url)" and "eval at ..." wrappers keep the innermost location
+		if i := strings.LastIndex(url, "("); i >= 0 {
+			url = url[i+1:]
+		}
+		frames = append(frames, Frame{
+			Function: strings.TrimPrefix(m[1], "async "),
+			URL:      url,
+			Line:     lineNo,
+			Column:   colNo,
+		})
+	}
+	return frames
+}
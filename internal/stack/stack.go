@@ -0,0 +1,69 @@
+// Package stack orders the services of a stack by their dependencies.
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Plan groups services into levels: each level depends only on earlier
+// ones, so its services can start together once those are ready. deps maps
+// each service to the services it depends on. Services within a level are
+// sorted by name.
+func Plan(deps map[string][]string) ([][]string, error) {
+	for name, ds := range deps {
+		for _, dep := range ds {
+			if _, ok := deps[dep]; !ok {
+				return nil, fmt.Errorf("service %s depends on unknown service %s", name, dep)
+			}
+			if dep == name {
+				return nil, fmt.Errorf("service %s depends on itself", name)
+			}
+		}
+	}
+
+	level := make(map[string]int, len(deps))
+	placed := 0
+	var levels [][]string
+	for placed < len(deps) {
+		var next []string
+		for name, ds := range deps {
+			if _, ok := level[name]; ok {
+				continue
+			}
+			ready := true
+			for _, dep := range ds {
+				if l, ok := level[dep]; !ok || l == len(levels) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = append(next, name)
+			}
+		}
+		if len(next) == 0 {
+			return nil, fmt.Errorf("dependency cycle between %s", strings.Join(unplaced(deps, level), ", "))
+		}
+		sort.Strings(next)
+		for _, name := range next {
+			level[name] = len(levels)
+		}
+		placed += len(next)
+		levels = append(levels, next)
+	}
+	return levels, nil
+}
+
+// unplaced returns the services not given a level, sorted.
+func unplaced(deps map[string][]string, level map[string]int) []string {
+	var names []string
+	for name := range deps {
+		if _, ok := level[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,42 @@
+package stack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	levels, err := Plan(map[string][]string{
+		"web":    {"api"},
+		"api":    {"db", "cache"},
+		"db":     nil,
+		"cache":  nil,
+		"worker": {"db"},
+	})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	want := [][]string{{"cache", "db"}, {"api", "worker"}, {"web"}}
+	if !reflect.DeepEqual(levels, want) {
+		t.Errorf("Plan = %v, want %v", levels, want)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	tests := map[string]map[string][]string{
+		"unknown service": {"api": {"db"}},
+		"itself":          {"api": {"api"}},
+		"cycle between a, b": {
+			"a":    {"b"},
+			"b":    {"a"},
+			"root": nil,
+		},
+	}
+	for want, deps := range tests {
+		_, err := Plan(deps)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q, got %v", want, err)
+		}
+	}
+}
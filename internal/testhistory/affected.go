@@ -0,0 +1,408 @@
+package testhistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxReasons caps how many reasons are kept per selected unit.
+const maxReasons = 5
+
+// Selection is a test unit picked by affected-test analysis and why.
+type Selection struct {
+	Unit    string   `json:"unit"`
+	Reasons []string `json:"reasons"`
+}
+
+// ChangedFiles returns the project's uncommitted files plus, when base is set,
+// the files changed since base (e.g. "main"). Paths are slash-separated and
+// relative to the repository root.
+func ChangedFiles(projectPath, base string) ([]string, error) {
+	set := make(map[string]bool)
+	if _, err := gitOutput(projectPath, "rev-parse", "HEAD"); err != nil {
+		return nil, fmt.Errorf("%s is not a git repository", projectPath)
+	}
+	if base != "" {
+		out, err := gitOutput(projectPath, "diff", "--name-only", base+"...HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("cannot diff against %q: %w", base, err)
+		}
+		for _, f := range strings.Split(strings.TrimSpace(out), "\n") {
+			if f != "" {
+				set[f] = true
+			}
+		}
+	}
+	_, dirty := Snapshot(projectPath)
+	for f := range dirty {
+		set[f] = true
+	}
+
+	files := make([]string, 0, len(set))
+	for f := range set {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Affected selects the units of the suite that may be affected by changed.
+// A change to a global input such as go.mod or package.json selects every unit.
+func (s *Suite) Affected(projectPath string, changed []string) ([]Selection, error) {
+	for _, f := range changed {
+		if isGlobalInput(f) {
+			selections := make([]Selection, 0, len(s.Units))
+			for _, unit := range s.Units {
+				selections = append(selections, Selection{Unit: unit, Reasons: []string{f + " changed (affects all tests)"}})
+			}
+			return selections, nil
+		}
+	}
+
+	switch s.Runner {
+	case RunnerGo:
+		return affectedGo(projectPath, changed)
+	case RunnerJest, RunnerVitest:
+		return s.affectedJS(projectPath, changed), nil
+	default:
+		return s.affectedPython(projectPath, changed), nil
+	}
+}
+
+// reasonSet collects reasons per unit, preserving unit order.
+type reasonSet struct {
+	order   []string
+	reasons map[string][]string
+}
+
+func (r *reasonSet) add(unit, reason string) {
+	if r.reasons == nil {
+		r.reasons = make(map[string][]string)
+	}
+	existing, ok := r.reasons[unit]
+	if !ok {
+		r.order = append(r.order, unit)
+	}
+	if len(existing) >= maxReasons {
+		return
+	}
+	for _, e := range existing {
+		if e == reason {
+			return
+		}
+	}
+	r.reasons[unit] = append(existing, reason)
+}
+
+func (r *reasonSet) selections() []Selection {
+	sort.Strings(r.order)
+	selections := make([]Selection, 0, len(r.order))
+	for _, unit := range r.order {
+		selections = append(selections, Selection{Unit: unit, Reasons: r.reasons[unit]})
+	}
+	return selections
+}
+
+// goPackage is the subset of `go list -json` output used for selection.
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	EmbedFiles   []string
+	Deps         []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// affectedGo selects packages whose files changed, whose dependencies
+// contain a changed package, or whose tests import an affected package.
+func affectedGo(projectPath string, changed []string) ([]Selection, error) {
+	cmd := exec.Command("go", "list", "-json", "./...")
+	cmd.Dir = projectPath
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w", err)
+	}
+	var pkgs []goPackage
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	for dec.More() {
+		var p goPackage
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("invalid go list output: %w", err)
+		}
+		pkgs = append(pkgs, p)
+	}
+	return selectGoPackages(projectPath, pkgs, changed), nil
+}
+
+// selectGoPackages is the package-graph part of affectedGo.
+func selectGoPackages(projectPath string, pkgs []goPackage, changed []string) []Selection {
+	root := repoRoot(projectPath)
+
+	// Map changed files onto the packages that contain them
+	byDir := make(map[string]*goPackage, len(pkgs))
+	for i := range pkgs {
+		byDir[filepath.Clean(pkgs[i].Dir)] = &pkgs[i]
+	}
+	changedPkgs := make(map[string]string) // import path -> changed file
+	testOnly := make(map[string]string)    // packages where only test files changed
+	for _, f := range changed {
+		// Files under testdata belong to the tests of the enclosing package
+		if i := strings.Index(f, "/testdata/"); i >= 0 {
+			if p, ok := byDir[filepath.Join(root, filepath.FromSlash(f[:i]))]; ok {
+				if _, ok := testOnly[p.ImportPath]; !ok {
+					testOnly[p.ImportPath] = f
+				}
+			}
+			continue
+		}
+		p, ok := byDir[filepath.Dir(filepath.Join(root, filepath.FromSlash(f)))]
+		if !ok {
+			continue
+		}
+		base := path.Base(f)
+		switch {
+		case containsName(p.TestGoFiles, base), containsName(p.XTestGoFiles, base):
+			if _, ok := testOnly[p.ImportPath]; !ok {
+				testOnly[p.ImportPath] = f
+			}
+		case strings.HasSuffix(base, ".go"), containsName(p.EmbedFiles, base):
+			if _, ok := changedPkgs[p.ImportPath]; !ok {
+				changedPkgs[p.ImportPath] = f
+			}
+		}
+	}
+
+	var rs reasonSet
+	affected := make(map[string]bool)
+	for _, p := range pkgs {
+		if f, ok := changedPkgs[p.ImportPath]; ok {
+			rs.add(p.ImportPath, "changed: "+f)
+			affected[p.ImportPath] = true
+		}
+		if f, ok := testOnly[p.ImportPath]; ok {
+			rs.add(p.ImportPath, "test changed: "+f)
+		}
+		for _, dep := range p.Deps {
+			if _, ok := changedPkgs[dep]; ok {
+				rs.add(p.ImportPath, "depends on changed package "+dep)
+				affected[p.ImportPath] = true
+			}
+		}
+	}
+	for _, p := range pkgs {
+		for _, imp := range append(append([]string(nil), p.TestImports...), p.XTestImports...) {
+			if imp != p.ImportPath && affected[imp] {
+				rs.add(p.ImportPath, "tests import affected package "+imp)
+			}
+		}
+	}
+
+	// Only packages with tests are worth running
+	var withTests reasonSet
+	for _, p := range pkgs {
+		if len(p.TestGoFiles)+len(p.XTestGoFiles) == 0 {
+			continue
+		}
+		for _, reason := range rs.reasons[p.ImportPath] {
+			withTests.add(p.ImportPath, reason)
+		}
+	}
+	return withTests.selections()
+}
+
+var (
+	jsImport = regexp.MustCompile(`(?:import|export)\s[^'"]*?from\s*['"]([^'"]+)['"]|import\s*\(?\s*['"]([^'"]+)['"]|require\(\s*['"]([^'"]+)['"]\s*\)`)
+	pyImport = regexp.MustCompile(`^\s*(?:from\s+([\w.]+)\s+import|import\s+([\w.]+))`)
+)
+
+// jsExtensions are tried, in order, when resolving extensionless relative imports.
+var jsExtensions = []string{"", ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", "/index.ts", "/index.tsx", "/index.js", "/index.jsx"}
+
+// affectedJS selects test files that changed or transitively import a changed
+// file through relative imports. For Jest, `jest --findRelatedTests` is asked
+// as well so module mappers and other config are honored.
+func (s *Suite) affectedJS(projectPath string, changed []string) []Selection {
+	root := repoRoot(projectPath)
+	changedAbs := make(map[string]string, len(changed))
+	for _, f := range changed {
+		changedAbs[filepath.Join(root, filepath.FromSlash(f))] = f
+	}
+
+	var rs reasonSet
+	for _, unit := range s.Units {
+		abs := filepath.Join(projectPath, filepath.FromSlash(unit))
+		if f, ok := changedAbs[abs]; ok {
+			rs.add(unit, "test changed: "+f)
+		}
+		for _, hit := range walkJSImports(abs, changedAbs) {
+			rs.add(unit, "imports changed "+hit)
+		}
+	}
+
+	if s.Runner == RunnerJest && len(changedAbs) > 0 {
+		args := []string{"jest", "--listTests", "--findRelatedTests"}
+		for abs := range changedAbs {
+			args = append(args, abs)
+		}
+		cmd := exec.Command("npx", args...)
+		cmd.Dir = projectPath
+		if out, err := cmd.Output(); err == nil {
+			for _, line := range strings.Split(string(out), "\n") {
+				line = strings.TrimSpace(line)
+				if !filepath.IsAbs(line) {
+					continue
+				}
+				if rel, err := filepath.Rel(projectPath, line); err == nil {
+					rs.add(filepath.ToSlash(rel), "related via jest --findRelatedTests")
+				}
+			}
+		}
+	}
+	return rs.selections()
+}
+
+// walkJSImports follows relative imports from file and returns the changed
+// files it reaches.
+func walkJSImports(file string, changedAbs map[string]string) []string {
+	var hits []string
+	seen := map[string]bool{file: true}
+	queue := []string{file}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range jsImports(current) {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			if f, ok := changedAbs[dep]; ok {
+				hits = append(hits, f)
+			}
+			queue = append(queue, dep)
+		}
+	}
+	sort.Strings(hits)
+	return hits
+}
+
+// jsImports returns the resolved relative imports of a JS/TS file.
+func jsImports(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var deps []string
+	for _, m := range jsImport.FindAllStringSubmatch(string(data), -1) {
+		spec := m[1] + m[2] + m[3]
+		if !strings.HasPrefix(spec, ".") {
+			continue
+		}
+		base := filepath.Join(filepath.Dir(file), filepath.FromSlash(spec))
+		for _, ext := range jsExtensions {
+			candidate := base + filepath.FromSlash(ext)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				deps = append(deps, candidate)
+				break
+			}
+		}
+	}
+	return deps
+}
+
+// affectedPython selects test files that changed, import a changed module, or
+// sit below a changed conftest.py.
+func (s *Suite) affectedPython(projectPath string, changed []string) []Selection {
+	root := repoRoot(projectPath)
+	changedSet := make(map[string]string, len(changed)) // project-relative -> as reported
+	modules := make(map[string]string)                  // dotted module name -> changed file
+	var conftests []string
+	for _, f := range changed {
+		rel := f
+		if r, err := filepath.Rel(projectPath, filepath.Join(root, filepath.FromSlash(f))); err == nil {
+			rel = filepath.ToSlash(r)
+		}
+		changedSet[rel] = f
+		if !strings.HasSuffix(rel, ".py") {
+			continue
+		}
+		if path.Base(rel) == "conftest.py" {
+			conftests = append(conftests, rel)
+			continue
+		}
+		mod := strings.TrimSuffix(strings.TrimSuffix(rel, ".py"), "/__init__")
+		mod = strings.ReplaceAll(mod, "/", ".")
+		modules[mod] = f
+		// src layout: src/pkg/mod.py is imported as pkg.mod
+		if trimmed, ok := strings.CutPrefix(mod, "src."); ok {
+			modules[trimmed] = f
+		}
+	}
+
+	var rs reasonSet
+	for _, unit := range s.Units {
+		if f, ok := changedSet[unit]; ok {
+			rs.add(unit, "test changed: "+f)
+		}
+		for _, c := range conftests {
+			dir := path.Dir(c)
+			if dir == "." || strings.HasPrefix(unit, dir+"/") {
+				rs.add(unit, "fixture changed: "+c)
+			}
+		}
+		for _, mod := range pythonImports(filepath.Join(projectPath, filepath.FromSlash(unit))) {
+			// "import pkg.mod" or "from pkg.mod import x" may name a parent of the changed module
+			for changedMod, f := range modules {
+				if mod == changedMod || strings.HasPrefix(changedMod, mod+".") {
+					rs.add(unit, "imports changed "+f)
+				}
+			}
+		}
+	}
+	return rs.selections()
+}
+
+// pythonImports returns the modules a Python file imports.
+func pythonImports(file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var mods []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := pyImport.FindStringSubmatch(scanner.Text()); m != nil {
+			mods = append(mods, m[1]+m[2])
+		}
+	}
+	return mods
+}
+
+// repoRoot returns the git top-level directory, or projectPath outside git.
+func repoRoot(projectPath string) string {
+	out, err := gitOutput(projectPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return projectPath
+	}
+	return filepath.Clean(strings.TrimSpace(out))
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
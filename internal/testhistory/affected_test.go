@@ -0,0 +1,109 @@
+package testhistory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSelectGoPackages(t *testing.T) {
+	dir := t.TempDir()
+	pkgs := []goPackage{
+		{ImportPath: "m/store", Dir: filepath.Join(dir, "store"), TestGoFiles: []string{"store_test.go"}},
+		{ImportPath: "m/api", Dir: filepath.Join(dir, "api"), Deps: []string{"m/store"}, TestGoFiles: []string{"api_test.go"}},
+		{ImportPath: "m/cli", Dir: filepath.Join(dir, "cli"), XTestGoFiles: []string{"cli_test.go"}, XTestImports: []string{"m/api"}},
+		{ImportPath: "m/util", Dir: filepath.Join(dir, "util"), TestGoFiles: []string{"util_test.go"}},
+		{ImportPath: "m/cmd", Dir: filepath.Join(dir, "cmd"), Deps: []string{"m/store"}},
+	}
+
+	got := selectGoPackages(dir, pkgs, []string{"store/store.go", "util/testdata/golden.txt", "README.md"})
+	want := []Selection{
+		{Unit: "m/api", Reasons: []string{"depends on changed package m/store"}},
+		{Unit: "m/cli", Reasons: []string{"tests import affected package m/api"}},
+		{Unit: "m/store", Reasons: []string{"changed: store/store.go"}},
+		{Unit: "m/util", Reasons: []string{"test changed: util/testdata/golden.txt"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectGoPackages() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestAffected_JS(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/cart.ts":        `import { price } from "./price"` + "\n",
+		"src/price.ts":       "export const price = 1\n",
+		"src/other.ts":       "export const other = 2\n",
+		"src/cart.test.ts":   `import { cart } from "./cart"` + "\n",
+		"src/other.test.ts":  `const other = require("./other")` + "\n",
+		"src/lazy.test.ts":   `await import("./price")` + "\n",
+		"src/untouched.ts":   "\n",
+		"src/alone.test.tsx": `import React from "react"` + "\n",
+	})
+
+	s := &Suite{Runner: RunnerVitest, Units: []string{"src/alone.test.tsx", "src/cart.test.ts", "src/lazy.test.ts", "src/other.test.ts"}}
+	got, err := s.Affected(dir, []string{"src/price.ts", "src/alone.test.tsx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Selection{
+		{Unit: "src/alone.test.tsx", Reasons: []string{"test changed: src/alone.test.tsx"}},
+		{Unit: "src/cart.test.ts", Reasons: []string{"imports changed src/price.ts"}},
+		{Unit: "src/lazy.test.ts", Reasons: []string{"imports changed src/price.ts"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Affected() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestAffected_Python(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app/models.py":           "\n",
+		"tests/conftest.py":       "\n",
+		"tests/test_models.py":    "from app.models import User\n",
+		"tests/test_views.py":     "import app\n",
+		"other/test_unrelated.py": "import json\n",
+	})
+
+	s := &Suite{Runner: RunnerPytest, Units: []string{"other/test_unrelated.py", "tests/test_models.py", "tests/test_views.py"}}
+
+	got, _ := s.Affected(dir, []string{"app/models.py"})
+	want := []Selection{
+		{Unit: "tests/test_models.py", Reasons: []string{"imports changed app/models.py"}},
+		{Unit: "tests/test_views.py", Reasons: []string{"imports changed app/models.py"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Affected() =\n%v\nwant\n%v", got, want)
+	}
+
+	got, _ = s.Affected(dir, []string{"tests/conftest.py"})
+	if len(got) != 2 || got[0].Reasons[0] != "fixture changed: tests/conftest.py" {
+		t.Errorf("conftest change selected %v", got)
+	}
+}
+
+func TestAffected_GlobalInput(t *testing.T) {
+	s := &Suite{Runner: RunnerJest, Units: []string{"a.test.js", "b.test.js"}}
+	got, err := s.Affected(t.TempDir(), []string{"package.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Reasons[0] != "package.json changed (affects all tests)" {
+		t.Errorf("Affected() = %v", got)
+	}
+}
@@ -0,0 +1,191 @@
+package testhistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoCoverage is returned when none of the inputs to a merge exist.
+var ErrNoCoverage = errors.New("no coverage reports found")
+
+// MergeGoCoverProfiles merges `go test -coverprofile` files into out and
+// returns the statement coverage percentage. Counts are summed for count and
+// atomic mode; set mode keeps the block covered if any profile covered it.
+// Missing profiles (e.g. from shards that failed to build) are skipped.
+func MergeGoCoverProfiles(profiles []string, out string) (float64, error) {
+	mode := ""
+	type block struct {
+		stmts int
+		count int
+	}
+	blocks := make(map[string]*block)
+	var order []string
+
+	for _, profile := range profiles {
+		f, err := os.Open(profile)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			if m, ok := strings.CutPrefix(line, "mode: "); ok {
+				if mode == "" {
+					mode = m
+				}
+				continue
+			}
+			// file:start.col,end.col numStmts count
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			stmts, err1 := strconv.Atoi(fields[1])
+			count, err2 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			b, ok := blocks[fields[0]]
+			if !ok {
+				b = &block{stmts: stmts}
+				blocks[fields[0]] = b
+				order = append(order, fields[0])
+			}
+			if mode == "set" {
+				if count > b.count {
+					b.count = count
+				}
+			} else {
+				b.count += count
+			}
+		}
+		f.Close()
+	}
+
+	if mode == "" {
+		return 0, ErrNoCoverage
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "mode: %s\n", mode)
+	total, covered := 0, 0
+	for _, key := range order {
+		b := blocks[key]
+		fmt.Fprintf(&sb, "%s %d %d\n", key, b.stmts, b.count)
+		total += b.stmts
+		if b.count > 0 {
+			covered += b.stmts
+		}
+	}
+	if err := os.WriteFile(out, []byte(sb.String()), 0644); err != nil {
+		return 0, err
+	}
+	return percent(covered, total), nil
+}
+
+// MergeIstanbulCoverage merges Istanbul coverage-final.json files (written by
+// Jest and Vitest) into out and returns the statement coverage percentage.
+func MergeIstanbulCoverage(reports []string, out string) (float64, error) {
+	merged := make(map[string]map[string]json.RawMessage)
+	found := false
+
+	for _, report := range reports {
+		data, err := os.ReadFile(report)
+		if err != nil {
+			continue
+		}
+		var files map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(data, &files); err != nil {
+			return 0, fmt.Errorf("invalid coverage report %s: %w", report, err)
+		}
+		found = true
+		for file, cov := range files {
+			existing, ok := merged[file]
+			if !ok {
+				merged[file] = cov
+				continue
+			}
+			for _, key := range []string{"s", "f"} {
+				existing[key] = mergeCounts(existing[key], cov[key])
+			}
+			existing["b"] = mergeBranchCounts(existing["b"], cov["b"])
+		}
+	}
+
+	if !found {
+		return 0, ErrNoCoverage
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return 0, err
+	}
+
+	total, covered := 0, 0
+	for _, cov := range merged {
+		var s map[string]int
+		json.Unmarshal(cov["s"], &s)
+		for _, count := range s {
+			total++
+			if count > 0 {
+				covered++
+			}
+		}
+	}
+	return percent(covered, total), nil
+}
+
+// mergeCounts sums two Istanbul hit count maps ({"0": 3, "1": 0}).
+func mergeCounts(a, b json.RawMessage) json.RawMessage {
+	var ma, mb map[string]int
+	json.Unmarshal(a, &ma)
+	json.Unmarshal(b, &mb)
+	if ma == nil {
+		ma = make(map[string]int)
+	}
+	for k, v := range mb {
+		ma[k] += v
+	}
+	out, _ := json.Marshal(ma)
+	return out
+}
+
+// mergeBranchCounts sums two Istanbul branch count maps ({"0": [1, 0]}).
+func mergeBranchCounts(a, b json.RawMessage) json.RawMessage {
+	var ma, mb map[string][]int
+	json.Unmarshal(a, &ma)
+	json.Unmarshal(b, &mb)
+	if ma == nil {
+		ma = make(map[string][]int)
+	}
+	for k, counts := range mb {
+		dst := ma[k]
+		for len(dst) < len(counts) {
+			dst = append(dst, 0)
+		}
+		for i, v := range counts {
+			dst[i] += v
+		}
+		ma[k] = dst
+	}
+	out, _ := json.Marshal(ma)
+	return out
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(total)
+}
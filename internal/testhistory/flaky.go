@@ -0,0 +1,155 @@
+package testhistory
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// globalInputs are files whose changes can affect any test.
+var globalInputs = []string{
+	"go.mod", "go.sum",
+	"package.json", "package-lock.json", "pnpm-lock.yaml", "yarn.lock", "bun.lockb",
+	"requirements.txt", "pyproject.toml", "poetry.lock",
+	"tsconfig.json", "jest.config.js", "jest.config.ts", "vitest.config.ts", "vite.config.ts",
+}
+
+// FlakyTest reports a test whose outcome flipped without related changes.
+type FlakyTest struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Dir              string    `json:"dir,omitempty"`
+	Runs             int       `json:"runs"`
+	Passes           int       `json:"passes"`
+	Failures         int       `json:"failures"`
+	Flips            int       `json:"flips"`             // Outcome changes between consecutive runs
+	UnexplainedFlips int       `json:"unexplained_flips"` // Flips with no related file changes
+	LastFlip         time.Time `json:"last_flip"`
+	Recent           string    `json:"recent"` // Recent outcomes, oldest first: P=pass F=fail
+}
+
+// ChangeFunc returns the files changed between two runs; see ChangedBetween.
+type ChangeFunc func(a, b *Run) (files []string, known bool)
+
+// DetectFlaky finds tests whose pass/fail outcome flipped between consecutive
+// runs while none of the files changed between those runs relate to the test.
+// A change is related when it is under the test's directory or is a global
+// input such as go.mod or package.json. Tests without a known directory only
+// count flips where nothing changed at all.
+func DetectFlaky(runs []*Run, changed ChangeFunc) []FlakyTest {
+	type state struct {
+		test     FlakyTest
+		last     Outcome
+		lastRun  int
+		outcomes []byte
+	}
+	tests := make(map[string]*state)
+
+	// Change sets are computed once per consecutive run pair
+	changes := make(map[[2]int][]string)
+	knownChanges := make(map[[2]int]bool)
+	changesFor := func(i, j int) ([]string, bool) {
+		key := [2]int{i, j}
+		if files, ok := changes[key]; ok || knownChanges[key] {
+			return files, knownChanges[key]
+		}
+		files, known := changed(runs[i], runs[j])
+		changes[key], knownChanges[key] = files, known
+		return files, known
+	}
+
+	for i, run := range runs {
+		for _, res := range run.Results {
+			if res.Outcome != OutcomePass && res.Outcome != OutcomeFail {
+				continue
+			}
+			id := res.ID()
+			st, ok := tests[id]
+			if !ok {
+				st = &state{test: FlakyTest{ID: id, Name: res.Name, Dir: res.Dir}, lastRun: -1}
+				tests[id] = st
+			}
+
+			st.test.Runs++
+			if res.Outcome == OutcomePass {
+				st.test.Passes++
+				st.outcomes = append(st.outcomes, 'P')
+			} else {
+				st.test.Failures++
+				st.outcomes = append(st.outcomes, 'F')
+			}
+
+			if st.lastRun >= 0 && st.last != res.Outcome {
+				st.test.Flips++
+				files, known := changesFor(st.lastRun, i)
+				if known && !relatedChange(res.Dir, files) {
+					st.test.UnexplainedFlips++
+					st.test.LastFlip = run.Timestamp
+				}
+			}
+			st.last = res.Outcome
+			st.lastRun = i
+		}
+	}
+
+	var flaky []FlakyTest
+	for _, st := range tests {
+		if st.test.UnexplainedFlips == 0 {
+			continue
+		}
+		recent := st.outcomes
+		if len(recent) > 10 {
+			recent = recent[len(recent)-10:]
+		}
+		st.test.Recent = string(recent)
+		flaky = append(flaky, st.test)
+	}
+
+	sort.Slice(flaky, func(i, j int) bool {
+		if flaky[i].UnexplainedFlips != flaky[j].UnexplainedFlips {
+			return flaky[i].UnexplainedFlips > flaky[j].UnexplainedFlips
+		}
+		if !flaky[i].LastFlip.Equal(flaky[j].LastFlip) {
+			return flaky[i].LastFlip.After(flaky[j].LastFlip)
+		}
+		return flaky[i].ID < flaky[j].ID
+	})
+	return flaky
+}
+
+// relatedChange reports whether any changed file could explain an outcome
+// change for a test in dir.
+func relatedChange(dir string, files []string) bool {
+	if len(files) == 0 {
+		return false
+	}
+	if dir == "" {
+		return true
+	}
+	for _, f := range files {
+		if isGlobalInput(f) {
+			return true
+		}
+		if dir == "." {
+			if !strings.Contains(f, "/") {
+				return true
+			}
+			continue
+		}
+		if f == dir || strings.HasPrefix(f, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isGlobalInput(file string) bool {
+	base := path.Base(file)
+	for _, g := range globalInputs {
+		if base == g {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package testhistory
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func makeRun(i int, commit string, outcomes map[string]Outcome) *Run {
+	run := &Run{
+		ID:        commit,
+		Timestamp: time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+		Commit:    commit,
+	}
+	names := make([]string, 0, len(outcomes))
+	for name := range outcomes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		run.Results = append(run.Results, Result{Name: name, Package: "example.com/app/" + name, Dir: name, Outcome: outcomes[name]})
+	}
+	return run
+}
+
+func TestDetectFlaky(t *testing.T) {
+	runs := []*Run{
+		makeRun(0, "c1", map[string]Outcome{"cache": OutcomePass, "api": OutcomePass, "db": OutcomePass}),
+		makeRun(1, "c2", map[string]Outcome{"cache": OutcomeFail, "api": OutcomeFail, "db": OutcomeSkip}),
+		makeRun(2, "c3", map[string]Outcome{"cache": OutcomePass, "api": OutcomeFail, "db": OutcomeFail}),
+		makeRun(3, "c4", map[string]Outcome{"cache": OutcomeFail, "api": OutcomePass, "db": OutcomeFail}),
+	}
+
+	// c1→c2 touches api, c2→c3 touches nothing, c3→c4 touches docs only
+	changed := func(a, b *Run) ([]string, bool) {
+		switch a.Commit + ">" + b.Commit {
+		case "c1>c2":
+			return []string{"api/handler.go"}, true
+		case "c2>c3":
+			return nil, true
+		case "c3>c4":
+			return []string{"docs/README.md"}, true
+		}
+		return nil, false
+	}
+
+	flaky := DetectFlaky(runs, changed)
+	if len(flaky) != 2 {
+		t.Fatalf("Expected 2 flaky tests, got %+v", flaky)
+	}
+
+	cache := flaky[0]
+	if cache.Name != "cache" || cache.Flips != 3 || cache.UnexplainedFlips != 3 || cache.Recent != "PFPF" {
+		t.Errorf("Unexpected cache report %+v", cache)
+	}
+	if !cache.LastFlip.Equal(runs[3].Timestamp) {
+		t.Errorf("Expected last flip at run 3, got %v", cache.LastFlip)
+	}
+
+	// api's first flip is explained by the api change; the second is not
+	api := flaky[1]
+	if api.Name != "api" || api.Flips != 2 || api.UnexplainedFlips != 1 {
+		t.Errorf("Unexpected api report %+v", api)
+	}
+}
+
+func TestDetectFlaky_UnknownChangesNotFlagged(t *testing.T) {
+	runs := []*Run{
+		makeRun(0, "", map[string]Outcome{"x": OutcomePass}),
+		makeRun(1, "", map[string]Outcome{"x": OutcomeFail}),
+	}
+	flaky := DetectFlaky(runs, func(a, b *Run) ([]string, bool) { return nil, false })
+	if len(flaky) != 0 {
+		t.Errorf("Expected no flaky tests without change info, got %+v", flaky)
+	}
+}
+
+func TestRelatedChange(t *testing.T) {
+	tests := []struct {
+		dir   string
+		files []string
+		want  bool
+	}{
+		{"internal/cache", nil, false},
+		{"internal/cache", []string{"internal/cache/lru.go"}, true},
+		{"internal/cache", []string{"internal/cachex/a.go"}, false},
+		{"internal/cache", []string{"go.sum"}, true},
+		{"src", []string{"web/package.json"}, true},
+		{".", []string{"main.go"}, true},
+		{".", []string{"internal/a.go"}, false},
+		{"", []string{"README.md"}, true},
+	}
+	for _, tt := range tests {
+		if got := relatedChange(tt.dir, tt.files); got != tt.want {
+			t.Errorf("relatedChange(%q, %v) = %v, want %v", tt.dir, tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshotAndChangedBetween(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		p := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte(content), 0644)
+	}
+
+	git("init", "-q")
+	write("a/a.go", "package a")
+	git("add", ".")
+	git("commit", "-qm", "init")
+
+	r1 := &Run{}
+	r1.Commit, r1.Dirty = Snapshot(dir)
+	if r1.Commit == "" || len(r1.Dirty) != 0 {
+		t.Fatalf("Expected clean snapshot, got %q %v", r1.Commit, r1.Dirty)
+	}
+
+	write("b/b.go", "package b")
+	r2 := &Run{}
+	r2.Commit, r2.Dirty = Snapshot(dir)
+	files, known := ChangedBetween(dir, r1, r2)
+	if !known || len(files) != 1 || files[0] != "b/b.go" {
+		t.Errorf("Expected untracked b/b.go as change, got %v %v", files, known)
+	}
+
+	git("add", ".")
+	git("commit", "-qm", "b")
+	write("a/a.go", "package a // edited")
+	r3 := &Run{}
+	r3.Commit, r3.Dirty = Snapshot(dir)
+	files, known = ChangedBetween(dir, r1, r3)
+	sort.Strings(files)
+	if !known || len(files) != 2 || files[0] != "a/a.go" || files[1] != "b/b.go" {
+		t.Errorf("Expected committed and dirty changes, got %v %v", files, known)
+	}
+
+	if files, known := ChangedBetween(dir, r3, r3); !known || len(files) != 0 {
+		t.Errorf("Expected no changes for identical runs, got %v %v", files, known)
+	}
+}
+
+func TestHistoryAppendAndTrim(t *testing.T) {
+	dir := t.TempDir()
+	h := Open(dir)
+	h.maxRuns = 3
+
+	for i := 0; i < 5; i++ {
+		if err := h.Append(makeRun(i, "c", map[string]Outcome{"x": OutcomePass})); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	reopened := Open(dir)
+	reopened.maxRuns = 3
+	runs, err := reopened.Runs()
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 runs after trim, got %d", len(runs))
+	}
+	if !runs[0].Timestamp.Equal(time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)) {
+		t.Errorf("Expected oldest runs trimmed, first run at %v", runs[0].Timestamp)
+	}
+}
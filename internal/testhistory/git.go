@@ -0,0 +1,95 @@
+package testhistory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshot records the git state of a project: HEAD and a content hash of
+// every uncommitted file. Returns an empty commit outside a git repository.
+func Snapshot(projectPath string) (commit string, dirty map[string]string) {
+	out, err := gitOutput(projectPath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", nil
+	}
+	commit = strings.TrimSpace(out)
+
+	status, err := gitOutput(projectPath, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return commit, nil
+	}
+
+	dirty = make(map[string]string)
+	entries := strings.Split(status, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, file := entry[:2], entry[3:]
+		// Renames and copies are followed by the original path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+		dirty[file] = hashFile(filepath.Join(projectPath, filepath.FromSlash(file)))
+	}
+	return commit, dirty
+}
+
+// ChangedBetween returns the files that differ between two runs. known is
+// false when the change set cannot be determined (no git info, or a commit
+// is no longer available).
+func ChangedBetween(projectPath string, a, b *Run) (files []string, known bool) {
+	if a.Commit == "" || b.Commit == "" {
+		return nil, false
+	}
+
+	set := make(map[string]bool)
+	if a.Commit != b.Commit {
+		out, err := gitOutput(projectPath, "diff", "--name-only", a.Commit, b.Commit)
+		if err != nil {
+			return nil, false
+		}
+		for _, f := range strings.Split(strings.TrimSpace(out), "\n") {
+			if f != "" {
+				set[f] = true
+			}
+		}
+	}
+	for f, hash := range a.Dirty {
+		if b.Dirty[f] != hash {
+			set[f] = true
+		}
+	}
+	for f := range b.Dirty {
+		if _, ok := a.Dirty[f]; !ok {
+			set[f] = true
+		}
+	}
+
+	for f := range set {
+		files = append(files, f)
+	}
+	return files, true
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// hashFile returns a short content hash, or "deleted" if the file is missing.
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "deleted"
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
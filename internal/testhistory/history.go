@@ -0,0 +1,186 @@
+package testhistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// HistoryDirName is the project-relative directory holding test history.
+	HistoryDirName = ".agnt"
+
+	// HistoryFileName is the test history file name (JSON lines, one run per line).
+	HistoryFileName = "test-history.jsonl"
+
+	// DefaultMaxRuns is how many runs are kept per project.
+	DefaultMaxRuns = 100
+)
+
+// Run is one recorded test run.
+type Run struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source,omitempty"` // Process ID or label the results came from
+	Commit    string    `json:"commit,omitempty"` // git HEAD at record time
+	// Dirty maps uncommitted files to a content hash at record time.
+	Dirty   map[string]string `json:"dirty,omitempty"`
+	Results []Result          `json:"results"`
+}
+
+// Counts returns the number of passed, failed and skipped tests in the run.
+func (r *Run) Counts() (passed, failed, skipped int) {
+	for _, res := range r.Results {
+		switch res.Outcome {
+		case OutcomePass:
+			passed++
+		case OutcomeFail:
+			failed++
+		default:
+			skipped++
+		}
+	}
+	return
+}
+
+// History is the file-backed run history for one project.
+type History struct {
+	path    string
+	maxRuns int
+
+	mu     sync.Mutex
+	runs   []*Run
+	loaded bool
+}
+
+// Open returns the history for a project. The file is read lazily.
+func Open(projectPath string) *History {
+	return &History{
+		path:    filepath.Join(projectPath, HistoryDirName, HistoryFileName),
+		maxRuns: DefaultMaxRuns,
+	}
+}
+
+// Append records a run, trimming the oldest runs beyond the limit.
+func (h *History) Append(run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.loadLocked(); err != nil {
+		return err
+	}
+
+	if run.Timestamp.IsZero() {
+		run.Timestamp = time.Now()
+	}
+	if run.ID == "" {
+		run.ID = fmt.Sprintf("run-%d", run.Timestamp.UnixNano())
+	}
+	h.runs = append(h.runs, run)
+
+	if len(h.runs) > h.maxRuns {
+		h.runs = h.runs[len(h.runs)-h.maxRuns:]
+		return h.rewriteLocked()
+	}
+	return h.appendLocked(run)
+}
+
+// Runs returns the recorded runs, oldest first.
+func (h *History) Runs() ([]*Run, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.loadLocked(); err != nil {
+		return nil, err
+	}
+	runs := make([]*Run, len(h.runs))
+	copy(runs, h.runs)
+	return runs, nil
+}
+
+func (h *History) loadLocked() error {
+	if h.loaded {
+		return nil
+	}
+
+	f, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			h.loaded = true
+			return nil
+		}
+		return fmt.Errorf("failed to open test history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue // Skip corrupt lines rather than losing the whole history
+		}
+		h.runs = append(h.runs, &run)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read test history: %w", err)
+	}
+
+	if len(h.runs) > h.maxRuns {
+		h.runs = h.runs[len(h.runs)-h.maxRuns:]
+	}
+	h.loaded = true
+	return nil
+}
+
+func (h *History) appendLocked(run *Run) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open test history: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func (h *History) rewriteLocked() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	tmp := h.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write test history: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, run := range h.runs {
+		data, err := json.Marshal(run)
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
@@ -0,0 +1,204 @@
+// Package testhistory records test outcomes across runs and detects flaky
+// tests whose results change without related source changes.
+package testhistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Outcome is the result of a single test.
+type Outcome string
+
+const (
+	OutcomePass Outcome = "pass"
+	OutcomeFail Outcome = "fail"
+	OutcomeSkip Outcome = "skip"
+)
+
+// Result is the outcome of one test in one run.
+type Result struct {
+	Name    string  `json:"name"`
+	Package string  `json:"package,omitempty"` // Go import path
+	File    string  `json:"file,omitempty"`    // Test file for JS/Python runners
+	Dir     string  `json:"dir,omitempty"`     // Project-relative directory the test lives in
+	Outcome Outcome `json:"outcome"`
+	Elapsed float64 `json:"elapsed,omitempty"` // Seconds
+}
+
+// ID returns a stable identifier for the test across runs.
+func (r Result) ID() string {
+	switch {
+	case r.Package != "":
+		return r.Package + "::" + r.Name
+	case r.File != "":
+		return r.File + "::" + r.Name
+	default:
+		return r.Name
+	}
+}
+
+var (
+	goVerboseResult = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`)
+	goPackageResult = regexp.MustCompile(`^(ok|FAIL|---)\s+(\S+)\s+(?:[\d.]+s|\(cached\)|\[)`)
+	jestFileHeader  = regexp.MustCompile(`^\s*(PASS|FAIL)\s+(\S+\.\w+)`)
+	jestTestResult  = regexp.MustCompile(`^\s+([✓✔√✕✗×○])\s+(.+?)(?:\s+\((\d+(?:\.\d+)?)\s*m?s\))?\s*$`)
+	vitestResult    = regexp.MustCompile(`^\s*([✓✔√✕✗×↓])\s+(\S+\.\w+) > (.+?)(?:\s+(\d+(?:\.\d+)?)\s*ms)?\s*$`)
+	pytestResult    = regexp.MustCompile(`^(\S+\.py)::(\S+)\s+(PASSED|FAILED|ERROR|SKIPPED|XFAIL|XPASS)`)
+)
+
+// ParseOutput extracts test results from runner output. It understands
+// `go test -json`, `go test -v`, Jest/Vitest default reporters and `pytest -v`.
+func ParseOutput(output string) []Result {
+	var (
+		results []Result
+		pending []int // Go -v results awaiting their package line
+		jestIn  string
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "{") {
+			if r, ok := parseGoJSON(line); ok {
+				results = append(results, r)
+			}
+			continue
+		}
+
+		if m := goVerboseResult.FindStringSubmatch(line); m != nil {
+			elapsed, _ := strconv.ParseFloat(m[3], 64)
+			results = append(results, Result{Name: m[2], Outcome: goOutcome(m[1]), Elapsed: elapsed})
+			pending = append(pending, len(results)-1)
+			continue
+		}
+		if m := goPackageResult.FindStringSubmatch(line); m != nil && len(pending) > 0 {
+			for _, i := range pending {
+				results[i].Package = m[2]
+			}
+			pending = pending[:0]
+			continue
+		}
+
+		if m := pytestResult.FindStringSubmatch(line); m != nil {
+			results = append(results, Result{Name: m[2], File: m[1], Outcome: pytestOutcome(m[3])})
+			continue
+		}
+
+		if m := vitestResult.FindStringSubmatch(line); m != nil {
+			r := Result{Name: m[3], File: m[2], Outcome: jestOutcome(m[1])}
+			if m[4] != "" {
+				ms, _ := strconv.ParseFloat(m[4], 64)
+				r.Elapsed = ms / 1000
+			}
+			results = append(results, r)
+			continue
+		}
+
+		if m := jestFileHeader.FindStringSubmatch(line); m != nil {
+			jestIn = m[2]
+			continue
+		}
+		if jestIn != "" {
+			if m := jestTestResult.FindStringSubmatch(line); m != nil {
+				r := Result{Name: m[2], File: jestIn, Outcome: jestOutcome(m[1])}
+				if m[3] != "" {
+					ms, _ := strconv.ParseFloat(m[3], 64)
+					r.Elapsed = ms / 1000
+				}
+				results = append(results, r)
+			}
+		}
+	}
+	return results
+}
+
+// parseGoJSON parses one `go test -json` event, returning test-level outcomes only.
+func parseGoJSON(line string) (Result, bool) {
+	var ev struct {
+		Action  string
+		Package string
+		Test    string
+		Elapsed float64
+	}
+	if err := json.Unmarshal([]byte(line), &ev); err != nil || ev.Test == "" {
+		return Result{}, false
+	}
+	switch ev.Action {
+	case "pass", "fail", "skip":
+		return Result{Name: ev.Test, Package: ev.Package, Outcome: Outcome(ev.Action), Elapsed: ev.Elapsed}, true
+	}
+	return Result{}, false
+}
+
+func goOutcome(s string) Outcome {
+	switch s {
+	case "PASS":
+		return OutcomePass
+	case "FAIL":
+		return OutcomeFail
+	}
+	return OutcomeSkip
+}
+
+func jestOutcome(mark string) Outcome {
+	switch mark {
+	case "✓", "✔", "√":
+		return OutcomePass
+	case "○", "↓":
+		return OutcomeSkip
+	}
+	return OutcomeFail
+}
+
+func pytestOutcome(s string) Outcome {
+	switch s {
+	case "PASSED", "XPASS":
+		return OutcomePass
+	case "SKIPPED", "XFAIL":
+		return OutcomeSkip
+	}
+	return OutcomeFail
+}
+
+// AssignDirs fills in the project-relative directory of each result so test
+// outcomes can be related to changed files. Go packages are mapped through the
+// module path in the project's go.mod.
+func AssignDirs(projectPath string, results []Result) {
+	modulePath := readModulePath(projectPath)
+	for i := range results {
+		r := &results[i]
+		switch {
+		case r.File != "":
+			r.Dir = path.Dir(filepath.ToSlash(r.File))
+		case r.Package != "" && modulePath != "":
+			if r.Package == modulePath {
+				r.Dir = "."
+			} else if rel, ok := strings.CutPrefix(r.Package, modulePath+"/"); ok {
+				r.Dir = rel
+			}
+		}
+	}
+}
+
+// readModulePath returns the module path declared in projectPath/go.mod.
+func readModulePath(projectPath string) string {
+	data, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package testhistory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOutput_GoVerbose(t *testing.T) {
+	out := `=== RUN   TestAdd
+--- PASS: TestAdd (0.00s)
+=== RUN   TestSub
+    --- FAIL: TestSub/negative (0.01s)
+--- FAIL: TestSub (0.02s)
+--- SKIP: TestSlow (0.00s)
+FAIL
+FAIL	example.com/app/internal/math	0.031s
+=== RUN   TestParse
+--- PASS: TestParse (0.10s)
+ok  	example.com/app/internal/parse	0.2s
+`
+	results := ParseOutput(out)
+	want := []Result{
+		{Name: "TestAdd", Package: "example.com/app/internal/math", Outcome: OutcomePass},
+		{Name: "TestSub/negative", Package: "example.com/app/internal/math", Outcome: OutcomeFail, Elapsed: 0.01},
+		{Name: "TestSub", Package: "example.com/app/internal/math", Outcome: OutcomeFail, Elapsed: 0.02},
+		{Name: "TestSlow", Package: "example.com/app/internal/math", Outcome: OutcomeSkip},
+		{Name: "TestParse", Package: "example.com/app/internal/parse", Outcome: OutcomePass, Elapsed: 0.1},
+	}
+	assertResults(t, results, want)
+}
+
+func TestParseOutput_GoJSON(t *testing.T) {
+	out := `{"Action":"run","Package":"example.com/app","Test":"TestA"}
+{"Action":"output","Package":"example.com/app","Test":"TestA","Output":"--- PASS: TestA (0.00s)\n"}
+{"Action":"pass","Package":"example.com/app","Test":"TestA","Elapsed":0.5}
+{"Action":"fail","Package":"example.com/app","Test":"TestB","Elapsed":1}
+{"Action":"pass","Package":"example.com/app","Elapsed":1.5}
+`
+	results := ParseOutput(out)
+	want := []Result{
+		{Name: "TestA", Package: "example.com/app", Outcome: OutcomePass, Elapsed: 0.5},
+		{Name: "TestB", Package: "example.com/app", Outcome: OutcomeFail, Elapsed: 1},
+	}
+	assertResults(t, results, want)
+}
+
+func TestParseOutput_Jest(t *testing.T) {
+	out := ` PASS  src/utils/math.test.ts
+  math
+    ✓ adds numbers (3 ms)
+    ○ skipped divides
+ FAIL  src/cart.test.tsx
+    ✕ adds item to cart (12 ms)
+    ✓ removes item
+`
+	results := ParseOutput(out)
+	want := []Result{
+		{Name: "adds numbers", File: "src/utils/math.test.ts", Outcome: OutcomePass, Elapsed: 0.003},
+		{Name: "skipped divides", File: "src/utils/math.test.ts", Outcome: OutcomeSkip},
+		{Name: "adds item to cart", File: "src/cart.test.tsx", Outcome: OutcomeFail, Elapsed: 0.012},
+		{Name: "removes item", File: "src/cart.test.tsx", Outcome: OutcomePass},
+	}
+	assertResults(t, results, want)
+}
+
+func TestParseOutput_Vitest(t *testing.T) {
+	out := ` ✓ src/utils/math.test.ts > math > adds numbers 2ms
+ × src/cart.test.tsx > cart > adds item 14ms
+ ↓ src/cart.test.tsx > cart > checkout
+`
+	results := ParseOutput(out)
+	want := []Result{
+		{Name: "math > adds numbers", File: "src/utils/math.test.ts", Outcome: OutcomePass, Elapsed: 0.002},
+		{Name: "cart > adds item", File: "src/cart.test.tsx", Outcome: OutcomeFail, Elapsed: 0.014},
+		{Name: "cart > checkout", File: "src/cart.test.tsx", Outcome: OutcomeSkip},
+	}
+	assertResults(t, results, want)
+}
+
+func TestParseOutput_Pytest(t *testing.T) {
+	out := `tests/test_api.py::test_get PASSED                                  [ 33%]
+tests/test_api.py::test_post FAILED                                 [ 66%]
+tests/test_api.py::test_skip SKIPPED (no db)                        [100%]
+`
+	results := ParseOutput(out)
+	want := []Result{
+		{Name: "test_get", File: "tests/test_api.py", Outcome: OutcomePass},
+		{Name: "test_post", File: "tests/test_api.py", Outcome: OutcomeFail},
+		{Name: "test_skip", File: "tests/test_api.py", Outcome: OutcomeSkip},
+	}
+	assertResults(t, results, want)
+}
+
+func TestAssignDirs(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0644)
+
+	results := []Result{
+		{Name: "TestRoot", Package: "example.com/app"},
+		{Name: "TestSub", Package: "example.com/app/internal/math"},
+		{Name: "TestOther", Package: "other.com/lib"},
+		{Name: "adds", File: "src/utils/math.test.ts"},
+	}
+	AssignDirs(dir, results)
+
+	want := []string{".", "internal/math", "", "src/utils"}
+	for i, w := range want {
+		if results[i].Dir != w {
+			t.Errorf("%s: Dir = %q, want %q", results[i].Name, results[i].Dir, w)
+		}
+	}
+}
+
+func assertResults(t *testing.T, got, want []Result) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d results, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
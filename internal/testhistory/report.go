@@ -0,0 +1,187 @@
+package testhistory
+
+import (
+	"bufio"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxSlowest is how many of the slowest tests a Report lists.
+	maxSlowest = 5
+
+	// maxBuildErrors caps the compiler errors a Report lists.
+	maxBuildErrors = 50
+
+	// maxFailureMessage caps the message kept per failing test.
+	maxFailureMessage = 500
+)
+
+// Report summarizes the test results and compiler errors in a run's output.
+type Report struct {
+	Total       int          `json:"total"`
+	Passed      int          `json:"passed"`
+	Failed      int          `json:"failed"`
+	Skipped     int          `json:"skipped"`
+	Elapsed     float64      `json:"elapsed,omitempty"` // Seconds, summed over tests
+	Failures    []Failure    `json:"failures,omitempty"`
+	Slowest     []Result     `json:"slowest,omitempty"`
+	BuildErrors []BuildError `json:"build_errors,omitempty"`
+}
+
+// Failure is a failed test with the first lines its runner reported for it.
+type Failure struct {
+	Result
+	Message string `json:"message,omitempty"`
+}
+
+// BuildError is a compiler or type checker error.
+type BuildError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Empty reports whether the output had neither test results nor build errors.
+func (r *Report) Empty() bool {
+	return r.Total == 0 && len(r.BuildErrors) == 0
+}
+
+var (
+	goTestRun       = regexp.MustCompile(`^=== (?:RUN|CONT)\s+(\S+)`)
+	goTestLog       = regexp.MustCompile(`^\s{4,}(\S+_test\.go:\d+): (.*)$`)
+	pytestFailed    = regexp.MustCompile(`^(?:FAILED|ERROR) (\S+\.py)::(\S+) - (.*)$`)
+	goBuildError    = regexp.MustCompile(`^(\S+\.go):(\d+):(\d+): (.+)$`)
+	tscBuildError   = regexp.MustCompile(`^(\S+\.[cm]?[jt]sx?)(?:\((\d+),(\d+)\):|:(\d+):(\d+) -) (error TS\d+: .+)$`)
+	rustErrorHeader = regexp.MustCompile(`^error(?:\[E\d+\])?: (.+)$`)
+	rustErrorAt     = regexp.MustCompile(`^\s+--> (\S+):(\d+):(\d+)$`)
+)
+
+// Summarize parses a run's output into a Report. Test results are found as
+// ParseOutput finds them; compiler errors of Go, TypeScript and Rust are
+// collected too, so a run that never got to its tests still says why.
+func Summarize(output string) *Report {
+	report := &Report{}
+	results := ParseOutput(output)
+	messages := failureMessages(output)
+
+	for _, r := range results {
+		report.Total++
+		report.Elapsed += r.Elapsed
+		switch r.Outcome {
+		case OutcomePass:
+			report.Passed++
+		case OutcomeFail:
+			report.Failed++
+			report.Failures = append(report.Failures, Failure{Result: r, Message: messages[r.Name]})
+		default:
+			report.Skipped++
+		}
+	}
+
+	slowest := make([]Result, 0, len(results))
+	for _, r := range results {
+		if r.Elapsed > 0 {
+			slowest = append(slowest, r)
+		}
+	}
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].Elapsed > slowest[j].Elapsed })
+	if len(slowest) > maxSlowest {
+		slowest = slowest[:maxSlowest]
+	}
+	report.Slowest = slowest
+
+	report.BuildErrors = parseBuildErrors(output)
+	return report
+}
+
+// failureMessages maps test names to what their runner logged about them:
+// `go test -v` log lines of the running test and pytest's short summary.
+func failureMessages(output string) map[string]string {
+	messages := map[string]string{}
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := goTestRun.FindStringSubmatch(line); m != nil {
+			current = m[1]
+			continue
+		}
+		if m := goTestLog.FindStringSubmatch(line); m != nil && current != "" {
+			appendMessage(messages, current, m[1]+": "+m[2])
+			continue
+		}
+		if m := pytestFailed.FindStringSubmatch(line); m != nil {
+			appendMessage(messages, m[2], m[3])
+		}
+	}
+	return messages
+}
+
+func appendMessage(messages map[string]string, name, line string) {
+	msg := messages[name]
+	if len(msg) >= maxFailureMessage {
+		return
+	}
+	if msg != "" {
+		msg += "\n"
+	}
+	msg += line
+	if len(msg) > maxFailureMessage {
+		msg = msg[:maxFailureMessage]
+	}
+	messages[name] = msg
+}
+
+// parseBuildErrors finds compiler errors: Go's file:line:col, tsc's
+// file(line,col) and file:line:col - forms, and Rust's error with its
+// --> location on a following line. Test log lines are indented, so they
+// don't match.
+func parseBuildErrors(output string) []BuildError {
+	var (
+		errs     []BuildError
+		seen     = map[BuildError]bool{}
+		rustMsg  string
+		addError = func(e BuildError) {
+			if !seen[e] && len(errs) < maxBuildErrors {
+				seen[e] = true
+				errs = append(errs, e)
+			}
+		}
+	)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case goBuildError.MatchString(line):
+			m := goBuildError.FindStringSubmatch(line)
+			addError(BuildError{File: strings.TrimPrefix(m[1], "./"), Line: atoi(m[2]), Column: atoi(m[3]), Message: m[4]})
+		case tscBuildError.MatchString(line):
+			m := tscBuildError.FindStringSubmatch(line)
+			lineNo, col := m[2], m[3]
+			if lineNo == "" {
+				lineNo, col = m[4], m[5]
+			}
+			addError(BuildError{File: m[1], Line: atoi(lineNo), Column: atoi(col), Message: m[6]})
+		case line == "":
+			rustMsg = ""
+		case rustErrorHeader.MatchString(line):
+			rustMsg = rustErrorHeader.FindStringSubmatch(line)[1]
+		case rustMsg != "" && rustErrorAt.MatchString(line):
+			m := rustErrorAt.FindStringSubmatch(line)
+			addError(BuildError{File: m[1], Line: atoi(m[2]), Column: atoi(m[3]), Message: rustMsg})
+			rustMsg = ""
+		}
+	}
+	return errs
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
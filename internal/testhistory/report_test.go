@@ -0,0 +1,79 @@
+package testhistory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize_GoVerbose(t *testing.T) {
+	out := `=== RUN   TestAdd
+--- PASS: TestAdd (0.30s)
+=== RUN   TestSub
+    math_test.go:14: got -1, want 1
+    math_test.go:15: sign flipped
+--- FAIL: TestSub (0.02s)
+=== RUN   TestSlow
+--- SKIP: TestSlow (0.00s)
+FAIL
+FAIL	example.com/app/internal/math	0.331s
+`
+	r := Summarize(out)
+	if r.Total != 3 || r.Passed != 1 || r.Failed != 1 || r.Skipped != 1 {
+		t.Fatalf("counts = %+v", r)
+	}
+	if len(r.Failures) != 1 || r.Failures[0].Name != "TestSub" || r.Failures[0].Package != "example.com/app/internal/math" {
+		t.Fatalf("failures = %+v", r.Failures)
+	}
+	if want := "math_test.go:14: got -1, want 1\nmath_test.go:15: sign flipped"; r.Failures[0].Message != want {
+		t.Errorf("message = %q, want %q", r.Failures[0].Message, want)
+	}
+	if len(r.Slowest) != 2 || r.Slowest[0].Name != "TestAdd" {
+		t.Errorf("slowest = %+v", r.Slowest)
+	}
+	if len(r.BuildErrors) != 0 {
+		t.Errorf("log lines taken for build errors: %+v", r.BuildErrors)
+	}
+}
+
+func TestSummarize_Pytest(t *testing.T) {
+	out := `tests/test_api.py::test_get PASSED
+tests/test_api.py::test_post FAILED
+=========================== short test summary info ============================
+FAILED tests/test_api.py::test_post - AssertionError: 500 != 201
+`
+	r := Summarize(out)
+	if r.Failed != 1 || r.Failures[0].File != "tests/test_api.py" || r.Failures[0].Message != "AssertionError: 500 != 201" {
+		t.Errorf("failures = %+v", r.Failures)
+	}
+}
+
+func TestSummarize_BuildErrors(t *testing.T) {
+	out := `# example.com/app/internal/math
+./math.go:12:9: undefined: sub
+./math.go:12:9: undefined: sub
+FAIL	example.com/app/internal/math [build failed]
+src/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.
+src/util.ts:8:1 - error TS1005: ';' expected.
+error[E0425]: cannot find value ` + "`x`" + ` in this scope
+ --> src/main.rs:2:13
+  |
+
+error: could not compile ` + "`app`" + `
+`
+	r := Summarize(out)
+	want := []BuildError{
+		{File: "math.go", Line: 12, Column: 9, Message: "undefined: sub"},
+		{File: "src/app.ts", Line: 3, Column: 7, Message: "error TS2322: Type 'string' is not assignable to type 'number'."},
+		{File: "src/util.ts", Line: 8, Column: 1, Message: "error TS1005: ';' expected."},
+		{File: "src/main.rs", Line: 2, Column: 13, Message: "cannot find value `x` in this scope"},
+	}
+	if !reflect.DeepEqual(r.BuildErrors, want) {
+		t.Errorf("build errors = %+v\nwant %+v", r.BuildErrors, want)
+	}
+	if r.Empty() {
+		t.Error("report with build errors is empty")
+	}
+	if !Summarize("listening on :3000\n").Empty() {
+		t.Error("server output has results")
+	}
+}
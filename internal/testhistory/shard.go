@@ -0,0 +1,76 @@
+package testhistory
+
+import "sort"
+
+// defaultUnitWeight is the assumed duration, in seconds, of a unit with no history.
+const defaultUnitWeight = 1.0
+
+// UnitWeights estimates how long each unit takes from the most recent run that
+// included it. Units are Go packages or test files, matching Suite.Units.
+func UnitWeights(runs []*Run) map[string]float64 {
+	weights := make(map[string]float64)
+	for i := len(runs) - 1; i >= 0; i-- {
+		seen := make(map[string]float64)
+		for _, r := range runs[i].Results {
+			unit := r.Package
+			if unit == "" {
+				unit = r.File
+			}
+			if unit == "" {
+				continue
+			}
+			seen[unit] += r.Elapsed
+		}
+		for unit, elapsed := range seen {
+			if _, ok := weights[unit]; !ok {
+				weights[unit] = elapsed
+			}
+		}
+	}
+	return weights
+}
+
+// PlanShards splits units into at most n shards of roughly equal expected
+// duration, assigning the slowest units first to the least loaded shard.
+// Units without a weight count as one second. Empty shards are dropped.
+func PlanShards(units []string, n int, weights map[string]float64) [][]string {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(units) {
+		n = len(units)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	weightOf := func(unit string) float64 {
+		if w, ok := weights[unit]; ok && w > 0 {
+			return w
+		}
+		return defaultUnitWeight
+	}
+
+	sorted := append([]string(nil), units...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return weightOf(sorted[i]) > weightOf(sorted[j])
+	})
+
+	shards := make([][]string, n)
+	loads := make([]float64, n)
+	for _, unit := range sorted {
+		least := 0
+		for i := 1; i < n; i++ {
+			if loads[i] < loads[least] {
+				least = i
+			}
+		}
+		shards[least] = append(shards[least], unit)
+		loads[least] += weightOf(unit)
+	}
+
+	for _, shard := range shards {
+		sort.Strings(shard)
+	}
+	return shards
+}
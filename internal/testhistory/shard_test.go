@@ -0,0 +1,131 @@
+package testhistory
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnitWeights(t *testing.T) {
+	runs := []*Run{
+		{Results: []Result{
+			{Name: "TestA", Package: "m/a", Elapsed: 9},
+			{Name: "TestB", Package: "m/b", Elapsed: 4},
+		}},
+		{Results: []Result{
+			{Name: "TestA", Package: "m/a", Elapsed: 2},
+			{Name: "TestA2", Package: "m/a", Elapsed: 1},
+			{Name: "renders", File: "src/app.test.ts", Elapsed: 0.5},
+		}},
+	}
+
+	want := map[string]float64{"m/a": 3, "m/b": 4, "src/app.test.ts": 0.5}
+	if got := UnitWeights(runs); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnitWeights() = %v, want %v", got, want)
+	}
+}
+
+func TestPlanShards(t *testing.T) {
+	units := []string{"a", "b", "c", "d", "e"}
+	weights := map[string]float64{"a": 10, "b": 6, "c": 5, "d": 3}
+
+	got := PlanShards(units, 2, weights)
+	want := [][]string{{"a", "d"}, {"b", "c", "e"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanShards() = %v, want %v", got, want)
+	}
+
+	if got := PlanShards(units[:2], 4, nil); len(got) != 2 {
+		t.Errorf("expected shards capped at unit count, got %v", got)
+	}
+	if got := PlanShards(nil, 3, nil); got != nil {
+		t.Errorf("expected no shards for no units, got %v", got)
+	}
+}
+
+func TestMergeGoCoverProfiles(t *testing.T) {
+	dir := t.TempDir()
+	p1 := filepath.Join(dir, "shard-1.out")
+	p2 := filepath.Join(dir, "shard-2.out")
+	os.WriteFile(p1, []byte("mode: set\nm/a/a.go:1.1,3.2 2 1\nm/a/a.go:4.1,6.2 3 0\n"), 0644)
+	os.WriteFile(p2, []byte("mode: set\nm/a/a.go:4.1,6.2 3 1\nm/b/b.go:1.1,2.2 5 0\n"), 0644)
+
+	out := filepath.Join(dir, "coverage.out")
+	pct, err := MergeGoCoverProfiles([]string{p1, p2, filepath.Join(dir, "missing.out")}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(pct-50) > 0.001 {
+		t.Errorf("percent = %v, want 50", pct)
+	}
+
+	data, _ := os.ReadFile(out)
+	want := "mode: set\nm/a/a.go:1.1,3.2 2 1\nm/a/a.go:4.1,6.2 3 1\nm/b/b.go:1.1,2.2 5 0\n"
+	if string(data) != want {
+		t.Errorf("merged profile =\n%s\nwant\n%s", data, want)
+	}
+
+	if _, err := MergeGoCoverProfiles([]string{filepath.Join(dir, "missing.out")}, out); err == nil {
+		t.Error("expected error when no profiles exist")
+	}
+}
+
+func TestMergeIstanbulCoverage(t *testing.T) {
+	dir := t.TempDir()
+	r1 := filepath.Join(dir, "1.json")
+	r2 := filepath.Join(dir, "2.json")
+	os.WriteFile(r1, []byte(`{"/p/a.ts":{"path":"/p/a.ts","s":{"0":1,"1":0},"f":{"0":1},"b":{"0":[1,0]}}}`), 0644)
+	os.WriteFile(r2, []byte(`{"/p/a.ts":{"path":"/p/a.ts","s":{"0":0,"1":2},"f":{"0":0},"b":{"0":[0,3]}},"/p/b.ts":{"path":"/p/b.ts","s":{"0":0,"1":0}}}`), 0644)
+
+	out := filepath.Join(dir, "coverage-final.json")
+	pct, err := MergeIstanbulCoverage([]string{r1, r2}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(pct-50) > 0.001 {
+		t.Errorf("percent = %v, want 50", pct)
+	}
+
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), `"b":{"0":[1,3]}`) {
+		t.Errorf("branch counts not merged: %s", data)
+	}
+}
+
+func TestSuiteCommand(t *testing.T) {
+	s := &Suite{Runner: RunnerGo}
+	cmd, args := s.Command([]string{"m/a", "m/b"}, "/tmp/c.out")
+	if cmd != "go" || strings.Join(args, " ") != "test -json -coverprofile=/tmp/c.out m/a m/b" {
+		t.Errorf("go command = %s %v", cmd, args)
+	}
+
+	s = &Suite{Runner: RunnerPytest}
+	cmd, args = s.Command([]string{"tests/test_a.py"}, "ignored")
+	if cmd != "python" || strings.Join(args, " ") != "-m pytest -v tests/test_a.py" {
+		t.Errorf("pytest command = %s %v", cmd, args)
+	}
+}
+
+func TestDetectSuite_Node(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"devDependencies":{"vitest":"^1.0.0"}}`), 0644)
+	os.MkdirAll(filepath.Join(dir, "src"), 0755)
+	os.MkdirAll(filepath.Join(dir, "node_modules", "x"), 0755)
+	os.WriteFile(filepath.Join(dir, "src", "app.test.ts"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "src", "app.ts"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "node_modules", "x", "x.test.js"), nil, 0644)
+
+	suite, err := DetectSuite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suite.Runner != RunnerVitest {
+		t.Errorf("runner = %s, want vitest", suite.Runner)
+	}
+	if !reflect.DeepEqual(suite.Units, []string{"src/app.test.ts"}) {
+		t.Errorf("units = %v", suite.Units)
+	}
+}
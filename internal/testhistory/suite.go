@@ -0,0 +1,150 @@
+package testhistory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Runner identifies the test runner a suite uses.
+type Runner string
+
+const (
+	RunnerGo     Runner = "go"
+	RunnerJest   Runner = "jest"
+	RunnerVitest Runner = "vitest"
+	RunnerPytest Runner = "pytest"
+)
+
+// Suite is a project's test suite split into independently runnable units:
+// Go packages or JS/Python test files.
+type Suite struct {
+	Runner Runner
+	Units  []string
+}
+
+var (
+	jsTestFile = regexp.MustCompile(`\.(test|spec)\.[cm]?[jt]sx?$`)
+	pyTestFile = regexp.MustCompile(`^(test_.*|.*_test)\.py$`)
+)
+
+// skipDirs are never searched for test files.
+var skipDirs = map[string]bool{
+	"node_modules": true, ".git": true, ".agnt": true, "dist": true, "build": true,
+	"coverage": true, "vendor": true, ".venv": true, "venv": true, "__pycache__": true,
+}
+
+// DetectSuite finds the test suite of the project at projectPath.
+func DetectSuite(projectPath string) (*Suite, error) {
+	switch {
+	case fileExists(filepath.Join(projectPath, "go.mod")):
+		cmd := exec.Command("go", "list", "./...")
+		cmd.Dir = projectPath
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("go list failed: %w", err)
+		}
+		return &Suite{Runner: RunnerGo, Units: strings.Fields(string(out))}, nil
+
+	case fileExists(filepath.Join(projectPath, "package.json")):
+		files, err := findTestFiles(projectPath, func(name string) bool { return jsTestFile.MatchString(name) })
+		if err != nil {
+			return nil, err
+		}
+		return &Suite{Runner: detectJSRunner(projectPath), Units: files}, nil
+
+	case fileExists(filepath.Join(projectPath, "pyproject.toml")),
+		fileExists(filepath.Join(projectPath, "setup.py")),
+		fileExists(filepath.Join(projectPath, "requirements.txt")):
+		files, err := findTestFiles(projectPath, func(name string) bool { return pyTestFile.MatchString(name) })
+		if err != nil {
+			return nil, err
+		}
+		return &Suite{Runner: RunnerPytest, Units: files}, nil
+	}
+	return nil, fmt.Errorf("no supported test suite found in %s", projectPath)
+}
+
+// Command returns the command that runs the given units. coverFile is where
+// coverage is written: a cover profile for Go, a report directory for
+// Jest/Vitest. It is ignored for pytest.
+func (s *Suite) Command(units []string, coverFile string) (string, []string) {
+	switch s.Runner {
+	case RunnerGo:
+		args := []string{"test", "-json"}
+		if coverFile != "" {
+			args = append(args, "-coverprofile="+coverFile)
+		}
+		return "go", append(args, units...)
+	case RunnerJest:
+		args := []string{"jest", "--ci", "--verbose"}
+		if coverFile != "" {
+			args = append(args, "--coverage", "--coverageReporters=json", "--coverageDirectory="+coverFile)
+		}
+		return "npx", append(args, units...)
+	case RunnerVitest:
+		args := []string{"vitest", "run", "--reporter=verbose"}
+		if coverFile != "" {
+			args = append(args, "--coverage.enabled", "--coverage.reporter=json", "--coverage.reportsDirectory="+coverFile)
+		}
+		return "npx", append(args, units...)
+	default:
+		return "python", append([]string{"-m", "pytest", "-v"}, units...)
+	}
+}
+
+// detectJSRunner picks vitest when package.json depends on it, jest otherwise.
+func detectJSRunner(projectPath string) Runner {
+	data, err := os.ReadFile(filepath.Join(projectPath, "package.json"))
+	if err != nil {
+		return RunnerJest
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) == nil {
+		if _, ok := pkg.DevDependencies["vitest"]; ok {
+			return RunnerVitest
+		}
+		if _, ok := pkg.Dependencies["vitest"]; ok {
+			return RunnerVitest
+		}
+	}
+	return RunnerJest
+}
+
+// findTestFiles returns project-relative, slash-separated test file paths.
+func findTestFiles(projectPath string, match func(name string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != projectPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(d.Name()) {
+			if rel, err := filepath.Rel(projectPath, p); err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+// A11yAuditInput represents input for the a11y_audit tool.
+type A11yAuditInput struct {
+	ProxyID   string `json:"proxy_id" jsonschema:"Proxy ID"`
+	SessionID string `json:"session_id,omitempty" jsonschema:"Page session ID from currentpage (default: whichever page answers first)"`
+	Selector  string `json:"selector,omitempty" jsonschema:"Only audit this element and its descendants"`
+	Level     string `json:"level,omitempty" jsonschema:"WCAG level: a, aa (default) or aaa"`
+	Mode      string `json:"mode,omitempty" jsonschema:"standard (axe-core, default), basic, fast or comprehensive"`
+	Samples   int    `json:"samples,omitempty" jsonschema:"Selectors listed per rule (default: 3, max: 20)"`
+}
+
+// RegisterA11yTool registers the a11y_audit MCP tool with the server.
+func RegisterA11yTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "a11y_audit",
+		Description: `Audit a proxied page for accessibility violations.
+
+Runs axe-core in the connected browser (falling back to built-in checks when it
+cannot load) and returns violations grouped by rule: how many elements fail, a
+few sample selectors, the fix and the WCAG reference. Rules with errors come
+first, then the most frequent.
+
+Examples:
+  a11y_audit {proxy_id: "dev"}
+  a11y_audit {proxy_id: "dev", selector: "#checkout", level: "aaa"}
+  a11y_audit {proxy_id: "dev", mode: "comprehensive", samples: 10}`,
+	}, dt.makeA11yAuditHandler())
+}
+
+// makeA11yAuditHandler creates a handler for the a11y_audit tool.
+func (dt *DaemonTools) makeA11yAuditHandler() func(context.Context, *mcp.CallToolRequest, A11yAuditInput) (*mcp.CallToolResult, proxy.A11yReport, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input A11yAuditInput) (*mcp.CallToolResult, proxy.A11yReport, error) {
+		if input.ProxyID == "" {
+			return errorResult("proxy_id required"), proxy.A11yReport{}, nil
+		}
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), proxy.A11yReport{}, nil
+		}
+
+		result, err := dt.client.A11yAudit(input.ProxyID, input.SessionID, proxy.A11yAuditOptions{
+			Selector: input.Selector,
+			Level:    input.Level,
+			Mode:     input.Mode,
+			Samples:  input.Samples,
+		})
+		if err != nil {
+			return formatDaemonError(err, "a11y_audit"), proxy.A11yReport{}, nil
+		}
+
+		var report proxy.A11yReport
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, &report)
+		}
+		return nil, report, nil
+	}
+}
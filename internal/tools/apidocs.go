@@ -37,6 +37,9 @@ var DevToolAPIDocs = struct {
 		{Name: "interactions", Description: "Track and query user interactions (clicks, keyboard, scroll)"},
 		{Name: "mutations", Description: "Track and query DOM mutations (added, removed, modified)"},
 		{Name: "indicator", Description: "Control the floating indicator bug"},
+		{Name: "banner", Description: "Environment banner naming the dev instance and active warnings"},
+		{Name: "ui", Description: "Language, theme and placement of the overlay (set per proxy with PROXY UI)"},
+		{Name: "timeTravel", Description: "Reconstruct the DOM as it was at an earlier moment of the page view"},
 		{Name: "sketch", Description: "Wireframing and annotation mode"},
 		{Name: "content", Description: "Content extraction, navigation, sitemaps, and markdown conversion"},
 		{Name: "connection", Description: "WebSocket connection status"},
@@ -311,6 +314,24 @@ var DevToolAPIDocs = struct {
 			Returns:     "Promise<Element>",
 			Example:     `const modal = await __devtool.waitForElement(".modal-open")`,
 		},
+		{
+			Name:        "whenIdle",
+			Category:    "interactive",
+			Description: "Wait until the page has no fetch/XHR requests in flight and no DOM mutations for a quiet window. Resolves with idle: false on timeout instead of rejecting",
+			Signature:   "whenIdle(options?)",
+			Parameters:  []string{"options: {quietMs?, timeoutMs?, maxInflight?} - Quiet window (default: 500), max wait (default: 10000), requests allowed to stay open (default: 0)"},
+			Returns:     "Promise<{idle, waitedMs, inflight, quietMs, sinceNetworkMs, sinceMutationMs, mutations}>",
+			Example:     `await __devtool.whenIdle({quietMs: 1000}); __devtool.screenshot("settled")`,
+		},
+		{
+			Name:        "isIdle",
+			Category:    "interactive",
+			Description: "Check whether the page is idle right now (loaded, no requests in flight, no recent DOM mutations)",
+			Signature:   "isIdle(options?)",
+			Parameters:  []string{"options: {quietMs?, maxInflight?} - Same as whenIdle"},
+			Returns:     "boolean",
+			Example:     `__devtool.isIdle()`,
+		},
 		{
 			Name:        "ask",
 			Category:    "interactive",
@@ -623,6 +644,117 @@ var DevToolAPIDocs = struct {
 			Returns:     "void",
 			Example:     `__devtool.indicator.destroy()`,
 		},
+		// Environment Banner
+		{
+			Name:        "banner.getState",
+			Category:    "banner",
+			Description: "Get what the environment banner shows (set per proxy with the banner option)",
+			Signature:   "banner.getState()",
+			Parameters:  []string{},
+			Returns:     "{label, branch, position, color, warnings} or null when the proxy has no banner",
+			Example:     `__devtool.banner.getState()`,
+		},
+		{
+			Name:        "banner.hide",
+			Category:    "banner",
+			Description: "Hide the environment banner for this page view (e.g. before a screenshot)",
+			Signature:   "banner.hide()",
+			Parameters:  []string{},
+			Returns:     "void",
+			Example:     `__devtool.banner.hide()`,
+		},
+		{
+			Name:        "banner.show",
+			Category:    "banner",
+			Description: "Show the environment banner again after hide()",
+			Signature:   "banner.show()",
+			Parameters:  []string{},
+			Returns:     "void",
+			Example:     `__devtool.banner.show()`,
+		},
+		// Overlay Language and Theme
+		{
+			Name:        "ui.getState",
+			Category:    "ui",
+			Description: "Get the overlay's language, strings and theme (set per proxy with the ui option or the ui action)",
+			Signature:   "ui.getState()",
+			Parameters:  []string{},
+			Returns:     "{language, messages, theme, position, accent, minimal, reduced_motion, hidden} or null while the overlay keeps its defaults",
+			Example:     `__devtool.ui.getState()`,
+		},
+		{
+			Name:        "ui.t",
+			Category:    "ui",
+			Description: "Get an overlay string in the configured language",
+			Signature:   "ui.t(key, fallback)",
+			Parameters:  []string{"key: string - Message key such as 'tab.errors'", "fallback: string - Text when the language has no string for key"},
+			Returns:     "string",
+			Example:     `__devtool.ui.t('compose.send', 'Send')`,
+		},
+		{
+			Name:        "ui.isDark",
+			Category:    "ui",
+			Description: "Whether the overlay draws dark: theme dark, or theme auto on a dark OS setting",
+			Signature:   "ui.isDark()",
+			Parameters:  []string{},
+			Returns:     "boolean",
+			Example:     `__devtool.ui.isDark()`,
+		},
+		{
+			Name:        "ui.reducedMotion",
+			Category:    "ui",
+			Description: "Whether the overlay runs without animations: the reduced_motion option, or prefers-reduced-motion in the OS",
+			Signature:   "ui.reducedMotion()",
+			Parameters:  []string{},
+			Returns:     "boolean",
+			Example:     `__devtool.ui.reducedMotion()`,
+		},
+		{
+			Name:        "ui.isHidden",
+			Category:    "ui",
+			Description: "Whether the overlay's visual chrome is hidden with the hidden option; capture and the __devtool API keep working",
+			Signature:   "ui.isHidden()",
+			Parameters:  []string{},
+			Returns:     "boolean",
+			Example:     `__devtool.ui.isHidden()`,
+		},
+		{
+			Name:        "ui.onChange",
+			Category:    "ui",
+			Description: "Call a function when the overlay options or the OS color scheme change",
+			Signature:   "ui.onChange(fn)",
+			Parameters:  []string{"fn: function - Called with the new state"},
+			Returns:     "void",
+			Example:     `__devtool.ui.onChange(function(state) { console.log(state.theme) })`,
+		},
+		// Time Travel
+		{
+			Name:        "timeTravel.domAt",
+			Category:    "timeTravel",
+			Description: "Reconstruct the page's HTML at an earlier moment by undoing the DOM mutations since then. Form values and canvas contents show their current state",
+			Signature:   "timeTravel.domAt(when, options?)",
+			Parameters:  []string{"when: number|string|Date - Epoch ms, negative ms from now (e.g. -5000), or a date string such as a proxylog timestamp", "options: object - {selector: only this element, maxLength: 100000, keepScripts: false}"},
+			Returns:     "{html, at, exact (false when older than the journal), coveredSince, undone, truncated, url}",
+			Example:     `__devtool.timeTravel.domAt(-5000, {selector: "#app"})`,
+		},
+		{
+			Name:        "timeTravel.beforeError",
+			Category:    "timeTravel",
+			Description: "Reconstruct the page's HTML just before a JavaScript error",
+			Signature:   "timeTravel.beforeError(index?, options?)",
+			Parameters:  []string{"index: number - Error index in recorded JS errors (default: the latest)", "options: object - Same as domAt"},
+			Returns:     "domAt result plus {error: {message, source, lineno, timestamp}}",
+			Example:     `__devtool.timeTravel.beforeError()`,
+		},
+		{
+			Name:        "timeTravel.getState",
+			Category:    "timeTravel",
+			Description: "How far back the page can be rewound exactly",
+			Signature:   "timeTravel.getState()",
+			Parameters:  []string{},
+			Returns:     "{records, coveredSince, oldest}",
+			Example:     `__devtool.timeTravel.getState()`,
+		},
 		// Sketch Mode
 		{
 			Name:        "sketch.open",
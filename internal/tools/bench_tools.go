@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// BenchInput represents input for the bench tool.
+type BenchInput struct {
+	Action    string  `json:"action" jsonschema:"Action: run, report, history"`
+	Name      string  `json:"name,omitempty" jsonschema:"Benchmark suite from .agnt.kdl benchmarks block (default: the only one, or Go benchmarks); for history, a benchmark name filter"`
+	Bench     string  `json:"bench,omitempty" jsonschema:"For run: go test -bench pattern override"`
+	Package   string  `json:"package,omitempty" jsonschema:"For run: Go package pattern override (default ./...)"`
+	Count     int     `json:"count,omitempty" jsonschema:"For run: samples per benchmark (default 5; at least 5 are needed for significance)"`
+	Baseline  string  `json:"baseline,omitempty" jsonschema:"For run/report: git ref to compare against (default: previous commit with results)"`
+	Threshold float64 `json:"threshold,omitempty" jsonschema:"For run/report: minimum slowdown in percent reported as a regression (default 5)"`
+	Timeout   string  `json:"timeout,omitempty" jsonschema:"For run: timeout such as '10m' (default 20m)"`
+}
+
+// BenchOutput represents output from the bench tool.
+type BenchOutput struct {
+	RunID          string             `json:"run_id,omitempty"`
+	Suite          string             `json:"suite,omitempty"`
+	Commit         string             `json:"commit,omitempty"`
+	Dirty          bool               `json:"dirty,omitempty"`
+	BaselineCommit string             `json:"baseline_commit,omitempty"`
+	Benchmarks     int                `json:"benchmarks,omitempty"`
+	Regressions    int                `json:"regressions,omitempty"`
+	Improvements   int                `json:"improvements,omitempty"`
+	Comparisons    []BenchComparison  `json:"comparisons,omitempty"`
+	Series         []BenchSeriesEntry `json:"series,omitempty"`
+	Count          int                `json:"count,omitempty"`
+	Message        string             `json:"message,omitempty"`
+}
+
+// BenchComparison is the change of one benchmark metric against the baseline.
+type BenchComparison struct {
+	Name        string  `json:"name"`
+	Unit        string  `json:"unit"`
+	BaseMean    float64 `json:"base_mean"`
+	CurMean     float64 `json:"cur_mean"`
+	DeltaPct    float64 `json:"delta_pct"`
+	P           float64 `json:"p"`
+	Significant bool    `json:"significant"`
+	Regression  bool    `json:"regression,omitempty"`
+	Improvement bool    `json:"improvement,omitempty"`
+}
+
+// BenchSeriesEntry is the per-commit history of one benchmark.
+type BenchSeriesEntry struct {
+	Name   string       `json:"name"`
+	Suite  string       `json:"suite"`
+	Unit   string       `json:"unit"`
+	Points []BenchPoint `json:"points"`
+}
+
+// BenchPoint is one commit's mean in a benchmark series.
+type BenchPoint struct {
+	Commit    string  `json:"commit"`
+	Timestamp string  `json:"timestamp"`
+	Dirty     bool    `json:"dirty,omitempty"`
+	Mean      float64 `json:"mean"`
+	N         int     `json:"n"`
+}
+
+// RegisterBenchTool registers the bench MCP tool with the server.
+func RegisterBenchTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "bench",
+		Description: `Track benchmarks per commit and detect performance regressions.
+
+Actions:
+  run: Run a benchmark suite, record results against the current commit and compare
+       with the baseline (Mann-Whitney U test, p < 0.05, slowdown >= threshold)
+  report: Compare the latest recorded run with its baseline without re-running
+  history: Per-commit series of each benchmark's main metric (ns/op when available)
+
+Suites come from the benchmarks block in .agnt.kdl:
+  benchmarks {
+      parser { package "./internal/parser"; bench "BenchmarkParse"; count 10 }
+      render { run "node scripts/bench.js" }   // prints {"name": "...", "value": 1.5, "unit": "ms"} lines
+  }
+Go modules without configured suites run go test -bench=. ./...
+
+Examples:
+  bench {action: "run"}
+  bench {action: "run", name: "parser", baseline: "main"}
+  bench {action: "history", name: "BenchmarkParse"}
+
+Regressions are also shown as a toast in proxied pages of the project.`,
+	}, dt.makeBenchHandler())
+}
+
+// makeBenchHandler creates a handler for the bench tool.
+func (dt *DaemonTools) makeBenchHandler() func(context.Context, *mcp.CallToolRequest, BenchInput) (*mcp.CallToolResult, BenchOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input BenchInput) (*mcp.CallToolResult, BenchOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), BenchOutput{}, nil
+		}
+
+		config := protocol.BenchConfig{
+			Name:      input.Name,
+			Bench:     input.Bench,
+			Package:   input.Package,
+			Count:     input.Count,
+			Baseline:  input.Baseline,
+			Threshold: input.Threshold,
+			Timeout:   input.Timeout,
+			Path:      dt.projectPath(req.Session),
+		}
+
+		var (
+			result map[string]interface{}
+			err    error
+		)
+		switch input.Action {
+		case "run":
+			result, err = dt.client.BenchRun(config)
+		case "report":
+			result, err = dt.client.BenchReport(config)
+		case "history":
+			result, err = dt.client.BenchHistory(input.Name)
+		default:
+			return errorResult(fmt.Sprintf("unknown action: %s (use: run, report, history)", input.Action)), BenchOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "bench "+input.Action), BenchOutput{}, nil
+		}
+
+		output := BenchOutput{
+			RunID:          getString(result, "run_id"),
+			Suite:          getString(result, "suite"),
+			Commit:         getString(result, "commit"),
+			Dirty:          getBool(result, "dirty"),
+			BaselineCommit: getString(result, "baseline_commit"),
+			Benchmarks:     getInt(result, "benchmarks"),
+			Regressions:    getInt(result, "regressions"),
+			Improvements:   getInt(result, "improvements"),
+			Count:          getInt(result, "count"),
+			Message:        getString(result, "message"),
+		}
+		for key, dst := range map[string]interface{}{
+			"comparisons": &output.Comparisons,
+			"series":      &output.Series,
+		} {
+			if raw, ok := result[key]; ok {
+				if b, err := json.Marshal(raw); err == nil {
+					json.Unmarshal(b, dst)
+				}
+			}
+		}
+
+		return nil, output, nil
+	}
+}
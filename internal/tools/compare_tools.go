@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/standardbeagle/agnt/internal/compare"
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CompareInput represents input for the compare tool.
+type CompareInput struct {
+	Script     string   `json:"script,omitempty" jsonschema:"Script from .agnt.kdl or detect (e.g. test, lint, build)"`
+	Run        string   `json:"run,omitempty" jsonschema:"Shell command instead of a script"`
+	Command    string   `json:"command,omitempty" jsonschema:"Executable instead of a script"`
+	Args       []string `json:"args,omitempty" jsonschema:"Arguments of command, or extra args for the script"`
+	Ref        string   `json:"ref,omitempty" jsonschema:"git ref of the base to compare against (default HEAD)"`
+	Workspace  string   `json:"workspace,omitempty" jsonschema:"Existing workspace to use as the base instead of ref"`
+	BundleDir  string   `json:"bundle_dir,omitempty" jsonschema:"Build output directory to measure in both trees (e.g. dist)"`
+	Sequential bool     `json:"sequential,omitempty" jsonschema:"Run one after the other instead of concurrently, for benchmarks"`
+	Timeout    string   `json:"timeout,omitempty" jsonschema:"Timeout such as 15m (default 10m)"`
+}
+
+// CompareOutput represents output from the compare tool.
+type CompareOutput struct {
+	Command          string          `json:"command"`
+	Ref              string          `json:"ref,omitempty"`
+	Commit           string          `json:"commit,omitempty"`
+	Workspace        string          `json:"workspace,omitempty"`
+	Diff             *compare.Diff   `json:"diff,omitempty"`
+	Current          *compare.Result `json:"current,omitempty"`
+	Base             *compare.Result `json:"base,omitempty"`
+	CurrentProcessID string          `json:"current_process_id,omitempty"`
+	BaseProcessID    string          `json:"base_process_id,omitempty"`
+}
+
+// RegisterCompareTool registers the compare MCP tool with the server.
+func RegisterCompareTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "compare",
+		Description: `Run the same script against the working tree and a base ref side by side, and diff the results.
+
+The base is a temporary git worktree at ref (default HEAD, i.e. without your uncommitted
+changes), or an existing workspace. Both runs' output is parsed for:
+  tests: pass/fail counts and failing tests (go test, Jest, Vitest, pytest)
+  diagnostics: tsc, ESLint, go build/vet and file:line:col: messages
+  bundle: asset sizes from Vite/Rollup/esbuild/webpack output, or bundle_dir
+  benchmarks: go test -bench and JSON lines {"name","value","unit"}
+
+diff.verdict is better, worse, mixed or same; diff lists new failures, fixed tests, new
+diagnostics, bundle and benchmark changes. Full output: proc {action: "output"} with the
+returned process IDs.
+
+Examples:
+  compare {script: "test"}
+  compare {script: "build", bundle_dir: "dist", ref: "main"}
+  compare {run: "go test -run=^$ -bench=. -count=5 ./...", sequential: true}`,
+	}, dt.makeCompareHandler())
+}
+
+// makeCompareHandler creates a handler for the compare tool.
+func (dt *DaemonTools) makeCompareHandler() func(context.Context, *mcp.CallToolRequest, CompareInput) (*mcp.CallToolResult, CompareOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input CompareInput) (*mcp.CallToolResult, CompareOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), CompareOutput{}, nil
+		}
+		if input.Script == "" && input.Run == "" && input.Command == "" {
+			return errorResult("script, run or command required"), CompareOutput{}, nil
+		}
+
+		result, err := dt.client.CompareRun(protocol.CompareConfig{
+			Script:    input.Script,
+			Run:       input.Run,
+			Command:   input.Command,
+			Args:      input.Args,
+			Ref:       input.Ref,
+			Workspace: input.Workspace,
+			BundleDir: input.BundleDir,
+			Timeout:   input.Timeout,
+			Path:      dt.projectPath(req.Session),
+		}, input.Sequential)
+		if err != nil {
+			return formatDaemonError(err, "compare"), CompareOutput{}, nil
+		}
+
+		var output CompareOutput
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, &output)
+		}
+		return nil, output, nil
+	}
+}
@@ -2,16 +2,22 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/protocol"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // DaemonInput defines input for the daemon management tool.
 type DaemonInput struct {
-	Action string `json:"action" jsonschema:"Action: status, info, start, stop, restart, stop_all, restart_all"`
+	Action string `json:"action" jsonschema:"Action: status, info, start, stop, restart, stop_all, restart_all, graph, resolve"`
+	Target string `json:"target,omitempty" jsonschema:"For graph: entity as kind:id (e.g. process:dev, proxy:dev-proxy) to list what depends on it. For resolve: partial ID, optionally as type:partial (e.g. proxy:app)"`
+	Global bool   `json:"global,omitempty" jsonschema:"For graph: include entities from all directories (default: false)"`
+	DryRun bool   `json:"dry_run,omitempty" jsonschema:"For stop_all: list the processes, proxies and tunnels that would be stopped without stopping them"`
 }
 
 // DaemonOutput defines output for daemon management.
@@ -35,6 +41,23 @@ type DaemonOutput struct {
 	ProcessesFailed  int `json:"processes_failed,omitempty"`
 	ProxiesFailed    int `json:"proxies_failed,omitempty"`
 
+	// For stop_all with dry_run
+	DryRun   bool                      `json:"dry_run,omitempty"`
+	Affected []protocol.AffectedEntity `json:"affected,omitempty"`
+
+	// For graph
+	Nodes      []GraphNode   `json:"nodes,omitempty"`
+	Edges      []GraphEdge   `json:"edges,omitempty"`
+	Dependents []GraphImpact `json:"dependents,omitempty"`
+
+	// For resolve
+	Type          string        `json:"type,omitempty"`
+	ID            string        `json:"id,omitempty"`
+	Path          string        `json:"path,omitempty"`
+	Ambiguous     bool          `json:"ambiguous,omitempty"`
+	Candidates    []ResolvedRef `json:"candidates,omitempty"`
+	OtherProjects []ResolvedRef `json:"other_projects,omitempty"`
+
 	// For all actions
 	Success bool   `json:"success,omitempty"`
 	Message string `json:"message,omitempty"`
@@ -53,6 +76,38 @@ type ProxyInfo struct {
 	TotalStarted int64 `json:"total_started"`
 }
 
+// GraphNode is a managed entity in the dependency graph.
+type GraphNode struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	State string `json:"state,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+// GraphEdge records that From depends on To (both "kind:id").
+type GraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// GraphImpact is an entity that depends, directly or transitively, on the target.
+type GraphImpact struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	State  string `json:"state,omitempty"`
+	Via    string `json:"via"`
+	Reason string `json:"reason"`
+}
+
+// ResolvedRef is an entity a partial ID may refer to.
+type ResolvedRef struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Path  string `json:"path,omitempty"`
+	Match string `json:"match"`
+}
+
 // RegisterDaemonManagementTool adds the daemon management tool to the server.
 func RegisterDaemonManagementTool(server *mcp.Server, dt *DaemonTools) {
 	mcp.AddTool(server, &mcp.Tool{
@@ -70,6 +125,10 @@ Actions:
   restart: Restart the daemon
   stop_all: Stop all processes and proxies (daemon keeps running)
   restart_all: Restart all processes and proxies (stop then start with same config)
+  graph: Dependencies between processes, proxies and tunnels (proxy targets a
+         process's port, tunnel fronts a proxy); with target, what depends on it
+  resolve: Entity type and full ID of a partial process, proxy, tunnel or
+           session ID, with the closest candidates when it is ambiguous
 
 Examples:
   daemon {action: "status"}
@@ -78,7 +137,11 @@ Examples:
   daemon {action: "stop"}
   daemon {action: "restart"}
   daemon {action: "stop_all"}
+  daemon {action: "stop_all", dry_run: true}
   daemon {action: "restart_all"}
+  daemon {action: "graph", target: "process:dev"}
+  daemon {action: "resolve", target: "dev"}
+  daemon {action: "resolve", target: "proxy:app"}
 
 The daemon auto-starts when needed, so manual start is rarely required.
 Use stop_all/restart_all to manage running resources without stopping the daemon.`,
@@ -99,11 +162,15 @@ func makeDaemonHandler(dt *DaemonTools) func(context.Context, *mcp.CallToolReque
 		case "restart":
 			return handleDaemonRestart(dt)
 		case "stop_all":
-			return handleDaemonStopAll(dt)
+			return handleDaemonStopAll(dt, input)
 		case "restart_all":
 			return handleDaemonRestartAll(dt)
+		case "graph":
+			return handleDaemonGraph(dt, input)
+		case "resolve":
+			return handleDaemonResolve(dt, input)
 		default:
-			return errorResult(fmt.Sprintf("unknown action %q. Use: status, info, start, stop, restart, stop_all, restart_all", input.Action)), DaemonOutput{}, nil
+			return errorResult(fmt.Sprintf("unknown action %q. Use: status, info, start, stop, restart, stop_all, restart_all, graph, resolve", input.Action)), DaemonOutput{}, nil
 		}
 	}
 }
@@ -256,11 +323,25 @@ func formatStatusMessage(running bool) string {
 	return "Daemon is not running"
 }
 
-func handleDaemonStopAll(dt *DaemonTools) (*mcp.CallToolResult, DaemonOutput, error) {
+func handleDaemonStopAll(dt *DaemonTools, input DaemonInput) (*mcp.CallToolResult, DaemonOutput, error) {
 	if err := dt.ensureConnected(); err != nil {
 		return errorResult(fmt.Sprintf("daemon not running: %v", err)), DaemonOutput{}, nil
 	}
 
+	if input.DryRun {
+		result, err := dt.client.StopAllDryRun()
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to plan stop all: %v", err)), DaemonOutput{}, nil
+		}
+		affected := getAffected(result)
+		return nil, DaemonOutput{
+			Running:  true,
+			DryRun:   true,
+			Affected: affected,
+			Message:  fmt.Sprintf("Would stop %d processes, proxies and tunnels", len(affected)),
+		}, nil
+	}
+
 	result, err := dt.client.StopAll()
 	if err != nil {
 		return errorResult(fmt.Sprintf("failed to stop all: %v", err)), DaemonOutput{}, nil
@@ -303,3 +384,95 @@ func handleDaemonRestartAll(dt *DaemonTools) (*mcp.CallToolResult, DaemonOutput,
 		Message:          fmt.Sprintf("Restarted %d processes, %d proxies", processesRestarted, proxiesRestarted),
 	}, nil
 }
+
+func handleDaemonGraph(dt *DaemonTools, input DaemonInput) (*mcp.CallToolResult, DaemonOutput, error) {
+	if err := dt.ensureConnected(); err != nil {
+		return errorResult(fmt.Sprintf("daemon not running: %v", err)), DaemonOutput{}, nil
+	}
+
+	var (
+		result map[string]interface{}
+		err    error
+	)
+	if input.Target != "" {
+		kind, id, ok := strings.Cut(input.Target, ":")
+		if !ok || id == "" {
+			return errorResult("target must be kind:id, e.g. process:dev"), DaemonOutput{}, nil
+		}
+		result, err = dt.client.GraphImpact(kind, id)
+	} else {
+		result, err = dt.client.GraphShow(input.Global)
+	}
+	if err != nil {
+		return formatDaemonError(err, "daemon graph"), DaemonOutput{}, nil
+	}
+
+	output := DaemonOutput{
+		Running: true,
+		Success: true,
+		Message: getString(result, "message"),
+	}
+	for key, dst := range map[string]interface{}{
+		"nodes":      &output.Nodes,
+		"edges":      &output.Edges,
+		"dependents": &output.Dependents,
+	} {
+		if raw, ok := result[key]; ok {
+			if b, err := json.Marshal(raw); err == nil {
+				json.Unmarshal(b, dst)
+			}
+		}
+	}
+	if input.Target != "" && output.Message == "" {
+		output.Message = "Nothing depends on " + input.Target
+	}
+
+	return nil, output, nil
+}
+
+func handleDaemonResolve(dt *DaemonTools, input DaemonInput) (*mcp.CallToolResult, DaemonOutput, error) {
+	if input.Target == "" {
+		return errorResult("target is required for resolve, e.g. dev or proxy:app"), DaemonOutput{}, nil
+	}
+	if err := dt.ensureConnected(); err != nil {
+		return errorResult(fmt.Sprintf("daemon not running: %v", err)), DaemonOutput{}, nil
+	}
+
+	partial, entityType := input.Target, ""
+	if kind, id, ok := strings.Cut(input.Target, ":"); ok && id != "" {
+		switch kind {
+		case "process", "proxy", "tunnel", "session":
+			partial, entityType = id, kind
+		}
+	}
+	result, err := dt.client.Resolve(partial, entityType)
+	if err != nil {
+		return formatDaemonError(err, "daemon resolve"), DaemonOutput{}, nil
+	}
+
+	output := DaemonOutput{
+		Running:   true,
+		Success:   true,
+		Type:      getString(result, "type"),
+		ID:        getString(result, "id"),
+		Path:      getString(result, "path"),
+		Ambiguous: getBool(result, "ambiguous"),
+	}
+	for key, dst := range map[string]interface{}{
+		"candidates":     &output.Candidates,
+		"other_projects": &output.OtherProjects,
+	} {
+		if raw, ok := result[key]; ok {
+			if b, err := json.Marshal(raw); err == nil {
+				json.Unmarshal(b, dst)
+			}
+		}
+	}
+	if output.Ambiguous {
+		output.Message = fmt.Sprintf("%q is ambiguous; use one of the candidates' full IDs", partial)
+	} else {
+		output.Message = fmt.Sprintf("%q is %s %s", partial, output.Type, output.ID)
+	}
+
+	return nil, output, nil
+}
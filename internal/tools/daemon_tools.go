@@ -10,9 +10,12 @@ import (
 	"sync"
 	"time"
 
+	agntconfig "github.com/standardbeagle/agnt/internal/config"
 	"github.com/standardbeagle/agnt/internal/daemon"
 	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/profile"
 	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -28,6 +31,11 @@ type DaemonTools struct {
 	sessionMu       sync.Mutex // Protects sessionCode
 	noAutoAttach    bool       // If true, skip auto-attach on connect
 	attachAttempted bool       // Whether we've attempted auto-attach
+
+	// Projects chosen with the project tool, per MCP session (protected by sessionMu)
+	scopes map[*mcp.ServerSession]*projectScope
+
+	sourceIndexes sourceIndexCache // Project source indexes for investigate
 }
 
 // NewDaemonTools creates a new daemon tools wrapper with auto-start and version checking.
@@ -177,17 +185,31 @@ Modes:
   background (default): Returns process_id immediately for tracking via proc tool
   foreground: Waits for completion, returns exit_code/state/runtime (output via proc)
   foreground-raw: Waits for completion, returns exit_code/state/runtime + stdout/stderr
+Foreground runs of tests or builds also return results: pass/fail/skip counts,
+failing tests with their messages, slowest tests and compiler errors.
 
 Restarting: To restart a dev server, use proc stop first, then run again:
   proc {action: "stop", process_id: "dev"}
   run {script_name: "dev"}
 Never use pkill or external commands - always use proc stop for clean shutdown.
 
+Profiling: profile: true starts Node with the inspector enabled so the profile
+tool can capture CPU/heap profiles. Go apps need to import net/http/pprof.
+
+Supervision (background): health_check {url} or {port} is probed every interval_ms;
+restart: "on-failure" restarts on a non-zero exit or when unhealthy, "always" on any
+exit, up to max_restarts (default 5) with backoff. proc status shows the health.
+
+Remote projects: with a remote block in .agnt.kdl, scripts run over ssh in the remote
+checkout and localhost URLs they print map to local forwards (see the remote tool).
+
 Examples:
   run {script_name: "test"}
   run {script_name: "test", mode: "foreground"}
   run {script_name: "test", mode: "foreground-raw"}
-  run {raw: true, command: "go", args: ["mod", "tidy"], mode: "foreground-raw"}`,
+  run {raw: true, command: "go", args: ["mod", "tidy"], mode: "foreground-raw"}
+  run {script_name: "dev", labels: {area: "checkout"}}
+  run {script_name: "dev", health_check: {url: "http://localhost:3000/health"}, restart: "on-failure"}`,
 	}, dt.makeRunHandler())
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -197,10 +219,26 @@ Examples:
 Actions:
   list: List all running processes (use global: true for all directories)
   status: Get process status and info
-  output: Get process output (tail/grep supported)
-  stop: Gracefully stop a process (use force: true for immediate kill)
-  restart: Restart a running process (stop then start with same config)
+  output: Get process output (tail/grep supported); follow: true also waits for new
+          lines until the process exits or follow_timeout_ms passes (default 10s, max 25s)
+  stop: Gracefully stop a process (use force: true for immediate kill); warns about
+        proxies/tunnels that depend on it (cascade: true stops them too)
+  restart: Restart a process with the same command, args, environment and project
+           path; returns its new state and PID
   cleanup_port: Kill any process using a specific port
+  crash: Crash reports (panic/exception stack frames, stderr tail, core dump), kept
+         after the process is gone; omit process_id to list them
+  label: Set labels (key/value tags) on a process, remove them with remove_labels;
+         they survive restarts. list with labels only shows matching processes
+  supervise: Health check a process (health_check: {url} or {port}) and restart it per
+             policy (restart: never, on-failure, always; "off" stops); status shows health.
+             Also set at start: run {script_name: "dev", restart: "on-failure", ...}
+  metrics: CPU and memory (RSS) of a process and its children, sampled every 5s with
+           10 minutes of history (limit: last N samples) and RSS growth over it;
+           status includes the latest sample
+  results: Test results (go test, jest, vitest, pytest) and compiler errors (Go,
+           TypeScript, Rust) in the output: counts, failing tests with their
+           messages, slowest tests. Foreground runs include them as results
 
 Restarting dev servers: Use restart action or stop then run again.
   proc {action: "restart", process_id: "dev"}
@@ -214,10 +252,17 @@ Examples:
   proc {action: "status", process_id: "test"}
   proc {action: "output", process_id: "test", tail: 20}
   proc {action: "output", process_id: "test", grep: "FAIL"}
+  proc {action: "output", process_id: "dev", tail: 20, follow: true}
   proc {action: "stop", process_id: "test"}
   proc {action: "stop", process_id: "test", force: true}
   proc {action: "restart", process_id: "dev"}
-  proc {action: "cleanup_port", port: 3000}`,
+  proc {action: "cleanup_port", port: 3000}
+  proc {action: "cleanup_port", port: 3000, dry_run: true}
+  proc {action: "label", process_id: "dev", labels: {area: "checkout"}}
+  proc {action: "list", labels: {area: "checkout"}}
+  proc {action: "supervise", process_id: "dev", health_check: {url: "http://localhost:3000/health"}, restart: "on-failure"}
+  proc {action: "metrics", process_id: "dev", limit: 12}
+  proc {action: "results", process_id: "test"}`,
 	}, dt.makeProcHandler())
 
 	// Proxy tools
@@ -233,6 +278,12 @@ Actions:
   list: List all running proxies
   exec: Execute JavaScript in connected browser clients
   toast: Send toast notification to connected browsers
+  mock: Serve canned responses for endpoints without calling the target
+  label: Set labels (key/value tags) on a proxy, remove them with remove_labels;
+         list with labels only shows matching proxies
+  routes: Add, remove or list path routes sending path prefixes to other upstreams
+  intercept: Pause matching requests to inspect them, then modify and resume or
+             reject them; paused requests continue on their own after a timeout
 
 Examples:
   proxy {action: "start", id: "dev", target_url: "http://localhost:3000"}
@@ -240,6 +291,12 @@ Examples:
   proxy {action: "list"}
   proxy {action: "exec", id: "dev", code: "document.title"}
   proxy {action: "toast", id: "dev", toast_message: "Build complete!", toast_type: "success"}
+  proxy {action: "label", id: "dev", labels: {area: "checkout"}}
+  proxy {action: "list", labels: {area: "checkout"}}
+  proxy {action: "routes", id: "dev", routes_operation: "add", path_route: {path: "/api", target: "8000"}}
+  proxy {action: "intercept", id: "dev", intercept_operation: "add", intercept_rule: {methods: ["POST"], url_pattern: "^/api/orders"}}
+  proxy {action: "intercept", id: "dev", wait_ms: 30000}
+  proxy {action: "intercept", id: "dev", intercept_operation: "resume", intercept_request_id: "int-1", intercept_edit: {set_headers: {"X-Debug": "1"}}}
   proxy {action: "stop", id: "dev"}
 
 The proxy automatically:
@@ -259,8 +316,15 @@ Toast notifications:
   proxy {action: "toast", id: "dev", toast_message: "Task complete"}
   proxy {action: "toast", id: "dev", toast_type: "error", toast_title: "Build Failed", toast_message: "See console for details"}
   proxy {action: "toast", id: "dev", toast_type: "warning", toast_message: "Slow network detected", toast_duration: 8000}
+  proxy {action: "toast", id: "dev", toast_title: "Apply fix?", toast_message: "Retry failed requests", toast_actions: [{label: "Yes", callback: "apply"}, {label: "No", callback: "skip"}], toast_wait_ms: 60000}
   Toast types: success, error, warning, info (default)
 
+Mock responses (develop against endpoints that don't exist yet):
+  proxy {action: "mock", id: "dev", mock_operation: "add", mock_rule: {id: "users", methods: ["GET"], url_pattern: "^/api/users", body: "[{\"id\":1}]"}}
+  proxy {action: "mock", id: "dev"}   # List rules with hit counts
+  proxy {action: "mock", id: "dev", mock_operation: "remove", mock_rule_id: "users"}
+  Mocked responses carry an X-Devtool-Mock header and are logged like proxied ones.
+
 __devtool API (injected into browser):
   proxy {action: "exec", help: true}                    # Full API overview
   proxy {action: "exec", describe: "screenshot"}        # Detailed function docs
@@ -285,7 +349,11 @@ Actions:
   query: Search logs with filters (default, may be large)
   summary: Get compact aggregated summary (recommended for large logs)
   clear: Clear all logs for a proxy
-  stats: Get log statistics
+  stats: Get log statistics and the slowest routes (p50/p95/p99, error rate, and
+         average upstream vs proxy time: connect, ttfb, body, inject)
+  replay: Re-issue a logged HTTP request (entry_id) against the target and log
+          the new response, optionally with replay: {method, url, headers, body}
+          overrides. Handy for reproducing intermittent 500s.
 
 Log Types:
   http: HTTP request/response pairs
@@ -350,6 +418,11 @@ Actions:
   get: Get detailed information for a specific session (may be large)
   summary: Get a compact summary optimized for long/complex pages (recommended)
   clear: Clear all page sessions
+  wait_idle: Wait until a page has no requests in flight and no DOM mutations
+             for quiet_ms (use before screenshots or snapshots)
+  snapshot: Capture and keep a serialized DOM of a page (last 20 kept)
+  snapshots: List kept DOM snapshots
+  diff: Elements added, removed and changed between two snapshots
 
 A page session groups together:
   - The initial HTML document request
@@ -366,10 +439,18 @@ Examples:
   currentpage {proxy_id: "dev", action: "summary", session_id: "page-1", detail: ["interactions", "mutations"]}
   currentpage {proxy_id: "dev", action: "get", session_id: "page-1"}
   currentpage {proxy_id: "dev", action: "clear"}
+  currentpage {proxy_id: "dev", action: "wait_idle", session_id: "page-1"}
+  currentpage {proxy_id: "dev", action: "wait_idle", quiet_ms: 1000, timeout_ms: 20000}
+  currentpage {proxy_id: "dev", action: "snapshot", session_id: "page-1", name: "before", styles: true}
+  currentpage {proxy_id: "dev", action: "diff", from: "dom-1", to: "dom-2"}
+  currentpage {proxy_id: "dev", action: "screenshot", session_id: "page-1", selector: "#cart", annotate: true}
+  currentpage {proxy_id: "dev", action: "coverage", session_id: "page-1", limit: 50}
 
 The list action returns summary counts (interaction_count, mutation_count).
 The summary action returns aggregated data (errors by type, interactions by type,
   last 5 interactions/mutations) - best for long pages to avoid context overflow.
+  It includes web_vitals: LCP, CLS, INP, FCP and TTFB of the latest page load,
+  each rated good, needs-improvement or poor, and a 0-100 performance score.
   Use detail parameter to get full data for specific sections:
   - detail: ["interactions"] - include full interaction list
   - detail: ["mutations"] - include full mutation list
@@ -408,7 +489,7 @@ func (dt *DaemonTools) makeDetectHandler() func(context.Context, *mcp.CallToolRe
 		// Use session project path (from AGNT_PROJECT_PATH) when path is not specified
 		path := input.Path
 		if path == "" {
-			path = getProjectPath()
+			path = dt.projectPath(req.Session)
 		}
 		absPath, err := filepath.Abs(path)
 		if err != nil {
@@ -452,8 +533,15 @@ func (dt *DaemonTools) makeRunHandler() func(context.Context, *mcp.CallToolReque
 		// Resolve path to absolute to ensure daemon uses correct directory
 		// Use session project path (from AGNT_PROJECT_PATH) when path is not specified
 		path := input.Path
+		if input.Workspace != "" {
+			ws, err := dt.client.WorkspaceGet(input.Workspace)
+			if err != nil {
+				return formatDaemonError(err, "run"), RunOutput{}, nil
+			}
+			path = getString(ws, "path")
+		}
 		if path == "" {
-			path = getProjectPath()
+			path = dt.projectPath(req.Session)
 		}
 		absPath, err := filepath.Abs(path)
 		if err != nil {
@@ -472,26 +560,79 @@ func (dt *DaemonTools) makeRunHandler() func(context.Context, *mcp.CallToolReque
 			Mode:       string(input.Mode),
 			Env:        os.Environ(),
 		}
+		// Point the process at the project's running API doubles
+		if doubles, err := dt.client.DoubleList(absPath); err == nil {
+			if env, ok := doubles["env"].(map[string]interface{}); ok {
+				for k, v := range env {
+					config.Env = append(config.Env, fmt.Sprintf("%s=%v", k, v))
+				}
+			}
+		}
 
 		if config.Mode == "" {
 			config.Mode = "background"
 		}
+		if input.Profile {
+			config.Env = profile.WithNodeInspector(config.Env)
+		}
 
-		result, err := dt.client.Run(config)
-		if err != nil {
-			return formatDaemonError(err, "run"), RunOutput{}, nil
+		// Projects with a remote block run on the remote checkout
+		var result map[string]interface{}
+		if agntCfg, err := agntconfig.LoadAgntConfig(absPath); err == nil && agntCfg.Remote != nil {
+			if input.Profile {
+				return errorResult("profile is not supported for remote projects"), RunOutput{}, nil
+			}
+			remoteCfg := protocol.RemoteRunConfig{ID: input.ID, Path: absPath, Mode: "background"}
+			if input.Raw {
+				remoteCfg.Command, remoteCfg.Args = input.Command, input.Args
+			} else {
+				remoteCfg.Script, remoteCfg.Args = input.ScriptName, input.Args
+			}
+			if config.Mode != "background" {
+				remoteCfg.Mode = "foreground"
+			}
+			result, err = dt.client.RemoteRun(remoteCfg)
+			if err != nil {
+				return formatDaemonError(err, "run"), RunOutput{}, nil
+			}
+		} else {
+			result, err = dt.client.Run(config)
+			if err != nil {
+				return formatDaemonError(err, "run"), RunOutput{}, nil
+			}
+		}
+		if len(input.Labels) > 0 {
+			if id := getString(result, "process_id"); id != "" {
+				if _, err := dt.client.ProcLabel(id, input.Labels, nil); err != nil {
+					return formatDaemonError(err, "run"), RunOutput{}, nil
+				}
+			}
+		}
+		if input.HealthCheck != nil || (input.Restart != "" && input.Restart != protocol.RestartNever) {
+			if id := getString(result, "process_id"); id != "" && config.Mode == "background" {
+				cfg := &protocol.SuperviseConfig{HealthCheck: input.HealthCheck, Restart: input.Restart, MaxRestarts: input.MaxRestarts}
+				if _, err := dt.client.ProcSupervise(id, cfg); err != nil {
+					return formatDaemonError(err, "run"), RunOutput{}, nil
+				}
+			}
 		}
 
 		// Convert to output type
 		output := RunOutput{
-			ProcessID: getString(result, "process_id"),
-			PID:       getInt(result, "pid"),
-			Command:   getString(result, "command"),
-			ExitCode:  getInt(result, "exit_code"),
-			State:     getString(result, "state"),
-			Runtime:   getString(result, "runtime"),
-			Stdout:    getString(result, "stdout"),
-			Stderr:    getString(result, "stderr"),
+			ProcessID:  getString(result, "process_id"),
+			PID:        getInt(result, "pid"),
+			Command:    getString(result, "command"),
+			ExitCode:   getInt(result, "exit_code"),
+			State:      getString(result, "state"),
+			Runtime:    getString(result, "runtime"),
+			Stdout:     getString(result, "stdout"),
+			Stderr:     getString(result, "stderr"),
+			RemoteHost: getString(result, "remote_host"),
+		}
+		if config.Mode != "background" && output.ProcessID != "" {
+			if results, err := dt.client.ProcResults(output.ProcessID); err == nil {
+				output.Results = decodeTestReport(results)
+			}
 		}
 
 		return nil, output, nil
@@ -515,9 +656,19 @@ func (dt *DaemonTools) makeProcHandler() func(context.Context, *mcp.CallToolRequ
 		case "restart":
 			return dt.handleProcRestart(input)
 		case "list":
-			return dt.handleProcList(input)
+			return dt.handleProcList(req.Session, input)
 		case "cleanup_port":
 			return dt.handleProcCleanupPort(input)
+		case "crash":
+			return dt.handleProcCrash(req.Session, input)
+		case "label":
+			return dt.handleProcLabel(input)
+		case "supervise":
+			return dt.handleProcSupervise(input)
+		case "metrics":
+			return dt.handleProcMetrics(input)
+		case "results":
+			return dt.handleProcResults(input)
 		default:
 			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), ProcOutput{}, nil
 		}
@@ -534,13 +685,127 @@ func (dt *DaemonTools) handleProcStatus(input ProcInput) (*mcp.CallToolResult, P
 		return formatDaemonError(err, "proc"), ProcOutput{}, nil
 	}
 
-	return nil, ProcOutput{
+	output := ProcOutput{
 		ProcessID: getString(result, "process_id"),
 		State:     getString(result, "state"),
 		Summary:   getString(result, "summary"),
 		ExitCode:  getInt(result, "exit_code"),
 		Runtime:   getString(result, "runtime"),
-	}, nil
+	}
+	if raw, ok := result["crash"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &output.Crash)
+		}
+	}
+	output.Health = decodeProcHealth(result)
+	if raw, ok := result["metrics"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &output.Metrics)
+		}
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleProcMetrics(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	if input.ProcessID == "" {
+		return errorResult("process_id required for metrics"), ProcOutput{}, nil
+	}
+
+	result, err := dt.client.ProcMetrics(input.ProcessID, input.Limit)
+	if err != nil {
+		return formatDaemonError(err, "proc"), ProcOutput{}, nil
+	}
+
+	var metrics struct {
+		ProcMetrics
+		Current *ProcSample `json:"current"`
+	}
+	if b, err := json.Marshal(result); err == nil {
+		json.Unmarshal(b, &metrics)
+	}
+	output := ProcOutput{
+		ProcessID: input.ProcessID,
+		Message:   getString(result, "message"),
+		Metrics:   metrics.Current,
+		History:   &metrics.ProcMetrics,
+	}
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleProcResults(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	if input.ProcessID == "" {
+		return errorResult("process_id required for results"), ProcOutput{}, nil
+	}
+
+	result, err := dt.client.ProcResults(input.ProcessID)
+	if err != nil {
+		return formatDaemonError(err, "proc"), ProcOutput{}, nil
+	}
+
+	output := ProcOutput{
+		ProcessID: input.ProcessID,
+		State:     getString(result, "state"),
+		ExitCode:  getInt(result, "exit_code"),
+		Message:   getString(result, "message"),
+		Results:   decodeTestReport(result),
+	}
+	return nil, output, nil
+}
+
+// decodeTestReport reads a PROC RESULTS response, or returns nil when the
+// output had no test results or build errors.
+func decodeTestReport(m map[string]interface{}) *TestReport {
+	var report TestReport
+	if b, err := json.Marshal(m); err == nil {
+		json.Unmarshal(b, &report)
+	}
+	if report.Total == 0 && len(report.BuildErrors) == 0 {
+		return nil
+	}
+	return &report
+}
+
+func (dt *DaemonTools) handleProcSupervise(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	if input.ProcessID == "" {
+		return errorResult("process_id required for supervise"), ProcOutput{}, nil
+	}
+
+	var cfg *protocol.SuperviseConfig
+	if input.Restart != "off" {
+		cfg = &protocol.SuperviseConfig{HealthCheck: input.HealthCheck, Restart: input.Restart, MaxRestarts: input.MaxRestarts}
+	}
+	result, err := dt.client.ProcSupervise(input.ProcessID, cfg)
+	if err != nil {
+		return formatDaemonError(err, "proc"), ProcOutput{}, nil
+	}
+
+	output := ProcOutput{
+		ProcessID: input.ProcessID,
+		Success:   true,
+		Health:    decodeProcHealth(result),
+	}
+	if cfg == nil {
+		output.Message = fmt.Sprintf("process %q is no longer supervised", input.ProcessID)
+	}
+	return nil, output, nil
+}
+
+// decodeProcHealth converts the health field of a daemon response.
+func decodeProcHealth(result map[string]interface{}) *ProcHealth {
+	raw, ok := result["health"]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var health ProcHealth
+	if json.Unmarshal(b, &health) != nil {
+		return nil
+	}
+	return &health
 }
 
 func (dt *DaemonTools) handleProcOutput(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
@@ -556,7 +821,13 @@ func (dt *DaemonTools) handleProcOutput(input ProcInput) (*mcp.CallToolResult, P
 		GrepV:  input.GrepV,
 	}
 
-	output, err := dt.client.ProcOutput(input.ProcessID, filter)
+	var output string
+	var err error
+	if input.Follow {
+		output, err = dt.client.ProcOutputFollow(input.ProcessID, filter, time.Duration(input.FollowTimeoutMs)*time.Millisecond)
+	} else {
+		output, err = dt.client.ProcOutput(input.ProcessID, filter)
+	}
 	if err != nil {
 		return formatDaemonError(err, "proc"), ProcOutput{}, nil
 	}
@@ -567,21 +838,57 @@ func (dt *DaemonTools) handleProcOutput(input ProcInput) (*mcp.CallToolResult, P
 	}, nil
 }
 
+func (dt *DaemonTools) handleProcCrash(ss *mcp.ServerSession, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	result, err := dt.client.ProcCrash(input.ProcessID, dt.projectPath(ss))
+	if err != nil {
+		return formatDaemonError(err, "proc"), ProcOutput{}, nil
+	}
+
+	output := ProcOutput{
+		ProcessID: input.ProcessID,
+		Count:     getInt(result, "count"),
+	}
+	for key, dst := range map[string]interface{}{
+		"crash":   &output.Crash,
+		"crashes": &output.Crashes,
+	} {
+		if raw, ok := result[key]; ok {
+			if b, err := json.Marshal(raw); err == nil {
+				json.Unmarshal(b, dst)
+			}
+		}
+	}
+
+	return nil, output, nil
+}
+
 func (dt *DaemonTools) handleProcStop(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
 	if input.ProcessID == "" {
 		return errorResult("process_id required for stop"), ProcOutput{}, nil
 	}
 
-	result, err := dt.client.ProcStop(input.ProcessID, input.Force)
+	result, err := dt.client.ProcStopCascade(input.ProcessID, input.Force, input.Cascade)
 	if err != nil {
 		return formatDaemonError(err, "proc"), ProcOutput{}, nil
 	}
 
-	return nil, ProcOutput{
+	output := ProcOutput{
 		ProcessID: getString(result, "process_id"),
 		State:     getString(result, "state"),
 		Success:   getBool(result, "success"),
-	}, nil
+		Message:   getString(result, "warning"),
+	}
+	if cascaded, ok := result["cascaded"].([]interface{}); ok && len(cascaded) > 0 {
+		names := make([]string, 0, len(cascaded))
+		for _, c := range cascaded {
+			if s, ok := c.(string); ok {
+				names = append(names, s)
+			}
+		}
+		output.Message = "also stopped " + strings.Join(names, ", ")
+	}
+
+	return nil, output, nil
 }
 
 func (dt *DaemonTools) handleProcRestart(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
@@ -597,29 +904,30 @@ func (dt *DaemonTools) handleProcRestart(input ProcInput) (*mcp.CallToolResult,
 	return nil, ProcOutput{
 		ProcessID: getString(result, "process_id"),
 		State:     getString(result, "state"),
+		PID:       getInt(result, "pid"),
 		Success:   getBool(result, "success"),
 		Message:   getString(result, "message"),
 	}, nil
 }
 
-func (dt *DaemonTools) handleProcList(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+func (dt *DaemonTools) handleProcList(ss *mcp.ServerSession, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
 	// Create directory filter with session code if attached
 	dirFilter := protocol.DirectoryFilter{
 		Global: input.Global,
 	}
 
 	// Use session code if attached, otherwise fall back to project path
-	if sessionCode := dt.SessionCode(); sessionCode != "" {
+	if sessionCode := dt.sessionCodeFor(ss); sessionCode != "" {
 		dirFilter.SessionCode = sessionCode
 	} else {
 		// Legacy fallback: use project path from environment or cwd
-		projectPath := getProjectPath()
+		projectPath := dt.projectPath(ss)
 		if projectPath != "" {
 			dirFilter.Directory = projectPath
 		}
 	}
 
-	result, err := dt.client.ProcList(dirFilter)
+	result, err := dt.client.ProcListFiltered(protocol.ListFilter{DirectoryFilter: dirFilter, Labels: input.Labels})
 	if err != nil {
 		return formatDaemonError(err, "proc"), ProcOutput{}, nil
 	}
@@ -641,6 +949,7 @@ func (dt *DaemonTools) handleProcList(input ProcInput) (*mcp.CallToolResult, Pro
 					Summary:     getString(pm, "summary"),
 					Runtime:     getString(pm, "runtime"),
 					ProjectPath: getString(pm, "project_path"),
+					Labels:      getLabels(pm, "labels"),
 				})
 			}
 		}
@@ -649,11 +958,41 @@ func (dt *DaemonTools) handleProcList(input ProcInput) (*mcp.CallToolResult, Pro
 	return nil, output, nil
 }
 
+func (dt *DaemonTools) handleProcLabel(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	if input.ProcessID == "" {
+		return errorResult("process_id required for label"), ProcOutput{}, nil
+	}
+
+	result, err := dt.client.ProcLabel(input.ProcessID, input.Labels, input.RemoveLabels)
+	if err != nil {
+		return formatDaemonError(err, "proc"), ProcOutput{}, nil
+	}
+
+	return nil, ProcOutput{
+		ProcessID: getString(result, "id"),
+		Labels:    getLabels(result, "labels"),
+		Success:   true,
+	}, nil
+}
+
 func (dt *DaemonTools) handleProcCleanupPort(input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
 	if input.Port <= 0 || input.Port > 65535 {
 		return errorResult("valid port number required (1-65535)"), ProcOutput{}, nil
 	}
 
+	if input.DryRun {
+		result, err := dt.client.ProcCleanupPortDryRun(input.Port)
+		if err != nil {
+			return formatDaemonError(err, "proc"), ProcOutput{}, nil
+		}
+		affected := getAffected(result)
+		return nil, ProcOutput{
+			DryRun:   true,
+			Affected: affected,
+			Message:  fmt.Sprintf("Would kill %d process(es) on port %d", len(affected), input.Port),
+		}, nil
+	}
+
 	result, err := dt.client.ProcCleanupPort(input.Port)
 	if err != nil {
 		return formatDaemonError(err, "proc"), ProcOutput{}, nil
@@ -689,7 +1028,7 @@ func (dt *DaemonTools) makeProxyHandler() func(context.Context, *mcp.CallToolReq
 
 		switch input.Action {
 		case "start":
-			return dt.handleProxyStart(input)
+			return dt.handleProxyStart(req.Session, input)
 		case "stop":
 			return dt.handleProxyStop(input)
 		case "restart":
@@ -697,20 +1036,30 @@ func (dt *DaemonTools) makeProxyHandler() func(context.Context, *mcp.CallToolReq
 		case "status":
 			return dt.handleProxyStatus(input)
 		case "list":
-			return dt.handleProxyList(input)
+			return dt.handleProxyList(req.Session, input)
 		case "exec":
 			return dt.handleProxyExec(input)
 		case "toast":
 			return dt.handleProxyToast(input)
 		case "chaos":
 			return dt.handleProxyChaos(input)
+		case "mock":
+			return dt.handleProxyMock(input)
+		case "label":
+			return dt.handleProxyLabel(input)
+		case "routes":
+			return dt.handleProxyRoutes(input)
+		case "ui":
+			return dt.handleProxyUI(input)
+		case "intercept":
+			return dt.handleProxyIntercept(input)
 		default:
 			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), ProxyOutput{}, nil
 		}
 	}
 }
 
-func (dt *DaemonTools) handleProxyStart(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+func (dt *DaemonTools) handleProxyStart(ss *mcp.ServerSession, input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
 	if input.ID == "" {
 		return errorResult("id required for start"), ProxyOutput{}, nil
 	}
@@ -718,10 +1067,9 @@ func (dt *DaemonTools) handleProxyStart(input ProxyInput) (*mcp.CallToolResult,
 		return errorResult("target_url required for start"), ProxyOutput{}, nil
 	}
 
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return errorResult(fmt.Sprintf("failed to get working directory: %v", err)), ProxyOutput{}, nil
+	cwd := dt.projectPath(ss)
+	if cwd == "" {
+		return errorResult("failed to get working directory"), ProxyOutput{}, nil
 	}
 
 	// Use -1 to signal "use default" (hash-based port), 0 means auto-assign
@@ -736,6 +1084,20 @@ func (dt *DaemonTools) handleProxyStart(input ProxyInput) (*mcp.CallToolResult,
 		BindAddress: input.BindAddress,
 		PublicURL:   input.PublicURL,
 		VerifyTLS:   input.VerifyTLS,
+		Encrypt:     input.Encrypt,
+		NoRetarget:  input.NoRetarget,
+		Routes:      input.Routes,
+		PathRoutes:  input.PathRoutes,
+		Cookies:     input.Cookies,
+		URLRewrite:  input.URLRewrite,
+		Storms:      input.Storms,
+		Banner:      input.Banner,
+		UI:          input.UI,
+		BodyCapture: input.BodyCapture,
+		PersistLogs: input.PersistLogs,
+
+		TrustedProxies: input.TrustedProxies,
+		Labels:         input.Labels,
 	}
 
 	// Configure tunnel if specified
@@ -776,6 +1138,7 @@ func (dt *DaemonTools) handleProxyStart(input ProxyInput) (*mcp.CallToolResult,
 		BindAddress: bindAddress,
 		PublicURL:   publicURL,
 		TunnelURL:   tunnelURL,
+		Labels:      getLabels(result, "labels"),
 		Message:     fmt.Sprintf("Proxy started. Access at %s", accessURL),
 	}, nil
 }
@@ -785,14 +1148,24 @@ func (dt *DaemonTools) handleProxyStop(input ProxyInput) (*mcp.CallToolResult, P
 		return errorResult("id required for stop"), ProxyOutput{}, nil
 	}
 
-	err := dt.client.ProxyStop(input.ID)
+	// Look up dependents first; the stop response carries no details
+	message := fmt.Sprintf("Proxy %s stopped", input.ID)
+	if impact, err := dt.client.GraphImpact("proxy", input.ID); err == nil && getInt(impact, "count") > 0 {
+		if input.Cascade {
+			message += "; dependents stopped too"
+		} else {
+			message += "; warning: " + getString(impact, "message")
+		}
+	}
+
+	err := dt.client.ProxyStopCascade(input.ID, input.Cascade)
 	if err != nil {
 		return formatDaemonError(err, "proxy"), ProxyOutput{}, nil
 	}
 
 	return nil, ProxyOutput{
 		Success: true,
-		Message: fmt.Sprintf("Proxy %s stopped", input.ID),
+		Message: message,
 	}, nil
 }
 
@@ -845,27 +1218,54 @@ func (dt *DaemonTools) handleProxyStatus(input ProxyInput) (*mcp.CallToolResult,
 		}
 	}
 
+	if stats, ok := result["stats"].(map[string]interface{}); ok {
+		if b, err := json.Marshal(stats["retargets"]); err == nil {
+			_ = json.Unmarshal(b, &output.Retargets)
+		}
+		if b, err := json.Marshal(stats["routes"]); err == nil {
+			_ = json.Unmarshal(b, &output.Routes)
+		}
+		if b, err := json.Marshal(stats["path_routes"]); err == nil {
+			_ = json.Unmarshal(b, &output.PathRoutes)
+		}
+		if n, ok := stats["cookie_rewrites"].(float64); ok {
+			output.CookieRewrites = int64(n)
+		}
+		if b, err := json.Marshal(stats["cookie_changes"]); err == nil {
+			_ = json.Unmarshal(b, &output.CookieChanges)
+		}
+		if b, err := json.Marshal(stats["url_rewrites"]); err == nil {
+			_ = json.Unmarshal(b, &output.URLRewrites)
+		}
+		if b, err := json.Marshal(stats["traffic"]); err == nil {
+			_ = json.Unmarshal(b, &output.Traffic)
+		}
+		if b, err := json.Marshal(stats["request_storms"]); err == nil {
+			_ = json.Unmarshal(b, &output.RequestStorms)
+		}
+	}
+
 	return nil, output, nil
 }
 
-func (dt *DaemonTools) handleProxyList(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+func (dt *DaemonTools) handleProxyList(ss *mcp.ServerSession, input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
 	// Create directory filter with session code if attached
 	dirFilter := protocol.DirectoryFilter{
 		Global: input.Global,
 	}
 
 	// Use session code if attached, otherwise fall back to project path
-	if sessionCode := dt.SessionCode(); sessionCode != "" {
+	if sessionCode := dt.sessionCodeFor(ss); sessionCode != "" {
 		dirFilter.SessionCode = sessionCode
 	} else {
 		// Legacy fallback: use project path from environment or cwd
-		projectPath := getProjectPath()
+		projectPath := dt.projectPath(ss)
 		if projectPath != "" {
 			dirFilter.Directory = projectPath
 		}
 	}
 
-	result, err := dt.client.ProxyList(dirFilter)
+	result, err := dt.client.ProxyListFiltered(protocol.ListFilter{DirectoryFilter: dirFilter, Labels: input.Labels})
 	if err != nil {
 		return formatDaemonError(err, "proxy"), ProxyOutput{}, nil
 	}
@@ -890,6 +1290,7 @@ func (dt *DaemonTools) handleProxyList(input ProxyInput) (*mcp.CallToolResult, P
 					Running:       getBool(pm, "running"),
 					Uptime:        getString(pm, "uptime"),
 					TotalRequests: getInt64(pm, "total_requests"),
+					Labels:        getLabels(pm, "labels"),
 				})
 			}
 		}
@@ -898,6 +1299,150 @@ func (dt *DaemonTools) handleProxyList(input ProxyInput) (*mcp.CallToolResult, P
 	return nil, output, nil
 }
 
+func (dt *DaemonTools) handleProxyLabel(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required for label"), ProxyOutput{}, nil
+	}
+
+	result, err := dt.client.ProxyLabel(input.ID, input.Labels, input.RemoveLabels)
+	if err != nil {
+		return formatDaemonError(err, "proxy"), ProxyOutput{}, nil
+	}
+
+	return nil, ProxyOutput{
+		ID:      getString(result, "id"),
+		Labels:  getLabels(result, "labels"),
+		Success: true,
+	}, nil
+}
+
+func (dt *DaemonTools) handleProxyRoutes(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required for routes"), ProxyOutput{}, nil
+	}
+
+	var result map[string]interface{}
+	var err error
+	switch input.RoutesOperation {
+	case "add":
+		if input.PathRoute == nil {
+			return errorResult("path_route required for add operation"), ProxyOutput{}, nil
+		}
+		result, err = dt.client.ProxyRoutesAdd(input.ID, *input.PathRoute)
+	case "remove":
+		if input.RoutePath == "" {
+			return errorResult("route_path required for remove operation"), ProxyOutput{}, nil
+		}
+		result, err = dt.client.ProxyRoutesRemove(input.ID, input.RoutePath)
+	case "", "list":
+		result, err = dt.client.ProxyRoutesList(input.ID)
+	default:
+		return errorResult(fmt.Sprintf("unknown routes operation %q. Use: add, remove, list", input.RoutesOperation)), ProxyOutput{}, nil
+	}
+	if err != nil {
+		return formatDaemonError(err, "proxy"), ProxyOutput{}, nil
+	}
+
+	output := ProxyOutput{ID: input.ID, Success: true}
+	if b, err := json.Marshal(result["path_routes"]); err == nil {
+		_ = json.Unmarshal(b, &output.PathRoutes)
+	}
+	if len(output.PathRoutes) == 0 {
+		output.Message = "No path routes"
+	}
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleProxyIntercept(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required for intercept"), ProxyOutput{}, nil
+	}
+
+	var result map[string]interface{}
+	var err error
+	var message string
+	switch input.InterceptOperation {
+	case "add":
+		if input.InterceptRule == nil {
+			return errorResult("intercept_rule required for add operation"), ProxyOutput{}, nil
+		}
+		result, err = dt.client.ProxyInterceptAdd(input.ID, *input.InterceptRule)
+		message = "Intercept rule added; matching requests now pause until resumed or rejected"
+	case "remove":
+		if input.InterceptRuleID == "" {
+			return errorResult("intercept_rule_id required for remove operation"), ProxyOutput{}, nil
+		}
+		result, err = dt.client.ProxyInterceptRemove(input.ID, input.InterceptRuleID)
+		message = fmt.Sprintf("Intercept rule %q removed", input.InterceptRuleID)
+	case "", "list":
+		result, err = dt.client.ProxyInterceptList(input.ID, time.Duration(input.WaitMs)*time.Millisecond)
+	case "modify", "resume", "reject":
+		if input.InterceptRequestID == "" {
+			return errorResult(fmt.Sprintf("intercept_request_id required for %s operation", input.InterceptOperation)), ProxyOutput{}, nil
+		}
+		switch input.InterceptOperation {
+		case "modify":
+			if input.InterceptEdit == nil {
+				return errorResult("intercept_edit required for modify operation"), ProxyOutput{}, nil
+			}
+			result, err = dt.client.ProxyInterceptModify(input.ID, input.InterceptRequestID, *input.InterceptEdit)
+			message = fmt.Sprintf("Request %s modified and still paused", input.InterceptRequestID)
+		case "resume":
+			result, err = dt.client.ProxyInterceptResume(input.ID, input.InterceptRequestID, input.InterceptEdit)
+			message = fmt.Sprintf("Request %s resumed", input.InterceptRequestID)
+		case "reject":
+			result, err = dt.client.ProxyInterceptReject(input.ID, input.InterceptRequestID, input.RejectStatus, input.RejectBody)
+			message = fmt.Sprintf("Request %s rejected", input.InterceptRequestID)
+		}
+	case "clear":
+		result, err = dt.client.ProxyInterceptClear(input.ID)
+		message = "Intercept rules cleared; paused requests continue"
+	default:
+		return errorResult(fmt.Sprintf("unknown intercept operation %q. Use: add, remove, list, modify, resume, reject, clear", input.InterceptOperation)), ProxyOutput{}, nil
+	}
+	if err != nil {
+		return formatDaemonError(err, "proxy"), ProxyOutput{}, nil
+	}
+
+	output := ProxyOutput{ID: input.ID, Success: true, Message: message}
+	if b, err := json.Marshal(result["rules"]); err == nil {
+		_ = json.Unmarshal(b, &output.InterceptRules)
+	}
+	if b, err := json.Marshal(result["pending"]); err == nil {
+		_ = json.Unmarshal(b, &output.Intercepted)
+	}
+	if output.Message == "" && len(output.Intercepted) == 0 {
+		output.Message = "No paused requests"
+	}
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleProxyUI(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required for ui"), ProxyOutput{}, nil
+	}
+
+	var result map[string]interface{}
+	var err error
+	if input.UI != nil {
+		result, err = dt.client.ProxySetUI(input.ID, *input.UI)
+	} else {
+		result, err = dt.client.ProxyUI(input.ID)
+	}
+	if err != nil {
+		return formatDaemonError(err, "proxy"), ProxyOutput{}, nil
+	}
+
+	output := ProxyOutput{ID: input.ID, Success: true}
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &output)
+	}
+	if input.UI != nil {
+		output.Message = fmt.Sprintf("Overlay updated in %d connected page(s)", output.PagesUpdated)
+	}
+	return nil, output, nil
+}
+
 func (dt *DaemonTools) handleProxyExec(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
 	// Handle help request - no proxy ID required
 	if input.Help {
@@ -987,6 +1532,9 @@ func (dt *DaemonTools) handleProxyToast(input ProxyInput) (*mcp.CallToolResult,
 		Title:    input.ToastTitle,
 		Message:  input.ToastMessage,
 		Duration: input.ToastDuration,
+		Actions:  input.ToastActions,
+		Session:  input.ToastSession,
+		WaitMs:   input.ToastWaitMs,
 	}
 
 	// Default type to "info" if not specified
@@ -1000,11 +1548,26 @@ func (dt *DaemonTools) handleProxyToast(input ProxyInput) (*mcp.CallToolResult,
 	}
 
 	sentCount := getInt(result, "sent_count")
+	output := ProxyOutput{
+		Success:  getBool(result, "success"),
+		Message:  fmt.Sprintf("Toast sent to %d connected client(s)", sentCount),
+		ToastID:  getString(result, "toast_id"),
+		TimedOut: getBool(result, "timed_out"),
+	}
+	if action, ok := result["action"].(map[string]interface{}); ok {
+		click := proxy.ToastClick{
+			ToastID:  getString(action, "toast_id"),
+			Callback: getString(action, "callback"),
+			Label:    getString(action, "label"),
+			URL:      getString(action, "url"),
+		}
+		output.ToastAction = &click
+		output.Message = fmt.Sprintf("User clicked %q (callback: %s)", click.Label, click.Callback)
+	} else if output.TimedOut {
+		output.Message = fmt.Sprintf("Toast sent to %d connected client(s); no click within toast_wait_ms", sentCount)
+	}
 
-	return nil, ProxyOutput{
-		Success: getBool(result, "success"),
-		Message: fmt.Sprintf("Toast sent to %d connected client(s)", sentCount),
-	}, nil
+	return nil, output, nil
 }
 
 // parseChaosStats extracts ChaosStatsOutput from a map result.
@@ -1048,6 +1611,35 @@ func parseChaosRules(rules []interface{}) []ChaosRuleOutput {
 	return result
 }
 
+// inputConfigToProtocol converts a ChaosConfigInput to protocol.ChaosConfigPayload.
+func inputConfigToProtocol(c ChaosConfigInput) protocol.ChaosConfigPayload {
+	config := protocol.ChaosConfigPayload{
+		Enabled:     c.Enabled,
+		GlobalOdds:  c.GlobalOdds,
+		Seed:        c.Seed,
+		LoggingMode: c.LoggingMode,
+	}
+	for _, r := range c.Rules {
+		rule := inputRuleToProtocol(r)
+		config.Rules = append(config.Rules, &rule)
+	}
+	return config
+}
+
+// inputScenarioToProtocol converts a ChaosScenarioInput to protocol.ChaosScenarioPayload.
+func inputScenarioToProtocol(s ChaosScenarioInput) protocol.ChaosScenarioPayload {
+	scenario := protocol.ChaosScenarioPayload{Name: s.Name, Loop: s.Loop}
+	for _, p := range s.Phases {
+		phase := protocol.ChaosPhasePayload{Name: p.Name, DurationMs: p.DurationMs, Preset: p.Preset}
+		for _, r := range p.Rules {
+			rule := inputRuleToProtocol(r)
+			phase.Rules = append(phase.Rules, &rule)
+		}
+		scenario.Phases = append(scenario.Phases, phase)
+	}
+	return scenario
+}
+
 // inputRuleToProtocol converts a ChaosRuleInput to protocol.ChaosRuleConfig.
 func inputRuleToProtocol(r ChaosRuleInput) protocol.ChaosRuleConfig {
 	return protocol.ChaosRuleConfig{
@@ -1058,13 +1650,19 @@ func inputRuleToProtocol(r ChaosRuleInput) protocol.ChaosRuleConfig {
 		URLPattern:         r.URLPattern,
 		Methods:            r.Methods,
 		Probability:        r.Probability,
+		Sessions:           r.Sessions,
+		Headers:            r.Headers,
+		Query:              r.Query,
+		BodyFields:         r.BodyFields,
 		MinLatencyMs:       r.MinLatencyMs,
 		MaxLatencyMs:       r.MaxLatencyMs,
 		JitterMs:           r.JitterMs,
 		BytesPerMs:         r.BytesPerMs,
 		ChunkSize:          r.ChunkSize,
+		BandwidthKbps:      r.BandwidthKbps,
 		DropAfterPercent:   r.DropAfterPercent,
 		DropAfterBytes:     r.DropAfterBytes,
+		Hosts:              r.Hosts,
 		ErrorCodes:         r.ErrorCodes,
 		ErrorMessage:       r.ErrorMessage,
 		TruncatePercent:    r.TruncatePercent,
@@ -1141,30 +1739,50 @@ func (dt *DaemonTools) handleProxyChaos(input ProxyInput) (*mcp.CallToolResult,
 			}
 			return nil, ProxyOutput{}, nil
 		}
+		scoped := len(input.ChaosSessions) > 0
+		if input.DryRun {
+			var result map[string]interface{}
+			var err error
+			if scoped {
+				result, err = dt.client.ChaosPresetForSessionsDryRun(input.ID, input.ChaosPreset, input.ChaosSessions)
+			} else {
+				result, err = dt.client.ChaosPresetDryRun(input.ID, input.ChaosPreset)
+			}
+			if err != nil {
+				return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
+			}
+			return nil, chaosDryRunOutput(result, fmt.Sprintf("Preset %q would replace", input.ChaosPreset)), nil
+		}
 		// Apply preset
-		result, err := dt.client.ChaosPreset(input.ID, input.ChaosPreset)
+		var result map[string]interface{}
+		var err error
+		message := fmt.Sprintf("Chaos preset %q applied", input.ChaosPreset)
+		if scoped {
+			result, err = dt.client.ChaosPresetForSessions(input.ID, input.ChaosPreset, input.ChaosSessions)
+			message += " to sessions " + strings.Join(input.ChaosSessions, ", ")
+		} else {
+			result, err = dt.client.ChaosPreset(input.ID, input.ChaosPreset)
+		}
 		if err != nil {
 			return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
 		}
 		return nil, ProxyOutput{
 			Success:      true,
 			ChaosEnabled: getBool(result, "enabled"),
-			Message:      fmt.Sprintf("Chaos preset %q applied", input.ChaosPreset),
+			Message:      message,
 		}, nil
 
 	case "set":
 		if input.ChaosConfig == nil {
 			return errorResult("chaos_config required for set operation"), ProxyOutput{}, nil
 		}
-		config := protocol.ChaosConfigPayload{
-			Enabled:     input.ChaosConfig.Enabled,
-			GlobalOdds:  input.ChaosConfig.GlobalOdds,
-			Seed:        input.ChaosConfig.Seed,
-			LoggingMode: input.ChaosConfig.LoggingMode,
-		}
-		for _, r := range input.ChaosConfig.Rules {
-			rule := inputRuleToProtocol(r)
-			config.Rules = append(config.Rules, &rule)
+		config := inputConfigToProtocol(*input.ChaosConfig)
+		if input.DryRun {
+			result, err := dt.client.ChaosSetDryRun(input.ID, config)
+			if err != nil {
+				return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
+			}
+			return nil, chaosDryRunOutput(result, "The configuration would replace"), nil
 		}
 		result, err := dt.client.ChaosSet(input.ID, config)
 		if err != nil {
@@ -1225,7 +1843,30 @@ func (dt *DaemonTools) handleProxyChaos(input ProxyInput) (*mcp.CallToolResult,
 		}
 		return nil, output, nil
 
+	case "preview":
+		var config *protocol.ChaosConfigPayload
+		if input.ChaosConfig != nil {
+			payload := inputConfigToProtocol(*input.ChaosConfig)
+			config = &payload
+		}
+		result, err := dt.client.ChaosPreview(input.ID, input.ChaosPreset, config)
+		if err != nil {
+			return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
+		}
+		var preview proxy.ChaosPreview
+		if b, err := json.Marshal(result); err == nil {
+			_ = json.Unmarshal(b, &preview)
+		}
+		return nil, ProxyOutput{ChaosPreview: &preview}, nil
+
 	case "clear":
+		if input.DryRun {
+			result, err := dt.client.ChaosClearDryRun(input.ID)
+			if err != nil {
+				return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
+			}
+			return nil, chaosDryRunOutput(result, "Clearing would remove"), nil
+		}
 		_, err := dt.client.ChaosClear(input.ID)
 		if err != nil {
 			return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
@@ -1236,11 +1877,132 @@ func (dt *DaemonTools) handleProxyChaos(input ProxyInput) (*mcp.CallToolResult,
 			Message:      "Chaos configuration cleared",
 		}, nil
 
+	case "scenario_start", "scenario_stop", "scenario_status":
+		var result map[string]interface{}
+		var err error
+		message := ""
+		switch operation {
+		case "scenario_start":
+			if input.ChaosScenario == nil {
+				return errorResult("chaos_scenario required for scenario_start operation"), ProxyOutput{}, nil
+			}
+			result, err = dt.client.ChaosScenarioStart(input.ID, inputScenarioToProtocol(*input.ChaosScenario))
+			message = fmt.Sprintf("Chaos scenario %q started", input.ChaosScenario.Name)
+		case "scenario_stop":
+			result, err = dt.client.ChaosScenarioStop(input.ID)
+			message = "Chaos scenario stopped; the previous configuration is restored"
+		default:
+			result, err = dt.client.ChaosScenarioStatus(input.ID)
+		}
+		if err != nil {
+			return formatDaemonError(err, "chaos"), ProxyOutput{}, nil
+		}
+		output := ProxyOutput{Success: true, Message: message}
+		if status, ok := result["scenario"]; ok {
+			output.ChaosScenario = &proxy.ChaosScenarioStatus{}
+			if b, err := json.Marshal(status); err == nil {
+				_ = json.Unmarshal(b, output.ChaosScenario)
+			}
+		} else {
+			output.Message = "No scenario has run on this proxy"
+		}
+		return nil, output, nil
+
+	default:
+		return errorResult(fmt.Sprintf("unknown chaos operation %q. Use: enable, disable, status, preset, set, add_rule, remove_rule, list_rules, stats, preview, clear, scenario_start, scenario_stop, scenario_status", operation)), ProxyOutput{}, nil
+	}
+}
+
+// chaosDryRunOutput reports the rules a chaos preset, set or clear would
+// remove, and for preset and set which logged requests it would hit.
+func chaosDryRunOutput(result map[string]interface{}, action string) ProxyOutput {
+	output := ProxyOutput{
+		DryRun:   true,
+		Affected: getAffected(result),
+	}
+	output.Message = fmt.Sprintf("%s %d chaos rule(s)", action, len(output.Affected))
+	if preview, ok := result["preview"]; ok {
+		output.ChaosPreview = &proxy.ChaosPreview{}
+		if b, err := json.Marshal(preview); err == nil {
+			_ = json.Unmarshal(b, output.ChaosPreview)
+		}
+	}
+	return output
+}
+
+func (dt *DaemonTools) handleProxyMock(input ProxyInput) (*mcp.CallToolResult, ProxyOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required for mock"), ProxyOutput{}, nil
+	}
+
+	operation := input.MockOperation
+	if operation == "" {
+		operation = "list"
+	}
+
+	switch operation {
+	case "add":
+		if input.MockRule == nil {
+			return errorResult("mock_rule required for add operation"), ProxyOutput{}, nil
+		}
+		result, err := dt.client.MockAdd(input.ID, *input.MockRule)
+		if err != nil {
+			return formatDaemonError(err, "mock"), ProxyOutput{}, nil
+		}
+		return nil, ProxyOutput{
+			Success:   true,
+			Message:   fmt.Sprintf("Mock rule %q added", input.MockRule.ID),
+			MockRules: parseMockRules(result),
+		}, nil
+
+	case "remove":
+		if input.MockRuleID == "" {
+			return errorResult("mock_rule_id required for remove operation"), ProxyOutput{}, nil
+		}
+		if err := dt.client.MockRemove(input.ID, input.MockRuleID); err != nil {
+			return formatDaemonError(err, "mock"), ProxyOutput{}, nil
+		}
+		return nil, ProxyOutput{
+			Success: true,
+			Message: fmt.Sprintf("Mock rule %q removed", input.MockRuleID),
+		}, nil
+
+	case "list":
+		result, err := dt.client.MockList(input.ID)
+		if err != nil {
+			return formatDaemonError(err, "mock"), ProxyOutput{}, nil
+		}
+		output := ProxyOutput{MockRules: parseMockRules(result)}
+		if len(output.MockRules) == 0 {
+			output.Message = "No mock rules"
+		}
+		return nil, output, nil
+
+	case "clear":
+		if err := dt.client.MockClear(input.ID); err != nil {
+			return formatDaemonError(err, "mock"), ProxyOutput{}, nil
+		}
+		return nil, ProxyOutput{
+			Success: true,
+			Message: "Mock rules cleared",
+		}, nil
+
 	default:
-		return errorResult(fmt.Sprintf("unknown chaos operation %q. Use: enable, disable, status, preset, set, add_rule, remove_rule, list_rules, stats, clear", operation)), ProxyOutput{}, nil
+		return errorResult(fmt.Sprintf("unknown mock operation %q. Use: add, remove, list, clear", operation)), ProxyOutput{}, nil
 	}
 }
 
+// parseMockRules reads the rules of a MOCK response.
+func parseMockRules(result map[string]interface{}) []proxy.MockRule {
+	var parsed struct {
+		Rules []proxy.MockRule `json:"rules"`
+	}
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &parsed)
+	}
+	return parsed.Rules
+}
+
 // makeProxyLogHandler creates a handler for the proxylog tool.
 func (dt *DaemonTools) makeProxyLogHandler() func(context.Context, *mcp.CallToolRequest, ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
@@ -1266,6 +2028,10 @@ func (dt *DaemonTools) makeProxyLogHandler() func(context.Context, *mcp.CallTool
 			return dt.handleProxyLogClear(input)
 		case "stats":
 			return dt.handleProxyLogStats(input)
+		case "aggregate":
+			return dt.handleProxyLogAggregate(input)
+		case "replay":
+			return dt.handleProxyLogReplay(input)
 		default:
 			return errorResult(fmt.Sprintf("unknown action %q", action)), ProxyLogOutput{}, nil
 		}
@@ -1375,19 +2141,46 @@ func (dt *DaemonTools) handleProxyLogClear(input ProxyLogInput) (*mcp.CallToolRe
 }
 
 func (dt *DaemonTools) handleProxyLogStats(input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
-	result, err := dt.client.ProxyLogStats(input.ProxyID)
+	result, err := dt.client.ProxyLogRouteStats(input.ProxyID, routeStatsFilter(input))
 	if err != nil {
 		return formatDaemonError(err, "proxylog"), ProxyLogOutput{}, nil
 	}
 
-	return nil, ProxyLogOutput{
-		Stats: &LogStatsOutput{
-			TotalEntries:     getInt64(result, "total_entries"),
-			AvailableEntries: getInt64(result, "available_entries"),
-			MaxSize:          getInt64(result, "max_size"),
-			Dropped:          getInt64(result, "dropped"),
-		},
-	}, nil
+	var stats LogStatsOutput
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &stats)
+	}
+	return nil, ProxyLogOutput{Stats: &stats}, nil
+}
+
+func (dt *DaemonTools) handleProxyLogAggregate(input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
+	result, err := dt.client.ProxyLogAggregate(input.ProxyID)
+	if err != nil {
+		return formatDaemonError(err, "proxylog"), ProxyLogOutput{}, nil
+	}
+
+	var traffic proxy.TrafficStats
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &traffic)
+	}
+	return nil, ProxyLogOutput{Traffic: &traffic}, nil
+}
+
+func (dt *DaemonTools) handleProxyLogReplay(input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
+	if input.EntryID == "" {
+		return errorResult("entry_id required for replay"), ProxyLogOutput{}, nil
+	}
+
+	result, err := dt.client.ProxyLogReplay(input.ProxyID, input.EntryID, input.Replay)
+	if err != nil {
+		return formatDaemonError(err, "proxylog"), ProxyLogOutput{}, nil
+	}
+
+	var replay proxy.ReplayResult
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &replay)
+	}
+	return nil, ProxyLogOutput{Replay: &replay}, nil
 }
 
 // makeCurrentPageHandler creates a handler for the currentpage tool.
@@ -1415,6 +2208,20 @@ func (dt *DaemonTools) makeCurrentPageHandler() func(context.Context, *mcp.CallT
 			return dt.handleCurrentPageSummary(input)
 		case "clear":
 			return dt.handleCurrentPageClear(input)
+		case "wait_idle":
+			return dt.handleCurrentPageWaitIdle(input)
+		case "snapshot":
+			return dt.handleCurrentPageSnapshot(input)
+		case "snapshots":
+			return dt.handleCurrentPageSnapshots(input)
+		case "diff":
+			return dt.handleCurrentPageDiff(input)
+		case "screenshot":
+			return dt.handleCurrentPageScreenshot(input)
+		case "screenshots":
+			return dt.handleCurrentPageScreenshots(input)
+		case "coverage":
+			return dt.handleCurrentPageCoverage(input)
 		default:
 			return errorResult(fmt.Sprintf("unknown action %q", action)), CurrentPageOutput{}, nil
 		}
@@ -1442,6 +2249,101 @@ func (dt *DaemonTools) handleCurrentPageList(input CurrentPageInput) (*mcp.CallT
 	return nil, output, nil
 }
 
+func (dt *DaemonTools) handleCurrentPageWaitIdle(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	result, err := dt.client.CurrentPageWaitForIdle(input.ProxyID, input.SessionID, idleOptions(input))
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	var idle proxy.IdleResult
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &idle)
+	}
+	return nil, CurrentPageOutput{Idle: &idle}, nil
+}
+
+func (dt *DaemonTools) handleCurrentPageSnapshot(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	result, err := dt.client.CurrentPageSnapshot(input.ProxyID, input.SessionID, snapshotOptions(input))
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	var snapshot proxy.DOMSnapshot
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &snapshot)
+	}
+	return nil, CurrentPageOutput{Snapshot: &snapshot}, nil
+}
+
+func (dt *DaemonTools) handleCurrentPageCoverage(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	result, err := dt.client.CurrentPageCoverage(input.ProxyID, input.SessionID, proxy.CSSCoverageOptions{Limit: input.Limit})
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	var report proxy.CSSCoverageReport
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &report)
+	}
+	return nil, CurrentPageOutput{CSSCoverage: &report}, nil
+}
+
+func (dt *DaemonTools) handleCurrentPageSnapshots(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	result, err := dt.client.CurrentPageSnapshots(input.ProxyID)
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	output := CurrentPageOutput{Count: getInt(result, "count")}
+	if b, err := json.Marshal(result["snapshots"]); err == nil {
+		_ = json.Unmarshal(b, &output.Snapshots)
+	}
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleCurrentPageScreenshot(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	result, err := dt.client.ScreenshotCapture(input.ProxyID, input.SessionID, screenshotOptions(input))
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	var shot proxy.PageScreenshot
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &shot)
+	}
+	return screenshotResult(shot)
+}
+
+func (dt *DaemonTools) handleCurrentPageScreenshots(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	result, err := dt.client.ScreenshotList(input.ProxyID)
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	output := CurrentPageOutput{Count: getInt(result, "count")}
+	if b, err := json.Marshal(result["screenshots"]); err == nil {
+		_ = json.Unmarshal(b, &output.Screenshots)
+	}
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleCurrentPageDiff(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	if input.From == "" || input.To == "" {
+		return errorResult("from and to snapshot IDs required for diff"), CurrentPageOutput{}, nil
+	}
+
+	result, err := dt.client.CurrentPageDiff(input.ProxyID, input.From, input.To)
+	if err != nil {
+		return formatDaemonError(err, "currentpage"), CurrentPageOutput{}, nil
+	}
+
+	var diff proxy.DOMDiff
+	if b, err := json.Marshal(result); err == nil {
+		_ = json.Unmarshal(b, &diff)
+	}
+	return nil, CurrentPageOutput{Diff: &diff}, nil
+}
+
 func (dt *DaemonTools) handleCurrentPageGet(input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
 	if input.SessionID == "" {
 		return errorResult("session_id required for get"), CurrentPageOutput{}, nil
@@ -1697,6 +2599,13 @@ func convertToPageSummary(m map[string]interface{}, detailSet map[string]bool, l
 		summary.ViewportWidth = getInt(perf, "viewport_width")
 	}
 
+	if vitals, ok := m["web_vitals"].(map[string]interface{}); ok {
+		var wv proxy.WebVitals
+		if b, err := json.Marshal(vitals); err == nil && json.Unmarshal(b, &wv) == nil {
+			summary.WebVitals = &wv
+		}
+	}
+
 	if len(detailSections) > 0 {
 		summary.DetailSections = detailSections
 	}
@@ -1829,6 +2738,7 @@ func buildProxyLogSummary(entries []interface{}, detailSet map[string]bool, limi
 	var performance []map[string]interface{}
 	var interactions []map[string]interface{}
 	var mutations []map[string]interface{}
+	var console []proxy.ConsoleEntry
 	var other []map[string]interface{}
 
 	var firstTime, lastTime time.Time
@@ -1933,6 +2843,14 @@ func buildProxyLogSummary(entries []interface{}, detailSet map[string]bool, limi
 				}
 			}
 
+		case "console":
+			if data != nil {
+				var entry proxy.ConsoleEntry
+				if b, err := json.Marshal(data); err == nil && json.Unmarshal(b, &entry) == nil {
+					console = append(console, entry)
+				}
+			}
+
 		case "mutation":
 			summary.MutationCount++
 			if data != nil {
@@ -1971,6 +2889,11 @@ func buildProxyLogSummary(entries []interface{}, detailSet map[string]bool, limi
 		}
 	}
 
+	if detailSet["console"] {
+		detailSections = append(detailSections, "console")
+	}
+	summarizeConsole(&summary, console, detailSet["console"], limit)
+
 	// Process errors
 	if detailSet["errors"] {
 		detailSections = append(detailSections, "errors")
@@ -2307,7 +3230,7 @@ func (dt *DaemonTools) handleCurrentPageClear(input CurrentPageInput) (*mcp.Call
 func getProjectPath() string {
 	var path string
 
-	// Check for project path set by agnt run
+	// Check for a project set by agnt run
 	if envPath := os.Getenv("AGNT_PROJECT_PATH"); envPath != "" {
 		// Convert to absolute path if needed
 		absPath, err := filepath.Abs(envPath)
@@ -2324,9 +3247,12 @@ func getProjectPath() string {
 		}
 		path = cwd
 	}
+	return normalizeProjectPath(path)
+}
 
-	// On Windows, normalize to lowercase for case-insensitive comparison
-	// This matches the normalization in daemon/handler.go
+// normalizeProjectPath lowercases path on Windows for case-insensitive
+// comparison. This matches the normalization in daemon/handler.go
+func normalizeProjectPath(path string) string {
 	if isWindows() {
 		path = strings.ToLower(path)
 	}
@@ -2345,6 +3271,17 @@ func getString(m map[string]interface{}, key string) string {
 	return ""
 }
 
+func getStringSlice(m map[string]interface{}, key string) []string {
+	raw, _ := m[key].([]interface{})
+	var out []string
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func getInt(m map[string]interface{}, key string) int {
 	if v, ok := m[key].(float64); ok {
 		return int(v)
@@ -2373,6 +3310,27 @@ func getBool(m map[string]interface{}, key string) bool {
 	return false
 }
 
+func getLabels(m map[string]interface{}, key string) map[string]string {
+	v, ok := m[key].(map[string]interface{})
+	if !ok || len(v) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(v))
+	for k, value := range v {
+		labels[k], _ = value.(string)
+	}
+	return labels
+}
+
+// getAffected returns the entities of a dry-run response.
+func getAffected(m map[string]interface{}) []protocol.AffectedEntity {
+	var affected []protocol.AffectedEntity
+	if b, err := json.Marshal(m["affected"]); err == nil {
+		_ = json.Unmarshal(b, &affected)
+	}
+	return affected
+}
+
 func getTime(m map[string]interface{}, key string) time.Time {
 	if v, ok := m[key].(string); ok {
 		if t, err := time.Parse(time.RFC3339, v); err == nil {
@@ -2435,6 +3393,9 @@ func formatStructuredError(err *protocol.StructuredError, toolName string) *mcp.
 
 	case protocol.ErrNotFound:
 		msg.WriteString(fmt.Sprintf("%s: not found - %s", toolName, err.Message))
+		if len(err.ValidParams) > 0 {
+			msg.WriteString(fmt.Sprintf("\n\nRetry with the full ID: %s", strings.Join(err.ValidParams, ", ")))
+		}
 
 	default:
 		msg.WriteString(fmt.Sprintf("%s: %s", toolName, err.Message))
@@ -1,11 +1,15 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/standardbeagle/agnt/internal/daemon"
 )
@@ -79,6 +83,59 @@ func TestGetProjectPath_FallbackToCwd(t *testing.T) {
 	}
 }
 
+func TestProjectScope_PerSession(t *testing.T) {
+	t.Setenv("AGNT_PROJECT_PATH", "/home/test/project")
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "agnt-test"}, nil)
+	connect := func() *mcp.ServerSession {
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		ss, err := server.Connect(ctx, serverTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, clientTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cs.Close() })
+		return ss
+	}
+	a, b := connect(), connect()
+
+	dt := &DaemonTools{sessionCode: "cwd1"}
+	active := t.TempDir()
+	dt.setProjectScope(a, active, "abc1")
+
+	if result := dt.projectPath(a); result != normalizeProjectPath(active) {
+		t.Errorf("Expected the project chosen by session a %q, got %q", active, result)
+	}
+	if code := dt.sessionCodeFor(a); code != "abc1" {
+		t.Errorf("Expected session a attached to abc1, got %q", code)
+	}
+	if result := dt.projectPath(b); result != "/home/test/project" {
+		t.Errorf("Expected session b to keep the environment path, got %q", result)
+	}
+	if code := dt.sessionCodeFor(b); code != "cwd1" {
+		t.Errorf("Expected session b to keep the session attached on connect, got %q", code)
+	}
+
+	// The scope goes away with its session
+	a.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		dt.sessionMu.Lock()
+		n := len(dt.scopes)
+		dt.sessionMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the scope of a closed session to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestGetProjectPath_EmptyEnvFallbackToCwd(t *testing.T) {
 	// Save original env value
 	originalEnv := os.Getenv("AGNT_PROJECT_PATH")
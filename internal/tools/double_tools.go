@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// DoubleInput represents input for the double tool.
+type DoubleInput struct {
+	Action      string `json:"action" jsonschema:"Action: start, stop, list"`
+	Name        string `json:"name,omitempty" jsonschema:"Double name from the doubles block of .agnt.kdl, or a built-in profile (stripe, auth0, rest)"`
+	Profile     string `json:"profile,omitempty" jsonschema:"Built-in profile when name is not one (default: name)"`
+	ProfileFile string `json:"profile_file,omitempty" jsonschema:"JSON profile file instead of a built-in profile"`
+	Port        int    `json:"port,omitempty" jsonschema:"Port to listen on (default: any free port)"`
+	WebhookURL  string `json:"webhook_url,omitempty" jsonschema:"URL webhooks are posted to, such as http://localhost:3000/api/webhooks"`
+	LatencyMs   string `json:"latency_ms,omitempty" jsonschema:"Latency range such as 50-200 (default: the profile's)"`
+	Seed        int64  `json:"seed,omitempty" jsonschema:"Seed of generated IDs and latencies"`
+}
+
+// DoubleOutput represents output from the double tool.
+type DoubleOutput struct {
+	Name       string            `json:"name,omitempty"`
+	Profile    string            `json:"profile,omitempty"`
+	ProcessID  string            `json:"process_id,omitempty"`
+	URL        string            `json:"url,omitempty"`
+	WebhookURL string            `json:"webhook_url,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	Success    bool              `json:"success,omitempty"`
+	Profiles   []DoubleProfile   `json:"profiles,omitempty"`
+	Configured []string          `json:"configured,omitempty"`
+	Running    []DoubleOutput    `json:"running,omitempty"`
+}
+
+// DoubleProfile is a built-in profile in the list action.
+type DoubleProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RegisterDoubleTool registers the double MCP tool with the server.
+func RegisterDoubleTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "double",
+		Description: `Run stand-ins for third-party APIs so payment and auth flows work offline.
+
+A double answers with canned, predictable responses after a realistic delay, and
+posts webhooks to webhook_url like the real service. While it runs, its env (such as
+STRIPE_API_BASE and STRIPE_SECRET_KEY) is added to scripts and run commands of the project.
+
+Built-in profiles:
+  stripe: customers, payment intents, refunds; pm_card_chargeDeclined is declined;
+          webhooks signed with whsec_double in Stripe-Signature
+  auth0: OIDC discovery, /authorize redirect, /oauth/token, /userinfo, /v2/logout
+  rest: in-memory CRUD collections at /{collection}[/{id}]
+
+Doubles can be configured in .agnt.kdl:
+
+  doubles {
+      stripe {
+          webhook-url "http://localhost:3000/api/webhooks"
+          autostart true
+      }
+      github {
+          profile-file "doubles/github.json"
+          env { GITHUB_API_URL "{url}"; }
+      }
+  }
+
+Actions:
+  start: Start a double and return its URL and env
+  stop: Stop a double
+  list: Built-in profiles, configured and running doubles, and their env
+
+Examples:
+  double {action: "start", name: "stripe", webhook_url: "http://localhost:3000/api/webhooks"}
+  double {action: "list"}`,
+	}, dt.makeDoubleHandler())
+}
+
+// makeDoubleHandler creates a handler for the double tool.
+func (dt *DaemonTools) makeDoubleHandler() func(context.Context, *mcp.CallToolRequest, DoubleInput) (*mcp.CallToolResult, DoubleOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input DoubleInput) (*mcp.CallToolResult, DoubleOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), DoubleOutput{}, nil
+		}
+		if input.Action != "list" && input.Name == "" {
+			return errorResult(fmt.Sprintf("name required for %s", input.Action)), DoubleOutput{}, nil
+		}
+
+		var result map[string]interface{}
+		var err error
+		switch input.Action {
+		case "start":
+			result, err = dt.client.DoubleStart(input.Name, dt.projectPath(req.Session), protocol.DoubleStartConfig{
+				Profile:     input.Profile,
+				ProfileFile: input.ProfileFile,
+				Port:        input.Port,
+				WebhookURL:  input.WebhookURL,
+				LatencyMs:   input.LatencyMs,
+				Seed:        input.Seed,
+			})
+		case "stop":
+			if err := dt.client.DoubleStop(input.Name, dt.projectPath(req.Session)); err != nil {
+				return formatDaemonError(err, "double"), DoubleOutput{}, nil
+			}
+			return nil, DoubleOutput{Name: input.Name, Success: true}, nil
+		case "list":
+			result, err = dt.client.DoubleList(dt.projectPath(req.Session))
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), DoubleOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "double"), DoubleOutput{}, nil
+		}
+
+		var output DoubleOutput
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, &output)
+		}
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// EventsInput represents input for the events tool.
+type EventsInput struct {
+	Follow    bool              `json:"follow,omitempty" jsonschema:"Wait for new events instead of returning recent ones"`
+	Types     []string          `json:"types,omitempty" jsonschema:"Event types such as process.exited or tunnel.url, or families such as process, proxy, tunnel, session, chaos (default: all)"`
+	Source    string            `json:"source,omitempty" jsonschema:"Only events of this process, proxy, tunnel or session; an ID part such as dev is enough"`
+	Labels    map[string]string `json:"labels,omitempty" jsonschema:"Only events of processes, proxies and tunnels with all these labels (an empty value matches any)"`
+	Since     int64             `json:"since,omitempty" jsonschema:"Only events after this sequence number (last_seq of an earlier call)"`
+	Limit     int               `json:"limit,omitempty" jsonschema:"Recent events returned (default: 100); with follow, events to wait for (default: 1)"`
+	TimeoutMs int               `json:"timeout_ms,omitempty" jsonschema:"With follow, how long to wait (default: 60000, max: 600000)"`
+	Global    bool              `json:"global,omitempty" jsonschema:"Events of all projects, not only this one"`
+}
+
+// EventsOutput represents output from the events tool.
+type EventsOutput struct {
+	Events    []EventEntry `json:"events"`
+	Count     int          `json:"count"`
+	LastSeq   int64        `json:"last_seq,omitempty"`
+	Truncated bool         `json:"truncated,omitempty"`
+	TimedOut  bool         `json:"timed_out,omitempty"`
+}
+
+// EventEntry is one daemon event.
+type EventEntry struct {
+	Seq     int64                  `json:"seq"`
+	Time    string                 `json:"time"`
+	Type    string                 `json:"type"`
+	Source  string                 `json:"source"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+	Labels  map[string]string      `json:"labels,omitempty"`
+}
+
+// RegisterEventsTool registers the events MCP tool with the server.
+func RegisterEventsTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "events",
+		Description: `What the daemon started, stopped and changed for this project, in order.
+
+Event types: process.started, process.exited, proxy.created, proxy.stopped,
+proxy.unreachable, tunnel.url, session.registered, session.unregistered, chaos.enabled,
+chaos.disabled, config.reloaded. The daemon keeps the last 1000. Every event has a sequence number; pass
+last_seq back as since to see only what happened after a call. With follow, waits for the
+next events instead, one by default, and returns timed_out when none come within timeout_ms.
+
+Examples:
+  events {}
+  events {types: ["process"], source: "api", limit: 20}
+  events {since: 42}
+  events {labels: {area: "checkout"}}
+  events {follow: true, types: ["process.exited"], source: "dev"}
+  events {follow: true, types: ["tunnel.url"], timeout_ms: 300000}`,
+	}, dt.makeEventsHandler())
+}
+
+// makeEventsHandler creates a handler for the events tool.
+func (dt *DaemonTools) makeEventsHandler() func(context.Context, *mcp.CallToolRequest, EventsInput) (*mcp.CallToolResult, EventsOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input EventsInput) (*mcp.CallToolResult, EventsOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), EventsOutput{}, nil
+		}
+
+		filter := protocol.EventsFilter{
+			Types:     input.Types,
+			Source:    input.Source,
+			Labels:    input.Labels,
+			Since:     input.Since,
+			Limit:     input.Limit,
+			TimeoutMs: input.TimeoutMs,
+			Global:    input.Global,
+			Path:      dt.projectPath(req.Session),
+		}
+
+		output := EventsOutput{Events: []EventEntry{}}
+		var events []daemon.DaemonEvent
+		if input.Follow {
+			if filter.Limit <= 0 {
+				filter.Limit = 1
+			}
+			var err error
+			events, err = dt.client.EventsSubscribe(filter)
+			if err != nil {
+				return formatDaemonError(err, "events"), EventsOutput{}, nil
+			}
+			output.TimedOut = len(events) < filter.Limit
+		} else {
+			result, err := dt.client.EventsQuery(filter)
+			if err != nil {
+				return formatDaemonError(err, "events"), EventsOutput{}, nil
+			}
+			if raw, ok := result["events"]; ok {
+				if b, err := json.Marshal(raw); err == nil {
+					json.Unmarshal(b, &events)
+				}
+			}
+			output.Truncated, _ = result["truncated"].(bool)
+			if seq, ok := result["last_seq"].(float64); ok {
+				output.LastSeq = int64(seq)
+			}
+		}
+
+		for _, e := range events {
+			output.Events = append(output.Events, EventEntry{
+				Seq:     e.Seq,
+				Time:    e.Time.Format(time.RFC3339),
+				Type:    e.Type,
+				Source:  e.Source,
+				Message: e.Message,
+				Data:    e.Data,
+				Labels:  e.Labels,
+			})
+			output.LastSeq = max(output.LastSeq, e.Seq)
+		}
+		output.Count = len(output.Events)
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ExposeInput represents input for the expose tool.
+type ExposeInput struct {
+	Action     string `json:"action" jsonschema:"Action: start, stop, list"`
+	ID         string `json:"id,omitempty" jsonschema:"Exposure ID (required for start/stop; defaults the script name)"`
+	Script     string `json:"script,omitempty" jsonschema:"Script to run and expose (default: id). Started if not already running."`
+	TargetURL  string `json:"target_url,omitempty" jsonschema:"Expose an already-running URL instead of a script"`
+	Provider   string `json:"provider,omitempty" jsonschema:"Tunnel provider: 'cloudflare', 'ngrok', 'localtunnel', 'tailscale' or 'custom' (required for start)"`
+	BinaryPath string `json:"binary_path,omitempty" jsonschema:"Optional path to tunnel binary"`
+	Command    string `json:"command,omitempty" jsonschema:"For the custom provider: command line to run, with {{PORT}} replaced by the port to expose"`
+	URLPattern string `json:"url_pattern,omitempty" jsonschema:"For the custom provider: regexp finding the public URL in the command's output (default: the first https URL)"`
+	MaxBytes   int64  `json:"max_bytes,omitempty" jsonschema:"Optional tunnel bandwidth cap in bytes"`
+	Expires    string `json:"expires,omitempty" jsonschema:"Optional TTL such as '30m' or '2h'"`
+	NoAuth     bool   `json:"no_auth,omitempty" jsonschema:"Skip access token protection (anyone with the URL can reach the app)"`
+	Encrypt    bool   `json:"encrypt,omitempty" jsonschema:"Encrypt instrumentation payloads sent through the tunnel"`
+}
+
+// ExposeOutput represents output from the expose tool.
+type ExposeOutput struct {
+	ID          string        `json:"id,omitempty"`
+	ProcessID   string        `json:"process_id,omitempty"`
+	ProxyID     string        `json:"proxy_id,omitempty"`
+	TunnelID    string        `json:"tunnel_id,omitempty"`
+	TargetURL   string        `json:"target_url,omitempty"`
+	PublicURL   string        `json:"public_url,omitempty"`
+	ShareURL    string        `json:"share_url,omitempty"`
+	AccessToken string        `json:"access_token,omitempty"`
+	ExpiresAt   string        `json:"expires_at,omitempty"`
+	Stopped     []string      `json:"stopped,omitempty"`
+	Success     bool          `json:"success,omitempty"`
+	Message     string        `json:"message,omitempty"`
+	Count       int           `json:"count,omitempty"`
+	Exposures   []ExposeEntry `json:"exposures,omitempty"`
+}
+
+// ExposeEntry represents an exposure in a list response.
+type ExposeEntry struct {
+	ID        string `json:"id"`
+	ProcessID string `json:"process_id,omitempty"`
+	ProxyID   string `json:"proxy_id"`
+	TargetURL string `json:"target_url"`
+	PublicURL string `json:"public_url"`
+	ShareURL  string `json:"share_url"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// RegisterExposeTool registers the expose MCP tool with the server.
+func RegisterExposeTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "expose",
+		Description: `Share a local dev server publicly in one call.
+
+Actions:
+  start: Run the script (if needed), proxy its detected URL, start a tunnel and protect it with an access token
+  stop: Tear down the tunnel, plus the proxy and process if expose started them
+  list: List active exposures
+
+Examples:
+  expose {action: "start", id: "dev", provider: "cloudflare"}
+  expose {action: "start", id: "web", script: "dev", provider: "ngrok", expires: "1h"}
+  expose {action: "start", id: "api", target_url: "http://localhost:3000", provider: "cloudflare"}
+  expose {action: "list"}
+  expose {action: "stop", id: "dev"}
+
+Share the returned share_url: it carries the access token, which is exchanged for a
+cookie on first visit. The bare public_url answers 401 unless no_auth is set. An
+existing proxy that expose reuses keeps its own token under no_auth, and gets its
+token back on stop.`,
+	}, dt.makeExposeHandler())
+}
+
+// makeExposeHandler creates a handler for the expose tool.
+func (dt *DaemonTools) makeExposeHandler() func(context.Context, *mcp.CallToolRequest, ExposeInput) (*mcp.CallToolResult, ExposeOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ExposeInput) (*mcp.CallToolResult, ExposeOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), ExposeOutput{}, nil
+		}
+
+		switch input.Action {
+		case "start":
+			return dt.handleExposeStart(input)
+		case "stop":
+			return dt.handleExposeStop(input)
+		case "list":
+			return dt.handleExposeList()
+		default:
+			return errorResult(fmt.Sprintf("unknown action: %s (use: start, stop, list)", input.Action)), ExposeOutput{}, nil
+		}
+	}
+}
+
+func (dt *DaemonTools) handleExposeStart(input ExposeInput) (*mcp.CallToolResult, ExposeOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required"), ExposeOutput{}, nil
+	}
+	if input.Provider == "" {
+		return errorResult("provider required (cloudflare, ngrok, localtunnel, tailscale or custom)"), ExposeOutput{}, nil
+	}
+
+	result, err := dt.client.ExposeStart(protocol.ExposeStartConfig{
+		ID:         input.ID,
+		Script:     input.Script,
+		TargetURL:  input.TargetURL,
+		Provider:   input.Provider,
+		BinaryPath: input.BinaryPath,
+		Command:    input.Command,
+		URLPattern: input.URLPattern,
+		MaxBytes:   input.MaxBytes,
+		Expires:    input.Expires,
+		NoAuth:     input.NoAuth,
+		Encrypt:    input.Encrypt,
+	})
+	if err != nil {
+		return formatDaemonError(err, "expose start"), ExposeOutput{}, nil
+	}
+
+	output := ExposeOutput{
+		ID:          getString(result, "id"),
+		ProcessID:   getString(result, "process_id"),
+		ProxyID:     getString(result, "proxy_id"),
+		TunnelID:    getString(result, "tunnel_id"),
+		TargetURL:   getString(result, "target_url"),
+		PublicURL:   getString(result, "public_url"),
+		ShareURL:    getString(result, "share_url"),
+		AccessToken: getString(result, "access_token"),
+		ExpiresAt:   getString(result, "expires_at"),
+		Success:     true,
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleExposeStop(input ExposeInput) (*mcp.CallToolResult, ExposeOutput, error) {
+	if input.ID == "" {
+		return errorResult("id required"), ExposeOutput{}, nil
+	}
+
+	result, err := dt.client.ExposeStop(input.ID)
+	if err != nil {
+		return formatDaemonError(err, "expose stop"), ExposeOutput{}, nil
+	}
+
+	var stopped []string
+	if raw, ok := result["stopped"].([]interface{}); ok {
+		for _, s := range raw {
+			if str, ok := s.(string); ok {
+				stopped = append(stopped, str)
+			}
+		}
+	}
+
+	output := ExposeOutput{
+		ID:      input.ID,
+		Stopped: stopped,
+		Success: true,
+		Message: "Exposure stopped",
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleExposeList() (*mcp.CallToolResult, ExposeOutput, error) {
+	result, err := dt.client.ExposeList()
+	if err != nil {
+		return formatDaemonError(err, "expose list"), ExposeOutput{}, nil
+	}
+
+	raw, _ := result["exposures"].([]interface{})
+	exposures := make([]ExposeEntry, 0, len(raw))
+	for _, e := range raw {
+		if em, ok := e.(map[string]interface{}); ok {
+			exposures = append(exposures, ExposeEntry{
+				ID:        getString(em, "id"),
+				ProcessID: getString(em, "process_id"),
+				ProxyID:   getString(em, "proxy_id"),
+				TargetURL: getString(em, "target_url"),
+				PublicURL: getString(em, "public_url"),
+				ShareURL:  getString(em, "share_url"),
+				CreatedAt: getString(em, "created_at"),
+			})
+		}
+	}
+
+	output := ExposeOutput{
+		Count:     getInt(result, "count"),
+		Exposures: exposures,
+	}
+
+	return nil, output, nil
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/git"
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// GitInput represents input for the git tool.
+type GitInput struct {
+	Action string   `json:"action" jsonschema:"Action: status, diff, log, changed-since"`
+	Ref    string   `json:"ref,omitempty" jsonschema:"For diff: commit to compare with; for log: where to start; for changed-since: base commit, branch or tag (default: HEAD)"`
+	Since  string   `json:"since,omitempty" jsonschema:"For log: only later commits; for changed-since: use the last commit before this as the base, e.g. '2 hours ago' or '2024-01-15'"`
+	Staged bool     `json:"staged,omitempty" jsonschema:"For diff: staged changes instead of the working tree"`
+	Patch  bool     `json:"patch,omitempty" jsonschema:"For diff: include the patch text (up to 64KB)"`
+	Author string   `json:"author,omitempty" jsonschema:"For log: only commits whose author name or email matches"`
+	Limit  int      `json:"limit,omitempty" jsonschema:"For log: commits returned (default: 20, max: 500)"`
+	Paths  []string `json:"paths,omitempty" jsonschema:"Only these paths, relative to the project"`
+}
+
+// GitOutput represents output from the git tool; the field of the action is set.
+type GitOutput struct {
+	Status  *git.Status  `json:"status,omitempty"`
+	Diff    *git.Diff    `json:"diff,omitempty"`
+	Log     *git.Log     `json:"log,omitempty"`
+	Changes *git.Changes `json:"changes,omitempty"`
+}
+
+// RegisterGitTool registers the git MCP tool with the server.
+func RegisterGitTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "git",
+		Description: `Git state of the project as structured data, instead of parsing git's text output.
+
+Runs git through the daemon in the project of the attached session (or the active
+project), and only on paths inside it.
+
+Actions:
+  status: Branch, upstream, ahead/behind, and each changed file with its staged and
+          unstaged change (added, modified, deleted, renamed, untracked, conflicted)
+  diff: Changed files with added/deleted line counts and hunks; staged for the index,
+        ref to compare with a commit, patch for the text
+  log: Commits with their files and line counts, and authors by commit count
+  changed-since: Every file that differs from ref (or the last commit before since),
+                 committed or not, including untracked files
+
+Examples:
+  git {action: "status"}
+  git {action: "diff", paths: ["src/api"]}
+  git {action: "diff", staged: true, patch: true}
+  git {action: "log", since: "1 week ago", limit: 50}
+  git {action: "changed-since", ref: "main"}
+  git {action: "changed-since", since: "2 hours ago"}`,
+	}, dt.makeGitHandler())
+}
+
+// makeGitHandler creates a handler for the git tool.
+func (dt *DaemonTools) makeGitHandler() func(context.Context, *mcp.CallToolRequest, GitInput) (*mcp.CallToolResult, GitOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input GitInput) (*mcp.CallToolResult, GitOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), GitOutput{}, nil
+		}
+
+		gitReq := protocol.GitRequest{
+			Ref:    input.Ref,
+			Since:  input.Since,
+			Staged: input.Staged,
+			Patch:  input.Patch,
+			Author: input.Author,
+			Limit:  input.Limit,
+			Paths:  input.Paths,
+			Path:   dt.projectPath(req.Session),
+		}
+
+		var output GitOutput
+		var result map[string]interface{}
+		var target interface{}
+		var err error
+		switch input.Action {
+		case "status":
+			output.Status = &git.Status{}
+			target = output.Status
+			result, err = dt.client.GitStatus(gitReq)
+		case "diff":
+			output.Diff = &git.Diff{}
+			target = output.Diff
+			result, err = dt.client.GitDiff(gitReq)
+		case "log":
+			output.Log = &git.Log{}
+			target = output.Log
+			result, err = dt.client.GitLog(gitReq)
+		case "changed-since":
+			output.Changes = &git.Changes{}
+			target = output.Changes
+			result, err = dt.client.GitChanged(gitReq)
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q. Use: status, diff, log, changed-since", input.Action)), GitOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "git"), GitOutput{}, nil
+		}
+
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, target)
+		}
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,602 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/sourcemap"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// investigateDefaultLimit and investigateMaxLimit bound the unique errors
+	// reported.
+	investigateDefaultLimit = 5
+	investigateMaxLimit     = 20
+
+	// investigateDefaultWindow is how far before each error context is collected.
+	investigateDefaultWindow = 15 * time.Second
+
+	// investigateMaxFrames is the number of stack frames reported per error.
+	investigateMaxFrames = 5
+
+	// investigateMaxContext is the number of requests/interactions reported per error.
+	investigateMaxContext = 5
+
+	// investigateSourceMapBudget bounds the time spent fetching scripts and
+	// source maps for one report; frames left unresolved keep their generated
+	// position.
+	investigateSourceMapBudget = 10 * time.Second
+
+	// sourceIndexMaxFiles caps how many project files are indexed for source linking.
+	sourceIndexMaxFiles = 50000
+
+	// sourceIndexTTL is how long a project's source index is reused.
+	sourceIndexTTL = time.Minute
+)
+
+// sourceIndexSkipDirs are directories never searched for source files.
+var sourceIndexSkipDirs = map[string]bool{
+	"node_modules": true,
+	".git":         true,
+	".agnt":        true,
+	".next":        true,
+	".nuxt":        true,
+	".svelte-kit":  true,
+	"dist":         true,
+	"build":        true,
+	"out":          true,
+	"coverage":     true,
+	"vendor":       true,
+}
+
+// InvestigateInput represents input for the investigate tool.
+type InvestigateInput struct {
+	ProxyID      string `json:"proxy_id,omitempty" jsonschema:"Proxy to investigate (default: the project's running proxy)"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum unique errors to report (default: 5, max: 20)"`
+	Since        string `json:"since,omitempty" jsonschema:"Only consider errors after this RFC3339 time"`
+	Window       string `json:"window,omitempty" jsonschema:"How far before each error to collect failed requests and interactions (default: 15s)"`
+	NoSourceMaps bool   `json:"no_source_maps,omitempty" jsonschema:"Skip fetching source maps to resolve stack frames"`
+}
+
+// InvestigateOutput represents output from the investigate tool.
+type InvestigateOutput struct {
+	ProxyID      string              `json:"proxy_id,omitempty"`
+	TotalErrors  int                 `json:"total_errors"`
+	UniqueErrors int                 `json:"unique_errors"`
+	Errors       []InvestigatedError `json:"errors,omitempty"`
+	Report       string              `json:"report"`
+}
+
+// InvestigatedError is one deduplicated frontend error with its context bundle.
+type InvestigatedError struct {
+	Rank           int                 `json:"rank"`
+	Score          int                 `json:"score"`
+	Message        string              `json:"message"`
+	Count          int                 `json:"count"`
+	FirstSeen      time.Time           `json:"first_seen"`
+	LastSeen       time.Time           `json:"last_seen"`
+	PageURL        string              `json:"page_url,omitempty"`
+	Frames         []InvestigatedFrame `json:"frames,omitempty"`
+	FailedRequests []string            `json:"failed_requests,omitempty"`
+	Interactions   []string            `json:"interactions,omitempty"`
+	SuggestedFiles []string            `json:"suggested_files,omitempty"`
+}
+
+// InvestigatedFrame is a stack frame with its source-mapped location.
+type InvestigatedFrame struct {
+	Function  string `json:"function,omitempty"`
+	Generated string `json:"generated"`
+	Original  string `json:"original,omitempty"`
+	File      string `json:"file,omitempty"` // Project-relative path, when found
+}
+
+// RegisterInvestigateTool registers the investigate MCP tool with the server.
+func RegisterInvestigateTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "investigate",
+		Description: `Investigate recent frontend errors in one call.
+
+Collects the most recent unique JavaScript errors captured by a proxy, resolves their
+stack traces through source maps, links frames to files in the project, and attaches
+the failed requests and user interactions that preceded each error. Errors are ranked
+so the likeliest app bugs come first. Scripts and source maps are only fetched from
+the proxy's target and listen address, for up to 10s per call.
+
+Examples:
+  investigate {}
+  investigate {proxy_id: "dev", limit: 3}
+  investigate {proxy_id: "dev", window: "30s", since: "2024-01-15T10:00:00Z"}
+
+Use proxylog for the raw entries behind a finding.`,
+	}, dt.makeInvestigateHandler())
+}
+
+// makeInvestigateHandler creates a handler for the investigate tool.
+func (dt *DaemonTools) makeInvestigateHandler() func(context.Context, *mcp.CallToolRequest, InvestigateInput) (*mcp.CallToolResult, InvestigateOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input InvestigateInput) (*mcp.CallToolResult, InvestigateOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), InvestigateOutput{}, nil
+		}
+
+		limit := input.Limit
+		if limit <= 0 {
+			limit = investigateDefaultLimit
+		}
+		if limit > investigateMaxLimit {
+			limit = investigateMaxLimit
+		}
+
+		window := investigateDefaultWindow
+		if input.Window != "" {
+			d, err := time.ParseDuration(input.Window)
+			if err != nil || d <= 0 {
+				return errorResult(fmt.Sprintf("invalid window %q (use a duration like 15s)", input.Window)), InvestigateOutput{}, nil
+			}
+			window = d
+		}
+
+		proxyID := input.ProxyID
+		if proxyID == "" {
+			id, err := dt.defaultProxyID(req.Session)
+			if err != nil {
+				return errorResult(err.Error()), InvestigateOutput{}, nil
+			}
+			proxyID = id
+		}
+
+		output, err := dt.investigate(ctx, req.Session, proxyID, input.Since, window, limit, !input.NoSourceMaps)
+		if err != nil {
+			return formatDaemonError(err, "investigate"), InvestigateOutput{}, nil
+		}
+		return nil, output, nil
+	}
+}
+
+// investigate ranks the unique frontend errors a proxy captured since the
+// given time, with the context window before each.
+func (dt *DaemonTools) investigate(ctx context.Context, ss *mcp.ServerSession, proxyID, since string, window time.Duration, limit int, sourceMaps bool) (InvestigateOutput, error) {
+	result, err := dt.client.ProxyLogQuery(proxyID, protocol.LogQueryFilter{
+		Types: []string{string(proxy.LogTypeError), string(proxy.LogTypeHTTP), string(proxy.LogTypeInteraction)},
+		Since: since,
+	})
+	if err != nil {
+		return InvestigateOutput{}, err
+	}
+
+	var entries []proxy.LogEntry
+	if raw, ok := result["logs"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &entries)
+		}
+	}
+
+	var resolver frameResolver
+	if sourceMaps {
+		// Only fetch from the proxied app: pages can report frames on any URL
+		status, err := dt.client.ProxyStatus(proxyID)
+		if err != nil {
+			return InvestigateOutput{}, err
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, investigateSourceMapBudget)
+		defer cancel()
+		resolver = sourcemap.NewResolver(ctx, nil, proxyOrigins(status))
+	}
+
+	index := dt.sourceIndexes.get(dt.projectPath(ss), time.Now())
+	findings, total := investigateErrors(entries, window, limit, resolver, index, time.Now())
+
+	return InvestigateOutput{
+		ProxyID:      proxyID,
+		TotalErrors:  total,
+		UniqueErrors: len(findings),
+		Errors:       findings,
+		Report:       formatInvestigation(proxyID, findings, total),
+	}, nil
+}
+
+// defaultProxyID picks the running proxy for the current session or project.
+func (dt *DaemonTools) defaultProxyID(ss *mcp.ServerSession) (string, error) {
+	result, err := dt.client.ProxyList(dt.directoryFilter(ss))
+	if err != nil {
+		return "", fmt.Errorf("failed to list proxies: %v", err)
+	}
+
+	proxies, _ := result["proxies"].([]interface{})
+	for _, p := range proxies {
+		if pm, ok := p.(map[string]interface{}); ok && getBool(pm, "running") {
+			return getString(pm, "id"), nil
+		}
+	}
+	return "", fmt.Errorf("no running proxy found; pass proxy_id")
+}
+
+// proxyOrigins returns the origins a proxy's pages load scripts from: its
+// target, and its listen address under the names of the local machine.
+func proxyOrigins(status map[string]interface{}) []string {
+	var origins []string
+	if target := getString(status, "target_url"); target != "" {
+		origins = append(origins, target)
+	}
+	host, port, err := net.SplitHostPort(getString(status, "listen_addr"))
+	if err != nil {
+		return origins
+	}
+	hosts := []string{host}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && (ip.IsUnspecified() || ip.IsLoopback())) {
+		hosts = []string{"localhost", "127.0.0.1", "::1"}
+	}
+	for _, h := range hosts {
+		addr := net.JoinHostPort(h, port)
+		origins = append(origins, "http://"+addr, "https://"+addr)
+	}
+	return origins
+}
+
+// frameResolver maps generated stack frames to original source positions.
+type frameResolver interface {
+	Resolve(frame sourcemap.Frame) (sourcemap.Position, error)
+}
+
+// investigateErrors groups error entries into unique findings with context,
+// ranks them and returns the top limit along with the total error count.
+func investigateErrors(entries []proxy.LogEntry, window time.Duration, limit int, resolver frameResolver, index *sourceIndex, now time.Time) ([]InvestigatedError, int) {
+	var (
+		httpEntries  []*proxy.HTTPLogEntry
+		interactions []*proxy.InteractionEvent
+		groups       = make(map[string]*errorGroup)
+		order        []string
+		total        int
+	)
+
+	for i := range entries {
+		e := &entries[i]
+		switch {
+		case e.Type == proxy.LogTypeError && e.Error != nil:
+			total++
+			key := errorGroupKey(e.Error)
+			g, ok := groups[key]
+			if !ok {
+				g = &errorGroup{first: e.Error}
+				groups[key] = g
+				order = append(order, key)
+			}
+			g.count++
+			if g.latest == nil || !e.Error.Timestamp.Before(g.latest.Timestamp) {
+				g.latest = e.Error
+			}
+			if e.Error.Timestamp.Before(g.first.Timestamp) {
+				g.first = e.Error
+			}
+		case e.Type == proxy.LogTypeHTTP && e.HTTP != nil:
+			httpEntries = append(httpEntries, e.HTTP)
+		case e.Type == proxy.LogTypeInteraction && e.Interaction != nil:
+			interactions = append(interactions, e.Interaction)
+		}
+	}
+
+	findings := make([]InvestigatedError, 0, len(groups))
+	for _, key := range order {
+		g := groups[key]
+		latest := g.latest
+		finding := InvestigatedError{
+			Message:   errorMessage(latest),
+			Count:     g.count,
+			FirstSeen: g.first.Timestamp,
+			LastSeen:  latest.Timestamp,
+			PageURL:   latest.URL,
+		}
+
+		finding.Frames = investigateFrames(latest, resolver, index)
+		seen := make(map[string]bool)
+		for _, f := range finding.Frames {
+			if f.File != "" && !seen[f.File] {
+				seen[f.File] = true
+				finding.SuggestedFiles = append(finding.SuggestedFiles, f.File)
+			}
+		}
+
+		from := latest.Timestamp.Add(-window)
+		for i := len(httpEntries) - 1; i >= 0 && len(finding.FailedRequests) < investigateMaxContext; i-- {
+			h := httpEntries[i]
+			if h.Timestamp.After(latest.Timestamp) || h.Timestamp.Before(from) {
+				continue
+			}
+			if h.StatusCode >= 400 || h.Error != "" {
+				finding.FailedRequests = append(finding.FailedRequests, describeFailedRequest(h))
+			}
+		}
+		for i := len(interactions) - 1; i >= 0 && len(finding.Interactions) < investigateMaxContext; i-- {
+			ev := interactions[i]
+			if ev.Timestamp.After(latest.Timestamp) || ev.Timestamp.Before(from) {
+				continue
+			}
+			finding.Interactions = append(finding.Interactions, describeInteraction(ev))
+		}
+
+		finding.Score = scoreFinding(finding, now)
+		findings = append(findings, finding)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Score != findings[j].Score {
+			return findings[i].Score > findings[j].Score
+		}
+		return findings[i].LastSeen.After(findings[j].LastSeen)
+	})
+	if len(findings) > limit {
+		findings = findings[:limit]
+	}
+	for i := range findings {
+		findings[i].Rank = i + 1
+	}
+	return findings, total
+}
+
+// errorGroup collects occurrences of the same error.
+type errorGroup struct {
+	first  *proxy.FrontendError
+	latest *proxy.FrontendError
+	count  int
+}
+
+// errorGroupKey identifies duplicate errors by message and throw location.
+func errorGroupKey(e *proxy.FrontendError) string {
+	return fmt.Sprintf("%s|%s:%d:%d", errorMessage(e), e.Source, e.LineNo, e.ColNo)
+}
+
+// errorMessage returns the most descriptive message for an error.
+func errorMessage(e *proxy.FrontendError) string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Error
+}
+
+// investigateFrames parses, resolves and links the frames of an error.
+func investigateFrames(e *proxy.FrontendError, resolver frameResolver, index *sourceIndex) []InvestigatedFrame {
+	frames := sourcemap.ParseStack(e.Stack)
+	if len(frames) == 0 && e.Source != "" {
+		frames = []sourcemap.Frame{{URL: e.Source, Line: e.LineNo, Column: e.ColNo}}
+	}
+	if len(frames) > investigateMaxFrames {
+		frames = frames[:investigateMaxFrames]
+	}
+
+	out := make([]InvestigatedFrame, 0, len(frames))
+	for _, f := range frames {
+		frame := InvestigatedFrame{
+			Function:  f.Function,
+			Generated: fmt.Sprintf("%s:%d:%d", f.URL, f.Line, f.Column),
+		}
+
+		source := ""
+		if resolver != nil {
+			if pos, err := resolver.Resolve(f); err == nil {
+				frame.Original = fmt.Sprintf("%s:%d:%d", pos.Source, pos.Line, pos.Column+1)
+				if frame.Function == "" {
+					frame.Function = pos.Name
+				}
+				source = pos.Source
+			}
+		}
+		if source == "" {
+			if u, err := url.Parse(f.URL); err == nil {
+				source = u.Path
+			}
+		}
+		frame.File = index.Find(source)
+		out = append(out, frame)
+	}
+	return out
+}
+
+// scoreFinding ranks findings: errors traced to project files, repeated and
+// recent errors score higher.
+func scoreFinding(f InvestigatedError, now time.Time) int {
+	score := f.Count
+	if score > 20 {
+		score = 20
+	}
+	if len(f.SuggestedFiles) > 0 {
+		score += 10
+	}
+	if len(f.FailedRequests) > 0 {
+		score += 3
+	}
+	if age := now.Sub(f.LastSeen); age < 5*time.Minute {
+		score += 5 - int(age/time.Minute)
+	}
+	return score
+}
+
+func describeFailedRequest(h *proxy.HTTPLogEntry) string {
+	target := h.URL
+	if u, err := url.Parse(h.URL); err == nil && u.Path != "" {
+		target = u.RequestURI()
+	}
+	if h.Error != "" {
+		return fmt.Sprintf("%s %s → %s", h.Method, target, h.Error)
+	}
+	return fmt.Sprintf("%s %s → %d", h.Method, target, h.StatusCode)
+}
+
+func describeInteraction(ev *proxy.InteractionEvent) string {
+	desc := ev.EventType + " " + ev.Target.Selector
+	if text := strings.TrimSpace(ev.Target.Text); text != "" && !ev.Masked {
+		if len(text) > 40 {
+			text = text[:40] + "…"
+		}
+		desc += fmt.Sprintf(" %q", text)
+	}
+	return desc
+}
+
+// formatInvestigation renders findings as a compact text report.
+func formatInvestigation(proxyID string, findings []InvestigatedError, total int) string {
+	if total == 0 {
+		return fmt.Sprintf("No frontend errors captured by proxy %s.", proxyID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d error(s) captured by proxy %s, top %d unique:\n", total, proxyID, len(findings))
+	for _, f := range findings {
+		fmt.Fprintf(&b, "\n#%d %s (×%d, last %s", f.Rank, f.Message, f.Count, f.LastSeen.Format("15:04:05"))
+		if u, err := url.Parse(f.PageURL); err == nil && u.Path != "" {
+			fmt.Fprintf(&b, " on %s", u.Path)
+		}
+		b.WriteString(")\n")
+		for _, fr := range f.Frames {
+			loc := fr.Original
+			if loc == "" {
+				loc = fr.Generated
+			}
+			name := fr.Function
+			if name == "" {
+				name = "<anonymous>"
+			}
+			fmt.Fprintf(&b, "   at %s %s", name, loc)
+			if fr.File != "" {
+				fmt.Fprintf(&b, " → %s", fr.File)
+			}
+			b.WriteString("\n")
+		}
+		for _, r := range f.FailedRequests {
+			fmt.Fprintf(&b, "   failed: %s\n", r)
+		}
+		for _, i := range f.Interactions {
+			fmt.Fprintf(&b, "   before: %s\n", i)
+		}
+		if len(f.SuggestedFiles) > 0 {
+			fmt.Fprintf(&b, "   look at: %s\n", strings.Join(f.SuggestedFiles, ", "))
+		}
+	}
+	return b.String()
+}
+
+// sourceIndex maps file names to project-relative paths for linking
+// source-mapped frames to files on disk.
+type sourceIndex struct {
+	byName map[string][]string
+}
+
+// sourceIndexCache keeps the source index of each project root for
+// sourceIndexTTL, so investigations don't walk the tree on every call.
+type sourceIndexCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedSourceIndex
+}
+
+type cachedSourceIndex struct {
+	idx   *sourceIndex
+	built time.Time
+}
+
+// get returns the index of root, building it when missing or stale.
+func (c *sourceIndexCache) get(root string, now time.Time) *sourceIndex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[root]; ok && now.Sub(e.built) < sourceIndexTTL {
+		return e.idx
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*cachedSourceIndex)
+	}
+	for r, e := range c.entries {
+		if now.Sub(e.built) >= sourceIndexTTL {
+			delete(c.entries, r)
+		}
+	}
+	idx := newSourceIndex(root)
+	c.entries[root] = &cachedSourceIndex{idx: idx, built: now}
+	return idx
+}
+
+// newSourceIndex indexes files under root, skipping dependency and build output.
+func newSourceIndex(root string) *sourceIndex {
+	idx := &sourceIndex{byName: make(map[string][]string)}
+	if root == "" {
+		return idx
+	}
+
+	count := 0
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && (sourceIndexSkipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if count >= sourceIndexMaxFiles {
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		idx.byName[d.Name()] = append(idx.byName[d.Name()], rel)
+		count++
+		return nil
+	})
+	return idx
+}
+
+// Find returns the project file best matching a source path from a source map
+// or script URL, or "" if none matches. Dependencies are never matched.
+func (idx *sourceIndex) Find(source string) string {
+	if idx == nil || source == "" {
+		return ""
+	}
+
+	// Strip bundler schemes such as webpack:// and vite's /@fs/ prefix
+	if i := strings.Index(source, "://"); i >= 0 {
+		source = source[i+3:]
+	}
+	if i := strings.IndexAny(source, "?#"); i >= 0 {
+		source = source[:i]
+	}
+	source = strings.TrimPrefix(source, "/@fs")
+	if strings.Contains(source, "node_modules/") {
+		return ""
+	}
+
+	parts := strings.Split(path.Clean("/"+source), "/")
+	name := parts[len(parts)-1]
+	candidates := idx.byName[name]
+
+	best, bestScore := "", 0
+	for _, c := range candidates {
+		score := commonSuffixParts(parts, strings.Split(c, "/"))
+		if score > bestScore || (score == bestScore && best != "" && len(c) < len(best)) {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// commonSuffixParts counts trailing path components shared by a and b.
+func commonSuffixParts(a, b []string) int {
+	n := 0
+	for i, j := len(a)-1, len(b)-1; i >= 0 && j >= 0 && a[i] == b[j]; i, j = i-1, j-1 {
+		n++
+	}
+	return n
+}
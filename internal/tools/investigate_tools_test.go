@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+	"github.com/standardbeagle/agnt/internal/sourcemap"
+)
+
+// fakeResolver maps every frame on main.js to src/components/Cart.tsx.
+type fakeResolver struct{}
+
+func (fakeResolver) Resolve(f sourcemap.Frame) (sourcemap.Position, error) {
+	if !strings.HasSuffix(f.URL, "/main.js") {
+		return sourcemap.Position{}, fmt.Errorf("no map")
+	}
+	return sourcemap.Position{Source: "webpack:///./src/components/Cart.tsx", Line: f.Line * 10, Column: 3, Name: "addItem"}, nil
+}
+
+func writeTestProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, rel := range []string{
+		"src/components/Cart.tsx",
+		"src/legacy/components/Cart.tsx",
+		"node_modules/react/index.js",
+		"src/index.ts",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestSourceIndexFind(t *testing.T) {
+	idx := newSourceIndex(writeTestProject(t))
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"webpack:///./src/components/Cart.tsx", "src/components/Cart.tsx"},
+		{"/@fs/home/me/app/src/legacy/components/Cart.tsx?v=123", "src/legacy/components/Cart.tsx"},
+		{"/src/index.ts", "src/index.ts"},
+		{"webpack:///./node_modules/react/index.js", ""},
+		{"/static/js/missing.js", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := idx.Find(tt.source); got != tt.want {
+			t.Errorf("Find(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestInvestigateErrors(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	cartStack := "TypeError: x is undefined\n    at addItem (http://localhost:3000/static/js/main.js:2:9)\n    at http://localhost:3000/static/js/vendor.js:1:1"
+	entries := []proxy.LogEntry{
+		{Type: proxy.LogTypeInteraction, Interaction: &proxy.InteractionEvent{Timestamp: at(70 * time.Second), EventType: "click", Target: proxy.InteractionTarget{Selector: "button#old"}}},
+		{Type: proxy.LogTypeError, Error: &proxy.FrontendError{Timestamp: at(10 * time.Minute), Message: "ResizeObserver loop limit exceeded", URL: "http://localhost:3000/"}},
+		{Type: proxy.LogTypeInteraction, Interaction: &proxy.InteractionEvent{Timestamp: at(62 * time.Second), EventType: "click", Target: proxy.InteractionTarget{Selector: "button#add", Text: "Add to cart"}}},
+		{Type: proxy.LogTypeHTTP, HTTP: &proxy.HTTPLogEntry{Timestamp: at(61 * time.Second), Method: "POST", URL: "http://localhost:3000/api/cart?id=1", StatusCode: 500}},
+		{Type: proxy.LogTypeHTTP, HTTP: &proxy.HTTPLogEntry{Timestamp: at(61 * time.Second), Method: "GET", URL: "http://localhost:3000/api/user", StatusCode: 200}},
+		{Type: proxy.LogTypeError, Error: &proxy.FrontendError{Timestamp: at(2 * time.Minute), Message: "TypeError: x is undefined", Source: "http://localhost:3000/static/js/main.js", LineNo: 2, ColNo: 9, Stack: cartStack, URL: "http://localhost:3000/cart"}},
+		{Type: proxy.LogTypeError, Error: &proxy.FrontendError{Timestamp: at(time.Minute), Message: "TypeError: x is undefined", Source: "http://localhost:3000/static/js/main.js", LineNo: 2, ColNo: 9, Stack: cartStack, URL: "http://localhost:3000/cart"}},
+		{Type: proxy.LogTypeInteraction, Interaction: &proxy.InteractionEvent{Timestamp: at(30 * time.Second), EventType: "click", Target: proxy.InteractionTarget{Selector: "button#after"}}},
+	}
+
+	idx := newSourceIndex(writeTestProject(t))
+	findings, total := investigateErrors(entries, 5*time.Second, 5, fakeResolver{}, idx, now)
+
+	if total != 3 {
+		t.Errorf("Expected 3 total errors, got %d", total)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 unique errors, got %d", len(findings))
+	}
+
+	top := findings[0]
+	if top.Rank != 1 || top.Message != "TypeError: x is undefined" {
+		t.Fatalf("Expected cart error ranked first, got %+v", top)
+	}
+	if top.Count != 2 || !top.LastSeen.Equal(at(time.Minute)) || !top.FirstSeen.Equal(at(2*time.Minute)) {
+		t.Errorf("Unexpected occurrence stats: count=%d first=%v last=%v", top.Count, top.FirstSeen, top.LastSeen)
+	}
+	if len(top.Frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %+v", top.Frames)
+	}
+	if f := top.Frames[0]; f.Original != "webpack:///./src/components/Cart.tsx:20:4" || f.File != "src/components/Cart.tsx" {
+		t.Errorf("Unexpected resolved frame %+v", f)
+	}
+	if f := top.Frames[1]; f.Original != "" || f.File != "" {
+		t.Errorf("Expected unresolved vendor frame, got %+v", f)
+	}
+	if len(top.SuggestedFiles) != 1 || top.SuggestedFiles[0] != "src/components/Cart.tsx" {
+		t.Errorf("Unexpected suggested files %v", top.SuggestedFiles)
+	}
+	if len(top.FailedRequests) != 1 || top.FailedRequests[0] != "POST /api/cart?id=1 → 500" {
+		t.Errorf("Unexpected failed requests %v", top.FailedRequests)
+	}
+	if len(top.Interactions) != 1 || top.Interactions[0] != `click button#add "Add to cart"` {
+		t.Errorf("Unexpected interactions %v", top.Interactions)
+	}
+
+	report := formatInvestigation("dev", findings, total)
+	for _, want := range []string{"#1 TypeError: x is undefined (×2", "→ src/components/Cart.tsx", "failed: POST /api/cart", "#2 ResizeObserver"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestInvestigateErrors_LimitAndNoResolver(t *testing.T) {
+	now := time.Now()
+	var entries []proxy.LogEntry
+	for i := 0; i < 4; i++ {
+		entries = append(entries, proxy.LogEntry{Type: proxy.LogTypeError, Error: &proxy.FrontendError{
+			Timestamp: now.Add(-time.Duration(i) * time.Second),
+			Message:   fmt.Sprintf("error %d", i),
+			Source:    "http://localhost:3000/src/index.ts",
+			LineNo:    i + 1,
+		}})
+	}
+
+	idx := newSourceIndex(writeTestProject(t))
+	findings, total := investigateErrors(entries, time.Second, 2, nil, idx, now)
+	if total != 4 || len(findings) != 2 {
+		t.Fatalf("Expected 2 of 4 findings, got %d of %d", len(findings), total)
+	}
+	// Without source maps the script URL path is linked directly
+	if len(findings[0].SuggestedFiles) != 1 || findings[0].SuggestedFiles[0] != "src/index.ts" {
+		t.Errorf("Expected URL path linked to project file, got %v", findings[0].SuggestedFiles)
+	}
+}
+
+func TestFormatInvestigation_NoErrors(t *testing.T) {
+	if got := formatInvestigation("dev", nil, 0); got != "No frontend errors captured by proxy dev." {
+		t.Errorf("Unexpected report %q", got)
+	}
+}
+
+func TestProxyOrigins(t *testing.T) {
+	origins := proxyOrigins(map[string]interface{}{
+		"target_url":  "http://localhost:3000",
+		"listen_addr": "[::]:12345",
+	})
+	want := map[string]bool{
+		"http://localhost:3000":   true,
+		"http://localhost:12345":  true,
+		"http://127.0.0.1:12345":  true,
+		"https://[::1]:12345":     true,
+		"https://localhost:12345": true,
+	}
+	got := map[string]bool{}
+	for _, o := range origins {
+		got[o] = true
+	}
+	for o := range want {
+		if !got[o] {
+			t.Errorf("Expected origin %s in %v", o, origins)
+		}
+	}
+
+	origins = proxyOrigins(map[string]interface{}{"listen_addr": "192.168.1.5:8080"})
+	if len(origins) != 2 || origins[0] != "http://192.168.1.5:8080" {
+		t.Errorf("Expected only the bound host, got %v", origins)
+	}
+}
+
+func TestSourceIndexCache(t *testing.T) {
+	root := writeTestProject(t)
+	now := time.Now()
+	var c sourceIndexCache
+
+	idx := c.get(root, now)
+	if c.get(root, now.Add(sourceIndexTTL/2)) != idx {
+		t.Error("Expected the index to be reused within the TTL")
+	}
+	if c.get(root, now.Add(sourceIndexTTL)) == idx {
+		t.Error("Expected a stale index to be rebuilt")
+	}
+}
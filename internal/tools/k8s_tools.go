@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/k8s"
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// K8sInput represents input for the k8s tool.
+type K8sInput struct {
+	Action    string `json:"action" jsonschema:"Action: forward, logs, status, stop"`
+	ID        string `json:"id,omitempty" jsonschema:"Forward or log stream ID (required for stop, derived when omitted otherwise)"`
+	Resource  string `json:"resource,omitempty" jsonschema:"For forward: svc/name, deploy/name or pod/name"`
+	Port      int    `json:"port,omitempty" jsonschema:"For forward: port of the resource"`
+	LocalPort int    `json:"local_port,omitempty" jsonschema:"For forward: loopback port (default: port if free)"`
+	Selector  string `json:"selector,omitempty" jsonschema:"For logs: label selector such as app=api"`
+	Container string `json:"container,omitempty" jsonschema:"For logs: one container (default: all)"`
+	Tail      int    `json:"tail,omitempty" jsonschema:"For logs: existing lines per container (default: 100)"`
+	Context   string `json:"context,omitempty" jsonschema:"kubectl context (default: current)"`
+	Namespace string `json:"namespace,omitempty" jsonschema:"Namespace (default: the context's)"`
+}
+
+// K8sOutput represents output from the k8s tool.
+type K8sOutput struct {
+	ID        string      `json:"id,omitempty"`
+	ProcessID string      `json:"process_id,omitempty"`
+	URL       string      `json:"url,omitempty"`
+	LocalPort int         `json:"local_port,omitempty"`
+	Pods      int         `json:"pods,omitempty"`
+	Entities  []K8sEntity `json:"entities,omitempty"`
+	Success   bool        `json:"success,omitempty"`
+}
+
+// K8sEntity is a port-forward or log stream in k8s status.
+type K8sEntity struct {
+	Entity struct {
+		ID        string             `json:"id"`
+		Kind      string             `json:"kind"`
+		ProcessID string             `json:"process_id"`
+		Target    k8s.Target         `json:"target"`
+		Resource  string             `json:"resource,omitempty"`
+		Port      int                `json:"port,omitempty"`
+		LocalPort int                `json:"local_port,omitempty"`
+		URL       string             `json:"url,omitempty"`
+		Selector  string             `json:"selector,omitempty"`
+		Pods      []k8s.Container    `json:"pods,omitempty"`
+		Restarts  []k8s.RestartEvent `json:"restarts,omitempty"`
+		LastError string             `json:"last_error,omitempty"`
+	} `json:"entity"`
+	State      string `json:"state"`
+	Reconnects int    `json:"reconnects"`
+}
+
+// RegisterK8sTool registers the k8s MCP tool with the server.
+func RegisterK8sTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "k8s",
+		Description: `Reach dev backends running in Kubernetes through kubectl.
+
+Port-forwards and log streams run kubectl as managed processes of the project, restarted
+when kubectl exits (e.g. the pod was replaced). Pod logs become process output, so
+proc output, grep and diagnostics work on them; lines are prefixed with pod and container.
+Log streams also watch their pods and record container restarts with the reason
+(OOMKilled, Error) and exit code. A reconnected log stream repeats up to tail lines.
+
+Actions:
+  forward: Port-forward a resource to loopback; returns the local URL
+  logs: Follow the logs of the pods matching a selector
+  status: Forwards and log streams with their pods and restart events
+  stop: Stop a forward or log stream
+
+Examples:
+  k8s {action: "forward", resource: "svc/api", port: 8080}
+  k8s {action: "logs", selector: "app=api", namespace: "dev"}
+  proc {action: "output", process_id: "<process_id from logs>", grep: "ERROR"}
+  k8s {action: "status"}
+  k8s {action: "stop", id: "svc-api-8080"}`,
+	}, dt.makeK8sHandler())
+}
+
+// makeK8sHandler creates a handler for the k8s tool.
+func (dt *DaemonTools) makeK8sHandler() func(context.Context, *mcp.CallToolRequest, K8sInput) (*mcp.CallToolResult, K8sOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input K8sInput) (*mcp.CallToolResult, K8sOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), K8sOutput{}, nil
+		}
+
+		var result map[string]interface{}
+		var err error
+		switch input.Action {
+		case "forward":
+			if input.Resource == "" || input.Port <= 0 {
+				return errorResult("resource and port required for forward"), K8sOutput{}, nil
+			}
+			result, err = dt.client.K8sForward(protocol.K8sForwardConfig{
+				ID:        input.ID,
+				Resource:  input.Resource,
+				Port:      input.Port,
+				LocalPort: input.LocalPort,
+				Context:   input.Context,
+				Namespace: input.Namespace,
+				Path:      dt.projectPath(req.Session),
+			})
+		case "logs":
+			if input.Selector == "" {
+				return errorResult("selector required for logs"), K8sOutput{}, nil
+			}
+			result, err = dt.client.K8sLogs(protocol.K8sLogsConfig{
+				ID:        input.ID,
+				Selector:  input.Selector,
+				Container: input.Container,
+				Tail:      input.Tail,
+				Context:   input.Context,
+				Namespace: input.Namespace,
+				Path:      dt.projectPath(req.Session),
+			})
+		case "status":
+			result, err = dt.client.K8sStatus(dt.projectPath(req.Session))
+		case "stop":
+			if input.ID == "" {
+				return errorResult("id required for stop"), K8sOutput{}, nil
+			}
+			if err := dt.client.K8sStop(input.ID, dt.projectPath(req.Session)); err != nil {
+				return formatDaemonError(err, "k8s"), K8sOutput{}, nil
+			}
+			return nil, K8sOutput{ID: input.ID, Success: true}, nil
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), K8sOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "k8s"), K8sOutput{}, nil
+		}
+
+		var output K8sOutput
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, &output)
+		}
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+const (
+	// procOutputResourceTail is how many lines of output a process's
+	// resource holds.
+	procOutputResourceTail = 500
+	// liveResourcePollInterval is how often subscribed process and proxy
+	// resources are checked for changes.
+	liveResourcePollInterval = 2 * time.Second
+)
+
+// ProcOutputURI names the output of a process as an MCP resource.
+func ProcOutputURI(processID string) string {
+	return "agnt://proc/" + url.PathEscape(processID) + "/output"
+}
+
+// ProxyLogSummaryURI names the log summary of a proxy as an MCP resource.
+func ProxyLogSummaryURI(proxyID string) string {
+	return "agnt://proxy/" + url.PathEscape(proxyID) + "/log-summary"
+}
+
+// parseLiveResourceURI splits a URI made by ProcOutputURI or
+// ProxyLogSummaryURI into "proc" or "proxy" and the ID.
+func parseLiveResourceURI(uri string) (kind, id string, ok bool) {
+	rest, found := strings.CutPrefix(uri, "agnt://")
+	parts := strings.Split(rest, "/")
+	if !found || len(parts) != 3 {
+		return "", "", false
+	}
+	switch {
+	case parts[0] == "proc" && parts[2] == "output":
+	case parts[0] == "proxy" && parts[2] == "log-summary":
+	default:
+		return "", "", false
+	}
+	id, err := url.PathUnescape(parts[1])
+	if err != nil || id == "" {
+		return "", "", false
+	}
+	return parts[0], id, true
+}
+
+// ResourceWatcher tracks the resources clients subscribed to, and tells
+// them when a subscribed process's output or proxy's log changes. Its
+// Subscribe and Unsubscribe are the server's subscription handlers.
+type ResourceWatcher struct {
+	mu           sync.Mutex
+	subs         map[string]int    // Subscribed sessions by URI
+	fingerprints map[string]string // Last seen state of watched URIs
+	polling      bool
+
+	server *mcp.Server
+	dt     *DaemonTools
+}
+
+// NewResourceWatcher creates a watcher; RegisterLiveResources connects it
+// to the server.
+func NewResourceWatcher() *ResourceWatcher {
+	return &ResourceWatcher{
+		subs:         make(map[string]int),
+		fingerprints: make(map[string]string),
+	}
+}
+
+// Subscribe records a resources/subscribe, and starts polling for the first
+// process or proxy resource.
+func (w *ResourceWatcher) Subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs[req.Params.URI]++
+	if _, _, ok := parseLiveResourceURI(req.Params.URI); ok && !w.polling && w.server != nil {
+		w.polling = true
+		go w.poll()
+	}
+	return nil
+}
+
+// Unsubscribe records a resources/unsubscribe.
+func (w *ResourceWatcher) Unsubscribe(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.subs[req.Params.URI]--; w.subs[req.Params.URI] <= 0 {
+		delete(w.subs, req.Params.URI)
+		delete(w.fingerprints, req.Params.URI)
+	}
+	return nil
+}
+
+// watched returns the subscribed process and proxy resources, and ends
+// polling when there are none.
+func (w *ResourceWatcher) watched() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var uris []string
+	for uri := range w.subs {
+		if _, _, ok := parseLiveResourceURI(uri); ok {
+			uris = append(uris, uri)
+		}
+	}
+	if len(uris) == 0 {
+		w.polling = false
+	}
+	return uris
+}
+
+// changed stores the fingerprint of a URI and reports whether it differs
+// from the one seen before. The first one seen is not a change.
+func (w *ResourceWatcher) changed(uri, fingerprint string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.subs[uri]; !ok {
+		return false
+	}
+	prev, seen := w.fingerprints[uri]
+	w.fingerprints[uri] = fingerprint
+	return seen && prev != fingerprint
+}
+
+// poll checks the subscribed resources until none are left.
+func (w *ResourceWatcher) poll() {
+	ticker := time.NewTicker(liveResourcePollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		uris := w.watched()
+		if len(uris) == 0 {
+			return
+		}
+		for _, uri := range uris {
+			fingerprint, err := w.fingerprint(uri)
+			if err != nil {
+				debug.Log("tools", "resource %s not checked: %v", uri, err)
+				continue
+			}
+			if w.changed(uri, fingerprint) {
+				w.server.ResourceUpdated(context.Background(), &mcp.ResourceUpdatedNotificationParams{URI: uri})
+			}
+		}
+	}
+}
+
+// fingerprint summarizes the current state of a resource: a hash of a
+// process's output tail and state, or the number of entries a proxy logged.
+func (w *ResourceWatcher) fingerprint(uri string) (string, error) {
+	if err := w.dt.ensureConnected(); err != nil {
+		return "", err
+	}
+	kind, id, _ := parseLiveResourceURI(uri)
+	if kind == "proxy" {
+		stats, err := w.dt.client.ProxyLogStats(id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(stats["total_entries"]), nil
+	}
+	output, err := w.dt.client.ProcOutput(id, protocol.OutputFilter{Tail: procOutputResourceTail})
+	if err != nil {
+		return "", err
+	}
+	status, err := w.dt.client.ProcStatus(id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%x", getString(status, "state"), sha256.Sum256([]byte(output))), nil
+}
+
+// RegisterLiveResources exposes the output of processes and the log
+// summaries of proxies as MCP resources, which clients can subscribe to
+// through w.
+func RegisterLiveResources(server *mcp.Server, dt *DaemonTools, w *ResourceWatcher) {
+	w.mu.Lock()
+	w.server, w.dt = server, dt
+	w.mu.Unlock()
+
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: "agnt://proc/{process_id}/output",
+		Name:        "process-output",
+		Title:       "Process output",
+		Description: fmt.Sprintf("The last %d lines of a process's stdout and stderr; subscribe to hear when it writes more or changes state", procOutputResourceTail),
+		MIMEType:    "text/plain",
+	}, dt.readProcOutputResource)
+
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: "agnt://proxy/{proxy_id}/log-summary",
+		Name:        "proxy-log-summary",
+		Title:       "Proxy log summary",
+		Description: "Counts of a proxy's logged requests, errors, console output and interactions with the most recent of each; subscribe to hear when the proxy logs more",
+		MIMEType:    "application/json",
+	}, dt.readProxyLogSummaryResource)
+}
+
+// readProcOutputResource returns the output tail of a process.
+func (dt *DaemonTools) readProcOutputResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	kind, id, ok := parseLiveResourceURI(uri)
+	if !ok || kind != "proc" {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+	output, err := dt.client.ProcOutput(id, protocol.OutputFilter{Tail: procOutputResourceTail})
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{
+		URI:      uri,
+		MIMEType: "text/plain",
+		Text:     output,
+	}}}, nil
+}
+
+// readProxyLogSummaryResource returns the log summary of a proxy, as the
+// proxylog tool's summary action builds it.
+func (dt *DaemonTools) readProxyLogSummaryResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	kind, id, ok := parseLiveResourceURI(uri)
+	if !ok || kind != "proxy" {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+	result, err := dt.client.ProxyLogQuery(id, protocol.LogQueryFilter{})
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	entries, _ := result["logs"].([]interface{})
+
+	data, err := json.MarshalIndent(buildProxyLogSummary(entries, map[string]bool{}, 10), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}}, nil
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestLiveResourceURIs(t *testing.T) {
+	kind, id, ok := parseLiveResourceURI(ProcOutputURI("abc:dev"))
+	if !ok || kind != "proc" || id != "abc:dev" {
+		t.Errorf("proc URI parsed as %q %q %v", kind, id, ok)
+	}
+	kind, id, ok = parseLiveResourceURI(ProxyLogSummaryURI("app/1"))
+	if !ok || kind != "proxy" || id != "app/1" {
+		t.Errorf("proxy URI parsed as %q %q %v", kind, id, ok)
+	}
+	for _, uri := range []string{"agnt://notifications", "agnt://proc/dev/logs", "agnt://proxy//log-summary", "agnt://panel/app/m/0"} {
+		if _, _, ok := parseLiveResourceURI(uri); ok {
+			t.Errorf("Expected %s rejected", uri)
+		}
+	}
+}
+
+func TestResourceWatcherChanges(t *testing.T) {
+	w := NewResourceWatcher()
+	ctx := context.Background()
+	uri := ProcOutputURI("dev")
+
+	if w.changed(uri, "a") {
+		t.Error("Expected an unsubscribed URI ignored")
+	}
+	w.Subscribe(ctx, &mcp.SubscribeRequest{Params: &mcp.SubscribeParams{URI: uri}})
+	w.Subscribe(ctx, &mcp.SubscribeRequest{Params: &mcp.SubscribeParams{URI: uri}})
+	w.Subscribe(ctx, &mcp.SubscribeRequest{Params: &mcp.SubscribeParams{URI: "agnt://notifications"}})
+	if got := w.watched(); len(got) != 1 || got[0] != uri {
+		t.Errorf("watched = %v", got)
+	}
+
+	if w.changed(uri, "a") {
+		t.Error("Expected the first fingerprint not to count as a change")
+	}
+	if w.changed(uri, "a") || !w.changed(uri, "b") {
+		t.Error("Expected only a new fingerprint to count as a change")
+	}
+
+	// Watched until the last session unsubscribes
+	w.Unsubscribe(ctx, &mcp.UnsubscribeRequest{Params: &mcp.UnsubscribeParams{URI: uri}})
+	if len(w.watched()) != 1 {
+		t.Error("Expected the URI still watched for the other session")
+	}
+	w.Unsubscribe(ctx, &mcp.UnsubscribeRequest{Params: &mcp.UnsubscribeParams{URI: uri}})
+	if len(w.watched()) != 0 || w.changed(uri, "c") {
+		t.Error("Expected the URI no longer watched")
+	}
+}
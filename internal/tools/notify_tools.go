@@ -0,0 +1,370 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/debug"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// Kinds of notification the notify tool subscribes to.
+const (
+	NotifyProcessExit      = "process_exit"      // A process exited with a non-zero code
+	NotifyProxyUnreachable = "proxy_unreachable" // A proxy can't connect to its target
+	NotifyTunnelURL        = "tunnel_url"        // A tunnel got a new public URL
+)
+
+var notifyKinds = []string{NotifyProcessExit, NotifyProxyUnreachable, NotifyTunnelURL}
+
+// notifyKindEvents maps each kind to the daemon event it is sent for.
+var notifyKindEvents = map[string]string{
+	NotifyProcessExit:      daemon.EventProcessExited,
+	NotifyProxyUnreachable: daemon.EventProxyUnreachable,
+	NotifyTunnelURL:        daemon.EventTunnelURL,
+}
+
+const (
+	// notificationsURI lists the notifications sent recently.
+	notificationsURI = "agnt://notifications"
+	// notificationLogger names agnt in notifications/message.
+	notificationLogger = "agnt"
+	// maxRecentNotifications is how many sent notifications are kept.
+	maxRecentNotifications = 50
+
+	// notifyStreamTimeout is how long one EVENTS SUBSCRIBE of the notifier
+	// lasts before it starts the next.
+	notifyStreamTimeout = 5 * time.Minute
+	// notifyRetryDelay is the pause before the notifier reconnects.
+	notifyRetryDelay = 5 * time.Second
+)
+
+// NotifyInput represents input for the notify tool.
+type NotifyInput struct {
+	Action string   `json:"action" jsonschema:"subscribe, unsubscribe or status"`
+	Kinds  []string `json:"kinds,omitempty" jsonschema:"process_exit (non-zero exits), proxy_unreachable, tunnel_url (default: all)"`
+	Global bool     `json:"global,omitempty" jsonschema:"subscribe: notify about all projects, not only this one"`
+}
+
+// NotifyOutput represents output from the notify tool.
+type NotifyOutput struct {
+	Kinds  []string     `json:"kinds"`
+	Global bool         `json:"global,omitempty"`
+	Recent []EventEntry `json:"recent,omitempty"` // Notifications sent, oldest first
+}
+
+// eventNotifier keeps the notify subscription of each MCP session, so each
+// client of agnt serve --http gets only the kinds it subscribed to.
+type eventNotifier struct {
+	server     *mcp.Server
+	socketPath string
+
+	mu   sync.Mutex
+	subs map[*mcp.ServerSession]*notifySubscription
+}
+
+// notifySubscription pushes the daemon events one MCP session subscribed to
+// as MCP notifications, over a daemon connection of its own so a waiting
+// EVENTS SUBSCRIBE doesn't hold up tool calls.
+type notifySubscription struct {
+	server     *mcp.Server
+	session    *mcp.ServerSession
+	socketPath string
+
+	mu     sync.Mutex
+	kinds  []string
+	global bool
+	cursor int64              // Sequence number of the last event seen
+	stop   context.CancelFunc // Ends the running stream; nil when stopped
+	recent []EventEntry
+}
+
+// subscription returns the subscription of ss, creating it when create is
+// set. A created subscription ends when its session closes.
+func (n *eventNotifier) subscription(ss *mcp.ServerSession, create bool) *notifySubscription {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if sub, ok := n.subs[ss]; ok || !create {
+		return sub
+	}
+	if n.subs == nil {
+		n.subs = make(map[*mcp.ServerSession]*notifySubscription)
+	}
+	sub := &notifySubscription{server: n.server, session: ss, socketPath: n.socketPath}
+	n.subs[ss] = sub
+	if ss != nil {
+		go func() {
+			ss.Wait()
+			n.mu.Lock()
+			delete(n.subs, ss)
+			n.mu.Unlock()
+			sub.unsubscribe(notifyKinds)
+		}()
+	}
+	return sub
+}
+
+// RegisterNotifyTool registers the notify MCP tool, and the
+// agnt://notifications resource it updates, with the server.
+func RegisterNotifyTool(server *mcp.Server, dt *DaemonTools) {
+	n := &eventNotifier{server: server, socketPath: dt.config.SocketPath}
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "notify",
+		Description: `Get pushed notifications instead of polling proc and proxylog.
+
+Kinds:
+  process_exit: a process of the project exited with a non-zero code
+  proxy_unreachable: a proxy can't connect to its target (once per outage)
+  tunnel_url: a tunnel got a public URL, or a new one after reconnecting
+
+Each notification is sent as an MCP log message (notifications/message, logger "agnt",
+level warning, info for tunnel_url) once the client has set a logging level, and as a
+resources/updated notification for agnt://notifications, which lists the notifications
+sent. Subscriptions belong to this MCP connection and last as long as it does.
+
+Actions:
+  subscribe: Start (or change) notifications for kinds (default: all)
+  unsubscribe: Stop notifications for kinds (default: all)
+  status: Current kinds and the notifications sent
+
+Examples:
+  notify {action: "subscribe"}
+  notify {action: "subscribe", kinds: ["process_exit"]}
+  notify {action: "unsubscribe", kinds: ["tunnel_url"]}
+  notify {action: "status"}`,
+	}, n.makeHandler(dt))
+
+	server.AddResource(&mcp.Resource{
+		URI:         notificationsURI,
+		Name:        "notifications",
+		Title:       "agnt notifications",
+		Description: "Notifications sent to this connection for the kinds subscribed with the notify tool, newest first",
+		MIMEType:    "application/json",
+	}, n.readNotifications)
+}
+
+// makeHandler creates a handler for the notify tool.
+func (n *eventNotifier) makeHandler(dt *DaemonTools) func(context.Context, *mcp.CallToolRequest, NotifyInput) (*mcp.CallToolResult, NotifyOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input NotifyInput) (*mcp.CallToolResult, NotifyOutput, error) {
+		for _, kind := range input.Kinds {
+			if !slices.Contains(notifyKinds, kind) {
+				return errorResult(fmt.Sprintf("unknown kind %q (use process_exit, proxy_unreachable, tunnel_url)", kind)), NotifyOutput{}, nil
+			}
+		}
+		kinds := input.Kinds
+		if len(kinds) == 0 {
+			kinds = notifyKinds
+		}
+
+		switch input.Action {
+		case "subscribe":
+			if err := dt.ensureConnected(); err != nil {
+				return errorResult(err.Error()), NotifyOutput{}, nil
+			}
+			n.subscription(req.Session, true).subscribe(kinds, input.Global, dt.projectPath(req.Session))
+		case "unsubscribe":
+			if sub := n.subscription(req.Session, false); sub != nil {
+				sub.unsubscribe(kinds)
+			}
+		case "status":
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q (use subscribe, unsubscribe, status)", input.Action)), NotifyOutput{}, nil
+		}
+		return nil, n.subscription(req.Session, false).status(), nil
+	}
+}
+
+// subscribe adds kinds and (re)starts the stream for the project at path, or
+// all projects with global.
+func (n *notifySubscription) subscribe(kinds []string, global bool, path string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, kind := range kinds {
+		if !slices.Contains(n.kinds, kind) {
+			n.kinds = append(n.kinds, kind)
+		}
+	}
+	if n.stop != nil && n.global == global {
+		return
+	}
+	if n.stop != nil {
+		n.stop()
+	}
+	n.global = global
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.stop = cancel
+	go n.run(ctx, protocol.EventsFilter{
+		Types:     []string{daemon.EventProcessExited, daemon.EventProxyUnreachable, daemon.EventTunnelURL},
+		Limit:     1,
+		TimeoutMs: int(notifyStreamTimeout.Milliseconds()),
+		Global:    global,
+		Path:      path,
+	})
+}
+
+// unsubscribe removes kinds, and stops the stream once none are left.
+func (n *notifySubscription) unsubscribe(kinds []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.kinds = slices.DeleteFunc(n.kinds, func(kind string) bool { return slices.Contains(kinds, kind) })
+	if len(n.kinds) == 0 && n.stop != nil {
+		n.stop()
+		n.stop = nil
+	}
+}
+
+// status reports the subscription and what was sent. A nil subscription
+// has neither.
+func (n *notifySubscription) status() NotifyOutput {
+	if n == nil {
+		return NotifyOutput{Kinds: []string{}}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return NotifyOutput{
+		Kinds:  append([]string{}, n.kinds...),
+		Global: n.global && n.stop != nil,
+		Recent: slices.Clone(n.recent),
+	}
+}
+
+// run streams events until ctx ends, reconnecting after errors.
+func (n *notifySubscription) run(ctx context.Context, filter protocol.EventsFilter) {
+	for ctx.Err() == nil {
+		err := n.stream(ctx, filter)
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+		debug.Log("tools", "notify: event stream failed: %v", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(notifyRetryDelay):
+		}
+	}
+}
+
+// stream connects to the daemon and hands each event to deliver. Closing
+// the connection when ctx ends cuts a waiting EVENTS SUBSCRIBE short.
+func (n *notifySubscription) stream(ctx context.Context, filter protocol.EventsFilter) error {
+	client := daemon.NewClientWithPath(n.socketPath)
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer func() {
+		if stop() {
+			client.Close()
+		}
+	}()
+
+	// Start with what happens from now on
+	n.mu.Lock()
+	cursor := n.cursor
+	n.mu.Unlock()
+	if cursor == 0 {
+		result, err := client.EventsQuery(protocol.EventsFilter{Limit: 1, Global: true})
+		if err != nil {
+			return err
+		}
+		if seq, ok := result["last_seq"].(float64); ok {
+			cursor = int64(seq)
+		}
+	}
+
+	for ctx.Err() == nil {
+		filter.Since = cursor
+		events, err := client.EventsSubscribe(filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			cursor = e.Seq
+			n.deliver(ctx, e)
+		}
+		n.mu.Lock()
+		n.cursor = cursor
+		n.mu.Unlock()
+	}
+	return nil
+}
+
+// wants reports whether e is for a subscribed kind. Only non-zero process
+// exits count.
+func (n *notifySubscription) wants(e daemon.DaemonEvent) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, kind := range n.kinds {
+		if notifyKindEvents[kind] != e.Type {
+			continue
+		}
+		if kind == NotifyProcessExit {
+			code, _ := e.Data["exit_code"].(float64)
+			return code != 0
+		}
+		return true
+	}
+	return false
+}
+
+// deliver sends e to the subscription's session if it subscribed to it.
+func (n *notifySubscription) deliver(ctx context.Context, e daemon.DaemonEvent) {
+	if !n.wants(e) {
+		return
+	}
+	entry := EventEntry{
+		Seq:     e.Seq,
+		Time:    e.Time.Format(time.RFC3339),
+		Type:    e.Type,
+		Source:  e.Source,
+		Message: e.Message,
+		Data:    e.Data,
+	}
+	n.mu.Lock()
+	n.recent = append(n.recent, entry)
+	if len(n.recent) > maxRecentNotifications {
+		n.recent = n.recent[len(n.recent)-maxRecentNotifications:]
+	}
+	n.mu.Unlock()
+
+	level := mcp.LoggingLevel("warning")
+	if e.Type == daemon.EventTunnelURL {
+		level = "info"
+	}
+	if n.session != nil {
+		if err := n.session.Log(ctx, &mcp.LoggingMessageParams{Level: level, Logger: notificationLogger, Data: entry}); err != nil {
+			debug.Log("tools", "notify: log message not sent: %v", err)
+		}
+	}
+	n.server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: notificationsURI})
+}
+
+// readNotifications lists the notifications sent to the reading session,
+// newest first.
+func (n *eventNotifier) readNotifications(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	status := n.subscription(req.Session, false).status()
+	slices.Reverse(status.Recent)
+	if status.Recent == nil {
+		status.Recent = []EventEntry{}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"kinds":         status.Kinds,
+		"notifications": status.Recent,
+		"count":         len(status.Recent),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{
+		URI:      req.Params.URI,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}}, nil
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/standardbeagle/agnt/internal/daemon"
+)
+
+func TestEventNotifierDeliver(t *testing.T) {
+	n := &notifySubscription{
+		server: mcp.NewServer(&mcp.Implementation{Name: "test"}, nil),
+		kinds:  []string{NotifyProcessExit, NotifyTunnelURL},
+	}
+	ctx := context.Background()
+
+	n.deliver(ctx, daemon.DaemonEvent{Seq: 1, Type: daemon.EventProcessExited, Source: "dev", Data: map[string]interface{}{"exit_code": float64(0)}})
+	n.deliver(ctx, daemon.DaemonEvent{Seq: 2, Type: daemon.EventProcessExited, Source: "dev", Data: map[string]interface{}{"exit_code": float64(1)}})
+	n.deliver(ctx, daemon.DaemonEvent{Seq: 3, Type: daemon.EventProxyUnreachable, Source: "app"})
+	n.deliver(ctx, daemon.DaemonEvent{Seq: 4, Type: daemon.EventTunnelURL, Source: "share"})
+	n.deliver(ctx, daemon.DaemonEvent{Seq: 5, Type: daemon.EventProcessStarted, Source: "dev"})
+
+	status := n.status()
+	if len(status.Recent) != 2 || status.Recent[0].Seq != 2 || status.Recent[1].Seq != 4 {
+		t.Fatalf("Expected the failed exit and the tunnel URL sent, got %+v", status.Recent)
+	}
+
+	n.unsubscribe([]string{NotifyTunnelURL})
+	n.deliver(ctx, daemon.DaemonEvent{Seq: 6, Type: daemon.EventTunnelURL, Source: "share"})
+	if status := n.status(); len(status.Recent) != 2 || len(status.Kinds) != 1 {
+		t.Errorf("Expected nothing sent after unsubscribing, got %+v", status)
+	}
+}
+
+func TestEventNotifierPerSession(t *testing.T) {
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "agnt-test"}, nil)
+
+	var mu sync.Mutex
+	received := map[string]int{}
+	connect := func(name string) *mcp.ServerSession {
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		ss, err := server.Connect(ctx, serverTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := mcp.NewClient(&mcp.Implementation{Name: name}, &mcp.ClientOptions{
+			LoggingMessageHandler: func(context.Context, *mcp.LoggingMessageRequest) {
+				mu.Lock()
+				received[name]++
+				mu.Unlock()
+			},
+		})
+		cs, err := client.Connect(ctx, clientTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cs.Close() })
+		if err := cs.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: "info"}); err != nil {
+			t.Fatal(err)
+		}
+		return ss
+	}
+	a, b := connect("a"), connect("b")
+
+	n := &eventNotifier{server: server}
+	subA := n.subscription(a, true)
+	subA.kinds = []string{NotifyProcessExit}
+	subB := n.subscription(b, true)
+	subB.kinds = []string{NotifyTunnelURL}
+
+	// Both streams see every event; each session gets only its kinds
+	for _, e := range []daemon.DaemonEvent{
+		{Seq: 1, Type: daemon.EventProcessExited, Source: "dev", Data: map[string]interface{}{"exit_code": float64(1)}},
+		{Seq: 2, Type: daemon.EventTunnelURL, Source: "share"},
+		{Seq: 3, Type: daemon.EventTunnelURL, Source: "share"},
+	} {
+		subA.deliver(ctx, e)
+		subB.deliver(ctx, e)
+	}
+	if got := len(subA.status().Recent); got != 1 {
+		t.Errorf("Expected 1 notification for session a, got %d", got)
+	}
+	if got := len(subB.status().Recent); got != 2 {
+		t.Errorf("Expected 2 notifications for session b, got %d", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		gotA, gotB := received["a"], received["b"]
+		mu.Unlock()
+		if gotA == 1 && gotB == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 1 log message for a and 2 for b, got %d and %d", gotA, gotB)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Unsubscribing one session leaves the other alone, and a closed
+	// session's subscription is dropped
+	subB.unsubscribe(notifyKinds)
+	if kinds := subA.status().Kinds; len(kinds) != 1 {
+		t.Errorf("Expected session a to keep its kinds, got %v", kinds)
+	}
+	a.Close()
+	for n.subscription(a, false) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscription of a closed session to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
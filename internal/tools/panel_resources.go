@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+// panelAttachmentsURI lists the attachments of recent panel messages.
+const panelAttachmentsURI = "agnt://panel"
+
+// PanelAttachmentEntry describes an attachment in the agnt://panel listing.
+type PanelAttachmentEntry struct {
+	URI       string `json:"uri"`
+	ProxyID   string `json:"proxy_id"`
+	MessageID string `json:"message_id"`
+	Message   string `json:"message,omitempty"` // Text of the panel message
+	Type      string `json:"type"`              // screenshot, file, ...
+	Name      string `json:"name,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+	PageURL   string `json:"page_url,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// RegisterPanelResources exposes the images and files users attach to
+// floating panel messages as MCP resources.
+func RegisterPanelResources(server *mcp.Server, dt *DaemonTools) {
+	server.AddResource(&mcp.Resource{
+		URI:         panelAttachmentsURI,
+		Name:        "panel-attachments",
+		Title:       "Floating panel attachments",
+		Description: "Screenshots and files attached to messages sent from the floating panel of the project's proxied pages, newest first, with the URI to read each",
+		MIMEType:    "application/json",
+	}, dt.readPanelAttachments)
+
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: proxy.PanelResourcePrefix + "{proxy_id}/{message_id}/{index}",
+		Name:        "panel-attachment",
+		Title:       "Floating panel attachment",
+		Description: "A screenshot or file attached to a floating panel message; the URI is given with the message and in the agnt://panel listing",
+	}, dt.readPanelAttachment)
+}
+
+// readPanelAttachments lists the attachments with content of the panel
+// messages the project's proxies still hold.
+func (dt *DaemonTools) readPanelAttachments(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+	dirFilter := protocol.DirectoryFilter{}
+	if sessionCode := dt.sessionCodeFor(req.Session); sessionCode != "" {
+		dirFilter.SessionCode = sessionCode
+	} else if projectPath := dt.projectPath(req.Session); projectPath != "" {
+		dirFilter.Directory = projectPath
+	}
+	result, err := dt.client.ProxyList(dirFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxies: %v", err)
+	}
+
+	entries := []PanelAttachmentEntry{}
+	proxies, _ := result["proxies"].([]interface{})
+	for _, p := range proxies {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		proxyID := getString(pm, "id")
+		messages, err := dt.panelMessages(proxyID)
+		if err != nil {
+			continue
+		}
+		for _, msg := range messages {
+			for i, att := range msg.Attachments {
+				if !att.HasContent() {
+					continue
+				}
+				var name string
+				if path := att.FilePath(); path != "" {
+					name = filepath.Base(path)
+				}
+				entries = append(entries, PanelAttachmentEntry{
+					URI:       proxy.PanelAttachmentURI(proxyID, msg.ID, i),
+					ProxyID:   proxyID,
+					MessageID: msg.ID,
+					Message:   msg.Message,
+					Type:      att.Type,
+					Name:      name,
+					MimeType:  attachmentMimeType(att, nil),
+					PageURL:   msg.URL,
+					Timestamp: msg.Timestamp.Format(time.RFC3339),
+				})
+			}
+		}
+	}
+	slices.Reverse(entries)
+
+	data, err := json.MarshalIndent(map[string]interface{}{"attachments": entries, "count": len(entries)}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{
+		URI:      req.Params.URI,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}}, nil
+}
+
+// readPanelAttachment returns the image or file of one panel attachment.
+func (dt *DaemonTools) readPanelAttachment(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	proxyID, messageID, index, err := proxy.ParsePanelAttachmentURI(uri)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+	messages, err := dt.panelMessages(proxyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range messages {
+		if msg.ID != messageID {
+			continue
+		}
+		if index >= len(msg.Attachments) || !msg.Attachments[index].HasContent() {
+			break
+		}
+		contents, err := panelAttachmentContents(uri, msg.Attachments[index])
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+	}
+	// The proxy's log has moved on, or the attachment never had content
+	return nil, mcp.ResourceNotFoundError(uri)
+}
+
+// panelMessages returns the panel messages a proxy still holds, oldest first.
+func (dt *DaemonTools) panelMessages(proxyID string) ([]proxy.PanelMessage, error) {
+	result, err := dt.client.ProxyLogQuery(proxyID, protocol.LogQueryFilter{
+		Types: []string{string(proxy.LogTypePanelMessage)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var entries []proxy.LogEntry
+	if raw, ok := result["logs"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &entries)
+		}
+	}
+	messages := make([]proxy.PanelMessage, 0, len(entries))
+	for _, entry := range entries {
+		if entry.PanelMessage != nil {
+			messages = append(messages, *entry.PanelMessage)
+		}
+	}
+	return messages, nil
+}
+
+// panelAttachmentContents reads a panel attachment from its saved file or,
+// without one, from its inline screenshot data. Text stays text; anything
+// else is returned as a blob.
+func panelAttachmentContents(uri string, att proxy.PanelAttachment) (*mcp.ResourceContents, error) {
+	var data []byte
+	if path := att.FilePath(); path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("attachment file is gone: %v", err)
+		}
+		if info.Size() > proxy.MaxUploadBytes {
+			return nil, fmt.Errorf("attachment file is over the %d MB limit", proxy.MaxUploadBytes>>20)
+		}
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	} else {
+		encoded := att.Area.Data
+		if _, payload, ok := strings.Cut(encoded, ","); ok {
+			encoded = payload
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid screenshot data: %v", err)
+		}
+		data = decoded
+	}
+
+	contents := &mcp.ResourceContents{URI: uri, MIMEType: attachmentMimeType(att, data)}
+	if strings.HasPrefix(contents.MIMEType, "text/") || contents.MIMEType == "application/json" {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = data
+	}
+	return contents, nil
+}
+
+// attachmentMimeType is the recorded type of an attachment, else the one
+// its file name or data suggests.
+func attachmentMimeType(att proxy.PanelAttachment, data []byte) string {
+	if mimeType, _ := att.Data["mime_type"].(string); mimeType != "" {
+		return mimeType
+	}
+	if path := att.FilePath(); path != "" {
+		if mimeType := mime.TypeByExtension(filepath.Ext(path)); mimeType != "" {
+			return mimeType
+		}
+	} else if att.Area != nil {
+		if header, _, ok := strings.Cut(att.Area.Data, ","); ok && strings.HasPrefix(header, "data:") {
+			return strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		}
+	}
+	if data != nil {
+		return http.DetectContentType(data)
+	}
+	return ""
+}
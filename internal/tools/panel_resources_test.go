@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestPanelAttachmentContents(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n-image")
+	pngPath := filepath.Join(dir, "mock.png")
+	notesPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(pngPath, png, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(notesPath, []byte("use the blue button"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dropped file: bytes as a blob, with its type
+	contents, err := panelAttachmentContents("agnt://panel/app/upload-1/0", proxy.PanelAttachment{Type: "file", Data: map[string]interface{}{"file_path": pngPath}})
+	if err != nil {
+		t.Fatalf("panelAttachmentContents failed: %v", err)
+	}
+	if contents.MIMEType != "image/png" || string(contents.Blob) != string(png) || contents.Text != "" {
+		t.Errorf("contents = %+v", contents)
+	}
+
+	// Text stays text
+	contents, err = panelAttachmentContents("agnt://panel/app/upload-2/0", proxy.PanelAttachment{Type: "file", Data: map[string]interface{}{"file_path": notesPath, "mime_type": "text/plain"}})
+	if err != nil || contents.Text != "use the blue button" || contents.Blob != nil {
+		t.Errorf("contents = %+v (%v)", contents, err)
+	}
+
+	// A screenshot area without a saved file reads its inline data
+	area := &proxy.ScreenshotArea{Data: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)}
+	contents, err = panelAttachmentContents("agnt://panel/app/metric-1/0", proxy.PanelAttachment{Type: "screenshot", Area: area})
+	if err != nil || contents.MIMEType != "image/png" || string(contents.Blob) != string(png) {
+		t.Errorf("contents = %+v (%v)", contents, err)
+	}
+
+	if _, err := panelAttachmentContents("agnt://panel/app/upload-3/0", proxy.PanelAttachment{Data: map[string]interface{}{"file_path": filepath.Join(dir, "gone.png")}}); err == nil {
+		t.Error("Expected an error for a deleted file")
+	}
+}
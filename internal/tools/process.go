@@ -7,8 +7,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/standardbeagle/agnt/internal/daemon"
 	"github.com/standardbeagle/agnt/internal/debug"
 	"github.com/standardbeagle/agnt/internal/project"
+	"github.com/standardbeagle/agnt/internal/protocol"
 	"github.com/standardbeagle/go-cli-server/process"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -29,12 +31,21 @@ const (
 // RunInput defines input for the run tool.
 type RunInput struct {
 	Path       string   `json:"path,omitempty" jsonschema:"Project directory (defaults to current dir)"`
+	Workspace  string   `json:"workspace,omitempty" jsonschema:"Run in a workspace created by the workspace tool instead of path"`
 	ScriptName string   `json:"script_name,omitempty" jsonschema:"Script name from detect (e.g. test, lint, build)"`
 	Raw        bool     `json:"raw,omitempty" jsonschema:"Raw mode: use command and args directly"`
 	Command    string   `json:"command,omitempty" jsonschema:"Raw mode: executable to run"`
 	Args       []string `json:"args,omitempty" jsonschema:"Extra args (appended in script mode, used directly in raw mode)"`
 	ID         string   `json:"id,omitempty" jsonschema:"Process ID (auto-generated if empty)"`
 	Mode       RunMode  `json:"mode,omitempty" jsonschema:"Execution mode: background (default), foreground, foreground-raw"`
+	Profile    bool     `json:"profile,omitempty" jsonschema:"Enable profiling: starts Node with the inspector so the profile tool can attach (Go apps must import net/http/pprof)"`
+
+	Labels map[string]string `json:"labels,omitempty" jsonschema:"Labels to tag the process with (e.g. {area: checkout}), for filtering proc list"`
+
+	// Supervision of background processes
+	HealthCheck *protocol.HealthCheck `json:"health_check,omitempty" jsonschema:"Background mode: probe {url} (HTTP below 400) or {port} (TCP) every interval_ms (default 5000); unhealthy after failure_threshold (default 3) misses"`
+	Restart     string                `json:"restart,omitempty" jsonschema:"Background mode: restart policy never (default), on-failure (non-zero exit or unhealthy) or always"`
+	MaxRestarts int                   `json:"max_restarts,omitempty" jsonschema:"Restarts before giving up (default 5)"`
 }
 
 // RunOutput defines output for run.
@@ -49,24 +60,42 @@ type RunOutput struct {
 	// Foreground-raw mode fields
 	Stdout string `json:"stdout,omitempty"`
 	Stderr string `json:"stderr,omitempty"`
+	// Foreground modes: test results and build errors found in the output
+	Results *TestReport `json:"results,omitempty"`
+	// Set when the project's .agnt.kdl has a remote block
+	RemoteHost string `json:"remote_host,omitempty"`
 }
 
 // ProcInput defines input for the proc tool.
 type ProcInput struct {
-	Action    string `json:"action" jsonschema:"Action: status, output, stop, list, cleanup_port"`
-	ProcessID string `json:"process_id,omitempty" jsonschema:"Process ID (required for status/output/stop)"`
+	Action    string `json:"action" jsonschema:"Action: status, output, stop, restart, list, cleanup_port, crash, label, supervise, metrics, results"`
+	ProcessID string `json:"process_id,omitempty" jsonschema:"Process ID (required for status/output/stop; for crash: process or crash report ID, omit to list)"`
 	// Output filters
 	Stream string `json:"stream,omitempty" jsonschema:"stdout, stderr, or combined (default)"`
 	Tail   int    `json:"tail,omitempty" jsonschema:"Last N lines only"`
 	Head   int    `json:"head,omitempty" jsonschema:"First N lines only"`
 	Grep   string `json:"grep,omitempty" jsonschema:"Filter lines matching regex pattern"`
 	GrepV  bool   `json:"grep_v,omitempty" jsonschema:"Invert grep (exclude matching lines)"`
+	// Follow mode
+	Follow          bool `json:"follow,omitempty" jsonschema:"For output: also wait for new lines until the process exits or follow_timeout_ms passes"`
+	FollowTimeoutMs int  `json:"follow_timeout_ms,omitempty" jsonschema:"For output with follow: how long to wait for new lines (default 10000, max 25000)"`
 	// Stop options
-	Force bool `json:"force,omitempty" jsonschema:"For stop: force kill immediately"`
+	Force   bool `json:"force,omitempty" jsonschema:"For stop: force kill immediately"`
+	Cascade bool `json:"cascade,omitempty" jsonschema:"For stop: also stop the proxies and tunnels that depend on the process"`
 	// Cleanup options
-	Port int `json:"port,omitempty" jsonschema:"Port number (required for cleanup_port)"`
+	Port   int  `json:"port,omitempty" jsonschema:"Port number (required for cleanup_port)"`
+	DryRun bool `json:"dry_run,omitempty" jsonschema:"For cleanup_port: list the processes on the port without killing them"`
 	// Directory filtering
 	Global bool `json:"global,omitempty" jsonschema:"For list: include processes from all directories (default: false)"`
+	// Labels
+	Labels       map[string]string `json:"labels,omitempty" jsonschema:"For label: labels to set; for list: only processes with all these labels (an empty value matches any)"`
+	RemoveLabels []string          `json:"remove_labels,omitempty" jsonschema:"For label: label keys to remove"`
+	// Supervision
+	HealthCheck *protocol.HealthCheck `json:"health_check,omitempty" jsonschema:"For supervise: probe {url} or {port} with optional interval_ms, timeout_ms, failure_threshold"`
+	Restart     string                `json:"restart,omitempty" jsonschema:"For supervise: never, on-failure or always; off stops supervising"`
+	MaxRestarts int                   `json:"max_restarts,omitempty" jsonschema:"For supervise: restarts before giving up (default 5)"`
+	// Metrics
+	Limit int `json:"limit,omitempty" jsonschema:"For metrics: only the last N samples"`
 }
 
 // ProcOutput defines output for proc.
@@ -77,6 +106,7 @@ type ProcOutput struct {
 	Summary   string `json:"summary,omitempty"`
 	ExitCode  int    `json:"exit_code,omitempty"`
 	Runtime   string `json:"runtime,omitempty"`
+	PID       int    `json:"pid,omitempty"` // For restart: the new process
 	// For output
 	Output    string `json:"output,omitempty"`
 	Lines     int    `json:"lines,omitempty"`
@@ -90,18 +120,142 @@ type ProcOutput struct {
 	// For stop
 	Success bool `json:"success,omitempty"`
 	// For cleanup_port
-	KilledPIDs []int  `json:"killed_pids,omitempty"`
-	Message    string `json:"message,omitempty"`
+	KilledPIDs []int                     `json:"killed_pids,omitempty"`
+	DryRun     bool                      `json:"dry_run,omitempty"`
+	Affected   []protocol.AffectedEntity `json:"affected,omitempty"`
+	Message    string                    `json:"message,omitempty"`
+	// For label
+	Labels map[string]string `json:"labels,omitempty"`
+	// For crash
+	Crash   *CrashReport   `json:"crash,omitempty"`
+	Crashes []CrashSummary `json:"crashes,omitempty"`
+	// For status and supervise
+	Health *ProcHealth `json:"health,omitempty"`
+	// For status and metrics: the latest sample
+	Metrics *ProcSample `json:"metrics,omitempty"`
+	// For metrics
+	History *ProcMetrics `json:"history,omitempty"`
+	// For results
+	Results *TestReport `json:"results,omitempty"`
+}
+
+// TestReport is the test results and build errors parsed from a run's output.
+type TestReport struct {
+	Total       int           `json:"total"`
+	Passed      int           `json:"passed"`
+	Failed      int           `json:"failed"`
+	Skipped     int           `json:"skipped"`
+	Elapsed     float64       `json:"elapsed,omitempty"` // Seconds, summed over tests
+	Failures    []TestFailure `json:"failures,omitempty"`
+	Slowest     []TestTiming  `json:"slowest,omitempty"`
+	BuildErrors []BuildError  `json:"build_errors,omitempty"`
+}
+
+// TestTiming is a test and how long it took.
+type TestTiming struct {
+	Name    string  `json:"name"`
+	Package string  `json:"package,omitempty"`
+	File    string  `json:"file,omitempty"`
+	Elapsed float64 `json:"elapsed,omitempty"` // Seconds
+}
+
+// TestFailure is a failed test with what its runner logged about it.
+type TestFailure struct {
+	TestTiming
+	Message string `json:"message,omitempty"`
+}
+
+// BuildError is a compiler or type checker error.
+type BuildError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProcSample is the CPU and memory use of a process and its children.
+type ProcSample struct {
+	Time         string  `json:"time"`
+	PID          int     `json:"pid"`
+	CPUPercent   float64 `json:"cpu_percent"` // 100 per fully used core
+	RSSBytes     uint64  `json:"rss_bytes"`
+	Processes    int     `json:"processes"`
+	RSS          string  `json:"rss,omitempty"`            // Latest sample only
+	PeakRSSBytes uint64  `json:"peak_rss_bytes,omitempty"` // Latest sample only
+}
+
+// ProcMetrics is the sampled history of a process.
+type ProcMetrics struct {
+	Samples        []ProcSample `json:"samples"`
+	IntervalMs     int64        `json:"interval_ms"`
+	WindowMs       int64        `json:"window_ms,omitempty"`
+	RSSGrowthBytes int64        `json:"rss_growth_bytes"`
+	PeakRSSBytes   uint64       `json:"peak_rss_bytes,omitempty"`
+}
+
+// ProcHealth is the health check and restart state of a supervised process.
+type ProcHealth struct {
+	Config              protocol.SuperviseConfig `json:"config"`
+	State               string                   `json:"state,omitempty"` // starting, healthy, unhealthy or exited
+	ConsecutiveFailures int                      `json:"consecutive_failures"`
+	LastCheck           string                   `json:"last_check,omitempty"`
+	LastError           string                   `json:"last_error,omitempty"`
+	Restarts            int                      `json:"restarts"`
+	LastRestartReason   string                   `json:"last_restart_reason,omitempty"`
+	GaveUp              bool                     `json:"gave_up,omitempty"`
+}
+
+// CrashReport is the post-mortem of a crashed process.
+type CrashReport struct {
+	ID         string       `json:"id"`
+	ProcessID  string       `json:"process_id"`
+	Command    string       `json:"command,omitempty"`
+	Args       []string     `json:"args,omitempty"`
+	PID        int          `json:"pid,omitempty"`
+	ExitCode   int          `json:"exit_code"`
+	Kind       string       `json:"kind"`
+	Signal     string       `json:"signal,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Goroutine  string       `json:"goroutine,omitempty"`
+	Frames     []CrashFrame `json:"frames,omitempty"`
+	StderrTail string       `json:"stderr_tail"`
+	Core       *CrashCore   `json:"core,omitempty"`
+	CrashedAt  string       `json:"crashed_at"`
+}
+
+// CrashFrame is one decoded stack frame of a crash.
+type CrashFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// CrashCore points at the core dump of a crash.
+type CrashCore struct {
+	Path string `json:"path,omitempty"`
+	Hint string `json:"hint,omitempty"`
+}
+
+// CrashSummary is a crash report in the list.
+type CrashSummary struct {
+	ID        string `json:"id"`
+	ProcessID string `json:"process_id"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message,omitempty"`
+	ExitCode  int    `json:"exit_code"`
+	CrashedAt string `json:"crashed_at"`
 }
 
 // ProcEntry is a process in the list.
 type ProcEntry struct {
-	ID          string `json:"id"`
-	Command     string `json:"command"`
-	State       string `json:"state"`
-	Summary     string `json:"summary"`
-	Runtime     string `json:"runtime"`
-	ProjectPath string `json:"project_path,omitempty"`
+	ID          string            `json:"id"`
+	Command     string            `json:"command"`
+	State       string            `json:"state"`
+	Summary     string            `json:"summary"`
+	Runtime     string            `json:"runtime"`
+	ProjectPath string            `json:"project_path,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
 }
 
 // RegisterProcessTools adds process-related MCP tools to the server.
@@ -134,8 +288,11 @@ Examples:
 Actions:
   list: List all running processes (use global: true for all directories)
   status: Get process status and info
-  output: Get process output (tail/grep supported)
+  output: Get process output (tail/grep supported); follow: true also waits for new
+          lines until the process exits or follow_timeout_ms passes (default 10s, max 25s)
   stop: Gracefully stop a process (use force: true for immediate kill)
+  restart: Restart a process with the same command, args, environment and project
+           path; returns its new state and PID
   cleanup_port: Kill any process using a specific port
 
 Restarting dev servers: Always use stop action, never pkill or external commands.
@@ -147,6 +304,7 @@ Examples:
   proc {action: "status", process_id: "test"}
   proc {action: "output", process_id: "test", tail: 20}
   proc {action: "output", process_id: "test", grep: "FAIL"}
+  proc {action: "output", process_id: "dev", tail: 20, follow: true}
   proc {action: "stop", process_id: "test"}
   proc {action: "stop", process_id: "test", force: true}
   proc {action: "cleanup_port", port: 3000}`,
@@ -273,15 +431,17 @@ func makeProcHandler(pm *process.ProcessManager) func(context.Context, *mcp.Call
 		case "status":
 			return handleStatus(pm, input)
 		case "output":
-			return handleOutput(pm, input)
+			return handleOutput(ctx, pm, input)
 		case "stop":
 			return handleStop(ctx, pm, input)
+		case "restart":
+			return handleRestart(ctx, pm, input)
 		case "list":
 			return handleList(pm)
 		case "cleanup_port":
 			return handleCleanupPort(ctx, pm, input)
 		default:
-			return errorResult(fmt.Sprintf("unknown action %q. Use: status, output, stop, list, cleanup_port", input.Action)), ProcOutput{}, nil
+			return errorResult(fmt.Sprintf("unknown action %q. Use: status, output, stop, restart, list, cleanup_port", input.Action)), ProcOutput{}, nil
 		}
 	}
 }
@@ -305,7 +465,7 @@ func handleStatus(pm *process.ProcessManager, input ProcInput) (*mcp.CallToolRes
 	}, nil
 }
 
-func handleOutput(pm *process.ProcessManager, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+func handleOutput(ctx context.Context, pm *process.ProcessManager, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
 	if input.ProcessID == "" {
 		return errorResult("process_id required for output"), ProcOutput{}, nil
 	}
@@ -320,6 +480,10 @@ func handleOutput(pm *process.ProcessManager, input ProcInput) (*mcp.CallToolRes
 		stream = "combined"
 	}
 
+	if input.Follow {
+		return handleOutputFollow(ctx, proc, stream, input)
+	}
+
 	var data []byte
 	var truncated bool
 
@@ -385,6 +549,59 @@ func handleOutput(pm *process.ProcessManager, input ProcInput) (*mcp.CallToolRes
 	}, nil
 }
 
+// handleOutputFollow returns the tail of a process's output followed by the
+// lines it writes until it exits or the follow timeout passes.
+func handleOutputFollow(ctx context.Context, proc *process.ManagedProcess, stream string, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	if stream != "stdout" && stream != "stderr" && stream != "combined" {
+		return errorResult("stream must be stdout, stderr, or combined"), ProcOutput{}, nil
+	}
+	var re *regexp.Regexp
+	if input.Grep != "" {
+		var err error
+		if re, err = regexp.Compile(input.Grep); err != nil {
+			return errorResult(fmt.Sprintf("invalid grep pattern: %v", err)), ProcOutput{}, nil
+		}
+	}
+	grep := func(lines []string) []string {
+		if re == nil {
+			return lines
+		}
+		var filtered []string
+		for _, line := range lines {
+			if re.MatchString(line) != input.GrepV {
+				filtered = append(filtered, line)
+			}
+		}
+		return filtered
+	}
+
+	timeout := time.Duration(input.FollowTimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = daemon.DefaultFollowTimeout
+	}
+	timeout = min(timeout, daemon.MaxFollowTimeout)
+
+	follower, lines := daemon.NewOutputFollower(proc, stream)
+	lines = grep(lines)
+	if input.Tail > 0 && len(lines) > input.Tail {
+		lines = lines[len(lines)-input.Tail:]
+	}
+	err := follower.Follow(ctx, timeout, func(added []string) error {
+		lines = append(lines, grep(added)...)
+		return nil
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("follow interrupted: %v", err)), ProcOutput{}, nil
+	}
+
+	return nil, ProcOutput{
+		ProcessID: proc.ID,
+		State:     proc.State().String(),
+		Output:    strings.Join(lines, "\n"),
+		Lines:     len(lines),
+	}, nil
+}
+
 func handleStop(ctx context.Context, pm *process.ProcessManager, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
 	if input.ProcessID == "" {
 		return errorResult("process_id required for stop"), ProcOutput{}, nil
@@ -411,6 +628,43 @@ func handleStop(ctx context.Context, pm *process.ProcessManager, input ProcInput
 	}, nil
 }
 
+func handleRestart(ctx context.Context, pm *process.ProcessManager, input ProcInput) (*mcp.CallToolResult, ProcOutput, error) {
+	if input.ProcessID == "" {
+		return errorResult("process_id required for restart"), ProcOutput{}, nil
+	}
+
+	proc, err := pm.Get(input.ProcessID)
+	if err != nil {
+		return errorResult(fmt.Sprintf("process not found: %s", input.ProcessID)), ProcOutput{}, nil
+	}
+
+	// Same command, args, environment and project path
+	config := process.ProcessConfig{
+		ID:          proc.ID,
+		ProjectPath: proc.ProjectPath,
+		Command:     proc.Command,
+		Args:        append([]string(nil), proc.Args...),
+		Env:         append([]string(nil), proc.Env...),
+	}
+	if proc.IsRunning() {
+		_ = pm.StopProcess(ctx, proc)
+	}
+	pm.RemoveByPath(config.ID, config.ProjectPath)
+
+	result, err := pm.StartOrReuse(ctx, config)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to restart %s: %v", input.ProcessID, err)), ProcOutput{}, nil
+	}
+
+	return nil, ProcOutput{
+		ProcessID: result.Process.ID,
+		State:     result.Process.State().String(),
+		PID:       result.Process.PID(),
+		Success:   true,
+		Message:   fmt.Sprintf("Process %q restarted", input.ProcessID),
+	}, nil
+}
+
 func handleList(pm *process.ProcessManager) (*mcp.CallToolResult, ProcOutput, error) {
 	procs := pm.List()
 
@@ -436,6 +690,10 @@ func handleCleanupPort(ctx context.Context, pm *process.ProcessManager, input Pr
 		return errorResult("valid port number required (1-65535)"), ProcOutput{}, nil
 	}
 
+	if input.DryRun {
+		return errorResult("dry_run for cleanup_port requires the daemon"), ProcOutput{}, nil
+	}
+
 	pids, err := pm.KillProcessByPort(ctx, input.Port)
 	if err != nil {
 		return errorResult(fmt.Sprintf("failed to cleanup port %d: %v", input.Port, err)), ProcOutput{}, nil
@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ProfileInput represents input for the profile tool.
+type ProfileInput struct {
+	Action    string `json:"action" jsonschema:"Action: cpu, heap, list, daemon"`
+	ProcessID string `json:"process_id,omitempty" jsonschema:"Managed process to profile (required for cpu/heap)"`
+	Seconds   int    `json:"seconds,omitempty" jsonschema:"For cpu: sampling duration in seconds (default 10, max 60); for Node heap: sampling window"`
+	Address   string `json:"address,omitempty" jsonschema:"pprof base URL (e.g. http://localhost:6060) or Node inspector ws:// URL (default: discovered)"`
+	Top       int    `json:"top,omitempty" jsonschema:"Functions listed in the summary (default 20)"`
+	Kind      string `json:"kind,omitempty" jsonschema:"For daemon: cpu (default, 30s) or heap"`
+}
+
+// ProfileOutput represents output from the profile tool.
+type ProfileOutput struct {
+	ProcessID string            `json:"process_id,omitempty"`
+	Kind      string            `json:"kind,omitempty"`
+	Runtime   string            `json:"runtime,omitempty"`
+	Artifact  string            `json:"artifact,omitempty"`
+	Bytes     int               `json:"bytes,omitempty"`
+	Seconds   int               `json:"seconds,omitempty"`
+	Summary   *ProfileSummary   `json:"summary,omitempty"`
+	Profiles  []ProfileArtifact `json:"profiles,omitempty"`
+	Count     int               `json:"count,omitempty"`
+	Table     string            `json:"table,omitempty"`
+	AdminURL  string            `json:"admin_url,omitempty"`
+}
+
+// ProfileSummary is the top-N function table of a captured profile.
+type ProfileSummary struct {
+	SampleType string         `json:"sample_type"`
+	Unit       string         `json:"unit"`
+	Total      int64          `json:"total"`
+	Top        []ProfileEntry `json:"top"`
+}
+
+// ProfileEntry is one function in a profile summary.
+type ProfileEntry struct {
+	Function string  `json:"function"`
+	File     string  `json:"file,omitempty"`
+	Line     int64   `json:"line,omitempty"`
+	Flat     int64   `json:"flat"`
+	FlatPct  float64 `json:"flat_pct"`
+	Cum      int64   `json:"cum"`
+	CumPct   float64 `json:"cum_pct"`
+}
+
+// ProfileArtifact is a stored profile file.
+type ProfileArtifact struct {
+	Path     string `json:"path"`
+	Bytes    int64  `json:"bytes"`
+	Captured string `json:"captured"`
+}
+
+// RegisterProfileTool registers the profile MCP tool with the server.
+func RegisterProfileTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "profile",
+		Description: `Capture CPU and heap profiles from managed processes.
+
+Actions:
+  cpu: Sample CPU usage for a number of seconds and summarize the hottest functions
+  heap: Snapshot heap usage (Go: in-use heap; Node: live allocations sampled over the window)
+  list: List stored profiles
+  daemon: Profile the agnt daemon itself (kind cpu or heap), to report its performance issues
+
+Node processes must be started with the inspector: run {script_name: "dev", profile: true}.
+Go processes must serve net/http/pprof (import _ "net/http/pprof"); the endpoint is found
+on the process's detected URLs or localhost:6060, or pass address explicitly.
+
+Profiles are stored under .agnt/profiles (.pb.gz for go tool pprof, .cpuprofile and
+.heapprofile for Chrome DevTools).
+
+Examples:
+  profile {action: "cpu", process_id: "dev", seconds: 15}
+  profile {action: "heap", process_id: "api", address: "http://localhost:6060"}
+  profile {action: "daemon", kind: "cpu", seconds: 30}`,
+	}, dt.makeProfileHandler())
+}
+
+// makeProfileHandler creates a handler for the profile tool.
+func (dt *DaemonTools) makeProfileHandler() func(context.Context, *mcp.CallToolRequest, ProfileInput) (*mcp.CallToolResult, ProfileOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ProfileInput) (*mcp.CallToolResult, ProfileOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), ProfileOutput{}, nil
+		}
+
+		config := protocol.ProfileConfig{
+			Seconds: input.Seconds,
+			Address: input.Address,
+			Top:     input.Top,
+			Path:    dt.projectPath(req.Session),
+		}
+
+		var (
+			result map[string]interface{}
+			err    error
+		)
+		switch input.Action {
+		case "cpu", "heap":
+			if input.ProcessID == "" {
+				return errorResult("process_id required for " + input.Action), ProfileOutput{}, nil
+			}
+			if input.Action == "cpu" {
+				result, err = dt.client.ProfileCPU(input.ProcessID, config)
+			} else {
+				result, err = dt.client.ProfileHeap(input.ProcessID, config)
+			}
+		case "list":
+			result, err = dt.client.ProfileList(config.Path)
+		case "daemon":
+			result, err = dt.client.DaemonProfile(input.Kind, config)
+		default:
+			return errorResult(fmt.Sprintf("unknown action: %s (use: cpu, heap, list, daemon)", input.Action)), ProfileOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "profile "+input.Action), ProfileOutput{}, nil
+		}
+
+		output := ProfileOutput{
+			ProcessID: getString(result, "process_id"),
+			Kind:      getString(result, "kind"),
+			Runtime:   getString(result, "runtime"),
+			Artifact:  getString(result, "artifact"),
+			Bytes:     getInt(result, "bytes"),
+			Seconds:   getInt(result, "seconds"),
+			Count:     getInt(result, "count"),
+			Table:     getString(result, "table"),
+			AdminURL:  getString(result, "admin_url"),
+		}
+		for key, dst := range map[string]interface{}{
+			"summary":  &output.Summary,
+			"profiles": &output.Profiles,
+		} {
+			if raw, ok := result[key]; ok {
+				if b, err := json.Marshal(raw); err == nil {
+					json.Unmarshal(b, dst)
+				}
+			}
+		}
+
+		return nil, output, nil
+	}
+}
@@ -16,18 +16,21 @@ type DetectInput struct {
 
 // DetectOutput defines output for detect.
 type DetectOutput struct {
-	Type           string            `json:"type"`
-	Name           string            `json:"name"`
-	Scripts        []string          `json:"scripts"`
-	PackageManager string            `json:"package_manager,omitempty"`
-	Metadata       map[string]string `json:"metadata,omitempty"`
+	Type           string              `json:"type"`
+	Name           string              `json:"name"`
+	Scripts        []string            `json:"scripts"`
+	PackageManager string              `json:"package_manager,omitempty"`
+	Metadata       map[string]string   `json:"metadata,omitempty"`
+	Workspaces     []project.Workspace `json:"workspaces,omitempty"`
 }
 
 // RegisterProjectTools adds project-related MCP tools to the server.
 func RegisterProjectTools(server *mcp.Server) {
 	mcp.AddTool(server, &mcp.Tool{
 		Name: "detect",
-		Description: `Detect project type and available scripts.
+		Description: `Detect project type (go, node, python, rust, java, dotnet) and available scripts.
+Monorepos (pnpm/npm/yarn workspaces, turbo, nx), Cargo and Gradle/Maven multi-projects and .NET
+solutions also list their workspaces, with each package's own scripts.
 Example: detect {path: "."} → {type: "go", scripts: ["test", "build", "lint"]}`,
 	}, handleDetect)
 }
@@ -54,5 +57,6 @@ func handleDetect(ctx context.Context, req *mcp.CallToolRequest, input DetectInp
 		Scripts:        scripts,
 		PackageManager: proj.PackageManager,
 		Metadata:       proj.Metadata,
+		Workspaces:     proj.Workspaces,
 	}, nil
 }
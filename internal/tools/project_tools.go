@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// projectScope is the project an MCP session switched to with the project
+// tool, and the agnt run session it attached to there. Each connection of
+// agnt serve --http has its own.
+type projectScope struct {
+	path        string
+	sessionCode string
+}
+
+// projectPath returns the project the tools of an MCP session work on: the
+// one it chose with the project tool, or else getProjectPath.
+func (dt *DaemonTools) projectPath(ss *mcp.ServerSession) string {
+	dt.sessionMu.Lock()
+	scope := dt.scopes[ss]
+	dt.sessionMu.Unlock()
+	if scope == nil {
+		return getProjectPath()
+	}
+	return normalizeProjectPath(scope.path)
+}
+
+// sessionCodeFor returns the agnt run session an MCP session works on: the
+// one of the project it chose, or else the one attached on connect.
+func (dt *DaemonTools) sessionCodeFor(ss *mcp.ServerSession) string {
+	dt.sessionMu.Lock()
+	defer dt.sessionMu.Unlock()
+	if scope := dt.scopes[ss]; scope != nil {
+		return scope.sessionCode
+	}
+	return dt.sessionCode
+}
+
+// setProjectScope makes path and sessionCode the project and session of an
+// MCP session's later tool calls, until it closes.
+func (dt *DaemonTools) setProjectScope(ss *mcp.ServerSession, path, sessionCode string) {
+	dt.sessionMu.Lock()
+	if dt.scopes == nil {
+		dt.scopes = make(map[*mcp.ServerSession]*projectScope)
+	}
+	_, known := dt.scopes[ss]
+	dt.scopes[ss] = &projectScope{path: path, sessionCode: sessionCode}
+	dt.sessionMu.Unlock()
+
+	if !known && ss != nil {
+		go func() {
+			ss.Wait()
+			dt.sessionMu.Lock()
+			delete(dt.scopes, ss)
+			dt.sessionMu.Unlock()
+		}()
+	}
+}
+
+// ProjectInput represents input for the project tool.
+type ProjectInput struct {
+	Action string `json:"action" jsonschema:"use, list or current"`
+	Path   string `json:"path,omitempty" jsonschema:"use: project directory, relative to the current project"`
+}
+
+// ProjectOutput represents output from the project tool.
+type ProjectOutput struct {
+	Active   string         `json:"active"`
+	Session  string         `json:"session,omitempty"` // Session of the active project, if any
+	Projects []ProjectEntry `json:"projects,omitempty"`
+	Count    int            `json:"count,omitempty"`
+}
+
+// ProjectEntry is a project known from its agnt run sessions.
+type ProjectEntry struct {
+	Path     string   `json:"path"`
+	Sessions []string `json:"sessions"`
+	Active   bool     `json:"active,omitempty"`
+}
+
+// RegisterProjectTool registers the project MCP tool with the server.
+func RegisterProjectTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "project",
+		Description: `Switch the project that run, proc, proxy and the other tools work on.
+
+By default tools work on the directory agnt was started in and attach to its agnt run
+session. Use switches every later call of this MCP connection (only this one under
+agnt serve --http) to another project: runs start there, lists show its processes and
+proxies, and the connection attaches to the project's session if it has one.
+
+Actions:
+  use: Make path the active project
+  list: Projects with agnt run sessions, and the active one
+  current: The active project and its session
+
+Examples:
+  project {action: "use", path: "/home/dev/api"}
+  project {action: "use", path: "../web"}
+  project {action: "list"}
+  project {action: "current"}`,
+	}, dt.makeProjectHandler())
+}
+
+// makeProjectHandler creates a handler for the project tool.
+func (dt *DaemonTools) makeProjectHandler() func(context.Context, *mcp.CallToolRequest, ProjectInput) (*mcp.CallToolResult, ProjectOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ProjectInput) (*mcp.CallToolResult, ProjectOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), ProjectOutput{}, nil
+		}
+
+		switch input.Action {
+		case "use":
+			return dt.handleProjectUse(req.Session, input)
+		case "list":
+			return dt.handleProjectList(req.Session)
+		case "current":
+			return nil, ProjectOutput{Active: dt.projectPath(req.Session), Session: dt.sessionCodeFor(req.Session)}, nil
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q. Use: use, list, current", input.Action)), ProjectOutput{}, nil
+		}
+	}
+}
+
+// handleProjectUse switches to a project and its session.
+func (dt *DaemonTools) handleProjectUse(ss *mcp.ServerSession, input ProjectInput) (*mcp.CallToolResult, ProjectOutput, error) {
+	if input.Path == "" {
+		return errorResult("path required for use"), ProjectOutput{}, nil
+	}
+	path := input.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dt.projectPath(ss), path)
+	}
+	path = filepath.Clean(path)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return errorResult(fmt.Sprintf("%s is not a directory", path)), ProjectOutput{}, nil
+	}
+	// Attach to the project's session, or detach from the previous project's
+	dt.sessionMu.Lock()
+	noAttach := dt.noAutoAttach
+	dt.sessionMu.Unlock()
+	var code string
+	if !noAttach {
+		if result, err := dt.client.SessionAttach(path); err == nil {
+			code = getString(result, "session_code")
+		}
+	}
+	dt.setProjectScope(ss, path, code)
+
+	return nil, ProjectOutput{Active: dt.projectPath(ss), Session: code}, nil
+}
+
+// handleProjectList lists the projects of the registered sessions.
+func (dt *DaemonTools) handleProjectList(ss *mcp.ServerSession) (*mcp.CallToolResult, ProjectOutput, error) {
+	result, err := dt.client.SessionList(protocol.DirectoryFilter{Global: true})
+	if err != nil {
+		return formatDaemonError(err, "project list"), ProjectOutput{}, nil
+	}
+
+	active := dt.projectPath(ss)
+	byPath := map[string]*ProjectEntry{active: {Path: active, Sessions: []string{}, Active: true}}
+	sessions, _ := result["sessions"].([]interface{})
+	for _, s := range sessions {
+		sm, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := getString(sm, "project_path")
+		if path == "" {
+			continue
+		}
+		entry, ok := byPath[path]
+		if !ok {
+			entry = &ProjectEntry{Path: path, Sessions: []string{}}
+			byPath[path] = entry
+		}
+		entry.Sessions = append(entry.Sessions, getString(sm, "code"))
+	}
+
+	output := ProjectOutput{Active: active, Session: dt.sessionCodeFor(ss)}
+	for _, entry := range byPath {
+		output.Projects = append(output.Projects, *entry)
+	}
+	sort.Slice(output.Projects, func(i, j int) bool { return output.Projects[i].Path < output.Projects[j].Path })
+	output.Count = len(output.Projects)
+	return nil, output, nil
+}
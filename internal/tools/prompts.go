@@ -0,0 +1,378 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/standardbeagle/agnt/internal/daemon"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+const (
+	// promptOutputTail is how many lines of output a prompt quotes per
+	// process.
+	promptOutputTail = 80
+	// promptMaxProcesses is how many failed processes a prompt covers.
+	promptMaxProcesses = 3
+	// promptMaxRoutes is how many of the slowest routes a prompt lists.
+	promptMaxRoutes = 10
+	// promptMaxEvents is how many recent daemon events a prompt lists.
+	promptMaxEvents = 10
+)
+
+// RegisterPrompts registers the MCP prompts that gather daemon data for a
+// common task into one message for the model.
+func RegisterPrompts(server *mcp.Server, dt *DaemonTools) {
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "diagnose-failing-build",
+		Title:       "Diagnose a failing build",
+		Description: "Exit codes, crash reports and output tails of the project's failed processes, with the task of finding the cause",
+		Arguments: []*mcp.PromptArgument{{
+			Name:        "process_id",
+			Description: "Process to diagnose (default: the project's failed processes)",
+		}},
+	}, dt.diagnoseFailingBuildPrompt)
+
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "investigate-frontend-error",
+		Title:       "Investigate a frontend error",
+		Description: "The ranked frontend errors a proxy captured, with what led up to them and the pages that hit them, with the task of explaining the cause",
+		Arguments: []*mcp.PromptArgument{{
+			Name:        "proxy_id",
+			Description: "Proxy to investigate (default: the project's running proxy)",
+		}},
+	}, dt.investigateFrontendErrorPrompt)
+
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "performance-triage",
+		Title:       "Performance triage",
+		Description: "The slowest routes through a proxy, page load times and the resource use of the project's processes, with the task of finding what to speed up",
+		Arguments: []*mcp.PromptArgument{{
+			Name:        "proxy_id",
+			Description: "Proxy to triage (default: the project's running proxy)",
+		}},
+	}, dt.performanceTriagePrompt)
+}
+
+// promptResult wraps the assembled text as the single user message of a
+// prompt.
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{{
+			Role:    "user",
+			Content: &mcp.TextContent{Text: text},
+		}},
+	}
+}
+
+// promptArg returns a prompt argument, or "" when not given.
+func promptArg(req *mcp.GetPromptRequest, name string) string {
+	if req.Params == nil {
+		return ""
+	}
+	return strings.TrimSpace(req.Params.Arguments[name])
+}
+
+// diagnoseFailingBuildPrompt gathers the failed processes of the project.
+func (dt *DaemonTools) diagnoseFailingBuildPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if id := promptArg(req, "process_id"); id != "" {
+		ids = []string{id}
+	} else {
+		var err error
+		if ids, err = dt.failedProcessIDs(req.Session); err != nil {
+			return nil, err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Diagnose a failing build\n\n")
+	b.WriteString("Find the root cause of the failure below and propose a fix. Quote the output lines you rely on, name the files to change, and say how to confirm the fix (which process to restart with the proc tool).\n")
+
+	if len(ids) == 0 {
+		b.WriteString("\nNo process of this project has failed or exited with a non-zero code. Ask which process to look at, or list them with the proc tool.\n")
+	}
+	for _, id := range ids {
+		status, err := dt.client.ProcStatus(id)
+		if err != nil {
+			return nil, fmt.Errorf("process %s: %w", id, err)
+		}
+		output, err := dt.client.ProcOutput(id, protocol.OutputFilter{Tail: promptOutputTail})
+		if err != nil {
+			return nil, fmt.Errorf("process %s output: %w", id, err)
+		}
+		b.WriteString("\n")
+		writeProcessSection(&b, id, status, output)
+	}
+
+	events, err := dt.recentEvents(req.Session, []string{daemon.EventProcessExited})
+	if err != nil {
+		return nil, err
+	}
+	writeEventsSection(&b, "Recent process exits", events)
+
+	return promptResult("Failed processes with their output", b.String()), nil
+}
+
+// failedProcessIDs returns the project's processes that failed or exited
+// with a non-zero code, failed ones first.
+func (dt *DaemonTools) failedProcessIDs(ss *mcp.ServerSession) ([]string, error) {
+	result, err := dt.client.ProcListFiltered(protocol.ListFilter{DirectoryFilter: dt.directoryFilter(ss)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %v", err)
+	}
+	processes, _ := result["processes"].([]interface{})
+
+	var failed, stopped []string
+	for _, p := range processes {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch getString(pm, "state") {
+		case "failed":
+			failed = append(failed, getString(pm, "id"))
+		case "stopped":
+			stopped = append(stopped, getString(pm, "id"))
+		}
+	}
+	for _, id := range stopped {
+		status, err := dt.client.ProcStatus(id)
+		if err == nil && getInt(status, "exit_code") != 0 {
+			failed = append(failed, id)
+		}
+	}
+	if len(failed) > promptMaxProcesses {
+		failed = failed[:promptMaxProcesses]
+	}
+	return failed, nil
+}
+
+// directoryFilter scopes list commands to the session, or else the project.
+func (dt *DaemonTools) directoryFilter(ss *mcp.ServerSession) protocol.DirectoryFilter {
+	if sessionCode := dt.sessionCodeFor(ss); sessionCode != "" {
+		return protocol.DirectoryFilter{SessionCode: sessionCode}
+	}
+	return protocol.DirectoryFilter{Directory: dt.projectPath(ss)}
+}
+
+// writeProcessSection writes the state, crash report and output tail of a
+// process.
+func writeProcessSection(b *strings.Builder, id string, status map[string]interface{}, output string) {
+	fmt.Fprintf(b, "## Process %s\n\n", id)
+	command := strings.TrimSpace(getString(status, "command") + " " + strings.Join(getStringSlice(status, "args"), " "))
+	fmt.Fprintf(b, "- Command: `%s`\n", command)
+	fmt.Fprintf(b, "- State: %s", getString(status, "state"))
+	if _, ok := status["exit_code"]; ok {
+		fmt.Fprintf(b, ", exit code %d", getInt(status, "exit_code"))
+	}
+	if runtime := getString(status, "runtime"); runtime != "" {
+		fmt.Fprintf(b, " after %s", runtime)
+	}
+	b.WriteString("\n")
+	if crash, ok := status["crash"].(map[string]interface{}); ok {
+		fmt.Fprintf(b, "- Crash: %s", getString(crash, "kind"))
+		if msg := getString(crash, "message"); msg != "" {
+			fmt.Fprintf(b, ": %s", msg)
+		}
+		fmt.Fprintf(b, " (report %s)\n", getString(crash, "id"))
+	}
+
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		b.WriteString("\nThe process wrote no output.\n")
+		return
+	}
+	fmt.Fprintf(b, "\nOutput (last %d lines):\n\n```\n%s\n```\n", promptOutputTail, output)
+}
+
+// investigateFrontendErrorPrompt gathers the frontend errors of a proxy.
+func (dt *DaemonTools) investigateFrontendErrorPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+	proxyID, err := dt.promptProxyID(req)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := dt.investigate(ctx, req.Session, proxyID, "", investigateDefaultWindow, investigateDefaultLimit, true)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", proxyID, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Investigate a frontend error\n\n")
+	b.WriteString("Explain what causes the errors below, most frequent first. Use the requests and interactions that led up to each to find the trigger, point at the source file and line to change, and propose a fix. Check it afterwards with the proxylog tool.\n\n")
+	b.WriteString(report.Report)
+	if !strings.HasSuffix(report.Report, "\n") {
+		b.WriteString("\n")
+	}
+
+	pages, err := dt.client.CurrentPageList(proxyID)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s pages: %w", proxyID, err)
+	}
+	var failing []proxy.PageSessionSummary
+	for _, page := range decodePageSessions(pages) {
+		if page.ErrorCount > 0 {
+			failing = append(failing, page)
+		}
+	}
+	if len(failing) > 0 {
+		b.WriteString("\n## Pages with errors\n\n")
+		for _, page := range failing {
+			fmt.Fprintf(&b, "- %s: %d error(s)", page.URL, page.ErrorCount)
+			if page.PageTitle != "" {
+				fmt.Fprintf(&b, " (%s)", page.PageTitle)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return promptResult(fmt.Sprintf("Frontend errors captured by proxy %s", proxyID), b.String()), nil
+}
+
+// performanceTriagePrompt gathers the timing data of a proxy and the
+// resource use of the project's processes.
+func (dt *DaemonTools) performanceTriagePrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	if err := dt.ensureConnected(); err != nil {
+		return nil, err
+	}
+	proxyID, err := dt.promptProxyID(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := dt.client.ProxyLogRouteStats(proxyID, proxy.RouteStatsFilter{SortBy: "p95", Limit: promptMaxRoutes})
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", proxyID, err)
+	}
+	var routes []proxy.RouteStats
+	if raw, ok := result["routes"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &routes)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Performance triage\n\n")
+	b.WriteString("Find what makes this app slow, from the data below. Rank the problems by their effect on users, say for each whether the server, the network or the page is the cause, and propose the first change to make. Measure again with the proxylog tool's stats action afterwards.\n")
+	writeRoutesSection(&b, routes)
+
+	pages, err := dt.client.CurrentPageList(proxyID)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s pages: %w", proxyID, err)
+	}
+	var loaded []proxy.PageSessionSummary
+	for _, page := range decodePageSessions(pages) {
+		if page.LoadTimeMs > 0 {
+			loaded = append(loaded, page)
+		}
+	}
+	if len(loaded) > 0 {
+		b.WriteString("\n## Page loads\n\n")
+		for _, page := range loaded {
+			fmt.Fprintf(&b, "- %s: %dms\n", page.URL, page.LoadTimeMs)
+		}
+	}
+
+	processes, err := dt.client.ProcListFiltered(protocol.ListFilter{DirectoryFilter: dt.directoryFilter(req.Session)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %v", err)
+	}
+	var usage []string
+	list, _ := processes["processes"].([]interface{})
+	for _, p := range list {
+		pm, ok := p.(map[string]interface{})
+		if !ok || getString(pm, "state") != "running" {
+			continue
+		}
+		status, err := dt.client.ProcStatus(getString(pm, "id"))
+		if err != nil {
+			continue
+		}
+		if m, ok := status["metrics"].(map[string]interface{}); ok {
+			usage = append(usage, fmt.Sprintf("- %s: %.0f%% CPU, %s memory", getString(pm, "id"), getFloat64(m, "cpu_percent"), getString(m, "rss")))
+		}
+	}
+	if len(usage) > 0 {
+		b.WriteString("\n## Process resource use\n\n")
+		b.WriteString(strings.Join(usage, "\n"))
+		b.WriteString("\n")
+	}
+
+	return promptResult(fmt.Sprintf("Timing data of proxy %s", proxyID), b.String()), nil
+}
+
+// writeRoutesSection writes the routes as a table, slowest first.
+func writeRoutesSection(b *strings.Builder, routes []proxy.RouteStats) {
+	b.WriteString("\n## Slowest routes (by p95)\n\n")
+	if len(routes) == 0 {
+		b.WriteString("The proxy has logged no requests yet. Load the app through it first.\n")
+		return
+	}
+	b.WriteString("| Route | Requests | Errors | p50 | p95 | p99 | Max |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, r := range routes {
+		fmt.Fprintf(b, "| %s %s | %d | %d | %.0fms | %.0fms | %.0fms | %.0fms |\n",
+			r.Method, r.Route, r.Count, r.Errors, r.P50Ms, r.P95Ms, r.P99Ms, r.MaxMs)
+	}
+}
+
+// promptProxyID returns the proxy_id argument, or the project's running
+// proxy.
+func (dt *DaemonTools) promptProxyID(req *mcp.GetPromptRequest) (string, error) {
+	if id := promptArg(req, "proxy_id"); id != "" {
+		return id, nil
+	}
+	return dt.defaultProxyID(req.Session)
+}
+
+// decodePageSessions reads the page sessions of a CURRENTPAGE LIST result.
+func decodePageSessions(result map[string]interface{}) []proxy.PageSessionSummary {
+	var pages []proxy.PageSessionSummary
+	if raw, ok := result["sessions"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &pages)
+		}
+	}
+	return pages
+}
+
+// recentEvents returns the project's latest daemon events of the types.
+func (dt *DaemonTools) recentEvents(ss *mcp.ServerSession, types []string) ([]daemon.DaemonEvent, error) {
+	result, err := dt.client.EventsQuery(protocol.EventsFilter{Types: types, Limit: promptMaxEvents, Path: dt.projectPath(ss)})
+	if err != nil {
+		return nil, err
+	}
+	var events []daemon.DaemonEvent
+	if raw, ok := result["events"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &events)
+		}
+	}
+	return events, nil
+}
+
+// writeEventsSection lists events under a heading, or nothing without any.
+func writeEventsSection(b *strings.Builder, heading string, events []daemon.DaemonEvent) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", heading)
+	for _, e := range events {
+		fmt.Fprintf(b, "- %s %s: %s\n", e.Time.Format(time.TimeOnly), e.Source, e.Message)
+	}
+}
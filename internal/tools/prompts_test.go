@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+func TestWriteProcessSection(t *testing.T) {
+	var b strings.Builder
+	writeProcessSection(&b, "build", map[string]interface{}{
+		"command":   "npm",
+		"args":      []interface{}{"run", "build"},
+		"state":     "stopped",
+		"runtime":   "4s",
+		"exit_code": float64(2),
+		"crash":     map[string]interface{}{"id": "c1", "kind": "exit", "message": "Module not found"},
+	}, "compiling\nerror: Module not found\n")
+
+	text := b.String()
+	for _, want := range []string{
+		"## Process build",
+		"Command: `npm run build`",
+		"State: stopped, exit code 2 after 4s",
+		"Crash: exit: Module not found (report c1)",
+		"```\ncompiling\nerror: Module not found\n```",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+
+	b.Reset()
+	writeProcessSection(&b, "dev", map[string]interface{}{"command": "air", "state": "failed"}, "")
+	if text := b.String(); strings.Contains(text, "exit code") || !strings.Contains(text, "wrote no output") {
+		t.Errorf("Unexpected section for a silent process:\n%s", text)
+	}
+}
+
+func TestWriteRoutesSection(t *testing.T) {
+	var b strings.Builder
+	writeRoutesSection(&b, nil)
+	if !strings.Contains(b.String(), "no requests yet") {
+		t.Errorf("Expected a note without routes, got:\n%s", b.String())
+	}
+
+	b.Reset()
+	writeRoutesSection(&b, []proxy.RouteStats{{Method: "GET", Route: "/api/users/:id", Count: 12, Errors: 1, P50Ms: 40, P95Ms: 950.4, P99Ms: 1200, MaxMs: 1300}})
+	if want := "| GET /api/users/:id | 12 | 1 | 40ms | 950ms | 1200ms | 1300ms |"; !strings.Contains(b.String(), want) {
+		t.Errorf("Expected row %q in:\n%s", want, b.String())
+	}
+}
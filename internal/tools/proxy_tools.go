@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/standardbeagle/agnt/internal/protocol"
 	"github.com/standardbeagle/agnt/internal/proxy"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -13,22 +14,40 @@ import (
 
 // ProxyInput defines input for the proxy tool.
 type ProxyInput struct {
-	Action        string `json:"action" jsonschema:"Action: start, stop, status, list, exec, toast, chaos"`
-	ID            string `json:"id,omitempty" jsonschema:"Proxy ID (required for start/stop/status/exec/toast/chaos)"`
-	TargetURL     string `json:"target_url,omitempty" jsonschema:"Target URL to proxy (required for start)"`
-	Port          int    `json:"port,omitempty" jsonschema:"Listen port (default: stable hash of target URL). Only specify if you need a specific port."`
-	MaxLogSize    int    `json:"max_log_size,omitempty" jsonschema:"Maximum log entries (default: 1000)"`
-	BindAddress   string `json:"bind_address,omitempty" jsonschema:"Bind address: '127.0.0.1' (default, localhost only) or '0.0.0.0' (all interfaces for tunnel/mobile testing)"`
-	PublicURL     string `json:"public_url,omitempty" jsonschema:"Public URL for tunnel services (e.g. 'https://abc123.trycloudflare.com'). Used for URL rewriting when behind a tunnel."`
-	VerifyTLS     bool   `json:"verify_tls,omitempty" jsonschema:"Verify TLS certificates (default: false, accepts self-signed/expired certs for dev). Set to true for strict validation."`
-	Code          string `json:"code,omitempty" jsonschema:"JavaScript code to execute (required for exec)"`
-	Global        bool   `json:"global,omitempty" jsonschema:"For list: include proxies from all directories (default: false)"`
-	Help          bool   `json:"help,omitempty" jsonschema:"For exec: show __devtool API overview instead of executing code"`
-	Describe      string `json:"describe,omitempty" jsonschema:"For exec: show detailed docs for a specific function (e.g. 'screenshot', 'interactions.getLastClick')"`
-	ToastType     string `json:"toast_type,omitempty" jsonschema:"For toast: notification type (success, error, warning, info). Default: info"`
-	ToastTitle    string `json:"toast_title,omitempty" jsonschema:"For toast: notification title (optional)"`
-	ToastMessage  string `json:"toast_message,omitempty" jsonschema:"For toast: notification message (required for toast)"`
-	ToastDuration int    `json:"toast_duration,omitempty" jsonschema:"For toast: duration in milliseconds (0 for default)"`
+	Action         string                   `json:"action" jsonschema:"Action: start, stop, status, list, exec, toast, chaos, mock, label, routes, ui, intercept"`
+	ID             string                   `json:"id,omitempty" jsonschema:"Proxy ID (required for start/stop/status/exec/toast/chaos/mock/label/routes/ui/intercept)"`
+	TargetURL      string                   `json:"target_url,omitempty" jsonschema:"Target URL to proxy (required for start)"`
+	Port           int                      `json:"port,omitempty" jsonschema:"Listen port (default: stable hash of target URL). Only specify if you need a specific port."`
+	MaxLogSize     int                      `json:"max_log_size,omitempty" jsonschema:"Maximum log entries (default: 1000)"`
+	BindAddress    string                   `json:"bind_address,omitempty" jsonschema:"Bind address: '127.0.0.1' (default, localhost only) or '0.0.0.0' (all interfaces for tunnel/mobile testing)"`
+	PublicURL      string                   `json:"public_url,omitempty" jsonschema:"Public URL for tunnel services (e.g. 'https://abc123.trycloudflare.com'). Used for URL rewriting when behind a tunnel."`
+	VerifyTLS      bool                     `json:"verify_tls,omitempty" jsonschema:"Verify TLS certificates (default: false, accepts self-signed/expired certs for dev). Set to true for strict validation."`
+	Encrypt        bool                     `json:"encrypt,omitempty" jsonschema:"Encrypt instrumentation payloads (screenshots, DOM data, interactions) between the page and agnt, independent of tunnel TLS. Requires a secure context (https or localhost)."`
+	NoRetarget     bool                     `json:"no_retarget,omitempty" jsonschema:"Keep the target port when the dev server restarts on a different one (default: the proxy follows it)"`
+	Routes         []proxy.HostRoute        `json:"routes,omitempty" jsonschema:"Host-based routes for apps using subdomains: each {host, target, host_header} sends matching hosts (e.g. '*.localhost', 'auth.localhost') to their own target URL or port"`
+	PathRoutes     []proxy.PathRoute        `json:"path_routes,omitempty" jsonschema:"Path-based routes: each {path, target, strip_prefix} sends requests under a path prefix (e.g. '/api') to their own target URL or port; the longest prefix wins"`
+	Cookies        *proxy.CookieRewrite     `json:"cookies,omitempty" jsonschema:"Set-Cookie rewriting: {domains: {upstream: replacement or empty to remove}, secure: auto|keep, same_site: auto|keep|lax|strict|none, disabled}. Default auto drops Secure for http access and fixes SameSite=None"`
+	URLRewrite     *proxy.URLRewrite        `json:"url_rewrite,omitempty" jsonschema:"Upstream URL rewriting: {exclude: path prefixes like '/oauth/callback' or URL prefixes never rewritten, no_body: only rewrite Location headers, not HTML links}"`
+	Storms         *proxy.StormDetection    `json:"storms,omitempty" jsonschema:"Request storm detection: {threshold: requests to one endpoint within the window (default 20), window_ms (default 5000), toast: warn in the page, disabled}"`
+	Banner         *proxy.EnvironmentBanner `json:"banner,omitempty" jsonschema:"Environment banner on proxied pages: {enabled, label (default: proxy ID), position: top|bottom, color, no_branch}. Shows the git branch and warns while chaos is active or the proxy is exposed"`
+	UI             *proxy.OverlayUI         `json:"ui,omitempty" jsonschema:"For start and ui: overlay language and theme: {language: en|de|es|fr|ja|pt (pt-BR falls back to pt), messages: {key: text} overriding strings, theme: auto|light|dark, position: bottom-left|bottom-right|top-left|top-right, accent: CSS color, minimal, reduced_motion: no animations, hidden: no overlay chrome while capture keeps running}. The ui action without it reports the current options and the message keys"`
+	BodyCapture    *proxy.BodyCapture       `json:"body_capture,omitempty" jsonschema:"What the traffic log keeps: {max_request_bytes, max_response_bytes (default 10240 each), content_types: media type prefixes captured (default text, JSON, XML, form, JS, GraphQL), redact_headers: masked on top of Authorization/Cookie/Set-Cookie, no_redact, disabled}"`
+	PersistLogs    *proxy.LogPersistence    `json:"persist_logs,omitempty" jsonschema:"Keep the traffic log on disk across daemon restarts: {enabled, dir (default .agnt/logs/<id>), segment_bytes (default 4MB), max_segments (default 8), max_age_hours}. proxylog query reads the older entries back from disk"`
+	TrustedProxies []string                 `json:"trusted_proxies,omitempty" jsonschema:"IPs/CIDRs whose X-Forwarded-* and CF-Connecting-IP headers are trusted for the real client IP and protocol (default: loopback, where tunnel agents connect from; 'none' trusts no proxy)"`
+	Code           string                   `json:"code,omitempty" jsonschema:"JavaScript code to execute (required for exec)"`
+	Global         bool                     `json:"global,omitempty" jsonschema:"For list: include proxies from all directories (default: false)"`
+	Labels         map[string]string        `json:"labels,omitempty" jsonschema:"For start and label: labels to set (e.g. {area: checkout}); for list: only proxies with all these labels (an empty value matches any)"`
+	RemoveLabels   []string                 `json:"remove_labels,omitempty" jsonschema:"For label: label keys to remove"`
+	Cascade        bool                     `json:"cascade,omitempty" jsonschema:"For stop: also stop the tunnels that front the proxy"`
+	Help           bool                     `json:"help,omitempty" jsonschema:"For exec: show __devtool API overview instead of executing code"`
+	Describe       string                   `json:"describe,omitempty" jsonschema:"For exec: show detailed docs for a specific function (e.g. 'screenshot', 'interactions.getLastClick')"`
+	ToastType      string                   `json:"toast_type,omitempty" jsonschema:"For toast: notification type (success, error, warning, info). Default: info"`
+	ToastTitle     string                   `json:"toast_title,omitempty" jsonschema:"For toast: notification title (optional)"`
+	ToastMessage   string                   `json:"toast_message,omitempty" jsonschema:"For toast: notification message (required for toast)"`
+	ToastDuration  int                      `json:"toast_duration,omitempty" jsonschema:"For toast: duration in milliseconds (0 for default)"`
+	ToastActions   []protocol.ToastAction   `json:"toast_actions,omitempty" jsonschema:"For toast: up to 3 buttons ({label, callback}). The clicked callback is typed into the session (toast_session, default: the project's session) or, with toast_wait_ms, returned"`
+	ToastSession   string                   `json:"toast_session,omitempty" jsonschema:"For toast with actions: session code the click is typed into"`
+	ToastWaitMs    int                      `json:"toast_wait_ms,omitempty" jsonschema:"For toast with actions: wait up to this long (max 300000) for a click and return it as toast_action"`
 	// Tunnel configuration (for start action)
 	Tunnel        string   `json:"tunnel,omitempty" jsonschema:"Tunnel provider: ngrok, cloudflared, tailscale, or custom. Creates public URL for the proxy."`
 	TunnelArgs    []string `json:"tunnel_args,omitempty" jsonschema:"Additional arguments for tunnel command"`
@@ -37,24 +56,55 @@ type ProxyInput struct {
 	TunnelCommand string   `json:"tunnel_command,omitempty" jsonschema:"Custom tunnel command (when tunnel is 'custom'). Use {{PORT}} as placeholder."`
 
 	// Chaos-related fields
-	ChaosOperation string            `json:"chaos_operation,omitempty" jsonschema:"For chaos: enable, disable, status, set, preset, add_rule, remove_rule, list_rules, stats, clear"`
-	ChaosPreset    string            `json:"chaos_preset,omitempty" jsonschema:"For chaos preset: mobile-3g, mobile-4g, flaky-api, race-condition, stale-tab, slow-connection, connection-drops, etc."`
-	ChaosRules     []ChaosRuleInput  `json:"chaos_rules,omitempty" jsonschema:"For chaos set: array of chaos rules to configure"`
-	ChaosRule      *ChaosRuleInput   `json:"chaos_rule,omitempty" jsonschema:"For chaos add_rule: single rule to add"`
-	ChaosRuleID    string            `json:"chaos_rule_id,omitempty" jsonschema:"For chaos remove_rule: ID of rule to remove"`
-	ChaosConfig    *ChaosConfigInput `json:"chaos_config,omitempty" jsonschema:"For chaos set: full chaos configuration"`
+	ChaosOperation string              `json:"chaos_operation,omitempty" jsonschema:"For chaos: enable, disable, status, set, preset, add_rule, remove_rule, list_rules, stats, preview (dry run against logged traffic), clear, scenario_start, scenario_stop, scenario_status"`
+	ChaosPreset    string              `json:"chaos_preset,omitempty" jsonschema:"For chaos preset and preview: mobile-3g, mobile-4g, slow-3g, connection-resets, flaky-api, race-condition, stale-tab, slow-connection, connection-drops, etc."`
+	ChaosSessions  []string            `json:"chaos_sessions,omitempty" jsonschema:"For chaos preset: limit the preset's rules to these page session IDs (page-N, from currentpage) or browser session IDs, leaving other tabs and teammates unaffected"`
+	ChaosRules     []ChaosRuleInput    `json:"chaos_rules,omitempty" jsonschema:"For chaos set: array of chaos rules to configure"`
+	ChaosRule      *ChaosRuleInput     `json:"chaos_rule,omitempty" jsonschema:"For chaos add_rule: single rule to add"`
+	ChaosRuleID    string              `json:"chaos_rule_id,omitempty" jsonschema:"For chaos remove_rule: ID of rule to remove"`
+	ChaosConfig    *ChaosConfigInput   `json:"chaos_config,omitempty" jsonschema:"For chaos set and preview: full chaos configuration"`
+	ChaosScenario  *ChaosScenarioInput `json:"chaos_scenario,omitempty" jsonschema:"For chaos scenario_start: named phases stepped through in order, each with duration_ms and rules or a preset; a phase with neither recovers"`
+	DryRun         bool                `json:"dry_run,omitempty" jsonschema:"For chaos preset, set and clear: report the rules that would be replaced, and the logged requests the new rules would hit, without changing anything"`
+
+	// Mock-related fields
+	MockOperation string          `json:"mock_operation,omitempty" jsonschema:"For mock: add, remove, list (default), clear"`
+	MockRule      *proxy.MockRule `json:"mock_rule,omitempty" jsonschema:"For mock add: {id, methods (empty = all), url_pattern (regex on path and query), status (default 200), headers, body, delay_ms}. Matching requests get this response without reaching the target; an existing id is replaced"`
+	MockRuleID    string          `json:"mock_rule_id,omitempty" jsonschema:"For mock remove: ID of rule to remove"`
+
+	// Path route fields
+	RoutesOperation string           `json:"routes_operation,omitempty" jsonschema:"For routes: add, remove, list (default)"`
+	PathRoute       *proxy.PathRoute `json:"path_route,omitempty" jsonschema:"For routes add: {path, target, strip_prefix}; replaces the route for the same path"`
+	RoutePath       string           `json:"route_path,omitempty" jsonschema:"For routes remove: path of the route to remove"`
+
+	// Intercept fields
+	InterceptOperation string               `json:"intercept_operation,omitempty" jsonschema:"For intercept: add, remove, list (default), modify, resume, reject, clear"`
+	InterceptRule      *proxy.InterceptRule `json:"intercept_rule,omitempty" jsonschema:"For intercept add: {id (generated when empty), methods (empty = all), url_pattern (regex on path and query), timeout_ms (default 60000, max 600000)}. Matching requests pause until resumed or rejected and continue on their own after the timeout"`
+	InterceptRuleID    string               `json:"intercept_rule_id,omitempty" jsonschema:"For intercept remove: ID of the rule to remove; requests it paused continue"`
+	InterceptRequestID string               `json:"intercept_request_id,omitempty" jsonschema:"For intercept modify, resume and reject: ID of the paused request (e.g. int-1)"`
+	InterceptEdit      *proxy.InterceptEdit `json:"intercept_edit,omitempty" jsonschema:"For intercept modify and resume: {method, url (path and query), set_headers, remove_headers, body (replaces the whole body)}"`
+	RejectStatus       int                  `json:"reject_status,omitempty" jsonschema:"For intercept reject: response status (default 403)"`
+	RejectBody         string               `json:"reject_body,omitempty" jsonschema:"For intercept reject: response body"`
+	WaitMs             int                  `json:"wait_ms,omitempty" jsonschema:"For intercept list: wait up to this many ms for a request to pause (max 300000)"`
 }
 
 // ChaosRuleInput defines input for a single chaos rule.
 type ChaosRuleInput struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name,omitempty"`
-	Type        string   `json:"type"` // latency, out_of_order, slow_drip, disconnect, http_error, truncate, etc.
+	Type        string   `json:"type"` // latency, bandwidth_limit, out_of_order, slow_drip, disconnect, connection_reset, dns_failure, http_error, truncate, etc.
 	Enabled     bool     `json:"enabled"`
 	URLPattern  string   `json:"url_pattern,omitempty"`
 	Methods     []string `json:"methods,omitempty"`
 	Probability float64  `json:"probability,omitempty"` // 0.0-1.0, default 1.0
 
+	// Page or browser sessions the rule is limited to
+	Sessions []string `json:"sessions,omitempty" jsonschema:"Limit the rule to these page session IDs (page-N, from currentpage) or browser session IDs (__devtool_sid cookie); empty affects everyone using the proxy"`
+
+	// Field matchers; every one must match
+	Headers    []protocol.ChaosMatcherConfig `json:"headers,omitempty" jsonschema:"Request headers to match: name and a value regex (empty value: header present)"`
+	Query      []protocol.ChaosMatcherConfig `json:"query,omitempty" jsonschema:"Query parameters to match: name and a value regex"`
+	BodyFields []protocol.ChaosMatcherConfig `json:"body_fields,omitempty" jsonschema:"JSON request body fields to match: dotted path (e.g. operationName or variables.tenant) and a value regex"`
+
 	// Latency config
 	MinLatencyMs int `json:"min_latency_ms,omitempty"`
 	MaxLatencyMs int `json:"max_latency_ms,omitempty"`
@@ -64,10 +114,16 @@ type ChaosRuleInput struct {
 	BytesPerMs int `json:"bytes_per_ms,omitempty"`
 	ChunkSize  int `json:"chunk_size,omitempty"`
 
-	// Connection drop config
+	// Bandwidth limit config
+	BandwidthKbps int `json:"bandwidth_kbps,omitempty"`
+
+	// Connection drop and reset config
 	DropAfterPercent float64 `json:"drop_after_percent,omitempty"`
 	DropAfterBytes   int64   `json:"drop_after_bytes,omitempty"`
 
+	// DNS failure config
+	Hosts []string `json:"hosts,omitempty"`
+
 	// Error injection config
 	ErrorCodes   []int  `json:"error_codes,omitempty"`
 	ErrorMessage string `json:"error_message,omitempty"`
@@ -92,14 +148,44 @@ type ChaosConfigInput struct {
 	LoggingMode int              `json:"logging_mode,omitempty"` // 0=silent, 1=testing, 2=coordinated
 }
 
+// ChaosScenarioInput defines input for a timed chaos scenario.
+type ChaosScenarioInput struct {
+	Name   string            `json:"name"`
+	Phases []ChaosPhaseInput `json:"phases"`
+	Loop   bool              `json:"loop,omitempty"` // Start over after the last phase
+}
+
+// ChaosPhaseInput defines one phase of a chaos scenario.
+type ChaosPhaseInput struct {
+	Name       string           `json:"name,omitempty"`
+	DurationMs int64            `json:"duration_ms"`      // 0 on the last phase holds it until scenario_stop
+	Preset     string           `json:"preset,omitempty"` // Use a preset's rules
+	Rules      []ChaosRuleInput `json:"rules,omitempty"`
+}
+
 // CurrentPageInput defines input for the currentpage tool.
 type CurrentPageInput struct {
 	ProxyID   string   `json:"proxy_id" jsonschema:"Proxy ID to query pages from"`
-	Action    string   `json:"action,omitempty" jsonschema:"Action: list, get, summary, clear (default: list)"`
-	SessionID string   `json:"session_id,omitempty" jsonschema:"Specific session ID (required for get/summary action)"`
+	Action    string   `json:"action,omitempty" jsonschema:"Action: list, get, summary, clear, wait_idle, snapshot, snapshots, diff, screenshot, screenshots, coverage (default: list)"`
+	SessionID string   `json:"session_id,omitempty" jsonschema:"Specific session ID (required for get/summary action; for wait_idle, omit to wait for all pages; for snapshot, screenshot and coverage, omit to use whichever page answers first)"`
 	Detail    []string `json:"detail,omitempty" jsonschema:"For summary: sections to include full detail for (interactions, mutations, errors, resources)"`
-	Limit     int      `json:"limit,omitempty" jsonschema:"For summary: max items per detailed section (default: 5, max: 100)"`
+	Limit     int      `json:"limit,omitempty" jsonschema:"For summary: max items per detailed section (default: 5, max: 100); for coverage: unused rules listed per stylesheet (default: 20, max: 200)"`
 	Raw       bool     `json:"raw,omitempty" jsonschema:"For get: return full arrays with all details instead of compact format (default: false)"`
+	// For wait_idle
+	QuietMs     int `json:"quiet_ms,omitempty" jsonschema:"For wait_idle: how long network and DOM must stay quiet (default: 1500)"`
+	TimeoutMs   int `json:"timeout_ms,omitempty" jsonschema:"For wait_idle: maximum wait (default: 10000, max: 25000)"`
+	MaxInflight int `json:"max_inflight,omitempty" jsonschema:"For wait_idle: requests allowed to stay open, e.g. a long poll (default: 0)"`
+	// For snapshot and diff
+	Name     string `json:"name,omitempty" jsonschema:"For snapshot: label for the snapshot; for screenshot: part of the file name (default: capture time)"`
+	Selector string `json:"selector,omitempty" jsonschema:"For snapshot: root element to serialize (default: body); for screenshot: element to crop to (default: whole page)"`
+	Styles   bool   `json:"styles,omitempty" jsonschema:"For snapshot: include key computed styles of every element"`
+	From     string `json:"from,omitempty" jsonschema:"For diff: earlier snapshot ID"`
+	To       string `json:"to,omitempty" jsonschema:"For diff: later snapshot ID"`
+	// For screenshot
+	Viewport      bool   `json:"viewport,omitempty" jsonschema:"For screenshot: capture only the visible viewport instead of the full page"`
+	Annotate      bool   `json:"annotate,omitempty" jsonschema:"For screenshot: outline the targets of the page's recent interactions, numbered newest first"`
+	AnnotateLimit int    `json:"annotate_limit,omitempty" jsonschema:"For screenshot: interactions to outline (default: 5, max: 20)"`
+	Format        string `json:"format,omitempty" jsonschema:"For screenshot: png (default) or jpeg"`
 }
 
 // CurrentPageOutput defines output for currentpage tool.
@@ -114,6 +200,21 @@ type CurrentPageOutput struct {
 	// For summary
 	Summary *PageSummaryOutput `json:"summary,omitempty"`
 
+	// For wait_idle
+	Idle *proxy.IdleResult `json:"idle,omitempty"`
+
+	// For snapshot, snapshots and diff
+	Snapshot  *proxy.DOMSnapshot  `json:"snapshot,omitempty"`
+	Snapshots []proxy.DOMSnapshot `json:"snapshots,omitempty"`
+	Diff      *proxy.DOMDiff      `json:"diff,omitempty"`
+
+	// For screenshot and screenshots
+	Screenshot  *proxy.PageScreenshot  `json:"screenshot,omitempty"`
+	Screenshots []proxy.ScreenshotFile `json:"screenshots,omitempty"`
+
+	// For coverage
+	CSSCoverage *proxy.CSSCoverageReport `json:"css_coverage,omitempty"`
+
 	// For clear
 	Success bool   `json:"success,omitempty"`
 	Message string `json:"message,omitempty"`
@@ -145,6 +246,10 @@ type PageSummaryOutput struct {
 	FirstPaintMs     int64 `json:"first_paint_ms,omitempty"`
 	DOMContentLoaded int64 `json:"dom_content_loaded_ms,omitempty"`
 
+	// Core Web Vitals of the most recent page load, rated good,
+	// needs-improvement or poor
+	WebVitals *proxy.WebVitals `json:"web_vitals,omitempty"`
+
 	// Interaction summary
 	InteractionCount   int                      `json:"interaction_count"`
 	InteractionsByType map[string]int           `json:"interactions_by_type,omitempty"` // e.g., {"click": 5, "scroll": 10}
@@ -212,6 +317,11 @@ type ProxyLogSummary struct {
 	Performance       []CompactPerformance `json:"performance,omitempty"`        // Full list when detail includes "performance"
 	RecentPerformance []CompactPerformance `json:"recent_performance,omitempty"` // Last 5 (when detail not specified)
 
+	// Console summary
+	Console        *proxy.ConsoleSummary `json:"console,omitempty"`
+	ConsoleEntries []CompactConsole      `json:"console_entries,omitempty"` // Full list when detail includes "console"
+	RecentConsole  []CompactConsole      `json:"recent_console,omitempty"`  // Last 5 (when detail not specified)
+
 	// Interaction summary
 	InteractionCount   int                  `json:"interaction_count"`
 	InteractionsByType map[string]int       `json:"interactions_by_type,omitempty"` // e.g., {"click": 50, "scroll": 100}
@@ -274,6 +384,41 @@ type CompactMutation struct {
 	Timestamp time.Time `json:"timestamp,omitempty"`
 }
 
+// CompactConsole represents a compact console message.
+type CompactConsole struct {
+	Level     string    `json:"level"`
+	Message   string    `json:"message"` // Truncated to 500 chars
+	Source    string    `json:"source,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// summarizeConsole fills the console section of a summary: the aggregate,
+// plus the last 5 messages or, with detail, the last limit messages.
+func summarizeConsole(summary *ProxyLogSummary, entries []proxy.ConsoleEntry, detail bool, limit int) {
+	if len(entries) == 0 {
+		return
+	}
+	console := proxy.SummarizeConsole(entries)
+	summary.Console = &console
+	if !detail {
+		limit = 5
+	}
+	var compact []CompactConsole
+	for _, e := range entries[maxInt(0, len(entries)-limit):] {
+		message := e.Message
+		if len(message) > 500 {
+			message = message[:497] + "..."
+		}
+		compact = append(compact, CompactConsole{Level: e.Level, Message: message, Source: e.Source, URL: e.URL, Timestamp: e.Timestamp})
+	}
+	if detail {
+		summary.ConsoleEntries = compact
+	} else {
+		summary.RecentConsole = compact
+	}
+}
+
 // CompactLogEntry represents a compact log entry for other types.
 type CompactLogEntry struct {
 	Type      string    `json:"type"`
@@ -315,12 +460,23 @@ type ProxyOutput struct {
 	PublicURL   string `json:"public_url,omitempty"`
 	TunnelURL   string `json:"tunnel_url,omitempty"` // Public tunnel URL if tunnel is configured
 
+	// For start and label
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// For status
-	Running       bool            `json:"running,omitempty"`
-	Uptime        string          `json:"uptime,omitempty"`
-	TotalRequests int64           `json:"total_requests,omitempty"`
-	LogStats      *LogStatsOutput `json:"log_stats,omitempty"`
-	Tunnel        *TunnelStatus   `json:"tunnel,omitempty"` // Tunnel status if configured
+	Running        bool                       `json:"running,omitempty"`
+	Uptime         string                     `json:"uptime,omitempty"`
+	TotalRequests  int64                      `json:"total_requests,omitempty"`
+	LogStats       *LogStatsOutput            `json:"log_stats,omitempty"`
+	Tunnel         *TunnelStatus              `json:"tunnel,omitempty"` // Tunnel status if configured
+	Retargets      []ProxyRetarget            `json:"retargets,omitempty"`
+	Routes         []proxy.HostRoute          `json:"routes,omitempty"`
+	PathRoutes     []proxy.PathRoute          `json:"path_routes,omitempty"` // Also for routes
+	CookieRewrites int64                      `json:"cookie_rewrites,omitempty"`
+	CookieChanges  []proxy.CookieRewriteEvent `json:"cookie_changes,omitempty"`
+	URLRewrites    *proxy.URLRewriteStats     `json:"url_rewrites,omitempty"`
+	Traffic        *proxy.TrafficStats        `json:"traffic,omitempty"`
+	RequestStorms  []proxy.RequestStorm       `json:"request_storms,omitempty"`
 
 	// For list
 	Count       int          `json:"count,omitempty"`
@@ -329,16 +485,41 @@ type ProxyOutput struct {
 	SessionCode string       `json:"session_code,omitempty"`
 	Global      bool         `json:"global,omitempty"`
 
+	// For toast with actions
+	ToastID     string            `json:"toast_id,omitempty"`
+	ToastAction *proxy.ToastClick `json:"toast_action,omitempty"` // Clicked action, with toast_wait_ms
+	TimedOut    bool              `json:"timed_out,omitempty"`
+
 	// For stop/exec
 	Success     bool   `json:"success,omitempty"`
 	Message     string `json:"message,omitempty"`
 	ExecutionID string `json:"execution_id,omitempty"` // For exec action
 
 	// For chaos
-	ChaosEnabled bool              `json:"chaos_enabled,omitempty"`
-	ChaosStats   *ChaosStatsOutput `json:"chaos_stats,omitempty"`
-	ChaosRules   []ChaosRuleOutput `json:"chaos_rules,omitempty"`
-	ChaosPresets []string          `json:"chaos_presets,omitempty"`
+	ChaosEnabled  bool                       `json:"chaos_enabled,omitempty"`
+	ChaosStats    *ChaosStatsOutput          `json:"chaos_stats,omitempty"`
+	ChaosRules    []ChaosRuleOutput          `json:"chaos_rules,omitempty"`
+	ChaosPresets  []string                   `json:"chaos_presets,omitempty"`
+	ChaosPreview  *proxy.ChaosPreview        `json:"chaos_preview,omitempty"`
+	ChaosScenario *proxy.ChaosScenarioStatus `json:"chaos_scenario,omitempty"`
+
+	// For dry runs
+	DryRun   bool                      `json:"dry_run,omitempty"`
+	Affected []protocol.AffectedEntity `json:"affected,omitempty"`
+
+	// For mock
+	MockRules []proxy.MockRule `json:"mock_rules,omitempty"`
+
+	// For intercept
+	InterceptRules []proxy.InterceptRule      `json:"intercept_rules,omitempty"`
+	Intercepted    []proxy.InterceptedRequest `json:"intercepted,omitempty"` // Paused requests, oldest first
+
+	// For ui
+	UI            *proxy.OverlayUI      `json:"ui,omitempty"`
+	UIState       *proxy.OverlayUIState `json:"ui_state,omitempty"`
+	UILanguages   []string              `json:"ui_languages,omitempty"`
+	UIMessageKeys []string              `json:"ui_message_keys,omitempty"`
+	PagesUpdated  int                   `json:"pages_updated,omitempty"`
 }
 
 // ChaosStatsOutput holds chaos engine statistics.
@@ -371,6 +552,14 @@ type TunnelStatus struct {
 	URL     string `json:"url,omitempty"`
 }
 
+// ProxyRetarget records the proxy following its dev server to a new port.
+type ProxyRetarget struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	Time   string `json:"time"`
+}
+
 // ProxyEntry represents a proxy in the list.
 type ProxyEntry struct {
 	ID            string `json:"id"`
@@ -384,29 +573,38 @@ type ProxyEntry struct {
 	TotalRequests int64  `json:"total_requests"`
 	TunnelURL     string `json:"tunnel_url,omitempty"`
 	TunnelRunning bool   `json:"tunnel_running,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // LogStatsOutput holds logger statistics.
 type LogStatsOutput struct {
-	TotalEntries     int64 `json:"total_entries"`
-	AvailableEntries int64 `json:"available_entries"`
-	MaxSize          int64 `json:"max_size"`
-	Dropped          int64 `json:"dropped"`
+	TotalEntries     int64              `json:"total_entries"`
+	AvailableEntries int64              `json:"available_entries"`
+	MaxSize          int64              `json:"max_size"`
+	Dropped          int64              `json:"dropped"`
+	Routes           []proxy.RouteStats `json:"routes,omitempty"` // Slowest routes first by default
 }
 
 // ProxyLogInput defines input for the proxylog tool.
 type ProxyLogInput struct {
 	ProxyID     string   `json:"proxy_id" jsonschema:"Proxy ID to query logs from"`
-	Action      string   `json:"action,omitempty" jsonschema:"Action: query, summary, clear, stats (default: query)"`
-	Types       []string `json:"types,omitempty" jsonschema:"Filter by type: http, error, performance"`
+	Action      string   `json:"action,omitempty" jsonschema:"Action: query, summary, clear, stats (log buffer plus per-route counts, error rates, p50/p95/p99 latency and upstream vs proxy time), aggregate (bytes by content type and largest responses), replay (re-issue a logged request) (default: query)"`
+	Types       []string `json:"types,omitempty" jsonschema:"Filter by type: http, error, performance, console, ws_message, sse_event"`
 	Methods     []string `json:"methods,omitempty" jsonschema:"Filter by HTTP method: GET, POST, etc."`
 	URLPattern  string   `json:"url_pattern,omitempty" jsonschema:"URL substring to match"`
 	StatusCodes []int    `json:"status_codes,omitempty" jsonschema:"Filter by HTTP status code"`
 	Since       string   `json:"since,omitempty" jsonschema:"Start time (RFC3339 or duration like '5m')"`
 	Until       string   `json:"until,omitempty" jsonschema:"End time (RFC3339)"`
-	Limit       int      `json:"limit,omitempty" jsonschema:"Maximum results (default: 100)"`
-	Detail      []string `json:"detail,omitempty" jsonschema:"For summary: sections to include full detail for (errors, http, performance, interactions, mutations)"`
+	Limit       int      `json:"limit,omitempty" jsonschema:"Maximum results (default: 100; for stats: routes reported, default 20, -1 for all)"`
+	Detail      []string `json:"detail,omitempty" jsonschema:"For summary: sections to include full detail for (errors, http, performance, console, interactions, mutations)"`
 	Raw         bool     `json:"raw,omitempty" jsonschema:"For query: return full raw data dumps instead of compact format (default: false)"`
+
+	SortBy   string `json:"sort_by,omitempty" jsonschema:"For stats: order routes by p50, p95 (default), p99, count, errors, error_rate or proxy (time spent in the proxy)"`
+	MinCount int64  `json:"min_count,omitempty" jsonschema:"For stats: skip routes with fewer requests"`
+
+	EntryID string                 `json:"entry_id,omitempty" jsonschema:"For replay: ID of the logged HTTP request (e.g. req-42)"`
+	Replay  *proxy.ReplayOverrides `json:"replay,omitempty" jsonschema:"For replay: overrides for the request (method, url, headers, body). Credentials masked in the log are only sent when given here"`
 }
 
 // ProxyLogOutput defines output for proxylog tool.
@@ -421,6 +619,12 @@ type ProxyLogOutput struct {
 	// For stats
 	Stats *LogStatsOutput `json:"stats,omitempty"`
 
+	// For aggregate
+	Traffic *proxy.TrafficStats `json:"traffic,omitempty"`
+
+	// For replay
+	Replay *proxy.ReplayResult `json:"replay,omitempty"`
+
 	// For clear
 	Success bool   `json:"success,omitempty"`
 	Message string `json:"message,omitempty"`
@@ -443,6 +647,11 @@ Actions:
   list: List all active page sessions with summary counts (default)
   get: Get information for a specific session (compact by default)
   clear: Clear all page sessions
+  wait_idle: Wait until a page has no requests in flight and no DOM mutations
+             for quiet_ms (use before screenshots or snapshots)
+  snapshot: Capture and keep a serialized DOM of a page (last 20 kept)
+  snapshots: List kept DOM snapshots
+  diff: Elements added, removed and changed between two snapshots
 
 A page session groups together:
   - The initial HTML document request
@@ -467,6 +676,24 @@ Full Details (raw format):
 Clear Sessions:
   currentpage {proxy_id: "dev", action: "clear"}
 
+Wait for the page to settle:
+  currentpage {proxy_id: "dev", action: "wait_idle", session_id: "page-1"}
+  currentpage {proxy_id: "dev", action: "wait_idle", quiet_ms: 1000, timeout_ms: 20000}
+
+Check a UI change for regressions:
+  currentpage {proxy_id: "dev", action: "snapshot", session_id: "page-1", name: "before", styles: true}
+  currentpage {proxy_id: "dev", action: "snapshot", session_id: "page-1", name: "after", styles: true}
+  currentpage {proxy_id: "dev", action: "diff", from: "dom-1", to: "dom-2"}
+
+Screenshots (stored in .agnt/audit/screenshots, thumbnail returned as an image):
+  currentpage {proxy_id: "dev", action: "screenshot", session_id: "page-1"}
+  currentpage {proxy_id: "dev", action: "screenshot", selector: "#cart", annotate: true}
+  currentpage {proxy_id: "dev", action: "screenshots"}
+
+Unused CSS (rules that matched no element on any page of the tab, by stylesheet):
+  currentpage {proxy_id: "dev", action: "coverage", session_id: "page-1"}
+  currentpage {proxy_id: "dev", action: "coverage", limit: 50}
+
 Tip: For detailed summaries with recent errors/interactions, use proxylog summary instead.
 
 This provides a high-level view of active pages and their resources,
@@ -528,7 +755,11 @@ Actions:
   query: Search logs with filters (default) - returns compact semi-structured format
   summary: Get overview with counts + top errors + recent items (RECOMMENDED for initial analysis)
   clear: Clear all logs for a proxy
-  stats: Get log statistics
+  stats: Get log statistics and the slowest routes (p50/p95/p99, error rate, and
+         average upstream vs proxy time: connect, ttfb, body, inject)
+  replay: Re-issue a logged HTTP request (entry_id) against the target and log
+          the new response, optionally with replay: {method, url, headers, body}
+          overrides. Handy for reproducing intermittent 500s.
 
 Log Types:
   http: HTTP request/response pairs
@@ -612,6 +843,33 @@ func handleProxyStart(ctx context.Context, pm *proxy.ProxyManager, input ProxyIn
 		MaxLogSize:  input.MaxLogSize,
 		AutoRestart: true, // Enable auto-restart for development tool
 		VerifyTLS:   input.VerifyTLS,
+		Encrypt:     input.Encrypt,
+		NoRetarget:  input.NoRetarget,
+		Routes:      input.Routes,
+		PathRoutes:  input.PathRoutes,
+
+		TrustedProxies: input.TrustedProxies,
+	}
+	if input.Cookies != nil {
+		config.Cookies = *input.Cookies
+	}
+	if input.URLRewrite != nil {
+		config.URLRewrite = *input.URLRewrite
+	}
+	if input.Storms != nil {
+		config.Storms = *input.Storms
+	}
+	if input.Banner != nil {
+		config.Banner = *input.Banner
+	}
+	if input.UI != nil {
+		config.UI = *input.UI
+	}
+	if input.PersistLogs != nil {
+		config.PersistLogs = *input.PersistLogs
+	}
+	if input.BodyCapture != nil {
+		config.BodyCapture = *input.BodyCapture
 	}
 
 	// Use background context - proxy should outlive the MCP tool call
@@ -622,7 +880,7 @@ func handleProxyStart(ctx context.Context, pm *proxy.ProxyManager, input ProxyIn
 
 	return nil, ProxyOutput{
 		ID:         proxyServer.ID,
-		TargetURL:  proxyServer.TargetURL.String(),
+		TargetURL:  proxyServer.Target().String(),
 		ListenAddr: proxyServer.ListenAddr,
 		Message:    fmt.Sprintf("Proxy started. Access at http://localhost%s", proxyServer.ListenAddr),
 	}, nil
@@ -827,8 +1085,24 @@ func makeProxyLogHandler(pm *proxy.ProxyManager) func(context.Context, *mcp.Call
 			return handleProxyLogClear(proxyServer, input)
 		case "stats":
 			return handleProxyLogStats(proxyServer, input)
+		case "aggregate":
+			traffic := proxyServer.Traffic()
+			return nil, ProxyLogOutput{Traffic: &traffic}, nil
+		case "replay":
+			if input.EntryID == "" {
+				return errorResult("entry_id required for replay"), ProxyLogOutput{}, nil
+			}
+			var overrides proxy.ReplayOverrides
+			if input.Replay != nil {
+				overrides = *input.Replay
+			}
+			result, err := proxyServer.Replay(ctx, input.EntryID, overrides)
+			if err != nil {
+				return errorResult(err.Error()), ProxyLogOutput{}, nil
+			}
+			return nil, ProxyLogOutput{Replay: result}, nil
 		default:
-			return errorResult(fmt.Sprintf("unknown action %q. Use: query, summary, clear, stats", action)), ProxyLogOutput{}, nil
+			return errorResult(fmt.Sprintf("unknown action %q. Use: query, summary, clear, stats, aggregate, replay", action)), ProxyLogOutput{}, nil
 		}
 	}
 }
@@ -1027,6 +1301,73 @@ func handleProxyLogQueryRaw(entries []proxy.LogEntry) (*mcp.CallToolResult, Prox
 				Timestamp: entry.Response.Timestamp,
 				Data:      marshalData(data),
 			}
+
+		case proxy.LogTypeWebSocket:
+			if entry.WebSocket != nil {
+				data["id"] = entry.WebSocket.ID
+				data["connection_id"] = entry.WebSocket.ConnectionID
+				data["url"] = entry.WebSocket.URL
+				data["direction"] = entry.WebSocket.Direction
+				data["opcode"] = entry.WebSocket.OpcodeName
+				data["length"] = entry.WebSocket.Length
+				data["preview"] = entry.WebSocket.Preview
+				data["preview_hex"] = entry.WebSocket.PreviewHex
+				data["truncated"] = entry.WebSocket.Truncated
+				data["since_open_ms"] = entry.WebSocket.SinceOpenMs
+				if entry.WebSocket.CloseCode != 0 {
+					data["close_code"] = entry.WebSocket.CloseCode
+				}
+			}
+			output[i] = LogEntryOutput{
+				Type:      string(entry.Type),
+				Timestamp: entry.WebSocket.Timestamp,
+				Data:      marshalData(data),
+			}
+
+		case proxy.LogTypeConsole:
+			if entry.Console != nil {
+				data["id"] = entry.Console.ID
+				data["level"] = entry.Console.Level
+				data["message"] = entry.Console.Message
+				data["url"] = entry.Console.URL
+				if len(entry.Console.Args) > 0 {
+					data["args"] = entry.Console.Args
+				}
+				if entry.Console.Source != "" {
+					data["source"] = entry.Console.Source
+				}
+				if entry.Console.Dropped > 0 {
+					data["dropped"] = entry.Console.Dropped
+				}
+			}
+			output[i] = LogEntryOutput{
+				Type:      string(entry.Type),
+				Timestamp: entry.Console.Timestamp,
+				Data:      marshalData(data),
+			}
+
+		case proxy.LogTypeSSE:
+			if entry.SSE != nil {
+				data["id"] = entry.SSE.ID
+				data["stream_id"] = entry.SSE.StreamID
+				data["url"] = entry.SSE.URL
+				data["event"] = entry.SSE.Event
+				data["data_size"] = entry.SSE.DataSize
+				data["preview"] = entry.SSE.Preview
+				data["truncated"] = entry.SSE.Truncated
+				data["since_open_ms"] = entry.SSE.SinceOpenMs
+				if entry.SSE.EventID != "" {
+					data["event_id"] = entry.SSE.EventID
+				}
+				if entry.SSE.Retry != 0 {
+					data["retry"] = entry.SSE.Retry
+				}
+			}
+			output[i] = LogEntryOutput{
+				Type:      string(entry.Type),
+				Timestamp: entry.SSE.Timestamp,
+				Data:      marshalData(data),
+			}
 		}
 	}
 
@@ -1052,11 +1393,16 @@ func handleProxyLogQueryCompact(entries []proxy.LogEntry) (*mcp.CallToolResult,
 				if entry.HTTP.Error != "" {
 					errorSuffix = fmt.Sprintf(" ERROR: %s", entry.HTTP.Error)
 				}
-				data = fmt.Sprintf("%s %s → %d (%dms)%s",
+				timing := ""
+				if t := entry.HTTP.Timing; t != nil {
+					timing = fmt.Sprintf(": upstream %dms, proxy %dms", t.Upstream.Milliseconds(), t.Proxy.Milliseconds())
+				}
+				data = fmt.Sprintf("%s %s → %d (%dms%s)%s",
 					entry.HTTP.Method,
 					entry.HTTP.URL,
 					entry.HTTP.StatusCode,
 					entry.HTTP.Duration.Milliseconds(),
+					timing,
 					errorSuffix)
 			}
 
@@ -1174,6 +1520,42 @@ func handleProxyLogQueryCompact(entries []proxy.LogEntry) (*mcp.CallToolResult,
 					entry.Sketch.FilePath)
 			}
 
+		case proxy.LogTypeWebSocket:
+			if entry.WebSocket != nil {
+				ws := entry.WebSocket
+				timestamp = ws.Timestamp
+				arrow := "→"
+				if ws.Direction == proxy.WSIncoming {
+					arrow = "←"
+				}
+				data = fmt.Sprintf("%s %s %s (%d bytes)", arrow, ws.URL, ws.OpcodeName, ws.Length)
+				if ws.CloseCode != 0 {
+					data += fmt.Sprintf(" code=%d", ws.CloseCode)
+				}
+				if ws.Preview != "" {
+					data += " " + ws.Preview
+				}
+			}
+
+		case proxy.LogTypeConsole:
+			if entry.Console != nil {
+				timestamp = entry.Console.Timestamp
+				data = fmt.Sprintf("[%s] %s", entry.Console.Level, entry.Console.Message)
+				if entry.Console.Source != "" {
+					data += " (" + entry.Console.Source + ")"
+				}
+			}
+
+		case proxy.LogTypeSSE:
+			if entry.SSE != nil {
+				sse := entry.SSE
+				timestamp = sse.Timestamp
+				data = fmt.Sprintf("← %s %s (%d bytes, +%dms)", sse.URL, sse.Event, sse.DataSize, sse.SinceOpenMs)
+				if sse.Preview != "" {
+					data += " " + sse.Preview
+				}
+			}
+
 		default:
 			// For other types, use basic string representation
 			data = fmt.Sprintf("%s event", entry.Type)
@@ -1194,6 +1576,8 @@ func handleProxyLogQueryCompact(entries []proxy.LogEntry) (*mcp.CallToolResult,
 
 func handleProxyLogClear(proxyServer *proxy.ProxyServer, input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
 	proxyServer.Logger().Clear()
+	proxyServer.ResetTraffic()
+	proxyServer.ResetRequestStorms()
 
 	return nil, ProxyLogOutput{
 		Success: true,
@@ -1202,6 +1586,10 @@ func handleProxyLogClear(proxyServer *proxy.ProxyServer, input ProxyLogInput) (*
 }
 
 func handleProxyLogStats(proxyServer *proxy.ProxyServer, input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
+	filter := routeStatsFilter(input)
+	if err := filter.Validate(); err != nil {
+		return errorResult(err.Error()), ProxyLogOutput{}, nil
+	}
 	stats := proxyServer.Logger().Stats()
 
 	return nil, ProxyLogOutput{
@@ -1210,10 +1598,16 @@ func handleProxyLogStats(proxyServer *proxy.ProxyServer, input ProxyLogInput) (*
 			AvailableEntries: stats.AvailableEntries,
 			MaxSize:          stats.MaxSize,
 			Dropped:          stats.Dropped,
+			Routes:           proxyServer.Logger().RouteStats(filter),
 		},
 	}, nil
 }
 
+// routeStatsFilter builds the per-route report options of the stats action.
+func routeStatsFilter(input ProxyLogInput) proxy.RouteStatsFilter {
+	return proxy.RouteStatsFilter{SortBy: input.SortBy, Limit: input.Limit, MinCount: input.MinCount}
+}
+
 func handleProxyLogSummary(proxyServer *proxy.ProxyServer, input ProxyLogInput) (*mcp.CallToolResult, ProxyLogOutput, error) {
 	// Query all logs
 	allEntries := proxyServer.Logger().Query(proxy.LogFilter{})
@@ -1255,6 +1649,7 @@ func handleProxyLogSummary(proxyServer *proxy.ProxyServer, input ProxyLogInput)
 	var perfEntries []proxy.PerformanceMetric
 	var interactionEntries []proxy.InteractionEvent
 	var mutationEntries []proxy.MutationEvent
+	var consoleEntries []proxy.ConsoleEntry
 	var otherEntries []proxy.LogEntry
 
 	// First pass: count and collect
@@ -1329,6 +1724,12 @@ func handleProxyLogSummary(proxyServer *proxy.ProxyServer, input ProxyLogInput)
 				summary.MutationsByType[entry.Mutation.MutationType]++
 			}
 
+		case proxy.LogTypeConsole:
+			if entry.Console != nil {
+				timestamp = entry.Console.Timestamp
+				consoleEntries = append(consoleEntries, *entry.Console)
+			}
+
 		default:
 			otherEntries = append(otherEntries, entry)
 			summary.OtherCount++
@@ -1360,6 +1761,14 @@ func handleProxyLogSummary(proxyServer *proxy.ProxyServer, input ProxyLogInput)
 				if entry.Sketch != nil {
 					timestamp = entry.Sketch.Timestamp
 				}
+			case proxy.LogTypeWebSocket:
+				if entry.WebSocket != nil {
+					timestamp = entry.WebSocket.Timestamp
+				}
+			case proxy.LogTypeSSE:
+				if entry.SSE != nil {
+					timestamp = entry.SSE.Timestamp
+				}
 			}
 		}
 
@@ -1412,6 +1821,8 @@ func handleProxyLogSummary(proxyServer *proxy.ProxyServer, input ProxyLogInput)
 	}
 	summary.UniqueErrors = uniqueErrors
 
+	summarizeConsole(summary, consoleEntries, detailSections["console"], limit)
+
 	// Recent errors (last 5) or full list if detail includes "errors"
 	if detailSections["errors"] {
 		summary.Errors = make([]CompactError, min(len(errorEntries), limit))
@@ -1694,8 +2105,35 @@ func makeCurrentPageHandler(pm *proxy.ProxyManager) func(context.Context, *mcp.C
 			return handleCurrentPageGet(proxyServer, input)
 		case "clear":
 			return handleCurrentPageClear(proxyServer, input)
+		case "wait_idle":
+			return handleCurrentPageWaitIdle(ctx, proxyServer, input)
+		case "snapshot":
+			return handleCurrentPageSnapshot(ctx, proxyServer, input)
+		case "snapshots":
+			snapshots := proxyServer.DOMSnapshots()
+			return nil, CurrentPageOutput{Snapshots: snapshots, Count: len(snapshots)}, nil
+		case "diff":
+			return handleCurrentPageDiff(proxyServer, input)
+		case "screenshot":
+			shot, err := proxyServer.CaptureScreenshot(ctx, input.SessionID, screenshotOptions(input))
+			if err != nil {
+				return errorResult(err.Error()), CurrentPageOutput{}, nil
+			}
+			return screenshotResult(shot)
+		case "screenshots":
+			files, err := proxyServer.Screenshots()
+			if err != nil {
+				return errorResult(err.Error()), CurrentPageOutput{}, nil
+			}
+			return nil, CurrentPageOutput{Screenshots: files, Count: len(files)}, nil
+		case "coverage":
+			report, err := proxyServer.CSSCoverage(ctx, input.SessionID, proxy.CSSCoverageOptions{Limit: input.Limit})
+			if err != nil {
+				return errorResult(err.Error()), CurrentPageOutput{}, nil
+			}
+			return nil, CurrentPageOutput{CSSCoverage: &report}, nil
 		default:
-			return errorResult(fmt.Sprintf("unknown action %q. Use: list, get, clear", action)), CurrentPageOutput{}, nil
+			return errorResult(fmt.Sprintf("unknown action %q. Use: list, get, clear, wait_idle, snapshot, snapshots, diff, screenshot, screenshots, coverage", action)), CurrentPageOutput{}, nil
 		}
 	}
 }
@@ -1757,6 +2195,79 @@ func handleCurrentPageClear(proxyServer *proxy.ProxyServer, input CurrentPageInp
 	}, nil
 }
 
+func handleCurrentPageWaitIdle(ctx context.Context, proxyServer *proxy.ProxyServer, input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	if input.SessionID != "" {
+		if _, ok := proxyServer.PageTracker().GetSession(input.SessionID); !ok {
+			return errorResult(fmt.Sprintf("session not found: %s", input.SessionID)), CurrentPageOutput{}, nil
+		}
+	}
+
+	result, err := proxyServer.WaitForIdle(ctx, input.SessionID, idleOptions(input))
+	if err != nil {
+		return errorResult(err.Error()), CurrentPageOutput{}, nil
+	}
+	return nil, CurrentPageOutput{Idle: &result}, nil
+}
+
+func handleCurrentPageSnapshot(ctx context.Context, proxyServer *proxy.ProxyServer, input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	snapshot, err := proxyServer.CaptureDOMSnapshot(ctx, input.SessionID, snapshotOptions(input))
+	if err != nil {
+		return errorResult(err.Error()), CurrentPageOutput{}, nil
+	}
+	return nil, CurrentPageOutput{Snapshot: &snapshot}, nil
+}
+
+func handleCurrentPageDiff(proxyServer *proxy.ProxyServer, input CurrentPageInput) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	if input.From == "" || input.To == "" {
+		return errorResult("from and to snapshot IDs required for diff"), CurrentPageOutput{}, nil
+	}
+	diff, err := proxyServer.DiffDOMSnapshots(input.From, input.To)
+	if err != nil {
+		return errorResult(err.Error()), CurrentPageOutput{}, nil
+	}
+	return nil, CurrentPageOutput{Diff: diff}, nil
+}
+
+// snapshotOptions returns the snapshot options of a currentpage call.
+func snapshotOptions(input CurrentPageInput) proxy.DOMSnapshotOptions {
+	return proxy.DOMSnapshotOptions{Name: input.Name, Selector: input.Selector, Styles: input.Styles}
+}
+
+// screenshotOptions returns the screenshot options of a currentpage call.
+func screenshotOptions(input CurrentPageInput) proxy.ScreenshotOptions {
+	return proxy.ScreenshotOptions{
+		Name:          input.Name,
+		Selector:      input.Selector,
+		Viewport:      input.Viewport,
+		Annotate:      input.Annotate,
+		AnnotateLimit: input.AnnotateLimit,
+		Format:        input.Format,
+	}
+}
+
+// screenshotResult returns a captured screenshot with its thumbnail as image
+// content, so MCP clients can show it, instead of as base64 in the output.
+func screenshotResult(shot proxy.PageScreenshot) (*mcp.CallToolResult, CurrentPageOutput, error) {
+	thumbnail := shot.Thumbnail
+	shot.Thumbnail = nil
+	output := CurrentPageOutput{Screenshot: &shot}
+	if len(thumbnail) == 0 {
+		return nil, output, nil
+	}
+	text, _ := json.Marshal(output)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(text)},
+			&mcp.ImageContent{Data: thumbnail, MIMEType: "image/png"},
+		},
+	}, output, nil
+}
+
+// idleOptions returns the wait_idle options of a currentpage call.
+func idleOptions(input CurrentPageInput) proxy.IdleOptions {
+	return proxy.IdleOptions{QuietMs: input.QuietMs, TimeoutMs: input.TimeoutMs, MaxInflight: input.MaxInflight}
+}
+
 // convertPageSession converts a PageSession to output format.
 func convertPageSession(session *proxy.PageSession, includeDetails bool) PageSessionOutput {
 	output := PageSessionOutput{
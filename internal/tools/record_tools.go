@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+// RecordInput represents input for the record tool.
+type RecordInput struct {
+	Action            string            `json:"action" jsonschema:"Action: start, stop, status, replay, list"`
+	ProxyID           string            `json:"proxy_id" jsonschema:"Proxy ID"`
+	SessionID         string            `json:"session_id,omitempty" jsonschema:"For start: page session to record (default: the first page to act). For replay: page to replay in (default: whichever page answers first)"`
+	Name              string            `json:"name,omitempty" jsonschema:"For start: recording name (default: recording-<time>). For replay: recording to replay"`
+	Values            map[string]string `json:"values,omitempty" jsonschema:"For replay: values of fields masked when recorded (passwords, card numbers), by selector"`
+	StepTimeoutMs     int               `json:"step_timeout_ms,omitempty" jsonschema:"For replay: wait per step for its element or page (default: 5000, max: 60000)"`
+	ContinueOnFailure bool              `json:"continue_on_failure,omitempty" jsonschema:"For replay: run the remaining steps after a failure instead of skipping them"`
+}
+
+// RecordOutput represents output from the record tool.
+type RecordOutput struct {
+	Recording  *proxy.Recording       `json:"recording,omitempty"`
+	Status     *proxy.RecordingStatus `json:"status,omitempty"`
+	Replay     *proxy.RecordingReplay `json:"replay,omitempty"`
+	Recordings []proxy.RecordingFile  `json:"recordings,omitempty"`
+	Count      int                    `json:"count,omitempty"`
+}
+
+// RegisterRecordTool registers the record MCP tool with the server.
+func RegisterRecordTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "record",
+		Description: `Record a flow demonstrated in a proxied page and replay it as a regression check.
+
+While recording, the proxy turns the page's clicks, field values, Enter/Escape/Tab
+presses and page loads into steps, following the page across navigations. Stopping
+stores the steps in the project's .agnt/recordings/<name>.json. Replaying runs them
+one at a time through the injected script, waiting for each element or page, and
+reports every step as passed, failed or skipped. Values of password and payment
+fields are masked when recorded; pass them in values to replay them.
+
+Actions:
+  start: Start recording a page (or the first page to act)
+  stop: Stop and store the recording
+  status: The recording in progress, if any
+  replay: Replay a stored recording
+  list: Stored recordings of the project
+
+Examples:
+  record {action: "start", proxy_id: "dev", name: "checkout"}
+  record {action: "stop", proxy_id: "dev"}
+  record {action: "replay", proxy_id: "dev", name: "checkout"}
+  record {action: "replay", proxy_id: "dev", name: "login", values: {"#password": "dev-secret"}}`,
+	}, dt.makeRecordHandler())
+}
+
+// makeRecordHandler creates a handler for the record tool.
+func (dt *DaemonTools) makeRecordHandler() func(context.Context, *mcp.CallToolRequest, RecordInput) (*mcp.CallToolResult, RecordOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input RecordInput) (*mcp.CallToolResult, RecordOutput, error) {
+		if input.ProxyID == "" {
+			return errorResult("proxy_id required"), RecordOutput{}, nil
+		}
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), RecordOutput{}, nil
+		}
+
+		var output RecordOutput
+		switch input.Action {
+		case "start":
+			result, err := dt.client.RecordStart(input.ProxyID, input.SessionID, proxy.RecordOptions{Name: input.Name})
+			if err != nil {
+				return formatDaemonError(err, "record"), RecordOutput{}, nil
+			}
+			output.Status = &proxy.RecordingStatus{}
+			decodeRecordResult(result["recording"], output.Status)
+		case "stop":
+			result, err := dt.client.RecordStop(input.ProxyID)
+			if err != nil {
+				return formatDaemonError(err, "record"), RecordOutput{}, nil
+			}
+			output.Recording = &proxy.Recording{}
+			decodeRecordResult(result, output.Recording)
+		case "status":
+			result, err := dt.client.RecordStatus(input.ProxyID)
+			if err != nil {
+				return formatDaemonError(err, "record"), RecordOutput{}, nil
+			}
+			if status, ok := result["status"]; ok {
+				output.Status = &proxy.RecordingStatus{}
+				decodeRecordResult(status, output.Status)
+			}
+		case "replay":
+			if input.Name == "" {
+				return errorResult("name required for replay"), RecordOutput{}, nil
+			}
+			result, err := dt.client.RecordReplay(input.ProxyID, input.Name, input.SessionID, proxy.RecordingReplayOptions{
+				Values:            input.Values,
+				StepTimeoutMs:     input.StepTimeoutMs,
+				ContinueOnFailure: input.ContinueOnFailure,
+			})
+			if err != nil {
+				return formatDaemonError(err, "record"), RecordOutput{}, nil
+			}
+			output.Replay = &proxy.RecordingReplay{}
+			decodeRecordResult(result, output.Replay)
+		case "list":
+			result, err := dt.client.RecordList(input.ProxyID)
+			if err != nil {
+				return formatDaemonError(err, "record"), RecordOutput{}, nil
+			}
+			decodeRecordResult(result["recordings"], &output.Recordings)
+			output.Count = len(output.Recordings)
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), RecordOutput{}, nil
+		}
+		return nil, output, nil
+	}
+}
+
+// decodeRecordResult converts part of a daemon response into its proxy type.
+func decodeRecordResult(v interface{}, out interface{}) {
+	if b, err := json.Marshal(v); err == nil {
+		json.Unmarshal(b, out)
+	}
+}
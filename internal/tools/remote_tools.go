@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/remote"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RemoteInput represents input for the remote tool.
+type RemoteInput struct {
+	Action string `json:"action" jsonschema:"Action: status, forward"`
+	Port   int    `json:"port,omitempty" jsonschema:"For forward: TCP port on the remote host's loopback"`
+}
+
+// RemoteOutput represents output from the remote tool.
+type RemoteOutput struct {
+	ProjectPath string           `json:"project_path,omitempty"`
+	Remote      *remote.Target   `json:"remote,omitempty"`
+	Processes   []RemoteProcess  `json:"processes,omitempty"`
+	Forwards    []remote.Forward `json:"forwards,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	// For forward
+	RemotePort int    `json:"remote_port,omitempty"`
+	LocalPort  int    `json:"local_port,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+// RemoteProcess is a managed process running on the remote checkout.
+type RemoteProcess struct {
+	ProcessID string `json:"process_id"`
+	Host      string `json:"host"`
+}
+
+// RegisterRemoteTool registers the remote MCP tool with the server.
+func RegisterRemoteTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "remote",
+		Description: `Inspect a project that runs on a remote dev box over ssh.
+
+With a remote block in .agnt.kdl, run starts scripts in the remote checkout over ssh
+(output streams back to proc output), URLs they print on localhost are rewritten to
+local ssh forwards, and proxies targeting localhost go through a forward too:
+
+  remote {
+      host "devbox"          // ~/.ssh/config alias or user@host; keys or agent, no prompts
+      path "/home/me/app"
+      // port 2222  identity "~/.ssh/id_devbox"  options "ProxyJump=bastion"
+  }
+
+Actions:
+  status: The project's remote, its remote processes and the forwards (starting, ready, exited)
+  forward: Forward another remote port, e.g. a database, to this machine
+
+Examples:
+  remote {action: "status"}
+  remote {action: "forward", port: 5432}`,
+	}, dt.makeRemoteHandler())
+}
+
+// makeRemoteHandler creates a handler for the remote tool.
+func (dt *DaemonTools) makeRemoteHandler() func(context.Context, *mcp.CallToolRequest, RemoteInput) (*mcp.CallToolResult, RemoteOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input RemoteInput) (*mcp.CallToolResult, RemoteOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), RemoteOutput{}, nil
+		}
+
+		var result map[string]interface{}
+		var err error
+		switch input.Action {
+		case "status":
+			result, err = dt.client.RemoteStatus(dt.projectPath(req.Session))
+		case "forward":
+			if input.Port <= 0 {
+				return errorResult("port required for forward"), RemoteOutput{}, nil
+			}
+			result, err = dt.client.RemoteForward(input.Port, dt.projectPath(req.Session))
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), RemoteOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "remote"), RemoteOutput{}, nil
+		}
+
+		var output RemoteOutput
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, &output)
+		}
+		return nil, output, nil
+	}
+}
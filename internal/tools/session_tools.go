@@ -2,10 +2,11 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/standardbeagle/agnt/internal/daemon"
 	"github.com/standardbeagle/agnt/internal/protocol"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -13,12 +14,24 @@ import (
 
 // SessionInput defines input for the session tool.
 type SessionInput struct {
-	Action   string `json:"action" jsonschema:"Action: list, send, schedule, tasks, cancel, get"`
-	Code     string `json:"code,omitempty" jsonschema:"Session code (required for send, schedule, get)"`
-	Message  string `json:"message,omitempty" jsonschema:"Message to send or schedule (required for send, schedule)"`
-	Duration string `json:"duration,omitempty" jsonschema:"Duration for scheduling (e.g. '5m', '1h30m') (required for schedule)"`
-	TaskID   string `json:"task_id,omitempty" jsonschema:"Task ID (required for cancel)"`
-	Global   bool   `json:"global,omitempty" jsonschema:"For list/tasks: include sessions/tasks from all directories (default: false)"`
+	Action      string                  `json:"action" jsonschema:"Action: list, send, schedule, tasks, cancel, pause, resume, get, status, digest, alert, clipboard, transcript, tag"`
+	Code        string                  `json:"code,omitempty" jsonschema:"Session code (required for send, schedule, get, status, digest, alert, clipboard, transcript, tag)"`
+	Message     string                  `json:"message,omitempty" jsonschema:"Message to send or schedule (required for send, schedule); for clipboard, text to share with the session's pages"`
+	Duration    string                  `json:"duration,omitempty" jsonschema:"Duration for scheduling (e.g. '5m', '1h30m') (required for schedule without cron)"`
+	Cron        string                  `json:"cron,omitempty" jsonschema:"For schedule: deliver every time this cron expression matches, until cancelled (e.g. '*/30 * * * *', '0 9 * * MON-FRI', '@daily', '@every 45m')"`
+	Timezone    string                  `json:"timezone,omitempty" jsonschema:"For schedule with cron: IANA time zone such as 'Europe/Berlin' (default: the daemon's local time)"`
+	TaskID      string                  `json:"task_id,omitempty" jsonschema:"Task ID (required for cancel, pause, resume)"`
+	Global      bool                    `json:"global,omitempty" jsonschema:"For list/tasks: include sessions/tasks from all directories (default: false)"`
+	WaitForIdle bool                    `json:"wait_for_idle,omitempty" jsonschema:"For schedule: once due, hold the message while the agent is busy (up to 10m)"`
+	Digest      *daemon.DigestConfig    `json:"digest,omitempty" jsonschema:"For digest: turn on or reconfigure the periodic digest (interval_minutes, deliver: session/toast/both, min_errors, min_failed_processes, min_slow_requests, slow_ms). Omit to see its state and a preview"`
+	Off         bool                    `json:"off,omitempty" jsonschema:"For digest: turn the digest off"`
+	Alert       *daemon.AlertRule       `json:"alert,omitempty" jsonschema:"For alert: add a rule pushing a summary when more than threshold errors arrive within window_seconds (kinds: frontend_error/http_5xx, threshold, window_seconds, cooldown_seconds, proxy_id, match, deliver: session/toast/both, id). Omit to list the rules"`
+	AlertID     string                  `json:"alert_id,omitempty" jsonschema:"For alert: remove the rule with this ID"`
+	Transcript  *daemon.TranscriptQuery `json:"transcript,omitempty" jsonschema:"For transcript: tail (last N lines, default 100), grep (Go regexp, (?i) for case-insensitive), since and until (RFC 3339 or a duration ago like 12h), raw (keep escape sequences)"`
+	Tags        map[string]string       `json:"tags,omitempty" jsonschema:"For tag: tags to set (e.g. {role: frontend, ticket: ABC-123}); for list: only sessions with all these tags (an empty value matches any)"`
+	RemoveTags  []string                `json:"remove_tags,omitempty" jsonschema:"For tag: tag keys to remove"`
+	Description *string                 `json:"description,omitempty" jsonschema:"For tag: replace the session's description (empty clears it)"`
+	Delivery    *daemon.SendOptions     `json:"delivery,omitempty" jsonschema:"For send: how the message is typed (preset: instant, paste, chunked, typed; chunk_size; chunk_delay_ms; enter; bracketed_paste). Defaults to a preset for the session's tool; use chunked for long prompts a tool drops"`
 }
 
 // SessionOutput defines output for the session tool.
@@ -41,6 +54,25 @@ type SessionOutput struct {
 	// For schedule
 	DeliverAt *time.Time `json:"deliver_at,omitempty"`
 
+	// For pause and resume
+	Task *TaskEntry `json:"task,omitempty"`
+
+	// For digest
+	Digest map[string]interface{} `json:"digest,omitempty"`
+
+	// For alert
+	Alerts map[string]interface{} `json:"alerts,omitempty"`
+
+	// For status
+	Status map[string]interface{} `json:"status,omitempty"`
+
+	// For clipboard
+	Clipboard map[string]interface{} `json:"clipboard,omitempty"`
+	SentCount int                    `json:"sent_count,omitempty"`
+
+	// For transcript
+	Transcript map[string]interface{} `json:"transcript,omitempty"`
+
 	// Directory filtering info
 	Directory string `json:"directory,omitempty"`
 	Global    bool   `json:"global,omitempty"`
@@ -48,27 +80,35 @@ type SessionOutput struct {
 
 // SessionEntry represents a session in the list.
 type SessionEntry struct {
-	Code        string    `json:"code"`
-	OverlayPath string    `json:"overlay_path,omitempty"`
-	ProjectPath string    `json:"project_path,omitempty"`
-	Command     string    `json:"command,omitempty"`
-	Args        []string  `json:"args,omitempty"`
-	StartedAt   time.Time `json:"started_at,omitempty"`
-	Status      string    `json:"status,omitempty"`
-	LastSeen    time.Time `json:"last_seen,omitempty"`
+	Code        string            `json:"code"`
+	OverlayPath string            `json:"overlay_path,omitempty"`
+	ProjectPath string            `json:"project_path,omitempty"`
+	Command     string            `json:"command,omitempty"`
+	Args        []string          `json:"args,omitempty"`
+	StartedAt   time.Time         `json:"started_at,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	LastSeen    time.Time         `json:"last_seen,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Description string            `json:"description,omitempty"`
 }
 
 // TaskEntry represents a scheduled task in the list.
 type TaskEntry struct {
-	ID          string    `json:"id"`
-	SessionCode string    `json:"session_code"`
-	Message     string    `json:"message"`
-	DeliverAt   time.Time `json:"deliver_at"`
-	CreatedAt   time.Time `json:"created_at"`
-	ProjectPath string    `json:"project_path,omitempty"`
-	Status      string    `json:"status"`
-	Attempts    int       `json:"attempts,omitempty"`
-	LastError   string    `json:"last_error,omitempty"`
+	ID          string           `json:"id"`
+	SessionCode string           `json:"session_code"`
+	Message     string           `json:"message"`
+	DeliverAt   time.Time        `json:"deliver_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ProjectPath string           `json:"project_path,omitempty"`
+	Status      string           `json:"status"`
+	Attempts    int              `json:"attempts,omitempty"`
+	LastError   string           `json:"last_error,omitempty"`
+	WaitForIdle bool             `json:"wait_for_idle,omitempty"`
+	Cron        string           `json:"cron,omitempty"`
+	Timezone    string           `json:"timezone,omitempty"`
+	RunCount    int              `json:"run_count,omitempty"`
+	Runs        []daemon.TaskRun `json:"runs,omitempty"`    // Last 20 deliveries, oldest first
+	Overdue     bool             `json:"overdue,omitempty"` // Came due while the daemon was down
 }
 
 // RegisterSessionTool adds the session MCP tool to the server.
@@ -81,21 +121,60 @@ Sessions are created automatically when 'agnt run' starts. You can send messages
 directly to a session or schedule messages for future delivery.
 
 Actions:
-  list: List active sessions (filtered by current directory unless global: true)
+  list: List active sessions (filtered by current directory unless global: true,
+        and by tags when given)
   get: Get details for a specific session
+  status: Whether the session's agent is busy or idle, and for how long
   send: Send a message to a session immediately
-  schedule: Schedule a message for future delivery
-  tasks: List scheduled tasks
+  schedule: Schedule a message for future delivery, once after a duration
+            or repeatedly on a cron schedule
+  tasks: List scheduled tasks with their recent deliveries
   cancel: Cancel a scheduled task
+  pause: Hold a scheduled task without cancelling it
+  resume: Resume a paused task (a recurring one continues at its next time)
+  digest: Turn on a periodic summary of new errors, failed processes and slow
+          endpoints, delivered to the session (or as a toast) only when
+          something reaches its threshold
+  alert: Push a summary to the session as soon as its project's proxies log
+         more than a threshold of frontend errors or 5xx responses within a
+         window, instead of waiting for the next digest
+  clipboard: Share text (up to 64KB) with the floating panel of the session's
+             proxied pages, or without a message read the last text moved
+             between pages and terminal. Text the user sends from the panel
+             is typed into the session without submitting it
+  transcript: Search the terminal output of a session started with
+              'agnt run --transcript': the last lines, lines matching a
+              regexp, or a time range. Works after the session has ended
+  tag: Set or remove tags of a session (role=frontend, ticket=ABC-123) and
+       its description, to tell parallel agents of a project apart
 
 Examples:
   session {action: "list"}
   session {action: "list", global: true}
+  session {action: "list", tags: {role: "frontend"}}
+  session {action: "tag", code: "claude-1", tags: {role: "frontend", ticket: "ABC-123"}, description: "Checkout redesign"}
+  session {action: "tag", code: "claude-1", remove_tags: ["ticket"]}
   session {action: "get", code: "claude-1"}
   session {action: "send", code: "claude-1", message: "Check the test results"}
+  session {action: "send", code: "gemini-1", message: "<long prompt>", delivery: {preset: "chunked"}}
   session {action: "schedule", code: "claude-1", duration: "5m", message: "Verify this completed"}
+  session {action: "schedule", code: "claude-1", duration: "1m", message: "Run the tests", wait_for_idle: true}
+  session {action: "schedule", code: "claude-1", cron: "*/30 * * * *", message: "Commit your work", wait_for_idle: true}
+  session {action: "schedule", code: "claude-1", cron: "0 9 * * MON-FRI", timezone: "Europe/Berlin", message: "Summarize open PRs"}
+  session {action: "status", code: "claude-1"}
   session {action: "tasks"}
   session {action: "cancel", task_id: "task-abc123"}
+  session {action: "pause", task_id: "task-abc123"}
+  session {action: "resume", task_id: "task-abc123"}
+  session {action: "digest", code: "claude-1", digest: {interval_minutes: 10, min_slow_requests: 5}}
+  session {action: "digest", code: "claude-1", off: true}
+  session {action: "alert", code: "claude-1", alert: {threshold: 5, window_seconds: 60}}
+  session {action: "alert", code: "claude-1", alert: {kinds: ["http_5xx"], match: "/api/", deliver: "both"}}
+  session {action: "alert", code: "claude-1", alert_id: "alert-1"}
+  session {action: "clipboard", code: "claude-1", message: "npm ERR! missing script: lint"}
+  session {action: "clipboard", code: "claude-1"}
+  session {action: "transcript", code: "claude-1", transcript: {tail: 50}}
+  session {action: "transcript", code: "claude-1", transcript: {grep: "(?i)error|fail", since: "12h"}}
 
 Duration format:
   - "5m" = 5 minutes
@@ -103,6 +182,11 @@ Duration format:
   - "1h30m" = 1 hour 30 minutes
   - "30s" = 30 seconds
 
+Cron format (minute hour day-of-month month day-of-week):
+  - "*/30 * * * *" = every 30 minutes
+  - "0 9 * * MON-FRI" = 9:00 on weekdays
+  - "@hourly", "@daily", "@weekly", "@every 45m"
+
 Scheduled messages are delivered as synthetic stdin to the AI agent's PTY,
 allowing you to remind the agent to check on tasks or verify completions.`,
 	}, dt.makeSessionHandler())
@@ -117,7 +201,7 @@ func (dt *DaemonTools) makeSessionHandler() func(context.Context, *mcp.CallToolR
 
 		switch input.Action {
 		case "list":
-			return dt.handleSessionList(input)
+			return dt.handleSessionList(req.Session, input)
 		case "get":
 			return dt.handleSessionGet(input)
 		case "send":
@@ -125,20 +209,33 @@ func (dt *DaemonTools) makeSessionHandler() func(context.Context, *mcp.CallToolR
 		case "schedule":
 			return dt.handleSessionSchedule(input)
 		case "tasks":
-			return dt.handleSessionTasks(input)
+			return dt.handleSessionTasks(req.Session, input)
 		case "cancel":
 			return dt.handleSessionCancel(input)
+		case "pause", "resume":
+			return dt.handleSessionPauseResume(input)
+		case "status":
+			return dt.handleSessionStatus(input)
+		case "digest":
+			return dt.handleSessionDigest(input)
+		case "alert":
+			return dt.handleSessionAlert(input)
+		case "clipboard":
+			return dt.handleSessionClipboard(input)
+		case "transcript":
+			return dt.handleSessionTranscript(req.Session, input)
+		case "tag":
+			return dt.handleSessionTag(input)
 		default:
-			return errorResult(fmt.Sprintf("unknown action %q. Use: list, get, send, schedule, tasks, cancel", input.Action)), SessionOutput{}, nil
+			return errorResult(fmt.Sprintf("unknown action %q. Use: list, get, status, send, schedule, tasks, cancel, pause, resume, digest, alert, clipboard, transcript, tag", input.Action)), SessionOutput{}, nil
 		}
 	}
 }
 
-func (dt *DaemonTools) handleSessionList(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return errorResult(fmt.Sprintf("failed to get working directory: %v", err)), SessionOutput{}, nil
+func (dt *DaemonTools) handleSessionList(ss *mcp.ServerSession, input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	cwd := dt.projectPath(ss)
+	if cwd == "" {
+		return errorResult("failed to get working directory"), SessionOutput{}, nil
 	}
 
 	// Create directory filter
@@ -147,7 +244,7 @@ func (dt *DaemonTools) handleSessionList(input SessionInput) (*mcp.CallToolResul
 		Global:    input.Global,
 	}
 
-	result, err := dt.client.SessionList(dirFilter)
+	result, err := dt.client.SessionListTagged(dirFilter, input.Tags)
 	if err != nil {
 		return formatDaemonError(err, "session"), SessionOutput{}, nil
 	}
@@ -161,31 +258,7 @@ func (dt *DaemonTools) handleSessionList(input SessionInput) (*mcp.CallToolResul
 	if sessions, ok := result["sessions"].([]interface{}); ok {
 		for _, s := range sessions {
 			if sm, ok := s.(map[string]interface{}); ok {
-				entry := SessionEntry{
-					Code:        getString(sm, "code"),
-					OverlayPath: getString(sm, "overlay_path"),
-					ProjectPath: getString(sm, "project_path"),
-					Command:     getString(sm, "command"),
-					Status:      getString(sm, "status"),
-				}
-				if args, ok := sm["args"].([]interface{}); ok {
-					for _, a := range args {
-						if str, ok := a.(string); ok {
-							entry.Args = append(entry.Args, str)
-						}
-					}
-				}
-				if ts, ok := sm["started_at"].(string); ok {
-					if t, err := time.Parse(time.RFC3339, ts); err == nil {
-						entry.StartedAt = t
-					}
-				}
-				if ts, ok := sm["last_seen"].(string); ok {
-					if t, err := time.Parse(time.RFC3339, ts); err == nil {
-						entry.LastSeen = t
-					}
-				}
-				output.Sessions = append(output.Sessions, entry)
+				output.Sessions = append(output.Sessions, sessionEntryFromMap(sm))
 			}
 		}
 	}
@@ -193,44 +266,70 @@ func (dt *DaemonTools) handleSessionList(input SessionInput) (*mcp.CallToolResul
 	return nil, output, nil
 }
 
-func (dt *DaemonTools) handleSessionGet(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
-	if input.Code == "" {
-		return errorResult("code required for get"), SessionOutput{}, nil
-	}
-
-	result, err := dt.client.SessionGet(input.Code)
-	if err != nil {
-		return formatDaemonError(err, "session"), SessionOutput{}, nil
-	}
-
+// sessionEntryFromMap converts a session of a daemon response.
+func sessionEntryFromMap(m map[string]interface{}) SessionEntry {
 	entry := SessionEntry{
-		Code:        getString(result, "code"),
-		OverlayPath: getString(result, "overlay_path"),
-		ProjectPath: getString(result, "project_path"),
-		Command:     getString(result, "command"),
-		Status:      getString(result, "status"),
+		Code:        getString(m, "code"),
+		OverlayPath: getString(m, "overlay_path"),
+		ProjectPath: getString(m, "project_path"),
+		Command:     getString(m, "command"),
+		Status:      getString(m, "status"),
+		Description: getString(m, "description"),
 	}
-	if args, ok := result["args"].([]interface{}); ok {
+	if args, ok := m["args"].([]interface{}); ok {
 		for _, a := range args {
 			if str, ok := a.(string); ok {
 				entry.Args = append(entry.Args, str)
 			}
 		}
 	}
-	if ts, ok := result["started_at"].(string); ok {
+	if tags, ok := m["tags"].(map[string]interface{}); ok {
+		entry.Tags = make(map[string]string, len(tags))
+		for k, v := range tags {
+			entry.Tags[k], _ = v.(string)
+		}
+	}
+	if ts, ok := m["started_at"].(string); ok {
 		if t, err := time.Parse(time.RFC3339, ts); err == nil {
 			entry.StartedAt = t
 		}
 	}
-	if ts, ok := result["last_seen"].(string); ok {
+	if ts, ok := m["last_seen"].(string); ok {
 		if t, err := time.Parse(time.RFC3339, ts); err == nil {
 			entry.LastSeen = t
 		}
 	}
+	return entry
+}
+
+func (dt *DaemonTools) handleSessionGet(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for get"), SessionOutput{}, nil
+	}
+
+	result, err := dt.client.SessionGet(input.Code)
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
 
+	entry := sessionEntryFromMap(result)
 	return nil, SessionOutput{Session: &entry}, nil
 }
 
+func (dt *DaemonTools) handleSessionTag(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for tag"), SessionOutput{}, nil
+	}
+
+	result, err := dt.client.SessionTag(input.Code, input.Tags, input.RemoveTags, input.Description)
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	entry := sessionEntryFromMap(result)
+	return nil, SessionOutput{Success: true, Session: &entry}, nil
+}
+
 func (dt *DaemonTools) handleSessionSend(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
 	if input.Code == "" {
 		return errorResult("code required for send"), SessionOutput{}, nil
@@ -239,7 +338,11 @@ func (dt *DaemonTools) handleSessionSend(input SessionInput) (*mcp.CallToolResul
 		return errorResult("message required for send"), SessionOutput{}, nil
 	}
 
-	result, err := dt.client.SessionSend(input.Code, input.Message)
+	var opts daemon.SendOptions
+	if input.Delivery != nil {
+		opts = *input.Delivery
+	}
+	result, err := dt.client.SessionSendWithOptions(input.Code, input.Message, opts)
 	if err != nil {
 		return formatDaemonError(err, "session"), SessionOutput{}, nil
 	}
@@ -254,14 +357,24 @@ func (dt *DaemonTools) handleSessionSchedule(input SessionInput) (*mcp.CallToolR
 	if input.Code == "" {
 		return errorResult("code required for schedule"), SessionOutput{}, nil
 	}
-	if input.Duration == "" {
-		return errorResult("duration required for schedule (e.g. '5m', '1h30m')"), SessionOutput{}, nil
+	if input.Duration == "" && input.Cron == "" {
+		return errorResult("duration (e.g. '5m', '1h30m') or cron (e.g. '*/30 * * * *') required for schedule"), SessionOutput{}, nil
 	}
 	if input.Message == "" {
 		return errorResult("message required for schedule"), SessionOutput{}, nil
 	}
 
-	result, err := dt.client.SessionSchedule(input.Code, input.Duration, input.Message)
+	var result map[string]interface{}
+	var err error
+	if input.Cron != "" {
+		result, err = dt.client.SessionScheduleCron(input.Code, input.Cron, input.Timezone, input.Message, input.WaitForIdle)
+	} else {
+		schedule := dt.client.SessionSchedule
+		if input.WaitForIdle {
+			schedule = dt.client.SessionScheduleWhenIdle
+		}
+		result, err = schedule(input.Code, input.Duration, input.Message)
+	}
 	if err != nil {
 		return formatDaemonError(err, "session"), SessionOutput{}, nil
 	}
@@ -281,11 +394,10 @@ func (dt *DaemonTools) handleSessionSchedule(input SessionInput) (*mcp.CallToolR
 	return nil, output, nil
 }
 
-func (dt *DaemonTools) handleSessionTasks(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return errorResult(fmt.Sprintf("failed to get working directory: %v", err)), SessionOutput{}, nil
+func (dt *DaemonTools) handleSessionTasks(ss *mcp.ServerSession, input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	cwd := dt.projectPath(ss)
+	if cwd == "" {
+		return errorResult("failed to get working directory"), SessionOutput{}, nil
 	}
 
 	// Create directory filter
@@ -308,26 +420,7 @@ func (dt *DaemonTools) handleSessionTasks(input SessionInput) (*mcp.CallToolResu
 	if tasks, ok := result["tasks"].([]interface{}); ok {
 		for _, t := range tasks {
 			if tm, ok := t.(map[string]interface{}); ok {
-				entry := TaskEntry{
-					ID:          getString(tm, "id"),
-					SessionCode: getString(tm, "session_code"),
-					Message:     getString(tm, "message"),
-					ProjectPath: getString(tm, "project_path"),
-					Status:      getString(tm, "status"),
-					Attempts:    getInt(tm, "attempts"),
-					LastError:   getString(tm, "last_error"),
-				}
-				if ts, ok := tm["deliver_at"].(string); ok {
-					if t, err := time.Parse(time.RFC3339, ts); err == nil {
-						entry.DeliverAt = t
-					}
-				}
-				if ts, ok := tm["created_at"].(string); ok {
-					if t, err := time.Parse(time.RFC3339, ts); err == nil {
-						entry.CreatedAt = t
-					}
-				}
-				output.Tasks = append(output.Tasks, entry)
+				output.Tasks = append(output.Tasks, convertToTaskEntry(tm))
 			}
 		}
 	}
@@ -335,6 +428,38 @@ func (dt *DaemonTools) handleSessionTasks(input SessionInput) (*mcp.CallToolResu
 	return nil, output, nil
 }
 
+// convertToTaskEntry converts a task from the daemon's response.
+func convertToTaskEntry(tm map[string]interface{}) TaskEntry {
+	entry := TaskEntry{
+		ID:          getString(tm, "id"),
+		SessionCode: getString(tm, "session_code"),
+		Message:     getString(tm, "message"),
+		ProjectPath: getString(tm, "project_path"),
+		Status:      getString(tm, "status"),
+		Attempts:    getInt(tm, "attempts"),
+		LastError:   getString(tm, "last_error"),
+		WaitForIdle: getBool(tm, "wait_for_idle"),
+		Cron:        getString(tm, "cron"),
+		Timezone:    getString(tm, "timezone"),
+		RunCount:    getInt(tm, "run_count"),
+		Overdue:     getBool(tm, "overdue"),
+	}
+	if ts, ok := tm["deliver_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			entry.DeliverAt = t
+		}
+	}
+	if ts, ok := tm["created_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			entry.CreatedAt = t
+		}
+	}
+	if b, err := json.Marshal(tm["runs"]); err == nil {
+		_ = json.Unmarshal(b, &entry.Runs)
+	}
+	return entry
+}
+
 func (dt *DaemonTools) handleSessionCancel(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
 	if input.TaskID == "" {
 		return errorResult("task_id required for cancel"), SessionOutput{}, nil
@@ -350,3 +475,134 @@ func (dt *DaemonTools) handleSessionCancel(input SessionInput) (*mcp.CallToolRes
 		Message: fmt.Sprintf("Task %s cancelled", input.TaskID),
 	}, nil
 }
+
+func (dt *DaemonTools) handleSessionPauseResume(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.TaskID == "" {
+		return errorResult(fmt.Sprintf("task_id required for %s", input.Action)), SessionOutput{}, nil
+	}
+
+	update := dt.client.SessionPause
+	if input.Action == "resume" {
+		update = dt.client.SessionResume
+	}
+	result, err := update(input.TaskID)
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	task := convertToTaskEntry(result)
+	return nil, SessionOutput{
+		Success: true,
+		Message: fmt.Sprintf("Task %s %s", input.TaskID, task.Status),
+		Task:    &task,
+	}, nil
+}
+
+func (dt *DaemonTools) handleSessionDigest(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for digest"), SessionOutput{}, nil
+	}
+
+	var result map[string]interface{}
+	var err error
+	if input.Off {
+		result, err = dt.client.SessionDigestOff(input.Code)
+	} else {
+		result, err = dt.client.SessionDigest(input.Code, input.Digest)
+	}
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	return nil, SessionOutput{
+		Success: true,
+		Digest:  result,
+	}, nil
+}
+
+func (dt *DaemonTools) handleSessionAlert(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for alert"), SessionOutput{}, nil
+	}
+
+	var result map[string]interface{}
+	var err error
+	if input.AlertID != "" {
+		result, err = dt.client.SessionAlertRemove(input.Code, input.AlertID)
+	} else {
+		result, err = dt.client.SessionAlerts(input.Code, input.Alert)
+	}
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	return nil, SessionOutput{
+		Success: true,
+		Alerts:  result,
+	}, nil
+}
+
+func (dt *DaemonTools) handleSessionStatus(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for status"), SessionOutput{}, nil
+	}
+
+	result, err := dt.client.SessionStatus(input.Code)
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	return nil, SessionOutput{Status: result}, nil
+}
+
+func (dt *DaemonTools) handleSessionClipboard(input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for clipboard"), SessionOutput{}, nil
+	}
+
+	if input.Message == "" {
+		result, err := dt.client.SessionClipboard(input.Code)
+		if err != nil {
+			return formatDaemonError(err, "session"), SessionOutput{}, nil
+		}
+		clip, _ := result["clipboard"].(map[string]interface{})
+		if clip == nil {
+			return nil, SessionOutput{Message: "Nothing moved between pages and terminal yet"}, nil
+		}
+		return nil, SessionOutput{Clipboard: clip}, nil
+	}
+
+	result, err := dt.client.SessionShareClipboard(input.Code, input.Message)
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	sent := getInt(result, "sent_count")
+	return nil, SessionOutput{
+		Success:   getBool(result, "success"),
+		SentCount: sent,
+		Message:   fmt.Sprintf("Shared %d bytes with %d page(s)", getInt(result, "bytes"), sent),
+	}, nil
+}
+
+func (dt *DaemonTools) handleSessionTranscript(ss *mcp.ServerSession, input SessionInput) (*mcp.CallToolResult, SessionOutput, error) {
+	if input.Code == "" {
+		return errorResult("code required for transcript"), SessionOutput{}, nil
+	}
+
+	var query daemon.TranscriptQuery
+	if input.Transcript != nil {
+		query = *input.Transcript
+	}
+	// Finds the transcript once the session has ended and unregistered
+	if query.ProjectPath == "" {
+		query.ProjectPath = dt.projectPath(ss)
+	}
+
+	result, err := dt.client.SessionTranscript(input.Code, query)
+	if err != nil {
+		return formatDaemonError(err, "session"), SessionOutput{}, nil
+	}
+
+	return nil, SessionOutput{Transcript: result}, nil
+}
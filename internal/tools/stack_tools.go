@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// StackInput represents input for the stack tool.
+type StackInput struct {
+	Action string `json:"action" jsonschema:"Action: start, stop, status, list"`
+	Name   string `json:"name,omitempty" jsonschema:"Stack name from the stacks block of .agnt.kdl (required except for list)"`
+}
+
+// StackOutput represents output from the stack tool.
+type StackOutput struct {
+	Name     string         `json:"name,omitempty"`
+	State    string         `json:"state,omitempty"`
+	Duration string         `json:"duration,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Services []StackService `json:"services,omitempty"`
+	Stopped  []string       `json:"stopped,omitempty"`
+	Stacks   []StackSummary `json:"stacks,omitempty"`
+}
+
+// StackService is one service of a stack.
+type StackService struct {
+	Name         string   `json:"name"`
+	Script       string   `json:"script"`
+	ProcessID    string   `json:"process_id"`
+	Level        int      `json:"level"`
+	DependsOn    []string `json:"depends_on,omitempty"`
+	State        string   `json:"state"`
+	ProcessState string   `json:"process_state,omitempty"`
+	ReadyIn      string   `json:"ready_in,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// StackSummary is a stack in the list action.
+type StackSummary struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Autostart bool       `json:"autostart,omitempty"`
+	Order     [][]string `json:"order,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// RegisterStackTool registers the stack MCP tool with the server.
+func RegisterStackTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "stack",
+		Description: `Start and stop groups of scripts as a unit, in dependency order.
+
+Stacks are defined in .agnt.kdl; services name a script (default: the service name),
+what they depend on, and when they count as ready:
+
+  stacks {
+      dev {
+          autostart true            // on session open, instead of each script's autostart
+          timeout-ms 60000          // per service
+          services {
+              db { ready-port 5432; }
+              api { depends-on "db"; ready-url "http://localhost:8080/health"; }
+              web { depends-on "api"; ready-log "ready in"; }
+          }
+      }
+  }
+
+Without a ready check a service is ready once it has run for a second. Start waits
+until every service is ready; if one exits or times out, its dependents are skipped.
+
+Actions:
+  start: Start the stack and wait until it is up or a service fails
+  stop: Stop its services, dependents first
+  status: State of each service (pending, starting, ready, failed, skipped, stopped)
+  list: The project's stacks with their startup order
+
+Examples:
+  stack {action: "start", name: "dev"}
+  stack {action: "status", name: "dev"}`,
+	}, dt.makeStackHandler())
+}
+
+// makeStackHandler creates a handler for the stack tool.
+func (dt *DaemonTools) makeStackHandler() func(context.Context, *mcp.CallToolRequest, StackInput) (*mcp.CallToolResult, StackOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input StackInput) (*mcp.CallToolResult, StackOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), StackOutput{}, nil
+		}
+		if input.Action != "list" && input.Name == "" {
+			return errorResult(fmt.Sprintf("name required for %s", input.Action)), StackOutput{}, nil
+		}
+
+		var result map[string]interface{}
+		var err error
+		switch input.Action {
+		case "start":
+			result, err = dt.client.StackStart(input.Name, dt.projectPath(req.Session))
+		case "stop":
+			result, err = dt.client.StackStop(input.Name, dt.projectPath(req.Session))
+		case "status":
+			result, err = dt.client.StackStatus(input.Name, dt.projectPath(req.Session))
+		case "list":
+			result, err = dt.client.StackList(dt.projectPath(req.Session))
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), StackOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "stack"), StackOutput{}, nil
+		}
+
+		var output StackOutput
+		if b, err := json.Marshal(result); err == nil {
+			json.Unmarshal(b, &output)
+		}
+		return nil, output, nil
+	}
+}
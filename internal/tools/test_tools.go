@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestInput represents input for the test tool.
+type TestInput struct {
+	Action    string `json:"action" jsonschema:"Action: run, record, history, flaky"`
+	ProcessID string `json:"process_id,omitempty" jsonschema:"For record: process whose output holds test results"`
+	Output    string `json:"output,omitempty" jsonschema:"For record: raw test runner output instead of a process"`
+	Source    string `json:"source,omitempty" jsonschema:"For record: label for the run (default: process ID)"`
+	Filter    string `json:"filter,omitempty" jsonschema:"For history: only show tests whose ID contains this text"`
+	Shards    int    `json:"shards,omitempty" jsonschema:"For run: number of parallel shards (default: 1)"`
+	Timeout   string `json:"timeout,omitempty" jsonschema:"For run: overall timeout such as '5m' (default: 10m)"`
+	Affected  bool   `json:"affected,omitempty" jsonschema:"For run: only run tests affected by uncommitted changes"`
+	Base      string `json:"base,omitempty" jsonschema:"For run with affected: also include changes since this git ref (e.g. 'main')"`
+	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"For run with affected: show which tests would run and why, without running them"`
+}
+
+// TestOutput represents output from the test tool.
+type TestOutput struct {
+	RunID      string            `json:"run_id,omitempty"`
+	Commit     string            `json:"commit,omitempty"`
+	Total      int               `json:"total,omitempty"`
+	Passed     int               `json:"passed,omitempty"`
+	Failed     int               `json:"failed,omitempty"`
+	Skipped    int               `json:"skipped,omitempty"`
+	KnownFlaky []string          `json:"known_flaky,omitempty"`
+	Runs       []TestRunEntry    `json:"runs,omitempty"`
+	Flaky      []FlakyTestOutput `json:"flaky,omitempty"`
+	Count      int               `json:"count,omitempty"`
+	Runner     string            `json:"runner,omitempty"`
+	Shards     []TestShardOutput `json:"shards,omitempty"`
+	Duration   string            `json:"duration,omitempty"`
+	TimedOut   bool              `json:"timed_out,omitempty"`
+	Coverage   *CoverageOutput   `json:"coverage,omitempty"`
+	Changed    []string          `json:"changed,omitempty"`
+	Selected   []SelectedTest    `json:"selected,omitempty"`
+	Message    string            `json:"message,omitempty"`
+}
+
+// SelectedTest is a test unit chosen by affected-test analysis and why.
+type SelectedTest struct {
+	Unit    string   `json:"unit"`
+	Reasons []string `json:"reasons"`
+}
+
+// TestShardOutput represents one shard of a sharded test run.
+type TestShardOutput struct {
+	Index     int      `json:"index"`
+	ProcessID string   `json:"process_id"`
+	Units     []string `json:"units"`
+	State     string   `json:"state"`
+	ExitCode  int      `json:"exit_code"`
+	Passed    int      `json:"passed"`
+	Failed    int      `json:"failed"`
+	Skipped   int      `json:"skipped,omitempty"`
+	Duration  string   `json:"duration,omitempty"`
+}
+
+// CoverageOutput describes merged coverage from a test run.
+type CoverageOutput struct {
+	File    string  `json:"file"`
+	Percent float64 `json:"percent"`
+}
+
+// TestRunEntry represents a run in a history response.
+type TestRunEntry struct {
+	RunID     string            `json:"run_id"`
+	Timestamp string            `json:"timestamp"`
+	Source    string            `json:"source,omitempty"`
+	Commit    string            `json:"commit,omitempty"`
+	Dirty     int               `json:"dirty,omitempty"`
+	Passed    int               `json:"passed"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped,omitempty"`
+	Outcomes  map[string]string `json:"outcomes,omitempty"`
+}
+
+// FlakyTestOutput represents a flaky test in a flaky report.
+type FlakyTestOutput struct {
+	ID               string `json:"id"`
+	Dir              string `json:"dir,omitempty"`
+	Runs             int    `json:"runs"`
+	Failures         int    `json:"failures"`
+	Flips            int    `json:"flips"`
+	UnexplainedFlips int    `json:"unexplained_flips"`
+	LastFlip         string `json:"last_flip,omitempty"`
+	Recent           string `json:"recent"`
+}
+
+// RegisterTestTool registers the test MCP tool with the server.
+func RegisterTestTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "test",
+		Description: `Track test outcomes across runs and find flaky tests.
+
+Actions:
+  run: Run the project's test suite (go test, jest, vitest or pytest), split by package/file
+       into parallel shards, record the combined results and merge coverage.
+       With affected, only tests related to changed files run (Go package graph,
+       JS imports and jest --findRelatedTests, Python imports and conftest.py),
+       and each selected unit lists the reasons it was picked
+  record: Parse test results from a process's output (go test -v/-json, jest, vitest, pytest -v)
+          and record them with the current git state
+  history: Recent runs with pass/fail counts; with filter, per-run outcomes of matching tests
+  flaky: Tests whose outcome flipped between runs with no related file changes
+
+Examples:
+  test {action: "run", shards: 4}
+  test {action: "run", affected: true, dry_run: true}
+  test {action: "run", affected: true, base: "main", shards: 2}
+  run {script_name: "test", mode: "foreground"}  then  test {action: "record", process_id: "test"}
+  test {action: "history", filter: "TestCheckout"}
+  test {action: "flaky"}
+
+A failure in a test listed by flaky (or returned in known_flaky by record) is likely
+not caused by your change.`,
+	}, dt.makeTestHandler())
+}
+
+// makeTestHandler creates a handler for the test tool.
+func (dt *DaemonTools) makeTestHandler() func(context.Context, *mcp.CallToolRequest, TestInput) (*mcp.CallToolResult, TestOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input TestInput) (*mcp.CallToolResult, TestOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), TestOutput{}, nil
+		}
+
+		switch input.Action {
+		case "run":
+			return dt.handleTestRun(req.Session, input)
+		case "record":
+			return dt.handleTestRecord(req.Session, input)
+		case "history":
+			return dt.handleTestHistory(input)
+		case "flaky":
+			return dt.handleFlakyList()
+		default:
+			return errorResult(fmt.Sprintf("unknown action: %s (use: run, record, history, flaky)", input.Action)), TestOutput{}, nil
+		}
+	}
+}
+
+func (dt *DaemonTools) handleTestRun(ss *mcp.ServerSession, input TestInput) (*mcp.CallToolResult, TestOutput, error) {
+	if input.Shards < 0 {
+		return errorResult("shards must be positive"), TestOutput{}, nil
+	}
+	if (input.DryRun || input.Base != "") && !input.Affected {
+		return errorResult("base and dry_run require affected: true"), TestOutput{}, nil
+	}
+
+	result, err := dt.client.TestRun(protocol.TestRunConfig{
+		Shards:   input.Shards,
+		Timeout:  input.Timeout,
+		Affected: input.Affected,
+		Base:     input.Base,
+		DryRun:   input.DryRun,
+		Path:     dt.projectPath(ss),
+	})
+	if err != nil {
+		return formatDaemonError(err, "test run"), TestOutput{}, nil
+	}
+
+	output := TestOutput{
+		RunID:    getString(result, "run_id"),
+		Runner:   getString(result, "runner"),
+		Total:    getInt(result, "total"),
+		Passed:   getInt(result, "passed"),
+		Failed:   getInt(result, "failed"),
+		Skipped:  getInt(result, "skipped"),
+		Duration: getString(result, "duration"),
+		TimedOut: getBool(result, "timed_out"),
+		Count:    getInt(result, "units"),
+		Message:  getString(result, "message"),
+	}
+	for key, dst := range map[string]interface{}{
+		"shards":   &output.Shards,
+		"selected": &output.Selected,
+		"changed":  &output.Changed,
+	} {
+		if raw, ok := result[key]; ok {
+			if b, err := json.Marshal(raw); err == nil {
+				json.Unmarshal(b, dst)
+			}
+		}
+	}
+	if cov, ok := result["coverage"].(map[string]interface{}); ok {
+		output.Coverage = &CoverageOutput{
+			File:    getString(cov, "file"),
+			Percent: getFloat64(cov, "percent"),
+		}
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleTestRecord(ss *mcp.ServerSession, input TestInput) (*mcp.CallToolResult, TestOutput, error) {
+	if input.ProcessID == "" && input.Output == "" {
+		return errorResult("process_id or output required"), TestOutput{}, nil
+	}
+
+	result, err := dt.client.TestRecord(protocol.TestRecordConfig{
+		ProcessID: input.ProcessID,
+		Output:    input.Output,
+		Source:    input.Source,
+		Path:      dt.projectPath(ss),
+	})
+	if err != nil {
+		return formatDaemonError(err, "test record"), TestOutput{}, nil
+	}
+
+	output := TestOutput{
+		RunID:   getString(result, "run_id"),
+		Commit:  getString(result, "commit"),
+		Total:   getInt(result, "total"),
+		Passed:  getInt(result, "passed"),
+		Failed:  getInt(result, "failed"),
+		Skipped: getInt(result, "skipped"),
+	}
+	if raw, ok := result["known_flaky"].([]interface{}); ok {
+		for _, id := range raw {
+			if s, ok := id.(string); ok {
+				output.KnownFlaky = append(output.KnownFlaky, s)
+			}
+		}
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleTestHistory(input TestInput) (*mcp.CallToolResult, TestOutput, error) {
+	result, err := dt.client.TestHistory(input.Filter)
+	if err != nil {
+		return formatDaemonError(err, "test history"), TestOutput{}, nil
+	}
+
+	output := TestOutput{Count: getInt(result, "count")}
+	if raw, ok := result["runs"].([]interface{}); ok {
+		for _, r := range raw {
+			rm, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			entry := TestRunEntry{
+				RunID:     getString(rm, "run_id"),
+				Timestamp: getString(rm, "timestamp"),
+				Source:    getString(rm, "source"),
+				Commit:    getString(rm, "commit"),
+				Dirty:     getInt(rm, "dirty"),
+				Passed:    getInt(rm, "passed"),
+				Failed:    getInt(rm, "failed"),
+				Skipped:   getInt(rm, "skipped"),
+			}
+			if outcomes, ok := rm["outcomes"].(map[string]interface{}); ok {
+				entry.Outcomes = make(map[string]string, len(outcomes))
+				for id, o := range outcomes {
+					entry.Outcomes[id], _ = o.(string)
+				}
+			}
+			output.Runs = append(output.Runs, entry)
+		}
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleFlakyList() (*mcp.CallToolResult, TestOutput, error) {
+	result, err := dt.client.FlakyList()
+	if err != nil {
+		return formatDaemonError(err, "flaky list"), TestOutput{}, nil
+	}
+
+	output := TestOutput{Count: getInt(result, "count")}
+	if raw, ok := result["flaky"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			json.Unmarshal(b, &output.Flaky)
+		}
+	}
+
+	return nil, output, nil
+}
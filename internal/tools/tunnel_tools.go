@@ -11,14 +11,26 @@ import (
 
 // TunnelInput represents input for the tunnel tool.
 type TunnelInput struct {
-	Action     string `json:"action" jsonschema:"Action: start, stop, status, list"`
-	ID         string `json:"id,omitempty" jsonschema:"Tunnel ID (required for start/stop/status)"`
-	Provider   string `json:"provider,omitempty" jsonschema:"Tunnel provider: 'cloudflare' or 'ngrok' (required for start)"`
+	Action     string `json:"action" jsonschema:"Action: start, stop, status, list, resume, label, events"`
+	ID         string `json:"id,omitempty" jsonschema:"Tunnel ID (required for start/stop/status/resume/label; for events: only this tunnel)"`
+	Provider   string `json:"provider,omitempty" jsonschema:"Tunnel provider: 'cloudflare', 'ngrok', 'localtunnel', 'tailscale' or 'custom' (required for start)"`
 	LocalPort  int    `json:"local_port,omitempty" jsonschema:"Local port to tunnel (required for start)"`
 	LocalHost  string `json:"local_host,omitempty" jsonschema:"Local host (default: localhost)"`
 	BinaryPath string `json:"binary_path,omitempty" jsonschema:"Optional path to tunnel binary"`
+	Command    string `json:"command,omitempty" jsonschema:"For the custom provider: command line to run, with {{PORT}} replaced by the port to expose (e.g. 'ssh -R 80:localhost:{{PORT}} nokey@localhost.run')"`
+	URLPattern string `json:"url_pattern,omitempty" jsonschema:"For the custom provider: regexp finding the public URL in the command's output, its first group if it has one (default: the first https URL)"`
 	ProxyID    string `json:"proxy_id,omitempty" jsonschema:"Optional proxy ID to auto-configure with the tunnel's public URL"`
+	MaxBytes   int64  `json:"max_bytes,omitempty" jsonschema:"Optional bandwidth cap in bytes (in + out). The tunnel pauses with a toast when exceeded; use resume to continue."`
+	Expires    string `json:"expires,omitempty" jsonschema:"Optional TTL such as '30m' or '2h'. The tunnel auto-stops and the proxy's public URL is cleared when it elapses, with a warning toast beforehand."`
 	Global     bool   `json:"global,omitempty" jsonschema:"For list: include tunnels from all directories (default: false)"`
+
+	NoReconnect bool   `json:"no_reconnect,omitempty" jsonschema:"For start: leave the tunnel failed when the provider drops instead of restarting it with backoff"`
+	AccessToken string `json:"access_token,omitempty" jsonschema:"For start with proxy_id: token the proxy requires of requests through the tunnel ('auto' generates one); share the returned share_url. Requests from this machine need none"`
+	BasicAuth   string `json:"basic_auth,omitempty" jsonschema:"For start with proxy_id: 'user:password' the proxy requires as HTTP basic auth of requests through the tunnel. Requests from this machine need none"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"For events: only the newest N events"`
+
+	Labels       map[string]string `json:"labels,omitempty" jsonschema:"For start and label: labels to set (e.g. {owner: payments}); for list: only tunnels with all these labels (an empty value matches any)"`
+	RemoveLabels []string          `json:"remove_labels,omitempty" jsonschema:"For label: label keys to remove"`
 }
 
 // TunnelOutput represents output from the tunnel tool.
@@ -29,10 +41,25 @@ type TunnelOutput struct {
 	PublicURL string        `json:"public_url,omitempty"`
 	LocalAddr string        `json:"local_addr,omitempty"`
 	Error     string        `json:"error,omitempty"`
+	BytesIn   int64         `json:"bytes_in,omitempty"`
+	BytesOut  int64         `json:"bytes_out,omitempty"`
+	RateIn    float64       `json:"rate_in,omitempty"`
+	RateOut   float64       `json:"rate_out,omitempty"`
+	MaxBytes  int64         `json:"max_bytes,omitempty"`
+	ExpiresAt string        `json:"expires_at,omitempty"`
 	Success   bool          `json:"success,omitempty"`
 	Message   string        `json:"message,omitempty"`
 	Count     int           `json:"count,omitempty"`
 	Tunnels   []TunnelEntry `json:"tunnels,omitempty"`
+
+	Reconnects int           `json:"reconnects,omitempty"`
+	Events     []TunnelEvent `json:"events,omitempty"`
+
+	Auth        string `json:"auth,omitempty"`
+	AccessToken string `json:"access_token,omitempty"`
+	ShareURL    string `json:"share_url,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // TunnelEntry represents a tunnel in a list response.
@@ -44,6 +71,23 @@ type TunnelEntry struct {
 	LocalAddr string `json:"local_addr"`
 	Path      string `json:"path,omitempty"`
 	Error     string `json:"error,omitempty"`
+	BytesIn   int64  `json:"bytes_in,omitempty"`
+	BytesOut  int64  `json:"bytes_out,omitempty"`
+
+	Reconnects int               `json:"reconnects,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// TunnelEvent is a disconnect, failed health check or reconnect of a tunnel.
+type TunnelEvent struct {
+	Time      string `json:"time"`
+	TunnelID  string `json:"tunnel_id"`
+	Type      string `json:"type"`
+	State     string `json:"state"`
+	Attempt   int    `json:"attempt,omitempty"`
+	RetryIn   string `json:"retry_in,omitempty"`
+	PublicURL string `json:"public_url,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
 
 // RegisterTunnelTool registers the tunnel MCP tool with the server.
@@ -55,26 +99,56 @@ func RegisterTunnelTool(server *mcp.Server, dt *DaemonTools) {
 Actions:
   start: Start a tunnel to expose a local port publicly
   stop: Stop a running tunnel
-  status: Get tunnel status and public URL
+  status: Get tunnel status, public URL, and bandwidth usage (bytes and rates)
   list: List all active tunnels
+  resume: Resume a tunnel paused by its bandwidth cap
+  label: Set labels (key/value tags) on a tunnel, remove them with remove_labels;
+         list with labels only shows matching tunnels
+  events: Disconnects, failed health checks and reconnects, also of ended tunnels
+
+A tunnel whose provider drops (or whose public URL stops answering three health
+checks, every 30s) is restarted with exponential backoff (1s up to 1m, 10
+attempts); the linked proxy and the project's sessions are told when it
+disconnects, reconnects (quick tunnels come back on a new URL) or gives up.
+no_reconnect turns this off.
 
 Providers:
   cloudflare: Uses cloudflared for Cloudflare Quick Tunnels (trycloudflare.com)
   ngrok: Uses ngrok for tunneling
+  localtunnel: Uses the localtunnel client 'lt' (loca.lt). Visitors first see a
+               reminder page asking for the tunnel password, the machine's public IP
+  tailscale: Uses Tailscale Funnel on the machine's ts.net name (Funnel must be enabled)
+  custom: Runs command and takes the public URL from its output via url_pattern
 
 Examples:
   tunnel {action: "start", id: "dev", provider: "cloudflare", local_port: 8080}
   tunnel {action: "start", id: "dev", provider: "cloudflare", local_port: 12345, proxy_id: "dev"}
+  tunnel {action: "start", id: "dev", provider: "ngrok", local_port: 8080, max_bytes: 1073741824}
+  tunnel {action: "start", id: "dev", provider: "cloudflare", local_port: 12345, proxy_id: "dev", expires: "2h"}
+  tunnel {action: "start", id: "dev", provider: "cloudflare", local_port: 12345, proxy_id: "dev", access_token: "auto"}
+  tunnel {action: "start", id: "dev", provider: "ngrok", local_port: 12345, proxy_id: "dev", basic_auth: "dev:s3cret"}
+  tunnel {action: "start", id: "dev", provider: "tailscale", local_port: 12345, proxy_id: "dev"}
+  tunnel {action: "start", id: "dev", provider: "custom", local_port: 12345, command: "ssh -R 80:localhost:{{PORT}} nokey@localhost.run", url_pattern: "https://[a-z0-9]+\\.lhr\\.life"}
   tunnel {action: "status", id: "dev"}
   tunnel {action: "list"}
+  tunnel {action: "list", labels: {owner: "payments"}}
+  tunnel {action: "events", id: "dev", limit: 20}
+  tunnel {action: "label", id: "dev", labels: {owner: "payments"}}
   tunnel {action: "stop", id: "dev"}
 
 The tunnel automatically configures the proxy's public_url when proxy_id is specified,
 enabling proper URL rewriting for mobile device testing through the tunnel.
 
+With access_token or basic_auth the proxy answers 401 to requests arriving through
+the tunnel without the credentials, while requests from this machine pass. Share the
+returned share_url: its token is exchanged for a cookie on first visit. API clients
+may send "Authorization: Bearer <token>". The protection ends with the tunnel.
+
 Requirements:
   - cloudflare provider: 'cloudflared' binary must be installed and in PATH
-  - ngrok provider: 'ngrok' binary must be installed and in PATH`,
+  - ngrok provider: 'ngrok' binary must be installed and in PATH
+  - localtunnel provider: 'lt' binary (npm install -g localtunnel) in PATH
+  - tailscale provider: 'tailscale' binary in PATH, logged in, with Funnel allowed for the node`,
 	}, dt.makeTunnelHandler())
 }
 
@@ -96,8 +170,14 @@ func (dt *DaemonTools) makeTunnelHandler() func(context.Context, *mcp.CallToolRe
 			return dt.handleTunnelStatus(input)
 		case "list":
 			return dt.handleTunnelList(input)
+		case "resume":
+			return dt.handleTunnelResume(input)
+		case "label":
+			return dt.handleTunnelLabel(input)
+		case "events":
+			return dt.handleTunnelEvents(input)
 		default:
-			return errorResult(fmt.Sprintf("unknown action: %s (use: start, stop, status, list)", input.Action)), emptyOutput, nil
+			return errorResult(fmt.Sprintf("unknown action: %s (use: start, stop, status, list, resume, label, events)", input.Action)), emptyOutput, nil
 		}
 	}
 }
@@ -109,7 +189,7 @@ func (dt *DaemonTools) handleTunnelStart(input TunnelInput) (*mcp.CallToolResult
 		return errorResult("id required"), emptyOutput, nil
 	}
 	if input.Provider == "" {
-		return errorResult("provider required (cloudflare or ngrok)"), emptyOutput, nil
+		return errorResult("provider required (cloudflare, ngrok, localtunnel, tailscale or custom)"), emptyOutput, nil
 	}
 	if input.LocalPort <= 0 {
 		return errorResult("local_port required"), emptyOutput, nil
@@ -122,6 +202,15 @@ func (dt *DaemonTools) handleTunnelStart(input TunnelInput) (*mcp.CallToolResult
 		LocalHost:  input.LocalHost,
 		BinaryPath: input.BinaryPath,
 		ProxyID:    input.ProxyID,
+		Command:    input.Command,
+		URLPattern: input.URLPattern,
+		MaxBytes:   input.MaxBytes,
+		Expires:    input.Expires,
+		Labels:     input.Labels,
+
+		NoReconnect: input.NoReconnect,
+		AccessToken: input.AccessToken,
+		BasicAuth:   input.BasicAuth,
 	}
 
 	result, err := dt.client.TunnelStart(config)
@@ -136,7 +225,14 @@ func (dt *DaemonTools) handleTunnelStart(input TunnelInput) (*mcp.CallToolResult
 		PublicURL: getString(result, "public_url"),
 		LocalAddr: getString(result, "local_addr"),
 		Error:     getString(result, "error"),
+		MaxBytes:  getInt64(result, "max_bytes"),
+		ExpiresAt: getString(result, "expires_at"),
 		Tunnels:   []TunnelEntry{},
+		Labels:    getLabels(result, "labels"),
+
+		Auth:        getString(result, "auth"),
+		AccessToken: getString(result, "access_token"),
+		ShareURL:    getString(result, "share_url"),
 	}
 
 	return nil, output, nil
@@ -182,7 +278,58 @@ func (dt *DaemonTools) handleTunnelStatus(input TunnelInput) (*mcp.CallToolResul
 		PublicURL: getString(result, "public_url"),
 		LocalAddr: getString(result, "local_addr"),
 		Error:     getString(result, "error"),
+		BytesIn:   getInt64(result, "bytes_in"),
+		BytesOut:  getInt64(result, "bytes_out"),
+		RateIn:    getFloat64(result, "rate_in"),
+		RateOut:   getFloat64(result, "rate_out"),
+		MaxBytes:  getInt64(result, "max_bytes"),
+		ExpiresAt: getString(result, "expires_at"),
 		Tunnels:   []TunnelEntry{},
+
+		Reconnects: getInt(result, "reconnects"),
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleTunnelResume(input TunnelInput) (*mcp.CallToolResult, TunnelOutput, error) {
+	emptyOutput := TunnelOutput{Tunnels: []TunnelEntry{}}
+
+	if input.ID == "" {
+		return errorResult("id required"), emptyOutput, nil
+	}
+
+	if err := dt.client.TunnelResume(input.ID); err != nil {
+		return formatDaemonError(err, "tunnel resume"), emptyOutput, nil
+	}
+
+	output := TunnelOutput{
+		Success: true,
+		ID:      input.ID,
+		Message: "Tunnel resumed",
+		Tunnels: []TunnelEntry{},
+	}
+
+	return nil, output, nil
+}
+
+func (dt *DaemonTools) handleTunnelLabel(input TunnelInput) (*mcp.CallToolResult, TunnelOutput, error) {
+	emptyOutput := TunnelOutput{Tunnels: []TunnelEntry{}}
+
+	if input.ID == "" {
+		return errorResult("id required"), emptyOutput, nil
+	}
+
+	result, err := dt.client.TunnelLabel(input.ID, input.Labels, input.RemoveLabels)
+	if err != nil {
+		return formatDaemonError(err, "tunnel label"), emptyOutput, nil
+	}
+
+	output := TunnelOutput{
+		Success: true,
+		ID:      getString(result, "id"),
+		Labels:  getLabels(result, "labels"),
+		Tunnels: []TunnelEntry{},
 	}
 
 	return nil, output, nil
@@ -193,7 +340,7 @@ func (dt *DaemonTools) handleTunnelList(input TunnelInput) (*mcp.CallToolResult,
 		Global: input.Global,
 	}
 
-	result, err := dt.client.TunnelList(dirFilter)
+	result, err := dt.client.TunnelListFiltered(protocol.ListFilter{DirectoryFilter: dirFilter, Labels: input.Labels})
 	if err != nil {
 		return formatDaemonError(err, "tunnel list"), TunnelOutput{Tunnels: []TunnelEntry{}}, nil
 	}
@@ -212,6 +359,11 @@ func (dt *DaemonTools) handleTunnelList(input TunnelInput) (*mcp.CallToolResult,
 				LocalAddr: getString(tm, "local_addr"),
 				Path:      getString(tm, "path"),
 				Error:     getString(tm, "error"),
+				BytesIn:   getInt64(tm, "bytes_in"),
+				BytesOut:  getInt64(tm, "bytes_out"),
+				Labels:    getLabels(tm, "labels"),
+
+				Reconnects: getInt(tm, "reconnects"),
 			})
 		}
 	}
@@ -223,3 +375,36 @@ func (dt *DaemonTools) handleTunnelList(input TunnelInput) (*mcp.CallToolResult,
 
 	return nil, output, nil
 }
+
+func (dt *DaemonTools) handleTunnelEvents(input TunnelInput) (*mcp.CallToolResult, TunnelOutput, error) {
+	result, err := dt.client.TunnelEvents(input.ID, input.Limit)
+	if err != nil {
+		return formatDaemonError(err, "tunnel events"), TunnelOutput{Tunnels: []TunnelEntry{}}, nil
+	}
+
+	eventsRaw, _ := result["events"].([]interface{})
+	events := make([]TunnelEvent, 0, len(eventsRaw))
+	for _, e := range eventsRaw {
+		if em, ok := e.(map[string]interface{}); ok {
+			events = append(events, TunnelEvent{
+				Time:      getString(em, "time"),
+				TunnelID:  getString(em, "tunnel_id"),
+				Type:      getString(em, "type"),
+				State:     getString(em, "state"),
+				Attempt:   getInt(em, "attempt"),
+				RetryIn:   getString(em, "retry_in"),
+				PublicURL: getString(em, "public_url"),
+				Error:     getString(em, "error"),
+			})
+		}
+	}
+
+	output := TunnelOutput{
+		ID:      input.ID,
+		Count:   len(events),
+		Events:  events,
+		Tunnels: []TunnelEntry{},
+	}
+
+	return nil, output, nil
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/crash"
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/proxy"
+)
+
+// WaitInput represents input for the wait tool.
+type WaitInput struct {
+	Kinds     []string `json:"kinds,omitempty" jsonschema:"Errors to wait for: frontend_error, http_5xx, process_failure (default: all)"`
+	Source    string   `json:"source,omitempty" jsonschema:"Only errors of this proxy or process; an ID part such as dev is enough"`
+	Match     string   `json:"match,omitempty" jsonschema:"Regex the error message, URL or stack must match"`
+	TimeoutMs int      `json:"timeout_ms,omitempty" jsonschema:"How long to wait (default: 60000, max: 300000)"`
+	Global    bool     `json:"global,omitempty" jsonschema:"Wait for errors of all projects, not only this one"`
+}
+
+// WaitOutput represents output from the wait tool.
+type WaitOutput struct {
+	TimedOut bool       `json:"timed_out,omitempty"`
+	WaitedMs int64      `json:"waited_ms"`
+	Event    *WaitEvent `json:"event,omitempty"`
+}
+
+// WaitEvent is the error a wait returned, with what led up to it.
+type WaitEvent struct {
+	Kind           string   `json:"kind"`
+	Time           string   `json:"time"`
+	Source         string   `json:"source"`
+	Message        string   `json:"message"`
+	PageURL        string   `json:"page_url,omitempty"`
+	Location       string   `json:"location,omitempty"`
+	Stack          string   `json:"stack,omitempty"`
+	ExitCode       int      `json:"exit_code,omitempty"`
+	CrashID        string   `json:"crash_id,omitempty"`
+	Stderr         []string `json:"stderr,omitempty"`
+	FailedRequests []string `json:"failed_requests,omitempty"`
+	Interactions   []string `json:"interactions,omitempty"`
+}
+
+// waitEventWire mirrors the event of a WAIT ERROR response.
+type waitEventWire struct {
+	Kind     string               `json:"kind"`
+	Time     time.Time            `json:"time"`
+	Source   string               `json:"source"`
+	Message  string               `json:"message"`
+	Error    *proxy.FrontendError `json:"error"`
+	HTTP     *proxy.HTTPLogEntry  `json:"http"`
+	ExitCode int                  `json:"exit_code"`
+	Crash    *crash.Report        `json:"crash"`
+	Stderr   []string             `json:"stderr"`
+	Recent   []proxy.LogEntry     `json:"recent"`
+}
+
+// RegisterWaitTool registers the wait MCP tool with the server.
+func RegisterWaitTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "wait",
+		Description: `Block until the next error, to check whether a fix held or to catch a flaky one.
+
+Waits for the next frontend error or 5xx response through one of the project's proxies,
+or the next process of the project to fail, and returns it with its context: the failed
+requests and interactions in the 15s before a proxy error, or the stderr tail and crash
+report of a failed process. Only errors after the call count. Returns timed_out when
+nothing happens within timeout_ms.
+
+Examples:
+  wait {}
+  wait {kinds: ["frontend_error"], match: "checkout", timeout_ms: 120000}
+  wait {kinds: ["process_failure"], source: "api"}`,
+	}, dt.makeWaitHandler())
+}
+
+// makeWaitHandler creates a handler for the wait tool.
+func (dt *DaemonTools) makeWaitHandler() func(context.Context, *mcp.CallToolRequest, WaitInput) (*mcp.CallToolResult, WaitOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input WaitInput) (*mcp.CallToolResult, WaitOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), WaitOutput{}, nil
+		}
+
+		result, err := dt.client.WaitError(protocol.WaitErrorConfig{
+			Kinds:     input.Kinds,
+			Source:    input.Source,
+			Match:     input.Match,
+			TimeoutMs: input.TimeoutMs,
+			Global:    input.Global,
+			Path:      dt.projectPath(req.Session),
+		})
+		if err != nil {
+			return formatDaemonError(err, "wait"), WaitOutput{}, nil
+		}
+
+		output := WaitOutput{}
+		output.TimedOut, _ = result["timed_out"].(bool)
+		if ms, ok := result["waited_ms"].(float64); ok {
+			output.WaitedMs = int64(ms)
+		}
+		if raw, ok := result["event"]; ok {
+			var wire waitEventWire
+			if b, err := json.Marshal(raw); err == nil {
+				json.Unmarshal(b, &wire)
+			}
+			output.Event = decodeWaitEvent(wire)
+		}
+		return nil, output, nil
+	}
+}
+
+// decodeWaitEvent flattens an event into what an agent acts on.
+func decodeWaitEvent(wire waitEventWire) *WaitEvent {
+	e := &WaitEvent{
+		Kind:     wire.Kind,
+		Time:     wire.Time.Format(time.RFC3339),
+		Source:   wire.Source,
+		Message:  wire.Message,
+		ExitCode: wire.ExitCode,
+		Stderr:   wire.Stderr,
+	}
+	if fe := wire.Error; fe != nil {
+		e.PageURL = fe.URL
+		e.Stack = fe.Stack
+		if fe.Source != "" {
+			e.Location = fmt.Sprintf("%s:%d:%d", fe.Source, fe.LineNo, fe.ColNo)
+		}
+	}
+	if wire.HTTP != nil {
+		e.PageURL = wire.HTTP.URL
+	}
+	if wire.Crash != nil {
+		e.CrashID = wire.Crash.ID
+		if len(e.Stderr) == 0 && wire.Crash.StderrTail != "" {
+			e.Stderr = strings.Split(strings.TrimRight(wire.Crash.StderrTail, "\n"), "\n")
+		}
+	}
+	for _, entry := range wire.Recent {
+		switch {
+		case entry.HTTP != nil:
+			e.FailedRequests = append(e.FailedRequests, describeFailedRequest(entry.HTTP))
+		case entry.Interaction != nil:
+			e.Interactions = append(e.Interactions, describeInteraction(entry.Interaction))
+		}
+	}
+	return e
+}
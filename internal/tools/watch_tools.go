@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/agnt/internal/protocol"
+)
+
+// WatchInput represents input for the watch tool.
+type WatchInput struct {
+	Action     string `json:"action" jsonschema:"Action: add, list, remove"`
+	Pattern    string `json:"pattern,omitempty" jsonschema:"For add: glob relative to the project, such as src/**/*.ts or *.go (without / it matches file names anywhere)"`
+	Script     string `json:"script,omitempty" jsonschema:"For add: script or process to re-run on change (omit to only notify)"`
+	Notify     *bool  `json:"notify,omitempty" jsonschema:"For add: tell the project's sessions and pages what changed (default: only without a script)"`
+	DebounceMs int    `json:"debounce_ms,omitempty" jsonschema:"For add: quiet time after the last change before firing (default: 300)"`
+	ID         string `json:"id,omitempty" jsonschema:"For remove: watch ID"`
+}
+
+// WatchOutput represents output from the watch tool.
+type WatchOutput struct {
+	Watch   *WatchInfo  `json:"watch,omitempty"`
+	Watches []WatchInfo `json:"watches,omitempty"`
+	Count   int         `json:"count,omitempty"`
+	Success bool        `json:"success,omitempty"`
+}
+
+// WatchInfo is one watch.
+type WatchInfo struct {
+	ID          string   `json:"id"`
+	Pattern     string   `json:"pattern"`
+	Script      string   `json:"script,omitempty"`
+	Notify      bool     `json:"notify"`
+	DebounceMs  int      `json:"debounce_ms"`
+	Files       int      `json:"files"`
+	Triggers    int      `json:"triggers"`
+	LastTrigger string   `json:"last_trigger,omitempty"`
+	LastChanges []string `json:"last_changes,omitempty"`
+	LastError   string   `json:"last_error,omitempty"`
+}
+
+// RegisterWatchTool registers the watch MCP tool with the server.
+func RegisterWatchTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "watch",
+		Description: `Re-run a script when project files change, like nodemon for any project type.
+
+The daemon scans the files matching a glob every second. When some change, it waits
+for debounce_ms without further changes, then restarts the script's process (or starts
+it from .agnt.kdl if it isn't running). With notify, or without a script, it also tells
+the project's sessions and proxied pages which files changed. .git, node_modules and
+.agnt are never watched.
+
+Actions:
+  add: Watch a glob, re-running script and/or notifying on change
+  list: The project's watches with their trigger counts and last changes
+  remove: Stop a watch
+
+Examples:
+  watch {action: "add", pattern: "*.go", script: "server"}
+  watch {action: "add", pattern: "src/**/*.ts"}
+  watch {action: "remove", id: "watch-1"}`,
+	}, dt.makeWatchHandler())
+}
+
+// makeWatchHandler creates a handler for the watch tool.
+func (dt *DaemonTools) makeWatchHandler() func(context.Context, *mcp.CallToolRequest, WatchInput) (*mcp.CallToolResult, WatchOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input WatchInput) (*mcp.CallToolResult, WatchOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), WatchOutput{}, nil
+		}
+
+		switch input.Action {
+		case "add":
+			if input.Pattern == "" {
+				return errorResult("pattern required for add"), WatchOutput{}, nil
+			}
+			result, err := dt.client.WatchAdd(input.Pattern, input.Script, dt.projectPath(req.Session), protocol.WatchConfig{
+				DebounceMs: input.DebounceMs,
+				Notify:     input.Notify,
+			})
+			if err != nil {
+				return formatDaemonError(err, "watch"), WatchOutput{}, nil
+			}
+			var info WatchInfo
+			if b, err := json.Marshal(result); err == nil {
+				json.Unmarshal(b, &info)
+			}
+			return nil, WatchOutput{Watch: &info, Success: true}, nil
+		case "list":
+			result, err := dt.client.WatchList(dt.projectPath(req.Session))
+			if err != nil {
+				return formatDaemonError(err, "watch"), WatchOutput{}, nil
+			}
+			var output WatchOutput
+			if b, err := json.Marshal(result); err == nil {
+				json.Unmarshal(b, &output)
+			}
+			return nil, output, nil
+		case "remove":
+			if input.ID == "" {
+				return errorResult("id required for remove"), WatchOutput{}, nil
+			}
+			if err := dt.client.WatchRemove(input.ID, dt.projectPath(req.Session)); err != nil {
+				return formatDaemonError(err, "watch"), WatchOutput{}, nil
+			}
+			return nil, WatchOutput{Success: true}, nil
+		default:
+			return errorResult(fmt.Sprintf("unknown action %q", input.Action)), WatchOutput{}, nil
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/standardbeagle/agnt/internal/protocol"
+	"github.com/standardbeagle/agnt/internal/workspace"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// WorkspaceInput represents input for the workspace tool.
+type WorkspaceInput struct {
+	Action string `json:"action" jsonschema:"Action: create, list, get, remove"`
+	ID     string `json:"id,omitempty" jsonschema:"Workspace ID (required for get/remove; optional for create, default ws-N)"`
+	Ref    string `json:"ref,omitempty" jsonschema:"For create: git ref to check out (default: the current working state, uncommitted changes included)"`
+	Method string `json:"method,omitempty" jsonschema:"For create: worktree or copy (default: worktree in git projects)"`
+	Global bool   `json:"global,omitempty" jsonschema:"For list: include workspaces of all projects"`
+}
+
+// WorkspaceOutput represents output from the workspace tool.
+type WorkspaceOutput struct {
+	Workspace        *workspace.Workspace   `json:"workspace,omitempty"`
+	Workspaces       []*workspace.Workspace `json:"workspaces,omitempty"`
+	Count            int                    `json:"count,omitempty"`
+	Removed          bool                   `json:"removed,omitempty"`
+	StoppedProcesses []string               `json:"stopped_processes,omitempty"`
+}
+
+// RegisterWorkspaceTool registers the workspace MCP tool with the server.
+func RegisterWorkspaceTool(server *mcp.Server, dt *DaemonTools) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "workspace",
+		Description: `Disposable copies of the project for experiments that must not touch the working tree.
+
+Actions:
+  create: Check the project out into a temporary directory (git worktree, or a plain copy)
+  list: List workspaces of the project
+  get: Get a workspace and its path
+  remove: Stop the processes running in a workspace and delete it
+
+Without a ref, a worktree holds the current working state including uncommitted changes
+to tracked files; the copy method also includes untracked files. Run scripts in a
+workspace with run {workspace: "ws-1", ...}. Workspaces are removed when the session ends.
+
+Examples:
+  workspace {action: "create"}
+  workspace {action: "create", id: "base", ref: "main"}
+  run {workspace: "base", raw: true, command: "npm", args: ["test"]}
+  workspace {action: "remove", id: "base"}`,
+	}, dt.makeWorkspaceHandler())
+}
+
+// makeWorkspaceHandler creates a handler for the workspace tool.
+func (dt *DaemonTools) makeWorkspaceHandler() func(context.Context, *mcp.CallToolRequest, WorkspaceInput) (*mcp.CallToolResult, WorkspaceOutput, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input WorkspaceInput) (*mcp.CallToolResult, WorkspaceOutput, error) {
+		if err := dt.ensureConnected(); err != nil {
+			return errorResult(err.Error()), WorkspaceOutput{}, nil
+		}
+
+		var (
+			result map[string]interface{}
+			err    error
+		)
+		switch input.Action {
+		case "create":
+			result, err = dt.client.WorkspaceCreate(workspace.CreateOptions{
+				ID:          input.ID,
+				ProjectPath: dt.projectPath(req.Session),
+				Ref:         input.Ref,
+				Method:      input.Method,
+				SessionCode: dt.sessionCodeFor(req.Session),
+			})
+		case "list":
+			filter := protocol.DirectoryFilter{Global: input.Global}
+			if !input.Global {
+				filter.Directory = dt.projectPath(req.Session)
+			}
+			result, err = dt.client.WorkspaceList(filter)
+		case "get", "remove":
+			if input.ID == "" {
+				return errorResult("id required for " + input.Action), WorkspaceOutput{}, nil
+			}
+			if input.Action == "get" {
+				result, err = dt.client.WorkspaceGet(input.ID)
+			} else {
+				result, err = dt.client.WorkspaceRemove(input.ID)
+			}
+		default:
+			return errorResult(fmt.Sprintf("unknown action: %s (use: create, list, get, remove)", input.Action)), WorkspaceOutput{}, nil
+		}
+		if err != nil {
+			return formatDaemonError(err, "workspace "+input.Action), WorkspaceOutput{}, nil
+		}
+
+		var output WorkspaceOutput
+		switch input.Action {
+		case "create", "get":
+			output.Workspace = decodeWorkspace(result)
+		case "list":
+			output.Count = getInt(result, "count")
+			if b, err := json.Marshal(result["workspaces"]); err == nil {
+				json.Unmarshal(b, &output.Workspaces)
+			}
+		case "remove":
+			output.Removed = getBool(result, "removed")
+			if b, err := json.Marshal(result["stopped_processes"]); err == nil {
+				json.Unmarshal(b, &output.StoppedProcesses)
+			}
+		}
+		return nil, output, nil
+	}
+}
+
+// decodeWorkspace converts a workspace from a daemon response.
+func decodeWorkspace(result map[string]interface{}) *workspace.Workspace {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil
+	}
+	var ws workspace.Workspace
+	if json.Unmarshal(b, &ws) != nil {
+		return nil
+	}
+	return &ws
+}
@@ -0,0 +1,167 @@
+package topology
+
+import (
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dialTimeout bounds connecting to a forward's target and reachability probes.
+const dialTimeout = 500 * time.Millisecond
+
+// Want is a loopback listener that should be reachable from the host.
+type Want struct {
+	Kind string // proxy or url
+	ID   string // Proxy or process ID
+	Port int
+}
+
+// Forward relays connections from the external address to a loopback port.
+type Forward struct {
+	Kind        string    `json:"kind"`
+	ID          string    `json:"id"`
+	Port        int       `json:"port"`
+	Listen      string    `json:"listen"`
+	Target      string    `json:"target"`
+	Connections int64     `json:"connections"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type forward struct {
+	Forward
+	ln    net.Listener
+	conns atomic.Int64
+}
+
+// Forwarder keeps a forward for each wanted port that the host can't reach
+// directly.
+type Forwarder struct {
+	listenHost string
+
+	mu       sync.Mutex
+	forwards map[int]*forward
+}
+
+// NewForwarder returns a forwarder listening on listenHost.
+func NewForwarder(listenHost string) *Forwarder {
+	return &Forwarder{listenHost: listenHost, forwards: make(map[int]*forward)}
+}
+
+// Reconcile adds forwards for new wanted ports and closes those no longer
+// wanted. Ports already reachable on the listen host, such as servers bound
+// to all interfaces, are left alone. Returns the ports added and removed.
+func (f *Forwarder) Reconcile(wants []Want) (added, removed []int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wanted := make(map[int]Want, len(wants))
+	for _, w := range wants {
+		if _, dup := wanted[w.Port]; !dup && w.Port > 0 {
+			wanted[w.Port] = w
+		}
+	}
+	for port, fw := range f.forwards {
+		if _, ok := wanted[port]; !ok {
+			fw.ln.Close()
+			delete(f.forwards, port)
+			removed = append(removed, port)
+		}
+	}
+	for port, w := range wanted {
+		if _, ok := f.forwards[port]; ok {
+			continue
+		}
+		listen := net.JoinHostPort(f.listenHost, strconv.Itoa(port))
+		if reachable(listen) {
+			continue
+		}
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			continue
+		}
+		fw := &forward{
+			Forward: Forward{
+				Kind:      w.Kind,
+				ID:        w.ID,
+				Port:      port,
+				Listen:    ln.Addr().String(),
+				Target:    net.JoinHostPort("localhost", strconv.Itoa(port)),
+				CreatedAt: time.Now(),
+			},
+			ln: ln,
+		}
+		f.forwards[port] = fw
+		go fw.serve()
+		added = append(added, port)
+	}
+	sort.Ints(added)
+	sort.Ints(removed)
+	return added, removed
+}
+
+// List returns the active forwards by port.
+func (f *Forwarder) List() []Forward {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := make([]Forward, 0, len(f.forwards))
+	for _, fw := range f.forwards {
+		info := fw.Forward
+		info.Connections = fw.conns.Load()
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+// Close removes every forward.
+func (f *Forwarder) Close() {
+	f.Reconcile(nil)
+}
+
+func (fw *forward) serve() {
+	for {
+		conn, err := fw.ln.Accept()
+		if err != nil {
+			return // Closed
+		}
+		fw.conns.Add(1)
+		go fw.relay(conn)
+	}
+}
+
+func (fw *forward) relay(conn net.Conn) {
+	defer conn.Close()
+	target, err := net.DialTimeout("tcp", fw.Target, dialTimeout)
+	if err != nil {
+		return
+	}
+	defer target.Close()
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		// Pass the half-close on so request/response protocols finish
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go pipe(target, conn)
+	go pipe(conn, target)
+	<-done
+	<-done
+}
+
+// reachable reports whether something accepts connections at addr.
+func reachable(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
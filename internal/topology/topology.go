@@ -0,0 +1,163 @@
+// Package topology detects whether agnt runs inside WSL or a container,
+// where a browser on the host can't reach loopback-only listeners, and
+// forwards such listeners to an address the host can reach.
+package topology
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"os"
+	"strings"
+)
+
+// Kinds of environment.
+const (
+	KindHost         = "host"
+	KindWSL          = "wsl"
+	KindContainer    = "container"
+	KindDevcontainer = "devcontainer"
+)
+
+// Environment describes where the daemon runs.
+type Environment struct {
+	Kind string `json:"kind"`
+	// Detail names the distro, container runtime or devcontainer host, when known.
+	Detail string `json:"detail,omitempty"`
+	// ExternalAddress is this machine's address on the network shared with
+	// the host (WSL's eth0, the container's bridge address); forwards listen
+	// there.
+	ExternalAddress string `json:"external_address,omitempty"`
+	// HostAddress is how the host is reached from here, for targets running
+	// on the host.
+	HostAddress string `json:"host_address,omitempty"`
+}
+
+// Isolated reports whether loopback listeners here are hidden from the host.
+func (e Environment) Isolated() bool {
+	return e.Kind != KindHost && e.Kind != ""
+}
+
+// probe is the system state detection reads, replaceable in tests.
+type probe struct {
+	getenv   func(string) string
+	readFile func(string) ([]byte, error)
+	exists   func(string) bool
+}
+
+var system = probe{
+	getenv:   os.Getenv,
+	readFile: os.ReadFile,
+	exists: func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	},
+}
+
+// Detect inspects the running system.
+func Detect() Environment {
+	env := system.detect()
+	if env.Isolated() {
+		env.ExternalAddress = externalAddress()
+		if env.Kind == KindWSL {
+			env.HostAddress = system.wslHostAddress()
+		} else if addrs, err := net.LookupHost("host.docker.internal"); err == nil && len(addrs) > 0 {
+			env.HostAddress = addrs[0]
+		}
+	}
+	return env
+}
+
+func (p probe) detect() Environment {
+	// Devcontainers first: they are containers, possibly inside WSL
+	switch {
+	case p.getenv("CODESPACES") == "true":
+		return Environment{Kind: KindDevcontainer, Detail: "codespaces"}
+	case p.getenv("REMOTE_CONTAINERS") == "true" || p.getenv("DEVCONTAINER") == "true":
+		return Environment{Kind: KindDevcontainer, Detail: "vscode"}
+	}
+
+	switch {
+	case p.exists("/.dockerenv"):
+		return Environment{Kind: KindContainer, Detail: "docker"}
+	case p.exists("/run/.containerenv"):
+		return Environment{Kind: KindContainer, Detail: "podman"}
+	}
+	if cgroup, err := p.readFile("/proc/1/cgroup"); err == nil {
+		for _, runtime := range []string{"docker", "kubepods", "containerd", "lxc"} {
+			if bytes.Contains(cgroup, []byte(runtime)) {
+				return Environment{Kind: KindContainer, Detail: runtime}
+			}
+		}
+	}
+
+	if distro := p.getenv("WSL_DISTRO_NAME"); distro != "" {
+		return Environment{Kind: KindWSL, Detail: distro}
+	}
+	if version, err := p.readFile("/proc/version"); err == nil && bytes.Contains(bytes.ToLower(version), []byte("microsoft")) {
+		return Environment{Kind: KindWSL}
+	}
+	return Environment{Kind: KindHost}
+}
+
+// wslHostAddress returns the Windows host's address under WSL2 NAT
+// networking: the nameserver WSL writes into resolv.conf.
+func (p probe) wslHostAddress() string {
+	data, err := p.readFile("/etc/resolv.conf")
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "nameserver" {
+			if ip := net.ParseIP(fields[1]); ip != nil && ip.To4() != nil && !ip.IsLoopback() {
+				return fields[1]
+			}
+		}
+	}
+	return ""
+}
+
+// externalAddress returns the first IPv4 address of an up, non-loopback
+// interface, skipping bridges this machine hosts for its own containers.
+func externalAddress() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || isBridge(iface.Name) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+				return ipnet.IP.String()
+			}
+		}
+	}
+	return ""
+}
+
+func isBridge(name string) bool {
+	for _, prefix := range []string{"docker", "br-", "veth", "virbr", "cni", "flannel"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectEnvironment reports the environment a project path belongs to when
+// it differs from the daemon's: a Windows daemon serving a project in WSL.
+func ProjectEnvironment(projectPath string) string {
+	lower := strings.ToLower(projectPath)
+	if strings.HasPrefix(lower, `\\wsl$\`) || strings.HasPrefix(lower, `\\wsl.localhost\`) {
+		return KindWSL
+	}
+	return ""
+}
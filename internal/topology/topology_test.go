@@ -0,0 +1,128 @@
+package topology
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func fakeProbe(env map[string]string, files map[string]string) probe {
+	return probe{
+		getenv: func(key string) string { return env[key] },
+		readFile: func(path string) ([]byte, error) {
+			if data, ok := files[path]; ok {
+				return []byte(data), nil
+			}
+			return nil, os.ErrNotExist
+		},
+		exists: func(path string) bool {
+			_, ok := files[path]
+			return ok
+		},
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		files map[string]string
+		want  Environment
+	}{
+		{"host", nil, map[string]string{"/proc/version": "Linux version 6.1.0 (gcc)"}, Environment{Kind: KindHost}},
+		{"wsl", map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, nil, Environment{Kind: KindWSL, Detail: "Ubuntu"}},
+		{"wsl kernel", nil, map[string]string{"/proc/version": "Linux version 5.15.133.1-microsoft-standard-WSL2"}, Environment{Kind: KindWSL}},
+		{"docker", nil, map[string]string{"/.dockerenv": ""}, Environment{Kind: KindContainer, Detail: "docker"}},
+		{"podman", nil, map[string]string{"/run/.containerenv": ""}, Environment{Kind: KindContainer, Detail: "podman"}},
+		{"kubernetes", nil, map[string]string{"/proc/1/cgroup": "0::/kubepods/besteffort/pod1"}, Environment{Kind: KindContainer, Detail: "kubepods"}},
+		{"devcontainer in wsl", map[string]string{"REMOTE_CONTAINERS": "true", "WSL_DISTRO_NAME": "Ubuntu"}, map[string]string{"/.dockerenv": ""}, Environment{Kind: KindDevcontainer, Detail: "vscode"}},
+		{"codespaces", map[string]string{"CODESPACES": "true"}, nil, Environment{Kind: KindDevcontainer, Detail: "codespaces"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fakeProbe(tt.env, tt.files).detect(); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	p := fakeProbe(nil, map[string]string{"/etc/resolv.conf": "# generated by WSL\nnameserver 172.22.96.1\n"})
+	if got := p.wslHostAddress(); got != "172.22.96.1" {
+		t.Errorf("Expected the WSL host address from resolv.conf, got %q", got)
+	}
+	if got := ProjectEnvironment(`\\wsl.localhost\Ubuntu\home\dev\app`); got != KindWSL {
+		t.Errorf("Expected a WSL project path, got %q", got)
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	// A loopback-only server, forwarded from another loopback address so
+	// the test needs no external interface
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				fmt.Fprintf(conn, "echo %s", line)
+			}()
+		}
+	}()
+	port := target.Addr().(*net.TCPAddr).Port
+
+	listenHost := "127.0.0.2"
+	if ln, err := net.Listen("tcp", net.JoinHostPort(listenHost, "0")); err != nil {
+		t.Skip("127.0.0.2 not available")
+	} else {
+		ln.Close()
+	}
+
+	f := NewForwarder(listenHost)
+	defer f.Close()
+	added, _ := f.Reconcile([]Want{{Kind: "proxy", ID: "app", Port: port}})
+	if len(added) != 1 || added[0] != port {
+		t.Fatalf("Expected a forward for port %d, got %v", port, added)
+	}
+
+	conn, err := net.Dial("tcp", net.JoinHostPort(listenHost, strconv.Itoa(port)))
+	if err != nil {
+		t.Fatalf("Dial through the forward failed: %v", err)
+	}
+	fmt.Fprintf(conn, "hello\n")
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	conn.Close()
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) && reply == "" {
+		t.Fatalf("Read through the forward failed: %v", err)
+	}
+	if reply != "echo hello\n" {
+		t.Errorf("Expected the target's reply, got %q", reply)
+	}
+
+	list := f.List()
+	if len(list) != 1 || list[0].ID != "app" || list[0].Connections != 1 {
+		t.Errorf("Unexpected forwards %+v", list)
+	}
+
+	// Unchanged wants keep the forward; dropped wants remove it
+	if added, removed := f.Reconcile([]Want{{Kind: "proxy", ID: "app", Port: port}}); len(added)+len(removed) != 0 {
+		t.Errorf("Expected no changes, got +%v -%v", added, removed)
+	}
+	if _, removed := f.Reconcile(nil); len(removed) != 1 {
+		t.Errorf("Expected the forward removed, got %v", removed)
+	}
+	if _, err := net.DialTimeout("tcp", net.JoinHostPort(listenHost, strconv.Itoa(port)), dialTimeout); err == nil {
+		t.Error("Expected the forward closed")
+	}
+}
@@ -0,0 +1,231 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTail is how many lines a query without a limit returns.
+	DefaultTail = 100
+
+	// MaxTail caps the lines one query returns.
+	MaxTail = 5000
+)
+
+// Query selects lines of a transcript. Filters apply in order: time range,
+// pattern, then the last Tail lines of what is left.
+type Query struct {
+	Tail  int            // Last N matching lines (default 100, max 5000)
+	Grep  *regexp.Regexp // Lines matching this pattern, nil for all
+	Since time.Time      // Lines written at or after this time
+	Until time.Time      // Lines written before this time
+	Raw   bool           // Keep terminal escape sequences
+}
+
+// Line is one line of terminal output.
+type Line struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Event is a start or exit of the session recorded in the transcript.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Command string    `json:"command,omitempty"`
+}
+
+// Result is the answer to a transcript query.
+type Result struct {
+	Path      string    `json:"path"`
+	Lines     []Line    `json:"lines"`
+	Matched   int       `json:"matched"`             // Lines passing the filters, before Tail
+	Truncated bool      `json:"truncated,omitempty"` // Matched exceeds the lines returned
+	Start     time.Time `json:"start,omitempty"`     // First record of the transcript
+	End       time.Time `json:"end,omitempty"`       // Last record of the transcript
+	Events    []Event   `json:"events,omitempty"`
+}
+
+// Search runs a query over a session's transcript, the rotated file
+// included.
+func Search(projectPath, code string, q Query) (*Result, error) {
+	if q.Tail <= 0 {
+		q.Tail = DefaultTail
+	}
+	if q.Tail > MaxTail {
+		q.Tail = MaxTail
+	}
+
+	path := Path(projectPath, code)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no transcript for session %q in %s (record one with agnt run --transcript)", code, projectPath)
+		}
+		return nil, err
+	}
+
+	res := &Result{Path: path, Lines: []Line{}}
+	lb := &lineBuilder{raw: q.Raw}
+	keep := func(l Line) {
+		if !q.Since.IsZero() && l.Time.Before(q.Since) {
+			return
+		}
+		if !q.Until.IsZero() && !l.Time.Before(q.Until) {
+			return
+		}
+		if q.Grep != nil && !q.Grep.MatchString(l.Text) {
+			return
+		}
+		res.Matched++
+		res.Lines = append(res.Lines, l)
+		// Keep memory bounded on long transcripts
+		if len(res.Lines) > 2*q.Tail {
+			res.Lines = append(res.Lines[:0], res.Lines[len(res.Lines)-q.Tail:]...)
+		}
+	}
+
+	for _, p := range []string{rotatedPath(path), path} {
+		if err := readRecords(p, func(rec Record) {
+			if res.Start.IsZero() {
+				res.Start = rec.Time
+			}
+			res.End = rec.Time
+			if rec.Event != "" {
+				lb.flush(keep)
+				res.Events = append(res.Events, Event{Time: rec.Time, Event: rec.Event, Command: rec.Data})
+				return
+			}
+			lb.add(rec, keep)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	lb.flush(keep)
+
+	if len(res.Lines) > q.Tail {
+		res.Lines = res.Lines[len(res.Lines)-q.Tail:]
+	}
+	res.Truncated = res.Matched > len(res.Lines)
+	return res, nil
+}
+
+// readRecords calls fn for each record of a transcript file. A missing file
+// has no records; a line that doesn't parse, like one cut short by a crash,
+// is skipped.
+func readRecords(path string, fn func(Record)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		fn(rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return nil
+}
+
+var (
+	// Cursor positioning starts the text somewhere else on screen, so it
+	// ends the current line
+	cursorMove = regexp.MustCompile(`\x1b\[[0-9;]*[HfEF]`)
+	// CSI, OSC (terminated by BEL or ST) and two-byte escape sequences
+	escapeSeq = regexp.MustCompile(`\x1b\[[0-9;?<>=!]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\x1b[@-Z\\-_=>]`)
+)
+
+// stripEscapes removes terminal escape sequences and other control
+// characters from output, keeping newlines and carriage returns.
+func stripEscapes(s string) string {
+	s = cursorMove.ReplaceAllString(s, "\n")
+	s = escapeSeq.ReplaceAllString(s, "")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' || r >= ' ' && r != 0x7f {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// lineBuilder splits recorded output into lines. A line is stamped with the
+// time of the chunk it started in.
+type lineBuilder struct {
+	raw     bool
+	current strings.Builder
+	started time.Time
+}
+
+// add appends a chunk of output, emitting the lines it completes.
+func (b *lineBuilder) add(rec Record, emit func(Line)) {
+	data := rec.Data
+	if !b.raw {
+		data = stripEscapes(data)
+	}
+	for {
+		i := strings.IndexByte(data, '\n')
+		part := data
+		if i >= 0 {
+			part = data[:i]
+		}
+		if part != "" && b.current.Len() == 0 {
+			b.started = rec.Time
+		}
+		b.current.WriteString(part)
+		if i < 0 {
+			return
+		}
+		b.flush(emit)
+		data = data[i+1:]
+	}
+}
+
+// flush emits the line being built, if it has any text.
+func (b *lineBuilder) flush(emit func(Line)) {
+	text := b.current.String()
+	b.current.Reset()
+	if !b.raw {
+		// A carriage return redraws the line: what follows the last one
+		// is what stayed on screen
+		if i := strings.LastIndexByte(strings.TrimRight(text, "\r"), '\r'); i >= 0 {
+			text = text[i+1:]
+		}
+		text = strings.TrimRight(text, " \t\r")
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	emit(Line{Time: b.started, Text: text})
+}
+
+// ParseTime parses a query bound: an RFC 3339 time, or a duration such as
+// "8h" meaning that long before now.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or a duration ago like 8h", s)
+	}
+	return now.Add(-d), nil
+}
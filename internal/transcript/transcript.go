@@ -0,0 +1,197 @@
+// Package transcript records the terminal output of an agnt run session and
+// searches it afterwards.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DirName is the project-relative directory holding session transcripts.
+	DirName = ".agnt/sessions"
+
+	// FileExt is the transcript file extension (JSON lines, one record per
+	// chunk of output).
+	FileExt = ".jsonl"
+
+	// DefaultMaxBytes is the size at which a transcript is rotated. One
+	// rotated file is kept, as <code>.1.jsonl.
+	DefaultMaxBytes = 50 * 1024 * 1024
+)
+
+// Record events besides output.
+const (
+	EventStart = "start"
+	EventExit  = "exit"
+)
+
+// Record is one line of a transcript file.
+type Record struct {
+	Time  time.Time `json:"t"`
+	Data  string    `json:"d,omitempty"`     // Raw terminal output, escape sequences included
+	Event string    `json:"event,omitempty"` // start or exit; Data then describes it
+}
+
+// Path returns the transcript file of a session.
+func Path(projectPath, code string) string {
+	return filepath.Join(projectPath, DirName, code+FileExt)
+}
+
+// rotatedPath returns where a transcript is moved when it grows past its cap.
+func rotatedPath(path string) string {
+	return strings.TrimSuffix(path, FileExt) + ".1" + FileExt
+}
+
+// Writer appends a session's terminal output to its transcript. It never
+// fails a write: recording stops on the first file error, so a full disk
+// cannot break the terminal it tees from. A nil Writer discards everything.
+type Writer struct {
+	path     string
+	maxBytes int64
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	pending []byte // Trailing bytes of an incomplete UTF-8 sequence
+	err     error
+}
+
+// Create opens the transcript of a session for appending and records its
+// start with the command being run.
+func Create(projectPath, code, command string) (*Writer, error) {
+	if code == "" {
+		return nil, fmt.Errorf("session code is required")
+	}
+	path := Path(projectPath, code)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat transcript: %w", err)
+	}
+
+	w := &Writer{path: path, maxBytes: DefaultMaxBytes, file: f, size: info.Size()}
+	w.writeRecord(Record{Time: time.Now(), Event: EventStart, Data: command})
+	return w, nil
+}
+
+// Path returns the file the writer appends to.
+func (w *Writer) Path() string {
+	if w == nil {
+		return ""
+	}
+	return w.path
+}
+
+// Write records a chunk of terminal output. It always reports success.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w == nil || len(p) == 0 {
+		return len(p), nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// A read can end mid-character; hold the partial rune for the next chunk
+	// so the JSON string doesn't get replacement characters.
+	data := append(w.pending, p...)
+	cut := completeUTF8(data)
+	w.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		w.writeRecord(Record{Time: time.Now(), Data: string(data[:cut])})
+	}
+	return len(p), nil
+}
+
+// Close records the session's exit and closes the file.
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 {
+		w.writeRecord(Record{Time: time.Now(), Data: string(w.pending)})
+		w.pending = nil
+	}
+	w.writeRecord(Record{Time: time.Now(), Event: EventExit})
+	if w.file == nil {
+		return w.err
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// writeRecord appends a record, rotating the file past maxBytes (caller
+// must hold the lock).
+func (w *Writer) writeRecord(rec Record) {
+	if w.err != nil || w.file == nil {
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		w.err = err
+		return
+	}
+	line = append(line, '\n')
+
+	if w.maxBytes > 0 && w.size > 0 && w.size+int64(len(line)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			w.err = err
+			return
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		w.err = err
+	}
+}
+
+// rotate moves the full transcript aside and starts a new file.
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.path, rotatedPath(w.path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		w.file = nil
+		return err
+	}
+	w.file = f
+	w.size = 0
+	return nil
+}
+
+// completeUTF8 returns the length of b without a trailing incomplete UTF-8
+// sequence. Invalid bytes count as complete; only a valid prefix of a
+// multi-byte character is held back.
+func completeUTF8(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(b[i]) {
+			continue
+		}
+		if !utf8.FullRune(b[i:]) {
+			return i
+		}
+		break
+	}
+	return len(b)
+}
@@ -0,0 +1,98 @@
+package transcript
+
+import (
+	"os"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWriterAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, "claude-1", "claude")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	w.Write([]byte("\x1b[32mRunning tests\x1b[0m\r\n"))
+	w.Write([]byte("progress 10%\rprogress 100%\r\nFAIL TestLogin\r\n"))
+	w.Write([]byte("ok  \xe2\x9c"))
+	w.Write([]byte("\x93 done\r\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	res, err := Search(dir, "claude-1", Query{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	want := []string{"Running tests", "progress 100%", "FAIL TestLogin", "ok  ✓ done"}
+	if len(res.Lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %+v", len(want), res.Lines)
+	}
+	for i, l := range res.Lines {
+		if l.Text != want[i] {
+			t.Errorf("Line %d = %q, want %q", i, l.Text, want[i])
+		}
+	}
+	if len(res.Events) != 2 || res.Events[0].Event != EventStart || res.Events[0].Command != "claude" || res.Events[1].Event != EventExit {
+		t.Errorf("Unexpected events: %+v", res.Events)
+	}
+
+	res, _ = Search(dir, "claude-1", Query{Grep: regexp.MustCompile(`FAIL`)})
+	if res.Matched != 1 || res.Lines[0].Text != "FAIL TestLogin" {
+		t.Errorf("Expected the FAIL line, got %+v", res.Lines)
+	}
+
+	res, _ = Search(dir, "claude-1", Query{Tail: 2})
+	if len(res.Lines) != 2 || !res.Truncated || res.Lines[1].Text != "ok  ✓ done" {
+		t.Errorf("Expected the last 2 lines, got %+v", res)
+	}
+
+	res, _ = Search(dir, "claude-1", Query{Since: time.Now().Add(time.Hour)})
+	if len(res.Lines) != 0 {
+		t.Errorf("Expected no lines after the time range, got %+v", res.Lines)
+	}
+}
+
+func TestWriterRotates(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, "s", "claude")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	w.maxBytes = 200
+	for i := 0; i < 10; i++ {
+		w.Write([]byte("line of output that fills the file\n"))
+	}
+	w.Close()
+
+	if _, err := os.Stat(rotatedPath(w.Path())); err != nil {
+		t.Fatalf("Expected a rotated file: %v", err)
+	}
+	res, err := Search(dir, "s", Query{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if res.Matched == 0 {
+		t.Error("Expected lines from the kept files")
+	}
+}
+
+func TestSearch_NoTranscript(t *testing.T) {
+	if _, err := Search(t.TempDir(), "missing", Query{}); err == nil {
+		t.Error("Expected an error for a session without a transcript")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if got, _ := ParseTime("8h", now); !got.Equal(now.Add(-8 * time.Hour)) {
+		t.Errorf("ParseTime(8h) = %v", got)
+	}
+	if got, _ := ParseTime("2026-02-28T22:00:00Z", now); got.Hour() != 22 {
+		t.Errorf("ParseTime(RFC 3339) = %v", got)
+	}
+	if _, err := ParseTime("last night", now); err == nil {
+		t.Error("Expected an error for an unparseable time")
+	}
+}
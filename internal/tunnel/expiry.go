@@ -0,0 +1,123 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxExpiryWarningLead is how long before expiry the warning callback fires.
+// Short TTLs warn at a fifth of their lifetime instead.
+const maxExpiryWarningLead = 5 * time.Minute
+
+// expiryStopTimeout bounds how long an expiring tunnel waits for its process to exit.
+const expiryStopTimeout = 10 * time.Second
+
+// expiryWarningLead returns how long before expiry to warn for a given TTL.
+func expiryWarningLead(ttl time.Duration) time.Duration {
+	if lead := ttl / 5; lead < maxExpiryWarningLead {
+		return lead
+	}
+	return maxExpiryWarningLead
+}
+
+// scheduleExpiry arms the TTL timers for a started tunnel.
+// Timers are cancelled when the tunnel exits for any other reason.
+func (t *Tunnel) scheduleExpiry() {
+	ttl := t.config.Expires
+	if ttl <= 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	t.expiresAt.Store(&expiresAt)
+
+	lead := expiryWarningLead(ttl)
+	warn := time.AfterFunc(ttl-lead, func() {
+		if t.config.OnExpiryWarning != nil {
+			t.config.OnExpiryWarning(lead)
+		}
+	})
+	expire := time.AfterFunc(ttl, t.expire)
+
+	go func() {
+		<-t.done
+		warn.Stop()
+		expire.Stop()
+	}()
+}
+
+// expire stops the tunnel because its TTL elapsed.
+func (t *Tunnel) expire() {
+	t.expired.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), expiryStopTimeout)
+	defer cancel()
+	t.Stop(ctx)
+
+	if t.config.OnExpired != nil {
+		t.config.OnExpired()
+	}
+}
+
+// ExpiresAt returns when the tunnel auto-stops, or the zero time if it has no TTL.
+func (t *Tunnel) ExpiresAt() time.Time {
+	if ptr := t.expiresAt.Load(); ptr != nil {
+		return *ptr
+	}
+	return time.Time{}
+}
+
+// Expired reports whether the tunnel was stopped by its TTL.
+func (t *Tunnel) Expired() bool {
+	return t.expired.Load()
+}
+
+// AuditDirName is the project-relative directory holding the tunnel audit log.
+const AuditDirName = ".agnt"
+
+// AuditFileName is the tunnel audit log file name (JSON lines).
+const AuditFileName = "tunnel-audit.jsonl"
+
+// AuditRecord is one entry in the tunnel audit log.
+type AuditRecord struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Event     string     `json:"event"` // started, expired
+	TunnelID  string     `json:"tunnel_id"`
+	Provider  Provider   `json:"provider"`
+	PublicURL string     `json:"public_url,omitempty"`
+	ProxyID   string     `json:"proxy_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AppendAudit appends a record to the project's tunnel audit log.
+func AppendAudit(projectPath string, record AuditRecord) error {
+	if projectPath == "" {
+		return fmt.Errorf("project path required for audit log")
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+
+	dir := filepath.Join(projectPath, AuditDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, AuditFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,108 @@
+package tunnel
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpiryWarningLead(t *testing.T) {
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{2 * time.Hour, 5 * time.Minute},
+		{10 * time.Minute, 2 * time.Minute},
+		{time.Minute, 12 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := expiryWarningLead(tt.ttl); got != tt.want {
+			t.Errorf("expiryWarningLead(%s) = %s, want %s", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestTunnel_ScheduleExpiry(t *testing.T) {
+	warned := make(chan time.Duration, 1)
+	expired := make(chan struct{})
+
+	tun := New(Config{
+		Provider:        ProviderCloudflare,
+		LocalPort:       8080,
+		Expires:         100 * time.Millisecond,
+		OnExpiryWarning: func(remaining time.Duration) { warned <- remaining },
+		OnExpired:       func() { close(expired) },
+	})
+	// Stand in for the provider process: cancelling ends the tunnel.
+	tun.cancel = func() { close(tun.done) }
+
+	tun.scheduleExpiry()
+	if tun.ExpiresAt().IsZero() {
+		t.Fatal("expected ExpiresAt to be set")
+	}
+	if tun.Info().ExpiresAt == "" {
+		t.Error("expected Info to include expires_at")
+	}
+
+	select {
+	case remaining := <-warned:
+		if remaining != 20*time.Millisecond {
+			t.Errorf("warning remaining = %s, want 20ms", remaining)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expiry warning not delivered")
+	}
+
+	select {
+	case <-expired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tunnel did not expire")
+	}
+
+	if !tun.Expired() {
+		t.Error("expected Expired() to be true")
+	}
+	if tun.State() != StateStopped {
+		t.Errorf("state = %s, want stopped", tun.State())
+	}
+}
+
+func TestAppendAudit(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := AppendAudit(dir, AuditRecord{Event: "started", TunnelID: "dev", Provider: ProviderNgrok}); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+	if err := AppendAudit(dir, AuditRecord{Event: "expired", TunnelID: "dev", Provider: ProviderNgrok, PublicURL: "https://x.ngrok.io"}); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, AuditDirName, AuditFileName))
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var events []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		if rec.Timestamp.IsZero() {
+			t.Error("expected timestamp to be filled in")
+		}
+		events = append(events, rec.Event)
+	}
+	if len(events) != 2 || events[0] != "started" || events[1] != "expired" {
+		t.Errorf("events = %v, want [started expired]", events)
+	}
+
+	if err := AppendAudit("", AuditRecord{Event: "expired"}); err == nil {
+		t.Error("expected error without project path")
+	}
+}
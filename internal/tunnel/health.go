@@ -0,0 +1,285 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType names a tunnel health event.
+type EventType string
+
+const (
+	// EventDisconnected: the provider process exited while the tunnel was up.
+	EventDisconnected EventType = "disconnected"
+	// EventUnhealthy: the public URL failed unhealthyThreshold probes in a row.
+	EventUnhealthy EventType = "unhealthy"
+	// EventReconnecting: the provider is restarted after a backoff.
+	EventReconnecting EventType = "reconnecting"
+	// EventReconnected: the restarted provider reported a public URL.
+	EventReconnected EventType = "reconnected"
+	// EventGaveUp: reconnecting stopped after maxReconnectAttempts.
+	EventGaveUp EventType = "gave_up"
+)
+
+// Event is one entry in a tunnel's health log.
+type Event struct {
+	Time      time.Time `json:"time"`
+	TunnelID  string    `json:"tunnel_id"`
+	Path      string    `json:"path,omitempty"`
+	Type      EventType `json:"type"`
+	State     string    `json:"state"`
+	Attempt   int       `json:"attempt,omitempty"`
+	RetryIn   string    `json:"retry_in,omitempty"`
+	PublicURL string    `json:"public_url,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+const (
+	// defaultHealthInterval is how often a connected tunnel's URL is probed.
+	defaultHealthInterval = 30 * time.Second
+	// healthProbeTimeout bounds one probe of the public URL.
+	healthProbeTimeout = 10 * time.Second
+	// unhealthyThreshold is how many failed probes in a row restart the provider.
+	unhealthyThreshold = 3
+
+	// Reconnect backoff doubles from minReconnectDelay up to maxReconnectDelay.
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+	// maxReconnectAttempts is how many restarts an outage gets before the
+	// tunnel is left failed.
+	maxReconnectAttempts = 10
+	// stableAfter is how long a restarted provider must stay up for the next
+	// outage to start again from minReconnectDelay.
+	stableAfter = 2 * time.Minute
+
+	// maxEvents is how many events the manager keeps across all tunnels.
+	maxEvents = 500
+)
+
+// reconnectDelay returns the backoff before restart attempt n (1-based).
+func reconnectDelay(attempt int) time.Duration {
+	delay := minReconnectDelay
+	for i := 1; i < attempt && delay < maxReconnectDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxReconnectDelay {
+		return maxReconnectDelay
+	}
+	return delay
+}
+
+// emit fills in the tunnel's identity and state and reports the event.
+func (t *Tunnel) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.TunnelID = t.config.ID
+	event.Path = t.config.Path
+	event.State = t.State().String()
+	if t.config.OnEvent != nil {
+		t.config.OnEvent(event)
+	}
+}
+
+// supervise restarts the provider process with exponential backoff when it
+// exits on its own after having connected. A provider that never reported a
+// URL fails as before, so start errors surface to the caller. done is closed
+// once the tunnel is stopped, gives up, or may not reconnect.
+func (t *Tunnel) supervise(ctx context.Context, exited <-chan struct{}) {
+	defer close(t.done)
+
+	connected := false
+	launched := time.Now()
+	for {
+		<-exited
+		if ctx.Err() != nil {
+			return
+		}
+		if t.PublicURL() != "" {
+			connected = true
+		}
+		if !connected || t.config.NoReconnect {
+			return
+		}
+
+		// A provider that stayed up for a while starts a fresh outage
+		if time.Since(launched) >= stableAfter {
+			t.attempt.Store(0)
+		}
+		attempt := int(t.attempt.Add(1))
+		reason := t.lastError()
+		if attempt == 1 {
+			t.emit(Event{Type: EventDisconnected, Error: reason})
+		}
+		if attempt > maxReconnectAttempts {
+			t.setState(StateFailed)
+			t.emit(Event{Type: EventGaveUp, Attempt: attempt - 1, Error: reason})
+			return
+		}
+
+		delay := reconnectDelay(attempt)
+		t.setState(StateReconnecting)
+		t.publicURL.Store(nil)
+		t.emit(Event{Type: EventReconnecting, Attempt: attempt, RetryIn: delay.String(), Error: reason})
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+
+		t.setState(StateStarting)
+		launched = time.Now()
+		var err error
+		if exited, err = t.launch(ctx); err != nil {
+			// The attempt counts; loop again on an already-exited process
+			closed := make(chan struct{})
+			close(closed)
+			exited = closed
+		}
+	}
+}
+
+// lastError returns the tunnel's error message, if any.
+func (t *Tunnel) lastError() string {
+	t.errMu.RLock()
+	defer t.errMu.RUnlock()
+	if t.err == nil {
+		return ""
+	}
+	return t.err.Error()
+}
+
+// monitorHealth probes the public URL of a connected tunnel. After
+// unhealthyThreshold failures in a row it reports the tunnel unhealthy and,
+// unless reconnecting is off, kills the provider so supervise restarts it.
+func (t *Tunnel) monitorHealth(ctx context.Context) {
+	interval := t.config.HealthInterval
+	if interval < 0 {
+		return
+	}
+	if interval == 0 {
+		interval = defaultHealthInterval
+	}
+
+	client := &http.Client{
+		Timeout: healthProbeTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+
+		url := t.PublicURL()
+		if t.State() != StateConnected || url == "" {
+			failures = 0
+			continue
+		}
+		err := probeURL(ctx, client, url)
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if failures++; failures < unhealthyThreshold {
+			continue
+		}
+		failures = 0
+		t.setError(fmt.Errorf("public URL unreachable: %w", err))
+		t.emit(Event{Type: EventUnhealthy, PublicURL: url, Error: err.Error()})
+
+		if !t.config.NoReconnect {
+			if cmd := t.currentCmd(); cmd != nil && cmd.Process != nil {
+				cmd.Process.Kill()
+			}
+		}
+	}
+}
+
+// probeURL checks that the provider still routes the public URL. Errors from
+// the local service (a 502 while it restarts) count as healthy: only network
+// failures and the providers' own "tunnel not found" answers fail.
+func probeURL(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	// Cloudflare answers 530 (error 1033) for a tunnel that is gone
+	if resp.StatusCode == 530 {
+		return fmt.Errorf("provider reports the tunnel offline (HTTP 530)")
+	}
+	if code := resp.Header.Get("Ngrok-Error-Code"); code != "" {
+		return fmt.Errorf("provider reports the tunnel offline (%s)", code)
+	}
+	return nil
+}
+
+// eventLog keeps the most recent tunnel events, oldest first.
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) add(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+	if len(l.events) > maxEvents {
+		l.events = append([]Event(nil), l.events[len(l.events)-maxEvents:]...)
+	}
+}
+
+// Events returns recorded tunnel events, oldest first, outliving the tunnels
+// themselves. An id matches the full ID or one component of a compound ID;
+// pathFilter keeps one project's events; limit > 0 keeps the newest ones.
+func (m *Manager) Events(id, pathFilter string, limit int) []Event {
+	m.events.mu.Lock()
+	defer m.events.mu.Unlock()
+
+	normalizedFilter := normalizePath(pathFilter)
+	var events []Event
+	for _, e := range m.events.events {
+		if id != "" && !matchesID(e.TunnelID, id) {
+			continue
+		}
+		if normalizedFilter != "" && normalizePath(e.Path) != normalizedFilter {
+			continue
+		}
+		events = append(events, e)
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events
+}
+
+// matchesID reports whether id is tunnelID or one of its ":" components.
+func matchesID(tunnelID, id string) bool {
+	if tunnelID == id {
+		return true
+	}
+	for _, part := range strings.Split(tunnelID, ":") {
+		if part == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package tunnel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, w := range want {
+		if got := reconnectDelay(i + 1); got != w {
+			t.Errorf("attempt %d: got %s, want %s", i+1, got, w)
+		}
+	}
+	if got := reconnectDelay(20); got != maxReconnectDelay {
+		t.Errorf("expected the delay to cap at %s, got %s", maxReconnectDelay, got)
+	}
+}
+
+func TestManager_ReconnectsDroppedTunnel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	// Each run prints a fresh URL and drops shortly after
+	script := filepath.Join(t.TempDir(), "flaky-tunnel")
+	body := "#!/bin/sh\necho \"ready at https://run-$$.example.test\"\nsleep 0.2\nexit 1\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan Event, 16)
+	m := NewManager()
+	tun, err := m.Start(context.Background(), "proj:web", Config{
+		Provider:       ProviderCustom,
+		LocalPort:      9,
+		Path:           "/proj",
+		Command:        script,
+		HealthInterval: -1,
+		OnEvent:        func(e Event) { events <- e },
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer m.Stop(context.Background(), "proj:web")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first, err := tun.WaitForURL(ctx)
+	if err != nil {
+		t.Fatalf("WaitForURL: %v", err)
+	}
+
+	var seen []EventType
+	for {
+		select {
+		case e := <-events:
+			seen = append(seen, e.Type)
+			if e.Type != EventReconnected {
+				continue
+			}
+			if e.PublicURL == "" || e.PublicURL == first {
+				t.Errorf("expected the new run's URL, got %q (first %q)", e.PublicURL, first)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no reconnect within 5s, events %v", seen)
+		}
+		break
+	}
+	if len(seen) < 3 || seen[0] != EventDisconnected || seen[1] != EventReconnecting {
+		t.Errorf("unexpected events %v", seen)
+	}
+	if tun.Info().Reconnects < 1 {
+		t.Errorf("expected the reconnect to be counted, got %+v", tun.Info())
+	}
+
+	logged := m.Events("web", "/proj", 0)
+	if len(logged) < len(seen) || logged[0].TunnelID != "proj:web" {
+		t.Errorf("expected the manager to log the events, got %+v", logged)
+	}
+	if got := m.Events("web", "/other", 0); len(got) != 0 {
+		t.Errorf("expected no events for another project, got %+v", got)
+	}
+}
+
+func TestTunnel_NoReconnect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "flaky-tunnel")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho https://once.example.test\nsleep 0.2\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tun := New(Config{Provider: ProviderCustom, LocalPort: 9, Command: script, NoReconnect: true, HealthInterval: -1})
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	select {
+	case <-tun.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the tunnel to end with its process")
+	}
+}
@@ -23,6 +23,9 @@ type Manager struct {
 	tunnels      sync.Map // map[string]*Tunnel
 	active       atomic.Int32
 	shuttingDown atomic.Bool
+
+	// Health events of all tunnels, kept after they exit
+	events eventLog
 }
 
 // NewManager creates a new tunnel manager.
@@ -44,6 +47,15 @@ func (m *Manager) Start(ctx context.Context, id string, config Config) (*Tunnel,
 	// Ensure ID is set in config
 	config.ID = id
 
+	// Record health events before handing them to the caller
+	onEvent := config.OnEvent
+	config.OnEvent = func(event Event) {
+		m.events.add(event)
+		if onEvent != nil {
+			onEvent(event)
+		}
+	}
+
 	tunnel := New(config)
 
 	// Store before starting to prevent race
@@ -0,0 +1,233 @@
+package tunnel
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateWindow is the number of one-second samples used to compute transfer rates.
+const rateWindow = 5
+
+// Usage reports bandwidth consumed through a tunnel.
+// BytesIn is traffic from the public side to the local service (requests),
+// BytesOut is traffic from the local service back out (responses).
+type Usage struct {
+	BytesIn  int64   `json:"bytes_in"`
+	BytesOut int64   `json:"bytes_out"`
+	RateIn   float64 `json:"rate_in"`  // bytes/sec over the last few seconds
+	RateOut  float64 `json:"rate_out"` // bytes/sec over the last few seconds
+	MaxBytes int64   `json:"max_bytes,omitempty"`
+	Paused   bool    `json:"paused,omitempty"`
+}
+
+// meterSample is a point-in-time byte count used for rate calculation.
+type meterSample struct {
+	at       time.Time
+	bytesIn  int64
+	bytesOut int64
+}
+
+// meter is a local TCP relay between the tunnel binary and the local service.
+// The tunnel provider is pointed at the relay so every byte crossing the
+// tunnel is counted, and the relay can refuse traffic once a cap is hit.
+type meter struct {
+	listener net.Listener
+	target   string
+	maxBytes int64
+
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+	capBase  atomic.Int64 // total at last resume; caps count from here
+	paused   atomic.Bool
+
+	onCap func(Usage)
+
+	conns sync.Map // net.Conn -> struct{}
+
+	samplesMu sync.Mutex
+	samples   []meterSample
+
+	done chan struct{}
+}
+
+// newMeter starts a relay on a loopback port forwarding to target.
+func newMeter(target string, maxBytes int64, onCap func(Usage)) (*meter, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	m := &meter{
+		listener: ln,
+		target:   target,
+		maxBytes: maxBytes,
+		onCap:    onCap,
+		done:     make(chan struct{}),
+	}
+	go m.acceptLoop()
+	go m.sampleLoop()
+	return m, nil
+}
+
+// Port returns the relay's listening port.
+func (m *meter) Port() int {
+	return m.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the relay and drops all relayed connections.
+func (m *meter) Close() {
+	select {
+	case <-m.done:
+		return
+	default:
+		close(m.done)
+	}
+	m.listener.Close()
+	m.closeConns()
+}
+
+// Usage returns the current byte counts and rates.
+func (m *meter) Usage() Usage {
+	in, out := m.bytesIn.Load(), m.bytesOut.Load()
+	u := Usage{
+		BytesIn:  in,
+		BytesOut: out,
+		MaxBytes: m.maxBytes,
+		Paused:   m.paused.Load(),
+	}
+
+	m.samplesMu.Lock()
+	if len(m.samples) > 0 {
+		oldest := m.samples[0]
+		if secs := time.Since(oldest.at).Seconds(); secs > 0 {
+			u.RateIn = float64(in-oldest.bytesIn) / secs
+			u.RateOut = float64(out-oldest.bytesOut) / secs
+		}
+	}
+	m.samplesMu.Unlock()
+
+	return u
+}
+
+// Resume clears a cap pause. The cap is counted afresh from the current totals.
+func (m *meter) Resume() {
+	m.capBase.Store(m.bytesIn.Load() + m.bytesOut.Load())
+	m.paused.Store(false)
+}
+
+func (m *meter) acceptLoop() {
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			return
+		}
+		if m.paused.Load() {
+			conn.Close()
+			continue
+		}
+		go m.relay(conn)
+	}
+}
+
+func (m *meter) relay(public net.Conn) {
+	local, err := net.DialTimeout("tcp", m.target, 10*time.Second)
+	if err != nil {
+		public.Close()
+		return
+	}
+
+	m.conns.Store(public, struct{}{})
+	m.conns.Store(local, struct{}{})
+	defer func() {
+		m.conns.Delete(public)
+		m.conns.Delete(local)
+		public.Close()
+		local.Close()
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(&countingWriter{w: local, n: &m.bytesIn, m: m}, public)
+		closeWrite(local)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(&countingWriter{w: public, n: &m.bytesOut, m: m}, local)
+		closeWrite(public)
+	}()
+	wg.Wait()
+}
+
+// checkCap pauses the relay once the configured cap is exceeded.
+func (m *meter) checkCap() {
+	if m.maxBytes <= 0 || m.paused.Load() {
+		return
+	}
+	used := m.bytesIn.Load() + m.bytesOut.Load() - m.capBase.Load()
+	if used < m.maxBytes {
+		return
+	}
+	if !m.paused.CompareAndSwap(false, true) {
+		return
+	}
+	m.closeConns()
+	if m.onCap != nil {
+		go m.onCap(m.Usage())
+	}
+}
+
+func (m *meter) closeConns() {
+	m.conns.Range(func(key, _ any) bool {
+		key.(net.Conn).Close()
+		return true
+	})
+}
+
+func (m *meter) sampleLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	m.record(time.Now())
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.record(now)
+		}
+	}
+}
+
+func (m *meter) record(now time.Time) {
+	m.samplesMu.Lock()
+	m.samples = append(m.samples, meterSample{at: now, bytesIn: m.bytesIn.Load(), bytesOut: m.bytesOut.Load()})
+	if len(m.samples) > rateWindow {
+		m.samples = m.samples[len(m.samples)-rateWindow:]
+	}
+	m.samplesMu.Unlock()
+}
+
+// countingWriter adds bytes written to a counter and checks the meter's cap.
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+	m *meter
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n.Add(int64(n))
+	cw.m.checkCap()
+	return n, err
+}
+
+// closeWrite half-closes a TCP connection so the peer sees EOF.
+func closeWrite(c net.Conn) {
+	if tc, ok := c.(*net.TCPConn); ok {
+		tc.CloseWrite()
+	}
+}
@@ -0,0 +1,127 @@
+package tunnel
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// startEchoServer starts a TCP server that echoes everything it receives.
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func dialMeter(t *testing.T, m *meter) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", m.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial meter: %v", err)
+	}
+	return conn
+}
+
+func TestMeter_CountsBytes(t *testing.T) {
+	m, err := newMeter(startEchoServer(t), 0, nil)
+	if err != nil {
+		t.Fatalf("newMeter: %v", err)
+	}
+	defer m.Close()
+
+	conn := dialMeter(t, m)
+	defer conn.Close()
+
+	payload := []byte("hello tunnel")
+	conn.Write(payload)
+	buf := make([]byte, len(payload))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read echo: %v", err)
+	}
+
+	usage := m.Usage()
+	if usage.BytesIn != int64(len(payload)) {
+		t.Errorf("BytesIn = %d, want %d", usage.BytesIn, len(payload))
+	}
+	if usage.BytesOut != int64(len(payload)) {
+		t.Errorf("BytesOut = %d, want %d", usage.BytesOut, len(payload))
+	}
+	if usage.RateIn <= 0 {
+		t.Errorf("RateIn = %f, want > 0", usage.RateIn)
+	}
+}
+
+func TestMeter_CapPausesAndResumes(t *testing.T) {
+	capped := make(chan Usage, 1)
+	m, err := newMeter(startEchoServer(t), 16, func(u Usage) { capped <- u })
+	if err != nil {
+		t.Fatalf("newMeter: %v", err)
+	}
+	defer m.Close()
+
+	conn := dialMeter(t, m)
+	defer conn.Close()
+	conn.Write(make([]byte, 32))
+
+	select {
+	case u := <-capped:
+		if !u.Paused {
+			t.Error("expected usage to report paused")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cap callback not invoked")
+	}
+
+	// Existing connection is dropped and new ones are refused
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Errorf("expected closed connection, got %v", err)
+	}
+	refused := dialMeter(t, m)
+	refused.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if n, _ := refused.Read(make([]byte, 1)); n != 0 {
+		t.Error("expected paused meter to refuse traffic")
+	}
+	refused.Close()
+
+	m.Resume()
+	if m.Usage().Paused {
+		t.Fatal("expected meter to be resumed")
+	}
+
+	resumed := dialMeter(t, m)
+	defer resumed.Close()
+	resumed.Write([]byte("ok"))
+	buf := make([]byte, 2)
+	resumed.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.ReadFull(resumed, buf); err != nil {
+		t.Fatalf("read after resume: %v", err)
+	}
+}
+
+func TestTunnel_ResumeRequiresPaused(t *testing.T) {
+	tun := New(Config{Provider: ProviderCloudflare, LocalPort: 8080})
+	if err := tun.Resume(); err == nil {
+		t.Error("expected error resuming a tunnel that is not paused")
+	}
+	if StatePaused.String() != "paused" {
+		t.Errorf("StatePaused.String() = %q", StatePaused.String())
+	}
+}
@@ -0,0 +1,145 @@
+package tunnel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandURLPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		input   string
+		want    string
+	}{
+		{"localtunnel", "", "your url is: https://tidy-pumas-wave.loca.lt", "https://tidy-pumas-wave.loca.lt"},
+		{"tailscale", "", "https://devbox.tail1234.ts.net/", "https://devbox.tail1234.ts.net"},
+		{"tailscale login", "", "To enable, visit: https://login.tailscale.com/f/funnel?node=abc", ""},
+		{"generic", "", "tunnel ready at https://abc.example.dev.", "https://abc.example.dev"},
+		{"group", `tunneled with tls termination, (https://\S+)`, "abc.lhr.life tunneled with tls termination, https://abc.lhr.life", "https://abc.lhr.life"},
+	}
+	defaults := map[string]string{
+		"localtunnel":     localtunnelURLPattern.String(),
+		"tailscale":       tailscaleURLPattern.String(),
+		"tailscale login": tailscaleURLPattern.String(),
+		"generic":         genericURLPattern.String(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := tt.pattern
+			if pattern == "" {
+				pattern = defaults[tt.name]
+			}
+			tun := New(Config{Provider: ProviderCustom, Command: "x", URLPattern: pattern})
+			_, _, re, err := tun.commandLine(1234)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchURL(re, tt.input); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, p := range []Provider{ProviderCloudflare, ProviderNgrok, ProviderLocaltunnel, ProviderTailscale} {
+		if err := (Config{Provider: p}).Validate(); err != nil {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+	if err := (Config{Provider: "pagekite"}).Validate(); err == nil {
+		t.Error("expected an unknown provider to fail")
+	}
+	if err := (Config{Provider: ProviderCustom}).Validate(); err == nil {
+		t.Error("expected custom without a command to fail")
+	}
+	if err := (Config{Provider: ProviderCustom, Command: "x", URLPattern: "("}).Validate(); err == nil {
+		t.Error("expected an invalid url_pattern to fail")
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	bin, args, _, _ := New(Config{Provider: ProviderLocaltunnel}).commandLine(4321)
+	if bin != "lt" || strings.Join(args, " ") != "--port 4321 --local-host 127.0.0.1" {
+		t.Errorf("localtunnel: %s %v", bin, args)
+	}
+	bin, args, _, _ = New(Config{Provider: ProviderTailscale, BinaryPath: "/opt/ts"}).commandLine(4321)
+	if bin != "/opt/ts" || strings.Join(args, " ") != "funnel 4321" {
+		t.Errorf("tailscale: %s %v", bin, args)
+	}
+	bin, args, _, _ = New(Config{Provider: ProviderCustom, Command: "ssh -R 80:localhost:{{PORT}} nokey@localhost.run"}).commandLine(4321)
+	if bin != "ssh" || strings.Join(args, " ") != "-R 80:localhost:4321 nokey@localhost.run" {
+		t.Errorf("custom: %s %v", bin, args)
+	}
+}
+
+func TestTunnel_CustomProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "fake-tunnel")
+	body := "#!/bin/sh\necho 'see https://docs.example.test for help' >&2\necho \"forwarding $1 to https://demo-$1.example.test\"\nsleep 30\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tun := New(Config{
+		Provider:   ProviderCustom,
+		LocalPort:  9,
+		Command:    script + " {{PORT}}",
+		URLPattern: `https://demo-\S+`,
+	})
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tun.Stop(ctx)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	url, err := tun.WaitForURL(ctx)
+	if err != nil {
+		t.Fatalf("WaitForURL: %v", err)
+	}
+	want := "https://demo-" + strconv.Itoa(tun.meter.Port()) + ".example.test"
+	if url != want {
+		t.Errorf("got %q, want %q", url, want)
+	}
+	if tun.State() != StateConnected {
+		t.Errorf("expected connected, got %s", tun.State())
+	}
+}
+
+func TestTunnel_CustomProviderExitsWithoutURL(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "fake-tunnel")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'login required'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tun := New(Config{Provider: ProviderCustom, LocalPort: 9, Command: script})
+	if err := tun.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := tun.WaitForURL(ctx); err == nil || !strings.Contains(err.Error(), "without printing a public URL") {
+		t.Errorf("expected an error for a missing URL, got %v", err)
+	}
+	if tun.State() != StateFailed {
+		t.Errorf("expected failed, got %s", tun.State())
+	}
+}
@@ -1,13 +1,19 @@
-// Package tunnel provides management for tunnel services like Cloudflare and ngrok.
+// Package tunnel provides management for tunnel services like Cloudflare,
+// ngrok, localtunnel and Tailscale Funnel.
 package tunnel
 
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,8 +27,17 @@ const (
 	ProviderCloudflare Provider = "cloudflare"
 	// ProviderNgrok uses ngrok for tunneling.
 	ProviderNgrok Provider = "ngrok"
+	// ProviderLocaltunnel uses the localtunnel client (lt) for loca.lt URLs.
+	ProviderLocaltunnel Provider = "localtunnel"
+	// ProviderTailscale uses Tailscale Funnel to serve on the machine's ts.net name.
+	ProviderTailscale Provider = "tailscale"
+	// ProviderCustom runs Config.Command and reads the URL from its output.
+	ProviderCustom Provider = "custom"
 )
 
+// PortPlaceholder is replaced in a custom command with the port to expose.
+const PortPlaceholder = "{{PORT}}"
+
 // State represents the tunnel state.
 type State uint32
 
@@ -32,6 +47,8 @@ const (
 	StateConnected
 	StateFailed
 	StateStopped
+	StatePaused       // bandwidth cap exceeded; relay refuses traffic until resumed
+	StateReconnecting // provider process died; waiting to restart it
 )
 
 func (s State) String() string {
@@ -46,6 +63,10 @@ func (s State) String() string {
 		return "failed"
 	case StateStopped:
 		return "stopped"
+	case StatePaused:
+		return "paused"
+	case StateReconnecting:
+		return "reconnecting"
 	default:
 		return "unknown"
 	}
@@ -59,6 +80,35 @@ type Config struct {
 	BinaryPath string // optional: path to tunnel binary, otherwise uses PATH
 	ID         string // tunnel identifier
 	Path       string // project path for session scoping
+	MaxBytes   int64  // optional bandwidth cap (bytes in + out); 0 means unlimited
+
+	Expires time.Duration // optional TTL; the tunnel auto-stops when it elapses
+
+	// Command is the command line of the custom provider, split on spaces,
+	// with PortPlaceholder replaced by the port to expose.
+	Command string
+	// URLPattern is a regexp finding the public URL in the custom command's
+	// output; its first group when it has one. Default: the first https URL.
+	URLPattern string
+
+	// NoReconnect leaves a tunnel failed when its provider process dies
+	// instead of restarting it with backoff.
+	NoReconnect bool
+	// HealthInterval is how often the public URL is probed; 0 means
+	// defaultHealthInterval and a negative value disables probing.
+	HealthInterval time.Duration
+
+	// OnEvent is called for disconnects, reconnects and failed health checks.
+	OnEvent func(event Event)
+
+	// OnCapExceeded is called when MaxBytes is exceeded and the tunnel pauses.
+	OnCapExceeded func(usage Usage)
+
+	// OnExpiryWarning is called shortly before the TTL elapses with the time remaining.
+	OnExpiryWarning func(remaining time.Duration)
+
+	// OnExpired is called after the tunnel has been stopped by its TTL.
+	OnExpired func()
 }
 
 // Tunnel represents a running tunnel instance.
@@ -67,11 +117,23 @@ type Tunnel struct {
 	state     atomic.Uint32
 	publicURL atomic.Pointer[string]
 	cmd       *exec.Cmd
+	cmdMu     sync.Mutex
 	cancel    context.CancelFunc
 	done      chan struct{}
 	err       error
 	errMu     sync.RWMutex
 
+	// Bandwidth meter relaying tunnel traffic to the local service
+	meter *meter
+
+	// TTL state
+	expiresAt atomic.Pointer[time.Time]
+	expired   atomic.Bool
+
+	// Reconnect state: attempts in the current outage and the total count
+	attempt    atomic.Int32
+	reconnects atomic.Int32
+
 	// Callbacks
 	onURL func(url string)
 }
@@ -85,6 +147,31 @@ type TunnelInfo struct {
 	LocalAddr string   `json:"local_addr"`
 	Path      string   `json:"path,omitempty"`
 	Error     string   `json:"error,omitempty"`
+	Usage     *Usage   `json:"usage,omitempty"`
+	ExpiresAt string   `json:"expires_at,omitempty"` // RFC3339, set when a TTL is configured
+	// Reconnects counts provider restarts after disconnects.
+	Reconnects int `json:"reconnects,omitempty"`
+}
+
+// Validate checks the provider and, for the custom provider, its command
+// and URL pattern.
+func (c Config) Validate() error {
+	switch c.Provider {
+	case ProviderCloudflare, ProviderNgrok, ProviderLocaltunnel, ProviderTailscale:
+		return nil
+	case ProviderCustom:
+		if strings.TrimSpace(c.Command) == "" {
+			return fmt.Errorf("custom tunnel provider requires a command")
+		}
+		if c.URLPattern != "" {
+			if _, err := regexp.Compile(c.URLPattern); err != nil {
+				return fmt.Errorf("invalid url_pattern: %w", err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported tunnel provider: %s (use cloudflare, ngrok, localtunnel, tailscale or custom)", c.Provider)
+	}
 }
 
 // New creates a new tunnel with the given configuration.
@@ -110,29 +197,91 @@ func (t *Tunnel) Start(ctx context.Context) error {
 		return fmt.Errorf("tunnel already started")
 	}
 
+	if err := t.config.Validate(); err != nil {
+		t.setState(StateFailed)
+		return err
+	}
+
+	// Route the provider through a local relay for bandwidth accounting
+	target := net.JoinHostPort(t.config.LocalHost, strconv.Itoa(t.config.LocalPort))
+	m, err := newMeter(target, t.config.MaxBytes, t.handleCapExceeded)
+	if err != nil {
+		t.setState(StateFailed)
+		return fmt.Errorf("failed to start bandwidth meter: %w", err)
+	}
+	t.meter = m
+	go func() {
+		<-t.done
+		m.Close()
+	}()
+
 	ctx, cancel := context.WithCancel(ctx)
 	t.cancel = cancel
 
+	exited, err := t.launch(ctx)
+	if err != nil {
+		close(t.done)
+		return err
+	}
+
+	t.scheduleExpiry()
+	go t.supervise(ctx, exited)
+	go t.monitorHealth(ctx)
+	return nil
+}
+
+// launch starts the provider process. The returned channel is closed when
+// the process exits.
+func (t *Tunnel) launch(ctx context.Context) (<-chan struct{}, error) {
+	exited := make(chan struct{})
+	var err error
 	switch t.config.Provider {
-	case ProviderCloudflare:
-		return t.startCloudflare(ctx)
 	case ProviderNgrok:
-		return t.startNgrok(ctx)
+		err = t.startNgrok(ctx, exited)
+	case ProviderCloudflare:
+		err = t.startCloudflare(ctx, exited)
 	default:
-		t.setState(StateFailed)
-		return fmt.Errorf("unsupported tunnel provider: %s", t.config.Provider)
+		err = t.startCommand(ctx, exited)
+	}
+	return exited, err
+}
+
+// handleCapExceeded marks the tunnel paused and notifies the cap callback.
+func (t *Tunnel) handleCapExceeded(usage Usage) {
+	t.setState(StatePaused)
+	if t.config.OnCapExceeded != nil {
+		t.config.OnCapExceeded(usage)
 	}
 }
 
+// Resume resumes a tunnel paused by its bandwidth cap.
+// The cap applies again from the current totals.
+func (t *Tunnel) Resume() error {
+	if t.State() != StatePaused || t.meter == nil {
+		return fmt.Errorf("tunnel is not paused")
+	}
+	t.meter.Resume()
+	t.setState(StateConnected)
+	return nil
+}
+
+// Usage returns bandwidth usage for the tunnel.
+func (t *Tunnel) Usage() Usage {
+	if t.meter == nil {
+		return Usage{MaxBytes: t.config.MaxBytes}
+	}
+	return t.meter.Usage()
+}
+
 // Stop stops the tunnel.
 func (t *Tunnel) Stop(ctx context.Context) error {
 	if t.cancel != nil {
 		t.cancel()
 	}
 
-	if t.cmd != nil && t.cmd.Process != nil {
+	if cmd := t.currentCmd(); cmd != nil && cmd.Process != nil {
 		// Send interrupt first for graceful shutdown
-		if err := t.cmd.Process.Kill(); err != nil {
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
 			return fmt.Errorf("failed to kill tunnel process: %w", err)
 		}
 	}
@@ -164,12 +313,20 @@ func (t *Tunnel) PublicURL() string {
 // Info returns information about the tunnel.
 func (t *Tunnel) Info() TunnelInfo {
 	info := TunnelInfo{
-		ID:        t.config.ID,
-		Provider:  t.config.Provider,
-		State:     t.State().String(),
-		PublicURL: t.PublicURL(),
-		LocalAddr: fmt.Sprintf("%s:%d", t.config.LocalHost, t.config.LocalPort),
-		Path:      t.config.Path,
+		ID:         t.config.ID,
+		Provider:   t.config.Provider,
+		State:      t.State().String(),
+		PublicURL:  t.PublicURL(),
+		LocalAddr:  fmt.Sprintf("%s:%d", t.config.LocalHost, t.config.LocalPort),
+		Path:       t.config.Path,
+		Reconnects: int(t.reconnects.Load()),
+	}
+	if t.meter != nil {
+		usage := t.meter.Usage()
+		info.Usage = &usage
+	}
+	if expiresAt := t.ExpiresAt(); !expiresAt.IsZero() {
+		info.ExpiresAt = expiresAt.Format(time.RFC3339)
 	}
 
 	t.errMu.RLock()
@@ -239,19 +396,36 @@ func (t *Tunnel) setError(err error) {
 }
 
 func (t *Tunnel) setPublicURL(url string) {
+	first := t.PublicURL() == ""
 	t.publicURL.Store(&url)
+	if attempt := t.attempt.Load(); attempt > 0 && first {
+		t.reconnects.Add(1)
+		t.emit(Event{Type: EventReconnected, Attempt: int(attempt), PublicURL: url})
+	}
 	if t.onURL != nil {
 		t.onURL(url)
 	}
 }
 
+func (t *Tunnel) setCmd(cmd *exec.Cmd) {
+	t.cmdMu.Lock()
+	t.cmd = cmd
+	t.cmdMu.Unlock()
+}
+
+func (t *Tunnel) currentCmd() *exec.Cmd {
+	t.cmdMu.Lock()
+	defer t.cmdMu.Unlock()
+	return t.cmd
+}
+
 // cloudflared output patterns
 var (
 	// Matches: https://something-something.trycloudflare.com
 	cloudflareURLPattern = regexp.MustCompile(`https://[a-z0-9-]+\.trycloudflare\.com`)
 )
 
-func (t *Tunnel) startCloudflare(ctx context.Context) error {
+func (t *Tunnel) startCloudflare(ctx context.Context, exited chan struct{}) error {
 	binary := t.config.BinaryPath
 	if binary == "" {
 		binary = "cloudflared"
@@ -261,36 +435,34 @@ func (t *Tunnel) startCloudflare(ctx context.Context) error {
 	if _, err := exec.LookPath(binary); err != nil {
 		t.setState(StateFailed)
 		t.setError(fmt.Errorf("cloudflared not found in PATH: %w", err))
-		close(t.done)
 		return t.err
 	}
 
-	localURL := fmt.Sprintf("http://%s:%d", t.config.LocalHost, t.config.LocalPort)
-	t.cmd = exec.CommandContext(ctx, binary, "tunnel", "--url", localURL)
+	localURL := fmt.Sprintf("http://127.0.0.1:%d", t.meter.Port())
+	cmd := exec.CommandContext(ctx, binary, "tunnel", "--url", localURL)
 
 	// Capture stderr (cloudflared logs to stderr)
-	stderr, err := t.cmd.StderrPipe()
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		t.setState(StateFailed)
 		t.setError(fmt.Errorf("failed to create stderr pipe: %w", err))
-		close(t.done)
 		return t.err
 	}
 
-	if err := t.cmd.Start(); err != nil {
+	if err := cmd.Start(); err != nil {
 		t.setState(StateFailed)
 		t.setError(fmt.Errorf("failed to start cloudflared: %w", err))
-		close(t.done)
 		return t.err
 	}
+	t.setCmd(cmd)
 
 	// Parse output in goroutine
 	go t.parseCloudflareOutput(stderr)
 
 	// Wait for process in goroutine
 	go func() {
-		defer close(t.done)
-		if err := t.cmd.Wait(); err != nil {
+		defer close(exited)
+		if err := cmd.Wait(); err != nil {
 			if ctx.Err() == nil { // Not cancelled
 				t.setError(fmt.Errorf("cloudflared exited: %w", err))
 				t.setState(StateFailed)
@@ -318,7 +490,7 @@ var (
 	ngrokURLPattern = regexp.MustCompile(`https://[a-z0-9-]+\.ngrok(?:-free)?\.(?:io|app)`)
 )
 
-func (t *Tunnel) startNgrok(ctx context.Context) error {
+func (t *Tunnel) startNgrok(ctx context.Context, exited chan struct{}) error {
 	binary := t.config.BinaryPath
 	if binary == "" {
 		binary = "ngrok"
@@ -328,35 +500,33 @@ func (t *Tunnel) startNgrok(ctx context.Context) error {
 	if _, err := exec.LookPath(binary); err != nil {
 		t.setState(StateFailed)
 		t.setError(fmt.Errorf("ngrok not found in PATH: %w", err))
-		close(t.done)
 		return t.err
 	}
 
-	t.cmd = exec.CommandContext(ctx, binary, "http", fmt.Sprintf("%d", t.config.LocalPort))
+	cmd := exec.CommandContext(ctx, binary, "http", fmt.Sprintf("127.0.0.1:%d", t.meter.Port()))
 
 	// ngrok outputs to stdout
-	stdout, err := t.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		t.setState(StateFailed)
 		t.setError(fmt.Errorf("failed to create stdout pipe: %w", err))
-		close(t.done)
 		return t.err
 	}
 
-	if err := t.cmd.Start(); err != nil {
+	if err := cmd.Start(); err != nil {
 		t.setState(StateFailed)
 		t.setError(fmt.Errorf("failed to start ngrok: %w", err))
-		close(t.done)
 		return t.err
 	}
+	t.setCmd(cmd)
 
 	// Parse output in goroutine
 	go t.parseNgrokOutput(stdout)
 
 	// Wait for process in goroutine
 	go func() {
-		defer close(t.done)
-		if err := t.cmd.Wait(); err != nil {
+		defer close(exited)
+		if err := cmd.Wait(); err != nil {
 			if ctx.Err() == nil { // Not cancelled
 				t.setError(fmt.Errorf("ngrok exited: %w", err))
 				t.setState(StateFailed)
@@ -377,3 +547,154 @@ func (t *Tunnel) parseNgrokOutput(r io.Reader) {
 		}
 	}
 }
+
+// Output patterns of the command-line providers
+var (
+	// Matches: your url is: https://tidy-pumas-wave.loca.lt
+	localtunnelURLPattern = regexp.MustCompile(`https://[a-z0-9-]+\.loca\.lt`)
+	// Matches: https://devbox.tail1234.ts.net/
+	tailscaleURLPattern = regexp.MustCompile(`https://[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.ts\.net`)
+	// Fallback for custom commands: the first https URL
+	genericURLPattern = regexp.MustCompile(`https://[^\s"'<>|]+`)
+)
+
+// commandLine returns the binary, arguments and URL pattern of a
+// command-line provider (localtunnel, tailscale, custom) exposing port.
+func (t *Tunnel) commandLine(port int) (string, []string, *regexp.Regexp, error) {
+	portArg := strconv.Itoa(port)
+	switch t.config.Provider {
+	case ProviderLocaltunnel:
+		return t.binary("lt"), []string{"--port", portArg, "--local-host", "127.0.0.1"}, localtunnelURLPattern, nil
+	case ProviderTailscale:
+		return t.binary("tailscale"), []string{"funnel", portArg}, tailscaleURLPattern, nil
+	default:
+		fields := strings.Fields(strings.ReplaceAll(t.config.Command, PortPlaceholder, portArg))
+		if len(fields) == 0 {
+			return "", nil, nil, fmt.Errorf("custom tunnel provider requires a command")
+		}
+		pattern := genericURLPattern
+		if t.config.URLPattern != "" {
+			var err error
+			if pattern, err = regexp.Compile(t.config.URLPattern); err != nil {
+				return "", nil, nil, fmt.Errorf("invalid url_pattern: %w", err)
+			}
+		}
+		return fields[0], fields[1:], pattern, nil
+	}
+}
+
+// binary returns the configured binary path or the provider's default.
+func (t *Tunnel) binary(name string) string {
+	if t.config.BinaryPath != "" {
+		return t.config.BinaryPath
+	}
+	return name
+}
+
+// startCommand starts a command-line provider. Its stdout and stderr are
+// read together, as the providers differ in which one carries the URL.
+func (t *Tunnel) startCommand(ctx context.Context, exited chan struct{}) error {
+	binary, args, pattern, err := t.commandLine(t.meter.Port())
+	if err != nil {
+		t.setState(StateFailed)
+		t.setError(err)
+		return t.err
+	}
+
+	// Check if binary exists
+	if _, err := exec.LookPath(binary); err != nil {
+		t.setState(StateFailed)
+		t.setError(fmt.Errorf("%s not found in PATH%s: %w", binary, installHint(t.config.Provider), err))
+		return t.err
+	}
+
+	// An OS pipe rather than an io.Pipe, so Wait returns when the process
+	// exits even if a child it spawned still holds the output open
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.setState(StateFailed)
+		t.setError(fmt.Errorf("failed to create output pipe: %w", err))
+		return t.err
+	}
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	err = cmd.Start()
+	pw.Close()
+	if err != nil {
+		pr.Close()
+		t.setState(StateFailed)
+		t.setError(fmt.Errorf("failed to start %s: %w", binary, err))
+		return t.err
+	}
+	t.setCmd(cmd)
+
+	// Parse output in goroutine
+	parsed := make(chan struct{})
+	go func() {
+		defer close(parsed)
+		t.parseCommandOutput(pr, pattern)
+	}()
+
+	// Wait for process in goroutine
+	go func() {
+		defer close(exited)
+		err := cmd.Wait()
+		// Let the parser read what the process printed before exiting
+		select {
+		case <-parsed:
+		case <-time.After(time.Second):
+		}
+		if err == nil && t.PublicURL() == "" {
+			err = fmt.Errorf("exited without printing a public URL")
+		}
+		if err != nil && ctx.Err() == nil { // Not cancelled
+			t.setError(fmt.Errorf("%s exited: %w", binary, err))
+			t.setState(StateFailed)
+		}
+	}()
+
+	return nil
+}
+
+// parseCommandOutput takes the first URL the pattern finds as the public URL.
+func (t *Tunnel) parseCommandOutput(r io.ReadCloser, pattern *regexp.Regexp) {
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if t.PublicURL() != "" {
+			continue // keep draining so the process never blocks on output
+		}
+		if url := matchURL(pattern, scanner.Text()); url != "" {
+			t.setPublicURL(url)
+			t.setState(StateConnected)
+		}
+	}
+}
+
+// matchURL returns the pattern's first group, or its whole match when it has
+// no group, without trailing slashes or punctuation.
+func matchURL(pattern *regexp.Regexp, line string) string {
+	m := pattern.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	url := m[0]
+	if len(m) > 1 && m[1] != "" {
+		url = m[1]
+	}
+	return strings.TrimRight(url, "/.,;")
+}
+
+// installHint names how to get a provider's binary.
+func installHint(p Provider) string {
+	switch p {
+	case ProviderLocaltunnel:
+		return " (npm install -g localtunnel)"
+	case ProviderTailscale:
+		return " (install Tailscale and enable Funnel for the tailnet)"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,227 @@
+// Package watch finds changes to project files matching glob patterns by
+// comparing snapshots of their size and modification time. Polling keeps it
+// dependency free and works the same on every platform and file system,
+// including network mounts and WSL drives where change notifications don't.
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxFiles bounds the files a snapshot keeps, so a broad pattern over a
+// large tree can't make every poll expensive.
+const MaxFiles = 20000
+
+// ErrTooManyFiles is returned with a snapshot cut at MaxFiles.
+var ErrTooManyFiles = errors.New("too many matching files")
+
+// skipDirs are never walked: version control, dependencies and agnt's own
+// state change often and are rarely what a watch is for.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".agnt":        true,
+	"node_modules": true,
+	"__pycache__":  true,
+	".venv":        true,
+}
+
+// ValidatePattern checks a glob pattern.
+func ValidatePattern(pattern string) error {
+	if pattern == "" {
+		return errors.New("empty pattern")
+	}
+	if path.IsAbs(pattern) || strings.HasPrefix(pattern, "../") {
+		return errors.New("pattern must be relative to the project")
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match reports whether a slash-separated path relative to the project
+// matches pattern. "**" matches any number of directories, and the other
+// segments use path.Match syntax. A pattern without "/" matches the file
+// name in any directory, so "*.go" is "**/*.go".
+func Match(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// FileState is what a snapshot keeps of a file.
+type FileState struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Snapshot maps slash-separated paths relative to the root to their state.
+type Snapshot map[string]FileState
+
+// Scan snapshots the files under root matching any of the patterns.
+func Scan(root string, patterns ...string) (Snapshot, error) {
+	snap := make(Snapshot)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil // Vanished or unreadable: not a change we can see
+		}
+		if d.IsDir() {
+			if p != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range patterns {
+			if !Match(pattern, rel) {
+				continue
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if len(snap) >= MaxFiles {
+				return ErrTooManyFiles
+			}
+			snap[rel] = FileState{Size: info.Size(), ModTime: info.ModTime()}
+			break
+		}
+		return nil
+	})
+	return snap, err
+}
+
+// Changes are the differences between two snapshots.
+type Changes struct {
+	Added    []string `json:"added,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// Empty reports whether nothing changed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// Paths returns every changed path, sorted.
+func (c Changes) Paths() []string {
+	paths := append(append(append([]string(nil), c.Added...), c.Modified...), c.Removed...)
+	sort.Strings(paths)
+	return paths
+}
+
+// Merge adds later changes to c. A file added then modified stays added,
+// and one added then removed is dropped.
+func (c Changes) Merge(later Changes) Changes {
+	state := make(map[string]string)
+	for _, p := range c.Added {
+		state[p] = "added"
+	}
+	for _, p := range c.Modified {
+		state[p] = "modified"
+	}
+	for _, p := range c.Removed {
+		state[p] = "removed"
+	}
+	for _, p := range later.Added {
+		if state[p] == "removed" {
+			state[p] = "modified"
+		} else {
+			state[p] = "added"
+		}
+	}
+	for _, p := range later.Modified {
+		if state[p] != "added" {
+			state[p] = "modified"
+		}
+	}
+	for _, p := range later.Removed {
+		if state[p] == "added" {
+			delete(state, p)
+		} else {
+			state[p] = "removed"
+		}
+	}
+	var merged Changes
+	for p, s := range state {
+		switch s {
+		case "added":
+			merged.Added = append(merged.Added, p)
+		case "modified":
+			merged.Modified = append(merged.Modified, p)
+		case "removed":
+			merged.Removed = append(merged.Removed, p)
+		}
+	}
+	merged.sort()
+	return merged
+}
+
+// Diff returns what changed from prev to cur.
+func Diff(prev, cur Snapshot) Changes {
+	var c Changes
+	for p, st := range cur {
+		old, ok := prev[p]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, p)
+		case old.Size != st.Size || !old.ModTime.Equal(st.ModTime):
+			c.Modified = append(c.Modified, p)
+		}
+	}
+	for p := range prev {
+		if _, ok := cur[p]; !ok {
+			c.Removed = append(c.Removed, p)
+		}
+	}
+	c.sort()
+	return c
+}
+
+func (c *Changes) sort() {
+	sort.Strings(c.Added)
+	sort.Strings(c.Modified)
+	sort.Strings(c.Removed)
+}
@@ -0,0 +1,96 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/watch/watch.go", true},
+		{"*.go", "main.go.orig", false},
+		{"src/**/*.ts", "src/app.ts", true},
+		{"src/**/*.ts", "src/a/b/app.ts", true},
+		{"src/**/*.ts", "lib/app.ts", false},
+		{"src/*.ts", "src/a/app.ts", false},
+		{"**", "any/thing", true},
+		{"config/app.yaml", "config/app.yaml", true},
+		{"config/*.y?ml", "config/app.yml", false},
+		{"config/*.y?ml", "config/app.yaml", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+
+	if err := ValidatePattern("src/[a-"); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+	if err := ValidatePattern("/etc/*"); err == nil {
+		t.Error("Expected an error for an absolute pattern")
+	}
+}
+
+func TestScanAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		p := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main")
+	write("pkg/util.go", "package pkg")
+	write("README.md", "# readme")
+	write("node_modules/dep/index.go", "ignored")
+
+	before, err := Scan(dir, "*.go")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(before) != 2 {
+		t.Fatalf("Expected 2 files outside node_modules, got %v", before)
+	}
+
+	write("main.go", "package main // changed")
+	write("pkg/new.go", "package pkg")
+	os.Remove(filepath.Join(dir, "pkg/util.go"))
+	after, _ := Scan(dir, "*.go")
+
+	want := Changes{Added: []string{"pkg/new.go"}, Modified: []string{"main.go"}, Removed: []string{"pkg/util.go"}}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %+v, want %+v", got, want)
+	}
+	if !Diff(after, after).Empty() {
+		t.Error("Expected no changes between equal snapshots")
+	}
+
+	// Same size, later mtime is a change too
+	st := after["main.go"]
+	st.ModTime = st.ModTime.Add(time.Second)
+	later := Snapshot{"main.go": st, "pkg/new.go": after["pkg/new.go"]}
+	if got := Diff(after, later); !reflect.DeepEqual(got.Modified, []string{"main.go"}) {
+		t.Errorf("Expected an mtime change detected, got %+v", got)
+	}
+}
+
+func TestChangesMerge(t *testing.T) {
+	first := Changes{Added: []string{"a.go", "b.go"}, Removed: []string{"c.go"}}
+	later := Changes{Modified: []string{"a.go"}, Removed: []string{"b.go"}, Added: []string{"c.go"}}
+	want := Changes{Added: []string{"a.go"}, Modified: []string{"c.go"}}
+	if got := first.Merge(later); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+	if got := want.Paths(); !reflect.DeepEqual(got, []string{"a.go", "c.go"}) {
+		t.Errorf("Paths = %v", got)
+	}
+}
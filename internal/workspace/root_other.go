@@ -0,0 +1,21 @@
+//go:build !unix
+
+package workspace
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkRoot refuses a workspace root that isn't a real directory. The root
+// is under the user's profile, which other users can't write to.
+func checkRoot(root string) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("workspace root %s is not a directory", root)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkRoot refuses a workspace root another user could have planted or can
+// write to: it must be a real directory owned by the current user. Group and
+// other access is removed.
+func checkRoot(root string) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("workspace root %s is not a directory", root)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("workspace root %s is owned by uid %d, not the current user", root, st.Uid)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return os.Chmod(root, 0o700)
+	}
+	return nil
+}
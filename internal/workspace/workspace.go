@@ -0,0 +1,338 @@
+// Package workspace creates disposable copies of a project — git worktrees
+// or plain copies — so experiments can run without touching the user's
+// working tree.
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Methods of creating a workspace.
+const (
+	// MethodWorktree checks the project out with git worktree. Without a
+	// ref it includes uncommitted changes to tracked files.
+	MethodWorktree = "worktree"
+	// MethodCopy copies the project directory, untracked files included.
+	MethodCopy = "copy"
+)
+
+// validID matches workspace IDs, which also name their directory.
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Workspace is a disposable copy of a project.
+type Workspace struct {
+	ID          string    `json:"id"`
+	ProjectPath string    `json:"project_path"` // The project it was created from
+	Path        string    `json:"path"`         // Where it lives; RUN it with this path
+	Method      string    `json:"method"`
+	Ref         string    `json:"ref,omitempty"`    // Requested git ref
+	Commit      string    `json:"commit,omitempty"` // Commit checked out, for worktrees
+	SessionCode string    `json:"session_code,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateOptions describes a workspace to create.
+type CreateOptions struct {
+	ID          string `json:"id,omitempty"`
+	ProjectPath string `json:"path"`
+	// Ref is a git ref to check out (worktree only); empty uses the current
+	// working state.
+	Ref string `json:"ref,omitempty"`
+	// Method is MethodWorktree or MethodCopy; empty picks worktree for git
+	// projects and copy otherwise.
+	Method string `json:"method,omitempty"`
+	// SessionCode ties the workspace to a session, which removes it when
+	// the session ends.
+	SessionCode string `json:"session_code,omitempty"`
+}
+
+// Manager tracks the workspaces it created under a root directory.
+type Manager struct {
+	root string
+
+	mu         sync.Mutex
+	workspaces map[string]*Workspace
+	seq        int
+}
+
+// NewManager returns a manager creating workspaces under root.
+func NewManager(root string) *Manager {
+	return &Manager{root: root, workspaces: make(map[string]*Workspace)}
+}
+
+// Create makes a workspace from a project.
+func (m *Manager) Create(ctx context.Context, opts CreateOptions) (*Workspace, error) {
+	if opts.ProjectPath == "" {
+		return nil, fmt.Errorf("project path required")
+	}
+	info, err := os.Stat(opts.ProjectPath)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("project path %q is not a directory", opts.ProjectPath)
+	}
+	method := opts.Method
+	if method == "" {
+		method = MethodCopy
+		if isGitRepo(ctx, opts.ProjectPath) {
+			method = MethodWorktree
+		}
+	}
+	switch method {
+	case MethodWorktree:
+	case MethodCopy:
+		if opts.Ref != "" {
+			return nil, fmt.Errorf("ref requires the worktree method")
+		}
+	default:
+		return nil, fmt.Errorf("unknown method %q (use worktree or copy)", method)
+	}
+
+	id, err := m.reserve(opts.ID)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := m.create(ctx, id, method, opts)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		delete(m.workspaces, id)
+		return nil, err
+	}
+	m.workspaces[id] = ws
+	return ws, nil
+}
+
+// reserve claims an ID, generating one when id is empty.
+func (m *Manager) reserve(id string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == "" {
+		for {
+			m.seq++
+			id = fmt.Sprintf("ws-%d", m.seq)
+			if _, taken := m.workspaces[id]; !taken {
+				break
+			}
+		}
+	} else if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid workspace ID %q", id)
+	}
+	if _, taken := m.workspaces[id]; taken {
+		return "", fmt.Errorf("workspace %q already exists", id)
+	}
+	m.workspaces[id] = nil // Placeholder while it is created
+	return id, nil
+}
+
+func (m *Manager) create(ctx context.Context, id, method string, opts CreateOptions) (*Workspace, error) {
+	if err := os.MkdirAll(m.root, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create workspace root: %w", err)
+	}
+	if err := checkRoot(m.root); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(m.root, id+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	ws := &Workspace{
+		ID:          id,
+		ProjectPath: opts.ProjectPath,
+		Path:        dir,
+		Method:      method,
+		Ref:         opts.Ref,
+		SessionCode: opts.SessionCode,
+		CreatedAt:   time.Now(),
+	}
+
+	if method == MethodWorktree {
+		err = addWorktree(ctx, ws)
+	} else {
+		err = copyTree(opts.ProjectPath, dir)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return ws, nil
+}
+
+// addWorktree checks the workspace's ref, or the project's working state,
+// out into its directory.
+func addWorktree(ctx context.Context, ws *Workspace) error {
+	commit := ws.Ref
+	if commit == "" {
+		// A commit of the working tree's tracked changes, leaving the tree
+		// and the stash list alone; empty when the tree is clean. The
+		// identity only needs to exist for the throwaway commit.
+		stash, err := git(ctx, ws.ProjectPath, "-c", "user.name=agnt", "-c", "user.email=agnt@localhost", "stash", "create")
+		if err != nil {
+			return err
+		}
+		commit = stash
+		if commit == "" {
+			commit = "HEAD"
+		}
+	}
+	resolved, err := git(ctx, ws.ProjectPath, "rev-parse", "--verify", commit+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("unknown ref %q: %w", commit, err)
+	}
+	if _, err := git(ctx, ws.ProjectPath, "worktree", "add", "--detach", ws.Path, resolved); err != nil {
+		return err
+	}
+	ws.Commit = resolved
+	return nil
+}
+
+// Get returns a workspace by ID.
+func (m *Manager) Get(id string) (*Workspace, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws := m.workspaces[id]
+	return ws, ws != nil
+}
+
+// List returns the workspaces of a project, or all with an empty path, by
+// creation time.
+func (m *Manager) List(projectPath string) []*Workspace {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*Workspace
+	for _, ws := range m.workspaces {
+		if ws != nil && (projectPath == "" || ws.ProjectPath == projectPath) {
+			list = append(list, ws)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// ForSession returns the workspaces tied to a session.
+func (m *Manager) ForSession(code string) []*Workspace {
+	var list []*Workspace
+	for _, ws := range m.List("") {
+		if ws.SessionCode == code {
+			list = append(list, ws)
+		}
+	}
+	return list
+}
+
+// Remove deletes a workspace and its directory.
+func (m *Manager) Remove(ctx context.Context, id string) error {
+	m.mu.Lock()
+	ws := m.workspaces[id]
+	if ws == nil {
+		m.mu.Unlock()
+		return fmt.Errorf("workspace %q not found", id)
+	}
+	delete(m.workspaces, id)
+	m.mu.Unlock()
+
+	if ws.Method == MethodWorktree {
+		// Also drops git's record of the worktree; the directory is removed
+		// below even when this fails
+		git(ctx, ws.ProjectPath, "worktree", "remove", "--force", ws.Path)
+	}
+	if err := os.RemoveAll(ws.Path); err != nil {
+		return fmt.Errorf("failed to remove workspace %q: %w", id, err)
+	}
+	return nil
+}
+
+// RemoveAll deletes every workspace, returning their IDs.
+func (m *Manager) RemoveAll(ctx context.Context) []string {
+	var ids []string
+	for _, ws := range m.List("") {
+		if m.Remove(ctx, ws.ID) == nil {
+			ids = append(ids, ws.ID)
+		}
+	}
+	return ids
+}
+
+// isGitRepo reports whether dir is inside a git work tree.
+func isGitRepo(ctx context.Context, dir string) bool {
+	out, err := git(ctx, dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// copyTree copies the contents of src into the existing directory dst,
+// keeping file modes and symlinks.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return nil // Sockets, devices and pipes aren't copied
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,154 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestWorktreeWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	project := t.TempDir()
+	runGit(t, project, "init", "-q")
+	os.WriteFile(filepath.Join(project, "main.txt"), []byte("v1"), 0644)
+	runGit(t, project, "add", ".")
+	runGit(t, project, "commit", "-q", "-m", "v1")
+	runGit(t, project, "tag", "v1")
+	os.WriteFile(filepath.Join(project, "main.txt"), []byte("v2 uncommitted"), 0644)
+
+	m := NewManager(t.TempDir())
+	current, err := m.Create(ctx, CreateOptions{ProjectPath: project, SessionCode: "claude-1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if current.Method != MethodWorktree || current.ID != "ws-1" || current.Commit == "" {
+		t.Errorf("Unexpected workspace %+v", current)
+	}
+	if got := readFile(t, filepath.Join(current.Path, "main.txt")); got != "v2 uncommitted" {
+		t.Errorf("Expected the uncommitted change in the workspace, got %q", got)
+	}
+
+	tagged, err := m.Create(ctx, CreateOptions{ID: "base", ProjectPath: project, Ref: "v1"})
+	if err != nil {
+		t.Fatalf("Create at ref failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(tagged.Path, "main.txt")); got != "v1" {
+		t.Errorf("Expected the tagged content, got %q", got)
+	}
+
+	// The user's tree is untouched
+	if got := readFile(t, filepath.Join(project, "main.txt")); got != "v2 uncommitted" {
+		t.Errorf("Expected the working tree unchanged, got %q", got)
+	}
+
+	if _, err := m.Create(ctx, CreateOptions{ID: "base", ProjectPath: project}); err == nil {
+		t.Error("Expected error for a duplicate ID")
+	}
+	if _, err := m.Create(ctx, CreateOptions{ProjectPath: project, Ref: "no-such-ref"}); err == nil {
+		t.Error("Expected error for an unknown ref")
+	}
+	if ws := m.ForSession("claude-1"); len(ws) != 1 || ws[0].ID != "ws-1" {
+		t.Errorf("Expected ws-1 tied to the session, got %v", ws)
+	}
+
+	if err := m.Remove(ctx, "ws-1"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(current.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected the workspace directory removed, got %v", err)
+	}
+	if ids := m.RemoveAll(ctx); len(ids) != 1 || ids[0] != "base" {
+		t.Errorf("Expected base removed, got %v", ids)
+	}
+	if list := m.List(""); len(list) != 0 {
+		t.Errorf("Expected no workspaces left, got %v", list)
+	}
+}
+
+func TestCopyWorkspace(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, "src"), 0755)
+	os.WriteFile(filepath.Join(project, "src", "app.js"), []byte("console.log(1)"), 0644)
+	os.WriteFile(filepath.Join(project, "run.sh"), []byte("#!/bin/sh"), 0755)
+	os.Symlink("src/app.js", filepath.Join(project, "link.js"))
+
+	m := NewManager(t.TempDir())
+	ws, err := m.Create(ctx, CreateOptions{ProjectPath: project, Method: MethodCopy})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(ws.Path, "link.js")); got != "console.log(1)" {
+		t.Errorf("Expected files and symlinks copied, got %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(ws.Path, "run.sh")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected the executable bit kept, got %v (%v)", info, err)
+	}
+
+	// Changes stay in the workspace
+	os.WriteFile(filepath.Join(ws.Path, "src", "app.js"), []byte("broken"), 0644)
+	if got := readFile(t, filepath.Join(project, "src", "app.js")); got != "console.log(1)" {
+		t.Errorf("Expected the project unchanged, got %q", got)
+	}
+
+	if _, err := m.Create(ctx, CreateOptions{ProjectPath: project, Method: MethodCopy, Ref: "main"}); err == nil {
+		t.Error("Expected error for a ref with the copy method")
+	}
+	if _, err := m.Create(ctx, CreateOptions{ID: "../escape", ProjectPath: project}); err == nil {
+		t.Error("Expected error for an invalid ID")
+	}
+}
+
+func TestWorkspaceRoot(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, "app.js"), []byte("console.log(1)"), 0644)
+
+	// The root is created for the current user only
+	root := filepath.Join(t.TempDir(), "workspaces")
+	if _, err := NewManager(root).Create(ctx, CreateOptions{ProjectPath: project, Method: MethodCopy}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if info, err := os.Stat(root); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0700) {
+		t.Errorf("Expected a 0700 workspace root, got %v (%v)", info, err)
+	}
+
+	// A root someone planted as a symlink is refused
+	target := t.TempDir()
+	link := filepath.Join(t.TempDir(), "workspaces")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if _, err := NewManager(link).Create(ctx, CreateOptions{ProjectPath: project, Method: MethodCopy}); err == nil {
+		t.Error("Expected a symlinked workspace root refused")
+	}
+	if entries, _ := os.ReadDir(target); len(entries) != 0 {
+		t.Errorf("Expected nothing created through the symlink, got %v", entries)
+	}
+}